@@ -10,14 +10,18 @@ import (
 )
 
 var addCmd = &cobra.Command{
-	Use:   "add [<class> | <class>/<id> | .]",
+	Use:   "add <pathspec>...",
 	Short: "Add changes to the staging area",
 	Long: `Add file contents to the staging area.
 
+A pathspec is a class ("Article" or "Article/"), an object ("Article/abc123"),
+or a glob over either part ("Article/abc*", "News*/").
+
 Examples:
-  wvc add .                 Stage all changes
-  wvc add Article           Stage all Article class changes
-  wvc add Article/abc123    Stage specific object change`,
+  wvc add .                   Stage all changes
+  wvc add Article             Stage all Article class changes
+  wvc add Article/abc123      Stage specific object change
+  wvc add Article/ Author/a*  Stage several classes and objects at once`,
 	Args: cobra.MinimumNArgs(1),
 	Run:  runAdd,
 }
@@ -29,37 +33,15 @@ func runAdd(cmd *cobra.Command, args []string) {
 
 	cfg, st, client := c.Config, c.Store, c.Client
 	green := color.New(color.FgGreen)
-	totalStaged := 0
 
-	for _, arg := range args {
-		if arg == "." {
-			count, err := core.StageAll(bgCtx, cfg, st, client)
-			if err != nil {
-				exitError("failed to stage changes: %v", err)
-			}
-			totalStaged += count
-		} else {
-			// Parse as class or class/id
-			className, objectID, err := core.ParseObjectRef(arg)
-			if err != nil {
-				exitError("%v", err)
-			}
+	spec, err := core.ParsePathspec(args)
+	if err != nil {
+		exitError("%v", err)
+	}
 
-			if objectID == "" {
-				// Stage entire class
-				count, err := core.StageClass(bgCtx, cfg, st, client, className)
-				if err != nil {
-					exitError("failed to stage %s: %v", className, err)
-				}
-				totalStaged += count
-			} else {
-				// Stage specific object
-				if err := core.StageObject(bgCtx, cfg, st, client, className, objectID); err != nil {
-					exitError("failed to stage %s/%s: %v", className, objectID, err)
-				}
-				totalStaged++
-			}
-		}
+	totalStaged, err := core.StagePathspec(bgCtx, cfg, st, client, spec)
+	if err != nil {
+		exitError("failed to stage changes: %v", err)
 	}
 
 	if totalStaged == 0 {
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"strings"
+
+	"github.com/kilupskalvis/wvc/internal/config"
+)
+
+// expandAlias replaces a user-defined alias in args with the command line it
+// stands for. Aliases come from the [alias] table of the repository config;
+// they cannot shadow built-in commands and are not expanded recursively.
+// Shell completion requests are expanded too, so aliases complete like the
+// commands they name.
+func expandAlias(args []string) []string {
+	i := 0
+	if len(args) > 0 && (args[0] == "__complete" || args[0] == "__completeNoDesc") {
+		// The word being completed is the last argument; only expand an
+		// alias that has already been typed out
+		if len(args) < 3 {
+			return args
+		}
+		i = 1
+	}
+	for i < len(args) && strings.HasPrefix(args[i], "-") {
+		i++
+	}
+	if i >= len(args) || isBuiltinCommand(args[i]) {
+		return args
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return args
+	}
+	expansion := strings.Fields(cfg.Alias[args[i]])
+	if len(expansion) == 0 {
+		return args
+	}
+
+	expanded := make([]string, 0, len(args)+len(expansion))
+	expanded = append(expanded, args[:i]...)
+	expanded = append(expanded, expansion...)
+	return append(expanded, args[i+1:]...)
+}
+
+// isBuiltinCommand reports whether name is a command or command alias of wvc
+func isBuiltinCommand(name string) bool {
+	if name == "help" {
+		return true
+	}
+	for _, cmd := range rootCmd.Commands() {
+		if cmd.Name() == name || cmd.HasAlias(name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/kilupskalvis/wvc/internal/core"
+	"github.com/spf13/cobra"
+)
+
+var applyCmd = &cobra.Command{
+	Use:   "apply <patch>",
+	Short: "Apply a patch file as staged changes",
+	Long: `Apply a patch written by 'wvc diff --patch' to Weaviate and stage the
+resulting changes, ready to commit.
+
+Every change is checked against the current state first. An insert conflicts
+with an existing object; an update or delete conflicts with an object that is
+missing or was changed since the patch was made. If any change conflicts,
+nothing is applied. Changes that are already present are skipped.
+
+Examples:
+  wvc apply fix.wvcp            Apply and stage a patch
+  wvc apply --check fix.wvcp    Only report whether the patch applies cleanly
+  wvc apply - < fix.wvcp        Read the patch from stdin`,
+	Args: cobra.ExactArgs(1),
+	Run:  runApply,
+}
+
+var applyCheck bool
+
+func init() {
+	applyCmd.Flags().BoolVar(&applyCheck, "check", false, "Check for conflicts without applying")
+}
+
+func runApply(cmd *cobra.Command, args []string) {
+	c := initFullContext()
+	defer c.Close()
+
+	var r io.Reader = os.Stdin
+	if args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			exitError("failed to open patch: %v", err)
+		}
+		defer f.Close()
+		r = f
+	}
+	patch, err := core.ReadPatch(r)
+	if err != nil {
+		exitError("%v", err)
+	}
+
+	result, err := core.ApplyPatch(context.Background(), c.Config, c.Store, c.Client, patch, core.PatchApplyOptions{Check: applyCheck})
+	if err != nil {
+		exitError("%v", err)
+	}
+
+	if len(result.Conflicts) > 0 {
+		colorDeleted.Printf("Patch does not apply: %d conflict(s)\n", len(result.Conflicts))
+		t := &table{indent: "  "}
+		for _, conflict := range result.Conflicts {
+			t.addRow(cell(conflict.ClassName+"/"+conflict.ObjectID, colorDeleted), cell(conflict.Reason, nil))
+		}
+		t.print()
+		os.Exit(1)
+	}
+
+	pending := len(patch.Changes) - result.Skipped
+	if applyCheck {
+		fmt.Printf("Patch applies cleanly: %d change(s) to apply, %d already present\n", pending, result.Skipped)
+		return
+	}
+
+	colorAdded.Printf("Applied %d change(s), %d staged\n", result.Applied, result.StagedCount)
+	if result.Skipped > 0 {
+		fmt.Printf("  %d change(s) already present\n", result.Skipped)
+	}
+	if len(result.Warnings) > 0 {
+		colorModified.Println("\nWarnings:")
+		for _, w := range result.Warnings {
+			colorModified.Printf("  - %s\n", w.Message)
+		}
+	}
+	if result.StagedCount > 0 {
+		colorHint.Println("\nUse 'wvc commit' to record the applied changes")
+	}
+}
@@ -0,0 +1,225 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	"github.com/kilupskalvis/wvc/internal/core"
+	"github.com/kilupskalvis/wvc/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export --output <file>",
+	Short: "Write the repository to a portable archive",
+	Long: `Write every branch and tag of the repository, with the commits, operations,
+schema versions, and vector blobs they reach, to a single archive for
+air-gapped backups or moving a repository between servers. The archive is
+a gzip-compressed tar file; restore it with 'wvc import'.
+
+Staged changes, stashes, remotes, and worktrees are not exported. Shallow
+clones and clones filtered by class hold only part of the history and
+cannot be exported; in other partial clones, vectors and payloads left on
+the remote are downloaded first.
+
+Examples:
+  wvc export --output repo.wvc
+  wvc export -o /mnt/backup/$(date +%F).wvc`,
+	Args: cobra.NoArgs,
+	Run:  runExport,
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import <archive>",
+	Short: "Restore a repository from an archive",
+	Long: `Restore an archive written by 'wvc export'.
+
+By default a new repository is initialized in the current directory, like
+'wvc clone', and the branch checked out when the archive was written, or
+--branch, is checked out into the Weaviate instance, which must have no
+classes yet.
+
+With --push, the archive is pushed to a wvc server instead: every branch and
+tag is created in the repository at the remote URL, and no local repository
+is created. The token is read from WVC_REMOTE_TOKEN_ORIGIN or
+WVC_REMOTE_TOKEN, or prompted for when neither is set.
+
+Examples:
+  wvc import repo.wvc
+  wvc import --url http://localhost:8081 --branch dev repo.wvc
+  wvc import --push http://server:8720/myrepo repo.wvc`,
+	Args: cobra.ExactArgs(1),
+	Run:  runImport,
+}
+
+var (
+	exportOutput string
+	importURL    string
+	importBranch string
+	importPush   string
+)
+
+func init() {
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Archive file to write")
+	_ = exportCmd.MarkFlagRequired("output")
+	addProfileFlags(exportCmd)
+	importCmd.Flags().StringVar(&importURL, "url", "http://localhost:8080", "Weaviate server URL")
+	importCmd.Flags().StringVarP(&importBranch, "branch", "b", "", "Branch to check out (default: the one checked out when exported)")
+	importCmd.Flags().StringVar(&importPush, "push", "", "Push the archive to this remote URL instead of creating a repository")
+	addProfileFlags(importCmd)
+}
+
+func archiveProgress(phase string, current, total int) {
+	if total > 0 {
+		fmt.Printf("\r  %s %d/%d", phase, current, total)
+	}
+}
+
+func runExport(cmd *cobra.Command, args []string) {
+	c := initContext()
+	defer c.Close()
+
+	// Written next to the target and renamed, so a failed export leaves no partial archive
+	tmp, err := os.CreateTemp(filepath.Dir(exportOutput), ".wvc-export-*")
+	if err != nil {
+		exitError("failed to create %s: %v", exportOutput, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	manifest, err := core.ExportArchive(c.Store, tmp, archiveProgress)
+	fmt.Println()
+	if err != nil {
+		tmp.Close()
+		exitError("%v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		exitError("failed to write %s: %v", exportOutput, err)
+	}
+	if err := os.Rename(tmp.Name(), exportOutput); err != nil {
+		exitError("failed to write %s: %v", exportOutput, err)
+	}
+
+	color.New(color.FgGreen).Printf("Exported %d commit(s), %d blob(s), %d branch(es), %d tag(s) to %s\n",
+		manifest.Commits, manifest.Blobs, len(manifest.Branches), len(manifest.Tags), exportOutput)
+}
+
+func runImport(cmd *cobra.Command, args []string) {
+	ctx := context.Background()
+	f, err := os.Open(args[0])
+	if err != nil {
+		exitError("%v", err)
+	}
+	defer f.Close()
+
+	if importPush != "" {
+		runImportPush(ctx, f)
+		return
+	}
+
+	cfg, st, wc := initRepository(ctx, importURL)
+	defer st.Close()
+	// A failed import leaves no half-initialized repository behind
+	fail := func(format string, args ...interface{}) {
+		st.Close()
+		os.RemoveAll(cfg.WVCPath())
+		exitError(format, args...)
+	}
+
+	// Checking out the import replaces the instance's schema and objects
+	classes, err := wc.GetClasses(ctx)
+	if err != nil {
+		fail("list Weaviate classes: %v", err)
+	}
+	if len(classes) > 0 {
+		fail("Weaviate at %s already has %d class(es); import into an empty instance", importURL, len(classes))
+	}
+
+	fmt.Printf("Importing %s...\n", args[0])
+	manifest, err := core.ImportArchive(st, f, archiveProgress)
+	fmt.Println()
+	if err != nil {
+		fail("%v", err)
+	}
+	if importBranch == "" && manifest.Head != "" {
+		if err := st.SetCurrentBranch(manifest.Head); err != nil {
+			fail("%v", err)
+		}
+	}
+	result, err := core.FinishLocalClone(ctx, cfg, st, wc, importBranch)
+	if err != nil {
+		fail("%v", err)
+	}
+
+	color.New(color.FgGreen).Printf("Imported %d commit(s), %d blob(s), %d branch(es), %d tag(s)\n",
+		manifest.Commits, manifest.Blobs, len(manifest.Branches), len(manifest.Tags))
+	if result.TargetCommit != "" {
+		fmt.Printf("Checked out '%s' at %s: %d object(s) restored\n", result.BranchName, shortID(result.TargetCommit), result.ObjectsAdded+result.ObjectsUpdated)
+	}
+	fmt.Printf("Tracking Weaviate at %s\n", cfg.WeaviateURL)
+	if len(result.Warnings) > 0 {
+		yellow := color.New(color.FgYellow)
+		yellow.Println("\nWarnings:")
+		for _, w := range result.Warnings {
+			yellow.Printf("  - %s\n", w.Message)
+		}
+	}
+}
+
+// runImportPush imports the archive into a scratch store and pushes it from there.
+func runImportPush(ctx context.Context, f *os.File) {
+	const remoteName = "origin"
+
+	dir, err := os.MkdirTemp("", "wvc-import-*")
+	if err != nil {
+		exitError("%v", err)
+	}
+	defer os.RemoveAll(dir)
+	fail := func(format string, args ...interface{}) {
+		os.RemoveAll(dir)
+		exitError(format, args...)
+	}
+
+	st, err := store.New(filepath.Join(dir, "wvc.db"))
+	if err != nil {
+		fail("failed to create store: %v", err)
+	}
+	defer st.Close()
+	if err := st.Initialize(); err != nil {
+		fail("failed to initialize store: %v", err)
+	}
+	if err := core.AddRemote(st, remoteName, importPush); err != nil {
+		fail("%v", err)
+	}
+	token, err := core.GetRemoteToken(st, remoteName)
+	if err != nil {
+		fail("get token: %v", err)
+	}
+	if token == "" {
+		if err := core.SetRemoteToken(st, remoteName, promptRemoteToken(remoteName)); err != nil {
+			fail("%v", err)
+		}
+	}
+	client, err := newRemoteClient(st, remoteName)
+	if err != nil {
+		fail("%v", err)
+	}
+
+	fmt.Printf("Reading %s...\n", f.Name())
+	manifest, err := core.ImportArchive(st, f, archiveProgress)
+	fmt.Println()
+	if err != nil {
+		fail("%v", err)
+	}
+
+	fmt.Printf("Pushing to %s...\n", importPush)
+	result, err := core.PushArchive(ctx, st, client, remoteName, manifest, archiveProgress)
+	fmt.Println()
+	if err != nil {
+		fail("%v", err)
+	}
+	color.New(color.FgGreen).Printf("Pushed %d branch(es), %d tag(s): %d commit(s), %d vector(s)\n",
+		len(result.Branches), len(result.Tags), result.Commits, result.Vectors)
+}
@@ -0,0 +1,191 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/core"
+	"github.com/kilupskalvis/wvc/internal/remote"
+	"github.com/kilupskalvis/wvc/internal/remote/blobstore"
+	"github.com/kilupskalvis/wvc/internal/remote/metastore"
+	"github.com/kilupskalvis/wvc/internal/remote/server"
+	"github.com/spf13/cobra"
+)
+
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Measure commit, checkout, merge, push, and pull throughput",
+	Long: `Measure end-to-end throughput of wvc on a synthetic dataset and print a
+report that can be compared between machines and releases.
+
+The benchmark runs in a scratch directory and does not touch the repository
+or Weaviate instance of the current directory: Weaviate is replaced by an
+in-memory client and push and pull go to a wvc server started in-process, so
+the numbers measure wvc itself.
+
+Examples:
+  wvc bench
+  wvc bench --objects 100k --dims 768
+  wvc bench --objects 10k --json > bench-v1.4.json`,
+	Args: cobra.NoArgs,
+	Run:  runBench,
+}
+
+var (
+	benchObjects  string
+	benchDims     int
+	benchNoRemote bool
+	benchJSON     bool
+)
+
+func init() {
+	benchCmd.Flags().StringVar(&benchObjects, "objects", "10k", "Number of objects, e.g. 5000, 100k, or 1m")
+	benchCmd.Flags().IntVar(&benchDims, "dims", 768, "Vector dimensions")
+	benchCmd.Flags().BoolVar(&benchNoRemote, "no-remote", false, "Skip the push and pull phases")
+	benchCmd.Flags().BoolVar(&benchJSON, "json", false, "Print the report as JSON")
+}
+
+func runBench(cmd *cobra.Command, args []string) {
+	objects, err := parseBenchCount(benchObjects)
+	if err != nil {
+		exitError("%v", err)
+	}
+	if benchDims < 0 {
+		exitError("--dims must not be negative")
+	}
+
+	dir, err := os.MkdirTemp("", "wvc-bench-")
+	if err != nil {
+		exitError("create scratch directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	opts := core.BenchOptions{Objects: objects, Dims: benchDims, Dir: dir}
+	if !benchJSON {
+		fmt.Printf("Benchmarking %d objects with %d-dimensional vectors\n", objects, benchDims)
+		opts.Progress = func(phase string) { fmt.Printf("  %s...\n", phase) }
+	}
+	if !benchNoRemote {
+		remoteURL, client, stop, err := startBenchServer(filepath.Join(dir, "server"))
+		if err != nil {
+			exitError("start benchmark server: %v", err)
+		}
+		defer stop()
+		opts.Remote, opts.RemoteURL = client, remoteURL
+	}
+
+	report, err := core.RunBenchmark(ctx, opts)
+	if err != nil {
+		exitError("%v", err)
+	}
+
+	if benchJSON {
+		report.Version = Version
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			exitError("%v", err)
+		}
+		fmt.Println(string(data))
+		return
+	}
+	printBenchReport(report)
+}
+
+func printBenchReport(report *core.BenchReport) {
+	fmt.Println()
+	fmt.Printf("wvc %s, %s, %s\n", Version, report.GoVersion, report.Platform)
+	fmt.Printf("%-10s %10s %12s %14s %12s\n", "Phase", "Objects", "Time", "Objects/s", "Allocated")
+	for _, phase := range report.Phases {
+		fmt.Printf("%-10s %10d %12s %14.0f %12s\n",
+			phase.Name,
+			phase.Objects,
+			phase.Duration.Round(time.Millisecond),
+			phase.ObjectsPerSecond(),
+			formatBytes(int64(phase.Allocated)),
+		)
+	}
+}
+
+// parseBenchCount parses an object count with an optional k or m suffix.
+func parseBenchCount(s string) (int, error) {
+	multiplier := 1
+	lower := strings.ToLower(s)
+	switch {
+	case strings.HasSuffix(lower, "k"):
+		multiplier, lower = 1000, strings.TrimSuffix(lower, "k")
+	case strings.HasSuffix(lower, "m"):
+		multiplier, lower = 1000000, strings.TrimSuffix(lower, "m")
+	}
+	n, err := strconv.Atoi(lower)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid --objects %q: want a positive count, e.g. 5000 or 100k", s)
+	}
+	return n * multiplier, nil
+}
+
+// benchRepoOpener serves the single repository of the benchmark server.
+type benchRepoOpener struct {
+	meta  metastore.MetaStore
+	blobs blobstore.BlobStore
+}
+
+func (o *benchRepoOpener) Open(string) (metastore.MetaStore, blobstore.BlobStore, error) {
+	return o.meta, o.blobs, nil
+}
+
+// startBenchServer starts a wvc server on a loopback port, backed by stores
+// under dir, and returns the URL of its repository and a client for it.
+func startBenchServer(dir string) (string, remote.RemoteClient, func(), error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", nil, nil, err
+	}
+	meta, err := metastore.NewBboltStore(filepath.Join(dir, "meta.db"))
+	if err != nil {
+		return "", nil, nil, err
+	}
+	blobs, err := blobstore.NewFSStore(filepath.Join(dir, "blobs"))
+	if err != nil {
+		meta.Close()
+		return "", nil, nil, err
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	tokens := newFileTokenStore(filepath.Join(dir, "tokens.json"), logger)
+	token, _, err := tokens.CreateToken("bench", []string{"*"}, "rw", server.TokenLimits{})
+	if err != nil {
+		meta.Close()
+		return "", nil, nil, err
+	}
+
+	cfg := server.DefaultServerConfig()
+	cfg.RequestsPerMinute = 0
+	h, cleanup := server.Handler(&benchRepoOpener{meta: meta, blobs: blobs}, tokens, cfg, logger, nil, nil)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		cleanup()
+		meta.Close()
+		return "", nil, nil, err
+	}
+	srv := &http.Server{Handler: h, ReadHeaderTimeout: 10 * time.Second}
+	go srv.Serve(ln)
+
+	stop := func() {
+		srv.Close()
+		cleanup()
+		meta.Close()
+	}
+	baseURL := "http://" + ln.Addr().String()
+	return baseURL + "/bench", remote.NewHTTPClient(baseURL, "bench", token), stop, nil
+}
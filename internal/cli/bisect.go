@@ -0,0 +1,176 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/kilupskalvis/wvc/internal/core"
+	"github.com/spf13/cobra"
+)
+
+var bisectCmd = &cobra.Command{
+	Use:   "bisect",
+	Short: "Binary search the history for the commit that introduced a regression",
+	Long: `Find the commit that degraded your data by binary search.
+
+Mark a commit where the data is bad and one where it was good; wvc checks out
+the commit halfway between them, restoring its state in Weaviate, and asks
+for a verdict. Each verdict halves the commits left to test.
+
+'wvc bisect run' automates the verdicts with a command, such as an evaluation
+script: exit code 0 marks the commit good, 125 skips it, and 1 to 127 marks
+it bad. The command sees the commit under test in WVC_BISECT_COMMIT.
+
+Examples:
+  wvc bisect start HEAD v1.2        Bad at HEAD, good at tag v1.2
+  wvc bisect good                   The checked-out commit is good
+  wvc bisect bad                    The checked-out commit is bad
+  wvc bisect run ./eval.sh          Let a script decide
+  wvc bisect reset                  Return to where the bisect started`,
+}
+
+var bisectStartCmd = &cobra.Command{
+	Use:   "start [<bad> [<good>...]]",
+	Short: "Start a bisect session",
+	Run:   runBisectStart,
+}
+
+var bisectResetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "End the bisect session and check out where it started",
+	Args:  cobra.NoArgs,
+	Run:   runBisectReset,
+}
+
+var bisectRunCmd = &cobra.Command{
+	Use:   "run <command> [<arg>...]",
+	Short: "Judge each commit by the exit code of a command",
+	Args:  cobra.MinimumNArgs(1),
+	Run:   runBisectRun,
+}
+
+func init() {
+	for _, verdict := range []struct{ name, short string }{
+		{core.BisectGood, "Mark a commit, HEAD by default, as good"},
+		{core.BisectBad, "Mark a commit, HEAD by default, as bad"},
+		{core.BisectSkip, "Skip a commit, HEAD by default, that can't be tested"},
+	} {
+		bisectCmd.AddCommand(&cobra.Command{
+			Use:   verdict.name + " [<commit>]",
+			Short: verdict.short,
+			Args:  cobra.MaximumNArgs(1),
+			Run: func(cmd *cobra.Command, args []string) {
+				runBisectMark(verdict.name, args)
+			},
+		})
+	}
+	// Flags after the command belong to it
+	bisectRunCmd.Flags().SetInterspersed(false)
+	bisectCmd.AddCommand(bisectStartCmd)
+	bisectCmd.AddCommand(bisectResetCmd)
+	bisectCmd.AddCommand(bisectRunCmd)
+}
+
+func runBisectStart(cmd *cobra.Command, args []string) {
+	c := initFullContext()
+	defer c.Close()
+	holdWriteLock(c, "checkout")
+	defer releaseWriteLock()
+
+	var bad string
+	var good []string
+	if len(args) > 0 {
+		bad, good = args[0], args[1:]
+	}
+	step, err := core.BisectStart(context.Background(), c.Config, c.Store, c.Client, bad, good)
+	if err != nil {
+		exitError("%v", err)
+	}
+	printBisectStep(c, step)
+}
+
+func runBisectMark(verdict string, args []string) {
+	c := initFullContext()
+	defer c.Close()
+	holdWriteLock(c, "checkout")
+	defer releaseWriteLock()
+
+	var ref string
+	if len(args) > 0 {
+		ref = args[0]
+	}
+	step, err := core.BisectMark(context.Background(), c.Config, c.Store, c.Client, verdict, ref)
+	if err != nil {
+		exitError("%v", err)
+	}
+	printBisectStep(c, step)
+}
+
+func runBisectReset(cmd *cobra.Command, args []string) {
+	c := initFullContext()
+	defer c.Close()
+	holdWriteLock(c, "checkout")
+	defer releaseWriteLock()
+
+	original, _, err := core.BisectReset(context.Background(), c.Config, c.Store, c.Client)
+	if err != nil {
+		exitError("%v", err)
+	}
+	color.New(color.FgGreen).Printf("Bisect reset; checked out %s\n", original)
+}
+
+func runBisectRun(cmd *cobra.Command, args []string) {
+	c := initFullContext()
+	defer c.Close()
+	holdWriteLock(c, "checkout")
+	defer releaseWriteLock()
+
+	test := core.BisectCommand(args, os.Stdout, os.Stderr)
+	step, err := core.BisectRun(context.Background(), c.Config, c.Store, c.Client, test,
+		func(commitID, verdict string, step *core.BisectStep) {
+			colorMuted.Printf("%s is %s\n", shortID(commitID), verdict)
+			if step.FirstBad == "" {
+				printBisectStep(c, step)
+			}
+		})
+	if err != nil {
+		exitError("%v", err)
+	}
+	printBisectStep(c, step)
+}
+
+func printBisectStep(c *cmdContext, step *core.BisectStep) {
+	switch {
+	case step.Waiting == core.BisectBad:
+		fmt.Println("Waiting for a bad commit: run 'wvc bisect bad [<commit>]'")
+	case step.Waiting == core.BisectGood:
+		fmt.Println("Waiting for a good commit: run 'wvc bisect good <commit>'")
+	case len(step.Candidates) > 0:
+		colorModified.Println("The first bad commit could be any of:")
+		for _, id := range step.Candidates {
+			printBisectCommit(c, id)
+		}
+		fmt.Println("Skipped commits prevent narrowing it down further.")
+	case step.FirstBad != "":
+		colorDeleted.Printf("%s is the first bad commit\n", shortID(step.FirstBad))
+		printBisectCommit(c, step.FirstBad)
+		fmt.Println("Run 'wvc bisect reset' to return to where the bisect started.")
+	default:
+		fmt.Printf("Bisecting: %d commits left to test (roughly %d steps)\n", step.Remaining-1, step.Steps)
+		printBisectCommit(c, step.Current)
+		if r := step.Checkout; r != nil && (r.ObjectsAdded > 0 || r.ObjectsUpdated > 0 || r.ObjectsRemoved > 0) {
+			fmt.Printf("  %d added, %d updated, %d removed\n", r.ObjectsAdded, r.ObjectsUpdated, r.ObjectsRemoved)
+		}
+	}
+}
+
+func printBisectCommit(c *cmdContext, id string) {
+	commit, err := c.Store.GetCommit(id)
+	if err != nil || commit == nil {
+		fmt.Printf("  %s\n", shortID(id))
+		return
+	}
+	fmt.Printf("  %s %s\n", colorCommit.Sprint(shortID(id)), firstLine(commit.Message))
+}
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/kilupskalvis/wvc/internal/core"
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/spf13/cobra"
+)
+
+var blameCmd = &cobra.Command{
+	Use:   "blame <class> <object-id>",
+	Short: "Show which commit last changed each property of an object",
+	Long: `Show, for each property and vector of an object at HEAD, the commit that
+last changed it, with its date and author. Vectors are listed by their blob
+hash.
+
+To list every commit that touched the object, use wvc log --object.
+
+Examples:
+  wvc blame Article obj-001`,
+	Args: cobra.ExactArgs(2),
+	Run:  runBlame,
+}
+
+func runBlame(cmd *cobra.Command, args []string) {
+	c := initContextWithMigrations()
+	defer c.Close()
+
+	result, err := core.Blame(c.Store, args[0], args[1])
+	if err != nil {
+		exitError("%v", err)
+	}
+
+	fmt.Printf("%s (created in %s)\n\n", models.ObjectKey(result.ClassName, result.ObjectID),
+		colorCommit.Sprint(result.Created.ShortID()))
+	t := &table{}
+	for _, line := range result.Properties {
+		value, err := json.Marshal(line.Value)
+		if err != nil {
+			value = []byte(fmt.Sprint(line.Value))
+		}
+		addBlameRow(t, line, line.Name, string(value))
+	}
+	for _, line := range result.Vectors {
+		name := "vector"
+		if line.Name != "" {
+			name = "vector:" + line.Name
+		}
+		addBlameRow(t, line, name, fmt.Sprint(line.Value))
+	}
+	t.print()
+}
+
+func addBlameRow(t *table, line *core.BlameLine, name, value string) {
+	author := line.Commit.Author
+	if author == "" {
+		author = core.UnknownAuthor
+	}
+	t.addRow(
+		cell(line.Commit.ShortID(), colorCommit),
+		cell(line.Commit.Timestamp.Format("2006-01-02"), nil),
+		cell(author, colorMuted),
+		cell(name, nil),
+		cell(value, nil),
+	)
+}
@@ -1,10 +1,14 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"strings"
 
-	"github.com/fatih/color"
 	"github.com/kilupskalvis/wvc/internal/core"
+	"github.com/kilupskalvis/wvc/internal/remote"
+	"github.com/kilupskalvis/wvc/internal/store"
 	"github.com/spf13/cobra"
 )
 
@@ -16,10 +20,21 @@ var branchCmd = &cobra.Command{
 Without arguments, lists all branches.
 With a name argument, creates a new branch at HEAD.
 
+A branch created with --classes is class-scoped: while it is checked out,
+status, diff, commit, checkout, and merge only consider objects of those
+classes, so teams can version disjoint class sets in one repository.
+
+With --remotes, lists the remote-tracking branches recorded by the last
+fetch. Adding -v asks each remote how far every branch is ahead of and
+behind its default branch.
+
 Examples:
   wvc branch              # List all branches
+  wvc branch -v           # List branches with their latest commit
+  wvc branch -r -v        # List remote branches, ahead/behind the remote default
   wvc branch feature      # Create 'feature' branch at HEAD
   wvc branch feature abc123  # Create 'feature' branch at commit abc123
+  wvc branch --classes Article,Author articles  # Create a class-scoped branch
   wvc branch -d feature   # Delete 'feature' branch`,
 	Run: runBranch,
 }
@@ -27,11 +42,17 @@ Examples:
 var (
 	branchDelete      bool
 	branchForceDelete bool
+	branchClasses     []string
+	branchVerbose     bool
+	branchRemotes     bool
 )
 
 func init() {
 	branchCmd.Flags().BoolVarP(&branchDelete, "delete", "d", false, "Delete a branch")
 	branchCmd.Flags().BoolVarP(&branchForceDelete, "force", "D", false, "Force delete a branch")
+	branchCmd.Flags().BoolVarP(&branchVerbose, "verbose", "v", false, "Show the commit and subject line of each branch")
+	branchCmd.Flags().BoolVarP(&branchRemotes, "remotes", "r", false, "List remote-tracking branches")
+	branchCmd.Flags().StringSliceVar(&branchClasses, "classes", nil, "Scope the new branch to these classes (comma-separated)")
 }
 
 func runBranch(cmd *cobra.Command, args []string) {
@@ -48,6 +69,10 @@ func runBranch(cmd *cobra.Command, args []string) {
 		if err := core.DeleteBranch(st, args[0], branchForceDelete); err != nil {
 			exitError("%v", err)
 		}
+		if jsonOutput() {
+			printJSON(map[string]string{"deleted": args[0]})
+			return
+		}
 		fmt.Printf("Deleted branch '%s'\n", args[0])
 		return
 	}
@@ -63,16 +88,40 @@ func runBranch(cmd *cobra.Command, args []string) {
 		if err := core.CreateBranch(st, name, startPoint); err != nil {
 			exitError("%v", err)
 		}
+		if len(branchClasses) > 0 {
+			if err := core.SetBranchClasses(st, name, branchClasses); err != nil {
+				exitError("%v", err)
+			}
+		}
 
 		// Get the commit ID for display
 		branch, _ := st.GetBranch(name)
+		if jsonOutput() {
+			created := branchJSON{Name: name}
+			if branch != nil {
+				created.CommitID, created.Classes = branch.CommitID, branch.Classes
+			}
+			printJSON(created)
+			return
+		}
 		if branch != nil {
-			fmt.Printf("Created branch '%s' at %s\n", name, shortID(branch.CommitID))
+			fmt.Printf("Created branch '%s' at %s", name, shortID(branch.CommitID))
+			if len(branch.Classes) > 0 {
+				fmt.Printf(" (classes: %s)", strings.Join(branch.Classes, ", "))
+			}
+			fmt.Println()
 		} else {
 			fmt.Printf("Created branch '%s'\n", name)
 		}
 		return
 	}
+	if len(branchClasses) > 0 {
+		exitError("--classes requires a branch name")
+	}
+	if branchRemotes {
+		listRemoteBranches(st)
+		return
+	}
 
 	// List branches
 	branches, currentBranch, err := core.ListBranches(st)
@@ -80,17 +129,129 @@ func runBranch(cmd *cobra.Command, args []string) {
 		exitError("failed to list branches: %v", err)
 	}
 
+	if jsonOutput() {
+		list := []branchJSON{}
+		for _, branch := range branches {
+			entry := branchJSON{Name: branch.Name, CommitID: branch.CommitID, Current: branch.Name == currentBranch, Classes: branch.Classes}
+			if commit, err := st.GetCommit(branch.CommitID); err == nil && commit != nil {
+				entry.Subject = firstLine(commit.Message)
+			}
+			list = append(list, entry)
+		}
+		printJSON(list)
+		return
+	}
+
 	if len(branches) == 0 {
 		fmt.Println("No branches yet. Create a commit first, then branches will be available.")
 		return
 	}
 
-	green := color.New(color.FgGreen)
+	startPager()
+	defer stopPager()
+
+	t := &table{}
 	for _, branch := range branches {
+		name := cell("  "+branch.Name, nil)
 		if branch.Name == currentBranch {
-			green.Printf("* %s\n", branch.Name)
-		} else {
-			fmt.Printf("  %s\n", branch.Name)
+			name = cell("* "+branch.Name, colorCurrent)
+		}
+		classes := cell("", nil)
+		if len(branch.Classes) > 0 {
+			classes = cell("["+strings.Join(branch.Classes, ", ")+"]", colorRef)
+		}
+		if !branchVerbose {
+			t.addRow(name, classes)
+			continue
+		}
+		subject := ""
+		if commit, err := st.GetCommit(branch.CommitID); err == nil && commit != nil {
+			subject = firstLine(commit.Message)
+		}
+		t.addRow(name, cell(shortID(branch.CommitID), colorCommit), classes, cell(subject, nil))
+	}
+	t.print()
+}
+
+// listRemoteBranches lists the remote-tracking branches of every remote.
+// Verbose listings ask the server how far each one is ahead of and behind
+// the remote's default branch, so no history has to be fetched.
+func listRemoteBranches(st *store.Store) {
+	remotes, err := core.ListRemotes(st)
+	if err != nil {
+		exitError("failed to list remotes: %v", err)
+	}
+
+	ctx := context.Background()
+	t := &table{}
+	list := []remoteBranchJSON{}
+	for _, rem := range remotes.Remotes {
+		tracking, err := st.ListRemoteBranches(rem.Name)
+		if err != nil {
+			exitError("failed to list remote-tracking branches: %v", err)
+		}
+		var client *remote.RetryClient
+		if branchVerbose && len(tracking) > 0 {
+			if client, err = newRemoteClient(st, rem.Name); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: %s: %v\n", rem.Name, err)
+			}
 		}
+		for _, rb := range tracking {
+			if strings.HasPrefix(rb.BranchName, "refs/") {
+				continue
+			}
+			entry := remoteBranchJSON{Remote: rem.Name, Branch: rb.BranchName, CommitID: rb.CommitID}
+			name := cell("  "+rem.Name+"/"+rb.BranchName, colorRef)
+			if !branchVerbose && !jsonOutput() {
+				t.addRow(name)
+				continue
+			}
+			counts := ""
+			if client != nil {
+				cmp, err := client.Compare(ctx, "", rb.CommitID)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "warning: %s/%s: %v\n", rem.Name, rb.BranchName, err)
+					client = nil // the remote is unreachable or too old; skip its other branches
+				} else {
+					counts = aheadBehind(cmp)
+					entry.Base, entry.Ahead, entry.Behind = cmp.Base, &cmp.AheadBy, &cmp.BehindBy
+				}
+			}
+			subject := ""
+			if commit, err := st.GetCommit(rb.CommitID); err == nil && commit != nil {
+				subject = firstLine(commit.Message)
+			}
+			entry.Subject = subject
+			list = append(list, entry)
+			t.addRow(name, cell(shortID(rb.CommitID), colorCommit), cell(counts, colorHint), cell(subject, nil))
+		}
+	}
+	if jsonOutput() {
+		printJSON(list)
+		return
+	}
+	if len(t.rows) == 0 {
+		fmt.Println("No remote-tracking branches. Fetch from a remote first.")
+		return
+	}
+
+	startPager()
+	defer stopPager()
+	t.print()
+}
+
+// aheadBehind describes how a compared ref differs from its base, such as
+// "[main: ahead 2, behind 1]"; it is empty when they are the same commit.
+func aheadBehind(cmp *remote.CompareResponse) string {
+	var parts []string
+	if cmp.AheadBy > 0 {
+		parts = append(parts, fmt.Sprintf("ahead %d", cmp.AheadBy))
+	}
+	if cmp.BehindBy > 0 {
+		parts = append(parts, fmt.Sprintf("behind %d", cmp.BehindBy))
+	}
+	if len(parts) == 0 {
+		return ""
 	}
+	return fmt.Sprintf("[%s: %s]", cmp.Base, strings.Join(parts, ", "))
 }
@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"os"
+
+	"github.com/kilupskalvis/wvc/internal/core"
+	"github.com/spf13/cobra"
+)
+
+var changelogCmd = &cobra.Command{
+	Use:   "changelog <from>..<to>",
+	Short: "Write release notes for the commits between two refs",
+	Long: `Summarize the commits between two refs as Markdown release notes: the
+commit subjects, the net objects added, updated, and deleted per class, the
+schema changes, and the values of commit message trailers such as
+"Ticket: DATA-12". <to> defaults to HEAD. Merge commits are not listed;
+their changes are in the commits they merged.
+
+--template renders a Go text/template instead of the built-in Markdown. The
+template sees .From, .To, .Commits (each with .Subject, .Body, .Trailers,
+.Author, .Timestamp, and .ID), .Classes (.Name, .Commits, .Added, .Updated,
+.Deleted), .Added, .Updated, .Deleted, .SchemaChanges, and .Trailers (.Key,
+.Values), and can call shortID and join.
+
+Examples:
+  wvc changelog v1.0..v2.0
+  wvc changelog v1.0.. --output RELEASE.md
+  wvc changelog v1.0..v2.0 --template changelog.tmpl`,
+	Args: cobra.ExactArgs(1),
+	Run:  runChangelog,
+}
+
+var (
+	changelogTemplate string
+	changelogOutput   string
+)
+
+func init() {
+	changelogCmd.Flags().StringVar(&changelogTemplate, "template", "", "Render with this Go text/template file")
+	changelogCmd.Flags().StringVarP(&changelogOutput, "output", "o", "", "Write the changelog to a file instead of stdout")
+}
+
+func runChangelog(cmd *cobra.Command, args []string) {
+	var text string
+	if changelogTemplate != "" {
+		data, err := os.ReadFile(changelogTemplate)
+		if err != nil {
+			exitError("failed to read template: %v", err)
+		}
+		text = string(data)
+	}
+
+	c := initContext()
+	defer c.Close()
+
+	changelog, err := core.BuildChangelog(c.Store, args[0])
+	if err != nil {
+		exitError("%v", err)
+	}
+
+	if changelogOutput == "" {
+		if err := changelog.Render(os.Stdout, text); err != nil {
+			exitError("%v", err)
+		}
+		return
+	}
+	f, err := os.Create(changelogOutput)
+	if err != nil {
+		exitError("failed to create %s: %v", changelogOutput, err)
+	}
+	if err := changelog.Render(f, text); err != nil {
+		f.Close()
+		exitError("%v", err)
+	}
+	if err := f.Close(); err != nil {
+		exitError("failed to write %s: %v", changelogOutput, err)
+	}
+}
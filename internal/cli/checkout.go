@@ -3,6 +3,8 @@ package cli
 import (
 	"context"
 	"fmt"
+	"maps"
+	"slices"
 
 	"github.com/fatih/color"
 	"github.com/kilupskalvis/wvc/internal/core"
@@ -10,36 +12,70 @@ import (
 )
 
 var checkoutCmd = &cobra.Command{
-	Use:   "checkout <branch|commit>",
+	Use:   "checkout <branch|commit> | [<commit>] -- <pathspec>...",
 	Short: "Switch branches or restore working tree",
 	Long: `Switch to a branch or checkout a specific commit.
 
+With pathspecs after "--", restore only the matching objects to their state
+at the given commit (HEAD by default) without switching branches.
+
 Examples:
-  wvc checkout main          # Switch to main branch
-  wvc checkout abc1234       # Checkout specific commit (detached HEAD)
-  wvc checkout -b feature    # Create and switch to new branch
-  wvc checkout -f main       # Force checkout, discarding uncommitted changes`,
-	Args: cobra.MaximumNArgs(1),
-	Run:  runCheckout,
+  wvc checkout main                 # Switch to main branch
+  wvc checkout abc1234              # Checkout specific commit (detached HEAD)
+  wvc checkout -b feature           # Create and switch to new branch
+  wvc checkout -f main              # Force checkout, discarding uncommitted changes
+  wvc checkout --recurse-submodules v2  # Also write the submodules pinned at v2
+  wvc checkout -- Article/obj-1*    # Discard changes to matching objects
+  wvc checkout abc1234 -- Author/   # Restore the Author class from a commit
+  wvc checkout v1.0 --scratch       # Inspect v1.0 in scratch classes
+
+With --scratch the commit is restored into a scratch: a copy of every class
+named WvcScratch<id>_<Class>, with references pointing into the copy. The
+live classes, HEAD, and staging area are left alone, so history can be
+inspected on a shared instance. Scratch classes never show up in status or
+commits; drop them with 'wvc scratch drop <id>'.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+			return cobra.MaximumNArgs(1)(cmd, args[:dash])
+		}
+		return cobra.MaximumNArgs(1)(cmd, args)
+	},
+	Run: runCheckout,
 }
 
 var (
-	checkoutCreateBranch bool
-	checkoutForce        bool
+	checkoutCreateBranch      bool
+	checkoutForce             bool
+	checkoutRecurseSubmodules bool
+	checkoutScratch           bool
 )
 
 func init() {
 	checkoutCmd.Flags().BoolVarP(&checkoutCreateBranch, "branch", "b", false, "Create and checkout a new branch")
 	checkoutCmd.Flags().BoolVarP(&checkoutForce, "force", "f", false, "Force checkout, discarding local changes")
+	checkoutCmd.Flags().BoolVar(&checkoutRecurseSubmodules, "recurse-submodules", false, "Also write the submodule commits pinned at the target into Weaviate")
+	checkoutCmd.Flags().BoolVar(&checkoutScratch, "scratch", false, "Restore into scratch classes, leaving the live data and HEAD alone")
+	addProfileFlags(checkoutCmd)
 }
 
 func runCheckout(cmd *cobra.Command, args []string) {
 	bgCtx := context.Background()
 	c := initFullContext()
 	defer c.Close()
+	if checkoutScratch {
+		checkoutIntoScratch(bgCtx, cmd, c, args)
+		return
+	}
+	holdWriteLock(c, "checkout")
+	defer releaseWriteLock()
 
 	cfg, st, client := c.Config, c.Store, c.Client
 
+	if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+		checkoutPaths(bgCtx, c, args[:dash], args[dash:])
+		return
+	}
+
 	// Determine target
 	var target string
 	if len(args) > 0 {
@@ -102,4 +138,90 @@ func runCheckout(cmd *cobra.Command, args []string) {
 			yellow.Printf("  - %s\n", w.Message)
 		}
 	}
+
+	if checkoutRecurseSubmodules {
+		checkoutSubmodules(bgCtx, c)
+	}
+}
+
+// checkoutSubmodules materializes the submodules pinned at the new HEAD.
+// Pins of submodules not configured here are reported and skipped.
+func checkoutSubmodules(ctx context.Context, c *cmdContext) {
+	pins, err := c.Store.GetSubmodulePins()
+	if err != nil {
+		exitError("%v", err)
+	}
+	var names []string
+	for _, name := range slices.Sorted(maps.Keys(pins)) {
+		if _, ok := c.Config.Submodule[name]; !ok {
+			color.New(color.FgYellow).Printf("Submodule '%s' is pinned but not configured; run 'wvc submodule add %s <remote>'\n", name, name)
+			continue
+		}
+		names = append(names, name)
+	}
+	updateSubmodules(ctx, c, names, false)
+}
+
+// checkoutIntoScratch restores a commit into scratch classes. Neither HEAD
+// nor the known state change, so no write lock is taken.
+func checkoutIntoScratch(ctx context.Context, cmd *cobra.Command, c *cmdContext, args []string) {
+	if checkoutCreateBranch || checkoutRecurseSubmodules {
+		exitError("--scratch cannot be combined with -b or --recurse-submodules")
+	}
+	if len(args) != 1 || cmd.ArgsLenAtDash() >= 0 {
+		exitError("--scratch takes one branch or commit and no pathspec")
+	}
+
+	result, err := core.CheckoutScratch(ctx, c.Config, c.Store, c.Client, args[0])
+	if err != nil {
+		exitError("%v", err)
+	}
+
+	color.New(color.FgGreen).Printf("Checked out %s into scratch %s\n", shortID(result.CommitID), result.ID)
+	fmt.Printf("  %d object(s) in %d class(es)\n", result.Objects, len(result.Classes))
+	t := &table{indent: "  "}
+	for _, class := range slices.Sorted(maps.Keys(result.Classes)) {
+		t.addRow(cell(class, nil), cell("->", colorMuted), cell(result.Classes[class], nil))
+	}
+	t.print()
+	fmt.Printf("Drop it with: wvc scratch drop %s\n", result.ID)
+}
+
+// checkoutPaths restores the objects matching a pathspec without switching branches
+func checkoutPaths(ctx context.Context, c *cmdContext, refArgs, pathArgs []string) {
+	if checkoutCreateBranch {
+		exitError("cannot use -b with a pathspec")
+	}
+	if len(pathArgs) == 0 {
+		exitError("pathspec required after --")
+	}
+	spec, err := core.ParsePathspec(pathArgs)
+	if err != nil {
+		exitError("%v", err)
+	}
+
+	var source string
+	if len(refArgs) > 0 {
+		source = refArgs[0]
+	}
+
+	result, err := core.CheckoutPaths(ctx, c.Config, c.Store, c.Client, source, spec)
+	if err != nil {
+		exitError("%v", err)
+	}
+
+	restored := result.ObjectsAdded + result.ObjectsUpdated + result.ObjectsRemoved
+	color.New(color.FgGreen).Printf("Restored %d object(s) from %s\n", restored, shortID(result.TargetCommit))
+	if restored > 0 {
+		fmt.Printf("  %d added, %d updated, %d removed\n",
+			result.ObjectsAdded, result.ObjectsUpdated, result.ObjectsRemoved)
+	}
+
+	if len(result.Warnings) > 0 {
+		yellow := color.New(color.FgYellow)
+		yellow.Println("\nWarnings:")
+		for _, w := range result.Warnings {
+			yellow.Printf("  - %s\n", w.Message)
+		}
+	}
 }
@@ -0,0 +1,303 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/kilupskalvis/wvc/internal/config"
+	"github.com/kilupskalvis/wvc/internal/core"
+	"github.com/kilupskalvis/wvc/internal/remote"
+	"github.com/kilupskalvis/wvc/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var cloneCmd = &cobra.Command{
+	Use:   "clone <remote-url> | clone --local <path>",
+	Short: "Initialize a repository from a remote",
+	Long: `Initialize a WVC repository in the current directory, add the remote as
+'origin', and pull its default branch into the Weaviate instance, which must
+have no classes yet.
+
+The token is read from WVC_REMOTE_TOKEN_ORIGIN or WVC_REMOTE_TOKEN, or
+prompted for when neither is set.
+
+With --no-vectors, commits and object metadata are fetched but vector blobs
+are not. Each vector is downloaded from the remote the first time a
+checkout, pull, or diff needs it and kept locally afterwards, so the first
+clone of a large embedding set only transfers what is restored.
+
+--filter selects what to leave on the remote more generally:
+  vector:none    skip vector blobs (same as --no-vectors)
+  payload:none   skip object JSON payloads; operations keep their hashes and
+                 a commit's payloads are downloaded when it is first read
+Specs can be combined with a comma.
+
+--class clones only the operations of the named classes, so one domain of a
+multi-team repository can be cloned without the others' objects. Later
+fetches from origin keep the same class filter, and the checkout is sparse:
+status, commit, and checkout only consider the cloned classes.
+
+--local clones another repository on the same machine instead of a remote.
+Its database is copied copy-on-write where the filesystem supports it
+(Btrfs, XFS, APFS), so history and vector blobs are shared with the source
+until either repository changes them, and copied in full otherwise. The
+clone keeps the source's branches, tags, and remotes, but not its staged
+changes, stashes, or worktrees; it checks out the source's current branch,
+or --branch, into the new Weaviate instance.
+
+Examples:
+  wvc clone http://server:8720/myrepo
+  wvc clone --local --url http://localhost:8081 ../prod-repo
+  wvc clone --url http://localhost:8081 http://server:8720/myrepo
+  wvc clone --no-vectors --branch dev http://server:8720/myrepo
+  wvc clone --filter=payload:none,vector:none http://server:8720/myrepo
+  wvc clone --class Article --class Author http://server:8720/myrepo`,
+	Args: cobra.ExactArgs(1),
+	Run:  runClone,
+}
+
+var (
+	cloneURL         string
+	cloneBranch      string
+	cloneDepth       int
+	cloneNoVectors   bool
+	cloneFilter      string
+	cloneClasses     []string
+	cloneLocal       bool
+	cloneConcurrency int
+)
+
+func init() {
+	cloneCmd.Flags().StringVar(&cloneURL, "url", "http://localhost:8080", "Weaviate server URL")
+	cloneCmd.Flags().StringVarP(&cloneBranch, "branch", "b", "", "Branch to check out (default: the remote's default branch)")
+	cloneCmd.Flags().IntVar(&cloneDepth, "depth", 0, "Limit number of commits to fetch (0 = all)")
+	cloneCmd.Flags().IntVar(&cloneConcurrency, "concurrency", core.DefaultTransferConcurrency, "Number of vectors to download at once")
+	cloneCmd.Flags().BoolVar(&cloneNoVectors, "no-vectors", false, "Download vectors on demand instead of during the clone")
+	cloneCmd.Flags().StringVar(&cloneFilter, "filter", "", "Data to download on demand: vector:none, payload:none")
+	cloneCmd.Flags().StringArrayVar(&cloneClasses, "class", nil, "Only clone operations of this class (repeatable)")
+	cloneCmd.Flags().BoolVar(&cloneLocal, "local", false, "Clone the repository at a local path, sharing its data copy-on-write")
+	addProfileFlags(cloneCmd)
+}
+
+func runClone(cmd *cobra.Command, args []string) {
+	ctx := context.Background()
+	if cloneLocal {
+		runLocalClone(ctx, args[0])
+		return
+	}
+	remoteURL := args[0]
+	const remoteName = "origin"
+
+	if _, _, err := core.ParseRemoteURL(remoteURL); err != nil {
+		exitError("%v", err)
+	}
+	spec := cloneFilter
+	for _, class := range cloneClasses {
+		spec += "," + remote.FilterClassPrefix + class
+	}
+	filter, err := remote.ParseFilter(spec)
+	if err != nil {
+		exitError("%v", err)
+	}
+	if cloneNoVectors {
+		filter.NoVectors = true
+	}
+
+	cfg, st, wc := initRepository(ctx, cloneURL)
+	defer st.Close()
+	// A failed clone leaves no half-initialized repository behind
+	fail := func(format string, args ...interface{}) {
+		st.Close()
+		os.RemoveAll(cfg.WVCPath())
+		exitError(format, args...)
+	}
+
+	// Checking out the clone replaces the instance's schema and objects
+	classes, err := wc.GetClasses(ctx)
+	if err != nil {
+		fail("list Weaviate classes: %v", err)
+	}
+	if len(classes) > 0 {
+		fail("Weaviate at %s already has %d class(es); clone into an empty instance", cloneURL, len(classes))
+	}
+
+	if err := core.AddRemote(st, remoteName, remoteURL); err != nil {
+		fail("%v", err)
+	}
+	token, err := core.GetRemoteToken(st, remoteName)
+	if err != nil {
+		fail("get token: %v", err)
+	}
+	if token == "" {
+		if err := core.SetRemoteToken(st, remoteName, promptRemoteToken(remoteName)); err != nil {
+			fail("%v", err)
+		}
+	}
+	if !filter.IsZero() {
+		if err := st.SetPromisorRemote(remoteName, filter); err != nil {
+			fail("%v", err)
+		}
+		installPromisorFetchers(st)
+	}
+	// Classes that were not cloned are left alone by status and commit
+	if err := st.SetSparseClasses(filter.Classes); err != nil {
+		fail("%v", err)
+	}
+
+	client, err := newRemoteClient(st, remoteName)
+	if err != nil {
+		fail("%v", err)
+	}
+	branch := cloneBranch
+	if branch == "" {
+		info, err := client.GetRepoInfo(ctx)
+		if err != nil {
+			fail("get remote info: %v", err)
+		}
+		branch = info.DefaultBranch
+	}
+	if branch == "" {
+		fmt.Println("Remote repository is empty.")
+		return
+	}
+	if err := st.SetCurrentBranch(branch); err != nil {
+		fail("%v", err)
+	}
+
+	fmt.Printf("Cloning %s (%s)...\n", remoteURL, branch)
+	result, err := core.Pull(ctx, cfg, st, wc, client, core.PullOptions{
+		RemoteName:  remoteName,
+		Branch:      branch,
+		Depth:       cloneDepth,
+		Concurrency: cloneConcurrency,
+	}, func(phase string, current, total int) {
+		if total > 0 {
+			fmt.Printf("\r  %s %d/%d", phase, current, total)
+		}
+	})
+	if err != nil {
+		fmt.Println()
+		fail("%v", err)
+	}
+	fmt.Println()
+
+	printCloneResult(cfg, st, result, branch)
+}
+
+func runLocalClone(ctx context.Context, source string) {
+	if cloneFilter != "" || cloneNoVectors || len(cloneClasses) > 0 || cloneDepth > 0 {
+		exitError("--local cannot be combined with --filter, --no-vectors, --class, or --depth")
+	}
+	srcPath, err := filepath.Abs(source)
+	if err != nil {
+		exitError("%v", err)
+	}
+	if filepath.Base(srcPath) != config.WVCDir {
+		srcPath = filepath.Join(srcPath, config.WVCDir)
+	}
+	srcCfg, err := config.LoadDir(srcPath)
+	if err != nil {
+		exitError("%s is not a wvc repository: %v", source, err)
+	}
+
+	cfg, wc := initConfig(ctx, cloneURL)
+	// A failed clone leaves no half-initialized repository behind
+	var st *store.Store
+	fail := func(format string, args ...interface{}) {
+		if st != nil {
+			st.Close()
+		}
+		os.RemoveAll(cfg.WVCPath())
+		exitError(format, args...)
+	}
+
+	// Checking out the clone replaces the instance's schema and objects
+	classes, err := wc.GetClasses(ctx)
+	if err != nil {
+		fail("list Weaviate classes: %v", err)
+	}
+	if len(classes) > 0 {
+		fail("Weaviate at %s already has %d class(es); clone into an empty instance", cloneURL, len(classes))
+	}
+
+	policy, err := cfg.SyncPolicy()
+	if err != nil {
+		fail("%v", err)
+	}
+	fmt.Printf("Cloning %s...\n", source)
+	cloned, err := store.CloneDatabase(srcCfg.DatabasePath(), cfg.DatabasePath(), policy)
+	if err != nil {
+		fail("%v", err)
+	}
+	st, err = store.New(cfg.DatabasePath())
+	if err != nil {
+		fail("%v", err)
+	}
+	defer st.Close()
+	// Add any buckets newer than the source's database
+	if err := st.Initialize(); err != nil {
+		fail("%v", err)
+	}
+	if promisor, _ := st.GetPromisorRemote(); promisor != "" {
+		installPromisorFetchers(st)
+	}
+
+	result, err := core.FinishLocalClone(ctx, cfg, st, wc, cloneBranch)
+	if err != nil {
+		fail("%v", err)
+	}
+
+	green := color.New(color.FgGreen)
+	if cloned {
+		green.Println("Cloned; data is shared copy-on-write with the source")
+	} else {
+		green.Println("Cloned; the filesystem has no copy-on-write support, so data was copied")
+	}
+	if result.TargetCommit != "" {
+		fmt.Printf("Checked out '%s' at %s: %d object(s) restored\n", result.BranchName, shortID(result.TargetCommit), result.ObjectsAdded+result.ObjectsUpdated)
+	}
+	fmt.Printf("Tracking Weaviate at %s\n", cfg.WeaviateURL)
+	if len(result.Warnings) > 0 {
+		yellow := color.New(color.FgYellow)
+		yellow.Println("\nWarnings:")
+		for _, w := range result.Warnings {
+			yellow.Printf("  - %s\n", w.Message)
+		}
+	}
+}
+
+func printCloneResult(cfg *config.Config, st *store.Store, result *core.PullResult, branch string) {
+	green := color.New(color.FgGreen)
+	yellow := color.New(color.FgYellow)
+
+	green.Printf("Cloned %d commit(s)", result.CommitsFetched)
+	if result.VectorsFetched > 0 {
+		fmt.Printf(", %d vector(s)", result.VectorsFetched)
+	}
+	fmt.Println()
+	if promisor, _ := st.GetPromisorRemote(); promisor != "" {
+		filter, _ := st.GetPromisorFilter()
+		if len(filter.Classes) > 0 {
+			fmt.Printf("Only class(es) %s are fetched from '%s'\n", strings.Join(filter.Classes, ", "), promisor)
+			filter.Classes = nil
+		}
+		if !filter.IsZero() {
+			fmt.Printf("Filtered data (%s) is downloaded from '%s' as commands need it\n", filter, promisor)
+		}
+	}
+	if result.RemoteTip != "" {
+		fmt.Printf("Checked out '%s' at %s: %d object(s) restored\n", branch, shortID(result.RemoteTip), result.ObjectsAdded+result.ObjectsUpdated)
+	}
+	fmt.Printf("Tracking Weaviate at %s\n", cfg.WeaviateURL)
+
+	if len(result.Warnings) > 0 {
+		yellow.Println("\nWarnings:")
+		for _, w := range result.Warnings {
+			yellow.Printf("  - %s\n", w.Message)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package cli
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/fatih/color"
 	"github.com/kilupskalvis/wvc/internal/core"
@@ -16,34 +17,68 @@ var commitCmd = &cobra.Command{
 	Long: `Create a new commit with staged changes.
 
 By default, only staged changes are committed. Use -a to automatically
-stage all changes before committing.`,
+stage all changes before committing.
+
+Several -m options are joined as separate paragraphs. Commit message rules
+in the [commit] table of .wvc/config (subject length, subject pattern, and
+required trailers such as "Ticket: DATA-12") are checked before anything is
+recorded.
+
+If another client writes to a tracked class while changes are being scanned,
+the commit is aborted with "database changed during commit" and can be
+retried once the writer has finished.
+
+With --verify, the objects the commit changed are re-read from Weaviate once
+it is recorded and compared with the recorded payloads and vectors, so a
+write by another client while the commit was being taken is reported instead
+of silently going unnoticed. --verify-sample checks a random sample of that
+many objects instead of all of them.
+
+Examples:
+  wvc commit -m "Import spring catalog"
+  wvc commit -a -m "data: refresh embeddings" -m "Model: text-embedding-3-small"
+  wvc commit -a --verify --verify-sample 500 -m "Nightly import"`,
 	Run: runCommit,
 }
 
 var (
-	commitMessage string
+	commitMessage []string
 	commitAll     bool
+	commitVerify  bool
+	commitSample  int
 )
 
 func init() {
-	commitCmd.Flags().StringVarP(&commitMessage, "message", "m", "", "Commit message (required)")
+	commitCmd.Flags().StringArrayVarP(&commitMessage, "message", "m", nil, "Commit message (required); repeat for more paragraphs")
 	commitCmd.Flags().BoolVarP(&commitAll, "all", "a", false, "Automatically stage all changes before committing")
+	commitCmd.Flags().BoolVar(&commitVerify, "verify", false, "Re-read the changed objects from Weaviate and check them against the commit")
+	commitCmd.Flags().IntVar(&commitSample, "verify-sample", 0, "With --verify, check this many randomly chosen objects (0 = all)")
 	commitCmd.MarkFlagRequired("message")
+	addProfileFlags(commitCmd)
 }
 
 func runCommit(cmd *cobra.Command, args []string) {
 	bgCtx := context.Background()
 	c := initFullContext()
 	defer c.Close()
+	holdWriteLock(c, "commit")
+	defer releaseWriteLock()
 
 	cfg, st, client := c.Config, c.Store, c.Client
 	var commit *models.Commit
+	message := strings.Join(commitMessage, "\n\n")
 
 	if commitAll {
-		_, err := core.StageAll(bgCtx, cfg, st, client)
+		watermarks, err := core.TakeWatermarks(bgCtx, st, client)
 		if err != nil {
+			exitError("%v", err)
+		}
+		if _, err := core.StageAll(bgCtx, cfg, st, client); err != nil {
 			exitError("failed to stage changes: %v", err)
 		}
+		if err := watermarks.Check(bgCtx, client); err != nil {
+			exitError("%v", err)
+		}
 	}
 
 	// Check if there are staged changes
@@ -53,18 +88,43 @@ func runCommit(cmd *cobra.Command, args []string) {
 	}
 
 	if stagedCount == 0 {
-		commit, err = core.CreateCommit(bgCtx, cfg, st, client, commitMessage)
+		commit, err = core.CreateCommit(bgCtx, cfg, st, client, message)
 		if err != nil {
 			exitError("%v", err)
 		}
 	} else {
-		commit, err = core.CreateCommitFromStaging(bgCtx, cfg, st, client, commitMessage)
+		commit, err = core.CreateCommitFromStaging(bgCtx, cfg, st, client, message)
 		if err != nil {
 			exitError("%v", err)
 		}
 	}
 
 	green := color.New(color.FgGreen)
-	green.Printf("[%s] %s\n", commit.ShortID(), commit.Message)
+	green.Printf("[%s] %s\n", commit.ShortID(), firstLine(commit.Message))
 	fmt.Printf(" %d operation(s)\n", commit.OperationCount)
+
+	if commitVerify {
+		verifyCommit(bgCtx, c, commit)
+	}
+}
+
+// verifyCommit checks a new commit against live Weaviate and exits with an
+// error listing the objects that changed while it was being recorded.
+func verifyCommit(ctx context.Context, c *cmdContext, commit *models.Commit) {
+	result, err := core.VerifyCommit(ctx, c.Store, c.Client, commit.ID, commitSample)
+	if err != nil {
+		exitError("verify commit: %v", err)
+	}
+	if len(result.Mismatches) == 0 {
+		fmt.Printf(" verified %d of %d object(s) against Weaviate\n", result.Checked, result.Total)
+		return
+	}
+
+	red := color.New(color.FgRed)
+	red.Printf("Commit %s does not match Weaviate: %d of %d checked object(s) changed while it was recorded\n",
+		commit.ShortID(), len(result.Mismatches), result.Checked)
+	for _, m := range result.Mismatches {
+		fmt.Printf("  %s: %s\n", models.ObjectKey(m.ClassName, m.ObjectID), m.Reason)
+	}
+	exitError("commit verification failed; check the writers of this instance and run \"wvc status\"")
 }
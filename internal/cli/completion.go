@@ -1,17 +1,25 @@
 package cli
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
 
+	"github.com/kilupskalvis/wvc/internal/config"
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/store"
 	"github.com/spf13/cobra"
 )
 
 func init() {
 	rootCmd.AddCommand(&cobra.Command{
-		Use:   "completion [bash|zsh|fish]",
+		Use:   "completion [bash|zsh|fish|powershell]",
 		Short: "Generate shell completion script",
 		Long: `Generate shell completion script for wvc.
 
+Completions include branch names, remotes, classes, and stash refs read
+from the local repository.
+
 To load completions:
 
 Bash:
@@ -28,8 +36,12 @@ Fish:
   $ wvc completion fish | source
   # Or add to config:
   $ wvc completion fish > ~/.config/fish/completions/wvc.fish
+
+PowerShell:
+  PS> wvc completion powershell | Out-String | Invoke-Expression
+  # Or add the output to your PowerShell profile
 `,
-		ValidArgs:             []string{"bash", "zsh", "fish"},
+		ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
 		Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
 		DisableFlagsInUseLine: true,
 		Run: func(cmd *cobra.Command, args []string) {
@@ -40,7 +52,205 @@ Fish:
 				rootCmd.GenZshCompletion(os.Stdout)
 			case "fish":
 				rootCmd.GenFishCompletion(os.Stdout, true)
+			case "powershell":
+				rootCmd.GenPowerShellCompletionWithDesc(os.Stdout)
 			}
 		},
 	})
+
+	// Dynamic completion of repository names
+	for _, cmd := range []*cobra.Command{mergeCmd, branchCmd} {
+		cmd.ValidArgsFunction = completeFirstArg(completeBranches)
+	}
+	for _, cmd := range []*cobra.Command{remoteRemoveCmd, remoteSetURLCmd, remoteInfoCmd, remoteShowCmd, remoteSetTokenCmd, stashFetchCmd} {
+		cmd.ValidArgsFunction = completeFirstArg(completeRemotes)
+	}
+	for _, cmd := range []*cobra.Command{pushCmd, pullCmd, fetchCmd} {
+		cmd.ValidArgsFunction = completeRemoteThenBranch
+	}
+	for _, cmd := range []*cobra.Command{stashPopCmd, stashApplyCmd, stashDropCmd, stashShowCmd} {
+		cmd.ValidArgsFunction = completeFirstArg(completeStashRefs)
+	}
+	for _, cmd := range []*cobra.Command{addCmd, statusCmd, diffCmd, logCmd, mvCmd} {
+		cmd.ValidArgsFunction = completePathspec
+	}
+	worktreeAddCmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		switch len(args) {
+		case 0:
+			return nil, cobra.ShellCompDirectiveFilterDirs
+		case 2:
+			return completeBranches()
+		}
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	worktreeRemoveCmd.ValidArgsFunction = completeFirstArg(completeWorktrees)
+	tagCmd.ValidArgsFunction = completeFirstArg(completeTags)
+	for _, cmd := range []*cobra.Command{conflictsShowCmd, conflictsResolveCmd} {
+		cmd.ValidArgsFunction = completeFirstArg(completeConflicts)
+	}
+	checkoutCmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if cmd.ArgsLenAtDash() >= 0 {
+			return completePathspec(cmd, args, toComplete)
+		}
+		return completeFirstArg(completeBranches)(cmd, args, toComplete)
+	}
+	_ = branchCmd.RegisterFlagCompletionFunc("classes", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completionClasses(), cobra.ShellCompDirectiveNoFileComp
+	})
+	for _, cmd := range []*cobra.Command{stashCmd, stashPushCmd} {
+		_ = cmd.RegisterFlagCompletionFunc("remote", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return completeRemotes()
+		})
+	}
+}
+
+// completionFunc lists completion candidates without regard to position
+type completionFunc func() ([]string, cobra.ShellCompDirective)
+
+// completeFirstArg offers candidates for the first positional argument only
+func completeFirstArg(candidates completionFunc) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return candidates()
+	}
+}
+
+// completeRemoteThenBranch completes "[<remote>] [<branch>]" arguments
+func completeRemoteThenBranch(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 0:
+		return completeRemotes()
+	case 1:
+		return completeBranches()
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completePathspec completes "<class>/" prefixes of a pathspec
+func completePathspec(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var out []string
+	for _, class := range completionClasses() {
+		out = append(out, class+"/")
+	}
+	return out, cobra.ShellCompDirectiveNoSpace | cobra.ShellCompDirectiveNoFileComp
+}
+
+func completeBranches() ([]string, cobra.ShellCompDirective) {
+	var out []string
+	withCompletionStore(func(st *store.Store) {
+		branches, err := st.ListBranches()
+		if err != nil {
+			return
+		}
+		for _, b := range branches {
+			out = append(out, b.Name)
+		}
+	})
+	return out, cobra.ShellCompDirectiveNoFileComp
+}
+
+func completeTags() ([]string, cobra.ShellCompDirective) {
+	var out []string
+	withCompletionStore(func(st *store.Store) {
+		tags, err := st.ListTags()
+		if err != nil {
+			return
+		}
+		for _, tag := range tags {
+			out = append(out, tag.Name)
+		}
+	})
+	return out, cobra.ShellCompDirectiveNoFileComp
+}
+
+func completeRemotes() ([]string, cobra.ShellCompDirective) {
+	var out []string
+	withCompletionStore(func(st *store.Store) {
+		remotes, err := st.ListRemotes()
+		if err != nil {
+			return
+		}
+		for _, r := range remotes {
+			out = append(out, r.Name+"\t"+r.URL)
+		}
+	})
+	return out, cobra.ShellCompDirectiveNoFileComp
+}
+
+func completeWorktrees() ([]string, cobra.ShellCompDirective) {
+	var out []string
+	withCompletionStore(func(st *store.Store) {
+		worktrees, err := st.ListWorktrees()
+		if err != nil {
+			return
+		}
+		for _, wt := range worktrees {
+			out = append(out, wt.Name+"\t"+wt.Path)
+		}
+	})
+	return out, cobra.ShellCompDirectiveNoFileComp
+}
+
+func completeConflicts() ([]string, cobra.ShellCompDirective) {
+	var out []string
+	withCompletionStore(func(st *store.Store) {
+		state, err := st.GetMergeState()
+		if err != nil || state == nil {
+			return
+		}
+		for _, c := range state.Conflicts {
+			out = append(out, c.Key+"\t"+string(c.Type))
+		}
+	})
+	return out, cobra.ShellCompDirectiveNoFileComp
+}
+
+func completeStashRefs() ([]string, cobra.ShellCompDirective) {
+	var out []string
+	withCompletionStore(func(st *store.Store) {
+		stashes, err := st.ListStashes()
+		if err != nil {
+			return
+		}
+		for i, s := range stashes {
+			out = append(out, fmt.Sprintf("stash@{%d}\t%s", i, firstLine(s.Message)))
+		}
+	})
+	return out, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completionClasses returns the classes of the latest committed schema
+func completionClasses() []string {
+	var out []string
+	withCompletionStore(func(st *store.Store) {
+		version, err := st.GetLatestSchemaVersion()
+		if err != nil || version == nil {
+			return
+		}
+		var schema models.WeaviateSchema
+		if err := json.Unmarshal(version.SchemaJSON, &schema); err != nil {
+			return
+		}
+		for _, class := range schema.Classes {
+			out = append(out, class.Class)
+		}
+	})
+	return out
+}
+
+// withCompletionStore opens the local store for a completion query. Errors
+// are swallowed: outside a repository there is simply nothing to offer.
+func withCompletionStore(fn func(st *store.Store)) {
+	cfg, err := config.Load()
+	if err != nil {
+		return
+	}
+	st, err := store.OpenWorktree(cfg.DatabasePath(), cfg.Worktree)
+	if err != nil {
+		return
+	}
+	defer st.Close()
+	fn(st)
 }
@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kilupskalvis/wvc/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Get and set repository settings",
+	Long: `Get and set settings in .wvc/config.
+
+Supported keys:
+  ` + strings.Join(config.SettableKeys(), "\n  ") + `
+
+The weaviate.auth keys connect wvc to a secured Weaviate, such as Weaviate
+Cloud: an API key, a static bearer token, an OIDC client secret, or an OIDC
+username and password, of which only one may be set. Headers are sent with
+every request, e.g. X-OpenAI-Api-Key for vectorizer modules. A config that
+stores credentials is made readable by its owner only. The environment
+variables WVC_WEAVIATE_API_KEY, WVC_WEAVIATE_BEARER_TOKEN,
+WVC_WEAVIATE_CLIENT_SECRET, WVC_WEAVIATE_USERNAME, and WVC_WEAVIATE_PASSWORD
+replace the configured credentials, and WVC_WEAVIATE_HEADERS adds
+comma-separated Name=value headers.
+
+Examples:
+  wvc config set weaviate.auth.api_key "$WEAVIATE_API_KEY"
+  wvc config set weaviate.auth.headers.X-OpenAI-Api-Key "$OPENAI_API_KEY"
+  wvc config set weaviate.auth.scopes offline_access,openid
+  wvc config get user.email
+  wvc config unset weaviate.auth.api_key`,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print the value of a setting",
+	Args:  cobra.ExactArgs(1),
+	Run:   runConfigGet,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Set a setting",
+	Args:  cobra.ExactArgs(2),
+	Run:   runConfigSet,
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset <key>",
+	Short: "Remove a setting",
+	Args:  cobra.ExactArgs(1),
+	Run:   runConfigUnset,
+}
+
+func init() {
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUnsetCmd)
+}
+
+func runConfigGet(cmd *cobra.Command, args []string) {
+	cfg, err := config.Load()
+	if err != nil {
+		exitError("%v", err)
+	}
+	value, set, err := cfg.Get(args[0])
+	if err != nil {
+		exitError("%v", err)
+	}
+	if !set {
+		exitError("%s is not set", args[0])
+	}
+	fmt.Println(value)
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) {
+	cfg, err := config.Load()
+	if err != nil {
+		exitError("%v", err)
+	}
+	if err := cfg.Set(args[0], args[1]); err != nil {
+		exitError("%v", err)
+	}
+	if err := cfg.Save(); err != nil {
+		exitError("%v", err)
+	}
+}
+
+func runConfigUnset(cmd *cobra.Command, args []string) {
+	cfg, err := config.Load()
+	if err != nil {
+		exitError("%v", err)
+	}
+	if err := cfg.Unset(args[0]); err != nil {
+		exitError("%v", err)
+	}
+	if err := cfg.Save(); err != nil {
+		exitError("%v", err)
+	}
+}
@@ -0,0 +1,314 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/kilupskalvis/wvc/internal/core"
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/spf13/cobra"
+)
+
+var conflictsCmd = &cobra.Command{
+	Use:   "conflicts",
+	Short: "Inspect and resolve the conflicts of a stopped merge",
+	Long: `List the object conflicts of the merge in progress and whether each one has
+been resolved.
+
+Examples:
+  wvc conflicts list                     List conflicts and their resolutions
+  wvc conflicts show Article/<id>        Compare base, ours, and theirs side by side
+  wvc conflicts resolve <id> --theirs    Keep their version of one object
+  wvc conflicts resolve <id> --edit      Write the resolved properties in an editor
+  wvc conflicts resolve <id> --edit resolved.json
+                                         Use the properties in a JSON file`,
+	Args: cobra.NoArgs,
+	Run:  runConflictsList,
+}
+
+var conflictsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List conflicts and their resolutions",
+	Args:  cobra.NoArgs,
+	Run:   runConflictsList,
+}
+
+var conflictsShowCmd = &cobra.Command{
+	Use:   "show [<class>/<id>]",
+	Short: "Show conflicts side by side",
+	Long: `Show the base, ours, and theirs versions of conflicted objects side by side,
+one property per row. Values a side changed relative to the base are
+highlighted, and properties both sides changed differently are marked with
+'!'. The vector row shows whether each side changed the object's vector.
+
+Without an object, every conflict is shown.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runConflictsShow,
+}
+
+var conflictsResolveCmd = &cobra.Command{
+	Use:   "resolve <class>/<id> --ours|--theirs|--edit [<json-file>]",
+	Short: "Resolve one conflict",
+	Long: `Record the resolution of one conflict of the merge in progress. --ours and
+--theirs keep that side's version. --edit opens the object's properties as
+JSON in $WVC_EDITOR, $VISUAL, or $EDITOR, starting from ours with the
+properties only they changed taken from theirs; the saved properties become
+the resolved version, which keeps our vector (theirs if we deleted the object).
+Given a file, --edit reads the properties from it instead of opening an editor.
+
+Run 'wvc merge --continue' once every conflict is resolved.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := cobra.RangeArgs(1, 2)(cmd, args); err != nil {
+			return err
+		}
+		if len(args) == 2 && !conflictsEdit {
+			return fmt.Errorf("a JSON file can only be given with --edit")
+		}
+		return nil
+	},
+	Run: runConflictsResolve,
+}
+
+var (
+	conflictsOurs   bool
+	conflictsTheirs bool
+	conflictsEdit   bool
+)
+
+func init() {
+	conflictsResolveCmd.Flags().BoolVar(&conflictsOurs, "ours", false, "Keep our version")
+	conflictsResolveCmd.Flags().BoolVar(&conflictsTheirs, "theirs", false, "Keep their version")
+	conflictsResolveCmd.Flags().BoolVar(&conflictsEdit, "edit", false, "Edit the resolved properties")
+	conflictsResolveCmd.MarkFlagsMutuallyExclusive("ours", "theirs", "edit")
+	conflictsResolveCmd.MarkFlagsOneRequired("ours", "theirs", "edit")
+
+	conflictsCmd.AddCommand(conflictsListCmd)
+	conflictsCmd.AddCommand(conflictsShowCmd)
+	conflictsCmd.AddCommand(conflictsResolveCmd)
+}
+
+func runConflictsList(cmd *cobra.Command, args []string) {
+	c := initContextWithMigrations()
+	defer c.Close()
+
+	state, conflicts, err := core.MergeConflicts(c.Store, "")
+	if err != nil {
+		exitError("%v", err)
+	}
+
+	fmt.Printf("Merging '%s' (%s) into %s: %d of %d conflict(s) unresolved\n",
+		state.TargetBranch, shortID(state.TheirHead), shortID(state.OurHead), len(state.Unresolved()), len(conflicts))
+	t := &table{indent: "  "}
+	for _, conflict := range conflicts {
+		t.addRow(resolutionCell(state, conflict), cell(string(conflict.Type), nil), cell(conflict.Key, nil))
+	}
+	t.print()
+}
+
+func runConflictsShow(cmd *cobra.Command, args []string) {
+	c := initContextWithMigrations()
+	defer c.Close()
+
+	object := ""
+	if len(args) > 0 {
+		object = args[0]
+	}
+	state, conflicts, err := core.MergeConflicts(c.Store, object)
+	if err != nil {
+		exitError("%v", err)
+	}
+
+	startPager()
+	defer stopPager()
+	for i, conflict := range conflicts {
+		if i > 0 {
+			fmt.Println()
+		}
+		printConflict(state, conflict)
+	}
+}
+
+func runConflictsResolve(cmd *cobra.Command, args []string) {
+	c := initContextWithMigrations()
+	defer c.Close()
+
+	var conflict *models.MergeConflict
+	var resolution string
+	switch {
+	case conflictsEdit:
+		_, matches, err := core.MergeConflicts(c.Store, args[0])
+		if err != nil {
+			exitError("%v", err)
+		}
+		var props map[string]interface{}
+		if len(args) == 2 {
+			props, err = readConflictProperties(matches[0], args[1])
+		} else {
+			props, err = editConflictProperties(matches[0])
+		}
+		if err != nil {
+			exitError("%v", err)
+		}
+		conflict, err = core.MergeResolveEdit(c.Store, args[0], props)
+		if err != nil {
+			exitError("%v", err)
+		}
+		resolution = "edited properties"
+	default:
+		side := models.ConflictOurs
+		if conflictsTheirs {
+			side = models.ConflictTheirs
+		}
+		var err error
+		conflict, err = core.MergeResolve(c.Store, args[0], side)
+		if err != nil {
+			exitError("%v", err)
+		}
+		resolution = "'" + string(side) + "'"
+	}
+
+	state, err := c.Store.GetMergeState()
+	if err != nil {
+		exitError("%v", err)
+	}
+	remaining := len(state.Unresolved())
+	fmt.Printf("Resolved %s using %s; %d conflict(s) remaining\n", conflict.Key, resolution, remaining)
+	if remaining == 0 {
+		colorHint.Println("Run 'wvc merge --continue' to finish the merge")
+	}
+}
+
+// editConflictProperties opens the conflict's edit template in the user's
+// editor and returns the saved properties
+func editConflictProperties(conflict *models.MergeConflict) (map[string]interface{}, error) {
+	data, err := json.MarshalIndent(core.ConflictEditTemplate(conflict), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.CreateTemp("", "wvc-conflict-*.json")
+	if err != nil {
+		return nil, err
+	}
+	path := f.Name()
+	defer os.Remove(path)
+	_, err = f.Write(append(data, '\n'))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := editFile(path); err != nil {
+		return nil, err
+	}
+	return readConflictProperties(conflict, path)
+}
+
+// readConflictProperties reads the resolved properties of a conflict from a
+// JSON file
+func readConflictProperties(conflict *models.MergeConflict, path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var props map[string]interface{}
+	if err := json.Unmarshal(data, &props); err != nil {
+		return nil, fmt.Errorf("edited properties of %s are not a JSON object: %w", conflict.Key, err)
+	}
+	return props, nil
+}
+
+// printConflict renders one conflict with its base, ours, and theirs
+// versions side by side
+func printConflict(state *models.MergeState, conflict *models.MergeConflict) {
+	fmt.Printf("%s  %s  ", colorDeleted.Sprint(conflict.Type), conflict.Key)
+	res := resolutionCell(state, conflict)
+	fmt.Println(colorize(res))
+
+	rows := core.ConflictProperties(conflict)
+	nameWidth := len("vector")
+	for _, row := range rows {
+		nameWidth = max(nameWidth, len(row.Name))
+	}
+	// Split the remaining width evenly between the three versions
+	valueWidth := 0
+	if width := terminalWidth(); width > 0 {
+		valueWidth = max((width-2-2-nameWidth-3*2)/3, minTableColumn)
+	}
+	fit := func(s string) string {
+		if valueWidth == 0 {
+			return s
+		}
+		return truncate(s, valueWidth)
+	}
+
+	t := &table{indent: "  "}
+	t.addRow(cell(" ", nil), cell("property", colorMuted), cell("base", colorMuted), cell("ours", colorMuted), cell("theirs", colorMuted))
+	for _, row := range rows {
+		marker := cell(" ", nil)
+		if row.Conflicting() {
+			marker = cell("!", colorDeleted)
+		}
+		t.addRow(marker, cell(row.Name, nil),
+			conflictValueCell(conflict.Base, "(absent)", row.Base, false, fit),
+			conflictValueCell(conflict.Ours, "(deleted)", row.Ours, row.OursChanged, fit),
+			conflictValueCell(conflict.Theirs, "(deleted)", row.Theirs, row.TheirsChanged, fit))
+	}
+
+	oursVec := conflict.Ours != nil && conflict.OursVectorHash != conflict.BaseVectorHash
+	theirsVec := conflict.Theirs != nil && conflict.TheirsVectorHash != conflict.BaseVectorHash
+	vecMarker := cell(" ", nil)
+	if oursVec && theirsVec && conflict.OursVectorHash != conflict.TheirsVectorHash {
+		vecMarker = cell("!", colorDeleted)
+	}
+	t.addRow(vecMarker, cell("vector", colorMuted),
+		vectorCell(conflict.Base, "(absent)", conflict.BaseVectorHash, false),
+		vectorCell(conflict.Ours, "(deleted)", conflict.OursVectorHash, oursVec),
+		vectorCell(conflict.Theirs, "(deleted)", conflict.TheirsVectorHash, theirsVec))
+	t.print()
+}
+
+func resolutionCell(state *models.MergeState, conflict *models.MergeConflict) tableCell {
+	side, ok := state.Resolutions[conflict.Key]
+	if !ok {
+		return cell("unresolved", colorDeleted)
+	}
+	return cell("resolved ("+string(side)+")", colorAdded)
+}
+
+// conflictValueCell renders a property value of one version; a nil object
+// means the version does not exist and is shown as missing
+func conflictValueCell(obj *models.WeaviateObject, missing string, value interface{}, changed bool, fit func(string) string) tableCell {
+	if obj == nil {
+		return cell(missing, colorDeleted)
+	}
+	if value == nil {
+		if changed {
+			return cell("(removed)", colorModified)
+		}
+		return cell("-", colorMuted)
+	}
+	text, err := json.Marshal(value)
+	if err != nil {
+		text = []byte(fmt.Sprint(value))
+	}
+	if changed {
+		return cell(fit(string(text)), colorModified)
+	}
+	return cell(fit(string(text)), nil)
+}
+
+func vectorCell(obj *models.WeaviateObject, missing, hash string, changed bool) tableCell {
+	switch {
+	case obj == nil:
+		return cell(missing, colorDeleted)
+	case hash == "":
+		return cell("(none)", colorMuted)
+	case changed:
+		return cell(shortID(hash)+" (changed)", colorModified)
+	}
+	return cell(shortID(hash), nil)
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var countObjectsCmd = &cobra.Command{
+	Use:   "count-objects",
+	Short: "Show object counts and database space usage",
+	Long: `Show how many commits, operations, and vector blobs are stored locally,
+along with the database file size and the space held by free pages.
+
+Free pages are reused by later writes but never returned to the filesystem.
+Run "wvc store compact" to shrink the file when the free space is large.
+
+Examples:
+  wvc count-objects`,
+	Args: cobra.NoArgs,
+	Run:  runCountObjects,
+}
+
+func runCountObjects(cmd *cobra.Command, args []string) {
+	c := initContextWithMigrations()
+	defer c.Close()
+
+	stats, err := c.Store.Stats()
+	if err != nil {
+		exitError("%v", err)
+	}
+
+	fmt.Printf("commits:       %d\n", stats.Commits)
+	fmt.Printf("operations:    %d\n", stats.Operations)
+	fmt.Printf("vector blobs:  %d\n", stats.VectorBlobs)
+	fmt.Printf("size:          %s\n", formatBytes(stats.FileSize))
+	fmt.Printf("page size:     %d\n", stats.PageSize)
+	fmt.Printf("free pages:    %d\n", stats.FreePages)
+	fmt.Printf("pending pages: %d\n", stats.PendingPages)
+	fmt.Printf("free space:    %s\n", formatBytes(stats.FreeBytes))
+}
+
+// formatBytes renders a byte count using binary units.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/kilupskalvis/wvc/internal/core"
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var deployCmd = &cobra.Command{
+	Use:   "deploy",
+	Short: "Record which commit is deployed to an environment",
+	Long: `Track which commit is deployed to each named environment.
+
+Examples:
+  wvc deploy record prod main                  Record main's tip as deployed to prod
+  wvc deploy record staging                    Record HEAD as deployed to staging
+  wvc deploy record prod main --remote origin  Also record it on origin`,
+}
+
+var deployRecordCmd = &cobra.Command{
+	Use:   "record <environment> [<ref>]",
+	Short: "Record the commit deployed to an environment",
+	Long: `Record the commit <ref> resolves to (HEAD by default) as deployed to
+<environment>, replacing the previous record. When <ref> is a local branch,
+or HEAD on a branch, the environment tracks that branch: push and merge warn
+when they change it.
+
+With --remote, the deployment is also recorded on the remote, which must
+already have the commit.`,
+	Args: cobra.RangeArgs(1, 2),
+	Run:  runDeployRecord,
+}
+
+var deploymentsCmd = &cobra.Command{
+	Use:   "deployments",
+	Short: "List recorded deployments",
+	Long: `List the commit recorded as deployed to each environment.
+
+Examples:
+  wvc deployments list
+  wvc deployments list --remote origin`,
+	Args: cobra.NoArgs,
+	Run:  runDeploymentsList,
+}
+
+var deploymentsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List recorded deployments",
+	Args:  cobra.NoArgs,
+	Run:   runDeploymentsList,
+}
+
+var (
+	deployRemote      string
+	deploymentsRemote string
+)
+
+func init() {
+	deployRecordCmd.Flags().StringVar(&deployRemote, "remote", "", "Also record the deployment on this remote")
+	deployCmd.AddCommand(deployRecordCmd)
+
+	deploymentsCmd.PersistentFlags().StringVar(&deploymentsRemote, "remote", "", "List the deployments recorded on this remote")
+	deploymentsCmd.AddCommand(deploymentsListCmd)
+}
+
+func runDeployRecord(cmd *cobra.Command, args []string) {
+	c := initContextWithMigrations()
+	defer c.Close()
+
+	ref := ""
+	if len(args) > 1 {
+		ref = args[1]
+	}
+	d, err := core.RecordDeployment(c.Store, args[0], ref)
+	if err != nil {
+		exitError("%v", err)
+	}
+
+	fmt.Printf("Recorded %s as deployed to '%s'", shortID(d.CommitID), d.Environment)
+	if d.Branch != "" {
+		fmt.Printf(" (tracking '%s')", d.Branch)
+	}
+	fmt.Println()
+
+	if deployRemote != "" {
+		client := resolveRemoteClientByName(c.Store, deployRemote)
+		if err := core.PublishDeployment(context.Background(), client, d); err != nil {
+			exitError("%v", err)
+		}
+		fmt.Printf("Recorded on %s\n", deployRemote)
+	}
+}
+
+func runDeploymentsList(cmd *cobra.Command, args []string) {
+	c := initContextWithMigrations()
+	defer c.Close()
+
+	var deployments []*models.Deployment
+	var err error
+	if deploymentsRemote != "" {
+		client := resolveRemoteClientByName(c.Store, deploymentsRemote)
+		deployments, err = core.ListRemoteDeployments(context.Background(), client)
+	} else {
+		deployments, err = c.Store.ListDeployments()
+	}
+	if err != nil {
+		exitError("%v", err)
+	}
+	if len(deployments) == 0 {
+		fmt.Println("No deployments recorded")
+		return
+	}
+
+	for _, d := range deployments {
+		colorRef.Printf("%-12s ", d.Environment)
+		colorCommit.Print(shortID(d.CommitID))
+		if d.Branch != "" {
+			fmt.Printf(" (%s)", d.Branch)
+		}
+		if !d.RecordedAt.IsZero() {
+			colorMuted.Printf(" %s", d.RecordedAt.Format("2006-01-02 15:04"))
+		}
+		fmt.Println()
+	}
+}
+
+// warnTrackedDeployments warns that branch, which was just changed, is
+// tracked by recorded deployments.
+func warnTrackedDeployments(st *store.Store, branch string) {
+	tracking, err := core.DeploymentsTrackingBranch(st, branch)
+	if err != nil {
+		return
+	}
+	yellow := color.New(color.FgYellow)
+	for _, d := range tracking {
+		yellow.Printf("Warning: '%s' is tracked by environment '%s' (deployed: %s)\n", branch, d.Environment, shortID(d.CommitID))
+	}
+}
@@ -4,27 +4,64 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
+	"os"
+	"strings"
 
 	"github.com/fatih/color"
 	"github.com/kilupskalvis/wvc/internal/core"
+	"github.com/kilupskalvis/wvc/internal/store"
 	"github.com/spf13/cobra"
 )
 
 var diffCmd = &cobra.Command{
-	Use:   "diff",
+	Use:   "diff [<from>..<to>] [<pathspec>...]",
 	Short: "Show changes between commits and working tree",
-	Long:  `Show the differences between the current Weaviate state and the last commit.`,
-	Run:   runDiff,
+	Long: `Show the differences between the current Weaviate state and the last commit.
+
+With a <from>..<to> range, the object changes between two commits are
+shown instead; <to> defaults to HEAD. Either end can be a ref at a past time,
+such as main@{yesterday}.
+
+Pathspecs limit the diff to matching classes and objects.
+
+With --patch, the object changes are written to a patch file instead, with
+their vectors, for 'wvc apply' in another repository or instance. Schema
+changes are not included in patches.
+
+Updated objects whose vector changed show its dimensions and the L2 and
+cosine distances between the committed and current vectors. --vectors adds a
+sparkline of the per-dimension change and the most-changed dimensions.
+
+Examples:
+  wvc diff                  Show all changes
+  wvc diff Article/obj-1*   Show changes to matching Article objects
+  wvc diff --stat Author/   Summarize changes to the Author class
+  wvc diff main@{1.week.ago}..main
+                            Show what changed on main in the last week
+  wvc diff --vectors Article/
+                            Show how the Article vectors changed
+  wvc diff --patch fix.wvcp Article/
+                            Write the Article changes to a patch file`,
+	Run: runDiff,
 }
 
 var (
-	diffStat   bool
-	diffSchema bool
+	diffStat    bool
+	diffSchema  bool
+	diffPatch   string
+	diffVectors bool
 )
 
+// vectorDiffTopDimensions is how many of the most-changed dimensions
+// --vectors lists
+const vectorDiffTopDimensions = 5
+
 func init() {
 	diffCmd.Flags().BoolVar(&diffStat, "stat", false, "Show diffstat instead of full diff")
 	diffCmd.Flags().BoolVar(&diffSchema, "schema", false, "Show schema changes only")
+	diffCmd.Flags().StringVar(&diffPatch, "patch", "", "Write the changes to a patch file")
+	diffCmd.Flags().BoolVar(&diffVectors, "vectors", false, "Show per-dimension vector changes")
 }
 
 func runDiff(cmd *cobra.Command, args []string) {
@@ -33,36 +70,82 @@ func runDiff(cmd *cobra.Command, args []string) {
 	defer c.Close()
 
 	cfg, st, client := c.Config, c.Store, c.Client
-	green := color.New(color.FgGreen)
-	red := color.New(color.FgRed)
-	yellow := color.New(color.FgYellow)
-	magenta := color.New(color.FgMagenta)
+	green, red, yellow, magenta := colorAdded, colorDeleted, colorModified, colorSchema
+
+	// A leading <from>..<to> compares two commits instead of Weaviate
+	var commitRange string
+	if len(args) > 0 && strings.Contains(args[0], "..") {
+		commitRange, args = args[0], args[1:]
+	}
+	spec, err := core.ParsePathspec(args)
+	if err != nil {
+		exitError("%v", err)
+	}
+	if commitRange != "" && diffSchema {
+		exitError("--schema cannot be combined with a commit range")
+	}
+
+	if diffPatch != "" && (diffSchema || diffStat || jsonOutput()) {
+		exitError("--patch cannot be combined with --schema, --stat, or --output json")
+	}
 
 	if diffSchema {
-		schemaDiff, err := core.ComputeSchemaDiff(bgCtx, st, client)
+		schemaDiff, err := core.ComputeSchemaDiff(bgCtx, cfg, st, client)
 		if err != nil {
 			exitError("failed to compute schema diff: %v", err)
 		}
+		schemaDiff = spec.FilterSchemaDiff(schemaDiff)
 
+		if jsonOutput() {
+			printJSON(map[string]interface{}{"schema_changes": schemaDiffJSON(schemaDiff)})
+			return
+		}
 		if !schemaDiff.HasChanges() {
 			fmt.Println("No schema changes")
 			return
 		}
 
+		startPager()
+		defer stopPager()
 		displaySchemaDiff(schemaDiff, green, red, yellow, magenta)
 		return
 	}
 
-	diff, err := core.ComputeDiff(bgCtx, cfg, st, client)
-	if err != nil {
-		exitError("failed to compute diff: %v", err)
+	var diff *core.DiffResult
+	if commitRange != "" {
+		fromID, toID, err := core.ResolveCommitRange(st, commitRange)
+		if err != nil {
+			exitError("%v", err)
+		}
+		diff, err = core.DiffCommits(st, fromID, toID)
+		if err != nil {
+			exitError("failed to compute diff: %v", err)
+		}
+	} else {
+		diff, err = core.ComputeDiff(bgCtx, cfg, st, client)
+		if err != nil {
+			exitError("failed to compute diff: %v", err)
+		}
 	}
+	diff = spec.FilterDiff(diff)
 
+	if jsonOutput() {
+		printDiffJSON(st, diff)
+		return
+	}
 	if diff.TotalChanges() == 0 {
 		fmt.Println("No changes")
 		return
 	}
 
+	if diffPatch != "" {
+		writeDiffPatch(st, diff, diffPatch)
+		return
+	}
+
+	startPager()
+	defer stopPager()
+
 	if diffStat {
 		// Show summary only
 		if len(diff.Inserted) > 0 {
@@ -80,7 +163,11 @@ func runDiff(cmd *cobra.Command, args []string) {
 
 	// Show full diff
 	for _, change := range diff.Inserted {
-		green.Printf("+++ %s/%s\n", change.ClassName, change.ObjectID)
+		if change.MovedFrom != "" {
+			green.Printf("+++ %s/%s (moved from %s)\n", change.ClassName, change.ObjectID, change.MovedFrom)
+		} else {
+			green.Printf("+++ %s/%s\n", change.ClassName, change.ObjectID)
+		}
 		if change.CurrentData != nil {
 			data, _ := json.MarshalIndent(change.CurrentData.Properties, "    ", "  ")
 			green.Printf("    %s\n", string(data))
@@ -89,7 +176,11 @@ func runDiff(cmd *cobra.Command, args []string) {
 	}
 
 	for _, change := range diff.Deleted {
-		red.Printf("--- %s/%s\n", change.ClassName, change.ObjectID)
+		if change.MovedTo != "" {
+			red.Printf("--- %s/%s (moved to %s)\n", change.ClassName, change.ObjectID, change.MovedTo)
+		} else {
+			red.Printf("--- %s/%s\n", change.ClassName, change.ObjectID)
+		}
 		if change.PreviousData != nil {
 			data, _ := json.MarshalIndent(change.PreviousData.Properties, "    ", "  ")
 			red.Printf("    %s\n", string(data))
@@ -99,7 +190,14 @@ func runDiff(cmd *cobra.Command, args []string) {
 
 	for _, change := range diff.Updated {
 		yellow.Printf("~~~ %s/%s\n", change.ClassName, change.ObjectID)
-		if change.PreviousData != nil && change.CurrentData != nil {
+		vectorDiff, err := core.ComputeVectorDiff(st, change)
+		if err != nil {
+			exitError("failed to compare vectors of %s/%s: %v", change.ClassName, change.ObjectID, err)
+		}
+		if vectorDiff != nil {
+			displayVectorDiff(vectorDiff, diffVectors)
+		}
+		if change.PreviousData != nil && change.CurrentData != nil && !change.VectorOnly {
 			fmt.Println("  Before:")
 			prevData, _ := json.MarshalIndent(change.PreviousData.Properties, "    ", "  ")
 			red.Printf("    %s\n", string(prevData))
@@ -111,6 +209,119 @@ func runDiff(cmd *cobra.Command, args []string) {
 	}
 }
 
+// printDiffJSON prints a diff for --output json: the counts with --stat,
+// otherwise every change with its properties and vector distances
+func printDiffJSON(st *store.Store, diff *core.DiffResult) {
+	if diffStat {
+		printJSON(map[string]int{
+			"inserted": len(diff.Inserted),
+			"updated":  len(diff.Updated),
+			"deleted":  len(diff.Deleted),
+			"total":    diff.TotalChanges(),
+		})
+		return
+	}
+
+	changes := objectChangesJSON(diff, true)
+	updated := diff.Updated
+	for i := range changes {
+		if changes[i].Change != "updated" {
+			continue
+		}
+		change := updated[0]
+		updated = updated[1:]
+		vectorDiff, err := core.ComputeVectorDiff(st, change)
+		if err != nil {
+			exitError("failed to compare vectors of %s/%s: %v", change.ClassName, change.ObjectID, err)
+		}
+		if vectorDiff == nil {
+			continue
+		}
+		vector := &vectorChangeJSON{Dimensions: len(vectorDiff.Current), PreviousDimensions: len(vectorDiff.Previous)}
+		if vectorDiff.Comparable {
+			vector.L2, vector.Cosine = &vectorDiff.L2, &vectorDiff.Cosine
+		}
+		changes[i].Vector = vector
+	}
+	printJSON(map[string]interface{}{"changes": changes})
+}
+
+// displayVectorDiff shows the dimensions and distances of a vector change,
+// and with detail the per-dimension changes
+func displayVectorDiff(d *core.VectorDiff, detail bool) {
+	switch {
+	case len(d.Previous) == 0:
+		fmt.Printf("  Vector: added (%d dims)\n", len(d.Current))
+		return
+	case len(d.Current) == 0:
+		fmt.Printf("  Vector: removed (%d dims)\n", len(d.Previous))
+		return
+	case !d.Comparable:
+		fmt.Printf("  Vector: %d -> %d dims\n", len(d.Previous), len(d.Current))
+		return
+	}
+	fmt.Printf("  Vector: %d dims, L2 %.4f, cosine distance %.4f\n", len(d.Current), d.L2, d.Cosine)
+	if !detail {
+		return
+	}
+
+	width := terminalWidth() - 4
+	if width <= 0 || width > 80 {
+		width = 80
+	}
+	fmt.Printf("    %s\n", sparkline(d.Deltas(width)))
+	for _, c := range d.TopChanged(vectorDiffTopDimensions) {
+		fmt.Printf("    [%d] %.4f -> %.4f (%+.4f)\n", c.Index, c.Previous, c.Current, c.Delta())
+	}
+}
+
+// sparkline renders values as a row of block characters scaled to the largest
+func sparkline(values []float64) string {
+	const blocks = "▁▂▃▄▅▆▇█"
+	levels := []rune(blocks)
+	peak := 0.0
+	for _, v := range values {
+		peak = math.Max(peak, v)
+	}
+	var b strings.Builder
+	for _, v := range values {
+		level := 0
+		if peak > 0 {
+			level = int(v / peak * float64(len(levels)-1))
+		}
+		b.WriteRune(levels[level])
+	}
+	return b.String()
+}
+
+// writeDiffPatch writes diff to a patch file at path ("-" for stdout)
+func writeDiffPatch(st *store.Store, diff *core.DiffResult, path string) {
+	patch, err := core.NewPatch(st, diff)
+	if err != nil {
+		exitError("failed to create patch: %v", err)
+	}
+
+	if path == "-" {
+		if err := core.WritePatch(os.Stdout, patch); err != nil {
+			exitError("failed to write patch: %v", err)
+		}
+		return
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		exitError("failed to create %s: %v", path, err)
+	}
+	if err := core.WritePatch(f, patch); err != nil {
+		f.Close()
+		exitError("failed to write patch: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		exitError("failed to write patch: %v", err)
+	}
+	fmt.Printf("Wrote %d change(s) to %s\n", len(patch.Changes), path)
+}
+
 // displaySchemaDiff shows schema changes with +++ / --- / ~~~ formatting
 func displaySchemaDiff(diff *core.SchemaDiffResult, green, red, yellow, magenta *color.Color) {
 	// Added classes
@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// editorCommand returns the user's editor command line: $WVC_EDITOR, then
+// $VISUAL, then $EDITOR, then vi
+func editorCommand() []string {
+	for _, name := range []string{"WVC_EDITOR", "VISUAL", "EDITOR"} {
+		if args := strings.Fields(os.Getenv(name)); len(args) > 0 {
+			return args
+		}
+	}
+	return []string{"vi"}
+}
+
+// editFile opens path in the user's editor and waits for it to exit
+func editFile(path string) error {
+	args := append(editorCommand(), path)
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = terminalOut
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor '%s' failed: %w", args[0], err)
+	}
+	return nil
+}
@@ -9,7 +9,11 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var fetchDepth int
+var (
+	fetchDepth       int
+	fetchUnshallow   bool
+	fetchConcurrency int
+)
 
 var fetchCmd = &cobra.Command{
 	Use:   "fetch [<remote>] [<branch>]",
@@ -19,17 +23,26 @@ the local branch. Updates the remote-tracking branch only.
 
 Defaults to the only configured remote and the current branch.
 
+--depth fetches only the commits within that many of the remote tip. History
+before them is replaced by the state of the oldest commits fetched, so
+checkout and diff work without it. --unshallow fetches the history that
+earlier depth-limited fetches or clones left out.
+
 Examples:
   wvc fetch                         Fetch current branch from default remote
   wvc fetch origin                  Fetch current branch from 'origin'
   wvc fetch origin main             Fetch 'main' from 'origin'
-  wvc fetch --depth 5 origin main   Fetch only the last 5 commits`,
+  wvc fetch --depth 5 origin main   Fetch only the last 5 commits
+  wvc fetch --unshallow             Fetch the rest of a shallow history`,
 	Args: cobra.MaximumNArgs(2),
 	Run:  runFetch,
 }
 
 func init() {
 	fetchCmd.Flags().IntVar(&fetchDepth, "depth", 0, "Limit number of commits to fetch (0 = all)")
+	fetchCmd.Flags().BoolVar(&fetchUnshallow, "unshallow", false, "Fetch the history a shallow clone left out")
+	fetchCmd.Flags().IntVar(&fetchConcurrency, "concurrency", core.DefaultTransferConcurrency, "Number of vectors to download at once")
+	fetchCmd.MarkFlagsMutuallyExclusive("depth", "unshallow")
 }
 
 func runFetch(cmd *cobra.Command, args []string) {
@@ -54,9 +67,11 @@ func runFetch(cmd *cobra.Command, args []string) {
 	fmt.Printf("Fetching from %s (%s)...\n", remoteName, remoteInfo.URL)
 
 	result, err := core.Fetch(ctx, c.Store, client, core.FetchOptions{
-		RemoteName: remoteName,
-		Branch:     branch,
-		Depth:      fetchDepth,
+		RemoteName:  remoteName,
+		Branch:      branch,
+		Depth:       fetchDepth,
+		Unshallow:   fetchUnshallow,
+		Concurrency: fetchConcurrency,
 	}, func(phase string, current, total int) {
 		if total > 0 {
 			fmt.Printf("\r  %s %d/%d", phase, current, total)
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/kilupskalvis/wvc/internal/core"
+	"github.com/spf13/cobra"
+)
+
+var findCmd = &cobra.Command{
+	Use:   "find --prop <name>=<value>...",
+	Short: "Find objects by property value, optionally across history",
+	Long: `Find objects whose properties match every --prop predicate.
+
+By default the last known state is searched. --history searches the objects
+written by every commit reachable from HEAD and lists, for each match, the
+commits that wrote a matching version. --range bounds the search to
+<from>..<to>: commits reachable from <to> but not from <from>, where either
+side may be omitted and <to> defaults to HEAD. Objects that still match at
+the end of the searched history are marked (current).
+
+Values are compared exactly: strings as they are, numbers and booleans in
+their JSON form. An array property matches when any element equals the value.
+
+Examples:
+  wvc find --class Article --prop title="Breaking News"
+  wvc find --class Article --prop title="Breaking News" --history
+  wvc find --prop author=alice --prop published=true --range release..main`,
+	Args: cobra.NoArgs,
+	Run:  runFind,
+}
+
+var (
+	findClass   string
+	findProps   []string
+	findHistory bool
+	findRange   string
+)
+
+func init() {
+	findCmd.Flags().StringVar(&findClass, "class", "", "Only search objects of this class")
+	findCmd.Flags().StringArrayVar(&findProps, "prop", nil, "Property predicate name=value (required); repeat to match all")
+	findCmd.Flags().BoolVar(&findHistory, "history", false, "Search all commits reachable from HEAD")
+	findCmd.Flags().StringVar(&findRange, "range", "", "Search commits in <from>..<to> (implies --history)")
+	findCmd.MarkFlagRequired("prop")
+}
+
+func runFind(cmd *cobra.Command, args []string) {
+	c := initContextWithMigrations()
+	defer c.Close()
+
+	opts := core.FindOptions{ClassName: findClass, History: findHistory, Range: findRange}
+	for _, prop := range findProps {
+		p, err := core.ParsePropertyPredicate(prop)
+		if err != nil {
+			exitError("%v", err)
+		}
+		opts.Predicates = append(opts.Predicates, p)
+	}
+
+	matches, err := core.FindObjects(c.Store, opts)
+	if err != nil {
+		exitError("%v", err)
+	}
+	if len(matches) == 0 {
+		fmt.Println("No matching objects")
+		return
+	}
+
+	startPager()
+	defer stopPager()
+
+	history := findHistory || findRange != ""
+	for _, m := range matches {
+		fmt.Printf("%s/%s", m.ClassName, m.ObjectID)
+		if history && m.Current {
+			colorCurrent.Print(" (current)")
+		}
+		fmt.Println()
+		for _, commit := range m.Commits {
+			colorCommit.Printf("    %s ", commit.ShortID())
+			colorMuted.Printf("%s ", commit.Timestamp.Format("2006-01-02 15:04"))
+			fmt.Println(firstLine(commit.Message))
+		}
+	}
+}
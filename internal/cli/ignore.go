@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kilupskalvis/wvc/internal/config"
+	"github.com/kilupskalvis/wvc/internal/core"
+	"github.com/spf13/cobra"
+)
+
+var ignoreCmd = &cobra.Command{
+	Use:   "ignore",
+	Short: "Manage rules for objects left out of status and commits",
+	Long: `Manage the repository's ignore rules, stored in .wvc/config.
+
+Objects matching a rule never appear in status or diff, are not staged, and
+are not committed; checkout leaves them in Weaviate. A rule is a class, a
+<class>/<id> pattern (globs allowed), and optional --where name=value
+property filters that must all match. A class rule without filters ignores
+the schema of its classes too.
+
+Examples:
+  wvc ignore add Cache
+  wvc ignore add 'Article/tmp-*'
+  wvc ignore add Article --where source=test
+  wvc ignore list
+  wvc ignore remove Cache`,
+}
+
+var ignoreAddCmd = &cobra.Command{
+	Use:   "add <pattern>",
+	Short: "Add an ignore rule",
+	Args:  cobra.ExactArgs(1),
+	Run:   runIgnoreAdd,
+}
+
+var ignoreListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the ignore rules",
+	Args:  cobra.NoArgs,
+	Run:   runIgnoreList,
+}
+
+var ignoreRemoveCmd = &cobra.Command{
+	Use:   "remove <pattern>",
+	Short: "Remove the ignore rules with a pattern",
+	Args:  cobra.ExactArgs(1),
+	Run:   runIgnoreRemove,
+}
+
+var ignoreWhere []string
+
+func init() {
+	ignoreAddCmd.Flags().StringArrayVar(&ignoreWhere, "where", nil, "only ignore objects whose property equals a value (name=value, repeatable)")
+	ignoreCmd.AddCommand(ignoreAddCmd)
+	ignoreCmd.AddCommand(ignoreListCmd)
+	ignoreCmd.AddCommand(ignoreRemoveCmd)
+}
+
+func runIgnoreAdd(cmd *cobra.Command, args []string) {
+	cfg, err := config.Load()
+	if err != nil {
+		exitError("%v", err)
+	}
+	if err := core.AddIgnoreRule(cfg, args[0], ignoreWhere); err != nil {
+		exitError("%v", err)
+	}
+	if err := cfg.Save(); err != nil {
+		exitError("%v", err)
+	}
+	fmt.Printf("Ignoring %s\n", formatIgnoreRule(cfg.Ignore[len(cfg.Ignore)-1]))
+}
+
+func runIgnoreList(cmd *cobra.Command, args []string) {
+	cfg, err := config.Load()
+	if err != nil {
+		exitError("%v", err)
+	}
+	for _, rule := range cfg.Ignore {
+		fmt.Println(formatIgnoreRule(rule))
+	}
+}
+
+func runIgnoreRemove(cmd *cobra.Command, args []string) {
+	cfg, err := config.Load()
+	if err != nil {
+		exitError("%v", err)
+	}
+	removed, err := core.RemoveIgnoreRule(cfg, args[0])
+	if err != nil {
+		exitError("%v", err)
+	}
+	if err := cfg.Save(); err != nil {
+		exitError("%v", err)
+	}
+	fmt.Printf("Removed %d ignore rule(s) for %s\n", removed, args[0])
+}
+
+func formatIgnoreRule(rule *config.IgnoreRule) string {
+	if len(rule.Where) == 0 {
+		return rule.Pattern
+	}
+	return rule.Pattern + " where " + strings.Join(rule.Where, " and ")
+}
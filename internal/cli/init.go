@@ -28,16 +28,68 @@ func init() {
 func runInit(cmd *cobra.Command, args []string) {
 	ctx := context.Background()
 
+	cfg, st, client := initRepository(ctx, initURL)
+	defer st.Close()
+
+	// Take initial snapshot of current state
+	fmt.Printf("Taking initial snapshot...\n")
+	useCursor := cfg.SupportsCursorPagination()
+	if err := core.UpdateKnownState(ctx, st, client, useCursor); err != nil {
+		exitError("failed to take initial snapshot: %v", err)
+	}
+
+	// Get object count for initial commit message
+	objects, _ := st.GetAllKnownObjects()
+	objectCount := len(objects)
+
+	// Create initial commit if there are objects
+	if objectCount > 0 {
+		fmt.Printf("Found %d existing objects\n", objectCount)
+	}
+
+	fmt.Printf("\nInitialized empty WVC repository in .wvc/\n")
+	fmt.Printf("Tracking Weaviate at %s\n", initURL)
+
+	if objectCount > 0 {
+		fmt.Printf("\nRun 'wvc commit -m \"Initial state\"' to create the first commit.\n")
+	}
+}
+
+// initRepository connects to Weaviate and creates the .wvc directory and
+// store in the current directory, with HEAD on an unborn "main" branch
+func initRepository(ctx context.Context, weaviateURL string) (*config.Config, *store.Store, weaviate.ClientInterface) {
+	cfg, client := initConfig(ctx, weaviateURL)
+
+	// Initialize store
+	st, err := store.New(cfg.DatabasePath())
+	if err != nil {
+		exitError("failed to create store: %v", err)
+	}
+
+	if err := st.Initialize(); err != nil {
+		st.Close()
+		exitError("failed to initialize store: %v", err)
+	}
+
+	// Set up initial branch state — point HEAD at "main" like git init does
+	_ = st.SetCurrentBranch("main")
+
+	return cfg, st, client
+}
+
+// initConfig connects to Weaviate and creates the .wvc directory and its
+// config in the current directory, without a store
+func initConfig(ctx context.Context, weaviateURL string) (*config.Config, weaviate.ClientInterface) {
 	// Check if already initialized
 	if _, err := config.FindWVCRoot(); err == nil {
 		exitError("wvc repository already exists")
 	}
 
 	fmt.Printf("Initializing WVC repository...\n")
-	fmt.Printf("Weaviate URL: %s\n", initURL)
+	fmt.Printf("Weaviate URL: %s\n", weaviateURL)
 
 	// Test connection to Weaviate
-	client, err := weaviate.NewClient(initURL)
+	client, err := newWeaviateClient(weaviateURL, nil)
 	if err != nil {
 		exitError("failed to create Weaviate client: %v", err)
 	}
@@ -62,7 +114,7 @@ func runInit(cmd *cobra.Command, args []string) {
 	}
 
 	// Initialize config
-	cfg, err := config.Initialize(initURL)
+	cfg, err := config.Initialize(weaviateURL)
 	if err != nil {
 		exitError("failed to initialize config: %v", err)
 	}
@@ -75,40 +127,5 @@ func runInit(cmd *cobra.Command, args []string) {
 		}
 	}
 
-	// Initialize store
-	st, err := store.New(cfg.DatabasePath())
-	if err != nil {
-		exitError("failed to create store: %v", err)
-	}
-	defer st.Close()
-
-	if err := st.Initialize(); err != nil {
-		exitError("failed to initialize store: %v", err)
-	}
-
-	// Set up initial branch state — point HEAD at "main" like git init does
-	_ = st.SetCurrentBranch("main")
-
-	// Take initial snapshot of current state
-	fmt.Printf("Taking initial snapshot...\n")
-	useCursor := cfg.SupportsCursorPagination()
-	if err := core.UpdateKnownState(ctx, st, client, useCursor); err != nil {
-		exitError("failed to take initial snapshot: %v", err)
-	}
-
-	// Get object count for initial commit message
-	objects, _ := st.GetAllKnownObjects()
-	objectCount := len(objects)
-
-	// Create initial commit if there are objects
-	if objectCount > 0 {
-		fmt.Printf("Found %d existing objects\n", objectCount)
-	}
-
-	fmt.Printf("\nInitialized empty WVC repository in .wvc/\n")
-	fmt.Printf("Tracking Weaviate at %s\n", initURL)
-
-	if objectCount > 0 {
-		fmt.Printf("\nRun 'wvc commit -m \"Initial state\"' to create the first commit.\n")
-	}
+	return cfg, client
 }
@@ -0,0 +1,377 @@
+package cli
+
+import (
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/core"
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/remote"
+)
+
+// The types below are the documents commands write with --output json.
+// Field names are snake_case like the rest of wvc's JSON, and only grow:
+// scripts may rely on them.
+
+// objectChangeJSON is one changed object
+type objectChangeJSON struct {
+	Change     string `json:"change"` // "inserted", "updated", or "deleted"
+	Class      string `json:"class"`
+	ID         string `json:"id"`
+	VectorOnly bool   `json:"vector_only,omitempty"`
+	MovedFrom  string `json:"moved_from,omitempty"`
+	MovedTo    string `json:"moved_to,omitempty"`
+	// Properties and PreviousProperties are set by diff
+	Properties         map[string]interface{} `json:"properties,omitempty"`
+	PreviousProperties map[string]interface{} `json:"previous_properties,omitempty"`
+	Vector             *vectorChangeJSON      `json:"vector,omitempty"`
+}
+
+// vectorChangeJSON describes how the vector of an updated object changed
+type vectorChangeJSON struct {
+	Dimensions         int      `json:"dimensions"`
+	PreviousDimensions int      `json:"previous_dimensions"`
+	L2                 *float64 `json:"l2,omitempty"`
+	Cosine             *float64 `json:"cosine_distance,omitempty"`
+}
+
+// objectChangesJSON lists the changes of a diff; with data, the properties
+// before and after each change are included
+func objectChangesJSON(diff *core.DiffResult, data bool) []objectChangeJSON {
+	changes := []objectChangeJSON{}
+	add := func(kind string, list []*core.ObjectChange) {
+		for _, c := range list {
+			change := objectChangeJSON{
+				Change:     kind,
+				Class:      c.ClassName,
+				ID:         c.ObjectID,
+				VectorOnly: c.VectorOnly,
+				MovedFrom:  c.MovedFrom,
+				MovedTo:    c.MovedTo,
+			}
+			if data && c.CurrentData != nil {
+				change.Properties = c.CurrentData.Properties
+			}
+			if data && c.PreviousData != nil {
+				change.PreviousProperties = c.PreviousData.Properties
+			}
+			changes = append(changes, change)
+		}
+	}
+	add("inserted", diff.Inserted)
+	add("updated", diff.Updated)
+	add("deleted", diff.Deleted)
+	return changes
+}
+
+// schemaChangeJSON is one schema change
+type schemaChangeJSON struct {
+	// Change is "class_added", "class_deleted", "property_added",
+	// "property_deleted", "property_modified", or "vectorizer_changed"
+	Change   string `json:"change"`
+	Class    string `json:"class"`
+	Property string `json:"property,omitempty"`
+	// Previous and Current are the definitions before and after the change,
+	// set by diff --schema
+	Previous interface{} `json:"previous,omitempty"`
+	Current  interface{} `json:"current,omitempty"`
+}
+
+// schemaChangesJSON lists the changes of a schema diff
+func schemaChangesJSON(diff *core.SchemaDiffResult) []schemaChangeJSON {
+	changes := schemaDiffJSON(diff)
+	for i := range changes {
+		changes[i].Previous, changes[i].Current = nil, nil
+	}
+	return changes
+}
+
+// schemaDiffJSON lists the changes of a schema diff with their definitions
+func schemaDiffJSON(diff *core.SchemaDiffResult) []schemaChangeJSON {
+	changes := []schemaChangeJSON{}
+	add := func(kind string, list []*models.SchemaChange) {
+		for _, c := range list {
+			changes = append(changes, schemaChangeJSON{Change: kind, Class: c.ClassName, Property: c.PropertyName, Previous: c.PreviousValue, Current: c.CurrentValue})
+		}
+	}
+	add("class_added", diff.ClassesAdded)
+	add("class_deleted", diff.ClassesDeleted)
+	add("property_added", diff.PropertiesAdded)
+	add("property_deleted", diff.PropertiesDeleted)
+	add("property_modified", diff.PropertiesModified)
+	add("vectorizer_changed", diff.VectorizersChanged)
+	return changes
+}
+
+// statusJSON is the document of wvc status
+type statusJSON struct {
+	Branch        string             `json:"branch,omitempty"`
+	Head          string             `json:"head,omitempty"`
+	Detached      bool               `json:"detached"`
+	Classes       []string           `json:"classes,omitempty"`
+	SparseClasses []string           `json:"sparse_classes,omitempty"`
+	FailedWrites  int                `json:"failed_writes,omitempty"`
+	Bisecting     bool               `json:"bisecting,omitempty"`
+	Merge         *mergeStateJSON    `json:"merge,omitempty"`
+	Clean         bool               `json:"clean"`
+	SchemaChanges []schemaChangeJSON `json:"schema_changes"`
+	Staged        []objectChangeJSON `json:"staged"`
+	Unstaged      []objectChangeJSON `json:"unstaged"`
+	Submodules    []submoduleJSON    `json:"submodules,omitempty"`
+}
+
+// mergeStateJSON is a merge in progress
+type mergeStateJSON struct {
+	Branch     string   `json:"branch"`
+	TheirHead  string   `json:"their_head"`
+	Conflicts  int      `json:"conflicts"`
+	Unresolved []string `json:"unresolved"`
+}
+
+// submoduleJSON is a submodule whose pin changed since the last commit
+type submoduleJSON struct {
+	Name      string `json:"name"`
+	Committed string `json:"committed,omitempty"`
+	Pinned    string `json:"pinned,omitempty"`
+}
+
+// logEntryJSON is one commit of wvc log
+type logEntryJSON struct {
+	*models.Commit
+	Head         bool   `json:"head,omitempty"`
+	SchemaChange bool   `json:"schema_change,omitempty"`
+	Note         string `json:"note,omitempty"`
+}
+
+// branchJSON is one branch of wvc branch
+type branchJSON struct {
+	Name     string   `json:"name"`
+	CommitID string   `json:"commit_id"`
+	Current  bool     `json:"current,omitempty"`
+	Classes  []string `json:"classes,omitempty"`
+	Subject  string   `json:"subject,omitempty"`
+}
+
+// remoteBranchJSON is one remote-tracking branch of wvc branch --remotes
+type remoteBranchJSON struct {
+	Remote   string `json:"remote"`
+	Branch   string `json:"branch"`
+	CommitID string `json:"commit_id"`
+	Subject  string `json:"subject,omitempty"`
+	// Base, Ahead, and Behind compare the branch with the remote's default
+	// branch; they are set with --verbose when the remote answered
+	Base   string `json:"base,omitempty"`
+	Ahead  *int   `json:"ahead,omitempty"`
+	Behind *int   `json:"behind,omitempty"`
+}
+
+// stashJSON is one stash of wvc stash list and fetch
+type stashJSON struct {
+	Index     int       `json:"index"`
+	Branch    string    `json:"branch,omitempty"`
+	CommitID  string    `json:"commit_id,omitempty"`
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"created_at,omitzero"`
+}
+
+func stashEntryJSON(e core.StashListEntry) stashJSON {
+	return stashJSON{Index: e.Index, Branch: e.BranchName, CommitID: e.CommitID, Message: e.Message, CreatedAt: e.CreatedAt}
+}
+
+// stashResultJSON is the outcome of stash push, pop, and apply
+type stashResultJSON struct {
+	Index    int      `json:"index"`
+	Message  string   `json:"message"`
+	Staged   int      `json:"staged"`
+	Unstaged int      `json:"unstaged"`
+	Dropped  bool     `json:"dropped,omitempty"`
+	Warnings []string `json:"warnings"`
+	// Remote and RemoteID are set when push uploaded the stash
+	Remote   string `json:"remote,omitempty"`
+	RemoteID string `json:"remote_id,omitempty"`
+}
+
+// stashShowJSON is the document of wvc stash show
+type stashShowJSON struct {
+	stashJSON
+	Staged   []stashChangeJSON `json:"staged"`
+	Unstaged []stashChangeJSON `json:"unstaged"`
+}
+
+// stashChangeJSON is one change held by a stash
+type stashChangeJSON struct {
+	Change    string `json:"change"` // "insert", "update", or "delete"
+	Class     string `json:"class"`
+	ID        string `json:"id"`
+	MovedFrom string `json:"moved_from,omitempty"`
+	MovedTo   string `json:"moved_to,omitempty"`
+}
+
+func stashChangesJSON(list []*models.StashChange) []stashChangeJSON {
+	changes := []stashChangeJSON{}
+	for _, sc := range list {
+		changes = append(changes, stashChangeJSON{Change: sc.ChangeType, Class: sc.ClassName, ID: sc.ObjectID, MovedFrom: sc.MovedFrom, MovedTo: sc.MovedTo})
+	}
+	return changes
+}
+
+// pushResultJSON is the document of wvc push
+type pushResultJSON struct {
+	Remote            string   `json:"remote"`
+	Branch            string   `json:"branch"`
+	RemoteRef         string   `json:"remote_ref,omitempty"`
+	UpToDate          bool     `json:"up_to_date"`
+	BranchCreated     bool     `json:"branch_created"`
+	CommitsPushed     int      `json:"commits_pushed"`
+	VectorsPushed     int      `json:"vectors_pushed"`
+	Forced            bool     `json:"forced,omitempty"`
+	RestrictedClasses []string `json:"restricted_classes,omitempty"`
+}
+
+// pullResultJSON is the document of wvc pull
+type pullResultJSON struct {
+	Remote         string            `json:"remote"`
+	Branch         string            `json:"branch"`
+	UpToDate       bool              `json:"up_to_date"`
+	CommitsFetched int               `json:"commits_fetched"`
+	VectorsFetched int               `json:"vectors_fetched"`
+	LocalTip       string            `json:"local_tip,omitempty"`
+	RemoteTip      string            `json:"remote_tip,omitempty"`
+	FastForward    bool              `json:"fast_forward"`
+	Diverged       bool              `json:"diverged"`
+	ObjectsAdded   int               `json:"objects_added"`
+	ObjectsUpdated int               `json:"objects_updated"`
+	ObjectsRemoved int               `json:"objects_removed"`
+	Merge          *mergeResultJSON  `json:"merge,omitempty"`
+	Rebase         *rebaseResultJSON `json:"rebase,omitempty"`
+	Warnings       []string          `json:"warnings"`
+}
+
+func pullJSON(result *core.PullResult, remoteName, branch string) *pullResultJSON {
+	doc := &pullResultJSON{
+		Remote:         remoteName,
+		Branch:         branch,
+		UpToDate:       result.UpToDate,
+		CommitsFetched: result.CommitsFetched,
+		VectorsFetched: result.VectorsFetched,
+		LocalTip:       result.LocalTip,
+		RemoteTip:      result.RemoteTip,
+		FastForward:    result.FastForward,
+		Diverged:       result.Diverged,
+		ObjectsAdded:   result.ObjectsAdded,
+		ObjectsUpdated: result.ObjectsUpdated,
+		ObjectsRemoved: result.ObjectsRemoved,
+		Warnings:       warningsJSON(result.Warnings),
+	}
+	if result.Merge != nil {
+		doc.Merge = mergeJSON(result.Merge)
+	}
+	if result.Rebase != nil {
+		doc.Rebase = rebaseJSON(result.Rebase)
+	}
+	return doc
+}
+
+// warningsJSON lists the messages of checkout warnings
+func warningsJSON(warnings []core.CheckoutWarning) []string {
+	messages := []string{}
+	for _, w := range warnings {
+		messages = append(messages, w.Message)
+	}
+	return messages
+}
+
+// mergeResultJSON is the outcome of the merge of a pull
+type mergeResultJSON struct {
+	Success           bool                    `json:"success"`
+	FastForward       bool                    `json:"fast_forward"`
+	CommitID          string                  `json:"commit_id,omitempty"`
+	ResolvedConflicts int                     `json:"resolved_conflicts,omitempty"`
+	ObjectsAdded      int                     `json:"objects_added"`
+	ObjectsUpdated    int                     `json:"objects_updated"`
+	ObjectsDeleted    int                     `json:"objects_deleted"`
+	Conflicts         []*models.MergeConflict `json:"conflicts,omitempty"`
+	Warnings          []string                `json:"warnings,omitempty"`
+}
+
+func mergeJSON(result *models.MergeResult) *mergeResultJSON {
+	m := &mergeResultJSON{
+		Success:           result.Success,
+		FastForward:       result.FastForward,
+		ResolvedConflicts: result.ResolvedConflicts,
+		ObjectsAdded:      result.ObjectsAdded,
+		ObjectsUpdated:    result.ObjectsUpdated,
+		ObjectsDeleted:    result.ObjectsDeleted,
+		Conflicts:         result.Conflicts,
+		Warnings:          result.Warnings,
+	}
+	if result.MergeCommit != nil {
+		m.CommitID = result.MergeCommit.ID
+	}
+	return m
+}
+
+// rebaseResultJSON is the outcome of the rebase of a pull
+type rebaseResultJSON struct {
+	Onto              string                  `json:"onto"`
+	Commits           []*models.Commit        `json:"commits"`
+	Skipped           int                     `json:"skipped,omitempty"`
+	ResolvedConflicts int                     `json:"resolved_conflicts,omitempty"`
+	StoppedAt         string                  `json:"stopped_at,omitempty"`
+	Conflicts         []*models.MergeConflict `json:"conflicts,omitempty"`
+	Warnings          []string                `json:"warnings,omitempty"`
+}
+
+func rebaseJSON(result *core.RebaseResult) *rebaseResultJSON {
+	commits := result.Commits
+	if commits == nil {
+		commits = []*models.Commit{}
+	}
+	return &rebaseResultJSON{
+		Onto:              result.Onto,
+		Commits:           commits,
+		Skipped:           result.Skipped,
+		ResolvedConflicts: result.ResolvedConflicts,
+		StoppedAt:         result.StoppedAt,
+		Conflicts:         result.Conflicts,
+		Warnings:          warningsJSON(result.Warnings),
+	}
+}
+
+// remoteShowJSON is the document of wvc remote show
+type remoteShowJSON struct {
+	*models.Remote
+	Info     *remote.RepoInfo `json:"info"`
+	Refs     []remoteRefJSON  `json:"refs"`
+	UserRefs []*models.Branch `json:"user_refs,omitempty"`
+}
+
+// remoteRefJSON is a branch on a remote and the state of its
+// remote-tracking branch
+type remoteRefJSON struct {
+	Branch    string    `json:"branch"`
+	State     string    `json:"state"` // "up_to_date", "stale", "new", or "gone"
+	LocalTip  string    `json:"local_tip,omitempty"`
+	RemoteTip string    `json:"remote_tip,omitempty"`
+	FetchedAt time.Time `json:"fetched_at,omitzero"`
+}
+
+var remoteRefStates = map[core.RemoteRefState]string{
+	core.RemoteRefUpToDate: "up_to_date",
+	core.RemoteRefStale:    "stale",
+	core.RemoteRefNew:      "new",
+	core.RemoteRefGone:     "gone",
+}
+
+func remoteShowDocument(result *core.ShowRemoteResult) *remoteShowJSON {
+	doc := &remoteShowJSON{Remote: result.Remote, Info: result.Info, Refs: []remoteRefJSON{}, UserRefs: result.UserRefs}
+	for _, ref := range result.Refs {
+		doc.Refs = append(doc.Refs, remoteRefJSON{
+			Branch:    ref.Branch,
+			State:     remoteRefStates[ref.State],
+			LocalTip:  ref.LocalTip,
+			RemoteTip: ref.RemoteTip,
+			FetchedAt: ref.FetchedAt,
+		})
+	}
+	return doc
+}
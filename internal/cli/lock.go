@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/kilupskalvis/wvc/internal/core"
+	"github.com/spf13/cobra"
+)
+
+var lockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Ask other writers to pause while Weaviate is snapshotted",
+	Long: `Coordinate with other writers to the Weaviate instance so commits and
+checkouts don't capture or leave a half-written state.
+
+wvc does not block writes itself. The [lock] table of .wvc/config names the
+commands that ask writers to pause and resume, e.g. by setting a flag your
+ingest jobs check:
+
+  [lock]
+  acquire = "./scripts/pause-ingest.sh"
+  release = "./scripts/resume-ingest.sh"
+  hold_during = ["commit", "checkout"]
+
+Commands are split on whitespace and run without a shell, with
+WVC_LOCK_HOLDER and WVC_LOCK_REASON set. A non-zero exit from the acquire
+command fails the acquire. Commands listed in hold_during take the lock for
+as long as they run, unless it is already held.
+
+Examples:
+  wvc lock acquire --reason "nightly snapshot"
+  wvc lock status
+  wvc lock release`,
+}
+
+var lockAcquireCmd = &cobra.Command{
+	Use:   "acquire",
+	Short: "Run the acquire command and hold the write lock",
+	Args:  cobra.NoArgs,
+	Run:   runLockAcquire,
+}
+
+var lockReleaseCmd = &cobra.Command{
+	Use:   "release",
+	Short: "Run the release command and drop the write lock",
+	Args:  cobra.NoArgs,
+	Run:   runLockRelease,
+}
+
+var lockStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether the write lock is held",
+	Args:  cobra.NoArgs,
+	Run:   runLockStatus,
+}
+
+var (
+	lockReason       string
+	lockReleaseForce bool
+)
+
+func init() {
+	lockAcquireCmd.Flags().StringVar(&lockReason, "reason", "", "Why writers are asked to pause, passed to the command")
+	lockReleaseCmd.Flags().BoolVar(&lockReleaseForce, "force", false, "Drop the lock even if the release command fails")
+	lockCmd.AddCommand(lockAcquireCmd)
+	lockCmd.AddCommand(lockReleaseCmd)
+	lockCmd.AddCommand(lockStatusCmd)
+}
+
+func runLockAcquire(cmd *cobra.Command, args []string) {
+	c := initContextWithMigrations()
+	defer c.Close()
+
+	lock, err := core.AcquireLock(context.Background(), c.Config, c.Store, lockReason)
+	if err != nil {
+		exitError("%v", err)
+	}
+	color.New(color.FgGreen).Printf("Write lock acquired at %s\n", lock.AcquiredAt.Format(time.RFC3339))
+}
+
+func runLockRelease(cmd *cobra.Command, args []string) {
+	c := initContextWithMigrations()
+	defer c.Close()
+
+	if err := core.ReleaseLock(context.Background(), c.Config, c.Store, lockReleaseForce); err != nil {
+		exitError("%v", err)
+	}
+	color.New(color.FgGreen).Println("Write lock released")
+}
+
+func runLockStatus(cmd *cobra.Command, args []string) {
+	c := initContextWithMigrations()
+	defer c.Close()
+
+	lock, err := c.Store.GetWriteLock()
+	if err != nil {
+		exitError("%v", err)
+	}
+	if lock == nil {
+		fmt.Println("Write lock is not held")
+		return
+	}
+	fmt.Printf("Write lock held since %s\n", lock.AcquiredAt.Format(time.RFC3339))
+	if lock.Holder != "" {
+		fmt.Printf("  holder: %s\n", lock.Holder)
+	}
+	if lock.Reason != "" {
+		fmt.Printf("  reason: %s\n", lock.Reason)
+	}
+}
+
+// heldLockRelease releases the write lock taken by holdWriteLock, if any.
+var heldLockRelease func() error
+
+// holdWriteLock takes the write lock for the rest of the command when the
+// command is listed in hold_during. It is released by releaseWriteLock,
+// which exitError also calls.
+func holdWriteLock(c *cmdContext, command string) {
+	release, err := core.HoldLock(context.Background(), c.Config, c.Store, command)
+	if err != nil {
+		exitError("%v", err)
+	}
+	heldLockRelease = release
+}
+
+// releaseWriteLock releases a lock taken by holdWriteLock. It is safe to call
+// more than once.
+func releaseWriteLock() {
+	release := heldLockRelease
+	heldLockRelease = nil
+	if release == nil {
+		return
+	}
+	if err := release(); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+}
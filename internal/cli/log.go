@@ -1,91 +1,259 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"os"
 
-	"github.com/fatih/color"
+	"github.com/kilupskalvis/wvc/internal/core"
+	"github.com/kilupskalvis/wvc/internal/models"
 	"github.com/spf13/cobra"
 )
 
 var logCmd = &cobra.Command{
-	Use:   "log",
+	Use:   "log [<pathspec>...]",
 	Short: "Show commit history",
-	Long:  `Display the commit history of the repository.`,
-	Run:   runLog,
+	Long: `Display the commit history of the repository.
+
+Pathspecs limit the history to commits that touched matching objects.
+
+With --remote, the history of a remote branch (its default branch unless
+--branch is given) is read from the server page by page, without
+downloading commit bundles.
+
+With --format dot or --format mermaid, the commit graph is written instead,
+for rendering with Graphviz or in Markdown: one node per commit, labelled
+with its branches and tags and its object changes, and an edge to each
+parent, dashed for merge parents. Dot nodes also carry the stats as
+attributes (added, updated, deleted, operations, author, timestamp).
+
+Examples:
+  wvc log                      Show all commits
+  wvc log --oneline Article/   Show commits that changed Article objects
+  wvc log --follow News/obj-1  Show an object's history, including before it was moved
+  wvc log --object Article/obj-001
+                               Show the commits that touched one object, from the object index
+  wvc log --remote origin -n 20
+                               Show the latest 20 commits of origin's default branch
+  wvc log --format dot | dot -Tsvg > history.svg
+                               Render the commit graph with Graphviz`,
+	Run: runLog,
 }
 
 var (
 	logOneline bool
 	logLimit   int
+	logFollow  bool
+	logRemote  string
+	logBranch  string
+	logFormat  string
+	logObject  string
 )
 
 func init() {
 	logCmd.Flags().BoolVar(&logOneline, "oneline", false, "Show each commit on a single line")
 	logCmd.Flags().IntVarP(&logLimit, "n", "n", 0, "Limit the number of commits to show")
+	logCmd.Flags().BoolVar(&logFollow, "follow", false, "Follow a single object's history across moves between classes")
+	logCmd.Flags().StringVar(&logRemote, "remote", "", "Show the history of a branch on this remote")
+	logCmd.Flags().StringVar(&logBranch, "branch", "", "Remote branch to show (with --remote; default: the remote's default branch)")
+	logCmd.Flags().StringVar(&logObject, "object", "", "Show only commits that touched this <class>/<id>, found through the object index")
+	logCmd.Flags().StringVar(&logFormat, "format", "", "Write the commit graph instead: dot or mermaid")
 }
 
 func runLog(cmd *cobra.Command, args []string) {
-	c := initContext()
+	if logFormat != "" && (logRemote != "" || logOneline || jsonOutput()) {
+		exitError("--format cannot be combined with --remote, --oneline, or --output json")
+	}
+	if logRemote != "" {
+		runRemoteLog(args)
+		return
+	}
+	if logBranch != "" {
+		exitError("--branch requires --remote")
+	}
+	// The object index is built by a migration in older repositories
+	c := initContextWithMigrations()
 	defer c.Close()
 
 	st := c.Store
-	commits, err := st.GetCommitLog(logLimit)
+	spec, err := core.ParsePathspec(args)
+	if err != nil {
+		exitError("%v", err)
+	}
+
+	if logObject != "" && (logFollow || !spec.IsEmpty()) {
+		exitError("--object cannot be combined with pathspecs or --follow")
+	}
+
+	// With a pathspec or object the limit applies after filtering
+	limit := logLimit
+	if !spec.IsEmpty() || logObject != "" {
+		limit = 0
+	}
+	commits, err := st.GetCommitLog(limit)
 	if err != nil {
 		exitError("failed to get commit log: %v", err)
 	}
+	if logObject != "" {
+		commits, err = core.ObjectLog(st, commits, logObject)
+	} else if logFollow {
+		if len(args) != 1 {
+			exitError("--follow requires exactly one <class>/<id>")
+		}
+		commits, err = core.FollowObjectHistory(st, commits, args[0])
+	} else {
+		commits, err = core.FilterCommitsByPathspec(st, commits, spec)
+	}
+	if err != nil {
+		exitError("%v", err)
+	}
+	if logLimit > 0 && len(commits) > logLimit {
+		commits = commits[:logLimit]
+	}
 
-	if len(commits) == 0 {
-		fmt.Println("No commits yet")
+	if logFormat != "" {
+		graph, err := core.BuildCommitGraph(st, commits)
+		if err != nil {
+			exitError("%v", err)
+		}
+		if err := graph.Write(os.Stdout, logFormat); err != nil {
+			exitError("%v", err)
+		}
 		return
 	}
 
 	head, _ := st.GetHEAD()
-	yellow := color.New(color.FgYellow)
+	notes, err := core.NotesByCommit(st)
+	if err != nil {
+		exitError("%v", err)
+	}
 
-	magenta := color.New(color.FgMagenta)
-	gray := color.New(color.FgHiBlack)
+	if jsonOutput() {
+		entries := []logEntryJSON{}
+		for _, commit := range commits {
+			hasSchemaChange, _ := st.CommitHasSchemaChange(commit.ID)
+			entries = append(entries, logEntryJSON{Commit: commit, Head: commit.ID == head, SchemaChange: hasSchemaChange, Note: noteMessage(notes[commit.ID])})
+		}
+		printJSON(entries)
+		return
+	}
 
-	for _, commit := range commits {
-		isHead := commit.ID == head
+	if len(commits) == 0 {
+		fmt.Println("No commits yet")
+		return
+	}
+
+	startPager()
+	defer stopPager()
 
+	for _, commit := range commits {
 		// Check if commit has schema changes
 		hasSchemaChange, _ := st.CommitHasSchemaChange(commit.ID)
+		printLogEntry(commit, commit.ID == head, hasSchemaChange, notes[commit.ID])
+	}
+}
 
-		if logOneline {
-			if isHead {
-				yellow.Printf("%s ", commit.ShortID())
-				color.New(color.FgCyan).Print("(HEAD) ")
-			} else {
-				yellow.Printf("%s ", commit.ShortID())
-			}
-			if commit.IsMergeCommit() {
-				gray.Print("[merge] ")
-			}
-			if hasSchemaChange {
-				magenta.Print("[schema] ")
+// remoteLogPageSize is the number of commits requested per page by log --remote.
+const remoteLogPageSize = 100
+
+// runRemoteLog prints the history of a remote branch, fetching pages of
+// commit metadata until the limit or the root commit is reached.
+func runRemoteLog(args []string) {
+	if len(args) > 0 || logFollow || logObject != "" {
+		exitError("--remote cannot be combined with pathspecs, --follow, or --object")
+	}
+	c := initContext()
+	defer c.Close()
+
+	client, err := newRemoteClient(c.Store, logRemote)
+	if err != nil {
+		exitError("%v", err)
+	}
+	ctx := context.Background()
+
+	pageSize := remoteLogPageSize
+	if logLimit > 0 && logLimit < pageSize {
+		pageSize = logLimit
+	}
+	shown := 0
+	before := ""
+	entries := []logEntryJSON{}
+	for {
+		page, err := client.GetCommitLog(ctx, logBranch, before, pageSize)
+		if err != nil {
+			exitError("%v", err)
+		}
+		if shown == 0 && !jsonOutput() {
+			if len(page.Commits) == 0 {
+				fmt.Println("No commits yet")
+				return
 			}
-			fmt.Println(commit.Message)
-		} else {
-			if isHead {
-				yellow.Printf("commit %s ", commit.ID)
-				color.New(color.FgCyan).Print("(HEAD)")
-				if hasSchemaChange {
-					magenta.Print(" [schema]")
-				}
-				fmt.Println()
+			startPager()
+			defer stopPager()
+		}
+		for _, commit := range page.Commits {
+			if jsonOutput() {
+				entries = append(entries, logEntryJSON{Commit: commit})
 			} else {
-				yellow.Printf("commit %s", commit.ID)
-				if hasSchemaChange {
-					magenta.Print(" [schema]")
-				}
-				fmt.Println()
+				printLogEntry(commit, false, false, nil)
 			}
-			if commit.IsMergeCommit() {
-				gray.Printf("Merge:  %s %s\n", shortID(commit.ParentID), shortID(commit.MergeParentID))
+			shown++
+			if logLimit > 0 && shown >= logLimit {
+				break
 			}
-			fmt.Printf("Date:   %s\n", commit.Timestamp.Format("Mon Jan 2 15:04:05 2006"))
-			fmt.Printf("\n    %s\n", commit.Message)
-			fmt.Printf("    (%d operations)\n\n", commit.OperationCount)
 		}
+		if page.Next == "" || (logLimit > 0 && shown >= logLimit) {
+			break
+		}
+		before = page.Next
+	}
+	if jsonOutput() {
+		printJSON(entries)
+	}
+}
+
+// noteMessage returns the message of a note, or "" without one
+func noteMessage(note *models.Note) string {
+	if note == nil {
+		return ""
+	}
+	return note.Message
+}
+
+// printLogEntry prints one commit in the short or full log format; the full
+// format includes the commit's note, if any
+func printLogEntry(commit *models.Commit, isHead, hasSchemaChange bool, note *models.Note) {
+	if logOneline {
+		colorCommit.Printf("%s ", commit.ShortID())
+		if isHead {
+			colorRef.Print("(HEAD) ")
+		}
+		if commit.IsMergeCommit() {
+			colorMuted.Print("[merge] ")
+		}
+		if hasSchemaChange {
+			colorSchema.Print("[schema] ")
+		}
+		fmt.Println(commit.Message)
+		return
+	}
+
+	colorCommit.Printf("commit %s", commit.ID)
+	if isHead {
+		colorRef.Print(" (HEAD)")
+	}
+	if hasSchemaChange {
+		colorSchema.Print(" [schema]")
+	}
+	fmt.Println()
+	if commit.IsMergeCommit() {
+		colorMuted.Printf("Merge:  %s %s\n", shortID(commit.ParentID), shortID(commit.MergeParentID))
+	}
+	if commit.Author != "" {
+		fmt.Printf("Author: %s\n", commit.Author)
 	}
+	fmt.Printf("Date:   %s\n", commit.Timestamp.Format("Mon Jan 2 15:04:05 2006"))
+	fmt.Printf("\n    %s\n", commit.Message)
+	fmt.Printf("    (%d operations)\n\n", commit.OperationCount)
+	printNote(note)
 }
@@ -11,20 +11,26 @@ import (
 )
 
 var mergeCmd = &cobra.Command{
-	Use:   "merge <branch>",
+	Use:   "merge [<branch>]",
 	Short: "Merge a branch into the current branch",
 	Long: `Merge the specified branch into the current branch.
 
 If there are no conflicts, a merge commit will be created.
-If conflicts are detected, the merge will abort unless --ours or --theirs is specified.
+If conflicts are detected, the merge stops unless --ours or --theirs is specified.
+A stopped merge is remembered: resolve each conflict with --resolve, then
+finish it with --continue, or discard it with --abort.
 
 Examples:
   wvc merge feature           # Merge 'feature' into current branch
   wvc merge --no-ff main      # Force merge commit even if fast-forward possible
   wvc merge -m "msg" feature  # Use custom merge commit message
   wvc merge --ours feature    # On conflict, prefer our version
-  wvc merge --theirs feature  # On conflict, prefer their version`,
-	Args: cobra.ExactArgs(1),
+  wvc merge --theirs feature  # On conflict, prefer their version
+  wvc merge --resolve Article/<id> --theirs  # Keep their version of one object
+  wvc merge --continue        # Finish a merge once all conflicts are resolved
+  wvc merge --continue --ours # Resolve the remaining conflicts as ours and finish
+  wvc merge --abort           # Discard a merge that stopped on conflicts`,
+	Args: cobra.MaximumNArgs(1),
 	Run:  runMerge,
 }
 
@@ -33,6 +39,9 @@ var (
 	mergeMessage string
 	mergeOurs    bool
 	mergeTheirs  bool
+	mergeAbort   bool
+	mergeCont    bool
+	mergeResolve string
 )
 
 func init() {
@@ -40,6 +49,9 @@ func init() {
 	mergeCmd.Flags().StringVarP(&mergeMessage, "message", "m", "", "Custom merge commit message")
 	mergeCmd.Flags().BoolVar(&mergeOurs, "ours", false, "On conflict, prefer our version")
 	mergeCmd.Flags().BoolVar(&mergeTheirs, "theirs", false, "On conflict, prefer their version")
+	mergeCmd.Flags().BoolVar(&mergeAbort, "abort", false, "Abort the merge in progress")
+	mergeCmd.Flags().BoolVar(&mergeCont, "continue", false, "Complete the merge in progress")
+	mergeCmd.Flags().StringVar(&mergeResolve, "resolve", "", "Resolve one conflict of the merge in progress (use with --ours or --theirs)")
 }
 
 func runMerge(cmd *cobra.Command, args []string) {
@@ -47,21 +59,68 @@ func runMerge(cmd *cobra.Command, args []string) {
 	c := initFullContext()
 	defer c.Close()
 
-	targetBranch := args[0]
-
 	// Validate flags
 	if mergeOurs && mergeTheirs {
 		exitError("cannot use --ours and --theirs together")
 	}
+	modes := 0
+	for _, set := range []bool{mergeAbort, mergeCont, mergeResolve != ""} {
+		if set {
+			modes++
+		}
+	}
+	if modes > 1 {
+		exitError("--abort, --continue, and --resolve cannot be combined")
+	}
+	if modes == 1 && len(args) > 0 {
+		exitError("a branch cannot be given with --abort, --continue, or --resolve")
+	}
 
 	// Determine conflict strategy
-	strategy := models.ConflictAbort
+	strategy := models.ConflictManual
 	if mergeOurs {
 		strategy = models.ConflictOurs
 	} else if mergeTheirs {
 		strategy = models.ConflictTheirs
 	}
 
+	switch {
+	case mergeAbort:
+		state, err := core.MergeAbort(c.Store)
+		if err != nil {
+			exitError("%v", err)
+		}
+		fmt.Printf("Merge of '%s' aborted\n", state.TargetBranch)
+		return
+	case mergeResolve != "":
+		if strategy == models.ConflictManual {
+			exitError("--resolve requires --ours or --theirs")
+		}
+		conflict, err := core.MergeResolve(c.Store, mergeResolve, strategy)
+		if err != nil {
+			exitError("%v", err)
+		}
+		state, err := c.Store.GetMergeState()
+		if err != nil {
+			exitError("%v", err)
+		}
+		fmt.Printf("Resolved %s using '%s'; %d conflict(s) remaining\n", conflict.Key, strategy, len(state.Unresolved()))
+		return
+	case mergeCont:
+		result, err := core.MergeContinue(ctx, c.Config, c.Store, c.Client, strategy)
+		if err != nil {
+			exitError("%v", err)
+		}
+		printMergeResult(result, strategy)
+		warnMergedDeployments(c)
+		return
+	}
+
+	if len(args) == 0 {
+		exitError("branch name required")
+	}
+	targetBranch := args[0]
+
 	opts := models.MergeOptions{
 		NoFastForward: mergeNoFF,
 		Message:       mergeMessage,
@@ -73,16 +132,32 @@ func runMerge(cmd *cobra.Command, args []string) {
 		exitError("%v", err)
 	}
 
-	green := color.New(color.FgGreen)
-	yellow := color.New(color.FgYellow)
-	red := color.New(color.FgRed, color.Bold)
-
 	// Handle conflicts
 	if !result.Success {
-		printMergeConflicts(result, red)
-		exitError("Automatic merge failed; fix conflicts and then commit the result.")
+		printMergeConflicts(result, color.New(color.FgRed, color.Bold))
+		exitError("Automatic merge failed; inspect conflicts with 'wvc conflicts show', resolve them with 'wvc conflicts resolve', and then run 'wvc merge --continue'.")
 	}
 
+	printMergeResult(result, strategy)
+	warnMergedDeployments(c)
+}
+
+// warnMergedDeployments warns when the branch a merge just changed is tracked
+// by a recorded deployment.
+func warnMergedDeployments(c *cmdContext) {
+	branch, err := c.Store.GetCurrentBranch()
+	if err != nil || branch == "" {
+		return
+	}
+	warnTrackedDeployments(c.Store, branch)
+}
+
+// printMergeResult displays the outcome of a successful merge
+func printMergeResult(result *models.MergeResult, strategy models.ConflictStrategy) {
+	green := color.New(color.FgGreen)
+	yellow := color.New(color.FgYellow)
+	red := color.New(color.FgRed, color.Bold)
+
 	// Success output
 	if result.FastForward {
 		green.Println("Fast-forward")
@@ -95,7 +170,11 @@ func runMerge(cmd *cobra.Command, args []string) {
 
 	// Show resolved conflicts if any
 	if result.ResolvedConflicts > 0 {
-		yellow.Printf("Auto-resolved %d conflict(s) using '%s' strategy\n", result.ResolvedConflicts, strategy)
+		if strategy == models.ConflictManual {
+			yellow.Printf("Applied %d conflict resolution(s)\n", result.ResolvedConflicts)
+		} else {
+			yellow.Printf("Auto-resolved %d conflict(s) using '%s' strategy\n", result.ResolvedConflicts, strategy)
+		}
 	}
 
 	// Show statistics
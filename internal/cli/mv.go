@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/fatih/color"
+	"github.com/kilupskalvis/wvc/internal/core"
+	"github.com/spf13/cobra"
+)
+
+var mvCmd = &cobra.Command{
+	Use:   "mv <class>/<id> <class>[/<id>]",
+	Short: "Move an object to another class",
+	Long: `Move a committed object to another class, optionally giving it a new ID.
+
+The object is recreated under the destination and removed from the source,
+and the pair is staged as a move so history shows the object changed class
+rather than one object being deleted and an unrelated one added. Use
+"wvc log --follow <class>/<id>" to see the object's history across moves.
+
+Examples:
+  wvc mv Article/obj-1 NewsArticle/obj-1   Move an object to NewsArticle
+  wvc mv Article/obj-1 NewsArticle         Same, keeping the object ID`,
+	Args: cobra.ExactArgs(2),
+	Run:  runMv,
+}
+
+func runMv(cmd *cobra.Command, args []string) {
+	bgCtx := context.Background()
+	c := initFullContext()
+	defer c.Close()
+
+	result, err := core.MoveObject(bgCtx, c.Store, c.Client, args[0], args[1])
+	if err != nil {
+		exitError("failed to move: %v", err)
+	}
+
+	color.New(color.FgGreen).Printf("Moved %s/%s -> %s/%s\n", result.FromClass, result.FromID, result.ToClass, result.ToID)
+}
@@ -0,0 +1,264 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/kilupskalvis/wvc/internal/core"
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/spf13/cobra"
+)
+
+var notesCmd = &cobra.Command{
+	Use:   "notes",
+	Short: "Attach notes to commits",
+	Long: `Manage notes: messages attached to commits after they were made, such as a
+QA sign-off. Notes are not part of a commit's ID, so adding or changing one
+does not rewrite history. A commit has at most one note; wvc log and wvc show
+print it below the commit message.
+
+Notes are kept locally until pushed. wvc notes push uploads every note to
+the remote, and wvc notes pull downloads the remote's notes on commits in the
+local history.
+
+Examples:
+  wvc notes add -m "validated by QA"        Note HEAD
+  wvc notes add abc1234 -m "validated by QA"
+  wvc notes add -f v1.0 -m "rejected"       Replace the note on v1.0
+  wvc notes show abc1234                    Print a commit's note
+  wvc notes list                            List all notes
+  wvc notes remove abc1234                  Remove a note
+  wvc notes push                            Push notes to the remote
+  wvc notes pull                            Download the remote's notes`,
+}
+
+var notesAddCmd = &cobra.Command{
+	Use:   "add [<commit>] -m <message>",
+	Short: "Attach a note to a commit",
+	Long: `Attach a note to <commit>, HEAD by default. A commit that already has a
+note is only given another with --force.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runNotesAdd,
+}
+
+var notesShowCmd = &cobra.Command{
+	Use:   "show [<commit>]",
+	Short: "Print the note on a commit",
+	Args:  cobra.MaximumNArgs(1),
+	Run:   runNotesShow,
+}
+
+var notesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all notes",
+	Args:  cobra.NoArgs,
+	Run:   runNotesList,
+}
+
+var notesRemoveCmd = &cobra.Command{
+	Use:   "remove [<commit>]",
+	Short: "Remove the note on a commit",
+	Long: `Remove the note on <commit>, HEAD by default. With --push the note is
+removed on the remote as well.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runNotesRemove,
+}
+
+var notesPushCmd = &cobra.Command{
+	Use:   "push",
+	Short: "Push notes to the remote",
+	Long: `Upload every local note to the remote. The noted commits must already be
+pushed. A note the remote holds with another message is only replaced with
+--force.`,
+	Args: cobra.NoArgs,
+	Run:  runNotesPush,
+}
+
+var notesPullCmd = &cobra.Command{
+	Use:   "pull",
+	Short: "Download notes from the remote",
+	Long: `Download the remote's notes on commits in the local history. A commit
+with another note locally keeps it unless --force is given.`,
+	Args: cobra.NoArgs,
+	Run:  runNotesPull,
+}
+
+var (
+	notesMessage string
+	notesForce   bool
+	notesPush    bool
+	notesRemote  string
+)
+
+func init() {
+	notesAddCmd.Flags().StringVarP(&notesMessage, "message", "m", "", "Note message")
+	notesAddCmd.Flags().BoolVarP(&notesForce, "force", "f", false, "Replace an existing note")
+	notesAddCmd.MarkFlagRequired("message")
+	notesRemoveCmd.Flags().BoolVar(&notesPush, "push", false, "Remove the note on the remote too")
+	notesPushCmd.Flags().BoolVarP(&notesForce, "force", "f", false, "Replace notes the remote holds with another message")
+	notesPullCmd.Flags().BoolVarP(&notesForce, "force", "f", false, "Replace local notes with the remote's")
+	for _, cmd := range []*cobra.Command{notesRemoveCmd, notesPushCmd, notesPullCmd} {
+		cmd.Flags().StringVar(&notesRemote, "remote", "", "Remote to push to or pull from")
+	}
+
+	notesCmd.AddCommand(notesAddCmd)
+	notesCmd.AddCommand(notesShowCmd)
+	notesCmd.AddCommand(notesListCmd)
+	notesCmd.AddCommand(notesRemoveCmd)
+	notesCmd.AddCommand(notesPushCmd)
+	notesCmd.AddCommand(notesPullCmd)
+}
+
+func runNotesAdd(cmd *cobra.Command, args []string) {
+	c := initContextWithMigrations()
+	defer c.Close()
+
+	note, err := core.AddNote(c.Store, noteRef(args), notesMessage, c.Config.Author(), notesForce)
+	if err != nil {
+		exitError("%v", err)
+	}
+	fmt.Printf("Added note to %s\n", shortID(note.CommitID))
+}
+
+func runNotesShow(cmd *cobra.Command, args []string) {
+	c := initContextWithMigrations()
+	defer c.Close()
+
+	note, err := core.GetNote(c.Store, noteRef(args))
+	if err != nil {
+		exitError("%v", err)
+	}
+	if note == nil {
+		exitError("no note on %s", noteRef(args))
+	}
+	fmt.Println(note.Message)
+}
+
+func runNotesList(cmd *cobra.Command, args []string) {
+	c := initContextWithMigrations()
+	defer c.Close()
+
+	notes, err := c.Store.ListNotes()
+	if err != nil {
+		exitError("failed to list notes: %v", err)
+	}
+	if len(notes) == 0 {
+		fmt.Println("No notes")
+		return
+	}
+
+	startPager()
+	defer stopPager()
+
+	t := &table{}
+	for _, note := range notes {
+		t.addRow(cell(shortID(note.CommitID), colorCommit), cell(firstLine(note.Message), nil))
+	}
+	t.print()
+}
+
+func runNotesRemove(cmd *cobra.Command, args []string) {
+	c := initContextWithMigrations()
+	defer c.Close()
+
+	st := c.Store
+	commitID, err := core.RemoveNote(st, noteRef(args))
+	if err != nil && !notesPush {
+		exitError("%v", err)
+	}
+	if notesPush {
+		// The note may exist only on the remote
+		if commitID == "" {
+			if commitID, _, err = core.ResolveRef(st, noteRef(args)); err != nil {
+				exitError("%v", err)
+			}
+		}
+		remoteName := notesRemoteName(c)
+		if err := core.DeleteRemoteNote(context.Background(), resolveRemoteClientByName(st, remoteName), commitID); err != nil {
+			exitError("%v", err)
+		}
+		fmt.Printf("Removed note from %s locally and on %s\n", shortID(commitID), remoteName)
+		return
+	}
+	fmt.Printf("Removed note from %s\n", shortID(commitID))
+}
+
+func runNotesPush(cmd *cobra.Command, args []string) {
+	c := initContextWithMigrations()
+	defer c.Close()
+
+	remoteName := notesRemoteName(c)
+	pushed, err := core.PushNotes(context.Background(), c.Store, resolveRemoteClientByName(c.Store, remoteName), notesForce)
+	if err != nil {
+		exitError("%v", err)
+	}
+	if len(pushed) == 0 {
+		fmt.Println("No notes to push")
+		return
+	}
+	fmt.Printf("Pushed %d note(s) to %s\n", len(pushed), remoteName)
+}
+
+func runNotesPull(cmd *cobra.Command, args []string) {
+	c := initContextWithMigrations()
+	defer c.Close()
+
+	client := resolveRemoteClientByName(c.Store, notesRemoteName(c))
+	result, err := core.FetchNotes(context.Background(), c.Store, client, notesForce)
+	if err != nil {
+		exitError("%v", err)
+	}
+
+	yellow := color.New(color.FgYellow)
+	for _, note := range result.Fetched {
+		fmt.Printf("New note on %s\n", shortID(note.CommitID))
+	}
+	for _, note := range result.Diverged {
+		yellow.Printf("Skipped note on %s: another note exists locally (use --force to overwrite)\n", shortID(note.CommitID))
+	}
+	if len(result.MissingCommit) > 0 {
+		yellow.Printf("Skipped %d note(s) on commits not fetched yet: %s\n", len(result.MissingCommit), noteCommits(result.MissingCommit))
+	}
+	if len(result.Fetched)+len(result.Diverged)+len(result.MissingCommit) == 0 {
+		fmt.Println("Notes are up-to-date.")
+	}
+}
+
+// noteRef returns the commit argument of a notes subcommand, HEAD by default.
+func noteRef(args []string) string {
+	if len(args) > 0 {
+		return args[0]
+	}
+	return "HEAD"
+}
+
+// notesRemoteName resolves --remote, defaulting to the only configured remote.
+func notesRemoteName(c *cmdContext) string {
+	name, err := core.ResolveRemote(c.Store, notesRemote)
+	if err != nil {
+		exitError("%v", err)
+	}
+	return name
+}
+
+func noteCommits(notes []*models.Note) string {
+	ids := make([]string, len(notes))
+	for i, note := range notes {
+		ids[i] = shortID(note.CommitID)
+	}
+	return strings.Join(ids, ", ")
+}
+
+// printNote prints a commit's note below its message in log and show.
+func printNote(note *models.Note) {
+	if note == nil {
+		return
+	}
+	colorMuted.Println("Notes:")
+	for _, line := range strings.Split(note.Message, "\n") {
+		fmt.Printf("    %s\n", line)
+	}
+	fmt.Println()
+}
@@ -0,0 +1,259 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+	"golang.org/x/term"
+)
+
+// Output theme shared by all commands, so the same kind of information is
+// always rendered in the same color
+var (
+	colorAdded    = color.New(color.FgGreen)
+	colorModified = color.New(color.FgYellow)
+	colorDeleted  = color.New(color.FgRed)
+	colorSchema   = color.New(color.FgMagenta)
+	colorCommit   = color.New(color.FgYellow)
+	colorRef      = color.New(color.FgCyan)
+	colorCurrent  = color.New(color.FgGreen)
+	colorHint     = color.New(color.FgCyan)
+	colorMuted    = color.New(color.FgHiBlack)
+)
+
+var (
+	outputNoColor bool
+	outputNoPager bool
+	// outputFormat is the --output format: "text" or "json"
+	outputFormat string
+	// jsonPrinted is set once a command has written its JSON result
+	jsonPrinted bool
+)
+
+// terminalOut is the real standard output, kept while a pager replaces os.Stdout
+var terminalOut = os.Stdout
+
+// configureOutput applies --output, --no-color, and NO_COLOR; fatih/color
+// already disables color when stdout is not a terminal
+func configureOutput() {
+	switch outputFormat {
+	case "text":
+	case "json":
+		color.NoColor = true
+	default:
+		exitError("invalid --output '%s': expected json or text", outputFormat)
+	}
+	if outputNoColor || os.Getenv("NO_COLOR") != "" {
+		color.NoColor = true
+	}
+}
+
+// jsonOutput reports whether --output json was given, in which case commands
+// that support it write a single JSON document to stdout instead of text
+func jsonOutput() bool {
+	return outputFormat == "json"
+}
+
+// printJSON writes a command's result to stdout as indented JSON
+func printJSON(v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		exitError("encode output: %v", err)
+	}
+	jsonPrinted = true
+	fmt.Fprintln(os.Stdout, string(data))
+}
+
+// pager is a running pager process that stdout is piped into
+type pager struct {
+	cmd         *exec.Cmd
+	w           *os.File
+	savedStdout *os.File
+	savedOutput io.Writer
+}
+
+var activePager *pager
+
+// startPager pipes standard output through the user's pager when stdout is a
+// terminal. The pager is $WVC_PAGER, then $PAGER, then less; setting either
+// to "" or "cat" disables paging, as does --no-pager. Unless LESS is set,
+// less runs with -FRX so output that fits on one screen is printed directly
+// and colors are kept. Call stopPager once output is complete.
+func startPager() {
+	if outputNoPager || jsonOutput() || activePager != nil || !isTerminal(terminalOut) {
+		return
+	}
+	args := pagerCommand()
+	if len(args) == 0 {
+		return
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = r
+	cmd.Stdout = terminalOut
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+	if _, ok := os.LookupEnv("LESS"); !ok {
+		cmd.Env = append(cmd.Env, "LESS=FRX")
+	}
+	if err := cmd.Start(); err != nil {
+		r.Close()
+		w.Close()
+		return
+	}
+	r.Close()
+
+	activePager = &pager{cmd: cmd, w: w, savedStdout: os.Stdout, savedOutput: color.Output}
+	os.Stdout = w
+	color.Output = w
+}
+
+// stopPager closes the pager's input and waits for the user to quit it
+func stopPager() {
+	p := activePager
+	if p == nil {
+		return
+	}
+	activePager = nil
+	os.Stdout = p.savedStdout
+	color.Output = p.savedOutput
+	p.w.Close()
+	_ = p.cmd.Wait()
+}
+
+// pagerCommand returns the configured pager command line, or nil for none
+func pagerCommand() []string {
+	value, ok := os.LookupEnv("WVC_PAGER")
+	if !ok {
+		value, ok = os.LookupEnv("PAGER")
+	}
+	if !ok {
+		value = "less"
+	}
+	args := strings.Fields(value)
+	if len(args) == 0 || args[0] == "cat" {
+		return nil
+	}
+	return args
+}
+
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// terminalWidth returns the width of the terminal standard output is shown
+// on, or 0 (unlimited) when it is not a terminal. COLUMNS overrides both.
+func terminalWidth() int {
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	if !isTerminal(terminalOut) {
+		return 0
+	}
+	width, _, err := term.GetSize(int(terminalOut.Fd()))
+	if err != nil {
+		return 0
+	}
+	return width
+}
+
+// minTableColumn is the narrowest the last table column is truncated to
+const minTableColumn = 12
+
+// tableCell is one cell of a table; the color applies to its text only, so
+// padding and width calculations use the plain text
+type tableCell struct {
+	text  string
+	color *color.Color
+}
+
+func cell(text string, c *color.Color) tableCell {
+	return tableCell{text: text, color: c}
+}
+
+// table renders rows as left-aligned columns. The last column is truncated
+// with "..." to fit the terminal width; other columns are padded to their
+// widest cell.
+type table struct {
+	indent string
+	rows   [][]tableCell
+}
+
+func (t *table) addRow(cells ...tableCell) {
+	t.rows = append(t.rows, cells)
+}
+
+// print renders the table to standard output at the terminal width
+func (t *table) print() {
+	t.render(os.Stdout, terminalWidth())
+}
+
+// render writes the table to w; a width of 0 disables truncation
+func (t *table) render(w io.Writer, width int) {
+	var widths []int
+	for _, row := range t.rows {
+		for i, c := range row[:len(row)-1] {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], utf8.RuneCountInString(c.text))
+		}
+	}
+
+	for _, row := range t.rows {
+		var b strings.Builder
+		b.WriteString(t.indent)
+		used := utf8.RuneCountInString(t.indent)
+		last := len(row) - 1
+		for i, c := range row[:last] {
+			b.WriteString(colorize(c))
+			pad := widths[i] - utf8.RuneCountInString(c.text) + 2
+			b.WriteString(strings.Repeat(" ", pad))
+			used += widths[i] + 2
+		}
+		final := row[last]
+		if width > 0 {
+			final.text = truncate(final.text, max(width-used, minTableColumn))
+		}
+		b.WriteString(colorize(final))
+		fmt.Fprintln(w, strings.TrimRight(b.String(), " "))
+	}
+}
+
+func colorize(c tableCell) string {
+	if c.color == nil || c.text == "" {
+		return c.text
+	}
+	return c.color.Sprint(c.text)
+}
+
+// truncate shortens s to at most n runes, marking the cut with "..."
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	runes := []rune(s)
+	if n <= 3 {
+		return string(runes[:n])
+	}
+	return string(runes[:n-3]) + "..."
+}
+
+// firstLine returns the subject line of a commit or stash message
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
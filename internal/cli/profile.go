@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	profileCPU   string
+	profileMem   string
+	profileTrace string
+)
+
+// profileStops finish the running profiles; see stopProfiling
+var profileStops []func() error
+
+// addProfileFlags registers the hidden --cpu-profile, --mem-profile, and
+// --trace flags on a long-running command. The profiles are written when the
+// command finishes, including when it exits with an error, so users can
+// attach them to reports of slow operations on large repositories.
+func addProfileFlags(cmd *cobra.Command) {
+	f := cmd.Flags()
+	f.StringVar(&profileCPU, "cpu-profile", "", "Write a CPU profile to this file")
+	f.StringVar(&profileMem, "mem-profile", "", "Write a heap profile to this file")
+	f.StringVar(&profileTrace, "trace", "", "Write an execution trace to this file")
+	f.MarkHidden("cpu-profile")
+	f.MarkHidden("mem-profile")
+	f.MarkHidden("trace")
+
+	cmd.PreRun = func(_ *cobra.Command, _ []string) { startProfiling() }
+	cmd.PostRun = func(_ *cobra.Command, _ []string) { stopProfiling() }
+}
+
+// startProfiling starts the profiles requested by the profiling flags
+func startProfiling() {
+	if profileCPU != "" {
+		f, err := os.Create(profileCPU)
+		if err != nil {
+			exitError("create CPU profile: %v", err)
+		}
+		if err := pprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			exitError("start CPU profile: %v", err)
+		}
+		profileStops = append(profileStops, func() error {
+			pprof.StopCPUProfile()
+			return f.Close()
+		})
+	}
+
+	if profileTrace != "" {
+		f, err := os.Create(profileTrace)
+		if err != nil {
+			exitError("create trace: %v", err)
+		}
+		if err := trace.Start(f); err != nil {
+			f.Close()
+			exitError("start trace: %v", err)
+		}
+		profileStops = append(profileStops, func() error {
+			trace.Stop()
+			return f.Close()
+		})
+	}
+
+	if profileMem != "" {
+		path := profileMem
+		profileStops = append(profileStops, func() error {
+			f, err := os.Create(path)
+			if err != nil {
+				return err
+			}
+			// Collect garbage first so the profile shows live memory
+			runtime.GC()
+			if err := pprof.WriteHeapProfile(f); err != nil {
+				f.Close()
+				return err
+			}
+			return f.Close()
+		})
+	}
+}
+
+// stopProfiling writes out the running profiles. It is safe to call more
+// than once.
+func stopProfiling() {
+	stops := profileStops
+	profileStops = nil
+	for _, stop := range stops {
+		if err := stop(); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: write profile: %v\n", err)
+		}
+	}
+}
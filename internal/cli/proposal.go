@@ -0,0 +1,271 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/kilupskalvis/wvc/internal/core"
+	"github.com/kilupskalvis/wvc/internal/remote"
+	"github.com/spf13/cobra"
+)
+
+var proposalCmd = &cobra.Command{
+	Use:   "proposal",
+	Short: "Propose, review, and merge branches on a remote",
+	Long: `Manage proposals: requests to merge one branch of a remote into another.
+The server merges a proposal by fast-forwarding the target to the source once
+enough tokens with the reviewer scope have approved the source's current tip;
+an approval of an earlier tip no longer counts after another push. The
+number of approvals is set with 'wvc server start --required-approvals'.
+
+Examples:
+  wvc proposal create feature main -m "Add articles"  Propose merging feature into main
+  wvc proposal list                                   List proposals
+  wvc proposal show 3                                 Show a proposal and its approvals
+  wvc proposal approve 3                              Approve it (reviewer scope)
+  wvc proposal merge 3                                Merge it once approved
+  wvc proposal audit                                  Print the audit log (repo-admin scope)`,
+}
+
+var proposalCreateCmd = &cobra.Command{
+	Use:   "create <source> <target>",
+	Short: "Propose merging a remote branch into another",
+	Long: `Open a proposal to merge branch <source> into <target> on the remote. Both
+branches must already be pushed.`,
+	Args: cobra.ExactArgs(2),
+	Run:  runProposalCreate,
+}
+
+var proposalListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List proposals",
+	Args:  cobra.NoArgs,
+	Run:   runProposalList,
+}
+
+var proposalShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Show a proposal and its approvals",
+	Args:  cobra.ExactArgs(1),
+	Run:   runProposalShow,
+}
+
+var proposalApproveCmd = &cobra.Command{
+	Use:   "approve <id>",
+	Short: "Approve a proposal",
+	Long: `Approve the current tip of a proposal's source branch. The remote token needs
+the reviewer scope, and the author of a proposal cannot approve it.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runProposalApprove,
+}
+
+var proposalMergeCmd = &cobra.Command{
+	Use:   "merge <id>",
+	Short: "Merge an approved proposal",
+	Long: `Fast-forward a proposal's target branch to its source on the remote. The
+server refuses until the source tip has the approvals it requires, and when
+the target has commits the source does not.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runProposalMerge,
+}
+
+var proposalAuditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Print the remote's audit log",
+	Long: `Print the approvals, merges, and refused merges of the remote repository,
+oldest first. The remote token needs the repo-admin scope.`,
+	Args: cobra.NoArgs,
+	Run:  runProposalAudit,
+}
+
+var (
+	proposalTitle  string
+	proposalRemote string
+)
+
+func init() {
+	proposalCreateCmd.Flags().StringVarP(&proposalTitle, "message", "m", "", "Proposal title")
+	proposalCmd.PersistentFlags().StringVar(&proposalRemote, "remote", "", "Remote holding the proposals")
+
+	proposalCmd.AddCommand(proposalCreateCmd)
+	proposalCmd.AddCommand(proposalListCmd)
+	proposalCmd.AddCommand(proposalShowCmd)
+	proposalCmd.AddCommand(proposalApproveCmd)
+	proposalCmd.AddCommand(proposalMergeCmd)
+	proposalCmd.AddCommand(proposalAuditCmd)
+}
+
+// proposalClient returns a client for --remote, defaulting to the only
+// configured remote.
+func proposalClient(c *cmdContext) *remote.RetryClient {
+	name, err := core.ResolveRemote(c.Store, proposalRemote)
+	if err != nil {
+		exitError("%v", err)
+	}
+	return resolveRemoteClientByName(c.Store, name)
+}
+
+// proposalID parses a proposal ID argument, with or without a leading #.
+func proposalID(arg string) int {
+	if len(arg) > 0 && arg[0] == '#' {
+		arg = arg[1:]
+	}
+	id, err := strconv.Atoi(arg)
+	if err != nil || id <= 0 {
+		exitError("invalid proposal ID '%s'", arg)
+	}
+	return id
+}
+
+func runProposalCreate(cmd *cobra.Command, args []string) {
+	c := initContextWithMigrations()
+	defer c.Close()
+
+	req := &remote.ProposalRequest{Title: proposalTitle, Source: args[0], Target: args[1]}
+	p, err := proposalClient(c).CreateProposal(context.Background(), req)
+	if err != nil {
+		exitError("%v", err)
+	}
+	if jsonOutput() {
+		printJSON(p)
+		return
+	}
+	fmt.Printf("Opened proposal #%d to merge '%s' into '%s'\n", p.ID, p.Source, p.Target)
+	if p.RequiredApprovals > 0 {
+		fmt.Printf("It needs %d approval(s) before it can be merged\n", p.RequiredApprovals)
+	}
+}
+
+func runProposalList(cmd *cobra.Command, args []string) {
+	c := initContextWithMigrations()
+	defer c.Close()
+
+	proposals, err := proposalClient(c).ListProposals(context.Background())
+	if err != nil {
+		exitError("%v", err)
+	}
+	if jsonOutput() {
+		printJSON(proposals)
+		return
+	}
+	if len(proposals) == 0 {
+		fmt.Println("No proposals")
+		return
+	}
+
+	t := &table{}
+	for _, p := range proposals {
+		t.addRow(
+			cell(fmt.Sprintf("#%d", p.ID), colorCommit),
+			cell(p.State, nil),
+			cell(p.Source+" -> "+p.Target, colorRef),
+			cell(p.Title, nil),
+		)
+	}
+	t.print()
+}
+
+func runProposalShow(cmd *cobra.Command, args []string) {
+	c := initContextWithMigrations()
+	defer c.Close()
+
+	p, err := proposalClient(c).GetProposal(context.Background(), proposalID(args[0]))
+	if err != nil {
+		exitError("%v", err)
+	}
+	if jsonOutput() {
+		printJSON(p)
+		return
+	}
+	printProposal(p)
+}
+
+func runProposalApprove(cmd *cobra.Command, args []string) {
+	c := initContextWithMigrations()
+	defer c.Close()
+
+	p, err := proposalClient(c).ApproveProposal(context.Background(), proposalID(args[0]))
+	if err != nil {
+		exitError("%v", err)
+	}
+	if jsonOutput() {
+		printJSON(p)
+		return
+	}
+	tip := p.Approvals[len(p.Approvals)-1].CommitID
+	fmt.Printf("Approved proposal #%d at %s (%d of %d approvals)\n", p.ID, shortID(tip), p.ApprovalsAt(tip), p.RequiredApprovals)
+}
+
+func runProposalMerge(cmd *cobra.Command, args []string) {
+	c := initContextWithMigrations()
+	defer c.Close()
+
+	p, err := proposalClient(c).MergeProposal(context.Background(), proposalID(args[0]))
+	if err != nil {
+		exitError("%v", err)
+	}
+	if jsonOutput() {
+		printJSON(p)
+		return
+	}
+	fmt.Printf("Merged proposal #%d: '%s' is now at %s\n", p.ID, p.Target, shortID(p.MergeCommit))
+}
+
+func runProposalAudit(cmd *cobra.Command, args []string) {
+	c := initContextWithMigrations()
+	defer c.Close()
+
+	entries, err := proposalClient(c).GetAuditLog(context.Background())
+	if err != nil {
+		exitError("%v", err)
+	}
+	if jsonOutput() {
+		printJSON(entries)
+		return
+	}
+	if len(entries) == 0 {
+		fmt.Println("The audit log is empty")
+		return
+	}
+
+	startPager()
+	defer stopPager()
+
+	t := &table{}
+	for _, e := range entries {
+		proposal := ""
+		if e.Proposal > 0 {
+			proposal = fmt.Sprintf("#%d", e.Proposal)
+		}
+		t.addRow(
+			cell(e.Timestamp.Format("2006-01-02 15:04"), colorMuted),
+			cell(e.TokenID, nil),
+			cell(e.Action, nil),
+			cell(proposal, colorCommit),
+			cell(shortID(e.CommitID), colorCommit),
+			cell(e.Detail, nil),
+		)
+	}
+	t.print()
+}
+
+func printProposal(p *remote.Proposal) {
+	colorCommit.Printf("Proposal #%d", p.ID)
+	if p.Title != "" {
+		fmt.Printf(": %s", p.Title)
+	}
+	fmt.Println()
+	fmt.Printf("  Merge:   ")
+	colorRef.Printf("%s -> %s\n", p.Source, p.Target)
+	fmt.Printf("  State:   %s\n", p.State)
+	fmt.Printf("  Author:  %s (%s)\n", p.Author, p.CreatedAt.Format("2006-01-02 15:04"))
+	if p.State == remote.ProposalMerged && p.MergedAt != nil {
+		fmt.Printf("  Merged:  %s by %s (%s)\n", shortID(p.MergeCommit), p.MergedBy, p.MergedAt.Format("2006-01-02 15:04"))
+	}
+	fmt.Printf("  Requires %d approval(s)\n", p.RequiredApprovals)
+	for _, a := range p.Approvals {
+		fmt.Printf("  Approved by %s at %s ", a.Reviewer, shortID(a.CommitID))
+		colorMuted.Printf("(%s)\n", a.Timestamp.Format("2006-01-02 15:04"))
+	}
+}
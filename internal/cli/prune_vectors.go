@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var pruneVectorsCmd = &cobra.Command{
+	Use:   "prune-vectors",
+	Short: "Delete vector blobs nothing references any more",
+	Long: `Delete local vector and payload blobs that no commit, staged change, stash,
+known object, or merge in progress references. Blobs can be left behind when
+history is rewritten or commits are dropped.
+
+The reclaimable space is reported per class. Blobs stored before classes were
+recorded are listed as "(unknown)". With --dry-run nothing is deleted.
+
+Deleted blobs free pages inside the database; run "wvc store compact" to
+return the space to the filesystem.
+
+Examples:
+  wvc prune-vectors --dry-run
+  wvc prune-vectors`,
+	Args: cobra.NoArgs,
+	Run:  runPruneVectors,
+}
+
+var pruneVectorsDryRun bool
+
+func init() {
+	pruneVectorsCmd.Flags().BoolVar(&pruneVectorsDryRun, "dry-run", false, "Report what would be deleted without deleting it")
+}
+
+func runPruneVectors(cmd *cobra.Command, args []string) {
+	c := initContextWithMigrations()
+	defer c.Close()
+
+	result, err := c.Store.PruneVectorBlobs(pruneVectorsDryRun)
+	if err != nil {
+		exitError("%v", err)
+	}
+	if result.Blobs == 0 {
+		fmt.Println("No unreferenced vector blobs")
+		return
+	}
+
+	t := &table{indent: "  "}
+	for _, pc := range result.Classes {
+		name := pc.ClassName
+		if name == "" {
+			name = "(unknown)"
+		}
+		t.addRow(cell(name, nil), cell(fmt.Sprintf("%d blob(s)", pc.Blobs), nil), cell(formatBytes(pc.Bytes), nil))
+	}
+
+	if pruneVectorsDryRun {
+		fmt.Printf("Would delete %d unreferenced blob(s), %s:\n", result.Blobs, formatBytes(result.Bytes))
+		t.print()
+		return
+	}
+	color.New(color.FgGreen).Printf("Deleted %d unreferenced blob(s), %s:\n", result.Blobs, formatBytes(result.Bytes))
+	t.print()
+	fmt.Println(`Run "wvc store compact" to return the space to the filesystem`)
+}
@@ -6,10 +6,18 @@ import (
 
 	"github.com/fatih/color"
 	"github.com/kilupskalvis/wvc/internal/core"
+	"github.com/kilupskalvis/wvc/internal/models"
 	"github.com/spf13/cobra"
 )
 
-var pullDepth int
+var (
+	pullDepth       int
+	pullMerge       bool
+	pullRebase      bool
+	pullOurs        bool
+	pullTheirs      bool
+	pullConcurrency int
+)
 
 var pullCmd = &cobra.Command{
 	Use:   "pull [<remote>] [<branch>]",
@@ -17,23 +25,65 @@ var pullCmd = &cobra.Command{
 	Long: `Download commits and vectors from a remote and fast-forward the local branch.
 
 If the remote branch has diverged from the local branch, the command reports
-the divergence and suggests running 'wvc merge'.
+the divergence and suggests running 'wvc merge', unless --merge or --rebase
+asks it to integrate the remote changes into the checked-out branch:
+
+  --merge   merges the remote-tracking branch, like 'wvc merge origin/main'.
+            A merge that stops on conflicts is finished with
+            'wvc merge --continue'.
+  --rebase  replays the local commits, keeping their messages and authors,
+            on top of the remote tip. On a conflict the branch is left as it
+            was.
+
+With either, --ours resolves conflicts with the local version and --theirs
+with the remote one.
 
 Defaults to the only configured remote and the current branch.
 
 Examples:
   wvc pull                          Pull current branch from default remote
   wvc pull origin main              Pull 'main' from 'origin'
-  wvc pull --depth 10 origin main   Pull only the last 10 commits`,
+  wvc pull --depth 10 origin main   Pull only the last 10 commits
+  wvc pull --rebase                 Replay local commits on the remote tip
+  wvc pull --merge --theirs         Merge, preferring remote versions on conflict`,
 	Args: cobra.MaximumNArgs(2),
 	Run:  runPull,
 }
 
 func init() {
 	pullCmd.Flags().IntVar(&pullDepth, "depth", 0, "Limit number of commits to fetch (0 = all)")
+	pullCmd.Flags().BoolVar(&pullMerge, "merge", false, "Merge the remote branch when it has diverged")
+	pullCmd.Flags().BoolVar(&pullRebase, "rebase", false, "Replay local commits on the remote branch when it has diverged")
+	pullCmd.Flags().BoolVar(&pullOurs, "ours", false, "On conflict, prefer the local version")
+	pullCmd.Flags().BoolVar(&pullTheirs, "theirs", false, "On conflict, prefer the remote version")
+	pullCmd.Flags().IntVar(&pullConcurrency, "concurrency", core.DefaultTransferConcurrency, "Number of vectors to download at once")
+	addProfileFlags(pullCmd)
 }
 
 func runPull(cmd *cobra.Command, args []string) {
+	if pullMerge && pullRebase {
+		exitError("cannot use --merge and --rebase together")
+	}
+	if pullOurs && pullTheirs {
+		exitError("cannot use --ours and --theirs together")
+	}
+	mode := core.PullFastForwardOnly
+	switch {
+	case pullMerge:
+		mode = core.PullMerge
+	case pullRebase:
+		mode = core.PullRebase
+	}
+	strategy := models.ConflictManual
+	if pullOurs {
+		strategy = models.ConflictOurs
+	} else if pullTheirs {
+		strategy = models.ConflictTheirs
+	}
+	if mode == core.PullFastForwardOnly && strategy != models.ConflictManual {
+		exitError("--ours and --theirs require --merge or --rebase")
+	}
+
 	c := initFullContext()
 	defer c.Close()
 
@@ -53,22 +103,45 @@ func runPull(cmd *cobra.Command, args []string) {
 	green := color.New(color.FgGreen)
 	yellow := color.New(color.FgYellow)
 
-	fmt.Printf("Pulling from %s (%s)...\n", remoteName, remoteInfo.URL)
-
-	result, err := core.Pull(ctx, c.Config, c.Store, c.Client, client, core.PullOptions{
-		RemoteName: remoteName,
-		Branch:     branch,
-		Depth:      pullDepth,
-	}, func(phase string, current, total int) {
+	progress := func(phase string, current, total int) {
 		if total > 0 {
 			fmt.Printf("\r  %s %d/%d", phase, current, total)
 		}
-	})
+	}
+	if jsonOutput() {
+		progress = nil
+	} else {
+		fmt.Printf("Pulling from %s (%s)...\n", remoteName, remoteInfo.URL)
+	}
+
+	result, err := core.Pull(ctx, c.Config, c.Store, c.Client, client, core.PullOptions{
+		RemoteName:  remoteName,
+		Branch:      branch,
+		Depth:       pullDepth,
+		Mode:        mode,
+		Strategy:    strategy,
+		Concurrency: pullConcurrency,
+	}, progress)
 	if err != nil {
-		fmt.Println()
+		if !jsonOutput() {
+			fmt.Println()
+		}
 		exitError("%v", err)
 	}
 
+	if jsonOutput() {
+		// A pull that stopped on conflicts still fails, after the document
+		// listing them
+		printJSON(pullJSON(result, remoteName, branch))
+		if result.Merge != nil && !result.Merge.Success {
+			exitError("automatic merge failed; resolve the conflicts and run 'wvc merge --continue'")
+		}
+		if result.Rebase != nil && result.Rebase.StoppedAt != "" {
+			exitError("rebase stopped: replaying %s conflicts with the remote changes; '%s' is unchanged", shortID(result.Rebase.StoppedAt), branch)
+		}
+		return
+	}
+
 	fmt.Println()
 	if result.UpToDate {
 		fmt.Println("Already up-to-date.")
@@ -91,9 +164,18 @@ func runPull(cmd *cobra.Command, args []string) {
 		}
 	}
 
-	if result.Diverged {
+	switch {
+	case result.Merge != nil:
+		if !result.Merge.Success {
+			printMergeConflicts(result.Merge, color.New(color.FgRed, color.Bold))
+			exitError("Automatic merge failed; inspect conflicts with 'wvc conflicts show', resolve them with 'wvc conflicts resolve', and then run 'wvc merge --continue'.")
+		}
+		printMergeResult(result.Merge, strategy)
+	case result.Rebase != nil:
+		printRebaseResult(result.Rebase, branch, strategy)
+	case result.Diverged:
 		yellow.Printf("Your branch and '%s/%s' have diverged.\n", remoteName, branch)
-		yellow.Printf("Run 'wvc merge %s/%s' to integrate remote changes.\n", remoteName, branch)
+		yellow.Printf("Run 'wvc merge %s/%s', or pull with --merge or --rebase, to integrate remote changes.\n", remoteName, branch)
 	}
 
 	if len(result.Warnings) > 0 {
@@ -103,3 +185,27 @@ func runPull(cmd *cobra.Command, args []string) {
 		}
 	}
 }
+
+// printRebaseResult displays the outcome of pull --rebase
+func printRebaseResult(result *core.RebaseResult, branch string, strategy models.ConflictStrategy) {
+	yellow := color.New(color.FgYellow)
+	for _, w := range result.Warnings {
+		yellow.Printf("  Warning: %s\n", w.Message)
+	}
+	if result.StoppedAt != "" {
+		printMergeConflicts(&models.MergeResult{Conflicts: result.Conflicts}, color.New(color.FgRed, color.Bold))
+		exitError("Rebase stopped: replaying %s conflicts with the remote changes; '%s' is unchanged. Pull with --ours or --theirs, or with --merge to resolve conflicts one by one.",
+			shortID(result.StoppedAt), branch)
+	}
+
+	color.New(color.FgGreen).Printf("Rebased '%s' onto %s: %d commit(s) replayed\n", branch, shortID(result.Onto), len(result.Commits))
+	for _, c := range result.Commits {
+		fmt.Printf("  %s %s\n", colorCommit.Sprint(shortID(c.ID)), firstLine(c.Message))
+	}
+	if result.Skipped > 0 {
+		fmt.Printf("  %d commit(s) skipped: the remote already has their changes\n", result.Skipped)
+	}
+	if result.ResolvedConflicts > 0 {
+		yellow.Printf("Auto-resolved %d conflict(s) using '%s' strategy\n", result.ResolvedConflicts, strategy)
+	}
+}
@@ -3,6 +3,7 @@ package cli
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/fatih/color"
 	"github.com/kilupskalvis/wvc/internal/core"
@@ -11,6 +12,8 @@ import (
 
 var pushForce bool
 var pushDelete string
+var pushUser bool
+var pushConcurrency int
 
 var pushCmd = &cobra.Command{
 	Use:   "push [<remote>] [<branch>]",
@@ -19,11 +22,17 @@ var pushCmd = &cobra.Command{
 
 Defaults to the only configured remote and the current branch.
 
+With --user, the branch is pushed to refs/users/<token-id>/<branch> on the
+remote: a namespace owned by your token that does not appear in the remote's
+branch list and that any read-write token may write for itself.
+
 Examples:
   wvc push                          Push current branch to default remote
   wvc push origin main              Push 'main' branch to 'origin'
   wvc push --force origin main      Force push (overwrites remote)
-  wvc push --delete origin feature  Delete 'feature' branch on 'origin'`,
+  wvc push --delete origin feature  Delete 'feature' branch on 'origin'
+  wvc push --user origin wip        Push 'wip' to your personal refs on 'origin'
+  wvc push --user --delete wip      Delete your personal ref 'wip'`,
 	Args: cobra.MaximumNArgs(2),
 	Run:  runPush,
 }
@@ -31,6 +40,9 @@ Examples:
 func init() {
 	pushCmd.Flags().BoolVarP(&pushForce, "force", "f", false, "Force push (overwrite remote branch)")
 	pushCmd.Flags().StringVar(&pushDelete, "delete", "", "Delete a remote branch")
+	pushCmd.Flags().BoolVar(&pushUser, "user", false, "Push to your personal ref namespace on the remote")
+	pushCmd.Flags().IntVar(&pushConcurrency, "concurrency", core.DefaultTransferConcurrency, "Number of vectors to upload at once")
+	addProfileFlags(pushCmd)
 }
 
 func runPush(cmd *cobra.Command, args []string) {
@@ -68,22 +80,46 @@ func runPush(cmd *cobra.Command, args []string) {
 	green := color.New(color.FgGreen)
 	yellow := color.New(color.FgYellow)
 
-	fmt.Printf("Pushing to %s (%s)...\n", remoteName, remoteInfo.URL)
-
-	result, err := core.Push(ctx, c.Store, client, core.PushOptions{
-		RemoteName: remoteName,
-		Branch:     branch,
-		Force:      pushForce,
-	}, func(phase string, current, total int) {
+	progress := func(phase string, current, total int) {
 		if total > 0 {
 			fmt.Printf("\r  %s %d/%d", phase, current, total)
 		}
-	})
+	}
+	if jsonOutput() {
+		progress = nil
+	} else {
+		fmt.Printf("Pushing to %s (%s)...\n", remoteName, remoteInfo.URL)
+	}
+
+	result, err := core.Push(ctx, c.Store, client, core.PushOptions{
+		RemoteName:  remoteName,
+		Branch:      branch,
+		Force:       pushForce,
+		UserRef:     pushUser,
+		Concurrency: pushConcurrency,
+	}, progress)
 	if err != nil {
-		fmt.Println() // newline after progress
+		if !jsonOutput() {
+			fmt.Println() // newline after progress
+		}
 		exitError("%v", err)
 	}
 
+	if jsonOutput() {
+		printJSON(&pushResultJSON{
+			Remote:            remoteName,
+			Branch:            branch,
+			RemoteRef:         result.RemoteRef,
+			UpToDate:          result.UpToDate,
+			BranchCreated:     result.BranchCreated,
+			CommitsPushed:     result.CommitsPushed,
+			VectorsPushed:     result.VectorsPushed,
+			Forced:            pushForce && !result.UpToDate,
+			RestrictedClasses: result.RestrictedClasses,
+		})
+		return
+	}
+
 	fmt.Println() // newline after progress
 	if result.UpToDate {
 		fmt.Println("Already up-to-date.")
@@ -91,7 +127,11 @@ func runPush(cmd *cobra.Command, args []string) {
 	}
 
 	if result.BranchCreated {
-		green.Printf("Created remote branch '%s'\n", branch)
+		if pushUser {
+			green.Printf("Created personal ref '%s'\n", result.RemoteRef)
+		} else {
+			green.Printf("Created remote branch '%s'\n", branch)
+		}
 	}
 
 	if result.CommitsPushed > 0 {
@@ -105,14 +145,38 @@ func runPush(cmd *cobra.Command, args []string) {
 	if pushForce {
 		yellow.Println("(force push)")
 	}
+	if len(result.RestrictedClasses) > 0 {
+		yellow.Printf("warning: pushed classes classified pii:restricted (%s) to '%s', which is not approved for personal data\n",
+			strings.Join(result.RestrictedClasses, ", "), remoteName)
+		fmt.Printf("  (mark an approved remote with \"wvc remote approve-pii %s\")\n", remoteName)
+	}
+	if !pushUser {
+		warnTrackedDeployments(c.Store, branch)
+	}
 }
 
 func handlePushDelete(ctx context.Context, c *cmdContext, remoteName, branch string) {
 	client := resolveRemoteClientByName(c.Store, remoteName)
 
+	if pushUser {
+		if err := core.DeleteUserRef(ctx, client, branch); err != nil {
+			exitError("%v", err)
+		}
+		if jsonOutput() {
+			printJSON(map[string]interface{}{"remote": remoteName, "deleted": branch, "user_ref": true})
+			return
+		}
+		fmt.Printf("Deleted personal ref '%s' on %s\n", branch, remoteName)
+		return
+	}
+
 	if err := core.DeleteRemoteBranch(ctx, c.Store, client, remoteName, branch); err != nil {
 		exitError("%v", err)
 	}
+	if jsonOutput() {
+		printJSON(map[string]interface{}{"remote": remoteName, "deleted": branch})
+		return
+	}
 
 	fmt.Printf("Deleted remote branch '%s/%s'\n", remoteName, branch)
 }
@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/kilupskalvis/wvc/internal/core"
+	"github.com/spf13/cobra"
+)
+
+var queryCmd = &cobra.Command{
+	Use:   "query --class <class> [--ref <ref>] [--near-text <concept>...]",
+	Short: "Query a class as it was at a past commit",
+	Long: `Query a class as it was at a commit without checking it out. The class is
+written, with the objects and vectors it had at the commit, to a temporary
+class in Weaviate, queried, and deleted again; the live class is not
+touched.
+
+--near-text runs a nearText search, which needs the class's vectorizer;
+without it objects are returned in no particular order. Reference
+properties are left out, since the objects they point to may not exist.
+Multi-tenant classes are queried one tenant at a time, as Class@tenant.
+
+Examples:
+  wvc query --ref v1.0 --class Article --near-text "climate"
+  wvc query --ref HEAD~3 --class Article --properties title,body --limit 3
+  wvc query --ref main --class Review@tenantA --json`,
+	Args: cobra.NoArgs,
+	Run:  runQuery,
+}
+
+var (
+	queryRef        string
+	queryClass      string
+	queryNearText   []string
+	queryLimit      int
+	queryProperties []string
+	queryJSON       bool
+)
+
+func init() {
+	queryCmd.Flags().StringVar(&queryRef, "ref", "HEAD", "Commit, branch, or tag to query")
+	queryCmd.Flags().StringVar(&queryClass, "class", "", "Class to query, as Class@tenant for multi-tenant classes (required)")
+	queryCmd.Flags().StringArrayVar(&queryNearText, "near-text", nil, "Concept to search near; repeat for several")
+	queryCmd.Flags().IntVar(&queryLimit, "limit", core.DefaultQueryLimit, "Maximum number of results")
+	queryCmd.Flags().StringSliceVar(&queryProperties, "properties", nil, "Properties to return (default all but references and nested objects)")
+	queryCmd.Flags().BoolVar(&queryJSON, "json", false, "Print the results as JSON")
+	queryCmd.MarkFlagRequired("class")
+}
+
+func runQuery(cmd *cobra.Command, args []string) {
+	ctx := context.Background()
+	c := initFullContext()
+	defer c.Close()
+
+	result, err := core.QueryAtRef(ctx, c.Config, c.Store, c.Client, core.QueryOptions{
+		Ref:        queryRef,
+		ClassName:  queryClass,
+		NearText:   queryNearText,
+		Limit:      queryLimit,
+		Properties: queryProperties,
+	})
+	if err != nil {
+		exitError("%v", err)
+	}
+
+	if queryJSON {
+		results := result.Results
+		if results == nil {
+			results = []map[string]interface{}{}
+		}
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			exitError("%v", err)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	colorMuted.Printf("%s at %s: %d object(s), %d result(s)\n", result.ClassName, shortID(result.CommitID), result.Materialized, len(result.Results))
+	for _, hit := range result.Results {
+		fmt.Println()
+		additional, _ := hit["_additional"].(map[string]interface{})
+		colorCommit.Printf("%v", additional["id"])
+		if distance, ok := additional["distance"]; ok && distance != nil {
+			colorMuted.Printf("  distance %v", distance)
+		}
+		fmt.Println()
+
+		names := make([]string, 0, len(hit))
+		for name := range hit {
+			if name != "_additional" {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			value, err := json.Marshal(hit[name])
+			if err != nil {
+				value = []byte(fmt.Sprint(hit[name]))
+			}
+			fmt.Printf("    %s: %s\n", name, value)
+		}
+	}
+}
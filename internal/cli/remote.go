@@ -4,11 +4,13 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"syscall"
 
 	"github.com/fatih/color"
 	"github.com/kilupskalvis/wvc/internal/core"
+	"github.com/kilupskalvis/wvc/internal/models"
 	"github.com/kilupskalvis/wvc/internal/remote"
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
@@ -26,7 +28,9 @@ Examples:
   wvc remote add origin https://...    Add a remote named 'origin'
   wvc remote remove origin             Remove a remote
   wvc remote set-url origin https://.. Update a remote's URL
-  wvc remote set-token origin          Set authentication token for a remote`,
+  wvc remote set-token origin          Set authentication token for a remote
+  wvc remote approve-pii origin        Approve a remote for restricted personal data
+  wvc remote show origin               Show detailed remote information`,
 	Run: runRemoteList,
 }
 
@@ -60,14 +64,35 @@ var remoteInfoCmd = &cobra.Command{
 	Use:   "info <name>",
 	Short: "Display remote repository stats",
 	Long: `Show information about a remote repository including branch count,
-commit count, and total stored blobs.
+commit count, total stored blobs, and the repository's quota if it has one.
+
+--usage also breaks down the storage the repository uses: bytes of vectors
+and payloads, bytes of metadata, and the classes with the most operation
+data. The server scans every operation for it.
 
 Examples:
-  wvc remote info origin`,
+  wvc remote info origin
+  wvc remote info origin --usage`,
 	Args: cobra.ExactArgs(1),
 	Run:  runRemoteInfo,
 }
 
+var remoteShowCmd = &cobra.Command{
+	Use:   "show <name>",
+	Short: "Show detailed information about a remote",
+	Long: `Show the remote URL, repository stats, default branch, the state of
+each remote-tracking branch, and the scope of the configured token.
+
+Remote-tracking branches are compared against the server's branch tips:
+"stale" means the server has moved since the last fetch, "new" means the
+branch has never been fetched, and "gone" means it was deleted on the remote.
+
+Examples:
+  wvc remote show origin`,
+	Args: cobra.ExactArgs(1),
+	Run:  runRemoteShow,
+}
+
 var remoteSetTokenCmd = &cobra.Command{
 	Use:   "set-token <name>",
 	Short: "Set authentication token for a remote",
@@ -81,14 +106,39 @@ Examples:
 	Run:  runRemoteSetToken,
 }
 
+var remoteApprovePIICmd = &cobra.Command{
+	Use:   "approve-pii <name>",
+	Short: "Approve a remote to hold restricted personal data",
+	Long: `Mark a remote as approved to hold classes classified pii:restricted.
+
+Classes are classified in the [classification] table of .wvc/config, and
+commits record the classification of the classes they touch. Pushing commits
+that touch restricted classes to a remote that is not approved warns.
+
+Examples:
+  wvc remote approve-pii origin           Approve origin
+  wvc remote approve-pii origin --revoke  Withdraw the approval`,
+	Args: cobra.ExactArgs(1),
+	Run:  runRemoteApprovePII,
+}
+
+var (
+	remoteRevokePII bool
+	remoteInfoUsage bool
+)
+
 func init() {
 	remoteCmd.Flags().BoolVarP(&remoteVerbose, "verbose", "v", false, "Show remote URLs")
+	remoteApprovePIICmd.Flags().BoolVar(&remoteRevokePII, "revoke", false, "Withdraw the approval")
+	remoteInfoCmd.Flags().BoolVar(&remoteInfoUsage, "usage", false, "Break down the storage the repository uses")
 
 	remoteCmd.AddCommand(remoteAddCmd)
 	remoteCmd.AddCommand(remoteRemoveCmd)
 	remoteCmd.AddCommand(remoteSetURLCmd)
 	remoteCmd.AddCommand(remoteSetTokenCmd)
 	remoteCmd.AddCommand(remoteInfoCmd)
+	remoteCmd.AddCommand(remoteShowCmd)
+	remoteCmd.AddCommand(remoteApprovePIICmd)
 }
 
 func runRemoteList(cmd *cobra.Command, args []string) {
@@ -100,6 +150,14 @@ func runRemoteList(cmd *cobra.Command, args []string) {
 		exitError("%v", err)
 	}
 
+	if jsonOutput() {
+		remotes := result.Remotes
+		if remotes == nil {
+			remotes = []*models.Remote{}
+		}
+		printJSON(remotes)
+		return
+	}
 	if len(result.Remotes) == 0 {
 		return
 	}
@@ -123,6 +181,10 @@ func runRemoteAdd(cmd *cobra.Command, args []string) {
 	if err := core.AddRemote(c.Store, name, url); err != nil {
 		exitError("%v", err)
 	}
+	if jsonOutput() {
+		printRemoteJSON(c, name)
+		return
+	}
 
 	green := color.New(color.FgGreen)
 	green.Printf("Added remote '%s' (%s)\n", name, url)
@@ -137,6 +199,10 @@ func runRemoteRemove(cmd *cobra.Command, args []string) {
 	if err := core.RemoveRemote(c.Store, name); err != nil {
 		exitError("%v", err)
 	}
+	if jsonOutput() {
+		printJSON(map[string]string{"removed": name})
+		return
+	}
 
 	fmt.Printf("Removed remote '%s'\n", name)
 }
@@ -151,10 +217,34 @@ func runRemoteSetURL(cmd *cobra.Command, args []string) {
 	if err := core.SetRemoteURL(c.Store, name, url); err != nil {
 		exitError("%v", err)
 	}
+	if jsonOutput() {
+		printRemoteJSON(c, name)
+		return
+	}
 
 	fmt.Printf("Updated remote '%s' URL to %s\n", name, url)
 }
 
+func runRemoteApprovePII(cmd *cobra.Command, args []string) {
+	c := initContextWithMigrations()
+	defer c.Close()
+
+	name := args[0]
+	if err := core.SetRemotePIIApproved(c.Store, name, !remoteRevokePII); err != nil {
+		exitError("%v", err)
+	}
+	if jsonOutput() {
+		printRemoteJSON(c, name)
+		return
+	}
+
+	if remoteRevokePII {
+		fmt.Printf("Remote '%s' is no longer approved for restricted personal data\n", name)
+		return
+	}
+	fmt.Printf("Remote '%s' is approved for restricted personal data\n", name)
+}
+
 func runRemoteSetToken(cmd *cobra.Command, args []string) {
 	c := initContextWithMigrations()
 	defer c.Close()
@@ -166,6 +256,30 @@ func runRemoteSetToken(cmd *cobra.Command, args []string) {
 		exitError("%v", err)
 	}
 
+	token := promptRemoteToken(name)
+	if err := core.SetRemoteToken(c.Store, name, token); err != nil {
+		exitError("%v", err)
+	}
+	if jsonOutput() {
+		printJSON(map[string]interface{}{"name": name, "token_stored": true})
+		return
+	}
+
+	green := color.New(color.FgGreen)
+	green.Printf("Token stored for remote '%s'\n", name)
+}
+
+// printRemoteJSON prints a remote as it is stored for --output json
+func printRemoteJSON(c *cmdContext, name string) {
+	r, err := core.GetRemote(c.Store, name)
+	if err != nil {
+		exitError("%v", err)
+	}
+	printJSON(r)
+}
+
+// promptRemoteToken reads a remote's token from the terminal without echoing it
+func promptRemoteToken(name string) string {
 	fmt.Fprintf(os.Stderr, "Enter token for remote '%s': ", name)
 
 	tokenBytes, err := term.ReadPassword(int(syscall.Stdin))
@@ -178,13 +292,7 @@ func runRemoteSetToken(cmd *cobra.Command, args []string) {
 	if token == "" {
 		exitError("token cannot be empty")
 	}
-
-	if err := core.SetRemoteToken(c.Store, name, token); err != nil {
-		exitError("%v", err)
-	}
-
-	green := color.New(color.FgGreen)
-	green.Printf("Token stored for remote '%s'\n", name)
+	return token
 }
 
 func runRemoteInfo(cmd *cobra.Command, args []string) {
@@ -215,12 +323,113 @@ func runRemoteInfo(cmd *cobra.Command, args []string) {
 
 	ctx := context.Background()
 	info, err := client.GetRepoInfo(ctx)
+	if remoteInfoUsage {
+		info, err = client.GetRepoUsage(ctx)
+	}
 	if err != nil {
 		exitError("failed to get remote info: %v", err)
 	}
 
+	if jsonOutput() {
+		printJSON(struct {
+			Name string `json:"name"`
+			URL  string `json:"url"`
+			*remote.RepoInfo
+		}{name, remoteInfo.URL, info})
+		return
+	}
+
 	fmt.Printf("Remote: %s (%s)\n", name, remoteInfo.URL)
 	fmt.Printf("  Branches: %d\n", info.BranchCount)
 	fmt.Printf("  Commits:  %d\n", info.CommitCount)
 	fmt.Printf("  Blobs:    %d\n", info.TotalBlobs)
+	if q := info.Quota; q != nil {
+		fmt.Printf("  Quota:    %s of blobs, %s commits\n",
+			quotaLimit(q.MaxBlobBytes, formatBytes),
+			quotaLimit(int64(q.MaxCommits), func(n int64) string { return strconv.FormatInt(n, 10) }))
+	}
+	if u := info.Usage; u != nil {
+		fmt.Println("  Usage:")
+		fmt.Printf("    Blobs:    %s\n", formatBytes(u.BlobBytes))
+		fmt.Printf("    Metadata: %s\n", formatBytes(u.MetaBytes))
+		if len(u.LargestClasses) > 0 {
+			fmt.Println("    Largest classes:")
+			t := &table{indent: "      "}
+			for _, c := range u.LargestClasses {
+				t.addRow(cell(formatBytes(c.Bytes), nil), cell(fmt.Sprintf("%d ops", c.Operations), colorMuted), cell(c.Class, nil))
+			}
+			t.print()
+		}
+	}
+}
+
+func runRemoteShow(cmd *cobra.Command, args []string) {
+	c := initContextWithMigrations()
+	defer c.Close()
+
+	name := args[0]
+	client := resolveRemoteClientByName(c.Store, name)
+
+	ctx := context.Background()
+	result, err := core.ShowRemote(ctx, c.Store, client, name)
+	if err != nil {
+		exitError("%v", err)
+	}
+	if jsonOutput() {
+		printJSON(remoteShowDocument(result))
+		return
+	}
+
+	green := color.New(color.FgGreen)
+	yellow := color.New(color.FgYellow)
+	red := color.New(color.FgRed)
+
+	fmt.Printf("* remote %s\n", name)
+	fmt.Printf("  URL: %s\n", result.Remote.URL)
+	if result.Remote.PIIApproved {
+		fmt.Println("  Approved for restricted personal data")
+	}
+
+	info := result.Info
+	defaultBranch := info.DefaultBranch
+	if defaultBranch == "" {
+		defaultBranch = "(none)"
+	}
+	fmt.Printf("  Default branch: %s\n", defaultBranch)
+	fmt.Printf("  Branches: %d\n", info.BranchCount)
+	fmt.Printf("  Commits:  %d\n", info.CommitCount)
+	fmt.Printf("  Blobs:    %d\n", info.TotalBlobs)
+
+	if info.Token != nil {
+		fmt.Printf("  Token: %s (%s, repos: %s)\n",
+			info.Token.ID, info.Token.Permission, strings.Join(info.Token.Repos, ", "))
+	}
+
+	if len(result.UserRefs) > 0 {
+		fmt.Println("  Personal refs:")
+		for _, ref := range result.UserRefs {
+			fmt.Printf("    %-20s %s\n", ref.Name, shortID(ref.CommitID))
+		}
+	}
+
+	if len(result.Refs) == 0 {
+		return
+	}
+
+	fmt.Println("  Remote branches:")
+	for _, ref := range result.Refs {
+		fmt.Printf("    %-20s ", ref.Branch)
+		switch ref.State {
+		case core.RemoteRefUpToDate:
+			green.Print(ref.State)
+		case core.RemoteRefGone:
+			red.Print(ref.State)
+		default:
+			yellow.Print(ref.State)
+		}
+		if !ref.FetchedAt.IsZero() {
+			fmt.Printf(" (fetched %s)", ref.FetchedAt.Local().Format("Mon Jan 2 15:04:05 2006"))
+		}
+		fmt.Println()
+	}
 }
@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/kilupskalvis/wvc/internal/core"
+	"github.com/spf13/cobra"
+)
+
+var (
+	restoreRetryFailed bool
+	restoreAttempts    int
+	restoreBackoff     time.Duration
+)
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore --retry-failed",
+	Short: "Retry object writes that failed while restoring a commit",
+	Long: `Finish bringing Weaviate to the state of HEAD after a checkout, reset --hard,
+pull, or stash apply stopped writing some objects (for example because
+Weaviate became unavailable midway).
+
+Only the objects whose writes failed are re-attempted, each with exponential
+backoff between attempts. Objects that still fail stay recorded, so the
+command can be run again once Weaviate has recovered.
+
+Examples:
+  wvc restore --retry-failed                  Retry the failed writes
+  wvc restore --retry-failed --attempts 10    Try each object up to 10 times
+  wvc restore --retry-failed --backoff 2s     Wait 2s before the first retry`,
+	Args: cobra.NoArgs,
+	Run:  runRestore,
+}
+
+func init() {
+	restoreCmd.Flags().BoolVar(&restoreRetryFailed, "retry-failed", false, "Re-attempt the object writes that failed during the last apply")
+	restoreCmd.Flags().IntVar(&restoreAttempts, "attempts", core.DefaultRetryAttempts, "Maximum attempts per object")
+	restoreCmd.Flags().DurationVar(&restoreBackoff, "backoff", core.DefaultRetryBackoff, "Delay before the first retry, doubled after each failure")
+}
+
+func runRestore(cmd *cobra.Command, args []string) {
+	if !restoreRetryFailed {
+		exitError("nothing to do; use --retry-failed to retry failed object writes")
+	}
+
+	c := initFullContext()
+	defer c.Close()
+
+	progress, err := c.Store.GetApplyProgress()
+	if err != nil {
+		exitError("failed to read apply progress: %v", err)
+	}
+	if progress != nil {
+		fmt.Printf("Retrying %d failed object write(s) for commit %s...\n", len(progress.Failed), shortID(progress.CommitID))
+	}
+
+	result, err := core.RetryFailedApply(context.Background(), c.Store, c.Client, core.RetryOptions{
+		MaxAttempts:    restoreAttempts,
+		InitialBackoff: restoreBackoff,
+	})
+	if err != nil {
+		exitError("%v", err)
+	}
+
+	if len(result.Failed) == 0 {
+		color.New(color.FgGreen).Printf("Restored %d object(s); Weaviate now matches %s\n", result.Succeeded, shortID(result.CommitID))
+		return
+	}
+
+	red := color.New(color.FgRed)
+	fmt.Printf("Restored %d of %d object(s)\n", result.Succeeded, result.Retried)
+	red.Printf("%d object(s) still failing:\n", len(result.Failed))
+	for _, f := range result.Failed {
+		fmt.Printf("  %s %s: %s\n", f.Action, f.Key(), f.Error)
+	}
+	exitError("run 'wvc restore --retry-failed' again once Weaviate has recovered")
+}
@@ -13,11 +13,25 @@ var revertCmd = &cobra.Command{
 	Use:   "revert <commit>",
 	Short: "Revert a commit",
 	Long: `Revert the changes made by a commit.
-This creates a new commit that undoes all changes from the specified commit.`,
+This creates a new commit that undoes all changes from the specified commit.
+
+Reverting a merge commit requires -m to pick the mainline parent. The
+objects are returned to that parent's state: -m 1 undoes everything the
+merge brought in from the merged branch, -m 2 undoes the mainline's side.
+
+Examples:
+  wvc revert abc1234        Revert an ordinary commit
+  wvc revert -m 1 def5678   Revert a merge relative to its first parent`,
 	Args: cobra.ExactArgs(1),
 	Run:  runRevert,
 }
 
+var revertMainline int
+
+func init() {
+	revertCmd.Flags().IntVarP(&revertMainline, "mainline", "m", 0, "Parent number (1 or 2) to revert a merge commit against")
+}
+
 func runRevert(cmd *cobra.Command, args []string) {
 	bgCtx := context.Background()
 	commitRef := args[0]
@@ -29,7 +43,7 @@ func runRevert(cmd *cobra.Command, args []string) {
 	fmt.Printf("Reverting commit %s...\n", commitRef)
 
 	var warnings []core.SchemaRevertWarning
-	revertCommit, err := core.RevertCommitWithWarnings(bgCtx, cfg, st, client, commitRef, &warnings)
+	revertCommit, err := core.RevertCommitMainline(bgCtx, cfg, st, client, commitRef, revertMainline, &warnings)
 	if err != nil {
 		exitError("failed to revert: %v", err)
 	}
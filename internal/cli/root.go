@@ -2,8 +2,11 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
+	"sync"
 
 	"github.com/kilupskalvis/wvc/internal/config"
 	"github.com/kilupskalvis/wvc/internal/core"
@@ -35,10 +38,17 @@ func initContext() *cmdContext {
 		exitError("%v", err)
 	}
 
-	st, err := store.New(cfg.DatabasePath())
+	policy, err := cfg.SyncPolicy()
+	if err != nil {
+		exitError("%v", err)
+	}
+	st, err := store.OpenWorktree(cfg.DatabasePath(), cfg.Worktree)
 	if err != nil {
 		exitError("failed to open store: %v", err)
 	}
+	st.SetSyncPolicy(policy)
+	st.SetSnapshotInterval(cfg.SnapshotInterval())
+	installPromisorFetchers(st)
 
 	return &cmdContext{Config: cfg, Store: st}
 }
@@ -59,7 +69,7 @@ func initContextWithMigrations() *cmdContext {
 func initFullContext() *cmdContext {
 	ctx := initContextWithMigrations()
 
-	client, err := weaviate.NewClient(ctx.Config.WeaviateURL)
+	client, err := newWeaviateClient(ctx.Config.WeaviateURL, ctx.Config)
 	if err != nil {
 		ctx.Close()
 		exitError("failed to create Weaviate client: %v", err)
@@ -69,43 +79,119 @@ func initFullContext() *cmdContext {
 	return ctx
 }
 
+// newWeaviateClient connects to Weaviate with the credentials of cfg and the
+// environment; a nil cfg uses the environment only
+func newWeaviateClient(url string, cfg *config.Config) (*weaviate.Client, error) {
+	auth, err := cfg.WeaviateAuth()
+	if err != nil {
+		return nil, err
+	}
+	if auth == nil {
+		return weaviate.NewClient(url)
+	}
+	return weaviate.NewClientWithAuth(url, &weaviate.Auth{
+		APIKey:       auth.APIKey,
+		BearerToken:  auth.BearerToken,
+		ClientSecret: auth.ClientSecret,
+		Username:     auth.Username,
+		Password:     auth.Password,
+		Scopes:       auth.Scopes,
+		Headers:      auth.Headers,
+	})
+}
+
 var rootCmd = &cobra.Command{
 	Use:   "wvc",
 	Short: "Weaviate Version Control",
 	Long: `WVC (Weaviate Version Control) is a git-like CLI tool for version controlling
 Weaviate databases. Track changes, revert commits, and maintain
 a full history of your vector database.`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		configureOutput()
+		startTelemetry(cmd)
+	},
 }
 
-// Execute runs the root command
+// Execute runs the root command, expanding a configured alias first
 func Execute() error {
-	return rootCmd.Execute()
+	rootCmd.SetArgs(expandAlias(os.Args[1:]))
+	err := rootCmd.Execute()
+	if err != nil {
+		reportTelemetry("usage")
+	} else {
+		reportTelemetry("")
+	}
+	return err
 }
 
 func init() {
+	rootCmd.PersistentFlags().BoolVar(&outputNoColor, "no-color", false, "Disable colored output (also set by NO_COLOR)")
+	rootCmd.PersistentFlags().BoolVar(&outputNoPager, "no-pager", false, "Do not pipe long output into a pager")
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "output", "text", "Output format of status, log, diff, branch, stash, remote, push, and pull: text or json")
+
 	rootCmd.AddCommand(initCmd)
+	rootCmd.AddCommand(cloneCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(importCmd)
 	rootCmd.AddCommand(statusCmd)
 	rootCmd.AddCommand(addCmd)
+	rootCmd.AddCommand(mvCmd)
 	rootCmd.AddCommand(resetCmd)
 	rootCmd.AddCommand(commitCmd)
 	rootCmd.AddCommand(logCmd)
+	rootCmd.AddCommand(shortlogCmd)
+	rootCmd.AddCommand(blameCmd)
+	rootCmd.AddCommand(changelogCmd)
+	rootCmd.AddCommand(findCmd)
 	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(applyCmd)
 	rootCmd.AddCommand(revertCmd)
+	rootCmd.AddCommand(restoreCmd)
 	rootCmd.AddCommand(showCmd)
 	rootCmd.AddCommand(branchCmd)
 	rootCmd.AddCommand(checkoutCmd)
+	rootCmd.AddCommand(tagCmd)
+	rootCmd.AddCommand(notesCmd)
 	rootCmd.AddCommand(mergeCmd)
+	rootCmd.AddCommand(conflictsCmd)
+	rootCmd.AddCommand(deployCmd)
+	rootCmd.AddCommand(deploymentsCmd)
+	rootCmd.AddCommand(proposalCmd)
 	rootCmd.AddCommand(stashCmd)
+	rootCmd.AddCommand(worktreeCmd)
 	rootCmd.AddCommand(remoteCmd)
 	rootCmd.AddCommand(pushCmd)
 	rootCmd.AddCommand(pullCmd)
 	rootCmd.AddCommand(fetchCmd)
+	rootCmd.AddCommand(subscribeCmd)
 	rootCmd.AddCommand(serverCmd)
+	rootCmd.AddCommand(countObjectsCmd)
+	rootCmd.AddCommand(storeCmd)
+	rootCmd.AddCommand(pruneVectorsCmd)
+	rootCmd.AddCommand(lockCmd)
+	rootCmd.AddCommand(submoduleCmd)
+	rootCmd.AddCommand(fixtureCmd)
+	rootCmd.AddCommand(benchCmd)
+	rootCmd.AddCommand(bisectCmd)
+	rootCmd.AddCommand(syncCmd)
+	rootCmd.AddCommand(telemetryCmd)
+	rootCmd.AddCommand(ignoreCmd)
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(schemaCmd)
+	rootCmd.AddCommand(queryCmd)
+	rootCmd.AddCommand(scratchCmd)
 }
 
 // exitError prints an error and exits
 func exitError(format string, args ...interface{}) {
+	releaseWriteLock()
+	stopPager()
+	stopProfiling()
 	fmt.Fprintf(os.Stderr, "error: "+format+"\n", args...)
+	if jsonOutput() && !jsonPrinted {
+		printJSON(map[string]string{"error": fmt.Sprintf(format, args...)})
+	}
+	reportTelemetry(telemetryErrorCategory(args))
 	os.Exit(1)
 }
 
@@ -146,28 +232,81 @@ func resolveRemoteClient(st *store.Store, remoteName, branch string) (*remote.Re
 
 // resolveRemoteClientByName loads the remote config and token for a known remote name.
 func resolveRemoteClientByName(st *store.Store, remoteName string) *remote.RetryClient {
-	remoteInfo, err := core.GetRemote(st, remoteName)
+	client, err := newRemoteClient(st, remoteName)
 	if err != nil {
 		exitError("%v", err)
 	}
+	return client
+}
+
+// newRemoteClient returns a retry client for a known remote name.
+func newRemoteClient(st *store.Store, remoteName string) (*remote.RetryClient, error) {
+	remoteInfo, err := core.GetRemote(st, remoteName)
+	if err != nil {
+		return nil, err
+	}
 
 	token, err := core.GetRemoteToken(st, remoteName)
 	if err != nil {
-		exitError("get token: %v", err)
+		return nil, fmt.Errorf("get token: %w", err)
 	}
 	if token == "" {
-		exitError("no token configured for remote '%s'", remoteName)
+		return nil, fmt.Errorf("no token configured for remote '%s'", remoteName)
 	}
 
 	baseURL, repoName, err := core.ParseRemoteURL(remoteInfo.URL)
 	if err != nil {
-		exitError("%v", err)
+		return nil, err
 	}
 
 	return remote.NewRetryClient(
 		remote.NewHTTPClient(baseURL, repoName, token),
 		remote.DefaultRetryConfig(),
+	), nil
+}
+
+// installPromisorFetchers lets a partial clone download the vectors and
+// payloads its filter skipped from the promisor remote when they are first
+// read. The remote client is created on the first download.
+func installPromisorFetchers(st *store.Store) {
+	name, err := st.GetPromisorRemote()
+	if err != nil || name == "" {
+		return
+	}
+	filter, err := st.GetPromisorFilter()
+	if err != nil {
+		return
+	}
+	var (
+		once      sync.Once
+		client    *remote.RetryClient
+		clientErr error
 	)
+	getClient := func() (*remote.RetryClient, error) {
+		once.Do(func() { client, clientErr = newRemoteClient(st, name) })
+		if clientErr != nil {
+			return nil, fmt.Errorf("promisor remote '%s': %w", name, clientErr)
+		}
+		return client, nil
+	}
+	if filter.NoVectors {
+		st.SetVectorFetcher(func(hash string) (io.ReadCloser, int, error) {
+			c, err := getClient()
+			if err != nil {
+				return nil, 0, err
+			}
+			return c.DownloadVector(context.Background(), hash)
+		})
+	}
+	if filter.NoPayloads {
+		st.SetPayloadFetcher(func(commitID string) ([]*remote.OperationPayload, error) {
+			c, err := getClient()
+			if err != nil {
+				return nil, err
+			}
+			return c.DownloadCommitPayloads(context.Background(), commitID)
+		})
+	}
 }
 
 // shortID returns first 8 characters of an ID
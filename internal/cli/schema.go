@@ -0,0 +1,190 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/kilupskalvis/wvc/internal/core"
+	"github.com/spf13/cobra"
+)
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Plan and apply schema migrations",
+}
+
+var schemaPlanCmd = &cobra.Command{
+	Use:   "plan <from-ref> <to-ref>",
+	Short: "Plan the schema migration between two commits",
+	Long: `Plan the migration from the schema recorded at one commit to the schema
+recorded at another, as an ordered list of steps:
+
+  create_class   create a class; properties referencing other new classes
+                 are added after every new class exists
+  add_property   add a property to a class
+  backfill       objects written before a property was added lack it;
+                 populate it from your own pipeline
+  manual         a change Weaviate cannot make in place: a retyped or
+                 removed property, or a changed vectorizer
+  delete_class   delete a class and all its objects, always last
+
+Write the plan with --output and run it with 'wvc schema apply-plan'.
+
+Examples:
+  wvc schema plan v1.0 main
+  wvc schema plan production HEAD --output migration.json`,
+	Args: cobra.ExactArgs(2),
+	Run:  runSchemaPlan,
+}
+
+var schemaApplyPlanCmd = &cobra.Command{
+	Use:   "apply-plan <file>",
+	Short: "Run a schema migration plan against Weaviate",
+	Long: `Run the steps of a plan written by 'wvc schema plan --output' against
+Weaviate, asking for confirmation before each one. Backfill and manual steps
+are shown to be acknowledged; wvc does not run them. Steps whose change is
+already in place are skipped, so a partly applied plan can be run again.
+
+Answer y to run a step, n to skip it, or q to stop. --yes runs every step
+without asking, except class deletions, which also need --allow-delete.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runSchemaApplyPlan,
+}
+
+var (
+	schemaPlanOutput       string
+	schemaPlanJSON         bool
+	schemaApplyYes         bool
+	schemaApplyAllowDelete bool
+)
+
+func init() {
+	schemaPlanCmd.Flags().StringVarP(&schemaPlanOutput, "output", "o", "", "write the plan as JSON to a file")
+	schemaPlanCmd.Flags().BoolVar(&schemaPlanJSON, "json", false, "print the plan as JSON")
+	schemaApplyPlanCmd.Flags().BoolVarP(&schemaApplyYes, "yes", "y", false, "run every step without asking")
+	schemaApplyPlanCmd.Flags().BoolVar(&schemaApplyAllowDelete, "allow-delete", false, "with --yes, also run class deletions")
+	schemaCmd.AddCommand(schemaPlanCmd)
+	schemaCmd.AddCommand(schemaApplyPlanCmd)
+}
+
+func runSchemaPlan(cmd *cobra.Command, args []string) {
+	c := initContextWithMigrations()
+	defer c.Close()
+
+	plan, err := core.PlanSchemaMigration(c.Config, c.Store, args[0], args[1])
+	if err != nil {
+		exitError("%v", err)
+	}
+
+	if schemaPlanOutput != "" {
+		if err := core.SaveMigrationPlan(plan, schemaPlanOutput); err != nil {
+			exitError("%v", err)
+		}
+	}
+	if schemaPlanJSON {
+		data, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			exitError("%v", err)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	if len(plan.Steps) == 0 {
+		fmt.Printf("Schemas of %s and %s match; nothing to migrate\n", plan.From, plan.To)
+		return
+	}
+	fmt.Printf("Migration from %s to %s (%d step(s)):\n\n", plan.From, plan.To, len(plan.Steps))
+	for i, step := range plan.Steps {
+		printMigrationStep(i, step)
+	}
+	if schemaPlanOutput != "" {
+		fmt.Printf("\nPlan written to %s; run it with 'wvc schema apply-plan %s'\n", schemaPlanOutput, schemaPlanOutput)
+	}
+}
+
+func printMigrationStep(i int, step *core.MigrationStep) {
+	kind := color.New(color.FgGreen)
+	switch {
+	case step.Destructive():
+		kind = color.New(color.FgRed)
+	case !step.Executable():
+		kind = color.New(color.FgYellow)
+	}
+	fmt.Printf("  %2d. ", i+1)
+	kind.Printf("%-13s", step.Kind)
+	fmt.Printf(" %s\n", step.Description)
+}
+
+func runSchemaApplyPlan(cmd *cobra.Command, args []string) {
+	plan, err := core.LoadMigrationPlan(args[0])
+	if err != nil {
+		exitError("%v", err)
+	}
+
+	ctx := context.Background()
+	c := initFullContext()
+	defer c.Close()
+
+	reader := bufio.NewReader(os.Stdin)
+	applied, skipped := 0, 0
+	for i, step := range plan.Steps {
+		printMigrationStep(i, step)
+
+		if !step.Executable() {
+			if !schemaApplyYes && !ask(reader, "      Acknowledge and continue? [y/N] ") {
+				fmt.Println("Stopped.")
+				break
+			}
+			continue
+		}
+
+		run := schemaApplyYes && (!step.Destructive() || schemaApplyAllowDelete)
+		if !run {
+			if schemaApplyYes {
+				fmt.Println("      Skipped: class deletions need --allow-delete")
+				skipped++
+				continue
+			}
+			answer := prompt(reader, "      Run this step? [y/N/q] ")
+			if answer == "q" {
+				fmt.Println("Stopped.")
+				break
+			}
+			if answer != "y" && answer != "yes" {
+				skipped++
+				continue
+			}
+		}
+
+		ok, err := core.ApplyMigrationStep(ctx, c.Client, step)
+		if err != nil {
+			exitError("step %d: %v", i+1, err)
+		}
+		if ok {
+			applied++
+		} else {
+			fmt.Println("      Already in place")
+		}
+	}
+
+	fmt.Printf("\nApplied %d step(s), skipped %d\n", applied, skipped)
+}
+
+// prompt prints a question and returns the lowercased answer
+func prompt(reader *bufio.Reader, question string) string {
+	fmt.Print(question)
+	answer, _ := reader.ReadString('\n')
+	return strings.TrimSpace(strings.ToLower(answer))
+}
+
+// ask prompts for a yes or no answer; anything but yes is no
+func ask(reader *bufio.Reader, question string) bool {
+	answer := prompt(reader, question)
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kilupskalvis/wvc/internal/core"
+	"github.com/spf13/cobra"
+)
+
+var scratchCmd = &cobra.Command{
+	Use:   "scratch",
+	Short: "Manage scratch checkouts",
+	Long: `Manage scratch checkouts: copies of a commit's classes created with
+'wvc checkout <ref> --scratch'. A scratch's classes are named
+WvcScratch<id>_<Class> and are ignored by status, commits, and checkouts.
+
+Examples:
+  wvc scratch list                  List the scratches in Weaviate
+  wvc scratch drop 3fa2c1d0         Delete a scratch's classes`,
+}
+
+var scratchListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List scratch checkouts",
+	Args:  cobra.NoArgs,
+	Run:   runScratchList,
+}
+
+var scratchDropCmd = &cobra.Command{
+	Use:   "drop <id>...",
+	Short: "Delete scratch checkouts",
+	Args:  cobra.MinimumNArgs(1),
+	Run:   runScratchDrop,
+}
+
+func init() {
+	scratchCmd.AddCommand(scratchListCmd)
+	scratchCmd.AddCommand(scratchDropCmd)
+}
+
+func runScratchList(cmd *cobra.Command, args []string) {
+	c := initFullContext()
+	defer c.Close()
+
+	scratches, err := core.ListScratches(context.Background(), c.Client)
+	if err != nil {
+		exitError("%v", err)
+	}
+	if len(scratches) == 0 {
+		fmt.Println("No scratch checkouts")
+		return
+	}
+
+	t := &table{}
+	for _, s := range scratches {
+		classes := make([]string, len(s.Classes))
+		for i, name := range s.Classes {
+			classes[i] = strings.TrimPrefix(name, core.ScratchPrefix+s.ID+"_")
+		}
+		t.addRow(cell(s.ID, colorCommit), cell(fmt.Sprintf("%d class(es)", len(s.Classes)), colorMuted), cell(strings.Join(classes, ", "), nil))
+	}
+	t.print()
+}
+
+func runScratchDrop(cmd *cobra.Command, args []string) {
+	c := initFullContext()
+	defer c.Close()
+
+	for _, id := range args {
+		dropped, err := core.DropScratch(context.Background(), c.Client, id)
+		if err != nil {
+			exitError("%v", err)
+		}
+		fmt.Printf("Dropped scratch %s (%d class(es))\n", id, len(dropped))
+	}
+}
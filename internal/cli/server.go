@@ -1,26 +1,34 @@
 package cli
 
 import (
+	"bytes"
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net"
 	"net/http"
+	"net/http/pprof"
+	"net/url"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/fatih/color"
 	"github.com/kilupskalvis/wvc/internal/remote"
 	"github.com/kilupskalvis/wvc/internal/remote/blobstore"
+	"github.com/kilupskalvis/wvc/internal/remote/conformance"
 	"github.com/kilupskalvis/wvc/internal/remote/metastore"
 	"github.com/kilupskalvis/wvc/internal/remote/server"
 	"github.com/spf13/cobra"
@@ -35,12 +43,37 @@ var (
 	serverTLSKey        string
 	serverWebhookURLs   string
 	serverWebhookSecret string
+	serverBlobStore     string
+	serverMetaStore     string
+	serverLockURL       string
+	serverMetaReplicas  string
+	serverSignedURLTTL  string
+	serverHealthListen  string
+	serverPprofListen   string
+	serverDrainDelay    string
+	serverStopTimeout   string
+	serverApprovals     string
+	serverGCInterval    string
+	serverGCGrace       string
+	serverMirrorEvery   string
+	serverSnapshotEvery string
+	serverRejectPII     bool
+	serverPolicyURL     string
+	serverPolicySecret  string
 
 	serverAdminURL        string
 	serverAdminToken      string
 	serverTokenDesc       string
 	serverTokenRepos      []string
 	serverTokenPermission string
+	serverTokenExpires    string
+	serverTokenBranches   []string
+	serverTokenScopes     []string
+	serverMirrorRepo      string
+	serverMirrorToken     string
+
+	serverConformanceRepo  string
+	serverConformanceToken string
 )
 
 var serverCmd = &cobra.Command{
@@ -55,15 +88,62 @@ var serverStartCmd = &cobra.Command{
 	Long: `Start the WVC remote server.
 
 The server stores commit metadata in bbolt and vector blobs on the local
-filesystem. Bearer token authentication is required for all repo endpoints.
+filesystem under --data-dir. Either can be moved to another storage driver
+with --meta-store and --blob-store, selected by URL scheme. The built-in
+drivers are bbolt:// and postgres:// for metadata and file://, gs:// (Google
+Cloud Storage), and azblob:// (Azure Blob Storage) for blobs; further drivers
+register themselves with metastore.Register and blobstore.Register when
+compiled in.
+Bearer token authentication is required for all repo endpoints.
+
+Pushes and GC take a per-repository write lock. It is held in-process by
+default; to run several replicas against shared storage, point --lock-url at
+Redis so the lock is shared between them. The metadata store must then
+support access from several processes, which bbolt does not; use postgres://.
+
+Read-only endpoints (pull negotiation, bundle, schema, branch, and info
+reads) can be served from metadata replicas given by --meta-store-replicas,
+spreading read-heavy load such as CI pulls; writes always go to --meta-store.
+
+With --signed-url-expiry, vector downloads from gs:// and azblob:// blob
+stores are redirected to short-lived signed URLs, so clients fetch the bytes
+straight from the bucket instead of through the server.
+
+The /healthz, /readyz, and /startupz probes are served on the main listener
+and, with --health-listen, on a separate plain-HTTP port. Readiness writes to
+the metadata and blob stores to verify they accept writes. On SIGTERM the
+server fails readiness and ends event streams, waits --shutdown-delay for
+load balancers to notice, then drains in-flight requests for up to
+--shutdown-timeout before closing the stores. --pprof serves net/http/pprof
+on its own listener for live profiling; bind it to localhost.
 
 The admin token is read from the WVC_ADMIN_TOKEN environment variable and
 enables the /admin/ endpoints for token management and garbage collection.
 
+Proposals opened with 'wvc proposal' are merged by the server once
+--required-approvals tokens with the reviewer scope have approved them.
+Approvals, merges, and refused merges are kept in the repository's audit
+log.
+
+Repositories can be mirrored to other wvc servers with 'wvc server mirrors'.
+Every push is forwarded to the mirrors in the background, and every
+--mirror-interval all mirrors are caught up after failures or restarts.
+Mirrors are stored in mirrors.json under --data-dir.
+
+Webhook deliveries are queued in webhook-queue.json under --data-dir and
+retried with exponential backoff, so events survive restarts. The admin
+endpoint /admin/webhooks/deliveries lists pending and recent deliveries.
+
 Examples:
   wvc server start
   wvc server start --listen 0.0.0.0:8720 --data-dir /var/lib/wvc
-  wvc server start --tls-cert server.crt --tls-key server.key`,
+  wvc server start --tls-cert server.crt --tls-key server.key
+  wvc server start --blob-store file:///mnt/vectors
+  wvc server start --blob-store gs://my-bucket/wvc
+  wvc server start --blob-store gs://my-bucket/wvc --signed-url-expiry 5m
+  wvc server start --meta-store postgres://wvc@db:5432/wvc?sslmode=verify-full
+  wvc server start --health-listen 0.0.0.0:8721 --shutdown-delay 10s
+  wvc server start --pprof 127.0.0.1:6060`,
 	Run: runServerStart,
 }
 
@@ -71,6 +151,8 @@ func init() {
 	serverCmd.AddCommand(serverStartCmd)
 	serverCmd.AddCommand(serverTokensCmd)
 	serverCmd.AddCommand(serverReposCmd)
+	serverCmd.AddCommand(serverMirrorsCmd)
+	serverCmd.AddCommand(serverConformanceCmd)
 
 	f := serverStartCmd.Flags()
 	f.StringVar(&serverListen, "listen", envOrDefault("WVC_LISTEN", "127.0.0.1:8720"), "Listen address (host:port)")
@@ -81,11 +163,28 @@ func init() {
 	f.StringVar(&serverTLSKey, "tls-key", os.Getenv("WVC_TLS_KEY"), "TLS key file")
 	f.StringVar(&serverWebhookURLs, "webhook-urls", os.Getenv("WVC_WEBHOOK_URLS"), "Comma-separated webhook URLs to notify on push")
 	f.StringVar(&serverWebhookSecret, "webhook-secret", os.Getenv("WVC_WEBHOOK_SECRET"), "HMAC secret for signing webhook payloads")
+	f.StringVar(&serverBlobStore, "blob-store", os.Getenv("WVC_BLOB_STORE"), "Blob store URL (default: file://<data-dir>/repos)")
+	f.StringVar(&serverLockURL, "lock-url", os.Getenv("WVC_LOCK_URL"), "Redis URL for repo locks shared between replicas (redis://host:6379/0)")
+	f.StringVar(&serverMetaStore, "meta-store", os.Getenv("WVC_META_STORE"), "Metadata store URL (default: bbolt://<data-dir>/repos)")
+	f.StringVar(&serverMetaReplicas, "meta-store-replicas", os.Getenv("WVC_META_STORE_REPLICAS"), "Comma-separated metadata store URLs to serve read-only endpoints from")
+	f.StringVar(&serverSignedURLTTL, "signed-url-expiry", os.Getenv("WVC_SIGNED_URL_EXPIRY"), "Redirect vector downloads to signed blob store URLs valid this long, e.g. 5m (gs:// and azblob:// only)")
+	f.StringVar(&serverHealthListen, "health-listen", os.Getenv("WVC_HEALTH_LISTEN"), "Separate listen address for the health probes (host:port)")
+	f.StringVar(&serverPprofListen, "pprof", os.Getenv("WVC_PPROF"), "Listen address for the pprof profiling endpoints (host:port)")
+	f.StringVar(&serverDrainDelay, "shutdown-delay", envOrDefault("WVC_SHUTDOWN_DELAY", "0s"), "Time between failing readiness and draining connections on shutdown")
+	f.StringVar(&serverStopTimeout, "shutdown-timeout", envOrDefault("WVC_SHUTDOWN_TIMEOUT", "30s"), "Maximum time to wait for in-flight requests on shutdown")
+	f.StringVar(&serverApprovals, "required-approvals", envOrDefault("WVC_REQUIRED_APPROVALS", "0"), "Reviewer approvals a proposal needs before it can be merged")
+	f.StringVar(&serverGCInterval, "gc-interval", os.Getenv("WVC_GC_INTERVAL"), "Garbage collect every repo this often, e.g. 6h (default: only on request)")
+	f.StringVar(&serverGCGrace, "gc-grace-period", envOrDefault("WVC_GC_GRACE_PERIOD", "1h"), "Minimum time a blob stays unreferenced before scheduled GC deletes it")
+	f.StringVar(&serverMirrorEvery, "mirror-interval", envOrDefault("WVC_MIRROR_INTERVAL", "5m"), "Catch every mirror up this often, in addition to replicating each push (0 disables)")
+	f.StringVar(&serverSnapshotEvery, "snapshot-interval", envOrDefault("WVC_SNAPSHOT_INTERVAL", strconv.Itoa(server.DefaultSnapshotInterval)), "Commits between state snapshots that commit state requests replay from (0 disables)")
+	f.StringVar(&serverPolicyURL, "policy-url", os.Getenv("WVC_POLICY_URL"), "Policy endpoint asked to allow each branch update, e.g. an OPA data API URL")
+	f.StringVar(&serverPolicySecret, "policy-secret", os.Getenv("WVC_POLICY_SECRET"), "HMAC secret for signing policy requests")
+	f.BoolVar(&serverRejectPII, "reject-pii", os.Getenv("WVC_REJECT_PII") == "true", "Reject pushed commits carrying classes classified as pii:restricted")
 
 	// Shared admin connection flags. PersistentFlags are inherited by all subcommands.
-	// Both parents bind the same package-level vars — safe because only one command
+	// All parents bind the same package-level vars — safe because only one command
 	// path executes at runtime.
-	for _, cmd := range []*cobra.Command{serverTokensCmd, serverReposCmd} {
+	for _, cmd := range []*cobra.Command{serverTokensCmd, serverReposCmd, serverMirrorsCmd} {
 		cmd.PersistentFlags().StringVar(&serverAdminURL, "url",
 			envOrDefault("WVC_SERVER_URL", ""),
 			"Server base URL (env: WVC_SERVER_URL)")
@@ -93,15 +192,34 @@ func init() {
 			os.Getenv("WVC_ADMIN_TOKEN"),
 			"Admin token (env: WVC_ADMIN_TOKEN)")
 	}
+	serverMirrorsCmd.PersistentFlags().Lookup("admin-token").Usage =
+		"Admin token, or a token with the repo-admin scope on the repository (env: WVC_ADMIN_TOKEN)"
 
 	serverTokensCmd.AddCommand(serverTokensCreateCmd, serverTokensListCmd, serverTokensDeleteCmd)
-	serverReposCmd.AddCommand(serverReposCreateCmd, serverReposListCmd, serverReposDeleteCmd)
+	serverReposCmd.AddCommand(serverReposCreateCmd, serverReposListCmd, serverReposDeleteCmd, serverReposQuotaCmd)
+	serverReposQuotaCmd.Flags().Int64Var(&serverQuotaBlobBytes, "max-blob-bytes", 0, "Maximum bytes of vectors and payloads stored (0 is unlimited)")
+	serverReposQuotaCmd.Flags().IntVar(&serverQuotaCommits, "max-commits", 0, "Maximum number of commits (0 is unlimited)")
+	serverMirrorsCmd.AddCommand(serverMirrorsAddCmd, serverMirrorsListCmd, serverMirrorsRemoveCmd, serverMirrorsSyncCmd)
+
+	mf := serverMirrorsAddCmd.Flags()
+	mf.StringVar(&serverMirrorRepo, "remote-repo", "", "Repository name on the mirror server (default: same name)")
+	mf.StringVar(&serverMirrorToken, "token", os.Getenv("WVC_MIRROR_TOKEN"), "Token for the mirror server, with pull, push, and branch-delete scopes (env: WVC_MIRROR_TOKEN)")
+
+	cf := serverConformanceCmd.Flags()
+	cf.StringVar(&serverAdminURL, "url", envOrDefault("WVC_SERVER_URL", ""), "Server base URL (env: WVC_SERVER_URL)")
+	cf.StringVar(&serverConformanceRepo, "repo", "conformance", "Scratch repository to run against; it must exist")
+	cf.StringVar(&serverConformanceToken, "token", os.Getenv("WVC_CONFORMANCE_TOKEN"), "Read-write token for the repository (env: WVC_CONFORMANCE_TOKEN)")
 
 	tf := serverTokensCreateCmd.Flags()
 	tf.StringVar(&serverTokenDesc, "desc", "", "Token description")
 	tf.StringArrayVar(&serverTokenRepos, "repo", nil,
 		"Repos to grant access to, repeat for multiple (default: *)")
 	tf.StringVar(&serverTokenPermission, "permission", "rw", "Permission level: ro or rw")
+	tf.StringVar(&serverTokenExpires, "expires", "", "Expire the token after this long, e.g. 30d or 12h (default: never)")
+	tf.StringArrayVar(&serverTokenBranches, "branch", nil,
+		"Branch pattern the token may update or delete, e.g. 'release/*'; repeat for multiple (default: all)")
+	tf.StringArrayVar(&serverTokenScopes, "scope", nil,
+		"Scope to grant: push, pull, branch-delete, gc, repo-admin, or reviewer; repeat for multiple (default: those of --permission)")
 }
 
 func runServerStart(_ *cobra.Command, _ []string) {
@@ -137,19 +255,105 @@ func runServerStart(_ *cobra.Command, _ []string) {
 		os.Exit(1)
 	}
 
+	blobURL, metaURL, err := storeURLs(reposDir)
+	if err != nil {
+		logger.Error("invalid storage configuration", "error", err)
+		os.Exit(1)
+	}
+	replicaURLs, err := replicaStoreURLs()
+	if err != nil {
+		logger.Error("invalid storage configuration", "error", err)
+		os.Exit(1)
+	}
+	if len(replicaURLs) > 0 {
+		logger.Info("metadata read replicas configured", "count", len(replicaURLs))
+	}
+
 	tokens := newFileTokenStore(filepath.Join(serverDataDir, "tokens.json"), logger)
 	if err := tokens.Load(); err != nil {
 		logger.Warn("no token store loaded — creating empty", "error", err)
 	}
 
 	repos := &diskRepoOpener{
-		reposDir: reposDir,
-		stores:   make(map[string]*repoEntry),
-		logger:   logger,
+		reposDir:    reposDir,
+		blobURL:     blobURL,
+		metaURL:     metaURL,
+		replicaURLs: replicaURLs,
+		stores:      make(map[string]*repoEntry),
+		logger:      logger,
+	}
+
+	drainDelay, err := time.ParseDuration(serverDrainDelay)
+	if err != nil || drainDelay < 0 {
+		logger.Error("invalid shutdown delay: must be a duration", "value", serverDrainDelay)
+		os.Exit(1)
+	}
+	stopTimeout, err := time.ParseDuration(serverStopTimeout)
+	if err != nil || stopTimeout <= 0 {
+		logger.Error("invalid shutdown timeout: must be a positive duration", "value", serverStopTimeout)
+		os.Exit(1)
 	}
 
+	health := server.NewHealth()
+	health.AddCheck("token store", func(context.Context) error {
+		_, err := tokens.ListTokens()
+		return err
+	})
+	health.AddCheck("metadata store", repos.probeMetaStore)
+	health.AddCheck("blob store", repos.probeBlobStore)
+
 	cfg := server.DefaultServerConfig()
 	cfg.AdminToken = os.Getenv("WVC_ADMIN_TOKEN")
+	cfg.Events = server.NewEventBroker()
+	cfg.Health = health
+	if serverSignedURLTTL != "" {
+		cfg.SignedURLExpiry, err = time.ParseDuration(serverSignedURLTTL)
+		if err != nil || cfg.SignedURLExpiry <= 0 {
+			logger.Error("invalid signed URL expiry: must be a positive duration", "value", serverSignedURLTTL)
+			os.Exit(1)
+		}
+		logger.Info("redirecting vector downloads to signed URLs", "expiry", cfg.SignedURLExpiry)
+	}
+	cfg.RequiredApprovals, err = strconv.Atoi(serverApprovals)
+	if err != nil || cfg.RequiredApprovals < 0 {
+		logger.Error("invalid required approvals: must be a non-negative number", "value", serverApprovals)
+		os.Exit(1)
+	}
+	if serverGCInterval != "" {
+		cfg.GCInterval, err = time.ParseDuration(serverGCInterval)
+		if err != nil || cfg.GCInterval <= 0 {
+			logger.Error("invalid gc interval: must be a positive duration", "value", serverGCInterval)
+			os.Exit(1)
+		}
+	}
+	cfg.GCGracePeriod, err = time.ParseDuration(serverGCGrace)
+	if err != nil || cfg.GCGracePeriod < 0 {
+		logger.Error("invalid gc grace period: must be a duration", "value", serverGCGrace)
+		os.Exit(1)
+	}
+	cfg.MirrorInterval, err = time.ParseDuration(serverMirrorEvery)
+	if err != nil || cfg.MirrorInterval < 0 {
+		logger.Error("invalid mirror interval: must be a duration", "value", serverMirrorEvery)
+		os.Exit(1)
+	}
+	cfg.SnapshotInterval, err = strconv.Atoi(serverSnapshotEvery)
+	if err != nil || cfg.SnapshotInterval < 0 {
+		logger.Error("invalid snapshot interval: must be a non-negative number of commits", "value", serverSnapshotEvery)
+		os.Exit(1)
+	}
+	cfg.RejectPII = serverRejectPII
+	cfg.PolicyURL = serverPolicyURL
+	cfg.PolicySecret = serverPolicySecret
+	if cfg.PolicyURL != "" {
+		logger.Info("evaluating branch updates against push policy", "url", cfg.PolicyURL)
+	}
+	mirrors := newFileMirrorStore(filepath.Join(serverDataDir, "mirrors.json"))
+	if err := mirrors.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		logger.Error("failed to load mirrors", "error", err)
+		os.Exit(1)
+	}
+	cfg.Mirrors = mirrors
+	cfg.WebhookQueue = &fileWebhookQueue{path: filepath.Join(serverDataDir, "webhook-queue.json")}
 
 	if serverWebhookURLs != "" {
 		urls := strings.Split(serverWebhookURLs, ",")
@@ -169,7 +373,19 @@ func runServerStart(_ *cobra.Command, _ []string) {
 		}
 	}
 
-	h, handlerCleanup := server.Handler(repos, tokens, cfg, logger, repos, repos)
+	var locker server.RepoLocker = repos
+	if serverLockURL != "" {
+		redisLocker, err := server.NewRedisLocker(serverLockURL, logger)
+		if err != nil {
+			logger.Error("invalid lock configuration", "error", err)
+			os.Exit(1)
+		}
+		defer redisLocker.Close()
+		locker = redisLocker
+		logger.Info("using distributed repo locks", "backend", "redis")
+	}
+
+	h, handlerCleanup := server.Handler(repos, tokens, cfg, logger, locker, repos)
 	defer handlerCleanup()
 
 	srv := &http.Server{
@@ -182,11 +398,20 @@ func runServerStart(_ *cobra.Command, _ []string) {
 		BaseContext:       func(_ net.Listener) context.Context { return context.Background() },
 	}
 
+	var sideServers []*http.Server
+	if serverHealthListen != "" {
+		sideServers = append(sideServers, startSideServer(logger, "health", serverHealthListen, health.Handler()))
+	}
+	if serverPprofListen != "" {
+		sideServers = append(sideServers, startSideServer(logger, "pprof", serverPprofListen, pprofHandler()))
+	}
+
 	done := make(chan os.Signal, 1)
 	signal.Notify(done, os.Interrupt, syscall.SIGTERM)
 
 	go func() {
-		logger.Info("starting wvc server", "listen", serverListen, "data_dir", serverDataDir)
+		logger.Info("starting wvc server", "listen", serverListen, "data_dir", serverDataDir,
+			"meta_store", metaURL.Scheme, "blob_store", blobURL.Scheme)
 		var err error
 		if serverTLSCert != "" && serverTLSKey != "" {
 			err = srv.ListenAndServeTLS(serverTLSCert, serverTLSKey)
@@ -200,19 +425,58 @@ func runServerStart(_ *cobra.Command, _ []string) {
 	}()
 
 	<-done
-	logger.Info("shutting down...")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	// Fail readiness first so load balancers stop sending new requests while
+	// the listener still accepts the ones already routed here
+	logger.Info("shutting down...", "phase", "drain", "delay", drainDelay)
+	health.Drain()
+	time.Sleep(drainDelay)
 
+	logger.Info("shutting down...", "phase", "shutdown", "timeout", stopTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), stopTimeout)
+	defer cancel()
 	if err := srv.Shutdown(ctx); err != nil {
-		logger.Error("shutdown error", "error", err)
+		logger.Error("shutdown error", "phase", "shutdown", "error", err)
 	}
 
+	logger.Info("shutting down...", "phase", "close stores")
 	repos.CloseAll()
+
+	for _, side := range sideServers {
+		side.Close()
+	}
 	logger.Info("server stopped")
 }
 
+// startSideServer serves h on addr in the background, for the health and
+// pprof listeners.
+func startSideServer(logger *slog.Logger, name, addr string, h http.Handler) *http.Server {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           h,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		logger.Info("starting "+name+" listener", "listen", addr)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error(name+" listener error", "error", err)
+			os.Exit(1)
+		}
+	}()
+	return srv
+}
+
+// pprofHandler serves the net/http/pprof endpoints under /debug/pprof/.
+func pprofHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return mux
+}
+
 // defaultDataDir returns the default server data directory (~/.wvc-server).
 func defaultDataDir() string {
 	home, err := os.UserHomeDir()
@@ -230,23 +494,78 @@ func envOrDefault(key, defaultVal string) string {
 	return defaultVal
 }
 
-// diskRepoOpener manages bbolt + filesystem stores per repository, opening them lazily.
+// storeURLs returns the blob and metadata store URLs from --blob-store and
+// --meta-store, defaulting to the built-in drivers rooted at reposDir.
+func storeURLs(reposDir string) (blobURL, metaURL *url.URL, err error) {
+	absDir, err := filepath.Abs(reposDir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("resolve repos directory: %w", err)
+	}
+
+	blobURL = &url.URL{Scheme: "file", Path: absDir}
+	if serverBlobStore != "" {
+		if blobURL, err = blobstore.ParseURL(serverBlobStore); err != nil {
+			return nil, nil, err
+		}
+	}
+	metaURL = &url.URL{Scheme: "bbolt", Path: absDir}
+	if serverMetaStore != "" {
+		if metaURL, err = metastore.ParseURL(serverMetaStore); err != nil {
+			return nil, nil, err
+		}
+	}
+	return blobURL, metaURL, nil
+}
+
+// replicaStoreURLs parses the --meta-store-replicas URLs.
+func replicaStoreURLs() ([]*url.URL, error) {
+	var urls []*url.URL
+	for _, raw := range strings.Split(serverMetaReplicas, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		u, err := metastore.ParseURL(raw)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, u)
+	}
+	return urls, nil
+}
+
+// diskRepoOpener manages the stores of each repository, opening them lazily
+// through the configured storage drivers. Repositories themselves are
+// directories under reposDir.
 type diskRepoOpener struct {
 	reposDir string
-	mu       sync.RWMutex
-	stores   map[string]*repoEntry
-	logger   *slog.Logger
+	blobURL  *url.URL
+	metaURL  *url.URL
+
+	// replicaURLs are metadata stores that serve read-only endpoints
+	replicaURLs []*url.URL
+	mu          sync.RWMutex
+	stores      map[string]*repoEntry
+	logger      *slog.Logger
 }
 
 type repoEntry struct {
 	meta    metastore.MetaStore
 	blobs   blobstore.BlobStore
 	writeMu sync.Mutex
+
+	replicaMu   sync.Mutex
+	replicas    []metastore.MetaStore
+	nextReplica atomic.Uint64
 }
 
 // Open returns the MetaStore and BlobStore for the named repository.
 // The repository directory must already exist under reposDir.
 func (d *diskRepoOpener) Open(name string) (metastore.MetaStore, blobstore.BlobStore, error) {
+	if !validRepoName(name) {
+		return nil, nil, fmt.Errorf("invalid repository name: %q", name)
+	}
+
 	d.mu.RLock()
 	entry, ok := d.stores[name]
 	d.mu.RUnlock()
@@ -262,21 +581,17 @@ func (d *diskRepoOpener) Open(name string) (metastore.MetaStore, blobstore.BlobS
 		return entry.meta, entry.blobs, nil
 	}
 
-	if strings.ContainsAny(name, "/\\") || name == ".." || name == "." || name == "" {
-		return nil, nil, fmt.Errorf("invalid repository name: %q", name)
-	}
-
 	repoDir := filepath.Join(d.reposDir, name)
 	if _, err := os.Stat(repoDir); os.IsNotExist(err) {
 		return nil, nil, fmt.Errorf("repository '%s' not found", name)
 	}
 
-	meta, err := metastore.NewBboltStore(filepath.Join(repoDir, "meta.db"))
+	meta, err := metastore.Open(d.metaURL, name)
 	if err != nil {
 		return nil, nil, fmt.Errorf("open metastore for %s: %w", name, err)
 	}
 
-	blobs, err := blobstore.NewFSStore(filepath.Join(repoDir, "blobs"))
+	blobs, err := blobstore.Open(d.blobURL, name)
 	if err != nil {
 		meta.Close()
 		return nil, nil, fmt.Errorf("open blobstore for %s: %w", name, err)
@@ -288,14 +603,66 @@ func (d *diskRepoOpener) Open(name string) (metastore.MetaStore, blobstore.BlobS
 	return meta, blobs, nil
 }
 
+// OpenRead returns the stores for read-only requests, with the MetaStore of
+// one of the read replicas in turn. Items a lagging replica does not hold
+// yet are read from the primary, so a pull spread over several replicas
+// never sees a commit disappear. It falls back to the primary entirely when
+// no replicas are configured or they cannot be opened.
+func (d *diskRepoOpener) OpenRead(name string) (metastore.MetaStore, blobstore.BlobStore, error) {
+	meta, blobs, err := d.Open(name)
+	if err != nil || len(d.replicaURLs) == 0 {
+		return meta, blobs, err
+	}
+
+	d.mu.RLock()
+	entry, ok := d.stores[name]
+	d.mu.RUnlock()
+	if !ok {
+		return meta, blobs, nil
+	}
+
+	entry.replicaMu.Lock()
+	if entry.replicas == nil {
+		replicas, err := d.openReplicas(name)
+		if err != nil {
+			entry.replicaMu.Unlock()
+			d.logger.Warn("open metastore replicas, reading from primary", "repo", name, "error", err)
+			return meta, blobs, nil
+		}
+		entry.replicas = replicas
+	}
+	replicas := entry.replicas
+	entry.replicaMu.Unlock()
+
+	n := entry.nextReplica.Add(1)
+	return metastore.WithFallback(replicas[n%uint64(len(replicas))], meta), blobs, nil
+}
+
+// openReplicas opens the replica MetaStores of a repository.
+func (d *diskRepoOpener) openReplicas(name string) ([]metastore.MetaStore, error) {
+	var replicas []metastore.MetaStore
+	for _, u := range d.replicaURLs {
+		replica, err := metastore.Open(u, name)
+		if err != nil {
+			for _, r := range replicas {
+				r.Close()
+			}
+			return nil, fmt.Errorf("open replica %s: %w", u.Redacted(), err)
+		}
+		replicas = append(replicas, replica)
+	}
+	return replicas, nil
+}
+
 // LockWrite acquires the per-repo write mutex, blocking concurrent GC and push operations.
-func (d *diskRepoOpener) LockWrite(name string) {
+func (d *diskRepoOpener) LockWrite(_ context.Context, name string) error {
 	d.mu.RLock()
 	entry, ok := d.stores[name]
 	d.mu.RUnlock()
 	if ok {
 		entry.writeMu.Lock()
 	}
+	return nil
 }
 
 // UnlockWrite releases the per-repo write mutex.
@@ -314,17 +681,36 @@ func (d *diskRepoOpener) CloseAll() {
 	defer d.mu.Unlock()
 
 	for name, entry := range d.stores {
-		if err := entry.meta.Close(); err != nil {
-			d.logger.Error("close metastore", "repo", name, "error", err)
-		}
+		d.closeEntry(name, entry)
 	}
 	d.stores = make(map[string]*repoEntry)
 }
 
+// closeEntry closes a repository's stores. Blob stores are closed only when
+// their driver holds resources (implements io.Closer).
+func (d *diskRepoOpener) closeEntry(name string, entry *repoEntry) {
+	if err := entry.meta.Close(); err != nil {
+		d.logger.Error("close metastore", "repo", name, "error", err)
+	}
+	entry.replicaMu.Lock()
+	for _, replica := range entry.replicas {
+		if err := replica.Close(); err != nil {
+			d.logger.Error("close metastore replica", "repo", name, "error", err)
+		}
+	}
+	entry.replicas = nil
+	entry.replicaMu.Unlock()
+	if c, ok := entry.blobs.(io.Closer); ok {
+		if err := c.Close(); err != nil {
+			d.logger.Error("close blobstore", "repo", name, "error", err)
+		}
+	}
+}
+
 // Create initialises a new repository directory under reposDir.
 // Returns an error containing "already exists" if the repo is present.
 func (d *diskRepoOpener) Create(name string) error {
-	if strings.ContainsAny(name, "/\\") || name == ".." || name == "." || name == "" {
+	if !validRepoName(name) {
 		return fmt.Errorf("invalid repository name: %q", name)
 	}
 
@@ -345,8 +731,13 @@ func (d *diskRepoOpener) Create(name string) error {
 }
 
 // Delete removes a repository, closing and evicting any open stores first.
+// Data kept outside reposDir by other storage drivers is left in place.
 // Returns an error containing "not found" if the repo directory does not exist.
 func (d *diskRepoOpener) Delete(name string) error {
+	if !validRepoName(name) {
+		return fmt.Errorf("repository '%s' not found", name)
+	}
+
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
@@ -361,9 +752,7 @@ func (d *diskRepoOpener) Delete(name string) error {
 		entry.writeMu.Lock()
 		defer entry.writeMu.Unlock()
 
-		if err := entry.meta.Close(); err != nil {
-			d.logger.Error("close metastore on delete", "repo", name, "error", err)
-		}
+		d.closeEntry(name, entry)
 		delete(d.stores, name)
 	}
 
@@ -383,13 +772,75 @@ func (d *diskRepoOpener) List() ([]string, error) {
 	}
 	var names []string
 	for _, e := range entries {
-		if e.IsDir() {
+		if e.IsDir() && e.Name() != storageProbeRepo {
 			names = append(names, e.Name())
 		}
 	}
 	return names, nil
 }
 
+// validRepoName reports whether name can be used as a repository name.
+func validRepoName(name string) bool {
+	return name != "" && name != "." && name != ".." && name != storageProbeRepo &&
+		!strings.ContainsAny(name, "/\\")
+}
+
+// storageProbeRepo is the store that readiness checks write to. It is hidden
+// from listings and cannot be used as a repository.
+const storageProbeRepo = ".wvc-probe"
+
+// probeStores opens the readiness probe stores through the configured drivers.
+func (d *diskRepoOpener) probeStores() (metastore.MetaStore, blobstore.BlobStore, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if entry, ok := d.stores[storageProbeRepo]; ok {
+		return entry.meta, entry.blobs, nil
+	}
+
+	meta, err := metastore.Open(d.metaURL, storageProbeRepo)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open metastore: %w", err)
+	}
+	blobs, err := blobstore.Open(d.blobURL, storageProbeRepo)
+	if err != nil {
+		meta.Close()
+		return nil, nil, fmt.Errorf("open blobstore: %w", err)
+	}
+	d.stores[storageProbeRepo] = &repoEntry{meta: meta, blobs: blobs}
+	return meta, blobs, nil
+}
+
+// probeMetaStore verifies that the metadata store accepts writes.
+func (d *diskRepoOpener) probeMetaStore(ctx context.Context) error {
+	meta, _, err := d.probeStores()
+	if err != nil {
+		return err
+	}
+	const branch = "probe"
+	if err := meta.DeleteBranch(ctx, branch); err != nil && !errors.Is(err, metastore.ErrNotFound) {
+		return err
+	}
+	if err := meta.CreateBranch(ctx, branch, time.Now().UTC().Format(time.RFC3339Nano)); err != nil {
+		return err
+	}
+	return meta.DeleteBranch(ctx, branch)
+}
+
+// probeBlobStore verifies that the blob store accepts writes.
+func (d *diskRepoOpener) probeBlobStore(ctx context.Context) error {
+	_, blobs, err := d.probeStores()
+	if err != nil {
+		return err
+	}
+	data := []byte(time.Now().UTC().Format(time.RFC3339Nano))
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	if err := blobs.Put(ctx, hash, bytes.NewReader(data), 0); err != nil {
+		return err
+	}
+	return blobs.Delete(ctx, hash)
+}
+
 // fileTokenStore is a JSON-file-backed implementation of server.TokenStore.
 // Tokens are stored as hashed values; the raw token is only returned on creation.
 type fileTokenStore struct {
@@ -466,16 +917,17 @@ func (s *fileTokenStore) Save() error {
 
 // CreateToken generates a new bearer token, persists it, and returns the raw value.
 // The raw token is only available at creation time; only its hash is stored.
-func (s *fileTokenStore) CreateToken(desc string, repos []string, permission string) (string, *server.TokenInfo, error) {
+func (s *fileTokenStore) CreateToken(desc string, repos []string, permission string, limits server.TokenLimits) (string, *server.TokenInfo, error) {
 	rawToken := fmt.Sprintf("wvc_%s", generateServerID())
 	tokenHash := server.HashToken(rawToken)
 
 	info := &server.TokenInfo{
-		ID:         generateServerID(),
-		TokenHash:  tokenHash,
-		Desc:       desc,
-		Repos:      repos,
-		Permission: permission,
+		ID:          generateServerID(),
+		TokenHash:   tokenHash,
+		Desc:        desc,
+		Repos:       repos,
+		Permission:  permission,
+		TokenLimits: limits,
 	}
 
 	s.mu.Lock()
@@ -536,6 +988,141 @@ func (s *fileTokenStore) DeleteToken(id string) error {
 	return nil
 }
 
+// fileMirrorStore is a JSON-file-backed implementation of server.MirrorStore.
+// The file holds mirror tokens, so it is only readable by its owner.
+type fileMirrorStore struct {
+	path    string
+	mu      sync.RWMutex
+	mirrors map[string][]*server.Mirror // keyed by repo
+}
+
+func newFileMirrorStore(path string) *fileMirrorStore {
+	return &fileMirrorStore{path: path, mirrors: make(map[string][]*server.Mirror)}
+}
+
+// Load reads the mirror store from disk.
+func (s *fileMirrorStore) Load() error {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+	mirrors := make(map[string][]*server.Mirror)
+	if err := json.Unmarshal(data, &mirrors); err != nil {
+		return fmt.Errorf("parse mirror store: %w", err)
+	}
+
+	s.mu.Lock()
+	s.mirrors = mirrors
+	s.mu.Unlock()
+	return nil
+}
+
+// ListMirrors returns copies of the mirrors of a repository.
+func (s *fileMirrorStore) ListMirrors(repo string) ([]*server.Mirror, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	mirrors := make([]*server.Mirror, 0, len(s.mirrors[repo]))
+	for _, m := range s.mirrors[repo] {
+		c := *m
+		mirrors = append(mirrors, &c)
+	}
+	return mirrors, nil
+}
+
+// PutMirror adds a mirror to a repository, replacing one with the same name.
+func (s *fileMirrorStore) PutMirror(repo string, m *server.Mirror) error {
+	c := *m
+	return s.update(func(mirrors map[string][]*server.Mirror) error {
+		for i, existing := range mirrors[repo] {
+			if existing.Name == m.Name {
+				mirrors[repo][i] = &c
+				return nil
+			}
+		}
+		mirrors[repo] = append(mirrors[repo], &c)
+		return nil
+	})
+}
+
+// DeleteMirror removes a mirror from a repository.
+func (s *fileMirrorStore) DeleteMirror(repo, name string) error {
+	return s.update(func(mirrors map[string][]*server.Mirror) error {
+		for i, m := range mirrors[repo] {
+			if m.Name == name {
+				mirrors[repo] = append(mirrors[repo][:i:i], mirrors[repo][i+1:]...)
+				if len(mirrors[repo]) == 0 {
+					delete(mirrors, repo)
+				}
+				return nil
+			}
+		}
+		return server.ErrMirrorNotFound
+	})
+}
+
+// update applies fn to a copy of the mirrors and persists the result,
+// keeping the in-memory state unchanged when either fails.
+func (s *fileMirrorStore) update(fn func(map[string][]*server.Mirror) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	mirrors := make(map[string][]*server.Mirror, len(s.mirrors))
+	for repo, list := range s.mirrors {
+		mirrors[repo] = append([]*server.Mirror(nil), list...)
+	}
+	if err := fn(mirrors); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(mirrors, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal mirrors: %w", err)
+	}
+	if err := os.WriteFile(s.path, data, 0600); err != nil {
+		return fmt.Errorf("persist mirrors: %w", err)
+	}
+	s.mirrors = mirrors
+	return nil
+}
+
+// fileWebhookQueue is a JSON-file-backed implementation of server.WebhookQueueStore.
+type fileWebhookQueue struct {
+	path string
+}
+
+// LoadDeliveries reads the queued deliveries; a missing file is an empty queue.
+func (q *fileWebhookQueue) LoadDeliveries() ([]*server.WebhookDelivery, error) {
+	data, err := os.ReadFile(q.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var deliveries []*server.WebhookDelivery
+	if err := json.Unmarshal(data, &deliveries); err != nil {
+		return nil, fmt.Errorf("parse webhook queue: %w", err)
+	}
+	return deliveries, nil
+}
+
+// SaveDeliveries replaces the queue file. It holds webhook secrets, so it is
+// only readable by the server's user.
+func (q *fileWebhookQueue) SaveDeliveries(deliveries []*server.WebhookDelivery) error {
+	data, err := json.Marshal(deliveries)
+	if err != nil {
+		return fmt.Errorf("marshal webhook queue: %w", err)
+	}
+	tmp := q.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("persist webhook queue: %w", err)
+	}
+	if err := os.Rename(tmp, q.path); err != nil {
+		return fmt.Errorf("persist webhook queue: %w", err)
+	}
+	return nil
+}
+
 // generateServerID returns a cryptographically random 16-byte hex string.
 func generateServerID() string {
 	b := make([]byte, 16)
@@ -600,6 +1187,108 @@ var serverReposDeleteCmd = &cobra.Command{
 	Run:   runServerReposDelete,
 }
 
+var serverReposQuotaCmd = &cobra.Command{
+	Use:   "quota <name>",
+	Short: "Show or set a repository's storage quota",
+	Long: `Show or set a repository's storage quota.
+
+Without flags the quota is shown. Each flag given replaces that limit; 0
+removes it. Uploads of new vectors and payloads beyond --max-blob-bytes are
+rejected, as are new commits beyond --max-commits. Data already stored
+beyond a lowered limit is kept.
+
+Only the admin token can change quotas.
+
+Examples:
+  wvc server repos quota my-repo
+  wvc server repos quota my-repo --max-blob-bytes 10737418240 --max-commits 5000
+  wvc server repos quota my-repo --max-commits 0`,
+	Args: cobra.ExactArgs(1),
+	Run:  runServerReposQuota,
+}
+
+var (
+	serverQuotaBlobBytes int64
+	serverQuotaCommits   int
+)
+
+// --- wvc server mirrors ---
+
+var serverMirrorsCmd = &cobra.Command{
+	Use:   "mirrors",
+	Short: "Manage repository mirrors",
+	Long: `Commands for mirroring repositories on a running wvc server to other wvc
+servers.
+
+A mirror receives every commit, vector, branch, and tag pushed to the
+repository, in the background after each push. Branches and tags deleted on
+this server are deleted on the mirror, and mirror branches follow this
+server even when they were moved there directly. 'list' shows each mirror's
+last replication, its lag, and the refs it has not received yet.
+
+Besides the admin token, a token with the repo-admin scope on the
+repository can manage its mirrors.`,
+}
+
+var serverMirrorsAddCmd = &cobra.Command{
+	Use:   "add <repo> <name> <url>",
+	Short: "Add or replace a mirror of a repository",
+	Long: `Add or replace a mirror of a repository.
+
+The token must be valid on the mirror server, with the pull, push, and
+branch-delete scopes on the mirror repository.
+
+Examples:
+  wvc server mirrors add vectors dr https://wvc-dr.example.com --token wvc_...
+  wvc server mirrors add vectors eu https://wvc-eu.example.com --remote-repo vectors-eu`,
+	Args: cobra.ExactArgs(3),
+	Run:  runServerMirrorsAdd,
+}
+
+var serverMirrorsListCmd = &cobra.Command{
+	Use:   "list <repo>",
+	Short: "List the mirrors of a repository with their replication status",
+	Args:  cobra.ExactArgs(1),
+	Run:   runServerMirrorsList,
+}
+
+var serverMirrorsRemoveCmd = &cobra.Command{
+	Use:   "remove <repo> <name>",
+	Short: "Remove a mirror; data already replicated stays on it",
+	Args:  cobra.ExactArgs(2),
+	Run:   runServerMirrorsRemove,
+}
+
+var serverMirrorsSyncCmd = &cobra.Command{
+	Use:   "sync <repo> <name>",
+	Short: "Replicate a repository to a mirror now",
+	Args:  cobra.ExactArgs(2),
+	Run:   runServerMirrorsSync,
+}
+
+var serverConformanceCmd = &cobra.Command{
+	Use:   "conformance",
+	Short: "Check that a server implements the remote protocol",
+	Long: `Run the protocol conformance suite against a server.
+
+The suite sends a fixed matrix of requests covering push and pull
+negotiation, branch compare-and-swap, gzip bodies, request limits, and error
+responses, and checks each answer against what wvc clients expect. Use it to
+prove that an alternative server implementation or storage backend is
+compatible.
+
+The suite uploads commits and vectors to the repository and creates and
+deletes a branch named conformance-<random>, so point it at a scratch
+repository. The command exits non-zero if any check fails.
+
+Examples:
+  wvc server repos create conformance
+  wvc server conformance --url https://wvc.example.com --token $TOKEN
+  wvc server conformance --url http://127.0.0.1:8720 --repo scratch`,
+	Args: cobra.NoArgs,
+	Run:  runServerConformance,
+}
+
 // resolveAdminClient builds an AdminClient from the package-level admin flag vars.
 func resolveAdminClient() *remote.AdminClient {
 	if serverAdminURL == "" {
@@ -620,7 +1309,17 @@ func runServerTokensCreate(_ *cobra.Command, _ []string) {
 		repos = []string{"*"}
 	}
 
-	resp, err := c.CreateToken(ctx, serverTokenDesc, repos, serverTokenPermission)
+	limits := remote.AdminTokenLimits{Branches: serverTokenBranches, Scopes: serverTokenScopes}
+	if serverTokenExpires != "" {
+		ttl, err := parseTokenTTL(serverTokenExpires)
+		if err != nil {
+			exitError("%v", err)
+		}
+		expiresAt := time.Now().Add(ttl).UTC()
+		limits.ExpiresAt = &expiresAt
+	}
+
+	resp, err := c.CreateToken(ctx, serverTokenDesc, repos, serverTokenPermission, limits)
 	if err != nil {
 		exitError("%v", err)
 	}
@@ -633,6 +1332,15 @@ func runServerTokensCreate(_ *cobra.Command, _ []string) {
 	fmt.Printf("  Description: %s\n", resp.Description)
 	fmt.Printf("  Repos:       %s\n", strings.Join(resp.Repos, ", "))
 	fmt.Printf("  Permission:  %s\n", resp.Permission)
+	if len(resp.Scopes) > 0 {
+		fmt.Printf("  Scopes:      %s\n", strings.Join(resp.Scopes, ", "))
+	}
+	if len(resp.Branches) > 0 {
+		fmt.Printf("  Branches:    %s\n", strings.Join(resp.Branches, ", "))
+	}
+	if resp.ExpiresAt != nil {
+		fmt.Printf("  Expires:     %s\n", resp.ExpiresAt.Format(time.RFC3339))
+	}
 	fmt.Println()
 	green.Printf("Token: %s\n", resp.Token)
 	yellow.Println("Save this token — it will not be shown again.")
@@ -651,17 +1359,47 @@ func runServerTokensList(_ *cobra.Command, _ []string) {
 		return
 	}
 
-	fmt.Printf("  %-32s  %-20s  %-16s  %s\n", "ID", "Description", "Repos", "Permission")
+	fmt.Printf("  %-32s  %-20s  %-16s  %-10s  %-20s  %s\n", "ID", "Description", "Repos", "Permission", "Expires", "Limits")
 	for _, t := range tokens {
-		fmt.Printf("  %-32s  %-20s  %-16s  %s\n",
+		expires := "never"
+		if t.ExpiresAt != nil {
+			expires = t.ExpiresAt.Format(time.RFC3339)
+		}
+		var limits []string
+		if len(t.Scopes) > 0 {
+			limits = append(limits, "scopes="+strings.Join(t.Scopes, ","))
+		}
+		if len(t.Branches) > 0 {
+			limits = append(limits, "branches="+strings.Join(t.Branches, ","))
+		}
+		fmt.Printf("  %-32s  %-20s  %-16s  %-10s  %-20s  %s\n",
 			t.ID,
 			t.Description,
 			strings.Join(t.Repos, ","),
 			t.Permission,
+			expires,
+			strings.Join(limits, " "),
 		)
 	}
 }
 
+// parseTokenTTL parses a token lifetime: a Go duration such as "12h", or a
+// whole number of days such as "30d".
+func parseTokenTTL(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid --expires %q: want a positive number of days, e.g. 30d", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	ttl, err := time.ParseDuration(s)
+	if err != nil || ttl <= 0 {
+		return 0, fmt.Errorf("invalid --expires %q: want a positive duration, e.g. 30d or 12h", s)
+	}
+	return ttl, nil
+}
+
 func runServerTokensDelete(_ *cobra.Command, args []string) {
 	c := resolveAdminClient()
 	ctx := context.Background()
@@ -709,3 +1447,145 @@ func runServerReposDelete(_ *cobra.Command, args []string) {
 
 	fmt.Printf("Deleted repository '%s'\n", args[0])
 }
+
+func runServerReposQuota(cmd *cobra.Command, args []string) {
+	c := resolveAdminClient()
+	ctx := context.Background()
+
+	quota, err := c.GetQuota(ctx, args[0])
+	if err != nil {
+		exitError("%v", err)
+	}
+	if cmd.Flags().Changed("max-blob-bytes") || cmd.Flags().Changed("max-commits") {
+		if cmd.Flags().Changed("max-blob-bytes") {
+			quota.MaxBlobBytes = serverQuotaBlobBytes
+		}
+		if cmd.Flags().Changed("max-commits") {
+			quota.MaxCommits = serverQuotaCommits
+		}
+		if err := c.PutQuota(ctx, args[0], quota); err != nil {
+			exitError("%v", err)
+		}
+	}
+
+	fmt.Printf("Repository '%s'\n", args[0])
+	fmt.Printf("  Max blob bytes: %s\n", quotaLimit(quota.MaxBlobBytes, formatBytes))
+	fmt.Printf("  Max commits:    %s\n", quotaLimit(int64(quota.MaxCommits), func(n int64) string { return strconv.FormatInt(n, 10) }))
+}
+
+// quotaLimit renders a quota limit, zero being unlimited.
+func quotaLimit(n int64, format func(int64) string) string {
+	if n <= 0 {
+		return "unlimited"
+	}
+	return format(n)
+}
+
+func runServerMirrorsAdd(_ *cobra.Command, args []string) {
+	c := resolveAdminClient()
+	ctx := context.Background()
+
+	if serverMirrorToken == "" {
+		exitError("--token or WVC_MIRROR_TOKEN is required")
+	}
+	m := remote.AdminMirror{Name: args[1], URL: args[2], Repo: serverMirrorRepo, Token: serverMirrorToken}
+	if err := c.PutMirror(ctx, args[0], m); err != nil {
+		exitError("%v", err)
+	}
+
+	green := color.New(color.FgGreen)
+	green.Printf("Mirroring '%s' to %s as '%s'\n", args[0], args[2], args[1])
+}
+
+func runServerMirrorsList(_ *cobra.Command, args []string) {
+	c := resolveAdminClient()
+	ctx := context.Background()
+
+	mirrors, err := c.ListMirrors(ctx, args[0])
+	if err != nil {
+		exitError("%v", err)
+	}
+
+	red := color.New(color.FgRed)
+	yellow := color.New(color.FgYellow)
+	for _, m := range mirrors {
+		target := m.URL
+		if m.Repo != "" {
+			target += " (" + m.Repo + ")"
+		}
+		fmt.Printf("%s  %s\n", m.Name, target)
+		last := "never"
+		if !m.Status.LastSuccess.IsZero() {
+			last = m.Status.LastSuccess.Local().Format(time.RFC3339)
+		}
+		fmt.Printf("  Last success: %s\n", last)
+		if m.Status.PendingSince != nil {
+			yellow.Printf("  Lag:          %s\n", (time.Duration(m.Status.LagSeconds) * time.Second).String())
+		}
+		if len(m.Status.OutOfSync) > 0 {
+			yellow.Printf("  Out of sync:  %s\n", strings.Join(m.Status.OutOfSync, ", "))
+		}
+		if m.Status.Error != "" {
+			red.Printf("  Error:        %s\n", m.Status.Error)
+		}
+	}
+}
+
+func runServerMirrorsRemove(_ *cobra.Command, args []string) {
+	c := resolveAdminClient()
+	ctx := context.Background()
+
+	if err := c.DeleteMirror(ctx, args[0], args[1]); err != nil {
+		exitError("%v", err)
+	}
+
+	fmt.Printf("Removed mirror '%s' of '%s'\n", args[1], args[0])
+}
+
+func runServerMirrorsSync(_ *cobra.Command, args []string) {
+	c := resolveAdminClient()
+	ctx := context.Background()
+
+	status, err := c.SyncMirror(ctx, args[0], args[1])
+	if err != nil {
+		exitError("%v", err)
+	}
+
+	green := color.New(color.FgGreen)
+	green.Printf("Mirror '%s' is up to date\n", args[1])
+	fmt.Printf("  Sent %d commit(s) and %d vector(s)\n", status.CommitsSent, status.VectorsSent)
+}
+
+func runServerConformance(_ *cobra.Command, _ []string) {
+	if serverAdminURL == "" {
+		exitError("--url or WVC_SERVER_URL is required")
+	}
+	if serverConformanceToken == "" {
+		exitError("--token or WVC_CONFORMANCE_TOKEN is required")
+	}
+
+	results := conformance.Run(context.Background(), conformance.Options{
+		URL:   serverAdminURL,
+		Repo:  serverConformanceRepo,
+		Token: serverConformanceToken,
+	})
+
+	green := color.New(color.FgGreen)
+	red := color.New(color.FgRed)
+	for _, res := range results {
+		if res.Passed() {
+			green.Printf("  ok    ")
+			fmt.Println(res.Name)
+			continue
+		}
+		red.Printf("  FAIL  ")
+		fmt.Printf("%s: %v\n", res.Name, res.Err)
+	}
+
+	failed := conformance.Failed(results)
+	fmt.Println()
+	if failed > 0 {
+		exitError("%d of %d conformance checks failed", failed, len(results))
+	}
+	green.Printf("All %d conformance checks passed\n", len(results))
+}
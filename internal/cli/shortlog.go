@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/core"
+	"github.com/spf13/cobra"
+)
+
+var shortlogCmd = &cobra.Command{
+	Use:   "shortlog",
+	Short: "Summarize commit activity by author and by class",
+	Long: `Summarize commits by author and by class, with the number of objects each
+added, updated, and deleted. Merge commits are not counted; their changes are
+counted in the commits they merged.
+
+Commits record the author from the [user] table of .wvc/config (name and
+email), overridden by WVC_AUTHOR_NAME and WVC_AUTHOR_EMAIL. Commits made
+before authors were recorded are listed as (unknown).
+
+Examples:
+  wvc shortlog                  Summarize all commits
+  wvc shortlog --since 30d      Summarize the last 30 days
+  wvc shortlog --since 2w --by class
+  wvc shortlog --since 2026-01-01`,
+	Args: cobra.NoArgs,
+	Run:  runShortlog,
+}
+
+var (
+	shortlogSince string
+	shortlogBy    string
+)
+
+func init() {
+	shortlogCmd.Flags().StringVar(&shortlogSince, "since", "", "Only count commits since a duration ago (30d, 2w, 12h) or a date")
+	shortlogCmd.Flags().StringVar(&shortlogBy, "by", "", "Show only one summary: author or class")
+}
+
+func runShortlog(cmd *cobra.Command, args []string) {
+	c := initContext()
+	defer c.Close()
+
+	if shortlogBy != "" && shortlogBy != "author" && shortlogBy != "class" {
+		exitError("--by must be 'author' or 'class'")
+	}
+	since, err := core.ParseSince(shortlogSince, time.Now())
+	if err != nil {
+		exitError("%v", err)
+	}
+
+	result, err := core.Shortlog(c.Store, since)
+	if err != nil {
+		exitError("%v", err)
+	}
+	if result.Commits == 0 {
+		fmt.Println("No commits in range")
+		return
+	}
+
+	if since.IsZero() {
+		fmt.Printf("%d commit(s)\n", result.Commits)
+	} else {
+		fmt.Printf("%d commit(s) since %s\n", result.Commits, since.Format("2006-01-02 15:04"))
+	}
+	if shortlogBy != "class" {
+		fmt.Println("\nBy author:")
+		printShortlogEntries(result.Authors)
+	}
+	if shortlogBy != "author" {
+		fmt.Println("\nBy class:")
+		printShortlogEntries(result.Classes)
+	}
+}
+
+func printShortlogEntries(entries []*core.ShortlogEntry) {
+	t := &table{indent: "  "}
+	for _, e := range entries {
+		t.addRow(
+			cell(strconv.Itoa(e.Commits), colorCommit),
+			cell("+"+strconv.Itoa(e.Added), colorAdded),
+			cell("~"+strconv.Itoa(e.Updated), colorModified),
+			cell("-"+strconv.Itoa(e.Deleted), colorDeleted),
+			cell(e.Name, nil),
+		)
+	}
+	t.print()
+}
@@ -2,6 +2,8 @@ package cli
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 
 	"github.com/fatih/color"
 	"github.com/kilupskalvis/wvc/internal/core"
@@ -13,9 +15,23 @@ import (
 var showCmd = &cobra.Command{
 	Use:   "show [commit]",
 	Short: "Show commit details",
-	Long:  `Show details about a specific commit including all operations.`,
-	Args:  cobra.MaximumNArgs(1),
-	Run:   runShow,
+	Long: `Show details about a specific commit including all operations.
+
+For a merge commit, the operations listed are those applied on top of the
+first parent. Use -m to show the full diff against each parent instead.
+
+Examples:
+  wvc show                  Show the HEAD commit
+  wvc show abc1234          Show a specific commit
+  wvc show -m abc1234       Show a merge commit's diff against each parent`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runShow,
+}
+
+var showMergeParents bool
+
+func init() {
+	showCmd.Flags().BoolVarP(&showMergeParents, "merge-parents", "m", false, "For merge commits, show the diff against each parent")
 }
 
 func runShow(cmd *cobra.Command, args []string) {
@@ -60,17 +76,45 @@ func runShow(cmd *cobra.Command, args []string) {
 	}
 	fmt.Println()
 
-	if commit.ParentID != "" {
+	if commit.IsMergeCommit() {
+		fmt.Printf("Merge:  %s %s\n", shortID(commit.ParentID), shortID(commit.MergeParentID))
+	} else if commit.ParentID != "" {
 		fmt.Printf("Parent: %s\n", shortID(commit.ParentID))
 	}
+	if commit.Author != "" {
+		fmt.Printf("Author: %s\n", commit.Author)
+	}
 	fmt.Printf("Date:   %s\n", commit.Timestamp.Format("Mon Jan 2 15:04:05 2006"))
 	fmt.Printf("\n    %s\n\n", commit.Message)
+	if note, err := st.GetNote(commit.ID); err == nil {
+		printNote(note)
+	}
+	if len(commit.Classifications) > 0 {
+		fmt.Println("Classifications:")
+		for _, class := range slices.Sorted(maps.Keys(commit.Classifications)) {
+			fmt.Printf("  %s: %s\n", class, commit.Classifications[class])
+		}
+		fmt.Println()
+	}
 
 	// Show schema changes if present
 	if hasSchemaChange {
 		showCommitSchemaChanges(st, commit.ID, green, red, yellow, magenta)
 	}
 
+	if commit.IsMergeCommit() && showMergeParents {
+		showMergeParentDiffs(st, commit.ID, green, red, yellow)
+		return
+	}
+
+	if len(commit.ParentSummaries) > 0 {
+		fmt.Println("Changes by parent:")
+		for _, s := range commit.ParentSummaries {
+			fmt.Printf("  %s: %d added, %d updated, %d deleted\n", shortID(s.ParentID), s.Added, s.Updated, s.Deleted)
+		}
+		fmt.Println()
+	}
+
 	// Get operations for this commit
 	operations, err := st.GetOperationsByCommit(commit.ID)
 	if err != nil {
@@ -85,6 +129,14 @@ func runShow(cmd *cobra.Command, args []string) {
 	if len(operations) > 0 {
 		fmt.Printf("Data Operations (%d):\n", len(operations))
 		for _, op := range operations {
+			switch {
+			case op.MovedTo != "":
+				// Shown with the insert half of the move
+				continue
+			case op.MovedFrom != "":
+				color.New(color.FgCyan).Printf("  > MOVE   %s -> %s/%s\n", op.MovedFrom, op.ClassName, op.ObjectID)
+				continue
+			}
 			switch op.Type {
 			case models.OperationInsert:
 				green.Printf("  + INSERT %s/%s\n", op.ClassName, shortID(op.ObjectID))
@@ -139,3 +191,35 @@ func showCommitSchemaChanges(st *store.Store, commitID string, green, red, yello
 
 	fmt.Println()
 }
+
+// showMergeParentDiffs displays what a merge commit changed relative to each parent
+func showMergeParentDiffs(st *store.Store, commitID string, green, red, yellow *color.Color) {
+	diffs, err := core.DiffMergeCommit(st, commitID)
+	if err != nil {
+		exitError("failed to diff merge commit: %v", err)
+	}
+
+	for i, pd := range diffs {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("Diff against parent %d (%s), %d change(s):\n", i+1, shortID(pd.ParentID), pd.Diff.TotalChanges())
+		if pd.Diff.TotalChanges() == 0 {
+			fmt.Println("  (no changes)")
+			continue
+		}
+		for _, c := range pd.Diff.Inserted {
+			green.Printf("  + INSERT %s/%s\n", c.ClassName, shortID(c.ObjectID))
+		}
+		for _, c := range pd.Diff.Updated {
+			if c.VectorOnly {
+				yellow.Printf("  ~ UPDATE %s/%s (vector)\n", c.ClassName, shortID(c.ObjectID))
+			} else {
+				yellow.Printf("  ~ UPDATE %s/%s\n", c.ClassName, shortID(c.ObjectID))
+			}
+		}
+		for _, c := range pd.Diff.Deleted {
+			red.Printf("  - DELETE %s/%s\n", c.ClassName, shortID(c.ObjectID))
+		}
+	}
+}
@@ -7,12 +7,16 @@ import (
 	"github.com/fatih/color"
 	"github.com/kilupskalvis/wvc/internal/core"
 	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/remote"
 	"github.com/spf13/cobra"
 )
 
 var (
-	stashMessage string
-	stashRestage bool // --index flag for pop/apply
+	stashMessage  string
+	stashRestage  bool // --index flag for pop/apply
+	stashToRemote bool
+	stashRemote   string
+	stashKeep     bool
 )
 
 var stashCmd = &cobra.Command{
@@ -25,6 +29,8 @@ When run without a subcommand, acts as 'stash push'.
 Examples:
   wvc stash                       Save all changes to a new stash
   wvc stash -m "work in progress" Save with a custom message
+  wvc stash push --to-remote      Save and upload the stash to the remote
+  wvc stash fetch                 Move your stashes from the remote to here
   wvc stash list                  List all stashes
   wvc stash pop                   Apply and remove the latest stash
   wvc stash apply stash@{1}       Apply a specific stash without removing
@@ -37,8 +43,26 @@ Examples:
 var stashPushCmd = &cobra.Command{
 	Use:   "push [-m <message>]",
 	Short: "Save changes to a new stash",
-	Long:  `Save all uncommitted changes (staged and unstaged) and restore Weaviate to the last committed state.`,
-	Run:   runStashPush,
+	Long: `Save all uncommitted changes (staged and unstaged) and restore Weaviate to the last committed state.
+
+With --to-remote, the new stash is also uploaded to the remote, stored under
+the identity of your token, so it can be picked up on another machine with
+'wvc stash fetch'. The stash's base commit must already be pushed.`,
+	Run: runStashPush,
+}
+
+var stashFetchCmd = &cobra.Command{
+	Use:   "fetch [<remote>]",
+	Short: "Download stashes pushed with --to-remote",
+	Long: `Download the stashes stored on the remote under your token's identity and
+add them to the local stash list. Fetched stashes are removed from the remote
+unless --keep is given. Stashes already present locally are not duplicated.
+
+Examples:
+  wvc stash fetch                   Move stashes from the default remote
+  wvc stash fetch origin --keep     Copy stashes, leaving them on 'origin'`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runStashFetch,
 }
 
 var stashListCmd = &cobra.Command{
@@ -90,6 +114,11 @@ var stashClearCmd = &cobra.Command{
 func init() {
 	stashCmd.Flags().StringVarP(&stashMessage, "message", "m", "", "Stash message")
 	stashPushCmd.Flags().StringVarP(&stashMessage, "message", "m", "", "Stash message")
+	for _, cmd := range []*cobra.Command{stashCmd, stashPushCmd} {
+		cmd.Flags().BoolVar(&stashToRemote, "to-remote", false, "Also upload the stash to the remote")
+		cmd.Flags().StringVar(&stashRemote, "remote", "", "Remote to upload to with --to-remote (default: the only configured remote)")
+	}
+	stashFetchCmd.Flags().BoolVar(&stashKeep, "keep", false, "Leave fetched stashes on the remote")
 	stashPopCmd.Flags().BoolVar(&stashRestage, "index", false, "Reinstate previously staged changes to the staging area")
 	stashApplyCmd.Flags().BoolVar(&stashRestage, "index", false, "Reinstate previously staged changes to the staging area")
 
@@ -100,6 +129,7 @@ func init() {
 	stashCmd.AddCommand(stashDropCmd)
 	stashCmd.AddCommand(stashShowCmd)
 	stashCmd.AddCommand(stashClearCmd)
+	stashCmd.AddCommand(stashFetchCmd)
 }
 
 func runStashPush(cmd *cobra.Command, args []string) {
@@ -116,6 +146,16 @@ func runStashPush(cmd *cobra.Command, args []string) {
 		exitError("%v", err)
 	}
 
+	if jsonOutput() {
+		doc := &stashResultJSON{Index: result.StashIndex, Message: result.Message, Staged: result.StagedCount, Unstaged: result.UnstagedCount, Warnings: warningsJSON(result.Warnings)}
+		if stashToRemote {
+			remoteName, rs := pushStashToRemote(bgCtx, c, result.StashIndex)
+			doc.Remote, doc.RemoteID = remoteName, rs.ID
+		}
+		printJSON(doc)
+		return
+	}
+
 	green := color.New(color.FgGreen)
 	yellow := color.New(color.FgYellow)
 
@@ -132,6 +172,79 @@ func runStashPush(cmd *cobra.Command, args []string) {
 	for _, w := range result.Warnings {
 		yellow.Printf("Warning: %s\n", w.Message)
 	}
+
+	if stashToRemote {
+		remoteName, rs := pushStashToRemote(bgCtx, c, result.StashIndex)
+		green.Printf("Uploaded stash to %s (%s)\n", remoteName, shortID(rs.ID))
+	}
+}
+
+// pushStashToRemote uploads a stash that was just saved for --to-remote
+func pushStashToRemote(ctx context.Context, c *cmdContext, index int) (string, *remote.RemoteStash) {
+	remoteName, err := core.ResolveRemote(c.Store, stashRemote)
+	if err != nil {
+		exitError("stash saved locally, but not uploaded: %v", err)
+	}
+	client := resolveRemoteClientByName(c.Store, remoteName)
+	rs, err := core.StashPushToRemote(ctx, c.Store, client, index, nil)
+	if err != nil {
+		exitError("stash saved locally, but not uploaded: %v", err)
+	}
+	return remoteName, rs
+}
+
+func runStashFetch(cmd *cobra.Command, args []string) {
+	c := initContextWithMigrations()
+	defer c.Close()
+
+	remoteName := ""
+	if len(args) > 0 {
+		remoteName = args[0]
+	}
+	remoteName, err := core.ResolveRemote(c.Store, remoteName)
+	if err != nil {
+		exitError("%v", err)
+	}
+	client := resolveRemoteClientByName(c.Store, remoteName)
+
+	result, err := core.StashFetchFromRemote(context.Background(), c.Store, client, stashKeep, nil)
+	if err != nil {
+		exitError("%v", err)
+	}
+
+	if jsonOutput() {
+		fetched := []stashJSON{}
+		for _, e := range result.Fetched {
+			fetched = append(fetched, stashEntryJSON(e))
+		}
+		printJSON(map[string]interface{}{
+			"remote":          remoteName,
+			"fetched":         fetched,
+			"already_present": result.AlreadyPresent,
+			"vectors_fetched": result.VectorsFetched,
+			"removed":         result.Removed,
+		})
+		return
+	}
+
+	if len(result.Fetched) == 0 {
+		fmt.Printf("No new stashes on %s\n", remoteName)
+	}
+	cyan := color.New(color.FgCyan)
+	for _, e := range result.Fetched {
+		cyan.Printf("stash@{%d}", e.Index)
+		fmt.Printf(": On %s: %s\n", displayStashBranch(e.BranchName), e.Message)
+	}
+	if result.Removed > 0 {
+		fmt.Printf("Removed %d stash(es) from %s\n", result.Removed, remoteName)
+	}
+}
+
+func displayStashBranch(branch string) string {
+	if branch == "" {
+		return "(detached)"
+	}
+	return branch
 }
 
 func runStashList(cmd *cobra.Command, args []string) {
@@ -143,20 +256,30 @@ func runStashList(cmd *cobra.Command, args []string) {
 		exitError("%v", err)
 	}
 
+	if jsonOutput() {
+		list := []stashJSON{}
+		for _, e := range entries {
+			list = append(list, stashEntryJSON(e))
+		}
+		printJSON(list)
+		return
+	}
+
 	if len(entries) == 0 {
 		fmt.Println("No stashes")
 		return
 	}
 
-	cyan := color.New(color.FgCyan)
+	t := &table{}
 	for _, e := range entries {
-		branch := e.BranchName
-		if branch == "" {
-			branch = "(detached)"
-		}
-		cyan.Printf("stash@{%d}", e.Index)
-		fmt.Printf(": On %s: %s\n", branch, e.Message)
+		t.addRow(
+			cell(fmt.Sprintf("stash@{%d}:", e.Index), colorRef),
+			cell("On "+displayStashBranch(e.BranchName)+":", nil),
+			cell(e.CreatedAt.Format("2006-01-02 15:04"), colorMuted),
+			cell(firstLine(e.Message), nil),
+		)
 	}
+	t.print()
 }
 
 func runStashPop(cmd *cobra.Command, args []string) {
@@ -176,7 +299,7 @@ func runStashPop(cmd *cobra.Command, args []string) {
 		exitError("%v", err)
 	}
 
-	displayStashApplyResult(result, true)
+	displayStashApplyResult(result, index, true)
 }
 
 func runStashApply(cmd *cobra.Command, args []string) {
@@ -196,7 +319,7 @@ func runStashApply(cmd *cobra.Command, args []string) {
 		exitError("%v", err)
 	}
 
-	displayStashApplyResult(result, false)
+	displayStashApplyResult(result, index, false)
 }
 
 func runStashDrop(cmd *cobra.Command, args []string) {
@@ -210,6 +333,11 @@ func runStashDrop(cmd *cobra.Command, args []string) {
 		exitError("%v", err)
 	}
 
+	if jsonOutput() {
+		printJSON(map[string]interface{}{"dropped": index, "message": msg})
+		return
+	}
+
 	green := color.New(color.FgGreen)
 	green.Printf("Dropped stash@{%d} (%s)\n", index, msg)
 }
@@ -225,6 +353,15 @@ func runStashShow(cmd *cobra.Command, args []string) {
 		exitError("%v", err)
 	}
 
+	if jsonOutput() {
+		printJSON(stashShowJSON{
+			stashJSON: stashJSON{Index: index, Branch: result.BranchName, CommitID: result.CommitID, Message: result.Message},
+			Staged:    stashChangesJSON(result.StagedChanges),
+			Unstaged:  stashChangesJSON(result.UnstagedChanges),
+		})
+		return
+	}
+
 	cyan := color.New(color.FgCyan)
 	green := color.New(color.FgGreen)
 	yellow := color.New(color.FgYellow)
@@ -268,6 +405,11 @@ func runStashClear(cmd *cobra.Command, args []string) {
 		exitError("%v", err)
 	}
 
+	if jsonOutput() {
+		printJSON(map[string]int{"cleared": count})
+		return
+	}
+
 	if count == 0 {
 		fmt.Println("No stashes to clear")
 	} else {
@@ -287,7 +429,12 @@ func parseStashArg(args []string) int {
 	return index
 }
 
-func displayStashApplyResult(result *core.StashApplyResult, dropped bool) {
+func displayStashApplyResult(result *core.StashApplyResult, index int, dropped bool) {
+	if jsonOutput() {
+		printJSON(&stashResultJSON{Index: index, Message: result.Message, Staged: result.StagedCount, Unstaged: result.UnstagedCount, Dropped: dropped, Warnings: warningsJSON(result.Warnings)})
+		return
+	}
+
 	green := color.New(color.FgGreen)
 	yellow := color.New(color.FgYellow)
 
@@ -5,16 +5,21 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/fatih/color"
 	"github.com/kilupskalvis/wvc/internal/core"
 	"github.com/spf13/cobra"
 )
 
 var statusCmd = &cobra.Command{
-	Use:   "status",
+	Use:   "status [<pathspec>...]",
 	Short: "Show the working tree status",
-	Long:  `Show the current status of the Weaviate database compared to the last commit.`,
-	Run:   runStatus,
+	Long: `Show the current status of the Weaviate database compared to the last commit.
+
+Pathspecs limit the listed changes to matching classes and objects.
+
+Examples:
+  wvc status                      Show all changes
+  wvc status Article/ Author/a*   Show changes to Article and some Author objects`,
+	Run: runStatus,
 }
 
 func runStatus(cmd *cobra.Command, args []string) {
@@ -24,15 +29,31 @@ func runStatus(cmd *cobra.Command, args []string) {
 
 	st, client := c.Store, c.Client
 
+	spec, err := core.ParsePathspec(args)
+	if err != nil {
+		exitError("%v", err)
+	}
+
+	if jsonOutput() {
+		printJSON(statusDocument(bgCtx, c, spec))
+		return
+	}
+
 	// Show branch info
 	currentBranch, _ := st.GetCurrentBranch()
 	head, _ := st.GetHEAD()
 
 	if currentBranch != "" {
 		fmt.Printf("On branch %s\n", currentBranch)
+		if branch, err := st.GetBranch(currentBranch); err == nil && branch != nil && len(branch.Classes) > 0 {
+			fmt.Printf("Scoped to classes: %s\n", strings.Join(branch.Classes, ", "))
+		}
 	} else if head != "" {
 		fmt.Printf("HEAD detached at %s\n", shortID(head))
 	}
+	if sparse, _ := st.GetSparseClasses(); len(sparse) > 0 {
+		fmt.Printf("Sparse checkout of classes: %s\n", strings.Join(sparse, ", "))
+	}
 
 	if head != "" {
 		commit, err := st.GetCommit(head)
@@ -43,55 +64,96 @@ func runStatus(cmd *cobra.Command, args []string) {
 		fmt.Println("No commits yet")
 	}
 
-	schemaDiff, err := core.ComputeSchemaDiff(bgCtx, st, client)
+	if progress, err := st.GetApplyProgress(); err == nil && progress != nil && len(progress.Failed) > 0 {
+		colorDeleted.Printf("\n%d object write(s) failed while restoring %s\n", len(progress.Failed), shortID(progress.CommitID))
+		fmt.Println("  (use \"wvc restore --retry-failed\" to retry them)")
+	}
+
+	if state, err := st.GetBisectState(); err == nil && state != nil {
+		colorModified.Printf("\nYou are bisecting, started from %s\n", state.Original)
+		fmt.Println("  (use \"wvc bisect reset\" to get back to the original branch)")
+	}
+
+	if state, err := st.GetMergeState(); err == nil && state != nil {
+		remaining := len(state.Unresolved())
+		colorModified.Printf("\nYou are merging branch '%s' (%s), %d of %d conflict(s) remaining\n",
+			state.TargetBranch, shortID(state.TheirHead), remaining, len(state.Conflicts))
+		if remaining > 0 {
+			fmt.Println("  (use \"wvc merge --resolve <object> --ours|--theirs\" to resolve a conflict)")
+			for _, c := range state.Unresolved() {
+				fmt.Printf("        %s: %s\n", c.Type, c.Key)
+			}
+		} else {
+			fmt.Println("  (all conflicts resolved: run \"wvc merge --continue\" to conclude the merge)")
+		}
+		fmt.Println("  (use \"wvc merge --abort\" to abort the merge)")
+	}
+
+	schemaDiff, err := core.ComputeSchemaDiff(bgCtx, c.Config, st, client)
 	if err != nil {
 		schemaDiff = &core.SchemaDiffResult{}
 	}
+	schemaDiff = spec.FilterSchemaDiff(schemaDiff)
 
 	diff, err := core.ComputeIncrementalDiff(bgCtx, c.Config, st, client)
 	if err != nil {
 		exitError("failed to compute diff: %v", err)
 	}
+	diff = spec.FilterIncrementalDiff(diff)
 
 	stagedCount := diff.TotalStagedChanges()
 	unstagedCount := diff.TotalUnstagedChanges()
 	schemaChanges := schemaDiff.TotalChanges()
 
-	if stagedCount == 0 && unstagedCount == 0 && schemaChanges == 0 {
+	submodules, err := core.ListSubmodules(c.Config, st)
+	if err != nil {
+		exitError("%v", err)
+	}
+	var bumped []*core.SubmoduleInfo
+	for _, info := range submodules {
+		if info.Pinned != info.Committed {
+			bumped = append(bumped, info)
+		}
+	}
+
+	if stagedCount == 0 && unstagedCount == 0 && schemaChanges == 0 && len(bumped) == 0 {
 		fmt.Println("\nNothing to commit, working tree clean")
 		return
 	}
 
-	green := color.New(color.FgGreen)
-	red := color.New(color.FgRed)
-	yellow := color.New(color.FgYellow)
-	cyan := color.New(color.FgCyan)
-	magenta := color.New(color.FgMagenta)
+	// Submodule pins are committed whenever they change
+	if len(bumped) > 0 {
+		fmt.Println("\nSubmodule pins:")
+		fmt.Println()
+		for _, info := range bumped {
+			fmt.Printf("        %s: %s -> %s\n", info.Name, shortOrNone(info.Committed), shortOrNone(info.Pinned))
+		}
+	}
 
 	// Show schema changes first
 	if schemaChanges > 0 {
 		fmt.Println("\nSchema changes:")
-		cyan.Println("  (schema changes are committed automatically with data)")
+		colorHint.Println("  (schema changes are committed automatically with data)")
 		fmt.Println()
-		printSchemaChanges(schemaDiff, green, yellow, red, magenta, "        ")
+		printSchemaChanges(schemaDiff, "        ")
 	}
 
 	// Show staged changes
 	if stagedCount > 0 {
 		fmt.Println("\nChanges to be committed:")
-		cyan.Println("  (use \"wvc reset <class>/<id>\" to unstage)")
+		colorHint.Println("  (use \"wvc reset <class>/<id>\" to unstage)")
 		fmt.Println()
 
-		printChanges(diff.Staged, green, yellow, red, "        ")
+		printChanges(diff.Staged, "        ")
 	}
 
 	// Show unstaged changes
 	if unstagedCount > 0 {
 		fmt.Println("\nChanges not staged for commit:")
-		cyan.Println("  (use \"wvc add <class>/<id>\" to stage)")
+		colorHint.Println("  (use \"wvc add <class>/<id>\" to stage)")
 		fmt.Println()
 
-		printChanges(diff.Unstaged, green, yellow, red, "        ")
+		printChanges(diff.Unstaged, "        ")
 	}
 
 	// Summary
@@ -107,71 +169,122 @@ func runStatus(cmd *cobra.Command, args []string) {
 		parts = append(parts, fmt.Sprintf("%d unstaged", unstagedCount))
 	}
 
+	if len(bumped) > 0 {
+		parts = append(parts, fmt.Sprintf("%d submodule", len(bumped)))
+	}
+
 	if len(parts) > 0 {
 		fmt.Println(strings.Join(parts, ", "))
 	}
 
-	if stagedCount > 0 || schemaChanges > 0 {
+	if stagedCount > 0 || schemaChanges > 0 || len(bumped) > 0 {
 		fmt.Println("\nUse 'wvc commit -m \"message\"' to commit changes.")
 	} else if unstagedCount > 0 {
 		fmt.Println("\nUse 'wvc add .' to stage all changes.")
 	}
 }
 
-// printChanges prints a diff result with color coding
-func printChanges(diff *core.DiffResult, green, yellow, red *color.Color, indent string) {
-	if len(diff.Inserted) > 0 {
-		for _, change := range diff.Inserted {
-			green.Printf("%snew:      %s/%s\n", indent, change.ClassName, shortID(change.ObjectID))
+// statusDocument gathers what status prints as text for --output json
+func statusDocument(ctx context.Context, c *cmdContext, spec core.Pathspec) *statusJSON {
+	st := c.Store
+	doc := &statusJSON{}
+	doc.Branch, _ = st.GetCurrentBranch()
+	doc.Head, _ = st.GetHEAD()
+	doc.Detached = doc.Branch == "" && doc.Head != ""
+	if doc.Branch != "" {
+		if branch, err := st.GetBranch(doc.Branch); err == nil && branch != nil {
+			doc.Classes = branch.Classes
 		}
 	}
+	doc.SparseClasses, _ = st.GetSparseClasses()
+	if progress, err := st.GetApplyProgress(); err == nil && progress != nil {
+		doc.FailedWrites = len(progress.Failed)
+	}
+	if state, err := st.GetBisectState(); err == nil && state != nil {
+		doc.Bisecting = true
+	}
+	if state, err := st.GetMergeState(); err == nil && state != nil {
+		merge := &mergeStateJSON{Branch: state.TargetBranch, TheirHead: state.TheirHead, Conflicts: len(state.Conflicts), Unresolved: []string{}}
+		for _, conflict := range state.Unresolved() {
+			merge.Unresolved = append(merge.Unresolved, conflict.Key)
+		}
+		doc.Merge = merge
+	}
 
-	if len(diff.Updated) > 0 {
-		for _, change := range diff.Updated {
-			if change.VectorOnly {
-				yellow.Printf("%smodified (vector): %s/%s\n", indent, change.ClassName, shortID(change.ObjectID))
-			} else {
-				yellow.Printf("%smodified: %s/%s\n", indent, change.ClassName, shortID(change.ObjectID))
-			}
+	schemaDiff, err := core.ComputeSchemaDiff(ctx, c.Config, st, c.Client)
+	if err != nil {
+		schemaDiff = &core.SchemaDiffResult{}
+	}
+	diff, err := core.ComputeIncrementalDiff(ctx, c.Config, st, c.Client)
+	if err != nil {
+		exitError("failed to compute diff: %v", err)
+	}
+	diff = spec.FilterIncrementalDiff(diff)
+	doc.SchemaChanges = schemaChangesJSON(spec.FilterSchemaDiff(schemaDiff))
+	doc.Staged = objectChangesJSON(diff.Staged, false)
+	doc.Unstaged = objectChangesJSON(diff.Unstaged, false)
+
+	submodules, err := core.ListSubmodules(c.Config, st)
+	if err != nil {
+		exitError("%v", err)
+	}
+	for _, info := range submodules {
+		if info.Pinned != info.Committed {
+			doc.Submodules = append(doc.Submodules, submoduleJSON{Name: info.Name, Committed: info.Committed, Pinned: info.Pinned})
 		}
 	}
+	doc.Clean = len(doc.SchemaChanges) == 0 && len(doc.Staged) == 0 && len(doc.Unstaged) == 0 && len(doc.Submodules) == 0
+	return doc
+}
 
-	if len(diff.Deleted) > 0 {
-		for _, change := range diff.Deleted {
-			red.Printf("%sdeleted:  %s/%s\n", indent, change.ClassName, shortID(change.ObjectID))
+// printChanges prints a diff result as a color-coded, width-aware table
+func printChanges(diff *core.DiffResult, indent string) {
+	t := &table{indent: indent}
+	for _, change := range diff.Inserted {
+		if change.MovedFrom != "" {
+			t.addRow(cell("moved:", colorAdded), cell(fmt.Sprintf("%s -> %s/%s", change.MovedFrom, change.ClassName, change.ObjectID), colorAdded))
+			continue
 		}
+		t.addRow(cell("new:", colorAdded), cell(change.ClassName+"/"+shortID(change.ObjectID), colorAdded))
 	}
+
+	for _, change := range diff.Updated {
+		label := "modified:"
+		if change.VectorOnly {
+			label = "modified (vector):"
+		}
+		t.addRow(cell(label, colorModified), cell(change.ClassName+"/"+shortID(change.ObjectID), colorModified))
+	}
+
+	for _, change := range diff.Deleted {
+		if change.MovedTo != "" {
+			continue // listed with the destination
+		}
+		t.addRow(cell("deleted:", colorDeleted), cell(change.ClassName+"/"+shortID(change.ObjectID), colorDeleted))
+	}
+	t.print()
 }
 
-// printSchemaChanges prints schema changes with color coding
-func printSchemaChanges(diff *core.SchemaDiffResult, green, yellow, red, magenta *color.Color, indent string) {
-	// Classes added
+// printSchemaChanges prints schema changes as a color-coded, width-aware table
+func printSchemaChanges(diff *core.SchemaDiffResult, indent string) {
+	t := &table{indent: indent}
 	for _, change := range diff.ClassesAdded {
-		green.Printf("%snew class:      %s\n", indent, change.ClassName)
+		t.addRow(cell("new class:", colorAdded), cell(change.ClassName, colorAdded))
 	}
-
-	// Classes deleted
 	for _, change := range diff.ClassesDeleted {
-		red.Printf("%sdeleted class:  %s\n", indent, change.ClassName)
+		t.addRow(cell("deleted class:", colorDeleted), cell(change.ClassName, colorDeleted))
 	}
-
-	// Properties added
 	for _, change := range diff.PropertiesAdded {
-		green.Printf("%snew property:   %s.%s\n", indent, change.ClassName, change.PropertyName)
+		t.addRow(cell("new property:", colorAdded), cell(change.ClassName+"."+change.PropertyName, colorAdded))
 	}
-
-	// Properties deleted
 	for _, change := range diff.PropertiesDeleted {
-		red.Printf("%sdeleted prop:   %s.%s\n", indent, change.ClassName, change.PropertyName)
+		t.addRow(cell("deleted prop:", colorDeleted), cell(change.ClassName+"."+change.PropertyName, colorDeleted))
 	}
-
-	// Properties modified
 	for _, change := range diff.PropertiesModified {
-		yellow.Printf("%smodified prop:  %s.%s\n", indent, change.ClassName, change.PropertyName)
+		t.addRow(cell("modified prop:", colorModified), cell(change.ClassName+"."+change.PropertyName, colorModified))
 	}
-
-	// Vectorizer changes
 	for _, change := range diff.VectorizersChanged {
-		magenta.Printf("%svectorizer:     %s\n", indent, change.ClassName)
+		t.addRow(cell("vectorizer:", colorSchema), cell(change.ClassName, colorSchema))
 	}
+	t.print()
 }
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/kilupskalvis/wvc/internal/config"
+	"github.com/kilupskalvis/wvc/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var storeCmd = &cobra.Command{
+	Use:   "store",
+	Short: "Maintain the local version store",
+	Long: `Maintenance commands for the local .wvc database.
+
+Examples:
+  wvc store compact    Rewrite the database to reclaim free space`,
+}
+
+var storeCompactCmd = &cobra.Command{
+	Use:   "compact",
+	Short: "Rewrite the local database to reclaim free space",
+	Long: `Copy the local database into a fresh file, verify the copy, and swap it
+into place. The database file never shrinks on its own after history is
+pruned; compaction returns the free pages to the filesystem.
+
+No other wvc process may be using the repository while compaction runs.
+Use "wvc count-objects" to see how much space is free.
+
+Examples:
+  wvc store compact`,
+	Args: cobra.NoArgs,
+	Run:  runStoreCompact,
+}
+
+func init() {
+	storeCmd.AddCommand(storeCompactCmd)
+}
+
+func runStoreCompact(cmd *cobra.Command, args []string) {
+	cfg, err := config.Load()
+	if err != nil {
+		exitError("%v", err)
+	}
+
+	result, err := store.Compact(cfg.DatabasePath())
+	if err != nil {
+		exitError("%v", err)
+	}
+
+	green := color.New(color.FgGreen)
+	green.Printf("Compacted %s\n", cfg.DatabasePath())
+	fmt.Printf("  before: %s\n", formatBytes(result.SizeBefore))
+	fmt.Printf("  after:  %s\n", formatBytes(result.SizeAfter))
+	if saved := result.SizeBefore - result.SizeAfter; saved > 0 {
+		fmt.Printf("  freed:  %s\n", formatBytes(saved))
+	}
+}
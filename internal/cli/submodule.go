@@ -0,0 +1,185 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/kilupskalvis/wvc/internal/core"
+	"github.com/spf13/cobra"
+)
+
+var submoduleCmd = &cobra.Command{
+	Use:   "submodule",
+	Short: "Reference other wvc repositories at a pinned commit",
+	Long: `Reference another wvc repository, e.g. a shared taxonomy dataset, at a
+specific commit.
+
+A submodule names a configured remote and the branch to follow. The commit
+it is pinned to is recorded by every commit of this repository, so checking
+out an old commit also tells you which version of the dataset it used.
+
+Updating a submodule writes the objects and schema of its pinned commit into
+Weaviate. Its classes then belong to the submodule: status, commit, and
+checkout of this repository leave them alone.
+
+Examples:
+  wvc remote add taxonomy https://wvc.example.com/taxonomy
+  wvc submodule add taxonomy taxonomy     Pin the tip of taxonomy/main
+  wvc submodule update                    Materialize the pinned commits
+  wvc submodule update --remote taxonomy  Pin and materialize the latest tip
+  wvc submodule status`,
+}
+
+var submoduleAddCmd = &cobra.Command{
+	Use:   "add <name> <remote>",
+	Short: "Add a submodule and pin it to its branch tip",
+	Args:  cobra.ExactArgs(2),
+	Run:   runSubmoduleAdd,
+}
+
+var submoduleUpdateCmd = &cobra.Command{
+	Use:   "update [<name>...]",
+	Short: "Write the pinned commit of submodules into Weaviate",
+	Long: `Write the objects and schema of each submodule's pinned commit into
+Weaviate, fetching the commit from the submodule's remote if needed.
+
+With --remote, each submodule is first pinned to the tip of its branch. The
+new pin is recorded by the next commit.`,
+	Run: runSubmoduleUpdate,
+}
+
+var submoduleStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show where each submodule is pinned",
+	Args:  cobra.NoArgs,
+	Run:   runSubmoduleStatus,
+}
+
+var (
+	submoduleBranch       string
+	submoduleUpdateRemote bool
+)
+
+func init() {
+	submoduleAddCmd.Flags().StringVar(&submoduleBranch, "branch", "", "Branch of the submodule to follow (default \"main\")")
+	submoduleUpdateCmd.Flags().BoolVar(&submoduleUpdateRemote, "remote", false, "Pin each submodule to the tip of its branch first")
+	submoduleCmd.AddCommand(submoduleAddCmd)
+	submoduleCmd.AddCommand(submoduleUpdateCmd)
+	submoduleCmd.AddCommand(submoduleStatusCmd)
+}
+
+func runSubmoduleAdd(cmd *cobra.Command, args []string) {
+	c := initContextWithMigrations()
+	defer c.Close()
+
+	name, remoteName := args[0], args[1]
+	if err := core.AddSubmodule(c.Config, c.Store, name, remoteName, submoduleBranch); err != nil {
+		exitError("%v", err)
+	}
+	bump, err := core.BumpSubmodule(context.Background(), c.Config, c.Store, resolveRemoteClientByName(c.Store, remoteName), name)
+	if err != nil {
+		exitError("%v", err)
+	}
+	color.New(color.FgGreen).Printf("Added submodule '%s' pinned at %s\n", name, shortID(bump.Pinned))
+	fmt.Printf("Run 'wvc submodule update %s' to write it into Weaviate and 'wvc commit' to record the pin\n", name)
+}
+
+func runSubmoduleUpdate(cmd *cobra.Command, args []string) {
+	c := initFullContext()
+	defer c.Close()
+
+	names := args
+	if len(names) == 0 {
+		names = slices.Sorted(maps.Keys(c.Config.Submodule))
+		if len(names) == 0 {
+			fmt.Println("No submodules configured")
+			return
+		}
+	}
+	updateSubmodules(context.Background(), c, names, submoduleUpdateRemote)
+}
+
+// updateSubmodules materializes the named submodules, first pinning them to
+// their branch tips when bump is set.
+func updateSubmodules(ctx context.Context, c *cmdContext, names []string, bump bool) {
+	green := color.New(color.FgGreen)
+	yellow := color.New(color.FgYellow)
+	for _, name := range names {
+		sub, ok := c.Config.Submodule[name]
+		if !ok {
+			exitError("no submodule named '%s'", name)
+		}
+		client := resolveRemoteClientByName(c.Store, sub.Remote)
+
+		if bump {
+			result, err := core.BumpSubmodule(ctx, c.Config, c.Store, client, name)
+			if err != nil {
+				exitError("%v", err)
+			}
+			if result.Previous != result.Pinned {
+				fmt.Printf("Submodule '%s': pinned %s -> %s\n", name, shortOrNone(result.Previous), shortID(result.Pinned))
+			}
+		}
+
+		result, err := core.UpdateSubmodule(ctx, c.Config, c.Store, c.Client, client, name)
+		if err != nil {
+			exitError("%v", err)
+		}
+		if result.UpToDate {
+			fmt.Printf("Submodule '%s' is up to date at %s\n", name, shortID(result.CommitID))
+			continue
+		}
+		green.Printf("Submodule '%s' at %s", name, shortID(result.CommitID))
+		fmt.Printf(" (%s): %d added, %d updated, %d removed\n", strings.Join(result.Classes, ", "),
+			result.Stats.Added, result.Stats.Updated, result.Stats.Removed)
+		for _, w := range result.Warnings {
+			yellow.Printf("  - %s\n", w.Message)
+		}
+	}
+}
+
+func runSubmoduleStatus(cmd *cobra.Command, args []string) {
+	c := initContextWithMigrations()
+	defer c.Close()
+
+	infos, err := core.ListSubmodules(c.Config, c.Store)
+	if err != nil {
+		exitError("%v", err)
+	}
+	if len(infos) == 0 {
+		fmt.Println("No submodules configured")
+		return
+	}
+
+	yellow := color.New(color.FgYellow)
+	for _, info := range infos {
+		fmt.Printf("%s (%s/%s)\n", info.Name, info.Remote, info.Branch)
+		if info.Pinned == "" {
+			fmt.Println("  not pinned")
+			continue
+		}
+		fmt.Printf("  pinned:       %s\n", shortID(info.Pinned))
+		if info.Committed != info.Pinned {
+			yellow.Printf("  committed:    %s (pin not committed)\n", shortOrNone(info.Committed))
+		}
+		if info.Materialized == "" {
+			yellow.Println("  materialized: no")
+		} else {
+			fmt.Printf("  materialized: %s (%s)\n", shortID(info.Materialized), strings.Join(info.Classes, ", "))
+			if info.Materialized != info.Pinned {
+				yellow.Printf("  run 'wvc submodule update %s' to write the pinned commit\n", info.Name)
+			}
+		}
+	}
+}
+
+func shortOrNone(id string) string {
+	if id == "" {
+		return "none"
+	}
+	return shortID(id)
+}
@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/kilupskalvis/wvc/internal/core"
+	"github.com/spf13/cobra"
+)
+
+var (
+	subscribeExec         string
+	subscribePollInterval time.Duration
+)
+
+var subscribeCmd = &cobra.Command{
+	Use:   "subscribe [<remote>] [<branch>]",
+	Short: "Run a command whenever a remote branch advances",
+	Long: `Watch a branch on a remote and run a command each time it moves to a new
+commit, e.g. to redeploy a dataset version to a downstream environment.
+
+The server's event stream is followed when available, reconnecting after
+network errors; older servers are polled every --poll-interval. The command
+runs once per new tip with these environment variables set:
+
+  WVC_REMOTE            the remote name
+  WVC_BRANCH            the branch name
+  WVC_COMMIT            the new tip
+  WVC_PREVIOUS_COMMIT   the previous tip, empty for a new branch
+
+A failing command is reported and the subscription continues. The local
+repository is not locked while subscribed, so the command can run wvc itself.
+Without --exec, each advance is printed.
+
+Defaults to the only configured remote and the current branch.
+
+Examples:
+  wvc subscribe origin main --exec ./redeploy.sh
+  wvc subscribe origin main --exec "wvc pull origin main"
+  wvc subscribe --poll-interval 10s`,
+	Args: cobra.MaximumNArgs(2),
+	Run:  runSubscribe,
+}
+
+func init() {
+	subscribeCmd.Flags().StringVar(&subscribeExec, "exec", "", "Command to run when the branch advances")
+	subscribeCmd.Flags().DurationVar(&subscribePollInterval, "poll-interval", core.DefaultSubscribePollInterval, "Polling interval for servers without an event stream")
+}
+
+func runSubscribe(cmd *cobra.Command, args []string) {
+	c := initContextWithMigrations()
+
+	remoteName := ""
+	branch := ""
+	if len(args) >= 1 {
+		remoteName = args[0]
+	}
+	if len(args) >= 2 {
+		branch = args[1]
+	}
+
+	client, remoteInfo, remoteName, branch := resolveRemoteClient(c.Store, remoteName, branch)
+	// Release the store so the command can use the repository
+	c.Close()
+
+	var command []string
+	if subscribeExec != "" {
+		command = strings.Fields(subscribeExec)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	green := color.New(color.FgGreen)
+	yellow := color.New(color.FgYellow)
+
+	fmt.Printf("Watching %s/%s (%s)...\n", remoteName, branch, remoteInfo.URL)
+
+	err := core.Subscribe(ctx, client, core.SubscribeOptions{
+		Branch:       branch,
+		PollInterval: subscribePollInterval,
+		OnPolling: func() {
+			fmt.Printf("Server has no event stream, polling every %s\n", subscribePollInterval)
+		},
+	}, func(a *core.BranchAdvance) error {
+		if a.Previous == "" {
+			green.Printf("%s/%s created at %s\n", remoteName, a.Branch, shortID(a.CommitID))
+		} else {
+			green.Printf("%s/%s advanced %s -> %s\n", remoteName, a.Branch, shortID(a.Previous), shortID(a.CommitID))
+		}
+		if command == nil {
+			return nil
+		}
+
+		run := exec.CommandContext(ctx, command[0], command[1:]...)
+		run.Env = append(os.Environ(),
+			"WVC_REMOTE="+remoteName,
+			"WVC_BRANCH="+a.Branch,
+			"WVC_COMMIT="+a.CommitID,
+			"WVC_PREVIOUS_COMMIT="+a.Previous,
+		)
+		run.Stdin = os.Stdin
+		run.Stdout = os.Stdout
+		run.Stderr = os.Stderr
+		if err := run.Run(); err != nil && ctx.Err() == nil {
+			yellow.Fprintf(os.Stderr, "warning: '%s' failed: %v\n", subscribeExec, err)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, context.Canceled) {
+		exitError("%v", err)
+	}
+}
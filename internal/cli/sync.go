@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/kilupskalvis/wvc/internal/core"
+	"github.com/kilupskalvis/wvc/internal/remote"
+	"github.com/spf13/cobra"
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Bring the repository to a state declared in a file",
+}
+
+var syncApplyCmd = &cobra.Command{
+	Use:   "apply <file>",
+	Short: "Apply a sync file such as wvc.yaml",
+	Long: `Bring the repository to the state a sync file declares: the branch to be
+on, the remote to pull from and push to, whether to commit uncommitted
+changes, and which ref each deployment environment runs.
+
+Steps whose state already holds are skipped, so applying the same file again
+does nothing, which makes the command safe to run from CI or a scheduler.
+
+Example wvc.yaml:
+
+  branch: main
+  remote:
+    name: origin
+    url: https://wvc.example.com/my-repo
+    pull: true
+    push: true
+    on_diverge: merge        # or fail, the default
+  auto_commit:
+    enabled: true
+    message: "nightly ingest"
+  environments:
+    staging: main
+    production: v1.4
+
+The remote's token comes from 'wvc remote set-token' or WVC_REMOTE_TOKEN_<NAME>.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runSyncApply,
+}
+
+func init() {
+	syncCmd.AddCommand(syncApplyCmd)
+}
+
+func runSyncApply(cmd *cobra.Command, args []string) {
+	spec, err := core.LoadSyncSpec(args[0])
+	if err != nil {
+		exitError("%v", err)
+	}
+
+	c := initFullContext()
+	defer c.Close()
+	holdWriteLock(c, "checkout")
+	defer releaseWriteLock()
+
+	result, err := core.ApplySync(context.Background(), c.Config, c.Store, c.Client, spec,
+		func(remoteName string) (remote.RemoteClient, error) {
+			return newRemoteClient(c.Store, remoteName)
+		})
+	if result != nil {
+		for _, action := range result.Actions {
+			fmt.Printf("  %s\n", action)
+		}
+	}
+	if err != nil {
+		exitError("%v", err)
+	}
+	if len(result.Actions) == 0 {
+		fmt.Println("Already in sync.")
+		return
+	}
+	color.New(color.FgGreen).Printf("Applied %s (%d step(s))\n", args[0], len(result.Actions))
+}
@@ -0,0 +1,182 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/kilupskalvis/wvc/internal/core"
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/spf13/cobra"
+)
+
+var tagCmd = &cobra.Command{
+	Use:   "tag [<name> [<ref>]]",
+	Short: "List, create, delete, push, or pull tags",
+	Long: `Manage tags: immutable names for commits, such as dataset releases.
+
+Without arguments, lists all tags. With a name, tags HEAD or <ref>; an
+existing tag is only moved with --force. Tags can be used anywhere a commit
+is expected.
+
+--push uploads the named tags, or all tags, to the remote; their commits
+must already be pushed. --pull downloads the remote's tags whose commits are
+in the local history. --remote picks the remote when more than one is
+configured.
+
+Examples:
+  wvc tag                          # List all tags
+  wvc tag v1.0                     # Tag HEAD as v1.0
+  wvc tag v1.0 abc123 -m "Q3 set"  # Tag a commit with a message
+  wvc tag -f v1.0 main             # Move v1.0 to main's tip
+  wvc tag -d v1.0                  # Delete a local tag
+  wvc tag -d --push v1.0           # Delete a tag locally and on the remote
+  wvc tag --push                   # Push all tags
+  wvc tag --push v1.0              # Push one tag
+  wvc tag --pull                   # Download the remote's tags`,
+	Args: cobra.ArbitraryArgs,
+	Run:  runTag,
+}
+
+var (
+	tagMessage string
+	tagForce   bool
+	tagDelete  bool
+	tagPush    bool
+	tagPull    bool
+	tagRemote  string
+)
+
+func init() {
+	tagCmd.Flags().StringVarP(&tagMessage, "message", "m", "", "Tag message")
+	tagCmd.Flags().BoolVarP(&tagForce, "force", "f", false, "Move an existing tag")
+	tagCmd.Flags().BoolVarP(&tagDelete, "delete", "d", false, "Delete a tag")
+	tagCmd.Flags().BoolVar(&tagPush, "push", false, "Push tags to the remote")
+	tagCmd.Flags().BoolVar(&tagPull, "pull", false, "Download tags from the remote")
+	tagCmd.Flags().StringVar(&tagRemote, "remote", "", "Remote to push to or pull from")
+	tagCmd.MarkFlagsMutuallyExclusive("pull", "push")
+	tagCmd.MarkFlagsMutuallyExclusive("pull", "delete")
+}
+
+func runTag(cmd *cobra.Command, args []string) {
+	c := initContextWithMigrations()
+	defer c.Close()
+
+	ctx := context.Background()
+	st := c.Store
+
+	switch {
+	case tagPull:
+		if len(args) > 0 {
+			exitError("--pull does not take tag names")
+		}
+		client := resolveRemoteClientByName(st, tagRemoteName(c))
+		result, err := core.FetchTags(ctx, st, client, tagForce)
+		if err != nil {
+			exitError("%v", err)
+		}
+		printTagFetchResult(result)
+		return
+
+	case tagDelete:
+		if len(args) != 1 {
+			exitError("exactly one tag name required for deletion")
+		}
+		if err := st.DeleteTag(args[0]); err != nil && !tagPush {
+			exitError("%v", err)
+		}
+		if tagPush {
+			remoteName := tagRemoteName(c)
+			if err := core.DeleteRemoteTag(ctx, resolveRemoteClientByName(st, remoteName), args[0]); err != nil {
+				exitError("%v", err)
+			}
+			fmt.Printf("Deleted tag '%s' locally and on %s\n", args[0], remoteName)
+			return
+		}
+		fmt.Printf("Deleted tag '%s'\n", args[0])
+		return
+
+	case tagPush:
+		remoteName := tagRemoteName(c)
+		pushed, err := core.PushTags(ctx, st, resolveRemoteClientByName(st, remoteName), args, tagForce)
+		if err != nil {
+			exitError("%v", err)
+		}
+		if len(pushed) == 0 {
+			fmt.Println("No tags to push")
+			return
+		}
+		for _, tag := range pushed {
+			fmt.Printf("Pushed tag '%s' (%s) to %s\n", tag.Name, shortID(tag.CommitID), remoteName)
+		}
+		return
+	}
+
+	if len(args) > 0 {
+		if len(args) > 2 {
+			exitError("too many arguments: expected <name> [<ref>]")
+		}
+		ref := ""
+		if len(args) > 1 {
+			ref = args[1]
+		}
+		tag, err := core.CreateTag(st, args[0], ref, tagMessage, tagForce)
+		if err != nil {
+			exitError("%v", err)
+		}
+		fmt.Printf("Tagged %s as '%s'\n", shortID(tag.CommitID), tag.Name)
+		return
+	}
+
+	tags, err := st.ListTags()
+	if err != nil {
+		exitError("failed to list tags: %v", err)
+	}
+	if len(tags) == 0 {
+		fmt.Println("No tags")
+		return
+	}
+
+	startPager()
+	defer stopPager()
+
+	t := &table{}
+	for _, tag := range tags {
+		t.addRow(cell(tag.Name, colorRef), cell(shortID(tag.CommitID), colorCommit), cell(firstLine(tag.Message), nil))
+	}
+	t.print()
+}
+
+// tagRemoteName resolves --remote, defaulting to the only configured remote.
+func tagRemoteName(c *cmdContext) string {
+	name, err := core.ResolveRemote(c.Store, tagRemote)
+	if err != nil {
+		exitError("%v", err)
+	}
+	return name
+}
+
+func printTagFetchResult(result *core.TagFetchResult) {
+	yellow := color.New(color.FgYellow)
+	for _, tag := range result.Fetched {
+		fmt.Printf("New tag '%s' (%s)\n", tag.Name, shortID(tag.CommitID))
+	}
+	for _, tag := range result.Diverged {
+		yellow.Printf("Skipped '%s': exists locally at another commit (use --force to overwrite)\n", tag.Name)
+	}
+	if len(result.MissingCommit) > 0 {
+		yellow.Printf("Skipped %d tag(s) whose commits are not fetched yet: %s\n", len(result.MissingCommit), tagNames(result.MissingCommit))
+	}
+	if len(result.Fetched)+len(result.Diverged)+len(result.MissingCommit) == 0 {
+		fmt.Println("Tags are up-to-date.")
+	}
+}
+
+func tagNames(tags []*models.Tag) string {
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = tag.Name
+	}
+	return strings.Join(names, ", ")
+}
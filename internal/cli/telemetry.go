@@ -0,0 +1,186 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/config"
+	"github.com/kilupskalvis/wvc/internal/core"
+	"github.com/spf13/cobra"
+)
+
+var telemetryCmd = &cobra.Command{
+	Use:   "telemetry",
+	Short: "Show or change anonymous usage reporting",
+	Long: `Show or change the opt-in reporting of anonymous usage metrics.
+
+Telemetry is off unless turned on. When on, each command records its name
+(such as "remote add"), its duration, and the category of its error, if any,
+with the wvc version, operating system, and a random ID of the repository.
+Arguments, flag values, object data, names, and URLs are never recorded.
+Events are queued in .wvc/telemetry.jsonl and posted to the endpoint in
+batches.
+
+WVC_TELEMETRY=off or DO_NOT_TRACK=1 turns telemetry off regardless of the
+configuration.
+
+Examples:
+  wvc telemetry status
+  wvc telemetry on --endpoint https://telemetry.example.com/v1/events
+  wvc telemetry off`,
+}
+
+var telemetryStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether telemetry is on",
+	Args:  cobra.NoArgs,
+	Run:   runTelemetryStatus,
+}
+
+var telemetryOnCmd = &cobra.Command{
+	Use:   "on",
+	Short: "Turn anonymous usage reporting on",
+	Args:  cobra.NoArgs,
+	Run:   runTelemetryOn,
+}
+
+var telemetryOffCmd = &cobra.Command{
+	Use:   "off",
+	Short: "Turn usage reporting off and discard queued events",
+	Args:  cobra.NoArgs,
+	Run:   runTelemetryOff,
+}
+
+var telemetryEndpoint string
+
+// telemetryTimeout bounds sending a batch of events at the end of a command.
+const telemetryTimeout = 2 * time.Second
+
+// The command being run and when it started, set before it runs; see
+// reportTelemetry
+var (
+	telemetryCommand string
+	telemetryStart   time.Time
+)
+
+func init() {
+	telemetryOnCmd.Flags().StringVar(&telemetryEndpoint, "endpoint", "", "URL the usage events are posted to")
+	telemetryCmd.AddCommand(telemetryStatusCmd)
+	telemetryCmd.AddCommand(telemetryOnCmd)
+	telemetryCmd.AddCommand(telemetryOffCmd)
+}
+
+func runTelemetryStatus(cmd *cobra.Command, args []string) {
+	cfg, err := config.Load()
+	if err != nil {
+		exitError("%v", err)
+	}
+	if cfg.Telemetry == nil || !cfg.Telemetry.Enabled {
+		fmt.Println("Telemetry is off")
+		return
+	}
+	if config.TelemetryDisabledByEnv() {
+		fmt.Println("Telemetry is on, but turned off by WVC_TELEMETRY or DO_NOT_TRACK")
+	} else {
+		fmt.Println("Telemetry is on")
+	}
+	fmt.Printf("  Endpoint: %s\n", cfg.Telemetry.Endpoint)
+	fmt.Printf("  ID:       %s\n", cfg.Telemetry.ID)
+	events, err := core.ReadTelemetryQueue(cfg.WVCPath())
+	if err != nil {
+		exitError("read telemetry queue: %v", err)
+	}
+	fmt.Printf("  Queued:   %d event(s)\n", len(events))
+}
+
+func runTelemetryOn(cmd *cobra.Command, args []string) {
+	cfg, err := config.Load()
+	if err != nil {
+		exitError("%v", err)
+	}
+	if cfg.Telemetry == nil {
+		cfg.Telemetry = &config.TelemetryConfig{}
+	}
+	if telemetryEndpoint != "" {
+		if !strings.HasPrefix(telemetryEndpoint, "https://") && !strings.HasPrefix(telemetryEndpoint, "http://") {
+			exitError("endpoint must be an http or https URL")
+		}
+		cfg.Telemetry.Endpoint = telemetryEndpoint
+	}
+	if cfg.Telemetry.Endpoint == "" {
+		exitError("no telemetry endpoint configured — pass --endpoint <url>")
+	}
+	if cfg.Telemetry.ID == "" {
+		if cfg.Telemetry.ID, err = core.NewTelemetryID(); err != nil {
+			exitError("generate telemetry ID: %v", err)
+		}
+	}
+	cfg.Telemetry.Enabled = true
+	if err := cfg.Save(); err != nil {
+		exitError("%v", err)
+	}
+	fmt.Printf("Telemetry on: reporting command names, durations, and error categories to %s\n", cfg.Telemetry.Endpoint)
+}
+
+func runTelemetryOff(cmd *cobra.Command, args []string) {
+	cfg, err := config.Load()
+	if err != nil {
+		exitError("%v", err)
+	}
+	if cfg.Telemetry != nil {
+		cfg.Telemetry.Enabled = false
+		if err := cfg.Save(); err != nil {
+			exitError("%v", err)
+		}
+	}
+	if err := core.ClearTelemetryQueue(cfg.WVCPath()); err != nil {
+		exitError("%v", err)
+	}
+	fmt.Println("Telemetry off")
+}
+
+// startTelemetry notes the command about to run, for reportTelemetry.
+func startTelemetry(cmd *cobra.Command) {
+	if !cmd.HasParent() {
+		return
+	}
+	telemetryCommand = strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+	telemetryStart = time.Now()
+}
+
+// reportTelemetry queues the usage event of the command that ran, when the
+// repository has telemetry on, and sends the queue once a batch is due.
+// Failures are ignored: telemetry never changes a command's outcome. Only
+// the first call after startTelemetry reports.
+func reportTelemetry(errCategory string) {
+	command := telemetryCommand
+	telemetryCommand = ""
+	if command == "" {
+		return
+	}
+	cfg, err := config.Load()
+	if err != nil || !cfg.TelemetryEnabled() {
+		return
+	}
+	event := core.NewTelemetryEvent(cfg.Telemetry.ID, command, Version, telemetryStart, errCategory)
+	if err := core.RecordTelemetry(cfg.WVCPath(), event); err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), telemetryTimeout)
+	defer cancel()
+	_, _ = core.FlushTelemetry(ctx, &http.Client{Timeout: telemetryTimeout}, cfg.WVCPath(), cfg.Telemetry.Endpoint, false)
+}
+
+// telemetryErrorCategory classifies the failure exitError reports from the
+// first error among its arguments.
+func telemetryErrorCategory(args []interface{}) string {
+	for _, arg := range args {
+		if err, ok := arg.(error); ok {
+			return core.TelemetryErrorCategory(err)
+		}
+	}
+	return "other"
+}
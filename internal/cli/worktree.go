@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kilupskalvis/wvc/internal/core"
+	"github.com/spf13/cobra"
+)
+
+var worktreeCmd = &cobra.Command{
+	Use:   "worktree",
+	Short: "Manage Weaviate instances linked to this history",
+	Long: `Manage worktrees: additional Weaviate instances linked to the repository's
+history. Each worktree has its own HEAD, branch, staging area, and known
+state, while commits, branches, stashes, and remotes are shared. A branch can
+be checked out in only one worktree at a time.
+
+A worktree lives in its own directory; run wvc commands there to work with
+its Weaviate instance.
+
+Examples:
+  wvc worktree add ../review http://localhost:8081 proposal
+                                    Check out 'proposal' into a scratch instance
+  wvc worktree list                 List the main and linked worktrees
+  wvc worktree remove review        Unlink the 'review' worktree`,
+}
+
+var worktreeAddCmd = &cobra.Command{
+	Use:   "add <path> <weaviate-url> [<branch|commit>]",
+	Short: "Link a Weaviate instance as a new worktree",
+	Long: `Create a worktree in <path> for the Weaviate instance at <weaviate-url> and
+check out <branch|commit> into it.
+
+Without a branch or commit, a new branch named after the worktree is created
+at HEAD. The instance must be empty unless --force is given, in which case its
+contents are replaced.
+
+Examples:
+  wvc worktree add ../review http://localhost:8081 proposal
+  wvc worktree add -b hotfix ../hotfix http://localhost:8082 main
+  wvc worktree add --detach ../audit http://localhost:8083 abc12345`,
+	Args: cobra.RangeArgs(2, 3),
+	Run:  runWorktreeAdd,
+}
+
+var worktreeListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List worktrees",
+	Args:  cobra.NoArgs,
+	Run:   runWorktreeList,
+}
+
+var worktreeRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Unlink a worktree",
+	Long: `Delete a worktree's local state and its .wvc directory. The Weaviate
+instance itself is left untouched. A worktree with staged changes or a merge
+in progress is only removed with --force.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runWorktreeRemove,
+}
+
+var (
+	worktreeName      string
+	worktreeNewBranch string
+	worktreeDetach    bool
+	worktreeForce     bool
+)
+
+func init() {
+	worktreeAddCmd.Flags().StringVar(&worktreeName, "name", "", "Worktree name (default: base name of <path>)")
+	worktreeAddCmd.Flags().StringVarP(&worktreeNewBranch, "branch", "b", "", "Create this branch and check it out")
+	worktreeAddCmd.Flags().BoolVar(&worktreeDetach, "detach", false, "Check out with a detached HEAD")
+	worktreeAddCmd.Flags().BoolVarP(&worktreeForce, "force", "f", false, "Replace the contents of a non-empty instance")
+	worktreeRemoveCmd.Flags().BoolVarP(&worktreeForce, "force", "f", false, "Remove even with staged changes or a merge in progress")
+
+	worktreeCmd.AddCommand(worktreeAddCmd)
+	worktreeCmd.AddCommand(worktreeListCmd)
+	worktreeCmd.AddCommand(worktreeRemoveCmd)
+}
+
+func runWorktreeAdd(cmd *cobra.Command, args []string) {
+	c := initContextWithMigrations()
+	defer c.Close()
+
+	opts := core.WorktreeAddOptions{
+		Path:        args[0],
+		Name:        worktreeName,
+		WeaviateURL: args[1],
+		NewBranch:   worktreeNewBranch,
+		Detach:      worktreeDetach,
+		Force:       worktreeForce,
+	}
+	if len(args) > 2 {
+		opts.Target = args[2]
+	}
+
+	client, err := newWeaviateClient(opts.WeaviateURL, c.Config)
+	if err != nil {
+		exitError("failed to create Weaviate client: %v", err)
+	}
+
+	result, err := core.AddWorktree(context.Background(), c.Config, c.Store, client, opts)
+	if err != nil {
+		exitError("%v", err)
+	}
+
+	wt, checkout := result.Worktree, result.Checkout
+	if checkout.IsDetached {
+		colorAdded.Printf("Created worktree '%s' at %s (HEAD detached at %s)\n", wt.Name, wt.Path, shortID(wt.HEAD))
+	} else {
+		colorAdded.Printf("Created worktree '%s' at %s on branch '%s'\n", wt.Name, wt.Path, wt.Branch)
+	}
+	fmt.Printf("  %d object(s) restored into %s\n", checkout.ObjectsAdded+checkout.ObjectsUpdated, wt.WeaviateURL)
+
+	if len(checkout.Warnings) > 0 {
+		colorModified.Println("\nWarnings:")
+		for _, w := range checkout.Warnings {
+			colorModified.Printf("  - %s\n", w.Message)
+		}
+	}
+}
+
+func runWorktreeList(cmd *cobra.Command, args []string) {
+	c := initContextWithMigrations()
+	defer c.Close()
+
+	worktrees, err := core.ListWorktrees(c.Config, c.Store)
+	if err != nil {
+		exitError("%v", err)
+	}
+
+	t := &table{}
+	for _, wt := range worktrees {
+		name := wt.Name
+		if name == "" {
+			name = "(main)"
+		}
+		nameCell := cell("  "+name, nil)
+		if wt.Name == c.Store.Worktree() {
+			nameCell = cell("* "+name, colorCurrent)
+		}
+		ref := cell("(detached)", colorMuted)
+		if wt.Branch != "" {
+			ref = cell("["+wt.Branch+"]", colorRef)
+		}
+		t.addRow(nameCell, cell(shortID(wt.HEAD), colorCommit), ref, cell(wt.WeaviateURL, nil), cell(wt.Path, nil))
+	}
+	t.print()
+}
+
+func runWorktreeRemove(cmd *cobra.Command, args []string) {
+	c := initContextWithMigrations()
+	defer c.Close()
+
+	if err := core.RemoveWorktree(c.Store, args[0], worktreeForce); err != nil {
+		exitError("%v", err)
+	}
+	fmt.Printf("Removed worktree '%s'\n", args[0])
+}
@@ -4,9 +4,15 @@ package config
 
 import (
 	"fmt"
+	"maps"
 	"os"
+	"os/user"
 	"path/filepath"
+	"slices"
+	"strings"
 
+	"github.com/kilupskalvis/wvc/internal/fsutil"
+	"github.com/kilupskalvis/wvc/internal/models"
 	"github.com/pelletier/go-toml/v2"
 )
 
@@ -21,7 +27,319 @@ const (
 type Config struct {
 	WeaviateURL   string `toml:"weaviate_url"`
 	ServerVersion string `toml:"server_version"` // Detected Weaviate server version on init
-	path          string // path to .wvc directory
+	// Alias maps a command alias to the command line it expands to, e.g.
+	// "st" = "status" or "lg" = "log --oneline"
+	Alias map[string]string `toml:"alias,omitempty"`
+	// User identifies the author recorded in new commits
+	User *UserConfig `toml:"user,omitempty"`
+	// Commit holds the rules every commit message must follow
+	Commit *CommitRules `toml:"commit,omitempty"`
+	// Lock declares how other writers to the Weaviate instance are asked to pause
+	Lock *LockConfig `toml:"lock,omitempty"`
+	// Core holds settings of the local store itself
+	Core *CoreConfig `toml:"core,omitempty"`
+	// Apply tunes how checkout, reset, and merge write objects to Weaviate
+	Apply *ApplyConfig `toml:"apply,omitempty"`
+	// Merge tunes three-way merges
+	Merge *MergeConfig `toml:"merge,omitempty"`
+	// Classification tags classes, by name, with the license their data may
+	// be used under and its PII level; new commits record the tags of the
+	// classes they touch
+	Classification map[string]*ClassificationConfig `toml:"classification,omitempty"`
+	// Submodule names the other wvc repositories this one references, by
+	// submodule name; the commit each is pinned to is recorded in history
+	Submodule map[string]*SubmoduleConfig `toml:"submodule,omitempty"`
+	// Ignore lists the objects left out of status, staging, and commits,
+	// such as caches and test data
+	Ignore []*IgnoreRule `toml:"ignore,omitempty"`
+	// Telemetry opts in to reporting anonymized command usage
+	Telemetry *TelemetryConfig `toml:"telemetry,omitempty"`
+	// Weaviate holds settings of the connection to Weaviate
+	Weaviate *WeaviateConfig `toml:"weaviate,omitempty"`
+	// CommonDir and Worktree are set in a linked worktree: the .wvc directory
+	// of the main repository, whose database holds the shared history, and
+	// the worktree's name in it
+	CommonDir string `toml:"common_dir,omitempty"`
+	Worktree  string `toml:"worktree,omitempty"`
+	path      string // path to .wvc directory
+}
+
+// UserConfig is the commit author identity
+type UserConfig struct {
+	Name  string `toml:"name,omitempty"`
+	Email string `toml:"email,omitempty"`
+}
+
+// CommitRules are the commit message conventions enforced by `wvc commit`
+type CommitRules struct {
+	MaxSubjectLength int      `toml:"max_subject_length,omitempty"` // longest allowed first line; 0 for no limit
+	SubjectPattern   string   `toml:"subject_pattern,omitempty"`    // regular expression the first line must match
+	RequiredTrailers []string `toml:"required_trailers,omitempty"`  // trailer keys that must be present, e.g. "Ticket"
+}
+
+// LockConfig declares the commands that pause and resume other writers to the
+// Weaviate instance, e.g. by toggling a flag ingest jobs check. Commands are
+// split on whitespace and run without a shell.
+type LockConfig struct {
+	Acquire    string   `toml:"acquire,omitempty"`     // asks writers to pause; a non-zero exit fails the acquire
+	Release    string   `toml:"release,omitempty"`     // lets writers resume
+	HoldDuring []string `toml:"hold_during,omitempty"` // commands that hold the lock while they run: "commit", "checkout"
+}
+
+// DefaultSnapshotInterval is the number of commits between state snapshots
+// when core.snapshot_interval is unset
+const DefaultSnapshotInterval = 100
+
+// CoreConfig holds settings of the local store
+type CoreConfig struct {
+	FSync string `toml:"fsync,omitempty"` // database fsync policy: "full" (default), "file", or "none"
+	// SnapshotInterval is the number of commits between saved state
+	// snapshots, which rebuilding a commit's state replays from; default
+	// 100, negative disables them
+	SnapshotInterval int `toml:"snapshot_interval,omitempty"`
+	// FullScan compares every object of every class, even classes whose
+	// object count and latest update time show no change since a scan
+	// that found none
+	FullScan bool `toml:"full_scan,omitempty"`
+}
+
+// IgnoreRule leaves the objects matching a pattern, and every predicate in
+// Where, out of status, staging, and commits. The pattern is a class or
+// "Class/id" glob; predicates are name=value property filters. A class
+// pattern without predicates ignores the schema of its classes too.
+type IgnoreRule struct {
+	Pattern string   `toml:"pattern"`
+	Where   []string `toml:"where,omitempty"`
+}
+
+// MergeConfig tunes three-way merges
+type MergeConfig struct {
+	// AppendOnly names classes whose objects are only ever added or deleted,
+	// never modified, such as logs and events. Merges take the objects only
+	// one side added to them without hashing or comparing properties.
+	AppendOnly []string `toml:"append_only,omitempty"`
+}
+
+// Defaults for ApplyConfig
+const (
+	DefaultApplyBatchSize   = 100
+	DefaultApplyConcurrency = 4
+)
+
+// ApplyConfig tunes the batched object writes made when a commit's state is
+// applied to Weaviate
+type ApplyConfig struct {
+	BatchSize   int `toml:"batch_size,omitempty"`  // objects per batch request; default 100
+	Concurrency int `toml:"concurrency,omitempty"` // batch requests in flight at once; default 4
+}
+
+// ApplyBatchSize returns the number of objects written per batch request
+func (c *Config) ApplyBatchSize() int {
+	if c == nil || c.Apply == nil || c.Apply.BatchSize <= 0 {
+		return DefaultApplyBatchSize
+	}
+	return c.Apply.BatchSize
+}
+
+// ApplyConcurrency returns the number of batch requests kept in flight
+func (c *Config) ApplyConcurrency() int {
+	if c == nil || c.Apply == nil || c.Apply.Concurrency <= 0 {
+		return DefaultApplyConcurrency
+	}
+	return c.Apply.Concurrency
+}
+
+// AppendOnlyClasses returns the set of classes configured as append-only
+func (c *Config) AppendOnlyClasses() map[string]bool {
+	if c == nil || c.Merge == nil || len(c.Merge.AppendOnly) == 0 {
+		return nil
+	}
+	classes := make(map[string]bool, len(c.Merge.AppendOnly))
+	for _, class := range c.Merge.AppendOnly {
+		classes[class] = true
+	}
+	return classes
+}
+
+// ClassificationConfig tags the data of one class
+type ClassificationConfig struct {
+	License string `toml:"license,omitempty"` // e.g. "CC-BY-4.0" or "proprietary"
+	PII     string `toml:"pii,omitempty"`     // "none", "internal", or "restricted"
+}
+
+// Classifications returns the configured classification of each class
+func (c *Config) Classifications() (map[string]*models.Classification, error) {
+	if c == nil || len(c.Classification) == 0 {
+		return nil, nil
+	}
+	out := make(map[string]*models.Classification, len(c.Classification))
+	for class, cc := range c.Classification {
+		if cc == nil || (cc.License == "" && cc.PII == "") {
+			continue
+		}
+		if cc.PII != "" && !models.ValidPII(cc.PII) {
+			return nil, fmt.Errorf("classification.%s.pii: unknown level %q (want none, internal, or restricted)", class, cc.PII)
+		}
+		out[class] = &models.Classification{License: cc.License, PII: cc.PII}
+	}
+	return out, nil
+}
+
+// SubmoduleConfig locates a referenced repository: a configured remote and
+// the branch whose tip "wvc submodule update --remote" pins.
+type SubmoduleConfig struct {
+	Remote string `toml:"remote"`
+	Branch string `toml:"branch,omitempty"` // defaults to "main"
+}
+
+// TelemetryConfig configures anonymous usage reporting, which is off
+// unless enabled with "wvc telemetry on"
+type TelemetryConfig struct {
+	Enabled  bool   `toml:"enabled,omitempty"`
+	Endpoint string `toml:"endpoint,omitempty"` // URL the usage events are posted to
+	ID       string `toml:"id,omitempty"`       // random identifier of the repository, never derived from it
+}
+
+// TelemetryEnabled reports whether usage events are recorded: telemetry is
+// enabled with an endpoint, and neither WVC_TELEMETRY=off nor DO_NOT_TRACK
+// is set in the environment
+func (c *Config) TelemetryEnabled() bool {
+	if c == nil || c.Telemetry == nil || !c.Telemetry.Enabled || c.Telemetry.Endpoint == "" {
+		return false
+	}
+	return !TelemetryDisabledByEnv()
+}
+
+// TelemetryDisabledByEnv reports whether the environment turns telemetry
+// off regardless of the configuration
+func TelemetryDisabledByEnv() bool {
+	switch os.Getenv("WVC_TELEMETRY") {
+	case "0", "off", "false":
+		return true
+	}
+	v := os.Getenv("DO_NOT_TRACK")
+	return v != "" && v != "0"
+}
+
+// WeaviateConfig holds settings of the connection to Weaviate
+type WeaviateConfig struct {
+	Auth *WeaviateAuthConfig `toml:"auth,omitempty"`
+}
+
+// WeaviateAuthConfig authenticates wvc to a secured Weaviate. At most one
+// method may be set: an API key, a bearer token, an OIDC client secret, or
+// an OIDC username and password. Headers are sent with every request, e.g.
+// the API keys of vectorizer modules.
+type WeaviateAuthConfig struct {
+	APIKey       string            `toml:"api_key,omitempty"`
+	BearerToken  string            `toml:"bearer_token,omitempty"`
+	ClientSecret string            `toml:"client_secret,omitempty"`
+	Username     string            `toml:"username,omitempty"`
+	Password     string            `toml:"password,omitempty"`
+	Scopes       []string          `toml:"scopes,omitempty"` // OIDC scopes requested with a client secret or password
+	Headers      map[string]string `toml:"headers,omitempty"`
+}
+
+// weaviateAuthEnv maps the environment variables overriding the Weaviate
+// credentials to the fields they set
+var weaviateAuthEnv = []struct {
+	name  string
+	field func(*WeaviateAuthConfig) *string
+}{
+	{"WVC_WEAVIATE_API_KEY", func(a *WeaviateAuthConfig) *string { return &a.APIKey }},
+	{"WVC_WEAVIATE_BEARER_TOKEN", func(a *WeaviateAuthConfig) *string { return &a.BearerToken }},
+	{"WVC_WEAVIATE_CLIENT_SECRET", func(a *WeaviateAuthConfig) *string { return &a.ClientSecret }},
+	{"WVC_WEAVIATE_USERNAME", func(a *WeaviateAuthConfig) *string { return &a.Username }},
+	{"WVC_WEAVIATE_PASSWORD", func(a *WeaviateAuthConfig) *string { return &a.Password }},
+}
+
+// WeaviateAuth returns the credentials and headers wvc sends to Weaviate,
+// or nil for none. Credentials set in the environment (WVC_WEAVIATE_API_KEY,
+// WVC_WEAVIATE_BEARER_TOKEN, WVC_WEAVIATE_CLIENT_SECRET, and
+// WVC_WEAVIATE_USERNAME with WVC_WEAVIATE_PASSWORD) replace the configured
+// ones, and WVC_WEAVIATE_HEADERS adds comma-separated Name=value headers.
+// A nil configuration reads the environment only.
+func (c *Config) WeaviateAuth() (*WeaviateAuthConfig, error) {
+	auth := &WeaviateAuthConfig{}
+	if c != nil && c.Weaviate != nil && c.Weaviate.Auth != nil {
+		*auth = *c.Weaviate.Auth
+		auth.Headers = maps.Clone(c.Weaviate.Auth.Headers)
+	}
+
+	var fromEnv WeaviateAuthConfig
+	envSet := false
+	for _, v := range weaviateAuthEnv {
+		if value := os.Getenv(v.name); value != "" {
+			*v.field(&fromEnv) = value
+			envSet = true
+		}
+	}
+	if envSet {
+		fromEnv.Scopes, fromEnv.Headers = auth.Scopes, auth.Headers
+		*auth = fromEnv
+	}
+
+	if env := os.Getenv("WVC_WEAVIATE_HEADERS"); env != "" {
+		for _, pair := range strings.Split(env, ",") {
+			name, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok || name == "" {
+				return nil, fmt.Errorf("WVC_WEAVIATE_HEADERS: expected Name=value, got %q", pair)
+			}
+			if auth.Headers == nil {
+				auth.Headers = make(map[string]string)
+			}
+			auth.Headers[name] = value
+		}
+	}
+
+	if err := auth.Validate(); err != nil {
+		return nil, err
+	}
+	if auth.method() == "" && len(auth.Headers) == 0 {
+		return nil, nil
+	}
+	return auth, nil
+}
+
+// method returns the name of the configured authentication method, or ""
+// if none is set
+func (a *WeaviateAuthConfig) method() string {
+	var methods []string
+	if a.APIKey != "" {
+		methods = append(methods, "api_key")
+	}
+	if a.BearerToken != "" {
+		methods = append(methods, "bearer_token")
+	}
+	if a.ClientSecret != "" {
+		methods = append(methods, "client_secret")
+	}
+	if a.Username != "" || a.Password != "" {
+		methods = append(methods, "username")
+	}
+	return strings.Join(methods, ", ")
+}
+
+// Validate checks that at most one authentication method is set, and that
+// a username comes with a password
+func (a *WeaviateAuthConfig) Validate() error {
+	if m := a.method(); strings.Contains(m, ",") {
+		return fmt.Errorf("weaviate.auth: only one of api_key, bearer_token, client_secret, or username may be set, got %s", m)
+	}
+	if (a.Username == "") != (a.Password == "") {
+		return fmt.Errorf("weaviate.auth: username and password must be set together")
+	}
+	return nil
+}
+
+// hasSecrets reports whether the configuration stores credentials, in
+// which case the config file is only readable by its owner
+func (c *Config) hasSecrets() bool {
+	if c.Weaviate == nil || c.Weaviate.Auth == nil {
+		return false
+	}
+	a := c.Weaviate.Auth
+	return a.method() != "" || len(a.Headers) > 0
 }
 
 // FindWVCRoot finds the .wvc directory by walking up from current directory
@@ -52,6 +370,11 @@ func Load() (*Config, error) {
 		return nil, err
 	}
 
+	return LoadDir(wvcPath)
+}
+
+// LoadDir loads the configuration of the given .wvc directory
+func LoadDir(wvcPath string) (*Config, error) {
 	configPath := filepath.Join(wvcPath, ConfigFile)
 	data, err := os.ReadFile(configPath)
 	if err != nil {
@@ -75,7 +398,15 @@ func (c *Config) Save() error {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
-	return os.WriteFile(configPath, data, 0644)
+	mode := os.FileMode(0644)
+	if c.hasSecrets() {
+		mode = 0600
+	}
+	if err := os.WriteFile(configPath, data, mode); err != nil {
+		return err
+	}
+	// WriteFile keeps the mode of an existing file
+	return os.Chmod(configPath, mode)
 }
 
 // WVCPath returns the path to the .wvc directory
@@ -83,11 +414,20 @@ func (c *Config) WVCPath() string {
 	return c.path
 }
 
-// DatabasePath returns the path to the bbolt database
+// DatabasePath returns the path to the bbolt database; linked worktrees
+// share the main repository's database
 func (c *Config) DatabasePath() string {
+	if c.CommonDir != "" {
+		return filepath.Join(c.CommonDir, DatabaseFile)
+	}
 	return filepath.Join(c.path, DatabaseFile)
 }
 
+// IsLinkedWorktree reports whether the configuration belongs to a linked worktree
+func (c *Config) IsLinkedWorktree() bool {
+	return c.CommonDir != ""
+}
+
 // SnapshotsPath returns the path to the snapshots directory
 func (c *Config) SnapshotsPath() string {
 	return filepath.Join(c.path, SnapshotsDir)
@@ -100,7 +440,18 @@ func Initialize(weaviateURL string) (*Config, error) {
 		return nil, err
 	}
 
-	wvcPath := filepath.Join(cwd, WVCDir)
+	return initializeIn(cwd, &Config{WeaviateURL: weaviateURL})
+}
+
+// InitializeWorktree creates the .wvc directory of a linked worktree in dir,
+// pointing at the main repository's .wvc directory commonDir
+func InitializeWorktree(dir, weaviateURL, commonDir, name string) (*Config, error) {
+	return initializeIn(dir, &Config{WeaviateURL: weaviateURL, CommonDir: commonDir, Worktree: name})
+}
+
+// initializeIn creates a .wvc directory in dir holding cfg
+func initializeIn(dir string, cfg *Config) (*Config, error) {
+	wvcPath := filepath.Join(dir, WVCDir)
 
 	// Check if already initialized
 	if _, err := os.Stat(wvcPath); err == nil {
@@ -117,10 +468,7 @@ func Initialize(weaviateURL string) (*Config, error) {
 		return nil, fmt.Errorf("failed to create snapshots directory: %w", err)
 	}
 
-	cfg := &Config{
-		WeaviateURL: weaviateURL,
-		path:        wvcPath,
-	}
+	cfg.path = wvcPath
 
 	if err := cfg.Save(); err != nil {
 		// Cleanup on failure
@@ -131,6 +479,64 @@ func Initialize(weaviateURL string) (*Config, error) {
 	return cfg, nil
 }
 
+// Author returns the author recorded in new commits, formatted as
+// "Name <email>". WVC_AUTHOR_NAME and WVC_AUTHOR_EMAIL override the [user]
+// table; without a name the operating system user name is used.
+func (c *Config) Author() string {
+	var name, email string
+	if c.User != nil {
+		name, email = c.User.Name, c.User.Email
+	}
+	if v := os.Getenv("WVC_AUTHOR_NAME"); v != "" {
+		name = v
+	}
+	if v := os.Getenv("WVC_AUTHOR_EMAIL"); v != "" {
+		email = v
+	}
+	if name == "" {
+		if u, err := user.Current(); err == nil {
+			name = u.Username
+		}
+	}
+	switch {
+	case name == "" && email == "":
+		return ""
+	case email == "":
+		return name
+	case name == "":
+		return "<" + email + ">"
+	}
+	return name + " <" + email + ">"
+}
+
+// SyncPolicy returns the fsync policy of the local database
+func (c *Config) SyncPolicy() (fsutil.SyncPolicy, error) {
+	var name string
+	if c.Core != nil {
+		name = c.Core.FSync
+	}
+	policy, err := fsutil.ParseSyncPolicy(name, fsutil.SyncFull)
+	if err != nil {
+		return "", fmt.Errorf("core.fsync: %w", err)
+	}
+	return policy, nil
+}
+
+// SnapshotInterval returns the number of commits between state snapshots,
+// or 0 when they are disabled
+func (c *Config) SnapshotInterval() int {
+	if c == nil || c.Core == nil || c.Core.SnapshotInterval == 0 {
+		return DefaultSnapshotInterval
+	}
+	return max(c.Core.SnapshotInterval, 0)
+}
+
+// FullScan reports whether change detection must compare every object
+// instead of skipping classes that are unchanged since a clean scan
+func (c *Config) FullScan() bool {
+	return c != nil && c.Core != nil && c.Core.FullScan
+}
+
 // SupportsCursorPagination returns true if the server version supports cursor pagination
 func (c *Config) SupportsCursorPagination() bool {
 	if c.ServerVersion == "" {
@@ -148,3 +554,157 @@ func (c *Config) SupportsCursorPagination() bool {
 	// Cursor pagination (WithAfter) requires Weaviate 1.18+
 	return major > 1 || (major == 1 && minor >= 18)
 }
+
+// settableKeys maps the keys "wvc config" reads and writes to their string
+// fields. Scopes and headers are handled separately.
+var settableKeys = map[string]func(c *Config) *string{
+	"user.name":                   func(c *Config) *string { return &c.user().Name },
+	"user.email":                  func(c *Config) *string { return &c.user().Email },
+	"weaviate.auth.api_key":       func(c *Config) *string { return &c.weaviateAuth().APIKey },
+	"weaviate.auth.bearer_token":  func(c *Config) *string { return &c.weaviateAuth().BearerToken },
+	"weaviate.auth.client_secret": func(c *Config) *string { return &c.weaviateAuth().ClientSecret },
+	"weaviate.auth.username":      func(c *Config) *string { return &c.weaviateAuth().Username },
+	"weaviate.auth.password":      func(c *Config) *string { return &c.weaviateAuth().Password },
+}
+
+const (
+	scopesKey       = "weaviate.auth.scopes"
+	headerKeyPrefix = "weaviate.auth.headers."
+)
+
+// SettableKeys returns the keys accepted by Get, Set, and Unset, in order;
+// weaviate.auth.headers.<Name> sets the header Name
+func SettableKeys() []string {
+	keys := make([]string, 0, len(settableKeys)+2)
+	for key := range settableKeys {
+		keys = append(keys, key)
+	}
+	keys = append(keys, scopesKey, headerKeyPrefix+"<Name>")
+	slices.Sort(keys)
+	return keys
+}
+
+// Get returns the value of a key, and whether it is set. Scopes are
+// returned comma-separated.
+func (c *Config) Get(key string) (string, bool, error) {
+	var value string
+	switch field, ok := settableKeys[key]; {
+	case ok:
+		value = *field(c.clone())
+	case key == scopesKey:
+		value = strings.Join(c.clone().weaviateAuth().Scopes, ",")
+	case strings.HasPrefix(key, headerKeyPrefix) && len(key) > len(headerKeyPrefix):
+		value = c.clone().weaviateAuth().Headers[strings.TrimPrefix(key, headerKeyPrefix)]
+	default:
+		return "", false, unknownKeyError(key)
+	}
+	return value, value != "", nil
+}
+
+// Set sets a key to a value; scopes are given comma-separated. The caller
+// saves the configuration.
+func (c *Config) Set(key, value string) error {
+	next := c.clone()
+	switch field, ok := settableKeys[key]; {
+	case ok:
+		*field(next) = value
+	case key == scopesKey:
+		next.weaviateAuth().Scopes = nil
+		for _, scope := range strings.Split(value, ",") {
+			if scope = strings.TrimSpace(scope); scope != "" {
+				next.weaviateAuth().Scopes = append(next.weaviateAuth().Scopes, scope)
+			}
+		}
+	case strings.HasPrefix(key, headerKeyPrefix) && len(key) > len(headerKeyPrefix):
+		auth := next.weaviateAuth()
+		if auth.Headers == nil {
+			auth.Headers = make(map[string]string)
+		}
+		auth.Headers[strings.TrimPrefix(key, headerKeyPrefix)] = value
+	default:
+		return unknownKeyError(key)
+	}
+	next.prune()
+	if next.Weaviate != nil && next.Weaviate.Auth != nil {
+		if err := next.Weaviate.Auth.Validate(); err != nil {
+			return err
+		}
+	}
+	c.User, c.Weaviate = next.User, next.Weaviate
+	return nil
+}
+
+// Unset removes a key. The caller saves the configuration.
+func (c *Config) Unset(key string) error {
+	if _, set, err := c.Get(key); err != nil {
+		return err
+	} else if !set {
+		return fmt.Errorf("%s is not set", key)
+	}
+	next := c.clone()
+	switch field, ok := settableKeys[key]; {
+	case ok:
+		*field(next) = ""
+	case key == scopesKey:
+		next.weaviateAuth().Scopes = nil
+	default:
+		delete(next.weaviateAuth().Headers, strings.TrimPrefix(key, headerKeyPrefix))
+	}
+	next.prune()
+	c.User, c.Weaviate = next.User, next.Weaviate
+	return nil
+}
+
+func unknownKeyError(key string) error {
+	return fmt.Errorf("unknown config key '%s' (supported: %s)", key, strings.Join(SettableKeys(), ", "))
+}
+
+// clone copies the tables Set and Unset modify, so a rejected change
+// leaves the configuration untouched
+func (c *Config) clone() *Config {
+	next := &Config{}
+	if c.User != nil {
+		user := *c.User
+		next.User = &user
+	}
+	if c.Weaviate != nil && c.Weaviate.Auth != nil {
+		auth := *c.Weaviate.Auth
+		auth.Scopes = slices.Clone(auth.Scopes)
+		auth.Headers = maps.Clone(auth.Headers)
+		next.Weaviate = &WeaviateConfig{Auth: &auth}
+	}
+	return next
+}
+
+func (c *Config) user() *UserConfig {
+	if c.User == nil {
+		c.User = &UserConfig{}
+	}
+	return c.User
+}
+
+func (c *Config) weaviateAuth() *WeaviateAuthConfig {
+	if c.Weaviate == nil {
+		c.Weaviate = &WeaviateConfig{}
+	}
+	if c.Weaviate.Auth == nil {
+		c.Weaviate.Auth = &WeaviateAuthConfig{}
+	}
+	return c.Weaviate.Auth
+}
+
+// prune drops the tables Set and Unset left empty
+func (c *Config) prune() {
+	if c.User != nil && *c.User == (UserConfig{}) {
+		c.User = nil
+	}
+	if c.Weaviate != nil && c.Weaviate.Auth != nil {
+		auth := c.Weaviate.Auth
+		if len(auth.Headers) == 0 {
+			auth.Headers = nil
+		}
+		if auth.method() == "" && len(auth.Scopes) == 0 && auth.Headers == nil {
+			c.Weaviate = nil
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kilupskalvis/wvc/internal/config"
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+	"golang.org/x/sync/errgroup"
+)
+
+// objectWrite is one create, update, or delete of an object in Weaviate
+type objectWrite struct {
+	Action models.ApplyAction
+	Object *models.WeaviateObject
+	// Err is set by applyObjectWrites when the write failed
+	Err error
+}
+
+// applyObjectWrites writes objects to Weaviate in batches of the configured
+// size, keeping up to the configured number of batch requests in flight.
+// Creates and updates go through the batch endpoint, which replaces existing
+// objects; deletes are batched per class. The Err of every write that failed
+// is set, including when its whole batch failed. Only cancellation of ctx is
+// returned as an error.
+func applyObjectWrites(ctx context.Context, cfg *config.Config, client weaviate.ClientInterface, writes []*objectWrite) error {
+	batchSize := cfg.ApplyBatchSize()
+
+	var batches [][]*objectWrite
+	var puts []*objectWrite
+	deletes := make(map[string][]*objectWrite)
+	var deleteClasses []string
+	for _, w := range writes {
+		if w.Action != models.ApplyDelete {
+			puts = append(puts, w)
+			continue
+		}
+		if _, ok := deletes[w.Object.TenantClass()]; !ok {
+			deleteClasses = append(deleteClasses, w.Object.TenantClass())
+		}
+		deletes[w.Object.TenantClass()] = append(deletes[w.Object.TenantClass()], w)
+	}
+	for _, className := range deleteClasses {
+		batches = append(batches, chunkWrites(deletes[className], batchSize)...)
+	}
+	batches = append(batches, chunkWrites(puts, batchSize)...)
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(cfg.ApplyConcurrency())
+	for _, batch := range batches {
+		g.Go(func() error {
+			if err := gctx.Err(); err != nil {
+				return err
+			}
+			writeBatch(gctx, client, batch)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// writeBatch sends one batch request. Batches hold either deletes of one
+// class or creates and updates.
+func writeBatch(ctx context.Context, client weaviate.ClientInterface, batch []*objectWrite) {
+	var errs []error
+	var err error
+	if batch[0].Action == models.ApplyDelete {
+		ids := make([]string, len(batch))
+		for i, w := range batch {
+			ids[i] = w.Object.ID
+		}
+		errs, err = client.BatchDeleteObjects(ctx, batch[0].Object.TenantClass(), ids)
+	} else {
+		objs := make([]*models.WeaviateObject, len(batch))
+		for i, w := range batch {
+			objs[i] = w.Object
+		}
+		errs, err = client.BatchPutObjects(ctx, objs)
+	}
+	for i, w := range batch {
+		switch {
+		case err != nil:
+			w.Err = err
+		case i < len(errs):
+			w.Err = errs[i]
+		}
+	}
+}
+
+// chunkWrites splits writes into consecutive batches of at most size
+func chunkWrites(writes []*objectWrite, size int) [][]*objectWrite {
+	var chunks [][]*objectWrite
+	for start := 0; start < len(writes); start += size {
+		chunks = append(chunks, writes[start:min(start+size, len(writes))])
+	}
+	return chunks
+}
+
+// applyWarning describes a failed write as a checkout warning
+func applyWarning(w *objectWrite) CheckoutWarning {
+	return CheckoutWarning{
+		Type:    string(w.Action) + "_failed",
+		Message: fmt.Sprintf("failed to %s %s/%s: %v", w.Action, w.Object.TenantClass(), w.Object.ID, w.Err),
+	}
+}
@@ -0,0 +1,366 @@
+package core
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/remote"
+	"github.com/kilupskalvis/wvc/internal/store"
+)
+
+// ArchiveVersion is the format version written to archive manifests
+const ArchiveVersion = 1
+
+// Entries of a repository archive, a gzip-compressed tar file. The manifest
+// comes first, then every blob, then every commit bundle, parents before
+// children, so an archive can be imported in a single pass.
+const (
+	archiveManifest = "manifest.json"
+	archiveBlobs    = "blobs/"
+	archiveCommits  = "commits/"
+	// archiveDimensions is the PAX record holding a blob's vector dimensions
+	archiveDimensions = "WVC.dimensions"
+)
+
+// ArchiveManifest describes the contents of a repository archive
+type ArchiveManifest struct {
+	Version   int              `json:"version"`
+	CreatedAt time.Time        `json:"created_at"`
+	Head      string           `json:"head,omitempty"` // branch checked out when exported
+	Branches  []*models.Branch `json:"branches"`
+	Tags      []*models.Tag    `json:"tags"`
+	Commits   int              `json:"commits"`
+	Blobs     int              `json:"blobs"`
+}
+
+// ArchiveProgress is called during export and import to report progress
+type ArchiveProgress func(phase string, current, total int)
+
+// ExportArchive writes every branch and tag of the repository, with the
+// commits, operations, schema versions, and vector and payload blobs they
+// reach, to w as a gzip-compressed tar archive. Shallow clones and clones
+// filtered by class lack part of the history and cannot be exported.
+func ExportArchive(st *store.Store, w io.Writer, progress ArchiveProgress) (*ArchiveManifest, error) {
+	if progress == nil {
+		progress = func(string, int, int) {}
+	}
+	if shallow, err := st.ListShallowCommits(); err != nil {
+		return nil, err
+	} else if len(shallow) > 0 {
+		return nil, fmt.Errorf("shallow repository: fetch the full history before exporting")
+	}
+	if filter, err := st.GetPromisorFilter(); err != nil {
+		return nil, err
+	} else if len(filter.Classes) > 0 {
+		return nil, fmt.Errorf("repository was cloned with a class filter; export from a full clone")
+	}
+
+	manifest := &ArchiveManifest{Version: ArchiveVersion, CreatedAt: time.Now().UTC()}
+	var err error
+	if manifest.Head, err = st.GetCurrentBranch(); err != nil {
+		return nil, err
+	}
+	branches, err := st.ListBranches()
+	if err != nil {
+		return nil, err
+	}
+	manifest.Branches = []*models.Branch{}
+	for _, b := range branches {
+		if b.CommitID != "" {
+			manifest.Branches = append(manifest.Branches, b)
+		}
+	}
+	sort.Slice(manifest.Branches, func(i, j int) bool { return manifest.Branches[i].Name < manifest.Branches[j].Name })
+	if manifest.Tags, err = st.ListTags(); err != nil {
+		return nil, err
+	}
+	if manifest.Tags == nil {
+		manifest.Tags = []*models.Tag{}
+	}
+	sort.Slice(manifest.Tags, func(i, j int) bool { return manifest.Tags[i].Name < manifest.Tags[j].Name })
+
+	// Commits reachable from any ref, parents first
+	var commitIDs []string
+	seen := make(map[string]bool)
+	tips := make([]string, 0, len(manifest.Branches)+len(manifest.Tags))
+	for _, b := range manifest.Branches {
+		tips = append(tips, b.CommitID)
+	}
+	for _, t := range manifest.Tags {
+		tips = append(tips, t.CommitID)
+	}
+	for _, tip := range tips {
+		if seen[tip] {
+			continue
+		}
+		ids, err := getCommitPath(st, tip)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			if !seen[id] {
+				seen[id] = true
+				commitIDs = append(commitIDs, id)
+			}
+		}
+	}
+
+	blobSet := make(map[string]bool)
+	for i, id := range commitIDs {
+		progress("reading commits", i+1, len(commitIDs))
+		ops, err := st.GetOperationsByCommit(id)
+		if err != nil {
+			return nil, fmt.Errorf("get operations of %s: %w", shortCommitID(id), err)
+		}
+		for _, op := range ops {
+			for _, h := range append(op.VectorHashes(), op.PayloadHashes()...) {
+				blobSet[h] = true
+			}
+		}
+	}
+	blobs := sortedKeys(blobSet)
+	manifest.Commits = len(commitIDs)
+	manifest.Blobs = len(blobs)
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal manifest: %w", err)
+	}
+	if err := writeArchiveEntry(tw, archiveManifest, data, nil); err != nil {
+		return nil, err
+	}
+
+	for i, hash := range blobs {
+		progress("writing blobs", i+1, len(blobs))
+		data, dims, err := st.GetVectorBlob(hash)
+		if err != nil {
+			return nil, fmt.Errorf("read blob %s: %w", hash, err)
+		}
+		records := map[string]string{archiveDimensions: strconv.Itoa(dims)}
+		if err := writeArchiveEntry(tw, archiveBlobs+hash, data, records); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, id := range commitIDs {
+		progress("writing commits", i+1, len(commitIDs))
+		bundle, err := buildCommitBundle(st, id, false, false)
+		if err != nil {
+			return nil, fmt.Errorf("build commit bundle for %s: %w", shortCommitID(id), err)
+		}
+		data, err := json.Marshal(bundle)
+		if err != nil {
+			return nil, fmt.Errorf("marshal commit %s: %w", shortCommitID(id), err)
+		}
+		// Numbered so the archive lists commits in import order
+		name := fmt.Sprintf("%s%08d-%s.json", archiveCommits, i, id)
+		if err := writeArchiveEntry(tw, name, data, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("write archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("write archive: %w", err)
+	}
+	return manifest, nil
+}
+
+func writeArchiveEntry(tw *tar.Writer, name string, data []byte, records map[string]string) error {
+	hdr := &tar.Header{
+		Name:       name,
+		Mode:       0644,
+		Size:       int64(len(data)),
+		ModTime:    time.Now().UTC(),
+		Format:     tar.FormatPAX,
+		PAXRecords: records,
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("write archive entry %s: %w", name, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("write archive entry %s: %w", name, err)
+	}
+	return nil
+}
+
+// ImportArchive restores an archive written by ExportArchive into a
+// repository without commits: blobs are verified against their hashes as
+// they are stored, then commits, branches, and tags are recorded. The
+// working state is left alone; check out a branch to restore its objects.
+func ImportArchive(st *store.Store, r io.Reader, progress ArchiveProgress) (*ArchiveManifest, error) {
+	if progress == nil {
+		progress = func(string, int, int) {}
+	}
+	if branches, err := st.ListBranches(); err != nil {
+		return nil, err
+	} else if len(branches) > 0 {
+		return nil, fmt.Errorf("repository already has commits; import into a new repository")
+	}
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a wvc archive: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+
+	hdr, err := tr.Next()
+	if err != nil || hdr.Name != archiveManifest {
+		return nil, fmt.Errorf("not a wvc archive: missing %s", archiveManifest)
+	}
+	var manifest ArchiveManifest
+	if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	if manifest.Version != ArchiveVersion {
+		return nil, fmt.Errorf("unsupported archive version %d (this wvc reads version %d)", manifest.Version, ArchiveVersion)
+	}
+
+	var bundles []*remote.CommitBundle
+	commits := 0
+	flush := func() error {
+		if err := st.InsertCommitBundles(bundles); err != nil {
+			return fmt.Errorf("store commits: %w", err)
+		}
+		bundles = bundles[:0]
+		return nil
+	}
+	blobs := 0
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read archive: %w", err)
+		}
+		switch {
+		case strings.HasPrefix(hdr.Name, archiveBlobs):
+			if commits > 0 {
+				return nil, fmt.Errorf("corrupt archive: blob %s after commits", hdr.Name)
+			}
+			blobs++
+			progress("storing blobs", blobs, manifest.Blobs)
+			hash := path.Base(hdr.Name)
+			dims, err := strconv.Atoi(hdr.PAXRecords[archiveDimensions])
+			if err != nil {
+				return nil, fmt.Errorf("corrupt archive: blob %s has no dimensions", hash)
+			}
+			if _, err := st.SaveVectorBlobFrom(tr, hash, dims); err != nil {
+				return nil, fmt.Errorf("store blob %s: %w", hash, err)
+			}
+		case strings.HasPrefix(hdr.Name, archiveCommits):
+			commits++
+			progress("storing commits", commits, manifest.Commits)
+			var bundle remote.CommitBundle
+			if err := json.NewDecoder(tr).Decode(&bundle); err != nil {
+				return nil, fmt.Errorf("read %s: %w", hdr.Name, err)
+			}
+			if bundle.Commit == nil {
+				return nil, fmt.Errorf("corrupt archive: %s has no commit", hdr.Name)
+			}
+			bundles = append(bundles, &bundle)
+			if len(bundles) == bundleInsertBatchSize {
+				if err := flush(); err != nil {
+					return nil, err
+				}
+			}
+		default:
+			return nil, fmt.Errorf("corrupt archive: unexpected entry %s", hdr.Name)
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	if commits != manifest.Commits || blobs != manifest.Blobs {
+		return nil, fmt.Errorf("corrupt archive: manifest lists %d commit(s) and %d blob(s), found %d and %d",
+			manifest.Commits, manifest.Blobs, commits, blobs)
+	}
+
+	for _, b := range manifest.Branches {
+		if has, err := st.HasCommit(b.CommitID); err != nil {
+			return nil, err
+		} else if !has {
+			return nil, fmt.Errorf("corrupt archive: branch '%s' points at missing commit %s", b.Name, shortCommitID(b.CommitID))
+		}
+		if err := st.CreateBranch(b.Name, b.CommitID); err != nil {
+			return nil, fmt.Errorf("create branch '%s': %w", b.Name, err)
+		}
+	}
+	for _, t := range manifest.Tags {
+		if has, err := st.HasCommit(t.CommitID); err != nil {
+			return nil, err
+		} else if !has {
+			return nil, fmt.Errorf("corrupt archive: tag '%s' points at missing commit %s", t.Name, shortCommitID(t.CommitID))
+		}
+		if err := st.PutTag(t); err != nil {
+			return nil, fmt.Errorf("create tag '%s': %w", t.Name, err)
+		}
+	}
+	return &manifest, nil
+}
+
+// ArchivePushResult summarizes pushing an imported archive to a remote
+type ArchivePushResult struct {
+	Branches []string
+	Tags     []string
+	Commits  int
+	Vectors  int
+}
+
+// PushArchive pushes the branches and tags of an imported archive to a
+// remote, creating them there. Existing remote branches are only moved
+// forward; existing tags must already point at the same commit.
+func PushArchive(ctx context.Context, st *store.Store, client remote.RemoteClient, remoteName string, manifest *ArchiveManifest, progress PushProgress) (*ArchivePushResult, error) {
+	result := &ArchivePushResult{}
+	for _, b := range manifest.Branches {
+		pushed, err := Push(ctx, st, client, PushOptions{RemoteName: remoteName, Branch: b.Name}, progress)
+		if err != nil {
+			return result, fmt.Errorf("push branch '%s': %w", b.Name, err)
+		}
+		result.Branches = append(result.Branches, b.Name)
+		result.Commits += pushed.CommitsPushed
+		result.Vectors += pushed.VectorsPushed
+	}
+
+	if len(manifest.Tags) == 0 {
+		return result, nil
+	}
+	existing, err := client.ListTags(ctx)
+	if err != nil {
+		return result, fmt.Errorf("list remote tags: %w", err)
+	}
+	remoteTags := make(map[string]string, len(existing))
+	for _, t := range existing {
+		remoteTags[t.Name] = t.CommitID
+	}
+	for _, t := range manifest.Tags {
+		if commitID, ok := remoteTags[t.Name]; ok {
+			if commitID != t.CommitID {
+				return result, fmt.Errorf("tag '%s' already exists on the remote at %s", t.Name, shortCommitID(commitID))
+			}
+			continue
+		}
+		if err := client.PutTag(ctx, t, false); err != nil {
+			return result, fmt.Errorf("push tag '%s': %w", t.Name, err)
+		}
+		result.Tags = append(result.Tags, t.Name)
+	}
+	return result, nil
+}
@@ -0,0 +1,199 @@
+package core
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/remote"
+	"github.com/kilupskalvis/wvc/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newArchiveTestStore builds a repository with two branches, a tag, and a vector blob.
+func newArchiveTestStore(t *testing.T) (*store.Store, string) {
+	t.Helper()
+	st := newPushTestStore(t)
+
+	now := time.Now()
+	vhash, err := st.SaveVectorBlob([]byte{0, 0, 128, 63, 0, 0, 0, 64}, 2)
+	require.NoError(t, err)
+	require.NoError(t, st.RecordOperation(&models.Operation{
+		Type:       models.OperationInsert,
+		ClassName:  "Article",
+		ObjectID:   "obj1",
+		VectorHash: vhash,
+	}))
+	require.NoError(t, st.CreateCommit(&models.Commit{ID: "c1", Message: "first", Timestamp: now}))
+	_, err = st.MarkOperationsCommitted("c1")
+	require.NoError(t, err)
+	require.NoError(t, st.CreateCommit(&models.Commit{ID: "c2", ParentID: "c1", Message: "second", Timestamp: now.Add(time.Second)}))
+	require.NoError(t, st.CreateCommit(&models.Commit{ID: "c3", ParentID: "c1", Message: "third", Timestamp: now.Add(2 * time.Second)}))
+	require.NoError(t, st.CreateBranch("main", "c2"))
+	require.NoError(t, st.CreateBranch("dev", "c3"))
+	require.NoError(t, st.SetCurrentBranch("dev"))
+	require.NoError(t, st.PutTag(&models.Tag{Name: "v1", CommitID: "c1", Message: "release", CreatedAt: now}))
+	return st, vhash
+}
+
+func TestArchive_RoundTrip(t *testing.T) {
+	src, vhash := newArchiveTestStore(t)
+
+	var buf bytes.Buffer
+	manifest, err := ExportArchive(src, &buf, nil)
+	require.NoError(t, err)
+	assert.Equal(t, ArchiveVersion, manifest.Version)
+	assert.Equal(t, "dev", manifest.Head)
+	assert.Equal(t, 3, manifest.Commits)
+	assert.Equal(t, 1, manifest.Blobs)
+	require.Len(t, manifest.Branches, 2)
+	assert.Equal(t, "dev", manifest.Branches[0].Name)
+	assert.Equal(t, "main", manifest.Branches[1].Name)
+
+	dst := newPushTestStore(t)
+	imported, err := ImportArchive(dst, bytes.NewReader(buf.Bytes()), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "dev", imported.Head)
+
+	for name, tip := range map[string]string{"main": "c2", "dev": "c3"} {
+		b, err := dst.GetBranch(name)
+		require.NoError(t, err)
+		assert.Equal(t, tip, b.CommitID)
+	}
+	commit, err := dst.GetCommit("c2")
+	require.NoError(t, err)
+	assert.Equal(t, "c1", commit.ParentID)
+	assert.Equal(t, "second", commit.Message)
+
+	tag, err := dst.GetTag("v1")
+	require.NoError(t, err)
+	assert.Equal(t, "c1", tag.CommitID)
+	assert.Equal(t, "release", tag.Message)
+
+	ops, err := dst.GetOperationsByCommit("c1")
+	require.NoError(t, err)
+	require.Len(t, ops, 1)
+	assert.Equal(t, vhash, ops[0].VectorHash)
+	data, dims, err := dst.GetVectorBlob(vhash)
+	require.NoError(t, err)
+	assert.Equal(t, 2, dims)
+	assert.Equal(t, []byte{0, 0, 128, 63, 0, 0, 0, 64}, data)
+
+	// A second import would clobber the history just restored
+	_, err = ImportArchive(dst, bytes.NewReader(buf.Bytes()), nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already has commits")
+}
+
+func TestArchive_ExportRefusesShallow(t *testing.T) {
+	st, _ := newArchiveTestStore(t)
+	require.NoError(t, st.MarkShallowCommit("c1"))
+
+	_, err := ExportArchive(st, &bytes.Buffer{}, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "shallow")
+}
+
+func TestArchive_ImportRejectsCorrupt(t *testing.T) {
+	src, vhash := newArchiveTestStore(t)
+	var buf bytes.Buffer
+	_, err := ExportArchive(src, &buf, nil)
+	require.NoError(t, err)
+
+	t.Run("not gzip", func(t *testing.T) {
+		_, err := ImportArchive(newPushTestStore(t), bytes.NewReader([]byte("hello")), nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not a wvc archive")
+	})
+
+	t.Run("tampered blob", func(t *testing.T) {
+		tampered := rewriteArchive(t, buf.Bytes(), func(hdr *tar.Header, data []byte) []byte {
+			if hdr.Name == archiveBlobs+vhash {
+				data[0] ^= 0xff
+			}
+			return data
+		})
+		_, err := ImportArchive(newPushTestStore(t), bytes.NewReader(tampered), nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), vhash)
+	})
+
+	t.Run("missing commit", func(t *testing.T) {
+		truncated := rewriteArchive(t, buf.Bytes(), func(hdr *tar.Header, data []byte) []byte {
+			if hdr.Name == archiveCommits+"00000002-c2.json" {
+				return nil
+			}
+			return data
+		})
+		_, err := ImportArchive(newPushTestStore(t), bytes.NewReader(truncated), nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "corrupt archive")
+	})
+}
+
+// rewriteArchive copies an archive through edit; entries edit returns nil for are dropped.
+func rewriteArchive(t *testing.T, archive []byte, edit func(hdr *tar.Header, data []byte) []byte) []byte {
+	t.Helper()
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+
+	var out bytes.Buffer
+	gw := gzip.NewWriter(&out)
+	tw := tar.NewWriter(gw)
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			break
+		}
+		data, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		data = edit(hdr, data)
+		if data == nil {
+			continue
+		}
+		hdr.Size = int64(len(data))
+		require.NoError(t, tw.WriteHeader(hdr))
+		_, err = tw.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+	return out.Bytes()
+}
+
+func TestPushArchive(t *testing.T) {
+	src, vhash := newArchiveTestStore(t)
+	var buf bytes.Buffer
+	_, err := ExportArchive(src, &buf, nil)
+	require.NoError(t, err)
+
+	st := newPushTestStore(t)
+	require.NoError(t, st.AddRemote("origin", "http://example.com"))
+	manifest, err := ImportArchive(st, bytes.NewReader(buf.Bytes()), nil)
+	require.NoError(t, err)
+
+	client := newPushMockClient()
+	client.negotiatePushResp = &remote.NegotiatePushResponse{MissingCommits: []string{"c1", "c3"}}
+	client.vectorCheckResp = &remote.VectorCheckResponse{Missing: []string{vhash}}
+
+	result, err := PushArchive(context.Background(), st, client, "origin", manifest, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dev", "main"}, result.Branches)
+	assert.Equal(t, []string{"v1"}, result.Tags)
+	assert.Contains(t, client.uploadedVectors, vhash)
+	require.Contains(t, client.tags, "v1")
+	assert.Equal(t, "c1", client.tags["v1"].CommitID)
+
+	// A remote tag with the same name at another commit is not overwritten
+	client.tags["v1"] = &models.Tag{Name: "v1", CommitID: "c2"}
+	_, err = PushArchive(context.Background(), st, client, "origin", manifest, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "tag 'v1' already exists")
+}
@@ -0,0 +1,218 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/config"
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/remote"
+	"github.com/kilupskalvis/wvc/internal/store"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+)
+
+// benchClass is the class the benchmark dataset is generated into
+const benchClass = "BenchArticle"
+
+// benchRemoteName is the remote the benchmark pushes to and pulls from
+const benchRemoteName = "bench"
+
+// BenchOptions configures RunBenchmark.
+type BenchOptions struct {
+	Objects int
+	Dims    int
+	Dir     string // scratch directory for the local stores
+
+	// Remote, reached at RemoteURL, is the server push and pull are measured
+	// against; both phases are skipped when it is nil.
+	Remote    remote.RemoteClient
+	RemoteURL string
+
+	// Progress, if set, is called as each phase starts.
+	Progress func(phase string)
+}
+
+// BenchPhase is the measurement of one benchmarked operation.
+type BenchPhase struct {
+	Name      string        `json:"name"`
+	Objects   int           `json:"objects"`
+	Duration  time.Duration `json:"duration_ns"`
+	Allocated uint64        `json:"allocated_bytes"`
+}
+
+// ObjectsPerSecond returns the phase's throughput.
+func (p BenchPhase) ObjectsPerSecond() float64 {
+	if p.Duration <= 0 {
+		return 0
+	}
+	return float64(p.Objects) / p.Duration.Seconds()
+}
+
+// BenchReport is the outcome of RunBenchmark.
+type BenchReport struct {
+	Version   string       `json:"version,omitempty"` // set by the caller
+	Objects   int          `json:"objects"`
+	Dims      int          `json:"dims"`
+	GoVersion string       `json:"go_version"`
+	Platform  string       `json:"platform"`
+	Phases    []BenchPhase `json:"phases"`
+}
+
+// RunBenchmark measures commit, checkout, merge, and, with a remote, push and
+// pull of a synthetic dataset. Weaviate is replaced by an in-memory client so
+// the numbers reflect wvc itself and runs on different machines or releases
+// stay comparable.
+//
+// The phases build on each other: commit records Objects new objects; a
+// branch then updates all of them, and checkout switches back, restoring
+// every object; merge brings the branch in after main added a tenth more
+// objects; push sends main to the remote and pull clones it into a fresh store.
+func RunBenchmark(ctx context.Context, opts BenchOptions) (*BenchReport, error) {
+	if opts.Objects <= 0 {
+		return nil, fmt.Errorf("benchmark object count must be positive")
+	}
+
+	report := &BenchReport{
+		Objects:   opts.Objects,
+		Dims:      opts.Dims,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	measure := func(name string, objects int, fn func() error) error {
+		if opts.Progress != nil {
+			opts.Progress(name)
+		}
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		start := time.Now()
+		if err := fn(); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		elapsed := time.Since(start)
+		runtime.ReadMemStats(&after)
+		report.Phases = append(report.Phases, BenchPhase{
+			Name:      name,
+			Objects:   objects,
+			Duration:  elapsed,
+			Allocated: after.TotalAlloc - before.TotalAlloc,
+		})
+		return nil
+	}
+
+	cfg := &config.Config{WeaviateURL: "memory", ServerVersion: "1.25.0"}
+	st, err := openBenchStore(filepath.Join(opts.Dir, "bench.db"))
+	if err != nil {
+		return nil, err
+	}
+	defer st.Close()
+	wc := weaviate.NewMockClient()
+
+	if _, err := GenerateFixtures(ctx, wc, FixtureOptions{Class: benchClass, Count: opts.Objects, Dims: opts.Dims, Seed: 1}); err != nil {
+		return nil, err
+	}
+	if err := measure("commit", opts.Objects, func() error {
+		_, err := CreateCommit(ctx, cfg, st, wc, "bench: base")
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	// Update every object on a branch, then switch back to main
+	if _, err := Checkout(ctx, cfg, st, wc, "main", CheckoutOptions{CreateBranch: true, NewBranchName: "bench"}); err != nil {
+		return nil, fmt.Errorf("create bench branch: %w", err)
+	}
+	objects, err := wc.GetAllObjects(ctx, benchClass, true)
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range objects {
+		updated := *obj
+		updated.Properties = map[string]interface{}{"title": "Revised"}
+		for k, v := range obj.Properties {
+			if k != "title" {
+				updated.Properties[k] = v
+			}
+		}
+		if err := wc.UpdateObject(ctx, &updated); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := CreateCommit(ctx, cfg, st, wc, "bench: revise titles"); err != nil {
+		return nil, err
+	}
+	if err := measure("checkout", opts.Objects, func() error {
+		_, err := Checkout(ctx, cfg, st, wc, "main", CheckoutOptions{})
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	// Diverge main so the merge is a real three-way merge
+	extra := max(opts.Objects/10, 1)
+	if _, err := GenerateFixtures(ctx, wc, FixtureOptions{Class: benchClass, Count: extra, Dims: opts.Dims, Seed: 2}); err != nil {
+		return nil, err
+	}
+	if _, err := CreateCommit(ctx, cfg, st, wc, "bench: add objects"); err != nil {
+		return nil, err
+	}
+	if err := measure("merge", opts.Objects, func() error {
+		result, err := Merge(ctx, cfg, st, wc, "bench", models.MergeOptions{Message: "bench: merge"})
+		if err == nil && !result.Success {
+			err = fmt.Errorf("merge stopped on %d conflicts", len(result.Conflicts))
+		}
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	if opts.Remote == nil {
+		return report, nil
+	}
+	total := opts.Objects + extra
+	if err := AddRemote(st, benchRemoteName, opts.RemoteURL); err != nil {
+		return nil, err
+	}
+	if err := measure("push", total, func() error {
+		_, err := Push(ctx, st, opts.Remote, PushOptions{RemoteName: benchRemoteName, Branch: "main"}, nil)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	clone, err := openBenchStore(filepath.Join(opts.Dir, "clone.db"))
+	if err != nil {
+		return nil, err
+	}
+	defer clone.Close()
+	if err := AddRemote(clone, benchRemoteName, opts.RemoteURL); err != nil {
+		return nil, err
+	}
+	if err := measure("pull", total, func() error {
+		_, err := Pull(ctx, cfg, clone, weaviate.NewMockClient(), opts.Remote, PullOptions{RemoteName: benchRemoteName, Branch: "main"}, nil)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// openBenchStore creates an empty store on branch main.
+func openBenchStore(path string) (*store.Store, error) {
+	st, err := store.New(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := st.Initialize(); err != nil {
+		st.Close()
+		return nil, err
+	}
+	if err := st.SetCurrentBranch("main"); err != nil {
+		st.Close()
+		return nil, err
+	}
+	return st, nil
+}
@@ -0,0 +1,33 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunBenchmark_LocalPhases(t *testing.T) {
+	var started []string
+	report, err := RunBenchmark(context.Background(), BenchOptions{
+		Objects:  50,
+		Dims:     4,
+		Dir:      t.TempDir(),
+		Progress: func(phase string) { started = append(started, phase) },
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"commit", "checkout", "merge"}, started, "push and pull need a remote")
+	require.Len(t, report.Phases, 3)
+	for _, phase := range report.Phases {
+		assert.Equal(t, 50, phase.Objects)
+		assert.Positive(t, phase.Duration)
+		assert.Positive(t, phase.ObjectsPerSecond())
+	}
+}
+
+func TestRunBenchmark_RequiresObjects(t *testing.T) {
+	_, err := RunBenchmark(context.Background(), BenchOptions{Dir: t.TempDir()})
+	assert.Error(t, err)
+}
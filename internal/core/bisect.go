@@ -0,0 +1,315 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"os"
+	"os/exec"
+	"slices"
+	"sort"
+
+	"github.com/kilupskalvis/wvc/internal/config"
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/store"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+)
+
+// Bisect verdicts for the checked-out commit
+const (
+	BisectGood = "good"
+	BisectBad  = "bad"
+	BisectSkip = "skip"
+)
+
+// bisectSkipExitCode is the exit code with which a bisect run command skips
+// the commit, as in git
+const bisectSkipExitCode = 125
+
+// BisectStep describes where a bisect session stands after a verdict.
+type BisectStep struct {
+	// Waiting names the verdict still needed before the search can start:
+	// BisectBad or BisectGood.
+	Waiting string
+	// Current is the commit checked out to be tested next.
+	Current   string
+	Remaining int // commits that may still be the first bad one
+	Steps     int // roughly how many verdicts remain
+	Checkout  *CheckoutResult
+	// FirstBad is set when the search is over. When skipped commits leave
+	// more than one candidate, Candidates lists them all.
+	FirstBad   string
+	Candidates []string
+}
+
+// BisectStart starts a bisect session. The bad and good commits are optional
+// and can be given later with BisectMark; once both are known, the commit
+// halfway between them is checked out, restoring its Weaviate state.
+func BisectStart(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface, bad string, good []string) (*BisectStep, error) {
+	if state, err := st.GetBisectState(); err != nil {
+		return nil, err
+	} else if state != nil {
+		return nil, fmt.Errorf("bisect already in progress; run 'wvc bisect reset' first")
+	}
+
+	original, err := st.GetCurrentBranch()
+	if err != nil {
+		return nil, err
+	}
+	if original == "" {
+		if original, err = st.GetHEAD(); err != nil {
+			return nil, err
+		}
+	}
+	if original == "" {
+		return nil, fmt.Errorf("no commits to bisect")
+	}
+
+	state := &models.BisectState{Original: original}
+	if bad != "" {
+		if state.Bad, _, err = ResolveRef(st, bad); err != nil {
+			return nil, err
+		}
+	}
+	for _, ref := range good {
+		id, _, err := ResolveRef(st, ref)
+		if err != nil {
+			return nil, err
+		}
+		state.Good = append(state.Good, id)
+	}
+	if err := st.SaveBisectState(state); err != nil {
+		return nil, err
+	}
+	return bisectNext(ctx, cfg, st, client, state)
+}
+
+// BisectMark records a verdict for a commit, HEAD when ref is empty, and
+// checks out the next commit to test.
+func BisectMark(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface, verdict, ref string) (*BisectStep, error) {
+	state, err := requireBisectState(st)
+	if err != nil {
+		return nil, err
+	}
+	if ref == "" {
+		ref = "HEAD"
+	}
+	commitID, _, err := ResolveRef(st, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	switch verdict {
+	case BisectBad:
+		state.Bad = commitID
+	case BisectGood:
+		if !slices.Contains(state.Good, commitID) {
+			state.Good = append(state.Good, commitID)
+		}
+	case BisectSkip:
+		if !slices.Contains(state.Skipped, commitID) {
+			state.Skipped = append(state.Skipped, commitID)
+		}
+	default:
+		return nil, fmt.Errorf("unknown bisect verdict '%s'", verdict)
+	}
+	if err := st.SaveBisectState(state); err != nil {
+		return nil, err
+	}
+	return bisectNext(ctx, cfg, st, client, state)
+}
+
+// BisectReset ends the bisect session and checks out the branch or commit it
+// started from. It returns what was checked out.
+func BisectReset(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface) (string, *CheckoutResult, error) {
+	state, err := requireBisectState(st)
+	if err != nil {
+		return "", nil, err
+	}
+	result, err := Checkout(ctx, cfg, st, client, state.Original, CheckoutOptions{})
+	if err != nil {
+		return "", nil, fmt.Errorf("check out %s: %w", state.Original, err)
+	}
+	if err := st.ClearBisectState(); err != nil {
+		return "", nil, err
+	}
+	return state.Original, result, nil
+}
+
+// BisectRun tests the checked-out commit with test, records its verdict, and
+// repeats until the first bad commit is found. onStep, if set, is called
+// after each verdict.
+func BisectRun(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface,
+	test func(ctx context.Context, commitID string) (string, error), onStep func(commitID, verdict string, step *BisectStep)) (*BisectStep, error) {
+	state, err := requireBisectState(st)
+	if err != nil {
+		return nil, err
+	}
+	if state.Bad == "" || len(state.Good) == 0 {
+		return nil, fmt.Errorf("bisect run needs a bad and a good commit; mark them with 'wvc bisect bad' and 'wvc bisect good'")
+	}
+
+	for {
+		head, err := st.GetHEAD()
+		if err != nil {
+			return nil, err
+		}
+		verdict, err := test(ctx, head)
+		if err != nil {
+			return nil, fmt.Errorf("test %s: %w", shortCommitID(head), err)
+		}
+		step, err := BisectMark(ctx, cfg, st, client, verdict, head)
+		if err != nil {
+			return nil, err
+		}
+		if onStep != nil {
+			onStep(head, verdict, step)
+		}
+		if step.FirstBad != "" {
+			return step, nil
+		}
+	}
+}
+
+// BisectCommand returns a BisectRun test that runs a command and judges the
+// commit by its exit code, as git bisect run does: 0 is good, 125 skips, and
+// 1 to 127 is bad. Any other exit aborts the run. The command sees the
+// commit under test in WVC_BISECT_COMMIT.
+func BisectCommand(args []string, stdout, stderr io.Writer) func(ctx context.Context, commitID string) (string, error) {
+	return func(ctx context.Context, commitID string) (string, error) {
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+		cmd.Env = append(os.Environ(), "WVC_BISECT_COMMIT="+commitID)
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+		err := cmd.Run()
+		var exitErr *exec.ExitError
+		switch {
+		case err == nil:
+			return BisectGood, nil
+		case !errors.As(err, &exitErr):
+			return "", err
+		}
+		switch code := exitErr.ExitCode(); {
+		case code == bisectSkipExitCode:
+			return BisectSkip, nil
+		case code >= 1 && code < 128:
+			return BisectBad, nil
+		default:
+			return "", fmt.Errorf("'%s' exited with %d", args[0], code)
+		}
+	}
+}
+
+func requireBisectState(st *store.Store) (*models.BisectState, error) {
+	state, err := st.GetBisectState()
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		return nil, fmt.Errorf("no bisect in progress; run 'wvc bisect start'")
+	}
+	return state, nil
+}
+
+// bisectNext checks out the commit that best halves the remaining
+// candidates, or reports the first bad commit once it is known.
+func bisectNext(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface, state *models.BisectState) (*BisectStep, error) {
+	if state.Bad == "" {
+		return &BisectStep{Waiting: BisectBad}, nil
+	}
+	if len(state.Good) == 0 {
+		return &BisectStep{Waiting: BisectGood}, nil
+	}
+
+	candidates, err := st.GetAllAncestors(state.Bad)
+	if err != nil {
+		return nil, err
+	}
+	for _, good := range state.Good {
+		if !candidates[good] {
+			return nil, fmt.Errorf("good commit %s is not an ancestor of bad commit %s", shortCommitID(good), shortCommitID(state.Bad))
+		}
+		goodAncestors, err := st.GetAllAncestors(good)
+		if err != nil {
+			return nil, err
+		}
+		for id := range goodAncestors {
+			delete(candidates, id)
+		}
+	}
+
+	var testable []string
+	for id := range candidates {
+		if id != state.Bad && !slices.Contains(state.Skipped, id) {
+			testable = append(testable, id)
+		}
+	}
+	if len(testable) == 0 {
+		step := &BisectStep{FirstBad: state.Bad, Remaining: len(candidates)}
+		if len(candidates) > 1 {
+			for id := range candidates {
+				step.Candidates = append(step.Candidates, id)
+			}
+			sort.Strings(step.Candidates)
+		}
+		return step, nil
+	}
+
+	next, err := bisectMidpoint(st, candidates, testable)
+	if err != nil {
+		return nil, err
+	}
+	result, err := Checkout(ctx, cfg, st, client, next, CheckoutOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("check out %s: %w", shortCommitID(next), err)
+	}
+	return &BisectStep{
+		Current:   next,
+		Remaining: len(candidates),
+		Steps:     bits.Len(uint(len(candidates) - 1)),
+		Checkout:  result,
+	}, nil
+}
+
+// bisectMidpoint returns the testable commit whose verdict rules out the
+// most candidates in the worse case: the one with closest to half of the
+// candidates among its ancestors.
+func bisectMidpoint(st *store.Store, candidates map[string]bool, testable []string) (string, error) {
+	parents := make(map[string][]string, len(candidates))
+	for id := range candidates {
+		commit, err := st.GetCommit(id)
+		if err != nil {
+			return "", err
+		}
+		for _, p := range []string{commit.ParentID, commit.MergeParentID} {
+			if candidates[p] {
+				parents[id] = append(parents[id], p)
+			}
+		}
+	}
+
+	sort.Strings(testable)
+	best, bestScore := "", -1
+	for _, id := range testable {
+		// Count the candidates at or below id
+		seen := map[string]bool{id: true}
+		queue := []string{id}
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+			for _, p := range parents[current] {
+				if !seen[p] {
+					seen[p] = true
+					queue = append(queue, p)
+				}
+			}
+		}
+		score := min(len(seen), len(candidates)-len(seen))
+		if score > bestScore {
+			best, bestScore = id, score
+		}
+	}
+	return best, nil
+}
@@ -0,0 +1,96 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBisect_FindsFirstBadCommit(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	wc := weaviate.NewMockClient()
+	wc.AddClass(&models.WeaviateClass{Class: "Article"})
+
+	var commits []string
+	for i := range 9 {
+		wc.AddObject(&models.WeaviateObject{ID: fmt.Sprintf("obj-%d", i), Class: "Article", Properties: map[string]interface{}{"n": i}})
+		commit, err := CreateCommit(ctx, cfg, st, wc, fmt.Sprintf("Add obj-%d", i))
+		require.NoError(t, err)
+		commits = append(commits, commit.ID)
+	}
+
+	step, err := BisectStart(ctx, cfg, st, wc, "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, BisectBad, step.Waiting)
+	step, err = BisectMark(ctx, cfg, st, wc, BisectBad, "")
+	require.NoError(t, err)
+	assert.Equal(t, BisectGood, step.Waiting)
+	step, err = BisectMark(ctx, cfg, st, wc, BisectGood, commits[0])
+	require.NoError(t, err)
+	assert.Equal(t, 8, step.Remaining)
+	assert.Equal(t, 3, step.Steps)
+
+	// The checked-out state is restored in Weaviate; obj-6 is the regression
+	tested := 0
+	step, err = BisectRun(ctx, cfg, st, wc, func(ctx context.Context, commitID string) (string, error) {
+		tested++
+		head, err := st.GetHEAD()
+		require.NoError(t, err)
+		assert.Equal(t, head, commitID)
+		if _, err := wc.GetObject(ctx, "Article", "obj-6"); err == nil {
+			return BisectBad, nil
+		}
+		return BisectGood, nil
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, commits[6], step.FirstBad)
+	assert.Empty(t, step.Candidates)
+	assert.LessOrEqual(t, tested, 4)
+
+	original, _, err := BisectReset(ctx, cfg, st, wc)
+	require.NoError(t, err)
+	assert.Equal(t, "main", original)
+	branch, err := st.GetCurrentBranch()
+	require.NoError(t, err)
+	assert.Equal(t, "main", branch)
+	_, err = wc.GetObject(ctx, "Article", "obj-8")
+	assert.NoError(t, err, "reset restores the original state")
+
+	_, err = BisectMark(ctx, cfg, st, wc, BisectGood, "")
+	assert.ErrorContains(t, err, "no bisect in progress")
+}
+
+func TestBisect_SkippedCandidates(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	wc := weaviate.NewMockClient()
+	wc.AddClass(&models.WeaviateClass{Class: "Article"})
+
+	var commits []string
+	for i := range 3 {
+		wc.AddObject(&models.WeaviateObject{ID: fmt.Sprintf("obj-%d", i), Class: "Article", Properties: map[string]interface{}{"n": i}})
+		commit, err := CreateCommit(ctx, cfg, st, wc, fmt.Sprintf("Add obj-%d", i))
+		require.NoError(t, err)
+		commits = append(commits, commit.ID)
+	}
+
+	step, err := BisectStart(ctx, cfg, st, wc, "HEAD", []string{commits[0]})
+	require.NoError(t, err)
+	assert.Equal(t, commits[1], step.Current)
+
+	_, err = BisectStart(ctx, cfg, st, wc, "", nil)
+	assert.ErrorContains(t, err, "already in progress")
+
+	step, err = BisectMark(ctx, cfg, st, wc, BisectSkip, "")
+	require.NoError(t, err)
+	assert.Equal(t, commits[2], step.FirstBad)
+	assert.ElementsMatch(t, commits[1:], step.Candidates, "the skipped commit may be the first bad one")
+}
@@ -0,0 +1,214 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/store"
+)
+
+// BlameLine attributes the current value of one property or vector of an
+// object to the commit that last changed it.
+type BlameLine struct {
+	Name   string      // property name, or the vector name ("" for the default vector)
+	Value  interface{} // property value; the blob hash for vectors
+	Commit *models.Commit
+}
+
+// BlameResult attributes each property and vector of an object at HEAD.
+type BlameResult struct {
+	ClassName  string
+	ObjectID   string
+	Created    *models.Commit // the commit that inserted the object
+	Properties []*BlameLine   // sorted by name
+	Vectors    []*BlameLine   // default vector first, then named vectors by name
+}
+
+// blameState is the object as replayed so far, with the commit each part
+// was last changed in.
+type blameState struct {
+	created    string
+	properties map[string]interface{}
+	propCommit map[string]string
+	vectors    map[string]string // vector name -> blob hash
+	vecCommit  map[string]string
+}
+
+// Blame replays the commits on HEAD's history that touched an object,
+// oldest first, and attributes each of its current properties and vectors to
+// the last commit that changed it. Reverted operations are skipped, as
+// checkout skips them.
+func Blame(st *store.Store, className, objectID string) (*BlameResult, error) {
+	head, err := st.GetHEAD()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	if head == "" {
+		return nil, fmt.Errorf("no commits yet")
+	}
+	touched, err := objectCommitSet(st, className, objectID)
+	if err != nil {
+		return nil, err
+	}
+	key := models.ObjectKey(className, objectID)
+	if len(touched) == 0 {
+		return nil, fmt.Errorf("no commit touched %s", key)
+	}
+	graph, err := loadCommitGraph(st, head)
+	if err != nil {
+		return nil, err
+	}
+
+	var state *blameState
+	for _, id := range graph.path {
+		if !touched[id] {
+			continue
+		}
+		ops, err := st.GetOperationsByCommit(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read operations of %s: %w", shortCommitID(id), err)
+		}
+		for _, op := range ops {
+			if op.Reverted || op.ClassName != className || op.ObjectID != objectID {
+				continue
+			}
+			if state, err = blameApply(state, id, op); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if state == nil {
+		return nil, fmt.Errorf("%s does not exist at HEAD", key)
+	}
+
+	commits := make(map[string]*models.Commit)
+	commit := func(id string) (*models.Commit, error) {
+		if c, ok := commits[id]; ok {
+			return c, nil
+		}
+		c, err := st.GetCommit(id)
+		if err != nil {
+			return nil, err
+		}
+		if c == nil {
+			return nil, fmt.Errorf("commit %s not found", shortCommitID(id))
+		}
+		commits[id] = c
+		return c, nil
+	}
+
+	result := &BlameResult{ClassName: className, ObjectID: objectID}
+	if result.Created, err = commit(state.created); err != nil {
+		return nil, err
+	}
+	for _, name := range sortedKeys(state.properties) {
+		c, err := commit(state.propCommit[name])
+		if err != nil {
+			return nil, err
+		}
+		result.Properties = append(result.Properties, &BlameLine{Name: name, Value: state.properties[name], Commit: c})
+	}
+	for _, name := range sortedKeys(state.vectors) {
+		c, err := commit(state.vecCommit[name])
+		if err != nil {
+			return nil, err
+		}
+		result.Vectors = append(result.Vectors, &BlameLine{Name: name, Value: state.vectors[name], Commit: c})
+	}
+	return result, nil
+}
+
+// blameApply applies one operation of commitID to the replayed object,
+// returning nil once it is deleted.
+func blameApply(state *blameState, commitID string, op *models.Operation) (*blameState, error) {
+	if op.Type == models.OperationDelete {
+		return nil, nil
+	}
+	var obj models.WeaviateObject
+	if len(op.ObjectData) > 0 {
+		if err := json.Unmarshal(op.ObjectData, &obj); err != nil {
+			return nil, fmt.Errorf("decode %s in %s: %w", models.ObjectKey(op.ClassName, op.ObjectID), shortCommitID(commitID), err)
+		}
+	}
+	vectors := make(map[string]string)
+	if op.VectorHash != "" {
+		vectors[""] = op.VectorHash
+	}
+	for name, h := range op.NamedVectorHashes {
+		if h != "" {
+			vectors[name] = h
+		}
+	}
+
+	if state == nil || op.Type == models.OperationInsert {
+		state = &blameState{
+			created:    commitID,
+			properties: map[string]interface{}{},
+			propCommit: map[string]string{},
+			vectors:    map[string]string{},
+			vecCommit:  map[string]string{},
+		}
+	}
+	for name := range state.properties {
+		if _, ok := obj.Properties[name]; !ok {
+			delete(state.properties, name)
+			delete(state.propCommit, name)
+		}
+	}
+	for name, value := range obj.Properties {
+		old, ok := state.properties[name]
+		if !ok || !sameValue(old, value) {
+			state.propCommit[name] = commitID
+		}
+		state.properties[name] = value
+	}
+	for name := range state.vectors {
+		if _, ok := vectors[name]; !ok {
+			delete(state.vectors, name)
+			delete(state.vecCommit, name)
+		}
+	}
+	for name, h := range vectors {
+		if state.vectors[name] != h {
+			state.vecCommit[name] = commitID
+		}
+		state.vectors[name] = h
+	}
+	return state, nil
+}
+
+// ObjectLog returns the commits of a log that touched one object, given as
+// "Class/ID", keeping their order. It reads the store's object index rather
+// than each commit's operations.
+func ObjectLog(st *store.Store, commits []*models.Commit, key string) ([]*models.Commit, error) {
+	className, objectID, ok := strings.Cut(key, "/")
+	if !ok || className == "" || objectID == "" {
+		return nil, fmt.Errorf("invalid object '%s': expected <class>/<id>", key)
+	}
+	touched, err := objectCommitSet(st, className, objectID)
+	if err != nil {
+		return nil, err
+	}
+	var kept []*models.Commit
+	for _, c := range commits {
+		if touched[c.ID] {
+			kept = append(kept, c)
+		}
+	}
+	return kept, nil
+}
+
+// objectCommitSet returns the IDs of the commits that touched an object.
+func objectCommitSet(st *store.Store, className, objectID string) (map[string]bool, error) {
+	ids, err := st.GetObjectCommits(className, objectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object index: %w", err)
+	}
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set, nil
+}
@@ -0,0 +1,75 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlame(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+
+	client.AddClass(&models.WeaviateClass{Class: "Article"})
+	client.AddObject(&models.WeaviateObject{ID: "obj-1", Class: "Article", Properties: map[string]interface{}{"title": "A", "body": "text", "draft": true}, Vector: []float32{0.1, 0.2}})
+	client.AddObject(&models.WeaviateObject{ID: "obj-2", Class: "Article", Properties: map[string]interface{}{"title": "B"}})
+	first, err := CreateCommit(ctx, cfg, st, client, "Add articles")
+	require.NoError(t, err)
+
+	client.AddObject(&models.WeaviateObject{ID: "obj-1", Class: "Article", Properties: map[string]interface{}{"title": "A2", "body": "text"}, Vector: []float32{0.1, 0.2}})
+	retitle, err := CreateCommit(ctx, cfg, st, client, "Retitle")
+	require.NoError(t, err)
+
+	client.AddObject(&models.WeaviateObject{ID: "obj-2", Class: "Article", Properties: map[string]interface{}{"title": "B2"}})
+	other, err := CreateCommit(ctx, cfg, st, client, "Other object")
+	require.NoError(t, err)
+
+	client.AddObject(&models.WeaviateObject{ID: "obj-1", Class: "Article", Properties: map[string]interface{}{"title": "A2", "body": "text"}, Vector: []float32{0.3, 0.4}})
+	revector, err := CreateCommit(ctx, cfg, st, client, "Re-embed")
+	require.NoError(t, err)
+
+	result, err := Blame(st, "Article", "obj-1")
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, result.Created.ID)
+	require.Len(t, result.Properties, 2, "removed properties are not listed")
+	assert.Equal(t, "body", result.Properties[0].Name)
+	assert.Equal(t, first.ID, result.Properties[0].Commit.ID)
+	assert.Equal(t, "title", result.Properties[1].Name)
+	assert.Equal(t, "A2", result.Properties[1].Value)
+	assert.Equal(t, retitle.ID, result.Properties[1].Commit.ID)
+	require.Len(t, result.Vectors, 1)
+	assert.Equal(t, "", result.Vectors[0].Name)
+	assert.Equal(t, revector.ID, result.Vectors[0].Commit.ID)
+
+	// The object index lists the commits that touched the object
+	commits, err := st.GetCommitLog(0)
+	require.NoError(t, err)
+	history, err := ObjectLog(st, commits, "Article/obj-1")
+	require.NoError(t, err)
+	var ids []string
+	for _, c := range history {
+		ids = append(ids, c.ID)
+	}
+	assert.Equal(t, []string{revector.ID, retitle.ID, first.ID}, ids)
+	history, err = ObjectLog(st, commits, "Article/obj-2")
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, other.ID, history[0].ID)
+	_, err = ObjectLog(st, commits, "obj-1")
+	assert.Error(t, err)
+
+	// A deleted object has nothing to blame
+	require.NoError(t, client.DeleteObject(ctx, "Article", "obj-2"))
+	_, err = CreateCommit(ctx, cfg, st, client, "Delete B")
+	require.NoError(t, err)
+	_, err = Blame(st, "Article", "obj-2")
+	assert.ErrorContains(t, err, "does not exist at HEAD")
+	_, err = Blame(st, "Article", "missing")
+	assert.ErrorContains(t, err, "no commit touched")
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/kilupskalvis/wvc/internal/models"
 	"github.com/kilupskalvis/wvc/internal/store"
@@ -62,6 +63,27 @@ func CreateBranch(st *store.Store, name string, startPoint string) error {
 	return st.CreateBranch(name, commitID)
 }
 
+// SetBranchClasses scopes a branch to the given classes, so status, diff,
+// commit, checkout, and merge on it only consider objects of those classes.
+// An empty list makes the branch cover every class again.
+func SetBranchClasses(st *store.Store, name string, classes []string) error {
+	exists, err := st.BranchExists(name)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("branch '%s' not found", name)
+	}
+
+	for _, c := range classes {
+		if strings.TrimSpace(c) == "" {
+			return fmt.Errorf("class name cannot be empty")
+		}
+	}
+
+	return st.SetBranchClasses(name, NewClassScope(classes).Classes())
+}
+
 // DeleteBranch deletes a branch
 func DeleteBranch(st *store.Store, name string, force bool) error {
 	// Cannot delete current branch
@@ -72,6 +94,11 @@ func DeleteBranch(st *store.Store, name string, force bool) error {
 	if name == currentBranch {
 		return fmt.Errorf("cannot delete branch '%s' while it is checked out", name)
 	}
+	if holder, err := branchCheckedOutElsewhere(st, name); err != nil {
+		return err
+	} else if holder != "" {
+		return fmt.Errorf("cannot delete branch '%s' while it is checked out in %s", name, holder)
+	}
 
 	// Check if branch exists
 	branch, err := st.GetBranch(name)
@@ -87,8 +114,18 @@ func DeleteBranch(st *store.Store, name string, force bool) error {
 
 // ResolveRef resolves a ref to a commit ID.
 // Returns (commitID, branchName, error) where branchName is empty if ref is not a local branch.
-// Resolution order: HEAD/HEAD~N, local branch, remote-tracking ref, full commit ID, short commit ID.
+// Resolution order: HEAD/HEAD~N, local branch, tag, remote-tracking ref, full commit ID, short commit ID.
 func ResolveRef(st *store.Store, ref string) (commitID string, branchName string, err error) {
+	// 0. <ref>@{<time>}: where the ref pointed at a past time
+	if base, when, ok := splitTimeRef(ref); ok {
+		t, err := parseRefTime(when, time.Now())
+		if err != nil {
+			return "", "", fmt.Errorf("invalid ref '%s': %w", ref, err)
+		}
+		commitID, err := resolveRefAt(st, base, t)
+		return commitID, "", err
+	}
+
 	// 1. HEAD or HEAD~N
 	if ref == "HEAD" || strings.HasPrefix(ref, "HEAD~") {
 		commitID, err := resolveHEADRef(st, ref)
@@ -104,7 +141,16 @@ func ResolveRef(st *store.Store, ref string) (commitID string, branchName string
 		return branch.CommitID, branch.Name, nil
 	}
 
-	// 3. Remote-tracking ref (e.g., "origin/main")
+	// 3. Tag
+	tag, err := st.GetTag(ref)
+	if err != nil {
+		return "", "", err
+	}
+	if tag != nil {
+		return tag.CommitID, "", nil
+	}
+
+	// 4. Remote-tracking ref (e.g., "origin/main")
 	if i := strings.IndexByte(ref, '/'); i > 0 {
 		remoteName := ref[:i]
 		remoteBranch := ref[i+1:]
@@ -114,13 +160,13 @@ func ResolveRef(st *store.Store, ref string) (commitID string, branchName string
 		}
 	}
 
-	// 4. Full commit ID
+	// 5. Full commit ID
 	commit, err := st.GetCommit(ref)
 	if err == nil && commit != nil {
 		return commit.ID, "", nil
 	}
 
-	// 5. Short commit ID
+	// 6. Short commit ID
 	commit, err = st.GetCommitByShortID(ref)
 	if err != nil {
 		return "", "", fmt.Errorf("'%s' is not a valid branch or commit", ref)
@@ -175,3 +221,108 @@ func resolveHEADRef(st *store.Store, ref string) (string, error) {
 
 	return commitID, nil
 }
+
+// splitTimeRef splits "<ref>@{<time>}" into its ref, HEAD when empty, and
+// time.
+func splitTimeRef(ref string) (base, when string, ok bool) {
+	i := strings.LastIndex(ref, "@{")
+	if i < 0 || !strings.HasSuffix(ref, "}") {
+		return "", "", false
+	}
+	base, when = ref[:i], ref[i+2:len(ref)-1]
+	if base == "" {
+		base = "HEAD"
+	}
+	return base, when, true
+}
+
+// refTimeUnits are the units of relative times such as "2.days.ago"
+var refTimeUnits = map[string]func(t time.Time, n int) time.Time{
+	"second": func(t time.Time, n int) time.Time { return t.Add(-time.Duration(n) * time.Second) },
+	"minute": func(t time.Time, n int) time.Time { return t.Add(-time.Duration(n) * time.Minute) },
+	"hour":   func(t time.Time, n int) time.Time { return t.Add(-time.Duration(n) * time.Hour) },
+	"day":    func(t time.Time, n int) time.Time { return t.AddDate(0, 0, -n) },
+	"week":   func(t time.Time, n int) time.Time { return t.AddDate(0, 0, -7*n) },
+	"month":  func(t time.Time, n int) time.Time { return t.AddDate(0, -n, 0) },
+	"year":   func(t time.Time, n int) time.Time { return t.AddDate(-n, 0, 0) },
+}
+
+// parseRefTime parses the time of a <ref>@{<time>} relative to now: "now",
+// "yesterday", a relative time such as "2.days.ago" or "3 hours ago", a date
+// ("2006-01-02") or date and time ("2006-01-02 15:04[:05]") in local time, an
+// RFC 3339 time, or a --since duration such as "2w".
+func parseRefTime(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	switch strings.ToLower(value) {
+	case "now":
+		return now, nil
+	case "yesterday":
+		return now.AddDate(0, 0, -1), nil
+	}
+
+	fields := strings.FieldsFunc(strings.ToLower(value), func(r rune) bool { return r == '.' || r == ' ' || r == '_' })
+	if len(fields) == 3 && fields[2] == "ago" {
+		fields = fields[:2]
+	}
+	if len(fields) == 2 {
+		if n, err := strconv.Atoi(fields[0]); err == nil && n >= 0 {
+			if back, ok := refTimeUnits[strings.TrimSuffix(fields[1], "s")]; ok {
+				return back(now, n), nil
+			}
+		}
+	}
+
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02T15:04:05"} {
+		if t, err := time.ParseInLocation(layout, value, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	if _, err := strconv.Atoi(value); err == nil {
+		return time.Time{}, fmt.Errorf("reflog positions such as @{%s} are not supported; use a time like @{yesterday}", value)
+	}
+	if t, err := ParseSince(value, now); err == nil && value != "" {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("unknown time '%s': use e.g. yesterday, 2.days.ago, or 2006-01-02", value)
+}
+
+// resolveRefAt resolves where ref pointed at t. Branches and HEAD are looked
+// up in the reflog; refs it has no record of, such as tags and branches last
+// moved before the reflog existed, resolve to the newest commit at or before
+// t on the ref's first-parent history.
+func resolveRefAt(st *store.Store, ref string, t time.Time) (string, error) {
+	at, next, err := st.RefAt(ref, t)
+	if err != nil {
+		return "", err
+	}
+	when := t.Format("2006-01-02 15:04:05")
+	switch {
+	case at != nil && at.New == "":
+		return "", fmt.Errorf("'%s' did not exist at %s", ref, when)
+	case at != nil:
+		return at.New, nil
+	case next != nil && next.Old == "":
+		return "", fmt.Errorf("'%s' did not exist yet at %s", ref, when)
+	case next != nil:
+		return next.Old, nil
+	}
+
+	commitID, _, err := ResolveRef(st, ref)
+	if err != nil {
+		return "", err
+	}
+	for commitID != "" {
+		commit, err := st.GetCommit(commitID)
+		if err != nil {
+			return "", fmt.Errorf("failed to get commit %s: %w", commitID, err)
+		}
+		if commit == nil {
+			break
+		}
+		if !commit.Timestamp.After(t) {
+			return commit.ID, nil
+		}
+		commitID = commit.ParentID
+	}
+	return "", fmt.Errorf("'%s' has no commits as old as %s", ref, when)
+}
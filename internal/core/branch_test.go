@@ -3,6 +3,7 @@ package core
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/kilupskalvis/wvc/internal/models"
 	"github.com/kilupskalvis/wvc/internal/store"
@@ -342,3 +343,57 @@ func TestResolveRef_HEADTilde_Negative(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "invalid ref")
 }
+
+func TestParseRefTime(t *testing.T) {
+	now := time.Date(2024, 6, 5, 12, 0, 0, 0, time.UTC)
+	for value, want := range map[string]time.Time{
+		"now":              now,
+		"yesterday":        now.AddDate(0, 0, -1),
+		"2.days.ago":       now.AddDate(0, 0, -2),
+		"3 hours ago":      now.Add(-3 * time.Hour),
+		"1.week":           now.AddDate(0, 0, -7),
+		"2024-06-01":       time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		"2024-06-01 08:30": time.Date(2024, 6, 1, 8, 30, 0, 0, time.UTC),
+		"2w":               now.Add(-14 * 24 * time.Hour),
+	} {
+		got, err := parseRefTime(value, now)
+		require.NoError(t, err, value)
+		assert.Equal(t, want, got, value)
+	}
+	for _, value := range []string{"", "1", "last tuesday", "2.fortnights.ago"} {
+		_, err := parseRefTime(value, now)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestResolveRef_AtTime(t *testing.T) {
+	st, cleanup := setupTestStoreForBranches(t)
+	defer cleanup()
+
+	// Commits made before the reflog saw them resolve by timestamp
+	base := time.Now().Add(-72 * time.Hour)
+	require.NoError(t, st.CreateCommit(&models.Commit{ID: "c1aaaaaa", Timestamp: base}))
+	require.NoError(t, st.CreateCommit(&models.Commit{ID: "c2bbbbbb", ParentID: "c1aaaaaa", Timestamp: base.Add(24 * time.Hour)}))
+	require.NoError(t, st.PutTag(&models.Tag{Name: "v1", CommitID: "c2bbbbbb"}))
+	id, _, err := ResolveRef(st, "v1@{60.hours.ago}")
+	require.NoError(t, err)
+	assert.Equal(t, "c1aaaaaa", id)
+	_, _, err = ResolveRef(st, "v1@{4.days.ago}")
+	assert.ErrorContains(t, err, "no commits as old as")
+
+	// Branch moves are looked up in the reflog
+	require.NoError(t, st.CreateCommit(&models.Commit{ID: "c3cccccc", ParentID: "c2bbbbbb", Timestamp: time.Now()}))
+	require.NoError(t, st.CreateBranchAndHEAD("main", "c2bbbbbb"))
+	moved := time.Now()
+	require.NoError(t, st.UpdateBranchAndHEAD("main", "c3cccccc"))
+
+	id, branch, err := ResolveRef(st, "main@{"+moved.Format(time.RFC3339Nano)+"}")
+	require.NoError(t, err)
+	assert.Equal(t, "c2bbbbbb", id)
+	assert.Empty(t, branch, "a past position is detached")
+	id, _, err = ResolveRef(st, "@{now}")
+	require.NoError(t, err)
+	assert.Equal(t, "c3cccccc", id)
+	_, _, err = ResolveRef(st, "main@{yesterday}")
+	assert.ErrorContains(t, err, "did not exist yet")
+}
@@ -0,0 +1,270 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/store"
+)
+
+// Changelog summarizes the commits between two refs for release notes
+type Changelog struct {
+	From   string
+	To     string
+	FromID string
+	ToID   string
+	// Commits are the commits reachable from To but not From, newest first.
+	// Merge commits are left out; their changes are in the commits they merged.
+	Commits []*ChangelogCommit
+	// Classes count the net object changes between From and To per class,
+	// with the number of commits in range that touched the class
+	Classes []*ShortlogEntry
+	Added   int
+	Updated int
+	Deleted int
+	// SchemaChanges describe the schema changes between From and To, such
+	// as "Added property Article.summary"
+	SchemaChanges []string
+	// Trailers collect the trailer values of every commit in range by key,
+	// in order of first appearance
+	Trailers []*ChangelogTrailer
+}
+
+// ChangelogCommit is a commit with its message split into parts
+type ChangelogCommit struct {
+	*models.Commit
+	Subject  string
+	Body     string
+	Trailers []Trailer
+}
+
+// ChangelogTrailer is a trailer key with the distinct values commits gave it
+type ChangelogTrailer struct {
+	Key    string
+	Values []string
+}
+
+// DefaultChangelogTemplate renders a changelog as Markdown
+const DefaultChangelogTemplate = `## {{.To}}
+
+Changes since {{.From}}: {{len .Commits}} commit(s), {{.Added}} object(s) added, {{.Updated}} updated, {{.Deleted}} deleted.
+{{- if .Commits}}
+
+### Commits
+{{range .Commits}}
+- {{.Subject}} ({{shortID .ID}}{{with .Author}}, {{.}}{{end}})
+{{- end}}
+{{- end}}
+{{- if .Classes}}
+
+### Classes
+
+| Class | Commits | Added | Updated | Deleted |
+|-------|--------:|------:|--------:|--------:|
+{{- range .Classes}}
+| {{.Name}} | {{.Commits}} | {{.Added}} | {{.Updated}} | {{.Deleted}} |
+{{- end}}
+{{- end}}
+{{- if .SchemaChanges}}
+
+### Schema
+{{range .SchemaChanges}}
+- {{.}}
+{{- end}}
+{{- end}}
+{{- if .Trailers}}
+
+### Metadata
+{{range .Trailers}}
+- {{.Key}}: {{join .Values ", "}}
+{{- end}}
+{{- end}}
+`
+
+// BuildChangelog collects the commits, object changes, schema changes, and
+// trailers between the refs of a <from>..<to> range
+func BuildChangelog(st *store.Store, r string) (*Changelog, error) {
+	fromID, toID, err := ResolveCommitRange(st, r)
+	if err != nil {
+		return nil, err
+	}
+	if toID == "" {
+		return nil, fmt.Errorf("no commits yet")
+	}
+	from, to, _ := strings.Cut(r, "..")
+	if to == "" {
+		to = "HEAD"
+	}
+	cl := &Changelog{From: from, To: to, FromID: fromID, ToID: toID}
+
+	excluded, err := st.GetAllAncestors(fromID)
+	if err != nil {
+		return nil, err
+	}
+	included, err := st.GetAllAncestors(toID)
+	if err != nil {
+		return nil, err
+	}
+
+	classes := make(map[string]*ShortlogEntry)
+	class := func(name string) *ShortlogEntry {
+		e, ok := classes[name]
+		if !ok {
+			e = &ShortlogEntry{Name: name}
+			classes[name] = e
+		}
+		return e
+	}
+	trailers := make(map[string]*ChangelogTrailer)
+	var commits []*models.Commit
+	for id := range included {
+		if excluded[id] {
+			continue
+		}
+		commit, err := st.GetCommit(id)
+		if err != nil {
+			return nil, fmt.Errorf("get commit %s: %w", shortCommitID(id), err)
+		}
+		if !commit.IsMergeCommit() {
+			commits = append(commits, commit)
+		}
+	}
+	sort.Slice(commits, func(i, j int) bool {
+		return commits[i].Timestamp.After(commits[j].Timestamp)
+	})
+
+	for _, commit := range commits {
+		ops, err := st.GetOperationsByCommit(commit.ID)
+		if err != nil {
+			return nil, fmt.Errorf("read operations of %s: %w", commit.ShortID(), err)
+		}
+		touched := make(map[string]bool)
+		for _, op := range ops {
+			if !touched[op.ClassName] {
+				touched[op.ClassName] = true
+				class(op.ClassName).Commits++
+			}
+		}
+
+		c := &ChangelogCommit{Commit: commit, Trailers: ParseTrailers(commit.Message)}
+		c.Subject, c.Body = splitCommitMessage(commit.Message, len(c.Trailers) > 0)
+		cl.Commits = append(cl.Commits, c)
+		for _, t := range c.Trailers {
+			key := strings.ToLower(t.Key)
+			ct, ok := trailers[key]
+			if !ok {
+				ct = &ChangelogTrailer{Key: t.Key}
+				trailers[key] = ct
+				cl.Trailers = append(cl.Trailers, ct)
+			}
+			if t.Value != "" && !slices.Contains(ct.Values, t.Value) {
+				ct.Values = append(ct.Values, t.Value)
+			}
+		}
+	}
+
+	diff, err := DiffCommits(st, fromID, toID)
+	if err != nil {
+		return nil, err
+	}
+	for _, change := range diff.Inserted {
+		class(change.ClassName).Added++
+	}
+	for _, change := range diff.Updated {
+		class(change.ClassName).Updated++
+	}
+	for _, change := range diff.Deleted {
+		class(change.ClassName).Deleted++
+	}
+	for _, e := range classes {
+		cl.Added += e.Added
+		cl.Updated += e.Updated
+		cl.Deleted += e.Deleted
+		cl.Classes = append(cl.Classes, e)
+	}
+	sort.Slice(cl.Classes, func(i, j int) bool { return cl.Classes[i].Name < cl.Classes[j].Name })
+
+	if cl.SchemaChanges, err = describeSchemaChanges(st, fromID, toID); err != nil {
+		return nil, err
+	}
+	return cl, nil
+}
+
+// Render writes the changelog with a text/template; an empty text uses
+// DefaultChangelogTemplate. Templates can call shortID and join.
+func (cl *Changelog) Render(w io.Writer, text string) error {
+	if text == "" {
+		text = DefaultChangelogTemplate
+	}
+	tmpl, err := template.New("changelog").Funcs(template.FuncMap{
+		"shortID": shortCommitID,
+		"join":    strings.Join,
+	}).Parse(text)
+	if err != nil {
+		return fmt.Errorf("parse changelog template: %w", err)
+	}
+	if err := tmpl.Execute(w, cl); err != nil {
+		return fmt.Errorf("render changelog: %w", err)
+	}
+	return nil
+}
+
+// splitCommitMessage splits a message into its subject and body, leaving
+// out the trailer block when there is one
+func splitCommitMessage(message string, hasTrailers bool) (string, string) {
+	paragraphs := strings.Split(strings.TrimSpace(strings.ReplaceAll(message, "\r\n", "\n")), "\n\n")
+	if hasTrailers {
+		paragraphs = paragraphs[:len(paragraphs)-1]
+	}
+	subject, rest, _ := strings.Cut(paragraphs[0], "\n")
+	body := append([]string{strings.TrimSpace(rest)}, paragraphs[1:]...)
+	return subject, strings.TrimSpace(strings.Join(body, "\n\n"))
+}
+
+// describeSchemaChanges lists the schema changes between two commits, in
+// the order classes, properties, vectorizers
+func describeSchemaChanges(st *store.Store, fromID, toID string) ([]string, error) {
+	to, err := st.GetSchemaVersionByCommit(toID)
+	if err != nil || to == nil {
+		return nil, err
+	}
+	from, err := st.GetSchemaVersionByCommit(fromID)
+	if err != nil {
+		return nil, err
+	}
+	var fromJSON []byte
+	if from != nil {
+		fromJSON = from.SchemaJSON
+	}
+	diff, err := ComputeSchemaDiffBetweenVersions(to.SchemaJSON, fromJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []string
+	for _, group := range []struct {
+		verb    string
+		changes []*models.SchemaChange
+	}{
+		{"Added class", diff.ClassesAdded},
+		{"Deleted class", diff.ClassesDeleted},
+		{"Added property", diff.PropertiesAdded},
+		{"Deleted property", diff.PropertiesDeleted},
+		{"Changed property", diff.PropertiesModified},
+		{"Changed vectorizer of", diff.VectorizersChanged},
+	} {
+		for _, c := range group.changes {
+			name := c.ClassName
+			if c.PropertyName != "" {
+				name += "." + c.PropertyName
+			}
+			changes = append(changes, group.verb+" "+name)
+		}
+	}
+	return changes, nil
+}
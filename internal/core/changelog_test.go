@@ -0,0 +1,94 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kilupskalvis/wvc/internal/config"
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildChangelog(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+	client.AddClass(&models.WeaviateClass{Class: "Article"})
+
+	client.AddObject(&models.WeaviateObject{ID: "a1", Class: "Article", Properties: map[string]interface{}{"title": "One"}})
+	client.AddObject(&models.WeaviateObject{ID: "a2", Class: "Article", Properties: map[string]interface{}{"title": "Two"}})
+	v1, err := CreateCommit(ctx, cfg, st, client, "Initial import")
+	require.NoError(t, err)
+	require.NoError(t, st.PutTag(&models.Tag{Name: "v1.0", CommitID: v1.ID}))
+
+	cfg.User = &config.UserConfig{Name: "Alice"}
+	client.Objects["Article/a1"].Properties["title"] = "One (edited)"
+	client.AddObject(&models.WeaviateObject{ID: "a3", Class: "Article", Properties: map[string]interface{}{"title": "Three"}})
+	_, err = CreateCommit(ctx, cfg, st, client, "Fix titles\n\nSeveral titles were truncated.\n\nTicket: DATA-1\nSource: crawl-7")
+	require.NoError(t, err)
+
+	client.AddClass(&models.WeaviateClass{Class: "Author"})
+	client.AddObject(&models.WeaviateObject{ID: "p1", Class: "Author", Properties: map[string]interface{}{"name": "Pat"}})
+	delete(client.Objects, "Article/a3")
+	_, err = CreateCommit(ctx, cfg, st, client, "Add authors\n\nticket: DATA-2\nTicket: DATA-1")
+	require.NoError(t, err)
+
+	cl, err := BuildChangelog(st, "v1.0..")
+	require.NoError(t, err)
+	assert.Equal(t, "v1.0", cl.From)
+	assert.Equal(t, "HEAD", cl.To)
+	require.Len(t, cl.Commits, 2)
+	assert.Equal(t, "Add authors", cl.Commits[0].Subject)
+	assert.Equal(t, "Fix titles", cl.Commits[1].Subject)
+	assert.Equal(t, "Several titles were truncated.", cl.Commits[1].Body)
+	assert.Equal(t, []*ShortlogEntry{
+		{Name: "Article", Commits: 2, Updated: 1},
+		{Name: "Author", Commits: 1, Added: 1},
+	}, cl.Classes)
+	assert.Equal(t, 1, cl.Added)
+	assert.Equal(t, []string{"Added class Author"}, cl.SchemaChanges)
+	assert.Equal(t, []*ChangelogTrailer{
+		{Key: "ticket", Values: []string{"DATA-2", "DATA-1"}},
+		{Key: "Source", Values: []string{"crawl-7"}},
+	}, cl.Trailers)
+
+	var out strings.Builder
+	require.NoError(t, cl.Render(&out, ""))
+	assert.Equal(t, `## HEAD
+
+Changes since v1.0: 2 commit(s), 1 object(s) added, 1 updated, 0 deleted.
+
+### Commits
+
+- Add authors (`+shortCommitID(cl.Commits[0].ID)+`, Alice)
+- Fix titles (`+shortCommitID(cl.Commits[1].ID)+`, Alice)
+
+### Classes
+
+| Class | Commits | Added | Updated | Deleted |
+|-------|--------:|------:|--------:|--------:|
+| Article | 2 | 0 | 1 | 0 |
+| Author | 1 | 1 | 0 | 0 |
+
+### Schema
+
+- Added class Author
+
+### Metadata
+
+- ticket: DATA-2, DATA-1
+- Source: crawl-7
+`, out.String())
+
+	out.Reset()
+	require.NoError(t, cl.Render(&out, "{{range .Commits}}{{.Subject}}{{range .Trailers}} [{{.Value}}]{{end}}\n{{end}}"))
+	assert.Equal(t, "Add authors [DATA-2] [DATA-1]\nFix titles [DATA-1] [crawl-7]\n", out.String())
+
+	assert.Error(t, cl.Render(&out, "{{.Missing"))
+	_, err = BuildChangelog(st, "v1.0")
+	assert.ErrorContains(t, err, "expected <from>..<to>")
+}
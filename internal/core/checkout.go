@@ -3,6 +3,7 @@ package core
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/kilupskalvis/wvc/internal/config"
@@ -40,23 +41,42 @@ type CheckoutWarning struct {
 func Checkout(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface, target string, opts CheckoutOptions) (*CheckoutResult, error) {
 	result := &CheckoutResult{Warnings: []CheckoutWarning{}}
 
-	// Step 1: Check for uncommitted changes (unless --force)
+	// Step 1: Resolve target to commit ID and determine if branch
+	targetCommitID, branchName, err := resolveCheckoutTarget(st, target, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// A branch can be checked out in only one worktree at a time
+	if branchName != "" && !opts.CreateBranch {
+		if holder, err := branchCheckedOutElsewhere(st, branchName); err != nil {
+			return nil, err
+		} else if holder != "" {
+			return nil, fmt.Errorf("branch '%s' is already checked out in %s", branchName, holder)
+		}
+	}
+
+	// Step 2: Check for uncommitted changes (unless --force) in every class
+	// the current or target branch versions, since the restore touches them all
 	if !opts.Force {
-		hasChanges, err := HasUncommittedChanges(ctx, cfg, st, client)
+		scope, err := checkoutScope(st, branchName)
+		if err != nil {
+			return nil, err
+		}
+		hasChanges, err := hasUncommittedChangesScoped(ctx, cfg, st, client, scope)
 		if err != nil {
 			return nil, fmt.Errorf("failed to check for changes: %w", err)
 		}
+		if !hasChanges {
+			if hasChanges, err = submodulePinsChanged(st); err != nil {
+				return nil, err
+			}
+		}
 		if hasChanges {
 			return nil, fmt.Errorf("you have uncommitted changes; commit them or use --force to discard")
 		}
 	}
 
-	// Step 2: Resolve target to commit ID and determine if branch
-	targetCommitID, branchName, err := resolveCheckoutTarget(st, target, opts)
-	if err != nil {
-		return nil, err
-	}
-
 	// Validate target commit exists
 	if targetCommitID == "" {
 		return nil, fmt.Errorf("cannot checkout: no commits yet")
@@ -90,8 +110,13 @@ func Checkout(ctx context.Context, cfg *config.Config, st *store.Store, client w
 		return finishCheckout(st, targetCommitID, branchName, opts.CreateBranch, result)
 	}
 
-	// Step 6: Restore Weaviate state to target commit
-	warnings, stats, err := restoreStateToCommit(ctx, cfg, st, client, targetCommitID)
+	// Step 6: Restore Weaviate state to target commit, limited to the target
+	// branch's classes when it is class-scoped
+	scope, err := branchScope(st, branchName)
+	if err != nil {
+		return nil, err
+	}
+	warnings, stats, err := restoreStateToCommit(ctx, cfg, st, client, targetCommitID, scope)
 	if err != nil {
 		return nil, fmt.Errorf("failed to restore state: %w", err)
 	}
@@ -104,6 +129,95 @@ func Checkout(ctx context.Context, cfg *config.Config, st *store.Store, client w
 	return finishCheckout(st, targetCommitID, branchName, opts.CreateBranch, result)
 }
 
+// CheckoutPaths restores the objects selected by a pathspec to their state at
+// source (HEAD when empty) without moving HEAD or switching branches. Staged
+// changes for the restored objects are dropped. Schema is left untouched.
+func CheckoutPaths(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface, source string, spec Pathspec) (*CheckoutResult, error) {
+	if spec.IsEmpty() {
+		return nil, fmt.Errorf("pathspec required")
+	}
+	if source == "" {
+		source = "HEAD"
+	}
+	commitID, _, err := ResolveRef(st, source)
+	if err != nil {
+		return nil, err
+	}
+	if commitID == "" {
+		return nil, fmt.Errorf("cannot checkout: no commits yet")
+	}
+
+	targetObjects, err := reconstructStateAtCommit(st, commitID)
+	if err != nil {
+		return nil, err
+	}
+	currentObjects, err := client.GetAllObjectsAllClasses(ctx, cfg.SupportsCursorPagination())
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CheckoutResult{TargetCommit: commitID, Warnings: []CheckoutWarning{}}
+	result.PreviousCommit, _ = st.GetHEAD()
+	result.BranchName, _ = st.GetCurrentBranch()
+	result.IsDetached = result.BranchName == ""
+
+	warn := func(kind string, format string, args ...interface{}) {
+		result.Warnings = append(result.Warnings, CheckoutWarning{Type: kind, Message: fmt.Sprintf(format, args...)})
+	}
+
+	keys := unionKeys(targetObjects)
+	for key := range currentObjects {
+		keys[key] = true
+	}
+	for _, key := range sortedKeys(keys) {
+		if !spec.matchesKey(key) {
+			continue
+		}
+		current, target := currentObjects[key], targetObjects[key]
+		switch {
+		case target == nil:
+			if err := client.DeleteObject(ctx, current.TenantClass(), current.ID); err != nil {
+				warn("delete_failed", "failed to delete %s: %v", key, err)
+				continue
+			}
+			result.ObjectsRemoved++
+		case current == nil:
+			target.restoreVectors(st)
+			if err := client.CreateObject(ctx, target.Object); err != nil {
+				warn("create_failed", "failed to create %s: %v", key, err)
+				continue
+			}
+			result.ObjectsAdded++
+		default:
+			targetHash, _ := storedHashes.HashObjectFull(target.Object)
+			currentHash, _ := client.HashCache().HashObjectFull(current)
+			if targetHash == currentHash {
+				continue
+			}
+			target.restoreVectors(st)
+			if err := client.UpdateObject(ctx, target.Object); err != nil {
+				warn("update_failed", "failed to update %s: %v", key, err)
+				continue
+			}
+			result.ObjectsUpdated++
+		}
+	}
+
+	staged, err := st.GetAllStagedChanges()
+	if err != nil {
+		return nil, err
+	}
+	for _, sc := range staged {
+		if spec.Matches(sc.ClassName, sc.ObjectID) {
+			if err := st.RemoveStagedChange(sc.ClassName, sc.ObjectID); err != nil {
+				return nil, fmt.Errorf("failed to unstage %s/%s: %w", sc.ClassName, sc.ObjectID, err)
+			}
+		}
+	}
+
+	return result, nil
+}
+
 // resolveCheckoutTarget resolves a target to (commitID, branchName)
 // branchName is empty if target is a commit (detached HEAD)
 func resolveCheckoutTarget(st *store.Store, target string, opts CheckoutOptions) (string, string, error) {
@@ -125,6 +239,12 @@ func resolveCheckoutTarget(st *store.Store, target string, opts CheckoutOptions)
 		return branch.CommitID, branch.Name, nil
 	}
 
+	// A ref at a past time, e.g. main@{yesterday}
+	if _, _, ok := splitTimeRef(target); ok {
+		commitID, _, err := ResolveRef(st, target)
+		return commitID, "", err // Detached HEAD
+	}
+
 	// Try as full commit ID
 	commit, err := st.GetCommit(target)
 	if err == nil && commit != nil {
@@ -139,8 +259,32 @@ func resolveCheckoutTarget(st *store.Store, target string, opts CheckoutOptions)
 	return commit.ID, "", nil // Detached HEAD
 }
 
-// HasUncommittedChanges checks if there are any uncommitted changes
+// checkoutScope returns the classes a checkout from the current branch to
+// targetBranch can affect: the union of both branches' scopes.
+func checkoutScope(st *store.Store, targetBranch string) (ClassScope, error) {
+	current, err := currentScope(st)
+	if err != nil || current == nil {
+		return nil, err
+	}
+	target, err := branchScope(st, targetBranch)
+	if err != nil || target == nil {
+		return nil, err
+	}
+	return current.union(target), nil
+}
+
+// HasUncommittedChanges checks if there are any uncommitted changes. On a
+// class-scoped branch only its own classes are considered.
 func HasUncommittedChanges(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface) (bool, error) {
+	scope, err := currentScope(st)
+	if err != nil {
+		return false, err
+	}
+	return hasUncommittedChangesScoped(ctx, cfg, st, client, scope)
+}
+
+// hasUncommittedChangesScoped checks for uncommitted changes in the classes in scope
+func hasUncommittedChangesScoped(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface, scope ClassScope) (bool, error) {
 	// Check staging area
 	stagedCount, err := st.GetStagedChangesCount()
 	if err != nil {
@@ -151,7 +295,7 @@ func HasUncommittedChanges(ctx context.Context, cfg *config.Config, st *store.St
 	}
 
 	// Check for unstaged changes
-	diff, err := ComputeIncrementalDiff(ctx, cfg, st, client)
+	diff, err := computeIncrementalDiffScoped(ctx, cfg, st, client, scope)
 	if err != nil {
 		return false, err
 	}
@@ -160,7 +304,7 @@ func HasUncommittedChanges(ctx context.Context, cfg *config.Config, st *store.St
 	}
 
 	// Check schema changes
-	schemaDiff, err := ComputeSchemaDiff(ctx, st, client)
+	schemaDiff, err := computeSchemaDiffScoped(ctx, cfg, st, client, scope)
 	if err != nil {
 		return false, err
 	}
@@ -174,32 +318,32 @@ type StateRestoreStats struct {
 	Updated int
 }
 
-// restoreStateToCommit transforms Weaviate to match the target commit's state
-func restoreStateToCommit(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface, targetCommitID string) ([]CheckoutWarning, *StateRestoreStats, error) {
+// restoreStateToCommit transforms Weaviate to match the target commit's state.
+// Objects and schema of classes outside scope, and ignored objects not in
+// the target state, are left untouched. Current
+// objects are read and reconciled a page at a time; beyond the target state
+// only the IDs of objects to delete are kept.
+func restoreStateToCommit(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface, targetCommitID string, scope ClassScope) ([]CheckoutWarning, *StateRestoreStats, error) {
 	warnings := []CheckoutWarning{}
 	stats := &StateRestoreStats{}
-
-	// Get target state: rebuild what objects should exist at targetCommitID
-	targetObjects, err := reconstructStateAtCommit(st, targetCommitID)
+	ignore, err := LoadIgnoreRules(cfg)
 	if err != nil {
 		return warnings, stats, err
 	}
 
-	// Get current Weaviate state
-	useCursor := cfg.SupportsCursorPagination()
-	currentObjectsList, err := client.GetAllObjectsAllClasses(ctx, useCursor)
+	// Get target state: rebuild what objects should exist at targetCommitID
+	targetObjects, err := reconstructStateAtCommit(st, targetCommitID)
 	if err != nil {
 		return warnings, stats, err
 	}
-
-	// Convert to map for easier comparison
-	currentObjects := make(map[string]*models.WeaviateObject)
-	for key, obj := range currentObjectsList {
-		currentObjects[key] = obj
+	for key, obj := range targetObjects {
+		if !scope.includesKey(key) || ignore.ignoresClass(obj.Object.TenantClass()) {
+			delete(targetObjects, key)
+		}
 	}
 
 	// Handle schema first (before data operations)
-	schemaWarnings, err := restoreSchemaToCommit(ctx, st, client, targetCommitID)
+	schemaWarnings, err := restoreSchemaToCommit(ctx, st, client, targetCommitID, scope, ignore)
 	if err != nil {
 		// Non-fatal - continue with data restoration
 		warnings = append(warnings, CheckoutWarning{
@@ -209,119 +353,217 @@ func restoreStateToCommit(ctx context.Context, cfg *config.Config, st *store.Sto
 	}
 	warnings = append(warnings, schemaWarnings...)
 
-	// Compute what needs to change
-	toDelete := make(map[string]*models.WeaviateObject)
-	toCreate := make(map[string]*objectWithVector)
-	toUpdate := make(map[string]*objectWithVector)
-
-	// Objects in current but not in target -> delete
-	for key, obj := range currentObjects {
-		if _, exists := targetObjects[key]; !exists {
-			toDelete[key] = obj
+	// Writes are sent in concurrent batches. Failed writes are recorded so
+	// they can be retried with restore --retry-failed
+	var failed []*models.FailedObject
+	apply := func(writes []*objectWrite) error {
+		if len(writes) == 0 {
+			return nil
 		}
+		if err := applyObjectWrites(ctx, cfg, client, writes); err != nil {
+			return err
+		}
+		for _, w := range writes {
+			if w.Err != nil {
+				warnings = append(warnings, applyWarning(w))
+				failed = append(failed, &models.FailedObject{
+					ClassName: w.Object.TenantClass(),
+					ObjectID:  w.Object.ID,
+					Action:    w.Action,
+					Error:     w.Err.Error(),
+					Attempts:  1,
+				})
+				continue
+			}
+			switch w.Action {
+			case models.ApplyCreate:
+				stats.Added++
+			case models.ApplyUpdate:
+				stats.Updated++
+			case models.ApplyDelete:
+				stats.Removed++
+			}
+		}
+		return nil
 	}
 
-	// Objects in target but not in current -> create
-	// Objects in both but different -> update
-	for key, targetObj := range targetObjects {
-		currentObj, exists := currentObjects[key]
-		if !exists {
-			toCreate[key] = targetObj
-		} else {
-			targetHash, _ := weaviate.HashObjectFull(targetObj.Object)
-			currentHash, _ := weaviate.HashObjectFull(currentObj)
-			if targetHash != currentHash {
-				toUpdate[key] = targetObj
+	// Objects in both but different -> update. Updates are collected across
+	// pages until there are enough to keep every batch request busy.
+	flushAt := cfg.ApplyBatchSize() * cfg.ApplyConcurrency()
+	var updates []*objectWrite
+	flushUpdates := func() error {
+		pending := updates
+		updates = nil
+		return apply(pending)
+	}
+
+	useCursor := cfg.SupportsCursorPagination()
+	classes, err := versionedClasses(ctx, client)
+	if err != nil {
+		return warnings, stats, err
+	}
+	existing := make(map[string]bool)
+	for _, className := range classes {
+		if !scope.Includes(className) || ignore.ignoresClass(className) {
+			continue
+		}
+
+		// Objects in current but not in target -> delete, unless ignored
+		var deletes []*objectWrite
+		err := client.IterateObjects(ctx, className, useCursor, func(batch []*models.WeaviateObject) error {
+			toUpdate := make(map[string]*objectWithVector)
+			for _, currentObj := range batch {
+				key := models.ObjectKey(className, currentObj.ID)
+				targetObj, exists := targetObjects[key]
+				if !exists {
+					if ignore.ignores(className, currentObj.ID, currentObj) {
+						continue
+					}
+					deletes = append(deletes, &objectWrite{
+						Action: models.ApplyDelete,
+						Object: &models.WeaviateObject{Class: currentObj.Class, Tenant: currentObj.Tenant, ID: currentObj.ID},
+					})
+					continue
+				}
+				existing[key] = true
+				targetHash, _ := storedHashes.HashObjectFull(targetObj.Object)
+				currentHash, _ := client.HashCache().HashObjectFull(currentObj)
+				if targetHash != currentHash {
+					toUpdate[key] = targetObj
+				}
+			}
+			if err := fetchMissingVectors(st, toUpdate); err != nil {
+				return err
+			}
+			for _, key := range sortedKeys(toUpdate) {
+				toUpdate[key].restoreVectors(st)
+				updates = append(updates, &objectWrite{Action: models.ApplyUpdate, Object: toUpdate[key].Object})
+			}
+			if len(updates) >= flushAt {
+				return flushUpdates()
 			}
+			return nil
+		})
+		if err != nil {
+			return warnings, stats, err
+		}
+		if err := flushUpdates(); err != nil {
+			return warnings, stats, err
 		}
-	}
 
-	for _, obj := range toDelete {
-		if err := client.DeleteObject(ctx, obj.Class, obj.ID); err != nil {
-			warnings = append(warnings, CheckoutWarning{
-				Type:    "delete_failed",
-				Message: fmt.Sprintf("failed to delete %s/%s: %v", obj.Class, obj.ID, err),
-			})
-		} else {
-			stats.Removed++
+		// Deleting after the class is paged through keeps offset pagination
+		// from skipping objects
+		if err := apply(deletes); err != nil {
+			return warnings, stats, err
 		}
 	}
 
-	// Apply creations
-	for _, objWithVec := range toCreate {
-		obj := objWithVec.Object
-		restoreObjectVector(st, obj, objWithVec.VectorHash)
-		if err := client.CreateObject(ctx, obj); err != nil {
-			warnings = append(warnings, CheckoutWarning{
-				Type:    "create_failed",
-				Message: fmt.Sprintf("failed to create %s/%s: %v", obj.Class, obj.ID, err),
-			})
-		} else {
-			stats.Added++
+	// Objects in target but not in current -> create
+	toCreate := make(map[string]*objectWithVector)
+	for key, targetObj := range targetObjects {
+		if !existing[key] {
+			toCreate[key] = targetObj
 		}
 	}
+	if err := fetchMissingVectors(st, toCreate); err != nil {
+		return warnings, stats, err
+	}
+	creates := make([]*objectWrite, 0, len(toCreate))
+	for _, key := range sortedKeys(toCreate) {
+		toCreate[key].restoreVectors(st)
+		creates = append(creates, &objectWrite{Action: models.ApplyCreate, Object: toCreate[key].Object})
+	}
+	if err := ensureTenants(ctx, client, classes, creates); err != nil {
+		return warnings, stats, err
+	}
+	if err := apply(creates); err != nil {
+		return warnings, stats, err
+	}
 
-	// Apply updates
-	for _, objWithVec := range toUpdate {
-		obj := objWithVec.Object
-		restoreObjectVector(st, obj, objWithVec.VectorHash)
-		if err := client.UpdateObject(ctx, obj); err != nil {
-			warnings = append(warnings, CheckoutWarning{
-				Type:    "update_failed",
-				Message: fmt.Sprintf("failed to update %s/%s: %v", obj.Class, obj.ID, err),
-			})
-		} else {
-			stats.Updated++
-		}
+	if err := recordApplyProgress(st, targetCommitID, stats.Added+stats.Updated+stats.Removed, failed); err != nil {
+		warnings = append(warnings, CheckoutWarning{
+			Type:    "apply_progress",
+			Message: fmt.Sprintf("failed to record apply progress: %v", err),
+		})
 	}
 
 	return warnings, stats, nil
 }
 
-// holds an object and its vector hash for restoration
+// holds an object and its vector hashes for restoration
 type objectWithVector struct {
-	Object     *models.WeaviateObject
-	VectorHash string
+	Object            *models.WeaviateObject
+	VectorHash        string
+	NamedVectorHashes map[string]string
+}
+
+// restoreVectors sets the object's default and named vectors to their exact
+// stored values
+func (o *objectWithVector) restoreVectors(st *store.Store) {
+	restoreObjectVector(st, o.Object, o.VectorHash)
+	restoreNamedVectors(st, o.Object, o.NamedVectorHashes)
 }
 
 // rebuilds what objects should exist at a commit
-// by walking the operation history from the beginning to the target commit
+// by walking the operation history from the nearest state snapshot, or the
+// beginning, to the target commit. In a shallow clone the walk begins at the
+// stored state of each boundary. Commits passed on the way that are due a
+// snapshot under the store's snapshot interval get one.
 func reconstructStateAtCommit(st *store.Store, targetCommitID string) (map[string]*objectWithVector, error) {
 	objects := make(map[string]*objectWithVector)
+	live := make(map[string]*models.Operation) // the operation that set each object
 
-	commitPath, err := getCommitPath(st, targetCommitID)
+	graph, err := loadCommitGraph(st, targetCommitID)
+	if err != nil {
+		return nil, err
+	}
+	start, snapshot, err := nearestSnapshot(st, graph)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, commitID := range commitPath {
-		ops, err := st.GetOperationsByCommit(commitID)
-		if err != nil {
-			return nil, err
-		}
-
+	apply := func(ops []*models.Operation) {
 		for _, op := range ops {
 			key := models.ObjectKey(op.ClassName, op.ObjectID)
 
 			switch op.Type {
-			case models.OperationInsert:
+			case models.OperationInsert, models.OperationUpdate:
 				var obj models.WeaviateObject
 				if err := json.Unmarshal(op.ObjectData, &obj); err == nil {
 					objects[key] = &objectWithVector{
-						Object:     &obj,
-						VectorHash: op.VectorHash,
-					}
-				}
-			case models.OperationUpdate:
-				var obj models.WeaviateObject
-				if err := json.Unmarshal(op.ObjectData, &obj); err == nil {
-					objects[key] = &objectWithVector{
-						Object:     &obj,
-						VectorHash: op.VectorHash,
+						Object:            &obj,
+						VectorHash:        op.VectorHash,
+						NamedVectorHashes: op.NamedVectorHashes,
 					}
+					live[key] = op
 				}
 			case models.OperationDelete:
 				delete(objects, key)
+				delete(live, key)
+			}
+		}
+	}
+	apply(snapshot)
+
+	// A payload a partial clone could not fetch would be missing from a snapshot for good
+	complete := true
+	interval := st.SnapshotInterval()
+	for i := start; i < len(graph.path); i++ {
+		commitID := graph.path[i]
+		ops, err := replayOperations(st, commitID)
+		if err != nil {
+			return nil, err
+		}
+		for _, op := range ops {
+			if op.PayloadOmitted && len(op.ObjectData) == 0 {
+				complete = false
+			}
+		}
+		apply(ops)
+
+		if complete && interval > 0 && (i+1)%interval == 0 && models.HistorySize(graph.parents, commitID) == i+1 {
+			if err := st.PutStateSnapshot(commitID, snapshotOperations(live)); err != nil {
+				return nil, fmt.Errorf("save state snapshot of %s: %w", shortCommitID(commitID), err)
 			}
 		}
 	}
@@ -329,9 +571,99 @@ func reconstructStateAtCommit(st *store.Store, targetCommitID string) (map[strin
 	return objects, nil
 }
 
+// nearestSnapshot returns the stored snapshot the replay of graph can start
+// from and the index in graph.path to replay on from: the latest commit with
+// a snapshot that every commit before it on the path leads to. Without one it
+// returns index 0 and no operations.
+func nearestSnapshot(st *store.Store, graph *commitGraph) (int, []*models.Operation, error) {
+	ids, err := st.ListStateSnapshots()
+	if err != nil {
+		return 0, nil, fmt.Errorf("list state snapshots: %w", err)
+	}
+	if len(ids) == 0 {
+		return 0, nil, nil
+	}
+	snapshots := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		snapshots[id] = true
+	}
+
+	for i := len(graph.path) - 1; i >= 0; i-- {
+		id := graph.path[i]
+		if !snapshots[id] || models.HistorySize(graph.parents, id) != i+1 {
+			continue
+		}
+		ops, ok, err := st.GetStateSnapshot(id)
+		if err != nil {
+			return 0, nil, fmt.Errorf("get state snapshot of %s: %w", shortCommitID(id), err)
+		}
+		if ok {
+			return i + 1, ops, nil
+		}
+	}
+	return 0, nil, nil
+}
+
+// snapshotOperations returns one insert per live object, sorted by key.
+func snapshotOperations(live map[string]*models.Operation) []*models.Operation {
+	ops := make([]*models.Operation, 0, len(live))
+	for _, key := range sortedKeys(live) {
+		op := live[key]
+		ops = append(ops, &models.Operation{
+			Timestamp:         op.Timestamp,
+			Type:              models.OperationInsert,
+			ClassName:         op.ClassName,
+			ObjectID:          op.ObjectID,
+			ObjectData:        op.ObjectData,
+			VectorHash:        op.VectorHash,
+			NamedVectorHashes: op.NamedVectorHashes,
+		})
+	}
+	return ops
+}
+
+// replayOperations returns the operations that bring the state before a
+// commit to the state after it: the stored state of a shallow boundary, whose
+// parents are missing, and otherwise the commit's own operations.
+func replayOperations(st *store.Store, commitID string) ([]*models.Operation, error) {
+	state, ok, err := st.GetShallowState(commitID)
+	if err != nil {
+		return nil, fmt.Errorf("get state of shallow commit %s: %w", commitID, err)
+	}
+	if ok {
+		return state, nil
+	}
+	return st.GetOperationsByCommit(commitID)
+}
+
 // getCommitPath returns all ancestor commits of targetCommitID in topological
-// order (parents before children), including commits reachable through merge parents.
+// order (parents before children), including commits reachable through merge
+// parents. The walk stops at shallow boundaries, whose parents are not stored.
 func getCommitPath(st *store.Store, targetCommitID string) ([]string, error) {
+	graph, err := loadCommitGraph(st, targetCommitID)
+	if err != nil {
+		return nil, err
+	}
+	return graph.path, nil
+}
+
+// commitGraph is the history of a commit as getCommitPath walks it.
+type commitGraph struct {
+	path    []string            // topological order, parents before children
+	parents map[string][]string // parents of each commit that are in the graph
+}
+
+// loadCommitGraph walks the history of targetCommitID for getCommitPath.
+func loadCommitGraph(st *store.Store, targetCommitID string) (*commitGraph, error) {
+	shallowIDs, err := st.ListShallowCommits()
+	if err != nil {
+		return nil, fmt.Errorf("list shallow commits: %w", err)
+	}
+	shallow := make(map[string]bool, len(shallowIDs))
+	for _, id := range shallowIDs {
+		shallow[id] = true
+	}
+
 	// Collect all ancestors via BFS
 	type commitInfo struct {
 		id            string
@@ -357,6 +689,10 @@ func getCommitPath(st *store.Store, targetCommitID string) ([]string, error) {
 			return nil, fmt.Errorf("get commit %s: %w", current, err)
 		}
 
+		if shallow[current] {
+			commits[current] = &commitInfo{id: current}
+			continue
+		}
 		commits[current] = &commitInfo{
 			id:            current,
 			parentID:      commit.ParentID,
@@ -372,9 +708,11 @@ func getCommitPath(st *store.Store, targetCommitID string) ([]string, error) {
 	}
 
 	// Topological sort via Kahn's algorithm (parents before children)
+	graph := &commitGraph{parents: make(map[string][]string, len(commits))}
 	inDegree := make(map[string]int)
 	children := make(map[string][]string)
-	for id, ci := range commits {
+	for _, id := range sortedKeys(commits) {
+		ci := commits[id]
 		if _, ok := inDegree[id]; !ok {
 			inDegree[id] = 0
 		}
@@ -382,20 +720,21 @@ func getCommitPath(st *store.Store, targetCommitID string) ([]string, error) {
 			if _, ok := commits[ci.parentID]; ok {
 				inDegree[id]++
 				children[ci.parentID] = append(children[ci.parentID], id)
+				graph.parents[id] = append(graph.parents[id], ci.parentID)
 			}
 		}
 		if ci.mergeParentID != "" {
 			if _, ok := commits[ci.mergeParentID]; ok {
 				inDegree[id]++
 				children[ci.mergeParentID] = append(children[ci.mergeParentID], id)
+				graph.parents[id] = append(graph.parents[id], ci.mergeParentID)
 			}
 		}
 	}
 
-	var path []string
 	var roots []string
-	for id, deg := range inDegree {
-		if deg == 0 {
+	for _, id := range sortedKeys(inDegree) {
+		if inDegree[id] == 0 {
 			roots = append(roots, id)
 		}
 	}
@@ -403,7 +742,7 @@ func getCommitPath(st *store.Store, targetCommitID string) ([]string, error) {
 	for len(roots) > 0 {
 		node := roots[0]
 		roots = roots[1:]
-		path = append(path, node)
+		graph.path = append(graph.path, node)
 
 		for _, child := range children[node] {
 			inDegree[child]--
@@ -413,11 +752,11 @@ func getCommitPath(st *store.Store, targetCommitID string) ([]string, error) {
 		}
 	}
 
-	return path, nil
+	return graph, nil
 }
 
-// restores Weaviate schema to match target commit
-func restoreSchemaToCommit(ctx context.Context, st *store.Store, client weaviate.ClientInterface, targetCommitID string) ([]CheckoutWarning, error) {
+// restores Weaviate schema to match target commit, leaving ignored classes alone
+func restoreSchemaToCommit(ctx context.Context, st *store.Store, client weaviate.ClientInterface, targetCommitID string, scope ClassScope, ignore IgnoreRules) ([]CheckoutWarning, error) {
 	warnings := []CheckoutWarning{}
 
 	targetSchema, err := st.GetSchemaVersionByCommit(targetCommitID)
@@ -442,7 +781,7 @@ func restoreSchemaToCommit(ctx context.Context, st *store.Store, client weaviate
 	// Compute diff: target is "current", live Weaviate is "previous".
 	// ClassesAdded = in target but not in live Weaviate -> need to create.
 	// ClassesDeleted = in live Weaviate but not in target -> need to delete.
-	diff := diffSchemas(&targetSchemaStruct, currentSchema)
+	diff := ignore.filterSchemaDiff(scope.filterSchemaDiff(diffSchemas(&targetSchemaStruct, currentSchema)))
 
 	// Classes in live Weaviate but not in target -> delete them
 	for _, change := range diff.ClassesDeleted {
@@ -499,6 +838,33 @@ func restoreSchemaToCommit(ctx context.Context, st *store.Store, client weaviate
 	return warnings, nil
 }
 
+// fetchMissingVectors downloads the vectors of objects about to be written
+// that a partial clone has not fetched yet, so a failed download stops the
+// restore before Weaviate is modified
+func fetchMissingVectors(st *store.Store, objects ...map[string]*objectWithVector) error {
+	for _, m := range objects {
+		for _, key := range sortedKeys(m) {
+			hashes := append([]string{m[key].VectorHash}, models.SortedNamedVectorHashes(m[key].NamedVectorHashes)...)
+			for _, hash := range hashes {
+				if hash == "" {
+					continue
+				}
+				has, err := st.HasVectorBlob(hash)
+				if err != nil {
+					return fmt.Errorf("check local vector %s: %w", hash, err)
+				}
+				if has {
+					continue
+				}
+				if _, _, err := st.GetVectorBlob(hash); err != nil && !errors.Is(err, store.ErrVectorNotFound) {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
 // retrieves the exact vector from blob store and sets it on the object
 func restoreObjectVector(st *store.Store, obj *models.WeaviateObject, vectorHash string) {
 	if vectorHash == "" {
@@ -518,6 +884,31 @@ func restoreObjectVector(st *store.Store, obj *models.WeaviateObject, vectorHash
 	obj.Vector = exactVector
 }
 
+// retrieves the exact named vectors from the blob store and sets them on the
+// object, keeping each single or multi-vector as it was recorded
+func restoreNamedVectors(st *store.Store, obj *models.WeaviateObject, hashes map[string]string) {
+	for name, hash := range hashes {
+		vectorBytes, dims, err := st.GetVectorBlob(hash)
+		if err != nil || len(vectorBytes) == 0 {
+			continue
+		}
+
+		var exact interface{}
+		if store.IsMultiVector(obj.Vectors[name]) || (obj.Vectors[name] == nil && len(vectorBytes) != dims*4) {
+			exact, err = store.BytesToMultiVector(vectorBytes, dims)
+		} else {
+			exact, err = store.BytesToVector(vectorBytes, dims)
+		}
+		if err != nil {
+			continue
+		}
+		if obj.Vectors == nil {
+			obj.Vectors = make(map[string]interface{}, len(hashes))
+		}
+		obj.Vectors[name] = exact
+	}
+}
+
 // finishCheckout updates HEAD and branch pointers
 func finishCheckout(st *store.Store, commitID, branchName string, createBranch bool, result *CheckoutResult) (*CheckoutResult, error) {
 	if createBranch && branchName != "" {
@@ -534,6 +925,10 @@ func finishCheckout(st *store.Store, commitID, branchName string, createBranch b
 		return nil, err
 	}
 
+	if err := syncSubmodulePins(st, commitID); err != nil {
+		return nil, err
+	}
+
 	if err := rebuildKnownObjectsFromCommit(st, commitID); err != nil {
 		result.Warnings = append(result.Warnings, CheckoutWarning{
 			Type:    "known_state",
@@ -544,9 +939,14 @@ func finishCheckout(st *store.Store, commitID, branchName string, createBranch b
 	return result, nil
 }
 
-// rebuilds known_objects table from commit history
+// rebuilds known_objects table from commit history, limited to the current
+// branch's classes when it is class-scoped
 func rebuildKnownObjectsFromCommit(st *store.Store, commitID string) error {
-	if err := st.ClearKnownObjects(); err != nil {
+	scope, err := currentScope(st)
+	if err != nil {
+		return err
+	}
+	if err := clearKnownObjects(st, scope); err != nil {
 		return err
 	}
 
@@ -555,14 +955,18 @@ func rebuildKnownObjectsFromCommit(st *store.Store, commitID string) error {
 		return err
 	}
 
-	for _, objWithVec := range objects {
+	for _, key := range sortedKeys(objects) {
+		objWithVec := objects[key]
 		obj := objWithVec.Object
-		objectHash, vectorHash := weaviate.HashObjectFull(obj)
+		if !scope.Includes(obj.TenantClass()) {
+			continue
+		}
+		objectHash, vectorHash := storedHashes.HashObjectFull(obj)
 		if objWithVec.VectorHash != "" {
 			vectorHash = objWithVec.VectorHash
 		}
 		data, _ := json.Marshal(obj)
-		if err := st.SaveKnownObjectWithVector(obj.Class, obj.ID, objectHash, vectorHash, data); err != nil {
+		if err := st.SaveKnownObjectWithVector(obj.TenantClass(), obj.ID, objectHash, vectorHash, data); err != nil {
 			return err
 		}
 	}
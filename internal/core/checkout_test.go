@@ -2,9 +2,13 @@ package core
 
 import (
 	"context"
+	"fmt"
 	"testing"
+	"time"
 
+	"github.com/kilupskalvis/wvc/internal/config"
 	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/remote"
 	"github.com/kilupskalvis/wvc/internal/weaviate"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -375,6 +379,58 @@ func TestReconstructStateAtCommit(t *testing.T) {
 	assert.NotContains(t, state, "Article/obj-003")
 }
 
+func TestReconstructStateAtCommit_Snapshots(t *testing.T) {
+	st := newTestStore(t)
+	st.SetSnapshotInterval(2)
+
+	insert := func(id string) *models.Operation {
+		return &models.Operation{Type: models.OperationInsert, ClassName: "Article", ObjectID: id, ObjectData: []byte(`{"id":"` + id + `"}`)}
+	}
+	// c1 <- c2 <- c3 <- c4, and m merging side (off c1) into c3
+	for _, b := range []*remote.CommitBundle{
+		{Commit: &models.Commit{ID: "c1"}, Operations: []*models.Operation{insert("obj-1")}},
+		{Commit: &models.Commit{ID: "c2", ParentID: "c1"}, Operations: []*models.Operation{insert("obj-2")}},
+		{Commit: &models.Commit{ID: "c3", ParentID: "c2"}, Operations: []*models.Operation{
+			{Type: models.OperationDelete, ClassName: "Article", ObjectID: "obj-1"},
+		}},
+		{Commit: &models.Commit{ID: "c4", ParentID: "c3"}, Operations: []*models.Operation{insert("obj-4")}},
+		{Commit: &models.Commit{ID: "side", ParentID: "c1"}, Operations: []*models.Operation{insert("obj-5")}},
+		{Commit: &models.Commit{ID: "m", ParentID: "c3", MergeParentID: "side"}, Operations: []*models.Operation{insert("obj-5")}},
+	} {
+		require.NoError(t, st.InsertCommitBundle(b))
+	}
+
+	// Rebuilding c4 saves snapshots of the commits with 2 and 4 in their history
+	state, err := reconstructStateAtCommit(st, "c4")
+	require.NoError(t, err)
+	assert.Len(t, state, 2)
+	ids, err := st.ListStateSnapshots()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"c2", "c4"}, ids)
+	ops, ok, err := st.GetStateSnapshot("c4")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Len(t, ops, 2)
+	assert.Equal(t, models.OperationInsert, ops[0].Type)
+	assert.Equal(t, "obj-2", ops[0].ObjectID)
+	assert.Equal(t, "obj-4", ops[1].ObjectID)
+
+	// Later rebuilds start from the snapshot instead of replaying its history
+	require.NoError(t, st.PutStateSnapshot("c4", []*models.Operation{insert("obj-9")}))
+	state, err = reconstructStateAtCommit(st, "c4")
+	require.NoError(t, err)
+	assert.Len(t, state, 1)
+	assert.Contains(t, state, "Article/obj-9")
+
+	// On the merge's path side comes before c3, so c3's snapshot cannot stand in for it
+	require.NoError(t, st.PutStateSnapshot("c3", nil))
+	state, err = reconstructStateAtCommit(st, "m")
+	require.NoError(t, err)
+	assert.Len(t, state, 2)
+	assert.Contains(t, state, "Article/obj-2")
+	assert.Contains(t, state, "Article/obj-5")
+}
+
 func TestHasUncommittedChanges(t *testing.T) {
 	ctx := context.Background()
 	st := newTestStore(t)
@@ -445,3 +501,270 @@ func TestBranchAdvancesOnCommit(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, commit2.ID, branch.CommitID)
 }
+
+func TestCheckout_RetryFailedWrites(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+
+	// Setup: feature has obj-001 and obj-002, main only obj-001
+	client.AddClass(&models.WeaviateClass{Class: "Article"})
+	client.AddObject(&models.WeaviateObject{
+		ID:         "obj-001",
+		Class:      "Article",
+		Properties: map[string]interface{}{"title": "First"},
+	})
+	_, err := CreateCommit(ctx, cfg, st, client, "First")
+	require.NoError(t, err)
+	require.NoError(t, CreateBranch(st, "feature", ""))
+	_, err = Checkout(ctx, cfg, st, client, "feature", CheckoutOptions{})
+	require.NoError(t, err)
+	client.AddObject(&models.WeaviateObject{
+		ID:         "obj-002",
+		Class:      "Article",
+		Properties: map[string]interface{}{"title": "Second"},
+	})
+	featureCommit, err := CreateCommit(ctx, cfg, st, client, "Second")
+	require.NoError(t, err)
+	_, err = Checkout(ctx, cfg, st, client, "main", CheckoutOptions{})
+	require.NoError(t, err)
+
+	_, err = RetryFailedApply(ctx, st, client, RetryOptions{})
+	require.Error(t, err, "nothing to retry after a clean checkout")
+
+	// Act: checkout feature while Weaviate rejects writes of obj-002
+	client.WriteErrs = map[string]error{"Article/obj-002": fmt.Errorf("weaviate unavailable")}
+	result, err := Checkout(ctx, cfg, st, client, "feature", CheckoutOptions{})
+	require.NoError(t, err)
+	assert.NotEmpty(t, result.Warnings)
+
+	progress, err := st.GetApplyProgress()
+	require.NoError(t, err)
+	require.NotNil(t, progress)
+	assert.Equal(t, featureCommit.ID, progress.CommitID)
+	require.Len(t, progress.Failed, 1)
+	assert.Equal(t, "obj-002", progress.Failed[0].ObjectID)
+	assert.Equal(t, models.ApplyCreate, progress.Failed[0].Action)
+
+	// Retrying while Weaviate still fails keeps the object recorded
+	opts := RetryOptions{MaxAttempts: 2, InitialBackoff: time.Millisecond}
+	retry, err := RetryFailedApply(ctx, st, client, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 0, retry.Succeeded)
+	require.Len(t, retry.Failed, 1)
+	assert.Equal(t, 3, retry.Failed[0].Attempts)
+
+	// Once Weaviate recovers, only the failed object is written
+	client.WriteErrs = nil
+	retry, err = RetryFailedApply(ctx, st, client, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, retry.Succeeded)
+	assert.Empty(t, retry.Failed)
+
+	_, err = client.GetObject(ctx, "Article", "obj-002")
+	assert.NoError(t, err)
+
+	progress, err = st.GetApplyProgress()
+	require.NoError(t, err)
+	assert.Nil(t, progress)
+}
+
+func TestClassScopedBranch_IgnoresOtherClasses(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+
+	// Setup: one commit with an Article and an Author
+	client.AddClass(&models.WeaviateClass{Class: "Article"})
+	client.AddClass(&models.WeaviateClass{Class: "Author"})
+	client.AddObject(&models.WeaviateObject{
+		ID:         "art-001",
+		Class:      "Article",
+		Properties: map[string]interface{}{"title": "First"},
+	})
+	client.AddObject(&models.WeaviateObject{
+		ID:         "auth-001",
+		Class:      "Author",
+		Properties: map[string]interface{}{"name": "Ann"},
+	})
+	_, err := CreateCommit(ctx, cfg, st, client, "Initial")
+	require.NoError(t, err)
+
+	require.NoError(t, CreateBranch(st, "articles", ""))
+	require.NoError(t, SetBranchClasses(st, "articles", []string{"Article"}))
+	_, err = Checkout(ctx, cfg, st, client, "articles", CheckoutOptions{})
+	require.NoError(t, err)
+
+	// Another team edits an Author while this branch edits an Article
+	client.Objects["Author/auth-001"].Properties["name"] = "Bob"
+	client.Objects["Article/art-001"].Properties["title"] = "Edited"
+
+	diff, err := ComputeIncrementalDiff(ctx, cfg, st, client)
+	require.NoError(t, err)
+	require.Len(t, diff.Unstaged.Updated, 1)
+	assert.Equal(t, "Article", diff.Unstaged.Updated[0].ClassName)
+
+	commit, err := CreateCommit(ctx, cfg, st, client, "Edit article")
+	require.NoError(t, err)
+	assert.Equal(t, 1, commit.OperationCount)
+
+	ops, err := st.GetOperationsByCommit(commit.ID)
+	require.NoError(t, err)
+	require.Len(t, ops, 1)
+	assert.Equal(t, "Article", ops[0].ClassName)
+
+	// The Author change is invisible on the scoped branch...
+	hasChanges, err := HasUncommittedChanges(ctx, cfg, st, client)
+	require.NoError(t, err)
+	assert.False(t, hasChanges)
+
+	// ...but still uncommitted for the unscoped main branch, so leaving for
+	// main would discard it and is refused
+	_, err = Checkout(ctx, cfg, st, client, "main", CheckoutOptions{})
+	require.Error(t, err)
+
+	// Restore the Author, then switching to main only reverts the Article
+	client.Objects["Author/auth-001"].Properties["name"] = "Ann"
+	_, err = Checkout(ctx, cfg, st, client, "main", CheckoutOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "First", client.Objects["Article/art-001"].Properties["title"])
+
+	// Checking the scoped branch out again leaves other classes alone
+	client.Objects["Author/auth-001"].Properties["name"] = "Cat"
+	_, err = CreateCommit(ctx, cfg, st, client, "Rename author")
+	require.NoError(t, err)
+	_, err = Checkout(ctx, cfg, st, client, "articles", CheckoutOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "Edited", client.Objects["Article/art-001"].Properties["title"])
+	assert.Equal(t, "Cat", client.Objects["Author/auth-001"].Properties["name"])
+}
+
+func TestSparseCheckout_IgnoresUntrackedClasses(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+
+	client.AddClass(&models.WeaviateClass{Class: "Article"})
+	client.AddObject(&models.WeaviateObject{
+		ID:         "art-001",
+		Class:      "Article",
+		Properties: map[string]interface{}{"title": "First"},
+	})
+	_, err := CreateCommit(ctx, cfg, st, client, "Initial")
+	require.NoError(t, err)
+	require.NoError(t, st.SetSparseClasses([]string{"Article"}))
+
+	// Objects of classes outside the sparse checkout are not versioned
+	client.AddClass(&models.WeaviateClass{Class: "Author"})
+	client.AddObject(&models.WeaviateObject{
+		ID:         "auth-001",
+		Class:      "Author",
+		Properties: map[string]interface{}{"name": "Ann"},
+	})
+	hasChanges, err := HasUncommittedChanges(ctx, cfg, st, client)
+	require.NoError(t, err)
+	assert.False(t, hasChanges)
+
+	client.Objects["Article/art-001"].Properties["title"] = "Edited"
+	commit, err := CreateCommit(ctx, cfg, st, client, "Edit article")
+	require.NoError(t, err)
+	assert.Equal(t, 1, commit.OperationCount)
+
+	// A class-scoped branch is narrowed to the sparse classes
+	require.NoError(t, CreateBranch(st, "authors", ""))
+	require.NoError(t, SetBranchClasses(st, "authors", []string{"Article", "Author"}))
+	require.NoError(t, st.SetCurrentBranch("authors"))
+	scope, err := currentScope(st)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Article"}, scope.Classes())
+}
+
+func TestCheckout_RestoresNamedVectors(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+
+	client.AddClass(&models.WeaviateClass{Class: "Article"})
+	client.AddObject(&models.WeaviateObject{
+		ID:         "obj-001",
+		Class:      "Article",
+		Properties: map[string]interface{}{"title": "Named"},
+		Vectors: map[string]interface{}{
+			"title":   []float32{0.1, 0.2, 0.3},
+			"colbert": [][]float32{{0.5, 0.25}, {0.125, 1}},
+		},
+	})
+	commit1, err := CreateCommit(ctx, cfg, st, client, "Named vectors")
+	require.NoError(t, err)
+
+	ops, err := st.GetOperationsByCommit(commit1.ID)
+	require.NoError(t, err)
+	require.Len(t, ops, 1)
+	assert.Len(t, ops[0].NamedVectorHashes, 2, "one blob per named vector")
+	assert.Len(t, ops[0].VectorHashes(), 2)
+
+	// Changing only a named vector is a change
+	client.AddObject(&models.WeaviateObject{
+		ID:         "obj-001",
+		Class:      "Article",
+		Properties: map[string]interface{}{"title": "Named"},
+		Vectors: map[string]interface{}{
+			"title":   []float32{0.9, 0.8, 0.7},
+			"colbert": [][]float32{{0.5, 0.25}, {0.125, 1}},
+		},
+	})
+	changed, err := HasUncommittedChanges(ctx, cfg, st, client)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	_, err = CreateCommit(ctx, cfg, st, client, "Move title vector")
+	require.NoError(t, err)
+
+	_, err = Checkout(ctx, cfg, st, client, commit1.ID, CheckoutOptions{})
+	require.NoError(t, err)
+
+	restored := client.Objects[models.ObjectKey("Article", "obj-001")]
+	require.NotNil(t, restored)
+	assert.Equal(t, []float32{0.1, 0.2, 0.3}, restored.Vectors["title"])
+	assert.Equal(t, [][]float32{{0.5, 0.25}, {0.125, 1}}, restored.Vectors["colbert"])
+
+	changed, err = HasUncommittedChanges(ctx, cfg, st, client)
+	require.NoError(t, err)
+	assert.False(t, changed)
+}
+
+func TestCheckout_BatchesWrites(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	cfg.Apply = &config.ApplyConfig{BatchSize: 10, Concurrency: 3}
+	client := weaviate.NewMockClient()
+
+	client.AddClass(&models.WeaviateClass{Class: "Article"})
+	for i := 0; i < 45; i++ {
+		client.AddObject(&models.WeaviateObject{
+			ID:         fmt.Sprintf("obj-%03d", i),
+			Class:      "Article",
+			Properties: map[string]interface{}{"n": float64(i)},
+		})
+	}
+	full, err := CreateCommit(ctx, cfg, st, client, "Full")
+	require.NoError(t, err)
+	for i := 0; i < 45; i++ {
+		require.NoError(t, client.DeleteObject(ctx, "Article", fmt.Sprintf("obj-%03d", i)))
+	}
+	_, err = CreateCommit(ctx, cfg, st, client, "Empty")
+	require.NoError(t, err)
+
+	// 45 creates in batches of 10
+	result, err := Checkout(ctx, cfg, st, client, full.ID, CheckoutOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 45, result.ObjectsAdded)
+	assert.Equal(t, 5, client.BatchRequests)
+	count, err := client.GetClassCount(ctx, "Article")
+	require.NoError(t, err)
+	assert.Equal(t, 45, count)
+}
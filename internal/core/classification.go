@@ -0,0 +1,28 @@
+package core
+
+import (
+	"github.com/kilupskalvis/wvc/internal/config"
+	"github.com/kilupskalvis/wvc/internal/models"
+)
+
+// commitClassifications returns the configured classification of each class
+// ops touch, for recording in a new commit; nil when none is classified.
+func commitClassifications(cfg *config.Config, ops []*models.Operation) (map[string]*models.Classification, error) {
+	configured, err := cfg.Classifications()
+	if err != nil || len(configured) == 0 {
+		return nil, err
+	}
+	var out map[string]*models.Classification
+	for _, op := range ops {
+		className, _ := models.SplitTenantClass(op.ClassName)
+		c, ok := configured[className]
+		if !ok {
+			continue
+		}
+		if out == nil {
+			out = make(map[string]*models.Classification)
+		}
+		out[className] = c
+	}
+	return out, nil
+}
@@ -0,0 +1,61 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kilupskalvis/wvc/internal/config"
+	"github.com/kilupskalvis/wvc/internal/store"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+)
+
+// FinishLocalClone turns a database copied from another repository with
+// store.CloneDatabase into a repository of its own: the source's working
+// copy state is dropped and branch, or the branch the source had checked
+// out when empty, is checked out into client's instance. History, branches,
+// tags, remotes, and vector blobs are kept as they were in the source.
+func FinishLocalClone(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface, branch string) (*CheckoutResult, error) {
+	requested := branch != ""
+	if !requested {
+		current, err := st.GetCurrentBranch()
+		if err != nil {
+			return nil, err
+		}
+		branch = current
+	}
+	if branch == "" {
+		branch = "main"
+	}
+	if err := st.ResetLocalState(); err != nil {
+		return nil, fmt.Errorf("reset cloned state: %w", err)
+	}
+
+	b, err := st.GetBranch(branch)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil && requested {
+		return nil, fmt.Errorf("branch '%s' not found in the source repository", branch)
+	}
+	result := &CheckoutResult{BranchName: branch, Warnings: []CheckoutWarning{}}
+	if err := st.SetCurrentBranch(branch); err != nil {
+		return nil, err
+	}
+	// The source's branch was unborn; so is the clone's
+	if b == nil || b.CommitID == "" {
+		return result, nil
+	}
+	result.TargetCommit = b.CommitID
+
+	scope, err := currentScope(st)
+	if err != nil {
+		return nil, err
+	}
+	warnings, stats, err := restoreStateToCommit(ctx, cfg, st, client, b.CommitID, scope)
+	if err != nil {
+		return nil, fmt.Errorf("cloned, but checkout of '%s' failed: %w", branch, err)
+	}
+	result.Warnings = append(result.Warnings, warnings...)
+	result.ObjectsAdded, result.ObjectsRemoved, result.ObjectsUpdated = stats.Added, stats.Removed, stats.Updated
+	return finishCheckout(st, b.CommitID, branch, false, result)
+}
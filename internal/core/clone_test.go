@@ -0,0 +1,65 @@
+package core
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/kilupskalvis/wvc/internal/fsutil"
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/store"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFinishLocalClone(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	cfg := newTestConfig()
+
+	srcPath := filepath.Join(dir, "src.db")
+	src, err := store.New(srcPath)
+	require.NoError(t, err)
+	require.NoError(t, src.Initialize())
+	require.NoError(t, src.SetCurrentBranch("main"))
+	srcClient := weaviate.NewMockClient()
+	srcClient.AddClass(&models.WeaviateClass{Class: "Article"})
+	srcClient.AddObject(&models.WeaviateObject{ID: "obj-1", Class: "Article", Vector: []float32{0.1, 0.2}})
+	first, err := CreateCommit(ctx, cfg, src, srcClient, "First")
+	require.NoError(t, err)
+	// Uncommitted work in the source stays there
+	srcClient.AddObject(&models.WeaviateObject{ID: "obj-2", Class: "Article"})
+	_, err = StageAll(ctx, cfg, src, srcClient)
+	require.NoError(t, err)
+	require.NoError(t, src.Close())
+
+	dstPath := filepath.Join(dir, "dst.db")
+	_, err = store.CloneDatabase(srcPath, dstPath, fsutil.SyncNone)
+	require.NoError(t, err)
+	dst, err := store.New(dstPath)
+	require.NoError(t, err)
+	defer dst.Close()
+
+	client := weaviate.NewMockClient()
+	_, err = FinishLocalClone(ctx, cfg, dst, client, "feature")
+	assert.ErrorContains(t, err, "not found")
+
+	result, err := FinishLocalClone(ctx, cfg, dst, client, "")
+	require.NoError(t, err)
+	assert.Equal(t, "main", result.BranchName)
+	assert.Equal(t, first.ID, result.TargetCommit)
+	assert.Equal(t, 1, result.ObjectsAdded)
+
+	obj, err := client.GetObject(ctx, "Article", "obj-1")
+	require.NoError(t, err)
+	assert.Equal(t, []float32{0.1, 0.2}, obj.Vector)
+	head, err := dst.GetHEAD()
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, head)
+
+	diff, err := ComputeIncrementalDiff(ctx, cfg, dst, client)
+	require.NoError(t, err)
+	assert.Zero(t, diff.TotalUnstagedChanges())
+	assert.Zero(t, diff.Staged.TotalChanges())
+}
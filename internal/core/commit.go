@@ -12,31 +12,73 @@ import (
 	"github.com/kilupskalvis/wvc/internal/weaviate"
 )
 
-// CreateCommit creates a new commit from current changes
+// CreateCommit creates a new commit from current changes. It fails with
+// ErrDatabaseChanged if Weaviate is written to while the changes are scanned.
+// Changes are recorded a page of objects at a time, so memory stays bounded
+// however large the classes are.
 func CreateCommit(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface, message string) (*models.Commit, error) {
-	diff, err := ComputeDiff(ctx, cfg, st, client)
+	if err := CheckCommitMessage(cfg, message); err != nil {
+		return nil, err
+	}
+
+	watermarks, err := TakeWatermarks(ctx, st, client)
+	if err != nil {
+		return nil, err
+	}
+
+	// Class-scoped branches only see changes to their own classes
+	scope, err := currentScope(st)
+	if err != nil {
+		return nil, err
+	}
+	moves, err := loadStagedMoves(st)
+	if err != nil {
+		return nil, err
+	}
+
+	// Operations are recorded as they are found; the ones recorded from
+	// fromSeq on are discarded if the commit is abandoned
+	fromSeq, err := st.NextUncommittedSeq()
 	if err != nil {
 		return nil, err
 	}
+	discard := func(cause error) (*models.Commit, error) {
+		if err := st.DiscardUncommittedOperations(fromSeq); err != nil {
+			return nil, fmt.Errorf("%w (discarding recorded operations: %v)", cause, err)
+		}
+		return nil, cause
+	}
 
-	schemaDiff, err := ComputeSchemaDiff(ctx, st, client)
+	changeCount := 0
+	err = walkDiff(ctx, cfg, st, client, scope, nil, func(changes *DiffResult) error {
+		moves.annotate(changes)
+		changes.Sort()
+		changeCount += changes.TotalChanges()
+		return RecordDiffAsOperations(st, changes)
+	})
+	if err != nil {
+		return discard(err)
+	}
+
+	schemaDiff, err := ComputeSchemaDiff(ctx, cfg, st, client)
 	if err != nil {
 		schemaDiff = &SchemaDiffResult{}
 	}
 
-	if diff.TotalChanges() == 0 && !schemaDiff.HasChanges() {
+	pinsChanged, err := submodulePinsChanged(st)
+	if err != nil {
+		return discard(err)
+	}
+	if changeCount == 0 && !schemaDiff.HasChanges() && !pinsChanged {
 		return nil, fmt.Errorf("no changes to commit")
 	}
-
-	if diff.TotalChanges() > 0 {
-		if err := RecordDiffAsOperations(st, diff); err != nil {
-			return nil, err
-		}
+	if err := watermarks.Check(ctx, client); err != nil {
+		return discard(err)
 	}
 
-	commit, err := finalizeCommit(ctx, st, client, message, diff.TotalChanges())
+	commit, err := finalizeCommit(ctx, cfg, st, client, message, changeCount)
 	if err != nil {
-		return nil, err
+		return discard(err)
 	}
 
 	useCursor := cfg.SupportsCursorPagination()
@@ -49,35 +91,46 @@ func CreateCommit(ctx context.Context, cfg *config.Config, st *store.Store, clie
 
 // CreateCommitFromStaging creates a commit from staged changes only
 func CreateCommitFromStaging(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface, message string) (*models.Commit, error) {
+	if err := CheckCommitMessage(cfg, message); err != nil {
+		return nil, err
+	}
+
 	stagedChanges, err := st.GetAllStagedChanges()
 	if err != nil {
 		return nil, err
 	}
 
-	schemaDiff, err := ComputeSchemaDiff(ctx, st, client)
+	schemaDiff, err := ComputeSchemaDiff(ctx, cfg, st, client)
 	if err != nil {
 		schemaDiff = &SchemaDiffResult{}
 	}
 
-	if len(stagedChanges) == 0 && !schemaDiff.HasChanges() {
+	pinsChanged, err := submodulePinsChanged(st)
+	if err != nil {
+		return nil, err
+	}
+	if len(stagedChanges) == 0 && !schemaDiff.HasChanges() && !pinsChanged {
 		return nil, fmt.Errorf("nothing to commit (use \"wvc add\" to stage changes)")
 	}
 
+	ops := make([]*models.Operation, 0, len(stagedChanges))
 	for _, sc := range stagedChanges {
-		op := &models.Operation{
+		ops = append(ops, &models.Operation{
 			Timestamp:    time.Now(),
 			Type:         models.OperationType(sc.ChangeType),
 			ClassName:    sc.ClassName,
 			ObjectID:     sc.ObjectID,
 			ObjectData:   sc.ObjectData,
 			PreviousData: sc.PreviousData,
-		}
-		if err := st.RecordOperation(op); err != nil {
-			return nil, err
-		}
+			MovedFrom:    sc.MovedFrom,
+			MovedTo:      sc.MovedTo,
+		})
+	}
+	if err := st.RecordOperations(ops); err != nil {
+		return nil, err
 	}
 
-	commit, err := finalizeCommit(ctx, st, client, message, len(stagedChanges))
+	commit, err := finalizeCommit(ctx, cfg, st, client, message, len(stagedChanges))
 	if err != nil {
 		return nil, err
 	}
@@ -102,9 +155,9 @@ func updateKnownStateForStagedChanges(ctx context.Context, st *store.Store, clie
 			if err != nil {
 				return fmt.Errorf("failed to fetch object %s/%s: %w", sc.ClassName, sc.ObjectID, err)
 			}
-			objectHash, vectorHash := weaviate.HashObjectFull(obj)
+			objectHash, vectorHash := client.HashCache().HashObjectFull(obj)
 			data, _ := json.Marshal(obj)
-			if err := st.SaveKnownObjectWithVector(obj.Class, obj.ID, objectHash, vectorHash, data); err != nil {
+			if err := st.SaveKnownObjectWithVector(obj.TenantClass(), obj.ID, objectHash, vectorHash, data); err != nil {
 				return err
 			}
 		case "delete":
@@ -118,7 +171,7 @@ func updateKnownStateForStagedChanges(ctx context.Context, st *store.Store, clie
 
 // finalizeCommit performs the shared commit finalization: generate ID, capture
 // schema, mark operations, create commit, set HEAD, and update branch pointer.
-func finalizeCommit(ctx context.Context, st *store.Store, client weaviate.ClientInterface, message string, opCount int) (*models.Commit, error) {
+func finalizeCommit(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface, message string, opCount int) (*models.Commit, error) {
 	parentID, err := st.GetHEAD()
 	if err != nil {
 		return nil, err
@@ -128,20 +181,32 @@ func finalizeCommit(ctx context.Context, st *store.Store, client weaviate.Client
 	if err != nil {
 		return nil, err
 	}
-
-	now := time.Now()
-	commitID := models.GenerateCommitID(message, now, parentID, uncommittedOps)
-
-	if err := captureSchemaSnapshot(ctx, st, client, commitID); err != nil {
-		return nil, fmt.Errorf("capture schema: %w", err)
+	pins, err := st.GetSubmodulePins()
+	if err != nil {
+		return nil, err
+	}
+	classifications, err := commitClassifications(cfg, uncommittedOps)
+	if err != nil {
+		return nil, err
 	}
 
 	commit := &models.Commit{
-		ID:             commitID,
-		ParentID:       parentID,
-		Message:        message,
-		Timestamp:      now,
-		OperationCount: opCount,
+		ParentID:        parentID,
+		Message:         message,
+		Author:          cfg.Author(),
+		Timestamp:       time.Now(),
+		OperationCount:  opCount,
+		HashVersion:     models.CurrentCommitHashVersion,
+		Submodules:      pins,
+		Classifications: classifications,
+	}
+	commit.ID, err = commit.ComputeID(uncommittedOps)
+	if err != nil {
+		return nil, fmt.Errorf("compute commit ID: %w", err)
+	}
+
+	if err := captureSchemaSnapshot(ctx, cfg, st, client, commit.ID); err != nil {
+		return nil, fmt.Errorf("capture schema: %w", err)
 	}
 
 	// Determine branch state before the atomic write
@@ -160,12 +225,31 @@ func finalizeCommit(ctx context.Context, st *store.Store, client weaviate.Client
 	return commit, nil
 }
 
-// captureSchemaSnapshot fetches current schema and saves it with the commit
-func captureSchemaSnapshot(ctx context.Context, st *store.Store, client weaviate.ClientInterface, commitID string) error {
+// captureSchemaSnapshot fetches current schema and saves it with the
+// commit. Classes of materialized submodules are left out, their schema is
+// versioned by the submodule, and so are ignored classes.
+func captureSchemaSnapshot(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface, commitID string) error {
 	schema, err := client.GetSchemaTyped(ctx)
 	if err != nil {
 		return err
 	}
+	owned, err := submoduleScope(st)
+	if err != nil {
+		return err
+	}
+	ignore, err := LoadIgnoreRules(cfg)
+	if err != nil {
+		return err
+	}
+	if owned != nil || len(ignore) > 0 {
+		kept := &models.WeaviateSchema{}
+		for _, class := range schema.Classes {
+			if owned.Includes(class.Class) && !ignore.ignoresClass(class.Class) {
+				kept.Classes = append(kept.Classes, class)
+			}
+		}
+		schema = kept
+	}
 
 	schemaJSON, err := json.Marshal(schema)
 	if err != nil {
@@ -1,11 +1,16 @@
 package core
 
 import (
+	"context"
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/kilupskalvis/wvc/internal/config"
 	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGenerateCommitID_Deterministic(t *testing.T) {
@@ -102,3 +107,273 @@ func TestComputeOperationsHash_EmptyOperations(t *testing.T) {
 	hash = models.ComputeOperationsHash([]*models.Operation{})
 	assert.Equal(t, "", hash)
 }
+
+func TestGenerateCommitIDV2_CanonicalOperations(t *testing.T) {
+	timestamp := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	insert := &models.Operation{Type: models.OperationInsert, ClassName: "Article", ObjectID: "obj-001",
+		ObjectData: []byte(`{"id":"obj-001","class":"Article","properties":{"title":"A","body":"B"}}`)}
+	del := &models.Operation{Type: models.OperationDelete, ClassName: "Author", ObjectID: "obj-002"}
+
+	id1, err := models.GenerateCommitIDV2("Merge", timestamp, "p1", "p2", []*models.Operation{insert, del})
+	require.NoError(t, err)
+	id2, err := models.GenerateCommitIDV2("Merge", timestamp, "p1", "p2", []*models.Operation{del, insert})
+	require.NoError(t, err)
+	assert.Equal(t, id1, id2, "operation order must not affect the ID")
+
+	// The same object data encoded differently hashes the same
+	reencoded := *insert
+	reencoded.ObjectData = []byte(`{ "properties": {"body": "B", "title": "A"}, "class": "Article", "id": "obj-001" }`)
+	id3, err := models.GenerateCommitIDV2("Merge", timestamp, "p1", "p2", []*models.Operation{&reencoded, del})
+	require.NoError(t, err)
+	assert.Equal(t, id1, id3)
+
+	// The time zone of the timestamp does not matter, the instant does
+	id4, err := models.GenerateCommitIDV2("Merge", timestamp.In(time.FixedZone("EET", 2*3600)), "p1", "p2", []*models.Operation{insert, del})
+	require.NoError(t, err)
+	assert.Equal(t, id1, id4)
+
+	id5, err := models.GenerateCommitIDV2("Merge", timestamp, "p2", "p1", []*models.Operation{insert, del})
+	require.NoError(t, err)
+	assert.NotEqual(t, id1, id5, "parent order is significant")
+
+	legacy := models.GenerateMergeCommitID("Merge", timestamp, "p1", "p2", []*models.Operation{insert, del})
+	assert.NotEqual(t, legacy, id1)
+}
+
+func TestCommit_ComputeIDByVersion(t *testing.T) {
+	timestamp := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	ops := []*models.Operation{{Type: models.OperationInsert, ClassName: "Article", ObjectID: "obj-001"}}
+
+	legacy := &models.Commit{ParentID: "p1", Message: "m", Timestamp: timestamp}
+	id, err := legacy.ComputeID(ops)
+	require.NoError(t, err)
+	assert.Equal(t, models.GenerateCommitID("m", timestamp, "p1", ops), id)
+
+	v2 := &models.Commit{ParentID: "p1", Message: "m", Timestamp: timestamp, HashVersion: models.CommitHashV2}
+	id, err = v2.ComputeID(ops)
+	require.NoError(t, err)
+	expected, err := models.GenerateCommitIDV2("m", timestamp, "p1", "", ops)
+	require.NoError(t, err)
+	assert.Equal(t, expected, id)
+
+	unknown := &models.Commit{Message: "m", Timestamp: timestamp, HashVersion: 99}
+	_, err = unknown.ComputeID(ops)
+	assert.Error(t, err)
+}
+
+func TestCreateCommit_UsesCurrentHashVersion(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+
+	client.AddClass(&models.WeaviateClass{Class: "Article"})
+	client.AddObject(&models.WeaviateObject{ID: "obj-001", Class: "Article", Properties: map[string]interface{}{"title": "A"}})
+	commit, err := CreateCommit(ctx, cfg, st, client, "Initial")
+	require.NoError(t, err)
+	assert.Equal(t, models.CurrentCommitHashVersion, commit.HashVersion)
+
+	stored, err := st.GetCommit(commit.ID)
+	require.NoError(t, err)
+	ops, err := st.GetOperationsByCommit(commit.ID)
+	require.NoError(t, err)
+	id, err := stored.ComputeID(ops)
+	require.NoError(t, err)
+	assert.Equal(t, commit.ID, id, "the stored commit and its operations must reproduce its ID")
+}
+
+func TestCreateCommit_RecordsClassifications(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	cfg.Classification = map[string]*config.ClassificationConfig{
+		"Person": {License: "internal", PII: "restricted"},
+		"Review": {PII: "internal"},
+	}
+	client := weaviate.NewMockClient()
+
+	client.AddClass(&models.WeaviateClass{Class: "Article"})
+	client.AddClass(&models.WeaviateClass{Class: "Person"})
+	client.AddObject(&models.WeaviateObject{ID: "obj-001", Class: "Article", Properties: map[string]interface{}{"title": "A"}})
+	client.AddObject(&models.WeaviateObject{ID: "obj-002", Class: "Person", Properties: map[string]interface{}{"name": "B"}})
+	commit, err := CreateCommit(ctx, cfg, st, client, "Initial")
+	require.NoError(t, err)
+
+	// Only the classes the commit touches are recorded, and they are part of its ID
+	require.Len(t, commit.Classifications, 1)
+	assert.Equal(t, &models.Classification{License: "internal", PII: models.PIIRestricted}, commit.Classifications["Person"])
+	assert.Equal(t, []string{"Person"}, commit.RestrictedClasses())
+	stored, err := st.GetCommit(commit.ID)
+	require.NoError(t, err)
+	ops, err := st.GetOperationsByCommit(commit.ID)
+	require.NoError(t, err)
+	id, err := stored.ComputeID(ops)
+	require.NoError(t, err)
+	assert.Equal(t, commit.ID, id)
+
+	cfg.Classification["Person"].PII = "secret"
+	client.AddObject(&models.WeaviateObject{ID: "obj-003", Class: "Person", Properties: map[string]interface{}{"name": "C"}})
+	_, err = CreateCommit(ctx, cfg, st, client, "Second")
+	assert.Error(t, err)
+}
+
+func TestVerifyCommit_DetectsConcurrentWrites(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+
+	client.AddClass(&models.WeaviateClass{Class: "Article"})
+	client.AddObject(&models.WeaviateObject{ID: "obj-001", Class: "Article", Properties: map[string]interface{}{"title": "A"}, Vector: []float32{0.1, 0.2}})
+	client.AddObject(&models.WeaviateObject{ID: "obj-002", Class: "Article", Properties: map[string]interface{}{"title": "B"}})
+	base, err := CreateCommit(ctx, cfg, st, client, "Initial")
+	require.NoError(t, err)
+
+	result, err := VerifyCommit(ctx, st, client, base.ID, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Checked)
+	assert.Empty(t, result.Mismatches)
+
+	require.NoError(t, client.DeleteObject(ctx, "Article", "obj-002"))
+	commit, err := CreateCommit(ctx, cfg, st, client, "Delete B")
+	require.NoError(t, err)
+
+	// Another writer changes the vector and recreates the deleted object
+	client.AddObject(&models.WeaviateObject{ID: "obj-001", Class: "Article", Properties: map[string]interface{}{"title": "A"}, Vector: []float32{0.3, 0.4}})
+	client.AddObject(&models.WeaviateObject{ID: "obj-002", Class: "Article", Properties: map[string]interface{}{"title": "B"}})
+
+	result, err = VerifyCommit(ctx, st, client, base.ID, 0)
+	require.NoError(t, err)
+	require.Len(t, result.Mismatches, 1)
+	assert.Equal(t, "obj-001", result.Mismatches[0].ObjectID)
+	assert.Contains(t, result.Mismatches[0].Reason, "vector")
+
+	result, err = VerifyCommit(ctx, st, client, commit.ID, 0)
+	require.NoError(t, err)
+	require.Len(t, result.Mismatches, 1)
+	assert.Equal(t, "deleted object still exists", result.Mismatches[0].Reason)
+
+	result, err = VerifyCommit(ctx, st, client, base.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Checked)
+	assert.Equal(t, 2, result.Total)
+}
+
+// writeDuringScanClient simulates another client writing to Weaviate right
+// after the commit's scan has read the objects.
+type writeDuringScanClient struct {
+	*weaviate.MockClient
+	write func()
+}
+
+func (c *writeDuringScanClient) IterateObjects(ctx context.Context, className string, useCursor bool, fn weaviate.ObjectBatchFunc) error {
+	err := c.MockClient.IterateObjects(ctx, className, useCursor, fn)
+	if c.write != nil {
+		c.write()
+		c.write = nil
+	}
+	return err
+}
+
+func TestCreateCommit_AbortsWhenDatabaseChanges(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	mock := weaviate.NewMockClient()
+	mock.AddClass(&models.WeaviateClass{Class: "Article"})
+	mock.AddObject(&models.WeaviateObject{ID: "obj-001", Class: "Article", Properties: map[string]interface{}{"title": "A"}, LastUpdateTimeUnix: 1000})
+
+	client := &writeDuringScanClient{MockClient: mock, write: func() {
+		mock.AddObject(&models.WeaviateObject{ID: "obj-001", Class: "Article", Properties: map[string]interface{}{"title": "B"}, LastUpdateTimeUnix: 2000})
+	}}
+	_, err := CreateCommit(ctx, cfg, st, client, "Torn")
+	require.ErrorIs(t, err, ErrDatabaseChanged)
+	assert.Contains(t, err.Error(), "Article")
+
+	head, err := st.GetHEAD()
+	require.NoError(t, err)
+	assert.Empty(t, head, "nothing is committed")
+	ops, err := st.GetUncommittedOperations()
+	require.NoError(t, err)
+	assert.Empty(t, ops)
+
+	// Once the writer is done, the retry captures its write
+	commit, err := CreateCommit(ctx, cfg, st, client, "Retry")
+	require.NoError(t, err)
+	ops, err = st.GetOperationsByCommit(commit.ID)
+	require.NoError(t, err)
+	require.Len(t, ops, 1)
+	assert.Contains(t, string(ops[0].ObjectData), `"B"`)
+}
+
+// pagingClient fails the test if a whole class is loaded at once and records
+// the largest page handed to a scan.
+type pagingClient struct {
+	*weaviate.MockClient
+	t       *testing.T
+	maxPage int
+}
+
+func (c *pagingClient) GetAllObjects(ctx context.Context, className string, useCursor bool) ([]*models.WeaviateObject, error) {
+	c.t.Errorf("class %s loaded at once", className)
+	return c.MockClient.GetAllObjects(ctx, className, useCursor)
+}
+
+func (c *pagingClient) GetAllObjectsAllClasses(ctx context.Context, useCursor bool) (map[string]*models.WeaviateObject, error) {
+	c.t.Errorf("all classes loaded at once")
+	return c.MockClient.GetAllObjectsAllClasses(ctx, useCursor)
+}
+
+func (c *pagingClient) IterateObjects(ctx context.Context, className string, useCursor bool, fn weaviate.ObjectBatchFunc) error {
+	return c.MockClient.IterateObjects(ctx, className, useCursor, func(batch []*models.WeaviateObject) error {
+		c.maxPage = max(c.maxPage, len(batch))
+		return fn(batch)
+	})
+}
+
+func TestCreateCommit_ScansInPages(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	mock := weaviate.NewMockClient()
+	mock.PageSize = 3
+	client := &pagingClient{MockClient: mock, t: t}
+
+	mock.AddClass(&models.WeaviateClass{Class: "Article"})
+	for i := 0; i < 10; i++ {
+		mock.AddObject(&models.WeaviateObject{
+			ID:         fmt.Sprintf("obj-%03d", i),
+			Class:      "Article",
+			Properties: map[string]interface{}{"n": i},
+			Vector:     []float32{float32(i), 1},
+		})
+	}
+	first, err := CreateCommit(ctx, cfg, st, client, "Ten objects")
+	require.NoError(t, err)
+	ops, err := st.GetOperationsByCommit(first.ID)
+	require.NoError(t, err)
+	assert.Len(t, ops, 10)
+
+	// Deletions are found by paging through the known objects
+	for i := 0; i < 4; i++ {
+		require.NoError(t, mock.DeleteObject(ctx, "Article", fmt.Sprintf("obj-%03d", i)))
+	}
+	mock.Objects[models.ObjectKey("Article", "obj-009")].Properties = map[string]interface{}{"n": 99}
+	staged, err := StageAll(ctx, cfg, st, client)
+	require.NoError(t, err)
+	assert.Equal(t, 5, staged)
+	second, err := CreateCommitFromStaging(ctx, cfg, st, client, "Delete four, update one")
+	require.NoError(t, err)
+	assert.Equal(t, 5, second.OperationCount)
+
+	// Checkout reconciles a page at a time
+	_, err = Checkout(ctx, cfg, st, client, first.ID, CheckoutOptions{})
+	require.NoError(t, err)
+	assert.Len(t, mock.Objects, 10)
+	assert.Equal(t, float64(9), mock.Objects[models.ObjectKey("Article", "obj-009")].Properties["n"])
+	changed, err := HasUncommittedChanges(ctx, cfg, st, client)
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	assert.Equal(t, 3, client.maxPage)
+}
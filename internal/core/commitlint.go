@@ -0,0 +1,100 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kilupskalvis/wvc/internal/config"
+)
+
+// trailerLine matches a "Key: value" trailer line
+var trailerLine = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9-]*):\s*(.*)$`)
+
+// CheckCommitMessage enforces the repository's commit message rules,
+// returning one error that lists every violation
+func CheckCommitMessage(cfg *config.Config, message string) error {
+	if cfg == nil || cfg.Commit == nil {
+		return nil
+	}
+	violations, err := LintCommitMessage(cfg.Commit, message)
+	if err != nil {
+		return err
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	return fmt.Errorf("commit message rejected:\n  - %s", strings.Join(violations, "\n  - "))
+}
+
+// LintCommitMessage returns the rules message violates. An error means the
+// rules themselves are invalid.
+func LintCommitMessage(rules *config.CommitRules, message string) ([]string, error) {
+	var violations []string
+	subject, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	subject = strings.TrimSpace(subject)
+
+	if rules.MaxSubjectLength > 0 {
+		if n := utf8.RuneCountInString(subject); n > rules.MaxSubjectLength {
+			violations = append(violations, fmt.Sprintf("subject is %d characters long; the limit is %d", n, rules.MaxSubjectLength))
+		}
+	}
+
+	if rules.SubjectPattern != "" {
+		re, err := regexp.Compile(rules.SubjectPattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid commit.subject_pattern in config: %w", err)
+		}
+		if !re.MatchString(subject) {
+			violations = append(violations, fmt.Sprintf("subject does not match the pattern %q", rules.SubjectPattern))
+		}
+	}
+
+	if len(rules.RequiredTrailers) > 0 {
+		trailers := parseTrailers(message)
+		for _, key := range rules.RequiredTrailers {
+			if trailers[strings.ToLower(key)] == "" {
+				violations = append(violations, fmt.Sprintf("missing required trailer '%s: <value>'", key))
+			}
+		}
+	}
+
+	return violations, nil
+}
+
+// Trailer is a "Key: value" line of a commit message's trailer block
+type Trailer struct {
+	Key   string
+	Value string
+}
+
+// parseTrailers returns the trailers of message, keyed by lowercased key
+func parseTrailers(message string) map[string]string {
+	trailers := make(map[string]string)
+	for _, t := range ParseTrailers(message) {
+		trailers[strings.ToLower(t.Key)] = t.Value
+	}
+	return trailers
+}
+
+// ParseTrailers returns the trailers of message in order, keys as written.
+// As in git, trailers are the lines of the last paragraph, which must not
+// be the subject and must consist only of "Key: value" lines.
+func ParseTrailers(message string) []Trailer {
+	paragraphs := strings.Split(strings.TrimSpace(strings.ReplaceAll(message, "\r\n", "\n")), "\n\n")
+	if len(paragraphs) < 2 {
+		return nil
+	}
+
+	var trailers []Trailer
+	last := paragraphs[len(paragraphs)-1]
+	for _, line := range strings.Split(last, "\n") {
+		m := trailerLine.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			return nil
+		}
+		trailers = append(trailers, Trailer{Key: m[1], Value: strings.TrimSpace(m[2])})
+	}
+	return trailers
+}
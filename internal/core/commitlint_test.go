@@ -0,0 +1,69 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kilupskalvis/wvc/internal/config"
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLintCommitMessage(t *testing.T) {
+	rules := &config.CommitRules{
+		MaxSubjectLength: 30,
+		SubjectPattern:   `^(data|schema): `,
+		RequiredTrailers: []string{"Ticket", "Model"},
+	}
+
+	tests := []struct {
+		name       string
+		message    string
+		violations int
+	}{
+		{"valid", "data: add articles\n\nImported from feed.\n\nTicket: DATA-12\nModel: ada-002", 0},
+		{"trailer keys are case-insensitive", "data: add articles\n\nticket: DATA-12\nmodel: ada-002", 0},
+		{"long subject", "data: add a great many articles from the feed\n\nTicket: DATA-12\nModel: ada-002", 1},
+		{"pattern mismatch", "add articles\n\nTicket: DATA-12\nModel: ada-002", 1},
+		{"missing trailer", "data: add articles\n\nTicket: DATA-12", 1},
+		{"empty trailer value", "data: add articles\n\nTicket:\nModel: ada-002", 1},
+		{"trailers need their own paragraph", "data: add articles\nTicket: DATA-12\nModel: ada-002", 2},
+		{"mixed last paragraph is not trailers", "data: add articles\n\nSee Ticket: DATA-12 for context\nModel: ada-002", 2},
+		{"subject only", "data: add articles", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations, err := LintCommitMessage(rules, tt.message)
+			require.NoError(t, err)
+			assert.Len(t, violations, tt.violations, "%v", violations)
+		})
+	}
+}
+
+func TestLintCommitMessage_InvalidPattern(t *testing.T) {
+	_, err := LintCommitMessage(&config.CommitRules{SubjectPattern: "("}, "msg")
+	assert.ErrorContains(t, err, "invalid commit.subject_pattern")
+}
+
+func TestCreateCommit_EnforcesMessageRules(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	cfg.Commit = &config.CommitRules{RequiredTrailers: []string{"Ticket"}}
+	client := weaviate.NewMockClient()
+	client.AddClass(&models.WeaviateClass{Class: "Article"})
+	client.AddObject(&models.WeaviateObject{ID: "obj-001", Class: "Article", Properties: map[string]interface{}{"title": "First"}})
+
+	_, err := CreateCommit(ctx, cfg, st, client, "Add article")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "missing required trailer 'Ticket: <value>'")
+
+	head, err := st.GetHEAD()
+	require.NoError(t, err)
+	assert.Empty(t, head)
+
+	_, err = CreateCommit(ctx, cfg, st, client, "Add article\n\nTicket: DATA-1")
+	require.NoError(t, err)
+}
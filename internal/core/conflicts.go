@@ -0,0 +1,169 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/store"
+)
+
+// ConflictProperty compares one property across the three versions of a
+// conflicted object. A value is nil when the property, or the whole object,
+// is absent from that version.
+type ConflictProperty struct {
+	Name          string
+	Base          interface{}
+	Ours          interface{}
+	Theirs        interface{}
+	OursChanged   bool // ours differs from base
+	TheirsChanged bool // theirs differs from base
+}
+
+// Conflicting reports whether both sides changed the property differently
+func (p ConflictProperty) Conflicting() bool {
+	return p.OursChanged && p.TheirsChanged && !sameValue(p.Ours, p.Theirs)
+}
+
+// MergeConflicts returns the in-progress merge and its conflicts; with an
+// object ("Class/ID" or a bare object ID) only that conflict is returned.
+func MergeConflicts(st *store.Store, object string) (*models.MergeState, []*models.MergeConflict, error) {
+	state, err := st.GetMergeState()
+	if err != nil {
+		return nil, nil, err
+	}
+	if state == nil {
+		return nil, nil, fmt.Errorf("no merge in progress")
+	}
+	if object == "" {
+		return state, state.Conflicts, nil
+	}
+	match, err := findConflict(state, object)
+	if err != nil {
+		return nil, nil, err
+	}
+	return state, []*models.MergeConflict{match}, nil
+}
+
+// ConflictProperties lines up the properties of a conflict's base, ours, and
+// theirs versions, sorted by name
+func ConflictProperties(c *models.MergeConflict) []ConflictProperty {
+	names := make(map[string]bool)
+	for _, obj := range []*models.WeaviateObject{c.Base, c.Ours, c.Theirs} {
+		if obj == nil {
+			continue
+		}
+		for name := range obj.Properties {
+			names[name] = true
+		}
+	}
+
+	rows := make([]ConflictProperty, 0, len(names))
+	for _, name := range sortedKeys(names) {
+		row := ConflictProperty{
+			Name:   name,
+			Base:   propertyValue(c.Base, name),
+			Ours:   propertyValue(c.Ours, name),
+			Theirs: propertyValue(c.Theirs, name),
+		}
+		row.OursChanged = !sameValue(row.Base, row.Ours)
+		row.TheirsChanged = !sameValue(row.Base, row.Theirs)
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// ConflictEditTemplate returns the starting properties for hand-editing a
+// conflict: ours, with the properties only they changed taken from theirs.
+// Properties both sides changed keep our value.
+func ConflictEditTemplate(c *models.MergeConflict) map[string]interface{} {
+	props := make(map[string]interface{})
+	for _, row := range ConflictProperties(c) {
+		value := row.Ours
+		if c.Ours == nil || (row.TheirsChanged && !row.OursChanged) {
+			value = row.Theirs
+		}
+		if value != nil {
+			props[row.Name] = value
+		}
+	}
+	return props
+}
+
+// MergeResolveEdit resolves one conflict of the in-progress merge with
+// hand-edited properties. The resolved object keeps our vector, or theirs
+// when we deleted the object.
+func MergeResolveEdit(st *store.Store, object string, properties map[string]interface{}) (*models.MergeConflict, error) {
+	state, err := st.GetMergeState()
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		return nil, fmt.Errorf("no merge in progress")
+	}
+
+	match, err := findConflict(state, object)
+	if err != nil {
+		return nil, err
+	}
+	if properties == nil {
+		properties = map[string]interface{}{}
+	}
+
+	if state.Resolutions == nil {
+		state.Resolutions = make(map[string]models.ConflictStrategy)
+	}
+	if state.Edits == nil {
+		state.Edits = make(map[string]*models.WeaviateObject)
+	}
+	state.Resolutions[match.Key] = models.ConflictEdited
+	state.Edits[match.Key] = &models.WeaviateObject{
+		ID:         match.ObjectID,
+		Class:      match.ClassName,
+		Properties: properties,
+	}
+	if err := st.SaveMergeState(state); err != nil {
+		return nil, fmt.Errorf("failed to save merge state: %w", err)
+	}
+	return match, nil
+}
+
+// findConflict finds a conflict of the merge by key ("Class/ID") or bare object ID
+func findConflict(state *models.MergeState, object string) (*models.MergeConflict, error) {
+	var match *models.MergeConflict
+	for _, c := range state.Conflicts {
+		if c.Key == object || c.ObjectID == object {
+			if match != nil {
+				return nil, fmt.Errorf("object ID %s is ambiguous; use <class>/<id>", object)
+			}
+			match = c
+		}
+	}
+	if match == nil {
+		return nil, fmt.Errorf("no conflict on %s", object)
+	}
+	return match, nil
+}
+
+// editedVectorHash is the vector kept by an edited resolution
+func editedVectorHash(c *models.MergeConflict) string {
+	if c.Ours != nil {
+		return c.OursVectorHash
+	}
+	return c.TheirsVectorHash
+}
+
+func propertyValue(obj *models.WeaviateObject, name string) interface{} {
+	if obj == nil {
+		return nil
+	}
+	return obj.Properties[name]
+}
+
+// sameValue compares property values by their JSON encoding, so numbers
+// decoded as different Go types still compare equal
+func sameValue(a, b interface{}) bool {
+	aJSON, errA := json.Marshal(a)
+	bJSON, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(aJSON) == string(bJSON)
+}
@@ -0,0 +1,81 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConflictProperties_MarksChangedSides(t *testing.T) {
+	conflict := &models.MergeConflict{
+		Key:    "Article/obj-001",
+		Type:   models.ConflictModifyModify,
+		Base:   &models.WeaviateObject{Properties: map[string]interface{}{"title": "Base", "views": 1.0, "tag": "a"}},
+		Ours:   &models.WeaviateObject{Properties: map[string]interface{}{"title": "Ours", "views": 1.0, "tag": "a"}},
+		Theirs: &models.WeaviateObject{Properties: map[string]interface{}{"title": "Theirs", "views": 2.0}},
+	}
+
+	rows := ConflictProperties(conflict)
+	require.Len(t, rows, 3)
+
+	assert.Equal(t, "tag", rows[0].Name)
+	assert.False(t, rows[0].OursChanged)
+	assert.True(t, rows[0].TheirsChanged)
+	assert.Nil(t, rows[0].Theirs)
+	assert.False(t, rows[0].Conflicting())
+
+	assert.Equal(t, "title", rows[1].Name)
+	assert.True(t, rows[1].Conflicting())
+
+	assert.Equal(t, "views", rows[2].Name)
+	assert.False(t, rows[2].OursChanged)
+	assert.True(t, rows[2].TheirsChanged)
+
+	// The edit template keeps ours and takes the properties only they changed
+	template := ConflictEditTemplate(conflict)
+	assert.Equal(t, map[string]interface{}{"title": "Ours", "views": 2.0}, template)
+}
+
+func TestMergeResolveEdit_ContinueAppliesEditedObject(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+	setupConflictingBranches(t, ctx, cfg, st, client)
+
+	result, err := Merge(ctx, cfg, st, client, "feature", models.MergeOptions{})
+	require.NoError(t, err)
+	require.False(t, result.Success)
+
+	_, conflicts, err := MergeConflicts(st, "obj-001")
+	require.NoError(t, err)
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, "Article/obj-001", conflicts[0].Key)
+
+	_, err = MergeResolveEdit(st, "obj-001", map[string]interface{}{"title": "Combined version"})
+	require.NoError(t, err)
+	_, err = MergeResolve(st, "obj-002", models.ConflictTheirs)
+	require.NoError(t, err)
+
+	state, err := st.GetMergeState()
+	require.NoError(t, err)
+	assert.Equal(t, models.ConflictEdited, state.Resolutions["Article/obj-001"])
+
+	result, err = MergeContinue(ctx, cfg, st, client, "")
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, 2, result.ResolvedConflicts)
+
+	assert.Equal(t, "Combined version", client.Objects["Article/obj-001"].Properties["title"])
+	assert.Equal(t, "Feature version", client.Objects["Article/obj-002"].Properties["title"])
+}
+
+func TestMergeConflicts_NoMerge(t *testing.T) {
+	st := newTestStore(t)
+	_, _, err := MergeConflicts(st, "")
+	assert.ErrorContains(t, err, "no merge in progress")
+}
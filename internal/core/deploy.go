@@ -0,0 +1,104 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/remote"
+	"github.com/kilupskalvis/wvc/internal/store"
+)
+
+// ValidateEnvironmentName checks that env can name a deployment environment
+// locally and on a remote: letters, digits, '.', '-' and '_', not starting
+// with '.'.
+func ValidateEnvironmentName(env string) error {
+	if env == "" || len(env) > 64 || env[0] == '.' {
+		return fmt.Errorf("invalid environment name '%s'", env)
+	}
+	for _, r := range env {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+		default:
+			return fmt.Errorf("invalid environment name '%s'", env)
+		}
+	}
+	return nil
+}
+
+// RecordDeployment records the commit ref resolves to as deployed to env.
+// When ref names a local branch, or is HEAD on a branch, the environment
+// tracks that branch.
+func RecordDeployment(st *store.Store, env, ref string) (*models.Deployment, error) {
+	if err := ValidateEnvironmentName(env); err != nil {
+		return nil, err
+	}
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	commitID, branch, err := ResolveRef(st, ref)
+	if err != nil {
+		return nil, err
+	}
+	if ref == "HEAD" {
+		if branch, err = st.GetCurrentBranch(); err != nil {
+			return nil, fmt.Errorf("get current branch: %w", err)
+		}
+	}
+
+	d := &models.Deployment{
+		Environment: env,
+		CommitID:    commitID,
+		Branch:      branch,
+		RecordedAt:  time.Now(),
+	}
+	if err := st.PutDeployment(d); err != nil {
+		return nil, fmt.Errorf("record deployment: %w", err)
+	}
+	return d, nil
+}
+
+// DeploymentsTrackingBranch returns the environments recorded as deployed
+// from branch.
+func DeploymentsTrackingBranch(st *store.Store, branch string) ([]*models.Deployment, error) {
+	all, err := st.ListDeployments()
+	if err != nil {
+		return nil, fmt.Errorf("list deployments: %w", err)
+	}
+	var tracking []*models.Deployment
+	for _, d := range all {
+		if branch != "" && d.Branch == branch {
+			tracking = append(tracking, d)
+		}
+	}
+	return tracking, nil
+}
+
+// PublishDeployment records a deployment on the remote. The commit must
+// already have been pushed.
+func PublishDeployment(ctx context.Context, client remote.RemoteClient, d *models.Deployment) error {
+	if err := client.RecordDeployment(ctx, d.Environment, d.CommitID); err != nil {
+		return fmt.Errorf("publish deployment: %w", err)
+	}
+	return nil
+}
+
+// ListRemoteDeployments returns the deployments recorded on the remote,
+// sorted by environment. Remote deployments carry no branch or time.
+func ListRemoteDeployments(ctx context.Context, client remote.RemoteClient) ([]*models.Deployment, error) {
+	refs, err := client.ListDeployments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	deployments := make([]*models.Deployment, 0, len(refs))
+	for _, ref := range refs {
+		deployments = append(deployments, &models.Deployment{Environment: ref.Name, CommitID: ref.CommitID})
+	}
+	sort.Slice(deployments, func(i, j int) bool {
+		return deployments[i].Environment < deployments[j].Environment
+	})
+	return deployments, nil
+}
@@ -0,0 +1,61 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordDeployment(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+
+	client.AddClass(&models.WeaviateClass{Class: "Article"})
+	client.AddObject(&models.WeaviateObject{ID: "obj-1", Class: "Article"})
+	first, err := CreateCommit(ctx, cfg, st, client, "First")
+	require.NoError(t, err)
+	require.NoError(t, st.SetCurrentBranch("main"))
+	require.NoError(t, st.CreateBranch("main", first.ID))
+
+	// HEAD on a branch tracks the branch
+	d, err := RecordDeployment(st, "staging", "")
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, d.CommitID)
+	assert.Equal(t, "main", d.Branch)
+
+	// A commit ID tracks no branch
+	d, err = RecordDeployment(st, "prod", first.ShortID())
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, d.CommitID)
+	assert.Empty(t, d.Branch)
+
+	tracking, err := DeploymentsTrackingBranch(st, "main")
+	require.NoError(t, err)
+	require.Len(t, tracking, 1)
+	assert.Equal(t, "staging", tracking[0].Environment)
+
+	_, err = RecordDeployment(st, "prod/eu", "HEAD")
+	assert.Error(t, err)
+	_, err = RecordDeployment(st, "prod", "nope")
+	assert.Error(t, err)
+}
+
+func TestPublishDeployment(t *testing.T) {
+	ctx := context.Background()
+	client := newPushMockClient()
+
+	require.NoError(t, PublishDeployment(ctx, client, &models.Deployment{Environment: "prod", CommitID: "c2"}))
+	require.NoError(t, PublishDeployment(ctx, client, &models.Deployment{Environment: "dev", CommitID: "c1"}))
+
+	deployments, err := ListRemoteDeployments(ctx, client)
+	require.NoError(t, err)
+	require.Len(t, deployments, 2)
+	assert.Equal(t, "dev", deployments[0].Environment)
+	assert.Equal(t, "c2", deployments[1].CommitID)
+}
@@ -0,0 +1,243 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/kilupskalvis/wvc/internal/config"
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/store"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// determinismRuns is how many times each computation is repeated; Go
+// randomizes map iteration, so a map-ordered result would differ across runs
+const determinismRuns = 20
+
+var determinismClasses = []string{"Article", "Author", "Category"}
+
+// seedDivergentBranches builds a repository whose main and feature branches
+// both changed many objects of several classes since their merge base,
+// including conflicting edits, and leaves main checked out.
+func seedDivergentBranches(t *testing.T, ctx context.Context, cfg *config.Config, st *store.Store, client *weaviate.MockClient) {
+	t.Helper()
+
+	for _, class := range determinismClasses {
+		client.AddClass(&models.WeaviateClass{Class: class})
+		for i := 0; i < 10; i++ {
+			client.AddObject(&models.WeaviateObject{
+				ID:         fmt.Sprintf("obj-%02d", i),
+				Class:      class,
+				Properties: map[string]interface{}{"title": "base"},
+			})
+		}
+	}
+	_, err := CreateCommit(ctx, cfg, st, client, "Base")
+	require.NoError(t, err)
+	require.NoError(t, CreateBranch(st, "feature", ""))
+
+	editBranch := func(label string, edit, remove []int, add int) {
+		for _, class := range determinismClasses {
+			for _, i := range edit {
+				client.Objects[models.ObjectKey(class, fmt.Sprintf("obj-%02d", i))].Properties["title"] = label
+			}
+			for _, i := range remove {
+				delete(client.Objects, models.ObjectKey(class, fmt.Sprintf("obj-%02d", i)))
+			}
+			for i := 0; i < add; i++ {
+				client.AddObject(&models.WeaviateObject{
+					ID:         fmt.Sprintf("%s-new-%02d", label, i),
+					Class:      class,
+					Properties: map[string]interface{}{"title": label},
+				})
+			}
+		}
+		_, err := CreateCommit(ctx, cfg, st, client, label)
+		require.NoError(t, err)
+	}
+
+	editBranch("main", []int{0, 1, 2, 3, 4}, []int{9}, 3)
+
+	_, err = Checkout(ctx, cfg, st, client, "feature", CheckoutOptions{})
+	require.NoError(t, err)
+	editBranch("feature", []int{2, 3, 4, 5, 6}, []int{8}, 4)
+
+	_, err = Checkout(ctx, cfg, st, client, "main", CheckoutOptions{})
+	require.NoError(t, err)
+}
+
+// assertIdenticalRuns runs compute repeatedly and requires every run to
+// serialize to the same bytes
+func assertIdenticalRuns(t *testing.T, compute func() interface{}) {
+	t.Helper()
+	first, err := json.Marshal(compute())
+	require.NoError(t, err)
+	for run := 1; run < determinismRuns; run++ {
+		again, err := json.Marshal(compute())
+		require.NoError(t, err)
+		require.Equal(t, string(first), string(again), "run %d differs from run 0", run)
+	}
+}
+
+func changeKeys(changes []*ObjectChange) []string {
+	keys := make([]string, 0, len(changes))
+	for _, c := range changes {
+		keys = append(keys, models.ObjectKey(c.ClassName, c.ObjectID))
+	}
+	return keys
+}
+
+func TestDeterminism_ComputeDiff(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+	seedDivergentBranches(t, ctx, cfg, st, client)
+
+	// Uncommitted changes across every class
+	for _, class := range determinismClasses {
+		client.Objects[models.ObjectKey(class, "obj-07")].Properties["title"] = "dirty"
+		delete(client.Objects, models.ObjectKey(class, "obj-06"))
+		client.AddObject(&models.WeaviateObject{ID: "dirty-new", Class: class, Properties: map[string]interface{}{"title": "dirty"}})
+	}
+
+	assertIdenticalRuns(t, func() interface{} {
+		diff, err := ComputeDiff(ctx, cfg, st, client)
+		require.NoError(t, err)
+		return diff
+	})
+	assertIdenticalRuns(t, func() interface{} {
+		diff, err := ComputeIncrementalDiff(ctx, cfg, st, client)
+		require.NoError(t, err)
+		return diff
+	})
+
+	diff, err := ComputeDiff(ctx, cfg, st, client)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Article/dirty-new", "Author/dirty-new", "Category/dirty-new"}, changeKeys(diff.Inserted))
+	assert.Equal(t, []string{"Article/obj-06", "Author/obj-06", "Category/obj-06"}, changeKeys(diff.Deleted))
+}
+
+func TestDeterminism_MergeConflicts(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+	seedDivergentBranches(t, ctx, cfg, st, client)
+
+	ourHead, err := st.GetHEAD()
+	require.NoError(t, err)
+	feature, err := st.GetBranch("feature")
+	require.NoError(t, err)
+	mergeBase, err := FindMergeBase(st, ourHead, feature.CommitID)
+	require.NoError(t, err)
+
+	assertIdenticalRuns(t, func() interface{} {
+		states, err := loadMergeStates(cfg, st, mergeBase, ourHead, feature.CommitID)
+		require.NoError(t, err)
+		return detectObjectConflicts(states)
+	})
+
+	// The persisted conflict list of a stopped merge is ordered by key
+	result, err := Merge(ctx, cfg, st, client, "feature", models.MergeOptions{})
+	require.NoError(t, err)
+	require.False(t, result.Success)
+	state, err := st.GetMergeState()
+	require.NoError(t, err)
+	var keys []string
+	for _, c := range state.Conflicts {
+		keys = append(keys, c.Key)
+	}
+	assert.Equal(t, []string{
+		"Article/obj-02", "Article/obj-03", "Article/obj-04",
+		"Author/obj-02", "Author/obj-03", "Author/obj-04",
+		"Category/obj-02", "Category/obj-03", "Category/obj-04",
+	}, keys)
+}
+
+func TestDeterminism_MergeOperationOrder(t *testing.T) {
+	ctx := context.Background()
+
+	// The same merge performed in independent repositories must record its
+	// operations, and apply its writes, in the same order
+	mergeOps := func() interface{} {
+		st := newTestStore(t)
+		cfg := newTestConfig()
+		client := weaviate.NewMockClient()
+		seedDivergentBranches(t, ctx, cfg, st, client)
+
+		result, err := Merge(ctx, cfg, st, client, "feature", models.MergeOptions{Strategy: models.ConflictTheirs})
+		require.NoError(t, err)
+		require.True(t, result.Success)
+
+		ops, err := st.GetOperationsByCommit(result.MergeCommit.ID)
+		require.NoError(t, err)
+		sequence := make([]string, 0, len(ops))
+		for _, op := range ops {
+			sequence = append(sequence, fmt.Sprintf("%d %s %s", op.Seq, op.Type, models.ObjectKey(op.ClassName, op.ObjectID)))
+		}
+		return sequence
+	}
+
+	assertIdenticalRuns(t, mergeOps)
+}
+
+func TestDeterminism_SchemaDiff(t *testing.T) {
+	previous := &models.WeaviateSchema{}
+	current := &models.WeaviateSchema{}
+	for i := 0; i < 10; i++ {
+		prev := &models.WeaviateClass{Class: fmt.Sprintf("Old%02d", i)}
+		curr := &models.WeaviateClass{Class: fmt.Sprintf("New%02d", i)}
+		shared := &models.WeaviateClass{Class: fmt.Sprintf("Shared%02d", i)}
+		sharedNext := &models.WeaviateClass{Class: shared.Class}
+		for p := 0; p < 5; p++ {
+			shared.Properties = append(shared.Properties, &models.WeaviateProperty{Name: fmt.Sprintf("old%d", p), DataType: []string{"text"}})
+			sharedNext.Properties = append(sharedNext.Properties, &models.WeaviateProperty{Name: fmt.Sprintf("new%d", p), DataType: []string{"text"}})
+		}
+		previous.Classes = append(previous.Classes, prev, shared)
+		current.Classes = append(current.Classes, curr, sharedNext)
+	}
+
+	assertIdenticalRuns(t, func() interface{} {
+		return diffSchemas(current, previous)
+	})
+}
+
+func TestDeterminism_StateRestoreOrder(t *testing.T) {
+	ctx := context.Background()
+
+	// Restoring a commit issues its Weaviate writes in key order
+	writeOrder := func() interface{} {
+		st := newTestStore(t)
+		cfg := newTestConfig()
+		client := weaviate.NewMockClient()
+		seedDivergentBranches(t, ctx, cfg, st, client)
+
+		var failed []string
+		client.WriteErrs = make(map[string]error)
+		for key := range client.Objects {
+			client.WriteErrs[key] = fmt.Errorf("unavailable")
+		}
+		for _, class := range determinismClasses {
+			for _, id := range []string{"feature-new-00", "feature-new-01", "obj-08"} {
+				client.WriteErrs[models.ObjectKey(class, id)] = fmt.Errorf("unavailable")
+			}
+		}
+		_, err := Checkout(ctx, cfg, st, client, "feature", CheckoutOptions{})
+		require.NoError(t, err)
+
+		progress, err := st.GetApplyProgress()
+		require.NoError(t, err)
+		require.NotNil(t, progress)
+		for _, f := range progress.Failed {
+			failed = append(failed, string(f.Action)+" "+f.Key())
+		}
+		return failed
+	}
+
+	assertIdenticalRuns(t, writeOrder)
+}
@@ -5,6 +5,10 @@ package core
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/kilupskalvis/wvc/internal/config"
@@ -29,6 +33,8 @@ type ObjectChange struct {
 	VectorHash         string // Current vector hash
 	PreviousVectorHash string // Previous vector hash (for updates)
 	VectorOnly         bool   // True if only the vector changed (properties unchanged)
+	MovedFrom          string // Source key ("Class/ID") when this insert is the result of a move
+	MovedTo            string // Destination key when this delete is the result of a move
 }
 
 // TotalChanges returns the total number of changes
@@ -36,80 +42,166 @@ func (d *DiffResult) TotalChanges() int {
 	return len(d.Inserted) + len(d.Updated) + len(d.Deleted)
 }
 
-// ComputeDiff computes the difference between current Weaviate state and last known state
-func ComputeDiff(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface) (*DiffResult, error) {
-	result := &DiffResult{
+// Sort orders each change list by class name and then object ID, so output
+// and the operations recorded from a diff do not depend on map iteration order.
+func (d *DiffResult) Sort() {
+	for _, changes := range [][]*ObjectChange{d.Inserted, d.Updated, d.Deleted} {
+		sort.Slice(changes, func(i, j int) bool {
+			if changes[i].ClassName != changes[j].ClassName {
+				return changes[i].ClassName < changes[j].ClassName
+			}
+			return changes[i].ObjectID < changes[j].ObjectID
+		})
+	}
+}
+
+// newDiffResult returns an empty DiffResult.
+func newDiffResult() *DiffResult {
+	return &DiffResult{
 		Inserted: make([]*ObjectChange, 0),
 		Updated:  make([]*ObjectChange, 0),
 		Deleted:  make([]*ObjectChange, 0),
 	}
+}
+
+// append adds the changes of other to d.
+func (d *DiffResult) append(other *DiffResult) {
+	d.Inserted = append(d.Inserted, other.Inserted...)
+	d.Updated = append(d.Updated, other.Updated...)
+	d.Deleted = append(d.Deleted, other.Deleted...)
+}
+
+// compare records current as inserted when known is nil, or as updated when
+// its properties or vector differ from known. hashes is the cache of the
+// instance current was read from.
+func (d *DiffResult) compare(current *models.WeaviateObject, known *models.KnownObjectInfo, hashes *weaviate.HashCache) {
+	// Compute current hashes
+	currentObjHash, currentVecHash := hashes.HashObjectFull(current)
+
+	if known == nil {
+		// New object
+		d.Inserted = append(d.Inserted, &ObjectChange{
+			ClassName:   current.TenantClass(),
+			ObjectID:    current.ID,
+			CurrentData: current,
+			VectorHash:  currentVecHash,
+		})
+		return
+	}
 
-	// Determine pagination method based on server version
-	useCursor := cfg.SupportsCursorPagination()
+	// Check if updated (either properties or vector)
+	propsChanged := currentObjHash != known.ObjectHash
+	vectorChanged := currentVecHash != known.VectorHash
+
+	if propsChanged || vectorChanged {
+		d.Updated = append(d.Updated, &ObjectChange{
+			ClassName:          current.TenantClass(),
+			ObjectID:           current.ID,
+			CurrentData:        current,
+			PreviousData:       known.Object,
+			VectorHash:         currentVecHash,
+			PreviousVectorHash: known.VectorHash,
+			VectorOnly:         !propsChanged && vectorChanged,
+		})
+	}
+}
+
+// ComputeDiff computes the difference between current Weaviate state and last known state
+func ComputeDiff(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface) (*DiffResult, error) {
+	result := newDiffResult()
 
-	// Get current state from Weaviate
-	currentObjects, err := client.GetAllObjectsAllClasses(ctx, useCursor)
+	// Class-scoped branches only see changes to their own classes
+	scope, err := currentScope(st)
 	if err != nil {
 		return nil, err
 	}
 
-	// Get last known state from store with hashes
-	knownObjects, err := st.GetAllKnownObjectsWithHashes()
+	err = walkDiff(ctx, cfg, st, client, scope, nil, func(changes *DiffResult) error {
+		result.append(changes)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Find inserted and updated objects
-	for key, current := range currentObjects {
-		// Compute current hashes
-		currentObjHash, currentVecHash := weaviate.HashObjectFull(current)
+	if err := annotateStagedMoves(st, result); err != nil {
+		return nil, err
+	}
+	result.Sort()
 
-		known, exists := knownObjects[key]
-		if !exists {
-			// New object
+	return result, nil
+}
+
+// DiffCommits computes the object changes from the state at commit fromID to
+// the state at commit toID.
+func DiffCommits(st *store.Store, fromID, toID string) (*DiffResult, error) {
+	from, err := reconstructStateAtCommit(st, fromID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := reconstructStateAtCommit(st, toID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := newDiffResult()
+	for key, current := range to {
+		obj := current.Object
+		previous, ok := from[key]
+		if !ok {
 			result.Inserted = append(result.Inserted, &ObjectChange{
-				ClassName:   current.Class,
-				ObjectID:    current.ID,
-				CurrentData: current,
-				VectorHash:  currentVecHash,
+				ClassName:   obj.TenantClass(),
+				ObjectID:    obj.ID,
+				CurrentData: obj,
+				VectorHash:  current.VectorHash,
+			})
+			continue
+		}
+		currentHash, _ := storedHashes.HashObjectFull(obj)
+		previousHash, _ := storedHashes.HashObjectFull(previous.Object)
+		propsChanged := currentHash != previousHash
+		vectorChanged := current.VectorHash != previous.VectorHash ||
+			!maps.Equal(current.NamedVectorHashes, previous.NamedVectorHashes)
+		if propsChanged || vectorChanged {
+			result.Updated = append(result.Updated, &ObjectChange{
+				ClassName:          obj.TenantClass(),
+				ObjectID:           obj.ID,
+				CurrentData:        obj,
+				PreviousData:       previous.Object,
+				VectorHash:         current.VectorHash,
+				PreviousVectorHash: previous.VectorHash,
+				VectorOnly:         !propsChanged,
 			})
-		} else {
-			// Check if updated (either properties or vector)
-			propsChanged := currentObjHash != known.ObjectHash
-			vectorChanged := currentVecHash != known.VectorHash
-
-			if propsChanged || vectorChanged {
-				result.Updated = append(result.Updated, &ObjectChange{
-					ClassName:          current.Class,
-					ObjectID:           current.ID,
-					CurrentData:        current,
-					PreviousData:       known.Object,
-					VectorHash:         currentVecHash,
-					PreviousVectorHash: known.VectorHash,
-					VectorOnly:         !propsChanged && vectorChanged,
-				})
-			}
 		}
 	}
-
-	// Find deleted objects
-	for key, known := range knownObjects {
-		if _, exists := currentObjects[key]; !exists {
+	for key, previous := range from {
+		if _, ok := to[key]; !ok {
 			result.Deleted = append(result.Deleted, &ObjectChange{
-				ClassName:          known.Object.Class,
-				ObjectID:           known.Object.ID,
-				PreviousData:       known.Object,
-				PreviousVectorHash: known.VectorHash,
+				ClassName:          previous.Object.TenantClass(),
+				ObjectID:           previous.Object.ID,
+				PreviousData:       previous.Object,
+				PreviousVectorHash: previous.VectorHash,
 			})
 		}
 	}
-
+	result.Sort()
 	return result, nil
 }
 
+// ResolveCommitRange resolves "<from>..<to>" to commit IDs; <to> defaults
+// to HEAD.
+func ResolveCommitRange(st *store.Store, r string) (fromID, toID string, err error) {
+	from, _, ok := strings.Cut(r, "..")
+	if !ok || from == "" {
+		return "", "", fmt.Errorf("invalid range '%s': expected <from>..<to>", r)
+	}
+	return resolveFindRange(st, r)
+}
+
 // RecordDiffAsOperations records diff changes as operations in the store
 func RecordDiffAsOperations(st *store.Store, diff *DiffResult) error {
 	now := time.Now()
+	ops := make([]*models.Operation, 0, len(diff.Inserted)+len(diff.Updated)+len(diff.Deleted))
 
 	// Record inserts
 	for _, change := range diff.Inserted {
@@ -121,17 +213,21 @@ func RecordDiffAsOperations(st *store.Store, diff *DiffResult) error {
 			return err
 		}
 
-		op := &models.Operation{
-			Timestamp:  now,
-			Type:       models.OperationInsert,
-			ClassName:  change.ClassName,
-			ObjectID:   change.ObjectID,
-			ObjectData: data,
-			VectorHash: vectorHash,
-		}
-		if err := st.RecordOperation(op); err != nil {
+		namedHashes, err := storeNamedVectors(st, change.CurrentData)
+		if err != nil {
 			return err
 		}
+
+		op := &models.Operation{
+			Timestamp:         now,
+			Type:              models.OperationInsert,
+			ClassName:         change.ClassName,
+			ObjectID:          change.ObjectID,
+			ObjectData:        data,
+			VectorHash:        vectorHash,
+			NamedVectorHashes: namedHashes,
+		}
+		ops = append(ops, op)
 	}
 
 	// Record updates
@@ -154,19 +250,25 @@ func RecordDiffAsOperations(st *store.Store, diff *DiffResult) error {
 			_ = st.IncrementVectorRefCount(previousVectorHash)
 		}
 
-		op := &models.Operation{
-			Timestamp:          now,
-			Type:               models.OperationUpdate,
-			ClassName:          change.ClassName,
-			ObjectID:           change.ObjectID,
-			ObjectData:         data,
-			PreviousData:       prevData,
-			VectorHash:         vectorHash,
-			PreviousVectorHash: previousVectorHash,
-		}
-		if err := st.RecordOperation(op); err != nil {
+		namedHashes, err := storeNamedVectors(st, change.CurrentData)
+		if err != nil {
 			return err
 		}
+		previousNamedHashes, _ := storeNamedVectors(st, change.PreviousData)
+
+		op := &models.Operation{
+			Timestamp:                 now,
+			Type:                      models.OperationUpdate,
+			ClassName:                 change.ClassName,
+			ObjectID:                  change.ObjectID,
+			ObjectData:                data,
+			PreviousData:              prevData,
+			VectorHash:                vectorHash,
+			PreviousVectorHash:        previousVectorHash,
+			NamedVectorHashes:         namedHashes,
+			PreviousNamedVectorHashes: previousNamedHashes,
+		}
+		ops = append(ops, op)
 	}
 
 	// Record deletes
@@ -181,20 +283,21 @@ func RecordDiffAsOperations(st *store.Store, diff *DiffResult) error {
 			_ = st.IncrementVectorRefCount(previousVectorHash)
 		}
 
+		previousNamedHashes, _ := storeNamedVectors(st, change.PreviousData)
+
 		op := &models.Operation{
-			Timestamp:          now,
-			Type:               models.OperationDelete,
-			ClassName:          change.ClassName,
-			ObjectID:           change.ObjectID,
-			PreviousData:       prevData,
-			PreviousVectorHash: previousVectorHash,
-		}
-		if err := st.RecordOperation(op); err != nil {
-			return err
+			Timestamp:                 now,
+			Type:                      models.OperationDelete,
+			ClassName:                 change.ClassName,
+			ObjectID:                  change.ObjectID,
+			PreviousData:              prevData,
+			PreviousVectorHash:        previousVectorHash,
+			PreviousNamedVectorHashes: previousNamedHashes,
 		}
+		ops = append(ops, op)
 	}
 
-	return nil
+	return st.RecordOperations(ops)
 }
 
 // storeVectorFromObject extracts vector from object, stores it, and returns hash
@@ -211,38 +314,105 @@ func storeVectorFromObject(st *store.Store, obj *models.WeaviateObject) (string,
 	return st.SaveVectorBlob(vectorBytes, dims)
 }
 
-// UpdateKnownState updates the known objects state to match current Weaviate state
+// storeNamedVectors stores each of the object's named vectors as a blob and
+// returns their hashes by name, or nil when it has none.
+func storeNamedVectors(st *store.Store, obj *models.WeaviateObject) (map[string]string, error) {
+	if obj == nil || len(obj.Vectors) == 0 {
+		return nil, nil
+	}
+	var hashes map[string]string
+	for _, name := range sortedKeys(obj.Vectors) {
+		vectorBytes, dims, err := store.VectorToBytes(obj.Vectors[name])
+		if err != nil || len(vectorBytes) == 0 {
+			continue
+		}
+		hash, err := st.SaveVectorBlob(vectorBytes, dims)
+		if err != nil {
+			return nil, err
+		}
+		if hashes == nil {
+			hashes = make(map[string]string, len(obj.Vectors))
+		}
+		hashes[name] = hash
+	}
+	return hashes, nil
+}
+
+// UpdateKnownState updates the known objects state to match current Weaviate
+// state. Objects are read and saved a page at a time.
 func UpdateKnownState(ctx context.Context, st *store.Store, client weaviate.ClientInterface, useCursor bool) error {
-	// Get current state from Weaviate
-	currentObjects, err := client.GetAllObjectsAllClasses(ctx, useCursor)
+	classes, err := versionedClasses(ctx, client)
 	if err != nil {
 		return err
 	}
 
-	// Clear and rebuild known objects
-	if err := st.ClearKnownObjects(); err != nil {
+	// Clear and rebuild known objects. On a class-scoped branch only its own
+	// classes are rebuilt, so other classes' uncommitted changes stay visible.
+	scope, err := currentScope(st)
+	if err != nil {
+		return err
+	}
+	if err := clearKnownObjects(st, scope); err != nil {
 		return err
 	}
 
-	for _, obj := range currentObjects {
-		objectHash, vectorHash := weaviate.HashObjectFull(obj)
-
-		// Store vector blob if present
-		if vectorHash != "" {
-			vectorBytes, dims, _ := store.VectorFromObject(obj)
-			if len(vectorBytes) > 0 {
-				storedHash, err := st.SaveVectorBlob(vectorBytes, dims)
-				if err == nil {
-					vectorHash = storedHash
+	for _, className := range classes {
+		if !scope.Includes(className) {
+			continue
+		}
+		err := client.IterateObjects(ctx, className, useCursor, func(batch []*models.WeaviateObject) error {
+			entries := make([]store.KnownObjectEntry, 0, len(batch))
+			for _, obj := range batch {
+				objectHash, vectorHash := client.HashCache().HashObjectFull(obj)
+
+				// Store vector blob if present
+				if vectorHash != "" {
+					vectorBytes, dims, _ := store.VectorFromObject(obj)
+					if len(vectorBytes) > 0 {
+						storedHash, err := st.SaveVectorBlob(vectorBytes, dims)
+						if err == nil {
+							vectorHash = storedHash
+						}
+					}
 				}
+
+				data, _ := json.Marshal(obj)
+				entries = append(entries, store.KnownObjectEntry{
+					ClassName:  obj.TenantClass(),
+					ObjectID:   obj.ID,
+					ObjectHash: objectHash,
+					VectorHash: vectorHash,
+					Data:       data,
+				})
 			}
+			return st.SaveKnownObjects(entries)
+		})
+		if err != nil {
+			return err
 		}
+	}
 
-		data, _ := json.Marshal(obj)
-		if err := st.SaveKnownObjectWithVector(obj.Class, obj.ID, objectHash, vectorHash, data); err != nil {
+	return nil
+}
+
+// clearKnownObjects removes the known objects covered by scope, of every
+// tenant of its classes. An exclusion scope clears them all: known objects
+// of excluded classes are never read.
+func clearKnownObjects(st *store.Store, scope ClassScope) error {
+	if scope == nil || scope.isExclusion() {
+		return st.ClearKnownObjects()
+	}
+	known, err := st.GetKnownClasses()
+	if err != nil {
+		return err
+	}
+	for _, className := range known {
+		if !scope.Includes(className) {
+			continue
+		}
+		if err := st.ClearKnownObjectsForClass(className); err != nil {
 			return err
 		}
 	}
-
 	return nil
 }
@@ -280,3 +280,36 @@ func TestUpdateKnownState(t *testing.T) {
 	require.NoError(t, err)
 	assert.Len(t, objects, 2)
 }
+
+func TestDiffCommits(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+
+	client.AddClass(&models.WeaviateClass{Class: "Article"})
+	client.AddObject(&models.WeaviateObject{ID: "obj-1", Class: "Article", Properties: map[string]interface{}{"title": "One"}})
+	client.AddObject(&models.WeaviateObject{ID: "obj-2", Class: "Article", Properties: map[string]interface{}{"title": "Two"}})
+	first, err := CreateCommit(ctx, cfg, st, client, "First")
+	require.NoError(t, err)
+
+	client.AddObject(&models.WeaviateObject{ID: "obj-1", Class: "Article", Properties: map[string]interface{}{"title": "One, edited"}})
+	require.NoError(t, client.DeleteObject(ctx, "Article", "obj-2"))
+	client.AddObject(&models.WeaviateObject{ID: "obj-3", Class: "Article", Properties: map[string]interface{}{"title": "Three"}})
+	_, err = CreateCommit(ctx, cfg, st, client, "Second")
+	require.NoError(t, err)
+
+	fromID, toID, err := ResolveCommitRange(st, first.ShortID()+"..")
+	require.NoError(t, err)
+	diff, err := DiffCommits(st, fromID, toID)
+	require.NoError(t, err)
+	require.Len(t, diff.Inserted, 1)
+	assert.Equal(t, "obj-3", diff.Inserted[0].ObjectID)
+	require.Len(t, diff.Updated, 1)
+	assert.Equal(t, "One", diff.Updated[0].PreviousData.Properties["title"])
+	require.Len(t, diff.Deleted, 1)
+	assert.Equal(t, "obj-2", diff.Deleted[0].ObjectID)
+
+	_, _, err = ResolveCommitRange(st, "HEAD")
+	assert.Error(t, err)
+}
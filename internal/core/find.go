@@ -0,0 +1,234 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/store"
+)
+
+// PropertyPredicate matches objects whose property equals a value. Values
+// are compared as text: strings as they are, other values as JSON, so
+// "count=3" and "published=true" match numbers and booleans. An array
+// property matches when any of its elements equals the value.
+type PropertyPredicate struct {
+	Name  string
+	Value string
+}
+
+// ParsePropertyPredicate parses a name=value predicate.
+func ParsePropertyPredicate(s string) (PropertyPredicate, error) {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return PropertyPredicate{}, fmt.Errorf("invalid property predicate '%s': expected name=value", s)
+	}
+	return PropertyPredicate{Name: name, Value: value}, nil
+}
+
+// Matches reports whether obj satisfies the predicate.
+func (p PropertyPredicate) Matches(obj *models.WeaviateObject) bool {
+	v, ok := obj.Properties[p.Name]
+	if !ok || v == nil {
+		return false
+	}
+	if list, ok := v.([]interface{}); ok {
+		for _, elem := range list {
+			if propertyText(elem) == p.Value {
+				return true
+			}
+		}
+		return false
+	}
+	return propertyText(v) == p.Value
+}
+
+func propertyText(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// FindOptions configures FindObjects.
+type FindOptions struct {
+	ClassName  string // empty searches all classes
+	Predicates []PropertyPredicate
+	// History searches the objects written by past commits instead of only
+	// the known state.
+	History bool
+	// Range bounds the history search as <from>..<to>: commits reachable
+	// from <to> (default HEAD) but not from <from>. It implies History.
+	Range string
+}
+
+// FindMatch is an object that satisfies the predicates.
+type FindMatch struct {
+	ClassName string
+	ObjectID  string
+	// Commits wrote a matching version of the object, oldest first. Only set
+	// for history searches.
+	Commits []*models.Commit
+	// Current is true when the object matches in the searched state: the
+	// known state, or the state at the end of the range.
+	Current bool
+}
+
+// FindObjects searches for objects whose properties match every predicate.
+// Results are sorted by class and object ID.
+func FindObjects(st *store.Store, opts FindOptions) ([]*FindMatch, error) {
+	if len(opts.Predicates) == 0 {
+		return nil, fmt.Errorf("at least one property predicate is required")
+	}
+	matches := func(obj *models.WeaviateObject) bool {
+		if opts.ClassName != "" && obj.Class != opts.ClassName && obj.TenantClass() != opts.ClassName {
+			return false
+		}
+		for _, p := range opts.Predicates {
+			if !p.Matches(obj) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if !opts.History && opts.Range == "" {
+		known, err := st.GetAllKnownObjects()
+		if err != nil {
+			return nil, fmt.Errorf("read known objects: %w", err)
+		}
+		var found []*FindMatch
+		for _, obj := range known {
+			if matches(obj) {
+				found = append(found, &FindMatch{ClassName: obj.TenantClass(), ObjectID: obj.ID, Current: true})
+			}
+		}
+		sortFindMatches(found)
+		return found, nil
+	}
+
+	fromID, toID, err := resolveFindRange(st, opts.Range)
+	if err != nil {
+		return nil, err
+	}
+	if toID == "" {
+		return nil, nil // no commits yet
+	}
+	excluded := map[string]bool{}
+	if fromID != "" {
+		if excluded, err = st.GetAllAncestors(fromID); err != nil {
+			return nil, err
+		}
+	}
+	path, err := getCommitPath(st, toID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Replay the history up to <to>, tracking which objects match, and note
+	// the commits in range that wrote a matching version
+	found := make(map[string]*FindMatch)
+	current := make(map[string]bool)
+	for _, commitID := range path {
+		ops, err := st.GetOperationsByCommit(commitID)
+		if err != nil {
+			return nil, fmt.Errorf("read operations of %s: %w", shortCommitID(commitID), err)
+		}
+		var commit *models.Commit
+		for _, op := range ops {
+			key := models.ObjectKey(op.ClassName, op.ObjectID)
+			switch op.Type {
+			case models.OperationInsert, models.OperationUpdate:
+				var obj models.WeaviateObject
+				if err := json.Unmarshal(op.ObjectData, &obj); err != nil || !matches(&obj) {
+					delete(current, key)
+					continue
+				}
+				current[key] = true
+				if excluded[commitID] {
+					continue
+				}
+				if commit == nil {
+					if commit, err = st.GetCommit(commitID); err != nil {
+						return nil, fmt.Errorf("get commit %s: %w", shortCommitID(commitID), err)
+					}
+				}
+				m := found[key]
+				if m == nil {
+					m = &FindMatch{ClassName: op.ClassName, ObjectID: op.ObjectID}
+					found[key] = m
+				}
+				if n := len(m.Commits); n == 0 || m.Commits[n-1].ID != commitID {
+					m.Commits = append(m.Commits, commit)
+				}
+			case models.OperationDelete:
+				delete(current, key)
+			}
+		}
+	}
+
+	// Objects that match at <to> but were last written before the range
+	// still appear in it
+	for key := range current {
+		if found[key] == nil {
+			className, objectID, _ := strings.Cut(key, "/")
+			found[key] = &FindMatch{ClassName: className, ObjectID: objectID}
+		}
+		found[key].Current = true
+	}
+
+	result := make([]*FindMatch, 0, len(found))
+	for _, m := range found {
+		result = append(result, m)
+	}
+	sortFindMatches(result)
+	return result, nil
+}
+
+// resolveFindRange resolves a <from>..<to> range to commit IDs. A range
+// without ".." names only <to>; <to> defaults to HEAD.
+func resolveFindRange(st *store.Store, r string) (string, string, error) {
+	from, to, isRange := strings.Cut(r, "..")
+	if !isRange {
+		from, to = "", r
+	}
+	if to == "" {
+		to = "HEAD"
+	}
+
+	var fromID string
+	if from != "" {
+		id, _, err := ResolveRef(st, from)
+		if err != nil {
+			return "", "", err
+		}
+		fromID = id
+	}
+	if to == "HEAD" {
+		head, err := st.GetHEAD()
+		if err != nil {
+			return "", "", fmt.Errorf("failed to get HEAD: %w", err)
+		}
+		return fromID, head, nil
+	}
+	toID, _, err := ResolveRef(st, to)
+	if err != nil {
+		return "", "", err
+	}
+	return fromID, toID, nil
+}
+
+func sortFindMatches(matches []*FindMatch) {
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].ClassName != matches[j].ClassName {
+			return matches[i].ClassName < matches[j].ClassName
+		}
+		return matches[i].ObjectID < matches[j].ObjectID
+	})
+}
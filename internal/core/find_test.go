@@ -0,0 +1,93 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPropertyPredicate_Matches(t *testing.T) {
+	obj := &models.WeaviateObject{Properties: map[string]interface{}{
+		"title": "Breaking News",
+		"count": float64(3),
+		"tags":  []interface{}{"news", "world"},
+	}}
+	for _, tc := range []struct {
+		predicate string
+		want      bool
+	}{
+		{"title=Breaking News", true},
+		{"title=breaking news", false},
+		{"count=3", true},
+		{"tags=world", true},
+		{"tags=sports", false},
+		{"missing=x", false},
+	} {
+		p, err := ParsePropertyPredicate(tc.predicate)
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, p.Matches(obj), tc.predicate)
+	}
+
+	_, err := ParsePropertyPredicate("title")
+	assert.Error(t, err)
+}
+
+func TestFindObjects_History(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+	breaking := PropertyPredicate{Name: "title", Value: "Breaking News"}
+
+	client.AddClass(&models.WeaviateClass{Class: "Article"})
+	client.AddObject(&models.WeaviateObject{ID: "obj-1", Class: "Article", Properties: map[string]interface{}{"title": "Breaking News"}})
+	client.AddObject(&models.WeaviateObject{ID: "obj-2", Class: "Article", Properties: map[string]interface{}{"title": "Weather"}})
+	first, err := CreateCommit(ctx, cfg, st, client, "Add articles")
+	require.NoError(t, err)
+
+	client.AddObject(&models.WeaviateObject{ID: "obj-1", Class: "Article", Properties: map[string]interface{}{"title": "Old News"}})
+	client.AddObject(&models.WeaviateObject{ID: "obj-2", Class: "Article", Properties: map[string]interface{}{"title": "Breaking News"}})
+	second, err := CreateCommit(ctx, cfg, st, client, "Retitle articles")
+	require.NoError(t, err)
+
+	// Known state only
+	found, err := FindObjects(st, FindOptions{Predicates: []PropertyPredicate{breaking}})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, "obj-2", found[0].ObjectID)
+	assert.True(t, found[0].Current)
+
+	// Whole history
+	found, err = FindObjects(st, FindOptions{ClassName: "Article", Predicates: []PropertyPredicate{breaking}, History: true})
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, "obj-1", found[0].ObjectID)
+	require.Len(t, found[0].Commits, 1)
+	assert.Equal(t, first.ID, found[0].Commits[0].ID)
+	assert.False(t, found[0].Current, "obj-1 was retitled")
+	require.Len(t, found[1].Commits, 1)
+	assert.Equal(t, second.ID, found[1].Commits[0].ID)
+	assert.True(t, found[1].Current)
+
+	// A range ending at the first commit
+	found, err = FindObjects(st, FindOptions{Predicates: []PropertyPredicate{breaking}, Range: first.ID})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, "obj-1", found[0].ObjectID)
+	assert.True(t, found[0].Current)
+
+	// A range after the first commit
+	found, err = FindObjects(st, FindOptions{Predicates: []PropertyPredicate{breaking}, Range: first.ID + ".."})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, "obj-2", found[0].ObjectID)
+
+	// Other classes are left out
+	found, err = FindObjects(st, FindOptions{ClassName: "Author", Predicates: []PropertyPredicate{breaking}, History: true})
+	require.NoError(t, err)
+	assert.Empty(t, found)
+}
@@ -0,0 +1,282 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+)
+
+// FixtureSeedProperty marks objects written by GenerateFixtures with the seed
+// they were generated from; CleanFixtures deletes objects that carry it.
+const FixtureSeedProperty = "fixtureSeed"
+
+// fixtureClassDescription is the description of classes GenerateFixtures
+// creates, so CleanFixtures knows it may drop them again.
+const fixtureClassDescription = "Synthetic fixture data generated by wvc"
+
+// fixtureNamespace derives deterministic fixture object IDs
+var fixtureNamespace = uuid.MustParse("6f1c7c1e-4f59-4b8e-9d1a-5c0b8a3e2f10")
+
+// fixtureTemplate is the schema of classes created for fixtures
+var fixtureTemplate = []*models.WeaviateProperty{
+	{Name: "title", DataType: []string{"text"}},
+	{Name: "body", DataType: []string{"text"}},
+	{Name: "category", DataType: []string{"text"}},
+	{Name: "tags", DataType: []string{"text[]"}},
+	{Name: "wordCount", DataType: []string{"int"}},
+	{Name: "rating", DataType: []string{"number"}},
+	{Name: "published", DataType: []string{"boolean"}},
+	{Name: "publishedAt", DataType: []string{"date"}},
+}
+
+var fixtureWords = []string{
+	"vector", "index", "shard", "query", "schema", "tenant", "cluster", "replica",
+	"signal", "harbor", "meadow", "lantern", "orbit", "granite", "willow", "canyon",
+	"amber", "quartz", "summit", "river", "falcon", "ember", "delta", "prism",
+	"rapid", "quiet", "bright", "hollow", "silver", "distant", "gentle", "steady",
+}
+
+var fixtureCategories = []string{"science", "technology", "culture", "travel", "sports", "business"}
+
+// fixtureEpoch is the earliest date generated for date properties
+var fixtureEpoch = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// FixtureOptions configures GenerateFixtures.
+type FixtureOptions struct {
+	Class string
+	Count int
+	Dims  int // vector dimensions; 0 leaves vectors to the class's vectorizer
+	Seed  int64
+}
+
+// FixtureResult is the outcome of GenerateFixtures.
+type FixtureResult struct {
+	Class        string
+	ClassCreated bool
+	Created      int
+	Updated      int
+}
+
+// GenerateFixtures writes Count deterministic synthetic objects into a class.
+// Property values follow the class's schema; a missing class is created from
+// a built-in article template. The same class, seed, and index always produce
+// the same object ID, properties, and vector, so running it again rewrites the
+// same objects.
+func GenerateFixtures(ctx context.Context, client weaviate.ClientInterface, opts FixtureOptions) (*FixtureResult, error) {
+	if opts.Class == "" {
+		return nil, fmt.Errorf("fixture class is required")
+	}
+	if opts.Count <= 0 {
+		return nil, fmt.Errorf("fixture count must be positive")
+	}
+	if opts.Dims < 0 {
+		return nil, fmt.Errorf("fixture dimensions must not be negative")
+	}
+
+	schema, err := client.GetSchemaTyped(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get schema: %w", err)
+	}
+	result := &FixtureResult{Class: opts.Class}
+	class := buildClassMap(schema)[opts.Class]
+	existing := make(map[string]bool)
+	if class == nil {
+		class = &models.WeaviateClass{
+			Class:       opts.Class,
+			Description: fixtureClassDescription,
+			Vectorizer:  "none",
+			Properties:  slices.Concat(fixtureTemplate, []*models.WeaviateProperty{{Name: FixtureSeedProperty, DataType: []string{"int"}}}),
+		}
+		if err := client.CreateClass(ctx, class); err != nil {
+			return nil, fmt.Errorf("create class %s: %w", opts.Class, err)
+		}
+		result.ClassCreated = true
+	} else {
+		if !slices.ContainsFunc(class.Properties, func(p *models.WeaviateProperty) bool { return p.Name == FixtureSeedProperty }) {
+			prop := &models.WeaviateProperty{Name: FixtureSeedProperty, DataType: []string{"int"}}
+			if err := client.AddProperty(ctx, opts.Class, prop); err != nil {
+				return nil, fmt.Errorf("add property %s to %s: %w", FixtureSeedProperty, opts.Class, err)
+			}
+		}
+		objects, err := client.GetAllObjects(ctx, opts.Class, true)
+		if err != nil {
+			return nil, fmt.Errorf("list objects of %s: %w", opts.Class, err)
+		}
+		for _, obj := range objects {
+			existing[obj.ID] = true
+		}
+	}
+
+	for i := range opts.Count {
+		obj := fixtureObject(class, opts, i)
+		if existing[obj.ID] {
+			if err := client.UpdateObject(ctx, obj); err != nil {
+				return result, fmt.Errorf("update %s: %w", models.ObjectKey(obj.Class, obj.ID), err)
+			}
+			result.Updated++
+			continue
+		}
+		if err := client.CreateObject(ctx, obj); err != nil {
+			return result, fmt.Errorf("create %s: %w", models.ObjectKey(obj.Class, obj.ID), err)
+		}
+		result.Created++
+	}
+	return result, nil
+}
+
+// CleanFixtures deletes the objects written by GenerateFixtures from the
+// given classes, or from every class when none are given. Classes that
+// GenerateFixtures created are dropped once they are empty. It returns the
+// number of objects deleted and the classes dropped.
+func CleanFixtures(ctx context.Context, client weaviate.ClientInterface, classes []string) (int, []string, error) {
+	schema, err := client.GetSchemaTyped(ctx)
+	if err != nil {
+		return 0, nil, fmt.Errorf("get schema: %w", err)
+	}
+	if len(classes) == 0 {
+		for _, class := range schema.Classes {
+			classes = append(classes, class.Class)
+		}
+	}
+
+	classMap := buildClassMap(schema)
+	deleted := 0
+	var dropped []string
+	for _, className := range classes {
+		class := classMap[className]
+		if class == nil {
+			return deleted, dropped, fmt.Errorf("class %s does not exist", className)
+		}
+		objects, err := client.GetAllObjects(ctx, className, true)
+		if err != nil {
+			return deleted, dropped, fmt.Errorf("list objects of %s: %w", className, err)
+		}
+		remaining := 0
+		for _, obj := range objects {
+			if _, ok := obj.Properties[FixtureSeedProperty]; !ok {
+				remaining++
+				continue
+			}
+			if err := client.DeleteObject(ctx, className, obj.ID); err != nil {
+				return deleted, dropped, fmt.Errorf("delete %s: %w", models.ObjectKey(className, obj.ID), err)
+			}
+			deleted++
+		}
+		if remaining == 0 && class.Description == fixtureClassDescription {
+			if err := client.DeleteClass(ctx, className); err != nil {
+				return deleted, dropped, fmt.Errorf("delete class %s: %w", className, err)
+			}
+			dropped = append(dropped, className)
+		}
+	}
+	return deleted, dropped, nil
+}
+
+// fixtureObject generates the i-th fixture object of a class. Each object
+// has its own random source so it doesn't depend on Count.
+func fixtureObject(class *models.WeaviateClass, opts FixtureOptions, i int) *models.WeaviateObject {
+	rng := rand.New(rand.NewPCG(uint64(opts.Seed), uint64(i)))
+	name := fmt.Sprintf("%s/%d/%d", class.Class, opts.Seed, i)
+	obj := &models.WeaviateObject{
+		ID:         uuid.NewSHA1(fixtureNamespace, []byte(name)).String(),
+		Class:      class.Class,
+		Properties: map[string]interface{}{FixtureSeedProperty: opts.Seed},
+	}
+	for _, prop := range class.Properties {
+		if prop.Name == FixtureSeedProperty || len(prop.DataType) == 0 {
+			continue
+		}
+		if value, ok := fixtureValue(rng, prop); ok {
+			obj.Properties[prop.Name] = value
+		}
+	}
+	if opts.Dims > 0 {
+		obj.Vector = fixtureVector(rng, opts.Dims)
+	}
+	return obj
+}
+
+// fixtureValue generates a value for a property by data type. References and
+// other types without a sensible synthetic value are skipped.
+func fixtureValue(rng *rand.Rand, prop *models.WeaviateProperty) (interface{}, bool) {
+	switch prop.DataType[0] {
+	case "text", "string":
+		return fixtureText(rng, prop.Name), true
+	case "text[]", "string[]":
+		return []string{fixtureWord(rng), fixtureWord(rng), fixtureWord(rng)}, true
+	case "int":
+		return rng.IntN(10000), true
+	case "int[]":
+		return []int{rng.IntN(100), rng.IntN(100)}, true
+	case "number":
+		return math.Round(rng.Float64()*100000) / 100, true
+	case "number[]":
+		return []float64{math.Round(rng.Float64()*100) / 100, math.Round(rng.Float64()*100) / 100}, true
+	case "boolean":
+		return rng.IntN(2) == 1, true
+	case "date":
+		return fixtureEpoch.Add(time.Duration(rng.IntN(5*365*24)) * time.Hour).Format(time.RFC3339), true
+	case "uuid":
+		return uuid.NewSHA1(fixtureNamespace, []byte(strconv.FormatUint(rng.Uint64(), 16))).String(), true
+	}
+	return nil, false
+}
+
+// fixtureText generates text shaped by the property name: a category, a
+// short title, or a couple of sentences.
+func fixtureText(rng *rand.Rand, name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.Contains(lower, "category") || strings.Contains(lower, "type"):
+		return fixtureCategories[rng.IntN(len(fixtureCategories))]
+	case strings.Contains(lower, "title") || strings.Contains(lower, "name"):
+		return fixturePhrase(rng, 3+rng.IntN(3))
+	}
+	sentences := make([]string, 2+rng.IntN(2))
+	for i := range sentences {
+		sentences[i] = fixturePhrase(rng, 6+rng.IntN(6)) + "."
+	}
+	return strings.Join(sentences, " ")
+}
+
+// fixturePhrase joins n random words, capitalizing the first.
+func fixturePhrase(rng *rand.Rand, n int) string {
+	words := make([]string, n)
+	for i := range words {
+		words[i] = fixtureWord(rng)
+	}
+	words[0] = strings.ToUpper(words[0][:1]) + words[0][1:]
+	return strings.Join(words, " ")
+}
+
+func fixtureWord(rng *rand.Rand) string {
+	return fixtureWords[rng.IntN(len(fixtureWords))]
+}
+
+// fixtureVector generates a unit-length vector of dims dimensions.
+func fixtureVector(rng *rand.Rand, dims int) []float32 {
+	vec := make([]float32, dims)
+	var norm float64
+	for i := range vec {
+		v := rng.NormFloat64()
+		vec[i] = float32(v)
+		norm += v * v
+	}
+	norm = math.Sqrt(norm)
+	if norm == 0 {
+		return vec
+	}
+	for i := range vec {
+		vec[i] = float32(float64(vec[i]) / norm)
+	}
+	return vec
+}
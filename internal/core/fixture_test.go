@@ -0,0 +1,92 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateFixtures_Deterministic(t *testing.T) {
+	ctx := context.Background()
+	opts := FixtureOptions{Class: "Article", Count: 5, Dims: 8, Seed: 42}
+
+	first := weaviate.NewMockClient()
+	result, err := GenerateFixtures(ctx, first, opts)
+	require.NoError(t, err)
+	assert.True(t, result.ClassCreated)
+	assert.Equal(t, 5, result.Created)
+
+	second := weaviate.NewMockClient()
+	_, err = GenerateFixtures(ctx, second, opts)
+	require.NoError(t, err)
+	assert.Equal(t, first.Objects, second.Objects, "the same seed generates the same objects")
+
+	for _, obj := range first.Objects {
+		assert.Len(t, obj.Vector, 8)
+		assert.NotEmpty(t, obj.Properties["title"])
+		assert.Contains(t, fixtureCategories, obj.Properties["category"])
+	}
+
+	// Running again rewrites the same objects
+	result, err = GenerateFixtures(ctx, first, opts)
+	require.NoError(t, err)
+	assert.False(t, result.ClassCreated)
+	assert.Equal(t, 0, result.Created)
+	assert.Equal(t, 5, result.Updated)
+
+	// A different seed generates different objects
+	other := weaviate.NewMockClient()
+	_, err = GenerateFixtures(ctx, other, FixtureOptions{Class: "Article", Count: 5, Seed: 7})
+	require.NoError(t, err)
+	for key := range other.Objects {
+		assert.NotContains(t, first.Objects, key)
+	}
+}
+
+func TestGenerateFixtures_FollowsExistingSchema(t *testing.T) {
+	ctx := context.Background()
+	wc := weaviate.NewMockClient()
+	wc.AddClass(&models.WeaviateClass{Class: "Product", Properties: []*models.WeaviateProperty{
+		{Name: "name", DataType: []string{"text"}},
+		{Name: "price", DataType: []string{"number"}},
+		{Name: "inStock", DataType: []string{"boolean"}},
+		{Name: "maker", DataType: []string{"Company"}},
+	}})
+
+	_, err := GenerateFixtures(ctx, wc, FixtureOptions{Class: "Product", Count: 3, Seed: 1})
+	require.NoError(t, err)
+	for _, obj := range wc.Objects {
+		assert.IsType(t, "", obj.Properties["name"])
+		assert.IsType(t, float64(0), obj.Properties["price"])
+		assert.IsType(t, false, obj.Properties["inStock"])
+		assert.NotContains(t, obj.Properties, "maker", "references are skipped")
+		assert.Nil(t, obj.Vector)
+	}
+}
+
+func TestCleanFixtures(t *testing.T) {
+	ctx := context.Background()
+	wc := weaviate.NewMockClient()
+	wc.AddClass(&models.WeaviateClass{Class: "Product"})
+	wc.AddObject(&models.WeaviateObject{ID: "real-001", Class: "Product", Properties: map[string]interface{}{"name": "Real"}})
+
+	_, err := GenerateFixtures(ctx, wc, FixtureOptions{Class: "Product", Count: 3, Seed: 1})
+	require.NoError(t, err)
+	_, err = GenerateFixtures(ctx, wc, FixtureOptions{Class: "Article", Count: 2, Seed: 1})
+	require.NoError(t, err)
+
+	deleted, dropped, err := CleanFixtures(ctx, wc, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 5, deleted)
+	assert.Equal(t, []string{"Article"}, dropped, "only classes created for fixtures are dropped")
+
+	classes, err := wc.GetClasses(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Product"}, classes)
+	_, err = wc.GetObject(ctx, "Product", "real-001")
+	assert.NoError(t, err, "objects not generated as fixtures are kept")
+}
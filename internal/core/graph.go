@@ -0,0 +1,245 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/store"
+)
+
+// Commit graph export formats
+const (
+	GraphFormatDot     = "dot"
+	GraphFormatMermaid = "mermaid"
+)
+
+// GraphNode is one commit of an exported commit graph
+type GraphNode struct {
+	Commit *models.Commit
+	// Refs label the commit: "HEAD", branch names, and "tag: <name>"
+	Refs    []string
+	Added   int
+	Updated int
+	Deleted int
+	Schema  bool
+}
+
+// CommitGraph is a set of commits with the refs pointing at them. Edges run
+// from each commit to those of its parents that are part of the graph.
+type CommitGraph struct {
+	Nodes []*GraphNode
+	index map[string]bool
+}
+
+// BuildCommitGraph labels commits, newest first, with the branches and tags
+// that point at them and counts the object changes each one recorded
+func BuildCommitGraph(st *store.Store, commits []*models.Commit) (*CommitGraph, error) {
+	refs, err := commitRefLabels(st)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &CommitGraph{index: make(map[string]bool, len(commits))}
+	for _, commit := range commits {
+		ops, err := st.GetOperationsByCommit(commit.ID)
+		if err != nil {
+			return nil, fmt.Errorf("read operations of %s: %w", commit.ShortID(), err)
+		}
+		node := &GraphNode{Commit: commit, Refs: refs[commit.ID]}
+		for _, op := range ops {
+			switch op.Type {
+			case models.OperationInsert:
+				node.Added++
+			case models.OperationUpdate:
+				node.Updated++
+			case models.OperationDelete:
+				node.Deleted++
+			}
+		}
+		node.Schema, _ = st.CommitHasSchemaChange(commit.ID)
+		g.Nodes = append(g.Nodes, node)
+		g.index[commit.ID] = true
+	}
+	return g, nil
+}
+
+// commitRefLabels maps commit IDs to HEAD, branch, and tag labels
+func commitRefLabels(st *store.Store) (map[string][]string, error) {
+	labels := make(map[string][]string)
+
+	branches, err := st.ListBranches()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(branches, func(i, j int) bool { return branches[i].Name < branches[j].Name })
+	current, _ := st.GetCurrentBranch()
+	for _, b := range branches {
+		name := b.Name
+		if name == current {
+			name = "HEAD -> " + name
+		}
+		labels[b.CommitID] = append(labels[b.CommitID], name)
+	}
+	if head, _ := st.GetHEAD(); head != "" && current == "" {
+		labels[head] = append([]string{"HEAD"}, labels[head]...)
+	}
+
+	tags, err := st.ListTags()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	for _, t := range tags {
+		labels[t.CommitID] = append(labels[t.CommitID], "tag: "+t.Name)
+	}
+	return labels, nil
+}
+
+// parents returns the parents of a commit that are part of the graph
+func (g *CommitGraph) parents(c *models.Commit) []string {
+	var parents []string
+	for _, id := range []string{c.ParentID, c.MergeParentID} {
+		if id != "" && g.index[id] {
+			parents = append(parents, id)
+		}
+	}
+	return parents
+}
+
+// Write renders the graph in the given format
+func (g *CommitGraph) Write(w io.Writer, format string) error {
+	switch format {
+	case GraphFormatDot:
+		return g.WriteDot(w)
+	case GraphFormatMermaid:
+		return g.WriteMermaid(w)
+	}
+	return fmt.Errorf("unknown graph format '%s': use %s or %s", format, GraphFormatDot, GraphFormatMermaid)
+}
+
+// WriteDot renders the graph as a Graphviz digraph. Each node carries the
+// commit's stats as attributes (added, updated, deleted, operations,
+// author, timestamp, merge, schema) for tools that read them.
+func (g *CommitGraph) WriteDot(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph commits {\n")
+	b.WriteString("  rankdir=BT;\n")
+	b.WriteString("  node [shape=box, style=\"rounded,filled\", fillcolor=white, fontname=monospace];\n")
+	for _, n := range g.Nodes {
+		c := n.Commit
+		var lines []string
+		for _, line := range n.labelLines() {
+			lines = append(lines, dotEscape(line))
+		}
+
+		attrs := []string{
+			`label="` + strings.Join(lines, `\n`) + `"`,
+			"tooltip=" + dotQuote(c.ID),
+			fmt.Sprintf("added=%d", n.Added),
+			fmt.Sprintf("updated=%d", n.Updated),
+			fmt.Sprintf("deleted=%d", n.Deleted),
+			fmt.Sprintf("operations=%d", c.OperationCount),
+			"timestamp=" + dotQuote(c.Timestamp.UTC().Format("2006-01-02T15:04:05Z")),
+		}
+		if c.Author != "" {
+			attrs = append(attrs, "author="+dotQuote(c.Author))
+		}
+		if c.IsMergeCommit() {
+			attrs = append(attrs, "merge=true", "shape=ellipse")
+		}
+		if n.Schema {
+			attrs = append(attrs, "schema=true")
+		}
+		if len(n.Refs) > 0 {
+			attrs = append(attrs, "fillcolor=lightyellow")
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", dotQuote(c.ID), strings.Join(attrs, ", "))
+	}
+	for _, n := range g.Nodes {
+		for i, parent := range g.parents(n.Commit) {
+			style := ""
+			if i > 0 {
+				style = " [style=dashed]"
+			}
+			fmt.Fprintf(&b, "  %s -> %s%s;\n", dotQuote(parent), dotQuote(n.Commit.ID), style)
+		}
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMermaid renders the graph as a Mermaid flowchart. Mermaid nodes have
+// no custom attributes, so the stats are part of each label.
+func (g *CommitGraph) WriteMermaid(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("flowchart BT\n")
+	for _, n := range g.Nodes {
+		c := n.Commit
+		var lines []string
+		for _, line := range n.labelLines() {
+			lines = append(lines, mermaidEscape(line))
+		}
+		// Merge commits are stadium-shaped
+		shape := `["%s"]`
+		if c.IsMergeCommit() {
+			shape = `(["%s"])`
+		}
+		fmt.Fprintf(&b, "  c%s"+shape+"\n", c.ID, strings.Join(lines, "<br/>"))
+	}
+	for _, n := range g.Nodes {
+		for i, parent := range g.parents(n.Commit) {
+			arrow := "-->"
+			if i > 0 {
+				arrow = "-.->"
+			}
+			fmt.Fprintf(&b, "  c%s %s c%s\n", parent, arrow, n.Commit.ID)
+		}
+	}
+	var labelled []string
+	for _, n := range g.Nodes {
+		if len(n.Refs) > 0 {
+			labelled = append(labelled, "c"+n.Commit.ID)
+		}
+	}
+	if len(labelled) > 0 {
+		b.WriteString("  classDef ref fill:#ffffe0\n")
+		fmt.Fprintf(&b, "  class %s ref\n", strings.Join(labelled, ","))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// labelLines returns a node's label: its refs, the commit's short ID and
+// subject, and its object changes, e.g. "+3 ~1 -0 [schema]"
+func (n *GraphNode) labelLines() []string {
+	var lines []string
+	if len(n.Refs) > 0 {
+		lines = append(lines, "("+strings.Join(n.Refs, ", ")+")")
+	}
+	subject, _, _ := strings.Cut(n.Commit.Message, "\n")
+	lines = append(lines, n.Commit.ShortID()+" "+subject)
+	stats := fmt.Sprintf("+%d ~%d -%d", n.Added, n.Updated, n.Deleted)
+	if n.Schema {
+		stats += " [schema]"
+	}
+	return append(lines, stats)
+}
+
+// dotQuote quotes a Graphviz ID or attribute value
+func dotQuote(s string) string {
+	return `"` + dotEscape(s) + `"`
+}
+
+func dotEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
+
+// mermaidEscape replaces the characters Mermaid reads as syntax inside a
+// quoted label with entity codes
+func mermaidEscape(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;").Replace(s)
+}
@@ -0,0 +1,72 @@
+package core
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommitGraph(t *testing.T) {
+	st := newTestStore(t)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	// c1 <- c2 <- c4 (main, merge of c3)
+	//    \- c3 (feature)
+	commits := []*models.Commit{
+		{ID: "commit4", ParentID: "commit2", MergeParentID: "commit3", Message: "Merge feature", Timestamp: now.Add(3 * time.Hour)},
+		{ID: "commit3", ParentID: "commit1", Message: "Add \"quoted\" <title>", Timestamp: now.Add(2 * time.Hour)},
+		{ID: "commit2", ParentID: "commit1", Message: "Second", Author: "Alice", Timestamp: now.Add(time.Hour)},
+		{ID: "commit1", Message: "First\n\nbody", Timestamp: now},
+	}
+	for _, c := range commits {
+		require.NoError(t, st.CreateCommit(c))
+	}
+	require.NoError(t, st.CreateBranch("main", "commit4"))
+	require.NoError(t, st.CreateBranch("feature", "commit3"))
+	require.NoError(t, st.SetHEAD("commit4"))
+	require.NoError(t, st.SetCurrentBranch("main"))
+	require.NoError(t, st.PutTag(&models.Tag{Name: "v1", CommitID: "commit1"}))
+	require.NoError(t, st.RecordOperation(&models.Operation{Type: models.OperationInsert, ClassName: "Article", ObjectID: "a1", CommitID: "commit2"}))
+
+	g, err := BuildCommitGraph(st, commits)
+	require.NoError(t, err)
+	require.Len(t, g.Nodes, 4)
+	assert.Equal(t, []string{"HEAD -> main"}, g.Nodes[0].Refs)
+	assert.Equal(t, []string{"feature"}, g.Nodes[1].Refs)
+	assert.Equal(t, 1, g.Nodes[2].Added)
+	assert.Equal(t, []string{"tag: v1"}, g.Nodes[3].Refs)
+
+	var dot strings.Builder
+	require.NoError(t, g.Write(&dot, GraphFormatDot))
+	out := dot.String()
+	assert.True(t, strings.HasPrefix(out, "digraph commits {\n"))
+	assert.Contains(t, out, `"commit2" [label="commit2 Second\n+1 ~0 -0", tooltip="commit2", added=1, updated=0, deleted=0, operations=0, timestamp="2026-03-01T13:00:00Z", author="Alice"];`)
+	assert.Contains(t, out, `label="(feature)\ncommit3 Add \"quoted\" <title>\n+0 ~0 -0"`)
+	assert.Contains(t, out, `label="(tag: v1)\ncommit1 First\n+0 ~0 -0"`)
+	assert.Contains(t, out, `"commit2" -> "commit4";`)
+	assert.Contains(t, out, `"commit3" -> "commit4" [style=dashed];`)
+	assert.Contains(t, out, "merge=true")
+
+	var mermaid strings.Builder
+	require.NoError(t, g.Write(&mermaid, GraphFormatMermaid))
+	out = mermaid.String()
+	assert.True(t, strings.HasPrefix(out, "flowchart BT\n"))
+	assert.Contains(t, out, `  ccommit4(["(HEAD -#gt; main)<br/>commit4 Merge feature<br/>+0 ~0 -0"])`)
+	assert.Contains(t, out, `  ccommit3["(feature)<br/>commit3 Add #quot;quoted#quot; #lt;title#gt;<br/>+0 ~0 -0"]`)
+	assert.Contains(t, out, "  ccommit2 --> ccommit4\n")
+	assert.Contains(t, out, "  ccommit3 -.-> ccommit4\n")
+	assert.Contains(t, out, "  class ccommit4,ccommit3,ccommit1 ref\n")
+
+	// Parents outside the graph have no edge
+	g, err = BuildCommitGraph(st, commits[:1])
+	require.NoError(t, err)
+	dot.Reset()
+	require.NoError(t, g.WriteDot(&dot))
+	assert.NotContains(t, dot.String(), `"commit2" ->`)
+
+	assert.Error(t, g.Write(&dot, "svg"))
+}
@@ -0,0 +1,178 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/kilupskalvis/wvc/internal/config"
+	"github.com/kilupskalvis/wvc/internal/models"
+)
+
+// IgnoreRules are the parsed ignore rules of a repository (see
+// config.IgnoreRule). Ignored objects are left out of diffs, staging, and
+// commits, and checkouts leave them in Weaviate. The nil value ignores
+// nothing.
+type IgnoreRules []*ignoreRule
+
+type ignoreRule struct {
+	pattern pathPattern
+	where   []PropertyPredicate
+}
+
+// scratchRule ignores the classes of scratch checkouts in every repository
+var scratchRule = &ignoreRule{pattern: pathPattern{raw: ScratchPrefix + "*", class: ScratchPrefix + "*"}}
+
+// LoadIgnoreRules parses the ignore rules of the configuration, which always
+// ignore the classes of scratch checkouts.
+func LoadIgnoreRules(cfg *config.Config) (IgnoreRules, error) {
+	rules := IgnoreRules{scratchRule}
+	if cfg == nil {
+		return rules, nil
+	}
+	for _, r := range cfg.Ignore {
+		rule, err := parseIgnoreRule(r.Pattern, r.Where)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func parseIgnoreRule(pattern string, where []string) (*ignoreRule, error) {
+	if pattern == "." {
+		return nil, fmt.Errorf("invalid ignore pattern '.': it would ignore every object")
+	}
+	spec, err := ParsePathspec([]string{pattern})
+	if err != nil {
+		return nil, fmt.Errorf("invalid ignore rule: %w", err)
+	}
+	rule := &ignoreRule{pattern: spec.patterns[0]}
+	for _, w := range where {
+		pred, err := ParsePropertyPredicate(w)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ignore rule: %w", err)
+		}
+		rule.where = append(rule.where, pred)
+	}
+	return rule, nil
+}
+
+// AddIgnoreRule adds a rule ignoring the objects that match pattern and
+// every where predicate to the configuration. The caller saves it.
+func AddIgnoreRule(cfg *config.Config, pattern string, where []string) error {
+	if _, err := parseIgnoreRule(pattern, where); err != nil {
+		return err
+	}
+	for _, r := range cfg.Ignore {
+		if r.Pattern == pattern && slices.Equal(r.Where, where) {
+			return fmt.Errorf("'%s' is already ignored", pattern)
+		}
+	}
+	cfg.Ignore = append(cfg.Ignore, &config.IgnoreRule{Pattern: pattern, Where: where})
+	return nil
+}
+
+// RemoveIgnoreRule removes the rules with the given pattern from the
+// configuration and returns how many there were. The caller saves it.
+func RemoveIgnoreRule(cfg *config.Config, pattern string) (int, error) {
+	kept := cfg.Ignore[:0]
+	for _, r := range cfg.Ignore {
+		if r.Pattern != pattern {
+			kept = append(kept, r)
+		}
+	}
+	removed := len(cfg.Ignore) - len(kept)
+	if removed == 0 {
+		return 0, fmt.Errorf("no ignore rule with pattern '%s'", pattern)
+	}
+	cfg.Ignore = kept
+	if len(cfg.Ignore) == 0 {
+		cfg.Ignore = nil
+	}
+	return removed, nil
+}
+
+// ignoresClass reports whether a rule ignores every object and the schema
+// of a class
+func (r IgnoreRules) ignoresClass(className string) bool {
+	for _, rule := range r {
+		if rule.pattern.id == "" && len(rule.where) == 0 && rule.pattern.matches(className, "") {
+			return true
+		}
+	}
+	return false
+}
+
+// ignores reports whether a rule ignores an object, given its data for the
+// property predicates
+func (r IgnoreRules) ignores(className, objectID string, obj *models.WeaviateObject) bool {
+	for _, rule := range r {
+		if rule.matches(className, objectID, obj) {
+			return true
+		}
+	}
+	return false
+}
+
+func (rule *ignoreRule) matches(className, objectID string, obj *models.WeaviateObject) bool {
+	if !rule.pattern.matches(className, objectID) {
+		return false
+	}
+	for _, pred := range rule.where {
+		if obj == nil || !pred.Matches(obj) {
+			return false
+		}
+	}
+	return true
+}
+
+// filterDiff drops the ignored changes of a diff. Inserts and updates are
+// matched by the object's new version, deletions by its last version.
+func (r IgnoreRules) filterDiff(diff *DiffResult) *DiffResult {
+	if len(r) == 0 || diff == nil {
+		return diff
+	}
+	keep := func(changes []*ObjectChange, deleted bool) []*ObjectChange {
+		kept := make([]*ObjectChange, 0, len(changes))
+		for _, c := range changes {
+			data := c.CurrentData
+			if deleted {
+				data = c.PreviousData
+			}
+			if !r.ignores(c.ClassName, c.ObjectID, data) {
+				kept = append(kept, c)
+			}
+		}
+		return kept
+	}
+	return &DiffResult{
+		Inserted: keep(diff.Inserted, false),
+		Updated:  keep(diff.Updated, false),
+		Deleted:  keep(diff.Deleted, true),
+	}
+}
+
+// filterSchemaDiff drops the schema changes of ignored classes
+func (r IgnoreRules) filterSchemaDiff(diff *SchemaDiffResult) *SchemaDiffResult {
+	if len(r) == 0 || diff == nil {
+		return diff
+	}
+	keep := func(changes []*models.SchemaChange) []*models.SchemaChange {
+		var kept []*models.SchemaChange
+		for _, c := range changes {
+			if !r.ignoresClass(c.ClassName) {
+				kept = append(kept, c)
+			}
+		}
+		return kept
+	}
+	return &SchemaDiffResult{
+		ClassesAdded:       keep(diff.ClassesAdded),
+		ClassesDeleted:     keep(diff.ClassesDeleted),
+		PropertiesAdded:    keep(diff.PropertiesAdded),
+		PropertiesDeleted:  keep(diff.PropertiesDeleted),
+		PropertiesModified: keep(diff.PropertiesModified),
+		VectorizersChanged: keep(diff.VectorizersChanged),
+	}
+}
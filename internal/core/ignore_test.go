@@ -0,0 +1,90 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIgnoreRules_ConfigRoundTrip(t *testing.T) {
+	cfg := newTestConfig()
+	require.NoError(t, AddIgnoreRule(cfg, "Cache", nil))
+	require.NoError(t, AddIgnoreRule(cfg, "Article", []string{"source=test"}))
+	require.NoError(t, AddIgnoreRule(cfg, "Article/tmp-*", nil))
+	assert.Error(t, AddIgnoreRule(cfg, "Cache", nil), "duplicate rule")
+	assert.Error(t, AddIgnoreRule(cfg, ".", nil))
+	assert.Error(t, AddIgnoreRule(cfg, "Article/[", nil))
+	assert.Error(t, AddIgnoreRule(cfg, "Article", []string{"source"}))
+
+	rules, err := LoadIgnoreRules(cfg)
+	require.NoError(t, err)
+	assert.True(t, rules.ignoresClass("Cache"))
+	assert.False(t, rules.ignoresClass("Article"), "rules with an ID or predicates leave the class tracked")
+	assert.True(t, rules.ignores("Article", "tmp-1", nil))
+	assert.True(t, rules.ignores("Article", "a", &models.WeaviateObject{Properties: map[string]interface{}{"source": "test"}}))
+	assert.False(t, rules.ignores("Article", "a", &models.WeaviateObject{Properties: map[string]interface{}{"source": "prod"}}))
+
+	removed, err := RemoveIgnoreRule(cfg, "Article")
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	assert.Len(t, cfg.Ignore, 2)
+	_, err = RemoveIgnoreRule(cfg, "Article")
+	assert.Error(t, err)
+}
+
+func TestIgnoreRules_StatusCommitAndCheckout(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+
+	client.AddClass(&models.WeaviateClass{Class: "Article"})
+	client.AddObject(&models.WeaviateObject{ID: "obj-001", Class: "Article", Properties: map[string]interface{}{"title": "A"}})
+	first, err := CreateCommit(ctx, cfg, st, client, "First")
+	require.NoError(t, err)
+
+	require.NoError(t, AddIgnoreRule(cfg, "Cache", nil))
+	require.NoError(t, AddIgnoreRule(cfg, "Article/tmp-*", nil))
+	require.NoError(t, AddIgnoreRule(cfg, "Article", []string{"source=test"}))
+	client.AddClass(&models.WeaviateClass{Class: "Cache"})
+	client.AddObject(&models.WeaviateObject{ID: "c-1", Class: "Cache", Properties: map[string]interface{}{"k": "v"}})
+	client.AddObject(&models.WeaviateObject{ID: "tmp-1", Class: "Article", Properties: map[string]interface{}{"title": "T"}})
+	client.AddObject(&models.WeaviateObject{ID: "obj-002", Class: "Article", Properties: map[string]interface{}{"title": "B", "source": "test"}})
+
+	// Only ignored changes: nothing to report or commit
+	diff, err := ComputeIncrementalDiff(ctx, cfg, st, client)
+	require.NoError(t, err)
+	assert.Zero(t, diff.TotalUnstagedChanges())
+	schemaDiff, err := ComputeSchemaDiff(ctx, cfg, st, client)
+	require.NoError(t, err)
+	assert.False(t, schemaDiff.HasChanges())
+	_, err = CreateCommit(ctx, cfg, st, client, "Nothing")
+	assert.Error(t, err)
+
+	client.AddObject(&models.WeaviateObject{ID: "obj-003", Class: "Article", Properties: map[string]interface{}{"title": "C", "source": "prod"}})
+	staged, err := StageAll(ctx, cfg, st, client)
+	require.NoError(t, err)
+	assert.Equal(t, 1, staged)
+	require.NoError(t, UnstageAll(st))
+	second, err := CreateCommit(ctx, cfg, st, client, "Second")
+	require.NoError(t, err)
+	ops, err := st.GetOperationsByCommit(second.ID)
+	require.NoError(t, err)
+	require.Len(t, ops, 1)
+	assert.Equal(t, "obj-003", ops[0].ObjectID)
+
+	// Checkout removes obj-003 but leaves the ignored objects and class alone
+	_, err = Checkout(ctx, cfg, st, client, first.ID, CheckoutOptions{})
+	require.NoError(t, err)
+	for _, key := range []string{"Article/obj-001", "Article/tmp-1", "Article/obj-002", "Cache/c-1"} {
+		assert.Contains(t, client.Objects, key)
+	}
+	assert.NotContains(t, client.Objects, "Article/obj-003")
+	classes, err := client.GetClasses(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, classes, "Cache")
+}
@@ -33,8 +33,18 @@ func (r *IncrementalDiffResult) TotalStagedChanges() int {
 	return r.Staged.TotalChanges()
 }
 
-// ComputeIncrementalDiff computes diff using incremental detection when possible
+// ComputeIncrementalDiff computes diff using incremental detection when possible.
+// On a class-scoped branch only changes to its own classes are reported.
 func ComputeIncrementalDiff(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface) (*IncrementalDiffResult, error) {
+	scope, err := currentScope(st)
+	if err != nil {
+		return nil, err
+	}
+	return computeIncrementalDiffScoped(ctx, cfg, st, client, scope)
+}
+
+// computeIncrementalDiffScoped computes the incremental diff for the classes in scope
+func computeIncrementalDiffScoped(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface, scope ClassScope) (*IncrementalDiffResult, error) {
 	// Get staged changes directly from the database
 	stagedDiff, err := GetStagedDiff(st)
 	if err != nil {
@@ -42,300 +52,228 @@ func ComputeIncrementalDiff(ctx context.Context, cfg *config.Config, st *store.S
 	}
 
 	result := &IncrementalDiffResult{
-		Staged: stagedDiff,
-		Unstaged: &DiffResult{
-			Inserted: make([]*ObjectChange, 0),
-			Updated:  make([]*ObjectChange, 0),
-			Deleted:  make([]*ObjectChange, 0),
-		},
-	}
-
-	useCursor := cfg.SupportsCursorPagination()
-
-	// Get all classes
-	classes, err := client.GetClasses(ctx)
-	if err != nil {
-		return nil, err
+		Staged:   stagedDiff,
+		Unstaged: newDiffResult(),
 	}
 
-	// Build map of staged object keys to exclude from unstaged detection
-	stagedChanges, err := st.GetAllStagedChanges()
+	// Staged objects are excluded from unstaged detection
+	stagedMap, err := stagedChangeMap(st)
 	if err != nil {
 		return nil, err
 	}
-	stagedMap := make(map[string]*store.StagedChange)
-	for _, sc := range stagedChanges {
-		key := models.ObjectKey(sc.ClassName, sc.ObjectID)
-		stagedMap[key] = sc
-	}
 
-	// Process each class
-	for _, className := range classes {
-		if err := processClassIncremental(ctx, st, client, className, useCursor, result, stagedMap); err != nil {
-			return nil, err
-		}
-	}
-
-	// Check for deleted classes (classes that were known but no longer exist)
-	knownClasses, err := getKnownClasses(st)
+	err = walkDiff(ctx, cfg, st, client, scope, stagedMap, func(changes *DiffResult) error {
+		result.Unstaged.append(changes)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-
-	classSet := make(map[string]bool)
-	for _, c := range classes {
-		classSet[c] = true
-	}
-
-	for _, knownClass := range knownClasses {
-		if !classSet[knownClass] {
-			// Class was deleted - all its objects are deletions
-			if err := processDeletedClass(st, knownClass, result, stagedMap); err != nil {
-				return nil, err
-			}
-		}
-	}
+	result.Unstaged.Sort()
 
 	return result, nil
 }
 
-// processClassIncremental processes a single class for changes
-func processClassIncremental(ctx context.Context, st *store.Store, client weaviate.ClientInterface, className string, useCursor bool, result *IncrementalDiffResult, stagedMap map[string]*store.StagedChange) error {
-	// Get scan metadata
-	meta, err := st.GetScanMetadata(className)
-	if err != nil {
-		return err
-	}
-
-	// Get current count
-	currentCount, err := client.GetClassCount(ctx, className)
-	if err != nil {
-		// Fall back to full scan if count fails
-		return processClassFullScan(ctx, st, client, className, useCursor, result, stagedMap)
-	}
-
-	// Get known count
-	knownCount, err := st.GetKnownObjectCount(className)
+// diffSink receives the changes found in one page of objects.
+type diffSink func(changes *DiffResult) error
+
+// walkDiff compares every class in scope, and every known class that no
+// longer exists, against its known state and hands the changes to sink a
+// page of objects at a time, so memory stays bounded however large the
+// classes are. Unless core.full_scan is set, a class whose object count and
+// latest update time match a previous scan that found no changes is skipped
+// without reading its objects. Objects in skip and ignored objects are left
+// out.
+func walkDiff(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface, scope ClassScope, skip map[string]*store.StagedChange, sink diffSink) error {
+	useCursor := cfg.SupportsCursorPagination()
+	fullScan := cfg.FullScan()
+	ignore, err := LoadIgnoreRules(cfg)
 	if err != nil {
 		return err
 	}
-
-	// Determine if we need full scan or can use incremental
-	needFullScan := meta == nil || // No previous scan
-		currentCount != knownCount // Count changed (inserts or deletes)
-
-	if needFullScan {
-		return processClassFullScan(ctx, st, client, className, useCursor, result, stagedMap)
-	}
-
-	// Incremental scan - fetch all but only process those with newer timestamps
-	return processClassIncrementalScan(ctx, st, client, className, useCursor, meta.ScanHighWatermark, result, stagedMap)
-}
-
-// processClassFullScan does a full comparison for a class
-func processClassFullScan(ctx context.Context, st *store.Store, client weaviate.ClientInterface, className string, useCursor bool, result *IncrementalDiffResult, stagedMap map[string]*store.StagedChange) error {
-	// Fetch all current objects
-	currentObjects, err := client.GetAllObjects(ctx, className, useCursor)
-	if err != nil {
-		return err
+	if len(ignore) > 0 {
+		next := sink
+		sink = func(changes *DiffResult) error {
+			return next(ignore.filterDiff(changes))
+		}
 	}
 
-	// Get known objects with hashes for this class
-	knownObjects, err := st.GetAllKnownObjectsWithHashes()
+	classes, err := versionedClasses(ctx, client)
 	if err != nil {
 		return err
 	}
 
-	// Build map of current objects
-	currentMap := make(map[string]*models.WeaviateObject)
-	for _, obj := range currentObjects {
-		key := models.ObjectKey(className, obj.ID)
-		currentMap[key] = obj
+	stagedClasses := make(map[string]bool)
+	for _, sc := range skip {
+		stagedClasses[sc.ClassName] = true
 	}
 
-	// Find inserts and updates (only add to Unstaged - staged changes come from DB)
-	for key, current := range currentMap {
-		knownInfo, exists := knownObjects[key]
-
-		// Skip if already staged
-		if stagedMap[key] != nil {
+	classSet := make(map[string]bool)
+	for _, className := range classes {
+		classSet[className] = true
+		if !scope.Includes(className) || ignore.ignoresClass(className) {
 			continue
 		}
 
-		// Compute current hashes (both property and vector)
-		currentObjHash, currentVecHash := weaviate.HashObjectFull(current)
-
-		if !exists {
-			// New object
-			change := &ObjectChange{
-				ClassName:   current.Class,
-				ObjectID:    current.ID,
-				CurrentData: current,
-				VectorHash:  currentVecHash,
+		// The probe is taken before the scan, so objects written while it
+		// runs are newer than what it records
+		var probe *models.ClassWatermark
+		if !fullScan {
+			var unchanged bool
+			if probe, unchanged, err = probeClass(ctx, st, client, className); err != nil {
+				return err
 			}
-			result.Unstaged.Inserted = append(result.Unstaged.Inserted, change)
-		} else {
-			// Check if updated (properties or vector)
-			propsChanged := currentObjHash != knownInfo.ObjectHash
-			vectorChanged := currentVecHash != knownInfo.VectorHash
-
-			if propsChanged || vectorChanged {
-				change := &ObjectChange{
-					ClassName:          current.Class,
-					ObjectID:           current.ID,
-					CurrentData:        current,
-					PreviousData:       knownInfo.Object,
-					VectorHash:         currentVecHash,
-					PreviousVectorHash: knownInfo.VectorHash,
-					VectorOnly:         !propsChanged && vectorChanged,
-				}
-				result.Unstaged.Updated = append(result.Unstaged.Updated, change)
+			if unchanged {
+				continue
 			}
 		}
-	}
 
-	// Find deletes - objects in known but not in current
-	for key, knownInfo := range knownObjects {
-		if knownInfo.Object.Class != className {
-			continue
+		// Changes are counted before ignore rules filter them, so a scan
+		// recorded as clean stays valid when the rules change
+		found := false
+		classSink := func(changes *DiffResult) error {
+			found = true
+			return sink(changes)
 		}
-
-		// Skip if already staged
-		if stagedMap[key] != nil {
-			continue
+		if err := scanClassChanges(ctx, st, client, className, useCursor, skip, classSink); err != nil {
+			return err
 		}
-
-		if _, exists := currentMap[key]; !exists {
-			change := &ObjectChange{
-				ClassName:          knownInfo.Object.Class,
-				ObjectID:           knownInfo.Object.ID,
-				PreviousData:       knownInfo.Object,
-				PreviousVectorHash: knownInfo.VectorHash,
+		if probe != nil && !found && !stagedClasses[className] {
+			if err := st.SaveScanMetadata(&store.ScanMetadata{
+				ClassName:         className,
+				LastScanTime:      time.Now(),
+				LastScanCount:     probe.Count,
+				ScanHighWatermark: probe.LastUpdateTimeUnix,
+			}); err != nil {
+				return err
 			}
-			result.Unstaged.Deleted = append(result.Unstaged.Deleted, change)
 		}
 	}
 
-	// Note: Don't update scan_metadata here - only during commit
-	return nil
-}
-
-// processClassIncrementalScan only checks objects modified since last scan
-func processClassIncrementalScan(ctx context.Context, st *store.Store, client weaviate.ClientInterface, className string, useCursor bool, watermark int64, result *IncrementalDiffResult, stagedMap map[string]*store.StagedChange) error {
-	// Fetch all objects (we have to, REST API doesn't support timestamp filtering)
-	currentObjects, err := client.GetAllObjects(ctx, className, useCursor)
+	// Check for deleted classes (classes that were known but no longer exist)
+	knownClasses, err := st.GetKnownClasses()
 	if err != nil {
 		return err
 	}
+	for _, knownClass := range knownClasses {
+		if !classSet[knownClass] && scope.Includes(knownClass) && !ignore.ignoresClass(knownClass) {
+			// Class was deleted - all its objects are deletions
+			if err := scanKnownDeletions(st, knownClass, nil, skip, sink); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
 
-	// Get known objects with hashes
-	knownObjects, err := st.GetAllKnownObjectsWithHashes()
+// probeClass reads the object count and latest update time of a class from
+// Weaviate aggregates, and reports whether both match the last scan that
+// found no changes in it. Since the known objects of the class have not
+// changed since that scan, the class has no changes either: an update or
+// insert moves the latest update time and a deletion lowers the count. The
+// probe is nil when it cannot tell, such as when Weaviate does not return
+// update times.
+func probeClass(ctx context.Context, st *store.Store, client weaviate.ClientInterface, className string) (*models.ClassWatermark, bool, error) {
+	probe, err := client.GetClassWatermark(ctx, className)
+	if err != nil || (probe.Count > 0 && probe.LastUpdateTimeUnix == 0) {
+		// Fall back to a full scan
+		return nil, false, nil
+	}
+	meta, err := st.GetScanMetadata(className)
 	if err != nil {
-		return err
+		return nil, false, err
 	}
+	unchanged := meta != nil && meta.LastScanCount == probe.Count && probe.LastUpdateTimeUnix <= meta.ScanHighWatermark
+	return probe, unchanged, nil
+}
 
-	// Only process objects with timestamps > watermark (and not already staged)
-	for _, obj := range currentObjects {
-		// Skip if not modified since last scan
-		if obj.LastUpdateTimeUnix <= watermark {
-			continue
-		}
-
-		key := models.ObjectKey(className, obj.ID)
+// scanClassChanges compares the objects of one class, a page at a time,
+// against their known state. Only the IDs of pages already passed are kept,
+// to find the known objects that were deleted.
+func scanClassChanges(ctx context.Context, st *store.Store, client weaviate.ClientInterface, className string, useCursor bool, skip map[string]*store.StagedChange, sink diffSink) error {
+	seen := make(map[string]struct{})
 
-		// Skip if already staged
-		if stagedMap[key] != nil {
-			continue
+	err := client.IterateObjects(ctx, className, useCursor, func(batch []*models.WeaviateObject) error {
+		ids := make([]string, len(batch))
+		for i, obj := range batch {
+			ids[i] = obj.ID
+		}
+		knownObjects, err := st.GetKnownObjectInfos(className, ids)
+		if err != nil {
+			return err
 		}
 
-		// Compute current hashes (both property and vector)
-		currentObjHash, currentVecHash := weaviate.HashObjectFull(obj)
-
-		knownInfo, exists := knownObjects[key]
-
-		if !exists {
-			// New object
-			change := &ObjectChange{
-				ClassName:   obj.Class,
-				ObjectID:    obj.ID,
-				CurrentData: obj,
-				VectorHash:  currentVecHash,
-			}
-			result.Unstaged.Inserted = append(result.Unstaged.Inserted, change)
-		} else {
-			// Check if actually updated (properties or vector)
-			propsChanged := currentObjHash != knownInfo.ObjectHash
-			vectorChanged := currentVecHash != knownInfo.VectorHash
+		changes := newDiffResult()
+		for _, current := range batch {
+			seen[current.ID] = struct{}{}
 
-			if propsChanged || vectorChanged {
-				change := &ObjectChange{
-					ClassName:          obj.Class,
-					ObjectID:           obj.ID,
-					CurrentData:        obj,
-					PreviousData:       knownInfo.Object,
-					VectorHash:         currentVecHash,
-					PreviousVectorHash: knownInfo.VectorHash,
-					VectorOnly:         !propsChanged && vectorChanged,
-				}
-				result.Unstaged.Updated = append(result.Unstaged.Updated, change)
+			// Skip if already staged
+			if skip[models.ObjectKey(className, current.ID)] != nil {
+				continue
 			}
+			changes.compare(current, knownObjects[current.ID], client.HashCache())
 		}
-	}
-
-	// Note: Don't update scan_metadata here - only during commit
-	return nil
-}
-
-// processDeletedClass handles a class that was deleted entirely
-func processDeletedClass(st *store.Store, className string, result *IncrementalDiffResult, stagedMap map[string]*store.StagedChange) error {
-	knownObjects, err := st.GetAllKnownObjectsWithHashes()
+		if changes.TotalChanges() == 0 {
+			return nil
+		}
+		return sink(changes)
+	})
 	if err != nil {
 		return err
 	}
 
-	for key, knownInfo := range knownObjects {
-		if knownInfo.Object.Class != className {
-			continue
-		}
+	return scanKnownDeletions(st, className, seen, skip, sink)
+}
 
-		// Skip if already staged
-		if stagedMap[key] != nil {
-			continue
+// scanKnownDeletions pages through the known objects of a class and reports
+// those not in present, or all of them when present is nil, as deleted.
+func scanKnownDeletions(st *store.Store, className string, present map[string]struct{}, skip map[string]*store.StagedChange, sink diffSink) error {
+	after := ""
+	for {
+		page, err := st.ListKnownObjects(className, after, weaviate.ObjectPageSize)
+		if err != nil {
+			return err
+		}
+		if len(page) == 0 {
+			return nil
 		}
 
-		change := &ObjectChange{
-			ClassName:          knownInfo.Object.Class,
-			ObjectID:           knownInfo.Object.ID,
-			PreviousData:       knownInfo.Object,
-			PreviousVectorHash: knownInfo.VectorHash,
+		changes := newDiffResult()
+		for _, knownInfo := range page {
+			if _, exists := present[knownInfo.Object.ID]; exists {
+				continue
+			}
+			// Skip if already staged
+			if skip[models.ObjectKey(className, knownInfo.Object.ID)] != nil {
+				continue
+			}
+			changes.Deleted = append(changes.Deleted, &ObjectChange{
+				ClassName:          knownInfo.Object.TenantClass(),
+				ObjectID:           knownInfo.Object.ID,
+				PreviousData:       knownInfo.Object,
+				PreviousVectorHash: knownInfo.VectorHash,
+			})
+		}
+		if len(changes.Deleted) > 0 {
+			if err := sink(changes); err != nil {
+				return err
+			}
 		}
-		result.Unstaged.Deleted = append(result.Unstaged.Deleted, change)
+		after = page[len(page)-1].Object.ID
 	}
-
-	return nil
 }
 
-// getKnownClasses returns all classes that have known objects.
-// It scans the known_objects bucket and extracts distinct class names from keys
-// formatted as "class:objectID".
-func getKnownClasses(st *store.Store) ([]string, error) {
-	knownObjects, err := st.GetAllKnownObjectsWithHashes()
+// stagedChangeMap returns the staged changes by object key.
+func stagedChangeMap(st *store.Store) (map[string]*store.StagedChange, error) {
+	stagedChanges, err := st.GetAllStagedChanges()
 	if err != nil {
 		return nil, err
 	}
-
-	classSet := make(map[string]bool)
-	for _, info := range knownObjects {
-		if info.Object != nil && info.Object.Class != "" {
-			classSet[info.Object.Class] = true
-		}
-	}
-
-	classes := make([]string, 0, len(classSet))
-	for c := range classSet {
-		classes = append(classes, c)
+	stagedMap := make(map[string]*store.StagedChange, len(stagedChanges))
+	for _, sc := range stagedChanges {
+		stagedMap[models.ObjectKey(sc.ClassName, sc.ObjectID)] = sc
 	}
-	return classes, nil
+	return stagedMap, nil
 }
 
 // ConvertToStagedChange converts an ObjectChange to a StagedChange for storing
@@ -358,5 +296,7 @@ func ConvertToStagedChange(change *ObjectChange, changeType string) *store.Stage
 		StagedAt:           time.Now(),
 		VectorHash:         change.VectorHash,
 		PreviousVectorHash: change.PreviousVectorHash,
+		MovedFrom:          change.MovedFrom,
+		MovedTo:            change.MovedTo,
 	}
 }
@@ -0,0 +1,95 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kilupskalvis/wvc/internal/config"
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scanCountingClient counts the classes whose objects are read.
+type scanCountingClient struct {
+	*weaviate.MockClient
+	scans int
+}
+
+func (c *scanCountingClient) IterateObjects(ctx context.Context, className string, useCursor bool, fn weaviate.ObjectBatchFunc) error {
+	c.scans++
+	return c.MockClient.IterateObjects(ctx, className, useCursor, fn)
+}
+
+func TestComputeIncrementalDiff_SkipsUnchangedClasses(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	mock := weaviate.NewMockClient()
+	client := &scanCountingClient{MockClient: mock}
+
+	mock.AddClass(&models.WeaviateClass{Class: "Article"})
+	mock.AddObject(&models.WeaviateObject{ID: "obj-001", Class: "Article", Properties: map[string]interface{}{"title": "A"}, LastUpdateTimeUnix: 1000})
+	mock.AddObject(&models.WeaviateObject{ID: "obj-002", Class: "Article", Properties: map[string]interface{}{"title": "B"}, LastUpdateTimeUnix: 1001})
+	_, err := CreateCommit(ctx, cfg, st, client, "Two articles")
+	require.NoError(t, err)
+
+	// The first status scans the class and finds it clean; the next ones
+	// only probe its count and latest update time
+	client.scans = 0
+	for i := 0; i < 3; i++ {
+		diff, err := ComputeIncrementalDiff(ctx, cfg, st, client)
+		require.NoError(t, err)
+		assert.Zero(t, diff.TotalUnstagedChanges())
+	}
+	assert.Equal(t, 1, client.scans)
+
+	// core.full_scan compares every object regardless
+	full := newTestConfig()
+	full.Core = &config.CoreConfig{FullScan: true}
+	_, err = ComputeIncrementalDiff(ctx, full, st, client)
+	require.NoError(t, err)
+	assert.Equal(t, 2, client.scans)
+
+	// An update moves the latest update time
+	mock.AddObject(&models.WeaviateObject{ID: "obj-002", Class: "Article", Properties: map[string]interface{}{"title": "C"}, LastUpdateTimeUnix: 2000})
+	diff, err := ComputeIncrementalDiff(ctx, cfg, st, client)
+	require.NoError(t, err)
+	require.Len(t, diff.Unstaged.Updated, 1)
+	assert.Equal(t, "obj-002", diff.Unstaged.Updated[0].ObjectID)
+
+	// A class with changes is scanned until they are committed
+	_, err = ComputeIncrementalDiff(ctx, cfg, st, client)
+	require.NoError(t, err)
+	assert.Equal(t, 4, client.scans)
+	_, err = CreateCommit(ctx, cfg, st, client, "Update")
+	require.NoError(t, err)
+
+	// A deletion lowers the count, and an insert that restores it moves the
+	// latest update time
+	_, err = ComputeIncrementalDiff(ctx, cfg, st, client)
+	require.NoError(t, err)
+	require.NoError(t, mock.DeleteObject(ctx, "Article", "obj-001"))
+	diff, err = ComputeIncrementalDiff(ctx, cfg, st, client)
+	require.NoError(t, err)
+	require.Len(t, diff.Unstaged.Deleted, 1)
+	assert.Equal(t, "obj-001", diff.Unstaged.Deleted[0].ObjectID)
+	mock.AddObject(&models.WeaviateObject{ID: "obj-003", Class: "Article", Properties: map[string]interface{}{"title": "D"}, LastUpdateTimeUnix: 3000})
+	diff, err = ComputeIncrementalDiff(ctx, cfg, st, client)
+	require.NoError(t, err)
+	assert.Len(t, diff.Unstaged.Deleted, 1)
+	assert.Len(t, diff.Unstaged.Inserted, 1)
+
+	// Classes whose objects have no update times are always compared
+	mock.AddClass(&models.WeaviateClass{Class: "Note"})
+	mock.AddObject(&models.WeaviateObject{ID: "note-001", Class: "Note", Properties: map[string]interface{}{"text": "N"}})
+	_, err = CreateCommit(ctx, cfg, st, client, "Note")
+	require.NoError(t, err)
+	client.scans = 0
+	for i := 0; i < 2; i++ {
+		_, err = ComputeIncrementalDiff(ctx, cfg, st, client)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 3, client.scans, "Article once, Note every time")
+}
@@ -333,6 +333,79 @@ func TestRevertCommit_Delete(t *testing.T) {
 	assert.Equal(t, "Test", obj.Properties["title"])
 }
 
+func TestRevertCommit_MergeMainline(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+
+	// Setup: main and feature diverge, then feature is merged into main
+	client.AddClass(&models.WeaviateClass{Class: "Article"})
+	client.AddObject(&models.WeaviateObject{
+		ID:         "obj-001",
+		Class:      "Article",
+		Properties: map[string]interface{}{"title": "Initial"},
+	})
+	_, err := CreateCommit(ctx, cfg, st, client, "Initial")
+	require.NoError(t, err)
+
+	require.NoError(t, CreateBranch(st, "feature", ""))
+	_, err = Checkout(ctx, cfg, st, client, "feature", CheckoutOptions{})
+	require.NoError(t, err)
+	client.AddObject(&models.WeaviateObject{
+		ID:         "obj-002",
+		Class:      "Article",
+		Properties: map[string]interface{}{"title": "Feature"},
+	})
+	client.Objects["Article/obj-001"].Properties["title"] = "Edited"
+	_, err = CreateCommit(ctx, cfg, st, client, "Feature commit")
+	require.NoError(t, err)
+
+	_, err = Checkout(ctx, cfg, st, client, "main", CheckoutOptions{})
+	require.NoError(t, err)
+	client.AddObject(&models.WeaviateObject{
+		ID:         "obj-003",
+		Class:      "Article",
+		Properties: map[string]interface{}{"title": "Main"},
+	})
+	mainCommit, err := CreateCommit(ctx, cfg, st, client, "Main commit")
+	require.NoError(t, err)
+
+	result, err := Merge(ctx, cfg, st, client, "feature", models.MergeOptions{})
+	require.NoError(t, err)
+	require.True(t, result.Success)
+	mergeID := result.MergeCommit.ID
+
+	// Act: a merge cannot be reverted without choosing a mainline parent
+	_, err = RevertCommit(ctx, cfg, st, client, mergeID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "-m 1")
+
+	_, err = RevertCommitMainline(ctx, cfg, st, client, mergeID, 3, nil)
+	require.Error(t, err)
+
+	_, err = RevertCommitMainline(ctx, cfg, st, client, mainCommit.ID, 1, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not a merge")
+
+	revertCommit, err := RevertCommitMainline(ctx, cfg, st, client, mergeID, 1, nil)
+	require.NoError(t, err)
+
+	// Assert: state matches the first parent again
+	assert.Equal(t, mergeID, revertCommit.ParentID)
+	assert.Equal(t, 2, revertCommit.OperationCount)
+
+	_, err = client.GetObject(ctx, "Article", "obj-002")
+	assert.Error(t, err, "object added by the merged branch should be removed")
+
+	obj, err := client.GetObject(ctx, "Article", "obj-001")
+	require.NoError(t, err)
+	assert.Equal(t, "Initial", obj.Properties["title"])
+
+	_, err = client.GetObject(ctx, "Article", "obj-003")
+	assert.NoError(t, err, "mainline object should be kept")
+}
+
 func TestIncrementalDiff_StagedVsUnstaged(t *testing.T) {
 	ctx := context.Background()
 	st := newTestStore(t)
@@ -0,0 +1,101 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/config"
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/store"
+)
+
+// AcquireLock runs the configured acquire command to ask other writers to
+// pause and records the lock as held. The command sees WVC_LOCK_HOLDER and
+// WVC_LOCK_REASON in its environment.
+func AcquireLock(ctx context.Context, cfg *config.Config, st *store.Store, reason string) (*models.WriteLock, error) {
+	if cfg.Lock == nil || cfg.Lock.Acquire == "" {
+		return nil, fmt.Errorf("no write lock configured; set acquire in the [lock] table of .wvc/config")
+	}
+	held, err := st.GetWriteLock()
+	if err != nil {
+		return nil, err
+	}
+	if held != nil {
+		return nil, fmt.Errorf("write lock already held since %s (%s)", held.AcquiredAt.Format(time.RFC3339), held.Reason)
+	}
+
+	lock := &models.WriteLock{Holder: cfg.Author(), Reason: reason, AcquiredAt: time.Now()}
+	if err := runLockCommand(ctx, cfg.Lock.Acquire, lock); err != nil {
+		return nil, fmt.Errorf("acquire write lock: %w", err)
+	}
+	if err := st.SaveWriteLock(lock); err != nil {
+		return nil, err
+	}
+	return lock, nil
+}
+
+// ReleaseLock runs the configured release command to let other writers resume
+// and records the lock as released. With force, the lock is recorded as
+// released even if the release command fails.
+func ReleaseLock(ctx context.Context, cfg *config.Config, st *store.Store, force bool) error {
+	held, err := st.GetWriteLock()
+	if err != nil {
+		return err
+	}
+	if held == nil {
+		return fmt.Errorf("write lock is not held")
+	}
+	if cfg.Lock != nil && cfg.Lock.Release != "" {
+		if err := runLockCommand(ctx, cfg.Lock.Release, held); err != nil && !force {
+			return fmt.Errorf("release write lock: %w (use --force to forget the lock anyway)", err)
+		}
+	}
+	return st.ClearWriteLock()
+}
+
+// HoldLock acquires the write lock for the duration of a command listed in
+// hold_during and returns the function that releases it. When the command
+// is not listed, or the lock is already held, the returned function does nothing.
+func HoldLock(ctx context.Context, cfg *config.Config, st *store.Store, command string) (func() error, error) {
+	noop := func() error { return nil }
+	if cfg.Lock == nil || !slices.Contains(cfg.Lock.HoldDuring, command) {
+		return noop, nil
+	}
+	held, err := st.GetWriteLock()
+	if err != nil {
+		return nil, err
+	}
+	if held != nil {
+		return noop, nil
+	}
+	if _, err := AcquireLock(ctx, cfg, st, "wvc "+command); err != nil {
+		return nil, err
+	}
+	return func() error {
+		return ReleaseLock(context.WithoutCancel(ctx), cfg, st, false)
+	}, nil
+}
+
+// runLockCommand runs a lock hook, passing its output through.
+func runLockCommand(ctx context.Context, command string, lock *models.WriteLock) error {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil
+	}
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Env = append(os.Environ(),
+		"WVC_LOCK_HOLDER="+lock.Holder,
+		"WVC_LOCK_REASON="+lock.Reason,
+	)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("'%s' failed: %w", command, err)
+	}
+	return nil
+}
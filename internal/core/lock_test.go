@@ -0,0 +1,84 @@
+package core
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/kilupskalvis/wvc/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcquireLock_RunsHooks(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	flag := filepath.Join(t.TempDir(), "paused")
+	cfg := newTestConfig()
+	cfg.Lock = &config.LockConfig{Acquire: "touch " + flag, Release: "rm " + flag}
+
+	lock, err := AcquireLock(ctx, cfg, st, "snapshot")
+	require.NoError(t, err)
+	assert.Equal(t, "snapshot", lock.Reason)
+	assert.FileExists(t, flag)
+
+	held, err := st.GetWriteLock()
+	require.NoError(t, err)
+	require.NotNil(t, held)
+	assert.Equal(t, "snapshot", held.Reason)
+
+	_, err = AcquireLock(ctx, cfg, st, "again")
+	assert.ErrorContains(t, err, "already held")
+
+	require.NoError(t, ReleaseLock(ctx, cfg, st, false))
+	assert.NoFileExists(t, flag)
+	held, err = st.GetWriteLock()
+	require.NoError(t, err)
+	assert.Nil(t, held)
+
+	assert.ErrorContains(t, ReleaseLock(ctx, cfg, st, false), "not held")
+}
+
+func TestAcquireLock_FailingHook(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+
+	_, err := AcquireLock(ctx, cfg, st, "")
+	assert.ErrorContains(t, err, "no write lock configured")
+
+	cfg.Lock = &config.LockConfig{Acquire: "false"}
+	_, err = AcquireLock(ctx, cfg, st, "")
+	require.Error(t, err)
+	held, err := st.GetWriteLock()
+	require.NoError(t, err)
+	assert.Nil(t, held, "a failed acquire leaves the lock free")
+}
+
+func TestHoldLock(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	flag := filepath.Join(t.TempDir(), "paused")
+	cfg := newTestConfig()
+	cfg.Lock = &config.LockConfig{Acquire: "touch " + flag, Release: "rm " + flag, HoldDuring: []string{"commit"}}
+
+	// Commands not listed don't take the lock
+	release, err := HoldLock(ctx, cfg, st, "checkout")
+	require.NoError(t, err)
+	require.NoError(t, release())
+	assert.NoFileExists(t, flag)
+
+	release, err = HoldLock(ctx, cfg, st, "commit")
+	require.NoError(t, err)
+	assert.FileExists(t, flag)
+	require.NoError(t, release())
+	assert.NoFileExists(t, flag)
+
+	// A lock acquired by hand outlives the commands that run under it
+	_, err = AcquireLock(ctx, cfg, st, "manual")
+	require.NoError(t, err)
+	release, err = HoldLock(ctx, cfg, st, "commit")
+	require.NoError(t, err)
+	require.NoError(t, release())
+	assert.FileExists(t, flag)
+}
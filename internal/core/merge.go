@@ -4,7 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/kilupskalvis/wvc/internal/config"
@@ -17,7 +20,15 @@ import (
 func Merge(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface, targetBranch string, opts models.MergeOptions) (*models.MergeResult, error) {
 	result := &models.MergeResult{Warnings: []string{}}
 
-	// Step 1: Validate we're on a branch
+	// Step 1: Validate no merge is in progress and we're on a branch
+	inProgress, err := st.GetMergeState()
+	if err != nil {
+		return nil, err
+	}
+	if inProgress != nil {
+		return nil, fmt.Errorf("cannot merge: a merge of '%s' is in progress; use 'wvc merge --continue' or 'wvc merge --abort'", inProgress.TargetBranch)
+	}
+
 	currentBranch, err := st.GetCurrentBranch()
 	if err != nil {
 		return nil, err
@@ -134,7 +145,11 @@ func canFastForward(st *store.Store, ourHead, theirHead string) (bool, error) {
 // performFastForward performs a fast-forward merge
 func performFastForward(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface, currentBranch, targetCommitID string, result *models.MergeResult) (*models.MergeResult, error) {
 	// Use existing checkout logic to restore state
-	warnings, stats, err := restoreStateToCommit(ctx, cfg, st, client, targetCommitID)
+	scope, err := currentScope(st)
+	if err != nil {
+		return nil, err
+	}
+	warnings, stats, err := restoreStateToCommit(ctx, cfg, st, client, targetCommitID, scope)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fast-forward: %w", err)
 	}
@@ -162,31 +177,43 @@ func performFastForward(ctx context.Context, cfg *config.Config, st *store.Store
 	return result, nil
 }
 
-// performThreeWayMerge performs a 3-way merge
+// performThreeWayMerge performs a 3-way merge. When it stops on conflicts the
+// merge state is persisted so it can be resolved and continued later.
 func performThreeWayMerge(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface, ourHead, theirHead, mergeBase, currentBranch, targetBranch string, opts models.MergeOptions, result *models.MergeResult) (*models.MergeResult, error) {
-	// Reconstruct states at all three points
-	baseState, err := reconstructStateAtCommit(st, mergeBase)
+	states, err := loadMergeStates(cfg, st, mergeBase, ourHead, theirHead)
 	if err != nil {
-		return nil, fmt.Errorf("failed to reconstruct base state: %w", err)
+		return nil, err
 	}
 
-	oursState, err := reconstructStateAtCommit(st, ourHead)
-	if err != nil {
-		return nil, fmt.Errorf("failed to reconstruct our state: %w", err)
-	}
+	// Detect conflicts
+	conflicts := detectObjectConflicts(states)
 
-	theirsState, err := reconstructStateAtCommit(st, theirHead)
-	if err != nil {
-		return nil, fmt.Errorf("failed to reconstruct their state: %w", err)
+	message := opts.Message
+	if message == "" {
+		message = fmt.Sprintf("Merge branch '%s' into %s", targetBranch, currentBranch)
 	}
 
-	// Detect conflicts
-	conflicts := detectObjectConflicts(baseState, oursState, theirsState)
-
 	// Handle conflicts based on strategy
 	if len(conflicts) > 0 {
-		if opts.Strategy == models.ConflictAbort || opts.Strategy == "" {
-			// Abort: return conflicts without merging
+		switch opts.Strategy {
+		case models.ConflictAbort:
+			result.Success = false
+			result.Conflicts = conflicts
+			return result, nil
+		case models.ConflictManual, "":
+			// Stop without merging, remembering where we were (MERGE_HEAD)
+			state := &models.MergeState{
+				TargetBranch: targetBranch,
+				OurHead:      ourHead,
+				TheirHead:    theirHead,
+				MergeBase:    mergeBase,
+				Message:      message,
+				Conflicts:    conflicts,
+				StartedAt:    time.Now(),
+			}
+			if err := st.SaveMergeState(state); err != nil {
+				return nil, fmt.Errorf("failed to save merge state: %w", err)
+			}
 			result.Success = false
 			result.Conflicts = conflicts
 			return result, nil
@@ -194,7 +221,7 @@ func performThreeWayMerge(ctx context.Context, cfg *config.Config, st *store.Sto
 	}
 
 	// Compute merged state (non-conflicting changes)
-	mergedState := computeMergedState(baseState, oursState, theirsState)
+	mergedState := computeMergedState(states)
 
 	// Resolve conflicts if using --ours or --theirs
 	if len(conflicts) > 0 && (opts.Strategy == models.ConflictOurs || opts.Strategy == models.ConflictTheirs) {
@@ -202,19 +229,77 @@ func performThreeWayMerge(ctx context.Context, cfg *config.Config, st *store.Sto
 		result.ResolvedConflicts = resolved
 	}
 
+	return finishThreeWayMerge(ctx, cfg, st, client, states, mergedState, ourHead, theirHead, currentBranch, message, result)
+}
+
+// loadMergeStates reconstructs and hashes the base, ours, and theirs states,
+// restricted to the current branch's classes when it is class-scoped.
+// Objects of the configured append-only classes only one state holds are
+// not hashed.
+func loadMergeStates(cfg *config.Config, st *store.Store, mergeBase, ourHead, theirHead string) (*mergeStates, error) {
+	// Reconstruct states at all three points
+	baseState, err := reconstructStateAtCommit(st, mergeBase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconstruct base state: %w", err)
+	}
+
+	oursState, err := reconstructStateAtCommit(st, ourHead)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconstruct our state: %w", err)
+	}
+
+	theirsState, err := reconstructStateAtCommit(st, theirHead)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconstruct their state: %w", err)
+	}
+
+	// On a class-scoped branch, classes outside the scope keep our version:
+	// pinning base and theirs to ours means no change is seen there
+	scope, err := currentScope(st)
+	if err != nil {
+		return nil, err
+	}
+	if scope != nil {
+		for key := range unionKeys(baseState, oursState, theirsState) {
+			if scope.includesKey(key) {
+				continue
+			}
+			if ours, ok := oursState[key]; ok {
+				baseState[key] = ours
+				theirsState[key] = ours
+			} else {
+				delete(baseState, key)
+				delete(theirsState, key)
+			}
+		}
+	}
+
+	// Hash all three states once, in parallel, reusing hashes from known_objects
+	// where the payload is provably unchanged. Both conflict detection and the
+	// merged-state computation work from these precomputed hashes.
+	known, err := st.GetAllKnownObjectsWithHashes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load known objects: %w", err)
+	}
+	return newMergeStates(baseState, oursState, theirsState, known, cfg.AppendOnlyClasses()), nil
+}
+
+// finishThreeWayMerge applies the merged state to Weaviate, creates the merge
+// commit, and advances the current branch.
+func finishThreeWayMerge(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface, states *mergeStates, mergedState map[string]*objectWithVector, ourHead, theirHead, currentBranch, message string, result *models.MergeResult) (*models.MergeResult, error) {
 	// Apply merged state to Weaviate
-	stats, err := applyMergedState(ctx, st, client, oursState, mergedState)
+	stats, err := applyMergedState(ctx, cfg, st, client, states.ours, mergedState)
 	if err != nil {
 		return nil, err
 	}
 
-	// Create merge commit
-	message := opts.Message
-	if message == "" {
-		message = fmt.Sprintf("Merge branch '%s' into %s", targetBranch, currentBranch)
+	// Record what the merge changed relative to each parent
+	summaries := []models.ParentChangeSummary{
+		{ParentID: ourHead, Added: stats.Added, Updated: stats.Updated, Deleted: stats.Removed},
+		summarizeStateChange(theirHead, states.theirs, mergedState),
 	}
 
-	mergeCommit, err := createMergeCommit(ctx, cfg, st, client, ourHead, theirHead, message, stats)
+	mergeCommit, err := createMergeCommit(ctx, cfg, st, client, ourHead, theirHead, message, stats, summaries)
 	if err != nil {
 		return nil, err
 	}
@@ -234,30 +319,273 @@ func performThreeWayMerge(ctx context.Context, cfg *config.Config, st *store.Sto
 	return result, nil
 }
 
-// detectObjectConflicts detects conflicts between three states
-func detectObjectConflicts(baseState, oursState, theirsState map[string]*objectWithVector) []*models.MergeConflict {
-	var conflicts []*models.MergeConflict
+// MergeContinue completes a merge that stopped on conflicts. strategy, when
+// set to ours or theirs, resolves every conflict that has no recorded
+// resolution; otherwise all conflicts must already be resolved.
+func MergeContinue(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface, strategy models.ConflictStrategy) (*models.MergeResult, error) {
+	state, err := st.GetMergeState()
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		return nil, fmt.Errorf("no merge in progress")
+	}
+
+	if strategy == models.ConflictOurs || strategy == models.ConflictTheirs {
+		if state.Resolutions == nil {
+			state.Resolutions = make(map[string]models.ConflictStrategy)
+		}
+		for _, c := range state.Unresolved() {
+			state.Resolutions[c.Key] = strategy
+		}
+	}
+	if remaining := len(state.Unresolved()); remaining > 0 {
+		return nil, fmt.Errorf("cannot continue: %d conflict(s) remaining; resolve them with 'wvc conflicts resolve <object> --ours|--theirs|--edit'", remaining)
+	}
+
+	currentBranch, err := st.GetCurrentBranch()
+	if err != nil {
+		return nil, err
+	}
+	if currentBranch == "" {
+		return nil, fmt.Errorf("cannot continue merge: HEAD is detached")
+	}
+	head, err := st.GetHEAD()
+	if err != nil {
+		return nil, err
+	}
+	if head != state.OurHead {
+		return nil, fmt.Errorf("cannot continue merge: HEAD has moved since the merge started; run 'wvc merge --abort'")
+	}
+
+	hasChanges, err := HasUncommittedChanges(ctx, cfg, st, client)
+	if err != nil {
+		return nil, err
+	}
+	if hasChanges {
+		return nil, fmt.Errorf("cannot continue merge: you have uncommitted changes")
+	}
+
+	states, err := loadMergeStates(cfg, st, state.MergeBase, state.OurHead, state.TheirHead)
+	if err != nil {
+		return nil, err
+	}
+
+	mergedState := computeMergedState(states)
+	result := &models.MergeResult{Warnings: []string{}}
+	for _, c := range detectObjectConflicts(states) {
+		side, ok := state.Resolutions[c.Key]
+		if !ok {
+			return nil, fmt.Errorf("conflict on %s has no resolution; run 'wvc merge --abort' and merge again", c.Key)
+		}
+		if side == models.ConflictEdited {
+			edited := state.Edits[c.Key]
+			if edited == nil {
+				return nil, fmt.Errorf("edited resolution of %s is missing; resolve it again", c.Key)
+			}
+			mergedState[c.Key] = &objectWithVector{Object: edited, VectorHash: editedVectorHash(c)}
+		} else {
+			resolveConflicts([]*models.MergeConflict{c}, side, mergedState)
+		}
+		result.ResolvedConflicts++
+	}
+
+	result, err = finishThreeWayMerge(ctx, cfg, st, client, states, mergedState, state.OurHead, state.TheirHead, currentBranch, state.Message, result)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := st.ClearMergeState(); err != nil {
+		return nil, fmt.Errorf("failed to clear merge state: %w", err)
+	}
+	return result, nil
+}
+
+// MergeResolve records which side to keep for one conflict of the in-progress
+// merge. object is either the conflict key ("Class/ID") or the bare object ID.
+func MergeResolve(st *store.Store, object string, side models.ConflictStrategy) (*models.MergeConflict, error) {
+	if side != models.ConflictOurs && side != models.ConflictTheirs {
+		return nil, fmt.Errorf("a conflict must be resolved with ours or theirs")
+	}
+
+	state, err := st.GetMergeState()
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		return nil, fmt.Errorf("no merge in progress")
+	}
 
-	// Collect all unique keys
-	allKeys := make(map[string]bool)
-	for k := range baseState {
-		allKeys[k] = true
+	match, err := findConflict(state, object)
+	if err != nil {
+		return nil, err
+	}
+
+	if state.Resolutions == nil {
+		state.Resolutions = make(map[string]models.ConflictStrategy)
+	}
+	state.Resolutions[match.Key] = side
+	delete(state.Edits, match.Key)
+	if err := st.SaveMergeState(state); err != nil {
+		return nil, fmt.Errorf("failed to save merge state: %w", err)
+	}
+	return match, nil
+}
+
+// MergeAbort discards the in-progress merge. Conflicted merges leave Weaviate
+// untouched, so only the recorded state needs to be removed.
+func MergeAbort(st *store.Store) (*models.MergeState, error) {
+	state, err := st.GetMergeState()
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		return nil, fmt.Errorf("no merge in progress")
+	}
+	if err := st.ClearMergeState(); err != nil {
+		return nil, fmt.Errorf("failed to clear merge state: %w", err)
+	}
+	return state, nil
+}
+
+// mergeStates holds the base, ours, and theirs states of a three-way merge
+// together with the precomputed hash of every object in each state.
+type mergeStates struct {
+	base, ours, theirs                   map[string]*objectWithVector
+	baseHashes, oursHashes, theirsHashes map[string]string
+}
+
+// appendOnlyHash stands in for the hash of an object of an append-only class
+// that only one of the three states holds: an object one side added, or one
+// both sides deleted. Only its presence matters to the merge, so it is never
+// hashed. Objects also held by another state are hashed as usual, so updates
+// to them merge and an object both sides added under the same ID with
+// different content still conflicts.
+const appendOnlyHash = "append-only"
+
+// newMergeStates hashes the three states concurrently. known may be nil; when
+// provided, its stored hashes are reused for objects whose payload matches.
+// Objects of the classes in appendOnly held by a single state get
+// appendOnlyHash instead of a hash.
+func newMergeStates(base, ours, theirs map[string]*objectWithVector, known map[string]*models.KnownObjectInfo, appendOnly map[string]bool) *mergeStates {
+	s := &mergeStates{base: base, ours: ours, theirs: theirs}
+
+	var skipBase, skipOurs, skipTheirs func(key string) bool
+	if len(appendOnly) > 0 {
+		// solo reports whether key is an append-only object none of others hold
+		solo := func(key string, others ...map[string]*objectWithVector) bool {
+			class, _, _ := strings.Cut(key, "/")
+			if class, _ = models.SplitTenantClass(class); !appendOnly[class] {
+				return false
+			}
+			for _, other := range others {
+				if _, ok := other[key]; ok {
+					return false
+				}
+			}
+			return true
+		}
+		skipBase = func(key string) bool { return solo(key, ours, theirs) }
+		skipOurs = func(key string) bool { return solo(key, base, theirs) }
+		skipTheirs = func(key string) bool { return solo(key, base, ours) }
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() { defer wg.Done(); s.baseHashes = hashStateParallel(base, known, skipBase) }()
+	go func() { defer wg.Done(); s.oursHashes = hashStateParallel(ours, known, skipOurs) }()
+	go func() { defer wg.Done(); s.theirsHashes = hashStateParallel(theirs, known, skipTheirs) }()
+	wg.Wait()
+
+	return s
+}
+
+// hashStateParallel computes hashObjWithVec for every object in state,
+// sharding the key space across GOMAXPROCS workers. Objects for which skip
+// returns true get appendOnlyHash instead; skip may be nil.
+func hashStateParallel(state map[string]*objectWithVector, known map[string]*models.KnownObjectInfo, skip func(key string) bool) map[string]string {
+	keys := make([]string, 0, len(state))
+	for k := range state {
+		keys = append(keys, k)
+	}
+
+	hashes := make([]string, len(keys))
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(keys) {
+		workers = len(keys)
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(shard int) {
+			defer wg.Done()
+			for i := shard; i < len(keys); i += workers {
+				if skip != nil && skip(keys[i]) {
+					hashes[i] = appendOnlyHash
+					continue
+				}
+				hashes[i] = cachedObjHash(state[keys[i]], known[keys[i]])
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	result := make(map[string]string, len(keys))
+	for i, k := range keys {
+		result[k] = hashes[i]
+	}
+	return result
+}
+
+// cachedObjHash returns the known_objects hash for obj when the known record is
+// the same payload (same vector and same non-zero Weaviate update timestamp),
+// and computes the hash otherwise.
+func cachedObjHash(obj *objectWithVector, known *models.KnownObjectInfo) string {
+	if obj == nil || obj.Object == nil {
+		return ""
 	}
-	for k := range oursState {
-		allKeys[k] = true
+	if known != nil && known.Object != nil && known.ObjectHash != "" &&
+		obj.Object.LastUpdateTimeUnix != 0 &&
+		known.Object.LastUpdateTimeUnix == obj.Object.LastUpdateTimeUnix &&
+		known.VectorHash == obj.VectorHash {
+		if obj.VectorHash != "" {
+			return known.ObjectHash + ":" + obj.VectorHash
+		}
+		return known.ObjectHash
 	}
-	for k := range theirsState {
-		allKeys[k] = true
+	return hashObjWithVec(obj)
+}
+
+// unionKeys returns the union of keys across the given states.
+func unionKeys(states ...map[string]*objectWithVector) map[string]bool {
+	keys := make(map[string]bool)
+	for _, st := range states {
+		for k := range st {
+			keys[k] = true
+		}
 	}
+	return keys
+}
 
-	for key := range allKeys {
-		base := baseState[key]
-		ours := oursState[key]
-		theirs := theirsState[key]
+// sortedKeys returns the keys of a map in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
 
-		baseHash := hashObjWithVec(base)
-		oursHash := hashObjWithVec(ours)
-		theirsHash := hashObjWithVec(theirs)
+// detectObjectConflicts detects conflicts between three states
+func detectObjectConflicts(s *mergeStates) []*models.MergeConflict {
+	var conflicts []*models.MergeConflict
+
+	for _, key := range sortedKeys(unionKeys(s.base, s.ours, s.theirs)) {
+		baseHash := s.baseHashes[key]
+		oursHash := s.oursHashes[key]
+		theirsHash := s.theirsHashes[key]
 
 		// No conflict if unchanged in at least one branch
 		if oursHash == baseHash || theirsHash == baseHash {
@@ -269,6 +597,10 @@ func detectObjectConflicts(baseState, oursState, theirsState map[string]*objectW
 			continue
 		}
 
+		base := s.base[key]
+		ours := s.ours[key]
+		theirs := s.theirs[key]
+
 		// Conflict detected
 		conflict := &models.MergeConflict{Key: key}
 
@@ -292,13 +624,13 @@ func detectObjectConflicts(baseState, oursState, theirsState map[string]*objectW
 
 		// Set objects
 		if base != nil {
-			conflict.Base = base.Object
+			conflict.Base, conflict.BaseVectorHash = base.Object, base.VectorHash
 		}
 		if ours != nil {
-			conflict.Ours = ours.Object
+			conflict.Ours, conflict.OursVectorHash = ours.Object, ours.VectorHash
 		}
 		if theirs != nil {
-			conflict.Theirs = theirs.Object
+			conflict.Theirs, conflict.TheirsVectorHash = theirs.Object, theirs.VectorHash
 		}
 
 		conflicts = append(conflicts, conflict)
@@ -308,35 +640,22 @@ func detectObjectConflicts(baseState, oursState, theirsState map[string]*objectW
 }
 
 // computeMergedState computes the merged state from three states (excluding conflicts)
-func computeMergedState(baseState, oursState, theirsState map[string]*objectWithVector) map[string]*objectWithVector {
-	merged := make(map[string]*objectWithVector)
+func computeMergedState(s *mergeStates) map[string]*objectWithVector {
+	merged := make(map[string]*objectWithVector, len(s.ours))
 
 	// Copy our state as starting point
-	for k, v := range oursState {
+	for k, v := range s.ours {
 		merged[k] = v
 	}
 
-	// Collect all unique keys
-	allKeys := make(map[string]bool)
-	for k := range baseState {
-		allKeys[k] = true
-	}
-	for k := range theirsState {
-		allKeys[k] = true
-	}
-
-	for key := range allKeys {
-		base := baseState[key]
-		theirs := theirsState[key]
-		ours := oursState[key]
-
-		baseHash := hashObjWithVec(base)
-		theirsHash := hashObjWithVec(theirs)
-		oursHash := hashObjWithVec(ours)
+	for key := range unionKeys(s.base, s.theirs) {
+		baseHash := s.baseHashes[key]
+		theirsHash := s.theirsHashes[key]
+		oursHash := s.oursHashes[key]
 
 		// If they changed and we didn't, take theirs
 		if oursHash == baseHash && theirsHash != baseHash {
-			if theirs != nil {
+			if theirs := s.theirs[key]; theirs != nil {
 				merged[key] = theirs
 			} else {
 				delete(merged, key)
@@ -357,14 +676,14 @@ func resolveConflicts(conflicts []*models.MergeConflict, strategy models.Conflic
 		switch strategy {
 		case models.ConflictOurs:
 			if c.Ours != nil {
-				merged[c.Key] = &objectWithVector{Object: c.Ours}
+				merged[c.Key] = &objectWithVector{Object: c.Ours, VectorHash: c.OursVectorHash}
 			} else {
 				delete(merged, c.Key) // We deleted it
 			}
 			resolved++
 		case models.ConflictTheirs:
 			if c.Theirs != nil {
-				merged[c.Key] = &objectWithVector{Object: c.Theirs}
+				merged[c.Key] = &objectWithVector{Object: c.Theirs, VectorHash: c.TheirsVectorHash}
 			} else {
 				delete(merged, c.Key) // They deleted it
 			}
@@ -374,11 +693,21 @@ func resolveConflicts(conflicts []*models.MergeConflict, strategy models.Conflic
 	return resolved
 }
 
-// applyMergedState applies the merged state to Weaviate
-func applyMergedState(ctx context.Context, st *store.Store, client weaviate.ClientInterface, currentState, mergedState map[string]*objectWithVector) (*StateRestoreStats, error) {
+// applyMergedState applies the merged state to Weaviate in batches.
+// Operations for the applied changes are recorded in one batch at the end,
+// including on partial failure, so the log still matches what was written to
+// Weaviate.
+func applyMergedState(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface, currentState, mergedState map[string]*objectWithVector) (_ *StateRestoreStats, err error) {
 	stats := &StateRestoreStats{}
 	now := time.Now()
 
+	var ops []*models.Operation
+	defer func() {
+		if recErr := st.RecordOperations(ops); recErr != nil && err == nil {
+			err = recErr
+		}
+	}()
+
 	// Compute what needs to change
 	toDelete := make(map[string]*objectWithVector)
 	toCreate := make(map[string]*objectWithVector)
@@ -406,107 +735,134 @@ func applyMergedState(ctx context.Context, st *store.Store, client weaviate.Clie
 		}
 	}
 
+	// apply writes one kind of change and records an operation for each
+	// object that was written. The first failure, in key order, is returned.
+	apply := func(action models.ApplyAction, objs map[string]*objectWithVector, record func(key string, objWithVec *objectWithVector)) error {
+		keys := sortedKeys(objs)
+		writes := make([]*objectWrite, len(keys))
+		for i, key := range keys {
+			if action != models.ApplyDelete {
+				objs[key].restoreVectors(st)
+			}
+			writes[i] = &objectWrite{Action: action, Object: objs[key].Object}
+		}
+		if err := applyObjectWrites(ctx, cfg, client, writes); err != nil {
+			return err
+		}
+		var firstErr error
+		for i, w := range writes {
+			if w.Err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("failed to %s %s: %w", action, keys[i], w.Err)
+				}
+				continue
+			}
+			record(keys[i], objs[keys[i]])
+		}
+		return firstErr
+	}
+
 	// Apply deletions
-	for key, objWithVec := range toDelete {
+	if err := apply(models.ApplyDelete, toDelete, func(key string, objWithVec *objectWithVector) {
 		obj := objWithVec.Object
-		if err := client.DeleteObject(ctx, obj.Class, obj.ID); err != nil {
-			return stats, fmt.Errorf("failed to delete %s: %w", key, err)
-		}
-		// Record operation
 		data, _ := json.Marshal(obj)
-		op := &models.Operation{
+		ops = append(ops, &models.Operation{
 			Timestamp:    now,
 			Type:         models.OperationDelete,
-			ClassName:    obj.Class,
+			ClassName:    obj.TenantClass(),
 			ObjectID:     obj.ID,
 			PreviousData: data,
-		}
-		if err := st.RecordOperation(op); err != nil {
-			return stats, err
-		}
+		})
 		stats.Removed++
+	}); err != nil {
+		return stats, err
 	}
 
 	// Apply creations
-	for key, objWithVec := range toCreate {
+	if err := apply(models.ApplyCreate, toCreate, func(key string, objWithVec *objectWithVector) {
 		obj := objWithVec.Object
-		restoreObjectVector(st, obj, objWithVec.VectorHash)
-		if err := client.CreateObject(ctx, obj); err != nil {
-			return stats, fmt.Errorf("failed to create %s: %w", key, err)
-		}
-		// Record operation
 		data, _ := json.Marshal(obj)
-		op := &models.Operation{
-			Timestamp:  now,
-			Type:       models.OperationInsert,
-			ClassName:  obj.Class,
-			ObjectID:   obj.ID,
-			ObjectData: data,
-			VectorHash: objWithVec.VectorHash,
-		}
-		if err := st.RecordOperation(op); err != nil {
-			return stats, err
-		}
+		ops = append(ops, &models.Operation{
+			Timestamp:         now,
+			Type:              models.OperationInsert,
+			ClassName:         obj.TenantClass(),
+			ObjectID:          obj.ID,
+			ObjectData:        data,
+			VectorHash:        objWithVec.VectorHash,
+			NamedVectorHashes: objWithVec.NamedVectorHashes,
+		})
 		stats.Added++
+	}); err != nil {
+		return stats, err
 	}
 
 	// Apply updates
-	for key, objWithVec := range toUpdate {
+	if err := apply(models.ApplyUpdate, toUpdate, func(key string, objWithVec *objectWithVector) {
 		obj := objWithVec.Object
-		restoreObjectVector(st, obj, objWithVec.VectorHash)
-		if err := client.UpdateObject(ctx, obj); err != nil {
-			return stats, fmt.Errorf("failed to update %s: %w", key, err)
-		}
-		// Record operation
 		currentObj := currentState[key]
 		prevData, _ := json.Marshal(currentObj.Object)
 		newData, _ := json.Marshal(obj)
-		op := &models.Operation{
-			Timestamp:          now,
-			Type:               models.OperationUpdate,
-			ClassName:          obj.Class,
-			ObjectID:           obj.ID,
-			ObjectData:         newData,
-			PreviousData:       prevData,
-			VectorHash:         objWithVec.VectorHash,
-			PreviousVectorHash: currentObj.VectorHash,
-		}
-		if err := st.RecordOperation(op); err != nil {
-			return stats, err
-		}
+		ops = append(ops, &models.Operation{
+			Timestamp:                 now,
+			Type:                      models.OperationUpdate,
+			ClassName:                 obj.TenantClass(),
+			ObjectID:                  obj.ID,
+			ObjectData:                newData,
+			PreviousData:              prevData,
+			VectorHash:                objWithVec.VectorHash,
+			PreviousVectorHash:        currentObj.VectorHash,
+			NamedVectorHashes:         objWithVec.NamedVectorHashes,
+			PreviousNamedVectorHashes: currentObj.NamedVectorHashes,
+		})
 		stats.Updated++
+	}); err != nil {
+		return stats, err
 	}
 
 	return stats, nil
 }
 
 // createMergeCommit creates a merge commit with two parents
-func createMergeCommit(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface, parent1, parent2, message string, stats *StateRestoreStats) (*models.Commit, error) {
-	now := time.Now()
-
+func createMergeCommit(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface, parent1, parent2, message string, stats *StateRestoreStats, summaries []models.ParentChangeSummary) (*models.Commit, error) {
 	// Get uncommitted operations for content-addressable commit ID
 	uncommittedOps, err := st.GetUncommittedOperations()
 	if err != nil {
 		return nil, err
 	}
+	// Submodule pins are not merged: the merge keeps ours
+	pins, err := st.GetSubmodulePins()
+	if err != nil {
+		return nil, err
+	}
+	classifications, err := commitClassifications(cfg, uncommittedOps)
+	if err != nil {
+		return nil, err
+	}
 
-	// Generate commit ID — for merges, include both parents in the hash
-	commitID := models.GenerateMergeCommitID(message, now, parent1, parent2, uncommittedOps)
+	commit := &models.Commit{
+		ParentID:        parent1,
+		MergeParentID:   parent2,
+		Message:         message,
+		Author:          cfg.Author(),
+		Timestamp:       time.Now(),
+		OperationCount:  stats.Added + stats.Updated + stats.Removed,
+		ParentSummaries: summaries,
+		HashVersion:     models.CurrentCommitHashVersion,
+		Submodules:      pins,
+		Classifications: classifications,
+	}
+
+	// Generate commit ID — for merges, both parents are part of the hash
+	commit.ID, err = commit.ComputeID(uncommittedOps)
+	if err != nil {
+		return nil, fmt.Errorf("compute commit ID: %w", err)
+	}
 
 	// Capture schema snapshot
-	if err := captureSchemaSnapshot(ctx, st, client, commitID); err != nil {
+	if err := captureSchemaSnapshot(ctx, cfg, st, client, commit.ID); err != nil {
 		// Non-fatal
 	}
 
-	commit := &models.Commit{
-		ID:             commitID,
-		ParentID:       parent1,
-		MergeParentID:  parent2,
-		Message:        message,
-		Timestamp:      now,
-		OperationCount: stats.Added + stats.Updated + stats.Removed,
-	}
-
 	// Atomically: mark operations committed, create commit, set HEAD, update branch
 	branchName, _ := st.GetCurrentBranch()
 	branchExists := false
@@ -527,13 +883,38 @@ func createMergeCommit(ctx context.Context, cfg *config.Config, st *store.Store,
 	return commit, nil
 }
 
+// summarizeStateChange counts the objects added, updated, and deleted going
+// from a parent's state to the merged state.
+func summarizeStateChange(parentID string, parent, merged map[string]*objectWithVector) models.ParentChangeSummary {
+	summary := models.ParentChangeSummary{ParentID: parentID}
+	for key := range unionKeys(parent, merged) {
+		before, after := parent[key], merged[key]
+		switch {
+		case before == nil:
+			summary.Added++
+		case after == nil:
+			summary.Deleted++
+		case hashObjWithVec(before) != hashObjWithVec(after):
+			summary.Updated++
+		}
+	}
+	return summary
+}
+
+// storedHashes caches the hashes of objects reconstructed from commits. Live
+// objects are hashed through the cache of the client that read them, which
+// belongs to one Weaviate instance.
+var storedHashes = weaviate.NewHashCache(weaviate.DefaultHashCacheSize)
+
 // hashObjWithVec returns a hash for an objectWithVector (or empty string if nil).
 // Includes the vector hash so that vector-only changes are detected as conflicts.
 func hashObjWithVec(obj *objectWithVector) string {
 	if obj == nil || obj.Object == nil {
 		return ""
 	}
-	hash, _ := weaviate.HashObjectFull(obj.Object)
+	// Base, ours, and theirs mostly share object versions, so the cache
+	// turns most of these into lookups.
+	hash, _ := storedHashes.HashObjectFull(obj.Object)
 	if obj.VectorHash != "" {
 		hash = hash + ":" + obj.VectorHash
 	}
@@ -2,9 +2,12 @@ package core
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
+	"github.com/kilupskalvis/wvc/internal/config"
 	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/store"
 	"github.com/kilupskalvis/wvc/internal/weaviate"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -115,7 +118,7 @@ func TestDetectObjectConflicts_NoConflict(t *testing.T) {
 		"Article/obj-003": {Object: &models.WeaviateObject{ID: "obj-003", Class: "Article", Properties: map[string]interface{}{"title": "Theirs"}}},
 	}
 
-	conflicts := detectObjectConflicts(baseState, oursState, theirsState)
+	conflicts := detectObjectConflicts(newMergeStates(baseState, oursState, theirsState, nil, nil))
 	assert.Len(t, conflicts, 0)
 }
 
@@ -131,7 +134,7 @@ func TestDetectObjectConflicts_ModifyModify(t *testing.T) {
 		"Article/obj-001": {Object: &models.WeaviateObject{ID: "obj-001", Class: "Article", Properties: map[string]interface{}{"title": "Theirs"}}},
 	}
 
-	conflicts := detectObjectConflicts(baseState, oursState, theirsState)
+	conflicts := detectObjectConflicts(newMergeStates(baseState, oursState, theirsState, nil, nil))
 	require.Len(t, conflicts, 1)
 	assert.Equal(t, models.ConflictModifyModify, conflicts[0].Type)
 	assert.Equal(t, "Article", conflicts[0].ClassName)
@@ -148,7 +151,7 @@ func TestDetectObjectConflicts_DeleteModify(t *testing.T) {
 		"Article/obj-001": {Object: &models.WeaviateObject{ID: "obj-001", Class: "Article", Properties: map[string]interface{}{"title": "Modified"}}},
 	}
 
-	conflicts := detectObjectConflicts(baseState, oursState, theirsState)
+	conflicts := detectObjectConflicts(newMergeStates(baseState, oursState, theirsState, nil, nil))
 	require.Len(t, conflicts, 1)
 	assert.Equal(t, models.ConflictDeleteModify, conflicts[0].Type)
 }
@@ -163,7 +166,7 @@ func TestDetectObjectConflicts_AddAdd(t *testing.T) {
 		"Article/obj-001": {Object: &models.WeaviateObject{ID: "obj-001", Class: "Article", Properties: map[string]interface{}{"title": "Theirs"}}},
 	}
 
-	conflicts := detectObjectConflicts(baseState, oursState, theirsState)
+	conflicts := detectObjectConflicts(newMergeStates(baseState, oursState, theirsState, nil, nil))
 	require.Len(t, conflicts, 1)
 	assert.Equal(t, models.ConflictAddAdd, conflicts[0].Type)
 }
@@ -181,10 +184,65 @@ func TestDetectObjectConflicts_SameChange(t *testing.T) {
 		"Article/obj-001": {Object: sameChange},
 	}
 
-	conflicts := detectObjectConflicts(baseState, oursState, theirsState)
+	conflicts := detectObjectConflicts(newMergeStates(baseState, oursState, theirsState, nil, nil))
 	assert.Len(t, conflicts, 0)
 }
 
+func TestMergeStates_AppendOnly(t *testing.T) {
+	// Base: evt1, evt-upd, art1
+	// Ours: evt1, evt-upd, evt2 (added), evt-dup (added), art1 (modified)
+	// Theirs: evt3 (added), evt-dup (added differently), evt1 deleted,
+	// evt-upd (modified), art1 (modified)
+	event := func(id, msg string) *objectWithVector {
+		return &objectWithVector{Object: &models.WeaviateObject{ID: id, Class: "Event", Properties: map[string]interface{}{"msg": msg}}}
+	}
+	article := func(title string) *objectWithVector {
+		return &objectWithVector{Object: &models.WeaviateObject{ID: "art-1", Class: "Article", Properties: map[string]interface{}{"title": title}}}
+	}
+	baseState := map[string]*objectWithVector{
+		"Event/evt-1":   event("evt-1", "start"),
+		"Event/evt-upd": event("evt-upd", "pending"),
+		"Article/art-1": article("Base"),
+	}
+	oursState := map[string]*objectWithVector{
+		"Event/evt-1":   event("evt-1", "start"),
+		"Event/evt-upd": event("evt-upd", "pending"),
+		"Event/evt-2":   event("evt-2", "ours"),
+		"Event/evt-dup": event("evt-dup", "ours"),
+		"Article/art-1": article("Ours"),
+	}
+	theirsState := map[string]*objectWithVector{
+		"Event/evt-3":   event("evt-3", "theirs"),
+		"Event/evt-upd": event("evt-upd", "done"),
+		"Event/evt-dup": event("evt-dup", "theirs"),
+		"Article/art-1": article("Theirs"),
+	}
+
+	states := newMergeStates(baseState, oursState, theirsState, nil, map[string]bool{"Event": true})
+	assert.Equal(t, appendOnlyHash, states.oursHashes["Event/evt-2"], "one-sided additions are not hashed")
+	assert.Equal(t, appendOnlyHash, states.theirsHashes["Event/evt-3"])
+	assert.NotEqual(t, appendOnlyHash, states.oursHashes["Event/evt-dup"])
+
+	// A same-ID addition with different content still conflicts
+	conflicts := detectObjectConflicts(states)
+	require.Len(t, conflicts, 2)
+	assert.Equal(t, "Article/art-1", conflicts[0].Key)
+	assert.Equal(t, "Event/evt-dup", conflicts[1].Key)
+	assert.Equal(t, models.ConflictAddAdd, conflicts[1].Type)
+
+	merged := computeMergedState(states)
+	assert.NotContains(t, merged, "Event/evt-1")
+	assert.Contains(t, merged, "Event/evt-2")
+	assert.Contains(t, merged, "Event/evt-3")
+	assert.Equal(t, "done", merged["Event/evt-upd"].Object.Properties["msg"], "one-sided updates are kept")
+
+	// The same additions on both sides merge cleanly
+	theirsState["Event/evt-dup"] = event("evt-dup", "ours")
+	conflicts = detectObjectConflicts(newMergeStates(baseState, oursState, theirsState, nil, map[string]bool{"Event": true}))
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, "Article/art-1", conflicts[0].Key)
+}
+
 func TestComputeMergedState(t *testing.T) {
 	// Base: obj1
 	// Ours: obj1 (unchanged), obj2 (added)
@@ -202,7 +260,7 @@ func TestComputeMergedState(t *testing.T) {
 		"Article/obj-003": {Object: &models.WeaviateObject{ID: "obj-003", Class: "Article", Properties: map[string]interface{}{"title": "Theirs3"}}},
 	}
 
-	merged := computeMergedState(baseState, oursState, theirsState)
+	merged := computeMergedState(newMergeStates(baseState, oursState, theirsState, nil, nil))
 
 	// Should have 3 objects
 	assert.Len(t, merged, 3)
@@ -392,6 +450,77 @@ func TestMerge_ThreeWay_NoConflicts(t *testing.T) {
 	assert.Len(t, client.Objects, 3)
 }
 
+func TestMerge_ThreeWay_RecordsParentSummaries(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+
+	client.AddClass(&models.WeaviateClass{Class: "Article"})
+	client.AddObject(&models.WeaviateObject{
+		ID:         "obj-001",
+		Class:      "Article",
+		Properties: map[string]interface{}{"title": "Initial"},
+	})
+	_, err := CreateCommit(ctx, cfg, st, client, "Initial")
+	require.NoError(t, err)
+
+	require.NoError(t, CreateBranch(st, "feature", ""))
+	_, err = Checkout(ctx, cfg, st, client, "feature", CheckoutOptions{})
+	require.NoError(t, err)
+
+	// Feature adds obj-002 and updates obj-001
+	client.AddObject(&models.WeaviateObject{
+		ID:         "obj-002",
+		Class:      "Article",
+		Properties: map[string]interface{}{"title": "Feature"},
+	})
+	client.Objects["Article/obj-001"].Properties["title"] = "Edited"
+	featureCommit, err := CreateCommit(ctx, cfg, st, client, "Feature commit")
+	require.NoError(t, err)
+
+	_, err = Checkout(ctx, cfg, st, client, "main", CheckoutOptions{})
+	require.NoError(t, err)
+
+	// Main adds obj-003
+	client.AddObject(&models.WeaviateObject{
+		ID:         "obj-003",
+		Class:      "Article",
+		Properties: map[string]interface{}{"title": "Main"},
+	})
+	mainCommit, err := CreateCommit(ctx, cfg, st, client, "Main commit")
+	require.NoError(t, err)
+
+	result, err := Merge(ctx, cfg, st, client, "feature", models.MergeOptions{})
+	require.NoError(t, err)
+	require.True(t, result.Success)
+
+	stored, err := st.GetCommit(result.MergeCommit.ID)
+	require.NoError(t, err)
+	require.Len(t, stored.ParentSummaries, 2)
+	assert.Equal(t, models.ParentChangeSummary{ParentID: mainCommit.ID, Added: 1, Updated: 1}, stored.ParentSummaries[0])
+	assert.Equal(t, models.ParentChangeSummary{ParentID: featureCommit.ID, Added: 1}, stored.ParentSummaries[1])
+
+	diffs, err := DiffMergeCommit(st, result.MergeCommit.ID)
+	require.NoError(t, err)
+	require.Len(t, diffs, 2)
+
+	assert.Equal(t, mainCommit.ID, diffs[0].ParentID)
+	require.Len(t, diffs[0].Diff.Inserted, 1)
+	assert.Equal(t, "obj-002", diffs[0].Diff.Inserted[0].ObjectID)
+	require.Len(t, diffs[0].Diff.Updated, 1)
+	assert.Equal(t, "obj-001", diffs[0].Diff.Updated[0].ObjectID)
+	assert.Empty(t, diffs[0].Diff.Deleted)
+
+	assert.Equal(t, featureCommit.ID, diffs[1].ParentID)
+	require.Len(t, diffs[1].Diff.Inserted, 1)
+	assert.Equal(t, "obj-003", diffs[1].Diff.Inserted[0].ObjectID)
+	assert.Empty(t, diffs[1].Diff.Updated)
+
+	_, err = DiffMergeCommit(st, mainCommit.ID)
+	assert.Error(t, err)
+}
+
 func TestMerge_WithConflict_Abort(t *testing.T) {
 	ctx := context.Background()
 	st := newTestStore(t)
@@ -439,6 +568,150 @@ func TestMerge_WithConflict_Abort(t *testing.T) {
 	assert.Equal(t, models.ConflictModifyModify, result.Conflicts[0].Type)
 }
 
+// setupConflictingBranches commits diverging edits of obj-001 and obj-002 on
+// main and feature and leaves main checked out.
+func setupConflictingBranches(t *testing.T, ctx context.Context, cfg *config.Config, st *store.Store, client *weaviate.MockClient) {
+	t.Helper()
+
+	client.AddClass(&models.WeaviateClass{Class: "Article"})
+	for _, id := range []string{"obj-001", "obj-002"} {
+		client.AddObject(&models.WeaviateObject{
+			ID:         id,
+			Class:      "Article",
+			Properties: map[string]interface{}{"title": "Initial"},
+		})
+	}
+	_, err := CreateCommit(ctx, cfg, st, client, "Initial")
+	require.NoError(t, err)
+	require.NoError(t, CreateBranch(st, "feature", ""))
+
+	client.Objects["Article/obj-001"].Properties["title"] = "Main version"
+	client.Objects["Article/obj-002"].Properties["title"] = "Main version"
+	_, err = CreateCommit(ctx, cfg, st, client, "Main modify")
+	require.NoError(t, err)
+
+	_, err = Checkout(ctx, cfg, st, client, "feature", CheckoutOptions{})
+	require.NoError(t, err)
+	client.Objects["Article/obj-001"].Properties["title"] = "Feature version"
+	client.Objects["Article/obj-002"].Properties["title"] = "Feature version"
+	_, err = CreateCommit(ctx, cfg, st, client, "Feature modify")
+	require.NoError(t, err)
+
+	_, err = Checkout(ctx, cfg, st, client, "main", CheckoutOptions{})
+	require.NoError(t, err)
+}
+
+func TestMerge_ConflictStateResolveAndContinue(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+	setupConflictingBranches(t, ctx, cfg, st, client)
+
+	result, err := Merge(ctx, cfg, st, client, "feature", models.MergeOptions{})
+	require.NoError(t, err)
+	require.False(t, result.Success)
+
+	// The stopped merge is persisted
+	state, err := st.GetMergeState()
+	require.NoError(t, err)
+	require.NotNil(t, state)
+	assert.Equal(t, "feature", state.TargetBranch)
+	assert.Len(t, state.Conflicts, 2)
+	assert.Len(t, state.Unresolved(), 2)
+
+	// A new merge is refused while one is in progress
+	_, err = Merge(ctx, cfg, st, client, "feature", models.MergeOptions{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "in progress")
+
+	// Continue is refused until every conflict is resolved
+	_, err = MergeContinue(ctx, cfg, st, client, "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "2 conflict(s) remaining")
+
+	_, err = MergeResolve(st, "obj-001", models.ConflictTheirs)
+	require.NoError(t, err)
+	_, err = MergeResolve(st, "Article/obj-002", models.ConflictOurs)
+	require.NoError(t, err)
+	_, err = MergeResolve(st, "obj-999", models.ConflictOurs)
+	require.Error(t, err)
+
+	state, err = st.GetMergeState()
+	require.NoError(t, err)
+	assert.Empty(t, state.Unresolved())
+
+	result, err = MergeContinue(ctx, cfg, st, client, "")
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.True(t, result.MergeCommit.IsMergeCommit())
+	assert.Equal(t, 2, result.ResolvedConflicts)
+
+	assert.Equal(t, "Feature version", client.Objects["Article/obj-001"].Properties["title"])
+	assert.Equal(t, "Main version", client.Objects["Article/obj-002"].Properties["title"])
+
+	state, err = st.GetMergeState()
+	require.NoError(t, err)
+	assert.Nil(t, state)
+}
+
+func TestMerge_ConflictStateAbort(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+	setupConflictingBranches(t, ctx, cfg, st, client)
+
+	headBefore, err := st.GetHEAD()
+	require.NoError(t, err)
+
+	result, err := Merge(ctx, cfg, st, client, "feature", models.MergeOptions{})
+	require.NoError(t, err)
+	require.False(t, result.Success)
+
+	state, err := MergeAbort(st)
+	require.NoError(t, err)
+	assert.Equal(t, "feature", state.TargetBranch)
+
+	head, err := st.GetHEAD()
+	require.NoError(t, err)
+	assert.Equal(t, headBefore, head)
+
+	_, err = MergeAbort(st)
+	assert.Error(t, err)
+
+	// With the state cleared, merging with a strategy succeeds
+	result, err = Merge(ctx, cfg, st, client, "feature", models.MergeOptions{Strategy: models.ConflictOurs})
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+}
+
+func TestMerge_AbortStrategyRecordsNoState(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+	setupConflictingBranches(t, ctx, cfg, st, client)
+
+	result, err := Merge(ctx, cfg, st, client, "feature", models.MergeOptions{Strategy: models.ConflictAbort})
+	require.NoError(t, err)
+	require.False(t, result.Success)
+	assert.Len(t, result.Conflicts, 2)
+
+	state, err := st.GetMergeState()
+	require.NoError(t, err)
+	assert.Nil(t, state)
+
+	// The manual strategy records the conflicts for resolution
+	result, err = Merge(ctx, cfg, st, client, "feature", models.MergeOptions{Strategy: models.ConflictManual})
+	require.NoError(t, err)
+	require.False(t, result.Success)
+	state, err = st.GetMergeState()
+	require.NoError(t, err)
+	require.NotNil(t, state)
+	assert.Len(t, state.Unresolved(), 2)
+}
+
 func TestMerge_WithConflict_ResolveOurs(t *testing.T) {
 	ctx := context.Background()
 	st := newTestStore(t)
@@ -544,3 +817,54 @@ func TestMerge_DetachedHead(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "detached")
 }
+
+func TestCachedObjHash_ReusesKnownHash(t *testing.T) {
+	obj := &objectWithVector{
+		Object:     &models.WeaviateObject{ID: "obj-001", Class: "Article", Properties: map[string]interface{}{"title": "A"}, LastUpdateTimeUnix: 42},
+		VectorHash: "vh",
+	}
+	known := &models.KnownObjectInfo{
+		Object:     &models.WeaviateObject{ID: "obj-001", Class: "Article", LastUpdateTimeUnix: 42},
+		ObjectHash: "cached",
+		VectorHash: "vh",
+	}
+	assert.Equal(t, "cached:vh", cachedObjHash(obj, known))
+
+	// A different update time means the payload may differ, so it is re-hashed.
+	known.Object.LastUpdateTimeUnix = 41
+	assert.Equal(t, hashObjWithVec(obj), cachedObjHash(obj, known))
+
+	// A zero timestamp is never trusted.
+	obj.Object.LastUpdateTimeUnix = 0
+	known.Object.LastUpdateTimeUnix = 0
+	assert.Equal(t, hashObjWithVec(obj), cachedObjHash(obj, known))
+}
+
+func TestDetectObjectConflicts_ManyObjects(t *testing.T) {
+	base := make(map[string]*objectWithVector)
+	ours := make(map[string]*objectWithVector)
+	theirs := make(map[string]*objectWithVector)
+	for i := 0; i < 500; i++ {
+		key := fmt.Sprintf("Article/obj-%03d", i)
+		mk := func(title string) *objectWithVector {
+			return &objectWithVector{Object: &models.WeaviateObject{ID: key[8:], Class: "Article", Properties: map[string]interface{}{"title": title}}}
+		}
+		base[key] = mk("base")
+		ours[key] = mk("base")
+		theirs[key] = mk("base")
+		if i%10 == 0 {
+			ours[key] = mk("ours")
+			theirs[key] = mk("theirs")
+		} else if i%10 == 1 {
+			theirs[key] = mk("theirs")
+		}
+	}
+
+	states := newMergeStates(base, ours, theirs, nil, nil)
+	assert.Len(t, detectObjectConflicts(states), 50)
+
+	merged := computeMergedState(states)
+	assert.Len(t, merged, 500)
+	assert.Equal(t, "theirs", merged["Article/obj-001"].Object.Properties["title"])
+	assert.Equal(t, "ours", merged["Article/obj-000"].Object.Properties["title"])
+}
@@ -0,0 +1,223 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/store"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+)
+
+// MoveResult describes an object moved by MoveObject
+type MoveResult struct {
+	FromClass string
+	FromID    string
+	ToClass   string
+	ToID      string
+}
+
+// MoveObject moves a committed object to another class (and optionally a new
+// ID). The object is created under the destination and deleted from the
+// source in Weaviate, and the pair is staged as a linked delete+insert so the
+// commit records a move rather than two unrelated changes.
+//
+// dest may be "Class/ID", or just "Class" (or "Class/") to keep the object ID.
+func MoveObject(ctx context.Context, st *store.Store, client weaviate.ClientInterface, source, dest string) (*MoveResult, error) {
+	fromClass, fromID, _ := ParseObjectRef(source)
+	if fromClass == "" || fromID == "" {
+		return nil, fmt.Errorf("source must be an object reference <class>/<id>, got '%s'", source)
+	}
+	toClass, toID, _ := ParseObjectRef(dest)
+	if toClass == "" {
+		return nil, fmt.Errorf("destination must be <class> or <class>/<id>, got '%s'", dest)
+	}
+	if toID == "" {
+		toID = fromID
+	}
+	fromKey, toKey := models.ObjectKey(fromClass, fromID), models.ObjectKey(toClass, toID)
+	if fromKey == toKey {
+		return nil, fmt.Errorf("source and destination are the same object")
+	}
+
+	scope, err := currentScope(st)
+	if err != nil {
+		return nil, err
+	}
+	for _, className := range []string{fromClass, toClass} {
+		if !scope.Includes(className) {
+			return nil, fmt.Errorf("class %s is outside the current branch's class scope", className)
+		}
+	}
+
+	known, err := st.GetKnownObjectInfo(fromClass, fromID)
+	if err != nil {
+		return nil, err
+	}
+	if known == nil {
+		return nil, fmt.Errorf("%s is not under version control; commit it before moving", fromKey)
+	}
+	for _, key := range [][2]string{{fromClass, fromID}, {toClass, toID}} {
+		if sc, err := st.GetStagedChange(key[0], key[1]); err != nil {
+			return nil, err
+		} else if sc != nil {
+			return nil, fmt.Errorf("%s has staged changes; commit or unstage them before moving", models.ObjectKey(key[0], key[1]))
+		}
+	}
+
+	classes, err := versionedClasses(ctx, client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list classes: %w", err)
+	}
+	classExists := false
+	for _, c := range classes {
+		classExists = classExists || c == toClass
+	}
+	if !classExists {
+		return nil, fmt.Errorf("destination class %s does not exist", toClass)
+	}
+
+	current, err := client.GetObject(ctx, fromClass, fromID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", fromKey, err)
+	}
+	if existing, err := client.GetObject(ctx, toClass, toID); err == nil && existing != nil {
+		return nil, fmt.Errorf("destination %s already exists", toKey)
+	}
+
+	moved := &models.WeaviateObject{
+		ID:         toID,
+		Properties: current.Properties,
+		Vector:     current.Vector,
+	}
+	moved.Class, moved.Tenant = models.SplitTenantClass(toClass)
+	if err := client.CreateObject(ctx, moved); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", toKey, err)
+	}
+	if err := client.DeleteObject(ctx, fromClass, fromID); err != nil {
+		if rbErr := client.DeleteObject(ctx, toClass, toID); rbErr != nil {
+			return nil, fmt.Errorf("failed to delete %s: %w (and failed to remove %s: %v)", fromKey, err, toKey, rbErr)
+		}
+		return nil, fmt.Errorf("failed to delete %s: %w", fromKey, err)
+	}
+
+	if err := stageMove(st, known, moved, fromKey, toKey); err != nil {
+		return nil, fmt.Errorf("object moved in Weaviate but staging failed: %w", err)
+	}
+
+	return &MoveResult{FromClass: fromClass, FromID: fromID, ToClass: toClass, ToID: toID}, nil
+}
+
+// stageMove stages the linked delete+insert pair of a move
+func stageMove(st *store.Store, known *models.KnownObjectInfo, moved *models.WeaviateObject, fromKey, toKey string) error {
+	previousData, err := json.Marshal(known.Object)
+	if err != nil {
+		return err
+	}
+	objectData, err := json.Marshal(moved)
+	if err != nil {
+		return err
+	}
+	_, vectorHash := weaviate.HashObjectFull(moved)
+	now := time.Now()
+
+	if err := st.AddStagedChange(&store.StagedChange{
+		ClassName:          known.Object.TenantClass(),
+		ObjectID:           known.Object.ID,
+		ChangeType:         string(models.OperationDelete),
+		PreviousData:       previousData,
+		StagedAt:           now,
+		PreviousVectorHash: known.VectorHash,
+		MovedTo:            toKey,
+	}); err != nil {
+		return err
+	}
+	return st.AddStagedChange(&store.StagedChange{
+		ClassName:  moved.TenantClass(),
+		ObjectID:   moved.ID,
+		ChangeType: string(models.OperationInsert),
+		ObjectData: objectData,
+		StagedAt:   now,
+		VectorHash: vectorHash,
+		MovedFrom:  fromKey,
+	})
+}
+
+// annotateStagedMoves marks the inserts and deletes of a diff that belong to
+// a move staged by MoveObject
+func annotateStagedMoves(st *store.Store, diff *DiffResult) error {
+	moves, err := loadStagedMoves(st)
+	if err != nil {
+		return err
+	}
+	moves.annotate(diff)
+	return nil
+}
+
+// stagedMoves holds the move targets of staged moves by object key
+type stagedMoves struct {
+	movedFrom map[string]string
+	movedTo   map[string]string
+}
+
+// loadStagedMoves reads the moves staged by MoveObject
+func loadStagedMoves(st *store.Store) (*stagedMoves, error) {
+	staged, err := st.GetAllStagedChanges()
+	if err != nil {
+		return nil, err
+	}
+	moves := &stagedMoves{movedFrom: make(map[string]string), movedTo: make(map[string]string)}
+	for _, sc := range staged {
+		key := models.ObjectKey(sc.ClassName, sc.ObjectID)
+		if sc.MovedFrom != "" {
+			moves.movedFrom[key] = sc.MovedFrom
+		}
+		if sc.MovedTo != "" {
+			moves.movedTo[key] = sc.MovedTo
+		}
+	}
+	return moves, nil
+}
+
+// annotate marks the inserts and deletes of diff that belong to a move
+func (m *stagedMoves) annotate(diff *DiffResult) {
+	if len(m.movedFrom) == 0 && len(m.movedTo) == 0 {
+		return
+	}
+	for _, c := range diff.Inserted {
+		c.MovedFrom = m.movedFrom[models.ObjectKey(c.ClassName, c.ObjectID)]
+	}
+	for _, c := range diff.Deleted {
+		c.MovedTo = m.movedTo[models.ObjectKey(c.ClassName, c.ObjectID)]
+	}
+}
+
+// FollowObjectHistory returns the commits, newest first, that touched the
+// object at key, following it back through moves into its earlier classes.
+func FollowObjectHistory(st *store.Store, commits []*models.Commit, key string) ([]*models.Commit, error) {
+	var kept []*models.Commit
+	for _, commit := range commits {
+		ops, err := st.GetOperationsByCommit(commit.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read operations of %s: %w", commit.ShortID(), err)
+		}
+		touched := false
+		previousKey := key
+		for _, op := range ops {
+			if models.ObjectKey(op.ClassName, op.ObjectID) != key {
+				continue
+			}
+			touched = true
+			if op.Type == models.OperationInsert && op.MovedFrom != "" {
+				previousKey = op.MovedFrom
+			}
+		}
+		if touched {
+			kept = append(kept, commit)
+		}
+		key = previousKey
+	}
+	return kept, nil
+}
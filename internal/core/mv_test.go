@@ -0,0 +1,113 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoveObject_RecordsLinkedOperations(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+
+	client.AddClass(&models.WeaviateClass{Class: "Article"})
+	client.AddClass(&models.WeaviateClass{Class: "NewsArticle"})
+	client.AddObject(&models.WeaviateObject{
+		ID:         "obj-1",
+		Class:      "Article",
+		Properties: map[string]interface{}{"title": "Breaking"},
+		Vector:     []float32{0.1, 0.2},
+	})
+	first, err := CreateCommit(ctx, cfg, st, client, "Add article")
+	require.NoError(t, err)
+
+	result, err := MoveObject(ctx, st, client, "Article/obj-1", "NewsArticle")
+	require.NoError(t, err)
+	assert.Equal(t, "NewsArticle", result.ToClass)
+	assert.Equal(t, "obj-1", result.ToID)
+
+	assert.NotContains(t, client.Objects, "Article/obj-1")
+	require.Contains(t, client.Objects, "NewsArticle/obj-1")
+	assert.Equal(t, "Breaking", client.Objects["NewsArticle/obj-1"].Properties["title"])
+
+	// Status shows one staged move, with nothing left unstaged
+	diff, err := ComputeIncrementalDiff(ctx, cfg, st, client)
+	require.NoError(t, err)
+	assert.Equal(t, 0, diff.TotalUnstagedChanges())
+	require.Len(t, diff.Staged.Inserted, 1)
+	assert.Equal(t, "Article/obj-1", diff.Staged.Inserted[0].MovedFrom)
+	require.Len(t, diff.Staged.Deleted, 1)
+	assert.Equal(t, "NewsArticle/obj-1", diff.Staged.Deleted[0].MovedTo)
+
+	moveCommit, err := CreateCommitFromStaging(ctx, cfg, st, client, "Move to NewsArticle")
+	require.NoError(t, err)
+
+	ops, err := st.GetOperationsByCommit(moveCommit.ID)
+	require.NoError(t, err)
+	require.Len(t, ops, 2)
+	for _, op := range ops {
+		assert.True(t, op.IsMove())
+		switch op.Type {
+		case models.OperationInsert:
+			assert.Equal(t, "Article/obj-1", op.MovedFrom)
+		case models.OperationDelete:
+			assert.Equal(t, "NewsArticle/obj-1", op.MovedTo)
+		}
+	}
+
+	// History of the new key follows the object back into its old class
+	client.Objects["NewsArticle/obj-1"].Properties["title"] = "Updated"
+	edit, err := CreateCommit(ctx, cfg, st, client, "Edit")
+	require.NoError(t, err)
+
+	commits, err := st.GetCommitLog(0)
+	require.NoError(t, err)
+	history, err := FollowObjectHistory(st, commits, "NewsArticle/obj-1")
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	assert.Equal(t, edit.ID, history[0].ID)
+	assert.Equal(t, moveCommit.ID, history[1].ID)
+	assert.Equal(t, first.ID, history[2].ID)
+
+	state, err := reconstructStateAtCommit(st, moveCommit.ID)
+	require.NoError(t, err)
+	assert.Contains(t, state, "NewsArticle/obj-1")
+	assert.NotContains(t, state, "Article/obj-1")
+}
+
+func TestMoveObject_Validation(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+
+	client.AddClass(&models.WeaviateClass{Class: "Article"})
+	client.AddObject(&models.WeaviateObject{ID: "obj-1", Class: "Article", Properties: map[string]interface{}{"title": "A"}})
+	client.AddObject(&models.WeaviateObject{ID: "obj-2", Class: "Article", Properties: map[string]interface{}{"title": "B"}})
+	_, err := CreateCommit(ctx, cfg, st, client, "Initial commit")
+	require.NoError(t, err)
+
+	_, err = MoveObject(ctx, st, client, "Article/obj-1", "Missing/obj-1")
+	assert.ErrorContains(t, err, "does not exist")
+
+	_, err = MoveObject(ctx, st, client, "Article/obj-1", "Article/obj-2")
+	assert.ErrorContains(t, err, "already exists")
+
+	client.AddObject(&models.WeaviateObject{ID: "obj-3", Class: "Article", Properties: map[string]interface{}{"title": "C"}})
+	_, err = MoveObject(ctx, st, client, "Article/obj-3", "Article/obj-4")
+	assert.ErrorContains(t, err, "not under version control")
+
+	// Unstaging one half of a move unstages both
+	_, err = MoveObject(ctx, st, client, "Article/obj-1", "Article/obj-9")
+	require.NoError(t, err)
+	require.NoError(t, UnstageObject(st, "Article", "obj-9"))
+	count, err := st.GetStagedChangesCount()
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
@@ -0,0 +1,150 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/remote"
+	"github.com/kilupskalvis/wvc/internal/store"
+)
+
+// AddNote attaches a note to the commit ref resolves to (HEAD when empty).
+// An existing note is only replaced when force is set.
+func AddNote(st *store.Store, ref, message, author string, force bool) (*models.Note, error) {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return nil, fmt.Errorf("note message cannot be empty")
+	}
+	if ref == "" {
+		ref = "HEAD"
+	}
+	commitID, _, err := ResolveRef(st, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := st.GetNote(commitID)
+	if err != nil {
+		return nil, fmt.Errorf("get note: %w", err)
+	}
+	if existing != nil && !force {
+		return nil, fmt.Errorf("commit %s already has a note (use --force to replace it)", shortCommitID(commitID))
+	}
+
+	note := &models.Note{CommitID: commitID, Message: message, Author: author, UpdatedAt: time.Now()}
+	if err := st.PutNote(note); err != nil {
+		return nil, fmt.Errorf("store note: %w", err)
+	}
+	return note, nil
+}
+
+// GetNote returns the note on the commit ref resolves to, or nil if it has none.
+func GetNote(st *store.Store, ref string) (*models.Note, error) {
+	if ref == "" {
+		ref = "HEAD"
+	}
+	commitID, _, err := ResolveRef(st, ref)
+	if err != nil {
+		return nil, err
+	}
+	return st.GetNote(commitID)
+}
+
+// RemoveNote removes the note on the commit ref resolves to and returns the commit ID.
+func RemoveNote(st *store.Store, ref string) (string, error) {
+	if ref == "" {
+		ref = "HEAD"
+	}
+	commitID, _, err := ResolveRef(st, ref)
+	if err != nil {
+		return "", err
+	}
+	if err := st.DeleteNote(commitID); err != nil {
+		return "", err
+	}
+	return commitID, nil
+}
+
+// NotesByCommit returns every local note keyed by commit ID.
+func NotesByCommit(st *store.Store) (map[string]*models.Note, error) {
+	notes, err := st.ListNotes()
+	if err != nil {
+		return nil, fmt.Errorf("list notes: %w", err)
+	}
+	byCommit := make(map[string]*models.Note, len(notes))
+	for _, note := range notes {
+		byCommit[note.CommitID] = note
+	}
+	return byCommit, nil
+}
+
+// PushNotes uploads every local note to the remote. Their commits must
+// already be on the remote; notes the remote holds with another message are
+// only replaced when force is set.
+func PushNotes(ctx context.Context, st *store.Store, client remote.RemoteClient, force bool) ([]*models.Note, error) {
+	notes, err := st.ListNotes()
+	if err != nil {
+		return nil, fmt.Errorf("list notes: %w", err)
+	}
+	for _, note := range notes {
+		if err := client.PutNote(ctx, note, force); err != nil {
+			return nil, err
+		}
+	}
+	return notes, nil
+}
+
+// DeleteRemoteNote deletes the note on a commit on the remote.
+func DeleteRemoteNote(ctx context.Context, client remote.RemoteClient, commitID string) error {
+	return client.DeleteNote(ctx, commitID)
+}
+
+// NoteFetchResult reports what FetchNotes did with each remote note.
+type NoteFetchResult struct {
+	Fetched []*models.Note
+	// MissingCommit lists notes whose commit has not been fetched yet
+	MissingCommit []*models.Note
+	// Diverged lists notes whose commit has another note locally
+	Diverged []*models.Note
+}
+
+// FetchNotes stores the remote's notes locally. Notes on commits not in the
+// local history are skipped, as are notes whose commit has another note
+// locally unless force is set.
+func FetchNotes(ctx context.Context, st *store.Store, client remote.RemoteClient, force bool) (*NoteFetchResult, error) {
+	remoteNotes, err := client.ListNotes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &NoteFetchResult{}
+	for _, note := range remoteNotes {
+		local, err := st.GetNote(note.CommitID)
+		if err != nil {
+			return nil, fmt.Errorf("get note: %w", err)
+		}
+		if local != nil && local.Message == note.Message {
+			continue
+		}
+		if local != nil && !force {
+			result.Diverged = append(result.Diverged, note)
+			continue
+		}
+		has, err := st.HasCommit(note.CommitID)
+		if err != nil {
+			return nil, fmt.Errorf("check commit: %w", err)
+		}
+		if !has {
+			result.MissingCommit = append(result.MissingCommit, note)
+			continue
+		}
+		if err := st.PutNote(note); err != nil {
+			return nil, fmt.Errorf("store note: %w", err)
+		}
+		result.Fetched = append(result.Fetched, note)
+	}
+	return result, nil
+}
@@ -0,0 +1,97 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddNote(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+
+	client.AddClass(&models.WeaviateClass{Class: "Article"})
+	client.AddObject(&models.WeaviateObject{ID: "obj-1", Class: "Article"})
+	first, err := CreateCommit(ctx, cfg, st, client, "First")
+	require.NoError(t, err)
+	client.AddObject(&models.WeaviateObject{ID: "obj-2", Class: "Article"})
+	second, err := CreateCommit(ctx, cfg, st, client, "Second")
+	require.NoError(t, err)
+
+	note, err := AddNote(st, first.ShortID(), "validated by QA", "qa", false)
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, note.CommitID)
+
+	// Notes do not change the commit
+	head, err := st.GetHEAD()
+	require.NoError(t, err)
+	assert.Equal(t, second.ID, head)
+
+	// Replacing a note needs force
+	_, err = AddNote(st, first.ID, "rejected", "qa", false)
+	assert.Error(t, err)
+	_, err = AddNote(st, first.ID, "rejected", "qa", true)
+	require.NoError(t, err)
+	note, err = GetNote(st, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "rejected", note.Message)
+
+	_, err = AddNote(st, "", "  ", "qa", false)
+	assert.Error(t, err)
+
+	commitID, err := RemoveNote(st, first.ShortID())
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, commitID)
+	_, err = RemoveNote(st, first.ID)
+	assert.Error(t, err)
+}
+
+func TestPushAndFetchNotes(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+	remoteClient := newPushMockClient()
+
+	client.AddClass(&models.WeaviateClass{Class: "Article"})
+	client.AddObject(&models.WeaviateObject{ID: "obj-1", Class: "Article"})
+	first, err := CreateCommit(ctx, cfg, st, client, "First")
+	require.NoError(t, err)
+
+	_, err = AddNote(st, "", "validated by QA", "qa", false)
+	require.NoError(t, err)
+	pushed, err := PushNotes(ctx, st, remoteClient, false)
+	require.NoError(t, err)
+	require.Len(t, pushed, 1)
+	assert.Equal(t, "validated by QA", remoteClient.notes[first.ID].Message)
+
+	// Someone else changes the note on the remote and notes an unknown commit
+	remoteClient.notes[first.ID] = &models.Note{CommitID: first.ID, Message: "rejected"}
+	remoteClient.notes["unknown"] = &models.Note{CommitID: "unknown", Message: "other history"}
+
+	_, err = PushNotes(ctx, st, remoteClient, false)
+	assert.Error(t, err)
+
+	result, err := FetchNotes(ctx, st, remoteClient, false)
+	require.NoError(t, err)
+	assert.Empty(t, result.Fetched)
+	require.Len(t, result.Diverged, 1)
+	require.Len(t, result.MissingCommit, 1)
+	assert.Equal(t, "unknown", result.MissingCommit[0].CommitID)
+
+	result, err = FetchNotes(ctx, st, remoteClient, true)
+	require.NoError(t, err)
+	require.Len(t, result.Fetched, 1)
+	notes, err := NotesByCommit(st)
+	require.NoError(t, err)
+	assert.Equal(t, "rejected", notes[first.ID].Message)
+
+	require.NoError(t, DeleteRemoteNote(ctx, remoteClient, first.ID))
+	assert.NotContains(t, remoteClient.notes, first.ID)
+}
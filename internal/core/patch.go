@@ -0,0 +1,264 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/config"
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/store"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+)
+
+// PatchApplyOptions configures ApplyPatch
+type PatchApplyOptions struct {
+	Check bool // only check for conflicts; change nothing
+}
+
+// PatchConflict is a patch change that does not fit the current state
+type PatchConflict struct {
+	ClassName string
+	ObjectID  string
+	Reason    string
+}
+
+// PatchApplyResult contains the result of applying a patch
+type PatchApplyResult struct {
+	Applied     int // changes written to Weaviate
+	Skipped     int // changes already present in the current state
+	StagedCount int
+	Conflicts   []PatchConflict
+	Warnings    []CheckoutWarning
+}
+
+// NewPatch builds a patch from a diff. The diff's objects must carry their
+// vectors, as those from ComputeDiff do.
+func NewPatch(st *store.Store, diff *DiffResult) (*models.Patch, error) {
+	head, err := st.GetHEAD()
+	if err != nil {
+		return nil, err
+	}
+	branch, err := st.GetCurrentBranch()
+	if err != nil {
+		return nil, err
+	}
+
+	patch := &models.Patch{
+		Format:     models.PatchFormat,
+		Version:    models.PatchVersion,
+		BaseCommit: head,
+		Branch:     branch,
+		CreatedAt:  time.Now(),
+		Changes:    make([]*models.PatchChange, 0, diff.TotalChanges()),
+	}
+	for _, group := range []struct {
+		changeType string
+		changes    []*ObjectChange
+	}{
+		{"insert", diff.Inserted},
+		{"update", diff.Updated},
+		{"delete", diff.Deleted},
+	} {
+		for _, change := range group.changes {
+			pc := &models.PatchChange{
+				ClassName:          change.ClassName,
+				ObjectID:           change.ObjectID,
+				ChangeType:         group.changeType,
+				Object:             change.CurrentData,
+				PreviousVectorHash: change.PreviousVectorHash,
+			}
+			if change.CurrentData != nil {
+				pc.ObjectHash, pc.VectorHash = weaviate.HashObjectFull(change.CurrentData)
+			}
+			if change.PreviousData != nil {
+				pc.PreviousObjectHash = weaviate.HashObject(change.PreviousData)
+			}
+			patch.Changes = append(patch.Changes, pc)
+		}
+	}
+	return patch, nil
+}
+
+// WritePatch writes a patch as indented JSON
+func WritePatch(w io.Writer, patch *models.Patch) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(patch)
+}
+
+// ReadPatch reads and validates a patch written by WritePatch
+func ReadPatch(r io.Reader) (*models.Patch, error) {
+	var patch models.Patch
+	if err := json.NewDecoder(r).Decode(&patch); err != nil {
+		return nil, fmt.Errorf("invalid patch: %w", err)
+	}
+	if patch.Format != models.PatchFormat {
+		return nil, fmt.Errorf("not a wvc patch")
+	}
+	if patch.Version < 1 || patch.Version > models.PatchVersion {
+		return nil, fmt.Errorf("unsupported patch version %d", patch.Version)
+	}
+	for _, pc := range patch.Changes {
+		switch pc.ChangeType {
+		case "insert", "update":
+			if pc.Object == nil {
+				return nil, fmt.Errorf("invalid patch: %s of %s/%s has no object", pc.ChangeType, pc.ClassName, pc.ObjectID)
+			}
+		case "delete":
+		default:
+			return nil, fmt.Errorf("invalid patch: unknown change type '%s' for %s/%s", pc.ChangeType, pc.ClassName, pc.ObjectID)
+		}
+	}
+	return &patch, nil
+}
+
+// ApplyPatch applies a patch to Weaviate and stages the resulting changes.
+// Every change is first checked against the current Weaviate state: an
+// insert conflicts with an existing object, and an update or delete with an
+// object that is missing or differs from the one the patch was made
+// against. Changes already present are skipped. If any change conflicts,
+// nothing is applied and the conflicts are returned.
+func ApplyPatch(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface, patch *models.Patch, opts PatchApplyOptions) (*PatchApplyResult, error) {
+	result := &PatchApplyResult{
+		Conflicts: []PatchConflict{},
+		Warnings:  []CheckoutWarning{},
+	}
+
+	scope, err := currentScope(st)
+	if err != nil {
+		return nil, err
+	}
+	current, err := client.GetAllObjectsAllClasses(ctx, cfg.SupportsCursorPagination())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read current state: %w", err)
+	}
+
+	var pending []*models.PatchChange
+	for _, pc := range patch.Changes {
+		if !scope.Includes(pc.ClassName) {
+			result.Conflicts = append(result.Conflicts, PatchConflict{pc.ClassName, pc.ObjectID, "class is outside the current branch's scope"})
+			continue
+		}
+		applied, reason := checkPatchChange(pc, current[models.ObjectKey(pc.ClassName, pc.ObjectID)], client.HashCache())
+		switch {
+		case reason != "":
+			result.Conflicts = append(result.Conflicts, PatchConflict{pc.ClassName, pc.ObjectID, reason})
+		case applied:
+			result.Skipped++
+		default:
+			pending = append(pending, pc)
+		}
+	}
+	if len(result.Conflicts) > 0 || opts.Check {
+		return result, nil
+	}
+
+	touched := make(map[string]bool, len(pending))
+	for _, pc := range pending {
+		var err error
+		switch pc.ChangeType {
+		case "insert":
+			err = client.CreateObject(ctx, pc.Object)
+		case "update":
+			err = client.UpdateObject(ctx, pc.Object)
+		case "delete":
+			err = client.DeleteObject(ctx, pc.ClassName, pc.ObjectID)
+		}
+		if err != nil {
+			result.Warnings = append(result.Warnings, CheckoutWarning{
+				Type:    "apply",
+				Message: fmt.Sprintf("failed to %s %s/%s: %v", pc.ChangeType, pc.ClassName, pc.ObjectID, err),
+			})
+			continue
+		}
+		result.Applied++
+		touched[models.ObjectKey(pc.ClassName, pc.ObjectID)] = true
+	}
+	if len(touched) == 0 {
+		return result, nil
+	}
+
+	staged, err := stageKeys(ctx, cfg, st, client, touched)
+	result.StagedCount = staged
+	if err != nil {
+		return result, fmt.Errorf("patch applied but staging failed: %w", err)
+	}
+	return result, nil
+}
+
+// checkPatchChange compares a patch change with the object currently in
+// Weaviate (nil if absent). It reports whether the change is already
+// present, or why it conflicts. hashes is the cache of the instance current
+// was read from.
+func checkPatchChange(pc *models.PatchChange, current *models.WeaviateObject, hashes *weaviate.HashCache) (applied bool, conflict string) {
+	var currentObj, currentVec string
+	if current != nil {
+		currentObj, currentVec = hashes.HashObjectFull(current)
+	}
+	matches := func(objHash, vecHash string) bool {
+		return current != nil && currentObj == objHash && currentVec == vecHash
+	}
+
+	switch pc.ChangeType {
+	case "insert":
+		if current == nil {
+			return false, ""
+		}
+		if matches(pc.ObjectHash, pc.VectorHash) {
+			return true, ""
+		}
+		return false, "object already exists"
+	case "update":
+		if current == nil {
+			return false, "object does not exist"
+		}
+		if matches(pc.ObjectHash, pc.VectorHash) {
+			return true, ""
+		}
+		if matches(pc.PreviousObjectHash, pc.PreviousVectorHash) {
+			return false, ""
+		}
+		return false, "object differs from the patch's base version"
+	case "delete":
+		if current == nil {
+			return true, ""
+		}
+		if matches(pc.PreviousObjectHash, pc.PreviousVectorHash) {
+			return false, ""
+		}
+		return false, "object differs from the patch's base version"
+	}
+	return false, fmt.Sprintf("unknown change type '%s'", pc.ChangeType)
+}
+
+// stageKeys stages the unstaged changes to the given objects
+func stageKeys(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface, keys map[string]bool) (int, error) {
+	diff, err := ComputeIncrementalDiff(ctx, cfg, st, client)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, group := range []struct {
+		changeType string
+		changes    []*ObjectChange
+	}{
+		{"insert", diff.Unstaged.Inserted},
+		{"update", diff.Unstaged.Updated},
+		{"delete", diff.Unstaged.Deleted},
+	} {
+		for _, change := range group.changes {
+			if !keys[models.ObjectKey(change.ClassName, change.ObjectID)] {
+				continue
+			}
+			if err := st.AddStagedChange(ConvertToStagedChange(change, group.changeType)); err != nil {
+				return count, err
+			}
+			count++
+		}
+	}
+	return count, nil
+}
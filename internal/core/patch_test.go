@@ -0,0 +1,168 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/kilupskalvis/wvc/internal/config"
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/store"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPatchRepo creates a repository with one committed Article object
+func newPatchRepo(t *testing.T) (*config.Config, *store.Store, *weaviate.MockClient) {
+	t.Helper()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+	client.AddClass(&models.WeaviateClass{Class: "Article"})
+	client.AddObject(&models.WeaviateObject{
+		ID:         "obj-001",
+		Class:      "Article",
+		Properties: map[string]interface{}{"title": "First"},
+		Vector:     []float32{0.1, 0.2},
+	})
+	client.AddObject(&models.WeaviateObject{
+		ID:         "obj-002",
+		Class:      "Article",
+		Properties: map[string]interface{}{"title": "Second"},
+	})
+	_, err := CreateCommit(context.Background(), cfg, st, client, "Initial commit")
+	require.NoError(t, err)
+	return cfg, st, client
+}
+
+// makePatch edits the source repository and returns its diff as a patch
+// that has been written and read back
+func makePatch(t *testing.T) *models.Patch {
+	t.Helper()
+	ctx := context.Background()
+	cfg, st, client := newPatchRepo(t)
+
+	client.Objects["Article/obj-001"] = &models.WeaviateObject{
+		ID:         "obj-001",
+		Class:      "Article",
+		Properties: map[string]interface{}{"title": "First (edited)"},
+		Vector:     []float32{0.3, 0.4},
+	}
+	delete(client.Objects, "Article/obj-002")
+	client.AddObject(&models.WeaviateObject{
+		ID:         "obj-003",
+		Class:      "Article",
+		Properties: map[string]interface{}{"title": "Third"},
+		Vector:     []float32{0.5, 0.6},
+	})
+
+	diff, err := ComputeDiff(ctx, cfg, st, client)
+	require.NoError(t, err)
+	patch, err := NewPatch(st, diff)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, WritePatch(&buf, patch))
+	read, err := ReadPatch(&buf)
+	require.NoError(t, err)
+	return read
+}
+
+func TestApplyPatch_AppliesAndStages(t *testing.T) {
+	ctx := context.Background()
+	patch := makePatch(t)
+	require.Len(t, patch.Changes, 3)
+
+	cfg, st, client := newPatchRepo(t)
+	result, err := ApplyPatch(ctx, cfg, st, client, patch, PatchApplyOptions{})
+	require.NoError(t, err)
+
+	assert.Empty(t, result.Conflicts)
+	assert.Equal(t, 3, result.Applied)
+	assert.Equal(t, 3, result.StagedCount)
+	assert.Equal(t, "First (edited)", client.Objects["Article/obj-001"].Properties["title"])
+	assert.Equal(t, []float32{0.3, 0.4}, vectorToFloats(client.Objects["Article/obj-001"].Vector))
+	assert.NotContains(t, client.Objects, "Article/obj-002")
+	assert.Contains(t, client.Objects, "Article/obj-003")
+
+	staged, err := GetStagedDiff(st)
+	require.NoError(t, err)
+	assert.Len(t, staged.Inserted, 1)
+	assert.Len(t, staged.Updated, 1)
+	assert.Len(t, staged.Deleted, 1)
+
+	// Applying again finds every change already present
+	again, err := ApplyPatch(ctx, cfg, st, client, patch, PatchApplyOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, again.Conflicts)
+	assert.Equal(t, 0, again.Applied)
+	assert.Equal(t, 3, again.Skipped)
+}
+
+func TestApplyPatch_ConflictsApplyNothing(t *testing.T) {
+	ctx := context.Background()
+	patch := makePatch(t)
+
+	cfg, st, client := newPatchRepo(t)
+	client.Objects["Article/obj-001"].Properties = map[string]interface{}{"title": "Diverged"}
+	client.AddObject(&models.WeaviateObject{
+		ID:         "obj-003",
+		Class:      "Article",
+		Properties: map[string]interface{}{"title": "Other third"},
+	})
+
+	result, err := ApplyPatch(ctx, cfg, st, client, patch, PatchApplyOptions{})
+	require.NoError(t, err)
+
+	require.Len(t, result.Conflicts, 2)
+	assert.Equal(t, "obj-003", result.Conflicts[0].ObjectID)
+	assert.Equal(t, "object already exists", result.Conflicts[0].Reason)
+	assert.Equal(t, "obj-001", result.Conflicts[1].ObjectID)
+	assert.Equal(t, 0, result.Applied)
+	assert.Contains(t, client.Objects, "Article/obj-002")
+
+	count, err := st.GetStagedChangesCount()
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
+
+func TestApplyPatch_CheckChangesNothing(t *testing.T) {
+	ctx := context.Background()
+	patch := makePatch(t)
+
+	cfg, st, client := newPatchRepo(t)
+	result, err := ApplyPatch(ctx, cfg, st, client, patch, PatchApplyOptions{Check: true})
+	require.NoError(t, err)
+
+	assert.Empty(t, result.Conflicts)
+	assert.Equal(t, 0, result.Applied)
+	assert.Equal(t, "First", client.Objects["Article/obj-001"].Properties["title"])
+	assert.Contains(t, client.Objects, "Article/obj-002")
+}
+
+func TestReadPatch_RejectsInvalid(t *testing.T) {
+	_, err := ReadPatch(bytes.NewBufferString(`{"format":"other","version":1}`))
+	assert.ErrorContains(t, err, "not a wvc patch")
+
+	_, err = ReadPatch(bytes.NewBufferString(`{"format":"wvc-patch","version":99}`))
+	assert.ErrorContains(t, err, "unsupported patch version")
+
+	_, err = ReadPatch(bytes.NewBufferString(`{"format":"wvc-patch","version":1,"changes":[{"class_name":"A","object_id":"1","change_type":"update"}]}`))
+	assert.ErrorContains(t, err, "has no object")
+}
+
+// vectorToFloats normalizes a vector that may have been decoded from JSON
+func vectorToFloats(v interface{}) []float32 {
+	switch vec := v.(type) {
+	case []float32:
+		return vec
+	case []interface{}:
+		out := make([]float32, len(vec))
+		for i, x := range vec {
+			out[i] = float32(x.(float64))
+		}
+		return out
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package core
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/store"
+)
+
+// Pathspec selects objects by class and object ID. Each pattern is one of:
+//
+//	.              every object
+//	Article        every object of a class (also "Article/")
+//	Article/abc    a single object
+//	Article/abc*   objects whose ID matches a glob (path.Match syntax)
+//
+// The class part may be a glob too ("News*/"). It matches the objects of
+// every tenant of a multi-tenant class, or of one tenant when qualified with
+// it ("Article@tenant/"). An empty Pathspec matches everything.
+type Pathspec struct {
+	patterns []pathPattern
+}
+
+type pathPattern struct {
+	raw   string
+	class string
+	id    string // empty matches every object of the class
+}
+
+// ParsePathspec builds a Pathspec from command-line arguments
+func ParsePathspec(args []string) (Pathspec, error) {
+	var spec Pathspec
+	for _, arg := range args {
+		if arg == "." {
+			return Pathspec{}, nil
+		}
+		className, objectID, _ := strings.Cut(arg, "/")
+		if className == "" {
+			return Pathspec{}, fmt.Errorf("invalid pathspec '%s': missing class name", arg)
+		}
+		for _, part := range []string{className, objectID} {
+			if _, err := path.Match(part, ""); err != nil {
+				return Pathspec{}, fmt.Errorf("invalid pathspec '%s': %w", arg, err)
+			}
+		}
+		spec.patterns = append(spec.patterns, pathPattern{raw: arg, class: className, id: objectID})
+	}
+	return spec, nil
+}
+
+// IsEmpty reports whether the pathspec matches every object
+func (p Pathspec) IsEmpty() bool {
+	return len(p.patterns) == 0
+}
+
+// Matches reports whether an object is selected by the pathspec
+func (p Pathspec) Matches(className, objectID string) bool {
+	if p.IsEmpty() {
+		return true
+	}
+	for _, pat := range p.patterns {
+		if pat.matches(className, objectID) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesClass reports whether any object of a class can be selected by the pathspec
+func (p Pathspec) MatchesClass(className string) bool {
+	if p.IsEmpty() {
+		return true
+	}
+	for _, pat := range p.patterns {
+		if pat.matchesClass(className) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesKey reports whether an object key ("ClassName/ObjectID") is selected
+func (p Pathspec) matchesKey(key string) bool {
+	className, objectID, _ := strings.Cut(key, "/")
+	return p.Matches(className, objectID)
+}
+
+func (pat pathPattern) matches(className, objectID string) bool {
+	if !pat.matchesClass(className) {
+		return false
+	}
+	if pat.id == "" {
+		return true
+	}
+	ok, _ := path.Match(pat.id, objectID)
+	return ok
+}
+
+// matchesClass reports whether the class part matches a class name, or the
+// class of a tenant-qualified one
+func (pat pathPattern) matchesClass(className string) bool {
+	if ok, _ := path.Match(pat.class, className); ok {
+		return true
+	}
+	base, tenant := models.SplitTenantClass(className)
+	if tenant == "" {
+		return false
+	}
+	ok, _ := path.Match(pat.class, base)
+	return ok
+}
+
+// isExactObject reports whether the pattern names a single object without wildcards
+func (pat pathPattern) isExactObject() bool {
+	return pat.id != "" && !strings.ContainsAny(pat.raw, `*?[\`)
+}
+
+// FilterDiff returns the part of a diff selected by the pathspec
+func (p Pathspec) FilterDiff(diff *DiffResult) *DiffResult {
+	if p.IsEmpty() || diff == nil {
+		return diff
+	}
+	return &DiffResult{
+		Inserted: p.filterChanges(diff.Inserted),
+		Updated:  p.filterChanges(diff.Updated),
+		Deleted:  p.filterChanges(diff.Deleted),
+	}
+}
+
+// FilterIncrementalDiff returns the staged and unstaged changes selected by the pathspec
+func (p Pathspec) FilterIncrementalDiff(diff *IncrementalDiffResult) *IncrementalDiffResult {
+	if p.IsEmpty() || diff == nil {
+		return diff
+	}
+	return &IncrementalDiffResult{
+		Staged:   p.FilterDiff(diff.Staged),
+		Unstaged: p.FilterDiff(diff.Unstaged),
+	}
+}
+
+// FilterSchemaDiff returns the schema changes of the classes the pathspec can select
+func (p Pathspec) FilterSchemaDiff(diff *SchemaDiffResult) *SchemaDiffResult {
+	if p.IsEmpty() || diff == nil {
+		return diff
+	}
+	return &SchemaDiffResult{
+		ClassesAdded:       p.filterSchemaChanges(diff.ClassesAdded),
+		ClassesDeleted:     p.filterSchemaChanges(diff.ClassesDeleted),
+		PropertiesAdded:    p.filterSchemaChanges(diff.PropertiesAdded),
+		PropertiesDeleted:  p.filterSchemaChanges(diff.PropertiesDeleted),
+		PropertiesModified: p.filterSchemaChanges(diff.PropertiesModified),
+		VectorizersChanged: p.filterSchemaChanges(diff.VectorizersChanged),
+	}
+}
+
+// selectsAny reports whether the pathspec selects at least one change of the diff
+func (p Pathspec) selectsAny(diff *DiffResult) bool {
+	for _, changes := range [][]*ObjectChange{diff.Inserted, diff.Updated, diff.Deleted} {
+		for _, c := range changes {
+			if p.Matches(c.ClassName, c.ObjectID) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (p Pathspec) filterChanges(changes []*ObjectChange) []*ObjectChange {
+	kept := make([]*ObjectChange, 0, len(changes))
+	for _, c := range changes {
+		if p.Matches(c.ClassName, c.ObjectID) {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+func (p Pathspec) filterSchemaChanges(changes []*models.SchemaChange) []*models.SchemaChange {
+	var kept []*models.SchemaChange
+	for _, c := range changes {
+		if p.MatchesClass(c.ClassName) {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+// FilterCommitsByPathspec keeps the commits whose operations touch an object
+// selected by the pathspec, preserving order.
+func FilterCommitsByPathspec(st *store.Store, commits []*models.Commit, spec Pathspec) ([]*models.Commit, error) {
+	if spec.IsEmpty() {
+		return commits, nil
+	}
+	var kept []*models.Commit
+	for _, commit := range commits {
+		ops, err := st.GetOperationsByCommit(commit.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read operations of %s: %w", commit.ShortID(), err)
+		}
+		for _, op := range ops {
+			if spec.Matches(op.ClassName, op.ObjectID) {
+				kept = append(kept, commit)
+				break
+			}
+		}
+	}
+	return kept, nil
+}
@@ -0,0 +1,149 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPathspec_Matches(t *testing.T) {
+	spec, err := ParsePathspec([]string{"Article/", "Author/obj-1*", "News*"})
+	require.NoError(t, err)
+
+	assert.True(t, spec.Matches("Article", "anything"))
+	assert.True(t, spec.Matches("Author", "obj-10"))
+	assert.False(t, spec.Matches("Author", "obj-2"))
+	assert.True(t, spec.Matches("NewsArticle", "x"))
+	assert.False(t, spec.Matches("Category", "x"))
+
+	assert.True(t, spec.MatchesClass("Author"))
+	assert.False(t, spec.MatchesClass("Category"))
+
+	all, err := ParsePathspec([]string{"Article", "."})
+	require.NoError(t, err)
+	assert.True(t, all.IsEmpty())
+	assert.True(t, all.Matches("Category", "x"))
+
+	_, err = ParsePathspec([]string{"Article/[obj"})
+	assert.Error(t, err)
+	_, err = ParsePathspec([]string{"/obj-1"})
+	assert.Error(t, err)
+}
+
+func TestStagePathspec(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+
+	client.AddClass(&models.WeaviateClass{Class: "Article"})
+	client.AddClass(&models.WeaviateClass{Class: "Author"})
+	_, err := CreateCommit(ctx, cfg, st, client, "Initial commit")
+	require.NoError(t, err)
+
+	for _, obj := range []*models.WeaviateObject{
+		{ID: "obj-10", Class: "Article", Properties: map[string]interface{}{"title": "A"}},
+		{ID: "obj-11", Class: "Article", Properties: map[string]interface{}{"title": "B"}},
+		{ID: "obj-20", Class: "Article", Properties: map[string]interface{}{"title": "C"}},
+		{ID: "obj-10", Class: "Author", Properties: map[string]interface{}{"name": "D"}},
+	} {
+		client.AddObject(obj)
+	}
+
+	spec, err := ParsePathspec([]string{"Article/obj-1*"})
+	require.NoError(t, err)
+	count, err := StagePathspec(ctx, cfg, st, client, spec)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	staged, err := GetStagedDiff(st)
+	require.NoError(t, err)
+	require.Len(t, staged.Inserted, 2)
+	for _, change := range staged.Inserted {
+		assert.Equal(t, "Article", change.ClassName)
+	}
+
+	// An exact object with no change is an error and stages nothing
+	spec, err = ParsePathspec([]string{"Author/", "Author/missing"})
+	require.NoError(t, err)
+	_, err = StagePathspec(ctx, cfg, st, client, spec)
+	assert.Error(t, err)
+	staged, err = GetStagedDiff(st)
+	require.NoError(t, err)
+	assert.Len(t, staged.Inserted, 2)
+}
+
+func TestCheckoutPaths_RestoresOnlyMatchingObjects(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+
+	client.AddClass(&models.WeaviateClass{Class: "Article"})
+	client.AddClass(&models.WeaviateClass{Class: "Author"})
+	client.AddObject(&models.WeaviateObject{ID: "obj-1", Class: "Article", Properties: map[string]interface{}{"title": "Original"}})
+	client.AddObject(&models.WeaviateObject{ID: "obj-2", Class: "Article", Properties: map[string]interface{}{"title": "Original"}})
+	client.AddObject(&models.WeaviateObject{ID: "obj-1", Class: "Author", Properties: map[string]interface{}{"name": "Original"}})
+	commit, err := CreateCommit(ctx, cfg, st, client, "Initial commit")
+	require.NoError(t, err)
+
+	client.Objects["Article/obj-1"].Properties["title"] = "Edited"
+	client.Objects["Article/obj-2"].Properties["title"] = "Edited"
+	delete(client.Objects, "Author/obj-1")
+	client.AddObject(&models.WeaviateObject{ID: "obj-3", Class: "Article", Properties: map[string]interface{}{"title": "New"}})
+
+	spec, err := ParsePathspec([]string{"Article/obj-1", "Article/obj-3", "Author/"})
+	require.NoError(t, err)
+	result, err := CheckoutPaths(ctx, cfg, st, client, "", spec)
+	require.NoError(t, err)
+
+	assert.Equal(t, commit.ID, result.TargetCommit)
+	assert.Equal(t, 1, result.ObjectsAdded)
+	assert.Equal(t, 1, result.ObjectsUpdated)
+	assert.Equal(t, 1, result.ObjectsRemoved)
+
+	assert.Equal(t, "Original", client.Objects["Article/obj-1"].Properties["title"])
+	assert.Equal(t, "Edited", client.Objects["Article/obj-2"].Properties["title"], "unmatched object must keep its change")
+	assert.Contains(t, client.Objects, "Author/obj-1")
+	assert.NotContains(t, client.Objects, "Article/obj-3")
+
+	head, _ := st.GetHEAD()
+	assert.Equal(t, commit.ID, head)
+}
+
+func TestFilterCommitsByPathspec(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+
+	client.AddClass(&models.WeaviateClass{Class: "Article"})
+	client.AddClass(&models.WeaviateClass{Class: "Author"})
+	client.AddObject(&models.WeaviateObject{ID: "obj-1", Class: "Article", Properties: map[string]interface{}{"title": "A"}})
+	first, err := CreateCommit(ctx, cfg, st, client, "Add article")
+	require.NoError(t, err)
+	client.AddObject(&models.WeaviateObject{ID: "obj-1", Class: "Author", Properties: map[string]interface{}{"name": "B"}})
+	second, err := CreateCommit(ctx, cfg, st, client, "Add author")
+	require.NoError(t, err)
+
+	commits, err := st.GetCommitLog(0)
+	require.NoError(t, err)
+
+	spec, err := ParsePathspec([]string{"Author/"})
+	require.NoError(t, err)
+	filtered, err := FilterCommitsByPathspec(st, commits, spec)
+	require.NoError(t, err)
+	require.Len(t, filtered, 1)
+	assert.Equal(t, second.ID, filtered[0].ID)
+
+	spec, err = ParsePathspec([]string{"Article/obj-*"})
+	require.NoError(t, err)
+	filtered, err = FilterCommitsByPathspec(st, commits, spec)
+	require.NoError(t, err)
+	require.Len(t, filtered, 1)
+	assert.Equal(t, first.ID, filtered[0].ID)
+}
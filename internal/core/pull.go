@@ -2,22 +2,26 @@ package core
 
 import (
 	"context"
-	"errors"
 	"fmt"
-	"io"
 
 	"github.com/kilupskalvis/wvc/internal/config"
+	"github.com/kilupskalvis/wvc/internal/models"
 	"github.com/kilupskalvis/wvc/internal/remote"
 	"github.com/kilupskalvis/wvc/internal/store"
 	"github.com/kilupskalvis/wvc/internal/weaviate"
-	"golang.org/x/sync/errgroup"
 )
 
-// FetchOptions configures a fetch operation.
+// FetchOptions configures a fetch operation. Depth limits the commits
+// fetched to those within Depth of the remote tip; Unshallow fetches the
+// history cut off by earlier depth-limited fetches.
 type FetchOptions struct {
 	RemoteName string
 	Branch     string
 	Depth      int
+	Unshallow  bool
+	// Concurrency is the number of vectors downloaded at once; zero means
+	// DefaultTransferConcurrency
+	Concurrency int
 }
 
 // FetchResult contains the outcome of a fetch operation.
@@ -29,24 +33,51 @@ type FetchResult struct {
 	LocalTip       string
 }
 
-// PullOptions configures a pull operation.
+// PullMode selects how a pull integrates a remote branch that has diverged
+// from the local one.
+type PullMode string
+
+const (
+	// PullFastForwardOnly only fast-forwards, reporting divergence.
+	PullFastForwardOnly PullMode = ""
+	// PullMerge merges the remote-tracking branch into the local branch.
+	PullMerge PullMode = "merge"
+	// PullRebase replays the local commits onto the remote tip.
+	PullRebase PullMode = "rebase"
+)
+
+// PullOptions configures a pull operation. Strategy resolves conflicts when
+// Mode merges or rebases; with no strategy a merge stops on conflicts and a
+// rebase leaves the branch unchanged.
 type PullOptions struct {
 	RemoteName string
 	Branch     string
 	Depth      int
+	Mode       PullMode
+	Strategy   models.ConflictStrategy
+	// Concurrency is the number of vectors downloaded at once; zero means
+	// DefaultTransferConcurrency
+	Concurrency int
 }
 
-// PullResult contains the outcome of a pull operation.
+// PullResult contains the outcome of a pull operation. Diverged is set when
+// the branches diverged; Merge or Rebase then holds the integration, if any.
 type PullResult struct {
 	FetchResult
 	FastForward    bool
 	Diverged       bool
+	Merge          *models.MergeResult
+	Rebase         *RebaseResult
 	ObjectsAdded   int
 	ObjectsUpdated int
 	ObjectsRemoved int
 	Warnings       []CheckoutWarning
 }
 
+// bundleInsertBatchSize is the number of fetched commit bundles stored per
+// bbolt write transaction.
+const bundleInsertBatchSize = 256
+
 // FetchProgress is called during fetch to report progress.
 type FetchProgress func(phase string, current, total int)
 
@@ -67,9 +98,35 @@ func Fetch(ctx context.Context, st *store.Store, client remote.RemoteClient, opt
 		localTip = rb.CommitID
 	}
 
+	// A partial clone leaves the data its filter names on the promisor
+	// remote, to be downloaded when a command first needs it
+	promisor, err := st.GetPromisorRemote()
+	if err != nil {
+		return nil, fmt.Errorf("get promisor remote: %w", err)
+	}
+	var filter remote.Filter
+	if promisor != "" && promisor == opts.RemoteName {
+		if filter, err = st.GetPromisorFilter(); err != nil {
+			return nil, fmt.Errorf("get promisor filter: %w", err)
+		}
+	}
+
+	var shallow []string
+	if opts.Unshallow {
+		if opts.Depth > 0 {
+			return nil, fmt.Errorf("--depth and --unshallow cannot be combined")
+		}
+		if shallow, err = st.ListShallowCommits(); err != nil {
+			return nil, fmt.Errorf("list shallow commits: %w", err)
+		}
+		if len(shallow) == 0 {
+			return nil, fmt.Errorf("--unshallow on a complete repository does not make sense")
+		}
+	}
+
 	// Negotiate with server
 	progress("negotiating", 0, 0)
-	negotiation, err := client.NegotiatePull(ctx, opts.Branch, localTip, opts.Depth)
+	negotiation, err := client.NegotiatePull(ctx, opts.Branch, localTip, opts.Depth, filter.Classes, shallow)
 	if err != nil {
 		return nil, fmt.Errorf("negotiate pull: %w", err)
 	}
@@ -88,23 +145,59 @@ func Fetch(ctx context.Context, st *store.Store, client remote.RemoteClient, opt
 	progress("downloading commits", 0, len(negotiation.MissingCommits))
 	bundles := make([]*remote.CommitBundle, 0, len(negotiation.MissingCommits))
 	var allVectorHashes []string
+
+	// Schemas are deduplicated by hash: tell the server which schema we already
+	// hold so unchanged schemas arrive as a bare hash. Bundles are stored in
+	// download order, so each one's schema is available to those after it.
+	var haveSchema string
+	if localTip != "" {
+		if sv, err := st.GetSchemaVersionByCommit(localTip); err == nil && sv != nil {
+			haveSchema = sv.SchemaHash
+		}
+	}
+
 	for i, commitID := range negotiation.MissingCommits {
 		progress("downloading commits", i+1, len(negotiation.MissingCommits))
 
-		bundle, err := client.DownloadCommitBundle(ctx, commitID)
+		bundle, err := downloadBundle(ctx, client, commitID, haveSchema, filter)
 		if err != nil {
 			return nil, fmt.Errorf("download commit %s: %w", commitID, err)
 		}
 		bundles = append(bundles, bundle)
+		if bundle.Schema != nil {
+			haveSchema = bundle.Schema.SchemaHash
+		}
 
-		// Collect vector hashes from operations
+		// Collect vector and offloaded payload hashes from operations
 		for _, op := range bundle.Operations {
-			if op.VectorHash != "" {
-				allVectorHashes = append(allVectorHashes, op.VectorHash)
+			if !filter.NoVectors {
+				allVectorHashes = append(allVectorHashes, op.VectorHashes()...)
+			}
+			if !op.PayloadOmitted {
+				allVectorHashes = append(allVectorHashes, op.PayloadHashes()...)
 			}
 		}
 	}
 
+	// Fill in schemas that arrived as a bare hash but aren't stored locally
+	if err := resolveSchemaRefs(ctx, st, client, bundles); err != nil {
+		return nil, err
+	}
+
+	// History replays from the state of each new shallow boundary
+	states, err := downloadShallowStates(ctx, client, negotiation.Shallow, filter)
+	if err != nil {
+		return nil, err
+	}
+	for _, state := range states {
+		for _, op := range state.Operations {
+			if !filter.NoVectors {
+				allVectorHashes = append(allVectorHashes, op.VectorHashes()...)
+			}
+			allVectorHashes = append(allVectorHashes, op.PayloadHashes()...)
+		}
+	}
+
 	// Phase 2: Download missing vectors BEFORE inserting any commits.
 	// If vector download fails, no commits have been persisted, so the store
 	// remains in a consistent state. Any already-downloaded vectors are
@@ -119,7 +212,7 @@ func Fetch(ctx context.Context, st *store.Store, client remote.RemoteClient, opt
 
 		if len(missingVectors) > 0 {
 			progress("downloading vectors", 0, len(missingVectors))
-			vectorsFetched, err = downloadMissingVectors(ctx, st, client, missingVectors, progress)
+			vectorsFetched, err = downloadMissingVectors(ctx, st, client, missingVectors, opts.Concurrency, progress)
 			if err != nil {
 				return nil, fmt.Errorf("download vectors: %w", err)
 			}
@@ -127,31 +220,24 @@ func Fetch(ctx context.Context, st *store.Store, client remote.RemoteClient, opt
 	}
 
 	// Phase 3: Now that all vectors are present locally, insert commit bundles.
-	// Each InsertCommitBundle call is individually atomic (single bbolt transaction).
+	// Bundles are written in batches, each batch in a single bbolt transaction.
 	progress("storing commits", 0, len(bundles))
-	for i, bundle := range bundles {
-		progress("storing commits", i+1, len(bundles))
-		if err := st.InsertCommitBundle(bundle); err != nil {
-			return nil, fmt.Errorf("store commit %s: %w", bundle.Commit.ID, err)
+	for start := 0; start < len(bundles); start += bundleInsertBatchSize {
+		end := min(start+bundleInsertBatchSize, len(bundles))
+		if err := st.InsertCommitBundles(bundles[start:end]); err != nil {
+			return nil, fmt.Errorf("store commits: %w", err)
 		}
+		progress("storing commits", end, len(bundles))
 	}
 
-	// Mark shallow boundary commits when using depth-limited fetch
-	if opts.Depth > 0 && len(negotiation.MissingCommits) > 0 {
-		// The oldest fetched commit's parents are the shallow boundary
-		oldestID := negotiation.MissingCommits[0]
-		oldest, err := st.GetCommit(oldestID)
-		if err == nil && oldest != nil {
-			if oldest.ParentID != "" {
-				has, _ := st.HasCommit(oldest.ParentID)
-				if !has {
-					if err := st.MarkShallowCommit(oldestID); err != nil {
-						return nil, fmt.Errorf("mark shallow commit: %w", err)
-					}
-				}
-			}
+	for _, state := range states {
+		if err := st.PutShallowState(state.CommitID, state.Operations); err != nil {
+			return nil, fmt.Errorf("store state of shallow commit %s: %w", state.CommitID, err)
 		}
 	}
+	if err := dropCompletedShallowCommits(st); err != nil {
+		return nil, err
+	}
 
 	// Update remote-tracking branch
 	if err := st.SetRemoteBranch(opts.RemoteName, opts.Branch, negotiation.RemoteTip); err != nil {
@@ -166,9 +252,78 @@ func Fetch(ctx context.Context, st *store.Store, client remote.RemoteClient, opt
 	}, nil
 }
 
+// downloadShallowStates downloads the state of each boundary the server cut
+// the fetched history at, limited to the classes of a partial clone.
+func downloadShallowStates(ctx context.Context, client remote.RemoteClient, boundaries []string, filter remote.Filter) ([]*remote.CommitState, error) {
+	var states []*remote.CommitState
+	for _, id := range boundaries {
+		state, err := client.DownloadCommitState(ctx, id, filter.Classes)
+		if err != nil {
+			return nil, fmt.Errorf("download state of shallow commit %s: %w", id, err)
+		}
+		state.CommitID = id
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// dropCompletedShallowCommits unmarks shallow boundaries whose parents have
+// since been fetched, so history replays through them again.
+func dropCompletedShallowCommits(st *store.Store) error {
+	shallow, err := st.ListShallowCommits()
+	if err != nil {
+		return fmt.Errorf("list shallow commits: %w", err)
+	}
+	for _, id := range shallow {
+		commit, err := st.GetCommit(id)
+		if err != nil {
+			return fmt.Errorf("get shallow commit %s: %w", id, err)
+		}
+		complete := true
+		for _, parent := range []string{commit.ParentID, commit.MergeParentID} {
+			if parent == "" {
+				continue
+			}
+			has, err := st.HasCommit(parent)
+			if err != nil {
+				return fmt.Errorf("check commit: %w", err)
+			}
+			complete = complete && has
+		}
+		if complete {
+			if err := st.RemoveShallowCommit(id); err != nil {
+				return fmt.Errorf("unmark shallow commit %s: %w", id, err)
+			}
+		}
+	}
+	return nil
+}
+
+// downloadBundle downloads a commit bundle. Bundles reduced by a class or
+// payload filter are checked against the manifest of the full commit.
+func downloadBundle(ctx context.Context, client remote.RemoteClient, commitID, haveSchema string, filter remote.Filter) (*remote.CommitBundle, error) {
+	if !filter.NoPayloads && len(filter.Classes) == 0 {
+		return client.DownloadCommitBundle(ctx, commitID, haveSchema)
+	}
+	fb, err := client.DownloadFilteredCommitBundle(ctx, commitID, &remote.FilteredBundleRequest{
+		Classes:      filter.Classes,
+		OmitPayloads: filter.NoPayloads,
+		HaveSchema:   haveSchema,
+		Deltas:       true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := fb.Verify(filter); err != nil {
+		return nil, err
+	}
+	return fb.Bundle, nil
+}
+
 // Pull fetches from a remote and attempts to fast-forward the local branch.
-// If the branches have diverged, it reports divergence without merging.
-// On a successful fast-forward, Weaviate is restored to the new tip's state.
+// If the branches have diverged, it merges or rebases the checked-out branch
+// as opts.Mode asks, or only reports the divergence. On a successful
+// fast-forward, Weaviate is restored to the new tip's state.
 func Pull(ctx context.Context, cfg *config.Config, st *store.Store, wc weaviate.ClientInterface, client remote.RemoteClient, opts PullOptions, progress FetchProgress) (*PullResult, error) {
 	// Check for uncommitted changes
 	uncommitted, err := st.GetUncommittedOperations()
@@ -180,7 +335,12 @@ func Pull(ctx context.Context, cfg *config.Config, st *store.Store, wc weaviate.
 	}
 
 	// Fetch first
-	fetchResult, err := Fetch(ctx, st, client, FetchOptions(opts), progress)
+	fetchResult, err := Fetch(ctx, st, client, FetchOptions{
+		RemoteName:  opts.RemoteName,
+		Branch:      opts.Branch,
+		Depth:       opts.Depth,
+		Concurrency: opts.Concurrency,
+	}, progress)
 	if err != nil {
 		return nil, err
 	}
@@ -189,9 +349,12 @@ func Pull(ctx context.Context, cfg *config.Config, st *store.Store, wc weaviate.
 		FetchResult: *fetchResult,
 	}
 
-	if fetchResult.UpToDate {
+	// Nothing new was fetched, but an earlier fetch may have left the
+	// branches diverged for an integrating pull to resolve
+	if fetchResult.UpToDate && (opts.Mode == PullFastForwardOnly || fetchResult.RemoteTip == "") {
 		return result, nil
 	}
+	result.UpToDate = false
 
 	// Check if we can fast-forward the local branch
 	localBranch, err := st.GetBranch(opts.Branch)
@@ -277,13 +440,43 @@ func Pull(ctx context.Context, cfg *config.Config, st *store.Store, wc weaviate.
 
 	// Branches have diverged
 	result.Diverged = true
+	if opts.Mode == PullFastForwardOnly {
+		return result, nil
+	}
+	currentBranch, err := st.GetCurrentBranch()
+	if err != nil {
+		return nil, err
+	}
+	if currentBranch != opts.Branch {
+		return nil, fmt.Errorf("cannot %s: '%s' is not checked out", opts.Mode, opts.Branch)
+	}
+
+	upstream := opts.RemoteName + "/" + opts.Branch
+	switch opts.Mode {
+	case PullMerge:
+		result.Merge, err = Merge(ctx, cfg, st, wc, upstream, models.MergeOptions{
+			Message:  fmt.Sprintf("Merge remote-tracking branch '%s' into %s", upstream, opts.Branch),
+			Strategy: opts.Strategy,
+		})
+	case PullRebase:
+		result.Rebase, err = rebaseOnto(ctx, cfg, st, wc, opts.Branch, fetchResult.RemoteTip, opts.Strategy)
+	default:
+		return nil, fmt.Errorf("unknown pull mode '%s'", opts.Mode)
+	}
+	if err != nil {
+		return nil, err
+	}
 	return result, nil
 }
 
 // applyPullRestore restores the Weaviate instance to the given commit's state and
 // rebuilds the known-objects table, mirroring what Checkout does after switching branches.
 func applyPullRestore(ctx context.Context, cfg *config.Config, st *store.Store, wc weaviate.ClientInterface, commitID string, result *PullResult) error {
-	warnings, stats, err := restoreStateToCommit(ctx, cfg, st, wc, commitID)
+	scope, err := currentScope(st)
+	if err != nil {
+		return err
+	}
+	warnings, stats, err := restoreStateToCommit(ctx, cfg, st, wc, commitID, scope)
 	if err != nil {
 		return fmt.Errorf("restore state after pull: %w", err)
 	}
@@ -301,6 +494,41 @@ func applyPullRestore(ctx context.Context, cfg *config.Config, st *store.Store,
 	return nil
 }
 
+// resolveSchemaRefs downloads the schema JSON for bundles that reference a
+// schema by hash only, unless the hash is stored locally or carried in full by
+// an earlier bundle. This keeps commit schemas complete even when the local
+// copy of a schema the server assumed we had is missing.
+func resolveSchemaRefs(ctx context.Context, st *store.Store, client remote.RemoteClient, bundles []*remote.CommitBundle) error {
+	known := make(map[string]bool)
+	for _, bundle := range bundles {
+		schema := bundle.Schema
+		if schema == nil || schema.SchemaHash == "" {
+			continue
+		}
+		if len(schema.SchemaJSON) > 0 || known[schema.SchemaHash] {
+			known[schema.SchemaHash] = true
+			continue
+		}
+
+		has, err := st.HasSchemaHash(schema.SchemaHash)
+		if err != nil {
+			return fmt.Errorf("check schema %s: %w", schema.SchemaHash, err)
+		}
+		if !has {
+			snapshot, err := client.DownloadSchema(ctx, schema.SchemaHash)
+			if err != nil {
+				return fmt.Errorf("download schema for commit %s: %w", bundle.Commit.ID, err)
+			}
+			if snapshot.SchemaHash != schema.SchemaHash || len(snapshot.SchemaJSON) == 0 {
+				return fmt.Errorf("download schema for commit %s: server returned schema %q", bundle.Commit.ID, snapshot.SchemaHash)
+			}
+			schema.SchemaJSON = snapshot.SchemaJSON
+		}
+		known[schema.SchemaHash] = true
+	}
+	return nil
+}
+
 // filterMissingLocalVectors returns hashes of vectors not present in the local store.
 func filterMissingLocalVectors(st *store.Store, hashes []string) ([]string, error) {
 	seen := make(map[string]bool)
@@ -312,59 +540,14 @@ func filterMissingLocalVectors(st *store.Store, hashes []string) ([]string, erro
 		}
 		seen[hash] = true
 
-		_, _, err := st.GetVectorBlob(hash)
+		has, err := st.HasVectorBlob(hash)
 		if err != nil {
-			if errors.Is(err, store.ErrVectorNotFound) {
-				missing = append(missing, hash)
-			} else {
-				return nil, fmt.Errorf("check local vector %s: %w", hash, err)
-			}
+			return nil, fmt.Errorf("check local vector %s: %w", hash, err)
+		}
+		if !has {
+			missing = append(missing, hash)
 		}
 	}
 
 	return missing, nil
 }
-
-// downloadMissingVectors downloads vector blobs in parallel with bounded concurrency.
-func downloadMissingVectors(ctx context.Context, st *store.Store, client remote.RemoteClient, missingHashes []string, progress FetchProgress) (int, error) {
-	const maxWorkers = 4
-
-	g, ctx := errgroup.WithContext(ctx)
-	g.SetLimit(maxWorkers)
-
-	for i, hash := range missingHashes {
-		progress("downloading vectors", i+1, len(missingHashes))
-		h := hash
-		g.Go(func() error {
-			reader, dims, err := client.DownloadVector(ctx, h)
-			if err != nil {
-				return fmt.Errorf("download vector %s: %w", h, err)
-			}
-			defer reader.Close()
-
-			data, err := io.ReadAll(reader)
-			if err != nil {
-				return fmt.Errorf("read vector %s: %w", h, err)
-			}
-
-			// Verify hash
-			computed := store.HashVector(data)
-			if computed != h {
-				return fmt.Errorf("vector hash mismatch for %s: got %s", h, computed)
-			}
-
-			// Store locally
-			if _, err := st.SaveVectorBlob(data, dims); err != nil {
-				return fmt.Errorf("save vector %s: %w", h, err)
-			}
-
-			return nil
-		})
-	}
-
-	if err := g.Wait(); err != nil {
-		return 0, err
-	}
-
-	return len(missingHashes), nil
-}
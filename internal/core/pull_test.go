@@ -2,6 +2,7 @@ package core
 
 import (
 	"context"
+	"errors"
 	"io"
 	"path/filepath"
 	"testing"
@@ -20,9 +21,16 @@ import (
 type mockRemoteClient struct {
 	negotiatePullResp *remote.NegotiatePullResponse
 	negotiatePullErr  error
+	negotiateClasses  []string
+	negotiateShallow  []string
+	commitStates      map[string]*remote.CommitState
 	commitBundles     map[string]*remote.CommitBundle
 	vectorData        map[string]mockVector
 	vectorCheckResp   *remote.VectorCheckResponse
+	schemas           map[string][]byte
+	stashes           map[string]*remote.RemoteStash
+	branches          []*models.Branch
+	repoInfo          *remote.RepoInfo
 }
 
 type mockVector struct {
@@ -34,7 +42,9 @@ func (m *mockRemoteClient) NegotiatePush(_ context.Context, _ string, _ []string
 	return nil, nil
 }
 
-func (m *mockRemoteClient) NegotiatePull(_ context.Context, _ string, _ string, _ int) (*remote.NegotiatePullResponse, error) {
+func (m *mockRemoteClient) NegotiatePull(_ context.Context, _ string, _ string, _ int, classes, shallow []string) (*remote.NegotiatePullResponse, error) {
+	m.negotiateClasses = classes
+	m.negotiateShallow = shallow
 	return m.negotiatePullResp, m.negotiatePullErr
 }
 
@@ -45,6 +55,10 @@ func (m *mockRemoteClient) CheckVectors(_ context.Context, hashes []string) (*re
 	return &remote.VectorCheckResponse{Have: nil, Missing: hashes}, nil
 }
 
+func (m *mockRemoteClient) VectorBloom(_ context.Context) (*remote.BloomFilter, error) {
+	return nil, &remote.RemoteError{Code: "not_found", Message: "not found", Status: 404}
+}
+
 func (m *mockRemoteClient) UploadVector(_ context.Context, _ string, _ io.Reader, _ int) error {
 	return nil
 }
@@ -61,14 +75,104 @@ func (m *mockRemoteClient) UploadCommitBundle(_ context.Context, _ *remote.Commi
 	return nil
 }
 
-func (m *mockRemoteClient) DownloadCommitBundle(_ context.Context, commitID string) (*remote.CommitBundle, error) {
+func (m *mockRemoteClient) DownloadCommitBundle(_ context.Context, commitID, haveSchema string) (*remote.CommitBundle, error) {
 	b, ok := m.commitBundles[commitID]
 	if !ok {
 		return nil, &remote.RemoteError{Code: "not_found", Message: "commit not found", Status: 404}
 	}
+	// Like the server, send only the hash when the caller already has the schema
+	if haveSchema != "" && b.Schema != nil && b.Schema.SchemaHash == haveSchema {
+		ref := *b
+		ref.Schema = &remote.SchemaSnapshot{SchemaHash: haveSchema}
+		return &ref, nil
+	}
 	return b, nil
 }
 
+func (m *mockRemoteClient) DownloadFilteredCommitBundle(ctx context.Context, commitID string, req *remote.FilteredBundleRequest) (*remote.FilteredBundle, error) {
+	b, err := m.DownloadCommitBundle(ctx, commitID, req.HaveSchema)
+	if err != nil {
+		return nil, err
+	}
+	cp := *b
+	cp.Operations = make([]*models.Operation, len(b.Operations))
+	for i, op := range b.Operations {
+		opCopy := *op
+		cp.Operations[i] = &opCopy
+	}
+	return remote.FilterBundle(&cp, req.Filter())
+}
+
+func (m *mockRemoteClient) DownloadCommitState(_ context.Context, commitID string, classes []string) (*remote.CommitState, error) {
+	s, ok := m.commitStates[commitID]
+	if !ok {
+		return nil, &remote.RemoteError{Code: "not_found", Message: "commit not found", Status: 404}
+	}
+	filter := remote.Filter{Classes: classes}
+	state := &remote.CommitState{CommitID: s.CommitID}
+	for _, op := range s.Operations {
+		if filter.KeepsClass(op.ClassName) {
+			opCopy := *op
+			state.Operations = append(state.Operations, &opCopy)
+		}
+	}
+	return state, nil
+}
+
+func (m *mockRemoteClient) DownloadCommitPayloads(_ context.Context, commitID string) ([]*remote.OperationPayload, error) {
+	b, ok := m.commitBundles[commitID]
+	if !ok {
+		return nil, &remote.RemoteError{Code: "not_found", Message: "commit not found", Status: 404}
+	}
+	var payloads []*remote.OperationPayload
+	for i, op := range b.Operations {
+		payloads = append(payloads, &remote.OperationPayload{Seq: i, ObjectData: op.ObjectData, PreviousData: op.PreviousData})
+	}
+	return payloads, nil
+}
+
+func (m *mockRemoteClient) DownloadSchema(_ context.Context, hash string) (*remote.SchemaSnapshot, error) {
+	data, ok := m.schemas[hash]
+	if !ok {
+		return nil, &remote.RemoteError{Code: "not_found", Message: "schema not found", Status: 404}
+	}
+	return &remote.SchemaSnapshot{SchemaJSON: data, SchemaHash: hash}, nil
+}
+
+func (m *mockRemoteClient) UploadStash(_ context.Context, stash *remote.RemoteStash) error {
+	if m.stashes == nil {
+		m.stashes = make(map[string]*remote.RemoteStash)
+	}
+	m.stashes[stash.ID] = stash
+	return nil
+}
+
+func (m *mockRemoteClient) ListStashes(_ context.Context) ([]*remote.RemoteStash, error) {
+	var list []*remote.RemoteStash
+	for _, s := range m.stashes {
+		summary := *s
+		summary.Changes = nil
+		list = append(list, &summary)
+	}
+	return list, nil
+}
+
+func (m *mockRemoteClient) DownloadStash(_ context.Context, id string) (*remote.RemoteStash, error) {
+	s, ok := m.stashes[id]
+	if !ok {
+		return nil, &remote.RemoteError{Code: "not_found", Message: "stash not found", Status: 404}
+	}
+	return s, nil
+}
+
+func (m *mockRemoteClient) DeleteStash(_ context.Context, id string) error {
+	if _, ok := m.stashes[id]; !ok {
+		return &remote.RemoteError{Code: "not_found", Message: "stash not found", Status: 404}
+	}
+	delete(m.stashes, id)
+	return nil
+}
+
 func (m *mockRemoteClient) UpdateBranch(_ context.Context, _, _, _ string) error {
 	return nil
 }
@@ -78,17 +182,69 @@ func (m *mockRemoteClient) DeleteBranch(_ context.Context, _ string) error {
 }
 
 func (m *mockRemoteClient) ListBranches(_ context.Context) ([]*models.Branch, error) {
-	return nil, nil
+	return m.branches, nil
 }
 
 func (m *mockRemoteClient) GetBranch(_ context.Context, _ string) (*models.Branch, error) {
 	return nil, nil
 }
 
-func (m *mockRemoteClient) GetRepoInfo(_ context.Context) (*remote.RepoInfo, error) {
+func (m *mockRemoteClient) ListUserRefs(_ context.Context) ([]*models.Branch, error) {
 	return nil, nil
 }
 
+func (m *mockRemoteClient) UpdateUserRef(_ context.Context, _, _, _ string) error {
+	return nil
+}
+
+func (m *mockRemoteClient) DeleteUserRef(_ context.Context, _ string) error {
+	return nil
+}
+
+func (m *mockRemoteClient) ListTags(_ context.Context) ([]*models.Tag, error) {
+	return nil, nil
+}
+
+func (m *mockRemoteClient) PutTag(_ context.Context, _ *models.Tag, _ bool) error {
+	return nil
+}
+
+func (m *mockRemoteClient) DeleteTag(_ context.Context, _ string) error {
+	return nil
+}
+
+func (m *mockRemoteClient) ListNotes(_ context.Context) ([]*models.Note, error) {
+	return nil, nil
+}
+
+func (m *mockRemoteClient) PutNote(_ context.Context, _ *models.Note, _ bool) error {
+	return nil
+}
+
+func (m *mockRemoteClient) DeleteNote(_ context.Context, _ string) error {
+	return nil
+}
+
+func (m *mockRemoteClient) ListDeployments(_ context.Context) ([]*models.Branch, error) {
+	return nil, nil
+}
+
+func (m *mockRemoteClient) RecordDeployment(_ context.Context, _, _ string) error {
+	return nil
+}
+
+func (m *mockRemoteClient) GetRepoInfo(_ context.Context) (*remote.RepoInfo, error) {
+	return m.repoInfo, nil
+}
+
+func (m *mockRemoteClient) GetCommitLog(_ context.Context, _, _ string, _ int) (*remote.CommitLogResponse, error) {
+	return &remote.CommitLogResponse{}, nil
+}
+
+func (m *mockRemoteClient) Compare(_ context.Context, _, _ string) (*remote.CompareResponse, error) {
+	return &remote.CompareResponse{}, nil
+}
+
 // readerAt wraps a byte slice to implement io.ReaderAt.
 type readerAt []byte
 
@@ -311,6 +467,182 @@ func TestPull_CreatesBranch(t *testing.T) {
 	assert.Equal(t, "c1", branch.CommitID)
 }
 
+func TestPull_PartialCloneFetchesVectorsOnRestore(t *testing.T) {
+	ctx := context.Background()
+	st := newPullTestStore(t)
+	require.NoError(t, st.AddRemote("origin", "http://example.com"))
+	require.NoError(t, st.SetPromisorRemote("origin", remote.Filter{NoVectors: true}))
+
+	vec, dims, err := store.VectorToBytes([]float32{0.5, 0.25})
+	require.NoError(t, err)
+	hash := store.HashVector(vec)
+	client := &mockRemoteClient{
+		negotiatePullResp: &remote.NegotiatePullResponse{
+			MissingCommits: []string{"c1"},
+			RemoteTip:      "c1",
+		},
+		commitBundles: map[string]*remote.CommitBundle{
+			"c1": {
+				Commit: &models.Commit{ID: "c1", Message: "initial", Timestamp: time.Now()},
+				Operations: []*models.Operation{{
+					Type: models.OperationInsert, ClassName: "Article", ObjectID: "obj-1", VectorHash: hash,
+					ObjectData: []byte(`{"id":"obj-1","class":"Article","properties":{"title":"A"}}`),
+				}},
+			},
+		},
+		vectorData: map[string]mockVector{hash: {data: vec, dims: dims}},
+	}
+
+	// The fetch skips the vector; restoring the object downloads it
+	fetched := 0
+	st.SetVectorFetcher(func(h string) (io.ReadCloser, int, error) {
+		fetched++
+		return client.DownloadVector(ctx, h)
+	})
+	wc := weaviate.NewMockClient()
+	result, err := Pull(ctx, &config.Config{}, st, wc, client, PullOptions{RemoteName: "origin", Branch: "main"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.VectorsFetched)
+	assert.Equal(t, 1, result.ObjectsAdded)
+	assert.Equal(t, 1, fetched)
+	assert.Equal(t, []float32{0.5, 0.25}, wc.Objects["Article/obj-1"].Vector)
+
+	has, err := st.HasVectorBlob(hash)
+	require.NoError(t, err)
+	assert.True(t, has)
+
+	// A failed download is reported instead of restoring without the vector
+	st.SetVectorFetcher(func(string) (io.ReadCloser, int, error) {
+		return nil, 0, errors.New("remote unreachable")
+	})
+	err = fetchMissingVectors(st, map[string]*objectWithVector{"Article/obj-2": {VectorHash: store.HashVector([]byte("missing"))}})
+	assert.ErrorContains(t, err, "remote unreachable")
+}
+
+func TestPull_PayloadFilterFetchesPayloadsOnRead(t *testing.T) {
+	ctx := context.Background()
+	st := newPullTestStore(t)
+	require.NoError(t, st.AddRemote("origin", "http://example.com"))
+	require.NoError(t, st.SetPromisorRemote("origin", remote.Filter{NoPayloads: true}))
+
+	ops := []*models.Operation{{
+		Type: models.OperationInsert, ClassName: "Article", ObjectID: "obj-1",
+		ObjectData: []byte(`{"id":"obj-1","class":"Article","properties":{"title":"A"}}`),
+	}}
+	commit := &models.Commit{Message: "initial", Timestamp: time.Now()}
+	id, err := commit.ComputeID(ops)
+	require.NoError(t, err)
+	commit.ID = id
+	client := &mockRemoteClient{
+		negotiatePullResp: &remote.NegotiatePullResponse{MissingCommits: []string{id}, RemoteTip: id},
+		commitBundles:     map[string]*remote.CommitBundle{id: {Commit: commit, Operations: ops}},
+	}
+
+	// The fetch stores operations without payloads; restoring downloads them
+	fetched := 0
+	st.SetPayloadFetcher(func(commitID string) ([]*remote.OperationPayload, error) {
+		fetched++
+		return client.DownloadCommitPayloads(ctx, commitID)
+	})
+	wc := weaviate.NewMockClient()
+	result, err := Pull(ctx, &config.Config{}, st, wc, client, PullOptions{RemoteName: "origin", Branch: "main"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.ObjectsAdded)
+	assert.Equal(t, 1, fetched)
+	assert.Equal(t, "A", wc.Objects["Article/obj-1"].Properties["title"])
+
+	// Downloaded payloads are kept
+	stored, err := st.GetOperationsByCommit(id)
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.False(t, stored[0].PayloadOmitted)
+	assert.Equal(t, 1, fetched)
+}
+
+func TestFetch_ClassFilter(t *testing.T) {
+	st := newPullTestStore(t)
+	require.NoError(t, st.AddRemote("origin", "http://example.com"))
+	require.NoError(t, st.SetPromisorRemote("origin", remote.Filter{Classes: []string{"Article"}}))
+
+	client := &mockRemoteClient{
+		negotiatePullResp: &remote.NegotiatePullResponse{MissingCommits: []string{"c1"}, RemoteTip: "c1"},
+		commitBundles: map[string]*remote.CommitBundle{
+			"c1": {
+				Commit: &models.Commit{ID: "c1", Message: "initial", Timestamp: time.Now()},
+				Operations: []*models.Operation{
+					{Type: models.OperationInsert, ClassName: "Article", ObjectID: "a1", ObjectData: []byte(`{}`)},
+					{Type: models.OperationInsert, ClassName: "Author", ObjectID: "b1", ObjectData: []byte(`{}`)},
+				},
+			},
+		},
+	}
+
+	_, err := Fetch(context.Background(), st, client, FetchOptions{RemoteName: "origin", Branch: "main"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Article"}, client.negotiateClasses)
+
+	ops, err := st.GetOperationsByCommit("c1")
+	require.NoError(t, err)
+	require.Len(t, ops, 1)
+	assert.Equal(t, "Article", ops[0].ClassName)
+}
+
+func TestFetch_Shallow(t *testing.T) {
+	st := newPullTestStore(t)
+	require.NoError(t, st.AddRemote("origin", "http://example.com"))
+
+	insert := func(id string) *models.Operation {
+		return &models.Operation{Type: models.OperationInsert, ClassName: "Article", ObjectID: id, ObjectData: []byte(`{}`)}
+	}
+	client := &mockRemoteClient{
+		negotiatePullResp: &remote.NegotiatePullResponse{MissingCommits: []string{"c2", "c3"}, RemoteTip: "c3", Shallow: []string{"c2"}},
+		commitBundles: map[string]*remote.CommitBundle{
+			"c1": {
+				Commit:     &models.Commit{ID: "c1", Message: "first", Timestamp: time.Now()},
+				Operations: []*models.Operation{insert("obj-1")},
+			},
+			"c2": {
+				Commit:     &models.Commit{ID: "c2", ParentID: "c1", Message: "second", Timestamp: time.Now()},
+				Operations: []*models.Operation{insert("obj-2")},
+			},
+			"c3": {
+				Commit:     &models.Commit{ID: "c3", ParentID: "c2", Message: "third", Timestamp: time.Now()},
+				Operations: []*models.Operation{insert("obj-3")},
+			},
+		},
+		commitStates: map[string]*remote.CommitState{
+			"c2": {CommitID: "c2", Operations: []*models.Operation{insert("obj-1"), insert("obj-2")}},
+		},
+	}
+
+	_, err := Fetch(context.Background(), st, client, FetchOptions{RemoteName: "origin", Branch: "main", Depth: 2}, nil)
+	require.NoError(t, err)
+	shallow, err := st.ListShallowCommits()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c2"}, shallow)
+
+	// Replay starts from the state at the boundary, not its own operations
+	state, err := reconstructStateAtCommit(st, "c3")
+	require.NoError(t, err)
+	assert.Len(t, state, 3)
+	assert.Contains(t, state, models.ObjectKey("Article", "obj-1"))
+
+	// Deepening sends the boundaries and completes the history
+	client.negotiatePullResp = &remote.NegotiatePullResponse{MissingCommits: []string{"c1"}, RemoteTip: "c3"}
+	_, err = Fetch(context.Background(), st, client, FetchOptions{RemoteName: "origin", Branch: "main", Unshallow: true}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c2"}, client.negotiateShallow)
+	shallow, err = st.ListShallowCommits()
+	require.NoError(t, err)
+	assert.Empty(t, shallow)
+	state, err = reconstructStateAtCommit(st, "c3")
+	require.NoError(t, err)
+	assert.Len(t, state, 3)
+
+	_, err = Fetch(context.Background(), st, client, FetchOptions{RemoteName: "origin", Branch: "main", Unshallow: true}, nil)
+	assert.ErrorContains(t, err, "complete repository")
+}
+
 func TestFetch_WithSchema(t *testing.T) {
 	st := newPullTestStore(t)
 	require.NoError(t, st.AddRemote("origin", "http://example.com"))
@@ -347,6 +679,71 @@ func TestFetch_WithSchema(t *testing.T) {
 	assert.Equal(t, "hash123", sv.SchemaHash)
 }
 
+func TestFetch_DeduplicatesUnchangedSchemas(t *testing.T) {
+	st := newPullTestStore(t)
+	require.NoError(t, st.AddRemote("origin", "http://example.com"))
+	require.NoError(t, st.SetRemoteBranch("origin", "main", ""))
+
+	schemaJSON := []byte(`{"classes":["Article"]}`)
+	schema := func() *remote.SchemaSnapshot {
+		return &remote.SchemaSnapshot{SchemaJSON: schemaJSON, SchemaHash: "hash123"}
+	}
+	client := &mockRemoteClient{
+		negotiatePullResp: &remote.NegotiatePullResponse{
+			MissingCommits: []string{"c1", "c2", "c3"},
+			RemoteTip:      "c3",
+		},
+		commitBundles: map[string]*remote.CommitBundle{
+			"c1": {Commit: &models.Commit{ID: "c1", Message: "first", Timestamp: time.Now()}, Schema: schema()},
+			"c2": {Commit: &models.Commit{ID: "c2", ParentID: "c1", Message: "second", Timestamp: time.Now()}, Schema: schema()},
+			"c3": {Commit: &models.Commit{ID: "c3", ParentID: "c2", Message: "third", Timestamp: time.Now()}, Schema: schema()},
+		},
+	}
+
+	_, err := Fetch(context.Background(), st, client, FetchOptions{RemoteName: "origin", Branch: "main"}, nil)
+	require.NoError(t, err)
+
+	// c2 and c3 arrived as bare hashes but resolve to the stored schema
+	for _, id := range []string{"c1", "c2", "c3"} {
+		sv, err := st.GetSchemaVersionByCommit(id)
+		require.NoError(t, err)
+		require.NotNil(t, sv, id)
+		assert.Equal(t, schemaJSON, sv.SchemaJSON, id)
+	}
+	sv1, _ := st.GetSchemaVersionByCommit("c1")
+	sv3, _ := st.GetSchemaVersionByCommit("c3")
+	assert.Equal(t, sv1.ID, sv3.ID, "schema stored once")
+}
+
+func TestFetch_DownloadsReferencedSchema(t *testing.T) {
+	st := newPullTestStore(t)
+	require.NoError(t, st.AddRemote("origin", "http://example.com"))
+	require.NoError(t, st.SetRemoteBranch("origin", "main", ""))
+
+	schemaJSON := []byte(`{"classes":["Article"]}`)
+	client := &mockRemoteClient{
+		negotiatePullResp: &remote.NegotiatePullResponse{
+			MissingCommits: []string{"c1"},
+			RemoteTip:      "c1",
+		},
+		commitBundles: map[string]*remote.CommitBundle{
+			"c1": {
+				Commit: &models.Commit{ID: "c1", Message: "first", Timestamp: time.Now()},
+				Schema: &remote.SchemaSnapshot{SchemaHash: "hash123"},
+			},
+		},
+		schemas: map[string][]byte{"hash123": schemaJSON},
+	}
+
+	_, err := Fetch(context.Background(), st, client, FetchOptions{RemoteName: "origin", Branch: "main"}, nil)
+	require.NoError(t, err)
+
+	sv, err := st.GetSchemaVersionByCommit("c1")
+	require.NoError(t, err)
+	require.NotNil(t, sv)
+	assert.Equal(t, schemaJSON, sv.SchemaJSON)
+}
+
 func newPullTestStore(t *testing.T) *store.Store {
 	t.Helper()
 	dbPath := filepath.Join(t.TempDir(), "test-pull.db")
@@ -369,3 +766,171 @@ func TestResolveRef_RemoteTracking(t *testing.T) {
 	_, _, err = ResolveRef(st, "nonexistent/main")
 	assert.Error(t, err)
 }
+
+// setupDivergedUpstream commits obj-002 on main and obj-003 on an upstream
+// branch forked from the same commit, records upstream's tip as origin/main,
+// and checks out main again. It returns upstream's tip.
+func setupDivergedUpstream(t *testing.T, ctx context.Context, cfg *config.Config, st *store.Store, client *weaviate.MockClient) string {
+	t.Helper()
+	require.NoError(t, st.AddRemote("origin", "http://example.com"))
+	client.AddClass(&models.WeaviateClass{Class: "Article"})
+	client.AddObject(&models.WeaviateObject{ID: "obj-001", Class: "Article", Properties: map[string]interface{}{"title": "Initial"}})
+	_, err := CreateCommit(ctx, cfg, st, client, "Initial")
+	require.NoError(t, err)
+	require.NoError(t, CreateBranch(st, "upstream", ""))
+
+	client.AddObject(&models.WeaviateObject{ID: "obj-002", Class: "Article", Properties: map[string]interface{}{"title": "Local"}})
+	_, err = CreateCommit(ctx, cfg, st, client, "Local work")
+	require.NoError(t, err)
+
+	_, err = Checkout(ctx, cfg, st, client, "upstream", CheckoutOptions{})
+	require.NoError(t, err)
+	client.AddObject(&models.WeaviateObject{ID: "obj-003", Class: "Article", Properties: map[string]interface{}{"title": "Remote"}})
+	remoteTip, err := CreateCommit(ctx, cfg, st, client, "Remote work")
+	require.NoError(t, err)
+
+	_, err = Checkout(ctx, cfg, st, client, "main", CheckoutOptions{})
+	require.NoError(t, err)
+	require.NoError(t, st.SetRemoteBranch("origin", "main", remoteTip.ID))
+	return remoteTip.ID
+}
+
+// upToDateRemote reports the remote tip as already fetched.
+func upToDateRemote(tip string) *mockRemoteClient {
+	return &mockRemoteClient{negotiatePullResp: &remote.NegotiatePullResponse{RemoteTip: tip}}
+}
+
+func TestPull_Rebase(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+	remoteTip := setupDivergedUpstream(t, ctx, cfg, st, client)
+
+	// Without a mode the divergence is only reported
+	result, err := Pull(ctx, cfg, st, client, upToDateRemote(remoteTip), PullOptions{RemoteName: "origin", Branch: "main"}, nil)
+	require.NoError(t, err)
+	assert.True(t, result.UpToDate)
+	assert.Nil(t, result.Rebase)
+
+	result, err = Pull(ctx, cfg, st, client, upToDateRemote(remoteTip), PullOptions{
+		RemoteName: "origin",
+		Branch:     "main",
+		Mode:       PullRebase,
+	}, nil)
+	require.NoError(t, err)
+	assert.True(t, result.Diverged)
+	require.NotNil(t, result.Rebase)
+	require.Len(t, result.Rebase.Commits, 1)
+	replayed := result.Rebase.Commits[0]
+	assert.Equal(t, "Local work", replayed.Message)
+	assert.Equal(t, remoteTip, replayed.ParentID)
+	assert.False(t, replayed.IsMergeCommit())
+
+	branch, err := st.GetBranch("main")
+	require.NoError(t, err)
+	assert.Equal(t, replayed.ID, branch.CommitID)
+	head, err := st.GetHEAD()
+	require.NoError(t, err)
+	assert.Equal(t, replayed.ID, head)
+	assert.Len(t, client.Objects, 3)
+
+	dirty, err := HasUncommittedChanges(ctx, cfg, st, client)
+	require.NoError(t, err)
+	assert.False(t, dirty)
+
+	// The replayed commit records only the local change
+	ops, err := st.GetOperationsByCommit(replayed.ID)
+	require.NoError(t, err)
+	require.Len(t, ops, 1)
+	assert.Equal(t, "obj-002", ops[0].ObjectID)
+}
+
+func TestPull_Merge(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+	remoteTip := setupDivergedUpstream(t, ctx, cfg, st, client)
+
+	result, err := Pull(ctx, cfg, st, client, upToDateRemote(remoteTip), PullOptions{
+		RemoteName: "origin",
+		Branch:     "main",
+		Mode:       PullMerge,
+	}, nil)
+	require.NoError(t, err)
+	require.NotNil(t, result.Merge)
+	assert.True(t, result.Merge.Success)
+	require.NotNil(t, result.Merge.MergeCommit)
+	assert.Equal(t, remoteTip, result.Merge.MergeCommit.MergeParentID)
+	assert.Equal(t, "Merge remote-tracking branch 'origin/main' into main", result.Merge.MergeCommit.Message)
+	assert.Len(t, client.Objects, 3)
+}
+
+func TestPull_RequiresCheckedOutBranch(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+	remoteTip := setupDivergedUpstream(t, ctx, cfg, st, client)
+	_, err := Checkout(ctx, cfg, st, client, "upstream", CheckoutOptions{})
+	require.NoError(t, err)
+
+	_, err = Pull(ctx, cfg, st, client, upToDateRemote(remoteTip), PullOptions{
+		RemoteName: "origin",
+		Branch:     "main",
+		Mode:       PullRebase,
+	}, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not checked out")
+}
+
+func TestRebaseOnto_Conflicts(t *testing.T) {
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		strategy models.ConflictStrategy
+		stopped  bool
+		skipped  bool // keeping onto's versions leaves nothing to replay
+		title    string
+	}{
+		{strategy: models.ConflictManual, stopped: true, title: "Main version"},
+		{strategy: models.ConflictOurs, title: "Main version"},
+		{strategy: models.ConflictTheirs, skipped: true, title: "Feature version"},
+	} {
+		t.Run(string(tc.strategy), func(t *testing.T) {
+			st := newTestStore(t)
+			cfg := newTestConfig()
+			client := weaviate.NewMockClient()
+			setupConflictingBranches(t, ctx, cfg, st, client)
+			origTip, err := st.GetHEAD()
+			require.NoError(t, err)
+			feature, err := st.GetBranch("feature")
+			require.NoError(t, err)
+
+			result, err := rebaseOnto(ctx, cfg, st, client, "main", feature.CommitID, tc.strategy)
+			require.NoError(t, err)
+
+			head, err := st.GetHEAD()
+			require.NoError(t, err)
+			if tc.stopped {
+				// Nothing is left half-rebased
+				assert.Equal(t, origTip, result.StoppedAt)
+				assert.Len(t, result.Conflicts, 2)
+				assert.Empty(t, result.Commits)
+				assert.Equal(t, origTip, head)
+			} else if tc.skipped {
+				assert.Equal(t, 1, result.Skipped)
+				assert.Empty(t, result.Commits)
+				assert.Equal(t, feature.CommitID, head)
+			} else {
+				assert.Equal(t, 2, result.ResolvedConflicts)
+				require.Len(t, result.Commits, 1)
+				assert.Equal(t, result.Commits[0].ID, head)
+				assert.Equal(t, feature.CommitID, result.Commits[0].ParentID)
+			}
+			assert.Equal(t, tc.title, client.Objects["Article/obj-001"].Properties["title"])
+			assert.Equal(t, tc.title, client.Objects["Article/obj-002"].Properties["title"])
+		})
+	}
+}
@@ -1,10 +1,10 @@
 package core
 
 import (
-	"bytes"
 	"context"
+	"errors"
 	"fmt"
-	"io"
+	"sync"
 
 	"github.com/kilupskalvis/wvc/internal/remote"
 	"github.com/kilupskalvis/wvc/internal/store"
@@ -16,6 +16,10 @@ type PushOptions struct {
 	RemoteName string
 	Branch     string
 	Force      bool
+	UserRef    bool // push to the caller's personal ref namespace instead of the branch
+	// Concurrency is the number of vectors uploaded at once; zero means
+	// DefaultTransferConcurrency
+	Concurrency int
 }
 
 // PushResult contains the outcome of a push operation.
@@ -24,6 +28,10 @@ type PushResult struct {
 	VectorsPushed int
 	UpToDate      bool
 	BranchCreated bool
+	RemoteRef     string // full name of the updated ref on the remote
+	// RestrictedClasses lists the classes classified pii:restricted in the
+	// pushed commits when the remote is not approved for personal data
+	RestrictedClasses []string
 }
 
 // PushProgress is called during push to report progress.
@@ -44,15 +52,23 @@ func Push(ctx context.Context, st *store.Store, client remote.RemoteClient, opts
 		return nil, fmt.Errorf("branch '%s' does not exist", opts.Branch)
 	}
 
+	// Personal refs live under refs/users/<token-id>/ on the server
+	remoteRef := opts.Branch
+	if opts.UserRef {
+		remoteRef, err = userRefName(ctx, client, opts.Branch)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Collect all commit IDs from tip to root
-	commitIDs, err := collectCommitChain(st, branch.CommitID)
+	commitIDs, parents, err := collectCommitGraph(st, branch.CommitID)
 	if err != nil {
 		return nil, fmt.Errorf("collect commit chain: %w", err)
 	}
 
 	// Negotiate with server
-	progress("negotiating", 0, 0)
-	negotiation, err := client.NegotiatePush(ctx, opts.Branch, commitIDs)
+	negotiation, err := negotiatePush(ctx, client, remoteRef, commitIDs, parents, progress)
 	if err != nil {
 		return nil, fmt.Errorf("negotiate push: %w", err)
 	}
@@ -60,7 +76,7 @@ func Push(ctx context.Context, st *store.Store, client remote.RemoteClient, opts
 	if len(negotiation.MissingCommits) == 0 {
 		// Check if branch pointer needs updating
 		if negotiation.RemoteTip == branch.CommitID {
-			return &PushResult{UpToDate: true}, nil
+			return &PushResult{UpToDate: true, RemoteRef: remoteRef}, nil
 		}
 	}
 
@@ -70,8 +86,9 @@ func Push(ctx context.Context, st *store.Store, client remote.RemoteClient, opts
 		missingSet[id] = true
 	}
 
-	// Collect vector hashes from missing commits
+	// Collect vector hashes and restricted classes from missing commits
 	vectorHashes := make(map[string]bool)
+	restricted := make(map[string]bool)
 	var orderedMissing []string
 	for _, id := range commitIDs {
 		if !missingSet[id] {
@@ -79,36 +96,25 @@ func Push(ctx context.Context, st *store.Store, client remote.RemoteClient, opts
 		}
 		orderedMissing = append(orderedMissing, id)
 
-		ops, err := st.GetOperationsByCommit(id)
+		commit, err := st.GetCommit(id)
 		if err != nil {
-			return nil, fmt.Errorf("get operations for commit %s: %w", id, err)
+			return nil, fmt.Errorf("get commit %s: %w", id, err)
 		}
-		for _, op := range ops {
-			if op.VectorHash != "" {
-				vectorHashes[op.VectorHash] = true
-			}
+		for _, class := range commit.RestrictedClasses() {
+			restricted[class] = true
 		}
-	}
 
-	// Check which vectors are missing on server
-	var vectorsPushed int
-	if len(vectorHashes) > 0 {
-		hashes := make([]string, 0, len(vectorHashes))
-		for h := range vectorHashes {
-			hashes = append(hashes, h)
-		}
-
-		progress("checking vectors", 0, len(hashes))
-		vecCheck, err := client.CheckVectors(ctx, hashes)
+		ops, err := st.GetOperationsByCommit(id)
 		if err != nil {
-			return nil, fmt.Errorf("check vectors: %w", err)
+			return nil, fmt.Errorf("get operations for commit %s: %w", id, err)
 		}
-
-		// Upload missing vectors in parallel
-		if len(vecCheck.Missing) > 0 {
-			vectorsPushed, err = uploadMissingVectors(ctx, st, client, vecCheck.Missing, progress)
-			if err != nil {
-				return nil, fmt.Errorf("upload vectors: %w", err)
+		for _, op := range ops {
+			for _, h := range op.VectorHashes() {
+				vectorHashes[h] = true
+			}
+			// Offloaded payloads travel as blobs alongside the vectors
+			for _, h := range op.PayloadHashes() {
+				vectorHashes[h] = true
 			}
 		}
 	}
@@ -118,19 +124,56 @@ func Push(ctx context.Context, st *store.Store, client remote.RemoteClient, opts
 		orderedMissing[i], orderedMissing[j] = orderedMissing[j], orderedMissing[i]
 	}
 
-	// Upload commits in topological order (oldest first)
-	progress("uploading commits", 0, len(orderedMissing))
-	for i, commitID := range orderedMissing {
-		progress("uploading commits", i+1, len(orderedMissing))
+	hashes := make([]string, 0, len(vectorHashes))
+	for h := range vectorHashes {
+		hashes = append(hashes, h)
+	}
+	restrictedClasses, err := unapprovedRestrictedClasses(st, opts.RemoteName, restricted)
+	if err != nil {
+		return nil, err
+	}
 
-		bundle, err := buildCommitBundle(st, commitID)
+	// Commit bundles are built from the local store while the vectors upload,
+	// but none is sent until every vector is on the server: a commit the
+	// server holds is negotiated as present, so a retry after a failed vector
+	// upload would never resend the vectors of commits sent before the
+	// failure. The branch pointer moves only after both have finished.
+	var progressMu sync.Mutex
+	syncProgress := func(phase string, current, total int) {
+		progressMu.Lock()
+		defer progressMu.Unlock()
+		progress(phase, current, total)
+	}
+
+	var vectorsPushed int
+	vectorsUploaded := make(chan struct{})
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		if len(hashes) == 0 {
+			close(vectorsUploaded)
+			return nil
+		}
+
+		syncProgress("checking vectors", 0, len(hashes))
+		missingVectors, err := checkMissingVectors(gctx, client, hashes)
 		if err != nil {
-			return nil, fmt.Errorf("build commit bundle for %s: %w", commitID, err)
+			return fmt.Errorf("check vectors: %w", err)
 		}
 
-		if err := client.UploadCommitBundle(ctx, bundle); err != nil {
-			return nil, fmt.Errorf("upload commit %s: %w", commitID, err)
+		if len(missingVectors) > 0 {
+			vectorsPushed, err = uploadMissingVectors(gctx, st, client, missingVectors, opts.Concurrency, syncProgress)
+			if err != nil {
+				return fmt.Errorf("upload vectors: %w", err)
+			}
 		}
+		close(vectorsUploaded)
+		return nil
+	})
+	g.Go(func() error {
+		return uploadCommitBundles(gctx, st, client, orderedMissing, negotiation.SchemaRefs, negotiation.Deltas, vectorsUploaded, syncProgress)
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
 	}
 
 	// Update branch pointer (CAS)
@@ -157,6 +200,19 @@ func Push(ctx context.Context, st *store.Store, client remote.RemoteClient, opts
 
 	progress("updating branch", 0, 0)
 	branchCreated := negotiation.RemoteTip == ""
+	if opts.UserRef {
+		if err := client.UpdateUserRef(ctx, opts.Branch, branch.CommitID, expectedTip); err != nil {
+			return nil, fmt.Errorf("update personal ref: %w", err)
+		}
+		// Personal refs are not tracked locally
+		return &PushResult{
+			CommitsPushed:     len(orderedMissing),
+			VectorsPushed:     vectorsPushed,
+			BranchCreated:     branchCreated,
+			RemoteRef:         remoteRef,
+			RestrictedClasses: restrictedClasses,
+		}, nil
+	}
 	if err := client.UpdateBranch(ctx, opts.Branch, branch.CommitID, expectedTip); err != nil {
 		return nil, fmt.Errorf("update remote branch: %w", err)
 	}
@@ -167,15 +223,54 @@ func Push(ctx context.Context, st *store.Store, client remote.RemoteClient, opts
 	}
 
 	return &PushResult{
-		CommitsPushed: len(orderedMissing),
-		VectorsPushed: vectorsPushed,
-		BranchCreated: branchCreated,
+		CommitsPushed:     len(orderedMissing),
+		VectorsPushed:     vectorsPushed,
+		BranchCreated:     branchCreated,
+		RemoteRef:         remoteRef,
+		RestrictedClasses: restrictedClasses,
 	}, nil
 }
 
+// unapprovedRestrictedClasses returns the restricted classes, sorted, unless
+// the remote is approved to hold restricted personal data.
+func unapprovedRestrictedClasses(st *store.Store, remoteName string, restricted map[string]bool) ([]string, error) {
+	if len(restricted) == 0 {
+		return nil, nil
+	}
+	r, err := st.GetRemote(remoteName)
+	if err != nil {
+		return nil, fmt.Errorf("get remote: %w", err)
+	}
+	if r != nil && r.PIIApproved {
+		return nil, nil
+	}
+	return sortedKeys(restricted), nil
+}
+
+// userRefName returns the full server-side name of the caller's personal ref,
+// using the token ID the server reports for this client.
+func userRefName(ctx context.Context, client remote.RemoteClient, name string) (string, error) {
+	info, err := client.GetRepoInfo(ctx)
+	if err != nil {
+		return "", fmt.Errorf("get repo info: %w", err)
+	}
+	if info == nil || info.Token == nil || info.Token.ID == "" {
+		return "", fmt.Errorf("remote does not report token identity; personal refs are not supported")
+	}
+	return remote.UserRefName(info.Token.ID, name), nil
+}
+
 // collectCommitChain walks from tip to root and returns commit IDs in tip-first order.
 func collectCommitChain(st *store.Store, tipID string) ([]string, error) {
+	chain, _, err := collectCommitGraph(st, tipID)
+	return chain, err
+}
+
+// collectCommitGraph walks from tip to root and returns commit IDs in tip-first
+// order along with each commit's parent IDs.
+func collectCommitGraph(st *store.Store, tipID string) ([]string, map[string][]string, error) {
 	var chain []string
+	parents := make(map[string][]string)
 	visited := make(map[string]bool)
 	queue := []string{tipID}
 
@@ -191,54 +286,223 @@ func collectCommitChain(st *store.Store, tipID string) ([]string, error) {
 
 		commit, err := st.GetCommit(current)
 		if err != nil {
-			return nil, fmt.Errorf("get commit %s: %w", current, err)
+			return nil, nil, fmt.Errorf("get commit %s: %w", current, err)
 		}
 
 		if commit.ParentID != "" {
+			parents[current] = append(parents[current], commit.ParentID)
 			queue = append(queue, commit.ParentID)
 		}
 		if commit.MergeParentID != "" {
+			parents[current] = append(parents[current], commit.MergeParentID)
 			queue = append(queue, commit.MergeParentID)
 		}
 	}
 
-	return chain, nil
+	return chain, parents, nil
 }
 
-// uploadMissingVectors uploads vector blobs in parallel with bounded concurrency.
-func uploadMissingVectors(ctx context.Context, st *store.Store, client remote.RemoteClient, missingHashes []string, progress PushProgress) (int, error) {
-	const maxWorkers = 4
+// negotiatePush discovers which local commits the server is missing without
+// sending the whole history. Each round asks about a skipping sample of the
+// still-unknown commits; a commit the server has implies all its ancestors are
+// present, and a commit it lacks implies all its descendants are missing too.
+// The server's own sample of the branch history resolves the common fast-forward
+// case in a single round.
+func negotiatePush(ctx context.Context, client remote.RemoteClient, branch string, commitIDs []string, parents map[string][]string, progress PushProgress) (*remote.NegotiatePushResponse, error) {
+	children := make(map[string][]string)
+	for id, ps := range parents {
+		for _, p := range ps {
+			children[p] = append(children[p], id)
+		}
+	}
 
-	g, ctx := errgroup.WithContext(ctx)
-	g.SetLimit(maxWorkers)
+	const (
+		unknown = iota
+		have
+		missing
+	)
+	state := make(map[string]int, len(commitIDs))
+	for _, id := range commitIDs {
+		state[id] = unknown
+	}
 
-	for i, hash := range missingHashes {
-		progress("uploading vectors", i+1, len(missingHashes))
-		h := hash
-		g.Go(func() error {
-			data, dims, err := st.GetVectorBlob(h)
-			if err != nil {
-				return fmt.Errorf("get local vector %s: %w", h, err)
+	// mark propagates a state along the graph: "have" flows to ancestors,
+	// "missing" flows to descendants.
+	var mark func(id string, s int)
+	mark = func(id string, s int) {
+		stack := []string{id}
+		for len(stack) > 0 {
+			cur := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			// Never overwrite an answer the server already gave.
+			if prev, ok := state[cur]; !ok || prev != unknown {
+				continue
+			}
+			state[cur] = s
+			if s == have {
+				stack = append(stack, parents[cur]...)
+			} else {
+				stack = append(stack, children[cur]...)
 			}
+		}
+	}
 
-			reader := io.NopCloser(bytes.NewReader(data))
-			if err := client.UploadVector(ctx, h, reader, dims); err != nil {
-				return fmt.Errorf("upload vector %s: %w", h, err)
+	result := &remote.NegotiatePushResponse{}
+	for round := 1; ; round++ {
+		var pending []string
+		for _, id := range commitIDs {
+			if state[id] == unknown {
+				pending = append(pending, id)
 			}
+		}
+		if len(pending) == 0 {
+			break
+		}
 
-			return nil
-		})
+		progress("negotiating", round, 0)
+		sample := remote.SampleCommitIDs(pending)
+		resp, err := client.NegotiatePush(ctx, branch, sample)
+		if err != nil {
+			return nil, err
+		}
+		result.RemoteTip = resp.RemoteTip
+		result.SchemaRefs = resp.SchemaRefs
+		result.Deltas = resp.Deltas
+
+		missingSet := make(map[string]bool, len(resp.MissingCommits))
+		for _, id := range resp.MissingCommits {
+			missingSet[id] = true
+		}
+		for _, id := range sample {
+			if missingSet[id] {
+				mark(id, missing)
+			} else {
+				mark(id, have)
+			}
+		}
+
+		// Commits from the remote branch history that exist locally are shared.
+		if resp.RemoteTip != "" {
+			mark(resp.RemoteTip, have)
+		}
+		for _, id := range resp.TipSample {
+			mark(id, have)
+		}
 	}
 
-	if err := g.Wait(); err != nil {
-		return 0, err
+	for _, id := range commitIDs {
+		if state[id] == missing {
+			result.MissingCommits = append(result.MissingCommits, id)
+		}
+	}
+	return result, nil
+}
+
+// bloomCheckThreshold is the number of vector hashes above which push fetches
+// the server's Bloom filter instead of asking about every hash. Below it, an
+// exact check is cheaper than downloading the filter.
+const bloomCheckThreshold = 1000
+
+// checkMissingVectors returns the hashes the server does not have. For large
+// pushes it consults the server's Bloom filter first: hashes the filter rules
+// out are missing for certain, and only probable hits are confirmed with an
+// exact check. Servers without the bloom endpoint get the exact check for all.
+func checkMissingVectors(ctx context.Context, client remote.RemoteClient, hashes []string) ([]string, error) {
+	toCheck := hashes
+	var missing []string
+
+	if len(hashes) >= bloomCheckThreshold {
+		if filter, err := client.VectorBloom(ctx); err == nil {
+			toCheck = nil
+			for _, h := range hashes {
+				if filter.MayContain(h) {
+					toCheck = append(toCheck, h)
+				} else {
+					missing = append(missing, h)
+				}
+			}
+		}
 	}
 
-	return len(missingHashes), nil
+	if len(toCheck) == 0 {
+		return missing, nil
+	}
+
+	vecCheck, err := client.CheckVectors(ctx, toCheck)
+	if err != nil {
+		return nil, err
+	}
+	return append(missing, vecCheck.Missing...), nil
 }
 
-// buildCommitBundle creates a CommitBundle from local store data.
-func buildCommitBundle(st *store.Store, commitID string) (*remote.CommitBundle, error) {
+// uploadCommitBundles uploads commits in the given (topological) order once
+// ready is closed. Bundles are built from the local store ahead of the upload
+// that needs them, so disk reads overlap with network sends while uploads
+// themselves stay sequential.
+func uploadCommitBundles(ctx context.Context, st *store.Store, client remote.RemoteClient, commitIDs []string, schemaRefs, deltas bool, ready <-chan struct{}, progress PushProgress) error {
+	const prefetch = 4
+
+	g, ctx := errgroup.WithContext(ctx)
+	bundles := make(chan *remote.CommitBundle, prefetch)
+
+	g.Go(func() error {
+		defer close(bundles)
+		for _, commitID := range commitIDs {
+			bundle, err := buildCommitBundle(st, commitID, schemaRefs, deltas)
+			if err != nil {
+				return fmt.Errorf("build commit bundle for %s: %w", commitID, err)
+			}
+			select {
+			case bundles <- bundle:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return nil
+	})
+
+	g.Go(func() error {
+		select {
+		case <-ready:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		progress("uploading commits", 0, len(commitIDs))
+		i := 0
+		for bundle := range bundles {
+			i++
+			progress("uploading commits", i, len(commitIDs))
+			err := client.UploadCommitBundle(ctx, bundle)
+			if isUnknownSchema(err) && bundle.Schema != nil && len(bundle.Schema.SchemaJSON) == 0 {
+				// The server lacks the referenced schema; resend it in full
+				full, buildErr := buildCommitBundle(st, bundle.Commit.ID, false, deltas)
+				if buildErr != nil {
+					return fmt.Errorf("build commit bundle for %s: %w", bundle.Commit.ID, buildErr)
+				}
+				err = client.UploadCommitBundle(ctx, full)
+			}
+			if err != nil {
+				return fmt.Errorf("upload commit %s: %w", bundle.Commit.ID, err)
+			}
+		}
+		return nil
+	})
+
+	return g.Wait()
+}
+
+// isUnknownSchema reports whether the server rejected a bundle because it
+// referenced a schema hash the server does not hold.
+func isUnknownSchema(err error) bool {
+	var re *remote.RemoteError
+	return errors.As(err, &re) && re.Code == "unknown_schema"
+}
+
+// buildCommitBundle creates a CommitBundle from local store data. With
+// schemaRefs set, a schema identical to the parent commit's is sent as a bare
+// hash, since the server already holds the parent. With deltas set, updates
+// are sent delta-encoded where that is smaller.
+func buildCommitBundle(st *store.Store, commitID string, schemaRefs, deltas bool) (*remote.CommitBundle, error) {
 	commit, err := st.GetCommit(commitID)
 	if err != nil {
 		return nil, fmt.Errorf("get commit: %w", err)
@@ -248,6 +512,12 @@ func buildCommitBundle(st *store.Store, commitID string) (*remote.CommitBundle,
 	if err != nil {
 		return nil, fmt.Errorf("get operations: %w", err)
 	}
+	for _, op := range ops {
+		op.StripOffloadedPayloads()
+		if deltas {
+			op.EncodeDelta()
+		}
+	}
 
 	bundle := &remote.CommitBundle{
 		Commit:     commit,
@@ -261,11 +531,24 @@ func buildCommitBundle(st *store.Store, commitID string) (*remote.CommitBundle,
 			SchemaJSON: sv.SchemaJSON,
 			SchemaHash: sv.SchemaHash,
 		}
+		if schemaRefs {
+			if parent, err := st.GetPreviousCommitSchema(commitID); err == nil && parent != nil && parent.SchemaHash == sv.SchemaHash {
+				bundle.Schema.SchemaJSON = nil
+			}
+		}
 	}
 
 	return bundle, nil
 }
 
+// DeleteUserRef deletes one of the caller's personal refs on the remote.
+func DeleteUserRef(ctx context.Context, client remote.RemoteClient, name string) error {
+	if err := client.DeleteUserRef(ctx, name); err != nil {
+		return fmt.Errorf("delete personal ref: %w", err)
+	}
+	return nil
+}
+
 // DeleteRemoteBranch deletes a branch on the remote server.
 func DeleteRemoteBranch(ctx context.Context, st *store.Store, client remote.RemoteClient, remoteName, branch string) error {
 	if err := client.DeleteBranch(ctx, branch); err != nil {
@@ -278,41 +561,51 @@ func DeleteRemoteBranch(ctx context.Context, st *store.Store, client remote.Remo
 
 // ResolveRemoteAndBranch resolves default remote and branch names.
 func ResolveRemoteAndBranch(st *store.Store, remoteName, branch string) (string, string, error) {
-	// Default remote
+	remoteName, err := ResolveRemote(st, remoteName)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Default branch
+	if branch == "" {
+		branch, err = st.GetCurrentBranch()
+		if err != nil {
+			return "", "", fmt.Errorf("get current branch: %w", err)
+		}
+		if branch == "" {
+			return "", "", fmt.Errorf("not on any branch — specify branch name explicitly")
+		}
+	}
+
+	return remoteName, branch, nil
+}
+
+// ResolveRemote returns remoteName if it exists, or the only configured remote
+// when remoteName is empty.
+func ResolveRemote(st *store.Store, remoteName string) (string, error) {
 	if remoteName == "" {
 		remotes, err := st.ListRemotes()
 		if err != nil {
-			return "", "", fmt.Errorf("list remotes: %w", err)
+			return "", fmt.Errorf("list remotes: %w", err)
 		}
 		if len(remotes) == 0 {
-			return "", "", fmt.Errorf("no remotes configured — add one with 'wvc remote add'")
+			return "", fmt.Errorf("no remotes configured — add one with 'wvc remote add'")
 		}
 		if len(remotes) == 1 {
 			remoteName = remotes[0].Name
 		} else {
-			return "", "", fmt.Errorf("multiple remotes configured — specify which with 'wvc push <remote>'")
+			return "", fmt.Errorf("multiple remotes configured — specify which with 'wvc push <remote>'")
 		}
 	}
 
 	// Verify remote exists
 	r, err := st.GetRemote(remoteName)
 	if err != nil {
-		return "", "", fmt.Errorf("get remote: %w", err)
+		return "", fmt.Errorf("get remote: %w", err)
 	}
 	if r == nil {
-		return "", "", fmt.Errorf("remote '%s' does not exist", remoteName)
-	}
-
-	// Default branch
-	if branch == "" {
-		branch, err = st.GetCurrentBranch()
-		if err != nil {
-			return "", "", fmt.Errorf("get current branch: %w", err)
-		}
-		if branch == "" {
-			return "", "", fmt.Errorf("not on any branch — specify branch name explicitly")
-		}
+		return "", fmt.Errorf("remote '%s' does not exist", remoteName)
 	}
 
-	return remoteName, branch, nil
+	return remoteName, nil
 }
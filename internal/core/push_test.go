@@ -30,6 +30,8 @@ type pushMockClient struct {
 
 	// Vectors
 	vectorCheckResp *remote.VectorCheckResponse
+	vectorCheckArgs []string
+	vectorBloom     *remote.BloomFilter
 	uploadedVectors map[string]int // hash -> dims
 	uploadVectorErr error
 
@@ -38,7 +40,14 @@ type pushMockClient struct {
 	uploadBundleErr error
 
 	// Branch
-	updateBranchErr  error
+	updateBranchErr error
+	updatedUserRef  bool // last update went through UpdateUserRef
+
+	deployments map[string]string // environment -> commit ID
+	tags        map[string]*models.Tag
+	notes       map[string]*models.Note
+
+	repoInfo         *remote.RepoInfo
 	updateBranchArgs struct {
 		branch      string
 		newTip      string
@@ -60,17 +69,27 @@ func (m *pushMockClient) NegotiatePush(_ context.Context, branch string, commitI
 	return m.negotiatePushResp, m.negotiatePushErr
 }
 
-func (m *pushMockClient) NegotiatePull(_ context.Context, _ string, _ string, _ int) (*remote.NegotiatePullResponse, error) {
+func (m *pushMockClient) NegotiatePull(_ context.Context, _ string, _ string, _ int, _, _ []string) (*remote.NegotiatePullResponse, error) {
 	return nil, nil
 }
 
 func (m *pushMockClient) CheckVectors(_ context.Context, hashes []string) (*remote.VectorCheckResponse, error) {
+	m.mu.Lock()
+	m.vectorCheckArgs = hashes
+	m.mu.Unlock()
 	if m.vectorCheckResp != nil {
 		return m.vectorCheckResp, nil
 	}
 	return &remote.VectorCheckResponse{Have: nil, Missing: hashes}, nil
 }
 
+func (m *pushMockClient) VectorBloom(_ context.Context) (*remote.BloomFilter, error) {
+	if m.vectorBloom != nil {
+		return m.vectorBloom, nil
+	}
+	return nil, &remote.RemoteError{Code: "not_found", Message: "not found", Status: 404}
+}
+
 func (m *pushMockClient) UploadVector(_ context.Context, hash string, r io.Reader, dims int) error {
 	if m.uploadVectorErr != nil {
 		return m.uploadVectorErr
@@ -99,19 +118,150 @@ func (m *pushMockClient) UploadCommitBundle(_ context.Context, bundle *remote.Co
 	return nil
 }
 
-func (m *pushMockClient) DownloadCommitBundle(_ context.Context, _ string) (*remote.CommitBundle, error) {
+func (m *pushMockClient) DownloadCommitBundle(_ context.Context, _, _ string) (*remote.CommitBundle, error) {
+	return nil, fmt.Errorf("not implemented in push mock")
+}
+
+func (m *pushMockClient) DownloadFilteredCommitBundle(_ context.Context, _ string, _ *remote.FilteredBundleRequest) (*remote.FilteredBundle, error) {
+	return nil, fmt.Errorf("not implemented in push mock")
+}
+
+func (m *pushMockClient) DownloadCommitState(_ context.Context, _ string, _ []string) (*remote.CommitState, error) {
+	return nil, fmt.Errorf("not implemented in push mock")
+}
+
+func (m *pushMockClient) DownloadCommitPayloads(_ context.Context, _ string) ([]*remote.OperationPayload, error) {
+	return nil, fmt.Errorf("not implemented in push mock")
+}
+
+func (m *pushMockClient) DownloadSchema(_ context.Context, _ string) (*remote.SchemaSnapshot, error) {
+	return nil, fmt.Errorf("not implemented in push mock")
+}
+
+func (m *pushMockClient) UploadStash(_ context.Context, _ *remote.RemoteStash) error {
+	return nil
+}
+
+func (m *pushMockClient) ListStashes(_ context.Context) ([]*remote.RemoteStash, error) {
+	return nil, nil
+}
+
+func (m *pushMockClient) DownloadStash(_ context.Context, _ string) (*remote.RemoteStash, error) {
 	return nil, fmt.Errorf("not implemented in push mock")
 }
 
+func (m *pushMockClient) DeleteStash(_ context.Context, _ string) error {
+	return nil
+}
+
 func (m *pushMockClient) UpdateBranch(_ context.Context, branch, newTip, expectedTip string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.updateBranchArgs.branch = branch
 	m.updateBranchArgs.newTip = newTip
 	m.updateBranchArgs.expectedTip = expectedTip
+	m.updatedUserRef = false
+	return m.updateBranchErr
+}
+
+func (m *pushMockClient) ListUserRefs(_ context.Context) ([]*models.Branch, error) {
+	return nil, nil
+}
+
+func (m *pushMockClient) UpdateUserRef(_ context.Context, name, newTip, expectedTip string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.updateBranchArgs.branch = name
+	m.updateBranchArgs.newTip = newTip
+	m.updateBranchArgs.expectedTip = expectedTip
+	m.updatedUserRef = true
 	return m.updateBranchErr
 }
 
+func (m *pushMockClient) DeleteUserRef(_ context.Context, _ string) error {
+	return nil
+}
+
+func (m *pushMockClient) ListTags(_ context.Context) ([]*models.Tag, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var tags []*models.Tag
+	for _, tag := range m.tags {
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+func (m *pushMockClient) PutTag(_ context.Context, tag *models.Tag, force bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.tags == nil {
+		m.tags = make(map[string]*models.Tag)
+	}
+	if existing := m.tags[tag.Name]; existing != nil && existing.CommitID != tag.CommitID && !force {
+		return fmt.Errorf("tag %s exists", tag.Name)
+	}
+	m.tags[tag.Name] = tag
+	return nil
+}
+
+func (m *pushMockClient) DeleteTag(_ context.Context, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.tags, name)
+	return nil
+}
+
+func (m *pushMockClient) ListNotes(_ context.Context) ([]*models.Note, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var notes []*models.Note
+	for _, note := range m.notes {
+		notes = append(notes, note)
+	}
+	return notes, nil
+}
+
+func (m *pushMockClient) PutNote(_ context.Context, note *models.Note, force bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.notes == nil {
+		m.notes = make(map[string]*models.Note)
+	}
+	if existing := m.notes[note.CommitID]; existing != nil && existing.Message != note.Message && !force {
+		return fmt.Errorf("commit %s already has another note", note.CommitID)
+	}
+	m.notes[note.CommitID] = note
+	return nil
+}
+
+func (m *pushMockClient) DeleteNote(_ context.Context, commitID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.notes, commitID)
+	return nil
+}
+
+func (m *pushMockClient) ListDeployments(_ context.Context) ([]*models.Branch, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var refs []*models.Branch
+	for env, commitID := range m.deployments {
+		refs = append(refs, &models.Branch{Name: env, CommitID: commitID})
+	}
+	return refs, nil
+}
+
+func (m *pushMockClient) RecordDeployment(_ context.Context, env, commitID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.deployments == nil {
+		m.deployments = make(map[string]string)
+	}
+	m.deployments[env] = commitID
+	return nil
+}
+
 func (m *pushMockClient) DeleteBranch(_ context.Context, _ string) error {
 	return nil
 }
@@ -125,7 +275,15 @@ func (m *pushMockClient) GetBranch(_ context.Context, _ string) (*models.Branch,
 }
 
 func (m *pushMockClient) GetRepoInfo(_ context.Context) (*remote.RepoInfo, error) {
-	return nil, nil
+	return m.repoInfo, nil
+}
+
+func (m *pushMockClient) GetCommitLog(_ context.Context, _, _ string, _ int) (*remote.CommitLogResponse, error) {
+	return &remote.CommitLogResponse{}, nil
+}
+
+func (m *pushMockClient) Compare(_ context.Context, _, _ string) (*remote.CompareResponse, error) {
+	return &remote.CompareResponse{}, nil
 }
 
 func newPushTestStore(t *testing.T) *store.Store {
@@ -202,6 +360,37 @@ func TestPush_WithCommits(t *testing.T) {
 	assert.Equal(t, "c2", rb.CommitID)
 }
 
+func TestPush_RestrictedClasses(t *testing.T) {
+	st := newPushTestStore(t)
+
+	now := time.Now()
+	require.NoError(t, st.CreateCommit(&models.Commit{ID: "c1", Message: "first", Timestamp: now}))
+	require.NoError(t, st.CreateCommit(&models.Commit{ID: "c2", ParentID: "c1", Message: "people", Timestamp: now.Add(time.Second),
+		Classifications: map[string]*models.Classification{
+			"Person":  {PII: models.PIIRestricted},
+			"Article": {License: "CC-BY-4.0"},
+		}}))
+	require.NoError(t, st.CreateBranch("main", "c2"))
+	require.NoError(t, st.AddRemote("origin", "http://example.com"))
+
+	push := func() *PushResult {
+		client := newPushMockClient()
+		client.negotiatePushResp = &remote.NegotiatePushResponse{MissingCommits: []string{"c2"}, RemoteTip: "c1"}
+		client.vectorCheckResp = &remote.VectorCheckResponse{}
+		result, err := Push(context.Background(), st, client, PushOptions{RemoteName: "origin", Branch: "main"}, nil)
+		require.NoError(t, err)
+		return result
+	}
+
+	// The push goes ahead, reporting what the remote should not hold
+	result := push()
+	assert.Equal(t, 1, result.CommitsPushed)
+	assert.Equal(t, []string{"Person"}, result.RestrictedClasses)
+
+	require.NoError(t, SetRemotePIIApproved(st, "origin", true))
+	assert.Empty(t, push().RestrictedClasses)
+}
+
 func TestPush_WithVectors(t *testing.T) {
 	st := newPushTestStore(t)
 
@@ -318,6 +507,37 @@ func TestPush_CreatesRemoteBranch(t *testing.T) {
 	assert.Equal(t, "", client.updateBranchArgs.expectedTip)
 }
 
+func TestPush_UserRef(t *testing.T) {
+	st := newPushTestStore(t)
+
+	require.NoError(t, st.CreateCommit(&models.Commit{ID: "c1", Message: "first", Timestamp: time.Now()}))
+	require.NoError(t, st.CreateBranch("wip", "c1"))
+	require.NoError(t, st.AddRemote("origin", "http://example.com"))
+
+	client := newPushMockClient()
+	client.repoInfo = &remote.RepoInfo{Token: &remote.TokenScope{ID: "tok-1", Permission: "rw"}}
+	client.negotiatePushResp = &remote.NegotiatePushResponse{
+		MissingCommits: []string{"c1"},
+	}
+
+	result, err := Push(context.Background(), st, client, PushOptions{
+		RemoteName: "origin",
+		Branch:     "wip",
+		UserRef:    true,
+	}, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, "refs/users/tok-1/wip", result.RemoteRef)
+	assert.Equal(t, "refs/users/tok-1/wip", client.negotiatePushArgs.branch)
+	assert.True(t, client.updatedUserRef)
+	assert.Equal(t, "wip", client.updateBranchArgs.branch)
+
+	// Personal refs are not tracked locally
+	rb, err := st.GetRemoteBranch("origin", "wip")
+	require.NoError(t, err)
+	assert.Nil(t, rb)
+}
+
 func TestPush_DivergenceRejected(t *testing.T) {
 	st := newPushTestStore(t)
 
@@ -543,3 +763,220 @@ func TestResolveRemoteAndBranch_MultipleRemotes(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "multiple remotes")
 }
+
+// graphNegotiateClient answers push negotiation from a set of commits the
+// "server" already has, recording each request so round sizes can be checked.
+type graphNegotiateClient struct {
+	*pushMockClient
+	has       map[string]bool
+	remoteTip string
+	requests  [][]string
+}
+
+func (g *graphNegotiateClient) NegotiatePush(_ context.Context, _ string, commitIDs []string) (*remote.NegotiatePushResponse, error) {
+	g.requests = append(g.requests, commitIDs)
+	resp := &remote.NegotiatePushResponse{RemoteTip: g.remoteTip}
+	for _, id := range commitIDs {
+		if !g.has[id] {
+			resp.MissingCommits = append(resp.MissingCommits, id)
+		}
+	}
+	return resp, nil
+}
+
+func TestNegotiatePush_SamplesLongHistory(t *testing.T) {
+	const total = 1000
+	const onServer = 600
+
+	ids := make([]string, total) // tip-first
+	parents := make(map[string][]string)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("c%04d", total-i)
+	}
+	for i := 0; i < total-1; i++ {
+		parents[ids[i]] = []string{ids[i+1]}
+	}
+
+	has := make(map[string]bool)
+	for _, id := range ids[total-onServer:] {
+		has[id] = true
+	}
+	// No remote tip so the sampling rounds must find the boundary on their own.
+	client := &graphNegotiateClient{pushMockClient: newPushMockClient(), has: has}
+
+	resp, err := negotiatePush(context.Background(), client, "main", ids, parents, func(string, int, int) {})
+	require.NoError(t, err)
+	assert.Equal(t, ids[:total-onServer], resp.MissingCommits)
+
+	sent := 0
+	for _, req := range client.requests {
+		sent += len(req)
+	}
+	assert.Less(t, sent, 200, "negotiation should exchange far fewer IDs than the full history")
+}
+
+func TestNegotiatePush_TipSampleResolvesFastForward(t *testing.T) {
+	ids := []string{"c5", "c4", "c3", "c2", "c1"}
+	parents := map[string][]string{"c5": {"c4"}, "c4": {"c3"}, "c3": {"c2"}, "c2": {"c1"}}
+
+	client := &graphNegotiateClient{
+		pushMockClient: newPushMockClient(),
+		has:            map[string]bool{"c3": true, "c2": true, "c1": true},
+		remoteTip:      "c3",
+	}
+
+	resp, err := negotiatePush(context.Background(), client, "main", ids, parents, func(string, int, int) {})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c5", "c4"}, resp.MissingCommits)
+	assert.Equal(t, "c3", resp.RemoteTip)
+	assert.Len(t, client.requests, 1)
+}
+
+func TestCheckMissingVectors_UsesBloomFilter(t *testing.T) {
+	hashes := make([]string, bloomCheckThreshold)
+	filter := remote.NewBloomFilter(bloomCheckThreshold, 0.01)
+	for i := range hashes {
+		hashes[i] = fmt.Sprintf("%064x", i)
+		if i%2 == 0 {
+			filter.Add(hashes[i])
+		}
+	}
+
+	client := newPushMockClient()
+	client.vectorBloom = filter
+
+	missing, err := checkMissingVectors(context.Background(), client, hashes)
+	require.NoError(t, err)
+
+	// The mock's exact check reports everything it is asked about as missing, so
+	// every hash must come back missing, but the server only confirms the ones the
+	// filter could not rule out.
+	assert.Len(t, missing, len(hashes))
+	assert.Less(t, len(client.vectorCheckArgs), len(hashes)*3/4)
+	for i := 0; i < len(hashes); i += 2 {
+		assert.Contains(t, client.vectorCheckArgs, hashes[i])
+	}
+}
+
+func TestCheckMissingVectors_FallsBackWithoutBloom(t *testing.T) {
+	hashes := make([]string, bloomCheckThreshold)
+	for i := range hashes {
+		hashes[i] = fmt.Sprintf("%064x", i)
+	}
+
+	client := newPushMockClient()
+
+	missing, err := checkMissingVectors(context.Background(), client, hashes)
+	require.NoError(t, err)
+	assert.Len(t, missing, len(hashes))
+	assert.Len(t, client.vectorCheckArgs, len(hashes))
+}
+
+// orderingMockClient fails bundle uploads that arrive before every vector
+// has been uploaded.
+type orderingMockClient struct {
+	*pushMockClient
+	wantVectors int
+}
+
+func (o *orderingMockClient) UploadCommitBundle(ctx context.Context, bundle *remote.CommitBundle) error {
+	o.mu.Lock()
+	uploaded := len(o.uploadedVectors)
+	o.mu.Unlock()
+	if uploaded < o.wantVectors {
+		return fmt.Errorf("bundle %s uploaded before its vectors", bundle.Commit.ID)
+	}
+	return o.pushMockClient.UploadCommitBundle(ctx, bundle)
+}
+
+func TestPush_UploadsVectorsBeforeBundles(t *testing.T) {
+	st := newPushTestStore(t)
+
+	require.NoError(t, st.CreateCommit(&models.Commit{ID: "c1", Message: "first", Timestamp: time.Now()}))
+	require.NoError(t, st.CreateBranch("main", "c1"))
+	require.NoError(t, st.AddRemote("origin", "http://example.com"))
+
+	for i, v := range [][]byte{{0, 0, 128, 63}, {0, 0, 0, 64}} {
+		vhash, err := st.SaveVectorBlob(v, 1)
+		require.NoError(t, err)
+		require.NoError(t, st.RecordOperation(&models.Operation{
+			Type: models.OperationInsert, ClassName: "A", ObjectID: fmt.Sprint(i), VectorHash: vhash,
+		}))
+	}
+	_, err := st.MarkOperationsCommitted("c1")
+	require.NoError(t, err)
+
+	client := &orderingMockClient{pushMockClient: newPushMockClient(), wantVectors: 2}
+	client.negotiatePushResp = &remote.NegotiatePushResponse{MissingCommits: []string{"c1"}}
+
+	result, err := Push(context.Background(), st, client, PushOptions{RemoteName: "origin", Branch: "main"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.CommitsPushed)
+	assert.Equal(t, 2, result.VectorsPushed)
+}
+
+func TestPush_VectorFailureSkipsBranchUpdate(t *testing.T) {
+	fastVectorRetry(t)
+	st := newPushTestStore(t)
+
+	require.NoError(t, st.CreateCommit(&models.Commit{ID: "c1", Message: "first", Timestamp: time.Now()}))
+	require.NoError(t, st.CreateBranch("main", "c1"))
+	require.NoError(t, st.AddRemote("origin", "http://example.com"))
+
+	vhash, err := st.SaveVectorBlob([]byte{0, 0, 128, 63}, 1)
+	require.NoError(t, err)
+	require.NoError(t, st.RecordOperation(&models.Operation{
+		Type: models.OperationInsert, ClassName: "A", ObjectID: "1", VectorHash: vhash,
+	}))
+	_, err = st.MarkOperationsCommitted("c1")
+	require.NoError(t, err)
+
+	client := newPushMockClient()
+	client.negotiatePushResp = &remote.NegotiatePushResponse{MissingCommits: []string{"c1"}}
+	client.uploadVectorErr = fmt.Errorf("disk full")
+
+	_, err = Push(context.Background(), st, client, PushOptions{RemoteName: "origin", Branch: "main"}, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "upload vectors")
+	assert.Empty(t, client.updateBranchArgs.newTip)
+}
+
+func TestPush_RetryAfterVectorFailureUploadsVectors(t *testing.T) {
+	fastVectorRetry(t)
+	st := newPushTestStore(t)
+
+	require.NoError(t, st.CreateCommit(&models.Commit{ID: "c1", Message: "first", Timestamp: time.Now()}))
+	require.NoError(t, st.CreateBranch("main", "c1"))
+	require.NoError(t, st.AddRemote("origin", "http://example.com"))
+
+	vhash, err := st.SaveVectorBlob([]byte{0, 0, 128, 63}, 1)
+	require.NoError(t, err)
+	require.NoError(t, st.RecordOperation(&models.Operation{
+		Type: models.OperationInsert, ClassName: "A", ObjectID: "1", VectorHash: vhash,
+	}))
+	_, err = st.MarkOperationsCommitted("c1")
+	require.NoError(t, err)
+
+	client := newPushMockClient()
+	client.negotiatePushResp = &remote.NegotiatePushResponse{MissingCommits: []string{"c1"}}
+	client.uploadVectorErr = fmt.Errorf("connection reset")
+
+	_, err = Push(context.Background(), st, client, PushOptions{RemoteName: "origin", Branch: "main"}, nil)
+	require.Error(t, err)
+	// The server must not hold a commit whose vectors it lacks
+	assert.Empty(t, client.uploadedBundles)
+
+	// The retry negotiates against what the server holds
+	var missing []string
+	if len(client.uploadedBundles) == 0 {
+		missing = []string{"c1"}
+	}
+	client.negotiatePushResp = &remote.NegotiatePushResponse{MissingCommits: missing}
+	client.uploadVectorErr = nil
+
+	result, err := Push(context.Background(), st, client, PushOptions{RemoteName: "origin", Branch: "main"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.CommitsPushed)
+	assert.Contains(t, client.uploadedVectors, vhash)
+	assert.Equal(t, "c1", client.updateBranchArgs.newTip)
+}
@@ -0,0 +1,236 @@
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kilupskalvis/wvc/internal/config"
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/store"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+)
+
+// DefaultQueryLimit is the number of results a historical query returns
+// when no limit is given
+const DefaultQueryLimit = 10
+
+// QueryOptions configures QueryAtRef.
+type QueryOptions struct {
+	Ref string
+	// ClassName is the class to query, qualified with a tenant
+	// ("Class@tenant") for multi-tenant classes
+	ClassName string
+	// NearText are the concepts of a nearText search; without them objects
+	// are returned in no particular order
+	NearText []string
+	// Limit is the number of results, DefaultQueryLimit when zero
+	Limit int
+	// Properties are the properties returned; by default every property
+	// that is not a reference or a nested object
+	Properties []string
+}
+
+// QueryResult is the result of a query against a past version of a class.
+type QueryResult struct {
+	CommitID  string
+	ClassName string
+	// Materialized is the number of objects the class had at the commit
+	Materialized int
+	// Query is the GraphQL query that was run
+	Query   string
+	Results []map[string]interface{}
+}
+
+// QueryAtRef runs a query against a class as it was at a ref, without
+// checking it out: the class is written, with the objects and vectors it had
+// at the ref's commit, to a temporary class in Weaviate, queried, and
+// deleted again. Reference properties are left out of the temporary class,
+// since the objects they point to may not exist.
+func QueryAtRef(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface, opts QueryOptions) (result *QueryResult, err error) {
+	commitID, _, err := ResolveRef(st, opts.Ref)
+	if err != nil {
+		return nil, err
+	}
+	schema, _, err := schemaAtRef(st, opts.Ref)
+	if err != nil {
+		return nil, err
+	}
+	baseClass, tenant := models.SplitTenantClass(opts.ClassName)
+	class := buildClassMap(schema)[baseClass]
+	if class == nil {
+		return nil, fmt.Errorf("class %s does not exist at %s", baseClass, opts.Ref)
+	}
+	if class.MultiTenant() && tenant == "" {
+		return nil, fmt.Errorf("class %s is multi-tenant; name a tenant as %s@<tenant>", baseClass, baseClass)
+	}
+
+	temp, err := queryClass(class)
+	if err != nil {
+		return nil, err
+	}
+	properties := opts.Properties
+	if len(properties) == 0 {
+		for _, prop := range temp.Properties {
+			if !isObjectProperty(prop) {
+				properties = append(properties, prop.Name)
+			}
+		}
+	}
+
+	query, err := buildQuery(temp.Class, properties, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := reconstructStateAtCommit(st, commitID)
+	if err != nil {
+		return nil, err
+	}
+	objects := make(map[string]*objectWithVector)
+	for key, obj := range state {
+		if obj.Object.TenantClass() == opts.ClassName {
+			objects[key] = obj
+		}
+	}
+	if err := fetchMissingVectors(st, objects); err != nil {
+		return nil, err
+	}
+
+	existing, err := client.GetClasses(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range existing {
+		if strings.EqualFold(name, temp.Class) {
+			return nil, fmt.Errorf("temporary class %s already exists", temp.Class)
+		}
+	}
+	if err := client.CreateClass(ctx, temp); err != nil {
+		return nil, fmt.Errorf("create temporary class %s: %w", temp.Class, err)
+	}
+	defer func() {
+		if derr := client.DeleteClass(context.WithoutCancel(ctx), temp.Class); derr != nil && err == nil {
+			result, err = nil, fmt.Errorf("delete temporary class %s: %w", temp.Class, derr)
+		}
+	}()
+
+	writes := make([]*objectWrite, 0, len(objects))
+	for _, key := range sortedKeys(objects) {
+		obj := objects[key]
+		obj.restoreVectors(st)
+		copied := *obj.Object
+		copied.Class, copied.Tenant = temp.Class, ""
+		copied.Properties = make(map[string]interface{}, len(obj.Object.Properties))
+		for _, prop := range temp.Properties {
+			if v, ok := obj.Object.Properties[prop.Name]; ok {
+				copied.Properties[prop.Name] = v
+			}
+		}
+		writes = append(writes, &objectWrite{Action: models.ApplyCreate, Object: &copied})
+	}
+	if err := applyObjectWrites(ctx, cfg, client, writes); err != nil {
+		return nil, err
+	}
+	var failed []error
+	for _, w := range writes {
+		if w.Err != nil {
+			failed = append(failed, fmt.Errorf("%s: %w", w.Object.ID, w.Err))
+		}
+	}
+	if len(failed) > 0 {
+		return nil, fmt.Errorf("write %d object(s) of %s to the temporary class: %w", len(failed), opts.ClassName, errors.Join(failed...))
+	}
+
+	data, err := client.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	result = &QueryResult{CommitID: commitID, ClassName: opts.ClassName, Materialized: len(writes), Query: query}
+	get, _ := data["Get"].(map[string]interface{})
+	hits, _ := get[temp.Class].([]interface{})
+	for _, hit := range hits {
+		if m, ok := hit.(map[string]interface{}); ok {
+			result.Results = append(result.Results, m)
+		}
+	}
+	return result, nil
+}
+
+// queryClass returns the definition of the temporary class a historical
+// query of class runs against: the same class under a unique name, without
+// reference properties or tenants
+func queryClass(class *models.WeaviateClass) (*models.WeaviateClass, error) {
+	nonce := make([]byte, 4)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	temp := *class
+	temp.Class = fmt.Sprintf("%sWvcQuery%s", class.Class, hex.EncodeToString(nonce))
+	temp.MultiTenancy, temp.ShardingConfig, temp.Replication = nil, nil, nil
+	temp.Properties = nil
+	for _, prop := range class.Properties {
+		if !isReferenceProperty(prop) {
+			temp.Properties = append(temp.Properties, prop)
+		}
+	}
+	return &temp, nil
+}
+
+// isReferenceProperty reports whether a property references other classes,
+// whose names start with an upper-case letter unlike primitive types
+func isReferenceProperty(prop *models.WeaviateProperty) bool {
+	return len(prop.DataType) > 0 && prop.DataType[0] != "" && prop.DataType[0][0] >= 'A' && prop.DataType[0][0] <= 'Z'
+}
+
+// isObjectProperty reports whether a property holds nested objects, which
+// GraphQL only returns field by field
+func isObjectProperty(prop *models.WeaviateProperty) bool {
+	return len(prop.DataType) == 1 && (prop.DataType[0] == "object" || prop.DataType[0] == "object[]")
+}
+
+// buildQuery builds the GraphQL Get query of a historical query
+func buildQuery(className string, properties []string, opts QueryOptions) (string, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultQueryLimit
+	}
+	args := fmt.Sprintf("limit: %d", limit)
+	additional := "id"
+	if len(opts.NearText) > 0 {
+		concepts, err := json.Marshal(opts.NearText)
+		if err != nil {
+			return "", err
+		}
+		args += fmt.Sprintf(", nearText: {concepts: %s}", concepts)
+		additional += " distance"
+	}
+	for _, prop := range properties {
+		if !graphQLName(prop) {
+			return "", fmt.Errorf("invalid property name '%s'", prop)
+		}
+	}
+	fields := strings.Join(properties, " ")
+	return fmt.Sprintf("{ Get { %s(%s) { %s _additional { %s } } } }", className, args, fields, additional), nil
+}
+
+// graphQLName reports whether s is a valid GraphQL field name
+func graphQLName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
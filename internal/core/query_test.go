@@ -0,0 +1,83 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryAtRef(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+
+	text := []string{"text"}
+	client.AddClass(&models.WeaviateClass{Class: "Author", Properties: []*models.WeaviateProperty{{Name: "name", DataType: text}}})
+	client.AddClass(&models.WeaviateClass{Class: "Article", Vectorizer: "text2vec-openai", Properties: []*models.WeaviateProperty{
+		{Name: "title", DataType: text},
+		{Name: "meta", DataType: []string{"object"}},
+		{Name: "author", DataType: []string{"Author"}},
+	}})
+	client.AddObject(&models.WeaviateObject{ID: "a-1", Class: "Article", Vector: []float32{1, 0}, Properties: map[string]interface{}{
+		"title":  "Old title",
+		"author": []interface{}{map[string]interface{}{"beacon": "weaviate://localhost/Author/au-1"}},
+	}})
+	_, err := CreateCommit(ctx, cfg, st, client, "v1")
+	require.NoError(t, err)
+	client.AddObject(&models.WeaviateObject{ID: "a-1", Class: "Article", Vector: []float32{0, 1}, Properties: map[string]interface{}{"title": "New title"}})
+	client.AddObject(&models.WeaviateObject{ID: "a-2", Class: "Article", Properties: map[string]interface{}{"title": "Second"}})
+	_, err = CreateCommit(ctx, cfg, st, client, "v2")
+	require.NoError(t, err)
+
+	// Answer with the objects of the temporary class as they are when the
+	// query runs
+	var written []*models.WeaviateObject
+	var tempClass string
+	client.QueryFunc = func(query string) (map[string]interface{}, error) {
+		var hits []interface{}
+		for _, obj := range client.Objects {
+			if strings.HasPrefix(obj.Class, "ArticleWvcQuery") {
+				tempClass = obj.Class
+				written = append(written, obj)
+				hits = append(hits, map[string]interface{}{"title": obj.Properties["title"]})
+			}
+		}
+		return map[string]interface{}{"Get": map[string]interface{}{tempClass: hits}}, nil
+	}
+
+	result, err := QueryAtRef(ctx, cfg, st, client, QueryOptions{Ref: "HEAD~1", ClassName: "Article", NearText: []string{"climate"}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Materialized)
+	require.Len(t, result.Results, 1)
+	assert.Equal(t, "Old title", result.Results[0]["title"])
+	assert.Equal(t, "{ Get { "+tempClass+`(limit: 10, nearText: {concepts: ["climate"]}) { title _additional { id distance } } } }`, result.Query)
+
+	require.Len(t, written, 1)
+	assert.Equal(t, []float32{1, 0}, written[0].Vector, "the vector of the commit is written")
+	assert.NotContains(t, written[0].Properties, "author", "references are left out")
+
+	// The temporary class is gone and the live class untouched
+	classes, err := client.GetClasses(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"Author", "Article"}, classes)
+	assert.Equal(t, "New title", client.Objects["Article/a-1"].Properties["title"])
+
+	result, err = QueryAtRef(ctx, cfg, st, client, QueryOptions{Ref: "HEAD", ClassName: "Article", Properties: []string{"title"}, Limit: 5})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Materialized)
+	assert.Contains(t, result.Query, "(limit: 5) { title _additional { id } }")
+
+	_, err = QueryAtRef(ctx, cfg, st, client, QueryOptions{Ref: "HEAD", ClassName: "Missing"})
+	assert.ErrorContains(t, err, "class Missing does not exist at HEAD")
+	_, err = QueryAtRef(ctx, cfg, st, client, QueryOptions{Ref: "HEAD", ClassName: "Article", Properties: []string{"title }"}})
+	assert.ErrorContains(t, err, "invalid property name")
+	classes, err = client.GetClasses(ctx)
+	require.NoError(t, err)
+	assert.Len(t, classes, 2, "temporary classes are deleted when a query fails")
+}
@@ -0,0 +1,179 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/config"
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/store"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+)
+
+// RebaseResult is the outcome of replaying local commits onto a new base.
+// When a commit conflicts and no strategy resolves it, the rebase stops:
+// StoppedAt names that commit, Conflicts lists what conflicted, and the
+// branch and Weaviate are returned to where they were.
+type RebaseResult struct {
+	Onto              string
+	Commits           []*models.Commit // replayed commits, oldest first
+	Skipped           int              // local commits whose changes the new base already had
+	ResolvedConflicts int
+	StoppedAt         string
+	Conflicts         []*models.MergeConflict
+	Warnings          []CheckoutWarning
+}
+
+// rebaseOnto replays the commits of the checked-out branch that onto lacks
+// on top of onto, one three-way merge per commit, keeping each commit's
+// message and author. As with merge, models.ConflictOurs resolves a conflict
+// with the local version, the replayed commit's, and models.ConflictTheirs
+// with the version on onto.
+func rebaseOnto(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface, branch, onto string, strategy models.ConflictStrategy) (*RebaseResult, error) {
+	origTip, err := st.GetHEAD()
+	if err != nil {
+		return nil, err
+	}
+	ontoAncestors, err := st.GetAllAncestors(onto)
+	if err != nil {
+		return nil, fmt.Errorf("get ancestors of %s: %w", shortCommitID(onto), err)
+	}
+
+	// The local commits, newest first along first parents
+	var replay []*models.Commit
+	id := origTip
+	for id != "" && !ontoAncestors[id] {
+		c, err := st.GetCommit(id)
+		if err != nil {
+			return nil, fmt.Errorf("get commit %s: %w", shortCommitID(id), err)
+		}
+		if c.MergeParentID != "" {
+			return nil, fmt.Errorf("cannot rebase: %s is a merge commit; pull with --merge instead", shortCommitID(c.ID))
+		}
+		replay = append(replay, c)
+		id = c.ParentID
+	}
+	if id == "" {
+		return nil, fmt.Errorf("cannot rebase: no common ancestor with %s", shortCommitID(onto))
+	}
+	slices.Reverse(replay)
+
+	result := &RebaseResult{Onto: onto}
+	scope, err := currentScope(st)
+	if err != nil {
+		return nil, err
+	}
+	moveTo := func(commitID string) error {
+		warnings, _, err := restoreStateToCommit(ctx, cfg, st, client, commitID, scope)
+		if err != nil {
+			return fmt.Errorf("restore state: %w", err)
+		}
+		result.Warnings = append(result.Warnings, warnings...)
+		if err := st.UpdateBranchAndHEAD(branch, commitID); err != nil {
+			return err
+		}
+		if err := rebuildKnownObjectsFromCommit(st, commitID); err != nil {
+			result.Warnings = append(result.Warnings, CheckoutWarning{
+				Type:    "known_state",
+				Message: fmt.Sprintf("failed to rebuild known state: %v", err),
+			})
+		}
+		return nil
+	}
+
+	if err := moveTo(onto); err != nil {
+		return nil, err
+	}
+	tip := onto
+	for _, c := range replay {
+		states, err := loadMergeStates(cfg, st, c.ParentID, tip, c.ID)
+		if err != nil {
+			return nil, err
+		}
+		conflicts := detectObjectConflicts(states)
+		if len(conflicts) > 0 && strategy != models.ConflictOurs && strategy != models.ConflictTheirs {
+			result.StoppedAt = c.ID
+			result.Conflicts = conflicts
+			result.Commits = nil
+			return result, moveTo(origTip)
+		}
+
+		// Replaying puts onto on our side of the merge, so the sides swap
+		side := models.ConflictTheirs
+		if strategy == models.ConflictTheirs {
+			side = models.ConflictOurs
+		}
+		merged := computeMergedState(states)
+		result.ResolvedConflicts += resolveConflicts(conflicts, side, merged)
+		stats, err := applyMergedState(ctx, cfg, st, client, states.ours, merged)
+		if err != nil {
+			return nil, fmt.Errorf("replay %s: %w", shortCommitID(c.ID), err)
+		}
+		if stats.Added+stats.Updated+stats.Removed == 0 {
+			result.Skipped++
+			continue
+		}
+		commit, err := replayCommit(ctx, cfg, st, client, c, stats)
+		if err != nil {
+			return nil, fmt.Errorf("replay %s: %w", shortCommitID(c.ID), err)
+		}
+		result.Commits = append(result.Commits, commit)
+		tip = commit.ID
+	}
+
+	if err := rebuildKnownObjectsFromCommit(st, tip); err != nil {
+		result.Warnings = append(result.Warnings, CheckoutWarning{
+			Type:    "known_state",
+			Message: fmt.Sprintf("failed to rebuild known state: %v", err),
+		})
+	}
+	return result, nil
+}
+
+// replayCommit commits the operations a replayed commit recorded on top of
+// HEAD, keeping the original message and author.
+func replayCommit(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface, orig *models.Commit, stats *StateRestoreStats) (*models.Commit, error) {
+	parentID, err := st.GetHEAD()
+	if err != nil {
+		return nil, err
+	}
+	uncommittedOps, err := st.GetUncommittedOperations()
+	if err != nil {
+		return nil, err
+	}
+	pins, err := st.GetSubmodulePins()
+	if err != nil {
+		return nil, err
+	}
+	classifications, err := commitClassifications(cfg, uncommittedOps)
+	if err != nil {
+		return nil, err
+	}
+
+	commit := &models.Commit{
+		ParentID:        parentID,
+		Message:         orig.Message,
+		Author:          orig.Author,
+		Timestamp:       time.Now(),
+		OperationCount:  stats.Added + stats.Updated + stats.Removed,
+		HashVersion:     models.CurrentCommitHashVersion,
+		Submodules:      pins,
+		Classifications: classifications,
+	}
+	commit.ID, err = commit.ComputeID(uncommittedOps)
+	if err != nil {
+		return nil, fmt.Errorf("compute commit ID: %w", err)
+	}
+
+	if err := captureSchemaSnapshot(ctx, cfg, st, client, commit.ID); err != nil {
+		return nil, fmt.Errorf("capture schema: %w", err)
+	}
+
+	branchName, _ := st.GetCurrentBranch()
+	if _, err := st.FinalizeCommit(commit, branchName, branchName != ""); err != nil {
+		return nil, fmt.Errorf("finalize commit: %w", err)
+	}
+	return commit, nil
+}
@@ -2,13 +2,19 @@
 package core
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
 	"os"
 	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/remote"
 	"github.com/kilupskalvis/wvc/internal/store"
 )
 
@@ -25,8 +31,17 @@ func AddRemote(st *store.Store, name, rawURL string) error {
 	return st.AddRemote(name, rawURL)
 }
 
-// RemoveRemote removes a remote and all its associated data.
+// RemoveRemote removes a remote and all its associated data. The promisor
+// remote of a partial clone is kept, since it serves the vectors not yet
+// downloaded.
 func RemoveRemote(st *store.Store, name string) error {
+	promisor, err := st.GetPromisorRemote()
+	if err != nil {
+		return err
+	}
+	if promisor == name {
+		return fmt.Errorf("cannot remove remote '%s': this partial clone fetches missing vectors from it", name)
+	}
 	return st.RemoveRemote(name)
 }
 
@@ -106,6 +121,12 @@ func SetRemoteURL(st *store.Store, name, rawURL string) error {
 	return st.UpdateRemoteURL(name, rawURL)
 }
 
+// SetRemotePIIApproved marks a remote as approved, or no longer approved, to
+// hold classes classified pii:restricted.
+func SetRemotePIIApproved(st *store.Store, name string, approved bool) error {
+	return st.SetRemotePIIApproved(name, approved)
+}
+
 // validateRemoteName checks that a remote name is valid.
 func validateRemoteName(name string) error {
 	if name == "" {
@@ -182,3 +203,98 @@ func validateRemoteURL(rawURL string) error {
 
 	return nil
 }
+
+// RemoteRefState describes how a local remote-tracking ref compares to the server.
+type RemoteRefState string
+
+const (
+	RemoteRefUpToDate RemoteRefState = "up to date"
+	RemoteRefStale    RemoteRefState = "stale"
+	RemoteRefNew      RemoteRefState = "new (not fetched)"
+	RemoteRefGone     RemoteRefState = "gone on remote"
+)
+
+// RemoteRefStatus pairs a remote branch with its local tracking state.
+type RemoteRefStatus struct {
+	Branch    string
+	LocalTip  string
+	RemoteTip string
+	FetchedAt time.Time
+	State     RemoteRefState
+}
+
+// ShowRemoteResult aggregates local and server-side details about a remote.
+type ShowRemoteResult struct {
+	Remote   *models.Remote
+	Info     *remote.RepoInfo
+	Refs     []*RemoteRefStatus
+	UserRefs []*models.Branch // the token's personal refs, nil if the server has none
+}
+
+// ShowRemote collects repo info and branch tips from the server and compares
+// them against the local remote-tracking refs for the named remote.
+func ShowRemote(ctx context.Context, st *store.Store, client remote.RemoteClient, name string) (*ShowRemoteResult, error) {
+	rem, err := GetRemote(st, name)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := client.GetRepoInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get repo info: %w", err)
+	}
+
+	remoteBranches, err := client.ListBranches(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list remote branches: %w", err)
+	}
+
+	tracking, err := st.ListRemoteBranches(name)
+	if err != nil {
+		return nil, fmt.Errorf("list remote-tracking branches: %w", err)
+	}
+
+	// Refs fetched from reserved namespaces never appear in branch listings
+	local := make(map[string]*models.RemoteBranch, len(tracking))
+	for _, rb := range tracking {
+		if !strings.HasPrefix(rb.BranchName, "refs/") {
+			local[rb.BranchName] = rb
+		}
+	}
+
+	refs := make([]*RemoteRefStatus, 0, len(remoteBranches)+len(tracking))
+	for _, b := range remoteBranches {
+		ref := &RemoteRefStatus{Branch: b.Name, RemoteTip: b.CommitID, State: RemoteRefNew}
+		if rb, ok := local[b.Name]; ok {
+			ref.LocalTip = rb.CommitID
+			ref.FetchedAt = rb.UpdatedAt
+			if rb.CommitID == b.CommitID {
+				ref.State = RemoteRefUpToDate
+			} else {
+				ref.State = RemoteRefStale
+			}
+			delete(local, b.Name)
+		}
+		refs = append(refs, ref)
+	}
+	for _, rb := range local {
+		refs = append(refs, &RemoteRefStatus{
+			Branch:    rb.BranchName,
+			LocalTip:  rb.CommitID,
+			FetchedAt: rb.UpdatedAt,
+			State:     RemoteRefGone,
+		})
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].Branch < refs[j].Branch })
+
+	// Older servers have no personal refs endpoint
+	userRefs, err := client.ListUserRefs(ctx)
+	if err != nil {
+		var re *remote.RemoteError
+		if !errors.As(err, &re) || re.Status != http.StatusNotFound {
+			return nil, fmt.Errorf("list personal refs: %w", err)
+		}
+	}
+
+	return &ShowRemoteResult{Remote: rem, Info: info, Refs: refs, UserRefs: userRefs}, nil
+}
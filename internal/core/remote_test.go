@@ -1,9 +1,12 @@
 package core
 
 import (
+	"context"
 	"os"
 	"testing"
 
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/remote"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -99,6 +102,16 @@ func TestRemoveRemote_NotFound(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestRemoveRemote_KeepsPromisor(t *testing.T) {
+	st := newTestStore(t)
+
+	require.NoError(t, AddRemote(st, "origin", "https://example.com/repo"))
+	require.NoError(t, st.SetPromisorRemote("origin", remote.Filter{NoVectors: true}))
+
+	err := RemoveRemote(st, "origin")
+	assert.ErrorContains(t, err, "partial clone")
+}
+
 func TestListRemotes(t *testing.T) {
 	st := newTestStore(t)
 
@@ -277,3 +290,45 @@ func TestValidateRemoteURL(t *testing.T) {
 		})
 	}
 }
+
+func TestShowRemote_RefStates(t *testing.T) {
+	st := newTestStore(t)
+	require.NoError(t, AddRemote(st, "origin", "https://example.com/repo"))
+	require.NoError(t, st.SetRemoteBranch("origin", "main", "c1"))
+	require.NoError(t, st.SetRemoteBranch("origin", "dev", "c2"))
+	require.NoError(t, st.SetRemoteBranch("origin", "old", "c3"))
+
+	client := &mockRemoteClient{
+		repoInfo: &remote.RepoInfo{BranchCount: 3, CommitCount: 5, DefaultBranch: "main"},
+		branches: []*models.Branch{
+			{Name: "main", CommitID: "c1"},
+			{Name: "dev", CommitID: "c4"},
+			{Name: "feature", CommitID: "c5"},
+		},
+	}
+
+	result, err := ShowRemote(context.Background(), st, client, "origin")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/repo", result.Remote.URL)
+	assert.Equal(t, "main", result.Info.DefaultBranch)
+
+	states := make(map[string]RemoteRefState)
+	for _, ref := range result.Refs {
+		states[ref.Branch] = ref.State
+	}
+	assert.Equal(t, map[string]RemoteRefState{
+		"main":    RemoteRefUpToDate,
+		"dev":     RemoteRefStale,
+		"feature": RemoteRefNew,
+		"old":     RemoteRefGone,
+	}, states)
+	assert.Equal(t, "dev", result.Refs[0].Branch)
+}
+
+func TestShowRemote_UnknownRemote(t *testing.T) {
+	st := newTestStore(t)
+
+	_, err := ShowRemote(context.Background(), st, &mockRemoteClient{}, "nope")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not exist")
+}
@@ -115,9 +115,16 @@ func ResetToCommit(ctx context.Context, cfg *config.Config, st *store.Store, cli
 			return nil, fmt.Errorf("failed to clear staging area: %w", err)
 		}
 		result.StagedCleared = stagedCount
+		if err := syncSubmodulePins(st, targetCommitID); err != nil {
+			return nil, err
+		}
 
 		// Restore Weaviate state (reuse checkout logic)
-		warnings, stats, err := restoreStateToCommit(ctx, cfg, st, client, targetCommitID)
+		scope, err := currentScope(st)
+		if err != nil {
+			return nil, err
+		}
+		warnings, stats, err := restoreStateToCommit(ctx, cfg, st, client, targetCommitID, scope)
 		if err != nil {
 			return nil, fmt.Errorf("failed to restore state: %w", err)
 		}
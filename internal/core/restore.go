@@ -0,0 +1,151 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/store"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+)
+
+// Default retry settings for RetryFailedApply
+const (
+	DefaultRetryAttempts = 5
+	DefaultRetryBackoff  = 500 * time.Millisecond
+)
+
+// RetryOptions configures RetryFailedApply
+type RetryOptions struct {
+	MaxAttempts    int           // attempts per object in this run
+	InitialBackoff time.Duration // delay before the second attempt; doubled after each failure
+}
+
+// RetryResult contains the outcome of retrying failed object writes
+type RetryResult struct {
+	CommitID  string
+	Retried   int
+	Succeeded int
+	Failed    []*models.FailedObject // objects that still could not be written
+}
+
+// recordApplyProgress stores the failed writes of an apply of commitID's state,
+// or clears the record when every write succeeded.
+func recordApplyProgress(st *store.Store, commitID string, applied int, failed []*models.FailedObject) error {
+	if len(failed) == 0 {
+		return st.ClearApplyProgress()
+	}
+	return st.SaveApplyProgress(&models.ApplyProgress{
+		CommitID:  commitID,
+		Applied:   applied,
+		Failed:    failed,
+		UpdatedAt: time.Now(),
+	})
+}
+
+// RetryFailedApply re-attempts only the object writes that failed during the
+// last checkout, reset, pull, or stash apply, with exponential backoff between
+// attempts. Objects that still fail remain recorded for a later retry.
+func RetryFailedApply(ctx context.Context, st *store.Store, client weaviate.ClientInterface, opts RetryOptions) (*RetryResult, error) {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DefaultRetryAttempts
+	}
+	if opts.InitialBackoff <= 0 {
+		opts.InitialBackoff = DefaultRetryBackoff
+	}
+
+	progress, err := st.GetApplyProgress()
+	if err != nil {
+		return nil, err
+	}
+	if progress == nil || len(progress.Failed) == 0 {
+		return nil, fmt.Errorf("nothing to retry: the last apply completed")
+	}
+
+	head, err := st.GetHEAD()
+	if err != nil {
+		return nil, err
+	}
+	if head != progress.CommitID {
+		return nil, fmt.Errorf("the failed apply targeted commit %s but HEAD is now %s; check out %s again instead",
+			shortCommitID(progress.CommitID), shortCommitID(head), shortCommitID(progress.CommitID))
+	}
+
+	target, err := reconstructStateAtCommit(st, progress.CommitID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconstruct target state: %w", err)
+	}
+
+	result := &RetryResult{CommitID: progress.CommitID}
+	for _, f := range progress.Failed {
+		result.Retried++
+
+		write, err := failedObjectWrite(st, client, target, progress.CommitID, f)
+		if err != nil {
+			return nil, err
+		}
+
+		attempts, err := retryWithBackoff(ctx, opts, write)
+		f.Attempts += attempts
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			f.Error = err.Error()
+			result.Failed = append(result.Failed, f)
+			continue
+		}
+		result.Succeeded++
+	}
+
+	applied := progress.Applied + result.Succeeded
+	if err := recordApplyProgress(st, progress.CommitID, applied, result.Failed); err != nil {
+		return nil, fmt.Errorf("failed to record apply progress: %w", err)
+	}
+
+	return result, nil
+}
+
+// failedObjectWrite returns the Weaviate write that brings a failed object to
+// its state in the target commit.
+func failedObjectWrite(st *store.Store, client weaviate.ClientInterface, target map[string]*objectWithVector, commitID string, f *models.FailedObject) (func(context.Context) error, error) {
+	if f.Action == models.ApplyDelete {
+		return func(ctx context.Context) error {
+			return client.DeleteObject(ctx, f.ClassName, f.ObjectID)
+		}, nil
+	}
+
+	objWithVec := target[f.Key()]
+	if objWithVec == nil {
+		return nil, fmt.Errorf("object %s is not part of commit %s", f.Key(), shortCommitID(commitID))
+	}
+	obj := objWithVec.Object
+	objWithVec.restoreVectors(st)
+
+	if f.Action == models.ApplyCreate {
+		return func(ctx context.Context) error { return client.CreateObject(ctx, obj) }, nil
+	}
+	return func(ctx context.Context) error { return client.UpdateObject(ctx, obj) }, nil
+}
+
+// retryWithBackoff calls write up to opts.MaxAttempts times, doubling the
+// delay between attempts. It returns the number of attempts made and the last error.
+func retryWithBackoff(ctx context.Context, opts RetryOptions, write func(context.Context) error) (int, error) {
+	delay := opts.InitialBackoff
+	var err error
+	for attempt := 0; attempt < opts.MaxAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return attempt, ctx.Err()
+			case <-time.After(delay):
+			}
+			delay *= 2
+		}
+		if err = write(ctx); err == nil {
+			return attempt + 1, nil
+		}
+	}
+	return opts.MaxAttempts, err
+}
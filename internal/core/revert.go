@@ -27,6 +27,14 @@ func RevertCommit(ctx context.Context, cfg *config.Config, st *store.Store, clie
 
 // RevertCommitWithWarnings reverts a commit and collects schema warnings
 func RevertCommitWithWarnings(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface, commitID string, warnings *[]SchemaRevertWarning) (*models.Commit, error) {
+	return RevertCommitMainline(ctx, cfg, st, client, commitID, 0, warnings)
+}
+
+// RevertCommitMainline reverts a commit relative to one of its parents.
+// Like git revert -m, mainline selects the parent whose state the merge's
+// changes are reversed against (1 = first parent, 2 = merge parent). It is
+// required for merge commits and must be zero for ordinary commits.
+func RevertCommitMainline(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface, commitID string, mainline int, warnings *[]SchemaRevertWarning) (*models.Commit, error) {
 	// Get the commit
 	commit, err := st.GetCommit(commitID)
 	if err != nil {
@@ -37,13 +45,23 @@ func RevertCommitWithWarnings(ctx context.Context, cfg *config.Config, st *store
 		}
 	}
 
+	mainlineParent, err := revertMainlineParent(commit, mainline)
+	if err != nil {
+		return nil, err
+	}
+
 	// Get schema versions for this commit and its parent
 	currentSchema, err := st.GetSchemaVersionByCommit(commit.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	parentSchema, _ := st.GetPreviousCommitSchema(commit.ID)
+	var parentSchema *models.SchemaVersion
+	if mainline == 2 {
+		parentSchema, _ = st.GetSchemaVersionByCommit(mainlineParent)
+	} else {
+		parentSchema, _ = st.GetPreviousCommitSchema(commit.ID)
+	}
 
 	// Compute schema diff if both exist
 	var schemaDiff *SchemaDiffResult
@@ -62,15 +80,27 @@ func RevertCommitWithWarnings(ctx context.Context, cfg *config.Config, st *store
 	}
 
 	// STEP 2: Get and apply data reverse operations
-	operations, err := st.GetOperationsByCommit(commit.ID)
-	if err != nil {
-		return nil, err
-	}
-
-	if len(operations) > 0 {
-		if err := applyReverseOperations(ctx, st, client, operations); err != nil {
+	var operations []*models.Operation
+	if commit.IsMergeCommit() {
+		// A merge's recorded operations are relative to its first parent only,
+		// so derive the changes from the states on either side instead
+		operations, err = mergeRevertOperations(st, commit.ID, mainlineParent)
+		if err != nil {
+			return nil, err
+		}
+		if err := applyReverseChanges(ctx, st, client, operations); err != nil {
+			return nil, err
+		}
+	} else {
+		operations, err = st.GetOperationsByCommit(commit.ID)
+		if err != nil {
 			return nil, err
 		}
+		if len(operations) > 0 {
+			if err := applyReverseOperations(ctx, st, client, operations); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	// STEP 3: After data revert - delete added classes (now empty)
@@ -80,27 +110,40 @@ func RevertCommitWithWarnings(ctx context.Context, cfg *config.Config, st *store
 
 	// Create revert commit
 	revertMessage := fmt.Sprintf("Revert: %s", commit.Message)
-	now := time.Now()
 
 	// Get uncommitted operations (the reverse ops we just recorded) for content-addressable ID
 	uncommittedOps, err := st.GetUncommittedOperations()
 	if err != nil {
 		return nil, err
 	}
-	revertCommitID := models.GenerateCommitID(revertMessage, now, commit.ID, uncommittedOps)
-
-	// Capture current schema state for the revert commit
-	if err := captureSchemaSnapshot(ctx, st, client, revertCommitID); err != nil {
-		// Non-fatal - continue
+	pins, err := st.GetSubmodulePins()
+	if err != nil {
+		return nil, err
+	}
+	classifications, err := commitClassifications(cfg, uncommittedOps)
+	if err != nil {
+		return nil, err
 	}
 
 	parentID, _ := st.GetHEAD()
 	revertCommit := &models.Commit{
-		ID:             revertCommitID,
-		ParentID:       parentID,
-		Message:        revertMessage,
-		Timestamp:      now,
-		OperationCount: len(operations),
+		ParentID:        parentID,
+		Message:         revertMessage,
+		Author:          cfg.Author(),
+		Timestamp:       time.Now(),
+		OperationCount:  len(operations),
+		HashVersion:     models.CurrentCommitHashVersion,
+		Submodules:      pins,
+		Classifications: classifications,
+	}
+	revertCommit.ID, err = revertCommit.ComputeID(uncommittedOps)
+	if err != nil {
+		return nil, fmt.Errorf("compute commit ID: %w", err)
+	}
+
+	// Capture current schema state for the revert commit
+	if err := captureSchemaSnapshot(ctx, cfg, st, client, revertCommit.ID); err != nil {
+		// Non-fatal - continue
 	}
 
 	// Atomically: mark operations committed, create commit, set HEAD, update branch
@@ -226,8 +269,122 @@ func revertSchemaAfterData(ctx context.Context, client weaviate.ClientInterface,
 	}
 }
 
+// revertMainlineParent validates the mainline choice for commit and returns
+// the ID of the parent the revert is computed against.
+func revertMainlineParent(commit *models.Commit, mainline int) (string, error) {
+	if !commit.IsMergeCommit() {
+		if mainline != 0 {
+			return "", fmt.Errorf("mainline was specified but commit %s is not a merge", commit.ShortID())
+		}
+		return commit.ParentID, nil
+	}
+
+	switch mainline {
+	case 0:
+		return "", fmt.Errorf("commit %s is a merge but no mainline parent was given; use -m 1 to revert against %s or -m 2 to revert against %s",
+			commit.ShortID(), shortCommitID(commit.ParentID), shortCommitID(commit.MergeParentID))
+	case 1:
+		return commit.ParentID, nil
+	case 2:
+		return commit.MergeParentID, nil
+	default:
+		return "", fmt.Errorf("commit %s does not have parent %d; merge commits have parents 1 and 2", commit.ShortID(), mainline)
+	}
+}
+
+// mergeRevertOperations builds the operations that take the mainline parent's
+// state to the merge commit's state. Reversing them undoes the merge relative
+// to that parent.
+func mergeRevertOperations(st *store.Store, mergeID, parentID string) ([]*models.Operation, error) {
+	mergeState, err := reconstructStateAtCommit(st, mergeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconstruct merge state: %w", err)
+	}
+	parentState, err := reconstructStateAtCommit(st, parentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconstruct parent state: %w", err)
+	}
+
+	diff := diffStates(parentState, mergeState)
+	operations := make([]*models.Operation, 0, diff.TotalChanges())
+	for _, c := range diff.Inserted {
+		data, err := json.Marshal(c.CurrentData)
+		if err != nil {
+			return nil, err
+		}
+		operations = append(operations, &models.Operation{
+			Type:       models.OperationInsert,
+			ClassName:  c.ClassName,
+			ObjectID:   c.ObjectID,
+			ObjectData: data,
+			VectorHash: c.VectorHash,
+		})
+	}
+	for _, c := range diff.Updated {
+		data, err := json.Marshal(c.CurrentData)
+		if err != nil {
+			return nil, err
+		}
+		prevData, err := json.Marshal(c.PreviousData)
+		if err != nil {
+			return nil, err
+		}
+		operations = append(operations, &models.Operation{
+			Type:               models.OperationUpdate,
+			ClassName:          c.ClassName,
+			ObjectID:           c.ObjectID,
+			ObjectData:         data,
+			PreviousData:       prevData,
+			VectorHash:         c.VectorHash,
+			PreviousVectorHash: c.PreviousVectorHash,
+		})
+	}
+	for _, c := range diff.Deleted {
+		prevData, err := json.Marshal(c.PreviousData)
+		if err != nil {
+			return nil, err
+		}
+		operations = append(operations, &models.Operation{
+			Type:               models.OperationDelete,
+			ClassName:          c.ClassName,
+			ObjectID:           c.ObjectID,
+			PreviousData:       prevData,
+			PreviousVectorHash: c.PreviousVectorHash,
+		})
+	}
+	return operations, nil
+}
+
+// shortCommitID abbreviates a commit ID for messages
+func shortCommitID(id string) string {
+	if len(id) > 7 {
+		return id[:7]
+	}
+	return id
+}
+
 // applyReverseOperations applies the reverse of each operation to undo changes
+// and marks the originals as reverted
 func applyReverseOperations(ctx context.Context, st *store.Store, client weaviate.ClientInterface, operations []*models.Operation) error {
+	if err := applyReverseChanges(ctx, st, client, operations); err != nil {
+		return err
+	}
+
+	// Mark original operations as reverted — all ops share the same commit ID
+	if len(operations) > 0 {
+		commitID := operations[0].CommitID
+		seqs := make([]int, len(operations))
+		for i, op := range operations {
+			seqs[i] = op.Seq
+		}
+		return st.MarkOperationsReverted(commitID, seqs)
+	}
+	return nil
+}
+
+// applyReverseChanges undoes each operation in Weaviate and records the
+// reverse operation as uncommitted
+func applyReverseChanges(ctx context.Context, st *store.Store, client weaviate.ClientInterface, operations []*models.Operation) error {
 	now := time.Now()
 
 	// Process in reverse order
@@ -242,12 +399,13 @@ func applyReverseOperations(ctx context.Context, st *store.Store, client weaviat
 			}
 			// Record the reverse operation
 			reverseOp := &models.Operation{
-				Timestamp:          now,
-				Type:               models.OperationDelete,
-				ClassName:          op.ClassName,
-				ObjectID:           op.ObjectID,
-				PreviousData:       op.ObjectData,
-				PreviousVectorHash: op.VectorHash, // The inserted vector becomes previous
+				Timestamp:                 now,
+				Type:                      models.OperationDelete,
+				ClassName:                 op.ClassName,
+				ObjectID:                  op.ObjectID,
+				PreviousData:              op.ObjectData,
+				PreviousVectorHash:        op.VectorHash, // The inserted vector becomes previous
+				PreviousNamedVectorHashes: op.NamedVectorHashes,
 			}
 			if err := st.RecordOperation(reverseOp); err != nil {
 				return err
@@ -270,18 +428,20 @@ func applyReverseOperations(ctx context.Context, st *store.Store, client weaviat
 					}
 				}
 			}
+			restoreNamedVectors(st, &obj, op.PreviousNamedVectorHashes)
 
 			if err := client.CreateObject(ctx, &obj); err != nil {
 				return fmt.Errorf("failed to recreate object %s/%s: %w", op.ClassName, op.ObjectID, err)
 			}
 			// Record the reverse operation
 			reverseOp := &models.Operation{
-				Timestamp:  now,
-				Type:       models.OperationInsert,
-				ClassName:  op.ClassName,
-				ObjectID:   op.ObjectID,
-				ObjectData: op.PreviousData,
-				VectorHash: op.PreviousVectorHash, // Restore the previous vector hash
+				Timestamp:         now,
+				Type:              models.OperationInsert,
+				ClassName:         op.ClassName,
+				ObjectID:          op.ObjectID,
+				ObjectData:        op.PreviousData,
+				VectorHash:        op.PreviousVectorHash, // Restore the previous vector hash
+				NamedVectorHashes: op.PreviousNamedVectorHashes,
 			}
 			if err := st.RecordOperation(reverseOp); err != nil {
 				return err
@@ -304,20 +464,23 @@ func applyReverseOperations(ctx context.Context, st *store.Store, client weaviat
 					}
 				}
 			}
+			restoreNamedVectors(st, &obj, op.PreviousNamedVectorHashes)
 
 			if err := client.UpdateObject(ctx, &obj); err != nil {
 				return fmt.Errorf("failed to restore object %s/%s: %w", op.ClassName, op.ObjectID, err)
 			}
 			// Record the reverse operation
 			reverseOp := &models.Operation{
-				Timestamp:          now,
-				Type:               models.OperationUpdate,
-				ClassName:          op.ClassName,
-				ObjectID:           op.ObjectID,
-				ObjectData:         op.PreviousData,
-				PreviousData:       op.ObjectData,
-				VectorHash:         op.PreviousVectorHash, // Previous becomes current
-				PreviousVectorHash: op.VectorHash,         // Current becomes previous
+				Timestamp:                 now,
+				Type:                      models.OperationUpdate,
+				ClassName:                 op.ClassName,
+				ObjectID:                  op.ObjectID,
+				ObjectData:                op.PreviousData,
+				PreviousData:              op.ObjectData,
+				VectorHash:                op.PreviousVectorHash, // Previous becomes current
+				PreviousVectorHash:        op.VectorHash,         // Current becomes previous
+				NamedVectorHashes:         op.PreviousNamedVectorHashes,
+				PreviousNamedVectorHashes: op.NamedVectorHashes,
 			}
 			if err := st.RecordOperation(reverseOp); err != nil {
 				return err
@@ -325,14 +488,5 @@ func applyReverseOperations(ctx context.Context, st *store.Store, client weaviat
 		}
 	}
 
-	// Mark original operations as reverted — all ops share the same commit ID
-	if len(operations) > 0 {
-		commitID := operations[0].CommitID
-		seqs := make([]int, len(operations))
-		for i, op := range operations {
-			seqs[i] = op.Seq
-		}
-		return st.MarkOperationsReverted(commitID, seqs)
-	}
 	return nil
 }
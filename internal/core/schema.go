@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"sort"
 
+	"github.com/kilupskalvis/wvc/internal/config"
 	"github.com/kilupskalvis/wvc/internal/models"
 	"github.com/kilupskalvis/wvc/internal/store"
 	"github.com/kilupskalvis/wvc/internal/weaviate"
@@ -37,16 +38,49 @@ func (s *SchemaDiffResult) TotalChanges() int {
 		len(s.VectorizersChanged)
 }
 
-// ComputeSchemaDiff compares the current Weaviate schema against the last known schema
-func ComputeSchemaDiff(ctx context.Context, st *store.Store, client weaviate.ClientInterface) (*SchemaDiffResult, error) {
+// ComputeSchemaDiff compares the current Weaviate schema against the last
+// known schema. Ignored classes are left out.
+func ComputeSchemaDiff(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface) (*SchemaDiffResult, error) {
+	// Class-scoped branches only see changes to their own classes
+	scope, err := currentScope(st)
+	if err != nil {
+		return nil, err
+	}
+	return computeSchemaDiffScoped(ctx, cfg, st, client, scope)
+}
+
+// headSchemaVersion returns the schema recorded at HEAD, or the schema most
+// recently attached to a commit when HEAD has none
+func headSchemaVersion(st *store.Store) (*models.SchemaVersion, error) {
+	head, err := st.GetHEAD()
+	if err != nil {
+		return nil, err
+	}
+	if head != "" {
+		if sv, err := st.GetSchemaVersionByCommit(head); err == nil && sv != nil {
+			return sv, nil
+		}
+	}
+	return st.GetLatestSchemaVersion()
+}
+
+// computeSchemaDiffScoped compares the current schema against the last known
+// schema for the classes in scope that are not ignored
+func computeSchemaDiffScoped(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface, scope ClassScope) (*SchemaDiffResult, error) {
+	ignore, err := LoadIgnoreRules(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	// Get current schema from Weaviate
 	currentSchema, err := client.GetSchemaTyped(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	// Get last known schema from store
-	previousVersion, err := st.GetLatestSchemaVersion()
+	// Get last known schema from store: HEAD's, since commits fetched from
+	// other repositories, such as submodules, may have been stored since
+	previousVersion, err := headSchemaVersion(st)
 	if err != nil {
 		return nil, err
 	}
@@ -60,7 +94,7 @@ func ComputeSchemaDiff(ctx context.Context, st *store.Store, client weaviate.Cli
 		previousSchema = &prev
 	}
 
-	return diffSchemas(currentSchema, previousSchema), nil
+	return ignore.filterSchemaDiff(scope.filterSchemaDiff(diffSchemas(currentSchema, previousSchema))), nil
 }
 
 // ComputeSchemaDiffBetweenVersions compares two schema versions by their JSON
@@ -94,7 +128,8 @@ func diffSchemas(current, previous *models.WeaviateSchema) *SchemaDiffResult {
 	previousClasses := buildClassMap(previous)
 
 	// Find added and modified classes
-	for name, currentClass := range currentClasses {
+	for _, name := range sortedKeys(currentClasses) {
+		currentClass := currentClasses[name]
 		prevClass, exists := previousClasses[name]
 		if !exists {
 			result.ClassesAdded = append(result.ClassesAdded, &models.SchemaChange{
@@ -108,7 +143,8 @@ func diffSchemas(current, previous *models.WeaviateSchema) *SchemaDiffResult {
 	}
 
 	// Find deleted classes
-	for name, prevClass := range previousClasses {
+	for _, name := range sortedKeys(previousClasses) {
+		prevClass := previousClasses[name]
 		if _, exists := currentClasses[name]; !exists {
 			result.ClassesDeleted = append(result.ClassesDeleted, &models.SchemaChange{
 				Type:          models.SchemaChangeClassDeleted,
@@ -166,7 +202,8 @@ func compareClasses(className string, prev, curr *models.WeaviateClass, result *
 	currProps := buildPropertyMap(curr)
 
 	// Find added and modified properties
-	for propName, currProp := range currProps {
+	for _, propName := range sortedKeys(currProps) {
+		currProp := currProps[propName]
 		prevProp, exists := prevProps[propName]
 		if !exists {
 			// Property was added
@@ -192,7 +229,8 @@ func compareClasses(className string, prev, curr *models.WeaviateClass, result *
 	}
 
 	// Find deleted properties
-	for propName, prevProp := range prevProps {
+	for _, propName := range sortedKeys(prevProps) {
+		prevProp := prevProps[propName]
 		if _, exists := currProps[propName]; !exists {
 			propMap := toMap(prevProp)
 			result.PropertiesDeleted = append(result.PropertiesDeleted, &models.SchemaChange{
@@ -0,0 +1,283 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/kilupskalvis/wvc/internal/config"
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/store"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+)
+
+// MigrationStepKind is the kind of a schema migration step
+type MigrationStepKind string
+
+const (
+	// MigrationCreateClass creates a class
+	MigrationCreateClass MigrationStepKind = "create_class"
+	// MigrationAddProperty adds a property to an existing class
+	MigrationAddProperty MigrationStepKind = "add_property"
+	// MigrationBackfill reminds that objects written before a property was
+	// added lack it; it is run by whoever owns the data, not by wvc
+	MigrationBackfill MigrationStepKind = "backfill"
+	// MigrationManual is a change Weaviate cannot make in place, such as
+	// removing or retyping a property or changing a vectorizer
+	MigrationManual MigrationStepKind = "manual"
+	// MigrationDeleteClass deletes a class and all its objects
+	MigrationDeleteClass MigrationStepKind = "delete_class"
+)
+
+// MigrationStep is one step of a schema migration plan. Class and Property
+// hold the definitions create and add steps write.
+type MigrationStep struct {
+	Kind        MigrationStepKind        `json:"kind"`
+	ClassName   string                   `json:"class"`
+	Property    *models.WeaviateProperty `json:"property,omitempty"`
+	Class       *models.WeaviateClass    `json:"definition,omitempty"`
+	Description string                   `json:"description"`
+}
+
+// Executable reports whether wvc runs the step; backfill and manual steps
+// only need to be acknowledged
+func (s *MigrationStep) Executable() bool {
+	switch s.Kind {
+	case MigrationCreateClass, MigrationAddProperty, MigrationDeleteClass:
+		return true
+	}
+	return false
+}
+
+// Destructive reports whether running the step deletes data
+func (s *MigrationStep) Destructive() bool {
+	return s.Kind == MigrationDeleteClass
+}
+
+// MigrationPlan is an ordered list of steps taking a Weaviate schema from
+// the schema of one commit to that of another. Steps run in order: classes
+// are created before the properties that reference them, additions come
+// before the manual steps, and class deletions come last.
+type MigrationPlan struct {
+	From     string           `json:"from"`
+	To       string           `json:"to"`
+	FromHash string           `json:"from_schema_hash"`
+	ToHash   string           `json:"to_schema_hash"`
+	Steps    []*MigrationStep `json:"steps"`
+}
+
+// PlanSchemaMigration plans the migration from the schema recorded at
+// fromRef to the one recorded at toRef. Ignored classes are left out.
+func PlanSchemaMigration(cfg *config.Config, st *store.Store, fromRef, toRef string) (*MigrationPlan, error) {
+	ignore, err := LoadIgnoreRules(cfg)
+	if err != nil {
+		return nil, err
+	}
+	from, fromHash, err := schemaAtRef(st, fromRef)
+	if err != nil {
+		return nil, err
+	}
+	to, toHash, err := schemaAtRef(st, toRef)
+	if err != nil {
+		return nil, err
+	}
+	plan := planSchemaMigration(from, to, ignore)
+	plan.From, plan.To, plan.FromHash, plan.ToHash = fromRef, toRef, fromHash, toHash
+	return plan, nil
+}
+
+// schemaAtRef returns the schema recorded at a ref's commit and its hash
+func schemaAtRef(st *store.Store, ref string) (*models.WeaviateSchema, string, error) {
+	commitID, _, err := ResolveRef(st, ref)
+	if err != nil {
+		return nil, "", err
+	}
+	sv, err := st.GetSchemaVersionByCommit(commitID)
+	if err != nil {
+		return nil, "", err
+	}
+	if sv == nil {
+		return nil, "", fmt.Errorf("commit %s of '%s' has no recorded schema", shortCommitID(commitID), ref)
+	}
+	var schema models.WeaviateSchema
+	if err := json.Unmarshal(sv.SchemaJSON, &schema); err != nil {
+		return nil, "", err
+	}
+	return &schema, sv.SchemaHash, nil
+}
+
+func planSchemaMigration(from, to *models.WeaviateSchema, ignore IgnoreRules) *MigrationPlan {
+	diff := ignore.filterSchemaDiff(diffSchemas(to, from))
+	plan := &MigrationPlan{}
+	toClasses := buildClassMap(to)
+
+	// Properties of new classes referencing a new class are added once every
+	// new class exists, so references between them, even cyclic, resolve
+	created := make(map[string]bool, len(diff.ClassesAdded))
+	for _, change := range diff.ClassesAdded {
+		created[change.ClassName] = true
+	}
+	var deferred []*MigrationStep
+	for _, change := range diff.ClassesAdded {
+		class := *toClasses[change.ClassName]
+		class.Properties = nil
+		for _, prop := range toClasses[change.ClassName].Properties {
+			if slices.ContainsFunc(prop.DataType, func(t string) bool { return created[t] }) {
+				deferred = append(deferred, &MigrationStep{
+					Kind:        MigrationAddProperty,
+					ClassName:   change.ClassName,
+					Property:    prop,
+					Description: fmt.Sprintf("add reference property %s.%s", change.ClassName, prop.Name),
+				})
+				continue
+			}
+			class.Properties = append(class.Properties, prop)
+		}
+		plan.Steps = append(plan.Steps, &MigrationStep{
+			Kind:        MigrationCreateClass,
+			ClassName:   change.ClassName,
+			Class:       &class,
+			Description: fmt.Sprintf("create class %s", change.ClassName),
+		})
+	}
+	plan.Steps = append(plan.Steps, deferred...)
+
+	var backfills []*MigrationStep
+	for _, change := range diff.PropertiesAdded {
+		prop := buildPropertyMap(toClasses[change.ClassName])[change.PropertyName]
+		plan.Steps = append(plan.Steps, &MigrationStep{
+			Kind:        MigrationAddProperty,
+			ClassName:   change.ClassName,
+			Property:    prop,
+			Description: fmt.Sprintf("add property %s.%s (%s)", change.ClassName, change.PropertyName, joinDataType(prop.DataType)),
+		})
+		backfills = append(backfills, &MigrationStep{
+			Kind:        MigrationBackfill,
+			ClassName:   change.ClassName,
+			Property:    prop,
+			Description: fmt.Sprintf("backfill %s.%s on existing objects; they have no value for it until updated", change.ClassName, change.PropertyName),
+		})
+	}
+	plan.Steps = append(plan.Steps, backfills...)
+
+	for _, change := range diff.PropertiesModified {
+		plan.Steps = append(plan.Steps, &MigrationStep{
+			Kind:        MigrationManual,
+			ClassName:   change.ClassName,
+			Description: fmt.Sprintf("property %s.%s changed its type or indexing; Weaviate cannot change it in place, so recreate the class and re-import its objects", change.ClassName, change.PropertyName),
+		})
+	}
+	for _, change := range diff.VectorizersChanged {
+		plan.Steps = append(plan.Steps, &MigrationStep{
+			Kind:      MigrationManual,
+			ClassName: change.ClassName,
+			Description: fmt.Sprintf("vectorizer of %s changed from %v to %v; recreate the class and re-vectorize its objects",
+				change.ClassName, change.PreviousValue["vectorizer"], change.CurrentValue["vectorizer"]),
+		})
+	}
+	for _, change := range diff.PropertiesDeleted {
+		plan.Steps = append(plan.Steps, &MigrationStep{
+			Kind:        MigrationManual,
+			ClassName:   change.ClassName,
+			Description: fmt.Sprintf("property %s.%s was removed; Weaviate cannot remove properties, so stop writing it or recreate the class", change.ClassName, change.PropertyName),
+		})
+	}
+
+	for _, change := range diff.ClassesDeleted {
+		plan.Steps = append(plan.Steps, &MigrationStep{
+			Kind:        MigrationDeleteClass,
+			ClassName:   change.ClassName,
+			Description: fmt.Sprintf("delete class %s and all its objects", change.ClassName),
+		})
+	}
+	return plan
+}
+
+func joinDataType(dataType []string) string {
+	if len(dataType) == 1 {
+		return dataType[0]
+	}
+	data, _ := json.Marshal(dataType)
+	return string(data)
+}
+
+// SaveMigrationPlan writes a plan as JSON to path
+func SaveMigrationPlan(plan *MigrationPlan, path string) error {
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// LoadMigrationPlan reads a plan written by SaveMigrationPlan
+func LoadMigrationPlan(path string) (*MigrationPlan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var plan MigrationPlan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("invalid migration plan %s: %w", path, err)
+	}
+	for i, step := range plan.Steps {
+		switch step.Kind {
+		case MigrationCreateClass:
+			if step.Class == nil {
+				return nil, fmt.Errorf("invalid migration plan %s: step %d creates no class", path, i+1)
+			}
+		case MigrationAddProperty:
+			if step.Property == nil {
+				return nil, fmt.Errorf("invalid migration plan %s: step %d adds no property", path, i+1)
+			}
+		case MigrationBackfill, MigrationManual, MigrationDeleteClass:
+		default:
+			return nil, fmt.Errorf("invalid migration plan %s: step %d has unknown kind '%s'", path, i+1, step.Kind)
+		}
+	}
+	return &plan, nil
+}
+
+// ApplyMigrationStep runs an executable step against Weaviate. A step whose
+// change is already in place is skipped and reported with applied false,
+// so a partly applied plan can be run again.
+func ApplyMigrationStep(ctx context.Context, client weaviate.ClientInterface, step *MigrationStep) (applied bool, err error) {
+	if !step.Executable() {
+		return false, nil
+	}
+	schema, err := client.GetSchemaTyped(ctx)
+	if err != nil {
+		return false, err
+	}
+	existing, exists := buildClassMap(schema)[step.ClassName]
+
+	switch step.Kind {
+	case MigrationCreateClass:
+		if exists {
+			return false, nil
+		}
+		if err := client.CreateClass(ctx, step.Class); err != nil {
+			return false, fmt.Errorf("create class %s: %w", step.ClassName, err)
+		}
+	case MigrationAddProperty:
+		if !exists {
+			return false, fmt.Errorf("add property %s.%s: class %s does not exist", step.ClassName, step.Property.Name, step.ClassName)
+		}
+		if _, has := buildPropertyMap(existing)[step.Property.Name]; has {
+			return false, nil
+		}
+		if err := client.AddProperty(ctx, step.ClassName, step.Property); err != nil {
+			return false, fmt.Errorf("add property %s.%s: %w", step.ClassName, step.Property.Name, err)
+		}
+	case MigrationDeleteClass:
+		if !exists {
+			return false, nil
+		}
+		if err := client.DeleteClass(ctx, step.ClassName); err != nil {
+			return false, fmt.Errorf("delete class %s: %w", step.ClassName, err)
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,106 @@
+package core
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaMigrationPlan_PlanAndApply(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+
+	text := []string{"text"}
+	client.AddClass(&models.WeaviateClass{Class: "Article", Vectorizer: "none", Properties: []*models.WeaviateProperty{
+		{Name: "title", DataType: text},
+		{Name: "legacy", DataType: text},
+	}})
+	client.AddClass(&models.WeaviateClass{Class: "Old"})
+	client.AddObject(&models.WeaviateObject{ID: "a-1", Class: "Article", Properties: map[string]interface{}{"title": "A"}})
+	_, err := CreateCommit(ctx, cfg, st, client, "v1")
+	require.NoError(t, err)
+
+	require.NoError(t, client.DeleteClass(ctx, "Old"))
+	require.NoError(t, client.DeleteClass(ctx, "Article"))
+	client.AddClass(&models.WeaviateClass{Class: "Article", Vectorizer: "text2vec-openai", Properties: []*models.WeaviateProperty{
+		{Name: "title", DataType: text},
+		{Name: "body", DataType: text},
+	}})
+	// Author and Book reference each other
+	client.AddClass(&models.WeaviateClass{Class: "Author", Properties: []*models.WeaviateProperty{
+		{Name: "name", DataType: text},
+		{Name: "books", DataType: []string{"Book"}},
+	}})
+	client.AddClass(&models.WeaviateClass{Class: "Book", Properties: []*models.WeaviateProperty{
+		{Name: "author", DataType: []string{"Author"}},
+	}})
+	client.AddObject(&models.WeaviateObject{ID: "a-1", Class: "Article", Properties: map[string]interface{}{"title": "A", "body": "B"}})
+	_, err = CreateCommit(ctx, cfg, st, client, "v2")
+	require.NoError(t, err)
+
+	plan, err := PlanSchemaMigration(cfg, st, "HEAD~1", "HEAD")
+	require.NoError(t, err)
+	var steps []string
+	for _, step := range plan.Steps {
+		steps = append(steps, string(step.Kind)+" "+step.ClassName)
+	}
+	assert.Equal(t, []string{
+		"create_class Author",
+		"create_class Book",
+		"add_property Author",
+		"add_property Book",
+		"add_property Article",
+		"backfill Article",
+		"manual Article", // vectorizer
+		"manual Article", // legacy removed
+		"delete_class Old",
+	}, steps)
+	assert.Len(t, plan.Steps[0].Class.Properties, 1, "the reference to Book is added once Book exists")
+	assert.NotEqual(t, plan.FromHash, plan.ToHash)
+
+	path := filepath.Join(t.TempDir(), "plan.json")
+	require.NoError(t, SaveMigrationPlan(plan, path))
+	loaded, err := LoadMigrationPlan(path)
+	require.NoError(t, err)
+	require.Len(t, loaded.Steps, len(plan.Steps))
+
+	// Apply to an instance still on v1
+	target := weaviate.NewMockClient()
+	target.AddClass(&models.WeaviateClass{Class: "Article", Properties: []*models.WeaviateProperty{{Name: "title", DataType: text}}})
+	target.AddClass(&models.WeaviateClass{Class: "Old"})
+	applied := 0
+	for _, step := range loaded.Steps {
+		ok, err := ApplyMigrationStep(ctx, target, step)
+		require.NoError(t, err)
+		if ok {
+			applied++
+		}
+	}
+	assert.Equal(t, 6, applied)
+	classes, err := target.GetClasses(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"Article", "Author", "Book"}, classes)
+	schema, err := target.GetSchemaTyped(ctx)
+	require.NoError(t, err)
+	props := buildPropertyMap(buildClassMap(schema)["Author"])
+	assert.Contains(t, props, "books")
+	assert.Contains(t, buildPropertyMap(buildClassMap(schema)["Article"]), "body")
+
+	// Running the plan again changes nothing
+	for _, step := range loaded.Steps {
+		ok, err := ApplyMigrationStep(ctx, target, step)
+		require.NoError(t, err)
+		assert.False(t, ok, step.Description)
+	}
+
+	same, err := PlanSchemaMigration(cfg, st, "HEAD", "HEAD")
+	require.NoError(t, err)
+	assert.Empty(t, same.Steps)
+}
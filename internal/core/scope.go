@@ -0,0 +1,228 @@
+package core
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/store"
+)
+
+// ClassScope is the set of classes a class-scoped branch versions. A nil
+// scope covers every class. A scope whose classes all map to false covers
+// every class except those.
+type ClassScope map[string]bool
+
+// NewClassScope builds a scope from a list of class names; an empty list
+// yields the nil (unrestricted) scope.
+func NewClassScope(classes []string) ClassScope {
+	if len(classes) == 0 {
+		return nil
+	}
+	scope := make(ClassScope, len(classes))
+	for _, c := range classes {
+		scope[c] = true
+	}
+	return scope
+}
+
+// excludingScope builds a scope covering every class except the listed
+// ones; an empty list yields the nil (unrestricted) scope.
+func excludingScope(classes []string) ClassScope {
+	if len(classes) == 0 {
+		return nil
+	}
+	scope := make(ClassScope, len(classes))
+	for _, c := range classes {
+		scope[c] = false
+	}
+	return scope
+}
+
+// isExclusion reports whether the scope lists the classes it leaves out
+// rather than the classes it covers
+func (s ClassScope) isExclusion() bool {
+	for _, included := range s {
+		if included {
+			return false
+		}
+	}
+	return len(s) > 0
+}
+
+// Includes reports whether className, which may be qualified with a
+// tenant, is covered by the scope
+func (s ClassScope) Includes(className string) bool {
+	if s == nil {
+		return true
+	}
+	className, _ = models.SplitTenantClass(className)
+	if included, ok := s[className]; ok {
+		return included
+	}
+	return s.isExclusion()
+}
+
+// Classes returns the scoped class names in sorted order. An exclusion
+// scope has none.
+func (s ClassScope) Classes() []string {
+	classes := make([]string, 0, len(s))
+	for c, included := range s {
+		if included {
+			classes = append(classes, c)
+		}
+	}
+	sort.Strings(classes)
+	return classes
+}
+
+// intersect returns the classes covered by both scopes
+func (s ClassScope) intersect(other ClassScope) ClassScope {
+	if s == nil {
+		return other
+	}
+	if other == nil {
+		return s
+	}
+	both := make(ClassScope)
+	if s.isExclusion() && other.isExclusion() {
+		for _, scope := range []ClassScope{s, other} {
+			for c := range scope {
+				both[c] = false
+			}
+		}
+		return both
+	}
+	for _, scope := range []ClassScope{s, other} {
+		for c, included := range scope {
+			if included && s.Includes(c) && other.Includes(c) {
+				both[c] = true
+			}
+		}
+	}
+	return both
+}
+
+// union returns the classes covered by either scope
+func (s ClassScope) union(other ClassScope) ClassScope {
+	if s == nil || other == nil {
+		return nil
+	}
+	either := make(ClassScope)
+	if !s.isExclusion() && !other.isExclusion() {
+		for _, scope := range []ClassScope{s, other} {
+			for c, included := range scope {
+				if included {
+					either[c] = true
+				}
+			}
+		}
+		return either
+	}
+	for _, scope := range []ClassScope{s, other} {
+		for c, included := range scope {
+			if !included && !s.Includes(c) && !other.Includes(c) {
+				either[c] = false
+			}
+		}
+	}
+	if len(either) == 0 {
+		return nil
+	}
+	return either
+}
+
+// sparseScope returns the classes a sparse checkout tracks; nil when the
+// repository tracks every class.
+func sparseScope(st *store.Store) (ClassScope, error) {
+	classes, err := st.GetSparseClasses()
+	if err != nil {
+		return nil, err
+	}
+	return NewClassScope(classes), nil
+}
+
+// branchScope returns the class scope of the named branch, narrowed to the
+// sparse checkout and without the classes of materialized submodules.
+// Unknown branches and detached HEAD (empty name) are restricted only by
+// the sparse checkout and submodules.
+func branchScope(st *store.Store, branchName string) (ClassScope, error) {
+	sparse, err := sparseScope(st)
+	if err != nil {
+		return nil, err
+	}
+	owned, err := submoduleScope(st)
+	if err != nil {
+		return nil, err
+	}
+	scope := sparse.intersect(owned)
+	if branchName == "" {
+		return scope, nil
+	}
+	branch, err := st.GetBranch(branchName)
+	if err != nil || branch == nil {
+		return scope, err
+	}
+	return NewClassScope(branch.Classes).intersect(scope), nil
+}
+
+// currentScope returns the class scope of the checked-out branch
+func currentScope(st *store.Store) (ClassScope, error) {
+	branchName, err := st.GetCurrentBranch()
+	if err != nil {
+		return nil, err
+	}
+	return branchScope(st, branchName)
+}
+
+// filterChanges keeps the object changes whose class is in scope
+func (s ClassScope) filterChanges(changes []*ObjectChange) []*ObjectChange {
+	if s == nil {
+		return changes
+	}
+	kept := make([]*ObjectChange, 0, len(changes))
+	for _, c := range changes {
+		if s.Includes(c.ClassName) {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+// filterSchemaChanges keeps the schema changes whose class is in scope
+func (s ClassScope) filterSchemaChanges(changes []*models.SchemaChange) []*models.SchemaChange {
+	if s == nil {
+		return changes
+	}
+	var kept []*models.SchemaChange
+	for _, c := range changes {
+		if s.Includes(c.ClassName) {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+// filterSchemaDiff restricts a schema diff to the classes in scope
+func (s ClassScope) filterSchemaDiff(diff *SchemaDiffResult) *SchemaDiffResult {
+	if s == nil || diff == nil {
+		return diff
+	}
+	return &SchemaDiffResult{
+		ClassesAdded:       s.filterSchemaChanges(diff.ClassesAdded),
+		ClassesDeleted:     s.filterSchemaChanges(diff.ClassesDeleted),
+		PropertiesAdded:    s.filterSchemaChanges(diff.PropertiesAdded),
+		PropertiesDeleted:  s.filterSchemaChanges(diff.PropertiesDeleted),
+		PropertiesModified: s.filterSchemaChanges(diff.PropertiesModified),
+		VectorizersChanged: s.filterSchemaChanges(diff.VectorizersChanged),
+	}
+}
+
+// includesKey reports whether an object key ("ClassName/ObjectID") is in scope
+func (s ClassScope) includesKey(key string) bool {
+	if s == nil {
+		return true
+	}
+	className, _, _ := strings.Cut(key, "/")
+	return s.Includes(className)
+}
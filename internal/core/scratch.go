@@ -0,0 +1,267 @@
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kilupskalvis/wvc/internal/config"
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/store"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+)
+
+// ScratchPrefix starts the names of the classes of scratch checkouts, which
+// are named WvcScratch<id>_<Class>. They are never diffed, committed, or
+// touched by checkouts, as if every repository ignored them.
+const ScratchPrefix = "WvcScratch"
+
+// ScratchResult describes a scratch checkout.
+type ScratchResult struct {
+	ID       string
+	CommitID string
+	// Classes maps each class, tenant-qualified for the tenants of
+	// multi-tenant classes, to its name in the scratch
+	Classes map[string]string
+	Objects int
+}
+
+// Scratch is a scratch checkout found in Weaviate.
+type Scratch struct {
+	ID string
+	// Classes are the names of the scratch's classes
+	Classes []string
+}
+
+// CheckoutScratch restores the state of a ref into a scratch: a copy of every
+// class under a new prefix, so history can be inspected on a shared instance
+// without touching its data, HEAD, or staging area. References are rewritten
+// to point into the scratch. Drop the scratch with DropScratch.
+func CheckoutScratch(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface, ref string) (result *ScratchResult, err error) {
+	commitID, _, err := ResolveRef(st, ref)
+	if err != nil {
+		return nil, err
+	}
+	if commitID == "" {
+		return nil, fmt.Errorf("cannot checkout: no commits yet")
+	}
+	schema, _, err := schemaAtRef(st, ref)
+	if err != nil {
+		return nil, err
+	}
+	ignore, err := LoadIgnoreRules(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, 4)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	id := hex.EncodeToString(nonce)
+
+	names := make(map[string]string)
+	var classes []*models.WeaviateClass
+	if schema != nil {
+		for _, class := range schema.Classes {
+			if class != nil && !ignore.ignoresClass(class.Class) {
+				names[class.Class] = scratchClassName(id, class.Class)
+				classes = append(classes, class)
+			}
+		}
+	}
+
+	state, err := reconstructStateAtCommit(st, commitID)
+	if err != nil {
+		return nil, err
+	}
+	objects := make(map[string]*objectWithVector)
+	for key, obj := range state {
+		if _, ok := names[obj.Object.Class]; ok && !ignore.ignores(obj.Object.TenantClass(), obj.Object.ID, obj.Object) {
+			objects[key] = obj
+		}
+	}
+	if err := fetchMissingVectors(st, objects); err != nil {
+		return nil, err
+	}
+
+	// Classes are created without their references first, since a
+	// reference property needs the class it points to
+	var created []string
+	defer func() {
+		if err == nil {
+			return
+		}
+		for _, name := range created {
+			if derr := client.DeleteClass(context.WithoutCancel(ctx), name); derr != nil {
+				err = errors.Join(err, fmt.Errorf("delete scratch class %s: %w", name, derr))
+			}
+		}
+	}()
+	result = &ScratchResult{ID: id, CommitID: commitID, Classes: make(map[string]string)}
+	type reference struct {
+		className string
+		prop      *models.WeaviateProperty
+	}
+	var references []reference
+	for _, class := range classes {
+		copied := *class
+		copied.Class = names[class.Class]
+		copied.ShardingConfig, copied.Replication = nil, nil
+		copied.Properties = nil
+		for _, prop := range class.Properties {
+			if !isReferenceProperty(prop) {
+				copied.Properties = append(copied.Properties, prop)
+				continue
+			}
+			ref := *prop
+			ref.DataType = make([]string, len(prop.DataType))
+			for i, target := range prop.DataType {
+				if name, ok := names[target]; ok {
+					target = name
+				}
+				ref.DataType[i] = target
+			}
+			references = append(references, reference{copied.Class, &ref})
+		}
+		if err := client.CreateClass(ctx, &copied); err != nil {
+			return nil, fmt.Errorf("create scratch class %s: %w", copied.Class, err)
+		}
+		created = append(created, copied.Class)
+		if !class.MultiTenant() {
+			result.Classes[class.Class] = copied.Class
+		}
+	}
+	for _, r := range references {
+		if err := client.AddProperty(ctx, r.className, r.prop); err != nil {
+			return nil, fmt.Errorf("add reference %s.%s: %w", r.className, r.prop.Name, err)
+		}
+	}
+
+	writes := make([]*objectWrite, 0, len(objects))
+	for _, key := range sortedKeys(objects) {
+		obj := objects[key]
+		obj.restoreVectors(st)
+		copied := *obj.Object
+		copied.Class = names[obj.Object.Class]
+		copied.Properties = make(map[string]interface{}, len(obj.Object.Properties))
+		for name, value := range obj.Object.Properties {
+			copied.Properties[name] = scratchReferences(value, names)
+		}
+		result.Classes[obj.Object.TenantClass()] = copied.TenantClass()
+		writes = append(writes, &objectWrite{Action: models.ApplyCreate, Object: &copied})
+	}
+	if err := ensureTenants(ctx, client, nil, writes); err != nil {
+		return nil, err
+	}
+	if err := applyObjectWrites(ctx, cfg, client, writes); err != nil {
+		return nil, err
+	}
+	var failed []error
+	for _, w := range writes {
+		if w.Err != nil {
+			failed = append(failed, fmt.Errorf("%s/%s: %w", w.Object.TenantClass(), w.Object.ID, w.Err))
+		}
+	}
+	if len(failed) > 0 {
+		return nil, fmt.Errorf("write %d object(s) to the scratch: %w", len(failed), errors.Join(failed...))
+	}
+	result.Objects = len(writes)
+	return result, nil
+}
+
+// ListScratches returns the scratch checkouts in Weaviate, by ID.
+func ListScratches(ctx context.Context, client weaviate.ClientInterface) ([]*Scratch, error) {
+	classes, err := client.GetClasses(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*Scratch)
+	for _, name := range classes {
+		id, _, ok := parseScratchClass(name)
+		if !ok {
+			continue
+		}
+		if byID[id] == nil {
+			byID[id] = &Scratch{ID: id}
+		}
+		byID[id].Classes = append(byID[id].Classes, name)
+	}
+	scratches := make([]*Scratch, 0, len(byID))
+	for _, id := range sortedKeys(byID) {
+		sort.Strings(byID[id].Classes)
+		scratches = append(scratches, byID[id])
+	}
+	return scratches, nil
+}
+
+// DropScratch deletes the classes of a scratch checkout and returns their
+// names.
+func DropScratch(ctx context.Context, client weaviate.ClientInterface, id string) ([]string, error) {
+	scratches, err := ListScratches(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range scratches {
+		if s.ID != id {
+			continue
+		}
+		for _, name := range s.Classes {
+			if err := client.DeleteClass(ctx, name); err != nil {
+				return nil, fmt.Errorf("delete scratch class %s: %w", name, err)
+			}
+		}
+		return s.Classes, nil
+	}
+	return nil, fmt.Errorf("scratch '%s' not found", id)
+}
+
+// scratchClassName returns the name of a class in a scratch
+func scratchClassName(id, className string) string {
+	return ScratchPrefix + id + "_" + className
+}
+
+// parseScratchClass splits the name of a scratch class into the scratch ID
+// and the original class name
+func parseScratchClass(name string) (id, className string, ok bool) {
+	rest, ok := strings.CutPrefix(name, ScratchPrefix)
+	if !ok {
+		return "", "", false
+	}
+	id, className, ok = strings.Cut(rest, "_")
+	return id, className, ok && id != "" && className != ""
+}
+
+// scratchReferences rewrites the beacons of a reference property value to
+// the scratch classes they point to. Other values are returned unchanged.
+func scratchReferences(value interface{}, names map[string]string) interface{} {
+	refs, ok := value.([]interface{})
+	if !ok {
+		return value
+	}
+	rewritten := make([]interface{}, len(refs))
+	for i, ref := range refs {
+		rewritten[i] = ref
+		m, ok := ref.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		beacon, ok := m["beacon"].(string)
+		if !ok {
+			continue
+		}
+		rest, ok := strings.CutPrefix(beacon, "weaviate://localhost/")
+		if !ok {
+			continue
+		}
+		className, objectID, ok := strings.Cut(rest, "/")
+		if name, known := names[className]; ok && known {
+			rewritten[i] = map[string]interface{}{"beacon": "weaviate://localhost/" + name + "/" + objectID}
+		}
+	}
+	return rewritten
+}
@@ -0,0 +1,90 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckoutScratch(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+
+	text := []string{"text"}
+	client.AddClass(&models.WeaviateClass{Class: "Author", Properties: []*models.WeaviateProperty{{Name: "name", DataType: text}}})
+	client.AddClass(&models.WeaviateClass{Class: "Article", Properties: []*models.WeaviateProperty{
+		{Name: "title", DataType: text},
+		{Name: "author", DataType: []string{"Author"}},
+	}})
+	client.AddClass(&models.WeaviateClass{Class: "Review", MultiTenancy: map[string]interface{}{"enabled": true}})
+	client.Tenants["Review"] = []string{"tenantA"}
+	client.AddObject(&models.WeaviateObject{ID: "au-1", Class: "Author", Properties: map[string]interface{}{"name": "Ann"}})
+	client.AddObject(&models.WeaviateObject{ID: "a-1", Class: "Article", Vector: []float32{1, 0}, Properties: map[string]interface{}{
+		"title":  "Old title",
+		"author": []interface{}{map[string]interface{}{"beacon": "weaviate://localhost/Author/au-1", "href": "/v1/objects/Author/au-1"}},
+	}})
+	client.AddObject(&models.WeaviateObject{ID: "r-1", Class: "Review", Tenant: "tenantA", Properties: map[string]interface{}{"stars": 5.0}})
+	first, err := CreateCommit(ctx, cfg, st, client, "v1")
+	require.NoError(t, err)
+	client.AddObject(&models.WeaviateObject{ID: "a-1", Class: "Article", Vector: []float32{0, 1}, Properties: map[string]interface{}{"title": "New title"}})
+	_, err = CreateCommit(ctx, cfg, st, client, "v2")
+	require.NoError(t, err)
+	head, err := st.GetHEAD()
+	require.NoError(t, err)
+
+	result, err := CheckoutScratch(ctx, cfg, st, client, "HEAD~1")
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Objects)
+	article := result.Classes["Article"]
+	assert.Equal(t, ScratchPrefix+result.ID+"_Article", article)
+	assert.Equal(t, ScratchPrefix+result.ID+"_Review@tenantA", result.Classes["Review@tenantA"])
+
+	// The scratch holds the commit's objects, with references into it
+	obj := client.Objects[article+"/a-1"]
+	require.NotNil(t, obj)
+	assert.Equal(t, "Old title", obj.Properties["title"])
+	assert.Equal(t, []float32{1, 0}, obj.Vector)
+	assert.Equal(t, []interface{}{map[string]interface{}{"beacon": "weaviate://localhost/" + result.Classes["Author"] + "/au-1"}}, obj.Properties["author"])
+	for _, class := range client.Schema.Classes {
+		if class.Class == article {
+			require.Len(t, class.Properties, 2)
+			assert.Equal(t, []string{result.Classes["Author"]}, class.Properties[1].DataType)
+		}
+	}
+	assert.Equal(t, []string{"tenantA"}, client.Tenants[ScratchPrefix+result.ID+"_Review"])
+
+	// The live data, HEAD, and status are untouched
+	assert.Equal(t, "New title", client.Objects["Article/a-1"].Properties["title"])
+	current, err := st.GetHEAD()
+	require.NoError(t, err)
+	assert.Equal(t, head, current)
+	diff, err := ComputeIncrementalDiff(ctx, cfg, st, client)
+	require.NoError(t, err)
+	assert.Zero(t, diff.TotalUnstagedChanges(), "scratch classes are not changes")
+
+	// A checkout leaves the scratch alone
+	_, err = Checkout(ctx, cfg, st, client, first.ID, CheckoutOptions{Force: true})
+	require.NoError(t, err)
+	assert.NotNil(t, client.Objects[article+"/a-1"])
+
+	scratches, err := ListScratches(ctx, client)
+	require.NoError(t, err)
+	require.Len(t, scratches, 1)
+	assert.Equal(t, result.ID, scratches[0].ID)
+	assert.Len(t, scratches[0].Classes, 3)
+
+	dropped, err := DropScratch(ctx, client, result.ID)
+	require.NoError(t, err)
+	assert.Len(t, dropped, 3)
+	classes, err := client.GetClasses(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"Author", "Article", "Review"}, classes)
+	_, err = DropScratch(ctx, client, result.ID)
+	assert.ErrorContains(t, err, "not found")
+}
@@ -0,0 +1,142 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/store"
+)
+
+// UnknownAuthor groups commits recorded before authors were tracked
+const UnknownAuthor = "(unknown)"
+
+// ShortlogEntry aggregates the commits of one author or one class
+type ShortlogEntry struct {
+	Name    string
+	Commits int
+	Added   int
+	Updated int
+	Deleted int
+}
+
+// Total returns the number of object changes
+func (e *ShortlogEntry) Total() int {
+	return e.Added + e.Updated + e.Deleted
+}
+
+// ShortlogResult is an activity summary by author and by class, each sorted
+// by commit count and then name
+type ShortlogResult struct {
+	Commits int
+	Authors []*ShortlogEntry
+	Classes []*ShortlogEntry
+}
+
+// Shortlog summarizes the commits made at or after since (all commits when
+// since is zero). Merge commits are skipped: their changes are already
+// counted in the commits they merged.
+func Shortlog(st *store.Store, since time.Time) (*ShortlogResult, error) {
+	commits, err := st.GetCommitLog(0)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ShortlogResult{}
+	authors := make(map[string]*ShortlogEntry)
+	classes := make(map[string]*ShortlogEntry)
+	entry := func(m map[string]*ShortlogEntry, name string) *ShortlogEntry {
+		e, ok := m[name]
+		if !ok {
+			e = &ShortlogEntry{Name: name}
+			m[name] = e
+		}
+		return e
+	}
+
+	for _, commit := range commits {
+		if commit.IsMergeCommit() || (!since.IsZero() && commit.Timestamp.Before(since)) {
+			continue
+		}
+		ops, err := st.GetOperationsByCommit(commit.ID)
+		if err != nil {
+			return nil, fmt.Errorf("read operations of %s: %w", commit.ShortID(), err)
+		}
+
+		result.Commits++
+		author := commit.Author
+		if author == "" {
+			author = UnknownAuthor
+		}
+		byAuthor := entry(authors, author)
+		byAuthor.Commits++
+
+		touched := make(map[string]bool)
+		for _, op := range ops {
+			byClass := entry(classes, op.ClassName)
+			if !touched[op.ClassName] {
+				touched[op.ClassName] = true
+				byClass.Commits++
+			}
+			for _, e := range []*ShortlogEntry{byAuthor, byClass} {
+				switch op.Type {
+				case models.OperationInsert:
+					e.Added++
+				case models.OperationUpdate:
+					e.Updated++
+				case models.OperationDelete:
+					e.Deleted++
+				}
+			}
+		}
+	}
+
+	result.Authors = sortShortlogEntries(authors)
+	result.Classes = sortShortlogEntries(classes)
+	return result, nil
+}
+
+func sortShortlogEntries(m map[string]*ShortlogEntry) []*ShortlogEntry {
+	entries := make([]*ShortlogEntry, 0, len(m))
+	for _, e := range m {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Commits != entries[j].Commits {
+			return entries[i].Commits > entries[j].Commits
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	return entries
+}
+
+// ParseSince parses a --since value relative to now: a duration such as
+// "30d", "2w", "12h", or "90m", or a date ("2006-01-02") or RFC 3339 time
+func ParseSince(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, now.Location()); err == nil {
+		return t, nil
+	}
+
+	units := map[byte]time.Duration{
+		'm': time.Minute,
+		'h': time.Hour,
+		'd': 24 * time.Hour,
+		'w': 7 * 24 * time.Hour,
+	}
+	if unit, ok := units[value[len(value)-1]]; ok {
+		if n, err := strconv.Atoi(value[:len(value)-1]); err == nil && n >= 0 {
+			return now.Add(-time.Duration(n) * unit), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time '%s': use a duration like 30d, 2w, or 12h, or a date like 2006-01-02", value)
+}
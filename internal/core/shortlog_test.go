@@ -0,0 +1,97 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/config"
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShortlog_AggregatesByAuthorAndClass(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+	client.AddClass(&models.WeaviateClass{Class: "Article"})
+	client.AddClass(&models.WeaviateClass{Class: "Author"})
+
+	cfg.User = &config.UserConfig{Name: "Alice", Email: "alice@example.com"}
+	client.AddObject(&models.WeaviateObject{ID: "a1", Class: "Article", Properties: map[string]interface{}{"title": "One"}})
+	client.AddObject(&models.WeaviateObject{ID: "a2", Class: "Article", Properties: map[string]interface{}{"title": "Two"}})
+	client.AddObject(&models.WeaviateObject{ID: "p1", Class: "Author", Properties: map[string]interface{}{"name": "Pat"}})
+	first, err := CreateCommit(ctx, cfg, st, client, "Initial import")
+	require.NoError(t, err)
+	assert.Equal(t, "Alice <alice@example.com>", first.Author)
+
+	cfg.User = &config.UserConfig{Name: "Bob"}
+	client.Objects["Article/a1"].Properties["title"] = "One (edited)"
+	delete(client.Objects, "Article/a2")
+	_, err = CreateCommit(ctx, cfg, st, client, "Edit articles")
+	require.NoError(t, err)
+
+	result, err := Shortlog(st, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Commits)
+
+	require.Len(t, result.Authors, 2)
+	assert.Equal(t, &ShortlogEntry{Name: "Alice <alice@example.com>", Commits: 1, Added: 3}, result.Authors[0])
+	assert.Equal(t, &ShortlogEntry{Name: "Bob", Commits: 1, Updated: 1, Deleted: 1}, result.Authors[1])
+
+	require.Len(t, result.Classes, 2)
+	assert.Equal(t, &ShortlogEntry{Name: "Article", Commits: 2, Added: 2, Updated: 1, Deleted: 1}, result.Classes[0])
+	assert.Equal(t, &ShortlogEntry{Name: "Author", Commits: 1, Added: 1}, result.Classes[1])
+
+	// Nothing was committed in the future
+	result, err = Shortlog(st, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Commits)
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"30d", now.AddDate(0, 0, -30)},
+		{"2w", now.AddDate(0, 0, -14)},
+		{"12h", now.Add(-12 * time.Hour)},
+		{"90m", now.Add(-90 * time.Minute)},
+		{"2026-01-02", time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"2026-01-02T15:04:05Z", time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{"", time.Time{}},
+	}
+	for _, tt := range tests {
+		got, err := ParseSince(tt.value, now)
+		require.NoError(t, err, tt.value)
+		assert.True(t, tt.want.Equal(got), "%s: got %v, want %v", tt.value, got, tt.want)
+	}
+
+	for _, bad := range []string{"30", "d", "-3d", "yesterday"} {
+		_, err := ParseSince(bad, now)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestComputeID_IncludesAuthor(t *testing.T) {
+	ts := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+	commit := &models.Commit{Message: "msg", Timestamp: ts, HashVersion: models.CommitHashV2}
+
+	// Without an author the ID is unchanged from GenerateCommitIDV2
+	anonymous, err := commit.ComputeID(nil)
+	require.NoError(t, err)
+	expected, err := models.GenerateCommitIDV2("msg", ts, "", "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, expected, anonymous)
+
+	commit.Author = "Alice <alice@example.com>"
+	withAuthor, err := commit.ComputeID(nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, anonymous, withAuthor)
+}
@@ -0,0 +1,84 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/kilupskalvis/wvc/internal/store"
+)
+
+// MergeParentDiff holds the changes a merge commit made relative to one of its parents
+type MergeParentDiff struct {
+	ParentID string
+	Diff     *DiffResult
+}
+
+// DiffMergeCommit computes the diff between each parent of a merge commit and
+// the merge commit itself. The first entry is the mainline parent (ParentID),
+// the second the merged-in parent (MergeParentID).
+func DiffMergeCommit(st *store.Store, commitID string) ([]*MergeParentDiff, error) {
+	commit, err := st.GetCommit(commitID)
+	if err != nil {
+		return nil, err
+	}
+	if !commit.IsMergeCommit() {
+		return nil, fmt.Errorf("commit %s is not a merge commit", commit.ShortID())
+	}
+
+	mergedState, err := reconstructStateAtCommit(st, commit.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconstruct merge state: %w", err)
+	}
+
+	var diffs []*MergeParentDiff
+	for _, parentID := range []string{commit.ParentID, commit.MergeParentID} {
+		parentState, err := reconstructStateAtCommit(st, parentID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to reconstruct parent state: %w", err)
+		}
+		diffs = append(diffs, &MergeParentDiff{
+			ParentID: parentID,
+			Diff:     diffStates(parentState, mergedState),
+		})
+	}
+
+	return diffs, nil
+}
+
+// diffStates compares two reconstructed states, returning the changes needed
+// to go from the first to the second, ordered by class and object ID.
+func diffStates(from, to map[string]*objectWithVector) *DiffResult {
+	result := &DiffResult{}
+	for _, key := range sortedKeys(unionKeys(from, to)) {
+		before, after := from[key], to[key]
+		switch {
+		case before == nil:
+			result.Inserted = append(result.Inserted, &ObjectChange{
+				ClassName:   after.Object.TenantClass(),
+				ObjectID:    after.Object.ID,
+				CurrentData: after.Object,
+				VectorHash:  after.VectorHash,
+			})
+		case after == nil:
+			result.Deleted = append(result.Deleted, &ObjectChange{
+				ClassName:          before.Object.TenantClass(),
+				ObjectID:           before.Object.ID,
+				PreviousData:       before.Object,
+				PreviousVectorHash: before.VectorHash,
+			})
+		case hashObjWithVec(before) != hashObjWithVec(after):
+			beforeHash, _ := storedHashes.HashObjectFull(before.Object)
+			afterHash, _ := storedHashes.HashObjectFull(after.Object)
+			result.Updated = append(result.Updated, &ObjectChange{
+				ClassName:          after.Object.TenantClass(),
+				ObjectID:           after.Object.ID,
+				CurrentData:        after.Object,
+				PreviousData:       before.Object,
+				VectorHash:         after.VectorHash,
+				PreviousVectorHash: before.VectorHash,
+				VectorOnly:         beforeHash == afterHash,
+			})
+		}
+	}
+
+	return result
+}
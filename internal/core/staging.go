@@ -12,33 +12,38 @@ import (
 	"github.com/kilupskalvis/wvc/internal/weaviate"
 )
 
-// StageAll stages all detected changes
+// StageAll stages all detected changes. Changes are staged a page of objects
+// at a time, so memory stays bounded however large the classes are.
 func StageAll(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface) (int, error) {
-	diff, err := ComputeIncrementalDiff(ctx, cfg, st, client)
+	scope, err := currentScope(st)
+	if err != nil {
+		return 0, err
+	}
+	stagedMap, err := stagedChangeMap(st)
 	if err != nil {
 		return 0, err
 	}
 
 	count := 0
-	for _, change := range diff.Unstaged.Inserted {
-		if err := st.AddStagedChange(ConvertToStagedChange(change, "insert")); err != nil {
-			return count, err
-		}
-		count++
-	}
-	for _, change := range diff.Unstaged.Updated {
-		if err := st.AddStagedChange(ConvertToStagedChange(change, "update")); err != nil {
-			return count, err
-		}
-		count++
-	}
-	for _, change := range diff.Unstaged.Deleted {
-		if err := st.AddStagedChange(ConvertToStagedChange(change, "delete")); err != nil {
-			return count, err
+	err = walkDiff(ctx, cfg, st, client, scope, stagedMap, func(changes *DiffResult) error {
+		for _, group := range []struct {
+			changeType string
+			changes    []*ObjectChange
+		}{
+			{"insert", changes.Inserted},
+			{"update", changes.Updated},
+			{"delete", changes.Deleted},
+		} {
+			for _, change := range group.changes {
+				if err := st.AddStagedChange(ConvertToStagedChange(change, group.changeType)); err != nil {
+					return err
+				}
+				count++
+			}
 		}
-		count++
-	}
-	return count, nil
+		return nil
+	})
+	return count, err
 }
 
 // StageClass stages all changes for a specific class
@@ -102,6 +107,43 @@ func StageObject(ctx context.Context, cfg *config.Config, st *store.Store, clien
 	return fmt.Errorf("no changes found for %s/%s", className, objectID)
 }
 
+// StagePathspec stages every unstaged change selected by the pathspec. An
+// exact object reference that matches no change is an error, as with StageObject.
+func StagePathspec(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface, spec Pathspec) (int, error) {
+	diff, err := ComputeIncrementalDiff(ctx, cfg, st, client)
+	if err != nil {
+		return 0, err
+	}
+	unstaged := spec.FilterDiff(diff.Unstaged)
+
+	for _, pat := range spec.patterns {
+		if !pat.isExactObject() {
+			continue
+		}
+		if !(Pathspec{patterns: []pathPattern{pat}}).selectsAny(unstaged) {
+			return 0, fmt.Errorf("no changes found for %s", pat.raw)
+		}
+	}
+
+	count := 0
+	for _, group := range []struct {
+		changeType string
+		changes    []*ObjectChange
+	}{
+		{"insert", unstaged.Inserted},
+		{"update", unstaged.Updated},
+		{"delete", unstaged.Deleted},
+	} {
+		for _, change := range group.changes {
+			if err := st.AddStagedChange(ConvertToStagedChange(change, group.changeType)); err != nil {
+				return count, err
+			}
+			count++
+		}
+	}
+	return count, nil
+}
+
 // UnstageAll removes all staged changes
 func UnstageAll(st *store.Store) error {
 	return st.ClearStagedChanges()
@@ -112,8 +154,24 @@ func UnstageClass(st *store.Store, className string) error {
 	return st.RemoveStagedChangesByClass(className)
 }
 
-// UnstageObject removes a staged change for a specific object
+// UnstageObject removes a staged change for a specific object. Unstaging
+// either half of a move unstages the other half too.
 func UnstageObject(st *store.Store, className, objectID string) error {
+	sc, err := st.GetStagedChange(className, objectID)
+	if err != nil {
+		return err
+	}
+	if sc != nil {
+		for _, partner := range []string{sc.MovedFrom, sc.MovedTo} {
+			if partner == "" {
+				continue
+			}
+			partnerClass, partnerID, _ := strings.Cut(partner, "/")
+			if err := st.RemoveStagedChange(partnerClass, partnerID); err != nil {
+				return err
+			}
+		}
+	}
 	return st.RemoveStagedChange(className, objectID)
 }
 
@@ -146,6 +204,8 @@ func GetStagedDiff(st *store.Store) (*DiffResult, error) {
 			ObjectID:           sc.ObjectID,
 			VectorHash:         sc.VectorHash,
 			PreviousVectorHash: sc.PreviousVectorHash,
+			MovedFrom:          sc.MovedFrom,
+			MovedTo:            sc.MovedTo,
 		}
 		if len(sc.ObjectData) > 0 {
 			var obj models.WeaviateObject
@@ -170,5 +230,7 @@ func GetStagedDiff(st *store.Store) (*DiffResult, error) {
 		}
 	}
 
+	result.Sort()
+
 	return result, nil
 }
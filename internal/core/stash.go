@@ -155,6 +155,8 @@ func StashPush(ctx context.Context, cfg *config.Config, st *store.Store, client
 			WasStaged:          true,
 			VectorHash:         sc.VectorHash,
 			PreviousVectorHash: sc.PreviousVectorHash,
+			MovedFrom:          sc.MovedFrom,
+			MovedTo:            sc.MovedTo,
 		}
 		if err := st.CreateStashChange(change); err != nil {
 			return nil, fmt.Errorf("failed to save staged change: %w", err)
@@ -191,7 +193,11 @@ func StashPush(ctx context.Context, cfg *config.Config, st *store.Store, client
 	}
 
 	// Restore Weaviate to HEAD commit state
-	warnings, _, err := restoreStateToCommit(ctx, cfg, st, client, headCommitID)
+	scope, err := currentScope(st)
+	if err != nil {
+		return nil, err
+	}
+	warnings, _, err := restoreStateToCommit(ctx, cfg, st, client, headCommitID, scope)
 	if err != nil {
 		return nil, fmt.Errorf("failed to restore state: %w", err)
 	}
@@ -259,6 +265,8 @@ func StashApply(ctx context.Context, cfg *config.Config, st *store.Store, client
 				StagedAt:           time.Now(),
 				VectorHash:         sc.VectorHash,
 				PreviousVectorHash: sc.PreviousVectorHash,
+				MovedFrom:          sc.MovedFrom,
+				MovedTo:            sc.MovedTo,
 			}
 			if err := st.AddStagedChange(staged); err != nil {
 				result.Warnings = append(result.Warnings, CheckoutWarning{
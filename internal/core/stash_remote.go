@@ -0,0 +1,214 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/remote"
+	"github.com/kilupskalvis/wvc/internal/store"
+)
+
+// StashFetchResult contains the outcome of fetching remote stashes.
+type StashFetchResult struct {
+	Fetched        []StashListEntry // stashes added locally, oldest first
+	AlreadyPresent int              // remote stashes that were already stored locally
+	VectorsFetched int
+	Removed        int // stashes deleted from the remote after fetching
+}
+
+// StashPushToRemote uploads the stash at index to the remote under the
+// caller's token identity, along with any vector blobs its changes reference.
+// The stash's base commit must already exist on the remote.
+func StashPushToRemote(ctx context.Context, st *store.Store, client remote.RemoteClient, index int, progress PushProgress) (*remote.RemoteStash, error) {
+	if progress == nil {
+		progress = func(string, int, int) {}
+	}
+
+	stash, err := st.GetStashByIndex(index)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stash: %w", err)
+	}
+	if stash == nil {
+		return nil, fmt.Errorf("no stash found at index %d", index)
+	}
+
+	changes, err := st.GetStashChanges(stash.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stash changes: %w", err)
+	}
+
+	rs := &remote.RemoteStash{
+		Message:    stash.Message,
+		BranchName: stash.BranchName,
+		CommitID:   stash.CommitID,
+		CreatedAt:  stash.CreatedAt,
+	}
+	for _, c := range changes {
+		// Local IDs mean nothing on another machine
+		cp := *c
+		cp.ID = 0
+		cp.StashID = 0
+		rs.Changes = append(rs.Changes, &cp)
+	}
+	rs.ID = rs.ContentID()
+
+	hashes := stashVectorHashes(rs.Changes)
+	if len(hashes) > 0 {
+		progress("checking vectors", 0, len(hashes))
+		missing, err := checkMissingVectors(ctx, client, hashes)
+		if err != nil {
+			return nil, fmt.Errorf("check vectors: %w", err)
+		}
+		if len(missing) > 0 {
+			if _, err := uploadMissingVectors(ctx, st, client, missing, 0, progress); err != nil {
+				return nil, fmt.Errorf("upload vectors: %w", err)
+			}
+		}
+	}
+
+	progress("uploading stash", 0, 1)
+	if err := client.UploadStash(ctx, rs); err != nil {
+		return nil, err
+	}
+	progress("uploading stash", 1, 1)
+
+	if err := st.SetStashRemoteID(stash.ID, rs.ID); err != nil {
+		return nil, fmt.Errorf("record remote stash ID: %w", err)
+	}
+
+	summary := *rs
+	summary.Changes = nil
+	return &summary, nil
+}
+
+// StashFetchFromRemote downloads the caller's stashes from the remote and adds
+// the ones not already present to the local stash list. Unless keep is set,
+// fetched stashes are then deleted from the remote, moving them to this machine.
+func StashFetchFromRemote(ctx context.Context, st *store.Store, client remote.RemoteClient, keep bool, progress FetchProgress) (*StashFetchResult, error) {
+	if progress == nil {
+		progress = func(string, int, int) {}
+	}
+
+	remoteStashes, err := client.ListStashes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	local, err := st.ListStashes()
+	if err != nil {
+		return nil, fmt.Errorf("list local stashes: %w", err)
+	}
+	present := make(map[string]bool)
+	for _, s := range local {
+		if s.RemoteID != "" {
+			present[s.RemoteID] = true
+		}
+	}
+
+	result := &StashFetchResult{}
+	var received []string
+
+	// Oldest first, so the newest remote stash ends up as stash@{0}
+	for i := len(remoteStashes) - 1; i >= 0; i-- {
+		summary := remoteStashes[i]
+		if present[summary.ID] {
+			result.AlreadyPresent++
+			received = append(received, summary.ID)
+			continue
+		}
+
+		progress("downloading stashes", len(remoteStashes)-i, len(remoteStashes))
+		rs, err := client.DownloadStash(ctx, summary.ID)
+		if err != nil {
+			return nil, err
+		}
+		if rs.ContentID() != summary.ID {
+			return nil, fmt.Errorf("stash %s: content does not match its ID", shortRemoteStashID(summary.ID))
+		}
+		has, err := st.HasCommit(rs.CommitID)
+		if err != nil {
+			return nil, fmt.Errorf("check base commit: %w", err)
+		}
+		if !has {
+			return nil, fmt.Errorf("stash %s is based on commit %s, which is not present locally; fetch it first",
+				shortRemoteStashID(rs.ID), rs.CommitID)
+		}
+
+		if hashes := stashVectorHashes(rs.Changes); len(hashes) > 0 {
+			missing, err := filterMissingLocalVectors(st, hashes)
+			if err != nil {
+				return nil, fmt.Errorf("filter vectors: %w", err)
+			}
+			if len(missing) > 0 {
+				n, err := downloadMissingVectors(ctx, st, client, missing, 0, progress)
+				if err != nil {
+					return nil, fmt.Errorf("download vectors: %w", err)
+				}
+				result.VectorsFetched += n
+			}
+		}
+
+		stash := &models.Stash{
+			Message:    rs.Message,
+			BranchName: rs.BranchName,
+			CommitID:   rs.CommitID,
+			CreatedAt:  rs.CreatedAt,
+			RemoteID:   rs.ID,
+		}
+		if _, err := st.ImportStash(stash, rs.Changes); err != nil {
+			return nil, fmt.Errorf("store stash: %w", err)
+		}
+		result.Fetched = append(result.Fetched, StashListEntry{
+			Message:    rs.Message,
+			BranchName: rs.BranchName,
+			CommitID:   rs.CommitID,
+			CreatedAt:  rs.CreatedAt,
+		})
+		received = append(received, rs.ID)
+	}
+
+	// Fetched entries were prepended; index them as they now appear locally
+	for i := range result.Fetched {
+		result.Fetched[i].Index = len(result.Fetched) - 1 - i
+	}
+
+	if !keep {
+		for _, id := range received {
+			if err := client.DeleteStash(ctx, id); err != nil {
+				var re *remote.RemoteError
+				if errors.As(err, &re) && re.Status == 404 {
+					continue
+				}
+				return result, fmt.Errorf("remove fetched stash from remote: %w", err)
+			}
+			result.Removed++
+		}
+	}
+
+	return result, nil
+}
+
+// stashVectorHashes returns the vector hashes referenced by stash changes.
+func stashVectorHashes(changes []*models.StashChange) []string {
+	var hashes []string
+	seen := make(map[string]bool)
+	for _, c := range changes {
+		for _, h := range []string{c.VectorHash, c.PreviousVectorHash} {
+			if h != "" && !seen[h] {
+				seen[h] = true
+				hashes = append(hashes, h)
+			}
+		}
+	}
+	return hashes
+}
+
+// shortRemoteStashID abbreviates a remote stash ID for messages.
+func shortRemoteStashID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
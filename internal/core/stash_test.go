@@ -3,6 +3,7 @@ package core
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/kilupskalvis/wvc/internal/models"
 	"github.com/kilupskalvis/wvc/internal/weaviate"
@@ -556,3 +557,75 @@ func TestStashPush_NoCommits_Error(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "no commits yet")
 }
+
+func TestStashRemote_PushAndFetchMovesStash(t *testing.T) {
+	ctx := context.Background()
+	client := &mockRemoteClient{}
+
+	// Machine A: a stash on top of c1 whose change carries a vector
+	src := newTestStore(t)
+	require.NoError(t, src.CreateCommit(&models.Commit{ID: "c1", Message: "first", Timestamp: time.Now()}))
+	vector := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	vecHash, err := src.SaveVectorBlob(vector, 2)
+	require.NoError(t, err)
+	stashID, err := src.CreateStash("WIP on main", "main", "c1")
+	require.NoError(t, err)
+	require.NoError(t, src.CreateStashChange(&models.StashChange{
+		StashID: stashID, ClassName: "Article", ObjectID: "obj-1", ChangeType: "insert",
+		ObjectData: []byte(`{"id":"obj-1","class":"Article"}`), VectorHash: vecHash,
+	}))
+
+	rs, err := StashPushToRemote(ctx, src, client, 0, nil)
+	require.NoError(t, err)
+	require.Contains(t, client.stashes, rs.ID)
+
+	local, err := src.GetStashByIndex(0)
+	require.NoError(t, err)
+	assert.Equal(t, rs.ID, local.RemoteID)
+
+	// Fetching back into the same store doesn't duplicate the stash
+	result, err := StashFetchFromRemote(ctx, src, client, true, nil)
+	require.NoError(t, err)
+	assert.Empty(t, result.Fetched)
+	assert.Equal(t, 1, result.AlreadyPresent)
+
+	// Machine B: has c1 but neither the stash nor its vector
+	dst := newTestStore(t)
+	require.NoError(t, dst.CreateCommit(&models.Commit{ID: "c1", Message: "first", Timestamp: time.Now()}))
+	client.vectorData = map[string]mockVector{vecHash: {data: vector, dims: 2}}
+
+	result, err = StashFetchFromRemote(ctx, dst, client, false, nil)
+	require.NoError(t, err)
+	require.Len(t, result.Fetched, 1)
+	assert.Equal(t, "WIP on main", result.Fetched[0].Message)
+	assert.Equal(t, 1, result.VectorsFetched)
+	assert.Equal(t, 1, result.Removed)
+	assert.Empty(t, client.stashes, "fetched stash is moved off the remote")
+
+	show, err := StashShow(dst, 0)
+	require.NoError(t, err)
+	require.Len(t, show.UnstagedChanges, 1)
+	assert.Equal(t, vecHash, show.UnstagedChanges[0].VectorHash)
+
+	data, dims, err := dst.GetVectorBlob(vecHash)
+	require.NoError(t, err)
+	assert.Equal(t, vector, data)
+	assert.Equal(t, 2, dims)
+}
+
+func TestStashRemote_FetchRequiresBaseCommit(t *testing.T) {
+	ctx := context.Background()
+	client := &mockRemoteClient{}
+
+	src := newTestStore(t)
+	require.NoError(t, src.CreateCommit(&models.Commit{ID: "c1", Message: "first", Timestamp: time.Now()}))
+	_, err := src.CreateStash("WIP", "main", "c1")
+	require.NoError(t, err)
+	_, err = StashPushToRemote(ctx, src, client, 0, nil)
+	require.NoError(t, err)
+
+	_, err = StashFetchFromRemote(ctx, newTestStore(t), client, false, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not present locally")
+	assert.Len(t, client.stashes, 1, "stash stays on the remote when fetch fails")
+}
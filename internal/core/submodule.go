@@ -0,0 +1,316 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+
+	"github.com/kilupskalvis/wvc/internal/config"
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/remote"
+	"github.com/kilupskalvis/wvc/internal/store"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+)
+
+// defaultSubmoduleBranch is the branch a submodule follows when none is configured
+const defaultSubmoduleBranch = "main"
+
+// SubmoduleInfo describes a configured submodule and where it is pinned.
+type SubmoduleInfo struct {
+	Name   string
+	Remote string
+	Branch string
+	// Pinned is the commit the next commit records, Committed the one HEAD
+	// records, and Materialized the one last written to Weaviate
+	Pinned       string
+	Committed    string
+	Materialized string
+	Classes      []string
+}
+
+// SubmoduleBump is the outcome of pinning a submodule to its branch tip.
+type SubmoduleBump struct {
+	Name           string
+	Previous       string
+	Pinned         string
+	CommitsFetched int
+}
+
+// SubmoduleUpdate is the outcome of materializing a submodule into Weaviate.
+type SubmoduleUpdate struct {
+	Name     string
+	CommitID string
+	Classes  []string
+	UpToDate bool
+	Stats    *StateRestoreStats
+	Warnings []CheckoutWarning
+}
+
+// AddSubmodule records a reference to the repository behind a configured
+// remote. It is pinned by the first BumpSubmodule.
+func AddSubmodule(cfg *config.Config, st *store.Store, name, remoteName, branch string) error {
+	if err := validateRemoteName(name); err != nil {
+		return fmt.Errorf("invalid submodule name: %w", err)
+	}
+	if _, ok := cfg.Submodule[name]; ok {
+		return fmt.Errorf("submodule '%s' already exists", name)
+	}
+	if _, err := GetRemote(st, remoteName); err != nil {
+		return err
+	}
+	if cfg.Submodule == nil {
+		cfg.Submodule = make(map[string]*config.SubmoduleConfig)
+	}
+	cfg.Submodule[name] = &config.SubmoduleConfig{Remote: remoteName, Branch: branch}
+	return cfg.Save()
+}
+
+// ListSubmodules returns the configured submodules sorted by name.
+func ListSubmodules(cfg *config.Config, st *store.Store) ([]*SubmoduleInfo, error) {
+	pins, err := st.GetSubmodulePins()
+	if err != nil {
+		return nil, err
+	}
+	committed, err := committedSubmodulePins(st)
+	if err != nil {
+		return nil, err
+	}
+	states, err := st.GetMaterializedSubmodules()
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]*SubmoduleInfo, 0, len(cfg.Submodule))
+	for _, name := range slices.Sorted(maps.Keys(cfg.Submodule)) {
+		sub := cfg.Submodule[name]
+		info := &SubmoduleInfo{
+			Name:      name,
+			Remote:    sub.Remote,
+			Branch:    submoduleBranch(sub),
+			Pinned:    pins[name],
+			Committed: committed[name],
+		}
+		if state := states[name]; state != nil {
+			info.Materialized = state.CommitID
+			info.Classes = state.Classes
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// BumpSubmodule fetches the submodule's branch and pins the submodule to its
+// tip. The new pin is recorded by the next commit.
+func BumpSubmodule(ctx context.Context, cfg *config.Config, st *store.Store, client remote.RemoteClient, name string) (*SubmoduleBump, error) {
+	sub, err := getSubmodule(cfg, name)
+	if err != nil {
+		return nil, err
+	}
+	fetched, err := Fetch(ctx, st, client, FetchOptions{RemoteName: sub.Remote, Branch: submoduleBranch(sub)}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("fetch submodule '%s': %w", name, err)
+	}
+	if fetched.RemoteTip == "" {
+		return nil, fmt.Errorf("branch '%s' of submodule '%s' has no commits", submoduleBranch(sub), name)
+	}
+
+	pins, err := st.GetSubmodulePins()
+	if err != nil {
+		return nil, err
+	}
+	if pins == nil {
+		pins = make(map[string]string)
+	}
+	bump := &SubmoduleBump{
+		Name:           name,
+		Previous:       pins[name],
+		Pinned:         fetched.RemoteTip,
+		CommitsFetched: fetched.CommitsFetched,
+	}
+	pins[name] = fetched.RemoteTip
+	if err := st.SetSubmodulePins(pins); err != nil {
+		return nil, err
+	}
+	return bump, nil
+}
+
+// UpdateSubmodule writes the objects and schema of the submodule's pinned
+// commit into Weaviate, fetching the commit first if it is not stored
+// locally. From then on the submodule's classes are left out of this
+// repository's status, commits, and checkouts.
+func UpdateSubmodule(ctx context.Context, cfg *config.Config, st *store.Store, wc weaviate.ClientInterface, client remote.RemoteClient, name string) (*SubmoduleUpdate, error) {
+	sub, err := getSubmodule(cfg, name)
+	if err != nil {
+		return nil, err
+	}
+	pins, err := st.GetSubmodulePins()
+	if err != nil {
+		return nil, err
+	}
+	pin := pins[name]
+	if pin == "" {
+		return nil, fmt.Errorf("submodule '%s' is not pinned; run 'wvc submodule update --remote %s'", name, name)
+	}
+
+	states, err := st.GetMaterializedSubmodules()
+	if err != nil {
+		return nil, err
+	}
+	previous := states[name]
+	if previous != nil && previous.CommitID == pin {
+		return &SubmoduleUpdate{Name: name, CommitID: pin, Classes: previous.Classes, UpToDate: true}, nil
+	}
+
+	has, err := st.HasCommit(pin)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		if _, err := Fetch(ctx, st, client, FetchOptions{RemoteName: sub.Remote, Branch: submoduleBranch(sub)}, nil); err != nil {
+			return nil, fmt.Errorf("fetch submodule '%s': %w", name, err)
+		}
+		if has, err = st.HasCommit(pin); err != nil {
+			return nil, err
+		} else if !has {
+			return nil, fmt.Errorf("commit %s of submodule '%s' is not on %s/%s", shortCommitID(pin), name, sub.Remote, submoduleBranch(sub))
+		}
+	}
+
+	classes, err := schemaClassesAt(st, pin)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSubmoduleClasses(st, states, name, classes); err != nil {
+		return nil, err
+	}
+
+	// Classes the previous pin had and this one dropped are removed
+	scope := NewClassScope(classes)
+	if previous != nil {
+		scope = NewClassScope(append(slices.Clone(classes), previous.Classes...))
+	}
+	warnings, stats, err := restoreStateToCommit(ctx, cfg, st, wc, pin, scope)
+	if err != nil {
+		return nil, fmt.Errorf("materialize submodule '%s': %w", name, err)
+	}
+	if err := st.SaveMaterializedSubmodule(name, &models.SubmoduleState{CommitID: pin, Classes: classes}); err != nil {
+		return nil, err
+	}
+	return &SubmoduleUpdate{Name: name, CommitID: pin, Classes: classes, Stats: stats, Warnings: warnings}, nil
+}
+
+// checkSubmoduleClasses fails when a class of the submodule is versioned by
+// this repository or owned by another submodule.
+func checkSubmoduleClasses(st *store.Store, states map[string]*models.SubmoduleState, name string, classes []string) error {
+	for other, state := range states {
+		if other == name {
+			continue
+		}
+		for _, c := range state.Classes {
+			if slices.Contains(classes, c) {
+				return fmt.Errorf("class %s of submodule '%s' is already owned by submodule '%s'", c, name, other)
+			}
+		}
+	}
+	head, err := st.GetHEAD()
+	if err != nil || head == "" {
+		return err
+	}
+	own, err := schemaClassesAt(st, head)
+	if err != nil {
+		return err
+	}
+	for _, c := range own {
+		if slices.Contains(classes, c) {
+			return fmt.Errorf("class %s of submodule '%s' is versioned by this repository", c, name)
+		}
+	}
+	return nil
+}
+
+// submoduleScope returns the scope leaving out the classes of materialized
+// submodules; nil when none are materialized.
+func submoduleScope(st *store.Store) (ClassScope, error) {
+	states, err := st.GetMaterializedSubmodules()
+	if err != nil {
+		return nil, err
+	}
+	var classes []string
+	for _, state := range states {
+		classes = append(classes, state.Classes...)
+	}
+	return excludingScope(classes), nil
+}
+
+// committedSubmodulePins returns the submodule pins recorded at HEAD.
+func committedSubmodulePins(st *store.Store) (map[string]string, error) {
+	head, err := st.GetHEAD()
+	if err != nil || head == "" {
+		return nil, err
+	}
+	commit, err := st.GetCommit(head)
+	if err != nil {
+		return nil, err
+	}
+	return commit.Submodules, nil
+}
+
+// submodulePinsChanged reports whether the working submodule pins differ
+// from those recorded at HEAD.
+func submodulePinsChanged(st *store.Store) (bool, error) {
+	pins, err := st.GetSubmodulePins()
+	if err != nil {
+		return false, err
+	}
+	committed, err := committedSubmodulePins(st)
+	if err != nil {
+		return false, err
+	}
+	return !maps.Equal(pins, committed), nil
+}
+
+// syncSubmodulePins makes the pins recorded at commitID the working pins.
+// Materialized submodules are left as they are until the next update.
+func syncSubmodulePins(st *store.Store, commitID string) error {
+	commit, err := st.GetCommit(commitID)
+	if err != nil {
+		return err
+	}
+	return st.SetSubmodulePins(commit.Submodules)
+}
+
+// schemaClassesAt returns the sorted class names of the schema recorded at a commit.
+func schemaClassesAt(st *store.Store, commitID string) ([]string, error) {
+	sv, err := st.GetSchemaVersionByCommit(commitID)
+	if err != nil || sv == nil {
+		return nil, err
+	}
+	var schema models.WeaviateSchema
+	if err := json.Unmarshal(sv.SchemaJSON, &schema); err != nil {
+		return nil, fmt.Errorf("parse schema of %s: %w", shortCommitID(commitID), err)
+	}
+	classes := make([]string, 0, len(schema.Classes))
+	for _, class := range schema.Classes {
+		classes = append(classes, class.Class)
+	}
+	sort.Strings(classes)
+	return classes, nil
+}
+
+func getSubmodule(cfg *config.Config, name string) (*config.SubmoduleConfig, error) {
+	sub, ok := cfg.Submodule[name]
+	if !ok {
+		return nil, fmt.Errorf("no submodule named '%s'", name)
+	}
+	return sub, nil
+}
+
+func submoduleBranch(sub *config.SubmoduleConfig) string {
+	if sub.Branch == "" {
+		return defaultSubmoduleBranch
+	}
+	return sub.Branch
+}
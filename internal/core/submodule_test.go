@@ -0,0 +1,94 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kilupskalvis/wvc/internal/config"
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/remote"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassScope_Exclusion(t *testing.T) {
+	excluded := excludingScope([]string{"Taxonomy"})
+	assert.True(t, excluded.Includes("Article"))
+	assert.False(t, excluded.Includes("Taxonomy"))
+	assert.Empty(t, excluded.Classes())
+
+	both := NewClassScope([]string{"Article", "Taxonomy"}).intersect(excluded)
+	assert.Equal(t, []string{"Article"}, both.Classes())
+	assert.False(t, both.Includes("Other"))
+
+	assert.False(t, excluded.intersect(excludingScope([]string{"Other"})).Includes("Other"))
+	assert.True(t, excluded.union(NewClassScope([]string{"Taxonomy"})).Includes("Taxonomy"))
+	assert.False(t, excluded.union(NewClassScope([]string{"Article"})).Includes("Taxonomy"))
+}
+
+func TestSubmodule_PinMaterializeAndCommit(t *testing.T) {
+	ctx := context.Background()
+
+	// The referenced repository has one commit with a Taxonomy class
+	subSt := newTestStore(t)
+	subWC := weaviate.NewMockClient()
+	subWC.AddClass(&models.WeaviateClass{Class: "Taxonomy"})
+	subWC.AddObject(&models.WeaviateObject{ID: "tax-001", Class: "Taxonomy", Properties: map[string]interface{}{"name": "Science"}})
+	subCommit, err := CreateCommit(ctx, newTestConfig(), subSt, subWC, "Taxonomy v1")
+	require.NoError(t, err)
+	bundle, err := buildCommitBundle(subSt, subCommit.ID, false, false)
+	require.NoError(t, err)
+	client := &mockRemoteClient{
+		negotiatePullResp: &remote.NegotiatePullResponse{MissingCommits: []string{subCommit.ID}, RemoteTip: subCommit.ID},
+		commitBundles:     map[string]*remote.CommitBundle{subCommit.ID: bundle},
+	}
+
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	cfg.Submodule = map[string]*config.SubmoduleConfig{"taxonomy": {Remote: "taxonomy"}}
+	require.NoError(t, st.AddRemote("taxonomy", "http://example.com/taxonomy"))
+	wc := weaviate.NewMockClient()
+	wc.AddClass(&models.WeaviateClass{Class: "Article"})
+	wc.AddObject(&models.WeaviateObject{ID: "obj-001", Class: "Article", Properties: map[string]interface{}{"title": "A"}})
+	_, err = CreateCommit(ctx, cfg, st, wc, "Initial")
+	require.NoError(t, err)
+
+	bump, err := BumpSubmodule(ctx, cfg, st, client, "taxonomy")
+	require.NoError(t, err)
+	assert.Empty(t, bump.Previous)
+	assert.Equal(t, subCommit.ID, bump.Pinned)
+
+	update, err := UpdateSubmodule(ctx, cfg, st, wc, client, "taxonomy")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Taxonomy"}, update.Classes)
+	assert.Equal(t, 1, update.Stats.Added)
+	_, err = wc.GetObject(ctx, "Taxonomy", "tax-001")
+	require.NoError(t, err, "the pinned commit is written into Weaviate")
+
+	// The submodule's classes are not changes of this repository, the pin is
+	hasChanges, err := HasUncommittedChanges(ctx, cfg, st, wc)
+	require.NoError(t, err)
+	assert.False(t, hasChanges)
+
+	commit, err := CreateCommit(ctx, cfg, st, wc, "Pin taxonomy")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"taxonomy": subCommit.ID}, commit.Submodules)
+	assert.Equal(t, 0, commit.OperationCount)
+	classes, err := schemaClassesAt(st, commit.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Article"}, classes, "the submodule's schema is not captured")
+
+	// The pin is part of the commit ID
+	id, err := commit.ComputeID(nil)
+	require.NoError(t, err)
+	assert.Equal(t, commit.ID, id)
+	unpinned := *commit
+	unpinned.Submodules = nil
+	id, err = unpinned.ComputeID(nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, commit.ID, id)
+
+	_, err = CreateCommit(ctx, cfg, st, wc, "Nothing")
+	assert.ErrorContains(t, err, "no changes to commit")
+}
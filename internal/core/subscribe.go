@@ -0,0 +1,152 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/remote"
+)
+
+// DefaultSubscribePollInterval is how often Subscribe polls servers without
+// an event stream, and how long it waits before reconnecting a dropped stream.
+const DefaultSubscribePollInterval = 30 * time.Second
+
+// SubscribeOptions configures Subscribe.
+type SubscribeOptions struct {
+	Branch       string
+	PollInterval time.Duration
+	// OnPolling is called when the server has no event stream and Subscribe
+	// falls back to polling.
+	OnPolling func()
+}
+
+// BranchAdvance reports that a remote branch moved to a new commit.
+// Previous is empty when the branch did not exist before.
+type BranchAdvance struct {
+	Branch   string
+	Previous string
+	CommitID string
+}
+
+// Subscribe watches a remote branch and calls onAdvance each time its tip
+// moves to a new commit. It follows the server's event stream, resuming
+// after disconnects, and polls the branch when the server has no stream.
+// It runs until ctx is done or onAdvance returns an error, which it returns.
+func Subscribe(ctx context.Context, client remote.RemoteClient, opts SubscribeOptions, onAdvance func(*BranchAdvance) error) error {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultSubscribePollInterval
+	}
+
+	tip, err := remoteBranchTip(ctx, client, opts.Branch)
+	if err != nil {
+		return err
+	}
+	advance := func(commitID string) error {
+		if commitID == tip {
+			return nil
+		}
+		previous := tip
+		tip = commitID
+		if commitID == "" {
+			return nil // deleted
+		}
+		return onAdvance(&BranchAdvance{Branch: opts.Branch, Previous: previous, CommitID: commitID})
+	}
+
+	if subscriber, ok := client.(remote.EventSubscriber); ok {
+		var lastID uint64
+		for {
+			var handlerErr error
+			err := subscriber.StreamEvents(ctx, opts.Branch, lastID, func(event *remote.RepoEvent) error {
+				lastID = event.ID
+				switch event.Type {
+				case remote.EventPush:
+					handlerErr = advance(event.CommitID)
+				case remote.EventBranchDelete:
+					handlerErr = advance("")
+				}
+				return handlerErr
+			})
+			if handlerErr != nil {
+				return handlerErr
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if errors.Is(err, remote.ErrEventsUnsupported) {
+				break
+			}
+			if isPermanentRemoteError(err) {
+				return err
+			}
+
+			// The stream dropped: wait, then catch up on anything the server's
+			// backlog no longer holds before reconnecting
+			if err := sleepContext(ctx, opts.PollInterval); err != nil {
+				return err
+			}
+			current, err := remoteBranchTip(ctx, client, opts.Branch)
+			if err != nil {
+				if isPermanentRemoteError(err) {
+					return err
+				}
+				continue
+			}
+			if err := advance(current); err != nil {
+				return err
+			}
+		}
+	}
+
+	if opts.OnPolling != nil {
+		opts.OnPolling()
+	}
+	for {
+		if err := sleepContext(ctx, opts.PollInterval); err != nil {
+			return err
+		}
+		current, err := remoteBranchTip(ctx, client, opts.Branch)
+		if err != nil {
+			if isPermanentRemoteError(err) {
+				return err
+			}
+			continue // transient: try again next interval
+		}
+		if err := advance(current); err != nil {
+			return err
+		}
+	}
+}
+
+// remoteBranchTip returns the commit a remote branch points to, or "" if the
+// branch does not exist.
+func remoteBranchTip(ctx context.Context, client remote.RemoteClient, branch string) (string, error) {
+	b, err := client.GetBranch(ctx, branch)
+	if err != nil {
+		var re *remote.RemoteError
+		if errors.As(err, &re) && re.Status == 404 {
+			return "", nil
+		}
+		return "", fmt.Errorf("get remote branch '%s': %w", branch, err)
+	}
+	return b.CommitID, nil
+}
+
+// isPermanentRemoteError reports whether err is a client error from the
+// server, such as a revoked token, that retrying will not fix.
+func isPermanentRemoteError(err error) bool {
+	var re *remote.RemoteError
+	return errors.As(err, &re) && re.Status >= 400 && re.Status < 500 && re.Status != 429
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
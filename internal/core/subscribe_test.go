@@ -0,0 +1,122 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errStopSubscribe = errors.New("stop")
+
+// tipSequenceClient returns the next of tips on each GetBranch, repeating
+// the last one; an empty tip means the branch does not exist.
+type tipSequenceClient struct {
+	mockRemoteClient
+	tips  []string
+	calls int
+}
+
+func (c *tipSequenceClient) GetBranch(_ context.Context, name string) (*models.Branch, error) {
+	tip := c.tips[min(c.calls, len(c.tips)-1)]
+	c.calls++
+	if tip == "" {
+		return nil, &remote.RemoteError{Code: "not_found", Message: "branch not found", Status: 404}
+	}
+	return &models.Branch{Name: name, CommitID: tip}, nil
+}
+
+// streamingClient delivers each of streams as one connection that then drops.
+type streamingClient struct {
+	tipSequenceClient
+	streams [][]remote.RepoEvent
+	lastIDs []uint64
+}
+
+func (c *streamingClient) StreamEvents(ctx context.Context, _ string, lastID uint64, fn func(*remote.RepoEvent) error) error {
+	c.lastIDs = append(c.lastIDs, lastID)
+	if len(c.streams) == 0 {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	events := c.streams[0]
+	c.streams = c.streams[1:]
+	for i := range events {
+		if err := fn(&events[i]); err != nil {
+			return err
+		}
+	}
+	return errors.New("connection reset")
+}
+
+func collectAdvances(n int, advances *[]BranchAdvance) func(*BranchAdvance) error {
+	return func(a *BranchAdvance) error {
+		*advances = append(*advances, *a)
+		if len(*advances) == n {
+			return errStopSubscribe
+		}
+		return nil
+	}
+}
+
+func TestSubscribe_Polling(t *testing.T) {
+	client := &tipSequenceClient{tips: []string{"", "", "c1", "c1", "c2"}}
+	polling := false
+
+	var advances []BranchAdvance
+	err := Subscribe(context.Background(), client, SubscribeOptions{
+		Branch:       "main",
+		PollInterval: time.Millisecond,
+		OnPolling:    func() { polling = true },
+	}, collectAdvances(2, &advances))
+
+	assert.ErrorIs(t, err, errStopSubscribe)
+	assert.True(t, polling)
+	assert.Equal(t, []BranchAdvance{
+		{Branch: "main", Previous: "", CommitID: "c1"},
+		{Branch: "main", Previous: "c1", CommitID: "c2"},
+	}, advances)
+}
+
+func TestSubscribe_EventStreamResumes(t *testing.T) {
+	client := &streamingClient{
+		tipSequenceClient: tipSequenceClient{tips: []string{"c1", "c3"}},
+		streams: [][]remote.RepoEvent{
+			{
+				{ID: 10, Type: remote.EventPush, Branch: "main", CommitID: "c1"}, // current tip
+				{ID: 11, Type: remote.EventPush, Branch: "main", CommitID: "c2"},
+			},
+			{
+				{ID: 13, Type: remote.EventPush, Branch: "main", CommitID: "c4"},
+			},
+		},
+	}
+
+	var advances []BranchAdvance
+	err := Subscribe(context.Background(), client, SubscribeOptions{Branch: "main", PollInterval: time.Millisecond},
+		collectAdvances(3, &advances))
+
+	assert.ErrorIs(t, err, errStopSubscribe)
+	require.Len(t, advances, 3)
+	assert.Equal(t, "c2", advances[0].CommitID)
+	assert.Equal(t, BranchAdvance{Branch: "main", Previous: "c2", CommitID: "c3"}, advances[1], "changes missed while disconnected are caught up")
+	assert.Equal(t, "c4", advances[2].CommitID)
+	assert.Equal(t, []uint64{0, 11}, client.lastIDs)
+}
+
+func TestSubscribe_StopsOnContext(t *testing.T) {
+	client := &streamingClient{tipSequenceClient: tipSequenceClient{tips: []string{"c1"}}}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := Subscribe(ctx, client, SubscribeOptions{Branch: "main"}, func(*BranchAdvance) error {
+		t.Fatal("no advance expected")
+		return nil
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,370 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+
+	"github.com/kilupskalvis/wvc/internal/config"
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/remote"
+	"github.com/kilupskalvis/wvc/internal/store"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+	"gopkg.in/yaml.v3"
+)
+
+// Ways a sync handles a branch that has diverged from its remote
+const (
+	SyncDivergeFail  = "fail"
+	SyncDivergeMerge = "merge"
+)
+
+// defaultSyncCommitMessage is the message of auto-commits without one
+const defaultSyncCommitMessage = "wvc sync: commit changes"
+
+// SyncSpec is the desired state of a repository, declared in a sync file
+// such as wvc.yaml and brought about by ApplySync.
+type SyncSpec struct {
+	// Branch is checked out, created from the remote or HEAD if missing.
+	Branch string          `yaml:"branch"`
+	Remote *SyncRemoteSpec `yaml:"remote"`
+	// AutoCommit commits uncommitted changes before pulling and pushing.
+	AutoCommit SyncAutoCommitSpec `yaml:"auto_commit"`
+	// Environments maps deployment environments to the ref deployed there.
+	Environments map[string]string `yaml:"environments"`
+}
+
+// SyncRemoteSpec declares the remote a sync exchanges the branch with.
+type SyncRemoteSpec struct {
+	Name string `yaml:"name"`
+	// URL adds the remote, or updates it, when it differs from the stored one.
+	URL       string `yaml:"url"`
+	Pull      bool   `yaml:"pull"`
+	Push      bool   `yaml:"push"`
+	OnDiverge string `yaml:"on_diverge"` // SyncDivergeFail (default) or SyncDivergeMerge
+}
+
+// SyncAutoCommitSpec declares whether a sync commits uncommitted changes.
+type SyncAutoCommitSpec struct {
+	Enabled bool   `yaml:"enabled"`
+	Message string `yaml:"message"`
+}
+
+// SyncResult lists the steps ApplySync took. A second run against an
+// unchanged repository and remote takes none.
+type SyncResult struct {
+	Actions []string
+}
+
+func (r *SyncResult) record(format string, args ...any) {
+	r.Actions = append(r.Actions, fmt.Sprintf(format, args...))
+}
+
+// LoadSyncSpec reads and validates a sync file.
+func LoadSyncSpec(path string) (*SyncSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read sync file: %w", err)
+	}
+	spec, err := ParseSyncSpec(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return spec, nil
+}
+
+// ParseSyncSpec parses and validates a sync file's YAML. Unknown keys are
+// rejected so a misspelled setting fails instead of being ignored.
+func ParseSyncSpec(data []byte) (*SyncSpec, error) {
+	var spec SyncSpec
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&spec); err != nil {
+		return nil, fmt.Errorf("parse sync file: %w", err)
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	return &spec, nil
+}
+
+// Validate checks that the spec can be applied.
+func (s *SyncSpec) Validate() error {
+	if s.Branch == "" {
+		return fmt.Errorf("sync file must name a branch")
+	}
+	if r := s.Remote; r != nil {
+		if err := validateRemoteName(r.Name); err != nil {
+			return err
+		}
+		if r.URL != "" {
+			if err := validateRemoteURL(r.URL); err != nil {
+				return err
+			}
+		}
+		switch r.OnDiverge {
+		case "", SyncDivergeFail, SyncDivergeMerge:
+		default:
+			return fmt.Errorf("invalid on_diverge '%s': want '%s' or '%s'", r.OnDiverge, SyncDivergeFail, SyncDivergeMerge)
+		}
+	}
+	for env, ref := range s.Environments {
+		if err := ValidateEnvironmentName(env); err != nil {
+			return err
+		}
+		if ref == "" {
+			return fmt.Errorf("environment '%s' names no ref", env)
+		}
+	}
+	return nil
+}
+
+// ApplySync brings the repository to the state spec declares. In order, it
+// configures the remote, checks out the branch, commits uncommitted changes
+// if auto-commit is on, pulls, pushes, and records the environments'
+// deployments, publishing them to the remote when pushing. Each step is
+// skipped when its state already holds, so applying the same spec again is
+// a no-op. connect returns a client for a configured remote.
+//
+// The result lists the steps taken, including those before a failure.
+func ApplySync(ctx context.Context, cfg *config.Config, st *store.Store, wc weaviate.ClientInterface, spec *SyncSpec,
+	connect func(remoteName string) (remote.RemoteClient, error)) (*SyncResult, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	result := &SyncResult{}
+
+	var client remote.RemoteClient
+	if r := spec.Remote; r != nil {
+		if err := syncRemote(st, r, result); err != nil {
+			return result, err
+		}
+		if r.Pull || r.Push {
+			var err error
+			if client, err = connect(r.Name); err != nil {
+				return result, err
+			}
+		}
+	}
+
+	if err := syncBranch(ctx, cfg, st, wc, spec, client, result); err != nil {
+		return result, err
+	}
+
+	if spec.AutoCommit.Enabled {
+		changed, err := HasUncommittedChanges(ctx, cfg, st, wc)
+		if err != nil {
+			return result, err
+		}
+		if changed {
+			message := spec.AutoCommit.Message
+			if message == "" {
+				message = defaultSyncCommitMessage
+			}
+			commit, err := CreateCommit(ctx, cfg, st, wc, message)
+			if err != nil {
+				return result, fmt.Errorf("auto-commit: %w", err)
+			}
+			result.record("commit %s %s", commit.ShortID(), message)
+		}
+	}
+
+	if client != nil && spec.Remote.Pull {
+		if err := syncPull(ctx, cfg, st, wc, spec, client, result); err != nil {
+			return result, err
+		}
+	}
+
+	if client != nil && spec.Remote.Push {
+		pushed, err := Push(ctx, st, client, PushOptions{RemoteName: spec.Remote.Name, Branch: spec.Branch}, nil)
+		if err != nil {
+			return result, err
+		}
+		if !pushed.UpToDate {
+			result.record("push %d commit(s) to %s/%s", pushed.CommitsPushed, spec.Remote.Name, spec.Branch)
+		}
+	}
+
+	var publish remote.RemoteClient
+	if client != nil && spec.Remote.Push {
+		publish = client
+	}
+	if err := syncEnvironments(ctx, st, spec.Environments, publish, result); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// syncRemote adds the remote, or updates its URL, to match r.
+func syncRemote(st *store.Store, r *SyncRemoteSpec, result *SyncResult) error {
+	existing, err := st.GetRemote(r.Name)
+	if err != nil {
+		return fmt.Errorf("get remote: %w", err)
+	}
+	switch {
+	case existing == nil && r.URL == "":
+		return fmt.Errorf("remote '%s' does not exist; give its url in the sync file", r.Name)
+	case existing == nil:
+		if err := AddRemote(st, r.Name, r.URL); err != nil {
+			return err
+		}
+		result.record("add remote %s %s", r.Name, r.URL)
+	case r.URL != "" && existing.URL != r.URL:
+		if err := SetRemoteURL(st, r.Name, r.URL); err != nil {
+			return err
+		}
+		result.record("set remote %s url to %s", r.Name, r.URL)
+	}
+	return nil
+}
+
+// syncBranch checks out the spec's branch. A missing branch is created from
+// the remote's branch when pulling and the remote has it, or else from HEAD.
+func syncBranch(ctx context.Context, cfg *config.Config, st *store.Store, wc weaviate.ClientInterface, spec *SyncSpec, client remote.RemoteClient, result *SyncResult) error {
+	current, err := st.GetCurrentBranch()
+	if err != nil {
+		return err
+	}
+	if current == spec.Branch {
+		return nil
+	}
+
+	exists, err := st.BranchExists(spec.Branch)
+	if err != nil {
+		return err
+	}
+	if exists {
+		if _, err := Checkout(ctx, cfg, st, wc, spec.Branch, CheckoutOptions{}); err != nil {
+			return fmt.Errorf("check out %s: %w", spec.Branch, err)
+		}
+		result.record("check out branch %s", spec.Branch)
+		return nil
+	}
+
+	start, from := "", ""
+	if client != nil && spec.Remote.Pull {
+		fetched, err := Fetch(ctx, st, client, FetchOptions{RemoteName: spec.Remote.Name, Branch: spec.Branch}, nil)
+		if err != nil && !isRemoteNotFound(err) {
+			return err
+		}
+		if err == nil && fetched.RemoteTip != "" {
+			start, from = fetched.RemoteTip, spec.Remote.Name+"/"+spec.Branch
+		}
+	}
+	if start == "" {
+		if start, err = st.GetHEAD(); err != nil {
+			return err
+		}
+		from = "HEAD"
+	}
+	if start == "" {
+		// Nothing committed yet: the branch is born with its first commit
+		if err := st.SetCurrentBranch(spec.Branch); err != nil {
+			return err
+		}
+		result.record("switch to new branch %s", spec.Branch)
+		return nil
+	}
+	if _, err := Checkout(ctx, cfg, st, wc, start, CheckoutOptions{CreateBranch: true, NewBranchName: spec.Branch}); err != nil {
+		return fmt.Errorf("create branch %s: %w", spec.Branch, err)
+	}
+	result.record("create branch %s from %s", spec.Branch, from)
+	return nil
+}
+
+// syncPull pulls the branch, merging the remote's when they have diverged
+// and the spec allows it. A branch the remote doesn't have yet is left for
+// the push to create.
+func syncPull(ctx context.Context, cfg *config.Config, st *store.Store, wc weaviate.ClientInterface, spec *SyncSpec, client remote.RemoteClient, result *SyncResult) error {
+	r := spec.Remote
+	pulled, err := Pull(ctx, cfg, st, wc, client, PullOptions{RemoteName: r.Name, Branch: spec.Branch}, nil)
+	if err != nil {
+		if isRemoteNotFound(err) && r.Push {
+			return nil
+		}
+		return err
+	}
+
+	tracking := r.Name + "/" + spec.Branch
+	switch {
+	case pulled.FastForward:
+		result.record("fast-forward %s to %s (%d commit(s))", spec.Branch, shortCommitID(pulled.RemoteTip), pulled.CommitsFetched)
+	case pulled.Diverged && r.OnDiverge == SyncDivergeMerge:
+		merged, err := Merge(ctx, cfg, st, wc, tracking, models.MergeOptions{
+			Message:  fmt.Sprintf("Merge %s into %s", tracking, spec.Branch),
+			Strategy: models.ConflictAbort,
+		})
+		if err != nil {
+			return fmt.Errorf("merge %s: %w", tracking, err)
+		}
+		if !merged.Success {
+			return fmt.Errorf("merge %s stopped on %d conflict(s); merge it by hand with 'wvc merge %s'",
+				tracking, len(merged.Conflicts)+len(merged.SchemaConflicts), tracking)
+		}
+		result.record("merge %s into %s", tracking, spec.Branch)
+	case pulled.Diverged:
+		return fmt.Errorf("branch %s has diverged from %s; merge it by hand or set on_diverge: %s", spec.Branch, tracking, SyncDivergeMerge)
+	}
+	return nil
+}
+
+// syncEnvironments records the deployment of each environment whose ref
+// resolves to a different commit than last recorded, and publishes the
+// deployments publish, if set, doesn't already have.
+func syncEnvironments(ctx context.Context, st *store.Store, environments map[string]string, publish remote.RemoteClient, result *SyncResult) error {
+	if len(environments) == 0 {
+		return nil
+	}
+	envs := make([]string, 0, len(environments))
+	for env := range environments {
+		envs = append(envs, env)
+	}
+	sort.Strings(envs)
+
+	published := map[string]string{}
+	if publish != nil {
+		remoteDeployments, err := ListRemoteDeployments(ctx, publish)
+		if err != nil {
+			return fmt.Errorf("list remote deployments: %w", err)
+		}
+		for _, d := range remoteDeployments {
+			published[d.Environment] = d.CommitID
+		}
+	}
+
+	for _, env := range envs {
+		ref := environments[env]
+		commitID, _, err := ResolveRef(st, ref)
+		if err != nil {
+			return fmt.Errorf("environment %s: %w", env, err)
+		}
+		d, err := st.GetDeployment(env)
+		if err != nil {
+			return err
+		}
+		if d == nil || d.CommitID != commitID {
+			if d, err = RecordDeployment(st, env, ref); err != nil {
+				return err
+			}
+			result.record("deploy %s (%s) to %s", shortCommitID(commitID), ref, env)
+		}
+		if publish != nil && published[env] != commitID {
+			if err := PublishDeployment(ctx, publish, d); err != nil {
+				return err
+			}
+			result.record("publish %s deployment", env)
+		}
+	}
+	return nil
+}
+
+// isRemoteNotFound reports whether err is the remote saying a branch or
+// other resource doesn't exist.
+func isRemoteNotFound(err error) bool {
+	var re *remote.RemoteError
+	return errors.As(err, &re) && re.Status == http.StatusNotFound
+}
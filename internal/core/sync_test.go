@@ -0,0 +1,153 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/remote"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSyncSpec(t *testing.T) {
+	spec, err := ParseSyncSpec([]byte(`
+branch: main
+remote:
+  name: origin
+  url: https://wvc.example.com/repo
+  pull: true
+  push: true
+  on_diverge: merge
+auto_commit:
+  enabled: true
+environments:
+  staging: main
+`))
+	require.NoError(t, err)
+	assert.Equal(t, "main", spec.Branch)
+	require.NotNil(t, spec.Remote)
+	assert.Equal(t, "origin", spec.Remote.Name)
+	assert.True(t, spec.Remote.Pull)
+	assert.Equal(t, SyncDivergeMerge, spec.Remote.OnDiverge)
+	assert.True(t, spec.AutoCommit.Enabled)
+	assert.Equal(t, map[string]string{"staging": "main"}, spec.Environments)
+
+	for name, doc := range map[string]string{
+		"no branch":       "remote:\n  name: origin\n",
+		"unknown key":     "branch: main\nauto_comit:\n  enabled: true\n",
+		"bad on_diverge":  "branch: main\nremote:\n  name: origin\n  on_diverge: rebase\n",
+		"bad environment": "branch: main\nenvironments:\n  prod/eu: main\n",
+		"empty ref":       "branch: main\nenvironments:\n  prod: \"\"\n",
+	} {
+		_, err := ParseSyncSpec([]byte(doc))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestApplySync_Local(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+
+	client.AddClass(&models.WeaviateClass{Class: "Article"})
+	client.AddObject(&models.WeaviateObject{ID: "obj-1", Class: "Article"})
+	first, err := CreateCommit(ctx, cfg, st, client, "First")
+	require.NoError(t, err)
+	require.NoError(t, st.CreateBranch("main", first.ID))
+
+	spec := &SyncSpec{
+		Branch:       "release",
+		AutoCommit:   SyncAutoCommitSpec{Enabled: true, Message: "ingest"},
+		Environments: map[string]string{"staging": "release", "prod": "main"},
+	}
+	noRemote := func(string) (remote.RemoteClient, error) {
+		t.Fatal("no remote is declared")
+		return nil, nil
+	}
+
+	result, err := ApplySync(ctx, cfg, st, client, spec, noRemote)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"create branch release from HEAD",
+		"deploy " + first.ShortID() + " (main) to prod",
+		"deploy " + first.ShortID() + " (release) to staging",
+	}, result.Actions)
+	current, err := st.GetCurrentBranch()
+	require.NoError(t, err)
+	assert.Equal(t, "release", current)
+
+	// New data is committed and staging follows the branch
+	client.AddObject(&models.WeaviateObject{ID: "obj-2", Class: "Article"})
+	result, err = ApplySync(ctx, cfg, st, client, spec, noRemote)
+	require.NoError(t, err)
+	require.Len(t, result.Actions, 2)
+	assert.Contains(t, result.Actions[0], "ingest")
+	head, err := st.GetHEAD()
+	require.NoError(t, err)
+	staging, err := st.GetDeployment("staging")
+	require.NoError(t, err)
+	assert.Equal(t, head, staging.CommitID)
+	prod, err := st.GetDeployment("prod")
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, prod.CommitID)
+
+	// Nothing left to do
+	result, err = ApplySync(ctx, cfg, st, client, spec, noRemote)
+	require.NoError(t, err)
+	assert.Empty(t, result.Actions)
+}
+
+func TestApplySync_PushAndPublish(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	wc := weaviate.NewMockClient()
+
+	wc.AddClass(&models.WeaviateClass{Class: "Article"})
+	wc.AddObject(&models.WeaviateObject{ID: "obj-1", Class: "Article"})
+	first, err := CreateCommit(ctx, cfg, st, wc, "First")
+	require.NoError(t, err)
+	require.NoError(t, st.CreateBranch("main", first.ID))
+
+	client := newPushMockClient()
+	client.negotiatePushResp = &remote.NegotiatePushResponse{MissingCommits: []string{first.ID}}
+	client.vectorCheckResp = &remote.VectorCheckResponse{}
+	connect := func(name string) (remote.RemoteClient, error) {
+		assert.Equal(t, "origin", name)
+		return client, nil
+	}
+	spec := &SyncSpec{
+		Branch:       "main",
+		Remote:       &SyncRemoteSpec{Name: "origin", URL: "https://wvc.example.com/repo", Push: true},
+		Environments: map[string]string{"prod": "main"},
+	}
+
+	result, err := ApplySync(ctx, cfg, st, wc, spec, connect)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"add remote origin https://wvc.example.com/repo",
+		"push 1 commit(s) to origin/main",
+		"deploy " + first.ShortID() + " (main) to prod",
+		"publish prod deployment",
+	}, result.Actions)
+	assert.Equal(t, first.ID, client.deployments["prod"])
+
+	// The remote is up to date; only a changed URL is applied
+	client.negotiatePushResp = &remote.NegotiatePushResponse{RemoteTip: first.ID}
+	spec.Remote.URL = "https://wvc.example.com/other"
+	result, err = ApplySync(ctx, cfg, st, wc, spec, connect)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"set remote origin url to https://wvc.example.com/other"}, result.Actions)
+}
+
+func TestApplySync_MissingRemote(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+
+	spec := &SyncSpec{Branch: "main", Remote: &SyncRemoteSpec{Name: "origin", Push: true}}
+	_, err := ApplySync(ctx, newTestConfig(), st, weaviate.NewMockClient(), spec, nil)
+	assert.ErrorContains(t, err, "does not exist")
+}
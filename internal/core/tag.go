@@ -0,0 +1,137 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/remote"
+	"github.com/kilupskalvis/wvc/internal/store"
+)
+
+// validateTagName checks that name is one or more slash-separated segments,
+// none empty, "." or "..", so it can be used in a remote's URL path.
+func validateTagName(name string) error {
+	if name == "" {
+		return fmt.Errorf("tag name cannot be empty")
+	}
+	for _, seg := range strings.Split(name, "/") {
+		if seg == "" || seg == "." || seg == ".." || strings.ContainsAny(seg, " \t\n") {
+			return fmt.Errorf("invalid tag name '%s'", name)
+		}
+	}
+	return nil
+}
+
+// CreateTag tags the commit ref resolves to (HEAD when empty). An existing
+// tag is only moved when force is set.
+func CreateTag(st *store.Store, name, ref, message string, force bool) (*models.Tag, error) {
+	if err := validateTagName(name); err != nil {
+		return nil, err
+	}
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	existing, err := st.GetTag(name)
+	if err != nil {
+		return nil, fmt.Errorf("get tag: %w", err)
+	}
+	if existing != nil && !force {
+		return nil, fmt.Errorf("tag '%s' already exists", name)
+	}
+
+	commitID, _, err := ResolveRef(st, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	tag := &models.Tag{Name: name, CommitID: commitID, Message: message, CreatedAt: time.Now()}
+	if err := st.PutTag(tag); err != nil {
+		return nil, fmt.Errorf("store tag: %w", err)
+	}
+	return tag, nil
+}
+
+// PushTags uploads the named local tags, or all of them when names is empty,
+// to the remote. Their commits must already be on the remote.
+func PushTags(ctx context.Context, st *store.Store, client remote.RemoteClient, names []string, force bool) ([]*models.Tag, error) {
+	var tags []*models.Tag
+	if len(names) == 0 {
+		var err error
+		if tags, err = st.ListTags(); err != nil {
+			return nil, fmt.Errorf("list tags: %w", err)
+		}
+	}
+	for _, name := range names {
+		tag, err := st.GetTag(name)
+		if err != nil {
+			return nil, fmt.Errorf("get tag: %w", err)
+		}
+		if tag == nil {
+			return nil, fmt.Errorf("tag '%s' not found", name)
+		}
+		tags = append(tags, tag)
+	}
+
+	for _, tag := range tags {
+		if err := client.PutTag(ctx, tag, force); err != nil {
+			return nil, err
+		}
+	}
+	return tags, nil
+}
+
+// DeleteRemoteTag deletes a tag on the remote.
+func DeleteRemoteTag(ctx context.Context, client remote.RemoteClient, name string) error {
+	return client.DeleteTag(ctx, name)
+}
+
+// TagFetchResult reports what FetchTags did with each remote tag.
+type TagFetchResult struct {
+	Fetched []*models.Tag
+	// MissingCommit lists tags whose commit has not been fetched yet
+	MissingCommit []*models.Tag
+	// Diverged lists tags that exist locally at another commit
+	Diverged []*models.Tag
+}
+
+// FetchTags stores the remote's tags locally. Tags whose commit is not in the
+// local history are skipped, as are tags held locally at another commit
+// unless force is set.
+func FetchTags(ctx context.Context, st *store.Store, client remote.RemoteClient, force bool) (*TagFetchResult, error) {
+	remoteTags, err := client.ListTags(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TagFetchResult{}
+	for _, tag := range remoteTags {
+		local, err := st.GetTag(tag.Name)
+		if err != nil {
+			return nil, fmt.Errorf("get tag: %w", err)
+		}
+		if local != nil && local.CommitID == tag.CommitID {
+			continue
+		}
+		if local != nil && !force {
+			result.Diverged = append(result.Diverged, tag)
+			continue
+		}
+		has, err := st.HasCommit(tag.CommitID)
+		if err != nil {
+			return nil, fmt.Errorf("check commit: %w", err)
+		}
+		if !has {
+			result.MissingCommit = append(result.MissingCommit, tag)
+			continue
+		}
+		if err := st.PutTag(tag); err != nil {
+			return nil, fmt.Errorf("store tag: %w", err)
+		}
+		result.Fetched = append(result.Fetched, tag)
+	}
+	return result, nil
+}
@@ -0,0 +1,91 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateTag(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+
+	client.AddClass(&models.WeaviateClass{Class: "Article"})
+	client.AddObject(&models.WeaviateObject{ID: "obj-1", Class: "Article"})
+	first, err := CreateCommit(ctx, cfg, st, client, "First")
+	require.NoError(t, err)
+	client.AddObject(&models.WeaviateObject{ID: "obj-2", Class: "Article"})
+	second, err := CreateCommit(ctx, cfg, st, client, "Second")
+	require.NoError(t, err)
+
+	tag, err := CreateTag(st, "v1", first.ShortID(), "first release", false)
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, tag.CommitID)
+
+	// Tags resolve as refs
+	commitID, _, err := ResolveRef(st, "v1")
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, commitID)
+
+	// Moving a tag needs force
+	_, err = CreateTag(st, "v1", "", "", false)
+	assert.Error(t, err)
+	tag, err = CreateTag(st, "v1", "", "", true)
+	require.NoError(t, err)
+	assert.Equal(t, second.ID, tag.CommitID)
+
+	_, err = CreateTag(st, "release/../v2", "", "", false)
+	assert.Error(t, err)
+}
+
+func TestPushAndFetchTags(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+	remoteClient := newPushMockClient()
+
+	client.AddClass(&models.WeaviateClass{Class: "Article"})
+	client.AddObject(&models.WeaviateObject{ID: "obj-1", Class: "Article"})
+	first, err := CreateCommit(ctx, cfg, st, client, "First")
+	require.NoError(t, err)
+
+	_, err = CreateTag(st, "v1", "", "", false)
+	require.NoError(t, err)
+	pushed, err := PushTags(ctx, st, remoteClient, nil, false)
+	require.NoError(t, err)
+	require.Len(t, pushed, 1)
+	assert.Equal(t, first.ID, remoteClient.tags["v1"].CommitID)
+
+	_, err = PushTags(ctx, st, remoteClient, []string{"nope"}, false)
+	assert.Error(t, err)
+
+	// Tags on the remote: one new, one at an unknown commit, one diverged
+	remoteClient.tags["v0"] = &models.Tag{Name: "v0", CommitID: first.ID}
+	remoteClient.tags["v9"] = &models.Tag{Name: "v9", CommitID: "unfetched"}
+	_, err = CreateTag(st, "v2", "", "", false)
+	require.NoError(t, err)
+	remoteClient.tags["v2"] = &models.Tag{Name: "v2", CommitID: "elsewhere"}
+
+	result, err := FetchTags(ctx, st, remoteClient, false)
+	require.NoError(t, err)
+	require.Len(t, result.Fetched, 1)
+	assert.Equal(t, "v0", result.Fetched[0].Name)
+	require.Len(t, result.MissingCommit, 1)
+	assert.Equal(t, "v9", result.MissingCommit[0].Name)
+	require.Len(t, result.Diverged, 1)
+	assert.Equal(t, "v2", result.Diverged[0].Name)
+
+	tag, err := st.GetTag("v0")
+	require.NoError(t, err)
+	require.NotNil(t, tag)
+
+	require.NoError(t, DeleteRemoteTag(ctx, remoteClient, "v0"))
+	assert.NotContains(t, remoteClient.tags, "v0")
+}
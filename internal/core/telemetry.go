@@ -0,0 +1,186 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/remote"
+)
+
+// TelemetryQueueFile holds the usage events not yet sent, one JSON object
+// per line, in the .wvc directory.
+const TelemetryQueueFile = "telemetry.jsonl"
+
+// Usage events are sent in batches, once enough have been queued or the
+// oldest has waited long enough, so most commands add no network round trip.
+const (
+	telemetryBatchSize = 50
+	telemetryMaxAge    = 24 * time.Hour
+	telemetryMaxQueue  = 1000 // events kept while the endpoint is unreachable
+)
+
+// TelemetryEvent is one anonymized command run: which command, how long it
+// took, and how it failed. Arguments, flag values, object data, names, and
+// URLs are never recorded.
+type TelemetryEvent struct {
+	ID         string    `json:"id"`      // the repository's random telemetry ID
+	Command    string    `json:"command"` // command path, e.g. "remote add"
+	DurationMS int64     `json:"duration_ms"`
+	Error      string    `json:"error,omitempty"` // error category, empty on success
+	Version    string    `json:"version"`
+	OS         string    `json:"os"`
+	Arch       string    `json:"arch"`
+	Timestamp  time.Time `json:"timestamp"` // truncated to the hour
+}
+
+// TelemetryBatch is the body posted to the telemetry endpoint.
+type TelemetryBatch struct {
+	Events []*TelemetryEvent `json:"events"`
+}
+
+// NewTelemetryID returns a random identifier for a repository's events.
+func NewTelemetryID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// NewTelemetryEvent returns the event of a command run that started at
+// start, with the platform and version filled in.
+func NewTelemetryEvent(id, command, version string, start time.Time, errCategory string) *TelemetryEvent {
+	return &TelemetryEvent{
+		ID:         id,
+		Command:    command,
+		DurationMS: time.Since(start).Milliseconds(),
+		Error:      errCategory,
+		Version:    version,
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		Timestamp:  start.UTC().Truncate(time.Hour),
+	}
+}
+
+// TelemetryErrorCategory classifies an error without recording its message:
+// the error code of a server response, or the kind of local failure.
+func TelemetryErrorCategory(err error) string {
+	var remoteErr *remote.RemoteError
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &remoteErr):
+		return "remote:" + remoteErr.Code
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return "timeout"
+	case errors.As(err, &netErr):
+		return "network"
+	case errors.Is(err, os.ErrNotExist):
+		return "not_found"
+	case errors.Is(err, os.ErrPermission):
+		return "permission"
+	}
+	return "other"
+}
+
+// RecordTelemetry appends an event to the queue in wvcPath, dropping the
+// oldest events beyond telemetryMaxQueue.
+func RecordTelemetry(wvcPath string, event *TelemetryEvent) error {
+	events, err := ReadTelemetryQueue(wvcPath)
+	if err != nil {
+		return err
+	}
+	events = append(events, event)
+	if len(events) > telemetryMaxQueue {
+		events = events[len(events)-telemetryMaxQueue:]
+	}
+	return writeTelemetryQueue(wvcPath, events)
+}
+
+// ReadTelemetryQueue returns the queued events, oldest first. Lines that do
+// not parse are skipped.
+func ReadTelemetryQueue(wvcPath string) ([]*TelemetryEvent, error) {
+	f, err := os.Open(filepath.Join(wvcPath, TelemetryQueueFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var events []*TelemetryEvent
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var event TelemetryEvent
+		if json.Unmarshal(scanner.Bytes(), &event) == nil {
+			events = append(events, &event)
+		}
+	}
+	return events, scanner.Err()
+}
+
+// ClearTelemetryQueue deletes the queued events.
+func ClearTelemetryQueue(wvcPath string) error {
+	err := os.Remove(filepath.Join(wvcPath, TelemetryQueueFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func writeTelemetryQueue(wvcPath string, events []*TelemetryEvent) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(filepath.Join(wvcPath, TelemetryQueueFile), buf.Bytes(), 0644)
+}
+
+// FlushTelemetry posts the queued events to endpoint and clears the queue
+// once they are accepted. Unless force is set, it waits until a batch has
+// filled or the oldest event is a day old. It returns the number sent.
+func FlushTelemetry(ctx context.Context, client *http.Client, wvcPath, endpoint string, force bool) (int, error) {
+	events, err := ReadTelemetryQueue(wvcPath)
+	if err != nil || len(events) == 0 {
+		return 0, err
+	}
+	if !force && len(events) < telemetryBatchSize && time.Since(events[0].Timestamp) < telemetryMaxAge {
+		return 0, nil
+	}
+
+	data, err := json.Marshal(&TelemetryBatch{Events: events})
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(data))
+	if err != nil {
+		return 0, fmt.Errorf("create telemetry request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return 0, fmt.Errorf("telemetry endpoint returned HTTP %d", resp.StatusCode)
+	}
+	return len(events), ClearTelemetryQueue(wvcPath)
+}
@@ -0,0 +1,87 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTelemetryQueue(t *testing.T) {
+	dir := t.TempDir()
+	var received []*TelemetryEvent
+	status := http.StatusInternalServerError
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch TelemetryBatch
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&batch))
+		if status == http.StatusAccepted {
+			received = append(received, batch.Events...)
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(endpoint.Close)
+	ctx := context.Background()
+
+	start := time.Now().Add(-1500 * time.Millisecond)
+	event := NewTelemetryEvent("id-1", "remote add", "1.2.3", start, "")
+	assert.GreaterOrEqual(t, event.DurationMS, int64(1500))
+	assert.Equal(t, start.UTC().Truncate(time.Hour), event.Timestamp)
+	require.NoError(t, RecordTelemetry(dir, event))
+	require.NoError(t, RecordTelemetry(dir, NewTelemetryEvent("id-1", "push", "1.2.3", time.Now(), "remote:push_rejected")))
+
+	// A small, recent queue waits for a full batch
+	sent, err := FlushTelemetry(ctx, endpoint.Client(), dir, endpoint.URL, false)
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+
+	// Rejected batches stay queued
+	_, err = FlushTelemetry(ctx, endpoint.Client(), dir, endpoint.URL, true)
+	assert.Error(t, err)
+	events, err := ReadTelemetryQueue(dir)
+	require.NoError(t, err)
+	assert.Len(t, events, 2)
+
+	status = http.StatusAccepted
+	sent, err = FlushTelemetry(ctx, endpoint.Client(), dir, endpoint.URL, true)
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	require.Len(t, received, 2)
+	assert.Equal(t, "remote add", received[0].Command)
+	assert.Equal(t, "remote:push_rejected", received[1].Error)
+	events, err = ReadTelemetryQueue(dir)
+	require.NoError(t, err)
+	assert.Empty(t, events)
+
+	// A full batch is sent without forcing, and the queue is bounded
+	for i := 0; i < telemetryMaxQueue+10; i++ {
+		require.NoError(t, RecordTelemetry(dir, NewTelemetryEvent("id-1", fmt.Sprintf("cmd-%d", i), "1.2.3", time.Now(), "")))
+	}
+	events, err = ReadTelemetryQueue(dir)
+	require.NoError(t, err)
+	require.Len(t, events, telemetryMaxQueue)
+	assert.Equal(t, "cmd-10", events[0].Command)
+	sent, err = FlushTelemetry(ctx, endpoint.Client(), dir, endpoint.URL, false)
+	require.NoError(t, err)
+	assert.Equal(t, telemetryMaxQueue, sent)
+}
+
+func TestTelemetryErrorCategory(t *testing.T) {
+	assert.Equal(t, "", TelemetryErrorCategory(nil))
+	assert.Equal(t, "remote:push_rejected", TelemetryErrorCategory(fmt.Errorf("push: %w", &remote.RemoteError{Code: "push_rejected", Status: 409})))
+	assert.Equal(t, "timeout", TelemetryErrorCategory(fmt.Errorf("wait: %w", context.DeadlineExceeded)))
+	assert.Equal(t, "not_found", TelemetryErrorCategory(fmt.Errorf("open: %w", os.ErrNotExist)))
+	assert.Equal(t, "other", TelemetryErrorCategory(errors.New("branch 'x' does not exist")))
+
+	_, err := http.Get("http://127.0.0.1:1")
+	require.Error(t, err)
+	assert.Equal(t, "network", TelemetryErrorCategory(err))
+}
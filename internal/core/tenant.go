@@ -0,0 +1,59 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+)
+
+// versionedClasses returns the names objects are versioned under, in schema
+// order: each class, with a multi-tenant class expanded into one
+// tenant-qualified name per tenant (see models.TenantClass), so every tenant
+// is diffed, committed, and restored like a class of its own.
+func versionedClasses(ctx context.Context, client weaviate.ClientInterface) ([]string, error) {
+	schema, err := client.GetSchemaTyped(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, class := range schema.Classes {
+		if !class.MultiTenant() {
+			names = append(names, class.Class)
+			continue
+		}
+		tenants, err := client.GetTenants(ctx, class.Class)
+		if err != nil {
+			return nil, err
+		}
+		for _, tenant := range tenants {
+			names = append(names, models.TenantClass(class.Class, tenant))
+		}
+	}
+	return names, nil
+}
+
+// ensureTenants creates the tenants that objects about to be written belong
+// to and that are not among the versioned classes Weaviate already has.
+func ensureTenants(ctx context.Context, client weaviate.ClientInterface, existing []string, writes []*objectWrite) error {
+	have := make(map[string]bool, len(existing))
+	for _, name := range existing {
+		have[name] = true
+	}
+	missing := make(map[string][]string)
+	for _, w := range writes {
+		name := w.Object.TenantClass()
+		if w.Object.Tenant == "" || have[name] {
+			continue
+		}
+		have[name] = true
+		missing[w.Object.Class] = append(missing[w.Object.Class], w.Object.Tenant)
+	}
+	for _, className := range sortedKeys(missing) {
+		if err := client.AddTenants(ctx, className, missing[className]); err != nil {
+			return fmt.Errorf("create tenants of %s: %w", className, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiTenantClass_CommitDiffAndCheckout(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+
+	client.AddClass(&models.WeaviateClass{Class: "Article"})
+	client.AddClass(&models.WeaviateClass{Class: "Doc", MultiTenancy: map[string]interface{}{"enabled": true}})
+	require.NoError(t, client.AddTenants(ctx, "Doc", []string{"acme", "globex"}))
+	client.AddObject(&models.WeaviateObject{ID: "a-1", Class: "Article", Properties: map[string]interface{}{"title": "A"}})
+	// The same ID in two tenants is two objects
+	client.AddObject(&models.WeaviateObject{ID: "d-1", Class: "Doc", Tenant: "acme", Properties: map[string]interface{}{"body": "acme"}})
+	client.AddObject(&models.WeaviateObject{ID: "d-1", Class: "Doc", Tenant: "globex", Properties: map[string]interface{}{"body": "globex"}})
+
+	first, err := CreateCommit(ctx, cfg, st, client, "First")
+	require.NoError(t, err)
+	ops, err := st.GetOperationsByCommit(first.ID)
+	require.NoError(t, err)
+	var keys []string
+	for _, op := range ops {
+		keys = append(keys, models.ObjectKey(op.ClassName, op.ObjectID))
+	}
+	assert.ElementsMatch(t, []string{"Article/a-1", "Doc@acme/d-1", "Doc@globex/d-1"}, keys)
+
+	// A change in one tenant is reported for that tenant only
+	client.AddObject(&models.WeaviateObject{ID: "d-1", Class: "Doc", Tenant: "globex", Properties: map[string]interface{}{"body": "changed"}})
+	diff, err := ComputeIncrementalDiff(ctx, cfg, st, client)
+	require.NoError(t, err)
+	require.Len(t, diff.Unstaged.Updated, 1)
+	assert.Equal(t, "Doc@globex", diff.Unstaged.Updated[0].ClassName)
+	assert.Equal(t, "globex", diff.Unstaged.Updated[0].CurrentData.Tenant)
+
+	// Pathspecs and class scopes cover every tenant of a class
+	spec, err := ParsePathspec([]string{"Doc"})
+	require.NoError(t, err)
+	assert.True(t, spec.Matches("Doc@globex", "d-1"))
+	assert.True(t, NewClassScope([]string{"Doc"}).Includes("Doc@acme"))
+
+	_, err = CreateCommit(ctx, cfg, st, client, "Second")
+	require.NoError(t, err)
+
+	// Checkout restores the tenant's object, and recreates a removed tenant
+	delete(client.Objects, "Doc@acme/d-1")
+	client.Tenants["Doc"] = []string{"globex"}
+	_, err = Checkout(ctx, cfg, st, client, first.ID, CheckoutOptions{Force: true})
+	require.NoError(t, err)
+	assert.Equal(t, "globex", client.Objects["Doc@globex/d-1"].Properties["body"])
+	require.Contains(t, client.Objects, "Doc@acme/d-1")
+	assert.Equal(t, "acme", client.Objects["Doc@acme/d-1"].Tenant)
+	assert.ElementsMatch(t, []string{"acme", "globex"}, client.Tenants["Doc"])
+}
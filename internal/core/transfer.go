@@ -0,0 +1,94 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/kilupskalvis/wvc/internal/remote"
+	"github.com/kilupskalvis/wvc/internal/store"
+	"golang.org/x/sync/errgroup"
+)
+
+// DefaultTransferConcurrency is the number of vectors push and pull transfer
+// at once when their options leave Concurrency unset.
+const DefaultTransferConcurrency = 8
+
+// vectorRetry configures the retries of a single vector upload. The retry
+// client cannot retry uploads, since their reader is consumed, so each
+// attempt reopens the local blob. Downloads are retried by the client alone.
+var vectorRetry = remote.DefaultRetryConfig()
+
+// transferVectors runs transfer for every hash on a pool of concurrency
+// workers. Progress reports the vectors finished so far under phase.
+func transferVectors(ctx context.Context, hashes []string, concurrency int, phase string, progress func(phase string, current, total int), transfer func(ctx context.Context, hash string) error) error {
+	if concurrency <= 0 {
+		concurrency = DefaultTransferConcurrency
+	}
+
+	var (
+		mu   sync.Mutex
+		done int
+	)
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	for _, hash := range hashes {
+		g.Go(func() error {
+			if err := transfer(ctx, hash); err != nil {
+				return err
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			done++
+			progress(phase, done, len(hashes))
+			return nil
+		})
+	}
+	return g.Wait()
+}
+
+// uploadMissingVectors uploads vector blobs, retrying each on transient
+// errors. Failures to read the local blob are not retried.
+func uploadMissingVectors(ctx context.Context, st *store.Store, client remote.RemoteClient, missingHashes []string, concurrency int, progress PushProgress) (int, error) {
+	err := transferVectors(ctx, missingHashes, concurrency, "uploading vectors", progress, func(ctx context.Context, h string) error {
+		return remote.Retry(ctx, vectorRetry, "", func() error {
+			reader, dims, err := st.OpenVectorBlob(h)
+			if err != nil {
+				return remote.Permanent(fmt.Errorf("get local vector %s: %w", h, err))
+			}
+			defer reader.Close()
+
+			if err := client.UploadVector(ctx, h, reader, dims); err != nil {
+				return fmt.Errorf("upload vector %s: %w", h, err)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(missingHashes), nil
+}
+
+// downloadMissingVectors downloads vector blobs into the store. The request
+// is retried by the client; a failure to store a blob, including a hash
+// mismatch, is not.
+func downloadMissingVectors(ctx context.Context, st *store.Store, client remote.RemoteClient, missingHashes []string, concurrency int, progress FetchProgress) (int, error) {
+	err := transferVectors(ctx, missingHashes, concurrency, "downloading vectors", progress, func(ctx context.Context, h string) error {
+		reader, dims, err := client.DownloadVector(ctx, h)
+		if err != nil {
+			return fmt.Errorf("download vector %s: %w", h, err)
+		}
+		defer reader.Close()
+
+		// Stream into the store; the hash is verified as the data is read
+		if _, err := st.SaveVectorBlobFrom(reader, h, dims); err != nil {
+			return fmt.Errorf("save vector %s: %w", h, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(missingHashes), nil
+}
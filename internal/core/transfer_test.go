@@ -0,0 +1,172 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/remote"
+	"github.com/kilupskalvis/wvc/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fastVectorRetry shortens the backoff between vector upload attempts for
+// the duration of a test.
+func fastVectorRetry(t *testing.T) {
+	t.Helper()
+	saved := vectorRetry
+	vectorRetry = &remote.RetryConfig{MaxRetries: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+	t.Cleanup(func() { vectorRetry = saved })
+}
+
+func TestTransferVectors_BoundsConcurrency(t *testing.T) {
+	hashes := make([]string, 20)
+	for i := range hashes {
+		hashes[i] = fmt.Sprintf("h%d", i)
+	}
+
+	var (
+		mu       sync.Mutex
+		inFlight int
+		peak     int
+		reported []int
+	)
+	progress := func(phase string, current, total int) {
+		assert.Equal(t, "uploading vectors", phase)
+		assert.Equal(t, len(hashes), total)
+		reported = append(reported, current)
+	}
+	err := transferVectors(context.Background(), hashes, 3, "uploading vectors", progress, func(context.Context, string) error {
+		mu.Lock()
+		inFlight++
+		peak = max(peak, inFlight)
+		mu.Unlock()
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		return nil
+	})
+	require.NoError(t, err)
+	assert.LessOrEqual(t, peak, 3)
+	require.Len(t, reported, len(hashes))
+	for i, current := range reported {
+		assert.Equal(t, i+1, current, "progress counts finished vectors in order")
+	}
+}
+
+// flakyUploadClient fails the first uploads of every vector with err.
+type flakyUploadClient struct {
+	*pushMockClient
+	failures int
+	err      error
+	mu       sync.Mutex
+	attempts map[string]int
+}
+
+func (c *flakyUploadClient) UploadVector(ctx context.Context, hash string, r io.Reader, dims int) error {
+	c.mu.Lock()
+	c.attempts[hash]++
+	fail := c.attempts[hash] <= c.failures
+	c.mu.Unlock()
+	if fail {
+		return c.err
+	}
+	return c.pushMockClient.UploadVector(ctx, hash, r, dims)
+}
+
+func TestUploadMissingVectors_RetriesTransientErrors(t *testing.T) {
+	fastVectorRetry(t)
+	st := newPushTestStore(t)
+	a, err := st.SaveVectorBlob([]byte{0, 0, 128, 63}, 1)
+	require.NoError(t, err)
+	b, err := st.SaveVectorBlob([]byte{0, 0, 0, 64}, 1)
+	require.NoError(t, err)
+
+	client := &flakyUploadClient{
+		pushMockClient: newPushMockClient(),
+		failures:       2,
+		err:            &remote.RemoteError{Status: 503, Code: "unavailable", Message: "try again"},
+		attempts:       make(map[string]int),
+	}
+	n, err := uploadMissingVectors(context.Background(), st, client, []string{a, b}, 0, func(string, int, int) {})
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, map[string]int{a: 3, b: 3}, client.attempts)
+	assert.Len(t, client.uploadedVectors, 2)
+}
+
+func TestUploadMissingVectors_PermanentErrorNotRetried(t *testing.T) {
+	fastVectorRetry(t)
+	st := newPushTestStore(t)
+	h, err := st.SaveVectorBlob([]byte{0, 0, 128, 63}, 1)
+	require.NoError(t, err)
+
+	client := &flakyUploadClient{
+		pushMockClient: newPushMockClient(),
+		failures:       1,
+		err:            &remote.RemoteError{Status: 413, Code: "quota_exceeded", Message: "over quota"},
+		attempts:       make(map[string]int),
+	}
+	_, err = uploadMissingVectors(context.Background(), st, client, []string{h}, 1, func(string, int, int) {})
+	assert.ErrorContains(t, err, "over quota")
+	assert.Equal(t, 1, client.attempts[h])
+}
+
+func TestUploadMissingVectors_LocalErrorNotRetried(t *testing.T) {
+	// A retry would wait out the backoff until the context expires
+	saved := vectorRetry
+	vectorRetry = &remote.RetryConfig{MaxRetries: 3, InitialBackoff: time.Hour, MaxBackoff: time.Hour}
+	t.Cleanup(func() { vectorRetry = saved })
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	st := newPushTestStore(t)
+
+	client := &flakyUploadClient{pushMockClient: newPushMockClient(), attempts: make(map[string]int)}
+	_, err := uploadMissingVectors(ctx, st, client, []string{"missing"}, 1, func(string, int, int) {})
+	require.ErrorIs(t, err, store.ErrVectorNotFound)
+	assert.NotContains(t, err.Error(), "retry")
+	assert.Empty(t, client.attempts)
+}
+
+// flakyDownloadClient corrupts the first download of every vector.
+type flakyDownloadClient struct {
+	*mockRemoteClient
+	mu        sync.Mutex
+	downloads map[string]int
+}
+
+func (c *flakyDownloadClient) DownloadVector(ctx context.Context, hash string) (io.ReadCloser, int, error) {
+	c.mu.Lock()
+	c.downloads[hash]++
+	first := c.downloads[hash] == 1
+	c.mu.Unlock()
+	if first {
+		v := c.vectorData[hash]
+		return io.NopCloser(bytes.NewReader(append([]byte{1}, v.data[1:]...))), v.dims, nil
+	}
+	return c.mockRemoteClient.DownloadVector(ctx, hash)
+}
+
+func TestDownloadMissingVectors_SaveErrorNotRetried(t *testing.T) {
+	st := newPushTestStore(t)
+
+	data := []byte{0, 0, 128, 63, 0, 0, 0, 64}
+	hash := store.HashVector(data)
+	client := &flakyDownloadClient{
+		mockRemoteClient: &mockRemoteClient{vectorData: map[string]mockVector{hash: {data: data, dims: 2}}},
+		downloads:        make(map[string]int),
+	}
+
+	_, err := downloadMissingVectors(context.Background(), st, client, []string{hash}, 2, func(string, int, int) {})
+	assert.ErrorContains(t, err, "save vector")
+	assert.Equal(t, 1, client.downloads[hash])
+	has, err := st.HasVectorBlob(hash)
+	require.NoError(t, err)
+	assert.False(t, has)
+}
@@ -0,0 +1,142 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/store"
+)
+
+// VectorDiff describes how an object's vector changed. Distances are only
+// set when both vectors exist and have the same number of dimensions.
+type VectorDiff struct {
+	Previous []float32
+	Current  []float32
+	L2       float64 // Euclidean distance
+	Cosine   float64 // cosine distance, 1 - cosine similarity
+	// Comparable is true when L2 and Cosine are set
+	Comparable bool
+}
+
+// DimensionChange is the change of a single vector dimension.
+type DimensionChange struct {
+	Index    int
+	Previous float32
+	Current  float32
+}
+
+// Delta returns the signed change of the dimension.
+func (c DimensionChange) Delta() float64 {
+	return float64(c.Current) - float64(c.Previous)
+}
+
+// ComputeVectorDiff compares the vectors of an updated object. The previous
+// vector is loaded from the vector blob store, falling back to the one in
+// the previous object data when the blob is missing.
+// It returns nil when the vector did not change.
+func ComputeVectorDiff(st *store.Store, change *ObjectChange) (*VectorDiff, error) {
+	if change.VectorHash == change.PreviousVectorHash {
+		return nil, nil
+	}
+
+	previous, err := previousVector(st, change)
+	if err != nil {
+		return nil, err
+	}
+	current, err := objectVector(change.CurrentData)
+	if err != nil {
+		return nil, err
+	}
+	return NewVectorDiff(previous, current), nil
+}
+
+// NewVectorDiff compares two vectors.
+func NewVectorDiff(previous, current []float32) *VectorDiff {
+	d := &VectorDiff{Previous: previous, Current: current}
+	if len(previous) == 0 || len(previous) != len(current) {
+		return d
+	}
+
+	var sumSq, dot, normPrev, normCurr float64
+	for i := range previous {
+		p, c := float64(previous[i]), float64(current[i])
+		sumSq += (c - p) * (c - p)
+		dot += p * c
+		normPrev += p * p
+		normCurr += c * c
+	}
+	d.L2 = math.Sqrt(sumSq)
+	if normPrev > 0 && normCurr > 0 {
+		d.Cosine = 1 - dot/(math.Sqrt(normPrev)*math.Sqrt(normCurr))
+	} else if normPrev != normCurr {
+		d.Cosine = 1 // a zero vector is orthogonal to everything else
+	}
+	d.Comparable = true
+	return d
+}
+
+// TopChanged returns up to n dimensions with the largest absolute change,
+// largest first.
+func (d *VectorDiff) TopChanged(n int) []DimensionChange {
+	if !d.Comparable {
+		return nil
+	}
+	changes := make([]DimensionChange, 0, len(d.Current))
+	for i := range d.Current {
+		if d.Current[i] != d.Previous[i] {
+			changes = append(changes, DimensionChange{Index: i, Previous: d.Previous[i], Current: d.Current[i]})
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		return math.Abs(changes[i].Delta()) > math.Abs(changes[j].Delta())
+	})
+	if len(changes) > n {
+		changes = changes[:n]
+	}
+	return changes
+}
+
+// Deltas returns the absolute change of each dimension, grouped into at most
+// width buckets that each hold the largest change among their dimensions.
+func (d *VectorDiff) Deltas(width int) []float64 {
+	if !d.Comparable || width <= 0 {
+		return nil
+	}
+	dims := len(d.Current)
+	if width > dims {
+		width = dims
+	}
+	buckets := make([]float64, width)
+	for i := range d.Current {
+		b := i * width / dims
+		delta := math.Abs(float64(d.Current[i]) - float64(d.Previous[i]))
+		if delta > buckets[b] {
+			buckets[b] = delta
+		}
+	}
+	return buckets
+}
+
+func previousVector(st *store.Store, change *ObjectChange) ([]float32, error) {
+	if change.PreviousVectorHash != "" {
+		data, dims, err := st.GetVectorBlob(change.PreviousVectorHash)
+		if err == nil {
+			return store.BytesToVector(data, dims)
+		}
+		if !errors.Is(err, store.ErrVectorNotFound) {
+			return nil, fmt.Errorf("load previous vector: %w", err)
+		}
+	}
+	return objectVector(change.PreviousData)
+}
+
+func objectVector(obj *models.WeaviateObject) ([]float32, error) {
+	data, dims, err := store.VectorFromObject(obj)
+	if err != nil {
+		return nil, err
+	}
+	return store.BytesToVector(data, dims)
+}
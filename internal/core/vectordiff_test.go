@@ -0,0 +1,93 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewVectorDiff_Distances(t *testing.T) {
+	d := NewVectorDiff([]float32{1, 0, 0}, []float32{0, 1, 0})
+	require.True(t, d.Comparable)
+	assert.InDelta(t, math.Sqrt2, d.L2, 1e-9)
+	assert.InDelta(t, 1.0, d.Cosine, 1e-9)
+
+	d = NewVectorDiff([]float32{1, 2}, []float32{2, 4})
+	assert.InDelta(t, 0.0, d.Cosine, 1e-9, "scaled vectors point the same way")
+
+	d = NewVectorDiff([]float32{1, 2}, []float32{1, 2, 3})
+	assert.False(t, d.Comparable)
+	assert.Nil(t, d.TopChanged(3))
+}
+
+func TestVectorDiff_TopChangedAndDeltas(t *testing.T) {
+	d := NewVectorDiff([]float32{0, 0, 0, 0}, []float32{0.1, -0.5, 0, 0.2})
+
+	top := d.TopChanged(2)
+	require.Len(t, top, 2)
+	assert.Equal(t, 1, top[0].Index)
+	assert.InDelta(t, -0.5, top[0].Delta(), 1e-6)
+	assert.Equal(t, 3, top[1].Index)
+
+	assert.Len(t, d.TopChanged(10), 3, "unchanged dimensions are left out")
+	deltas := d.Deltas(2)
+	require.Len(t, deltas, 2)
+	assert.InDelta(t, 0.5, deltas[0], 1e-6)
+	assert.InDelta(t, 0.2, deltas[1], 1e-6)
+}
+
+func TestComputeVectorDiff_LoadsPreviousFromBlobStore(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+
+	client.AddClass(&models.WeaviateClass{Class: "Article"})
+	obj := &models.WeaviateObject{
+		ID:         "obj-001",
+		Class:      "Article",
+		Properties: map[string]interface{}{"title": "Test"},
+		Vector:     []float32{1, 0, 0},
+	}
+	client.AddObject(obj)
+	require.NoError(t, UpdateKnownState(ctx, st, client, true))
+
+	// Drop the vector from the known object data so only the blob has it
+	known, err := st.GetAllKnownObjectsWithHashes()
+	require.NoError(t, err)
+	k := known["Article/obj-001"]
+	stripped := *k.Object
+	stripped.Vector = nil
+	data, _ := json.Marshal(&stripped)
+	require.NoError(t, st.SaveKnownObjectWithVector("Article", "obj-001", k.ObjectHash, k.VectorHash, data))
+
+	client.AddObject(&models.WeaviateObject{
+		ID:         "obj-001",
+		Class:      "Article",
+		Properties: map[string]interface{}{"title": "Test"},
+		Vector:     []float32{0, 1, 0},
+	})
+	diff, err := ComputeDiff(ctx, cfg, st, client)
+	require.NoError(t, err)
+	require.Len(t, diff.Updated, 1)
+	require.True(t, diff.Updated[0].VectorOnly)
+
+	vd, err := ComputeVectorDiff(st, diff.Updated[0])
+	require.NoError(t, err)
+	require.NotNil(t, vd)
+	assert.Equal(t, []float32{1, 0, 0}, vd.Previous)
+	assert.Equal(t, []float32{0, 1, 0}, vd.Current)
+	assert.InDelta(t, 1.0, vd.Cosine, 1e-9)
+
+	// Unchanged vectors have no diff
+	unchanged := &ObjectChange{VectorHash: k.VectorHash, PreviousVectorHash: k.VectorHash}
+	vd, err = ComputeVectorDiff(st, unchanged)
+	require.NoError(t, err)
+	assert.Nil(t, vd)
+}
@@ -0,0 +1,112 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/store"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+)
+
+// VerifyMismatch is an object whose live state differs from what a commit recorded.
+type VerifyMismatch struct {
+	ClassName string
+	ObjectID  string
+	Reason    string
+}
+
+// VerifyResult reports how many of a commit's objects were re-read and which
+// of them no longer match the commit.
+type VerifyResult struct {
+	Checked    int
+	Total      int
+	Mismatches []VerifyMismatch
+}
+
+// VerifyCommit re-reads the objects a commit changed from Weaviate and checks
+// them against the recorded payloads and vectors: inserted and updated
+// objects must match, deleted objects must be gone. With sample > 0 only that
+// many randomly chosen objects are read. A mismatch means Weaviate was written
+// to while the commit was being recorded.
+func VerifyCommit(ctx context.Context, st *store.Store, client weaviate.ClientInterface, commitID string, sample int) (*VerifyResult, error) {
+	ops, err := st.GetOperationsByCommit(commitID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The last operation on an object is its state at the commit
+	latest := make(map[string]*models.Operation)
+	var keys []string
+	for _, op := range ops {
+		key := models.ObjectKey(op.ClassName, op.ObjectID)
+		if _, ok := latest[key]; !ok {
+			keys = append(keys, key)
+		}
+		latest[key] = op
+	}
+
+	result := &VerifyResult{Total: len(keys)}
+	if sample > 0 && sample < len(keys) {
+		picked := make([]string, sample)
+		for i, j := range rand.Perm(len(keys))[:sample] {
+			picked[i] = keys[j]
+		}
+		keys = picked
+	}
+
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		op := latest[key]
+		reason, err := verifyOperation(ctx, client, op)
+		if err != nil {
+			return nil, err
+		}
+		result.Checked++
+		if reason != "" {
+			result.Mismatches = append(result.Mismatches, VerifyMismatch{
+				ClassName: op.ClassName,
+				ObjectID:  op.ObjectID,
+				Reason:    reason,
+			})
+		}
+	}
+	return result, nil
+}
+
+// verifyOperation returns why the live object does not match op, or "" if it does.
+func verifyOperation(ctx context.Context, client weaviate.ClientInterface, op *models.Operation) (string, error) {
+	live, getErr := client.GetObject(ctx, op.ClassName, op.ObjectID)
+
+	if op.Type == models.OperationDelete {
+		// Weaviate reports a missing object as an error
+		if getErr == nil && live != nil {
+			return "deleted object still exists", nil
+		}
+		return "", nil
+	}
+
+	if getErr != nil || live == nil {
+		return "object is missing", nil
+	}
+	var recorded models.WeaviateObject
+	if err := json.Unmarshal(op.ObjectData, &recorded); err != nil {
+		return "", fmt.Errorf("unmarshal recorded object %s/%s: %w", op.ClassName, op.ObjectID, err)
+	}
+	wantObject, wantVector := weaviate.HashObjectFull(&recorded)
+	if wantVector == "" {
+		wantVector = op.VectorHash
+	}
+	gotObject, gotVector := weaviate.HashObjectFull(live)
+	switch {
+	case gotObject != wantObject:
+		return "properties differ from the recorded payload", nil
+	case gotVector != wantVector:
+		return "vector differs from the recorded vector", nil
+	}
+	return "", nil
+}
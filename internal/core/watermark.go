@@ -0,0 +1,84 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/store"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+)
+
+// ErrDatabaseChanged is returned when Weaviate was written to between scanning
+// for changes and recording them, so the scan may have seen a torn state.
+var ErrDatabaseChanged = errors.New("database changed during commit")
+
+// Watermarks are the per-class write watermarks of the classes in scope,
+// taken before a scan so the scan can be checked for concurrent writes.
+type Watermarks struct {
+	scope   ClassScope
+	classes map[string]models.ClassWatermark
+}
+
+// TakeWatermarks records the watermark of every class the current branch tracks.
+func TakeWatermarks(ctx context.Context, st *store.Store, client weaviate.ClientInterface) (*Watermarks, error) {
+	scope, err := currentScope(st)
+	if err != nil {
+		return nil, err
+	}
+	classes, err := readWatermarks(ctx, client, scope)
+	if err != nil {
+		return nil, err
+	}
+	return &Watermarks{scope: scope, classes: classes}, nil
+}
+
+// Check reads the watermarks again and returns an error wrapping
+// ErrDatabaseChanged, naming the classes, if any class was written to or
+// created or dropped since they were taken.
+func (w *Watermarks) Check(ctx context.Context, client weaviate.ClientInterface) error {
+	current, err := readWatermarks(ctx, client, w.scope)
+	if err != nil {
+		return err
+	}
+	var changed []string
+	for class, before := range w.classes {
+		if after, ok := current[class]; !ok || after != before {
+			changed = append(changed, class)
+		}
+	}
+	for class := range current {
+		if _, ok := w.classes[class]; !ok {
+			changed = append(changed, class)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	sort.Strings(changed)
+	return fmt.Errorf("%w: %s written to by another client; nothing was committed, retry once other writers have finished",
+		ErrDatabaseChanged, strings.Join(changed, ", "))
+}
+
+// readWatermarks fetches the watermark of each class in scope.
+func readWatermarks(ctx context.Context, client weaviate.ClientInterface, scope ClassScope) (map[string]models.ClassWatermark, error) {
+	classes, err := versionedClasses(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	watermarks := make(map[string]models.ClassWatermark, len(classes))
+	for _, class := range classes {
+		if !scope.Includes(class) {
+			continue
+		}
+		wm, err := client.GetClassWatermark(ctx, class)
+		if err != nil {
+			return nil, fmt.Errorf("read watermark of %s: %w", class, err)
+		}
+		watermarks[class] = *wm
+	}
+	return watermarks, nil
+}
@@ -0,0 +1,261 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/config"
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/store"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+)
+
+// WorktreeAddOptions configures AddWorktree
+type WorktreeAddOptions struct {
+	Path        string // directory to create the worktree's .wvc in
+	Name        string // defaults to the base name of Path
+	WeaviateURL string // the instance the worktree checks out into
+	Target      string // branch or commit to check out; defaults to HEAD
+	NewBranch   string // create this branch at Target and check it out
+	Detach      bool   // check out Target with a detached HEAD
+	Force       bool   // replace the contents of a non-empty instance
+}
+
+// WorktreeAddResult describes a worktree created by AddWorktree
+type WorktreeAddResult struct {
+	Worktree *models.Worktree
+	Checkout *CheckoutResult
+}
+
+// AddWorktree links another Weaviate instance to the repository's history as
+// a worktree with its own HEAD, branch, staging area, and known state, and
+// checks out the target into it. client must be connected to the new
+// instance. Without a target, branch, or --detach, a branch named after the
+// worktree is created at HEAD, so the worktree never shares a checked-out
+// branch with another one.
+func AddWorktree(ctx context.Context, cfg *config.Config, st *store.Store, client weaviate.ClientInterface, opts WorktreeAddOptions) (*WorktreeAddResult, error) {
+	if opts.Path == "" || opts.WeaviateURL == "" {
+		return nil, fmt.Errorf("worktree path and Weaviate URL are required")
+	}
+	path, err := filepath.Abs(opts.Path)
+	if err != nil {
+		return nil, err
+	}
+	name := opts.Name
+	if name == "" {
+		name = filepath.Base(path)
+	}
+	if err := validateWorktreeName(name); err != nil {
+		return nil, err
+	}
+	if existing, err := st.GetWorktree(name); err != nil {
+		return nil, err
+	} else if existing != nil {
+		return nil, fmt.Errorf("worktree '%s' already exists at %s", name, existing.Path)
+	}
+
+	target := opts.Target
+	if target == "" {
+		target = "HEAD"
+		if opts.NewBranch == "" && !opts.Detach {
+			opts.NewBranch = name
+		}
+	}
+	commitID, branchName, err := ResolveRef(st, target)
+	if err != nil {
+		return nil, err
+	}
+	if commitID == "" {
+		return nil, fmt.Errorf("cannot add worktree: no commits yet")
+	}
+	switch {
+	case opts.Detach:
+		branchName = ""
+	case opts.NewBranch != "":
+		exists, err := st.BranchExists(opts.NewBranch)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, fmt.Errorf("branch '%s' already exists", opts.NewBranch)
+		}
+	case branchName != "":
+		if holder, err := worktreeWithBranch(st, branchName, false); err != nil {
+			return nil, err
+		} else if holder != "" {
+			return nil, fmt.Errorf("branch '%s' is already checked out in %s", branchName, holder)
+		}
+	}
+
+	if !opts.Force {
+		classes, err := client.GetClasses(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list classes of %s: %w", opts.WeaviateURL, err)
+		}
+		if len(classes) > 0 {
+			return nil, fmt.Errorf("Weaviate at %s is not empty; use --force to replace its contents", opts.WeaviateURL)
+		}
+	}
+
+	commonDir := cfg.CommonDir
+	if commonDir == "" {
+		commonDir = cfg.WVCPath()
+	}
+	wtCfg, err := config.InitializeWorktree(path, opts.WeaviateURL, commonDir, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create worktree at %s: %w", path, err)
+	}
+	// The worktree keeps connecting with the credentials it was created with
+	if cfg.Weaviate != nil {
+		wtCfg.Weaviate = cfg.Weaviate
+		if err := wtCfg.Save(); err != nil {
+			os.RemoveAll(filepath.Join(path, config.WVCDir))
+			return nil, err
+		}
+	}
+	wt := &models.Worktree{Name: name, Path: path, WeaviateURL: opts.WeaviateURL, CreatedAt: time.Now()}
+	if err := st.CreateWorktree(wt); err != nil {
+		os.RemoveAll(filepath.Join(path, config.WVCDir))
+		return nil, err
+	}
+
+	createBranch := false
+	if opts.NewBranch != "" && !opts.Detach {
+		branchName, createBranch = opts.NewBranch, true
+	}
+
+	wst := st.WithWorktree(name)
+	result := &CheckoutResult{TargetCommit: commitID, BranchName: branchName, IsDetached: branchName == "", Warnings: []CheckoutWarning{}}
+	scope, err := branchScope(wst, branchName)
+	if err != nil {
+		return nil, err
+	}
+	warnings, stats, err := restoreStateToCommit(ctx, wtCfg, wst, client, commitID, scope)
+	if err != nil {
+		return nil, fmt.Errorf("worktree '%s' created but checkout failed: %w", name, err)
+	}
+	result.Warnings = append(result.Warnings, warnings...)
+	result.ObjectsAdded, result.ObjectsRemoved, result.ObjectsUpdated = stats.Added, stats.Removed, stats.Updated
+	if _, err := finishCheckout(wst, commitID, branchName, createBranch, result); err != nil {
+		return nil, err
+	}
+
+	wt.HEAD, wt.Branch = commitID, branchName
+	return &WorktreeAddResult{Worktree: wt, Checkout: result}, nil
+}
+
+// ListWorktrees returns the main worktree, with an empty name, followed by
+// the linked worktrees, each with its HEAD and branch.
+func ListWorktrees(cfg *config.Config, st *store.Store) ([]*models.Worktree, error) {
+	mainDir := cfg.WVCPath()
+	mainURL := cfg.WeaviateURL
+	if cfg.IsLinkedWorktree() {
+		mainDir = cfg.CommonDir
+		if mainCfg, err := config.LoadDir(cfg.CommonDir); err == nil {
+			mainURL = mainCfg.WeaviateURL
+		}
+	}
+	mainStore := st.WithWorktree("")
+	head, err := mainStore.GetHEAD()
+	if err != nil {
+		return nil, err
+	}
+	branch, err := mainStore.GetCurrentBranch()
+	if err != nil {
+		return nil, err
+	}
+	worktrees := []*models.Worktree{{Path: filepath.Dir(mainDir), WeaviateURL: mainURL, HEAD: head, Branch: branch}}
+
+	linked, err := st.ListWorktrees()
+	if err != nil {
+		return nil, err
+	}
+	return append(worktrees, linked...), nil
+}
+
+// RemoveWorktree unlinks a worktree: its local state and .wvc directory are
+// deleted. The Weaviate instance is left as it is. Without force, a worktree
+// with staged changes or a merge in progress is kept.
+func RemoveWorktree(st *store.Store, name string, force bool) error {
+	if name == st.Worktree() {
+		return fmt.Errorf("cannot remove the current worktree")
+	}
+	wt, err := st.GetWorktree(name)
+	if err != nil {
+		return err
+	}
+	if wt == nil {
+		return fmt.Errorf("worktree '%s' not found", name)
+	}
+
+	if !force {
+		wst := st.WithWorktree(name)
+		staged, err := wst.GetStagedChangesCount()
+		if err != nil {
+			return err
+		}
+		if staged > 0 {
+			return fmt.Errorf("worktree '%s' has %d staged change(s); use --force to remove it anyway", name, staged)
+		}
+		if state, err := wst.GetMergeState(); err != nil {
+			return err
+		} else if state != nil {
+			return fmt.Errorf("worktree '%s' has a merge in progress; use --force to remove it anyway", name)
+		}
+	}
+
+	if err := st.DeleteWorktree(name); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(filepath.Join(wt.Path, config.WVCDir)); err != nil {
+		return fmt.Errorf("worktree unlinked but failed to delete %s: %w", filepath.Join(wt.Path, config.WVCDir), err)
+	}
+	return nil
+}
+
+// branchCheckedOutElsewhere describes the worktree other than st's that has
+// branch checked out, or returns "" when there is none
+func branchCheckedOutElsewhere(st *store.Store, branch string) (string, error) {
+	return worktreeWithBranch(st, branch, true)
+}
+
+// worktreeWithBranch describes the worktree that has branch checked out, or
+// returns "" when there is none. skipCurrent ignores st's own worktree.
+func worktreeWithBranch(st *store.Store, branch string, skipCurrent bool) (string, error) {
+	if !skipCurrent || st.Worktree() != "" {
+		current, err := st.WithWorktree("").GetCurrentBranch()
+		if err != nil {
+			return "", err
+		}
+		if current == branch {
+			return "the main worktree", nil
+		}
+	}
+	worktrees, err := st.ListWorktrees()
+	if err != nil {
+		return "", err
+	}
+	for _, wt := range worktrees {
+		if skipCurrent && wt.Name == st.Worktree() {
+			continue
+		}
+		if wt.Branch == branch {
+			return fmt.Sprintf("worktree '%s' (%s)", wt.Name, wt.Path), nil
+		}
+	}
+	return "", nil
+}
+
+func validateWorktreeName(name string) error {
+	if name == "" || name == "." || name == ".." {
+		return fmt.Errorf("invalid worktree name '%s'", name)
+	}
+	if strings.ContainsAny(name, "/\\:@ \t") {
+		return fmt.Errorf("invalid worktree name '%s': must not contain '/', '\\', ':', '@', or whitespace", name)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/weaviate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddWorktree_ChecksOutIntoSeparateInstance(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+
+	client.AddClass(&models.WeaviateClass{Class: "Article"})
+	client.AddObject(&models.WeaviateObject{ID: "obj-1", Class: "Article", Properties: map[string]interface{}{"title": "Original"}})
+	base, err := CreateCommit(ctx, cfg, st, client, "Initial commit")
+	require.NoError(t, err)
+
+	_, err = Checkout(ctx, cfg, st, client, "proposal", CheckoutOptions{CreateBranch: true, NewBranchName: "proposal"})
+	require.NoError(t, err)
+	client.AddObject(&models.WeaviateObject{ID: "obj-2", Class: "Article", Properties: map[string]interface{}{"title": "Proposed"}})
+	proposal, err := CreateCommit(ctx, cfg, st, client, "Propose article")
+	require.NoError(t, err)
+	_, err = Checkout(ctx, cfg, st, client, "main", CheckoutOptions{})
+	require.NoError(t, err)
+
+	scratch := weaviate.NewMockClient()
+	dir := filepath.Join(t.TempDir(), "review")
+	result, err := AddWorktree(ctx, cfg, st, scratch, WorktreeAddOptions{Path: dir, WeaviateURL: "http://scratch:8080", Target: "proposal"})
+	require.NoError(t, err)
+	assert.Equal(t, "review", result.Worktree.Name)
+	assert.Equal(t, "proposal", result.Worktree.Branch)
+	assert.Equal(t, proposal.ID, result.Worktree.HEAD)
+	assert.Contains(t, scratch.Objects, "Article/obj-2")
+	assert.FileExists(t, filepath.Join(dir, ".wvc", "config"))
+
+	// Main keeps its own HEAD, branch, and instance
+	head, err := st.GetHEAD()
+	require.NoError(t, err)
+	assert.Equal(t, base.ID, head)
+	assert.NotContains(t, client.Objects, "Article/obj-2")
+
+	// The worktree's known state matches its instance
+	wst := st.WithWorktree("review")
+	diff, err := ComputeIncrementalDiff(ctx, cfg, wst, scratch)
+	require.NoError(t, err)
+	assert.Equal(t, 0, diff.TotalStagedChanges()+diff.TotalUnstagedChanges())
+
+	// A branch checked out in one worktree can't be checked out or deleted in another
+	_, err = Checkout(ctx, cfg, st, client, "proposal", CheckoutOptions{})
+	assert.ErrorContains(t, err, "already checked out in worktree 'review'")
+	assert.ErrorContains(t, DeleteBranch(st, "proposal", true), "checked out in worktree 'review'")
+	_, err = Checkout(ctx, cfg, wst, scratch, "main", CheckoutOptions{})
+	assert.ErrorContains(t, err, "already checked out in the main worktree")
+
+	worktrees, err := ListWorktrees(cfg, st)
+	require.NoError(t, err)
+	require.Len(t, worktrees, 2)
+	assert.Equal(t, "main", worktrees[0].Branch)
+	assert.Equal(t, "review", worktrees[1].Name)
+
+	require.NoError(t, RemoveWorktree(st, "review", false))
+	_, err = os.Stat(filepath.Join(dir, ".wvc"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = Checkout(ctx, cfg, st, client, "proposal", CheckoutOptions{})
+	assert.NoError(t, err)
+}
+
+func TestAddWorktree_Validation(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	cfg := newTestConfig()
+	client := weaviate.NewMockClient()
+
+	client.AddClass(&models.WeaviateClass{Class: "Article"})
+	client.AddObject(&models.WeaviateObject{ID: "obj-1", Class: "Article", Properties: map[string]interface{}{"title": "A"}})
+	_, err := CreateCommit(ctx, cfg, st, client, "Initial commit")
+	require.NoError(t, err)
+
+	// The current branch is in use, so the default is a new branch named after the worktree
+	result, err := AddWorktree(ctx, cfg, st, weaviate.NewMockClient(), WorktreeAddOptions{Path: filepath.Join(t.TempDir(), "hotfix"), WeaviateURL: "http://a"})
+	require.NoError(t, err)
+	assert.Equal(t, "hotfix", result.Worktree.Branch)
+
+	_, err = AddWorktree(ctx, cfg, st, weaviate.NewMockClient(), WorktreeAddOptions{Path: filepath.Join(t.TempDir(), "other"), WeaviateURL: "http://b", Target: "main"})
+	assert.ErrorContains(t, err, "already checked out in the main worktree")
+
+	nonEmpty := weaviate.NewMockClient()
+	nonEmpty.AddClass(&models.WeaviateClass{Class: "Live"})
+	_, err = AddWorktree(ctx, cfg, st, nonEmpty, WorktreeAddOptions{Path: filepath.Join(t.TempDir(), "live"), WeaviateURL: "http://c", Detach: true})
+	assert.ErrorContains(t, err, "not empty")
+
+	_, err = AddWorktree(ctx, cfg, st, weaviate.NewMockClient(), WorktreeAddOptions{Path: t.TempDir(), Name: "bad/name", WeaviateURL: "http://d"})
+	assert.ErrorContains(t, err, "invalid worktree name")
+}
@@ -0,0 +1,50 @@
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// errCloneUnsupported is returned by cloneFile when the platform or
+// filesystem cannot share data blocks between files
+var errCloneUnsupported = errors.New("copy-on-write clones not supported")
+
+// CopyFile copies src to a new file dst. Where the filesystem supports it
+// (Btrfs, XFS, and other reflink filesystems on Linux; APFS on macOS) the
+// copy is a copy-on-write clone that shares data blocks with src until
+// either file is written; cloned reports whether that happened. Otherwise
+// the bytes are copied. dst must not exist.
+func CopyFile(src, dst string, policy SyncPolicy) (cloned bool, err error) {
+	if err := cloneFile(src, dst); err == nil {
+		return true, nil
+	} else if !errors.Is(err, errCloneUnsupported) {
+		return false, err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return false, err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return false, err
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return false, err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return false, fmt.Errorf("copy %s: %w", src, err)
+	}
+	if err := Fsync(out, policy); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return false, err
+	}
+	return false, out.Close()
+}
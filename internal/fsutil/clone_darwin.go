@@ -0,0 +1,17 @@
+package fsutil
+
+import (
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// cloneFile creates dst as an APFS clone of src.
+func cloneFile(src, dst string) error {
+	err := unix.Clonefile(src, dst, unix.CLONE_NOFOLLOW)
+	// Filesystems other than APFS, and files on different volumes
+	if errors.Is(err, unix.ENOTSUP) || errors.Is(err, unix.EXDEV) {
+		return errCloneUnsupported
+	}
+	return err
+}
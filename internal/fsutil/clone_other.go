@@ -0,0 +1,8 @@
+//go:build !linux && !darwin
+
+package fsutil
+
+// cloneFile is unsupported; CopyFile copies the bytes instead.
+func cloneFile(src, dst string) error {
+	return errCloneUnsupported
+}
@@ -0,0 +1,190 @@
+// Package fsutil holds the filesystem primitives shared by the local store
+// and the filesystem blob store, hardened for Windows and for
+// case-insensitive filesystems such as the macOS default.
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SyncPolicy controls how much a writer fsyncs before it reports a file as
+// written. Weaker policies trade crash durability for speed on slow disks.
+type SyncPolicy string
+
+const (
+	// SyncNone never fsyncs; a crash can lose recently written files
+	SyncNone SyncPolicy = "none"
+	// SyncFile fsyncs file contents before they are renamed into place
+	SyncFile SyncPolicy = "file"
+	// SyncFull also fsyncs the directory after a rename, so the new name
+	// survives a crash. Windows cannot sync directories; there it is SyncFile.
+	SyncFull SyncPolicy = "full"
+)
+
+// ParseSyncPolicy parses a sync policy name; empty selects def.
+func ParseSyncPolicy(name string, def SyncPolicy) (SyncPolicy, error) {
+	switch p := SyncPolicy(strings.ToLower(strings.TrimSpace(name))); p {
+	case "":
+		return def, nil
+	case SyncNone, SyncFile, SyncFull:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown fsync policy %q (want none, file, or full)", name)
+	}
+}
+
+// SyncsFiles reports whether the policy fsyncs file contents.
+func (p SyncPolicy) SyncsFiles() bool {
+	return p == SyncFile || p == SyncFull
+}
+
+// LongPath returns path made absolute and, on Windows, in the \\?\ form that
+// lifts the 260-character MAX_PATH limit. Paths derived from the result by
+// filepath.Join keep the form.
+func LongPath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return longPath(abs), nil
+}
+
+// renameRetries and renameBackoff bound how long Rename and Remove wait for
+// another process, typically a virus scanner or indexer, to release a file.
+const (
+	renameRetries = 8
+	renameBackoff = 10 * time.Millisecond
+)
+
+// Rename atomically replaces newpath with oldpath. On Windows a rename fails
+// with a sharing violation while another process has either file open, so
+// it is retried with backoff before giving up.
+func Rename(oldpath, newpath string) error {
+	return retrySharing(func() error { return os.Rename(oldpath, newpath) })
+}
+
+// Remove removes a file, retrying sharing violations like Rename. A missing
+// file is not an error.
+func Remove(path string) error {
+	err := retrySharing(func() error { return os.Remove(path) })
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// retrySharing runs op until it succeeds, fails with an error other than a
+// sharing violation, or runs out of retries.
+func retrySharing(op func() error) error {
+	backoff := renameBackoff
+	var err error
+	for attempt := 0; attempt < renameRetries; attempt++ {
+		if err = op(); err == nil || !isSharingViolation(err) {
+			return err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+	return err
+}
+
+// ErrLockTimeout is returned by LockFile when another holder keeps the lock
+// past the timeout.
+var ErrLockTimeout = errors.New("timed out waiting for file lock")
+
+// lockPoll is how often LockFile retries a held lock.
+const lockPoll = 20 * time.Millisecond
+
+// LockFile takes an exclusive advisory lock on the file at path, creating it
+// if needed, and returns the function that releases it. It waits up to
+// timeout while another process or another LockFile call holds the lock.
+// The lock is released if the process exits.
+func LockFile(path string, timeout time.Duration) (unlock func() error, err error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		locked, err := tryLock(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("lock %s: %w", path, err)
+		}
+		if locked {
+			break
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("lock %s: %w", path, ErrLockTimeout)
+		}
+		time.Sleep(lockPoll)
+	}
+	return func() error {
+		return errors.Join(unlockFile(f), f.Close())
+	}, nil
+}
+
+// Fsync flushes an open file when the policy syncs files.
+func Fsync(f *os.File, policy SyncPolicy) error {
+	if !policy.SyncsFiles() {
+		return nil
+	}
+	return f.Sync()
+}
+
+// FsyncDir flushes a directory entry so a rename survives a crash, when the
+// policy asks for it. Best effort: not every platform supports syncing
+// directories.
+func FsyncDir(dir string, policy SyncPolicy) {
+	if policy != SyncFull {
+		return
+	}
+	syncDir(dir)
+}
+
+// CaseInsensitive reports whether the filesystem holding dir treats names
+// differing only in case as the same file, by creating a probe file and
+// looking it up under an upper-cased name.
+func CaseInsensitive(dir string) (bool, error) {
+	f, err := os.CreateTemp(dir, ".case-probe-*")
+	if err != nil {
+		return false, fmt.Errorf("probe filesystem case sensitivity: %w", err)
+	}
+	name := f.Name()
+	_ = f.Close()
+	defer os.Remove(name)
+
+	upper := filepath.Join(filepath.Dir(name), strings.ToUpper(filepath.Base(name)))
+	if _, err := os.Stat(upper); err == nil {
+		return true, nil
+	} else if !os.IsNotExist(err) {
+		return false, fmt.Errorf("probe filesystem case sensitivity: %w", err)
+	}
+	return false, nil
+}
+
+// CaseCollision returns the name of another entry of dir that differs from
+// name only in case, or "" when there is none. On a case-insensitive
+// filesystem such an entry answers lookups for name, so a file written
+// under name would silently resolve to it.
+func CaseCollision(dir, name string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	for _, entry := range entries {
+		if entry.Name() != name && strings.EqualFold(entry.Name(), name) {
+			return entry.Name(), nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,44 @@
+//go:build !windows
+
+package fsutil
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// longPath returns abs unchanged; only Windows limits path length.
+func longPath(abs string) string {
+	return abs
+}
+
+// isSharingViolation is always false: POSIX renames do not fail on open files.
+func isSharingViolation(error) bool {
+	return false
+}
+
+// syncDir flushes a directory entry. Best effort.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	_ = d.Sync()
+	_ = d.Close()
+}
+
+// tryLock takes an exclusive flock on f without waiting, reporting false if
+// another open file holds it.
+func tryLock(f *os.File) (bool, error) {
+	err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// unlockFile releases the flock taken by tryLock.
+func unlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
@@ -0,0 +1,113 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSyncPolicy(t *testing.T) {
+	for name, want := range map[string]SyncPolicy{
+		"":       SyncFile,
+		"none":   SyncNone,
+		"File":   SyncFile,
+		" full ": SyncFull,
+	} {
+		got, err := ParseSyncPolicy(name, SyncFile)
+		require.NoError(t, err, name)
+		assert.Equal(t, want, got, name)
+	}
+
+	_, err := ParseSyncPolicy("always", SyncFile)
+	assert.ErrorContains(t, err, "unknown fsync policy")
+
+	assert.False(t, SyncNone.SyncsFiles())
+	assert.True(t, SyncFull.SyncsFiles())
+}
+
+func TestLongPath_IsAbsolute(t *testing.T) {
+	path, err := LongPath(filepath.Join("a", "b"))
+	require.NoError(t, err)
+	assert.True(t, filepath.IsAbs(path))
+	assert.Equal(t, filepath.Join("a", "b"), filepath.Join(filepath.Base(filepath.Dir(path)), filepath.Base(path)))
+}
+
+func TestRenameAndRemove(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "dst")
+	require.NoError(t, os.WriteFile(src, []byte("new"), 0644))
+	require.NoError(t, os.WriteFile(dst, []byte("old"), 0644))
+
+	require.NoError(t, Rename(src, dst))
+	data, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(data))
+
+	require.NoError(t, Remove(dst))
+	require.NoError(t, Remove(dst), "a missing file is not an error")
+}
+
+func TestLockFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db.lock")
+
+	unlock, err := LockFile(path, time.Second)
+	require.NoError(t, err)
+	_, err = LockFile(path, 50*time.Millisecond)
+	assert.ErrorIs(t, err, ErrLockTimeout, "the lock is exclusive")
+
+	require.NoError(t, unlock())
+	unlock, err = LockFile(path, 50*time.Millisecond)
+	require.NoError(t, err)
+	require.NoError(t, unlock())
+}
+
+func TestCaseCollision(t *testing.T) {
+	dir := t.TempDir()
+	insensitive, err := CaseInsensitive(dir)
+	require.NoError(t, err)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "the probe file is removed")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ABCDEF"), nil, 0644))
+	if !insensitive {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "abcdef"), nil, 0644))
+	}
+	other, err := CaseCollision(dir, "abcdef")
+	require.NoError(t, err)
+	assert.Equal(t, "ABCDEF", other)
+
+	other, err = CaseCollision(dir, "012345")
+	require.NoError(t, err)
+	assert.Empty(t, other)
+	other, err = CaseCollision(filepath.Join(dir, "missing"), "abcdef")
+	require.NoError(t, err)
+	assert.Empty(t, other)
+}
+
+func TestCopyFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "dst")
+	require.NoError(t, os.WriteFile(src, []byte("vectors"), 0600))
+
+	_, err := CopyFile(src, dst, SyncNone)
+	require.NoError(t, err)
+	data, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, "vectors", string(data))
+
+	// Writing the copy leaves the original alone, cloned or not
+	require.NoError(t, os.WriteFile(dst, []byte("changed"), 0600))
+	data, err = os.ReadFile(src)
+	require.NoError(t, err)
+	assert.Equal(t, "vectors", string(data))
+
+	_, err = CopyFile(src, dst, SyncNone)
+	assert.Error(t, err, "dst must not exist")
+}
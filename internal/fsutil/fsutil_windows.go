@@ -0,0 +1,52 @@
+//go:build windows
+
+package fsutil
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"golang.org/x/sys/windows"
+)
+
+// longPath prefixes an absolute path with \\?\ (\\?\UNC\ for shares).
+func longPath(abs string) string {
+	switch {
+	case strings.HasPrefix(abs, `\\?\`):
+		return abs
+	case strings.HasPrefix(abs, `\\`):
+		return `\\?\UNC\` + abs[2:]
+	default:
+		return `\\?\` + abs
+	}
+}
+
+// isSharingViolation reports whether err comes from another process holding
+// the file open. Replacing a file that is open without FILE_SHARE_DELETE
+// fails with access denied rather than a sharing violation.
+func isSharingViolation(err error) bool {
+	return errors.Is(err, windows.ERROR_SHARING_VIOLATION) ||
+		errors.Is(err, windows.ERROR_LOCK_VIOLATION) ||
+		errors.Is(err, windows.ERROR_ACCESS_DENIED)
+}
+
+// syncDir does nothing: Windows cannot open a directory for flushing, and
+// NTFS journals renames.
+func syncDir(string) {}
+
+// tryLock locks the first byte of f exclusively without waiting, reporting
+// false if another handle holds it.
+func tryLock(f *os.File) (bool, error) {
+	ol := new(windows.Overlapped)
+	err := windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0, ol)
+	if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// unlockFile releases the lock taken by tryLock.
+func unlockFile(f *os.File) error {
+	return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, new(windows.Overlapped))
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// ApplyAction identifies the Weaviate write made for an object while applying a commit's state
+type ApplyAction string
+
+const (
+	ApplyCreate ApplyAction = "create"
+	ApplyUpdate ApplyAction = "update"
+	ApplyDelete ApplyAction = "delete"
+)
+
+// FailedObject is an object whose write failed while applying a commit's state
+type FailedObject struct {
+	ClassName string      `json:"class_name"`
+	ObjectID  string      `json:"object_id"`
+	Action    ApplyAction `json:"action"`
+	Error     string      `json:"error"`
+	Attempts  int         `json:"attempts"`
+}
+
+// Key returns the object's "ClassName/ObjectID" key
+func (f *FailedObject) Key() string {
+	return ObjectKey(f.ClassName, f.ObjectID)
+}
+
+// ApplyProgress records an apply of a commit's state to Weaviate that left
+// some objects unwritten, so the failed writes can be retried later
+type ApplyProgress struct {
+	CommitID  string          `json:"commit_id"` // commit whose state was being applied
+	Applied   int             `json:"applied"`   // object writes that succeeded
+	Failed    []*FailedObject `json:"failed"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
@@ -0,0 +1,12 @@
+package models
+
+// BisectState is the persisted state of a bisect session, like git's
+// refs/bisect/*. It lives until the session is reset.
+type BisectState struct {
+	// Original is the branch, or the commit when HEAD was detached, that
+	// was checked out when the session started; reset returns to it.
+	Original string   `json:"original"`
+	Bad      string   `json:"bad,omitempty"`
+	Good     []string `json:"good,omitempty"`
+	Skipped  []string `json:"skipped,omitempty"`
+}
@@ -7,4 +7,14 @@ type Branch struct {
 	Name      string    `json:"name"`
 	CommitID  string    `json:"commit_id"`
 	CreatedAt time.Time `json:"created_at"`
+	// Classes limits the branch to the listed classes; empty means all classes
+	Classes []string `json:"classes,omitempty"`
+}
+
+// ReflogEntry records one move of a branch or HEAD. Old is empty when the
+// ref was created and New when it was deleted.
+type ReflogEntry struct {
+	Old  string    `json:"old,omitempty"`
+	New  string    `json:"new,omitempty"`
+	Time time.Time `json:"time"`
 }
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"sort"
+	"strings"
+)
+
+// PII levels of a class, from least to most sensitive.
+const (
+	PIINone       = "none"
+	PIIInternal   = "internal"
+	PIIRestricted = "restricted"
+)
+
+// ValidPII reports whether level is a known PII level.
+func ValidPII(level string) bool {
+	switch level {
+	case PIINone, PIIInternal, PIIRestricted:
+		return true
+	}
+	return false
+}
+
+// Classification labels the data of a class with the license it may be used
+// under and how sensitive the personal data in it is. Classes tagged
+// pii:restricted may only be pushed to remotes approved for personal data.
+type Classification struct {
+	License string `json:"license,omitempty"`
+	PII     string `json:"pii,omitempty"`
+}
+
+// Restricted reports whether the class holds restricted personal data.
+func (c *Classification) Restricted() bool {
+	return c != nil && c.PII == PIIRestricted
+}
+
+// String formats the classification as tags, e.g. "license:CC-BY-4.0 pii:restricted".
+func (c *Classification) String() string {
+	var tags []string
+	if c.License != "" {
+		tags = append(tags, "license:"+c.License)
+	}
+	if c.PII != "" {
+		tags = append(tags, "pii:"+c.PII)
+	}
+	return strings.Join(tags, " ")
+}
+
+// RestrictedClasses returns the classes the commit records as holding
+// restricted personal data, sorted.
+func (c *Commit) RestrictedClasses() []string {
+	var classes []string
+	for class, cl := range c.Classifications {
+		if cl.Restricted() {
+			classes = append(classes, class)
+		}
+	}
+	sort.Strings(classes)
+	return classes
+}
@@ -8,8 +8,35 @@ type Commit struct {
 	ParentID       string    `json:"parent_id,omitempty"`
 	MergeParentID  string    `json:"merge_parent_id,omitempty"`
 	Message        string    `json:"message"`
+	Author         string    `json:"author,omitempty"` // "Name <email>"; part of the CommitHashV2 ID when set
 	Timestamp      time.Time `json:"timestamp"`
 	OperationCount int       `json:"operation_count"`
+	// HashVersion is the algorithm the ID was generated with (see
+	// CommitHashV1, CommitHashV2); zero means CommitHashV1.
+	HashVersion int `json:"hash_version,omitempty"`
+	// ParentSummaries records, for merge commits, how the merged state
+	// differs from each parent. Order matches ParentID, MergeParentID.
+	ParentSummaries []ParentChangeSummary `json:"parent_summaries,omitempty"`
+	// Submodules pins each referenced repository, by submodule name, to the
+	// commit of it this commit uses. Part of the CommitHashV2 ID when set.
+	Submodules map[string]string `json:"submodules,omitempty"`
+	// Classifications records, by class, the license and PII tags the
+	// repository config gave the classes the commit's operations touch.
+	// Part of the CommitHashV2 ID when set.
+	Classifications map[string]*Classification `json:"classifications,omitempty"`
+}
+
+// ParentChangeSummary counts the object changes between a parent and a merge commit
+type ParentChangeSummary struct {
+	ParentID string `json:"parent_id"`
+	Added    int    `json:"added"`
+	Updated  int    `json:"updated"`
+	Deleted  int    `json:"deleted"`
+}
+
+// Total returns the total number of changed objects
+func (s ParentChangeSummary) Total() int {
+	return s.Added + s.Updated + s.Deleted
 }
 
 // ShortID returns a shortened commit ID (first 7 characters)
@@ -24,3 +51,24 @@ func (c *Commit) ShortID() string {
 func (c *Commit) IsMergeCommit() bool {
 	return c.MergeParentID != ""
 }
+
+// HistorySize returns the number of commits in a graph, given as the parents
+// of each commit it holds, that id is or descends from. Replaying a
+// topological path up to id gives exactly id's state when this equals id's
+// position on the path plus one, so clients and the server use it alike to
+// decide where state snapshots may be taken and reused.
+func HistorySize(parents map[string][]string, id string) int {
+	seen := map[string]bool{id: true}
+	queue := []string{id}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, p := range parents[current] {
+			if !seen[p] {
+				seen[p] = true
+				queue = append(queue, p)
+			}
+		}
+	}
+	return len(seen)
+}
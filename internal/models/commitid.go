@@ -1,8 +1,10 @@
 package models
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
@@ -38,9 +40,24 @@ func ComputeOperationsHash(operations []*Operation) string {
 
 	hashes := make([]string, len(operations))
 	for i, op := range operations {
+		// Offloaded payloads are identified by their blob hash so the digest is
+		// the same whether or not the payload has been resolved. A delta that
+		// cannot be applied leaves the payload empty, so the ID does not match.
+		data, _ := op.objectData()
+		payload := string(data)
+		if op.ObjectDataHash != "" {
+			payload = "blob:" + op.ObjectDataHash
+		}
 		opData := fmt.Sprintf("%s|%s|%s|%s|%s",
 			op.Type, op.ClassName, op.ObjectID,
-			string(op.ObjectData), op.VectorHash)
+			payload, op.VectorHash)
+		// Move markers only contribute when present, keeping existing IDs stable
+		if op.IsMove() {
+			opData += "|move:" + op.MovedFrom + ">" + op.MovedTo
+		}
+		if len(op.NamedVectorHashes) > 0 {
+			opData += "|vectors:" + namedVectorsDigestInput(op.NamedVectorHashes)
+		}
 		h := sha256.Sum256([]byte(opData))
 		hashes[i] = hex.EncodeToString(h[:])
 	}
@@ -52,3 +69,183 @@ func ComputeOperationsHash(operations []*Operation) string {
 	final := sha256.Sum256([]byte(combined))
 	return hex.EncodeToString(final[:])
 }
+
+// namedVectorsDigestInput joins named vector hashes as name=hash pairs in
+// name order.
+func namedVectorsDigestInput(named map[string]string) string {
+	names := make([]string, 0, len(named))
+	for name := range named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + "=" + named[name]
+	}
+	return strings.Join(pairs, ",")
+}
+
+// Commit hash versions. Commits without a recorded version use CommitHashV1.
+const (
+	// CommitHashV1 joins metadata with "|" and combines per-operation hashes
+	CommitHashV1 = 1
+	// CommitHashV2 hashes a canonical JSON document of the commit metadata and
+	// its operations sorted by class, object ID, and type, with object data
+	// re-encoded canonically and timestamps in UTC
+	CommitHashV2 = 2
+
+	// CurrentCommitHashVersion is the version used for new commits
+	CurrentCommitHashVersion = CommitHashV2
+)
+
+// canonicalCommit is the document hashed by CommitHashV2. Field order is fixed.
+type canonicalCommit struct {
+	Version    int               `json:"version"`
+	Parents    []string          `json:"parents"`
+	Message    string            `json:"message"`
+	Author     string            `json:"author,omitempty"` // omitted when empty, so commits without an author keep their IDs
+	Timestamp  string            `json:"timestamp"`
+	Operations []json.RawMessage `json:"operations"`
+	Submodules map[string]string `json:"submodules,omitempty"` // omitted when empty, like Author
+	// Classifications are omitted when empty, like Author
+	Classifications map[string]*Classification `json:"classifications,omitempty"`
+}
+
+// canonicalOperation is the hashed form of one operation. Local bookkeeping
+// (sequence, commit, revert state, timestamps) is excluded.
+type canonicalOperation struct {
+	ClassName  string          `json:"class"`
+	ObjectID   string          `json:"id"`
+	Type       OperationType   `json:"type"`
+	Data       json.RawMessage `json:"data,omitempty"`
+	DataBlob   string          `json:"data_blob,omitempty"`
+	VectorHash string          `json:"vector,omitempty"`
+	MovedFrom  string          `json:"moved_from,omitempty"`
+	MovedTo    string          `json:"moved_to,omitempty"`
+	// Named vectors only contribute when present, keeping existing IDs stable
+	NamedVectors map[string]string `json:"named_vectors,omitempty"`
+}
+
+// GenerateCommitIDV2 generates a CommitHashV2 commit ID. The result does not
+// depend on the order of operations or on how their object data was encoded.
+// mergeParentID is empty for ordinary commits.
+func GenerateCommitIDV2(message string, timestamp time.Time, parentID, mergeParentID string, operations []*Operation) (string, error) {
+	return generateCommitIDV2(message, "", timestamp, parentID, mergeParentID, operations, nil, nil)
+}
+
+// generateCommitIDV2 is GenerateCommitIDV2 for a commit with an author,
+// submodule pins, and class classifications
+func generateCommitIDV2(message, author string, timestamp time.Time, parentID, mergeParentID string, operations []*Operation, submodules map[string]string, classifications map[string]*Classification) (string, error) {
+	parents := []string{}
+	for _, p := range []string{parentID, mergeParentID} {
+		if p != "" {
+			parents = append(parents, p)
+		}
+	}
+
+	ops := make([]json.RawMessage, 0, len(operations))
+	for _, op := range operations {
+		encoded, err := canonicalizeOperation(op)
+		if err != nil {
+			return "", err
+		}
+		ops = append(ops, encoded)
+	}
+	// Records start with class, ID, and type, so byte order sorts by them
+	sort.Slice(ops, func(i, j int) bool { return string(ops[i]) < string(ops[j]) })
+
+	doc, err := json.Marshal(canonicalCommit{
+		Version:    CommitHashV2,
+		Parents:    parents,
+		Message:    message,
+		Author:     author,
+		Timestamp:  timestamp.UTC().Format(time.RFC3339Nano),
+		Operations: ops,
+		Submodules: submodules,
+
+		Classifications: classifications,
+	})
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(doc)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// canonicalizeOperation encodes an operation for CommitHashV2. Inline object
+// data, delta-encoded or not, is decoded and re-encoded so key order and
+// whitespace do not matter; offloaded data is identified by its blob hash.
+func canonicalizeOperation(op *Operation) (json.RawMessage, error) {
+	objectData, err := op.objectData()
+	if err != nil {
+		return nil, err
+	}
+	c := canonicalOperation{
+		ClassName:  op.ClassName,
+		ObjectID:   op.ObjectID,
+		Type:       op.Type,
+		VectorHash: op.VectorHash,
+		MovedFrom:  op.MovedFrom,
+		MovedTo:    op.MovedTo,
+
+		NamedVectors: op.NamedVectorHashes,
+	}
+	switch {
+	case op.ObjectDataHash != "":
+		c.DataBlob = op.ObjectDataHash
+	case len(objectData) > 0:
+		var data interface{}
+		decoder := json.NewDecoder(bytes.NewReader(objectData))
+		decoder.UseNumber()
+		if err := decoder.Decode(&data); err != nil {
+			return nil, fmt.Errorf("operation %s/%s has invalid object data: %w", op.ClassName, op.ObjectID, err)
+		}
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			return nil, err
+		}
+		c.Data = encoded
+	}
+	return json.Marshal(c)
+}
+
+// Digest returns the hash of the operation's CommitHashV2 record, which
+// identifies it independently of the commit it belongs to.
+func (op *Operation) Digest() (string, error) {
+	encoded, err := canonicalizeOperation(op)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(encoded)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// EffectiveHashVersion returns the commit's hash version, treating an unset version as CommitHashV1
+func (c *Commit) EffectiveHashVersion() int {
+	if c.HashVersion == 0 {
+		return CommitHashV1
+	}
+	return c.HashVersion
+}
+
+// ComputeID returns the content-addressable ID of the commit for the given
+// operations, using the commit's hash version.
+func (c *Commit) ComputeID(operations []*Operation) (string, error) {
+	switch c.EffectiveHashVersion() {
+	case CommitHashV1:
+		if len(c.Submodules) > 0 {
+			return "", fmt.Errorf("submodule pins require commit hash version %d", CommitHashV2)
+		}
+		if len(c.Classifications) > 0 {
+			return "", fmt.Errorf("class classifications require commit hash version %d", CommitHashV2)
+		}
+		if c.MergeParentID != "" {
+			return GenerateMergeCommitID(c.Message, c.Timestamp, c.ParentID, c.MergeParentID, operations), nil
+		}
+		return GenerateCommitID(c.Message, c.Timestamp, c.ParentID, operations), nil
+	case CommitHashV2:
+		return generateCommitIDV2(c.Message, c.Author, c.Timestamp, c.ParentID, c.MergeParentID, operations, c.Submodules, c.Classifications)
+	default:
+		return "", fmt.Errorf("unsupported commit hash version %d", c.HashVersion)
+	}
+}
@@ -0,0 +1,202 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// deltaField is one member of a delta-encoded JSON object, in the order it
+// appears in the new object. A member without Value or Fields is copied
+// unchanged from the previous object; Fields patches a nested object.
+type deltaField struct {
+	Key    string          `json:"k"`
+	Value  json.RawMessage `json:"v,omitempty"`
+	Fields []deltaField    `json:"f,omitempty"`
+}
+
+// jsonMember is a member of a JSON object with its value's raw bytes.
+type jsonMember struct {
+	key   string
+	value json.RawMessage
+}
+
+// EncodeDelta replaces the object data of an update with a delta against its
+// previous data, when both are inline, the delta is smaller, and applying it
+// reproduces the object data byte for byte. Otherwise the full snapshot is kept.
+func (op *Operation) EncodeDelta() {
+	if op.Type != OperationUpdate || len(op.ObjectDelta) > 0 ||
+		len(op.ObjectData) == 0 || len(op.PreviousData) == 0 ||
+		op.ObjectDataHash != "" || op.PreviousDataHash != "" {
+		return
+	}
+	fields, ok := diffObject(op.PreviousData, op.ObjectData)
+	if !ok {
+		return
+	}
+	delta, err := json.Marshal(fields)
+	if err != nil || len(delta) >= len(op.ObjectData) {
+		return
+	}
+	rebuilt, err := applyObjectDelta(op.PreviousData, delta)
+	if err != nil || !bytes.Equal(rebuilt, op.ObjectData) {
+		return
+	}
+	op.ObjectDelta = delta
+	op.ObjectData = nil
+}
+
+// DecodeDelta restores the object data of a delta-encoded update from its
+// previous data. Operations without a delta are left unchanged.
+func (op *Operation) DecodeDelta() error {
+	if len(op.ObjectDelta) == 0 {
+		return nil
+	}
+	data, err := op.objectData()
+	if err != nil {
+		return err
+	}
+	op.ObjectData = data
+	op.ObjectDelta = nil
+	return nil
+}
+
+// objectData returns the operation's object data, applying its delta if it has one.
+func (op *Operation) objectData() ([]byte, error) {
+	if len(op.ObjectDelta) == 0 {
+		return op.ObjectData, nil
+	}
+	if len(op.PreviousData) == 0 {
+		return nil, fmt.Errorf("operation %s/%s has a delta but no previous data", op.ClassName, op.ObjectID)
+	}
+	data, err := applyObjectDelta(op.PreviousData, op.ObjectDelta)
+	if err != nil {
+		return nil, fmt.Errorf("operation %s/%s: %w", op.ClassName, op.ObjectID, err)
+	}
+	return data, nil
+}
+
+// diffObject returns the delta turning the JSON object prev into next.
+// ok is false when either is not a JSON object.
+func diffObject(prev, next []byte) ([]deltaField, bool) {
+	prevMembers, err := parseObject(prev)
+	if err != nil {
+		return nil, false
+	}
+	nextMembers, err := parseObject(next)
+	if err != nil {
+		return nil, false
+	}
+	old := make(map[string]json.RawMessage, len(prevMembers))
+	for _, m := range prevMembers {
+		old[m.key] = m.value
+	}
+
+	fields := make([]deltaField, 0, len(nextMembers))
+	for _, m := range nextMembers {
+		field := deltaField{Key: m.key}
+		prevValue, found := old[m.key]
+		switch {
+		case found && bytes.Equal(prevValue, m.value):
+		case found && isObject(prevValue) && isObject(m.value):
+			if nested, ok := diffObject(prevValue, m.value); ok && len(nested) > 0 {
+				field.Fields = nested
+			} else {
+				field.Value = m.value
+			}
+		default:
+			field.Value = m.value
+		}
+		fields = append(fields, field)
+	}
+	return fields, true
+}
+
+// applyObjectDelta rebuilds an object from the previous object and a delta.
+func applyObjectDelta(prev, delta []byte) ([]byte, error) {
+	var fields []deltaField
+	if err := json.Unmarshal(delta, &fields); err != nil {
+		return nil, fmt.Errorf("invalid object delta: %w", err)
+	}
+	return applyFields(prev, fields)
+}
+
+func applyFields(prev []byte, fields []deltaField) ([]byte, error) {
+	prevMembers, err := parseObject(prev)
+	if err != nil {
+		return nil, fmt.Errorf("delta base is not a JSON object: %w", err)
+	}
+	old := make(map[string]json.RawMessage, len(prevMembers))
+	for _, m := range prevMembers {
+		old[m.key] = m.value
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, field := range fields {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(field.Key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+
+		value := []byte(field.Value)
+		if len(value) == 0 {
+			base, found := old[field.Key]
+			if !found {
+				return nil, fmt.Errorf("delta copies missing member %q", field.Key)
+			}
+			value = base
+			if len(field.Fields) > 0 {
+				if value, err = applyFields(base, field.Fields); err != nil {
+					return nil, err
+				}
+			}
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// parseObject splits a JSON object into its members, keeping their order and
+// the raw bytes of their values.
+func parseObject(data []byte) ([]jsonMember, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if tok != json.Delim('{') {
+		return nil, fmt.Errorf("expected a JSON object")
+	}
+	var members []jsonMember
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected an object key")
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		members = append(members, jsonMember{key: key, value: value})
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+func isObject(value json.RawMessage) bool {
+	trimmed := bytes.TrimSpace(value)
+	return len(trimmed) > 0 && trimmed[0] == '{'
+}
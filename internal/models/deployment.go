@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// Deployment records which commit is deployed to a named environment
+type Deployment struct {
+	Environment string `json:"environment"`
+	CommitID    string `json:"commit_id"`
+	// Branch is the branch the commit was deployed from; empty when the
+	// deployment was recorded from a commit or remote-tracking ref
+	Branch     string    `json:"branch,omitempty"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// WriteLock records that other writers to the Weaviate instance were asked
+// to pause, by "wvc lock acquire" or by a command holding the lock.
+type WriteLock struct {
+	Holder     string    `json:"holder,omitempty"`
+	Reason     string    `json:"reason,omitempty"`
+	AcquiredAt time.Time `json:"acquired_at"`
+}
@@ -1,12 +1,16 @@
 package models
 
+import "time"
+
 // ConflictStrategy defines how to handle merge conflicts
 type ConflictStrategy string
 
 const (
-	ConflictAbort  ConflictStrategy = "abort"  // Default: abort on conflict
+	ConflictManual ConflictStrategy = "manual" // Default: stop and record conflicts for resolution one by one
+	ConflictAbort  ConflictStrategy = "abort"  // Stop on conflict without recording a merge in progress
 	ConflictOurs   ConflictStrategy = "ours"   // Prefer our version
 	ConflictTheirs ConflictStrategy = "theirs" // Prefer their version
+	ConflictEdited ConflictStrategy = "edited" // Use a hand-edited version (single-conflict resolution only)
 )
 
 // MergeConflictType identifies the type of merge conflict
@@ -21,13 +25,17 @@ const (
 
 // MergeConflict represents a conflict during merge
 type MergeConflict struct {
-	Key       string            // "ClassName/ObjectID"
-	ClassName string            // Weaviate class name
-	ObjectID  string            // Object UUID
-	Type      MergeConflictType // Type of conflict
-	Base      *WeaviateObject   // State at common ancestor (nil for add-add)
-	Ours      *WeaviateObject   // State in our branch (nil for delete-modify)
-	Theirs    *WeaviateObject   // State in their branch (nil for modify-delete)
+	Key       string            `json:"key"`              // "ClassName/ObjectID"
+	ClassName string            `json:"class_name"`       // Weaviate class name
+	ObjectID  string            `json:"object_id"`        // Object UUID
+	Type      MergeConflictType `json:"type"`             // Type of conflict
+	Base      *WeaviateObject   `json:"base,omitempty"`   // State at common ancestor (nil for add-add)
+	Ours      *WeaviateObject   `json:"ours,omitempty"`   // State in our branch (nil for delete-modify)
+	Theirs    *WeaviateObject   `json:"theirs,omitempty"` // State in their branch (nil for modify-delete)
+
+	BaseVectorHash   string `json:"base_vector_hash,omitempty"`
+	OursVectorHash   string `json:"ours_vector_hash,omitempty"`
+	TheirsVectorHash string `json:"theirs_vector_hash,omitempty"`
 }
 
 // SchemaConflict represents a schema-level conflict
@@ -59,3 +67,28 @@ type MergeOptions struct {
 	Message       string           // Custom merge commit message
 	Strategy      ConflictStrategy // How to handle conflicts
 }
+
+// MergeState is the persisted state of a merge stopped on conflicts. It lives
+// until the merge is continued or aborted, like git's MERGE_HEAD.
+type MergeState struct {
+	TargetBranch string                      `json:"target_branch"`
+	OurHead      string                      `json:"our_head"`
+	TheirHead    string                      `json:"their_head"` // MERGE_HEAD
+	MergeBase    string                      `json:"merge_base"`
+	Message      string                      `json:"message"`
+	Conflicts    []*MergeConflict            `json:"conflicts"`
+	Resolutions  map[string]ConflictStrategy `json:"resolutions,omitempty"` // conflict key -> chosen side
+	Edits        map[string]*WeaviateObject  `json:"edits,omitempty"`       // conflict key -> object for ConflictEdited resolutions
+	StartedAt    time.Time                   `json:"started_at"`
+}
+
+// Unresolved returns the conflicts that have no recorded resolution
+func (s *MergeState) Unresolved() []*MergeConflict {
+	var unresolved []*MergeConflict
+	for _, c := range s.Conflicts {
+		if _, ok := s.Resolutions[c.Key]; !ok {
+			unresolved = append(unresolved, c)
+		}
+	}
+	return unresolved
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// Note is a message attached to a commit after the fact, such as a QA
+// sign-off. Notes are not part of the commit's ID, so they can be added,
+// changed, or removed without rewriting history. A commit has at most one note.
+type Note struct {
+	CommitID  string    `json:"commit_id"`
+	Message   string    `json:"message"`
+	Author    string    `json:"author,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -2,24 +2,95 @@
 // including Weaviate objects, operations, and commits.
 package models
 
+import "strings"
+
 // WeaviateObject represents an object stored in Weaviate
 type WeaviateObject struct {
 	ID                 string                 `json:"id"`
 	Class              string                 `json:"class"`
+	Tenant             string                 `json:"tenant,omitempty"` // Tenant of an object of a multi-tenant class
 	Properties         map[string]interface{} `json:"properties"`
 	Vector             interface{}            `json:"vector,omitempty"`             // interface{} to support multi-vectors (ColBERT) in Weaviate v5+
+	Vectors            map[string]interface{} `json:"vectors,omitempty"`            // Named vectors (Weaviate 1.24+), each single or multi-vector
 	CreationTimeUnix   int64                  `json:"creationTimeUnix,omitempty"`   // Object creation timestamp (ms)
 	LastUpdateTimeUnix int64                  `json:"lastUpdateTimeUnix,omitempty"` // Last modification timestamp (ms)
 }
 
-// Key returns the unique key for an object
+// ObjectKey returns the unique key for an object: "Class/ID", or
+// "Class@tenant/ID" when className is qualified with a tenant
 func ObjectKey(className, objectID string) string {
 	return className + "/" + objectID
 }
 
+// TenantSeparator joins a multi-tenant class and one of its tenants into the
+// name the tenant's objects are versioned under. Weaviate allows it in
+// neither class nor tenant names.
+const TenantSeparator = "@"
+
+// TenantClass returns the name the objects of a class's tenant are
+// versioned under: "Class@tenant", or the class itself without a tenant.
+func TenantClass(className, tenant string) string {
+	if tenant == "" {
+		return className
+	}
+	return className + TenantSeparator + tenant
+}
+
+// SplitTenantClass splits a name built by TenantClass into the class and
+// the tenant, which is empty for classes without tenants.
+func SplitTenantClass(name string) (className, tenant string) {
+	className, tenant, _ = strings.Cut(name, TenantSeparator)
+	return className, tenant
+}
+
+// TenantClass returns the name the object is versioned under, qualified
+// with its tenant.
+func (o *WeaviateObject) TenantClass() string {
+	return TenantClass(o.Class, o.Tenant)
+}
+
+// Key returns the unique key of the object, including its tenant.
+func (o *WeaviateObject) Key() string {
+	return ObjectKey(o.TenantClass(), o.ID)
+}
+
+// ClassWatermark summarizes how far writes to a class have progressed: its
+// object count and the newest lastUpdateTimeUnix (ms) of any of its objects.
+// Any insert, update, or delete changes at least one of them.
+type ClassWatermark struct {
+	Count              int
+	LastUpdateTimeUnix int64
+}
+
 // KnownObjectInfo holds a known object along with its hashes for diff computation
 type KnownObjectInfo struct {
 	Object     *WeaviateObject
 	ObjectHash string
 	VectorHash string
 }
+
+// MultiVectorRows returns the rows of a multi-vector (ColBERT), one vector
+// per token, and false when v is a single vector.
+func MultiVectorRows(v interface{}) ([]interface{}, bool) {
+	switch vec := v.(type) {
+	case [][]float32:
+		rows := make([]interface{}, len(vec))
+		for i := range vec {
+			rows[i] = vec[i]
+		}
+		return rows, true
+	case [][]float64:
+		rows := make([]interface{}, len(vec))
+		for i := range vec {
+			rows[i] = vec[i]
+		}
+		return rows, true
+	case []interface{}:
+		if len(vec) > 0 {
+			if _, isRow := vec[0].([]interface{}); isRow {
+				return vec, true
+			}
+		}
+	}
+	return nil, false
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"sort"
+	"time"
+)
 
 // OperationType represents the type of database operation.
 type OperationType string
@@ -25,4 +28,80 @@ type Operation struct {
 	Reverted           bool          `json:"reverted"`
 	VectorHash         string        `json:"vector_hash,omitempty"`          // Hash reference to vector_blobs
 	PreviousVectorHash string        `json:"previous_vector_hash,omitempty"` // Previous vector hash for revert
+	ObjectDataHash     string        `json:"object_data_hash,omitempty"`     // Blob hash when ObjectData is offloaded
+	PreviousDataHash   string        `json:"previous_data_hash,omitempty"`   // Blob hash when PreviousData is offloaded
+	MovedFrom          string        `json:"moved_from,omitempty"`           // Source key of an insert recorded by "wvc mv"
+	MovedTo            string        `json:"moved_to,omitempty"`             // Destination key of a delete recorded by "wvc mv"
+	PayloadOmitted     bool          `json:"payload_omitted,omitempty"`      // Payloads left on the remote by a payload filter
+	ObjectDelta        []byte        `json:"object_delta,omitempty"`         // ObjectData of an update encoded against PreviousData
+
+	NamedVectorHashes         map[string]string `json:"named_vector_hashes,omitempty"`          // Vector blob hash per named vector
+	PreviousNamedVectorHashes map[string]string `json:"previous_named_vector_hashes,omitempty"` // Previous named vector hashes for revert
+}
+
+// VectorHashes returns the blob hashes of the vectors the operation writes:
+// the default vector and each named vector, in name order.
+func (op *Operation) VectorHashes() []string {
+	var hashes []string
+	if op.VectorHash != "" {
+		hashes = append(hashes, op.VectorHash)
+	}
+	return append(hashes, SortedNamedVectorHashes(op.NamedVectorHashes)...)
+}
+
+// SortedNamedVectorHashes returns the non-empty hashes of named vectors in
+// name order.
+func SortedNamedVectorHashes(named map[string]string) []string {
+	names := make([]string, 0, len(named))
+	for name, h := range named {
+		if h != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	hashes := make([]string, len(names))
+	for i, name := range names {
+		hashes[i] = named[name]
+	}
+	return hashes
+}
+
+// IsMove reports whether the operation is one half of a move recorded by "wvc mv".
+func (op *Operation) IsMove() bool {
+	return op.MovedFrom != "" || op.MovedTo != ""
+}
+
+// PayloadHashes returns the blob hashes of object payloads stored outside the operation.
+func (op *Operation) PayloadHashes() []string {
+	var hashes []string
+	if op.ObjectDataHash != "" {
+		hashes = append(hashes, op.ObjectDataHash)
+	}
+	if op.PreviousDataHash != "" {
+		hashes = append(hashes, op.PreviousDataHash)
+	}
+	return hashes
+}
+
+// OmitPayloads clears the operation's payloads, inline or offloaded, and marks
+// them as omitted so they are fetched from the remote when needed.
+func (op *Operation) OmitPayloads() {
+	if len(op.ObjectData) == 0 && len(op.ObjectDelta) == 0 && len(op.PreviousData) == 0 && op.ObjectDataHash == "" && op.PreviousDataHash == "" {
+		return
+	}
+	op.ObjectData = nil
+	op.ObjectDelta = nil
+	op.PreviousData = nil
+	op.PayloadOmitted = true
+}
+
+// StripOffloadedPayloads clears payload bytes that are also held in blob storage,
+// leaving only the hash references. Used before sending operations over the wire.
+func (op *Operation) StripOffloadedPayloads() {
+	if op.ObjectDataHash != "" {
+		op.ObjectData = nil
+	}
+	if op.PreviousDataHash != "" {
+		op.PreviousData = nil
+	}
 }
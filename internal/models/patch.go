@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// PatchFormat identifies a wvc patch file
+const PatchFormat = "wvc-patch"
+
+// PatchVersion is the patch file version written by this build
+const PatchVersion = 1
+
+// Patch is a portable set of object changes, written by `wvc diff --patch`
+// and applied by `wvc apply`. Objects carry their vectors inline so a patch
+// can be applied without access to the repository it came from.
+type Patch struct {
+	Format     string         `json:"format"`
+	Version    int            `json:"version"`
+	BaseCommit string         `json:"base_commit,omitempty"` // HEAD of the repository the patch was made in
+	Branch     string         `json:"branch,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+	Changes    []*PatchChange `json:"changes"`
+}
+
+// PatchChange is one object change in a patch. The previous hashes describe
+// the state the change was made against and are used to detect conflicts.
+type PatchChange struct {
+	ClassName          string          `json:"class_name"`
+	ObjectID           string          `json:"object_id"`
+	ChangeType         string          `json:"change_type"` // "insert", "update", "delete"
+	Object             *WeaviateObject `json:"object,omitempty"`
+	ObjectHash         string          `json:"object_hash,omitempty"`
+	VectorHash         string          `json:"vector_hash,omitempty"`
+	PreviousObjectHash string          `json:"previous_object_hash,omitempty"`
+	PreviousVectorHash string          `json:"previous_vector_hash,omitempty"`
+}
@@ -7,6 +7,9 @@ type Remote struct {
 	Name      string    `json:"name"`
 	URL       string    `json:"url"`
 	CreatedAt time.Time `json:"created_at"`
+	// PIIApproved marks the remote as cleared to hold classes classified
+	// pii:restricted; pushing them elsewhere warns
+	PIIApproved bool `json:"pii_approved,omitempty"`
 }
 
 // RemoteBranch represents a remote-tracking branch reference.
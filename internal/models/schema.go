@@ -41,6 +41,12 @@ type WeaviateClass struct {
 	MultiTenancy      map[string]interface{} `json:"multiTenancyConfig,omitempty"`
 }
 
+// MultiTenant reports whether the class stores its objects per tenant
+func (c *WeaviateClass) MultiTenant() bool {
+	enabled, _ := c.MultiTenancy["enabled"].(bool)
+	return enabled
+}
+
 // WeaviateProperty represents a property definition in a class
 type WeaviateProperty struct {
 	Name            string   `json:"name"`
@@ -9,6 +9,7 @@ type Stash struct {
 	BranchName string    `json:"branch_name"`
 	CommitID   string    `json:"commit_id"`
 	CreatedAt  time.Time `json:"created_at"`
+	RemoteID   string    `json:"remote_id,omitempty"` // set once pushed to or fetched from a remote
 }
 
 // StashChange represents a single object change within a stash
@@ -23,4 +24,6 @@ type StashChange struct {
 	WasStaged          bool   `json:"was_staged"`
 	VectorHash         string `json:"vector_hash,omitempty"`
 	PreviousVectorHash string `json:"previous_vector_hash,omitempty"`
+	MovedFrom          string `json:"moved_from,omitempty"`
+	MovedTo            string `json:"moved_to,omitempty"`
 }
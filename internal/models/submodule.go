@@ -0,0 +1,9 @@
+package models
+
+// SubmoduleState records which commit of a submodule was last materialized
+// into Weaviate and the classes it wrote, which the repository then leaves
+// to the submodule.
+type SubmoduleState struct {
+	CommitID string   `json:"commit_id"`
+	Classes  []string `json:"classes"`
+}
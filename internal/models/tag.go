@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// Tag is an immutable named reference to a commit, used to mark releases
+type Tag struct {
+	Name      string    `json:"name"`
+	CommitID  string    `json:"commit_id"`
+	Message   string    `json:"message,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// Worktree is a Weaviate instance linked to a repository's history with its
+// own HEAD, branch, staging area, and known state
+type Worktree struct {
+	Name        string    `json:"name"`
+	Path        string    `json:"path"` // directory holding the worktree's .wvc
+	WeaviateURL string    `json:"weaviate_url"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	// HEAD and Branch are read from the worktree's state, not stored with it
+	HEAD   string `json:"-"`
+	Branch string `json:"-"`
+}
@@ -32,11 +32,21 @@ func NewAdminClient(baseURL, token string) *AdminClient {
 	}
 }
 
+// AdminTokenLimits narrow what a token may do: when it expires, the branch
+// patterns it may update or delete, and its scopes (push, pull,
+// branch-delete, gc, repo-admin, reviewer). Zero values impose no limit.
+type AdminTokenLimits struct {
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Branches  []string   `json:"branches,omitempty"`
+	Scopes    []string   `json:"scopes,omitempty"`
+}
+
 // adminTokenCreateReq is the request body for POST /admin/tokens.
 type adminTokenCreateReq struct {
 	Description string   `json:"description"`
 	Repos       []string `json:"repos"`
 	Permission  string   `json:"permission"`
+	AdminTokenLimits
 }
 
 // AdminTokenCreateResponse is the decoded response from POST /admin/tokens.
@@ -47,6 +57,7 @@ type AdminTokenCreateResponse struct {
 	Description string   `json:"description"`
 	Repos       []string `json:"repos"`
 	Permission  string   `json:"permission"`
+	AdminTokenLimits
 }
 
 // AdminTokenInfo is one entry in the GET /admin/tokens response.
@@ -55,6 +66,7 @@ type AdminTokenInfo struct {
 	Description string   `json:"description"`
 	Repos       []string `json:"repos"`
 	Permission  string   `json:"permission"`
+	AdminTokenLimits
 }
 
 // adminReposListResp is the decoded response from GET /admin/repos.
@@ -106,8 +118,8 @@ func (c *AdminClient) doJSON(ctx context.Context, method, url string, reqBody, r
 
 // CreateToken calls POST /admin/tokens and returns the newly created token.
 // The raw token value is only available in the response — it is never stored by the server.
-func (c *AdminClient) CreateToken(ctx context.Context, desc string, repos []string, permission string) (*AdminTokenCreateResponse, error) {
-	req := adminTokenCreateReq{Description: desc, Repos: repos, Permission: permission}
+func (c *AdminClient) CreateToken(ctx context.Context, desc string, repos []string, permission string, limits AdminTokenLimits) (*AdminTokenCreateResponse, error) {
+	req := adminTokenCreateReq{Description: desc, Repos: repos, Permission: permission, AdminTokenLimits: limits}
 	var resp AdminTokenCreateResponse
 	if err := c.doJSON(ctx, "POST", c.baseURL+"/admin/tokens", req, &resp); err != nil {
 		return nil, fmt.Errorf("create token: %w", err)
@@ -170,3 +182,89 @@ func (c *AdminClient) ListRepos(ctx context.Context) ([]string, error) {
 	}
 	return resp.Repos, nil
 }
+
+// GetQuota calls GET /admin/repos/{name}/quota and returns the repository's quota.
+func (c *AdminClient) GetQuota(ctx context.Context, name string) (*RepoQuota, error) {
+	var quota RepoQuota
+	if err := c.doJSON(ctx, "GET", c.baseURL+"/admin/repos/"+name+"/quota", nil, &quota); err != nil {
+		return nil, fmt.Errorf("get quota: %w", err)
+	}
+	return &quota, nil
+}
+
+// PutQuota calls PUT /admin/repos/{name}/quota to replace the repository's quota.
+func (c *AdminClient) PutQuota(ctx context.Context, name string, quota *RepoQuota) error {
+	if err := c.doJSON(ctx, "PUT", c.baseURL+"/admin/repos/"+name+"/quota", quota, nil); err != nil {
+		return fmt.Errorf("put quota: %w", err)
+	}
+	return nil
+}
+
+// AdminMirror is a mirror server a repository replicates to.
+// Token is only sent; the server never returns it.
+type AdminMirror struct {
+	Name  string `json:"name"`
+	URL   string `json:"url"`
+	Repo  string `json:"repo,omitempty"`
+	Token string `json:"token,omitempty"`
+}
+
+// AdminMirrorStatus is the replication state of one mirror.
+type AdminMirrorStatus struct {
+	LastAttempt  time.Time  `json:"last_attempt,omitzero"`
+	LastSuccess  time.Time  `json:"last_success,omitzero"`
+	Error        string     `json:"error,omitempty"`
+	CommitsSent  int        `json:"commits_sent"`
+	VectorsSent  int        `json:"vectors_sent"`
+	PendingSince *time.Time `json:"pending_since,omitempty"`
+	LagSeconds   float64    `json:"lag_seconds"`
+	OutOfSync    []string   `json:"out_of_sync,omitempty"`
+}
+
+// AdminMirrorInfo is one entry in the GET /admin/repos/{repo}/mirrors response.
+type AdminMirrorInfo struct {
+	AdminMirror
+	Status AdminMirrorStatus `json:"status"`
+}
+
+// ListMirrors calls GET /admin/repos/{repo}/mirrors and returns each mirror with its status.
+func (c *AdminClient) ListMirrors(ctx context.Context, repo string) ([]AdminMirrorInfo, error) {
+	var resp struct {
+		Mirrors []AdminMirrorInfo `json:"mirrors"`
+	}
+	if err := c.doJSON(ctx, "GET", c.baseURL+"/admin/repos/"+repo+"/mirrors", nil, &resp); err != nil {
+		return nil, fmt.Errorf("list mirrors: %w", err)
+	}
+	return resp.Mirrors, nil
+}
+
+// PutMirror calls PUT /admin/repos/{repo}/mirrors/{name} to add or replace a mirror.
+func (c *AdminClient) PutMirror(ctx context.Context, repo string, m AdminMirror) error {
+	if err := c.doJSON(ctx, "PUT", c.baseURL+"/admin/repos/"+repo+"/mirrors/"+m.Name, m, nil); err != nil {
+		return fmt.Errorf("put mirror: %w", err)
+	}
+	return nil
+}
+
+// DeleteMirror calls DELETE /admin/repos/{repo}/mirrors/{name}.
+func (c *AdminClient) DeleteMirror(ctx context.Context, repo, name string) error {
+	resp, err := c.do(ctx, "DELETE", c.baseURL+"/admin/repos/"+repo+"/mirrors/"+name, nil, nil)
+	if err != nil {
+		return fmt.Errorf("delete mirror: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("delete mirror: %w", decodeError(resp))
+	}
+	return nil
+}
+
+// SyncMirror calls POST /admin/repos/{repo}/mirrors/{name}/sync and waits
+// for the replication to finish.
+func (c *AdminClient) SyncMirror(ctx context.Context, repo, name string) (*AdminMirrorStatus, error) {
+	var status AdminMirrorStatus
+	if err := c.doJSON(ctx, "POST", c.baseURL+"/admin/repos/"+repo+"/mirrors/"+name+"/sync", nil, &status); err != nil {
+		return nil, fmt.Errorf("sync mirror: %w", err)
+	}
+	return &status, nil
+}
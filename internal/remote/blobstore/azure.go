@@ -0,0 +1,299 @@
+package blobstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/streaming"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blockblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/service"
+)
+
+func init() {
+	Register("azblob", openAzure)
+}
+
+// AzureStore implements BlobStore on Azure Blob Storage. Blobs are block
+// blobs named <prefix><hash> with their dimensions in the "dims" metadata
+// field. Uploads stage blocks and commit the block list only after the data
+// has been verified, so a bad upload never becomes visible.
+type AzureStore struct {
+	container *container.Client
+	service   *service.Client // nil when authorized with a SAS token
+	name      string          // container name
+	prefix    string
+	chunkSize int
+
+	keyMu     sync.Mutex
+	key       *service.UserDelegationCredential
+	keyExpiry time.Time
+}
+
+// NewAzureStore creates a blob store for the blobs under prefix in the
+// container at containerURL. Requests are authorized with the SAS token if
+// given, otherwise with cred.
+func NewAzureStore(containerURL, prefix, sas string, cred azcore.TokenCredential) (*AzureStore, error) {
+	containerURL = strings.TrimRight(containerURL, "/")
+	parts, err := blob.ParseURL(containerURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid container URL: %w", err)
+	}
+	opts := &container.ClientOptions{ClientOptions: azcore.ClientOptions{Transport: httpClient}}
+	s := &AzureStore{name: parts.ContainerName, prefix: prefix, chunkSize: defaultChunkSize}
+
+	if sas = strings.TrimPrefix(sas, "?"); sas != "" {
+		if _, err := url.ParseQuery(sas); err != nil {
+			return nil, fmt.Errorf("invalid SAS token: %w", err)
+		}
+		if s.container, err = container.NewClientWithNoCredential(containerURL+"?"+sas, opts); err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+
+	if s.container, err = container.NewClient(containerURL, cred, opts); err != nil {
+		return nil, err
+	}
+	serviceURL := containerURL[:strings.LastIndex(containerURL, "/")]
+	if s.service, err = service.NewClient(serviceURL, cred, &service.ClientOptions{ClientOptions: opts.ClientOptions}); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// openAzure opens azblob://account/container/path as the blobs under
+// path/<repo>/. An endpoint query parameter replaces
+// https://<account>.blob.core.windows.net, e.g. for Azurite. Requests use the
+// SAS token in AZURE_STORAGE_SAS_TOKEN or, by default, workload identity.
+func openAzure(u *url.URL, repo string) (BlobStore, error) {
+	name, path, _ := strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+	if u.Host == "" || name == "" {
+		return nil, fmt.Errorf("azblob blob store URL must name an account and container like azblob://account/container/prefix: %q", u.String())
+	}
+
+	endpoint := endpointParam(u)
+	if endpoint == "" {
+		endpoint = "https://" + u.Host + ".blob.core.windows.net"
+	}
+	containerURL := endpoint + "/" + url.PathEscape(name)
+	prefix := storePrefix(path, repo)
+
+	if sas := os.Getenv("AZURE_STORAGE_SAS_TOKEN"); sas != "" {
+		return NewAzureStore(containerURL, prefix, sas, nil)
+	}
+	// Reads AZURE_CLIENT_ID, AZURE_TENANT_ID, AZURE_FEDERATED_TOKEN_FILE, and
+	// AZURE_AUTHORITY_HOST as set up for the pod
+	cred, err := azidentity.NewWorkloadIdentityCredential(nil)
+	if err != nil {
+		return nil, fmt.Errorf("no Azure credentials: set AZURE_STORAGE_SAS_TOKEN or configure workload identity: %w", err)
+	}
+	return NewAzureStore(containerURL, prefix, "", cred)
+}
+
+// Has checks whether a blob exists.
+func (s *AzureStore) Has(ctx context.Context, hash string) (bool, error) {
+	if !validHash.MatchString(hash) {
+		return false, nil
+	}
+	_, err := s.blob(hash).GetProperties(ctx, nil)
+	switch {
+	case err == nil:
+		return true, nil
+	case azureStatus(err) == http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("stat blob %s: %w", hash, err)
+	}
+}
+
+// Get opens a blob for reading. Returns the data reader and dimensions.
+// Returns ErrBlobNotFound if the blob does not exist.
+func (s *AzureStore) Get(ctx context.Context, hash string) (io.ReadCloser, int, error) {
+	if !validHash.MatchString(hash) {
+		return nil, 0, ErrBlobNotFound
+	}
+	resp, err := s.blob(hash).DownloadStream(ctx, nil)
+	if err != nil {
+		if azureStatus(err) == http.StatusNotFound {
+			return nil, 0, ErrBlobNotFound
+		}
+		return nil, 0, fmt.Errorf("open blob %s: %w", hash, err)
+	}
+	dims, err := azureDims(hash, resp.Metadata)
+	if err != nil {
+		resp.Body.Close()
+		return nil, 0, err
+	}
+	return resp.Body, dims, nil
+}
+
+// Put stores a blob. The data is read from r and verified against the hash.
+// Idempotent — if the blob exists, this is a no-op.
+func (s *AzureStore) Put(ctx context.Context, hash string, r io.Reader, dims int) error {
+	if !validHash.MatchString(hash) {
+		return fmt.Errorf("invalid blob hash: %q", hash)
+	}
+	if exists, err := s.Has(ctx, hash); err != nil || exists {
+		return err
+	}
+
+	// Block IDs are unique to this upload so concurrent uploads of the same
+	// blob cannot overwrite each other's staged blocks.
+	nonce := make([]byte, 8)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return fmt.Errorf("generate block id: %w", err)
+	}
+
+	client := s.container.NewBlockBlobClient(s.prefix + hash)
+	hasher := sha256.New()
+	chunks := newChunkReader(r, s.chunkSize)
+	blockIDs := []string{}
+	for {
+		chunk, last, err := chunks.next()
+		if err != nil {
+			return err
+		}
+		hasher.Write(chunk)
+
+		if len(chunk) > 0 {
+			id := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%x-%06d", nonce, len(blockIDs))))
+			if _, err := client.StageBlock(ctx, id, streaming.NopCloser(bytes.NewReader(chunk)), nil); err != nil {
+				return fmt.Errorf("upload blob %s: %w", hash, err)
+			}
+			blockIDs = append(blockIDs, id)
+		}
+		if last {
+			break
+		}
+	}
+
+	// Staged blocks that are never committed are discarded by the service
+	if computed := hex.EncodeToString(hasher.Sum(nil)); computed != hash {
+		return fmt.Errorf("expected %s, got %s: %w", hash, computed, ErrHashMismatch)
+	}
+
+	dimsValue := strconv.Itoa(dims)
+	ifNoneMatch := azcore.ETagAny
+	_, err := client.CommitBlockList(ctx, blockIDs, &blockblob.CommitBlockListOptions{
+		Metadata: map[string]*string{"dims": &dimsValue},
+		AccessConditions: &blob.AccessConditions{
+			ModifiedAccessConditions: &blob.ModifiedAccessConditions{IfNoneMatch: &ifNoneMatch},
+		},
+	})
+	switch status := azureStatus(err); {
+	case err == nil:
+		return nil
+	case status == http.StatusConflict || status == http.StatusPreconditionFailed:
+		// Stored concurrently by another upload of the same blob
+		return nil
+	default:
+		return fmt.Errorf("commit blob %s: %w", hash, err)
+	}
+}
+
+// Delete removes a blob. No error if it doesn't exist.
+func (s *AzureStore) Delete(ctx context.Context, hash string) error {
+	if !validHash.MatchString(hash) {
+		return nil
+	}
+	if _, err := s.blob(hash).Delete(ctx, nil); err != nil && azureStatus(err) != http.StatusNotFound {
+		return fmt.Errorf("delete blob %s: %w", hash, err)
+	}
+	return nil
+}
+
+// TotalCount returns the number of stored blobs.
+func (s *AzureStore) TotalCount(ctx context.Context) (int, error) {
+	hashes, err := s.ListHashes(ctx)
+	return len(hashes), err
+}
+
+// ListHashes returns all blob hashes by listing the blobs under the prefix.
+func (s *AzureStore) ListHashes(ctx context.Context) ([]string, error) {
+	var hashes []string
+	err := s.list(ctx, func(hash string, _ int64) {
+		hashes = append(hashes, hash)
+	})
+	return hashes, err
+}
+
+// TotalSize returns the size of the blobs under the prefix.
+func (s *AzureStore) TotalSize(ctx context.Context) (int64, error) {
+	var total int64
+	err := s.list(ctx, func(_ string, size int64) {
+		total += size
+	})
+	return total, err
+}
+
+// list calls fn with the hash and size of each blob under the prefix.
+func (s *AzureStore) list(ctx context.Context, fn func(hash string, size int64)) error {
+	pager := s.container.NewListBlobsFlatPager(&container.ListBlobsFlatOptions{Prefix: &s.prefix})
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("list blobs: %w", err)
+		}
+		for _, item := range page.Segment.BlobItems {
+			if item.Name == nil {
+				continue
+			}
+			var size int64
+			if item.Properties != nil && item.Properties.ContentLength != nil {
+				size = *item.Properties.ContentLength
+			}
+			if hash := strings.TrimPrefix(*item.Name, s.prefix); validHash.MatchString(hash) {
+				fn(hash, size)
+			}
+		}
+	}
+	return nil
+}
+
+// blob returns the client of a blob.
+func (s *AzureStore) blob(hash string) *blob.Client {
+	return s.container.NewBlobClient(s.prefix + hash)
+}
+
+// azureStatus returns the HTTP status of a failed request, or 0.
+func azureStatus(err error) int {
+	var respErr *azcore.ResponseError
+	if errors.As(err, &respErr) {
+		return respErr.StatusCode
+	}
+	return 0
+}
+
+// azureDims reads a blob's dimensions from its metadata, whose keys the SDK
+// returns in canonical header case.
+func azureDims(hash string, metadata map[string]*string) (int, error) {
+	value := ""
+	for k, v := range metadata {
+		if strings.EqualFold(k, "dims") && v != nil {
+			value = *v
+		}
+	}
+	dims, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("read blob meta %s: invalid dims %q", hash, value)
+	}
+	return dims, nil
+}
@@ -0,0 +1,236 @@
+package blobstore
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAzureStore_Fake(t *testing.T) {
+	fake := newFakeAzure(t, "wvc")
+	s, err := NewAzureStore(fake.URL+"/wvc", "data/myrepo/", "?sv=2021&sig=test", nil)
+	require.NoError(t, err)
+	s.chunkSize = 4
+	testBlobStore(t, s)
+}
+
+func TestAzureStore_RequiresAuthorization(t *testing.T) {
+	fake := newFakeAzure(t, "wvc")
+
+	s, err := NewAzureStore(fake.URL+"/wvc", "p/", "sig=wrong", nil)
+	require.NoError(t, err)
+	_, err = s.Has(t.Context(), hashBytes([]byte("x")))
+	assert.ErrorContains(t, err, "403")
+}
+
+func TestOpenAzure(t *testing.T) {
+	t.Setenv("AZURE_STORAGE_SAS_TOKEN", "sv=2021&sig=abc")
+
+	u, err := ParseURL("azblob://devstoreaccount1/vectors/team?endpoint=http://127.0.0.1:10000/devstoreaccount1")
+	require.NoError(t, err)
+	s, err := Open(u, "myrepo")
+	require.NoError(t, err)
+
+	az := s.(*AzureStore)
+	containerURL, err := url.Parse(az.container.URL())
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:10000", containerURL.Host)
+	assert.Equal(t, "/devstoreaccount1/vectors", containerURL.Path)
+	assert.Equal(t, "abc", containerURL.Query().Get("sig"))
+	assert.Equal(t, "vectors", az.name)
+	assert.Equal(t, "team/myrepo/", az.prefix)
+	assert.Nil(t, az.service, "SAS tokens cannot sign URLs")
+
+	u, err = ParseURL("azblob://account")
+	require.NoError(t, err)
+	_, err = Open(u, "myrepo")
+	assert.ErrorContains(t, err, "must name an account and container")
+}
+
+func TestOpenAzure_NoCredentials(t *testing.T) {
+	t.Setenv("AZURE_STORAGE_SAS_TOKEN", "")
+	t.Setenv("AZURE_FEDERATED_TOKEN_FILE", "")
+
+	u, err := ParseURL("azblob://account/container")
+	require.NoError(t, err)
+	_, err = Open(u, "myrepo")
+	assert.ErrorContains(t, err, "no Azure credentials")
+}
+
+// TestAzureStore_Emulator runs against Azurite when WVC_TEST_AZURITE_URL is
+// set, e.g. to http://127.0.0.1:10000/devstoreaccount1, with an account SAS
+// token in WVC_TEST_AZURITE_SAS.
+func TestAzureStore_Emulator(t *testing.T) {
+	endpoint := os.Getenv("WVC_TEST_AZURITE_URL")
+	if endpoint == "" {
+		t.Skip("WVC_TEST_AZURITE_URL not set")
+	}
+	sas := strings.TrimPrefix(os.Getenv("WVC_TEST_AZURITE_SAS"), "?")
+
+	containerURL := endpoint + "/wvc-test-" + strconv.Itoa(os.Getpid())
+	s, err := NewAzureStore(containerURL, "wvc/myrepo/", sas, nil)
+	require.NoError(t, err)
+	if _, err := s.container.Create(t.Context(), nil); err != nil {
+		require.Equal(t, http.StatusConflict, azureStatus(err), "create container: %v", err)
+	}
+	s.chunkSize = 64 << 10
+	testBlobStore(t, s)
+}
+
+// staticCredential authorizes requests with a fixed bearer token.
+type staticCredential string
+
+func (c staticCredential) GetToken(context.Context, policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: string(c), ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+// useHTTPClient sends the requests of stores created during the test with c.
+func useHTTPClient(t *testing.T, c *http.Client) {
+	saved := httpClient
+	httpClient = c
+	t.Cleanup(func() { httpClient = saved })
+}
+
+// fakeAzure implements the subset of the Blob service REST API used by
+// AzureStore, authorizing requests with the SAS signature "test".
+type fakeAzure struct {
+	*httptest.Server
+	container string
+
+	mu     sync.Mutex
+	blobs  map[string]*fakeAzureBlob
+	staged map[string]map[string][]byte
+}
+
+type fakeAzureBlob struct {
+	data []byte
+	dims string
+}
+
+func newFakeAzure(t *testing.T, container string) *fakeAzure {
+	f := &fakeAzure{
+		container: container,
+		blobs:     make(map[string]*fakeAzureBlob),
+		staged:    make(map[string]map[string][]byte),
+	}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeAzure) serve(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("sig") != "test" || r.Header.Get("x-ms-version") == "" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.URL.Path == "/"+f.container && q.Get("comp") == "list" {
+		f.list(w, q)
+		return
+	}
+	name, ok := strings.CutPrefix(r.URL.Path, "/"+f.container+"/")
+	if !ok {
+		http.Error(w, fmt.Sprintf("unexpected %s %s", r.Method, r.URL.Path), http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodPut && q.Get("comp") == "block":
+		data, _ := io.ReadAll(r.Body)
+		if f.staged[name] == nil {
+			f.staged[name] = make(map[string][]byte)
+		}
+		f.staged[name][q.Get("blockid")] = data
+		w.WriteHeader(http.StatusCreated)
+
+	case r.Method == http.MethodPut && q.Get("comp") == "blocklist":
+		if _, exists := f.blobs[name]; exists && r.Header.Get("If-None-Match") == "*" {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		var list struct {
+			Latest []string `xml:"Latest"`
+		}
+		if err := xml.NewDecoder(r.Body).Decode(&list); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		blob := &fakeAzureBlob{data: []byte{}, dims: r.Header.Get("x-ms-meta-dims")}
+		for _, id := range list.Latest {
+			data, ok := f.staged[name][id]
+			if !ok {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			blob.data = append(blob.data, data...)
+			delete(f.staged[name], id)
+		}
+		f.blobs[name] = blob
+		w.WriteHeader(http.StatusCreated)
+
+	default:
+		blob, ok := f.blobs[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.Method {
+		case http.MethodDelete:
+			delete(f.blobs, name)
+			w.WriteHeader(http.StatusAccepted)
+		case http.MethodHead, http.MethodGet:
+			w.Header().Set("x-ms-meta-dims", blob.dims)
+			w.Write(blob.data)
+		}
+	}
+}
+
+// list returns blobs two per page to exercise pagination.
+func (f *fakeAzure) list(w http.ResponseWriter, q url.Values) {
+	var names []string
+	for name := range f.blobs {
+		if strings.HasPrefix(name, q.Get("prefix")) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	start, _ := strconv.Atoi(q.Get("marker"))
+	end := min(start+2, len(names))
+	type blob struct {
+		Name string `xml:"Name"`
+		Size int    `xml:"Properties>Content-Length"`
+	}
+	result := struct {
+		XMLName    xml.Name `xml:"EnumerationResults"`
+		Blobs      []blob   `xml:"Blobs>Blob"`
+		NextMarker string   `xml:"NextMarker"`
+	}{}
+	for _, name := range names[start:end] {
+		result.Blobs = append(result.Blobs, blob{Name: name, Size: len(f.blobs[name].data)})
+	}
+	if end < len(names) {
+		result.NextMarker = strconv.Itoa(end)
+	}
+	xml.NewEncoder(w).Encode(result)
+}
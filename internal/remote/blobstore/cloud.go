@@ -0,0 +1,63 @@
+package blobstore
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// defaultChunkSize is the upload chunk size of the cloud drivers. Blobs are
+// streamed one chunk at a time, so memory use per upload stays bounded.
+const defaultChunkSize = 8 << 20
+
+// httpClient sends the requests of the Azure driver. Requests are bounded by
+// their context.
+var httpClient = &http.Client{}
+
+// chunkReader reads a blob in fixed-size chunks and reports which chunk is
+// the last, so a driver can verify the hash before finalizing the upload.
+type chunkReader struct {
+	r   *bufio.Reader
+	buf []byte
+}
+
+func newChunkReader(r io.Reader, size int) *chunkReader {
+	return &chunkReader{r: bufio.NewReader(r), buf: make([]byte, size)}
+}
+
+// next returns the next chunk and whether it is the last one. The chunk is
+// only valid until the following call.
+func (c *chunkReader) next() ([]byte, bool, error) {
+	n, err := io.ReadFull(c.r, c.buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return c.buf[:n], true, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("read blob data: %w", err)
+	}
+	if _, err := c.r.Peek(1); err == io.EOF {
+		return c.buf[:n], true, nil
+	} else if err != nil {
+		return nil, false, fmt.Errorf("read blob data: %w", err)
+	}
+	return c.buf[:n], false, nil
+}
+
+// storePrefix returns the object name prefix of a repository under a URL
+// path, e.g. "/wvc" and "myrepo" give "wvc/myrepo/".
+func storePrefix(path, repo string) string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return repo + "/"
+	}
+	return path + "/" + repo + "/"
+}
+
+// endpointParam returns the endpoint query parameter of a store URL, used to
+// point a driver at an emulator.
+func endpointParam(u *url.URL) string {
+	return strings.TrimRight(u.Query().Get("endpoint"), "/")
+}
@@ -0,0 +1,97 @@
+package blobstore
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testBlobStore runs the BlobStore contract against an empty store. Cloud
+// stores are created with a small chunk size so uploads span several chunks.
+func testBlobStore(t *testing.T, s BlobStore) {
+	ctx := context.Background()
+
+	t.Run("PutAndGet", func(t *testing.T) {
+		for _, data := range [][]byte{
+			[]byte("multi-chunk vector data"),
+			[]byte("16 bytes exactly"),
+			{},
+		} {
+			hash := hashBytes(data)
+			require.NoError(t, s.Put(ctx, hash, bytes.NewReader(data), 7))
+
+			reader, dims, err := s.Get(ctx, hash)
+			require.NoError(t, err)
+			got, err := io.ReadAll(reader)
+			reader.Close()
+			require.NoError(t, err)
+			assert.Equal(t, 7, dims)
+			assert.Equal(t, data, got)
+
+			require.NoError(t, s.Put(ctx, hash, bytes.NewReader(data), 7)) // no-op
+			require.NoError(t, s.Delete(ctx, hash))
+		}
+	})
+
+	t.Run("HashMismatch", func(t *testing.T) {
+		wrongHash := hashBytes([]byte("other data"))
+		err := s.Put(ctx, wrongHash, bytes.NewReader([]byte("a vector spanning chunks")), 1)
+		assert.ErrorIs(t, err, ErrHashMismatch)
+
+		has, err := s.Has(ctx, wrongHash)
+		require.NoError(t, err)
+		assert.False(t, has, "a rejected upload must not become visible")
+	})
+
+	t.Run("InvalidHash", func(t *testing.T) {
+		assert.Error(t, s.Put(ctx, "nonexistent", bytes.NewReader(nil), 1))
+		has, err := s.Has(ctx, "nonexistent")
+		require.NoError(t, err)
+		assert.False(t, has)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		missing := hashBytes([]byte("missing"))
+		_, _, err := s.Get(ctx, missing)
+		assert.ErrorIs(t, err, ErrBlobNotFound)
+		assert.NoError(t, s.Delete(ctx, missing))
+	})
+
+	t.Run("ListAndDelete", func(t *testing.T) {
+		var expected []string
+		for i := 0; i < 5; i++ {
+			data := []byte{byte(i), byte(i + 10), byte(i + 20)}
+			hash := hashBytes(data)
+			require.NoError(t, s.Put(ctx, hash, bytes.NewReader(data), 1))
+			expected = append(expected, hash)
+		}
+
+		hashes, err := s.ListHashes(ctx)
+		require.NoError(t, err)
+		sort.Strings(hashes)
+		sort.Strings(expected)
+		assert.Equal(t, expected, hashes)
+
+		count, err := s.TotalCount(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 5, count)
+		size, err := s.TotalSize(ctx)
+		require.NoError(t, err)
+		assert.Positive(t, size)
+
+		for _, hash := range expected {
+			require.NoError(t, s.Delete(ctx, hash))
+		}
+		count, err = s.TotalCount(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 0, count)
+		size, err = s.TotalSize(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, size)
+	})
+}
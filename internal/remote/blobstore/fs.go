@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -11,24 +12,58 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+
+	"github.com/kilupskalvis/wvc/internal/fsutil"
 )
 
 // validHash matches a lowercase hex-encoded SHA256 hash (64 characters).
 var validHash = regexp.MustCompile(`^[0-9a-f]{64}$`)
 
+// ErrCaseCollision is returned when a blob's file name collides, differing
+// only in case, with another file on a case-insensitive filesystem.
+var ErrCaseCollision = errors.New("blob path collides with a file differing only in case")
+
 // FSStore implements BlobStore using the local filesystem.
 // Blobs are stored in a two-level directory structure using the first two
 // characters of the hash as a prefix directory.
 type FSStore struct {
 	root string
+	sync fsutil.SyncPolicy
+	// caseInsensitive is set when the filesystem folds case, as Windows and
+	// macOS do by default
+	caseInsensitive bool
+}
+
+// FSOptions tunes an FSStore.
+type FSOptions struct {
+	// Sync is the fsync policy for written blobs; fsutil.SyncFile when empty
+	Sync fsutil.SyncPolicy
 }
 
 // NewFSStore creates a filesystem-backed blob store rooted at the given directory.
 func NewFSStore(root string) (*FSStore, error) {
+	return NewFSStoreWithOptions(root, FSOptions{})
+}
+
+// NewFSStoreWithOptions creates a filesystem-backed blob store with the given
+// options. The root is made absolute, in long-path form on Windows.
+func NewFSStoreWithOptions(root string, opts FSOptions) (*FSStore, error) {
+	root, err := fsutil.LongPath(root)
+	if err != nil {
+		return nil, fmt.Errorf("resolve blob root: %w", err)
+	}
 	if err := os.MkdirAll(root, 0755); err != nil {
 		return nil, fmt.Errorf("create blob root: %w", err)
 	}
-	return &FSStore{root: root}, nil
+	caseInsensitive, err := fsutil.CaseInsensitive(root)
+	if err != nil {
+		return nil, err
+	}
+	policy := opts.Sync
+	if policy == "" {
+		policy = fsutil.SyncFile
+	}
+	return &FSStore{root: root, sync: policy, caseInsensitive: caseInsensitive}, nil
 }
 
 // Has checks whether a blob exists.
@@ -84,6 +119,9 @@ func (s *FSStore) Put(_ context.Context, hash string, r io.Reader, dims int) err
 	// Check if already exists — only skip if BOTH blob and meta exist
 	blobExists := false
 	if _, err := os.Stat(blobPath); err == nil {
+		if err := s.checkCaseCollision(blobPath); err != nil {
+			return err
+		}
 		if _, err := os.Stat(metaPath); err == nil {
 			return nil // both exist, idempotent
 		}
@@ -115,6 +153,11 @@ func (s *FSStore) Put(_ context.Context, hash string, r io.Reader, dims int) err
 			os.Remove(tmpPath)
 			return fmt.Errorf("write blob data: %w", err)
 		}
+		if err := fsutil.Fsync(tmpFile, s.sync); err != nil {
+			tmpFile.Close()
+			os.Remove(tmpPath)
+			return fmt.Errorf("sync blob data: %w", err)
+		}
 
 		if err := tmpFile.Close(); err != nil {
 			os.Remove(tmpPath)
@@ -129,7 +172,7 @@ func (s *FSStore) Put(_ context.Context, hash string, r io.Reader, dims int) err
 		}
 
 		// Atomic rename
-		if err := os.Rename(tmpPath, blobPath); err != nil {
+		if err := fsutil.Rename(tmpPath, blobPath); err != nil {
 			os.Remove(tmpPath)
 			return fmt.Errorf("rename blob: %w", err)
 		}
@@ -146,14 +189,20 @@ func (s *FSStore) Put(_ context.Context, hash string, r io.Reader, dims int) err
 		os.Remove(tmpMetaPath)
 		return fmt.Errorf("write temp meta: %w", err)
 	}
+	if err := fsutil.Fsync(tmpMeta, s.sync); err != nil {
+		tmpMeta.Close()
+		os.Remove(tmpMetaPath)
+		return fmt.Errorf("sync temp meta: %w", err)
+	}
 	if err := tmpMeta.Close(); err != nil {
 		os.Remove(tmpMetaPath)
 		return fmt.Errorf("close temp meta: %w", err)
 	}
-	if err := os.Rename(tmpMetaPath, metaPath); err != nil {
+	if err := fsutil.Rename(tmpMetaPath, metaPath); err != nil {
 		os.Remove(tmpMetaPath)
 		return fmt.Errorf("rename meta: %w", err)
 	}
+	fsutil.FsyncDir(dir, s.sync)
 
 	return nil
 }
@@ -163,8 +212,8 @@ func (s *FSStore) Delete(_ context.Context, hash string) error {
 	if !validHash.MatchString(hash) {
 		return nil
 	}
-	os.Remove(s.blobPath(hash))
-	os.Remove(s.metaPath(hash))
+	_ = fsutil.Remove(s.blobPath(hash))
+	_ = fsutil.Remove(s.metaPath(hash))
 	return nil
 }
 
@@ -185,6 +234,24 @@ func (s *FSStore) TotalCount(_ context.Context) (int, error) {
 	return count, err
 }
 
+// TotalSize returns the size of the stored blobs by scanning the directory
+// tree. Metadata files are not counted.
+func (s *FSStore) TotalSize(_ context.Context) (int64, error) {
+	var size int64
+
+	err := filepath.Walk(s.root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && !strings.HasSuffix(path, ".meta") && !strings.HasPrefix(info.Name(), ".") {
+			size += info.Size()
+		}
+		return nil
+	})
+
+	return size, err
+}
+
 // ListHashes returns all blob hashes by scanning the directory tree.
 func (s *FSStore) ListHashes(_ context.Context) ([]string, error) {
 	var hashes []string
@@ -219,6 +286,23 @@ func (s *FSStore) blobPath(hash string) string {
 	return filepath.Join(s.root, hash[:2], hash[2:])
 }
 
+// checkCaseCollision fails with ErrCaseCollision when, on a case-insensitive
+// filesystem, path resolved to a file whose name differs from it in case.
+// Blob names are lowercase hex, so such a file was not written by the store.
+func (s *FSStore) checkCaseCollision(path string) error {
+	if !s.caseInsensitive {
+		return nil
+	}
+	other, err := fsutil.CaseCollision(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return fmt.Errorf("check blob name case: %w", err)
+	}
+	if other != "" {
+		return fmt.Errorf("%s and %s: %w", filepath.Base(path), other, ErrCaseCollision)
+	}
+	return nil
+}
+
 // metaPath returns the filesystem path for a blob's metadata.
 func (s *FSStore) metaPath(hash string) string {
 	return s.blobPath(hash) + ".meta"
@@ -6,8 +6,13 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/kilupskalvis/wvc/internal/fsutil"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -137,3 +142,42 @@ func TestFSStore_TotalCount(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, 3, count)
 }
+
+func TestFSStore_PutDetectsCaseCollision(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+	if s.caseInsensitive {
+		t.Skip("needs a case-sensitive filesystem to create the colliding file")
+	}
+
+	data := []byte("test")
+	hash := hashBytes(data)
+	require.NoError(t, s.Put(ctx, hash, bytes.NewReader(data), 1))
+
+	// A file differing only in case, as copied from a case-sensitive system,
+	// would answer lookups for the blob on a case-insensitive filesystem
+	foreign := filepath.Join(s.root, hash[:2], strings.ToUpper(hash[2:]))
+	require.NoError(t, os.WriteFile(foreign, []byte("other"), 0644))
+	s.caseInsensitive = true
+
+	err := s.Put(ctx, hash, bytes.NewReader(data), 1)
+	assert.ErrorIs(t, err, ErrCaseCollision)
+}
+
+func TestFSStore_SyncPolicies(t *testing.T) {
+	ctx := context.Background()
+	for _, policy := range []fsutil.SyncPolicy{fsutil.SyncNone, fsutil.SyncFile, fsutil.SyncFull} {
+		s, err := NewFSStoreWithOptions(t.TempDir(), FSOptions{Sync: policy})
+		require.NoError(t, err)
+		assert.Equal(t, policy, s.sync)
+
+		data := []byte("vector " + string(policy))
+		require.NoError(t, s.Put(ctx, hashBytes(data), bytes.NewReader(data), 2))
+		has, err := s.Has(ctx, hashBytes(data))
+		require.NoError(t, err)
+		assert.True(t, has)
+	}
+
+	s := newTestStore(t)
+	assert.Equal(t, fsutil.SyncFile, s.sync, "contents are synced by default")
+}
@@ -0,0 +1,232 @@
+package blobstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+func init() {
+	Register("gs", openGCS)
+}
+
+// GCSStore implements BlobStore on Google Cloud Storage. Blobs are objects
+// named <prefix><hash> with their dimensions in the "dims" custom metadata
+// field. Uploads are only finalized after the data has been verified, so a
+// bad upload never becomes visible.
+type GCSStore struct {
+	bucket    *storage.BucketHandle
+	prefix    string
+	signing   bool
+	chunkSize int
+}
+
+// NewGCSStore creates a blob store for the objects under prefix in bucket,
+// accessed through client. Signed URLs are enabled with EnableSignedURLs.
+func NewGCSStore(client *storage.Client, bucket, prefix string) *GCSStore {
+	return &GCSStore{
+		bucket:    client.Bucket(bucket),
+		prefix:    prefix,
+		chunkSize: defaultChunkSize,
+	}
+}
+
+// openGCS opens gs://bucket/path as the objects under path/<repo>/.
+// STORAGE_EMULATOR_HOST or an endpoint query parameter selects an emulator,
+// which is accessed without credentials. Otherwise requests use the token in
+// GOOGLE_OAUTH_ACCESS_TOKEN or the application default credentials: the key
+// in GOOGLE_APPLICATION_CREDENTIALS or the workload identity of the instance.
+func openGCS(u *url.URL, repo string) (BlobStore, error) {
+	if u.Host == "" {
+		return nil, fmt.Errorf("GCS blob store URL must name a bucket like gs://bucket/prefix: %q", u.String())
+	}
+	prefix := storePrefix(u.Path, repo)
+
+	opts := []option.ClientOption{storage.WithJSONReads()}
+	emulator := os.Getenv("STORAGE_EMULATOR_HOST") != ""
+	if endpoint := endpointParam(u); endpoint != "" {
+		opts = append(opts, option.WithEndpoint(endpoint+"/storage/v1/"), option.WithoutAuthentication())
+		emulator = true
+	} else if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" && !emulator {
+		opts = append(opts, option.WithTokenSource(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})))
+	}
+
+	client, err := storage.NewClient(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("create GCS client: %w", err)
+	}
+	s := NewGCSStore(client, u.Host, prefix)
+	if !emulator {
+		s.EnableSignedURLs()
+	}
+	return s, nil
+}
+
+// Has checks whether a blob exists.
+func (s *GCSStore) Has(ctx context.Context, hash string) (bool, error) {
+	if !validHash.MatchString(hash) {
+		return false, nil
+	}
+	_, err := s.object(hash).Attrs(ctx)
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, storage.ErrObjectNotExist):
+		return false, nil
+	default:
+		return false, fmt.Errorf("stat blob %s: %w", hash, err)
+	}
+}
+
+// Get opens a blob for reading. Returns the data reader and dimensions.
+// Returns ErrBlobNotFound if the blob does not exist.
+func (s *GCSStore) Get(ctx context.Context, hash string) (io.ReadCloser, int, error) {
+	if !validHash.MatchString(hash) {
+		return nil, 0, ErrBlobNotFound
+	}
+
+	dims, err := s.dims(ctx, hash)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	r, err := s.object(hash).NewReader(ctx)
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotExist) {
+			return nil, 0, ErrBlobNotFound
+		}
+		return nil, 0, fmt.Errorf("open blob %s: %w", hash, err)
+	}
+	return r, dims, nil
+}
+
+// dims reads a blob's dimensions from its object metadata.
+func (s *GCSStore) dims(ctx context.Context, hash string) (int, error) {
+	attrs, err := s.object(hash).Attrs(ctx)
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotExist) {
+			return 0, ErrBlobNotFound
+		}
+		return 0, fmt.Errorf("read blob meta %s: %w", hash, err)
+	}
+	dims, err := strconv.Atoi(attrs.Metadata["dims"])
+	if err != nil {
+		return 0, fmt.Errorf("read blob meta %s: invalid dims %q", hash, attrs.Metadata["dims"])
+	}
+	return dims, nil
+}
+
+// Put stores a blob. The data is read from r and verified against the hash.
+// Idempotent — if the blob exists, this is a no-op.
+func (s *GCSStore) Put(ctx context.Context, hash string, r io.Reader, dims int) error {
+	if !validHash.MatchString(hash) {
+		return fmt.Errorf("invalid blob hash: %q", hash)
+	}
+	if exists, err := s.Has(ctx, hash); err != nil || exists {
+		return err
+	}
+
+	// Cancelling the upload's context abandons it without creating the object
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	w := s.object(hash).If(storage.Conditions{DoesNotExist: true}).NewWriter(ctx)
+	w.ChunkSize = s.chunkSize
+	w.Metadata = map[string]string{"dims": strconv.Itoa(dims)}
+
+	hasher := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(w, hasher), r); err != nil {
+		cancel()
+		w.Close()
+		return fmt.Errorf("upload blob %s: %w", hash, err)
+	}
+	if computed := hex.EncodeToString(hasher.Sum(nil)); computed != hash {
+		cancel()
+		w.Close()
+		return fmt.Errorf("expected %s, got %s: %w", hash, computed, ErrHashMismatch)
+	}
+
+	if err := w.Close(); err != nil {
+		var apiErr *googleapi.Error
+		if errors.As(err, &apiErr) && apiErr.Code == http.StatusPreconditionFailed {
+			// Stored concurrently by another upload of the same blob
+			return nil
+		}
+		return fmt.Errorf("upload blob %s: %w", hash, err)
+	}
+	return nil
+}
+
+// Delete removes a blob. No error if it doesn't exist.
+func (s *GCSStore) Delete(ctx context.Context, hash string) error {
+	if !validHash.MatchString(hash) {
+		return nil
+	}
+	if err := s.object(hash).Delete(ctx); err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+		return fmt.Errorf("delete blob %s: %w", hash, err)
+	}
+	return nil
+}
+
+// TotalCount returns the number of stored blobs.
+func (s *GCSStore) TotalCount(ctx context.Context) (int, error) {
+	hashes, err := s.ListHashes(ctx)
+	return len(hashes), err
+}
+
+// ListHashes returns all blob hashes by listing the objects under the prefix.
+func (s *GCSStore) ListHashes(ctx context.Context) ([]string, error) {
+	var hashes []string
+	err := s.list(ctx, func(hash string, _ int64) {
+		hashes = append(hashes, hash)
+	})
+	return hashes, err
+}
+
+// TotalSize returns the size of the objects under the prefix.
+func (s *GCSStore) TotalSize(ctx context.Context) (int64, error) {
+	var total int64
+	err := s.list(ctx, func(_ string, size int64) {
+		total += size
+	})
+	return total, err
+}
+
+// list calls fn with the hash and size of each blob under the prefix.
+func (s *GCSStore) list(ctx context.Context, fn func(hash string, size int64)) error {
+	query := &storage.Query{Prefix: s.prefix}
+	if err := query.SetAttrSelection([]string{"Name", "Size"}); err != nil {
+		return err
+	}
+	it := s.bucket.Objects(ctx, query)
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("list blobs: %w", err)
+		}
+		if hash := strings.TrimPrefix(attrs.Name, s.prefix); validHash.MatchString(hash) {
+			fn(hash, attrs.Size)
+		}
+	}
+}
+
+// object returns the handle of a blob's object.
+func (s *GCSStore) object(hash string) *storage.ObjectHandle {
+	return s.bucket.Object(s.prefix + hash)
+}
@@ -0,0 +1,271 @@
+package blobstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"cloud.google.com/go/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+)
+
+func TestGCSStore_Fake(t *testing.T) {
+	fake := newFakeGCS(t, "wvc-test")
+	s := NewGCSStore(fake.client(t), "wvc-test", "wvc/myrepo/")
+	testBlobStore(t, s)
+}
+
+func TestGCSStore_ResumableUpload(t *testing.T) {
+	fake := newFakeGCS(t, "wvc-test")
+	s := NewGCSStore(fake.client(t), "wvc-test", "wvc/myrepo/")
+	s.chunkSize = 256 << 10
+	ctx := context.Background()
+
+	// Larger than a chunk, so it is sent in a resumable session
+	data := bytes.Repeat([]byte("vector data "), 64<<10)
+	hash := hashBytes(data)
+	require.NoError(t, s.Put(ctx, hash, bytes.NewReader(data), 2))
+	reader, dims, err := s.Get(ctx, hash)
+	require.NoError(t, err)
+	got, err := io.ReadAll(reader)
+	reader.Close()
+	require.NoError(t, err)
+	assert.Equal(t, 2, dims)
+	assert.Equal(t, data, got)
+
+	err = s.Put(ctx, hashBytes([]byte("other")), bytes.NewReader(data), 2)
+	assert.ErrorIs(t, err, ErrHashMismatch)
+	hashes, err := s.ListHashes(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{hash}, hashes, "a rejected upload must not become visible")
+}
+
+func TestOpenGCS(t *testing.T) {
+	t.Setenv("STORAGE_EMULATOR_HOST", "localhost:4443")
+
+	u, err := ParseURL("gs://bucket/some/prefix")
+	require.NoError(t, err)
+	s, err := Open(u, "myrepo")
+	require.NoError(t, err)
+
+	gcs := s.(*GCSStore)
+	assert.Equal(t, "bucket", gcs.bucket.BucketName())
+	assert.Equal(t, "some/prefix/myrepo/", gcs.prefix)
+	assert.False(t, gcs.signing, "emulators cannot sign URLs")
+
+	u, err = ParseURL("gs:///prefix")
+	require.NoError(t, err)
+	_, err = Open(u, "myrepo")
+	assert.ErrorContains(t, err, "must name a bucket")
+}
+
+// TestGCSStore_Emulator runs against fake-gcs-server when
+// WVC_TEST_GCS_EMULATOR is set, e.g. to http://localhost:4443.
+func TestGCSStore_Emulator(t *testing.T) {
+	endpoint := os.Getenv("WVC_TEST_GCS_EMULATOR")
+	if endpoint == "" {
+		t.Skip("WVC_TEST_GCS_EMULATOR not set")
+	}
+
+	bucket := "wvc-test-" + strconv.Itoa(os.Getpid())
+	body, _ := json.Marshal(map[string]string{"name": bucket})
+	resp, err := http.Post(endpoint+"/storage/v1/b?project=test", "application/json", bytes.NewReader(body))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Contains(t, []int{http.StatusOK, http.StatusConflict}, resp.StatusCode)
+
+	u, err := ParseURL("gs://" + bucket + "/wvc?endpoint=" + url.QueryEscape(endpoint))
+	require.NoError(t, err)
+	s, err := Open(u, "myrepo")
+	require.NoError(t, err)
+	testBlobStore(t, s)
+}
+
+// fakeGCS implements the subset of the GCS JSON API used by GCSStore.
+type fakeGCS struct {
+	*httptest.Server
+	bucket string
+
+	mu       sync.Mutex
+	objects  map[string]*fakeGCSObject
+	sessions map[string]*fakeGCSObject
+	nextID   int
+}
+
+type fakeGCSObject struct {
+	Bucket   string            `json:"bucket"`
+	Name     string            `json:"name"`
+	Metadata map[string]string `json:"metadata"`
+	data     []byte
+}
+
+func newFakeGCS(t *testing.T, bucket string) *fakeGCS {
+	f := &fakeGCS{
+		bucket:   bucket,
+		objects:  make(map[string]*fakeGCSObject),
+		sessions: make(map[string]*fakeGCSObject),
+	}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.Close)
+	return f
+}
+
+// client returns an unauthenticated storage client for the fake server.
+func (f *fakeGCS) client(t *testing.T) *storage.Client {
+	client, err := storage.NewClient(context.Background(),
+		option.WithEndpoint(f.URL+"/storage/v1/"), option.WithoutAuthentication(), storage.WithJSONReads())
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func (f *fakeGCS) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := r.URL.EscapedPath()
+	objectsPath := "/storage/v1/b/" + f.bucket + "/o"
+	switch {
+	case r.Method == http.MethodPost && path == "/token":
+		// OAuth token endpoint for service account keys
+		json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "fake", "token_type": "Bearer", "expires_in": 3600})
+
+	case r.Method == http.MethodPost && path == "/upload"+objectsPath && r.URL.Query().Get("uploadType") == "multipart":
+		obj, err := readMultipartObject(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if _, exists := f.objects[obj.Name]; exists {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		f.objects[obj.Name] = obj
+		json.NewEncoder(w).Encode(obj)
+
+	case r.Method == http.MethodPost && path == "/upload"+objectsPath:
+		var obj fakeGCSObject
+		if err := json.NewDecoder(r.Body).Decode(&obj); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.nextID++
+		id := strconv.Itoa(f.nextID)
+		f.sessions[id] = &obj
+		w.Header().Set("Location", f.URL+"/upload/session/"+id)
+
+	case strings.HasPrefix(path, "/upload/session/"):
+		id := strings.TrimPrefix(path, "/upload/session/")
+		obj, ok := f.sessions[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodDelete {
+			delete(f.sessions, id)
+			w.WriteHeader(499)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		obj.data = append(obj.data, data...)
+		if strings.HasSuffix(r.Header.Get("Content-Range"), "/*") {
+			// The client asks for this in place of 308 Resume Incomplete
+			w.Header().Set("X-Http-Status-Code-Override", "308")
+			return
+		}
+		delete(f.sessions, id)
+		if _, exists := f.objects[obj.Name]; exists {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		f.objects[obj.Name] = obj
+		json.NewEncoder(w).Encode(obj)
+
+	case r.Method == http.MethodGet && path == objectsPath:
+		f.list(w, r)
+
+	case strings.HasPrefix(path, objectsPath+"/"):
+		name, _ := url.PathUnescape(strings.TrimPrefix(path, objectsPath+"/"))
+		obj, ok := f.objects[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch {
+		case r.Method == http.MethodDelete:
+			delete(f.objects, name)
+			w.WriteHeader(http.StatusNoContent)
+		case r.URL.Query().Get("alt") == "media":
+			w.Header().Set("Content-Length", strconv.Itoa(len(obj.data)))
+			w.Write(obj.data)
+		default:
+			json.NewEncoder(w).Encode(obj)
+		}
+
+	default:
+		http.Error(w, fmt.Sprintf("unexpected %s %s", r.Method, path), http.StatusBadRequest)
+	}
+}
+
+// readMultipartObject reads the metadata and data of a single-request upload.
+func readMultipartObject(r *http.Request) (*fakeGCSObject, error) {
+	_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, err
+	}
+	parts := multipart.NewReader(r.Body, params["boundary"])
+	part, err := parts.NextPart()
+	if err != nil {
+		return nil, err
+	}
+	var obj fakeGCSObject
+	if err := json.NewDecoder(part).Decode(&obj); err != nil {
+		return nil, err
+	}
+	if part, err = parts.NextPart(); err != nil {
+		return nil, err
+	}
+	if obj.data, err = io.ReadAll(part); err != nil {
+		return nil, err
+	}
+	return &obj, nil
+}
+
+// list returns objects two per page to exercise pagination.
+func (f *fakeGCS) list(w http.ResponseWriter, r *http.Request) {
+	var names []string
+	for name := range f.objects {
+		if strings.HasPrefix(name, r.URL.Query().Get("prefix")) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	start, _ := strconv.Atoi(r.URL.Query().Get("pageToken"))
+	end := min(start+2, len(names))
+	page := map[string]interface{}{}
+	var items []map[string]string
+	for _, name := range names[start:end] {
+		items = append(items, map[string]string{"name": name, "size": strconv.Itoa(len(f.objects[name].data))})
+	}
+	page["items"] = items
+	if end < len(names) {
+		page["nextPageToken"] = strconv.Itoa(end)
+	}
+	json.NewEncoder(w).Encode(page)
+}
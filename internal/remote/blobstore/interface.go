@@ -1,10 +1,12 @@
-// Package blobstore provides content-addressable blob storage for vector data.
+// Package blobstore provides content-addressable blob storage for vector data
+// and for large object payloads offloaded from operations (stored with zero dimensions).
 package blobstore
 
 import (
 	"context"
 	"errors"
 	"io"
+	"time"
 )
 
 // ErrBlobNotFound is returned when a requested blob does not exist.
@@ -13,6 +15,10 @@ var ErrBlobNotFound = errors.New("blob not found")
 // ErrHashMismatch is returned when the computed hash of blob data does not match the expected hash.
 var ErrHashMismatch = errors.New("blob hash mismatch")
 
+// ErrSignedURLUnsupported is returned by a URLSigner that is not configured
+// with credentials able to sign URLs.
+var ErrSignedURLUnsupported = errors.New("signed URLs not supported")
+
 // BlobStore defines the contract for content-addressable binary storage.
 type BlobStore interface {
 	// Has checks whether a blob with the given hash exists.
@@ -34,4 +40,16 @@ type BlobStore interface {
 
 	// ListHashes returns all blob hashes in the store.
 	ListHashes(ctx context.Context) ([]string, error)
+
+	// TotalSize returns the bytes the stored blobs take up.
+	TotalSize(ctx context.Context) (int64, error)
+}
+
+// URLSigner is implemented by blob stores that can hand out short-lived URLs
+// for downloading a blob directly from the storage backend.
+type URLSigner interface {
+	// SignedURL returns a URL to GET the blob's data that is valid for expiry,
+	// and the vector dimensions. Returns ErrBlobNotFound if the blob does not
+	// exist and ErrSignedURLUnsupported if the store cannot sign URLs.
+	SignedURL(ctx context.Context, hash string, expiry time.Duration) (string, int, error)
 }
@@ -0,0 +1,109 @@
+package blobstore
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/kilupskalvis/wvc/internal/fsutil"
+)
+
+// Factory opens the blob store of one repository. u is the store URL from
+// the server configuration; a driver keeps each repository's blobs apart
+// under it, e.g. gs://bucket/prefix/<repo>.
+type Factory func(u *url.URL, repo string) (BlobStore, error)
+
+var (
+	driversMu sync.RWMutex
+	drivers   = make(map[string]Factory)
+)
+
+func init() {
+	Register("file", openFS)
+}
+
+// Register makes a blob store driver available under a URL scheme. It is
+// meant to be called from an init function and panics if the scheme is
+// already registered or the factory is nil.
+func Register(scheme string, factory Factory) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+
+	if factory == nil {
+		panic("blobstore: Register factory is nil")
+	}
+	if _, dup := drivers[scheme]; dup {
+		panic("blobstore: Register called twice for scheme " + scheme)
+	}
+	drivers[scheme] = factory
+}
+
+// Drivers returns the sorted list of registered schemes.
+func Drivers() []string {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+	return sortedSchemes()
+}
+
+// ParseURL parses a blob store URL and checks that its scheme has a
+// registered driver.
+func ParseURL(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid blob store URL %q: %w", rawURL, err)
+	}
+	if u.Scheme == "" {
+		return nil, fmt.Errorf("blob store URL %q has no scheme (registered: %s)", rawURL, strings.Join(Drivers(), ", "))
+	}
+	if _, err := lookup(u.Scheme); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// Open opens the blob store of a repository with the driver registered for
+// the URL's scheme.
+func Open(u *url.URL, repo string) (BlobStore, error) {
+	factory, err := lookup(u.Scheme)
+	if err != nil {
+		return nil, err
+	}
+	return factory(u, repo)
+}
+
+func lookup(scheme string) (Factory, error) {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+
+	factory, ok := drivers[scheme]
+	if !ok {
+		return nil, fmt.Errorf("unknown blob store driver %q (registered: %s)", scheme, strings.Join(sortedSchemes(), ", "))
+	}
+	return factory, nil
+}
+
+// sortedSchemes lists registered schemes; the caller holds driversMu.
+func sortedSchemes() []string {
+	schemes := make([]string, 0, len(drivers))
+	for scheme := range drivers {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// openFS opens file:///path as <path>/<repo>/blobs, the layout of the
+// server's data directory. An ?fsync= parameter sets the sync policy.
+func openFS(u *url.URL, repo string) (BlobStore, error) {
+	if (u.Host != "" && u.Host != "localhost") || u.Path == "" || !filepath.IsAbs(u.Path) {
+		return nil, fmt.Errorf("file blob store URL must be an absolute path like file:///var/lib/wvc: %q", u.String())
+	}
+	policy, err := fsutil.ParseSyncPolicy(u.Query().Get("fsync"), fsutil.SyncFile)
+	if err != nil {
+		return nil, fmt.Errorf("file blob store URL %q: %w", u.String(), err)
+	}
+	return NewFSStoreWithOptions(filepath.Join(u.Path, repo, "blobs"), FSOptions{Sync: policy})
+}
@@ -0,0 +1,89 @@
+package blobstore
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/kilupskalvis/wvc/internal/fsutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpen_FileDriver(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+
+	u, err := ParseURL("file://" + root)
+	require.NoError(t, err)
+	s, err := Open(u, "myrepo")
+	require.NoError(t, err)
+
+	data := []byte("vector")
+	require.NoError(t, s.Put(ctx, hashBytes(data), bytes.NewReader(data), 1))
+
+	// Same layout as the server data directory
+	fs := &FSStore{root: filepath.Join(root, "myrepo", "blobs")}
+	has, err := fs.Has(ctx, hashBytes(data))
+	require.NoError(t, err)
+	assert.True(t, has)
+}
+
+func TestOpen_FileDriverSyncPolicy(t *testing.T) {
+	root := t.TempDir()
+
+	u, err := ParseURL("file://" + root + "?fsync=none")
+	require.NoError(t, err)
+	s, err := Open(u, "myrepo")
+	require.NoError(t, err)
+	assert.Equal(t, fsutil.SyncNone, s.(*FSStore).sync)
+
+	u, err = ParseURL("file://" + root + "?fsync=sometimes")
+	require.NoError(t, err)
+	_, err = Open(u, "myrepo")
+	assert.ErrorContains(t, err, "unknown fsync policy")
+}
+
+func TestOpen_FileDriverRejectsRelativePath(t *testing.T) {
+	u, err := ParseURL("file://relative/path")
+	require.NoError(t, err)
+	_, err = Open(u, "myrepo")
+	assert.ErrorContains(t, err, "absolute path")
+}
+
+func TestRegister_CustomDriver(t *testing.T) {
+	var gotURL *url.URL
+	var gotRepo string
+	Register("test-mem", func(u *url.URL, repo string) (BlobStore, error) {
+		gotURL, gotRepo = u, repo
+		return newTestStore(t), nil
+	})
+	defer func() {
+		driversMu.Lock()
+		delete(drivers, "test-mem")
+		driversMu.Unlock()
+	}()
+
+	assert.Contains(t, Drivers(), "test-mem")
+
+	u, err := ParseURL("test-mem://bucket/prefix")
+	require.NoError(t, err)
+	_, err = Open(u, "myrepo")
+	require.NoError(t, err)
+	assert.Equal(t, "bucket", gotURL.Host)
+	assert.Equal(t, "myrepo", gotRepo)
+
+	assert.Panics(t, func() {
+		Register("test-mem", func(*url.URL, string) (BlobStore, error) { return nil, nil })
+	})
+}
+
+func TestParseURL_UnknownScheme(t *testing.T) {
+	_, err := ParseURL("nosuch://bucket")
+	assert.ErrorContains(t, err, `unknown blob store driver "nosuch"`)
+
+	_, err = ParseURL("/var/lib/wvc")
+	assert.ErrorContains(t, err, "has no scheme")
+}
@@ -0,0 +1,109 @@
+package blobstore
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/service"
+)
+
+// maxSignedURLExpiry is the longest lifetime GCS accepts for a V4 signed URL.
+const maxSignedURLExpiry = 7 * 24 * time.Hour
+
+// EnableSignedURLs lets SignedURL sign with the client's credentials: the
+// key of a service account key file, or else the IAM signBlob API as the
+// instance's service account, which needs the iam.serviceAccounts.signBlob
+// permission on itself.
+func (s *GCSStore) EnableSignedURLs() {
+	s.signing = true
+}
+
+// SignedURL returns a V4 signed URL for downloading a blob.
+func (s *GCSStore) SignedURL(ctx context.Context, hash string, expiry time.Duration) (string, int, error) {
+	if !s.signing {
+		return "", 0, ErrSignedURLUnsupported
+	}
+	if !validHash.MatchString(hash) {
+		return "", 0, ErrBlobNotFound
+	}
+	dims, err := s.dims(ctx, hash)
+	if err != nil {
+		return "", 0, err
+	}
+	signed, err := s.bucket.SignedURL(s.prefix+hash, &storage.SignedURLOptions{
+		Scheme:  storage.SigningSchemeV4,
+		Method:  http.MethodGet,
+		Expires: time.Now().Add(min(expiry, maxSignedURLExpiry)),
+	})
+	if err != nil {
+		return "", 0, fmt.Errorf("sign URL for blob %s: %w", hash, err)
+	}
+	return signed, dims, nil
+}
+
+// SignedURL returns a blob URL with a read-only user delegation SAS. It
+// requires token credentials: a store configured with a SAS token cannot
+// derive narrower tokens and does not hand its own out.
+func (s *AzureStore) SignedURL(ctx context.Context, hash string, expiry time.Duration) (string, int, error) {
+	if s.service == nil {
+		return "", 0, ErrSignedURLUnsupported
+	}
+	if !validHash.MatchString(hash) {
+		return "", 0, ErrBlobNotFound
+	}
+
+	client := s.blob(hash)
+	props, err := client.GetProperties(ctx, nil)
+	if err != nil {
+		if azureStatus(err) == http.StatusNotFound {
+			return "", 0, ErrBlobNotFound
+		}
+		return "", 0, fmt.Errorf("stat blob %s: %w", hash, err)
+	}
+	dims, err := azureDims(hash, props.Metadata)
+	if err != nil {
+		return "", 0, err
+	}
+
+	now := time.Now().UTC()
+	key, err := s.delegationKey(ctx, now)
+	if err != nil {
+		return "", 0, err
+	}
+	query, err := sas.BlobSignatureValues{
+		Protocol:      sas.ProtocolHTTPS,
+		StartTime:     now.Add(-5 * time.Minute),
+		ExpiryTime:    now.Add(min(expiry, maxSignedURLExpiry)),
+		Permissions:   (&sas.BlobPermissions{Read: true}).String(),
+		ContainerName: s.name,
+		BlobName:      s.prefix + hash,
+	}.SignWithUserDelegation(key)
+	if err != nil {
+		return "", 0, fmt.Errorf("sign URL for blob %s: %w", hash, err)
+	}
+	return client.URL() + "?" + query.Encode(), dims, nil
+}
+
+// delegationKey returns a cached user delegation key, requesting a new one
+// when it would expire before a URL signed now.
+func (s *AzureStore) delegationKey(ctx context.Context, now time.Time) (*service.UserDelegationCredential, error) {
+	s.keyMu.Lock()
+	defer s.keyMu.Unlock()
+	if s.key != nil && s.keyExpiry.Sub(now) > maxSignedURLExpiry {
+		return s.key, nil
+	}
+
+	start := now.Add(-5 * time.Minute).Format(sas.TimeFormat)
+	expiry := now.Add(2 * maxSignedURLExpiry)
+	end := expiry.Format(sas.TimeFormat)
+	key, err := s.service.GetUserDelegationCredential(ctx, service.KeyInfo{Start: &start, Expiry: &end}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("get user delegation key: %w", err)
+	}
+	s.key, s.keyExpiry = key, expiry
+	return key, nil
+}
@@ -0,0 +1,124 @@
+package blobstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+)
+
+func TestGCSStore_SignedURL(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	fake := newFakeGCS(t, "wvc-test")
+	keyJSON, _ := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"client_email": "wvc@project.iam.gserviceaccount.com",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"token_uri":    fake.URL + "/token",
+	})
+	ctx := context.Background()
+	client, err := storage.NewClient(ctx, option.WithEndpoint(fake.URL+"/storage/v1/"), option.WithCredentialsJSON(keyJSON), storage.WithJSONReads())
+	require.NoError(t, err)
+	defer client.Close()
+	s := NewGCSStore(client, "wvc-test", "wvc/myrepo/")
+	data := []byte("signed vector")
+	hash := hashBytes(data)
+	require.NoError(t, s.Put(ctx, hash, bytes.NewReader(data), 3))
+
+	_, _, err = s.SignedURL(ctx, hash, time.Minute)
+	assert.ErrorIs(t, err, ErrSignedURLUnsupported)
+	s.EnableSignedURLs()
+
+	_, _, err = s.SignedURL(ctx, hashBytes([]byte("missing")), time.Minute)
+	assert.ErrorIs(t, err, ErrBlobNotFound)
+
+	signed, dims, err := s.SignedURL(ctx, hash, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 3, dims)
+
+	u, err := url.Parse(signed)
+	require.NoError(t, err)
+	assert.Equal(t, "/wvc-test/wvc/myrepo/"+hash, u.Path)
+	q := u.Query()
+	assert.Equal(t, "GOOG4-RSA-SHA256", q.Get("X-Goog-Algorithm"))
+	assert.True(t, strings.HasPrefix(q.Get("X-Goog-Credential"), "wvc@project.iam.gserviceaccount.com/"))
+	assert.Contains(t, []string{"59", "60"}, q.Get("X-Goog-Expires"))
+	assert.Equal(t, "host", q.Get("X-Goog-SignedHeaders"))
+	sig, err := hex.DecodeString(q.Get("X-Goog-Signature"))
+	require.NoError(t, err)
+	assert.Len(t, sig, key.Size(), "an RSA signature with the account's key")
+}
+
+func TestAzureStore_SignedURL(t *testing.T) {
+	keyRequests := 0
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch {
+		case r.Method == http.MethodPost && r.URL.Query().Get("comp") == "userdelegationkey":
+			keyRequests++
+			w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?><UserDelegationKey>` +
+				`<SignedOid>oid</SignedOid><SignedTid>tid</SignedTid>` +
+				`<SignedStart>2026-01-01T00:00:00Z</SignedStart><SignedExpiry>2026-01-15T00:00:00Z</SignedExpiry>` +
+				`<SignedService>b</SignedService><SignedVersion>2021-08-06</SignedVersion>` +
+				`<Value>` + base64.StdEncoding.EncodeToString([]byte("delegation-key")) + `</Value></UserDelegationKey>`))
+		case r.Method == http.MethodHead && r.URL.Path == "/devstoreaccount1/wvc/p/"+hashBytes([]byte("x")):
+			w.Header().Set("x-ms-meta-dims", "5")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	// Token credentials are only sent over TLS
+	useHTTPClient(t, srv.Client())
+
+	ctx := context.Background()
+	withSAS, err := NewAzureStore(srv.URL+"/devstoreaccount1/wvc", "p/", "sig=test", nil)
+	require.NoError(t, err)
+	_, _, err = withSAS.SignedURL(ctx, hashBytes([]byte("x")), time.Minute)
+	assert.ErrorIs(t, err, ErrSignedURLUnsupported, "the store's own SAS token is never handed out")
+
+	s, err := NewAzureStore(srv.URL+"/devstoreaccount1/wvc", "p/", "", staticCredential("secret"))
+	require.NoError(t, err)
+	assert.Equal(t, "wvc", s.name)
+
+	_, _, err = s.SignedURL(ctx, hashBytes([]byte("missing")), time.Minute)
+	assert.ErrorIs(t, err, ErrBlobNotFound)
+
+	for i := 0; i < 2; i++ {
+		signed, dims, err := s.SignedURL(ctx, hashBytes([]byte("x")), time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, 5, dims)
+
+		u, err := url.Parse(signed)
+		require.NoError(t, err)
+		q := u.Query()
+		assert.Equal(t, "r", q.Get("sp"))
+		assert.Equal(t, "b", q.Get("sr"))
+		assert.Equal(t, "oid", q.Get("skoid"))
+		assert.Equal(t, "https", q.Get("spr"), "signed URLs only work over HTTPS")
+		assert.NotEmpty(t, q.Get("sig"))
+	}
+	assert.Equal(t, 1, keyRequests, "the delegation key is cached")
+}
@@ -0,0 +1,72 @@
+package remote
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+)
+
+// BloomFilter is a compact probabilistic summary of a blob set. MayContain
+// never returns false for an added hash, so a negative answer proves the
+// server does not have the blob and the client can skip asking about it.
+type BloomFilter struct {
+	M    uint64 `json:"m"` // number of bits
+	K    int    `json:"k"` // number of hash functions
+	Bits []byte `json:"bits"`
+}
+
+// NewBloomFilter sizes a filter for n items at the given false-positive rate.
+func NewBloomFilter(n int, fpRate float64) *BloomFilter {
+	if n < 1 {
+		n = 1
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		fpRate = 0.01
+	}
+
+	m := uint64(math.Ceil(-float64(n) * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	if m < 64 {
+		m = 64
+	}
+	k := int(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+
+	return &BloomFilter{M: m, K: k, Bits: make([]byte, (m+7)/8)}
+}
+
+// Add inserts a hash into the filter.
+func (b *BloomFilter) Add(hash string) {
+	h1, h2 := bloomBase(hash)
+	for i := 0; i < b.K; i++ {
+		bit := (h1 + uint64(i)*h2) % b.M
+		b.Bits[bit/8] |= 1 << (bit % 8)
+	}
+}
+
+// MayContain reports whether the hash might be in the filter. A malformed
+// filter answers true so callers fall back to an exact check.
+func (b *BloomFilter) MayContain(hash string) bool {
+	if b.M == 0 || uint64(len(b.Bits))*8 < b.M {
+		return true
+	}
+	h1, h2 := bloomBase(hash)
+	for i := 0; i < b.K; i++ {
+		bit := (h1 + uint64(i)*h2) % b.M
+		if b.Bits[bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// bloomBase derives the two base values for double hashing. The input is
+// re-hashed rather than decoded so that structured or non-hex inputs still
+// spread evenly across the bit array.
+func bloomBase(hash string) (uint64, uint64) {
+	sum := sha256.Sum256([]byte(hash))
+	h1 := binary.LittleEndian.Uint64(sum[0:8])
+	h2 := binary.LittleEndian.Uint64(sum[8:16]) | 1 // odd so probes cycle through all bits
+	return h1, h2
+}
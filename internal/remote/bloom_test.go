@@ -0,0 +1,44 @@
+package remote
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBloomFilter_NoFalseNegatives(t *testing.T) {
+	f := NewBloomFilter(1000, 0.01)
+	for i := 0; i < 1000; i++ {
+		f.Add(fmt.Sprintf("%064x", i))
+	}
+	for i := 0; i < 1000; i++ {
+		assert.True(t, f.MayContain(fmt.Sprintf("%064x", i)))
+	}
+}
+
+func TestBloomFilter_FalsePositiveRate(t *testing.T) {
+	f := NewBloomFilter(1000, 0.01)
+	for i := 0; i < 1000; i++ {
+		f.Add(fmt.Sprintf("%064x", i))
+	}
+
+	hits := 0
+	for i := 1000; i < 11000; i++ {
+		if f.MayContain(fmt.Sprintf("%064x", i)) {
+			hits++
+		}
+	}
+	assert.Less(t, hits, 300, "false positive rate should stay near the configured 1%%")
+}
+
+func TestBloomFilter_NonHexInput(t *testing.T) {
+	f := NewBloomFilter(10, 0.01)
+	f.Add("not-a-hash")
+	assert.True(t, f.MayContain("not-a-hash"))
+}
+
+func TestBloomFilter_MalformedAnswersTrue(t *testing.T) {
+	f := &BloomFilter{M: 1024, K: 3, Bits: make([]byte, 4)}
+	assert.True(t, f.MayContain("abc"))
+}
@@ -1,13 +1,16 @@
 package remote
 
 import (
+	"bufio"
 	"bytes"
 	"compress/gzip"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
@@ -19,21 +22,48 @@ import (
 // RemoteClient defines the contract for communicating with a wvc-server.
 type RemoteClient interface {
 	NegotiatePush(ctx context.Context, branch string, commitIDs []string) (*NegotiatePushResponse, error)
-	NegotiatePull(ctx context.Context, branch string, localTip string, depth int) (*NegotiatePullResponse, error)
+	NegotiatePull(ctx context.Context, branch string, localTip string, depth int, classes, shallow []string) (*NegotiatePullResponse, error)
 
 	CheckVectors(ctx context.Context, hashes []string) (*VectorCheckResponse, error)
+	VectorBloom(ctx context.Context) (*BloomFilter, error)
 	UploadVector(ctx context.Context, hash string, r io.Reader, dims int) error
 	DownloadVector(ctx context.Context, hash string) (io.ReadCloser, int, error)
 
 	UploadCommitBundle(ctx context.Context, bundle *CommitBundle) error
-	DownloadCommitBundle(ctx context.Context, commitID string) (*CommitBundle, error)
+	DownloadCommitBundle(ctx context.Context, commitID, haveSchema string) (*CommitBundle, error)
+	DownloadFilteredCommitBundle(ctx context.Context, commitID string, req *FilteredBundleRequest) (*FilteredBundle, error)
+	DownloadCommitPayloads(ctx context.Context, commitID string) ([]*OperationPayload, error)
+	DownloadCommitState(ctx context.Context, commitID string, classes []string) (*CommitState, error)
+	DownloadSchema(ctx context.Context, hash string) (*SchemaSnapshot, error)
+
+	UploadStash(ctx context.Context, stash *RemoteStash) error
+	ListStashes(ctx context.Context) ([]*RemoteStash, error)
+	DownloadStash(ctx context.Context, id string) (*RemoteStash, error)
+	DeleteStash(ctx context.Context, id string) error
 
 	UpdateBranch(ctx context.Context, branch, newTip, expectedTip string) error
 	DeleteBranch(ctx context.Context, branch string) error
 	ListBranches(ctx context.Context) ([]*models.Branch, error)
 	GetBranch(ctx context.Context, branch string) (*models.Branch, error)
 
+	ListUserRefs(ctx context.Context) ([]*models.Branch, error)
+	UpdateUserRef(ctx context.Context, name, newTip, expectedTip string) error
+	DeleteUserRef(ctx context.Context, name string) error
+
+	ListTags(ctx context.Context) ([]*models.Tag, error)
+	PutTag(ctx context.Context, tag *models.Tag, force bool) error
+	DeleteTag(ctx context.Context, name string) error
+
+	ListNotes(ctx context.Context) ([]*models.Note, error)
+	PutNote(ctx context.Context, note *models.Note, force bool) error
+	DeleteNote(ctx context.Context, commitID string) error
+
+	ListDeployments(ctx context.Context) ([]*models.Branch, error)
+	RecordDeployment(ctx context.Context, env, commitID string) error
+
 	GetRepoInfo(ctx context.Context) (*RepoInfo, error)
+	GetCommitLog(ctx context.Context, branch, before string, limit int) (*CommitLogResponse, error)
+	Compare(ctx context.Context, base, head string) (*CompareResponse, error)
 }
 
 // HTTPClient implements RemoteClient over HTTP.
@@ -50,10 +80,22 @@ func NewHTTPClient(baseURL, repoName, token string) *HTTPClient {
 		fmt.Fprintf(os.Stderr, "warning: sending credentials over unencrypted HTTP connection\n")
 	}
 	return &HTTPClient{
-		baseURL:    baseURL,
-		repoName:   repoName,
-		token:      token,
-		httpClient: &http.Client{Timeout: 5 * time.Minute},
+		baseURL:  baseURL,
+		repoName: repoName,
+		token:    token,
+		httpClient: &http.Client{
+			Timeout: 5 * time.Minute,
+			// Signed URL redirects are followed by hand to keep their headers
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if via[0].Header.Get("X-WVC-Accept-Redirect") != "" {
+					return http.ErrUseLastResponse
+				}
+				if len(via) >= 10 {
+					return errors.New("stopped after 10 redirects")
+				}
+				return nil
+			},
+		},
 	}
 }
 
@@ -122,8 +164,8 @@ func (c *HTTPClient) NegotiatePush(ctx context.Context, branch string, commitIDs
 }
 
 // NegotiatePull asks the server which commits the client needs.
-func (c *HTTPClient) NegotiatePull(ctx context.Context, branch string, localTip string, depth int) (*NegotiatePullResponse, error) {
-	req := &NegotiatePullRequest{Branch: branch, LocalTip: localTip, Depth: depth}
+func (c *HTTPClient) NegotiatePull(ctx context.Context, branch string, localTip string, depth int, classes, shallow []string) (*NegotiatePullResponse, error) {
+	req := &NegotiatePullRequest{Branch: branch, LocalTip: localTip, Depth: depth, Classes: classes, Shallow: shallow}
 	var resp NegotiatePullResponse
 	if err := c.doJSON(ctx, "POST", c.repoURL("/negotiate/pull"), req, &resp); err != nil {
 		return nil, fmt.Errorf("negotiate pull: %w", err)
@@ -141,6 +183,15 @@ func (c *HTTPClient) CheckVectors(ctx context.Context, hashes []string) (*Vector
 	return &resp, nil
 }
 
+// VectorBloom fetches a Bloom filter summarizing the server's stored vector blobs.
+func (c *HTTPClient) VectorBloom(ctx context.Context) (*BloomFilter, error) {
+	var filter BloomFilter
+	if err := c.doJSON(ctx, "GET", c.repoURL("/vectors/bloom"), nil, &filter); err != nil {
+		return nil, fmt.Errorf("get vector bloom filter: %w", err)
+	}
+	return &filter, nil
+}
+
 // UploadVector streams a vector blob to the server.
 func (c *HTTPClient) UploadVector(ctx context.Context, hash string, r io.Reader, dims int) error {
 	url := c.repoURL("/vectors/" + hash)
@@ -162,11 +213,12 @@ func (c *HTTPClient) UploadVector(ctx context.Context, hash string, r io.Reader,
 	return nil
 }
 
-// DownloadVector streams a vector blob from the server.
+// DownloadVector streams a vector blob from the server. Servers may redirect
+// to a signed URL on their blob store, which is fetched without credentials.
 func (c *HTTPClient) DownloadVector(ctx context.Context, hash string) (io.ReadCloser, int, error) {
 	url := c.repoURL("/vectors/" + hash)
 
-	resp, err := c.do(ctx, "GET", url, nil, nil)
+	resp, err := c.do(ctx, "GET", url, nil, map[string]string{"X-WVC-Accept-Redirect": "1"})
 	if err != nil {
 		return nil, 0, fmt.Errorf("download vector %s: %w", hash, err)
 	}
@@ -181,6 +233,26 @@ func (c *HTTPClient) DownloadVector(ctx context.Context, hash string) (io.ReadCl
 		dims, _ = strconv.Atoi(d)
 	}
 
+	if resp.StatusCode == http.StatusTemporaryRedirect {
+		resp.Body.Close()
+		location, err := resp.Location()
+		if err != nil {
+			return nil, 0, fmt.Errorf("download vector %s: %w", hash, err)
+		}
+		req, err := http.NewRequestWithContext(ctx, "GET", location.String(), nil)
+		if err != nil {
+			return nil, 0, fmt.Errorf("create request: %w", err)
+		}
+		resp, err = c.httpClient.Do(req)
+		if err != nil {
+			return nil, 0, fmt.Errorf("download vector %s from signed URL: %w", hash, err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, 0, fmt.Errorf("download vector %s from signed URL: HTTP %d", hash, resp.StatusCode)
+		}
+	}
+
 	return resp.Body, dims, nil
 }
 
@@ -212,9 +284,16 @@ func (c *HTTPClient) UploadCommitBundle(ctx context.Context, bundle *CommitBundl
 	return nil
 }
 
-// DownloadCommitBundle retrieves a commit bundle from the server.
-func (c *HTTPClient) DownloadCommitBundle(ctx context.Context, commitID string) (*CommitBundle, error) {
-	url := c.repoURL("/commits/" + commitID + "/bundle")
+// DownloadCommitBundle fetches a commit bundle. haveSchema is the hash of a schema
+// the caller already holds; if the commit's schema has that hash, the server
+// sends only the hash. Pass "" to always receive the full schema.
+func (c *HTTPClient) DownloadCommitBundle(ctx context.Context, commitID, haveSchema string) (*CommitBundle, error) {
+	// Offloaded payloads are fetched as blobs, so ask for references only;
+	// the store expands delta-encoded updates
+	url := c.repoURL("/commits/" + commitID + "/bundle?payload_refs=1&delta=1")
+	if haveSchema != "" {
+		url += "&have_schema=" + haveSchema
+	}
 	headers := map[string]string{"Accept-Encoding": "gzip"}
 
 	resp, err := c.do(ctx, "GET", url, nil, headers)
@@ -245,6 +324,85 @@ func (c *HTTPClient) DownloadCommitBundle(ctx context.Context, commitID string)
 	return &bundle, nil
 }
 
+// DownloadFilteredCommitBundle fetches the operations of a commit that the
+// request selects, with offloaded payloads as references, and a manifest of
+// all its operations. Callers check completeness with FilteredBundle.Verify.
+func (c *HTTPClient) DownloadFilteredCommitBundle(ctx context.Context, commitID string, req *FilteredBundleRequest) (*FilteredBundle, error) {
+	var fb FilteredBundle
+	if err := c.doJSON(ctx, "POST", c.repoURL("/commits/"+commitID+"/bundle/filtered"), req, &fb); err != nil {
+		return nil, fmt.Errorf("download filtered commit bundle %s: %w", commitID, err)
+	}
+	return &fb, nil
+}
+
+// DownloadCommitPayloads fetches the payloads of every operation of a commit,
+// with offloaded payloads inline.
+func (c *HTTPClient) DownloadCommitPayloads(ctx context.Context, commitID string) ([]*OperationPayload, error) {
+	var payloads []*OperationPayload
+	if err := c.doJSON(ctx, "GET", c.repoURL("/commits/"+commitID+"/payloads"), nil, &payloads); err != nil {
+		return nil, fmt.Errorf("download payloads of commit %s: %w", commitID, err)
+	}
+	return payloads, nil
+}
+
+// DownloadCommitState fetches the objects live after a commit, limited to
+// classes when set, for a shallow boundary.
+func (c *HTTPClient) DownloadCommitState(ctx context.Context, commitID string, classes []string) (*CommitState, error) {
+	path := "/commits/" + commitID + "/state"
+	if len(classes) > 0 {
+		path += "?" + url.Values{"filter": {Filter{Classes: classes}.String()}}.Encode()
+	}
+	var state CommitState
+	if err := c.doJSON(ctx, "GET", c.repoURL(path), nil, &state); err != nil {
+		return nil, fmt.Errorf("download state of commit %s: %w", commitID, err)
+	}
+	return &state, nil
+}
+
+// DownloadSchema fetches a schema snapshot by hash. Pull uses it to resolve
+// bundles that reference a schema by hash only.
+func (c *HTTPClient) DownloadSchema(ctx context.Context, hash string) (*SchemaSnapshot, error) {
+	var snapshot SchemaSnapshot
+	if err := c.doJSON(ctx, "GET", c.repoURL("/schemas/"+hash), nil, &snapshot); err != nil {
+		return nil, fmt.Errorf("download schema %s: %w", hash, err)
+	}
+	return &snapshot, nil
+}
+
+// UploadStash stores a stash under the caller's token identity.
+func (c *HTTPClient) UploadStash(ctx context.Context, stash *RemoteStash) error {
+	if err := c.doJSON(ctx, "PUT", c.repoURL("/stashes/"+stash.ID), stash, nil); err != nil {
+		return fmt.Errorf("upload stash %s: %w", stash.ID, err)
+	}
+	return nil
+}
+
+// ListStashes returns the caller's stashes on the remote, newest first, without changes.
+func (c *HTTPClient) ListStashes(ctx context.Context) ([]*RemoteStash, error) {
+	var stashes []*RemoteStash
+	if err := c.doJSON(ctx, "GET", c.repoURL("/stashes"), nil, &stashes); err != nil {
+		return nil, fmt.Errorf("list stashes: %w", err)
+	}
+	return stashes, nil
+}
+
+// DownloadStash fetches one of the caller's stashes with its changes.
+func (c *HTTPClient) DownloadStash(ctx context.Context, id string) (*RemoteStash, error) {
+	var stash RemoteStash
+	if err := c.doJSON(ctx, "GET", c.repoURL("/stashes/"+id), nil, &stash); err != nil {
+		return nil, fmt.Errorf("download stash %s: %w", id, err)
+	}
+	return &stash, nil
+}
+
+// DeleteStash removes one of the caller's stashes from the remote.
+func (c *HTTPClient) DeleteStash(ctx context.Context, id string) error {
+	if err := c.doJSON(ctx, "DELETE", c.repoURL("/stashes/"+id), nil, nil); err != nil {
+		return fmt.Errorf("delete stash %s: %w", id, err)
+	}
+	return nil
+}
+
 // UpdateBranch performs a CAS update on a remote branch.
 func (c *HTTPClient) UpdateBranch(ctx context.Context, branch, newTip, expectedTip string) error {
 	req := &BranchUpdateRequest{CommitID: newTip, Expected: expectedTip}
@@ -287,6 +445,144 @@ func (c *HTTPClient) GetBranch(ctx context.Context, branch string) (*models.Bran
 	return &b, nil
 }
 
+// ListUserRefs returns the caller's personal refs, named relative to their namespace.
+func (c *HTTPClient) ListUserRefs(ctx context.Context) ([]*models.Branch, error) {
+	var refs []*models.Branch
+	if err := c.doJSON(ctx, "GET", c.repoURL("/user-refs"), nil, &refs); err != nil {
+		return nil, fmt.Errorf("list personal refs: %w", err)
+	}
+	return refs, nil
+}
+
+// UpdateUserRef performs a CAS update on one of the caller's personal refs.
+func (c *HTTPClient) UpdateUserRef(ctx context.Context, name, newTip, expectedTip string) error {
+	req := &BranchUpdateRequest{CommitID: newTip, Expected: expectedTip}
+	if err := c.doJSON(ctx, "PUT", c.repoURL("/user-refs/"+name), req, nil); err != nil {
+		return fmt.Errorf("update personal ref %s: %w", name, err)
+	}
+	return nil
+}
+
+// DeleteUserRef removes one of the caller's personal refs.
+func (c *HTTPClient) DeleteUserRef(ctx context.Context, name string) error {
+	if err := c.doJSON(ctx, "DELETE", c.repoURL("/user-refs/"+name), nil, nil); err != nil {
+		return fmt.Errorf("delete personal ref %s: %w", name, err)
+	}
+	return nil
+}
+
+// GetCommitLog fetches a page of commit metadata from a remote branch, or
+// from its default branch when branch is empty. before continues from the
+// Next of a previous page; limit <= 0 uses the server's page size.
+func (c *HTTPClient) GetCommitLog(ctx context.Context, branch, before string, limit int) (*CommitLogResponse, error) {
+	query := url.Values{}
+	if branch != "" {
+		query.Set("branch", branch)
+	}
+	if before != "" {
+		query.Set("before", before)
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	path := "/commits"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	var resp CommitLogResponse
+	if err := c.doJSON(ctx, "GET", c.repoURL(path), nil, &resp); err != nil {
+		return nil, fmt.Errorf("get commit log: %w", err)
+	}
+	return &resp, nil
+}
+
+// Compare compares two refs of the remote, each a branch, tag, or commit ID;
+// an empty base compares against the default branch.
+func (c *HTTPClient) Compare(ctx context.Context, base, head string) (*CompareResponse, error) {
+	query := url.Values{}
+	if base != "" {
+		query.Set("base", base)
+	}
+	query.Set("head", head)
+	var resp CompareResponse
+	if err := c.doJSON(ctx, "GET", c.repoURL("/compare?"+query.Encode()), nil, &resp); err != nil {
+		return nil, fmt.Errorf("compare %s...%s: %w", base, head, err)
+	}
+	return &resp, nil
+}
+
+// ListTags returns the remote's tags sorted by name.
+func (c *HTTPClient) ListTags(ctx context.Context) ([]*models.Tag, error) {
+	var tags []*models.Tag
+	if err := c.doJSON(ctx, "GET", c.repoURL("/tags"), nil, &tags); err != nil {
+		return nil, fmt.Errorf("list tags: %w", err)
+	}
+	return tags, nil
+}
+
+// PutTag creates a tag on the remote; force moves an existing one.
+func (c *HTTPClient) PutTag(ctx context.Context, tag *models.Tag, force bool) error {
+	req := &TagRequest{CommitID: tag.CommitID, Message: tag.Message, Force: force}
+	if err := c.doJSON(ctx, "PUT", c.repoURL("/tags/"+tag.Name), req, nil); err != nil {
+		return fmt.Errorf("push tag %s: %w", tag.Name, err)
+	}
+	return nil
+}
+
+// DeleteTag removes a tag on the remote.
+func (c *HTTPClient) DeleteTag(ctx context.Context, name string) error {
+	if err := c.doJSON(ctx, "DELETE", c.repoURL("/tags/"+name), nil, nil); err != nil {
+		return fmt.Errorf("delete tag %s: %w", name, err)
+	}
+	return nil
+}
+
+// ListNotes returns the remote's commit notes sorted by commit ID.
+func (c *HTTPClient) ListNotes(ctx context.Context) ([]*models.Note, error) {
+	var notes []*models.Note
+	if err := c.doJSON(ctx, "GET", c.repoURL("/notes"), nil, &notes); err != nil {
+		return nil, fmt.Errorf("list notes: %w", err)
+	}
+	return notes, nil
+}
+
+// PutNote attaches a note to a commit on the remote; force replaces a note
+// with another message.
+func (c *HTTPClient) PutNote(ctx context.Context, note *models.Note, force bool) error {
+	req := &NoteRequest{Message: note.Message, Author: note.Author, Force: force}
+	if err := c.doJSON(ctx, "PUT", c.repoURL("/notes/"+note.CommitID), req, nil); err != nil {
+		return fmt.Errorf("push note on %s: %w", note.CommitID, err)
+	}
+	return nil
+}
+
+// DeleteNote removes the note on a commit on the remote.
+func (c *HTTPClient) DeleteNote(ctx context.Context, commitID string) error {
+	if err := c.doJSON(ctx, "DELETE", c.repoURL("/notes/"+commitID), nil, nil); err != nil {
+		return fmt.Errorf("delete note on %s: %w", commitID, err)
+	}
+	return nil
+}
+
+// ListDeployments returns the environments recorded on the remote, each as a
+// ref named after the environment.
+func (c *HTTPClient) ListDeployments(ctx context.Context) ([]*models.Branch, error) {
+	var refs []*models.Branch
+	if err := c.doJSON(ctx, "GET", c.repoURL("/deployments"), nil, &refs); err != nil {
+		return nil, fmt.Errorf("list deployments: %w", err)
+	}
+	return refs, nil
+}
+
+// RecordDeployment records commitID as deployed to env on the remote.
+func (c *HTTPClient) RecordDeployment(ctx context.Context, env, commitID string) error {
+	req := &BranchUpdateRequest{CommitID: commitID}
+	if err := c.doJSON(ctx, "PUT", c.repoURL("/deployments/"+env), req, nil); err != nil {
+		return fmt.Errorf("record deployment %s: %w", env, err)
+	}
+	return nil
+}
+
 // GetRepoInfo returns summary info about the remote repository.
 func (c *HTTPClient) GetRepoInfo(ctx context.Context) (*RepoInfo, error) {
 	var info RepoInfo
@@ -296,6 +592,160 @@ func (c *HTTPClient) GetRepoInfo(ctx context.Context) (*RepoInfo, error) {
 	return &info, nil
 }
 
+// GetRepoUsage returns summary info about the remote repository along with
+// a breakdown of the storage it uses, which takes the server a scan of the
+// repository's operations.
+func (c *HTTPClient) GetRepoUsage(ctx context.Context) (*RepoInfo, error) {
+	var info RepoInfo
+	if err := c.doJSON(ctx, "GET", c.repoURL("/info?usage=true"), nil, &info); err != nil {
+		return nil, fmt.Errorf("get repo usage: %w", err)
+	}
+	return &info, nil
+}
+
+// ErrEventsUnsupported is returned by StreamEvents when the server has no
+// events endpoint.
+var ErrEventsUnsupported = errors.New("server does not support event streams")
+
+// EventSubscriber is implemented by clients that can stream repository events.
+type EventSubscriber interface {
+	StreamEvents(ctx context.Context, branch string, lastID uint64, fn func(*RepoEvent) error) error
+}
+
+// StreamEvents subscribes to the repository's events, limited to branch if
+// it is not empty, and calls fn for each one until the stream ends, ctx is
+// done, or fn returns an error. A non-zero lastID first replays the events
+// the server still holds after it. Returns nil when the server closes the stream.
+func (c *HTTPClient) StreamEvents(ctx context.Context, branch string, lastID uint64, fn func(*RepoEvent) error) error {
+	streamURL := c.repoURL("/events")
+	if branch != "" {
+		streamURL += "?branch=" + url.QueryEscape(branch)
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", streamURL, nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "text/event-stream")
+	if lastID > 0 {
+		req.Header.Set("Last-Event-ID", strconv.FormatUint(lastID, 10))
+	}
+
+	// The stream stays open indefinitely, so it cannot share the request timeout
+	streamClient := *c.httpClient
+	streamClient.Timeout = 0
+	resp, err := streamClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("stream events: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		err := decodeError(resp)
+		var re *RemoteError
+		if errors.As(err, &re) && re.Status == http.StatusNotFound && re.Code != "not_found" {
+			return ErrEventsUnsupported
+		}
+		return fmt.Errorf("stream events: %w", err)
+	}
+
+	// Server-Sent Events: "field: value" lines, dispatched at a blank line
+	scanner := bufio.NewScanner(resp.Body)
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if len(data) > 0 {
+				var event RepoEvent
+				if err := json.Unmarshal([]byte(strings.Join(data, "\n")), &event); err != nil {
+					return fmt.Errorf("stream events: decode event: %w", err)
+				}
+				if err := fn(&event); err != nil {
+					return err
+				}
+			}
+			data = nil
+			continue
+		}
+		if field, value, _ := strings.Cut(line, ":"); field == "data" {
+			data = append(data, strings.TrimPrefix(value, " "))
+		}
+	}
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("stream events: %w", err)
+	}
+	return ctx.Err()
+}
+
+// ProposalClient is implemented by clients that can open, approve, and merge
+// proposals on the server.
+type ProposalClient interface {
+	ListProposals(ctx context.Context) ([]*Proposal, error)
+	GetProposal(ctx context.Context, id int) (*Proposal, error)
+	CreateProposal(ctx context.Context, req *ProposalRequest) (*Proposal, error)
+	ApproveProposal(ctx context.Context, id int) (*Proposal, error)
+	MergeProposal(ctx context.Context, id int) (*Proposal, error)
+	GetAuditLog(ctx context.Context) ([]*AuditEntry, error)
+}
+
+// ListProposals returns the repository's proposals, open and merged.
+func (c *HTTPClient) ListProposals(ctx context.Context) ([]*Proposal, error) {
+	var proposals []*Proposal
+	if err := c.doJSON(ctx, "GET", c.repoURL("/proposals"), nil, &proposals); err != nil {
+		return nil, fmt.Errorf("list proposals: %w", err)
+	}
+	return proposals, nil
+}
+
+// GetProposal returns one proposal.
+func (c *HTTPClient) GetProposal(ctx context.Context, id int) (*Proposal, error) {
+	var p Proposal
+	if err := c.doJSON(ctx, "GET", c.repoURL(fmt.Sprintf("/proposals/%d", id)), nil, &p); err != nil {
+		return nil, fmt.Errorf("get proposal #%d: %w", id, err)
+	}
+	return &p, nil
+}
+
+// CreateProposal opens a proposal to merge one remote branch into another.
+func (c *HTTPClient) CreateProposal(ctx context.Context, req *ProposalRequest) (*Proposal, error) {
+	var p Proposal
+	if err := c.doJSON(ctx, "POST", c.repoURL("/proposals"), req, &p); err != nil {
+		return nil, fmt.Errorf("create proposal: %w", err)
+	}
+	return &p, nil
+}
+
+// ApproveProposal approves the current source tip of a proposal. The token
+// needs the reviewer scope.
+func (c *HTTPClient) ApproveProposal(ctx context.Context, id int) (*Proposal, error) {
+	var p Proposal
+	if err := c.doJSON(ctx, "POST", c.repoURL(fmt.Sprintf("/proposals/%d/approve", id)), nil, &p); err != nil {
+		return nil, fmt.Errorf("approve proposal #%d: %w", id, err)
+	}
+	return &p, nil
+}
+
+// MergeProposal fast-forwards a proposal's target to its source. The server
+// refuses with 403 approval_required until the source tip has the approvals
+// it requires.
+func (c *HTTPClient) MergeProposal(ctx context.Context, id int) (*Proposal, error) {
+	var p Proposal
+	if err := c.doJSON(ctx, "POST", c.repoURL(fmt.Sprintf("/proposals/%d/merge", id)), nil, &p); err != nil {
+		return nil, fmt.Errorf("merge proposal #%d: %w", id, err)
+	}
+	return &p, nil
+}
+
+// GetAuditLog returns the repository's audit log, oldest first. The token
+// needs the repo-admin scope.
+func (c *HTTPClient) GetAuditLog(ctx context.Context) ([]*AuditEntry, error) {
+	var entries []*AuditEntry
+	if err := c.doJSON(ctx, "GET", c.repoURL("/audit"), nil, &entries); err != nil {
+		return nil, fmt.Errorf("get audit log: %w", err)
+	}
+	return entries, nil
+}
+
 // RemoteError represents a structured error from the server.
 type RemoteError struct {
 	Code    string
@@ -0,0 +1,638 @@
+// Package conformance checks that a server speaks the wvc remote protocol.
+// It runs a fixed matrix of requests covering push and pull negotiation,
+// branch compare-and-swap, gzip bodies, request limits, and error responses
+// against a server URL, so alternative server implementations and storage
+// backends can prove they are compatible with wvc clients.
+//
+// The suite writes to the repository it is given: it uploads commits and
+// vectors and creates and deletes a branch named conformance-<random>.
+// Point it at a scratch repository.
+package conformance
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/remote"
+)
+
+// maxNegotiateItems is the largest number of commits a negotiation request
+// may list; servers reject larger requests.
+const maxNegotiateItems = 10000
+
+// Result is the outcome of one check. Err is nil when the check passed.
+type Result struct {
+	Name string
+	Err  error
+}
+
+// Passed reports whether the check passed.
+func (r *Result) Passed() bool {
+	return r.Err == nil
+}
+
+// Options configures a conformance run.
+type Options struct {
+	URL   string // server base URL, e.g. https://wvc.example.com
+	Repo  string // repository to run against; it must exist
+	Token string // read-write token for the repository
+}
+
+// check is one entry of the matrix. Checks run in order and share the run's
+// state, so later checks may rely on what earlier ones uploaded.
+type check struct {
+	name string
+	run  func(ctx context.Context, r *runner) error
+}
+
+var checks = []check{
+	{"auth/missing-token", checkMissingToken},
+	{"auth/invalid-token", checkInvalidToken},
+	{"negotiate/push-unknown-branch", checkNegotiatePushUnknown},
+	{"commits/upload-gzip", checkUploadGzip},
+	{"commits/upload-plain", checkUploadPlain},
+	{"commits/reupload", checkReupload},
+	{"commits/invalid-gzip", checkInvalidGzip},
+	{"commits/invalid-json", checkInvalidJSON},
+	{"commits/id-mismatch", checkIDMismatch},
+	{"commits/unknown-parent", checkUnknownParent},
+	{"commits/download-gzip", checkDownloadGzip},
+	{"commits/not-found", checkCommitNotFound},
+	{"branches/cas-missing-branch", checkBranchExpectedMissing},
+	{"branches/create", checkBranchCreate},
+	{"branches/cas-conflict", checkBranchConflict},
+	{"branches/cas-update", checkBranchUpdate},
+	{"branches/missing-commit-id", checkBranchMissingCommitID},
+	{"negotiate/push-known-branch", checkNegotiatePushKnown},
+	{"negotiate/pull", checkNegotiatePull},
+	{"negotiate/too-many-commits", checkNegotiateLimit},
+	{"vectors/upload-and-download", checkVectorRoundTrip},
+	{"vectors/hash-mismatch", checkVectorHashMismatch},
+	{"vectors/missing-dimensions", checkVectorMissingDimensions},
+	{"vectors/not-found", checkVectorNotFound},
+	{"branches/delete", checkBranchDelete},
+}
+
+// runner holds the state shared by the checks of a run.
+type runner struct {
+	opts   Options
+	client *remote.HTTPClient
+	http   *http.Client
+
+	branch string         // branch created and deleted by the run
+	nonce  string         // makes the run's commits and vectors unique
+	first  *models.Commit // root commit, uploaded gzip'd
+	second *models.Commit // child of first, uploaded as plain JSON
+}
+
+// Run runs the conformance matrix against a server and returns one result
+// per check, in order. A check that depends on an earlier failed check
+// fails too. The branch the run creates is deleted even if checks fail.
+func Run(ctx context.Context, opts Options) []*Result {
+	opts.URL = strings.TrimRight(opts.URL, "/")
+	r := &runner{
+		opts:   opts,
+		client: remote.NewHTTPClient(opts.URL, opts.Repo, opts.Token),
+		http:   &http.Client{Timeout: time.Minute},
+		nonce:  randomHex(8),
+	}
+	r.branch = "conformance-" + r.nonce
+	defer func() {
+		// Best effort: the branch may never have been created
+		_ = r.client.DeleteBranch(context.WithoutCancel(ctx), r.branch)
+	}()
+
+	results := make([]*Result, 0, len(checks))
+	for _, c := range checks {
+		if err := ctx.Err(); err != nil {
+			results = append(results, &Result{Name: c.name, Err: err})
+			continue
+		}
+		results = append(results, &Result{Name: c.name, Err: c.run(ctx, r)})
+	}
+	return results
+}
+
+// Failed returns the number of failed results.
+func Failed(results []*Result) int {
+	n := 0
+	for _, res := range results {
+		if !res.Passed() {
+			n++
+		}
+	}
+	return n
+}
+
+// --- Checks ---
+
+func checkMissingToken(ctx context.Context, r *runner) error {
+	resp, err := r.request(ctx, "GET", "/branches", nil, map[string]string{"Authorization": ""})
+	if err != nil {
+		return err
+	}
+	return expectStatus(resp, http.StatusUnauthorized)
+}
+
+func checkInvalidToken(ctx context.Context, r *runner) error {
+	resp, err := r.request(ctx, "GET", "/branches", nil, map[string]string{"Authorization": "Bearer invalid-" + r.nonce})
+	if err != nil {
+		return err
+	}
+	return expectStatus(resp, http.StatusUnauthorized)
+}
+
+func checkNegotiatePushUnknown(ctx context.Context, r *runner) error {
+	missing := "conformance-missing-" + r.nonce
+	resp, err := r.client.NegotiatePush(ctx, r.branch, []string{missing})
+	if err != nil {
+		return err
+	}
+	if resp.RemoteTip != "" {
+		return fmt.Errorf("remote tip of a new branch is %q, want empty", resp.RemoteTip)
+	}
+	if len(resp.MissingCommits) != 1 || resp.MissingCommits[0] != missing {
+		return fmt.Errorf("missing commits are %v, want [%s]", resp.MissingCommits, missing)
+	}
+	return nil
+}
+
+func checkUploadGzip(ctx context.Context, r *runner) error {
+	commit, ops, err := r.newCommit("", "first")
+	if err != nil {
+		return err
+	}
+	// The client always compresses uploads
+	if err := r.client.UploadCommitBundle(ctx, &remote.CommitBundle{Commit: commit, Operations: ops}); err != nil {
+		return err
+	}
+	r.first = commit
+	return nil
+}
+
+func checkUploadPlain(ctx context.Context, r *runner) error {
+	if r.first == nil {
+		return errSkipped("commits/upload-gzip")
+	}
+	commit, ops, err := r.newCommit(r.first.ID, "second")
+	if err != nil {
+		return err
+	}
+	resp, err := r.postJSON(ctx, "/commits", &remote.CommitBundle{Commit: commit, Operations: ops})
+	if err != nil {
+		return err
+	}
+	if err := expectStatus(resp, http.StatusCreated); err != nil {
+		return err
+	}
+	r.second = commit
+	return nil
+}
+
+func checkReupload(ctx context.Context, r *runner) error {
+	if r.first == nil {
+		return errSkipped("commits/upload-gzip")
+	}
+	_, ops, err := r.newCommit("", "first")
+	if err != nil {
+		return err
+	}
+	// Pushes retried after a lost response upload the same commit again
+	if err := r.client.UploadCommitBundle(ctx, &remote.CommitBundle{Commit: r.first, Operations: ops}); err != nil {
+		return fmt.Errorf("uploading an existing commit again: %w", err)
+	}
+	return nil
+}
+
+func checkInvalidGzip(ctx context.Context, r *runner) error {
+	headers := map[string]string{"Content-Type": "application/json", "Content-Encoding": "gzip"}
+	resp, err := r.request(ctx, "POST", "/commits", strings.NewReader(`{"commit":null}`), headers)
+	if err != nil {
+		return err
+	}
+	return expectError(resp, http.StatusBadRequest, "bad_request")
+}
+
+func checkInvalidJSON(ctx context.Context, r *runner) error {
+	resp, err := r.request(ctx, "POST", "/commits", strings.NewReader(`{"commit":`), map[string]string{"Content-Type": "application/json"})
+	if err != nil {
+		return err
+	}
+	return expectError(resp, http.StatusBadRequest, "bad_request")
+}
+
+func checkIDMismatch(ctx context.Context, r *runner) error {
+	commit, ops, err := r.newCommit("", "mismatch")
+	if err != nil {
+		return err
+	}
+	commit.ID = strings.Repeat("0", len(commit.ID))
+	resp, err := r.postJSON(ctx, "/commits", &remote.CommitBundle{Commit: commit, Operations: ops})
+	if err != nil {
+		return err
+	}
+	return expectError(resp, http.StatusUnprocessableEntity, "commit_id_mismatch")
+}
+
+func checkUnknownParent(ctx context.Context, r *runner) error {
+	commit, ops, err := r.newCommit(strings.Repeat("f", 64), "orphan")
+	if err != nil {
+		return err
+	}
+	resp, err := r.postJSON(ctx, "/commits", &remote.CommitBundle{Commit: commit, Operations: ops})
+	if err != nil {
+		return err
+	}
+	return expectError(resp, http.StatusUnprocessableEntity, "validation_failed")
+}
+
+func checkDownloadGzip(ctx context.Context, r *runner) error {
+	if r.first == nil {
+		return errSkipped("commits/upload-gzip")
+	}
+	resp, err := r.request(ctx, "GET", "/commits/"+r.first.ID+"/bundle", nil, map[string]string{"Accept-Encoding": "gzip"})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return expectStatus(resp, http.StatusOK)
+	}
+	if resp.Header.Get("Content-Encoding") != "gzip" {
+		return fmt.Errorf("bundle was sent with Content-Encoding %q to a client accepting gzip", resp.Header.Get("Content-Encoding"))
+	}
+	gz, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return fmt.Errorf("decompress bundle: %w", err)
+	}
+	defer gz.Close()
+	var bundle remote.CommitBundle
+	if err := json.NewDecoder(gz).Decode(&bundle); err != nil {
+		return fmt.Errorf("decode bundle: %w", err)
+	}
+	if bundle.Commit == nil || bundle.Commit.ID != r.first.ID {
+		return fmt.Errorf("downloaded bundle is not commit %s", r.first.ID)
+	}
+	if len(bundle.Operations) != 1 || bundle.Operations[0].ObjectID != "obj-"+r.nonce+"-first" {
+		return fmt.Errorf("downloaded bundle has %d operation(s), want the 1 uploaded", len(bundle.Operations))
+	}
+	return nil
+}
+
+func checkCommitNotFound(ctx context.Context, r *runner) error {
+	resp, err := r.request(ctx, "GET", "/commits/"+strings.Repeat("e", 64)+"/bundle", nil, nil)
+	if err != nil {
+		return err
+	}
+	return expectError(resp, http.StatusNotFound, "not_found")
+}
+
+func checkBranchExpectedMissing(ctx context.Context, r *runner) error {
+	if r.first == nil {
+		return errSkipped("commits/upload-gzip")
+	}
+	// Expecting a tip of a branch that does not exist is a conflict
+	resp, err := r.putBranch(ctx, r.first.ID, r.first.ID)
+	if err != nil {
+		return err
+	}
+	return expectError(resp, http.StatusConflict, "push_rejected")
+}
+
+func checkBranchCreate(ctx context.Context, r *runner) error {
+	if r.first == nil {
+		return errSkipped("commits/upload-gzip")
+	}
+	if err := r.client.UpdateBranch(ctx, r.branch, r.first.ID, ""); err != nil {
+		return err
+	}
+	branch, err := r.client.GetBranch(ctx, r.branch)
+	if err != nil {
+		return err
+	}
+	if branch.CommitID != r.first.ID {
+		return fmt.Errorf("created branch points to %s, want %s", branch.CommitID, r.first.ID)
+	}
+	return nil
+}
+
+func checkBranchConflict(ctx context.Context, r *runner) error {
+	if r.first == nil || r.second == nil {
+		return errSkipped("commits/upload-plain")
+	}
+	// A stale expected tip must not move the branch
+	resp, err := r.putBranch(ctx, r.second.ID, r.second.ID)
+	if err != nil {
+		return err
+	}
+	errResp, err := decodeErrorResponse(resp, http.StatusConflict, "push_rejected")
+	if err != nil {
+		return err
+	}
+	if tip := errResp.Detail["remote_tip"]; tip != r.first.ID {
+		return fmt.Errorf("conflict reports remote tip %q, want %s", tip, r.first.ID)
+	}
+	branch, err := r.client.GetBranch(ctx, r.branch)
+	if err != nil {
+		return err
+	}
+	if branch.CommitID != r.first.ID {
+		return fmt.Errorf("rejected update moved the branch to %s", branch.CommitID)
+	}
+	return nil
+}
+
+func checkBranchUpdate(ctx context.Context, r *runner) error {
+	if r.first == nil || r.second == nil {
+		return errSkipped("commits/upload-plain")
+	}
+	if err := r.client.UpdateBranch(ctx, r.branch, r.second.ID, r.first.ID); err != nil {
+		return err
+	}
+	branch, err := r.client.GetBranch(ctx, r.branch)
+	if err != nil {
+		return err
+	}
+	if branch.CommitID != r.second.ID {
+		return fmt.Errorf("updated branch points to %s, want %s", branch.CommitID, r.second.ID)
+	}
+	return nil
+}
+
+func checkBranchMissingCommitID(ctx context.Context, r *runner) error {
+	resp, err := r.putBranch(ctx, "", "")
+	if err != nil {
+		return err
+	}
+	return expectError(resp, http.StatusBadRequest, "bad_request")
+}
+
+func checkNegotiatePushKnown(ctx context.Context, r *runner) error {
+	if r.second == nil {
+		return errSkipped("branches/cas-update")
+	}
+	missing := "conformance-missing-" + r.nonce
+	resp, err := r.client.NegotiatePush(ctx, r.branch, []string{r.first.ID, r.second.ID, missing})
+	if err != nil {
+		return err
+	}
+	if resp.RemoteTip != r.second.ID {
+		return fmt.Errorf("remote tip is %q, want %s", resp.RemoteTip, r.second.ID)
+	}
+	if len(resp.MissingCommits) != 1 || resp.MissingCommits[0] != missing {
+		return fmt.Errorf("missing commits are %v, want [%s]", resp.MissingCommits, missing)
+	}
+	return nil
+}
+
+func checkNegotiatePull(ctx context.Context, r *runner) error {
+	if r.second == nil {
+		return errSkipped("branches/cas-update")
+	}
+	resp, err := r.client.NegotiatePull(ctx, r.branch, r.first.ID, 0, nil, nil)
+	if err != nil {
+		return err
+	}
+	if resp.RemoteTip != r.second.ID {
+		return fmt.Errorf("remote tip is %q, want %s", resp.RemoteTip, r.second.ID)
+	}
+	if len(resp.MissingCommits) != 1 || resp.MissingCommits[0] != r.second.ID {
+		return fmt.Errorf("missing commits are %v, want [%s]", resp.MissingCommits, r.second.ID)
+	}
+
+	// A client without history needs the whole branch
+	resp, err = r.client.NegotiatePull(ctx, r.branch, "", 0, nil, nil)
+	if err != nil {
+		return err
+	}
+	if len(resp.MissingCommits) != 2 {
+		return fmt.Errorf("fresh clone is missing %d commit(s), want 2", len(resp.MissingCommits))
+	}
+	return nil
+}
+
+func checkNegotiateLimit(ctx context.Context, r *runner) error {
+	commits := make([]string, maxNegotiateItems+1)
+	for i := range commits {
+		commits[i] = fmt.Sprintf("%064x", i)
+	}
+	resp, err := r.postJSON(ctx, "/negotiate/push", &remote.NegotiatePushRequest{Branch: r.branch, Commits: commits})
+	if err != nil {
+		return err
+	}
+	return expectError(resp, http.StatusBadRequest, "bad_request")
+}
+
+func checkVectorRoundTrip(ctx context.Context, r *runner) error {
+	data := []byte("conformance vector " + r.nonce)
+	hash := hashBytes(data)
+	if err := r.client.UploadVector(ctx, hash, bytes.NewReader(data), 5); err != nil {
+		return err
+	}
+	check, err := r.client.CheckVectors(ctx, []string{hash, hashBytes([]byte("missing " + r.nonce))})
+	if err != nil {
+		return err
+	}
+	if len(check.Have) != 1 || check.Have[0] != hash || len(check.Missing) != 1 {
+		return fmt.Errorf("vector check reports have %v and missing %v, want the uploaded vector only", check.Have, check.Missing)
+	}
+	body, dims, err := r.client.DownloadVector(ctx, hash)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	got, err := io.ReadAll(body)
+	if err != nil {
+		return fmt.Errorf("read vector: %w", err)
+	}
+	if !bytes.Equal(got, data) || dims != 5 {
+		return fmt.Errorf("downloaded vector differs from the upload (%d bytes, %d dimensions)", len(got), dims)
+	}
+	return nil
+}
+
+func checkVectorHashMismatch(ctx context.Context, r *runner) error {
+	hash := hashBytes([]byte("other data " + r.nonce))
+	headers := map[string]string{"Content-Type": "application/octet-stream", "X-WVC-Dimensions": "1"}
+	resp, err := r.request(ctx, "POST", "/vectors/"+hash, strings.NewReader("vector data "+r.nonce), headers)
+	if err != nil {
+		return err
+	}
+	if err := expectError(resp, http.StatusUnprocessableEntity, "hash_mismatch"); err != nil {
+		return err
+	}
+	check, err := r.client.CheckVectors(ctx, []string{hash})
+	if err != nil {
+		return err
+	}
+	if len(check.Have) != 0 {
+		return fmt.Errorf("a rejected upload was stored")
+	}
+	return nil
+}
+
+func checkVectorMissingDimensions(ctx context.Context, r *runner) error {
+	data := []byte("dimensionless " + r.nonce)
+	resp, err := r.request(ctx, "POST", "/vectors/"+hashBytes(data), bytes.NewReader(data), map[string]string{"Content-Type": "application/octet-stream"})
+	if err != nil {
+		return err
+	}
+	return expectError(resp, http.StatusBadRequest, "bad_request")
+}
+
+func checkVectorNotFound(ctx context.Context, r *runner) error {
+	resp, err := r.request(ctx, "GET", "/vectors/"+hashBytes([]byte("missing "+r.nonce)), nil, nil)
+	if err != nil {
+		return err
+	}
+	return expectError(resp, http.StatusNotFound, "not_found")
+}
+
+func checkBranchDelete(ctx context.Context, r *runner) error {
+	if r.first == nil {
+		return errSkipped("branches/create")
+	}
+	if err := r.client.DeleteBranch(ctx, r.branch); err != nil {
+		return err
+	}
+	_, err := r.client.GetBranch(ctx, r.branch)
+	var remoteErr *remote.RemoteError
+	if !errors.As(err, &remoteErr) || remoteErr.Status != http.StatusNotFound {
+		return fmt.Errorf("deleted branch can still be read (error: %v)", err)
+	}
+	return nil
+}
+
+// --- Helpers ---
+
+// newCommit builds a commit inserting one object, unique to the run and
+// label, with a valid content ID.
+func (r *runner) newCommit(parentID, label string) (*models.Commit, []*models.Operation, error) {
+	data, err := json.Marshal(map[string]interface{}{"label": label})
+	if err != nil {
+		return nil, nil, err
+	}
+	timestamp := time.Unix(1700000000, 0).UTC()
+	ops := []*models.Operation{{
+		Seq:        1,
+		Timestamp:  timestamp,
+		Type:       models.OperationInsert,
+		ClassName:  "Conformance",
+		ObjectID:   "obj-" + r.nonce + "-" + label,
+		ObjectData: data,
+	}}
+	commit := &models.Commit{
+		ParentID:       parentID,
+		Message:        fmt.Sprintf("conformance %s %s", r.nonce, label),
+		Author:         "wvc conformance",
+		Timestamp:      timestamp,
+		OperationCount: len(ops),
+		HashVersion:    models.CommitHashV2,
+	}
+	commit.ID, err = commit.ComputeID(ops)
+	if err != nil {
+		return nil, nil, err
+	}
+	return commit, ops, nil
+}
+
+// request sends a request to a repository endpoint with the run's token.
+// An Authorization entry in headers replaces the token; empty removes it.
+func (r *runner) request(ctx context.Context, method, path string, body io.Reader, headers map[string]string) (*http.Response, error) {
+	url := fmt.Sprintf("%s/api/v1/repos/%s%s", r.opts.URL, r.opts.Repo, path)
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+r.opts.Token)
+	for k, v := range headers {
+		if v == "" {
+			req.Header.Del(k)
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	return resp, nil
+}
+
+// putBranch sends a raw compare-and-swap update of the run's branch.
+func (r *runner) putBranch(ctx context.Context, commitID, expected string) (*http.Response, error) {
+	body := jsonBody(&remote.BranchUpdateRequest{CommitID: commitID, Expected: expected})
+	return r.request(ctx, "PUT", "/branches/"+r.branch, body, map[string]string{"Content-Type": "application/json"})
+}
+
+func (r *runner) postJSON(ctx context.Context, path string, v interface{}) (*http.Response, error) {
+	return r.request(ctx, "POST", path, jsonBody(v), map[string]string{"Content-Type": "application/json"})
+}
+
+func jsonBody(v interface{}) io.Reader {
+	data, _ := json.Marshal(v)
+	return bytes.NewReader(data)
+}
+
+// expectStatus checks the status of a response and closes its body.
+func expectStatus(resp *http.Response, status int) error {
+	defer resp.Body.Close()
+	if resp.StatusCode != status {
+		return fmt.Errorf("got HTTP %d, want %d", resp.StatusCode, status)
+	}
+	return nil
+}
+
+// expectError checks that a response is an error response with the given
+// status and error code, and a message.
+func expectError(resp *http.Response, status int, code string) error {
+	_, err := decodeErrorResponse(resp, status, code)
+	return err
+}
+
+func decodeErrorResponse(resp *http.Response, status int, code string) (*remote.ErrorResponse, error) {
+	defer resp.Body.Close()
+	var errResp remote.ErrorResponse
+	decodeErr := json.NewDecoder(io.LimitReader(resp.Body, 1024*1024)).Decode(&errResp)
+	if resp.StatusCode != status {
+		return nil, fmt.Errorf("got HTTP %d (%s), want %d %s", resp.StatusCode, errResp.Error, status, code)
+	}
+	if decodeErr != nil {
+		return nil, fmt.Errorf("error body is not JSON: %w", decodeErr)
+	}
+	if errResp.Error != code {
+		return nil, fmt.Errorf("got error code %q, want %q", errResp.Error, code)
+	}
+	if errResp.Message == "" {
+		return nil, fmt.Errorf("error %q has no message", code)
+	}
+	return &errResp, nil
+}
+
+// errSkipped is the error of a check that needs the state of a failed check.
+func errSkipped(dependency string) error {
+	return fmt.Errorf("skipped: %s failed", dependency)
+}
+
+func hashBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
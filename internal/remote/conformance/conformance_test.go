@@ -0,0 +1,109 @@
+package conformance
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kilupskalvis/wvc/internal/remote/blobstore"
+	"github.com/kilupskalvis/wvc/internal/remote/metastore"
+	"github.com/kilupskalvis/wvc/internal/remote/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testRepoOpener struct {
+	meta  metastore.MetaStore
+	blobs blobstore.BlobStore
+}
+
+func (t *testRepoOpener) Open(string) (metastore.MetaStore, blobstore.BlobStore, error) {
+	return t.meta, t.blobs, nil
+}
+
+type testTokenStore struct {
+	info *server.TokenInfo
+}
+
+func (t *testTokenStore) GetByHash(hash string) (*server.TokenInfo, error) {
+	if hash == t.info.TokenHash {
+		return t.info, nil
+	}
+	return nil, nil
+}
+
+func (t *testTokenStore) UpdateLastUsed(string) error { return nil }
+
+func (t *testTokenStore) ListTokens() ([]*server.TokenInfo, error) {
+	return []*server.TokenInfo{t.info}, nil
+}
+
+func (t *testTokenStore) DeleteToken(string) error { return nil }
+
+func (t *testTokenStore) CreateToken(string, []string, string, server.TokenLimits) (string, *server.TokenInfo, error) {
+	return "", nil, nil
+}
+
+// newTestHandler returns the reference server's handler over fresh stores
+// and a read-write token for it.
+func newTestHandler(t *testing.T) (http.Handler, string) {
+	t.Helper()
+
+	tmpDir := t.TempDir()
+	meta, err := metastore.NewBboltStore(filepath.Join(tmpDir, "meta.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { meta.Close() })
+	blobs, err := blobstore.NewFSStore(filepath.Join(tmpDir, "blobs"))
+	require.NoError(t, err)
+
+	rawToken := "conformance-token"
+	tokens := &testTokenStore{info: &server.TokenInfo{ID: "tok-1", TokenHash: server.HashToken(rawToken), Repos: []string{"*"}, Permission: "rw"}}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	h, cleanup := server.Handler(&testRepoOpener{meta: meta, blobs: blobs}, tokens, server.DefaultServerConfig(), logger, nil, nil)
+	t.Cleanup(cleanup)
+	return h, rawToken
+}
+
+func TestRun_ReferenceServer(t *testing.T) {
+	h, token := newTestHandler(t)
+	ts := httptest.NewServer(h)
+	defer ts.Close()
+
+	results := Run(context.Background(), Options{URL: ts.URL + "/", Repo: "test", Token: token})
+	require.Len(t, results, len(checks))
+	for _, res := range results {
+		assert.NoError(t, res.Err, res.Name)
+	}
+	assert.Zero(t, Failed(results))
+
+	// Each run uses its own commits and branch, so runs can repeat
+	assert.Zero(t, Failed(Run(context.Background(), Options{URL: ts.URL, Repo: "test", Token: token})))
+}
+
+func TestRun_ReportsFailures(t *testing.T) {
+	h, token := newTestHandler(t)
+	// A server that answers unauthenticated requests and never compresses
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		r.Header.Del("Accept-Encoding")
+		h.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	results := Run(context.Background(), Options{URL: ts.URL, Repo: "test", Token: token})
+	var failed []string
+	for _, res := range results {
+		if !res.Passed() {
+			failed = append(failed, res.Name)
+		}
+	}
+	assert.Equal(t, []string{"auth/missing-token", "commits/download-gzip"}, failed)
+	assert.Equal(t, 2, Failed(results))
+}
@@ -0,0 +1,70 @@
+package remote
+
+import "fmt"
+
+// FilterBundle reduces a full commit bundle to the operations the filter
+// selects, recording a manifest of all operations first. Operations keep
+// their order; the bundle is modified in place.
+func FilterBundle(bundle *CommitBundle, filter Filter) (*FilteredBundle, error) {
+	manifest := make([]ManifestEntry, len(bundle.Operations))
+	for i, op := range bundle.Operations {
+		digest, err := op.Digest()
+		if err != nil {
+			return nil, err
+		}
+		manifest[i] = ManifestEntry{Seq: i, ClassName: op.ClassName, Digest: digest}
+	}
+
+	kept := bundle.Operations[:0]
+	for _, op := range bundle.Operations {
+		if !filter.KeepsClass(op.ClassName) {
+			continue
+		}
+		if filter.NoPayloads {
+			op.OmitPayloads()
+		}
+		kept = append(kept, op)
+	}
+	bundle.Operations = kept
+	return &FilteredBundle{Bundle: bundle, Manifest: manifest}, nil
+}
+
+// Verify checks that the bundle holds exactly the manifest operations the
+// filter selects, in order, and that every operation received with its
+// payloads, or with an offloaded payload's hash, matches its digest. Omitted
+// inline payloads are checked when fetched.
+func (fb *FilteredBundle) Verify(filter Filter) error {
+	if fb.Bundle == nil || fb.Bundle.Commit == nil {
+		return fmt.Errorf("filtered bundle has no commit")
+	}
+	commitID := fb.Bundle.Commit.ID
+	ops := fb.Bundle.Operations
+	next := 0
+	for _, entry := range fb.Manifest {
+		if !filter.KeepsClass(entry.ClassName) {
+			continue
+		}
+		if next >= len(ops) {
+			return fmt.Errorf("commit %s: operation %d (%s) is missing from the bundle", commitID, entry.Seq, entry.ClassName)
+		}
+		op := ops[next]
+		next++
+		if op.ClassName != entry.ClassName {
+			return fmt.Errorf("commit %s: operation %d is %s, manifest says %s", commitID, entry.Seq, op.ClassName, entry.ClassName)
+		}
+		if op.PayloadOmitted && op.ObjectDataHash == "" {
+			continue
+		}
+		digest, err := op.Digest()
+		if err != nil {
+			return fmt.Errorf("commit %s: %w", commitID, err)
+		}
+		if digest != entry.Digest {
+			return fmt.Errorf("commit %s: operation %d does not match its manifest digest", commitID, entry.Seq)
+		}
+	}
+	if next != len(ops) {
+		return fmt.Errorf("commit %s: bundle has %d operation(s) not in the manifest", commitID, len(ops)-next)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package remote
+
+import (
+	"testing"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterBundle_VerifiesAgainstManifest(t *testing.T) {
+	newBundle := func() *CommitBundle {
+		return &CommitBundle{
+			Commit: &models.Commit{ID: "c1"},
+			Operations: []*models.Operation{
+				{Type: models.OperationInsert, ClassName: "Article", ObjectID: "a1", ObjectData: []byte(`{"title":"A"}`)},
+				{Type: models.OperationInsert, ClassName: "Author", ObjectID: "b1", ObjectData: []byte(`{"name":"B"}`)},
+				{Type: models.OperationDelete, ClassName: "Article", ObjectID: "a2"},
+			},
+		}
+	}
+	filter := Filter{Classes: []string{"Article"}}
+
+	fb, err := FilterBundle(newBundle(), filter)
+	require.NoError(t, err)
+	assert.Len(t, fb.Manifest, 3)
+	require.Len(t, fb.Bundle.Operations, 2)
+	require.NoError(t, fb.Verify(filter))
+
+	// A tampered operation no longer matches its digest
+	fb.Bundle.Operations[0].ObjectData = []byte(`{"title":"X"}`)
+	assert.ErrorContains(t, fb.Verify(filter), "digest")
+
+	// An operation of an unrequested class is not expected
+	fb, err = FilterBundle(newBundle(), Filter{})
+	require.NoError(t, err)
+	assert.ErrorContains(t, fb.Verify(filter), "manifest says")
+
+	// Omitted payloads are not checked until they are fetched
+	fb, err = FilterBundle(newBundle(), Filter{NoPayloads: true})
+	require.NoError(t, err)
+	assert.True(t, fb.Bundle.Operations[0].PayloadOmitted)
+	require.NoError(t, fb.Verify(Filter{NoPayloads: true}))
+}
@@ -2,11 +2,13 @@ package metastore
 
 import (
 	"context"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -19,7 +21,29 @@ var (
 	bucketCommits    = []byte("commits")
 	bucketOperations = []byte("operations")
 	bucketBranches   = []byte("branches")
-	bucketSchemaVers = []byte("schema_versions")
+	bucketSchemaVers = []byte("schema_versions") // commit_id -> schema snapshot (hash only once deduplicated)
+	bucketSchemas    = []byte("schemas")         // schema hash -> schema JSON
+	bucketStashes    = []byte("stashes")         // owner/stash_id -> stash with changes
+	bucketTags       = []byte("tags")
+	bucketNotes      = []byte("notes")     // commit_id -> note
+	bucketSnapshots  = []byte("snapshots") // commit_id -> JSON array of insert operations
+	bucketCounters   = []byte("counters")  // counter name -> decimal value
+	bucketSettings   = []byte("settings")  // setting name -> JSON value
+	bucketAudit      = []byte("audit")     // big-endian sequence -> audit entry
+)
+
+// Counter names in bucketCounters.
+var (
+	counterCommits   = []byte("commits")
+	counterBlobs     = []byte("blobs")
+	counterBlobBytes = []byte("blob_bytes")
+)
+
+// Setting names in bucketSettings.
+var (
+	settingWebhooks  = []byte("webhooks")
+	settingQuota     = []byte("quota")
+	settingProposals = []byte("proposals")
 )
 
 // BboltStore implements MetaStore using bbolt.
@@ -43,12 +67,15 @@ func NewBboltStore(dbPath string) (*BboltStore, error) {
 
 	// Create buckets
 	if err := db.Update(func(tx *bolt.Tx) error {
-		for _, name := range [][]byte{bucketCommits, bucketOperations, bucketBranches, bucketSchemaVers} {
+		for _, name := range [][]byte{bucketCommits, bucketOperations, bucketBranches, bucketSchemaVers, bucketSchemas, bucketStashes, bucketTags, bucketNotes, bucketSnapshots, bucketCounters, bucketSettings, bucketAudit} {
 			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
 				return fmt.Errorf("create bucket %s: %w", name, err)
 			}
 		}
-		return nil
+		if err := indexInlineSchemas(tx); err != nil {
+			return err
+		}
+		return seedCommitCounter(tx)
 	}); err != nil {
 		db.Close()
 		return nil, err
@@ -57,6 +84,88 @@ func NewBboltStore(dbPath string) (*BboltStore, error) {
 	return &BboltStore{db: db}, nil
 }
 
+// seedCommitCounter counts the commits of a database written before the
+// commit counter existed, once.
+func seedCommitCounter(tx *bolt.Tx) error {
+	counters := tx.Bucket(bucketCounters)
+	if counters.Get(counterCommits) != nil {
+		return nil
+	}
+	n := tx.Bucket(bucketCommits).Stats().KeyN
+	return counters.Put(counterCommits, []byte(strconv.Itoa(n)))
+}
+
+// addCounter adds delta to a counter, leaving a counter that was never set
+// unset.
+func addCounter(tx *bolt.Tx, name []byte, delta int) error {
+	counters := tx.Bucket(bucketCounters)
+	data := counters.Get(name)
+	if data == nil {
+		return nil
+	}
+	n, err := strconv.Atoi(string(data))
+	if err != nil {
+		return fmt.Errorf("parse counter %s: %w", name, err)
+	}
+	return counters.Put(name, []byte(strconv.Itoa(max(n+delta, 0))))
+}
+
+// readCounter returns a counter's value and whether it has been set.
+func readCounter(tx *bolt.Tx, name []byte) (int, bool, error) {
+	data := tx.Bucket(bucketCounters).Get(name)
+	if data == nil {
+		return 0, false, nil
+	}
+	n, err := strconv.Atoi(string(data))
+	if err != nil {
+		return 0, false, fmt.Errorf("parse counter %s: %w", name, err)
+	}
+	return n, true, nil
+}
+
+// indexInlineSchemas moves schema JSON stored inline per commit by earlier
+// versions into the hash-keyed schemas bucket, leaving hash-only records.
+func indexInlineSchemas(tx *bolt.Tx) error {
+	schemas := tx.Bucket(bucketSchemas)
+	schemaVers := tx.Bucket(bucketSchemaVers)
+
+	var inline [][]byte
+	err := schemaVers.ForEach(func(k, v []byte) error {
+		var snap remote.SchemaSnapshot
+		if err := json.Unmarshal(v, &snap); err != nil {
+			return fmt.Errorf("unmarshal schema %s: %w", k, err)
+		}
+		if len(snap.SchemaJSON) == 0 {
+			return nil
+		}
+		if schemas.Get([]byte(snap.SchemaHash)) == nil {
+			if err := schemas.Put([]byte(snap.SchemaHash), snap.SchemaJSON); err != nil {
+				return err
+			}
+		}
+		inline = append(inline, append([]byte(nil), k...))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, k := range inline {
+		var snap remote.SchemaSnapshot
+		if err := json.Unmarshal(schemaVers.Get(k), &snap); err != nil {
+			return fmt.Errorf("unmarshal schema %s: %w", k, err)
+		}
+		data, err := json.Marshal(&remote.SchemaSnapshot{SchemaHash: snap.SchemaHash})
+		if err != nil {
+			return err
+		}
+		if err := schemaVers.Put(k, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Close releases the bbolt database.
 func (s *BboltStore) Close() error {
 	if s.db == nil {
@@ -111,6 +220,9 @@ func (s *BboltStore) InsertCommitBundle(_ context.Context, b *remote.CommitBundl
 		if err := commitBucket.Put([]byte(b.Commit.ID), commitData); err != nil {
 			return fmt.Errorf("store commit: %w", err)
 		}
+		if err := addCounter(tx, counterCommits, 1); err != nil {
+			return err
+		}
 
 		// Store operations
 		opBucket := tx.Bucket(bucketOperations)
@@ -127,14 +239,23 @@ func (s *BboltStore) InsertCommitBundle(_ context.Context, b *remote.CommitBundl
 			}
 		}
 
-		// Store schema if present
+		// Store schema if present, keeping one copy of the JSON per hash
 		if b.Schema != nil {
-			schemaBucket := tx.Bucket(bucketSchemaVers)
-			schemaData, err := json.Marshal(b.Schema)
+			schemas := tx.Bucket(bucketSchemas)
+			if schemas.Get([]byte(b.Schema.SchemaHash)) == nil {
+				if len(b.Schema.SchemaJSON) == 0 {
+					return fmt.Errorf("%w: %s", ErrUnknownSchema, b.Schema.SchemaHash)
+				}
+				if err := schemas.Put([]byte(b.Schema.SchemaHash), b.Schema.SchemaJSON); err != nil {
+					return fmt.Errorf("store schema: %w", err)
+				}
+			}
+
+			schemaData, err := json.Marshal(&remote.SchemaSnapshot{SchemaHash: b.Schema.SchemaHash})
 			if err != nil {
 				return fmt.Errorf("marshal schema: %w", err)
 			}
-			if err := schemaBucket.Put([]byte(b.Commit.ID), schemaData); err != nil {
+			if err := tx.Bucket(bucketSchemaVers).Put([]byte(b.Commit.ID), schemaData); err != nil {
 				return fmt.Errorf("store schema: %w", err)
 			}
 		}
@@ -177,6 +298,13 @@ func (s *BboltStore) GetCommitBundle(_ context.Context, id string) (*remote.Comm
 			if err := json.Unmarshal(schemaData, bundle.Schema); err != nil {
 				return fmt.Errorf("unmarshal schema: %w", err)
 			}
+			if len(bundle.Schema.SchemaJSON) == 0 {
+				schemaJSON := tx.Bucket(bucketSchemas).Get([]byte(bundle.Schema.SchemaHash))
+				if schemaJSON == nil {
+					return fmt.Errorf("%w: %s", ErrUnknownSchema, bundle.Schema.SchemaHash)
+				}
+				bundle.Schema.SchemaJSON = append([]byte(nil), schemaJSON...)
+			}
 		}
 
 		return nil
@@ -188,6 +316,26 @@ func (s *BboltStore) GetCommitBundle(_ context.Context, id string) (*remote.Comm
 	return bundle, nil
 }
 
+// GetSchema returns the schema snapshot stored under the given hash.
+func (s *BboltStore) GetSchema(_ context.Context, hash string) (*remote.SchemaSnapshot, error) {
+	var snapshot *remote.SchemaSnapshot
+	err := s.db.View(func(tx *bolt.Tx) error {
+		schemaJSON := tx.Bucket(bucketSchemas).Get([]byte(hash))
+		if schemaJSON == nil {
+			return ErrNotFound
+		}
+		snapshot = &remote.SchemaSnapshot{
+			SchemaJSON: append([]byte(nil), schemaJSON...),
+			SchemaHash: hash,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
 // GetAncestors returns all ancestor commit IDs reachable from the given commit.
 func (s *BboltStore) GetAncestors(_ context.Context, id string) (map[string]bool, error) {
 	ancestors := make(map[string]bool)
@@ -229,16 +377,146 @@ func (s *BboltStore) GetAncestors(_ context.Context, id string) (map[string]bool
 	return ancestors, err
 }
 
+// GetCommitLog returns up to limit commits reachable from tip, newest first,
+// starting after the commit before when it is set.
+func (s *BboltStore) GetCommitLog(ctx context.Context, tip, before string, limit int) ([]*models.Commit, error) {
+	var commits []*models.Commit
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketCommits)
+		get := func(_ context.Context, id string) (*models.Commit, error) {
+			data := b.Get([]byte(id))
+			if data == nil {
+				return nil, ErrNotFound
+			}
+			var c models.Commit
+			if err := json.Unmarshal(data, &c); err != nil {
+				return nil, fmt.Errorf("unmarshal commit %s: %w", id, err)
+			}
+			return &c, nil
+		}
+		var err error
+		commits, err = commitLog(ctx, get, tip, before, limit)
+		return err
+	})
+	return commits, err
+}
+
 // GetCommitCount returns the total number of commits.
 func (s *BboltStore) GetCommitCount(_ context.Context) (int, error) {
 	var count int
 	err := s.db.View(func(tx *bolt.Tx) error {
-		count = tx.Bucket(bucketCommits).Stats().KeyN
-		return nil
+		var err error
+		count, _, err = readCounter(tx, counterCommits)
+		return err
 	})
 	return count, err
 }
 
+// GetBlobCount returns the blob count and whether it is known.
+func (s *BboltStore) GetBlobCount(_ context.Context) (int, bool, error) {
+	var (
+		count int
+		ok    bool
+	)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		count, ok, err = readCounter(tx, counterBlobs)
+		return err
+	})
+	return count, ok, err
+}
+
+// AddBlobCount adds delta to a known blob count.
+func (s *BboltStore) AddBlobCount(_ context.Context, delta int) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return addCounter(tx, counterBlobs, delta)
+	})
+}
+
+// SetBlobCount sets the blob count.
+func (s *BboltStore) SetBlobCount(_ context.Context, n int) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketCounters).Put(counterBlobs, []byte(strconv.Itoa(n)))
+	})
+}
+
+// GetBlobBytes returns the bytes stored in the blob store and whether they
+// are known.
+func (s *BboltStore) GetBlobBytes(_ context.Context) (int64, bool, error) {
+	var (
+		n  int
+		ok bool
+	)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		n, ok, err = readCounter(tx, counterBlobBytes)
+		return err
+	})
+	return int64(n), ok, err
+}
+
+// AddBlobBytes adds delta to known blob bytes.
+func (s *BboltStore) AddBlobBytes(_ context.Context, delta int64) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return addCounter(tx, counterBlobBytes, int(delta))
+	})
+}
+
+// SetBlobBytes sets the bytes stored in the blob store.
+func (s *BboltStore) SetBlobBytes(_ context.Context, n int64) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketCounters).Put(counterBlobBytes, []byte(strconv.FormatInt(n, 10)))
+	})
+}
+
+// MetaSize returns the size of the database.
+func (s *BboltStore) MetaSize(_ context.Context) (int64, error) {
+	var size int64
+	err := s.db.View(func(tx *bolt.Tx) error {
+		size = tx.Size()
+		return nil
+	})
+	return size, err
+}
+
+// ClassUsage scans the operations and sums their stored bytes per class.
+func (s *BboltStore) ClassUsage(_ context.Context) ([]*remote.ClassUsage, error) {
+	classes := make(map[string]*remote.ClassUsage)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketOperations).ForEach(func(_, v []byte) error {
+			var op struct {
+				ClassName string `json:"class_name"`
+			}
+			if err := json.Unmarshal(v, &op); err != nil {
+				return nil // skip malformed entries
+			}
+			usage := classes[op.ClassName]
+			if usage == nil {
+				usage = &remote.ClassUsage{Class: op.ClassName}
+				classes[op.ClassName] = usage
+			}
+			usage.Operations++
+			usage.Bytes += int64(len(v))
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	usages := make([]*remote.ClassUsage, 0, len(classes))
+	for _, usage := range classes {
+		usages = append(usages, usage)
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		if usages[i].Bytes != usages[j].Bytes {
+			return usages[i].Bytes > usages[j].Bytes
+		}
+		return usages[i].Class < usages[j].Class
+	})
+	return usages, nil
+}
+
 // ListBranches returns all branches sorted by name.
 func (s *BboltStore) ListBranches(_ context.Context) ([]*models.Branch, error) {
 	var branches []*models.Branch
@@ -368,18 +646,386 @@ func (s *BboltStore) DeleteBranch(_ context.Context, name string) error {
 	})
 }
 
-// GetAllVectorHashes scans all operations and returns every unique VectorHash.
+// ListTags returns all tags sorted by name.
+func (s *BboltStore) ListTags(_ context.Context) ([]*models.Tag, error) {
+	var tags []*models.Tag
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketTags).ForEach(func(_, v []byte) error {
+			var tag models.Tag
+			if err := json.Unmarshal(v, &tag); err != nil {
+				return fmt.Errorf("unmarshal tag: %w", err)
+			}
+			tags = append(tags, &tag)
+			return nil
+		})
+	})
+	return tags, err
+}
+
+// GetTag retrieves a tag by name. Returns ErrNotFound if missing.
+func (s *BboltStore) GetTag(_ context.Context, name string) (*models.Tag, error) {
+	var tag *models.Tag
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucketTags).Get([]byte(name))
+		if data == nil {
+			return ErrNotFound
+		}
+		tag = &models.Tag{}
+		return json.Unmarshal(data, tag)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+// PutTag creates a tag, or moves it when replace is set.
+func (s *BboltStore) PutTag(_ context.Context, tag *models.Tag, replace bool) error {
+	data, err := json.Marshal(tag)
+	if err != nil {
+		return fmt.Errorf("marshal tag: %w", err)
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketTags)
+		if existing := b.Get([]byte(tag.Name)); existing != nil {
+			var current models.Tag
+			if err := json.Unmarshal(existing, &current); err != nil {
+				return fmt.Errorf("unmarshal tag: %w", err)
+			}
+			if current.CommitID == tag.CommitID {
+				return nil
+			}
+			if !replace {
+				return ErrConflict
+			}
+		}
+		return b.Put([]byte(tag.Name), data)
+	})
+}
+
+// DeleteTag removes a tag. Returns ErrNotFound if it doesn't exist.
+func (s *BboltStore) DeleteTag(_ context.Context, name string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketTags)
+		if b.Get([]byte(name)) == nil {
+			return ErrNotFound
+		}
+		return b.Delete([]byte(name))
+	})
+}
+
+// ListNotes returns all notes sorted by commit ID.
+func (s *BboltStore) ListNotes(_ context.Context) ([]*models.Note, error) {
+	var notes []*models.Note
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketNotes).ForEach(func(_, v []byte) error {
+			var note models.Note
+			if err := json.Unmarshal(v, &note); err != nil {
+				return fmt.Errorf("unmarshal note: %w", err)
+			}
+			notes = append(notes, &note)
+			return nil
+		})
+	})
+	return notes, err
+}
+
+// PutNote stores a note, or replaces another message when replace is set.
+func (s *BboltStore) PutNote(_ context.Context, note *models.Note, replace bool) error {
+	data, err := json.Marshal(note)
+	if err != nil {
+		return fmt.Errorf("marshal note: %w", err)
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketNotes)
+		if existing := b.Get([]byte(note.CommitID)); existing != nil {
+			var current models.Note
+			if err := json.Unmarshal(existing, &current); err != nil {
+				return fmt.Errorf("unmarshal note: %w", err)
+			}
+			if current.Message == note.Message {
+				return nil
+			}
+			if !replace {
+				return ErrConflict
+			}
+		}
+		return b.Put([]byte(note.CommitID), data)
+	})
+}
+
+// DeleteNote removes the note on a commit. Returns ErrNotFound if there is none.
+func (s *BboltStore) DeleteNote(_ context.Context, commitID string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketNotes)
+		if b.Get([]byte(commitID)) == nil {
+			return ErrNotFound
+		}
+		return b.Delete([]byte(commitID))
+	})
+}
+
+// ListStateSnapshots returns the IDs of commits with a state snapshot.
+func (s *BboltStore) ListStateSnapshots(_ context.Context) ([]string, error) {
+	var ids []string
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketSnapshots).ForEach(func(k, _ []byte) error {
+			ids = append(ids, string(k))
+			return nil
+		})
+	})
+	return ids, err
+}
+
+// GetStateSnapshot returns the snapshot of a commit's state. Returns
+// ErrNotFound if the commit has none.
+func (s *BboltStore) GetStateSnapshot(_ context.Context, commitID string) ([]*models.Operation, error) {
+	var ops []*models.Operation
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucketSnapshots).Get([]byte(commitID))
+		if data == nil {
+			return ErrNotFound
+		}
+		return json.Unmarshal(data, &ops)
+	})
+	return ops, err
+}
+
+// PutStateSnapshot stores the snapshot of a commit's state, replacing any
+// earlier one.
+func (s *BboltStore) PutStateSnapshot(_ context.Context, commitID string, ops []*models.Operation) error {
+	data, err := json.Marshal(ops)
+	if err != nil {
+		return fmt.Errorf("marshal state snapshot: %w", err)
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketSnapshots).Put([]byte(commitID), data)
+	})
+}
+
+// stashKey returns the bbolt key for an owner's stash.
+func stashKey(owner, id string) []byte {
+	return []byte(owner + "/" + id)
+}
+
+// PutStash stores a stash for the given owner, replacing any with the same ID.
+func (s *BboltStore) PutStash(_ context.Context, owner string, stash *remote.RemoteStash) error {
+	data, err := json.Marshal(stash)
+	if err != nil {
+		return fmt.Errorf("marshal stash: %w", err)
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketStashes).Put(stashKey(owner, stash.ID), data)
+	})
+}
+
+// ListStashes returns the owner's stashes newest first, without their changes.
+func (s *BboltStore) ListStashes(_ context.Context, owner string) ([]*remote.RemoteStash, error) {
+	var stashes []*remote.RemoteStash
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		prefix := owner + "/"
+		c := tx.Bucket(bucketStashes).Cursor()
+		for k, v := c.Seek([]byte(prefix)); k != nil && strings.HasPrefix(string(k), prefix); k, v = c.Next() {
+			var stash remote.RemoteStash
+			if err := json.Unmarshal(v, &stash); err != nil {
+				return fmt.Errorf("unmarshal stash: %w", err)
+			}
+			stash.Changes = nil
+			stashes = append(stashes, &stash)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(stashes, func(i, j int) bool {
+		return stashes[i].CreatedAt.After(stashes[j].CreatedAt)
+	})
+	return stashes, nil
+}
+
+// GetStash returns one of the owner's stashes with its changes.
+func (s *BboltStore) GetStash(_ context.Context, owner, id string) (*remote.RemoteStash, error) {
+	var stash remote.RemoteStash
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucketStashes).Get(stashKey(owner, id))
+		if data == nil {
+			return ErrNotFound
+		}
+		return json.Unmarshal(data, &stash)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &stash, nil
+}
+
+// DeleteStash removes one of the owner's stashes.
+func (s *BboltStore) DeleteStash(_ context.Context, owner, id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketStashes)
+		if b.Get(stashKey(owner, id)) == nil {
+			return ErrNotFound
+		}
+		return b.Delete(stashKey(owner, id))
+	})
+}
+
+// GetWebhooks returns the repository's webhooks.
+func (s *BboltStore) GetWebhooks(_ context.Context) ([]*remote.Webhook, error) {
+	hooks := []*remote.Webhook{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucketSettings).Get(settingWebhooks)
+		if data == nil {
+			return nil
+		}
+		return json.Unmarshal(data, &hooks)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read webhooks: %w", err)
+	}
+	return hooks, nil
+}
+
+// PutWebhooks replaces the repository's webhooks.
+func (s *BboltStore) PutWebhooks(_ context.Context, hooks []*remote.Webhook) error {
+	data, err := json.Marshal(hooks)
+	if err != nil {
+		return fmt.Errorf("marshal webhooks: %w", err)
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketSettings).Put(settingWebhooks, data)
+	})
+}
+
+// GetQuota returns the repository's quota.
+func (s *BboltStore) GetQuota(_ context.Context) (*remote.RepoQuota, error) {
+	quota := &remote.RepoQuota{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucketSettings).Get(settingQuota)
+		if data == nil {
+			return nil
+		}
+		return json.Unmarshal(data, quota)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read quota: %w", err)
+	}
+	return quota, nil
+}
+
+// PutQuota replaces the repository's quota.
+func (s *BboltStore) PutQuota(_ context.Context, quota *remote.RepoQuota) error {
+	data, err := json.Marshal(quota)
+	if err != nil {
+		return fmt.Errorf("marshal quota: %w", err)
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketSettings).Put(settingQuota, data)
+	})
+}
+
+// GetProposals returns the repository's proposals.
+func (s *BboltStore) GetProposals(_ context.Context) ([]*remote.Proposal, error) {
+	proposals := []*remote.Proposal{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucketSettings).Get(settingProposals)
+		if data == nil {
+			return nil
+		}
+		return json.Unmarshal(data, &proposals)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read proposals: %w", err)
+	}
+	return proposals, nil
+}
+
+// PutProposals replaces the repository's proposals.
+func (s *BboltStore) PutProposals(_ context.Context, proposals []*remote.Proposal) error {
+	data, err := json.Marshal(proposals)
+	if err != nil {
+		return fmt.Errorf("marshal proposals: %w", err)
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketSettings).Put(settingProposals, data)
+	})
+}
+
+// AppendAudit adds an entry to the end of the audit log.
+func (s *BboltStore) AppendAudit(_ context.Context, entry *remote.AuditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("marshal audit entry: %w", err)
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketAudit)
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		return b.Put(binary.BigEndian.AppendUint64(nil, seq), data)
+	})
+}
+
+// ListAudit returns the audit log, oldest first.
+func (s *BboltStore) ListAudit(_ context.Context) ([]*remote.AuditEntry, error) {
+	entries := []*remote.AuditEntry{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketAudit).ForEach(func(_, v []byte) error {
+			var entry remote.AuditEntry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return err
+			}
+			entries = append(entries, &entry)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read audit log: %w", err)
+	}
+	return entries, nil
+}
+
+// GetAllVectorHashes scans all operations and stashes and returns every unique
+// vector hash, default or named, along with the hashes of offloaded payload blobs.
 func (s *BboltStore) GetAllVectorHashes(_ context.Context) (map[string]bool, error) {
 	hashes := make(map[string]bool)
 
 	err := s.db.View(func(tx *bolt.Tx) error {
-		return tx.Bucket(bucketOperations).ForEach(func(_, v []byte) error {
+		err := tx.Bucket(bucketOperations).ForEach(func(_, v []byte) error {
 			var op models.Operation
 			if err := json.Unmarshal(v, &op); err != nil {
 				return nil // skip malformed entries
 			}
-			if op.VectorHash != "" {
-				hashes[op.VectorHash] = true
+			for _, h := range op.VectorHashes() {
+				hashes[h] = true
+			}
+			for _, h := range op.PayloadHashes() {
+				hashes[h] = true
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// Vectors referenced only by stashes must survive GC too
+		return tx.Bucket(bucketStashes).ForEach(func(_, v []byte) error {
+			var stash remote.RemoteStash
+			if err := json.Unmarshal(v, &stash); err != nil {
+				return nil // skip malformed entries
+			}
+			for _, c := range stash.Changes {
+				if c.VectorHash != "" {
+					hashes[c.VectorHash] = true
+				}
+				if c.PreviousVectorHash != "" {
+					hashes[c.PreviousVectorHash] = true
+				}
 			}
 			return nil
 		})
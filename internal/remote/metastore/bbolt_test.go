@@ -2,6 +2,7 @@ package metastore
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"path/filepath"
 	"testing"
@@ -11,6 +12,7 @@ import (
 	"github.com/kilupskalvis/wvc/internal/remote"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
 )
 
 func newTestStore(t *testing.T) *BboltStore {
@@ -119,6 +121,63 @@ func TestBboltStore_InsertCommitBundle_WithSchema(t *testing.T) {
 	assert.Equal(t, "schemahash", result.Schema.SchemaHash)
 }
 
+func TestBboltStore_InsertCommitBundle_SchemaReference(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	require.NoError(t, s.InsertCommitBundle(ctx, &remote.CommitBundle{
+		Commit: &models.Commit{ID: "c1", Message: "first"},
+		Schema: &remote.SchemaSnapshot{SchemaJSON: []byte(`{"classes":[]}`), SchemaHash: "schemahash"},
+	}))
+	require.NoError(t, s.InsertCommitBundle(ctx, &remote.CommitBundle{
+		Commit: &models.Commit{ID: "c2", ParentID: "c1", Message: "second"},
+		Schema: &remote.SchemaSnapshot{SchemaHash: "schemahash"},
+	}))
+
+	result, err := s.GetCommitBundle(ctx, "c2")
+	require.NoError(t, err)
+	require.NotNil(t, result.Schema)
+	assert.Equal(t, `{"classes":[]}`, string(result.Schema.SchemaJSON))
+
+	err = s.InsertCommitBundle(ctx, &remote.CommitBundle{
+		Commit: &models.Commit{ID: "c3", ParentID: "c2", Message: "third"},
+		Schema: &remote.SchemaSnapshot{SchemaHash: "unknown"},
+	})
+	assert.ErrorIs(t, err, ErrUnknownSchema)
+	_, err = s.GetCommitBundle(ctx, "c3")
+	assert.ErrorIs(t, err, ErrNotFound, "rejected bundle is not stored")
+}
+
+func TestBboltStore_MovesInlineSchemasOnOpen(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "meta.db")
+	s, err := NewBboltStore(dbPath)
+	require.NoError(t, err)
+	require.NoError(t, s.InsertCommitBundle(ctx, &remote.CommitBundle{Commit: &models.Commit{ID: "c1"}}))
+
+	// Write a schema the way earlier versions did: inline per commit
+	legacy, err := json.Marshal(&remote.SchemaSnapshot{SchemaJSON: []byte(`{"classes":[]}`), SchemaHash: "legacy"})
+	require.NoError(t, err)
+	require.NoError(t, s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketSchemaVers).Put([]byte("c1"), legacy)
+	}))
+	require.NoError(t, s.Close())
+
+	s, err = NewBboltStore(dbPath)
+	require.NoError(t, err)
+	defer s.Close()
+
+	require.NoError(t, s.InsertCommitBundle(ctx, &remote.CommitBundle{
+		Commit: &models.Commit{ID: "c2", ParentID: "c1"},
+		Schema: &remote.SchemaSnapshot{SchemaHash: "legacy"},
+	}))
+	for _, id := range []string{"c1", "c2"} {
+		result, err := s.GetCommitBundle(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, `{"classes":[]}`, string(result.Schema.SchemaJSON), id)
+	}
+}
+
 func TestBboltStore_GetCommitBundle(t *testing.T) {
 	ctx := context.Background()
 	s := newTestStore(t)
@@ -168,6 +227,45 @@ func TestBboltStore_GetAncestors(t *testing.T) {
 	assert.Len(t, ancestors, 3)
 }
 
+func TestBboltStore_GetCommitLog(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	// c1 -> c2 -> c4 (merge of c2 and c3), with c3 branching off c1
+	base := time.Now()
+	for _, b := range []*remote.CommitBundle{
+		{Commit: &models.Commit{ID: "c1", Message: "first", Timestamp: base}},
+		{Commit: &models.Commit{ID: "c2", ParentID: "c1", Message: "second", Timestamp: base.Add(time.Minute)}},
+		{Commit: &models.Commit{ID: "c3", ParentID: "c1", Message: "side", Timestamp: base.Add(2 * time.Minute)}},
+		{Commit: &models.Commit{ID: "c4", ParentID: "c2", MergeParentID: "c3", Message: "merge", Timestamp: base.Add(3 * time.Minute)}},
+		{Commit: &models.Commit{ID: "other", Message: "unrelated", Timestamp: base.Add(time.Hour)}},
+	} {
+		require.NoError(t, s.InsertCommitBundle(ctx, b))
+	}
+
+	ids := func(commits []*models.Commit) []string {
+		var out []string
+		for _, c := range commits {
+			out = append(out, c.ID)
+		}
+		return out
+	}
+
+	all, err := s.GetCommitLog(ctx, "c4", "", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c4", "c3", "c2", "c1"}, ids(all))
+
+	page, err := s.GetCommitLog(ctx, "c4", "", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c4", "c3"}, ids(page))
+	page, err = s.GetCommitLog(ctx, "c4", "c3", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c2", "c1"}, ids(page))
+
+	_, err = s.GetCommitLog(ctx, "c4", "missing", 2)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
 func TestBboltStore_GetCommitCount(t *testing.T) {
 	ctx := context.Background()
 	s := newTestStore(t)
@@ -190,6 +288,96 @@ func TestBboltStore_GetCommitCount(t *testing.T) {
 	count, err = s.GetCommitCount(ctx)
 	require.NoError(t, err)
 	assert.Equal(t, 3, count)
+
+	// Inserting an existing commit doesn't count it twice
+	require.NoError(t, s.InsertCommitBundle(ctx, &remote.CommitBundle{Commit: &models.Commit{ID: "commit-0"}}))
+	count, err = s.GetCommitCount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+}
+
+func TestBboltStore_SeedsCommitCounterOnOpen(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "meta.db")
+	s, err := NewBboltStore(dbPath)
+	require.NoError(t, err)
+	require.NoError(t, s.InsertCommitBundle(ctx, &remote.CommitBundle{Commit: &models.Commit{ID: "c1"}}))
+	require.NoError(t, s.InsertCommitBundle(ctx, &remote.CommitBundle{Commit: &models.Commit{ID: "c2"}}))
+
+	// Drop the counter the way a database from an earlier version lacks it
+	require.NoError(t, s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketCounters).Delete(counterCommits)
+	}))
+	require.NoError(t, s.Close())
+
+	s, err = NewBboltStore(dbPath)
+	require.NoError(t, err)
+	defer s.Close()
+	count, err := s.GetCommitCount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestBboltStore_BlobCount(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	// Unknown until set; adding to an unknown count does nothing
+	require.NoError(t, s.AddBlobCount(ctx, 1))
+	_, ok, err := s.GetBlobCount(ctx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, s.SetBlobCount(ctx, 5))
+	require.NoError(t, s.AddBlobCount(ctx, 2))
+	require.NoError(t, s.AddBlobCount(ctx, -10))
+	n, ok, err := s.GetBlobCount(ctx)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 0, n, "the count never goes negative")
+}
+
+func TestBboltStore_BlobBytes(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	require.NoError(t, s.AddBlobBytes(ctx, 100))
+	_, ok, err := s.GetBlobBytes(ctx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, s.SetBlobBytes(ctx, 5<<30))
+	require.NoError(t, s.AddBlobBytes(ctx, 512))
+	n, ok, err := s.GetBlobBytes(ctx)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(5<<30+512), n)
+}
+
+func TestBboltStore_Usage(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	require.NoError(t, s.InsertCommitBundle(ctx, &remote.CommitBundle{
+		Commit: &models.Commit{ID: "c1", Timestamp: time.Now()},
+		Operations: []*models.Operation{
+			{Type: models.OperationInsert, ClassName: "Article", ObjectID: "a1", ObjectData: []byte(`{"title":"A long article body"}`)},
+			{Type: models.OperationInsert, ClassName: "Article", ObjectID: "a2", ObjectData: []byte(`{"title":"Another"}`)},
+			{Type: models.OperationInsert, ClassName: "Author", ObjectID: "b1"},
+		},
+	}))
+
+	usage, err := s.ClassUsage(ctx)
+	require.NoError(t, err)
+	require.Len(t, usage, 2)
+	assert.Equal(t, "Article", usage[0].Class)
+	assert.Equal(t, 2, usage[0].Operations)
+	assert.Equal(t, "Author", usage[1].Class)
+	assert.Greater(t, usage[0].Bytes, usage[1].Bytes)
+
+	size, err := s.MetaSize(ctx)
+	require.NoError(t, err)
+	assert.Positive(t, size)
 }
 
 func TestBboltStore_Branches(t *testing.T) {
@@ -256,6 +444,199 @@ func TestBboltStore_UpdateBranchCAS(t *testing.T) {
 	assert.Equal(t, "def456", branch.CommitID)
 }
 
+func TestBboltStore_Tags(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	require.NoError(t, s.PutTag(ctx, &models.Tag{Name: "v2", CommitID: "def456"}, false))
+	require.NoError(t, s.PutTag(ctx, &models.Tag{Name: "v1", CommitID: "abc123", Message: "first release"}, false))
+
+	// Same commit is a no-op, another commit needs replace
+	require.NoError(t, s.PutTag(ctx, &models.Tag{Name: "v1", CommitID: "abc123"}, false))
+	assert.ErrorIs(t, s.PutTag(ctx, &models.Tag{Name: "v1", CommitID: "def456"}, false), ErrConflict)
+
+	tag, err := s.GetTag(ctx, "v1")
+	require.NoError(t, err)
+	assert.Equal(t, "abc123", tag.CommitID)
+	assert.Equal(t, "first release", tag.Message)
+
+	require.NoError(t, s.PutTag(ctx, &models.Tag{Name: "v1", CommitID: "def456"}, true))
+	tag, err = s.GetTag(ctx, "v1")
+	require.NoError(t, err)
+	assert.Equal(t, "def456", tag.CommitID)
+
+	tags, err := s.ListTags(ctx)
+	require.NoError(t, err)
+	require.Len(t, tags, 2)
+	assert.Equal(t, "v1", tags[0].Name)
+
+	require.NoError(t, s.DeleteTag(ctx, "v1"))
+	assert.ErrorIs(t, s.DeleteTag(ctx, "v1"), ErrNotFound)
+	_, err = s.GetTag(ctx, "v1")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestBboltStore_Notes(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	require.NoError(t, s.PutNote(ctx, &models.Note{CommitID: "def456", Message: "needs review"}, false))
+	require.NoError(t, s.PutNote(ctx, &models.Note{CommitID: "abc123", Message: "validated by QA", Author: "qa"}, false))
+
+	// The same message is a no-op, another message needs replace
+	require.NoError(t, s.PutNote(ctx, &models.Note{CommitID: "abc123", Message: "validated by QA"}, false))
+	assert.ErrorIs(t, s.PutNote(ctx, &models.Note{CommitID: "abc123", Message: "rejected"}, false), ErrConflict)
+
+	notes, err := s.ListNotes(ctx)
+	require.NoError(t, err)
+	require.Len(t, notes, 2)
+	assert.Equal(t, "abc123", notes[0].CommitID)
+	assert.Equal(t, "validated by QA", notes[0].Message)
+	assert.Equal(t, "qa", notes[0].Author)
+
+	require.NoError(t, s.PutNote(ctx, &models.Note{CommitID: "abc123", Message: "rejected"}, true))
+	notes, err = s.ListNotes(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "rejected", notes[0].Message)
+
+	require.NoError(t, s.DeleteNote(ctx, "abc123"))
+	assert.ErrorIs(t, s.DeleteNote(ctx, "abc123"), ErrNotFound)
+}
+
+func TestBboltStore_StateSnapshots(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	_, err := s.GetStateSnapshot(ctx, "abc123")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	ops := []*models.Operation{{Type: models.OperationInsert, ClassName: "Article", ObjectID: "obj-1", VectorHash: "v1"}}
+	require.NoError(t, s.PutStateSnapshot(ctx, "abc123", ops))
+	require.NoError(t, s.PutStateSnapshot(ctx, "def456", nil))
+
+	got, err := s.GetStateSnapshot(ctx, "abc123")
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "v1", got[0].VectorHash)
+	got, err = s.GetStateSnapshot(ctx, "def456")
+	require.NoError(t, err)
+	assert.Empty(t, got)
+
+	ids, err := s.ListStateSnapshots(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"abc123", "def456"}, ids)
+}
+
+func TestBboltStore_Stashes(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	older := &remote.RemoteStash{ID: "s1", Message: "older", CommitID: "c1", CreatedAt: time.Now().Add(-time.Hour)}
+	newer := &remote.RemoteStash{ID: "s2", Message: "newer", CommitID: "c1", CreatedAt: time.Now(),
+		Changes: []*models.StashChange{{ClassName: "Article", ObjectID: "obj-1", ChangeType: "update", VectorHash: "v1", PreviousVectorHash: "v0"}}}
+	require.NoError(t, s.PutStash(ctx, "tok-1", older))
+	require.NoError(t, s.PutStash(ctx, "tok-1", newer))
+	require.NoError(t, s.PutStash(ctx, "tok-2", &remote.RemoteStash{ID: "s3", CommitID: "c1"}))
+
+	list, err := s.ListStashes(ctx, "tok-1")
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "s2", list[0].ID, "newest first")
+	assert.Empty(t, list[0].Changes)
+
+	got, err := s.GetStash(ctx, "tok-1", "s2")
+	require.NoError(t, err)
+	require.Len(t, got.Changes, 1)
+
+	_, err = s.GetStash(ctx, "tok-2", "s2")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// Vectors referenced only by stashes count as in use
+	hashes, err := s.GetAllVectorHashes(ctx)
+	require.NoError(t, err)
+	assert.True(t, hashes["v1"])
+	assert.True(t, hashes["v0"])
+
+	require.NoError(t, s.DeleteStash(ctx, "tok-1", "s2"))
+	assert.ErrorIs(t, s.DeleteStash(ctx, "tok-1", "s2"), ErrNotFound)
+}
+
+func TestBboltStore_Webhooks(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	hooks, err := s.GetWebhooks(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, hooks)
+
+	require.NoError(t, s.PutWebhooks(ctx, []*remote.Webhook{
+		{URL: "https://hooks.example.com/a", Secret: "s3cret", Events: []string{remote.EventPush}},
+		{URL: "https://hooks.example.com/b"},
+	}))
+	hooks, err = s.GetWebhooks(ctx)
+	require.NoError(t, err)
+	require.Len(t, hooks, 2)
+	assert.Equal(t, "s3cret", hooks[0].Secret)
+	assert.Equal(t, []string{remote.EventPush}, hooks[0].Events)
+
+	require.NoError(t, s.PutWebhooks(ctx, nil))
+	hooks, err = s.GetWebhooks(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, hooks)
+}
+
+func TestBboltStore_Quota(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	quota, err := s.GetQuota(ctx)
+	require.NoError(t, err)
+	assert.True(t, quota.IsZero())
+
+	require.NoError(t, s.PutQuota(ctx, &remote.RepoQuota{MaxBlobBytes: 1 << 30, MaxCommits: 100}))
+	quota, err = s.GetQuota(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &remote.RepoQuota{MaxBlobBytes: 1 << 30, MaxCommits: 100}, quota)
+}
+
+func TestBboltStore_Proposals(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	proposals, err := s.GetProposals(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, proposals)
+
+	require.NoError(t, s.PutProposals(ctx, []*remote.Proposal{
+		{ID: 1, Source: "feature", Target: "main", State: remote.ProposalOpen,
+			Approvals: []*remote.Approval{{Reviewer: "tok-2", CommitID: "c2"}}},
+	}))
+	proposals, err = s.GetProposals(ctx)
+	require.NoError(t, err)
+	require.Len(t, proposals, 1)
+	assert.Equal(t, 1, proposals[0].ApprovalsAt("c2"))
+	assert.Zero(t, proposals[0].ApprovalsAt("c1"))
+}
+
+func TestBboltStore_Audit(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	entries, err := s.ListAudit(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	for i, action := range []string{remote.AuditProposalOpen, remote.AuditProposalApprove, remote.AuditProposalMerge} {
+		require.NoError(t, s.AppendAudit(ctx, &remote.AuditEntry{TokenID: fmt.Sprintf("tok-%d", i), Action: action, Proposal: 1}))
+	}
+	entries, err = s.ListAudit(ctx)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, remote.AuditProposalOpen, entries[0].Action)
+	assert.Equal(t, remote.AuditProposalMerge, entries[2].Action)
+	assert.Equal(t, "tok-2", entries[2].TokenID)
+}
+
 func TestBboltStore_GetAllVectorHashes(t *testing.T) {
 	ctx := context.Background()
 	s := newTestStore(t)
@@ -290,6 +671,21 @@ func TestBboltStore_GetAllVectorHashes(t *testing.T) {
 	assert.True(t, hashes["hash1"])
 	assert.True(t, hashes["hash2"])
 	assert.True(t, hashes["hash3"])
+
+	// Offloaded payload blobs are referenced too
+	bundle3 := &remote.CommitBundle{
+		Commit: &models.Commit{ID: "c3", ParentID: "c2", Message: "third"},
+		Operations: []*models.Operation{
+			{Seq: 0, Type: models.OperationUpdate, ClassName: "Test", ObjectID: "o1", ObjectDataHash: "payload1", PreviousDataHash: "payload0"},
+		},
+	}
+	require.NoError(t, s.InsertCommitBundle(ctx, bundle3))
+
+	hashes, err = s.GetAllVectorHashes(ctx)
+	require.NoError(t, err)
+	assert.Len(t, hashes, 5)
+	assert.True(t, hashes["payload0"])
+	assert.True(t, hashes["payload1"])
 }
 
 func TestBboltStore_UpdateBranchCAS_NonExistentWithExpected(t *testing.T) {
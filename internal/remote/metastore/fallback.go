@@ -0,0 +1,131 @@
+package metastore
+
+import (
+	"context"
+	"errors"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/remote"
+)
+
+// fallbackStore reads from a replica and retries reads of items the replica
+// does not hold against the primary. Everything else, including writes, goes
+// to the replica.
+type fallbackStore struct {
+	MetaStore // the replica
+	primary   MetaStore
+}
+
+// WithFallback returns a MetaStore that serves reads from replica and falls
+// back to primary for commits, schemas, branches, tags, snapshots, and
+// stashes the replica does not hold yet, as when it lags behind the primary.
+// A client that negotiated against one store can then fetch what it was
+// told about from another. Close closes neither store.
+func WithFallback(replica, primary MetaStore) MetaStore {
+	return &fallbackStore{MetaStore: replica, primary: primary}
+}
+
+// orPrimary returns the replica's answer unless it is ErrNotFound, in which
+// case it asks the primary.
+func orPrimary[T any](fromReplica, fromPrimary func() (T, error)) (T, error) {
+	v, err := fromReplica()
+	if errors.Is(err, ErrNotFound) {
+		return fromPrimary()
+	}
+	return v, err
+}
+
+// storeWith returns the replica when it holds the commit and the primary
+// otherwise, for reads that return an empty result for unknown commits
+// instead of ErrNotFound.
+func (s *fallbackStore) storeWith(ctx context.Context, commitID string) (MetaStore, error) {
+	ok, err := s.MetaStore.HasCommit(ctx, commitID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return s.primary, nil
+	}
+	return s.MetaStore, nil
+}
+
+func (s *fallbackStore) HasCommit(ctx context.Context, id string) (bool, error) {
+	ok, err := s.MetaStore.HasCommit(ctx, id)
+	if err != nil || ok {
+		return ok, err
+	}
+	return s.primary.HasCommit(ctx, id)
+}
+
+func (s *fallbackStore) GetCommit(ctx context.Context, id string) (*models.Commit, error) {
+	return orPrimary(
+		func() (*models.Commit, error) { return s.MetaStore.GetCommit(ctx, id) },
+		func() (*models.Commit, error) { return s.primary.GetCommit(ctx, id) })
+}
+
+func (s *fallbackStore) GetCommitBundle(ctx context.Context, id string) (*remote.CommitBundle, error) {
+	return orPrimary(
+		func() (*remote.CommitBundle, error) { return s.MetaStore.GetCommitBundle(ctx, id) },
+		func() (*remote.CommitBundle, error) { return s.primary.GetCommitBundle(ctx, id) })
+}
+
+func (s *fallbackStore) GetAncestors(ctx context.Context, id string) (map[string]bool, error) {
+	store, err := s.storeWith(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return store.GetAncestors(ctx, id)
+}
+
+func (s *fallbackStore) GetCommitLog(ctx context.Context, tip, before string, limit int) ([]*models.Commit, error) {
+	store, err := s.storeWith(ctx, tip)
+	if err != nil {
+		return nil, err
+	}
+	return store.GetCommitLog(ctx, tip, before, limit)
+}
+
+func (s *fallbackStore) GetOperationsByCommit(ctx context.Context, commitID string) ([]*models.Operation, error) {
+	store, err := s.storeWith(ctx, commitID)
+	if err != nil {
+		return nil, err
+	}
+	return store.GetOperationsByCommit(ctx, commitID)
+}
+
+func (s *fallbackStore) GetSchema(ctx context.Context, hash string) (*remote.SchemaSnapshot, error) {
+	return orPrimary(
+		func() (*remote.SchemaSnapshot, error) { return s.MetaStore.GetSchema(ctx, hash) },
+		func() (*remote.SchemaSnapshot, error) { return s.primary.GetSchema(ctx, hash) })
+}
+
+func (s *fallbackStore) GetBranch(ctx context.Context, name string) (*models.Branch, error) {
+	return orPrimary(
+		func() (*models.Branch, error) { return s.MetaStore.GetBranch(ctx, name) },
+		func() (*models.Branch, error) { return s.primary.GetBranch(ctx, name) })
+}
+
+func (s *fallbackStore) GetTag(ctx context.Context, name string) (*models.Tag, error) {
+	return orPrimary(
+		func() (*models.Tag, error) { return s.MetaStore.GetTag(ctx, name) },
+		func() (*models.Tag, error) { return s.primary.GetTag(ctx, name) })
+}
+
+func (s *fallbackStore) GetStateSnapshot(ctx context.Context, commitID string) ([]*models.Operation, error) {
+	store, err := s.storeWith(ctx, commitID)
+	if err != nil {
+		return nil, err
+	}
+	return store.GetStateSnapshot(ctx, commitID)
+}
+
+func (s *fallbackStore) GetStash(ctx context.Context, owner, id string) (*remote.RemoteStash, error) {
+	return orPrimary(
+		func() (*remote.RemoteStash, error) { return s.MetaStore.GetStash(ctx, owner, id) },
+		func() (*remote.RemoteStash, error) { return s.primary.GetStash(ctx, owner, id) })
+}
+
+// Close is a no-op: the replica and primary belong to the caller.
+func (s *fallbackStore) Close() error {
+	return nil
+}
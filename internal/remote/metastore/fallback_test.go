@@ -0,0 +1,79 @@
+package metastore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithFallback_LaggingReplica(t *testing.T) {
+	ctx := context.Background()
+	primary := newTestStore(t)
+	replica := newTestStore(t)
+
+	c1 := &remote.CommitBundle{
+		Commit:     &models.Commit{ID: "c1", Message: "first", Timestamp: time.Now()},
+		Operations: []*models.Operation{{Seq: 1, Type: models.OperationInsert, ClassName: "A", ObjectID: "1"}},
+	}
+	c2 := &remote.CommitBundle{
+		Commit:     &models.Commit{ID: "c2", ParentID: "c1", Message: "second", Timestamp: time.Now().Add(time.Second)},
+		Operations: []*models.Operation{{Seq: 1, Type: models.OperationInsert, ClassName: "A", ObjectID: "2"}},
+	}
+	// The replica has only replicated the first commit
+	for _, s := range []*BboltStore{primary, replica} {
+		require.NoError(t, s.InsertCommitBundle(ctx, c1))
+		require.NoError(t, s.CreateBranch(ctx, "main", "c1"))
+	}
+	require.NoError(t, primary.InsertCommitBundle(ctx, c2))
+	require.NoError(t, primary.UpdateBranchCAS(ctx, "main", "c2", "c1"))
+	require.NoError(t, primary.CreateBranch(ctx, "feature", "c2"))
+
+	s := WithFallback(replica, primary)
+
+	has, err := s.HasCommit(ctx, "c2")
+	require.NoError(t, err)
+	assert.True(t, has)
+
+	commit, err := s.GetCommit(ctx, "c2")
+	require.NoError(t, err)
+	assert.Equal(t, "second", commit.Message)
+
+	bundle, err := s.GetCommitBundle(ctx, "c2")
+	require.NoError(t, err)
+	assert.Len(t, bundle.Operations, 1)
+
+	ops, err := s.GetOperationsByCommit(ctx, "c2")
+	require.NoError(t, err)
+	require.Len(t, ops, 1)
+	assert.Equal(t, "2", ops[0].ObjectID)
+
+	ancestors, err := s.GetAncestors(ctx, "c2")
+	require.NoError(t, err)
+	assert.True(t, ancestors["c1"])
+
+	log, err := s.GetCommitLog(ctx, "c2", "", 0)
+	require.NoError(t, err)
+	assert.Len(t, log, 2)
+
+	branch, err := s.GetBranch(ctx, "feature")
+	require.NoError(t, err)
+	assert.Equal(t, "c2", branch.CommitID)
+
+	// Items the replica holds are served from it, even when stale
+	branch, err = s.GetBranch(ctx, "main")
+	require.NoError(t, err)
+	assert.Equal(t, "c1", branch.CommitID)
+
+	_, err = s.GetCommit(ctx, "c3")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// The stores belong to the caller
+	require.NoError(t, s.Close())
+	_, err = replica.GetCommit(ctx, "c1")
+	assert.NoError(t, err)
+}
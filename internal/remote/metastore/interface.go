@@ -11,8 +11,9 @@ import (
 
 // Sentinel errors for expected conditions.
 var (
-	ErrNotFound = errors.New("not found")
-	ErrConflict = errors.New("conflict")
+	ErrNotFound      = errors.New("not found")
+	ErrConflict      = errors.New("conflict")
+	ErrUnknownSchema = errors.New("unknown schema")
 )
 
 // MetaStore defines the contract for server-side metadata persistence.
@@ -23,8 +24,39 @@ type MetaStore interface {
 	InsertCommitBundle(ctx context.Context, b *remote.CommitBundle) error
 	GetCommitBundle(ctx context.Context, id string) (*remote.CommitBundle, error)
 	GetAncestors(ctx context.Context, id string) (map[string]bool, error)
+
+	// GetCommitLog returns up to limit commits reachable from tip, newest
+	// first by timestamp, then ID. A non-empty before resumes a previous page
+	// after that commit; limit <= 0 returns every commit.
+	GetCommitLog(ctx context.Context, tip, before string, limit int) ([]*models.Commit, error)
+
+	// GetCommitCount returns the number of commits from a counter updated
+	// with each insert, without scanning them.
 	GetCommitCount(ctx context.Context) (int, error)
 
+	// The blob count is kept alongside the metadata so repository info needs
+	// no blob store scan. It is unknown (ok false) until first set; adding to
+	// an unknown count is a no-op.
+	GetBlobCount(ctx context.Context) (n int, ok bool, err error)
+	AddBlobCount(ctx context.Context, delta int) error
+	SetBlobCount(ctx context.Context, n int) error
+
+	// The bytes stored in the blob store are kept the same way, so quotas
+	// are enforced without scanning it.
+	GetBlobBytes(ctx context.Context) (n int64, ok bool, err error)
+	AddBlobBytes(ctx context.Context, delta int64) error
+	SetBlobBytes(ctx context.Context, n int64) error
+
+	// MetaSize returns the bytes the repository's metadata takes up.
+	MetaSize(ctx context.Context) (int64, error)
+
+	// ClassUsage returns the operations stored per class and their bytes,
+	// largest first.
+	ClassUsage(ctx context.Context) ([]*remote.ClassUsage, error)
+
+	// GetSchema returns the schema snapshot stored under the given hash.
+	GetSchema(ctx context.Context, hash string) (*remote.SchemaSnapshot, error)
+
 	// Branches
 	ListBranches(ctx context.Context) ([]*models.Branch, error)
 	GetBranch(ctx context.Context, name string) (*models.Branch, error)
@@ -32,10 +64,57 @@ type MetaStore interface {
 	UpdateBranchCAS(ctx context.Context, name, newCommitID, expectedCommitID string) error
 	DeleteBranch(ctx context.Context, name string) error
 
+	// Tags are immutable: PutTag returns ErrConflict when the tag exists at
+	// another commit unless replace is set, and is a no-op when it exists at
+	// the same commit.
+	ListTags(ctx context.Context) ([]*models.Tag, error)
+	GetTag(ctx context.Context, name string) (*models.Tag, error)
+	PutTag(ctx context.Context, tag *models.Tag, replace bool) error
+	DeleteTag(ctx context.Context, name string) error
+
+	// Notes are keyed by commit ID: PutNote returns ErrConflict when the
+	// commit has a note with another message unless replace is set, and is a
+	// no-op when the message is the same.
+	ListNotes(ctx context.Context) ([]*models.Note, error)
+	PutNote(ctx context.Context, note *models.Note, replace bool) error
+	DeleteNote(ctx context.Context, commitID string) error
+
+	// State snapshots hold the objects live after a commit, one insert per
+	// object, so its state can be computed without replaying all history.
+	// GetStateSnapshot returns ErrNotFound for a commit without one.
+	ListStateSnapshots(ctx context.Context) ([]string, error)
+	GetStateSnapshot(ctx context.Context, commitID string) ([]*models.Operation, error)
+	PutStateSnapshot(ctx context.Context, commitID string, ops []*models.Operation) error
+
+	// Stashes are scoped to the ID of the token that pushed them.
+	PutStash(ctx context.Context, owner string, stash *remote.RemoteStash) error
+	ListStashes(ctx context.Context, owner string) ([]*remote.RemoteStash, error)
+	GetStash(ctx context.Context, owner, id string) (*remote.RemoteStash, error)
+	DeleteStash(ctx context.Context, owner, id string) error
+
+	// Webhooks are stored as one list, replaced as a whole; a repository
+	// without webhooks returns an empty list.
+	GetWebhooks(ctx context.Context) ([]*remote.Webhook, error)
+	PutWebhooks(ctx context.Context, hooks []*remote.Webhook) error
+
+	// GetQuota returns the repository's quota, a zero quota when none is set.
+	GetQuota(ctx context.Context) (*remote.RepoQuota, error)
+	PutQuota(ctx context.Context, quota *remote.RepoQuota) error
+
+	// Proposals are stored as one list, replaced as a whole; a repository
+	// without proposals returns an empty list.
+	GetProposals(ctx context.Context) ([]*remote.Proposal, error)
+	PutProposals(ctx context.Context, proposals []*remote.Proposal) error
+
+	// The audit log is append-only; ListAudit returns it oldest first.
+	AppendAudit(ctx context.Context, entry *remote.AuditEntry) error
+	ListAudit(ctx context.Context) ([]*remote.AuditEntry, error)
+
 	// Operations
 	GetOperationsByCommit(ctx context.Context, commitID string) ([]*models.Operation, error)
 
-	// GetAllVectorHashes returns all unique vector hashes referenced by operations.
+	// GetAllVectorHashes returns all unique blob hashes (vectors and offloaded
+	// payloads) referenced by operations and stashes.
 	GetAllVectorHashes(ctx context.Context) (map[string]bool, error)
 
 	// Close releases resources.
@@ -0,0 +1,83 @@
+package metastore
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+)
+
+// commitLog returns up to limit commits reachable from tip, newest first,
+// skipping those that do not sort after before. Commits are ordered by
+// timestamp, then ID, so pages do not overlap. Missing ancestors (shallow
+// history) end their line. get reads one commit, returning ErrNotFound
+// when it is absent.
+func commitLog(ctx context.Context, get func(ctx context.Context, id string) (*models.Commit, error), tip, before string, limit int) ([]*models.Commit, error) {
+	var cursor *models.Commit
+	if before != "" {
+		c, err := get(ctx, before)
+		if err != nil {
+			return nil, err
+		}
+		cursor = c
+	}
+
+	pending := &commitHeap{}
+	seen := make(map[string]bool)
+	push := func(id string) error {
+		if id == "" || seen[id] {
+			return nil
+		}
+		seen[id] = true
+		c, err := get(ctx, id)
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		heap.Push(pending, c)
+		return nil
+	}
+
+	if err := push(tip); err != nil {
+		return nil, err
+	}
+	var out []*models.Commit
+	for pending.Len() > 0 && (limit <= 0 || len(out) < limit) {
+		c := heap.Pop(pending).(*models.Commit)
+		if cursor == nil || newerCommit(cursor, c) {
+			out = append(out, c)
+		}
+		if err := push(c.ParentID); err != nil {
+			return nil, err
+		}
+		if err := push(c.MergeParentID); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// newerCommit reports whether a sorts before b in a newest-first log.
+func newerCommit(a, b *models.Commit) bool {
+	if !a.Timestamp.Equal(b.Timestamp) {
+		return a.Timestamp.After(b.Timestamp)
+	}
+	return a.ID > b.ID
+}
+
+// commitHeap pops the newest commit first.
+type commitHeap []*models.Commit
+
+func (h commitHeap) Len() int            { return len(h) }
+func (h commitHeap) Less(i, j int) bool  { return newerCommit(h[i], h[j]) }
+func (h commitHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *commitHeap) Push(x interface{}) { *h = append(*h, x.(*models.Commit)) }
+func (h *commitHeap) Pop() interface{} {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
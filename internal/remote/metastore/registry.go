@@ -0,0 +1,105 @@
+package metastore
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Factory opens the metadata store of one repository. u is the store URL from
+// the server configuration; a driver keeps each repository's metadata apart
+// under it, e.g. postgres://host/db with a schema per repository.
+type Factory func(u *url.URL, repo string) (MetaStore, error)
+
+var (
+	driversMu sync.RWMutex
+	drivers   = make(map[string]Factory)
+)
+
+func init() {
+	Register("bbolt", openBbolt)
+	Register("postgres", openPostgres)
+	Register("postgresql", openPostgres)
+}
+
+// Register makes a metadata store driver available under a URL scheme. It is
+// meant to be called from an init function and panics if the scheme is
+// already registered or the factory is nil.
+func Register(scheme string, factory Factory) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+
+	if factory == nil {
+		panic("metastore: Register factory is nil")
+	}
+	if _, dup := drivers[scheme]; dup {
+		panic("metastore: Register called twice for scheme " + scheme)
+	}
+	drivers[scheme] = factory
+}
+
+// Drivers returns the sorted list of registered schemes.
+func Drivers() []string {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+	return sortedSchemes()
+}
+
+// ParseURL parses a metadata store URL and checks that its scheme has a
+// registered driver.
+func ParseURL(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid metadata store URL %q: %w", rawURL, err)
+	}
+	if u.Scheme == "" {
+		return nil, fmt.Errorf("metadata store URL %q has no scheme (registered: %s)", rawURL, strings.Join(Drivers(), ", "))
+	}
+	if _, err := lookup(u.Scheme); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// Open opens the metadata store of a repository with the driver registered for
+// the URL's scheme.
+func Open(u *url.URL, repo string) (MetaStore, error) {
+	factory, err := lookup(u.Scheme)
+	if err != nil {
+		return nil, err
+	}
+	return factory(u, repo)
+}
+
+func lookup(scheme string) (Factory, error) {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+
+	factory, ok := drivers[scheme]
+	if !ok {
+		return nil, fmt.Errorf("unknown metadata store driver %q (registered: %s)", scheme, strings.Join(sortedSchemes(), ", "))
+	}
+	return factory, nil
+}
+
+// sortedSchemes lists registered schemes; the caller holds driversMu.
+func sortedSchemes() []string {
+	schemes := make([]string, 0, len(drivers))
+	for scheme := range drivers {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// openBbolt opens bbolt:///path as <path>/<repo>/meta.db, the layout of the
+// server's data directory.
+func openBbolt(u *url.URL, repo string) (MetaStore, error) {
+	if (u.Host != "" && u.Host != "localhost") || u.Path == "" || !filepath.IsAbs(u.Path) {
+		return nil, fmt.Errorf("bbolt metadata store URL must be an absolute path like bbolt:///var/lib/wvc: %q", u.String())
+	}
+	return NewBboltStore(filepath.Join(u.Path, repo, "meta.db"))
+}
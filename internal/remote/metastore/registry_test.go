@@ -0,0 +1,54 @@
+package metastore
+
+import (
+	"context"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpen_BboltDriver(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+
+	u, err := ParseURL("bbolt://" + root)
+	require.NoError(t, err)
+	s, err := Open(u, "myrepo")
+	require.NoError(t, err)
+	defer s.Close()
+
+	count, err := s.GetCommitCount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+	assert.FileExists(t, filepath.Join(root, "myrepo", "meta.db"))
+}
+
+func TestRegister_CustomDriver(t *testing.T) {
+	var gotRepo string
+	Register("test-meta", func(u *url.URL, repo string) (MetaStore, error) {
+		gotRepo = repo
+		return NewBboltStore(filepath.Join(t.TempDir(), "meta.db"))
+	})
+	defer func() {
+		driversMu.Lock()
+		delete(drivers, "test-meta")
+		driversMu.Unlock()
+	}()
+
+	assert.Equal(t, []string{"bbolt", "postgres", "postgresql", "test-meta"}, Drivers())
+
+	u, err := ParseURL("test-meta://host/db")
+	require.NoError(t, err)
+	s, err := Open(u, "myrepo")
+	require.NoError(t, err)
+	defer s.Close()
+	assert.Equal(t, "myrepo", gotRepo)
+}
+
+func TestParseURL_UnknownScheme(t *testing.T) {
+	_, err := ParseURL("mysql://host/db")
+	assert.ErrorContains(t, err, `unknown metadata store driver "mysql" (registered: bbolt, postgres, postgresql)`)
+}
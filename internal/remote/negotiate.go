@@ -0,0 +1,26 @@
+package remote
+
+// SampleCommitIDs picks a logarithmic subset of a tip-first commit list using
+// a skipping walk: positions 0, 1, 2, 4, 8, ... plus the final (oldest) entry.
+// Both sides of push negotiation use it so that only O(log n) commit IDs are
+// exchanged per round regardless of history length.
+func SampleCommitIDs(ids []string) []string {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var sample []string
+	for i := 0; i < len(ids); {
+		sample = append(sample, ids[i])
+		if i == 0 {
+			i = 1
+		} else {
+			i *= 2
+		}
+	}
+
+	if last := ids[len(ids)-1]; sample[len(sample)-1] != last {
+		sample = append(sample, last)
+	}
+	return sample
+}
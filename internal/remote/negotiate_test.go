@@ -0,0 +1,20 @@
+package remote
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSampleCommitIDs(t *testing.T) {
+	assert.Nil(t, SampleCommitIDs(nil))
+	assert.Equal(t, []string{"a"}, SampleCommitIDs([]string{"a"}))
+	assert.Equal(t, []string{"a", "b", "c"}, SampleCommitIDs([]string{"a", "b", "c"}))
+
+	ids := make([]string, 20)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("c%d", i)
+	}
+	assert.Equal(t, []string{"c0", "c1", "c2", "c4", "c8", "c16", "c19"}, SampleCommitIDs(ids))
+}
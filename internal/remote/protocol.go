@@ -2,6 +2,14 @@
 package remote
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
 	"github.com/kilupskalvis/wvc/internal/models"
 )
 
@@ -12,22 +20,43 @@ type NegotiatePushRequest struct {
 }
 
 // NegotiatePushResponse tells the client which commits are missing on the server.
+// TipSample is a skipping-walk sample of the remote branch's first-parent
+// history, letting the client mark shared ancestors without asking about them.
 type NegotiatePushResponse struct {
 	MissingCommits []string `json:"missing_commits"`
 	RemoteTip      string   `json:"remote_tip"`
+	TipSample      []string `json:"tip_sample,omitempty"`
+	SchemaRefs     bool     `json:"schema_refs,omitempty"` // server accepts schema snapshots by hash alone
+	Deltas         bool     `json:"deltas,omitempty"`      // server accepts delta-encoded updates
 }
 
 // NegotiatePullRequest is sent by the client to discover which commits it needs.
+// Shallow lists shallow boundaries whose history the client wants: their
+// ancestors are sent even though LocalTip reaches them.
 type NegotiatePullRequest struct {
-	Branch   string `json:"branch"`
-	LocalTip string `json:"local_tip"`
-	Depth    int    `json:"depth,omitempty"`
+	Branch   string   `json:"branch"`
+	LocalTip string   `json:"local_tip"`
+	Depth    int      `json:"depth,omitempty"`
+	Classes  []string `json:"classes,omitempty"` // Classes the bundles will be filtered to; checked against the remote tip
+	Shallow  []string `json:"shallow,omitempty"`
 }
 
-// NegotiatePullResponse tells the client which commits to download.
+// NegotiatePullResponse tells the client which commits to download. Shallow
+// lists the missing commits whose parents Depth cut off; the client fetches
+// their state instead of their history.
 type NegotiatePullResponse struct {
 	MissingCommits []string `json:"missing_commits"`
 	RemoteTip      string   `json:"remote_tip"`
+	Shallow        []string `json:"shallow,omitempty"`
+}
+
+// CommitState is the set of objects live after a commit, one insert
+// operation per object, ordered by class and object ID. Offloaded payloads
+// are sent as references. A shallow clone replays history from the state of
+// each shallow boundary.
+type CommitState struct {
+	CommitID   string              `json:"commit_id"`
+	Operations []*models.Operation `json:"operations"`
 }
 
 // VectorCheckRequest asks the server which vector blobs it already has.
@@ -42,30 +71,396 @@ type VectorCheckResponse struct {
 }
 
 // CommitBundle contains a commit with its operations and optional schema version,
-// serialized together for transfer between client and server.
+// serialized together for transfer between client and server. Updates carry
+// ObjectDelta in place of ObjectData only when the receiver supports deltas.
 type CommitBundle struct {
 	Commit     *models.Commit      `json:"commit"`
 	Operations []*models.Operation `json:"operations"`
 	Schema     *SchemaSnapshot     `json:"schema,omitempty"`
 }
 
-// SchemaSnapshot is the schema state at a particular commit.
+// SchemaSnapshot is the schema state at a particular commit. Schemas are
+// deduplicated by hash, so SchemaJSON may be omitted when the receiver is
+// known to already hold the schema with SchemaHash.
 type SchemaSnapshot struct {
-	SchemaJSON []byte `json:"schema_json"`
+	SchemaJSON []byte `json:"schema_json,omitempty"`
 	SchemaHash string `json:"schema_hash"`
 }
 
+// OperationPayload carries the object payloads of one operation of a commit,
+// identified by its position in the commit.
+type OperationPayload struct {
+	Seq          int    `json:"seq"`
+	ObjectData   []byte `json:"object_data,omitempty"`
+	PreviousData []byte `json:"previous_data,omitempty"`
+}
+
+// Filter specs select what a partial clone leaves on the remote until a
+// command needs it.
+const (
+	FilterVectorNone  = "vector:none"  // vector blobs are downloaded on first read
+	FilterPayloadNone = "payload:none" // operation payloads are downloaded per commit on first read
+	FilterClassPrefix = "class:"       // only operations of the named class are fetched
+)
+
+// Filter is a parsed list of filter specs.
+type Filter struct {
+	NoVectors  bool
+	NoPayloads bool
+	Classes    []string // when set, operations of other classes are never fetched
+}
+
+// ParseFilter parses comma-separated filter specs such as
+// "payload:none" or "class:Article,class:Author".
+func ParseFilter(spec string) (Filter, error) {
+	var f Filter
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		switch {
+		case part == "":
+		case part == FilterVectorNone:
+			f.NoVectors = true
+		case part == FilterPayloadNone:
+			f.NoPayloads = true
+		case strings.HasPrefix(part, FilterClassPrefix) && len(part) > len(FilterClassPrefix):
+			f.Classes = append(f.Classes, strings.TrimPrefix(part, FilterClassPrefix))
+		default:
+			return Filter{}, fmt.Errorf("unknown filter %q (supported: %s, %s, %s<name>)", part, FilterVectorNone, FilterPayloadNone, FilterClassPrefix)
+		}
+	}
+	// Omitted payloads are verified against the commit ID, which needs
+	// every operation of the commit
+	if f.NoPayloads && len(f.Classes) > 0 {
+		return Filter{}, fmt.Errorf("%s cannot be combined with class filters", FilterPayloadNone)
+	}
+	return f, nil
+}
+
+// IsZero reports whether the filter leaves nothing on the remote.
+func (f Filter) IsZero() bool {
+	return !f.NoVectors && !f.NoPayloads && len(f.Classes) == 0
+}
+
+// KeepsClass reports whether operations of the class pass the filter.
+func (f Filter) KeepsClass(class string) bool {
+	class, _ = models.SplitTenantClass(class)
+	return len(f.Classes) == 0 || slices.Contains(f.Classes, class)
+}
+
+// String returns the filter as comma-separated specs.
+func (f Filter) String() string {
+	var specs []string
+	if f.NoVectors {
+		specs = append(specs, FilterVectorNone)
+	}
+	if f.NoPayloads {
+		specs = append(specs, FilterPayloadNone)
+	}
+	for _, class := range f.Classes {
+		specs = append(specs, FilterClassPrefix+class)
+	}
+	return strings.Join(specs, ",")
+}
+
+// FilteredBundleRequest selects the parts of a commit bundle to download.
+// HaveSchema works as for DownloadCommitBundle; Deltas accepts delta-encoded updates.
+type FilteredBundleRequest struct {
+	Classes      []string `json:"classes,omitempty"`
+	OmitPayloads bool     `json:"omit_payloads,omitempty"`
+	HaveSchema   string   `json:"have_schema,omitempty"`
+	Deltas       bool     `json:"deltas,omitempty"`
+}
+
+// Filter returns the filter the request applies to operations.
+func (r *FilteredBundleRequest) Filter() Filter {
+	return Filter{NoPayloads: r.OmitPayloads, Classes: r.Classes}
+}
+
+// ManifestEntry describes one operation of a commit, sent or not.
+type ManifestEntry struct {
+	Seq       int    `json:"seq"`
+	ClassName string `json:"class"`
+	Digest    string `json:"digest"`
+}
+
+// FilteredBundle is a commit bundle reduced by a FilteredBundleRequest,
+// with a manifest of every operation of the full commit so the client can
+// check that nothing it asked for is missing.
+type FilteredBundle struct {
+	Bundle   *CommitBundle   `json:"bundle"`
+	Manifest []ManifestEntry `json:"manifest"`
+}
+
+// RemoteStash is a stash entry stored on the server under the identity of the
+// token that pushed it. ID is derived from the stash content, so uploading the
+// same stash twice is a no-op. Listings omit Changes.
+type RemoteStash struct {
+	ID         string                `json:"id"`
+	Message    string                `json:"message"`
+	BranchName string                `json:"branch_name"`
+	CommitID   string                `json:"commit_id"`
+	CreatedAt  time.Time             `json:"created_at"`
+	Changes    []*models.StashChange `json:"changes,omitempty"`
+}
+
+// ContentID returns the ID derived from the stash's content: the SHA256 of its
+// JSON encoding with ID left empty.
+func (s *RemoteStash) ContentID() string {
+	c := *s
+	c.ID = ""
+	data, _ := json.Marshal(&c)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// UserRefPrefix is the namespace holding each token's personal refs, named
+// UserRefPrefix + <token-id> + "/" + <name>. Any token can read them, only the
+// owning token can write them, and they are left out of branch listings.
+const UserRefPrefix = "refs/users/"
+
+// UserRefName returns the full name of a personal ref owned by tokenID.
+func UserRefName(tokenID, name string) string {
+	return UserRefPrefix + tokenID + "/" + name
+}
+
+// DeploymentRefPrefix is the namespace holding the commit deployed to each
+// environment, named DeploymentRefPrefix + <environment>.
+const DeploymentRefPrefix = "refs/deployments/"
+
 // BranchUpdateRequest is a compare-and-swap update for a branch pointer.
 type BranchUpdateRequest struct {
 	CommitID string `json:"commit_id"`
 	Expected string `json:"expected"`
 }
 
+// TagRequest creates a tag on the server. Force moves an existing tag to
+// another commit.
+type TagRequest struct {
+	CommitID string `json:"commit_id"`
+	Message  string `json:"message,omitempty"`
+	Force    bool   `json:"force,omitempty"`
+}
+
+// NoteRequest attaches a note to a commit on the server. Force replaces a
+// note with another message.
+type NoteRequest struct {
+	Message string `json:"message"`
+	Author  string `json:"author,omitempty"`
+	Force   bool   `json:"force,omitempty"`
+}
+
+// CommitLogResponse is one page of a remote branch's history, newest first.
+// Next is passed as before to fetch the following page; it is empty on the
+// last page.
+type CommitLogResponse struct {
+	Branch  string           `json:"branch"`
+	Commits []*models.Commit `json:"commits"`
+	Next    string           `json:"next,omitempty"`
+}
+
+// CompareResponse compares two refs of a remote repository. Ahead holds the
+// commits reachable from head but not from base, Behind the reverse, newest
+// first and cut at the requested limit; AheadBy and BehindBy are the full
+// counts. Diffstat aggregates the operations of every commit ahead, which is
+// what merging head into base brings in.
+type CompareResponse struct {
+	Base       string           `json:"base"`
+	Head       string           `json:"head"`
+	BaseCommit string           `json:"base_commit"`
+	HeadCommit string           `json:"head_commit"`
+	MergeBase  string           `json:"merge_base,omitempty"`
+	AheadBy    int              `json:"ahead_by"`
+	BehindBy   int              `json:"behind_by"`
+	Ahead      []*models.Commit `json:"ahead"`
+	Behind     []*models.Commit `json:"behind"`
+	Diffstat   *Diffstat        `json:"diffstat"`
+}
+
+// Diffstat counts inserted, updated, and deleted objects, in total and per class.
+type Diffstat struct {
+	Inserted int                       `json:"inserted"`
+	Updated  int                       `json:"updated"`
+	Deleted  int                       `json:"deleted"`
+	Classes  map[string]*ClassDiffstat `json:"classes"`
+}
+
+// ClassDiffstat counts the operations recorded on one class.
+type ClassDiffstat struct {
+	Inserted int `json:"inserted"`
+	Updated  int `json:"updated"`
+	Deleted  int `json:"deleted"`
+}
+
 // RepoInfo contains summary information about a remote repository.
 type RepoInfo struct {
-	BranchCount int `json:"branch_count"`
-	CommitCount int `json:"commit_count"`
-	TotalBlobs  int `json:"total_blobs"`
+	BranchCount   int         `json:"branch_count"`
+	CommitCount   int         `json:"commit_count"`
+	TotalBlobs    int         `json:"total_blobs"`
+	DefaultBranch string      `json:"default_branch,omitempty"`
+	Token         *TokenScope `json:"token,omitempty"`
+	Quota         *RepoQuota  `json:"quota,omitempty"`
+	// Usage is only reported when requested with ?usage=true, since
+	// breaking it down by class reads every operation
+	Usage *RepoUsage `json:"usage,omitempty"`
+}
+
+// RepoQuota limits the storage of a repository; a zero limit is unlimited.
+// Uploads beyond MaxBlobBytes are rejected with 413 and commits beyond
+// MaxCommits with 422. It is the body of GET and PUT
+// /admin/repos/{repo}/quota.
+type RepoQuota struct {
+	MaxBlobBytes int64 `json:"max_blob_bytes,omitempty"`
+	MaxCommits   int   `json:"max_commits,omitempty"`
+}
+
+// IsZero reports whether the quota sets no limit.
+func (q *RepoQuota) IsZero() bool {
+	return q == nil || (q.MaxBlobBytes <= 0 && q.MaxCommits <= 0)
+}
+
+// RepoUsage breaks down the storage a repository uses.
+type RepoUsage struct {
+	BlobBytes int64 `json:"blob_bytes"`
+	MetaBytes int64 `json:"meta_bytes"`
+	// LargestClasses are the classes with the most operation bytes stored,
+	// largest first
+	LargestClasses []*ClassUsage `json:"largest_classes"`
+}
+
+// ClassUsage is the metadata stored for the operations of one class.
+type ClassUsage struct {
+	Class      string `json:"class"`
+	Operations int    `json:"operations"`
+	Bytes      int64  `json:"bytes"`
+}
+
+// TokenScope describes what the token used for a request is allowed to access.
+type TokenScope struct {
+	ID         string   `json:"id"`
+	Repos      []string `json:"repos"`
+	Permission string   `json:"permission"`
+}
+
+// Repository event types streamed by the events endpoint.
+const (
+	EventPush         = "push"
+	EventBranchDelete = "branch_delete"
+	EventTag          = "tag"
+	EventTagDelete    = "tag_delete"
+)
+
+// Event types only delivered to webhooks. Repository creation and deletion
+// only reach the server-wide webhooks.
+const (
+	EventRepoCreate = "repo_create"
+	EventRepoDelete = "repo_delete"
+	EventGC         = "gc"
+)
+
+// RepoWebhookEvents lists the event types a repository webhook can subscribe to.
+var RepoWebhookEvents = []string{EventPush, EventBranchDelete, EventTag, EventTagDelete, EventGC}
+
+// Webhook is a URL notified of a repository's events, stored with the
+// repository's metadata. Events filters the event types delivered; empty
+// delivers all of them. Secret signs the deliveries and is never returned.
+type Webhook struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret,omitempty"`
+	Events []string `json:"events,omitempty"`
+}
+
+// Wants reports whether the webhook receives events of the given type.
+func (h *Webhook) Wants(eventType string) bool {
+	return len(h.Events) == 0 || slices.Contains(h.Events, eventType)
+}
+
+// WebhookList is the body of GET and PUT /api/v1/repos/{repo}/webhooks.
+// PUT replaces every webhook of the repository, secrets included.
+type WebhookList struct {
+	Webhooks []*Webhook `json:"webhooks"`
+}
+
+// RepoEvent is a change to a repository, streamed to subscribers of
+// GET /api/v1/repos/{repo}/events. IDs increase monotonically, so a client
+// can resume after a disconnect by sending the last ID it saw.
+type RepoEvent struct {
+	ID        uint64 `json:"id"`
+	Type      string `json:"type"`
+	Repo      string `json:"repo"`
+	Branch    string `json:"branch"`
+	CommitID  string `json:"commit_id,omitempty"`
+	Timestamp string `json:"timestamp"`
+}
+
+// Proposal states.
+const (
+	ProposalOpen   = "open"
+	ProposalMerged = "merged"
+)
+
+// Proposal asks for branch Source to be merged into Target on the server.
+// Merging fast-forwards Target to the tip of Source once enough reviewers
+// have approved that tip. Author and MergedBy are token IDs.
+// RequiredApprovals is the server's approval count, set in responses.
+type Proposal struct {
+	ID                int         `json:"id"`
+	Title             string      `json:"title"`
+	Source            string      `json:"source"`
+	Target            string      `json:"target"`
+	Author            string      `json:"author"`
+	State             string      `json:"state"`
+	CreatedAt         time.Time   `json:"created_at"`
+	Approvals         []*Approval `json:"approvals,omitempty"`
+	MergedBy          string      `json:"merged_by,omitempty"`
+	MergedAt          *time.Time  `json:"merged_at,omitempty"`
+	MergeCommit       string      `json:"merge_commit,omitempty"`
+	RequiredApprovals int         `json:"required_approvals,omitempty"`
+}
+
+// Approval is a reviewer's approval of a proposal's source at CommitID.
+type Approval struct {
+	Reviewer  string    `json:"reviewer"`
+	CommitID  string    `json:"commit_id"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// ApprovalsAt counts the approvals of the source at commitID. Approvals of
+// an earlier tip do not count once more commits are pushed.
+func (p *Proposal) ApprovalsAt(commitID string) int {
+	n := 0
+	for _, a := range p.Approvals {
+		if a.CommitID == commitID {
+			n++
+		}
+	}
+	return n
+}
+
+// ProposalRequest opens a proposal; it is the body of
+// POST /api/v1/repos/{repo}/proposals.
+type ProposalRequest struct {
+	Title  string `json:"title"`
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+// Audit log actions.
+const (
+	AuditProposalOpen    = "proposal_open"
+	AuditProposalApprove = "proposal_approve"
+	AuditProposalMerge   = "proposal_merge"
+	AuditMergeRefused    = "proposal_merge_refused"
+)
+
+// AuditEntry records an action taken on a repository and the token that
+// took it. GET /api/v1/repos/{repo}/audit lists them, oldest first.
+type AuditEntry struct {
+	Timestamp time.Time `json:"timestamp"`
+	TokenID   string    `json:"token_id"`
+	Action    string    `json:"action"`
+	Proposal  int       `json:"proposal,omitempty"`
+	CommitID  string    `json:"commit_id,omitempty"`
+	Detail    string    `json:"detail,omitempty"`
 }
 
 // ErrorResponse is the structured error format returned by the server.
@@ -0,0 +1,29 @@
+package remote
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFilter(t *testing.T) {
+	f, err := ParseFilter("vector:none, class:Article,class:Author")
+	require.NoError(t, err)
+	assert.Equal(t, Filter{NoVectors: true, Classes: []string{"Article", "Author"}}, f)
+	assert.Equal(t, "vector:none,class:Article,class:Author", f.String())
+	assert.True(t, f.KeepsClass("Author"))
+	assert.False(t, f.KeepsClass("Product"))
+
+	f, err = ParseFilter("")
+	require.NoError(t, err)
+	assert.True(t, f.IsZero())
+	assert.True(t, f.KeepsClass("Product"))
+
+	_, err = ParseFilter("blob:none")
+	assert.Error(t, err)
+	_, err = ParseFilter("class:")
+	assert.Error(t, err)
+	_, err = ParseFilter("payload:none,class:Article")
+	assert.Error(t, err)
+}
@@ -45,11 +45,33 @@ func NewRetryClient(inner RemoteClient, cfg *RetryConfig) *RetryClient {
 	return &RetryClient{inner: inner, config: cfg}
 }
 
+// PermanentError marks an error that retrying cannot fix, such as a failure
+// of the local store, so Retry returns it at once.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string { return e.Err.Error() }
+
+func (e *PermanentError) Unwrap() error { return e.Err }
+
+// Permanent wraps err so that it is not retried. A nil err stays nil.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PermanentError{Err: err}
+}
+
 // isTransient returns true for errors that are worth retrying.
 func isTransient(err error) bool {
 	if err == nil {
 		return false
 	}
+	var pe *PermanentError
+	if errors.As(err, &pe) {
+		return false
+	}
 	var re *RemoteError
 	if errors.As(err, &re) {
 		return re.Status >= 500 || re.Status == http.StatusTooManyRequests
@@ -61,12 +83,12 @@ func isTransient(err error) bool {
 }
 
 // backoff computes the delay for the given attempt with jitter.
-func (rc *RetryClient) backoff(attempt int) time.Duration {
-	base := float64(rc.config.InitialBackoff) * math.Pow(2, float64(attempt))
-	if base > float64(rc.config.MaxBackoff) {
-		base = float64(rc.config.MaxBackoff)
+func (c *RetryConfig) backoff(attempt int) time.Duration {
+	base := float64(c.InitialBackoff) * math.Pow(2, float64(attempt))
+	if base > float64(c.MaxBackoff) {
+		base = float64(c.MaxBackoff)
 	}
-	jitter := base * rc.config.JitterFraction * (rand.Float64()*2 - 1) // +/- jitter
+	jitter := base * c.JitterFraction * (rand.Float64()*2 - 1) // +/- jitter
 	d := time.Duration(base + jitter)
 	if d < 0 {
 		d = 0
@@ -86,10 +108,19 @@ func sleep(ctx context.Context, d time.Duration) error {
 	}
 }
 
-// retry executes fn with retry logic. Only retries transient errors.
-func (rc *RetryClient) retry(ctx context.Context, operation string, fn func() error) error {
+// Retry executes fn, retrying transient errors with backoff. The operation
+// names fn in the error returned once retries run out or are cancelled; with
+// an empty operation the caller is expected to wrap the error itself.
+func Retry(ctx context.Context, cfg *RetryConfig, operation string, fn func() error) error {
+	if cfg == nil {
+		cfg = DefaultRetryConfig()
+	}
+	prefix := ""
+	if operation != "" {
+		prefix = operation + ": "
+	}
 	var lastErr error
-	for attempt := 0; attempt <= rc.config.MaxRetries; attempt++ {
+	for attempt := 0; attempt <= cfg.MaxRetries; attempt++ {
 		lastErr = fn()
 		if lastErr == nil {
 			return nil
@@ -97,14 +128,19 @@ func (rc *RetryClient) retry(ctx context.Context, operation string, fn func() er
 		if !isTransient(lastErr) {
 			return lastErr
 		}
-		if attempt < rc.config.MaxRetries {
-			d := rc.backoff(attempt)
+		if attempt < cfg.MaxRetries {
+			d := cfg.backoff(attempt)
 			if err := sleep(ctx, d); err != nil {
-				return fmt.Errorf("%s: %w (retry cancelled)", operation, lastErr)
+				return fmt.Errorf("%s%w (retry cancelled)", prefix, lastErr)
 			}
 		}
 	}
-	return fmt.Errorf("%s: %w (after %d retries)", operation, lastErr, rc.config.MaxRetries)
+	return fmt.Errorf("%s%w (after %d retries)", prefix, lastErr, cfg.MaxRetries)
+}
+
+// retry executes fn with retry logic. Only retries transient errors.
+func (rc *RetryClient) retry(ctx context.Context, operation string, fn func() error) error {
+	return Retry(ctx, rc.config, operation, fn)
 }
 
 // --- Delegate all RemoteClient methods through retry logic ---
@@ -117,9 +153,9 @@ func (rc *RetryClient) NegotiatePush(ctx context.Context, branch string, commitI
 	return
 }
 
-func (rc *RetryClient) NegotiatePull(ctx context.Context, branch string, localTip string, depth int) (resp *NegotiatePullResponse, err error) {
+func (rc *RetryClient) NegotiatePull(ctx context.Context, branch string, localTip string, depth int, classes, shallow []string) (resp *NegotiatePullResponse, err error) {
 	err = rc.retry(ctx, "negotiate pull", func() error {
-		resp, err = rc.inner.NegotiatePull(ctx, branch, localTip, depth)
+		resp, err = rc.inner.NegotiatePull(ctx, branch, localTip, depth, classes, shallow)
 		return err
 	})
 	return
@@ -133,9 +169,17 @@ func (rc *RetryClient) CheckVectors(ctx context.Context, hashes []string) (resp
 	return
 }
 
+func (rc *RetryClient) VectorBloom(ctx context.Context) (filter *BloomFilter, err error) {
+	err = rc.retry(ctx, "get vector bloom filter", func() error {
+		filter, err = rc.inner.VectorBloom(ctx)
+		return err
+	})
+	return
+}
+
 func (rc *RetryClient) UploadVector(ctx context.Context, hash string, r io.Reader, dims int) error {
 	// Note: Cannot retry uploads with io.Reader (consumed on first attempt).
-	// Callers should buffer if they need retry.
+	// Callers that can reopen the source retry with Retry.
 	return rc.inner.UploadVector(ctx, hash, r, dims)
 }
 
@@ -158,14 +202,74 @@ func (rc *RetryClient) UploadCommitBundle(ctx context.Context, bundle *CommitBun
 	})
 }
 
-func (rc *RetryClient) DownloadCommitBundle(ctx context.Context, commitID string) (bundle *CommitBundle, err error) {
+func (rc *RetryClient) DownloadCommitBundle(ctx context.Context, commitID, haveSchema string) (bundle *CommitBundle, err error) {
 	err = rc.retry(ctx, "download commit bundle", func() error {
-		bundle, err = rc.inner.DownloadCommitBundle(ctx, commitID)
+		bundle, err = rc.inner.DownloadCommitBundle(ctx, commitID, haveSchema)
+		return err
+	})
+	return
+}
+
+func (rc *RetryClient) DownloadFilteredCommitBundle(ctx context.Context, commitID string, req *FilteredBundleRequest) (fb *FilteredBundle, err error) {
+	err = rc.retry(ctx, "download filtered commit bundle", func() error {
+		fb, err = rc.inner.DownloadFilteredCommitBundle(ctx, commitID, req)
+		return err
+	})
+	return
+}
+
+func (rc *RetryClient) DownloadCommitState(ctx context.Context, commitID string, classes []string) (state *CommitState, err error) {
+	err = rc.retry(ctx, "download commit state", func() error {
+		state, err = rc.inner.DownloadCommitState(ctx, commitID, classes)
+		return err
+	})
+	return
+}
+
+func (rc *RetryClient) DownloadCommitPayloads(ctx context.Context, commitID string) (payloads []*OperationPayload, err error) {
+	err = rc.retry(ctx, "download commit payloads", func() error {
+		payloads, err = rc.inner.DownloadCommitPayloads(ctx, commitID)
+		return err
+	})
+	return
+}
+
+func (rc *RetryClient) DownloadSchema(ctx context.Context, hash string) (snapshot *SchemaSnapshot, err error) {
+	err = rc.retry(ctx, "download schema", func() error {
+		snapshot, err = rc.inner.DownloadSchema(ctx, hash)
 		return err
 	})
 	return
 }
 
+func (rc *RetryClient) UploadStash(ctx context.Context, stash *RemoteStash) error {
+	return rc.retry(ctx, "upload stash", func() error {
+		return rc.inner.UploadStash(ctx, stash)
+	})
+}
+
+func (rc *RetryClient) ListStashes(ctx context.Context) (stashes []*RemoteStash, err error) {
+	err = rc.retry(ctx, "list stashes", func() error {
+		stashes, err = rc.inner.ListStashes(ctx)
+		return err
+	})
+	return
+}
+
+func (rc *RetryClient) DownloadStash(ctx context.Context, id string) (stash *RemoteStash, err error) {
+	err = rc.retry(ctx, "download stash", func() error {
+		stash, err = rc.inner.DownloadStash(ctx, id)
+		return err
+	})
+	return
+}
+
+func (rc *RetryClient) DeleteStash(ctx context.Context, id string) error {
+	return rc.retry(ctx, "delete stash", func() error {
+		return rc.inner.DeleteStash(ctx, id)
+	})
+}
+
 func (rc *RetryClient) UpdateBranch(ctx context.Context, branch, newTip, expectedTip string) error {
 	// CAS operations are NOT retried — conflict errors are not transient.
 	return rc.inner.UpdateBranch(ctx, branch, newTip, expectedTip)
@@ -193,6 +297,164 @@ func (rc *RetryClient) GetBranch(ctx context.Context, branch string) (b *models.
 	return
 }
 
+func (rc *RetryClient) ListUserRefs(ctx context.Context) (refs []*models.Branch, err error) {
+	err = rc.retry(ctx, "list personal refs", func() error {
+		refs, err = rc.inner.ListUserRefs(ctx)
+		return err
+	})
+	return
+}
+
+func (rc *RetryClient) UpdateUserRef(ctx context.Context, name, newTip, expectedTip string) error {
+	// CAS operations are NOT retried — conflict errors are not transient.
+	return rc.inner.UpdateUserRef(ctx, name, newTip, expectedTip)
+}
+
+func (rc *RetryClient) DeleteUserRef(ctx context.Context, name string) error {
+	return rc.retry(ctx, "delete personal ref", func() error {
+		return rc.inner.DeleteUserRef(ctx, name)
+	})
+}
+
+func (rc *RetryClient) ListTags(ctx context.Context) (tags []*models.Tag, err error) {
+	err = rc.retry(ctx, "list tags", func() error {
+		tags, err = rc.inner.ListTags(ctx)
+		return err
+	})
+	return
+}
+
+func (rc *RetryClient) PutTag(ctx context.Context, tag *models.Tag, force bool) error {
+	return rc.retry(ctx, "push tag", func() error {
+		return rc.inner.PutTag(ctx, tag, force)
+	})
+}
+
+func (rc *RetryClient) DeleteTag(ctx context.Context, name string) error {
+	return rc.retry(ctx, "delete tag", func() error {
+		return rc.inner.DeleteTag(ctx, name)
+	})
+}
+
+func (rc *RetryClient) ListNotes(ctx context.Context) (notes []*models.Note, err error) {
+	err = rc.retry(ctx, "list notes", func() error {
+		notes, err = rc.inner.ListNotes(ctx)
+		return err
+	})
+	return
+}
+
+func (rc *RetryClient) PutNote(ctx context.Context, note *models.Note, force bool) error {
+	return rc.retry(ctx, "push note", func() error {
+		return rc.inner.PutNote(ctx, note, force)
+	})
+}
+
+func (rc *RetryClient) DeleteNote(ctx context.Context, commitID string) error {
+	return rc.retry(ctx, "delete note", func() error {
+		return rc.inner.DeleteNote(ctx, commitID)
+	})
+}
+
+func (rc *RetryClient) ListDeployments(ctx context.Context) (refs []*models.Branch, err error) {
+	err = rc.retry(ctx, "list deployments", func() error {
+		refs, err = rc.inner.ListDeployments(ctx)
+		return err
+	})
+	return
+}
+
+func (rc *RetryClient) RecordDeployment(ctx context.Context, env, commitID string) error {
+	return rc.retry(ctx, "record deployment", func() error {
+		return rc.inner.RecordDeployment(ctx, env, commitID)
+	})
+}
+
+// StreamEvents streams events from the inner client without retrying, since
+// a stream that ends is resumed by the caller with the last event ID.
+func (rc *RetryClient) StreamEvents(ctx context.Context, branch string, lastID uint64, fn func(*RepoEvent) error) error {
+	subscriber, ok := rc.inner.(EventSubscriber)
+	if !ok {
+		return ErrEventsUnsupported
+	}
+	return subscriber.StreamEvents(ctx, branch, lastID, fn)
+}
+
+// ErrProposalsUnsupported is returned by the proposal methods of a
+// RetryClient whose inner client has none.
+var ErrProposalsUnsupported = errors.New("client does not support proposals")
+
+// proposals returns the inner client's proposal methods.
+func (rc *RetryClient) proposals() (ProposalClient, error) {
+	pc, ok := rc.inner.(ProposalClient)
+	if !ok {
+		return nil, ErrProposalsUnsupported
+	}
+	return pc, nil
+}
+
+func (rc *RetryClient) ListProposals(ctx context.Context) (proposals []*Proposal, err error) {
+	pc, err := rc.proposals()
+	if err != nil {
+		return nil, err
+	}
+	err = rc.retry(ctx, "list proposals", func() error {
+		proposals, err = pc.ListProposals(ctx)
+		return err
+	})
+	return
+}
+
+func (rc *RetryClient) GetProposal(ctx context.Context, id int) (p *Proposal, err error) {
+	pc, err := rc.proposals()
+	if err != nil {
+		return nil, err
+	}
+	err = rc.retry(ctx, "get proposal", func() error {
+		p, err = pc.GetProposal(ctx, id)
+		return err
+	})
+	return
+}
+
+// CreateProposal, ApproveProposal, and MergeProposal are not retried: a
+// request that reached the server may have taken effect.
+func (rc *RetryClient) CreateProposal(ctx context.Context, req *ProposalRequest) (*Proposal, error) {
+	pc, err := rc.proposals()
+	if err != nil {
+		return nil, err
+	}
+	return pc.CreateProposal(ctx, req)
+}
+
+func (rc *RetryClient) ApproveProposal(ctx context.Context, id int) (*Proposal, error) {
+	pc, err := rc.proposals()
+	if err != nil {
+		return nil, err
+	}
+	return pc.ApproveProposal(ctx, id)
+}
+
+func (rc *RetryClient) MergeProposal(ctx context.Context, id int) (*Proposal, error) {
+	pc, err := rc.proposals()
+	if err != nil {
+		return nil, err
+	}
+	return pc.MergeProposal(ctx, id)
+}
+
+func (rc *RetryClient) GetAuditLog(ctx context.Context) (entries []*AuditEntry, err error) {
+	pc, err := rc.proposals()
+	if err != nil {
+		return nil, err
+	}
+	err = rc.retry(ctx, "get audit log", func() error {
+		entries, err = pc.GetAuditLog(ctx)
+		return err
+	})
+	return
+}
+
 func (rc *RetryClient) GetRepoInfo(ctx context.Context) (info *RepoInfo, err error) {
 	err = rc.retry(ctx, "get repo info", func() error {
 		info, err = rc.inner.GetRepoInfo(ctx)
@@ -200,3 +462,19 @@ func (rc *RetryClient) GetRepoInfo(ctx context.Context) (info *RepoInfo, err err
 	})
 	return
 }
+
+func (rc *RetryClient) GetCommitLog(ctx context.Context, branch, before string, limit int) (resp *CommitLogResponse, err error) {
+	err = rc.retry(ctx, "get commit log", func() error {
+		resp, err = rc.inner.GetCommitLog(ctx, branch, before, limit)
+		return err
+	})
+	return
+}
+
+func (rc *RetryClient) Compare(ctx context.Context, base, head string) (resp *CompareResponse, err error) {
+	err = rc.retry(ctx, "compare", func() error {
+		resp, err = rc.inner.Compare(ctx, base, head)
+		return err
+	})
+	return
+}
@@ -2,6 +2,8 @@ package remote
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"testing"
 	"time"
@@ -33,6 +35,12 @@ func TestIsTransient_NetworkError(t *testing.T) {
 	assert.True(t, isTransient(err))
 }
 
+func TestIsTransient_Permanent(t *testing.T) {
+	err := fmt.Errorf("get local vector: %w", Permanent(errors.New("not found")))
+	assert.False(t, isTransient(err))
+	assert.Nil(t, Permanent(nil))
+}
+
 func TestRetryClient_Backoff(t *testing.T) {
 	rc := NewRetryClient(nil, &RetryConfig{
 		MaxRetries:     3,
@@ -41,9 +49,9 @@ func TestRetryClient_Backoff(t *testing.T) {
 		JitterFraction: 0.0, // no jitter for deterministic test
 	})
 
-	d0 := rc.backoff(0)
-	d1 := rc.backoff(1)
-	d2 := rc.backoff(2)
+	d0 := rc.config.backoff(0)
+	d1 := rc.config.backoff(1)
+	d2 := rc.config.backoff(2)
 
 	assert.Equal(t, 100*time.Millisecond, d0)
 	assert.Equal(t, 200*time.Millisecond, d1)
@@ -58,7 +66,7 @@ func TestRetryClient_BackoffCapped(t *testing.T) {
 		JitterFraction: 0.0,
 	})
 
-	d := rc.backoff(10)
+	d := rc.config.backoff(10)
 	assert.Equal(t, 5*time.Second, d)
 }
 
@@ -157,3 +165,12 @@ func TestSleep_Normal(t *testing.T) {
 	err := sleep(context.Background(), 1*time.Millisecond)
 	assert.NoError(t, err)
 }
+
+func TestRetry_WithoutOperation(t *testing.T) {
+	cfg := &RetryConfig{MaxRetries: 1, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+	err := Retry(context.Background(), cfg, "", func() error {
+		return &RemoteError{Status: 503, Code: "unavailable", Message: "busy"}
+	})
+	assert.EqualError(t, err, "remote error (503): unavailable — busy (after 1 retries)")
+}
@@ -0,0 +1,282 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/remote"
+	"github.com/kilupskalvis/wvc/internal/remote/blobstore"
+	"github.com/kilupskalvis/wvc/internal/remote/metastore"
+)
+
+// Commit list sizes of the compare endpoint.
+const (
+	defaultCompareLimit = 100
+	maxCompareLimit     = 1000
+)
+
+// maxCompareCommits bounds the commits read on either side of a comparison.
+const maxCompareCommits = 10000
+
+// errCompareTooLarge is returned when two refs have diverged too far to compare.
+var errCompareTooLarge = errors.New("too many commits to compare")
+
+// handleCompare compares two refs, each a branch, tag, or commit ID: the
+// commits each has that the other lacks and the diffstat of those on head.
+// base defaults to the repository's default branch.
+func handleCompare(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, _ blobstore.BlobStore, _ *ServerConfig) {
+	ctx := r.Context()
+	q := r.URL.Query()
+	limit := defaultCompareLimit
+	if s := q.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request", "message": "limit must be a positive integer"})
+			return
+		}
+		limit = min(n, maxCompareLimit)
+	}
+
+	baseName, headName := q.Get("base"), q.Get("head")
+	if headName == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request", "message": "head is required"})
+		return
+	}
+	if baseName == "" {
+		branches, err := meta.ListBranches(ctx)
+		if err != nil {
+			internalError(w, "list branches", err)
+			return
+		}
+		if baseName = defaultBranchName(publicBranches(branches)); baseName == "" {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not_found", "message": "repository has no branches"})
+			return
+		}
+	}
+
+	resp := &remote.CompareResponse{Base: baseName, Head: headName}
+	for _, ref := range []struct {
+		name string
+		id   *string
+	}{{baseName, &resp.BaseCommit}, {headName, &resp.HeadCommit}} {
+		id, err := resolveCompareRef(ctx, meta, ref.name)
+		if errors.Is(err, metastore.ErrNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not_found", "message": fmt.Sprintf("ref '%s' not found", ref.name)})
+			return
+		}
+		if err != nil {
+			internalError(w, "resolve ref", err)
+			return
+		}
+		*ref.id = id
+	}
+
+	if err := compareCommits(ctx, meta, resp, limit); err != nil {
+		if errors.Is(err, errCompareTooLarge) {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": "too_large", "message": err.Error()})
+			return
+		}
+		internalError(w, "compare", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// resolveCompareRef resolves a branch, tag, or commit ID to a commit ID, in
+// that order. Branches in the reserved refs/ namespace are not visible.
+func resolveCompareRef(ctx context.Context, meta metastore.MetaStore, name string) (string, error) {
+	if !isReservedRef(name) {
+		branch, err := meta.GetBranch(ctx, name)
+		if err == nil {
+			return branch.CommitID, nil
+		}
+		if !errors.Is(err, metastore.ErrNotFound) {
+			return "", err
+		}
+	}
+	tag, err := meta.GetTag(ctx, name)
+	if err == nil {
+		return tag.CommitID, nil
+	}
+	if !errors.Is(err, metastore.ErrNotFound) {
+		return "", err
+	}
+	has, err := meta.HasCommit(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	if !has {
+		return "", metastore.ErrNotFound
+	}
+	return name, nil
+}
+
+// compareCommits fills in the ahead and behind commits, the merge base, and
+// the diffstat of resp, whose commits are resolved.
+func compareCommits(ctx context.Context, meta metastore.MetaStore, resp *remote.CompareResponse, limit int) error {
+	baseAncestors, err := meta.GetAncestors(ctx, resp.BaseCommit)
+	if err != nil {
+		return fmt.Errorf("get ancestors of %s: %w", resp.BaseCommit, err)
+	}
+	headAncestors, err := meta.GetAncestors(ctx, resp.HeadCommit)
+	if err != nil {
+		return fmt.Errorf("get ancestors of %s: %w", resp.HeadCommit, err)
+	}
+
+	side := func(tip string, other map[string]bool) ([]*models.Commit, error) {
+		if other[tip] {
+			return nil, nil
+		}
+		c, err := meta.GetCommit(ctx, tip)
+		if err != nil {
+			return nil, fmt.Errorf("get commit %s: %w", tip, err)
+		}
+		commits, truncated, err := collectCommits(ctx, meta, c, other, maxCompareCommits)
+		if err != nil {
+			return nil, err
+		}
+		if truncated {
+			return nil, fmt.Errorf("%w: more than %d commits on one side", errCompareTooLarge, maxCompareCommits)
+		}
+		return commits, nil
+	}
+	ahead, err := side(resp.HeadCommit, baseAncestors)
+	if err != nil {
+		return err
+	}
+	behind, err := side(resp.BaseCommit, headAncestors)
+	if err != nil {
+		return err
+	}
+
+	if resp.Diffstat, err = diffstat(ctx, meta, ahead); err != nil {
+		return err
+	}
+	resp.MergeBase, err = compareMergeBase(ctx, meta, resp.HeadCommit, ahead, baseAncestors)
+	if err != nil {
+		return err
+	}
+
+	for _, commits := range []*[]*models.Commit{&ahead, &behind} {
+		sort.Slice(*commits, func(i, j int) bool {
+			a, b := (*commits)[i], (*commits)[j]
+			if !a.Timestamp.Equal(b.Timestamp) {
+				return a.Timestamp.After(b.Timestamp)
+			}
+			return a.ID > b.ID
+		})
+	}
+	resp.AheadBy, resp.BehindBy = len(ahead), len(behind)
+	resp.Ahead = ahead[:min(len(ahead), limit)]
+	resp.Behind = behind[:min(len(behind), limit)]
+	if resp.Ahead == nil {
+		resp.Ahead = []*models.Commit{}
+	}
+	if resp.Behind == nil {
+		resp.Behind = []*models.Commit{}
+	}
+	return nil
+}
+
+// compareMergeBase returns the newest commit both sides share: head itself
+// when base already contains it, otherwise the newest parent of a commit
+// ahead that base contains. It is empty for unrelated histories.
+func compareMergeBase(ctx context.Context, meta metastore.MetaStore, head string, ahead []*models.Commit, baseAncestors map[string]bool) (string, error) {
+	if baseAncestors[head] {
+		return head, nil
+	}
+	var best *models.Commit
+	seen := make(map[string]bool)
+	for _, c := range ahead {
+		for _, id := range []string{c.ParentID, c.MergeParentID} {
+			if id == "" || !baseAncestors[id] || seen[id] {
+				continue
+			}
+			seen[id] = true
+			parent, err := meta.GetCommit(ctx, id)
+			if err != nil {
+				return "", fmt.Errorf("get commit %s: %w", id, err)
+			}
+			if best == nil || parent.Timestamp.After(best.Timestamp) ||
+				(parent.Timestamp.Equal(best.Timestamp) && parent.ID > best.ID) {
+				best = parent
+			}
+		}
+	}
+	if best == nil {
+		return "", nil
+	}
+	return best.ID, nil
+}
+
+// collectCommits walks back breadth-first from tip, stopping at commits in
+// known, and returns the commits reached, tip first. It reads at most limit
+// commits and reports whether more remained.
+func collectCommits(ctx context.Context, meta metastore.MetaStore, tip *models.Commit, known map[string]bool, limit int) ([]*models.Commit, bool, error) {
+	truncated := false
+	reached := map[string]bool{tip.ID: true}
+	order := []*models.Commit{tip}
+	for i := 0; i < len(order); i++ {
+		for _, id := range []string{order[i].ParentID, order[i].MergeParentID} {
+			if id == "" || known[id] || reached[id] {
+				continue
+			}
+			if len(order) == limit {
+				truncated = true
+				continue
+			}
+			c, err := meta.GetCommit(ctx, id)
+			if err != nil {
+				return nil, false, fmt.Errorf("get commit %s: %w", id, err)
+			}
+			reached[id] = true
+			order = append(order, c)
+		}
+	}
+	return order, truncated, nil
+}
+
+// diffstat counts the operations of commits by class. Merge commits whose
+// merged parent is among the commits are skipped; their operations repeat
+// those of the merged commits.
+func diffstat(ctx context.Context, meta metastore.MetaStore, commits []*models.Commit) (*remote.Diffstat, error) {
+	included := make(map[string]bool, len(commits))
+	for _, c := range commits {
+		included[c.ID] = true
+	}
+
+	stat := &remote.Diffstat{Classes: map[string]*remote.ClassDiffstat{}}
+	for _, c := range commits {
+		if c.MergeParentID != "" && included[c.MergeParentID] {
+			continue
+		}
+		ops, err := meta.GetOperationsByCommit(ctx, c.ID)
+		if err != nil {
+			return nil, fmt.Errorf("get operations of %s: %w", c.ID, err)
+		}
+		for _, op := range ops {
+			counts := stat.Classes[op.ClassName]
+			if counts == nil {
+				counts = &remote.ClassDiffstat{}
+				stat.Classes[op.ClassName] = counts
+			}
+			switch op.Type {
+			case models.OperationInsert:
+				counts.Inserted++
+				stat.Inserted++
+			case models.OperationUpdate:
+				counts.Updated++
+				stat.Updated++
+			case models.OperationDelete:
+				counts.Deleted++
+				stat.Deleted++
+			}
+		}
+	}
+	return stat, nil
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompare(t *testing.T) {
+	ts, meta, _, token := newTestServer(t)
+	ctx := context.Background()
+
+	op := func(typ models.OperationType, class string) *models.Operation {
+		return &models.Operation{Type: typ, ClassName: class, ObjectID: "obj"}
+	}
+	base := time.Now()
+	at := func(i int) time.Time { return base.Add(time.Duration(i) * time.Minute) }
+	// main: c1 <- c2; feature: c1 <- c3 <- c5 (merge of c4, which forks from c3)
+	for _, b := range []*remote.CommitBundle{
+		{Commit: &models.Commit{ID: "c1", Message: "first", Timestamp: at(1)},
+			Operations: []*models.Operation{op(models.OperationInsert, "Article")}},
+		{Commit: &models.Commit{ID: "c2", ParentID: "c1", Message: "main work", Timestamp: at(2)},
+			Operations: []*models.Operation{op(models.OperationUpdate, "Article")}},
+		{Commit: &models.Commit{ID: "c3", ParentID: "c1", Message: "add authors", Timestamp: at(3)},
+			Operations: []*models.Operation{op(models.OperationInsert, "Author"), op(models.OperationInsert, "Author")}},
+		{Commit: &models.Commit{ID: "c4", ParentID: "c3", Message: "drop article", Timestamp: at(4)},
+			Operations: []*models.Operation{op(models.OperationDelete, "Article")}},
+		{Commit: &models.Commit{ID: "c5", ParentID: "c3", MergeParentID: "c4", Message: "merge", Timestamp: at(5)},
+			Operations: []*models.Operation{op(models.OperationDelete, "Article")}},
+	} {
+		require.NoError(t, meta.InsertCommitBundle(ctx, b))
+	}
+	require.NoError(t, meta.CreateBranch(ctx, "main", "c2"))
+	require.NoError(t, meta.CreateBranch(ctx, "feature", "c5"))
+	require.NoError(t, meta.PutTag(ctx, &models.Tag{Name: "v1", CommitID: "c1"}, false))
+
+	client := remote.NewHTTPClient(ts.URL, "test", token)
+	ids := func(commits []*models.Commit) []string {
+		out := []string{}
+		for _, c := range commits {
+			out = append(out, c.ID)
+		}
+		return out
+	}
+
+	// An empty base compares against the default branch
+	cmp, err := client.Compare(ctx, "", "feature")
+	require.NoError(t, err)
+	assert.Equal(t, "main", cmp.Base)
+	assert.Equal(t, "c2", cmp.BaseCommit)
+	assert.Equal(t, "c5", cmp.HeadCommit)
+	assert.Equal(t, "c1", cmp.MergeBase)
+	assert.Equal(t, 3, cmp.AheadBy)
+	assert.Equal(t, 1, cmp.BehindBy)
+	assert.Equal(t, []string{"c5", "c4", "c3"}, ids(cmp.Ahead))
+	assert.Equal(t, []string{"c2"}, ids(cmp.Behind))
+
+	// The merge commit repeats the merged delete and is not counted
+	require.NotNil(t, cmp.Diffstat)
+	assert.Equal(t, 2, cmp.Diffstat.Inserted)
+	assert.Equal(t, 0, cmp.Diffstat.Updated)
+	assert.Equal(t, 1, cmp.Diffstat.Deleted)
+	assert.Equal(t, map[string]*remote.ClassDiffstat{
+		"Author":  {Inserted: 2},
+		"Article": {Deleted: 1},
+	}, cmp.Diffstat.Classes)
+
+	// Tags and commit IDs resolve too
+	cmp, err = client.Compare(ctx, "v1", "c4")
+	require.NoError(t, err)
+	assert.Equal(t, "c1", cmp.MergeBase)
+	assert.Equal(t, []string{"c4", "c3"}, ids(cmp.Ahead))
+	assert.Empty(t, cmp.Behind)
+
+	// A head already in base is behind only
+	cmp, err = client.Compare(ctx, "feature", "v1")
+	require.NoError(t, err)
+	assert.Equal(t, "c1", cmp.MergeBase)
+	assert.Equal(t, 0, cmp.AheadBy)
+	assert.Equal(t, 3, cmp.BehindBy)
+	assert.Empty(t, cmp.Diffstat.Classes)
+
+	_, err = client.Compare(ctx, "main", "missing")
+	assert.Error(t, err)
+
+	for _, query := range []string{"base=main", "head=feature&limit=0"} {
+		resp, err := http.DefaultClient.Do(authReq("GET", ts.URL+"/api/v1/repos/test/compare?"+query, token, nil))
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+	}
+	resp, err := http.DefaultClient.Do(authReq("GET", ts.URL+"/api/v1/repos/test/compare?head=missing", token, nil))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
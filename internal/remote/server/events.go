@@ -0,0 +1,204 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/remote"
+	"github.com/kilupskalvis/wvc/internal/remote/blobstore"
+	"github.com/kilupskalvis/wvc/internal/remote/metastore"
+)
+
+const (
+	// eventBacklog is how many recent events are kept for clients resuming
+	// with Last-Event-ID.
+	eventBacklog = 256
+	// eventBuffer is how many events a subscriber may fall behind before it
+	// is disconnected to resume from the backlog.
+	eventBuffer = 64
+	// eventKeepalive is the interval of comment lines that keep idle streams
+	// open through proxies.
+	eventKeepalive = 15 * time.Second
+)
+
+// EventBroker fans repository events out to the subscribers of the events
+// endpoint. Events live in memory only: with several server replicas, each
+// streams the events of the writes it handled.
+type EventBroker struct {
+	mu      sync.Mutex
+	nextID  uint64
+	backlog []remote.RepoEvent
+	subs    map[*eventSub]struct{}
+	closed  bool
+}
+
+type eventSub struct {
+	repo string
+	ch   chan remote.RepoEvent
+}
+
+// NewEventBroker creates an event broker.
+func NewEventBroker() *EventBroker {
+	return &EventBroker{
+		// IDs start at the current time so they keep increasing across
+		// restarts and a resuming client never skips new events
+		nextID: uint64(time.Now().UnixNano()),
+		subs:   make(map[*eventSub]struct{}),
+	}
+}
+
+// Publish sends an event to the subscribers of repo. It never blocks: a
+// subscriber whose buffer is full is disconnected.
+func (b *EventBroker) Publish(repo, eventType, branch, commitID string) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+
+	b.nextID++
+	event := remote.RepoEvent{
+		ID:        b.nextID,
+		Type:      eventType,
+		Repo:      repo,
+		Branch:    branch,
+		CommitID:  commitID,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+	}
+	b.backlog = append(b.backlog, event)
+	if len(b.backlog) > eventBacklog {
+		b.backlog = b.backlog[len(b.backlog)-eventBacklog:]
+	}
+
+	for sub := range b.subs {
+		if sub.repo != repo {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			delete(b.subs, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// subscribe registers a subscriber for repo and returns the backlogged events
+// after lastID. It returns nil once the broker is closed.
+func (b *EventBroker) subscribe(repo string, lastID uint64) (*eventSub, []remote.RepoEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, nil
+	}
+
+	var replay []remote.RepoEvent
+	if lastID > 0 {
+		for _, event := range b.backlog {
+			if event.ID > lastID && event.Repo == repo {
+				replay = append(replay, event)
+			}
+		}
+	}
+	sub := &eventSub{repo: repo, ch: make(chan remote.RepoEvent, eventBuffer)}
+	b.subs[sub] = struct{}{}
+	return sub, replay
+}
+
+func (b *EventBroker) unsubscribe(sub *eventSub) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subs[sub]; ok {
+		delete(b.subs, sub)
+		close(sub.ch)
+	}
+}
+
+// Close ends all event streams, e.g. on server shutdown.
+func (b *EventBroker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for sub := range b.subs {
+		delete(b.subs, sub)
+		close(sub.ch)
+	}
+}
+
+// --- Event Stream Handler ---
+
+// handleEvents streams the repository's events as Server-Sent Events. A
+// branch query parameter limits the stream to one branch, and a
+// Last-Event-ID header replays recent events missed since that ID.
+func handleEvents(w http.ResponseWriter, r *http.Request, _ metastore.MetaStore, _ blobstore.BlobStore, cfg *ServerConfig) {
+	repo := r.PathValue("repo")
+	branch := r.URL.Query().Get("branch")
+	lastID, _ := strconv.ParseUint(r.Header.Get("Last-Event-ID"), 10, 64)
+
+	sub, replay := cfg.Events.subscribe(repo, lastID)
+	if sub == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "unavailable", "message": "server is shutting down"})
+		return
+	}
+	defer cfg.Events.unsubscribe(sub)
+
+	// Streams outlive the server's write timeout
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	send := func(event remote.RepoEvent) error {
+		if branch != "" && event.Branch != branch {
+			return nil
+		}
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+		return err
+	}
+
+	for _, event := range replay {
+		if send(event) != nil {
+			return
+		}
+	}
+	if rc.Flush() != nil {
+		return
+	}
+
+	keepalive := time.NewTicker(eventKeepalive)
+	defer keepalive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-sub.ch:
+			if !ok {
+				return
+			}
+			if send(event) != nil {
+				return
+			}
+		case <-keepalive.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+		}
+		if rc.Flush() != nil {
+			return
+		}
+	}
+}
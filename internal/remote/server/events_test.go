@@ -0,0 +1,183 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventBroker_ReplaysAfterLastID(t *testing.T) {
+	b := NewEventBroker()
+	defer b.Close()
+
+	b.Publish("repo", remote.EventPush, "main", "c1")
+	b.Publish("other", remote.EventPush, "main", "x1")
+	b.Publish("repo", remote.EventPush, "main", "c2")
+
+	sub, replay := b.subscribe("repo", 0)
+	assert.Empty(t, replay, "new subscribers start at the live edge")
+	b.unsubscribe(sub)
+
+	first := b.backlog[0].ID
+	_, replay = b.subscribe("repo", first)
+	require.Len(t, replay, 1)
+	assert.Equal(t, "c2", replay[0].CommitID)
+}
+
+func TestEventBroker_DisconnectsSlowSubscriber(t *testing.T) {
+	b := NewEventBroker()
+	sub, _ := b.subscribe("repo", 0)
+
+	for i := 0; i <= eventBuffer; i++ {
+		b.Publish("repo", remote.EventPush, "main", "c"+strconv.Itoa(i))
+	}
+	n := 0
+	for range sub.ch {
+		n++
+	}
+	assert.Equal(t, eventBuffer, n, "the channel is closed once the buffer overflows")
+
+	b.unsubscribe(sub) // no-op after disconnect
+	b.Close()
+	sub, _ = b.subscribe("repo", 0)
+	assert.Nil(t, sub)
+}
+
+func TestEvents_StreamsBranchChanges(t *testing.T) {
+	ts, meta, _, token := newTestServer(t)
+	ctx := context.Background()
+	for _, id := range []string{"c1", "c2"} {
+		require.NoError(t, meta.InsertCommitBundle(ctx, &remote.CommitBundle{Commit: &models.Commit{ID: id, Timestamp: time.Now()}}))
+	}
+
+	streamCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	req := authReq("GET", ts.URL+"/api/v1/repos/test/events?branch=main", token, nil).WithContext(streamCtx)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	update := func(branch, commitID, expected string) {
+		data, _ := json.Marshal(&remote.BranchUpdateRequest{CommitID: commitID, Expected: expected})
+		resp, err := http.DefaultClient.Do(authReq("PUT", ts.URL+"/api/v1/repos/test/branches/"+branch, token, bytes.NewReader(data)))
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	update("feature", "c1", "") // filtered out
+	update("main", "c1", "")
+	update("main", "c2", "c1")
+	resp2, err := http.DefaultClient.Do(authReq("DELETE", ts.URL+"/api/v1/repos/test/branches/main", token, nil))
+	require.NoError(t, err)
+	resp2.Body.Close()
+
+	events := readEvents(t, bufio.NewReader(resp.Body), 3)
+	assert.Equal(t, remote.EventPush, events[0].Type)
+	assert.Equal(t, "c1", events[0].CommitID)
+	assert.Equal(t, "c2", events[1].CommitID)
+	assert.Equal(t, remote.EventBranchDelete, events[2].Type)
+	assert.Equal(t, "test", events[2].Repo)
+	assert.Less(t, events[0].ID, events[1].ID)
+
+	// Resuming replays what came after the given ID
+	req = authReq("GET", ts.URL+"/api/v1/repos/test/events", token, nil).WithContext(streamCtx)
+	req.Header.Set("Last-Event-ID", strconv.FormatUint(events[0].ID, 10))
+	resp3, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp3.Body.Close()
+	replayed := readEvents(t, bufio.NewReader(resp3.Body), 2)
+	assert.Equal(t, events[1:], replayed)
+}
+
+func TestEvents_ClientStreamsEvents(t *testing.T) {
+	ts, meta, _, token := newTestServer(t)
+	ctx := context.Background()
+	require.NoError(t, meta.InsertCommitBundle(ctx, &remote.CommitBundle{Commit: &models.Commit{ID: "c1", Timestamp: time.Now()}}))
+
+	client := remote.NewHTTPClient(ts.URL, "test", token)
+	errStop := errors.New("stop")
+	received := make(chan *remote.RepoEvent, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- client.StreamEvents(ctx, "main", 0, func(event *remote.RepoEvent) error {
+			received <- event
+			return errStop
+		})
+	}()
+
+	// Create and delete the branch until the subscription, which connects
+	// concurrently, sees a change
+	var event *remote.RepoEvent
+	for event == nil {
+		for _, req := range []*http.Request{
+			authReq("PUT", ts.URL+"/api/v1/repos/test/branches/main", token, strings.NewReader(`{"commit_id":"c1"}`)),
+			authReq("DELETE", ts.URL+"/api/v1/repos/test/branches/main", token, nil),
+		} {
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+		}
+		select {
+		case event = <-received:
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+	assert.Equal(t, "main", event.Branch)
+	assert.Equal(t, "test", event.Repo)
+	assert.ErrorIs(t, <-done, errStop)
+
+	// Servers without the endpoint
+	old := httptest.NewServer(http.NotFoundHandler())
+	defer old.Close()
+	err := remote.NewHTTPClient(old.URL, "test", token).StreamEvents(ctx, "", 0, nil)
+	assert.ErrorIs(t, err, remote.ErrEventsUnsupported)
+}
+
+func TestEvents_RequiresAuth(t *testing.T) {
+	ts, _, _, _ := newTestServer(t)
+	resp, err := http.Get(ts.URL + "/api/v1/repos/test/events")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+// readEvents parses n events from a Server-Sent Events stream.
+func readEvents(t *testing.T, rd *bufio.Reader, n int) []remote.RepoEvent {
+	t.Helper()
+	var events []remote.RepoEvent
+	var id, eventType string
+	for len(events) < n {
+		line, err := rd.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			eventType = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			var event remote.RepoEvent
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event))
+			assert.Equal(t, id, strconv.FormatUint(event.ID, 10))
+			assert.Equal(t, eventType, event.Type)
+			events = append(events, event)
+		}
+	}
+	return events
+}
@@ -13,11 +13,17 @@ import (
 type GCResult struct {
 	BlobsScanned    int
 	BlobsDeleted    int
+	BlobsDeferred   int // unreferenced but kept until their grace period ends
 	ReferencedBlobs int
 }
 
 // GarbageCollect removes blobs not referenced by any operation in the metastore.
 func GarbageCollect(ctx context.Context, meta metastore.MetaStore, blobs blobstore.BlobStore, logger *slog.Logger) (*GCResult, error) {
+	return garbageCollect(ctx, meta, blobs, logger, nil)
+}
+
+// garbageCollect removes unreferenced blobs for which keep, if set, returns false.
+func garbageCollect(ctx context.Context, meta metastore.MetaStore, blobs blobstore.BlobStore, logger *slog.Logger, keep func(hash string) bool) (*GCResult, error) {
 	result := &GCResult{}
 
 	// Collect all referenced vector hashes
@@ -39,6 +45,10 @@ func GarbageCollect(ctx context.Context, meta metastore.MetaStore, blobs blobsto
 		if referenced[hash] {
 			continue
 		}
+		if keep != nil && keep(hash) {
+			result.BlobsDeferred++
+			continue
+		}
 		if err := blobs.Delete(ctx, hash); err != nil {
 			logger.Warn("gc: failed to delete blob", "hash", hash, "error", err)
 			continue
@@ -46,10 +56,21 @@ func GarbageCollect(ctx context.Context, meta metastore.MetaStore, blobs blobsto
 		result.BlobsDeleted++
 	}
 
+	// The scan is the true blob count; it corrects any drift in the counter
+	if err := meta.SetBlobCount(ctx, result.BlobsScanned-result.BlobsDeleted); err != nil {
+		logger.Warn("gc: failed to update blob count", "error", err)
+	}
+	if size, err := blobs.TotalSize(ctx); err != nil {
+		logger.Warn("gc: failed to measure blobs", "error", err)
+	} else if err := meta.SetBlobBytes(ctx, size); err != nil {
+		logger.Warn("gc: failed to update blob bytes", "error", err)
+	}
+
 	logger.Info("gc complete",
 		"scanned", result.BlobsScanned,
 		"referenced", result.ReferencedBlobs,
 		"deleted", result.BlobsDeleted,
+		"deferred", result.BlobsDeferred,
 	)
 
 	return result, nil
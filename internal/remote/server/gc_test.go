@@ -115,6 +115,16 @@ func TestGarbageCollect_DeletesUnreferenced(t *testing.T) {
 	assert.Equal(t, 1, result.BlobsDeleted)
 	assert.Equal(t, 1, result.ReferencedBlobs)
 
+	// The blob count and bytes are reset to what survived
+	count, ok, err := meta.GetBlobCount(ctx)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 1, count)
+	size, ok, err := meta.GetBlobBytes(ctx)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(len(data1)), size)
+
 	// Verify orphan is gone
 	has, err := blobs.Has(ctx, hash2)
 	require.NoError(t, err)
@@ -0,0 +1,203 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/remote/metastore"
+)
+
+// GC triggers recorded in GCStatus.
+const (
+	GCTriggerScheduled = "scheduled"
+	GCTriggerManual    = "manual"
+)
+
+// errLockUnavailable is returned by a GC run that could not take the repo's write lock.
+var errLockUnavailable = errors.New("write lock unavailable")
+
+// GCStatus describes the last garbage collection run of a repository.
+type GCStatus struct {
+	Trigger    string    `json:"trigger"`
+	StartedAt  time.Time `json:"started_at"`
+	DurationMs int64     `json:"duration_ms"`
+	Result     *GCResult `json:"result,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// gcScheduler runs garbage collection on every repository at a fixed interval
+// and records the outcome of each run, scheduled or manual.
+//
+// A blob store does not report when a blob was written, so the grace period
+// is measured from the first scheduled run that found a blob unreferenced: it
+// is deleted by a later run once it has stayed unreferenced that long. This
+// keeps vectors uploaded ahead of their commit bundle.
+type gcScheduler struct {
+	repos    RepoOpener
+	manager  RepoManager
+	locker   RepoLocker
+	interval time.Duration
+	grace    time.Duration
+	logger   *slog.Logger
+	now      func() time.Time
+	// onRun, when set, is called after every run that got the write lock
+	onRun func(ctx context.Context, repo string, meta metastore.MetaStore, status *GCStatus)
+
+	mu      sync.Mutex
+	runs    map[string]*GCStatus
+	pending map[string]map[string]time.Time // repo -> unreferenced blob -> first seen
+	nextRun time.Time
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func newGCScheduler(repos RepoOpener, manager RepoManager, locker RepoLocker, interval, grace time.Duration, logger *slog.Logger) *gcScheduler {
+	return &gcScheduler{
+		repos:    repos,
+		manager:  manager,
+		locker:   locker,
+		interval: interval,
+		grace:    grace,
+		logger:   logger,
+		now:      time.Now,
+		runs:     make(map[string]*GCStatus),
+		pending:  make(map[string]map[string]time.Time),
+	}
+}
+
+// start runs garbage collection every interval until stop. It does nothing
+// when no interval is configured.
+func (s *gcScheduler) start() {
+	if s.interval <= 0 {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+	s.setNextRun()
+
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.runAll(ctx)
+				s.setNextRun()
+			}
+		}
+	}()
+	s.logger.Info("scheduled gc enabled", "interval", s.interval, "grace_period", s.grace)
+}
+
+// stop stops the scheduler and waits for a run in progress to finish.
+func (s *gcScheduler) stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	<-s.done
+}
+
+// runAll collects garbage in every repository in turn.
+func (s *gcScheduler) runAll(ctx context.Context) {
+	names, err := s.manager.List()
+	if err != nil {
+		s.logger.Error("scheduled gc: list repos", "error", err)
+		return
+	}
+	for _, name := range names {
+		if ctx.Err() != nil {
+			return
+		}
+		if _, err := s.run(ctx, name, GCTriggerScheduled); err != nil {
+			s.logger.Error("scheduled gc failed", "repo", name, "error", err)
+		}
+	}
+}
+
+// run collects garbage in a repository under its write lock and records the
+// outcome. Manual runs delete every unreferenced blob; scheduled runs honor
+// the grace period.
+func (s *gcScheduler) run(ctx context.Context, repo, trigger string) (*GCResult, error) {
+	meta, blobs, err := s.repos.Open(repo)
+	if err != nil {
+		return nil, fmt.Errorf("open repo: %w", err)
+	}
+
+	// Hold the write lock so GC can't delete a blob a concurrent push just referenced
+	if err := s.locker.LockWrite(ctx, repo); err != nil {
+		return nil, fmt.Errorf("%w: %w", errLockUnavailable, err)
+	}
+	defer s.locker.UnlockWrite(repo)
+
+	var keep func(string) bool
+	seen := make(map[string]time.Time)
+	started := s.now()
+	if trigger == GCTriggerScheduled && s.grace > 0 {
+		s.mu.Lock()
+		pending := s.pending[repo]
+		s.mu.Unlock()
+		keep = func(hash string) bool {
+			first, ok := pending[hash]
+			if !ok {
+				first = started
+			}
+			if started.Sub(first) >= s.grace {
+				return false
+			}
+			seen[hash] = first
+			return true
+		}
+	}
+
+	result, err := garbageCollect(ctx, meta, blobs, s.logger.With("repo", repo), keep)
+	status := &GCStatus{
+		Trigger:    trigger,
+		StartedAt:  started,
+		DurationMs: s.now().Sub(started).Milliseconds(),
+		Result:     result,
+	}
+	if err != nil {
+		status.Error = err.Error()
+	}
+
+	s.mu.Lock()
+	s.runs[repo] = status
+	if err == nil && keep != nil {
+		s.pending[repo] = seen
+	}
+	s.mu.Unlock()
+	if s.onRun != nil {
+		s.onRun(ctx, repo, meta, status)
+	}
+	return result, err
+}
+
+// lastRun returns the last recorded run of a repository, or nil.
+func (s *gcScheduler) lastRun(repo string) *GCStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.runs[repo]
+}
+
+func (s *gcScheduler) setNextRun() {
+	s.mu.Lock()
+	s.nextRun = s.now().Add(s.interval)
+	s.mu.Unlock()
+}
+
+// nextRunAt returns when the next scheduled run starts; zero when GC is not scheduled.
+func (s *gcScheduler) nextRunAt() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.nextRun
+}
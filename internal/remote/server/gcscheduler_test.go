@@ -0,0 +1,92 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/remote"
+	"github.com/kilupskalvis/wvc/internal/remote/blobstore"
+	"github.com/kilupskalvis/wvc/internal/remote/metastore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGCScheduler_GracePeriod(t *testing.T) {
+	ctx := context.Background()
+
+	meta, err := metastore.NewBboltStore(t.TempDir() + "/meta.db")
+	require.NoError(t, err)
+	defer meta.Close()
+
+	blobs, err := blobstore.NewFSStore(t.TempDir())
+	require.NoError(t, err)
+
+	orphan := []byte("orphan blob")
+	orphanHash := hashTestBytes(orphan)
+	require.NoError(t, blobs.Put(ctx, orphanHash, bytes.NewReader(orphan), 4))
+
+	pushed := []byte("pushed blob")
+	pushedHash := hashTestBytes(pushed)
+	require.NoError(t, blobs.Put(ctx, pushedHash, bytes.NewReader(pushed), 4))
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := newGCScheduler(&testRepoOpener{meta: meta, blobs: blobs}, &testRepoManager{repos: []string{"test"}},
+		noopRepoLocker{}, time.Hour, 2*time.Hour, slog.Default())
+	s.now = func() time.Time { return now }
+
+	// Both blobs are unreferenced but within the grace period
+	s.runAll(ctx)
+	status := s.lastRun("test")
+	require.NotNil(t, status)
+	assert.Equal(t, GCTriggerScheduled, status.Trigger)
+	assert.Equal(t, 2, status.Result.BlobsDeferred)
+	assert.Equal(t, 0, status.Result.BlobsDeleted)
+
+	// The commit referencing one of them arrives
+	require.NoError(t, meta.InsertCommitBundle(ctx, &remote.CommitBundle{
+		Commit:     &models.Commit{ID: "commit1", Message: "test"},
+		Operations: []*models.Operation{{Seq: 0, Type: "upsert", ClassName: "Test", VectorHash: pushedHash}},
+	}))
+
+	now = now.Add(time.Hour)
+	s.runAll(ctx)
+	assert.Equal(t, 1, s.lastRun("test").Result.BlobsDeferred)
+	has, err := blobs.Has(ctx, orphanHash)
+	require.NoError(t, err)
+	assert.True(t, has, "kept until the grace period ends")
+
+	now = now.Add(time.Hour)
+	s.runAll(ctx)
+	assert.Equal(t, 1, s.lastRun("test").Result.BlobsDeleted)
+	has, err = blobs.Has(ctx, orphanHash)
+	require.NoError(t, err)
+	assert.False(t, has)
+	has, err = blobs.Has(ctx, pushedHash)
+	require.NoError(t, err)
+	assert.True(t, has)
+}
+
+func TestGCScheduler_ManualRunIgnoresGrace(t *testing.T) {
+	ctx := context.Background()
+
+	meta, err := metastore.NewBboltStore(t.TempDir() + "/meta.db")
+	require.NoError(t, err)
+	defer meta.Close()
+
+	blobs, err := blobstore.NewFSStore(t.TempDir())
+	require.NoError(t, err)
+
+	orphan := []byte("orphan blob")
+	require.NoError(t, blobs.Put(ctx, hashTestBytes(orphan), bytes.NewReader(orphan), 4))
+
+	s := newGCScheduler(&testRepoOpener{meta: meta, blobs: blobs}, &testRepoManager{},
+		noopRepoLocker{}, 0, time.Hour, slog.Default())
+	result, err := s.run(ctx, "test", GCTriggerManual)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.BlobsDeleted)
+	assert.Equal(t, GCTriggerManual, s.lastRun("test").Trigger)
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"compress/gzip"
+	"context"
 	"crypto/sha256"
 	"crypto/subtle"
 	"encoding/json"
@@ -10,8 +11,11 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/kilupskalvis/wvc/internal/models"
 	"github.com/kilupskalvis/wvc/internal/remote"
@@ -24,19 +28,41 @@ type RepoOpener interface {
 	Open(name string) (metastore.MetaStore, blobstore.BlobStore, error)
 }
 
+// ReadRepoOpener is implemented by a RepoOpener that can serve reads from
+// replicas. Read-only endpoints (pull negotiation, bundle, schema, branch, and
+// info reads) open repos through OpenRead, while all writes and push
+// negotiation use Open and go to the primary.
+type ReadRepoOpener interface {
+	OpenRead(name string) (metastore.MetaStore, blobstore.BlobStore, error)
+}
+
+// readOpener routes Open to OpenRead when the wrapped opener supports it.
+type readOpener struct {
+	RepoOpener
+}
+
+func (o readOpener) Open(name string) (metastore.MetaStore, blobstore.BlobStore, error) {
+	if r, ok := o.RepoOpener.(ReadRepoOpener); ok {
+		return r.OpenRead(name)
+	}
+	return o.RepoOpener.Open(name)
+}
+
 // RepoLocker provides per-repo mutual exclusion between write operations and GC.
 // Write handlers and GC acquire the lock to prevent the race condition where GC
-// deletes a blob that was just referenced by a concurrent push.
+// deletes a blob that was just referenced by a concurrent push. LockWrite
+// blocks until the lock is held or ctx is done; a distributed implementation
+// also fails when its lock service is unreachable.
 type RepoLocker interface {
-	LockWrite(repo string)
+	LockWrite(ctx context.Context, repo string) error
 	UnlockWrite(repo string)
 }
 
 // noopRepoLocker is a no-op implementation for when no locking is needed.
 type noopRepoLocker struct{}
 
-func (noopRepoLocker) LockWrite(string)   {}
-func (noopRepoLocker) UnlockWrite(string) {}
+func (noopRepoLocker) LockWrite(context.Context, string) error { return nil }
+func (noopRepoLocker) UnlockWrite(string)                      {}
 
 // RepoManager provides lifecycle management for repositories.
 type RepoManager interface {
@@ -59,14 +85,68 @@ type ServerConfig struct {
 	RequestsPerMinute int    // per-token rate limit
 	AdminToken        string // for admin endpoints
 	Webhooks          *WebhookNotifier
+	Events            *EventBroker // streams repo events; Handler creates one if nil
+	Health            *Health      // probe checks and drain hooks; Handler checks the token store if nil
+
+	// SignedURLExpiry enables redirecting vector downloads to signed URLs on
+	// blob stores that support them, valid for this long. Zero proxies the bytes.
+	SignedURLExpiry time.Duration
+
+	// RequiredApprovals is the number of reviewer approvals of a proposal's
+	// source tip the merge endpoint requires; zero merges without review.
+	RequiredApprovals int
+
+	// GCInterval runs garbage collection on every repository this often;
+	// zero leaves GC to the admin endpoint. Blobs are only deleted by a
+	// scheduled run once they have been unreferenced for GCGracePeriod.
+	GCInterval    time.Duration
+	GCGracePeriod time.Duration
+
+	// Mirrors holds the mirrors of each repository, which receive every
+	// push in the background; nil disables mirroring. MirrorInterval also
+	// replicates every repository this often, catching mirrors up after
+	// failed or missed pushes.
+	Mirrors        MirrorStore
+	MirrorInterval time.Duration
+
+	// SnapshotInterval is the number of commits between the state snapshots
+	// computing a commit's state saves and starts from; zero disables them.
+	SnapshotInterval int
+
+	// RejectPII refuses pushed commits that carry classes classified as
+	// restricted personal data.
+	RejectPII bool
+
+	// PolicyURL is asked to allow or deny every branch update before it is
+	// applied, with a body and response shaped like the OPA data API; empty
+	// disables the check. PolicySecret signs the requests like webhooks.
+	PolicyURL    string
+	PolicySecret string
+
+	// WebhookAllowPrivate skips SSRF validation of repository webhooks (for tests only)
+	WebhookAllowPrivate bool
+
+	// WebhookQueue persists webhook deliveries so retries survive restarts;
+	// nil keeps the queue in memory.
+	WebhookQueue WebhookQueueStore
+
+	replication  *replicator        // set by Handler
+	webhooks     *webhookDispatcher // set by Handler
+	tipSamples   *tipSampleCache    // set by Handler
+	vectorBlooms *vectorBloomCache  // set by Handler
 }
 
+// DefaultSnapshotInterval is the number of commits between state snapshots
+// in DefaultServerConfig.
+const DefaultSnapshotInterval = 100
+
 // DefaultServerConfig returns reasonable defaults.
 func DefaultServerConfig() *ServerConfig {
 	return &ServerConfig{
 		MaxRequestBody:    64 * 1024 * 1024,  // 64MB
 		MaxBlobSize:       512 * 1024 * 1024, // 512MB
 		RequestsPerMinute: 300,
+		SnapshotInterval:  DefaultSnapshotInterval,
 	}
 }
 
@@ -84,11 +164,32 @@ func Handler(repos RepoOpener, tokens TokenStore, cfg *ServerConfig, logger *slo
 	if cfg == nil {
 		cfg = DefaultServerConfig()
 	}
+	// Handler state goes on a copy, leaving the caller's config untouched
+	withState := *cfg
+	cfg = &withState
+	if cfg.Events == nil {
+		cfg.Events = NewEventBroker()
+	}
+	cfg.tipSamples = newTipSampleCache()
+	cfg.vectorBlooms = newVectorBloomCache()
 	if logger == nil {
 		logger = slog.Default()
 	}
 
+	readRepos := readOpener{repos}
 	rl := newRateLimiter(cfg.RequestsPerMinute)
+	allowPrivate := cfg.WebhookAllowPrivate || (cfg.Webhooks != nil && cfg.Webhooks.config.AllowPrivate)
+	webhookQueue := newWebhookQueue(newWebhookClient(allowPrivate), cfg.WebhookQueue, logger)
+	webhookQueue.start()
+	cfg.webhooks = newWebhookDispatcher(cfg.Webhooks, cfg.WebhookAllowPrivate, webhookQueue, logger)
+	gc := newGCScheduler(repos, manager, repoLocker, cfg.GCInterval, cfg.GCGracePeriod, logger)
+	gc.onRun = func(ctx context.Context, repo string, meta metastore.MetaStore, status *GCStatus) {
+		cfg.vectorBlooms.invalidate(repo)
+		cfg.webhooks.notify(ctx, meta, &WebhookEvent{Event: remote.EventGC, Repo: repo, GC: status.Result, Error: status.Error})
+	}
+	gc.start()
+	cfg.replication = newReplicator(repos, manager, cfg.Mirrors, cfg.MirrorInterval, logger)
+	cfg.replication.start()
 	auth := authMiddleware(tokens, logger)
 
 	// repoWriteLockMW acquires a per-repo write lock for the duration of the request.
@@ -97,37 +198,52 @@ func Handler(repos RepoOpener, tokens TokenStore, cfg *ServerConfig, logger *slo
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			repo := r.PathValue("repo")
 			if repo != "" {
-				repoLocker.LockWrite(repo)
+				if err := repoLocker.LockWrite(r.Context(), repo); err != nil {
+					lockUnavailable(w, logger, repo, err)
+					return
+				}
 				defer repoLocker.UnlockWrite(repo)
 			}
 			next.ServeHTTP(w, r)
 		})
 	}
 
-	// Wrap a handler with auth + repo check + rate limit.
+	// Wrap a handler with auth + repo check + scope check + rate limit.
 	// applyMiddleware reverses the list, so the last item runs outermost (first).
-	// Execution order: auth -> requireRepo -> rl -> handler
-	withAuth := func(h http.HandlerFunc) http.Handler {
-		return applyMiddleware(h, auth, requireRepo, rl.middleware)
+	// Execution order: auth -> requireRepo -> requireScope(pull) -> rl -> handler
+	withAuthRead := func(h http.HandlerFunc) http.Handler {
+		return applyMiddleware(h, auth, requireRepo, requireScope(ScopePull), rl.middleware)
+	}
+	// Reads a push makes before writing, open to push-only tokens too.
+	// Execution order: auth -> requireRepo -> requireScope(push or pull) -> rl -> handler
+	withAuthPushRead := func(h http.HandlerFunc) http.Handler {
+		return applyMiddleware(h, auth, requireRepo, requireScope(ScopePush, ScopePull), rl.middleware)
+	}
+	// Execution order: auth -> requireRepo -> requireWrite -> requireScope -> repoWriteLock -> rl -> handler
+	withAuthScope := func(scope string, h http.HandlerFunc) http.Handler {
+		return applyMiddleware(h, auth, requireRepo, requireWrite, requireScope(scope), repoWriteLockMW, rl.middleware)
 	}
-	// Execution order: auth -> requireRepo -> requireWrite -> repoWriteLock -> rl -> handler
 	withAuthWrite := func(h http.HandlerFunc) http.Handler {
-		return applyMiddleware(h, auth, requireRepo, requireWrite, repoWriteLockMW, rl.middleware)
+		return withAuthScope(ScopePush, h)
+	}
+	// Branch writes are further limited to the token's branch patterns
+	withAuthBranch := func(scope string, h http.HandlerFunc) http.Handler {
+		return applyMiddleware(h, auth, requireRepo, requireWrite, requireScope(scope), requireBranch, repoWriteLockMW, rl.middleware)
 	}
 
 	mux := http.NewServeMux()
 
 	// Health endpoints (no auth)
-	mux.HandleFunc("GET /healthz", handleHealthz)
-	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, r *http.Request) {
-		if _, err := tokens.ListTokens(); err != nil {
-			w.WriteHeader(http.StatusServiceUnavailable)
-			w.Write([]byte("not ready: token store unavailable"))
-			return
-		}
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("ok"))
-	})
+	health := cfg.Health
+	if health == nil {
+		health = NewHealth()
+		health.AddCheck("token store", func(context.Context) error {
+			_, err := tokens.ListTokens()
+			return err
+		})
+	}
+	health.OnDrain(cfg.Events.Close)
+	health.register(mux)
 
 	// Admin endpoints
 	if cfg.AdminToken != "" {
@@ -136,33 +252,104 @@ func Handler(repos RepoOpener, tokens TokenStore, cfg *ServerConfig, logger *slo
 		adminMux.HandleFunc("DELETE /admin/tokens/{id}", makeAdminDeleteTokenHandler(tokens, logger))
 		adminMux.HandleFunc("GET /admin/tokens", makeAdminListTokensHandler(tokens, logger))
 		adminMux.HandleFunc("GET /admin/repos", makeAdminListReposHandler(manager, logger))
-		adminMux.HandleFunc("POST /admin/repos", makeAdminCreateRepoHandler(manager, logger))
-		adminMux.HandleFunc("DELETE /admin/repos/{name}", makeAdminDeleteRepoHandler(manager, logger))
-		adminMux.HandleFunc("POST /admin/repos/{repo}/gc", makeAdminGCHandler(repos, repoLocker, logger))
+		adminMux.HandleFunc("POST /admin/repos", makeAdminCreateRepoHandler(manager, cfg.webhooks, logger))
+		adminMux.HandleFunc("DELETE /admin/repos/{name}", makeAdminDeleteRepoHandler(manager, cfg.webhooks, logger))
+		adminMux.HandleFunc("GET /admin/webhooks/deliveries", makeAdminWebhookDeliveriesHandler(webhookQueue))
+		adminMux.HandleFunc("GET /admin/repos/{repo}/quota", makeAdminGetQuotaHandler(repos))
+		adminMux.HandleFunc("PUT /admin/repos/{repo}/quota", makeAdminPutQuotaHandler(repos, logger))
 		mux.Handle("/admin/", adminAuth(cfg.AdminToken, adminMux))
 	}
 
+	// Repository administration, open to the admin token and to tokens with
+	// the repo-admin scope on the repository. Token and repository lifecycle
+	// stay with the admin token, as do quotas, so repo admins cannot raise
+	// their own.
+	// Execution order: auth -> requireRepo -> requireWrite -> requireScope(repo-admin) -> rl -> handler
+	withRepoAdmin := func(h http.HandlerFunc) http.Handler {
+		return repoAdminAuth(cfg.AdminToken, h, auth, requireRepo, requireWrite, requireScope(ScopeRepoAdmin), rl.middleware)
+	}
+	repoAdmin := func(pattern string, h http.HandlerFunc) {
+		mux.Handle(pattern, withRepoAdmin(h))
+	}
+	repoAdmin("POST /admin/repos/{repo}/gc", makeAdminGCHandler(repos, gc, logger))
+	repoAdmin("GET /admin/repos/{repo}/gc/status", makeAdminGCStatusHandler(repos, gc))
+	registerMirrorAdmin(repoAdmin, repos, cfg.replication, logger)
+
 	// Negotiation
-	mux.Handle("POST /api/v1/repos/{repo}/negotiate/push", withAuth(makeRepoHandler(repos, cfg, handleNegotiatePush)))
-	mux.Handle("POST /api/v1/repos/{repo}/negotiate/pull", withAuth(makeRepoHandler(repos, cfg, handleNegotiatePull)))
-	mux.Handle("POST /api/v1/repos/{repo}/vectors/have", withAuth(makeRepoHandler(repos, cfg, handleVectorsHave)))
+	mux.Handle("POST /api/v1/repos/{repo}/negotiate/push", withAuthPushRead(makeRepoHandler(repos, cfg, handleNegotiatePush)))
+	mux.Handle("POST /api/v1/repos/{repo}/negotiate/pull", withAuthRead(makeRepoHandler(readRepos, cfg, handleNegotiatePull)))
+	mux.Handle("POST /api/v1/repos/{repo}/vectors/have", withAuthPushRead(makeRepoHandler(repos, cfg, handleVectorsHave)))
+	mux.Handle("GET /api/v1/repos/{repo}/vectors/bloom", withAuthPushRead(makeRepoHandler(repos, cfg, handleVectorsBloom)))
 
 	// Commits
-	mux.Handle("GET /api/v1/repos/{repo}/commits/{id}/bundle", withAuth(makeRepoHandler(repos, cfg, handleGetCommitBundle)))
+	mux.Handle("GET /api/v1/repos/{repo}/commits", withAuthRead(makeRepoHandler(readRepos, cfg, handleCommitLog)))
+	mux.Handle("GET /api/v1/repos/{repo}/compare", withAuthRead(makeRepoHandler(readRepos, cfg, handleCompare)))
+	mux.Handle("GET /api/v1/repos/{repo}/commits/{id}/bundle", withAuthRead(makeRepoHandler(readRepos, cfg, handleGetCommitBundle)))
+	mux.Handle("POST /api/v1/repos/{repo}/commits/{id}/bundle/filtered", withAuthRead(makeRepoHandler(readRepos, cfg, handlePostFilteredBundle)))
+	mux.Handle("GET /api/v1/repos/{repo}/commits/{id}/payloads", withAuthRead(makeRepoHandler(readRepos, cfg, handleGetCommitPayloads)))
+	mux.Handle("GET /api/v1/repos/{repo}/commits/{id}/state", withAuthRead(makeRepoHandler(readRepos, cfg, handleGetCommitState)))
 	mux.Handle("POST /api/v1/repos/{repo}/commits", withAuthWrite(makeRepoHandler(repos, cfg, handlePostCommitBundle)))
 
+	// Schemas
+	mux.Handle("GET /api/v1/repos/{repo}/schemas/{hash}", withAuthRead(makeRepoHandler(readRepos, cfg, handleGetSchema)))
+
+	// Stashes (scoped to the calling token)
+	mux.Handle("GET /api/v1/repos/{repo}/stashes", withAuthRead(makeRepoHandler(repos, cfg, handleListStashes)))
+	mux.Handle("GET /api/v1/repos/{repo}/stashes/{id}", withAuthRead(makeRepoHandler(repos, cfg, handleGetStash)))
+	mux.Handle("PUT /api/v1/repos/{repo}/stashes/{id}", withAuthWrite(makeRepoHandler(repos, cfg, handlePutStash)))
+	mux.Handle("DELETE /api/v1/repos/{repo}/stashes/{id}", withAuthWrite(makeRepoHandler(repos, cfg, handleDeleteStash)))
+
 	// Vectors
-	mux.Handle("GET /api/v1/repos/{repo}/vectors/{hash}", withAuth(makeRepoHandler(repos, cfg, handleGetVector)))
+	mux.Handle("GET /api/v1/repos/{repo}/vectors/{hash}", withAuthRead(makeRepoHandler(repos, cfg, handleGetVector)))
 	mux.Handle("POST /api/v1/repos/{repo}/vectors/{hash}", withAuthWrite(makeRepoHandler(repos, cfg, handlePostVector)))
 
 	// Branches
-	mux.Handle("GET /api/v1/repos/{repo}/branches", withAuth(makeRepoHandler(repos, cfg, handleListBranches)))
-	mux.Handle("GET /api/v1/repos/{repo}/branches/{name}", withAuth(makeRepoHandler(repos, cfg, handleGetBranch)))
-	mux.Handle("PUT /api/v1/repos/{repo}/branches/{name}", withAuthWrite(makeRepoHandler(repos, cfg, handleUpdateBranch)))
-	mux.Handle("DELETE /api/v1/repos/{repo}/branches/{name}", withAuthWrite(makeRepoHandler(repos, cfg, handleDeleteBranch)))
-
-	// Info
-	mux.Handle("GET /api/v1/repos/{repo}/info", withAuth(makeRepoHandler(repos, cfg, handleRepoInfo)))
+	mux.Handle("GET /api/v1/repos/{repo}/branches", withAuthRead(makeRepoHandler(readRepos, cfg, handleListBranches)))
+	mux.Handle("GET /api/v1/repos/{repo}/branches/{name}", withAuthRead(makeRepoHandler(readRepos, cfg, handleGetBranch)))
+	mux.Handle("PUT /api/v1/repos/{repo}/branches/{name}", withAuthBranch(ScopePush, makeRepoHandler(repos, cfg, handleUpdateBranch)))
+	mux.Handle("DELETE /api/v1/repos/{repo}/branches/{name}", withAuthBranch(ScopeBranchDelete, makeRepoHandler(repos, cfg, handleDeleteBranch)))
+
+	// Personal refs of the calling token, under refs/users/<token-id>/
+	mux.Handle("GET /api/v1/repos/{repo}/user-refs", withAuthRead(makeRepoHandler(repos, cfg, handleListUserRefs)))
+	mux.Handle("PUT /api/v1/repos/{repo}/user-refs/{name...}", withAuthWrite(makeRepoHandler(repos, cfg, handleUpdateUserRef)))
+	mux.Handle("DELETE /api/v1/repos/{repo}/user-refs/{name...}", withAuthWrite(makeRepoHandler(repos, cfg, handleDeleteUserRef)))
+
+	// Tags
+	mux.Handle("GET /api/v1/repos/{repo}/tags", withAuthRead(makeRepoHandler(readRepos, cfg, handleListTags)))
+	mux.Handle("GET /api/v1/repos/{repo}/tags/{name...}", withAuthRead(makeRepoHandler(readRepos, cfg, handleGetTag)))
+	mux.Handle("PUT /api/v1/repos/{repo}/tags/{name...}", withAuthWrite(makeRepoHandler(repos, cfg, handlePutTag)))
+	mux.Handle("DELETE /api/v1/repos/{repo}/tags/{name...}", withAuthWrite(makeRepoHandler(repos, cfg, handleDeleteTag)))
+
+	// Notes
+	mux.Handle("GET /api/v1/repos/{repo}/notes", withAuthRead(makeRepoHandler(readRepos, cfg, handleListNotes)))
+	mux.Handle("PUT /api/v1/repos/{repo}/notes/{commit}", withAuthWrite(makeRepoHandler(repos, cfg, handlePutNote)))
+	mux.Handle("DELETE /api/v1/repos/{repo}/notes/{commit}", withAuthWrite(makeRepoHandler(repos, cfg, handleDeleteNote)))
+
+	// Deployments, under refs/deployments/
+	mux.Handle("GET /api/v1/repos/{repo}/deployments", withAuthRead(makeRepoHandler(readRepos, cfg, handleListDeployments)))
+	mux.Handle("PUT /api/v1/repos/{repo}/deployments/{env}", withAuthWrite(makeRepoHandler(repos, cfg, handleRecordDeployment)))
+
+	// Proposals, merged once reviewers have approved them
+	mux.Handle("GET /api/v1/repos/{repo}/proposals", withAuthRead(makeRepoHandler(readRepos, cfg, handleListProposals)))
+	mux.Handle("GET /api/v1/repos/{repo}/proposals/{id}", withAuthRead(makeRepoHandler(readRepos, cfg, handleGetProposal)))
+	mux.Handle("POST /api/v1/repos/{repo}/proposals", withAuthWrite(makeRepoHandler(repos, cfg, handleCreateProposal)))
+	mux.Handle("POST /api/v1/repos/{repo}/proposals/{id}/approve", withAuthScope(ScopeReviewer, makeRepoHandler(repos, cfg, handleApproveProposal)))
+	mux.Handle("POST /api/v1/repos/{repo}/proposals/{id}/merge", withAuthWrite(makeRepoHandler(repos, cfg, handleMergeProposal)))
+	mux.Handle("GET /api/v1/repos/{repo}/audit", withRepoAdmin(makeRepoHandler(repos, cfg, handleGetAudit)))
+
+	// Info, which a push to a personal ref reads for the token's ID
+	mux.Handle("GET /api/v1/repos/{repo}/info", withAuthPushRead(makeRepoHandler(readRepos, cfg, handleRepoInfo)))
+
+	// Events
+	mux.Handle("GET /api/v1/repos/{repo}/events", withAuthRead(makeRepoHandler(repos, cfg, handleEvents)))
+
+	// Webhooks of the repository, managed by repo admins
+	mux.Handle("GET /api/v1/repos/{repo}/webhooks", withRepoAdmin(makeRepoHandler(repos, cfg, handleGetWebhooks)))
+	mux.Handle("PUT /api/v1/repos/{repo}/webhooks", withRepoAdmin(makeRepoHandler(repos, cfg, handlePutWebhooks)))
+
+	// Garbage collection by tokens granted the gc scope; takes the write lock itself
+	mux.Handle("POST /api/v1/repos/{repo}/gc", applyMiddleware(makeAdminGCHandler(repos, gc, logger),
+		auth, requireRepo, requireWrite, requireScope(ScopeGC), rl.middleware))
 
 	// Apply global middleware
 	handler := applyMiddleware(mux,
@@ -172,7 +359,11 @@ func Handler(repos RepoOpener, tokens TokenStore, cfg *ServerConfig, logger *slo
 	)
 
 	cleanup := func() {
+		gc.stop()
+		cfg.replication.stop()
+		webhookQueue.stop()
 		rl.Stop()
+		cfg.Events.Close()
 	}
 
 	return handler, cleanup
@@ -256,12 +447,102 @@ func handleNegotiatePush(w http.ResponseWriter, r *http.Request, meta metastore.
 		}
 	}
 
+	tipSample, err := sampleFirstParentChain(r.Context(), cfg.tipSamples, r.PathValue("repo"), meta, remoteTip, maxNegotiateItems)
+	if err != nil {
+		internalError(w, "sample branch history", err)
+		return
+	}
+
 	writeJSON(w, http.StatusOK, &remote.NegotiatePushResponse{
 		MissingCommits: missing,
 		RemoteTip:      remoteTip,
+		TipSample:      tipSample,
+		SchemaRefs:     true,
+		Deltas:         true,
 	})
 }
 
+// sampleFirstParentChain walks up to limit first-parent ancestors of tip and
+// returns a skipping sample of them for push negotiation. Samples are cached
+// per repository and tip, so the rounds of one push walk the chain once.
+func sampleFirstParentChain(ctx context.Context, cache *tipSampleCache, repo string, meta metastore.MetaStore, tip string, limit int) ([]string, error) {
+	if sample, ok := cache.get(repo, tip); ok {
+		return sample, nil
+	}
+
+	var chain []string
+	complete := true
+	for id := tip; id != "" && len(chain) < limit; {
+		chain = append(chain, id)
+		commit, err := meta.GetCommit(ctx, id)
+		if err != nil {
+			if errors.Is(err, metastore.ErrNotFound) {
+				// Shallow history: stop at the boundary, which a later
+				// push may fill in.
+				complete = false
+				break
+			}
+			return nil, err
+		}
+		id = commit.ParentID
+	}
+	sample := remote.SampleCommitIDs(chain)
+	if complete {
+		cache.put(repo, tip, sample)
+	}
+	return sample, nil
+}
+
+// tipSampleCacheSize bounds the number of cached tip samples.
+const tipSampleCacheSize = 1024
+
+// tipSampleCache holds the first-parent samples of recently negotiated branch
+// tips. Commits are immutable, so a tip's sample never changes once its
+// history is complete. The oldest entry is evicted first. A nil cache caches
+// nothing.
+type tipSampleCache struct {
+	mu      sync.Mutex
+	samples map[tipSampleKey][]string
+	order   []tipSampleKey
+}
+
+type tipSampleKey struct {
+	repo string
+	tip  string
+}
+
+func newTipSampleCache() *tipSampleCache {
+	return &tipSampleCache{samples: make(map[tipSampleKey][]string)}
+}
+
+func (c *tipSampleCache) get(repo, tip string) ([]string, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sample, ok := c.samples[tipSampleKey{repo, tip}]
+	return sample, ok
+}
+
+func (c *tipSampleCache) put(repo, tip string, sample []string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := tipSampleKey{repo, tip}
+	if _, ok := c.samples[key]; ok {
+		return
+	}
+	if len(c.order) >= tipSampleCacheSize {
+		delete(c.samples, c.order[0])
+		c.order = c.order[1:]
+	}
+	c.samples[key] = sample
+	c.order = append(c.order, key)
+}
+
 func handleNegotiatePull(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, _ blobstore.BlobStore, cfg *ServerConfig) {
 	const maxNegotiateDepth = 10000
 
@@ -290,6 +571,22 @@ func handleNegotiatePull(w http.ResponseWriter, r *http.Request, meta metastore.
 		return
 	}
 
+	// A class-filtered fetch must name classes the remote knows
+	if len(req.Classes) > 0 {
+		unknown, err := unknownClasses(r.Context(), meta, branch.CommitID, req.Classes)
+		if err != nil {
+			internalError(w, "check classes", err)
+			return
+		}
+		if len(unknown) > 0 {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]string{
+				"error":   "validation_failed",
+				"message": fmt.Sprintf("unknown class(es) on branch %s: %s", req.Branch, strings.Join(unknown, ", ")),
+			})
+			return
+		}
+	}
+
 	// Walk commits from tip backwards to find what client is missing
 	localAncestors := make(map[string]bool)
 	if req.LocalTip != "" {
@@ -302,14 +599,40 @@ func handleNegotiatePull(w http.ResponseWriter, r *http.Request, meta metastore.
 		}
 	}
 
-	// Walk from remote tip
+	// Walk from remote tip, and from the parents of shallow boundaries whose
+	// history the client asks for
 	type queueItem struct {
 		id    string
 		depth int
+		child string
 	}
 	var missing []string
 	queue := []queueItem{{id: branch.CommitID, depth: 0}}
+	for _, id := range req.Shallow {
+		commit, err := meta.GetCommit(r.Context(), id)
+		if err != nil {
+			continue
+		}
+		for _, parent := range []string{commit.ParentID, commit.MergeParentID} {
+			if parent == "" {
+				continue
+			}
+			anc, err := meta.GetAncestors(r.Context(), parent)
+			if err != nil {
+				internalError(w, "get ancestors", err)
+				return
+			}
+			for k := range anc {
+				delete(localAncestors, k)
+			}
+			queue = append(queue, queueItem{id: parent, child: id})
+		}
+	}
+	for _, id := range req.Shallow {
+		localAncestors[id] = true
+	}
 	visited := make(map[string]bool)
+	shallow := make(map[string]bool)
 
 	for len(queue) > 0 {
 		item := queue[0]
@@ -319,6 +642,8 @@ func handleNegotiatePull(w http.ResponseWriter, r *http.Request, meta metastore.
 			continue
 		}
 		if req.Depth > 0 && item.depth >= req.Depth {
+			// The client's history will end at the child
+			shallow[item.child] = true
 			continue
 		}
 		visited[item.id] = true
@@ -329,10 +654,10 @@ func handleNegotiatePull(w http.ResponseWriter, r *http.Request, meta metastore.
 			continue
 		}
 		if commit.ParentID != "" {
-			queue = append(queue, queueItem{id: commit.ParentID, depth: item.depth + 1})
+			queue = append(queue, queueItem{id: commit.ParentID, depth: item.depth + 1, child: item.id})
 		}
 		if commit.MergeParentID != "" {
-			queue = append(queue, queueItem{id: commit.MergeParentID, depth: item.depth + 1})
+			queue = append(queue, queueItem{id: commit.MergeParentID, depth: item.depth + 1, child: item.id})
 		}
 	}
 
@@ -341,10 +666,43 @@ func handleNegotiatePull(w http.ResponseWriter, r *http.Request, meta metastore.
 		missing[i], missing[j] = missing[j], missing[i]
 	}
 
-	writeJSON(w, http.StatusOK, &remote.NegotiatePullResponse{
+	resp := &remote.NegotiatePullResponse{
 		MissingCommits: missing,
 		RemoteTip:      branch.CommitID,
-	})
+	}
+	for _, id := range missing {
+		if shallow[id] {
+			resp.Shallow = append(resp.Shallow, id)
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// unknownClasses returns the classes missing from the schema of commitID.
+// Commits recorded without a schema accept any class.
+func unknownClasses(ctx context.Context, meta metastore.MetaStore, commitID string, classes []string) ([]string, error) {
+	bundle, err := meta.GetCommitBundle(ctx, commitID)
+	if err != nil {
+		return nil, err
+	}
+	if bundle.Schema == nil || len(bundle.Schema.SchemaJSON) == 0 {
+		return nil, nil
+	}
+	var schema models.WeaviateSchema
+	if err := json.Unmarshal(bundle.Schema.SchemaJSON, &schema); err != nil {
+		return nil, fmt.Errorf("parse schema of %s: %w", commitID, err)
+	}
+	known := make(map[string]bool, len(schema.Classes))
+	for _, class := range schema.Classes {
+		known[class.Class] = true
+	}
+	var unknown []string
+	for _, class := range classes {
+		if !known[class] {
+			unknown = append(unknown, class)
+		}
+	}
+	return unknown, nil
 }
 
 func handleVectorsHave(w http.ResponseWriter, r *http.Request, _ metastore.MetaStore, blobs blobstore.BlobStore, cfg *ServerConfig) {
@@ -379,9 +737,120 @@ func handleVectorsHave(w http.ResponseWriter, r *http.Request, _ metastore.MetaS
 	})
 }
 
+// vectorBloomFPRate is the false-positive rate of the filter served by vectors/bloom.
+const vectorBloomFPRate = 0.01
+
+// vectorBloomTTL bounds how long a cached filter is served before it is
+// rebuilt, so blobs uploaded through other server replicas show up in it.
+const vectorBloomTTL = time.Minute
+
+func handleVectorsBloom(w http.ResponseWriter, r *http.Request, _ metastore.MetaStore, blobs blobstore.BlobStore, cfg *ServerConfig) {
+	repo := r.PathValue("repo")
+	filter, gen := cfg.vectorBlooms.get(repo)
+	if filter == nil {
+		hashes, err := blobs.ListHashes(r.Context())
+		if err != nil {
+			internalError(w, "list blob hashes", err)
+			return
+		}
+		filter = cfg.vectorBlooms.put(repo, gen, hashes)
+	}
+
+	writeJSON(w, http.StatusOK, filter)
+}
+
+// vectorBloomCache holds each repository's vectors/bloom filter, so a push
+// does not list the whole blob store. Uploads add to a cached filter while
+// it has room; GC drops it, as do uploads once it is full. A nil cache
+// caches nothing.
+type vectorBloomCache struct {
+	mu      sync.Mutex
+	filters map[string]*vectorBloom
+	gens    map[string]uint64 // bumped by every change, to discard stale rebuilds
+}
+
+type vectorBloom struct {
+	filter   *remote.BloomFilter
+	n        int // hashes added
+	capacity int // hashes the filter was sized for
+	built    time.Time
+}
+
+func newVectorBloomCache() *vectorBloomCache {
+	return &vectorBloomCache{filters: make(map[string]*vectorBloom), gens: make(map[string]uint64)}
+}
+
+// get returns a copy of the repository's cached filter, or nil with the
+// generation to pass to put after rebuilding it.
+func (c *vectorBloomCache) get(repo string) (*remote.BloomFilter, uint64) {
+	if c == nil {
+		return nil, 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b := c.filters[repo]
+	if b == nil || time.Since(b.built) > vectorBloomTTL {
+		return nil, c.gens[repo]
+	}
+	return &remote.BloomFilter{M: b.filter.M, K: b.filter.K, Bits: slices.Clone(b.filter.Bits)}, 0
+}
+
+// put builds a filter of hashes, with room for uploads to add to it, and
+// caches it unless the repository changed since get returned gen. It
+// returns a copy of the filter.
+func (c *vectorBloomCache) put(repo string, gen uint64, hashes []string) *remote.BloomFilter {
+	capacity := len(hashes) + len(hashes)/4 + 1024
+	filter := remote.NewBloomFilter(capacity, vectorBloomFPRate)
+	for _, h := range hashes {
+		filter.Add(h)
+	}
+	if c == nil {
+		return filter
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.gens[repo] == gen {
+		c.filters[repo] = &vectorBloom{filter: filter, n: len(hashes), capacity: capacity, built: time.Now()}
+		filter = &remote.BloomFilter{M: filter.M, K: filter.K, Bits: slices.Clone(filter.Bits)}
+	}
+	return filter
+}
+
+// add records an uploaded blob in the repository's cached filter, dropping
+// the filter once it holds more hashes than it was sized for.
+func (c *vectorBloomCache) add(repo, hash string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gens[repo]++
+	b := c.filters[repo]
+	if b == nil {
+		return
+	}
+	if b.n >= b.capacity {
+		delete(c.filters, repo)
+		return
+	}
+	b.filter.Add(hash)
+	b.n++
+}
+
+// invalidate drops the repository's cached filter, as after GC deleted blobs.
+func (c *vectorBloomCache) invalidate(repo string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gens[repo]++
+	delete(c.filters, repo)
+}
+
 // --- Commit Handlers ---
 
-func handleGetCommitBundle(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, _ blobstore.BlobStore, _ *ServerConfig) {
+func handleGetCommitBundle(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, blobs blobstore.BlobStore, _ *ServerConfig) {
 	commitID := r.PathValue("id")
 	if commitID == "" {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request", "message": "commit ID required"})
@@ -398,6 +867,44 @@ func handleGetCommitBundle(w http.ResponseWriter, r *http.Request, meta metastor
 		return
 	}
 
+	filter, err := remote.ParseFilter(r.URL.Query().Get("filter"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request", "message": err.Error()})
+		return
+	}
+
+	// Skip the schema JSON when the client already holds a schema with this hash
+	if have := r.URL.Query().Get("have_schema"); have != "" && bundle.Schema != nil && bundle.Schema.SchemaHash == have {
+		bundle.Schema.SchemaJSON = nil
+	}
+
+	if len(filter.Classes) > 0 {
+		kept := bundle.Operations[:0]
+		for _, op := range bundle.Operations {
+			if filter.KeepsClass(op.ClassName) {
+				kept = append(kept, op)
+			}
+		}
+		bundle.Operations = kept
+	}
+
+	if filter.NoPayloads {
+		// Partial clones fetch payloads from the payloads endpoint when needed
+		for _, op := range bundle.Operations {
+			op.OmitPayloads()
+		}
+	} else if r.URL.Query().Get("payload_refs") != "1" {
+		// Clients that don't fetch payload blobs themselves get payloads inline
+		if err := resolveBundlePayloads(r.Context(), blobs, bundle); err != nil {
+			internalError(w, "resolve payloads", err)
+			return
+		}
+	}
+	if err := encodeBundleDeltas(bundle, r.URL.Query().Get("delta") == "1"); err != nil {
+		internalError(w, "encode deltas", err)
+		return
+	}
+
 	// Respond with gzip if client accepts it
 	if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
 		w.Header().Set("Content-Encoding", "gzip")
@@ -416,6 +923,127 @@ func handleGetCommitBundle(w http.ResponseWriter, r *http.Request, meta metastor
 	writeJSON(w, http.StatusOK, bundle)
 }
 
+// handlePostFilteredBundle returns the operations of a commit selected by
+// class and payload filters, with a manifest of all its operations. Offloaded
+// payloads are sent as references, as partial-fetch clients fetch blobs.
+func handlePostFilteredBundle(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, _ blobstore.BlobStore, cfg *ServerConfig) {
+	commitID := r.PathValue("id")
+	if commitID == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request", "message": "commit ID required"})
+		return
+	}
+
+	var req remote.FilteredBundleRequest
+	if err := readJSON(w, r, cfg.MaxRequestBody, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request", "message": err.Error()})
+		return
+	}
+
+	bundle, err := meta.GetCommitBundle(r.Context(), commitID)
+	if err != nil {
+		if errors.Is(err, metastore.ErrNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not_found", "message": "commit not found"})
+			return
+		}
+		internalError(w, "get commit bundle", err)
+		return
+	}
+
+	if req.HaveSchema != "" && bundle.Schema != nil && bundle.Schema.SchemaHash == req.HaveSchema {
+		bundle.Schema.SchemaJSON = nil
+	}
+
+	filtered, err := remote.FilterBundle(bundle, req.Filter())
+	if err != nil {
+		internalError(w, "filter commit bundle", err)
+		return
+	}
+	if err := encodeBundleDeltas(filtered.Bundle, req.Deltas); err != nil {
+		internalError(w, "encode deltas", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, filtered)
+}
+
+// encodeBundleDeltas delta-encodes the bundle's updates for clients that
+// accept deltas and expands stored deltas for clients that don't.
+func encodeBundleDeltas(bundle *remote.CommitBundle, deltas bool) error {
+	for _, op := range bundle.Operations {
+		if !deltas {
+			if err := op.DecodeDelta(); err != nil {
+				return err
+			}
+			continue
+		}
+		op.EncodeDelta()
+	}
+	return nil
+}
+
+// handleGetCommitPayloads returns the payloads of every operation of a commit,
+// for clients that fetched its bundle without them.
+func handleGetCommitPayloads(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, blobs blobstore.BlobStore, _ *ServerConfig) {
+	commitID := r.PathValue("id")
+	if commitID == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request", "message": "commit ID required"})
+		return
+	}
+
+	bundle, err := meta.GetCommitBundle(r.Context(), commitID)
+	if err != nil {
+		if errors.Is(err, metastore.ErrNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not_found", "message": "commit not found"})
+			return
+		}
+		internalError(w, "get commit bundle", err)
+		return
+	}
+	if err := resolveBundlePayloads(r.Context(), blobs, bundle); err != nil {
+		internalError(w, "resolve payloads", err)
+		return
+	}
+
+	payloads := make([]*remote.OperationPayload, len(bundle.Operations))
+	for i, op := range bundle.Operations {
+		payloads[i] = &remote.OperationPayload{Seq: i, ObjectData: op.ObjectData, PreviousData: op.PreviousData}
+	}
+	writeJSON(w, http.StatusOK, payloads)
+}
+
+// resolveBundlePayloads fills in operation payloads that were offloaded to blob
+// storage and expands delta-encoded updates.
+func resolveBundlePayloads(ctx context.Context, blobs blobstore.BlobStore, bundle *remote.CommitBundle) error {
+	load := func(hash string) ([]byte, error) {
+		rc, _, err := blobs.Get(ctx, hash)
+		if err != nil {
+			return nil, fmt.Errorf("get payload %s: %w", hash, err)
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+
+	for _, op := range bundle.Operations {
+		if op.ObjectDataHash != "" && len(op.ObjectData) == 0 {
+			data, err := load(op.ObjectDataHash)
+			if err != nil {
+				return err
+			}
+			op.ObjectData = data
+		}
+		if op.PreviousDataHash != "" && len(op.PreviousData) == 0 {
+			data, err := load(op.PreviousDataHash)
+			if err != nil {
+				return err
+			}
+			op.PreviousData = data
+		}
+		if err := op.DecodeDelta(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func handlePostCommitBundle(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, _ blobstore.BlobStore, cfg *ServerConfig) {
 	var bundle remote.CommitBundle
 
@@ -445,11 +1073,24 @@ func handlePostCommitBundle(w http.ResponseWriter, r *http.Request, meta metasto
 		return
 	}
 
-	var expectedID string
-	if bundle.Commit.MergeParentID != "" {
-		expectedID = models.GenerateMergeCommitID(bundle.Commit.Message, bundle.Commit.Timestamp, bundle.Commit.ParentID, bundle.Commit.MergeParentID, bundle.Operations)
-	} else {
-		expectedID = models.GenerateCommitID(bundle.Commit.Message, bundle.Commit.Timestamp, bundle.Commit.ParentID, bundle.Operations)
+	for i, op := range bundle.Operations {
+		if op.PayloadOmitted {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]string{
+				"error":   "validation_failed",
+				"message": fmt.Sprintf("operation %d is missing its payloads", i),
+			})
+			return
+		}
+	}
+
+	// Both hash versions are accepted so clients can migrate independently
+	expectedID, err := bundle.Commit.ComputeID(bundle.Operations)
+	if err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{
+			"error":   "validation_failed",
+			"message": err.Error(),
+		})
+		return
 	}
 	if bundle.Commit.ID != expectedID {
 		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{
@@ -459,6 +1100,14 @@ func handlePostCommitBundle(w http.ResponseWriter, r *http.Request, meta metasto
 		return
 	}
 
+	if restricted := bundle.Commit.RestrictedClasses(); cfg.RejectPII && len(restricted) > 0 {
+		writeJSON(w, http.StatusForbidden, map[string]string{
+			"error":   "pii_restricted",
+			"message": fmt.Sprintf("commit %s carries restricted personal data in %s", bundle.Commit.ID, strings.Join(restricted, ", ")),
+		})
+		return
+	}
+
 	// Validate parent exists (unless initial commit)
 	if bundle.Commit.ParentID != "" {
 		has, err := meta.HasCommit(r.Context(), bundle.Commit.ParentID)
@@ -491,7 +1140,41 @@ func handlePostCommitBundle(w http.ResponseWriter, r *http.Request, meta metasto
 		}
 	}
 
+	quota, err := meta.GetQuota(r.Context())
+	if err != nil {
+		internalError(w, "get quota", err)
+		return
+	}
+	if quota.MaxCommits > 0 {
+		has, err := meta.HasCommit(r.Context(), bundle.Commit.ID)
+		if err != nil {
+			internalError(w, "has commit", err)
+			return
+		}
+		count, err := meta.GetCommitCount(r.Context())
+		if err != nil {
+			internalError(w, "get commit count", err)
+			return
+		}
+		if !has && count >= quota.MaxCommits {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]string{
+				"error":   "quota_exceeded",
+				"message": fmt.Sprintf("repository commit quota of %d commits reached", quota.MaxCommits),
+			})
+			return
+		}
+	}
+
+	// Updates are stored as deltas where that is smaller
+	for _, op := range bundle.Operations {
+		op.EncodeDelta()
+	}
+
 	if err := meta.InsertCommitBundle(r.Context(), &bundle); err != nil {
+		if errors.Is(err, metastore.ErrUnknownSchema) {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": "unknown_schema", "message": err.Error()})
+			return
+		}
 		internalError(w, "insert commit bundle", err)
 		return
 	}
@@ -499,33 +1182,179 @@ func handlePostCommitBundle(w http.ResponseWriter, r *http.Request, meta metasto
 	w.WriteHeader(http.StatusCreated)
 }
 
-// --- Vector Handlers ---
+// --- Schema Handlers ---
 
-func handleGetVector(w http.ResponseWriter, r *http.Request, _ metastore.MetaStore, blobs blobstore.BlobStore, _ *ServerConfig) {
+func handleGetSchema(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, _ blobstore.BlobStore, _ *ServerConfig) {
 	hash := r.PathValue("hash")
 	if hash == "" {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request", "message": "vector hash required"})
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request", "message": "schema hash required"})
 		return
 	}
 
-	reader, dims, err := blobs.Get(r.Context(), hash)
+	snapshot, err := meta.GetSchema(r.Context(), hash)
 	if err != nil {
-		if errors.Is(err, blobstore.ErrBlobNotFound) {
-			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not_found", "message": "vector not found"})
+		if errors.Is(err, metastore.ErrNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not_found", "message": "schema not found"})
 			return
 		}
-		internalError(w, "get vector", err)
+		internalError(w, "get schema", err)
 		return
 	}
-	defer reader.Close()
 
-	w.Header().Set("Content-Type", "application/octet-stream")
-	w.Header().Set("X-WVC-Dimensions", strconv.Itoa(dims))
-	w.WriteHeader(http.StatusOK)
-	io.Copy(w, reader)
+	writeJSON(w, http.StatusOK, snapshot)
 }
 
-func handlePostVector(w http.ResponseWriter, r *http.Request, _ metastore.MetaStore, blobs blobstore.BlobStore, cfg *ServerConfig) {
+// --- Stash Handlers ---
+
+// stashOwner returns the ID of the token making the request; stashes are
+// stored and listed per token.
+func stashOwner(r *http.Request) string {
+	owner, _ := r.Context().Value(contextKeyTokenID).(string)
+	return owner
+}
+
+func handleListStashes(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, _ blobstore.BlobStore, _ *ServerConfig) {
+	stashes, err := meta.ListStashes(r.Context(), stashOwner(r))
+	if err != nil {
+		internalError(w, "list stashes", err)
+		return
+	}
+	if stashes == nil {
+		stashes = []*remote.RemoteStash{}
+	}
+	writeJSON(w, http.StatusOK, stashes)
+}
+
+func handleGetStash(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, _ blobstore.BlobStore, _ *ServerConfig) {
+	stash, err := meta.GetStash(r.Context(), stashOwner(r), r.PathValue("id"))
+	if err != nil {
+		if errors.Is(err, metastore.ErrNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not_found", "message": "stash not found"})
+			return
+		}
+		internalError(w, "get stash", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, stash)
+}
+
+func handlePutStash(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, blobs blobstore.BlobStore, cfg *ServerConfig) {
+	r.Body = http.MaxBytesReader(w, r.Body, cfg.MaxRequestBody)
+
+	var stash remote.RemoteStash
+	if err := json.NewDecoder(r.Body).Decode(&stash); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request", "message": fmt.Sprintf("invalid JSON: %v", err)})
+		return
+	}
+
+	if stash.ID != r.PathValue("id") || stash.ID != stash.ContentID() {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{
+			"error":   "stash_id_mismatch",
+			"message": fmt.Sprintf("stash ID does not match content: expected %s", stash.ContentID()),
+		})
+		return
+	}
+
+	// The stash is only usable elsewhere if its base commit was pushed
+	has, err := meta.HasCommit(r.Context(), stash.CommitID)
+	if err != nil {
+		internalError(w, "has commit", err)
+		return
+	}
+	if !has {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{
+			"error":   "validation_failed",
+			"message": fmt.Sprintf("stash base commit %s does not exist; push it first", stash.CommitID),
+		})
+		return
+	}
+
+	for _, c := range stash.Changes {
+		for _, hash := range []string{c.VectorHash, c.PreviousVectorHash} {
+			if hash == "" {
+				continue
+			}
+			ok, err := blobs.Has(r.Context(), hash)
+			if err != nil {
+				internalError(w, "has vector", err)
+				return
+			}
+			if !ok {
+				writeJSON(w, http.StatusUnprocessableEntity, map[string]string{
+					"error":   "validation_failed",
+					"message": fmt.Sprintf("vector %s referenced by stash does not exist", hash),
+				})
+				return
+			}
+		}
+	}
+
+	if err := meta.PutStash(r.Context(), stashOwner(r), &stash); err != nil {
+		internalError(w, "put stash", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func handleDeleteStash(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, _ blobstore.BlobStore, _ *ServerConfig) {
+	if err := meta.DeleteStash(r.Context(), stashOwner(r), r.PathValue("id")); err != nil {
+		if errors.Is(err, metastore.ErrNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not_found", "message": "stash not found"})
+			return
+		}
+		internalError(w, "delete stash", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// --- Vector Handlers ---
+
+func handleGetVector(w http.ResponseWriter, r *http.Request, _ metastore.MetaStore, blobs blobstore.BlobStore, cfg *ServerConfig) {
+	hash := r.PathValue("hash")
+	if hash == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request", "message": "vector hash required"})
+		return
+	}
+
+	// Clients that can follow a redirect download straight from the backend
+	signer, ok := blobs.(blobstore.URLSigner)
+	if ok && cfg.SignedURLExpiry > 0 && r.Header.Get("X-WVC-Accept-Redirect") != "" {
+		signedURL, dims, err := signer.SignedURL(r.Context(), hash, cfg.SignedURLExpiry)
+		switch {
+		case err == nil:
+			w.Header().Set("Location", signedURL)
+			w.Header().Set("X-WVC-Dimensions", strconv.Itoa(dims))
+			w.Header().Set("Cache-Control", "no-store")
+			w.WriteHeader(http.StatusTemporaryRedirect)
+			return
+		case errors.Is(err, blobstore.ErrBlobNotFound):
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not_found", "message": "vector not found"})
+			return
+		case !errors.Is(err, blobstore.ErrSignedURLUnsupported):
+			internalError(w, "sign vector URL", err)
+			return
+		}
+	}
+
+	reader, dims, err := blobs.Get(r.Context(), hash)
+	if err != nil {
+		if errors.Is(err, blobstore.ErrBlobNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not_found", "message": "vector not found"})
+			return
+		}
+		internalError(w, "get vector", err)
+		return
+	}
+	defer reader.Close()
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("X-WVC-Dimensions", strconv.Itoa(dims))
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, reader)
+}
+
+func handlePostVector(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, blobs blobstore.BlobStore, cfg *ServerConfig) {
 	hash := r.PathValue("hash")
 	if hash == "" {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request", "message": "vector hash required"})
@@ -542,13 +1371,44 @@ func handlePostVector(w http.ResponseWriter, r *http.Request, _ metastore.MetaSt
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request", "message": "invalid X-WVC-Dimensions value"})
 		return
 	}
-	if dims <= 0 {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request", "message": "dimensions must be positive"})
+	// Zero dimensions marks an offloaded object payload rather than a vector
+	if dims < 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request", "message": "dimensions must not be negative"})
 		return
 	}
 
-	limited := io.LimitReader(r.Body, cfg.MaxBlobSize)
-	if err := blobs.Put(r.Context(), hash, limited, dims); err != nil {
+	existed, err := blobs.Has(r.Context(), hash)
+	if err != nil {
+		internalError(w, "check vector", err)
+		return
+	}
+
+	// Only new blobs count against the quota. An upload without a length
+	// is checked against the bytes already stored.
+	if !existed {
+		quota, err := meta.GetQuota(r.Context())
+		if err != nil {
+			internalError(w, "get quota", err)
+			return
+		}
+		if quota.MaxBlobBytes > 0 {
+			used, err := blobBytes(r.Context(), meta, blobs)
+			if err != nil {
+				internalError(w, "get blob bytes", err)
+				return
+			}
+			if used+max(r.ContentLength, 0) > quota.MaxBlobBytes || used >= quota.MaxBlobBytes {
+				writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{
+					"error":   "quota_exceeded",
+					"message": fmt.Sprintf("repository blob quota of %d bytes exceeded (%d bytes used)", quota.MaxBlobBytes, used),
+				})
+				return
+			}
+		}
+	}
+
+	body := &countingReader{r: io.LimitReader(r.Body, cfg.MaxBlobSize)}
+	if err := blobs.Put(r.Context(), hash, body, dims); err != nil {
 		if errors.Is(err, blobstore.ErrHashMismatch) {
 			writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": "hash_mismatch", "message": err.Error()})
 			return
@@ -556,10 +1416,71 @@ func handlePostVector(w http.ResponseWriter, r *http.Request, _ metastore.MetaSt
 		internalError(w, "put vector", err)
 		return
 	}
+	if !existed {
+		countNewBlob(r.Context(), meta, blobs, body.n)
+		cfg.vectorBlooms.add(r.PathValue("repo"), hash)
+	}
 
 	w.WriteHeader(http.StatusCreated)
 }
 
+// countNewBlob adds a stored blob of size bytes to the repository's blob
+// count and blob bytes, scanning the blob store once for either if it is not
+// known yet. Concurrent uploads of the same blob may both count it; GC
+// resets both to the true values.
+func countNewBlob(ctx context.Context, meta metastore.MetaStore, blobs blobstore.BlobStore, size int64) {
+	_, ok, err := meta.GetBlobCount(ctx)
+	if err == nil && !ok {
+		var n int
+		if n, err = blobs.TotalCount(ctx); err == nil {
+			err = meta.SetBlobCount(ctx, n)
+		}
+	} else if err == nil {
+		err = meta.AddBlobCount(ctx, 1)
+	}
+	if err != nil {
+		slog.Warn("update blob count", "error", err)
+	}
+
+	_, ok, err = meta.GetBlobBytes(ctx)
+	if err == nil && !ok {
+		var n int64
+		if n, err = blobs.TotalSize(ctx); err == nil {
+			err = meta.SetBlobBytes(ctx, n)
+		}
+	} else if err == nil {
+		err = meta.AddBlobBytes(ctx, size)
+	}
+	if err != nil {
+		slog.Warn("update blob bytes", "error", err)
+	}
+}
+
+// blobBytes returns the bytes stored in the blob store, scanning it and
+// storing the result if the counter is not known yet.
+func blobBytes(ctx context.Context, meta metastore.MetaStore, blobs blobstore.BlobStore) (int64, error) {
+	n, ok, err := meta.GetBlobBytes(ctx)
+	if err != nil || ok {
+		return n, err
+	}
+	if n, err = blobs.TotalSize(ctx); err != nil {
+		return 0, err
+	}
+	return n, meta.SetBlobBytes(ctx, n)
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
 // --- Branch Handlers ---
 
 func handleListBranches(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, _ blobstore.BlobStore, _ *ServerConfig) {
@@ -569,7 +1490,32 @@ func handleListBranches(w http.ResponseWriter, r *http.Request, meta metastore.M
 		return
 	}
 
-	writeJSON(w, http.StatusOK, branches)
+	writeJSON(w, http.StatusOK, publicBranches(branches))
+}
+
+// isReservedRef reports whether name lies in the refs/ namespace, which is
+// written only through dedicated endpoints such as user-refs.
+func isReservedRef(name string) bool {
+	return strings.HasPrefix(name, "refs/")
+}
+
+// publicBranches filters out refs under the reserved refs/ namespace.
+func publicBranches(branches []*models.Branch) []*models.Branch {
+	public := make([]*models.Branch, 0, len(branches))
+	for _, b := range branches {
+		if !isReservedRef(b.Name) {
+			public = append(public, b)
+		}
+	}
+	return public
+}
+
+// writeReservedRef rejects a branch write that targets the reserved namespace.
+func writeReservedRef(w http.ResponseWriter, name string) {
+	writeJSON(w, http.StatusForbidden, map[string]string{
+		"error":   "reserved_ref",
+		"message": fmt.Sprintf("'%s' is in the reserved refs/ namespace", name),
+	})
 }
 
 func handleGetBranch(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, _ blobstore.BlobStore, _ *ServerConfig) {
@@ -589,16 +1535,39 @@ func handleGetBranch(w http.ResponseWriter, r *http.Request, meta metastore.Meta
 
 func handleUpdateBranch(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, _ blobstore.BlobStore, cfg *ServerConfig) {
 	name := r.PathValue("name")
+	if isReservedRef(name) {
+		writeReservedRef(w, name)
+		return
+	}
+
+	req, ok := applyBranchUpdate(w, r, meta, cfg, name, name)
+	if !ok {
+		return
+	}
+
+	publish(r, meta, cfg, &WebhookEvent{Event: remote.EventPush, Repo: r.PathValue("repo"), Branch: name, CommitID: req.CommitID, Before: req.Expected})
+	w.WriteHeader(http.StatusOK)
+}
 
+// applyBranchUpdate decodes a BranchUpdateRequest and CAS-updates the ref
+// stored under name, writing an error response on failure. display is the
+// name shown in messages. On success it returns the applied request and true,
+// leaving the success response to the caller.
+func applyBranchUpdate(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, cfg *ServerConfig, name, display string) (*remote.BranchUpdateRequest, bool) {
 	var req remote.BranchUpdateRequest
 	if err := readJSON(w, r, cfg.MaxRequestBody, &req); err != nil {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request", "message": err.Error()})
-		return
+		return nil, false
 	}
 
 	if req.CommitID == "" {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request", "message": "commit_id is required"})
-		return
+		return nil, false
+	}
+
+	// Personal refs are not subject to the push policy
+	if !isReservedRef(name) && !checkPushPolicy(w, r, meta, cfg, name, &req) {
+		return nil, false
 	}
 
 	err := meta.UpdateBranchCAS(r.Context(), name, req.CommitID, req.Expected)
@@ -611,34 +1580,359 @@ func handleUpdateBranch(w http.ResponseWriter, r *http.Request, meta metastore.M
 			}
 			writeJSON(w, http.StatusConflict, map[string]interface{}{
 				"error":   "push_rejected",
-				"message": fmt.Sprintf("remote branch '%s' has diverged — expected tip %s, got %s", name, req.Expected, currentTip),
+				"message": fmt.Sprintf("remote branch '%s' has diverged — expected tip %s, got %s", display, req.Expected, currentTip),
 				"detail":  map[string]string{"remote_tip": currentTip},
 			})
-			return
+			return nil, false
 		}
 		internalError(w, "update branch", err)
+		return nil, false
+	}
+
+	return &req, true
+}
+
+func handleDeleteBranch(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, _ blobstore.BlobStore, cfg *ServerConfig) {
+	name := r.PathValue("name")
+	if isReservedRef(name) {
+		writeReservedRef(w, name)
 		return
 	}
 
-	// Fire webhook on successful branch update (push)
-	if cfg.Webhooks != nil {
-		repoName := r.PathValue("repo")
-		cfg.Webhooks.NotifyPush(repoName, name, req.CommitID)
+	err := meta.DeleteBranch(r.Context(), name)
+	if err != nil {
+		if errors.Is(err, metastore.ErrNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not_found", "message": "branch not found"})
+			return
+		}
+		internalError(w, "delete branch", err)
+		return
+	}
+
+	publishEvent(r, meta, cfg, remote.EventBranchDelete, name, "")
+	w.WriteHeader(http.StatusOK)
+}
+
+// --- User Ref Handlers ---
+
+// userRefPrefix returns the namespace of the calling token's personal refs.
+func userRefPrefix(r *http.Request) string {
+	tokenID, _ := r.Context().Value(contextKeyTokenID).(string)
+	return remote.UserRefName(tokenID, "")
+}
+
+// validUserRefName reports whether name is usable as a personal ref: one or
+// more slash-separated segments, none empty, "." or "..".
+func validUserRefName(name string) bool {
+	if name == "" || len(name) > 255 {
+		return false
+	}
+	for _, seg := range strings.Split(name, "/") {
+		if seg == "" || seg == "." || seg == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+func handleListUserRefs(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, _ blobstore.BlobStore, _ *ServerConfig) {
+	branches, err := meta.ListBranches(r.Context())
+	if err != nil {
+		internalError(w, "list branches", err)
+		return
+	}
+
+	prefix := userRefPrefix(r)
+	refs := []*models.Branch{}
+	for _, b := range branches {
+		if strings.HasPrefix(b.Name, prefix) {
+			ref := *b
+			ref.Name = strings.TrimPrefix(b.Name, prefix)
+			refs = append(refs, &ref)
+		}
 	}
+	writeJSON(w, http.StatusOK, refs)
+}
 
+// handleUpdateUserRef moves a ref in the caller's own namespace. Any rw token
+// may write its namespace; branch rules do not apply there.
+func handleUpdateUserRef(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, _ blobstore.BlobStore, cfg *ServerConfig) {
+	name := r.PathValue("name")
+	if !validUserRefName(name) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request", "message": fmt.Sprintf("invalid ref name '%s'", name)})
+		return
+	}
+
+	if _, ok := applyBranchUpdate(w, r, meta, cfg, userRefPrefix(r)+name, name); !ok {
+		return
+	}
 	w.WriteHeader(http.StatusOK)
 }
 
-func handleDeleteBranch(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, _ blobstore.BlobStore, _ *ServerConfig) {
+func handleDeleteUserRef(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, _ blobstore.BlobStore, _ *ServerConfig) {
 	name := r.PathValue("name")
+	if !validUserRefName(name) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request", "message": fmt.Sprintf("invalid ref name '%s'", name)})
+		return
+	}
 
-	err := meta.DeleteBranch(r.Context(), name)
+	if err := meta.DeleteBranch(r.Context(), userRefPrefix(r)+name); err != nil {
+		if errors.Is(err, metastore.ErrNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not_found", "message": "ref not found"})
+			return
+		}
+		internalError(w, "delete ref", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// --- Tag Handlers ---
+
+func handleListTags(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, _ blobstore.BlobStore, _ *ServerConfig) {
+	tags, err := meta.ListTags(r.Context())
+	if err != nil {
+		internalError(w, "list tags", err)
+		return
+	}
+	if tags == nil {
+		tags = []*models.Tag{}
+	}
+	writeJSON(w, http.StatusOK, tags)
+}
+
+func handleGetTag(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, _ blobstore.BlobStore, _ *ServerConfig) {
+	tag, err := meta.GetTag(r.Context(), r.PathValue("name"))
 	if err != nil {
 		if errors.Is(err, metastore.ErrNotFound) {
-			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not_found", "message": "branch not found"})
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not_found", "message": "tag not found"})
 			return
 		}
-		internalError(w, "delete branch", err)
+		internalError(w, "get tag", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, tag)
+}
+
+// handlePutTag creates a tag at a commit the server already has. Recreating
+// a tag at the same commit succeeds; moving it requires force.
+func handlePutTag(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, _ blobstore.BlobStore, cfg *ServerConfig) {
+	name := r.PathValue("name")
+	if !validUserRefName(name) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request", "message": fmt.Sprintf("invalid tag name '%s'", name)})
+		return
+	}
+
+	var req remote.TagRequest
+	if err := readJSON(w, r, cfg.MaxRequestBody, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request", "message": err.Error()})
+		return
+	}
+	has, err := meta.HasCommit(r.Context(), req.CommitID)
+	if err != nil {
+		internalError(w, "check commit", err)
+		return
+	}
+	if !has {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not_found", "message": fmt.Sprintf("commit '%s' not found; push it first", req.CommitID)})
+		return
+	}
+
+	tag := &models.Tag{Name: name, CommitID: req.CommitID, Message: req.Message, CreatedAt: time.Now()}
+	if err := meta.PutTag(r.Context(), tag, req.Force); err != nil {
+		if errors.Is(err, metastore.ErrConflict) {
+			writeJSON(w, http.StatusConflict, map[string]string{"error": "tag_exists", "message": fmt.Sprintf("tag '%s' already exists at another commit", name)})
+			return
+		}
+		internalError(w, "put tag", err)
+		return
+	}
+
+	publishEvent(r, meta, cfg, remote.EventTag, name, req.CommitID)
+	w.WriteHeader(http.StatusOK)
+}
+
+func handleDeleteTag(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, _ blobstore.BlobStore, cfg *ServerConfig) {
+	name := r.PathValue("name")
+	if err := meta.DeleteTag(r.Context(), name); err != nil {
+		if errors.Is(err, metastore.ErrNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not_found", "message": "tag not found"})
+			return
+		}
+		internalError(w, "delete tag", err)
+		return
+	}
+
+	publishEvent(r, meta, cfg, remote.EventTagDelete, name, "")
+	w.WriteHeader(http.StatusOK)
+}
+
+// --- Note Handlers ---
+
+func handleListNotes(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, _ blobstore.BlobStore, _ *ServerConfig) {
+	notes, err := meta.ListNotes(r.Context())
+	if err != nil {
+		internalError(w, "list notes", err)
+		return
+	}
+	if notes == nil {
+		notes = []*models.Note{}
+	}
+	writeJSON(w, http.StatusOK, notes)
+}
+
+// handlePutNote attaches a note to a commit the server already has.
+// Resending the same message succeeds; replacing another needs force.
+func handlePutNote(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, _ blobstore.BlobStore, cfg *ServerConfig) {
+	commitID := r.PathValue("commit")
+	var req remote.NoteRequest
+	if err := readJSON(w, r, cfg.MaxRequestBody, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request", "message": err.Error()})
+		return
+	}
+	if req.Message == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request", "message": "note message cannot be empty"})
+		return
+	}
+	has, err := meta.HasCommit(r.Context(), commitID)
+	if err != nil {
+		internalError(w, "check commit", err)
+		return
+	}
+	if !has {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not_found", "message": fmt.Sprintf("commit '%s' not found; push it first", commitID)})
+		return
+	}
+
+	note := &models.Note{CommitID: commitID, Message: req.Message, Author: req.Author, UpdatedAt: time.Now()}
+	if err := meta.PutNote(r.Context(), note, req.Force); err != nil {
+		if errors.Is(err, metastore.ErrConflict) {
+			writeJSON(w, http.StatusConflict, map[string]string{"error": "note_exists", "message": fmt.Sprintf("commit '%s' already has another note", commitID)})
+			return
+		}
+		internalError(w, "put note", err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func handleDeleteNote(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, _ blobstore.BlobStore, _ *ServerConfig) {
+	if err := meta.DeleteNote(r.Context(), r.PathValue("commit")); err != nil {
+		if errors.Is(err, metastore.ErrNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not_found", "message": "note not found"})
+			return
+		}
+		internalError(w, "delete note", err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// publishEvent notifies event stream subscribers, webhooks, and mirrors of a
+// change to the request's repository. The server-wide webhooks receive every
+// event; repository webhooks receive the event types they subscribed to.
+func publishEvent(r *http.Request, meta metastore.MetaStore, cfg *ServerConfig, eventType, ref, commitID string) {
+	publish(r, meta, cfg, &WebhookEvent{Event: eventType, Repo: r.PathValue("repo"), Branch: ref, CommitID: commitID})
+}
+
+// publish is publishEvent for events with more to tell webhooks, such as the
+// previous tip of a push.
+func publish(r *http.Request, meta metastore.MetaStore, cfg *ServerConfig, event *WebhookEvent) {
+	cfg.Events.Publish(event.Repo, event.Event, event.Branch, event.CommitID)
+	cfg.webhooks.notify(r.Context(), meta, event)
+	cfg.replication.notify(event.Repo)
+}
+
+// --- Webhook Handlers ---
+
+// handleGetWebhooks lists the repository's webhooks without their secrets.
+func handleGetWebhooks(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, _ blobstore.BlobStore, _ *ServerConfig) {
+	hooks, err := meta.GetWebhooks(r.Context())
+	if err != nil {
+		internalError(w, "get webhooks", err)
+		return
+	}
+	for _, h := range hooks {
+		h.Secret = ""
+	}
+	writeJSON(w, http.StatusOK, &remote.WebhookList{Webhooks: hooks})
+}
+
+// handlePutWebhooks replaces the repository's webhooks.
+func handlePutWebhooks(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, _ blobstore.BlobStore, cfg *ServerConfig) {
+	var req remote.WebhookList
+	if err := readJSON(w, r, cfg.MaxRequestBody, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request", "message": err.Error()})
+		return
+	}
+	if err := cfg.webhooks.validate(req.Webhooks); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request", "message": err.Error()})
+		return
+	}
+	if req.Webhooks == nil {
+		req.Webhooks = []*remote.Webhook{}
+	}
+	if err := meta.PutWebhooks(r.Context(), req.Webhooks); err != nil {
+		internalError(w, "put webhooks", err)
+		return
+	}
+	slog.Info("repository webhooks updated", "repo", r.PathValue("repo"), "count", len(req.Webhooks))
+	w.WriteHeader(http.StatusOK)
+}
+
+// --- Deployment Handlers ---
+
+func handleListDeployments(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, _ blobstore.BlobStore, _ *ServerConfig) {
+	branches, err := meta.ListBranches(r.Context())
+	if err != nil {
+		internalError(w, "list branches", err)
+		return
+	}
+
+	refs := []*models.Branch{}
+	for _, b := range branches {
+		if strings.HasPrefix(b.Name, remote.DeploymentRefPrefix) {
+			ref := *b
+			ref.Name = strings.TrimPrefix(b.Name, remote.DeploymentRefPrefix)
+			refs = append(refs, &ref)
+		}
+	}
+	writeJSON(w, http.StatusOK, refs)
+}
+
+// handleRecordDeployment points an environment at a commit the server
+// already has. Without an expected commit the previous deployment is
+// replaced unconditionally.
+func handleRecordDeployment(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, _ blobstore.BlobStore, cfg *ServerConfig) {
+	env := r.PathValue("env")
+	if !validUserRefName(env) || strings.Contains(env, "/") {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request", "message": fmt.Sprintf("invalid environment name '%s'", env)})
+		return
+	}
+
+	var req remote.BranchUpdateRequest
+	if err := readJSON(w, r, cfg.MaxRequestBody, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request", "message": err.Error()})
+		return
+	}
+	has, err := meta.HasCommit(r.Context(), req.CommitID)
+	if err != nil {
+		internalError(w, "check commit", err)
+		return
+	}
+	if !has {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not_found", "message": fmt.Sprintf("commit '%s' not found; push it first", req.CommitID)})
+		return
+	}
+
+	err = meta.UpdateBranchCAS(r.Context(), remote.DeploymentRefPrefix+env, req.CommitID, req.Expected)
+	if err != nil {
+		if errors.Is(err, metastore.ErrConflict) {
+			writeJSON(w, http.StatusConflict, map[string]string{"error": "conflict", "message": fmt.Sprintf("deployment of '%s' changed concurrently", env)})
+			return
+		}
+		internalError(w, "record deployment", err)
 		return
 	}
 
@@ -653,6 +1947,7 @@ func handleRepoInfo(w http.ResponseWriter, r *http.Request, meta metastore.MetaS
 		internalError(w, "list branches", err)
 		return
 	}
+	branches = publicBranches(branches)
 
 	commitCount, err := meta.GetCommitCount(r.Context())
 	if err != nil {
@@ -660,17 +1955,171 @@ func handleRepoInfo(w http.ResponseWriter, r *http.Request, meta metastore.MetaS
 		return
 	}
 
-	blobCount, err := blobs.TotalCount(r.Context())
+	// Info may be served by a read replica, so an unknown blob count is
+	// scanned here but only stored by writes
+	blobCount, ok, err := meta.GetBlobCount(r.Context())
+	if err == nil && !ok {
+		blobCount, err = blobs.TotalCount(r.Context())
+	}
 	if err != nil {
 		internalError(w, "get blob count", err)
 		return
 	}
 
-	writeJSON(w, http.StatusOK, &remote.RepoInfo{
-		BranchCount: len(branches),
-		CommitCount: commitCount,
-		TotalBlobs:  blobCount,
-	})
+	tokenID, _ := r.Context().Value(contextKeyTokenID).(string)
+	tokenRepos, _ := r.Context().Value(contextKeyRepos).([]string)
+	permission, _ := r.Context().Value(contextKeyPermission).(string)
+
+	info := &remote.RepoInfo{
+		BranchCount:   len(branches),
+		CommitCount:   commitCount,
+		TotalBlobs:    blobCount,
+		DefaultBranch: defaultBranchName(branches),
+		Token: &remote.TokenScope{
+			ID:         tokenID,
+			Repos:      tokenRepos,
+			Permission: permission,
+		},
+	}
+
+	quota, err := meta.GetQuota(r.Context())
+	if err != nil {
+		internalError(w, "get quota", err)
+		return
+	}
+	if !quota.IsZero() {
+		info.Quota = quota
+	}
+
+	if r.URL.Query().Get("usage") == "true" {
+		if info.Usage, err = repoUsage(r.Context(), meta, blobs); err != nil {
+			internalError(w, "get usage", err)
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, info)
+}
+
+// maxUsageClasses is how many of the largest classes repository info lists.
+const maxUsageClasses = 10
+
+// repoUsage breaks down the storage of a repository. Like the blob count,
+// unknown blob bytes are scanned but not stored.
+func repoUsage(ctx context.Context, meta metastore.MetaStore, blobs blobstore.BlobStore) (*remote.RepoUsage, error) {
+	usage := &remote.RepoUsage{}
+	var (
+		ok  bool
+		err error
+	)
+	usage.BlobBytes, ok, err = meta.GetBlobBytes(ctx)
+	if err == nil && !ok {
+		usage.BlobBytes, err = blobs.TotalSize(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if usage.MetaBytes, err = meta.MetaSize(ctx); err != nil {
+		return nil, err
+	}
+	classes, err := meta.ClassUsage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if classes == nil {
+		classes = []*remote.ClassUsage{}
+	}
+	usage.LargestClasses = classes[:min(len(classes), maxUsageClasses)]
+	return usage, nil
+}
+
+// Page sizes of the commit log endpoint.
+const (
+	defaultCommitLogLimit = 50
+	maxCommitLogLimit     = 500
+)
+
+// handleCommitLog returns a page of a branch's commit metadata, newest first,
+// so clients can browse history without downloading bundles.
+func handleCommitLog(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, _ blobstore.BlobStore, _ *ServerConfig) {
+	q := r.URL.Query()
+	limit := defaultCommitLogLimit
+	if s := q.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request", "message": "limit must be a positive integer"})
+			return
+		}
+		limit = min(n, maxCommitLogLimit)
+	}
+
+	branchName := q.Get("branch")
+	if branchName == "" {
+		branches, err := meta.ListBranches(r.Context())
+		if err != nil {
+			internalError(w, "list branches", err)
+			return
+		}
+		branchName = defaultBranchName(publicBranches(branches))
+		if branchName == "" {
+			writeJSON(w, http.StatusOK, &remote.CommitLogResponse{Commits: []*models.Commit{}})
+			return
+		}
+	}
+	branch, err := meta.GetBranch(r.Context(), branchName)
+	if err != nil {
+		if errors.Is(err, metastore.ErrNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not_found", "message": "branch not found"})
+			return
+		}
+		internalError(w, "get branch", err)
+		return
+	}
+
+	// One extra commit tells whether another page follows
+	commits, err := meta.GetCommitLog(r.Context(), branch.CommitID, q.Get("before"), limit+1)
+	if err != nil {
+		if errors.Is(err, metastore.ErrNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not_found", "message": "commit in before not found"})
+			return
+		}
+		internalError(w, "get commit log", err)
+		return
+	}
+	resp := &remote.CommitLogResponse{Branch: branchName, Commits: commits}
+	if len(commits) > limit {
+		resp.Commits = commits[:limit]
+		resp.Next = commits[limit-1].ID
+	}
+	if resp.Commits == nil {
+		resp.Commits = []*models.Commit{}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// defaultBranchName picks the branch clients should treat as the default:
+// "main" if present, then "master", otherwise the first branch by name.
+func defaultBranchName(branches []*models.Branch) string {
+	if len(branches) == 0 {
+		return ""
+	}
+	first := branches[0].Name
+	hasMaster := false
+	for _, b := range branches {
+		switch b.Name {
+		case "main":
+			return b.Name
+		case "master":
+			hasMaster = true
+		}
+		if b.Name < first {
+			first = b.Name
+		}
+	}
+	if hasMaster {
+		return "master"
+	}
+	return first
 }
 
 // --- Health Handlers ---
@@ -694,6 +2143,22 @@ func adminAuth(adminToken string, next http.Handler) http.Handler {
 	})
 }
 
+// repoAdminAuth passes requests bearing the admin token straight to next and
+// sends every other request through the token middlewares first. An empty
+// adminToken matches no request.
+func repoAdminAuth(adminToken string, next http.Handler, tokenAuth ...func(http.Handler) http.Handler) http.Handler {
+	expectedHash := sha256.Sum256([]byte("Bearer " + adminToken))
+	withToken := applyMiddleware(next, tokenAuth...)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHash := sha256.Sum256([]byte(r.Header.Get("Authorization")))
+		if adminToken != "" && subtle.ConstantTimeCompare(expectedHash[:], authHash[:]) == 1 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		withToken.ServeHTTP(w, r)
+	})
+}
+
 // --- Helpers ---
 
 func internalError(w http.ResponseWriter, context string, err error) {
@@ -726,6 +2191,7 @@ func makeAdminCreateTokenHandler(tokens TokenStore, logger *slog.Logger) http.Ha
 			Description string   `json:"description"`
 			Repos       []string `json:"repos"`
 			Permission  string   `json:"permission"`
+			TokenLimits
 		}
 		if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&req); err != nil {
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request", "message": "invalid JSON"})
@@ -738,8 +2204,16 @@ func makeAdminCreateTokenHandler(tokens TokenStore, logger *slog.Logger) http.Ha
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request", "message": "permission must be 'ro' or 'rw'"})
 			return
 		}
+		if err := req.TokenLimits.Validate(req.Permission); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request", "message": err.Error()})
+			return
+		}
+		if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request", "message": "expires_at must be in the future"})
+			return
+		}
 
-		rawToken, info, err := tokens.CreateToken(req.Description, req.Repos, req.Permission)
+		rawToken, info, err := tokens.CreateToken(req.Description, req.Repos, req.Permission, req.TokenLimits)
 		if err != nil {
 			internalError(w, "create token", err)
 			return
@@ -751,6 +2225,9 @@ func makeAdminCreateTokenHandler(tokens TokenStore, logger *slog.Logger) http.Ha
 			"description": info.Desc,
 			"repos":       info.Repos,
 			"permission":  info.Permission,
+			"expires_at":  info.ExpiresAt,
+			"branches":    info.Branches,
+			"scopes":      info.Scopes,
 		})
 	}
 }
@@ -769,6 +2246,7 @@ func makeAdminListTokensHandler(tokens TokenStore, logger *slog.Logger) http.Han
 			Description string   `json:"description"`
 			Repos       []string `json:"repos"`
 			Permission  string   `json:"permission"`
+			TokenLimits
 		}
 		entries := make([]tokenEntry, len(list))
 		for i, t := range list {
@@ -777,6 +2255,7 @@ func makeAdminListTokensHandler(tokens TokenStore, logger *slog.Logger) http.Han
 				Description: t.Desc,
 				Repos:       t.Repos,
 				Permission:  t.Permission,
+				TokenLimits: t.TokenLimits,
 			}
 		}
 
@@ -822,7 +2301,7 @@ func makeAdminListReposHandler(manager RepoManager, _ *slog.Logger) http.Handler
 	}
 }
 
-func makeAdminCreateRepoHandler(manager RepoManager, _ *slog.Logger) http.HandlerFunc {
+func makeAdminCreateRepoHandler(manager RepoManager, webhooks *webhookDispatcher, _ *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req struct {
 			Name string `json:"name"`
@@ -843,11 +2322,12 @@ func makeAdminCreateRepoHandler(manager RepoManager, _ *slog.Logger) http.Handle
 			internalError(w, "create repo", err)
 			return
 		}
+		webhooks.notify(r.Context(), nil, &WebhookEvent{Event: remote.EventRepoCreate, Repo: req.Name})
 		w.WriteHeader(http.StatusCreated)
 	}
 }
 
-func makeAdminDeleteRepoHandler(manager RepoManager, _ *slog.Logger) http.HandlerFunc {
+func makeAdminDeleteRepoHandler(manager RepoManager, webhooks *webhookDispatcher, _ *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		name := r.PathValue("name")
 		if name == "" {
@@ -862,13 +2342,37 @@ func makeAdminDeleteRepoHandler(manager RepoManager, _ *slog.Logger) http.Handle
 			internalError(w, "delete repo", err)
 			return
 		}
+		webhooks.notify(r.Context(), nil, &WebhookEvent{Event: remote.EventRepoDelete, Repo: name})
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
 
+// makeAdminWebhookDeliveriesHandler lists queued and recent webhook
+// deliveries, newest first, optionally filtered by ?state=.
+func makeAdminWebhookDeliveriesHandler(queue *webhookQueue) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		state := r.URL.Query().Get("state")
+		switch state {
+		case "", DeliveryPending, DeliveryDelivered, DeliveryFailed:
+		default:
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request",
+				"message": fmt.Sprintf("unknown state '%s' (want %s, %s, or %s)", state, DeliveryPending, DeliveryDelivered, DeliveryFailed)})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"deliveries": queue.deliveries(state)})
+	}
+}
+
+// lockUnavailable responds when a repo write lock could not be acquired.
+func lockUnavailable(w http.ResponseWriter, logger *slog.Logger, repo string, err error) {
+	logger.Warn("repo write lock unavailable", "repo", repo, "error", err)
+	w.Header().Set("Retry-After", "1")
+	writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "lock_unavailable", "message": fmt.Sprintf("write lock for repository '%s' is unavailable, retry later", repo)})
+}
+
 // makeAdminGCHandler creates a handler for garbage collecting a repo's unreferenced blobs.
-// The locker prevents concurrent writes from racing with the mark-sweep GC.
-func makeAdminGCHandler(repos RepoOpener, locker RepoLocker, logger *slog.Logger) http.HandlerFunc {
+// The run holds the repo's write lock so concurrent writes can't race with the mark-sweep GC.
+func makeAdminGCHandler(repos RepoOpener, gc *gcScheduler, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		repoName := r.PathValue("repo")
 		if repoName == "" {
@@ -876,18 +2380,16 @@ func makeAdminGCHandler(repos RepoOpener, locker RepoLocker, logger *slog.Logger
 			return
 		}
 
-		meta, blobs, err := repos.Open(repoName)
-		if err != nil {
+		if _, _, err := repos.Open(repoName); err != nil {
 			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not_found", "message": fmt.Sprintf("repository '%s' not found", repoName)})
 			return
 		}
 
-		// Acquire write lock to prevent concurrent pushes from creating the
-		// TOCTOU race where GC deletes a blob just referenced by a push.
-		locker.LockWrite(repoName)
-		defer locker.UnlockWrite(repoName)
-
-		result, err := GarbageCollect(r.Context(), meta, blobs, logger)
+		result, err := gc.run(r.Context(), repoName, GCTriggerManual)
+		if errors.Is(err, errLockUnavailable) {
+			lockUnavailable(w, logger, repoName, err)
+			return
+		}
 		if err != nil {
 			internalError(w, "garbage collect", err)
 			return
@@ -896,3 +2398,76 @@ func makeAdminGCHandler(repos RepoOpener, locker RepoLocker, logger *slog.Logger
 		writeJSON(w, http.StatusOK, result)
 	}
 }
+
+// makeAdminGCStatusHandler creates a handler reporting the GC schedule and the
+// last run of a repo.
+func makeAdminGCStatusHandler(repos RepoOpener, gc *gcScheduler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		repoName := r.PathValue("repo")
+		if _, _, err := repos.Open(repoName); err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not_found", "message": fmt.Sprintf("repository '%s' not found", repoName)})
+			return
+		}
+
+		resp := map[string]any{
+			"repo":         repoName,
+			"scheduled":    gc.interval > 0,
+			"last_run":     gc.lastRun(repoName),
+			"grace_period": gc.grace.String(),
+		}
+		if gc.interval > 0 {
+			resp["interval"] = gc.interval.String()
+			resp["next_run_at"] = gc.nextRunAt()
+		}
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+// makeAdminGetQuotaHandler creates a handler returning a repo's quota.
+func makeAdminGetQuotaHandler(repos RepoOpener) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		repoName := r.PathValue("repo")
+		meta, _, err := repos.Open(repoName)
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not_found", "message": fmt.Sprintf("repository '%s' not found", repoName)})
+			return
+		}
+		quota, err := meta.GetQuota(r.Context())
+		if err != nil {
+			internalError(w, "get quota", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, quota)
+	}
+}
+
+// makeAdminPutQuotaHandler creates a handler replacing a repo's quota. Data
+// already stored beyond a lowered quota is kept; only new uploads and
+// commits are rejected.
+func makeAdminPutQuotaHandler(repos RepoOpener, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		repoName := r.PathValue("repo")
+		meta, _, err := repos.Open(repoName)
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not_found", "message": fmt.Sprintf("repository '%s' not found", repoName)})
+			return
+		}
+
+		var quota remote.RepoQuota
+		if err := readJSON(w, r, 1<<20, &quota); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request", "message": err.Error()})
+			return
+		}
+		if quota.MaxBlobBytes < 0 || quota.MaxCommits < 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request", "message": "quota limits must not be negative"})
+			return
+		}
+		if err := meta.PutQuota(r.Context(), &quota); err != nil {
+			internalError(w, "put quota", err)
+			return
+		}
+
+		logger.Info("quota set", "repo", repoName, "max_blob_bytes", quota.MaxBlobBytes, "max_commits", quota.MaxCommits)
+		writeJSON(w, http.StatusOK, &quota)
+	}
+}
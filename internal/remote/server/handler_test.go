@@ -13,6 +13,8 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -94,15 +96,16 @@ func (t *testTokenStore) DeleteToken(id string) error {
 	return fmt.Errorf("token '%s' not found", id)
 }
 
-func (t *testTokenStore) CreateToken(desc string, repos []string, permission string) (string, *TokenInfo, error) {
+func (t *testTokenStore) CreateToken(desc string, repos []string, permission string, limits TokenLimits) (string, *TokenInfo, error) {
 	rawToken := "test-created-token"
 	tokenHash := HashToken(rawToken)
 	info := &TokenInfo{
-		ID:         "tok-new",
-		TokenHash:  tokenHash,
-		Desc:       desc,
-		Repos:      repos,
-		Permission: permission,
+		ID:          "tok-new",
+		TokenHash:   tokenHash,
+		Desc:        desc,
+		Repos:       repos,
+		Permission:  permission,
+		TokenLimits: limits,
 	}
 	t.tokens[tokenHash] = info
 	return rawToken, info, nil
@@ -239,6 +242,91 @@ func TestCommitBundle_UploadAndDownload(t *testing.T) {
 	assert.Len(t, result.Operations, 1)
 }
 
+func TestCommitBundle_AcceptsBothHashVersions(t *testing.T) {
+	ts, _, _, token := newTestServer(t)
+
+	upload := func(commit *models.Commit, ops []*models.Operation) (int, map[string]string) {
+		data, err := json.Marshal(&remote.CommitBundle{Commit: commit, Operations: ops})
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(authReq("POST", ts.URL+"/api/v1/repos/test/commits", token, bytes.NewReader(data)))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var body map[string]string
+		_ = json.NewDecoder(resp.Body).Decode(&body)
+		return resp.StatusCode, body
+	}
+
+	ts0 := time.Now().Truncate(time.Second)
+	ops := []*models.Operation{{Type: models.OperationInsert, ClassName: "Article", ObjectID: "obj-001"}}
+
+	v1 := &models.Commit{Message: "v1", Timestamp: ts0}
+	v1.ID = models.GenerateCommitID(v1.Message, ts0, "", ops)
+	status, _ := upload(v1, ops)
+	assert.Equal(t, http.StatusCreated, status)
+
+	v2 := &models.Commit{ParentID: v1.ID, Message: "v2", Timestamp: ts0, HashVersion: models.CommitHashV2}
+	var err error
+	v2.ID, err = models.GenerateCommitIDV2(v2.Message, ts0, v1.ID, "", ops)
+	require.NoError(t, err)
+	status, _ = upload(v2, ops)
+	assert.Equal(t, http.StatusCreated, status)
+
+	// A v2 ID presented as v1 does not verify
+	mislabeled := &models.Commit{ParentID: v1.ID, Message: "v2", Timestamp: ts0, ID: v2.ID}
+	status, body := upload(mislabeled, ops)
+	assert.Equal(t, http.StatusUnprocessableEntity, status)
+	assert.Equal(t, "commit_id_mismatch", body["error"])
+
+	unknown := &models.Commit{ParentID: v1.ID, Message: "v9", Timestamp: ts0, ID: "x", HashVersion: 9}
+	status, body = upload(unknown, ops)
+	assert.Equal(t, http.StatusUnprocessableEntity, status)
+	assert.Equal(t, "validation_failed", body["error"])
+}
+
+func TestCommitBundle_RejectPII(t *testing.T) {
+	tmpDir := t.TempDir()
+	meta, err := metastore.NewBboltStore(filepath.Join(tmpDir, "meta.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { meta.Close() })
+	blobs, err := blobstore.NewFSStore(filepath.Join(tmpDir, "blobs"))
+	require.NoError(t, err)
+
+	rawToken := "test-token-123"
+	tokens := &testTokenStore{tokens: map[string]*TokenInfo{
+		HashToken(rawToken): {ID: "tok-1", TokenHash: HashToken(rawToken), Repos: []string{"*"}, Permission: "rw"},
+	}}
+	cfg := DefaultServerConfig()
+	cfg.RejectPII = true
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	h, cleanup := Handler(&testRepoOpener{meta: meta, blobs: blobs}, tokens, cfg, logger, nil, nil)
+	t.Cleanup(cleanup)
+	ts := httptest.NewServer(h)
+	t.Cleanup(ts.Close)
+
+	upload := func(classifications map[string]*models.Classification) (int, map[string]string) {
+		ops := []*models.Operation{{Type: models.OperationInsert, ClassName: "Person", ObjectID: "p1"}}
+		commit := &models.Commit{Message: "people", Timestamp: time.Now().Truncate(time.Second), HashVersion: models.CommitHashV2, Classifications: classifications}
+		commit.ID, err = commit.ComputeID(ops)
+		require.NoError(t, err)
+		data, err := json.Marshal(&remote.CommitBundle{Commit: commit, Operations: ops})
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(authReq("POST", ts.URL+"/api/v1/repos/test/commits", rawToken, bytes.NewReader(data)))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var body map[string]string
+		_ = json.NewDecoder(resp.Body).Decode(&body)
+		return resp.StatusCode, body
+	}
+
+	status, body := upload(map[string]*models.Classification{"Person": {PII: models.PIIRestricted}})
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Equal(t, "pii_restricted", body["error"])
+	assert.Contains(t, body["message"], "Person")
+
+	status, _ = upload(map[string]*models.Classification{"Person": {License: "CC-BY-4.0", PII: models.PIIInternal}})
+	assert.Equal(t, http.StatusCreated, status)
+}
+
 func TestBranchUpdate_CAS(t *testing.T) {
 	ts, meta, _, token := newTestServer(t)
 	ctx := context.Background()
@@ -276,6 +364,67 @@ func TestBranchUpdate_CAS(t *testing.T) {
 	assert.Equal(t, http.StatusConflict, resp.StatusCode)
 }
 
+// replicaRepoOpener implements ReadRepoOpener with a separate replica MetaStore.
+type replicaRepoOpener struct {
+	testRepoOpener
+	replica metastore.MetaStore
+}
+
+func (o *replicaRepoOpener) OpenRead(name string) (metastore.MetaStore, blobstore.BlobStore, error) {
+	return o.replica, o.blobs, nil
+}
+
+func TestReadReplicas_ServeReadOnlyEndpoints(t *testing.T) {
+	ctx := context.Background()
+	tmpDir := t.TempDir()
+	primary, err := metastore.NewBboltStore(filepath.Join(tmpDir, "primary.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { primary.Close() })
+	replica, err := metastore.NewBboltStore(filepath.Join(tmpDir, "replica.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { replica.Close() })
+	blobs, err := blobstore.NewFSStore(filepath.Join(tmpDir, "blobs"))
+	require.NoError(t, err)
+
+	// Both have the commit; only the replica has a branch yet
+	for _, meta := range []metastore.MetaStore{primary, replica} {
+		bundle := &remote.CommitBundle{Commit: &models.Commit{ID: "c1", Message: "first", Timestamp: time.Now()}}
+		require.NoError(t, meta.InsertCommitBundle(ctx, bundle))
+	}
+	require.NoError(t, replica.CreateBranch(ctx, "replicated", "c1"))
+
+	repos := &replicaRepoOpener{testRepoOpener: testRepoOpener{meta: primary, blobs: blobs}, replica: replica}
+	rawToken := "test-token-123"
+	tokens := &testTokenStore{tokens: map[string]*TokenInfo{
+		HashToken(rawToken): {ID: "tok-1", TokenHash: HashToken(rawToken), Repos: []string{"*"}, Permission: "rw"},
+	}}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	h, cleanup := Handler(repos, tokens, DefaultServerConfig(), logger, nil, nil)
+	t.Cleanup(cleanup)
+	ts := httptest.NewServer(h)
+	t.Cleanup(ts.Close)
+
+	// Reads come from the replica
+	req := authReq("GET", ts.URL+"/api/v1/repos/test/branches/replicated", rawToken, nil)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Writes go to the primary
+	data, _ := json.Marshal(&remote.BranchUpdateRequest{CommitID: "c1"})
+	req = authReq("PUT", ts.URL+"/api/v1/repos/test/branches/main", rawToken, bytes.NewReader(data))
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	_, err = primary.GetBranch(ctx, "main")
+	assert.NoError(t, err)
+	_, err = replica.GetBranch(ctx, "main")
+	assert.ErrorIs(t, err, metastore.ErrNotFound)
+}
+
 func TestNegotiatePush(t *testing.T) {
 	ts, meta, _, token := newTestServer(t)
 	ctx := context.Background()
@@ -302,6 +451,79 @@ func TestNegotiatePush(t *testing.T) {
 	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
 	assert.Equal(t, "c1", result.RemoteTip)
 	assert.ElementsMatch(t, []string{"c3", "c2"}, result.MissingCommits)
+	assert.Equal(t, []string{"c1"}, result.TipSample)
+}
+
+// commitReadCounter counts the commits read from a MetaStore.
+type commitReadCounter struct {
+	metastore.MetaStore
+	reads atomic.Int64
+}
+
+func (c *commitReadCounter) GetCommit(ctx context.Context, id string) (*models.Commit, error) {
+	c.reads.Add(1)
+	return c.MetaStore.GetCommit(ctx, id)
+}
+
+func TestNegotiatePush_WalksBranchHistoryOncePerTip(t *testing.T) {
+	_, meta, _, token := newTestServer(t)
+	ctx := context.Background()
+
+	const depth = 50
+	parent := ""
+	for i := 0; i < depth; i++ {
+		id := fmt.Sprintf("c%d", i)
+		bundle := &remote.CommitBundle{
+			Commit: &models.Commit{ID: id, ParentID: parent, Message: id, Timestamp: time.Now()},
+		}
+		require.NoError(t, meta.InsertCommitBundle(ctx, bundle))
+		parent = id
+	}
+	require.NoError(t, meta.CreateBranch(ctx, "main", parent))
+
+	counter := &commitReadCounter{MetaStore: meta}
+	cfg := DefaultServerConfig()
+	cfg.tipSamples = newTipSampleCache()
+	negotiate := func() *remote.NegotiatePushResponse {
+		data, _ := json.Marshal(&remote.NegotiatePushRequest{Branch: "main", Commits: []string{"x2", "x1"}})
+		req := authReq("POST", "/api/v1/repos/test/negotiate/push", token, bytes.NewReader(data))
+		req.SetPathValue("repo", "test")
+		rec := httptest.NewRecorder()
+		handleNegotiatePush(rec, req, counter, nil, cfg)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var result remote.NegotiatePushResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&result))
+		return &result
+	}
+
+	first := negotiate()
+	assert.Equal(t, int64(depth), counter.reads.Load(), "the first round walks the chain")
+	assert.Equal(t, "c49", first.TipSample[0])
+	assert.Equal(t, "c0", first.TipSample[len(first.TipSample)-1])
+
+	for round := 2; round <= 4; round++ {
+		counter.reads.Store(0)
+		result := negotiate()
+		assert.Zero(t, counter.reads.Load(), "round %d re-read the chain", round)
+		assert.Equal(t, first.TipSample, result.TipSample)
+	}
+}
+
+func TestNegotiatePush_ShallowTipSampleNotCached(t *testing.T) {
+	_, meta, _, _ := newTestServer(t)
+	ctx := context.Background()
+
+	bundle := &remote.CommitBundle{
+		Commit: &models.Commit{ID: "c1", ParentID: "c0", Message: "shallow", Timestamp: time.Now()},
+	}
+	require.NoError(t, meta.InsertCommitBundle(ctx, bundle))
+
+	cache := newTipSampleCache()
+	sample, err := sampleFirstParentChain(ctx, cache, "test", meta, "c1", 100)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c1", "c0"}, sample)
+	_, cached := cache.get("test", "c1")
+	assert.False(t, cached, "a walk cut short by missing history can grow later")
 }
 
 func TestVectorUploadAndDownload(t *testing.T) {
@@ -333,6 +555,525 @@ func TestVectorUploadAndDownload(t *testing.T) {
 	assert.Equal(t, data, got)
 }
 
+// signingBlobStore serves signed URLs from a separate bucket server.
+type signingBlobStore struct {
+	*blobstore.FSStore
+	bucketURL string
+}
+
+func (s *signingBlobStore) SignedURL(ctx context.Context, hash string, _ time.Duration) (string, int, error) {
+	reader, dims, err := s.Get(ctx, hash)
+	if err != nil {
+		return "", 0, err
+	}
+	reader.Close()
+	return s.bucketURL + "/" + hash + "?sig=ok", dims, nil
+}
+
+func TestGetVector_SignedURLRedirect(t *testing.T) {
+	tmpDir := t.TempDir()
+	meta, err := metastore.NewBboltStore(filepath.Join(tmpDir, "meta.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { meta.Close() })
+	fs, err := blobstore.NewFSStore(filepath.Join(tmpDir, "blobs"))
+	require.NoError(t, err)
+
+	data := []byte("vector-data-here")
+	h := sha256.Sum256(data)
+	hash := hex.EncodeToString(h[:])
+	require.NoError(t, fs.Put(context.Background(), hash, bytes.NewReader(data), 4))
+
+	bucket := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" || r.URL.Query().Get("sig") != "ok" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write(data)
+	}))
+	t.Cleanup(bucket.Close)
+
+	rawToken := "test-token-123"
+	tokens := &testTokenStore{tokens: map[string]*TokenInfo{
+		HashToken(rawToken): {ID: "tok-1", TokenHash: HashToken(rawToken), Repos: []string{"*"}, Permission: "rw"},
+	}}
+	cfg := DefaultServerConfig()
+	cfg.SignedURLExpiry = time.Minute
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler, cleanup := Handler(&testRepoOpener{meta: meta, blobs: &signingBlobStore{FSStore: fs, bucketURL: bucket.URL}}, tokens, cfg, logger, nil, nil)
+	t.Cleanup(cleanup)
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+
+	// Clients that opt in get a redirect
+	noFollow := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	req := authReq("GET", ts.URL+"/api/v1/repos/test/vectors/"+hash, rawToken, nil)
+	req.Header.Set("X-WVC-Accept-Redirect", "1")
+	resp, err := noFollow.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+	assert.Equal(t, bucket.URL+"/"+hash+"?sig=ok", resp.Header.Get("Location"))
+	assert.Equal(t, "4", resp.Header.Get("X-WVC-Dimensions"))
+
+	// Others are still proxied
+	resp, err = noFollow.Do(authReq("GET", ts.URL+"/api/v1/repos/test/vectors/"+hash, rawToken, nil))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// The remote client follows the redirect without its credentials
+	client := remote.NewHTTPClient(ts.URL, "test", rawToken)
+	reader, dims, err := client.DownloadVector(context.Background(), hash)
+	require.NoError(t, err)
+	defer reader.Close()
+	got, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, 4, dims)
+	assert.Equal(t, data, got)
+
+	_, _, err = client.DownloadVector(context.Background(), hex.EncodeToString(make([]byte, 32)))
+	assert.Error(t, err)
+}
+
+func TestCommitBundle_ResolvesOffloadedPayloads(t *testing.T) {
+	ts, meta, blobs, token := newTestServer(t)
+	ctx := context.Background()
+
+	payload := []byte(`{"class":"Article","properties":{"body":"very long text"}}`)
+	h := sha256.Sum256(payload)
+	payloadHash := hex.EncodeToString(h[:])
+	require.NoError(t, blobs.Put(ctx, payloadHash, bytes.NewReader(payload), 0))
+
+	bundle := &remote.CommitBundle{
+		Commit: &models.Commit{ID: "c1", Message: "big object", Timestamp: time.Now()},
+		Operations: []*models.Operation{
+			{Type: models.OperationInsert, ClassName: "Article", ObjectID: "obj-1", ObjectDataHash: payloadHash},
+		},
+	}
+	require.NoError(t, meta.InsertCommitBundle(ctx, bundle))
+
+	// Default: payload resolved inline
+	resp, err := http.DefaultClient.Do(authReq("GET", ts.URL+"/api/v1/repos/test/commits/c1/bundle", token, nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var resolved remote.CommitBundle
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&resolved))
+	require.Len(t, resolved.Operations, 1)
+	assert.Equal(t, payload, resolved.Operations[0].ObjectData)
+	assert.Equal(t, payloadHash, resolved.Operations[0].ObjectDataHash)
+
+	// payload_refs=1: reference only
+	resp2, err := http.DefaultClient.Do(authReq("GET", ts.URL+"/api/v1/repos/test/commits/c1/bundle?payload_refs=1", token, nil))
+	require.NoError(t, err)
+	defer resp2.Body.Close()
+	var refs remote.CommitBundle
+	require.NoError(t, json.NewDecoder(resp2.Body).Decode(&refs))
+	require.Len(t, refs.Operations, 1)
+	assert.Empty(t, refs.Operations[0].ObjectData)
+	assert.Equal(t, payloadHash, refs.Operations[0].ObjectDataHash)
+}
+
+func TestCommitBundle_DeltaEncodedUpdates(t *testing.T) {
+	ts, _, _, token := newTestServer(t)
+
+	prev := []byte(`{"id":"obj-1","class":"Article","properties":{"body":"` + strings.Repeat("long text ", 50) + `","title":"Old"}}`)
+	next := []byte(`{"id":"obj-1","class":"Article","properties":{"body":"` + strings.Repeat("long text ", 50) + `","title":"New"}}`)
+	op := &models.Operation{Type: models.OperationUpdate, ClassName: "Article", ObjectID: "obj-1", ObjectData: next, PreviousData: prev}
+	commit := &models.Commit{Message: "edit title", Timestamp: time.Now().Truncate(time.Second), HashVersion: models.CommitHashV2}
+	var err error
+	commit.ID, err = commit.ComputeID([]*models.Operation{op})
+	require.NoError(t, err)
+
+	// Uploaded delta-encoded, verified against the expanded data
+	op.EncodeDelta()
+	require.NotEmpty(t, op.ObjectDelta)
+	data, err := json.Marshal(&remote.CommitBundle{Commit: commit, Operations: []*models.Operation{op}})
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(authReq("POST", ts.URL+"/api/v1/repos/test/commits", token, bytes.NewReader(data)))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	download := func(query string) *models.Operation {
+		resp, err := http.DefaultClient.Do(authReq("GET", ts.URL+"/api/v1/repos/test/commits/"+commit.ID+"/bundle"+query, token, nil))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var bundle remote.CommitBundle
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&bundle))
+		require.Len(t, bundle.Operations, 1)
+		return bundle.Operations[0]
+	}
+
+	// Clients without delta support get the full snapshot
+	full := download("")
+	assert.Equal(t, next, full.ObjectData)
+	assert.Empty(t, full.ObjectDelta)
+
+	encoded := download("?delta=1")
+	assert.Empty(t, encoded.ObjectData)
+	require.NoError(t, encoded.DecodeDelta())
+	assert.Equal(t, next, encoded.ObjectData)
+}
+
+func TestCommitBundle_PayloadFilter(t *testing.T) {
+	ts, meta, blobs, token := newTestServer(t)
+	ctx := context.Background()
+
+	payload := []byte(`{"class":"Article","properties":{"body":"very long text"}}`)
+	h := sha256.Sum256(payload)
+	payloadHash := hex.EncodeToString(h[:])
+	require.NoError(t, blobs.Put(ctx, payloadHash, bytes.NewReader(payload), 0))
+	inline := []byte(`{"class":"Article","properties":{"title":"short"}}`)
+
+	bundle := &remote.CommitBundle{
+		Commit: &models.Commit{ID: "c1", Message: "two objects", Timestamp: time.Now()},
+		Operations: []*models.Operation{
+			{Type: models.OperationInsert, ClassName: "Article", ObjectID: "obj-1", ObjectDataHash: payloadHash},
+			{Type: models.OperationInsert, ClassName: "Article", ObjectID: "obj-2", ObjectData: inline},
+		},
+	}
+	require.NoError(t, meta.InsertCommitBundle(ctx, bundle))
+
+	client := remote.NewHTTPClient(ts.URL, "test", token)
+	fb, err := client.DownloadFilteredCommitBundle(ctx, "c1", &remote.FilteredBundleRequest{OmitPayloads: true})
+	require.NoError(t, err)
+	require.NoError(t, fb.Verify(remote.Filter{NoPayloads: true}))
+	filtered := fb.Bundle
+	require.Len(t, filtered.Operations, 2)
+	for _, op := range filtered.Operations {
+		assert.True(t, op.PayloadOmitted)
+		assert.Empty(t, op.ObjectData)
+	}
+	assert.Equal(t, payloadHash, filtered.Operations[0].ObjectDataHash)
+
+	payloads, err := client.DownloadCommitPayloads(ctx, "c1")
+	require.NoError(t, err)
+	require.Len(t, payloads, 2)
+	assert.Equal(t, payload, payloads[0].ObjectData)
+	assert.Equal(t, 1, payloads[1].Seq)
+	assert.Equal(t, inline, payloads[1].ObjectData)
+
+	_, err = client.DownloadCommitPayloads(ctx, "missing")
+	assert.Error(t, err)
+}
+
+func TestCommitBundle_SchemaReferences(t *testing.T) {
+	ts, meta, _, token := newTestServer(t)
+	ctx := context.Background()
+
+	require.NoError(t, meta.InsertCommitBundle(ctx, &remote.CommitBundle{
+		Commit: &models.Commit{ID: "c1", Message: "first", Timestamp: time.Now()},
+		Schema: &remote.SchemaSnapshot{SchemaJSON: []byte(`{"classes":[]}`), SchemaHash: "h1"},
+	}))
+
+	get := func(query string) *remote.CommitBundle {
+		resp, err := http.DefaultClient.Do(authReq("GET", ts.URL+"/api/v1/repos/test/commits/c1/bundle"+query, token, nil))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var b remote.CommitBundle
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&b))
+		return &b
+	}
+	assert.Equal(t, `{"classes":[]}`, string(get("").Schema.SchemaJSON))
+	assert.Empty(t, get("?have_schema=h1").Schema.SchemaJSON)
+	assert.Equal(t, `{"classes":[]}`, string(get("?have_schema=other").Schema.SchemaJSON))
+
+	// The schema is also served on its own by hash
+	resp, err := http.DefaultClient.Do(authReq("GET", ts.URL+"/api/v1/repos/test/schemas/h1", token, nil))
+	require.NoError(t, err)
+	var snapshot remote.SchemaSnapshot
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&snapshot))
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `{"classes":[]}`, string(snapshot.SchemaJSON))
+
+	resp, err = http.DefaultClient.Do(authReq("GET", ts.URL+"/api/v1/repos/test/schemas/missing", token, nil))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// Uploading a bundle that references an unknown schema is rejected
+	now := time.Now()
+	commit := &models.Commit{ParentID: "c1", Message: "second", Timestamp: now}
+	commit.ID = models.GenerateCommitID(commit.Message, now, commit.ParentID, nil)
+	body, err := json.Marshal(&remote.CommitBundle{Commit: commit, Schema: &remote.SchemaSnapshot{SchemaHash: "missing"}})
+	require.NoError(t, err)
+	resp, err = http.DefaultClient.Do(authReq("POST", ts.URL+"/api/v1/repos/test/commits", token, bytes.NewReader(body)))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	var errResp map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&errResp))
+	assert.Equal(t, "unknown_schema", errResp["error"])
+}
+
+func TestStashes_ScopedToToken(t *testing.T) {
+	ts, meta, _, token := newTestServer(t)
+	ctx := context.Background()
+
+	require.NoError(t, meta.InsertCommitBundle(ctx, &remote.CommitBundle{
+		Commit: &models.Commit{ID: "c1", Message: "first", Timestamp: time.Now()},
+	}))
+
+	put := func(stash *remote.RemoteStash) *http.Response {
+		body, err := json.Marshal(stash)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(authReq("PUT", ts.URL+"/api/v1/repos/test/stashes/"+stash.ID, token, bytes.NewReader(body)))
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	stash := &remote.RemoteStash{
+		Message:   "WIP on main",
+		CommitID:  "c1",
+		CreatedAt: time.Now().UTC(),
+		Changes:   []*models.StashChange{{ClassName: "Article", ObjectID: "obj-1", ChangeType: "insert", ObjectData: []byte(`{}`)}},
+	}
+
+	// ID must match content
+	stash.ID = "bogus"
+	assert.Equal(t, http.StatusUnprocessableEntity, put(stash).StatusCode)
+
+	// Base commit must exist on the server
+	orphan := *stash
+	orphan.CommitID = "missing"
+	orphan.ID = orphan.ContentID()
+	assert.Equal(t, http.StatusUnprocessableEntity, put(&orphan).StatusCode)
+
+	stash.ID = stash.ContentID()
+	assert.Equal(t, http.StatusNoContent, put(stash).StatusCode)
+
+	resp, err := http.DefaultClient.Do(authReq("GET", ts.URL+"/api/v1/repos/test/stashes", token, nil))
+	require.NoError(t, err)
+	var list []*remote.RemoteStash
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	resp.Body.Close()
+	require.Len(t, list, 1)
+	assert.Equal(t, stash.ID, list[0].ID)
+	assert.Empty(t, list[0].Changes, "listings omit changes")
+
+	resp, err = http.DefaultClient.Do(authReq("GET", ts.URL+"/api/v1/repos/test/stashes/"+stash.ID, token, nil))
+	require.NoError(t, err)
+	var got remote.RemoteStash
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	resp.Body.Close()
+	require.Len(t, got.Changes, 1)
+	assert.Equal(t, stash.ID, got.ContentID())
+
+	// Stored under the token's ID, invisible to other identities
+	others, err := meta.ListStashes(ctx, "tok-2")
+	require.NoError(t, err)
+	assert.Empty(t, others)
+
+	resp, err = http.DefaultClient.Do(authReq("DELETE", ts.URL+"/api/v1/repos/test/stashes/"+stash.ID, token, nil))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, err = http.DefaultClient.Do(authReq("GET", ts.URL+"/api/v1/repos/test/stashes/"+stash.ID, token, nil))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestUserRefs(t *testing.T) {
+	ts, meta, _, token := newTestServer(t)
+	ctx := context.Background()
+
+	require.NoError(t, meta.InsertCommitBundle(ctx, &remote.CommitBundle{
+		Commit: &models.Commit{ID: "c1", Message: "first", Timestamp: time.Now()},
+	}))
+	require.NoError(t, meta.CreateBranch(ctx, "main", "c1"))
+
+	do := func(method, path string, body interface{}) *http.Response {
+		var r io.Reader
+		if body != nil {
+			data, err := json.Marshal(body)
+			require.NoError(t, err)
+			r = bytes.NewReader(data)
+		}
+		resp, err := http.DefaultClient.Do(authReq(method, ts.URL+"/api/v1/repos/test"+path, token, r))
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	resp := do("PUT", "/user-refs/wip/feature", &remote.BranchUpdateRequest{CommitID: "c1"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Stored under the token's namespace
+	ref, err := meta.GetBranch(ctx, "refs/users/tok-1/wip/feature")
+	require.NoError(t, err)
+	assert.Equal(t, "c1", ref.CommitID)
+
+	// Stale expected tip is rejected like a branch update
+	resp = do("PUT", "/user-refs/wip/feature", &remote.BranchUpdateRequest{CommitID: "c1", Expected: "other"})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	var refs []*models.Branch
+	require.NoError(t, json.NewDecoder(do("GET", "/user-refs", nil).Body).Decode(&refs))
+	require.Len(t, refs, 1)
+	assert.Equal(t, "wip/feature", refs[0].Name)
+
+	// Hidden from the branch list and repo info
+	var branches []*models.Branch
+	require.NoError(t, json.NewDecoder(do("GET", "/branches", nil).Body).Decode(&branches))
+	require.Len(t, branches, 1)
+	assert.Equal(t, "main", branches[0].Name)
+	var info remote.RepoInfo
+	require.NoError(t, json.NewDecoder(do("GET", "/info", nil).Body).Decode(&info))
+	assert.Equal(t, 1, info.BranchCount)
+
+	// The reserved namespace can't be written through the branch endpoints
+	resp = do("PUT", "/branches/refs%2Fusers%2Ftok-2%2Fx", &remote.BranchUpdateRequest{CommitID: "c1"})
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp = do("PUT", "/user-refs/../main", &remote.BranchUpdateRequest{CommitID: "c1"})
+	assert.NotEqual(t, http.StatusOK, resp.StatusCode)
+
+	resp = do("DELETE", "/user-refs/wip/feature", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = do("DELETE", "/user-refs/wip/feature", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestTags(t *testing.T) {
+	ts, meta, _, token := newTestServer(t)
+	ctx := context.Background()
+
+	for _, id := range []string{"c1", "c2"} {
+		require.NoError(t, meta.InsertCommitBundle(ctx, &remote.CommitBundle{
+			Commit: &models.Commit{ID: id, Timestamp: time.Now()},
+		}))
+	}
+
+	do := func(method, path string, body interface{}) *http.Response {
+		var r io.Reader
+		if body != nil {
+			data, err := json.Marshal(body)
+			require.NoError(t, err)
+			r = bytes.NewReader(data)
+		}
+		resp, err := http.DefaultClient.Do(authReq(method, ts.URL+"/api/v1/repos/test"+path, token, r))
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	resp := do("PUT", "/tags/release/v1", &remote.TagRequest{CommitID: "c1", Message: "first"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = do("PUT", "/tags/release/v1", &remote.TagRequest{CommitID: "c1"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "pushing a tag again is a no-op")
+
+	// Moving a tag needs force
+	resp = do("PUT", "/tags/release/v1", &remote.TagRequest{CommitID: "c2"})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	resp = do("PUT", "/tags/release/v1", &remote.TagRequest{CommitID: "c2", Force: true})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = do("PUT", "/tags/v2", &remote.TagRequest{CommitID: "missing"})
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	var tag models.Tag
+	require.NoError(t, json.NewDecoder(do("GET", "/tags/release/v1", nil).Body).Decode(&tag))
+	assert.Equal(t, "c2", tag.CommitID)
+
+	var tags []*models.Tag
+	require.NoError(t, json.NewDecoder(do("GET", "/tags", nil).Body).Decode(&tags))
+	require.Len(t, tags, 1)
+	assert.Equal(t, "release/v1", tags[0].Name)
+
+	resp = do("DELETE", "/tags/release/v1", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = do("GET", "/tags/release/v1", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestNotes(t *testing.T) {
+	ts, meta, _, token := newTestServer(t)
+	ctx := context.Background()
+
+	require.NoError(t, meta.InsertCommitBundle(ctx, &remote.CommitBundle{
+		Commit: &models.Commit{ID: "c1", Timestamp: time.Now()},
+	}))
+	client := remote.NewHTTPClient(ts.URL, "test", token)
+
+	note := &models.Note{CommitID: "c1", Message: "validated by QA", Author: "qa"}
+	require.NoError(t, client.PutNote(ctx, note, false))
+	require.NoError(t, client.PutNote(ctx, note, false), "pushing a note again is a no-op")
+
+	// Replacing a note needs force
+	assert.Error(t, client.PutNote(ctx, &models.Note{CommitID: "c1", Message: "rejected"}, false))
+	require.NoError(t, client.PutNote(ctx, &models.Note{CommitID: "c1", Message: "rejected"}, true))
+
+	assert.Error(t, client.PutNote(ctx, &models.Note{CommitID: "missing", Message: "x"}, false))
+	assert.Error(t, client.PutNote(ctx, &models.Note{CommitID: "c1"}, true))
+
+	notes, err := client.ListNotes(ctx)
+	require.NoError(t, err)
+	require.Len(t, notes, 1)
+	assert.Equal(t, "rejected", notes[0].Message)
+	assert.False(t, notes[0].UpdatedAt.IsZero())
+
+	require.NoError(t, client.DeleteNote(ctx, "c1"))
+	assert.Error(t, client.DeleteNote(ctx, "c1"))
+	notes, err = client.ListNotes(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, notes)
+}
+
+func TestDeployments(t *testing.T) {
+	ts, meta, _, token := newTestServer(t)
+	ctx := context.Background()
+
+	require.NoError(t, meta.InsertCommitBundle(ctx, &remote.CommitBundle{
+		Commit: &models.Commit{ID: "c1", Message: "first", Timestamp: time.Now()},
+	}))
+	require.NoError(t, meta.CreateBranch(ctx, "main", "c1"))
+
+	do := func(method, path string, body interface{}) *http.Response {
+		var r io.Reader
+		if body != nil {
+			data, err := json.Marshal(body)
+			require.NoError(t, err)
+			r = bytes.NewReader(data)
+		}
+		resp, err := http.DefaultClient.Do(authReq(method, ts.URL+"/api/v1/repos/test"+path, token, r))
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	resp := do("PUT", "/deployments/prod", &remote.BranchUpdateRequest{CommitID: "c1"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = do("PUT", "/deployments/prod", &remote.BranchUpdateRequest{CommitID: "c1"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "recording again replaces the deployment")
+
+	ref, err := meta.GetBranch(ctx, "refs/deployments/prod")
+	require.NoError(t, err)
+	assert.Equal(t, "c1", ref.CommitID)
+
+	// Unknown commits and environment names are rejected
+	resp = do("PUT", "/deployments/prod", &remote.BranchUpdateRequest{CommitID: "missing"})
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp = do("PUT", "/deployments/..", &remote.BranchUpdateRequest{CommitID: "c1"})
+	assert.NotEqual(t, http.StatusOK, resp.StatusCode)
+
+	var refs []*models.Branch
+	require.NoError(t, json.NewDecoder(do("GET", "/deployments", nil).Body).Decode(&refs))
+	require.Len(t, refs, 1)
+	assert.Equal(t, "prod", refs[0].Name)
+
+	// Hidden from the branch list
+	var branches []*models.Branch
+	require.NoError(t, json.NewDecoder(do("GET", "/branches", nil).Body).Decode(&branches))
+	require.Len(t, branches, 1)
+}
+
 func TestVectorsHave(t *testing.T) {
 	ts, _, blobs, token := newTestServer(t)
 	ctx := context.Background()
@@ -427,6 +1168,86 @@ func TestNegotiatePull_Fresh(t *testing.T) {
 	assert.Equal(t, []string{"c1"}, result.MissingCommits)
 }
 
+func TestNegotiatePull_ClassFilter(t *testing.T) {
+	ts, meta, _, token := newTestServer(t)
+	ctx := context.Background()
+
+	schema := []byte(`{"classes":[{"class":"Article"},{"class":"Author"}]}`)
+	bundle := &remote.CommitBundle{
+		Commit: &models.Commit{ID: "c1", Message: "initial", Timestamp: time.Now()},
+		Operations: []*models.Operation{
+			{Type: models.OperationInsert, ClassName: "Article", ObjectID: "a1", ObjectData: []byte(`{}`)},
+			{Type: models.OperationInsert, ClassName: "Author", ObjectID: "b1", ObjectData: []byte(`{}`)},
+		},
+		Schema: &remote.SchemaSnapshot{SchemaJSON: schema, SchemaHash: "s1"},
+	}
+	require.NoError(t, meta.InsertCommitBundle(ctx, bundle))
+	require.NoError(t, meta.CreateBranch(ctx, "main", "c1"))
+
+	client := remote.NewHTTPClient(ts.URL, "test", token)
+	_, err := client.NegotiatePull(ctx, "main", "", 0, []string{"Article", "Product"}, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Product")
+
+	result, err := client.NegotiatePull(ctx, "main", "", 0, []string{"Article"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c1"}, result.MissingCommits)
+
+	fb, err := client.DownloadFilteredCommitBundle(ctx, "c1", &remote.FilteredBundleRequest{Classes: []string{"Article"}, HaveSchema: "s1"})
+	require.NoError(t, err)
+	require.Len(t, fb.Bundle.Operations, 1)
+	assert.Equal(t, "Article", fb.Bundle.Operations[0].ClassName)
+	assert.Empty(t, fb.Bundle.Schema.SchemaJSON)
+	require.Len(t, fb.Manifest, 2)
+	assert.Equal(t, "Author", fb.Manifest[1].ClassName)
+	require.NoError(t, fb.Verify(remote.Filter{Classes: []string{"Article"}}))
+
+	// Dropping an operation the filter selected is detected
+	fb.Bundle.Operations = nil
+	assert.Error(t, fb.Verify(remote.Filter{Classes: []string{"Article"}}))
+}
+
+func TestCommitLog_Pagination(t *testing.T) {
+	ts, meta, _, token := newTestServer(t)
+	ctx := context.Background()
+
+	base := time.Now()
+	parent := ""
+	for i := 1; i <= 5; i++ {
+		id := fmt.Sprintf("c%d", i)
+		require.NoError(t, meta.InsertCommitBundle(ctx, &remote.CommitBundle{
+			Commit: &models.Commit{ID: id, ParentID: parent, Message: id, Timestamp: base.Add(time.Duration(i) * time.Minute)},
+		}))
+		parent = id
+	}
+	require.NoError(t, meta.CreateBranch(ctx, "main", "c5"))
+
+	client := remote.NewHTTPClient(ts.URL, "test", token)
+	var seen []string
+	before := ""
+	for {
+		page, err := client.GetCommitLog(ctx, "", before, 2)
+		require.NoError(t, err)
+		assert.Equal(t, "main", page.Branch)
+		for _, c := range page.Commits {
+			seen = append(seen, c.ID)
+		}
+		if page.Next == "" {
+			break
+		}
+		before = page.Next
+	}
+	assert.Equal(t, []string{"c5", "c4", "c3", "c2", "c1"}, seen)
+
+	_, err := client.GetCommitLog(ctx, "missing", "", 0)
+	assert.Error(t, err)
+
+	resp, err := http.DefaultClient.Do(authReq("GET", ts.URL+"/api/v1/repos/test/commits?limit=abc", token, nil))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
 // newAdminTestServer creates a test server with admin auth and a testRepoManager.
 // Returns the server, the repo manager, and the raw admin token.
 func newAdminTestServer(t *testing.T) (*httptest.Server, *testRepoManager, string) {
@@ -569,4 +1390,501 @@ func TestRepoInfo(t *testing.T) {
 	require.NoError(t, json.NewDecoder(resp.Body).Decode(&info))
 	assert.Equal(t, 1, info.BranchCount)
 	assert.Equal(t, 1, info.CommitCount)
+	assert.Equal(t, "main", info.DefaultBranch)
+	require.NotNil(t, info.Token)
+	assert.Equal(t, "tok-1", info.Token.ID)
+	assert.Equal(t, "rw", info.Token.Permission)
+	assert.Equal(t, []string{"*"}, info.Token.Repos)
+}
+
+func TestRepoInfo_BlobCounter(t *testing.T) {
+	ts, meta, blobs, token := newTestServer(t)
+	ctx := context.Background()
+
+	info := func() *remote.RepoInfo {
+		resp, err := http.DefaultClient.Do(authReq("GET", ts.URL+"/api/v1/repos/test/info", token, nil))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var info remote.RepoInfo
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&info))
+		return &info
+	}
+	upload := func(data []byte) {
+		h := sha256.Sum256(data)
+		req := authReq("POST", ts.URL+"/api/v1/repos/test/vectors/"+hex.EncodeToString(h[:]), token, bytes.NewReader(data))
+		req.Header.Set("X-WVC-Dimensions", "1")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	// A blob stored before the counter existed is found by a scan
+	data := []byte("old")
+	h := sha256.Sum256(data)
+	require.NoError(t, blobs.Put(ctx, hex.EncodeToString(h[:]), bytes.NewReader(data), 1))
+	assert.Equal(t, 1, info().TotalBlobs)
+	_, ok, err := meta.GetBlobCount(ctx)
+	require.NoError(t, err)
+	assert.False(t, ok, "info does not store the count")
+
+	// The first upload seeds the counter; later ones add to it
+	upload([]byte("new-1"))
+	upload([]byte("new-2"))
+	upload([]byte("new-2"))
+	count, ok, err := meta.GetBlobCount(ctx)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 3, count)
+	assert.Equal(t, 3, info().TotalBlobs)
+}
+
+func TestRepoQuota_Enforced(t *testing.T) {
+	ts, meta, _, token := newTestServer(t)
+	ctx := context.Background()
+	require.NoError(t, meta.PutQuota(ctx, &remote.RepoQuota{MaxBlobBytes: 10, MaxCommits: 1}))
+
+	upload := func(data []byte) (int, string) {
+		h := sha256.Sum256(data)
+		req := authReq("POST", ts.URL+"/api/v1/repos/test/vectors/"+hex.EncodeToString(h[:]), token, bytes.NewReader(data))
+		req.Header.Set("X-WVC-Dimensions", "1")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var body map[string]string
+		_ = json.NewDecoder(resp.Body).Decode(&body)
+		return resp.StatusCode, body["error"]
+	}
+	status, _ := upload([]byte("eight-by"))
+	assert.Equal(t, http.StatusCreated, status)
+	status, code := upload([]byte("more"))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, status)
+	assert.Equal(t, "quota_exceeded", code)
+	status, _ = upload([]byte("eight-by"))
+	assert.Equal(t, http.StatusCreated, status, "a blob already stored is not counted again")
+	used, ok, err := meta.GetBlobBytes(ctx)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(8), used)
+
+	commit := func(msg string) (int, string) {
+		ts0 := time.Now().Truncate(time.Second)
+		id := models.GenerateCommitID(msg, ts0, "", nil)
+		data, err := json.Marshal(&remote.CommitBundle{Commit: &models.Commit{ID: id, Message: msg, Timestamp: ts0}})
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(authReq("POST", ts.URL+"/api/v1/repos/test/commits", token, bytes.NewReader(data)))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var body map[string]string
+		_ = json.NewDecoder(resp.Body).Decode(&body)
+		return resp.StatusCode, body["error"]
+	}
+	status, _ = commit("first")
+	assert.Equal(t, http.StatusCreated, status)
+	status, code = commit("second")
+	assert.Equal(t, http.StatusUnprocessableEntity, status)
+	assert.Equal(t, "quota_exceeded", code)
+}
+
+func TestRepoInfo_Usage(t *testing.T) {
+	ts, meta, blobs, token := newTestServer(t)
+	ctx := context.Background()
+
+	require.NoError(t, meta.InsertCommitBundle(ctx, &remote.CommitBundle{
+		Commit: &models.Commit{ID: "c1", Timestamp: time.Now()},
+		Operations: []*models.Operation{
+			{Type: models.OperationInsert, ClassName: "Article", ObjectID: "a1", ObjectData: []byte(`{"title":"A"}`)},
+			{Type: models.OperationInsert, ClassName: "Article", ObjectID: "a2", ObjectData: []byte(`{"title":"B"}`)},
+			{Type: models.OperationInsert, ClassName: "Author", ObjectID: "b1"},
+		},
+	}))
+	data := []byte("vector")
+	h := sha256.Sum256(data)
+	require.NoError(t, blobs.Put(ctx, hex.EncodeToString(h[:]), bytes.NewReader(data), 1))
+
+	info := func(query string) *remote.RepoInfo {
+		resp, err := http.DefaultClient.Do(authReq("GET", ts.URL+"/api/v1/repos/test/info"+query, token, nil))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var info remote.RepoInfo
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&info))
+		return &info
+	}
+
+	// Usage and an unset quota are left out by default
+	plain := info("")
+	assert.Nil(t, plain.Usage)
+	assert.Nil(t, plain.Quota)
+
+	require.NoError(t, meta.PutQuota(ctx, &remote.RepoQuota{MaxCommits: 5}))
+	full := info("?usage=true")
+	require.NotNil(t, full.Quota)
+	assert.Equal(t, 5, full.Quota.MaxCommits)
+	require.NotNil(t, full.Usage)
+	assert.Equal(t, int64(len(data)), full.Usage.BlobBytes)
+	assert.Positive(t, full.Usage.MetaBytes)
+	require.Len(t, full.Usage.LargestClasses, 2)
+	assert.Equal(t, "Article", full.Usage.LargestClasses[0].Class)
+	assert.Equal(t, 2, full.Usage.LargestClasses[0].Operations)
+}
+
+func TestAdminQuota(t *testing.T) {
+	ts, _, adminToken := newAdminTestServer(t)
+
+	get := func() *remote.RepoQuota {
+		resp, err := http.DefaultClient.Do(adminReq("GET", ts.URL+"/admin/repos/test/quota", adminToken, nil))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var quota remote.RepoQuota
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&quota))
+		return &quota
+	}
+	assert.True(t, get().IsZero())
+
+	resp, err := http.DefaultClient.Do(adminReq("PUT", ts.URL+"/admin/repos/test/quota", adminToken, strings.NewReader(`{"max_blob_bytes":1048576,"max_commits":100}`)))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, &remote.RepoQuota{MaxBlobBytes: 1 << 20, MaxCommits: 100}, get())
+
+	resp, err = http.DefaultClient.Do(adminReq("PUT", ts.URL+"/admin/repos/test/quota", adminToken, strings.NewReader(`{"max_commits":-1}`)))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = http.DefaultClient.Do(adminReq("PUT", ts.URL+"/admin/repos/test/quota", "not-the-admin-token", strings.NewReader(`{}`)))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestDefaultBranchName(t *testing.T) {
+	assert.Equal(t, "", defaultBranchName(nil))
+	assert.Equal(t, "main", defaultBranchName([]*models.Branch{{Name: "dev"}, {Name: "main"}, {Name: "master"}}))
+	assert.Equal(t, "master", defaultBranchName([]*models.Branch{{Name: "dev"}, {Name: "master"}}))
+	assert.Equal(t, "alpha", defaultBranchName([]*models.Branch{{Name: "zeta"}, {Name: "alpha"}}))
+}
+
+func TestVectorsBloom(t *testing.T) {
+	ts, _, blobs, token := newTestServer(t)
+	ctx := context.Background()
+
+	data := []byte("bloom-vector")
+	h := sha256.Sum256(data)
+	hash := hex.EncodeToString(h[:])
+	require.NoError(t, blobs.Put(ctx, hash, bytes.NewReader(data), 3))
+
+	req := authReq("GET", ts.URL+"/api/v1/repos/test/vectors/bloom", token, nil)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var filter remote.BloomFilter
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&filter))
+	assert.True(t, filter.MayContain(hash))
+}
+
+func TestVectorsBloom_Cached(t *testing.T) {
+	ts, _, blobs, token := newTestServer(t)
+	ctx := context.Background()
+
+	bloom := func() *remote.BloomFilter {
+		resp, err := http.DefaultClient.Do(authReq("GET", ts.URL+"/api/v1/repos/test/vectors/bloom", token, nil))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var filter remote.BloomFilter
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&filter))
+		return &filter
+	}
+	blob := func(s string) ([]byte, string) {
+		h := sha256.Sum256([]byte(s))
+		return []byte(s), hex.EncodeToString(h[:])
+	}
+	bloom()
+
+	// Uploads extend the cached filter
+	data, uploaded := blob("uploaded")
+	req := authReq("POST", ts.URL+"/api/v1/repos/test/vectors/"+uploaded, token, bytes.NewReader(data))
+	req.Header.Set("X-WVC-Dimensions", "2")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	// A blob stored behind the server's back shows the filter is not rebuilt
+	data, direct := blob("direct")
+	require.NoError(t, blobs.Put(ctx, direct, bytes.NewReader(data), 2))
+
+	filter := bloom()
+	assert.True(t, filter.MayContain(uploaded))
+	assert.False(t, filter.MayContain(direct))
+}
+
+func TestVectorBloomCache(t *testing.T) {
+	c := newVectorBloomCache()
+	filter, gen := c.get("r")
+	assert.Nil(t, filter)
+	c.put("r", gen, []string{"a"})
+	filter, _ = c.get("r")
+	require.NotNil(t, filter)
+	assert.True(t, filter.MayContain("a"))
+
+	c.invalidate("r")
+	filter, gen = c.get("r")
+	assert.Nil(t, filter, "GC drops the filter")
+
+	// A rebuild that raced with an upload is not cached
+	c.add("r", "b")
+	c.put("r", gen, []string{"a"})
+	filter, _ = c.get("r")
+	assert.Nil(t, filter)
+
+	// A full filter is dropped rather than overfilled
+	_, gen = c.get("r")
+	c.put("r", gen, nil)
+	for i := 0; i <= 1024; i++ {
+		c.add("r", fmt.Sprint(i))
+	}
+	filter, _ = c.get("r")
+	assert.Nil(t, filter)
+}
+
+// newLimitedTokenServer creates a test server whose only token has the given
+// permission and limits.
+func newLimitedTokenServer(t *testing.T, permission string, limits TokenLimits) (*httptest.Server, metastore.MetaStore, string) {
+	t.Helper()
+
+	tmpDir := t.TempDir()
+	meta, err := metastore.NewBboltStore(filepath.Join(tmpDir, "meta.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { meta.Close() })
+
+	blobs, err := blobstore.NewFSStore(filepath.Join(tmpDir, "blobs"))
+	require.NoError(t, err)
+
+	rawToken := "limited-token"
+	tokenHash := HashToken(rawToken)
+	tokens := &testTokenStore{tokens: map[string]*TokenInfo{
+		tokenHash: {ID: "tok-1", TokenHash: tokenHash, Repos: []string{"*"}, Permission: permission, TokenLimits: limits},
+	}}
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	h, cleanup := Handler(&testRepoOpener{meta: meta, blobs: blobs}, tokens, DefaultServerConfig(), logger, nil, nil)
+	t.Cleanup(cleanup)
+	ts := httptest.NewServer(h)
+	t.Cleanup(ts.Close)
+
+	return ts, meta, rawToken
+}
+
+func TestTokenLimits_Expired(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	ts, _, token := newLimitedTokenServer(t, "rw", TokenLimits{ExpiresAt: &past})
+
+	resp, err := http.DefaultClient.Do(authReq("GET", ts.URL+"/api/v1/repos/test/branches", token, nil))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestTokenLimits_BranchPatterns(t *testing.T) {
+	ts, meta, token := newLimitedTokenServer(t, "rw", TokenLimits{Branches: []string{"release/*"}})
+	require.NoError(t, meta.InsertCommitBundle(context.Background(), &remote.CommitBundle{
+		Commit: &models.Commit{ID: "c1", Message: "first", Timestamp: time.Now()},
+	}))
+
+	put := func(branch string) int {
+		data, _ := json.Marshal(&remote.BranchUpdateRequest{CommitID: "c1"})
+		resp, err := http.DefaultClient.Do(authReq("PUT", ts.URL+"/api/v1/repos/test/branches/"+branch, token, bytes.NewReader(data)))
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	assert.Equal(t, http.StatusOK, put("release%2Fv1"))
+	assert.Equal(t, http.StatusForbidden, put("main"))
+
+	// Reads are not restricted by branch
+	resp, err := http.DefaultClient.Do(authReq("GET", ts.URL+"/api/v1/repos/test/branches", token, nil))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestTokenLimits_Scopes(t *testing.T) {
+	ts, meta, token := newLimitedTokenServer(t, "rw", TokenLimits{Scopes: []string{ScopePull, ScopePush}})
+	ctx := context.Background()
+	require.NoError(t, meta.InsertCommitBundle(ctx, &remote.CommitBundle{
+		Commit: &models.Commit{ID: "c1", Message: "first", Timestamp: time.Now()},
+	}))
+	require.NoError(t, meta.CreateBranch(ctx, "main", "c1"))
+
+	resp, err := http.DefaultClient.Do(authReq("DELETE", ts.URL+"/api/v1/repos/test/branches/main", token, nil))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "branch-delete was not granted")
+
+	resp, err = http.DefaultClient.Do(authReq("POST", ts.URL+"/api/v1/repos/test/gc", token, nil))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "gc is never granted by default")
+
+	ts, _, token = newLimitedTokenServer(t, "rw", TokenLimits{Scopes: []string{ScopeGC}})
+	resp, err = http.DefaultClient.Do(authReq("POST", ts.URL+"/api/v1/repos/test/gc", token, nil))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = http.DefaultClient.Do(authReq("GET", ts.URL+"/api/v1/repos/test/commits", token, nil))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "pull was not granted")
+}
+
+func TestTokenLimits_PushWithoutPull(t *testing.T) {
+	ts, meta, token := newLimitedTokenServer(t, "rw", TokenLimits{Scopes: []string{ScopePush}})
+	ctx := context.Background()
+	require.NoError(t, meta.InsertCommitBundle(ctx, &remote.CommitBundle{
+		Commit: &models.Commit{ID: "c1", Message: "first", Timestamp: time.Now()},
+	}))
+	require.NoError(t, meta.CreateBranch(ctx, "main", "c1"))
+
+	status := func(method, path string, body any) int {
+		var r io.Reader
+		if body != nil {
+			data, _ := json.Marshal(body)
+			r = bytes.NewReader(data)
+		}
+		resp, err := http.DefaultClient.Do(authReq(method, ts.URL+"/api/v1/repos/test"+path, token, r))
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	for _, path := range []string{"/branches", "/branches/main", "/tags", "/tags/v1", "/notes", "/deployments", "/user-refs", "/commits"} {
+		assert.Equal(t, http.StatusForbidden, status("GET", path, nil), "GET %s needs pull", path)
+	}
+
+	// A push still negotiates and checks which vectors the server has
+	assert.Equal(t, http.StatusOK, status("POST", "/negotiate/push", &remote.NegotiatePushRequest{Branch: "main", Commits: []string{"c1"}}))
+	assert.Equal(t, http.StatusOK, status("POST", "/vectors/have", &remote.VectorCheckRequest{Hashes: []string{}}))
+	assert.NotEqual(t, http.StatusForbidden, status("GET", "/vectors/bloom", nil))
+	assert.Equal(t, http.StatusOK, status("GET", "/info", nil))
+}
+
+func TestTokenLimits_Validate(t *testing.T) {
+	assert.NoError(t, TokenLimits{Scopes: []string{ScopePull}, Branches: []string{"release/*"}}.Validate("ro"))
+	assert.ErrorContains(t, TokenLimits{Scopes: []string{"admin"}}.Validate("rw"), "unknown scope")
+	assert.Error(t, TokenLimits{Scopes: []string{ScopePush}}.Validate("ro"))
+	assert.Error(t, TokenLimits{Branches: []string{"[bad"}}.Validate("rw"))
+}
+
+func TestAdminTokens_CreateWithLimits(t *testing.T) {
+	ts, _, adminToken := newAdminTestServer(t)
+
+	create := func(body map[string]any) *http.Response {
+		data, _ := json.Marshal(body)
+		resp, err := http.DefaultClient.Do(adminReq("POST", ts.URL+"/admin/tokens", adminToken, bytes.NewReader(data)))
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	resp := create(map[string]any{"repos": []string{"*"}, "permission": "rw", "scopes": []string{"everything"}})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = create(map[string]any{"repos": []string{"*"}, "permission": "rw", "expires_at": time.Now().Add(-time.Hour)})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	expires := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	resp = create(map[string]any{"repos": []string{"*"}, "permission": "rw", "expires_at": expires, "branches": []string{"release/*"}})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created struct {
+		ExpiresAt *time.Time `json:"expires_at"`
+		Branches  []string   `json:"branches"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	require.NotNil(t, created.ExpiresAt)
+	assert.True(t, expires.Equal(*created.ExpiresAt))
+	assert.Equal(t, []string{"release/*"}, created.Branches)
+}
+
+func TestAdminGC_Status(t *testing.T) {
+	ts, _, adminToken := newAdminTestServer(t)
+
+	status := func() map[string]any {
+		resp, err := http.DefaultClient.Do(adminReq("GET", ts.URL+"/admin/repos/test/gc/status", adminToken, nil))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		return body
+	}
+
+	body := status()
+	assert.Equal(t, false, body["scheduled"])
+	assert.Nil(t, body["last_run"])
+
+	resp, err := http.DefaultClient.Do(adminReq("POST", ts.URL+"/admin/repos/test/gc", adminToken, nil))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	lastRun, ok := status()["last_run"].(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, GCTriggerManual, lastRun["trigger"])
+	assert.NotNil(t, lastRun["result"])
+}
+
+func TestRepoAdmin_Scope(t *testing.T) {
+	tmpDir := t.TempDir()
+	meta, err := metastore.NewBboltStore(filepath.Join(tmpDir, "meta.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { meta.Close() })
+	blobs, err := blobstore.NewFSStore(filepath.Join(tmpDir, "blobs"))
+	require.NoError(t, err)
+
+	repoAdmin, pusher := "repo-admin-token", "push-token"
+	tokens := &testTokenStore{tokens: map[string]*TokenInfo{
+		HashToken(repoAdmin): {ID: "tok-1", TokenHash: HashToken(repoAdmin), Repos: []string{"test"}, Permission: "rw",
+			TokenLimits: TokenLimits{Scopes: []string{ScopeRepoAdmin}}},
+		HashToken(pusher): {ID: "tok-2", TokenHash: HashToken(pusher), Repos: []string{"*"}, Permission: "rw"},
+	}}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	cfg := DefaultServerConfig()
+	cfg.AdminToken = "admin-test-token-123"
+	h, cleanup := Handler(&testRepoOpener{meta: meta, blobs: blobs}, tokens, cfg, logger, nil, &testRepoManager{})
+	t.Cleanup(cleanup)
+	ts := httptest.NewServer(h)
+	t.Cleanup(ts.Close)
+
+	do := func(method, path, token string) int {
+		resp, err := http.DefaultClient.Do(adminReq(method, ts.URL+path, token, nil))
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// Repository administration of its own repo
+	assert.Equal(t, http.StatusOK, do("POST", "/admin/repos/test/gc", repoAdmin))
+	assert.Equal(t, http.StatusOK, do("GET", "/admin/repos/test/gc/status", repoAdmin))
+	assert.Equal(t, http.StatusNotImplemented, do("GET", "/admin/repos/test/mirrors", repoAdmin))
+	assert.Equal(t, http.StatusForbidden, do("POST", "/admin/repos/other/gc", repoAdmin))
+
+	// Token and repository lifecycle stay with the admin token
+	assert.Equal(t, http.StatusUnauthorized, do("GET", "/admin/tokens", repoAdmin))
+	assert.Equal(t, http.StatusUnauthorized, do("GET", "/admin/repos", repoAdmin))
+	assert.Equal(t, http.StatusUnauthorized, do("DELETE", "/admin/repos/test", repoAdmin))
+
+	// Other tokens need the scope; the admin token needs nothing else
+	assert.Equal(t, http.StatusForbidden, do("POST", "/admin/repos/test/gc", pusher))
+	assert.Equal(t, http.StatusUnauthorized, do("POST", "/admin/repos/test/gc", "wrong"))
+	assert.Equal(t, http.StatusOK, do("POST", "/admin/repos/other/gc", cfg.AdminToken))
+
+	// The scope is never granted by default and needs rw
+	assert.False(t, (&TokenInfo{Permission: "rw"}).HasScope(ScopeRepoAdmin))
+	assert.Error(t, TokenLimits{Scopes: []string{ScopeRepoAdmin}}.Validate("ro"))
 }
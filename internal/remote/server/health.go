@@ -0,0 +1,137 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// readyCheckInterval bounds how often readiness probes reach the backends;
+// probes in between get the last result.
+const readyCheckInterval = 5 * time.Second
+
+// errDraining is reported by readiness probes once shutdown has begun.
+var errDraining = errors.New("draining for shutdown")
+
+// Health tracks the server's lifecycle for orchestrator probes:
+//
+//   - /healthz (liveness) succeeds while the process serves HTTP.
+//   - /startupz succeeds once every check has passed at least once.
+//   - /readyz succeeds while every check passes and the server is not draining.
+//
+// Checks typically verify that the storage backends accept writes.
+type Health struct {
+	mu      sync.Mutex
+	checks  []healthCheck
+	lastRun time.Time
+	lastErr error
+	started atomic.Bool
+
+	draining   atomic.Bool
+	drainHooks []func()
+}
+
+type healthCheck struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+// NewHealth creates a Health with no checks.
+func NewHealth() *Health {
+	return &Health{}
+}
+
+// AddCheck registers a readiness check.
+func (h *Health) AddCheck(name string, check func(ctx context.Context) error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks = append(h.checks, healthCheck{name: name, check: check})
+	h.lastRun = time.Time{}
+}
+
+// OnDrain registers a hook that runs when draining begins, such as ending
+// long-lived streams so clients reconnect to another replica.
+func (h *Health) OnDrain(hook func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.drainHooks = append(h.drainHooks, hook)
+}
+
+// Drain marks the server as shutting down, failing readiness so load
+// balancers stop routing to it, and runs the drain hooks once.
+func (h *Health) Drain() {
+	if h.draining.Swap(true) {
+		return
+	}
+	h.mu.Lock()
+	hooks := h.drainHooks
+	h.mu.Unlock()
+	for _, hook := range hooks {
+		hook()
+	}
+}
+
+// Ready runs the checks, or returns their result from the last
+// readyCheckInterval, and reports the first failure.
+func (h *Health) Ready(ctx context.Context) error {
+	if h.draining.Load() {
+		return errDraining
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.lastRun.IsZero() && time.Since(h.lastRun) < readyCheckInterval {
+		return h.lastErr
+	}
+
+	var failures []string
+	for _, c := range h.checks {
+		if err := c.check(ctx); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", c.name, err))
+		}
+	}
+	h.lastRun = time.Now()
+	h.lastErr = nil
+	if len(failures) > 0 {
+		h.lastErr = errors.New(strings.Join(failures, "; "))
+	} else {
+		h.started.Store(true)
+	}
+	return h.lastErr
+}
+
+// Handler serves the probe endpoints, for a separate health port.
+func (h *Health) Handler() http.Handler {
+	mux := http.NewServeMux()
+	h.register(mux)
+	return mux
+}
+
+func (h *Health) register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /healthz", handleHealthz)
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, r *http.Request) {
+		if err := h.Ready(r.Context()); err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("not ready: " + err.Error()))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("GET /startupz", func(w http.ResponseWriter, r *http.Request) {
+		if !h.started.Load() {
+			if err := h.Ready(r.Context()); err != nil && !h.started.Load() {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				w.Write([]byte("starting: " + err.Error()))
+				return
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("ok"))
+	})
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func probe(t *testing.T, h http.Handler, path string) (int, string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+	return rec.Code, string(body)
+}
+
+func TestHealth_ReadyCachesChecks(t *testing.T) {
+	h := NewHealth()
+	calls := 0
+	h.AddCheck("store", func(context.Context) error {
+		calls++
+		return nil
+	})
+
+	require.NoError(t, h.Ready(context.Background()))
+	require.NoError(t, h.Ready(context.Background()))
+	assert.Equal(t, 1, calls, "probes within the interval reuse the last result")
+}
+
+func TestHealth_StartupIsSticky(t *testing.T) {
+	h := NewHealth()
+	var failure error = errors.New("disk full")
+	h.AddCheck("blob store", func(context.Context) error { return failure })
+	handler := h.Handler()
+
+	code, body := probe(t, handler, "/startupz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Contains(t, body, "blob store: disk full")
+	code, _ = probe(t, handler, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+
+	failure = nil
+	h.lastRun = h.lastRun.Add(-readyCheckInterval)
+	code, _ = probe(t, handler, "/startupz")
+	assert.Equal(t, http.StatusOK, code)
+
+	// A later failure fails readiness but not startup
+	failure = errors.New("disk full")
+	h.lastRun = h.lastRun.Add(-readyCheckInterval)
+	code, _ = probe(t, handler, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	code, _ = probe(t, handler, "/startupz")
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = probe(t, handler, "/healthz")
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func TestHealth_DrainFailsReadiness(t *testing.T) {
+	h := NewHealth()
+	hooks := 0
+	h.OnDrain(func() { hooks++ })
+	handler := h.Handler()
+
+	code, _ := probe(t, handler, "/readyz")
+	require.Equal(t, http.StatusOK, code)
+
+	h.Drain()
+	h.Drain()
+	assert.Equal(t, 1, hooks)
+	code, body := probe(t, handler, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Contains(t, body, "draining")
+	code, _ = probe(t, handler, "/healthz")
+	assert.Equal(t, http.StatusOK, code, "liveness holds while draining")
+}
@@ -5,9 +5,12 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
 	"log/slog"
 	"net"
 	"net/http"
+	"path"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -22,6 +25,22 @@ const (
 	contextKeyTokenID    contextKey = "token_id"
 	contextKeyRepos      contextKey = "repos"
 	contextKeyPermission contextKey = "permission"
+	contextKeyToken      contextKey = "token"
+)
+
+// Token scopes. A token without explicit scopes has those of its permission:
+// "rw" grants push, pull, and branch-delete; "ro" grants pull. The gc,
+// repo-admin, and reviewer scopes must always be granted explicitly.
+// repo-admin opens the repository endpoints under /admin/repos/{repo}/
+// (garbage collection and mirrors) and the repository's webhooks and audit
+// log for the token's repos. reviewer approves proposals.
+const (
+	ScopePush         = "push"
+	ScopePull         = "pull"
+	ScopeBranchDelete = "branch-delete"
+	ScopeGC           = "gc"
+	ScopeRepoAdmin    = "repo-admin"
+	ScopeReviewer     = "reviewer"
 )
 
 // TokenInfo holds the metadata for an authenticated token.
@@ -31,6 +50,69 @@ type TokenInfo struct {
 	Desc       string   `json:"description"`
 	Repos      []string `json:"repos"`
 	Permission string   `json:"permission"` // "ro" or "rw"
+	TokenLimits
+}
+
+// TokenLimits narrow what a token may do beyond its repos and permission.
+// Zero values impose no limit.
+type TokenLimits struct {
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Branches  []string   `json:"branches,omitempty"` // path.Match patterns of the branches the token may update or delete
+	Scopes    []string   `json:"scopes,omitempty"`
+}
+
+// Validate checks that the scopes are known and allowed for permission and
+// that the branch patterns are well formed.
+func (l TokenLimits) Validate(permission string) error {
+	for _, scope := range l.Scopes {
+		switch scope {
+		case ScopePull:
+		case ScopePush, ScopeBranchDelete, ScopeGC, ScopeRepoAdmin, ScopeReviewer:
+			if permission != "rw" {
+				return fmt.Errorf("scope '%s' requires permission 'rw'", scope)
+			}
+		default:
+			return fmt.Errorf("unknown scope '%s' (want push, pull, branch-delete, gc, repo-admin, or reviewer)", scope)
+		}
+	}
+	for _, pattern := range l.Branches {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid branch pattern '%s': %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Expired reports whether the token has expired at now.
+func (t *TokenInfo) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// HasScope reports whether the token grants scope.
+func (t *TokenInfo) HasScope(scope string) bool {
+	if len(t.Scopes) > 0 {
+		return slices.Contains(t.Scopes, scope)
+	}
+	switch scope {
+	case ScopePull:
+		return true
+	case ScopePush, ScopeBranchDelete:
+		return t.Permission == "rw"
+	}
+	return false
+}
+
+// AllowsBranch reports whether the token may update or delete the branch.
+func (t *TokenInfo) AllowsBranch(branch string) bool {
+	if len(t.Branches) == 0 {
+		return true
+	}
+	for _, pattern := range t.Branches {
+		if ok, _ := path.Match(pattern, branch); ok {
+			return true
+		}
+	}
+	return false
 }
 
 // TokenStore is the interface for managing authentication tokens.
@@ -39,7 +121,7 @@ type TokenStore interface {
 	UpdateLastUsed(id string) error
 	ListTokens() ([]*TokenInfo, error)
 	DeleteToken(id string) error
-	CreateToken(desc string, repos []string, permission string) (rawToken string, info *TokenInfo, err error)
+	CreateToken(desc string, repos []string, permission string, limits TokenLimits) (rawToken string, info *TokenInfo, err error)
 }
 
 // requestIDMiddleware generates a UUID per request and adds it to the context.
@@ -117,6 +199,13 @@ func authMiddleware(tokens TokenStore, logger *slog.Logger) func(http.Handler) h
 				})
 				return
 			}
+			if info.Expired(time.Now()) {
+				writeJSON(w, http.StatusUnauthorized, map[string]string{
+					"error":   "auth_failed",
+					"message": "token expired",
+				})
+				return
+			}
 
 			// Async update last_used_at
 			select {
@@ -135,6 +224,7 @@ func authMiddleware(tokens TokenStore, logger *slog.Logger) func(http.Handler) h
 			ctx = context.WithValue(ctx, contextKeyTokenID, info.ID)
 			ctx = context.WithValue(ctx, contextKeyRepos, info.Repos)
 			ctx = context.WithValue(ctx, contextKeyPermission, info.Permission)
+			ctx = context.WithValue(ctx, contextKeyToken, info)
 
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
@@ -189,6 +279,39 @@ func requireWrite(next http.Handler) http.Handler {
 	})
 }
 
+// requireScope checks that the token grants at least one of scopes.
+func requireScope(scopes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			info, _ := r.Context().Value(contextKeyToken).(*TokenInfo)
+			if info == nil || !slices.ContainsFunc(scopes, info.HasScope) {
+				writeJSON(w, http.StatusForbidden, map[string]string{
+					"error":   "forbidden",
+					"message": "token does not have the '" + strings.Join(scopes, "' or '") + "' scope",
+				})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requireBranch checks that the token may write the branch named in the path.
+func requireBranch(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		branch := r.PathValue("name")
+		info, _ := r.Context().Value(contextKeyToken).(*TokenInfo)
+		if info == nil || !info.AllowsBranch(branch) {
+			writeJSON(w, http.StatusForbidden, map[string]string{
+				"error":   "forbidden",
+				"message": "token may not write branch '" + branch + "'",
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 // rateLimitMiddleware implements a per-token sliding window rate limiter.
 type rateLimiter struct {
 	mu      sync.Mutex
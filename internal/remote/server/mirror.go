@@ -0,0 +1,722 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/remote"
+	"github.com/kilupskalvis/wvc/internal/remote/blobstore"
+	"github.com/kilupskalvis/wvc/internal/remote/metastore"
+)
+
+// ErrMirrorNotFound is returned by MirrorStore.DeleteMirror for an unknown mirror.
+var ErrMirrorNotFound = errors.New("mirror not found")
+
+// mirrorBatchSize bounds the commits and vector hashes asked about in one
+// negotiation request, well under the server's limit of 10000.
+const mirrorBatchSize = 1000
+
+// Mirror is a repository on another wvc server that receives every commit,
+// vector, branch, and tag pushed to a repository of this one.
+type Mirror struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	// Repo is the repository on the mirror server; empty means the same name
+	Repo string `json:"repo,omitempty"`
+	// Token authenticates to the mirror server; it needs pull, push, and
+	// branch-delete scopes. It is never returned by the admin API.
+	Token string `json:"token,omitempty"`
+}
+
+// MirrorStore persists the mirrors configured for each repository.
+type MirrorStore interface {
+	ListMirrors(repo string) ([]*Mirror, error)
+	PutMirror(repo string, m *Mirror) error
+	DeleteMirror(repo, name string) error
+}
+
+// MirrorStatus describes the replication of a repository to one mirror.
+// Lag is the time since the oldest push the mirror has not received yet;
+// OutOfSync lists the branches and tags whose tip on this server differs
+// from the tip last replicated.
+type MirrorStatus struct {
+	LastAttempt  time.Time  `json:"last_attempt,omitzero"`
+	LastSuccess  time.Time  `json:"last_success,omitzero"`
+	Error        string     `json:"error,omitempty"`
+	CommitsSent  int        `json:"commits_sent"`
+	VectorsSent  int        `json:"vectors_sent"`
+	PendingSince *time.Time `json:"pending_since,omitempty"`
+	LagSeconds   float64    `json:"lag_seconds"`
+	OutOfSync    []string   `json:"out_of_sync,omitempty"`
+}
+
+// MirrorInfo is one entry of the admin mirror list: the mirror without its
+// token, and its replication status.
+type MirrorInfo struct {
+	Mirror
+	Status *MirrorStatus `json:"status"`
+}
+
+// mirrorState is the replication state of one mirror of one repository.
+type mirrorState struct {
+	run sync.Mutex // held while replicating
+
+	// Guarded by replicator.mu
+	queued       bool
+	status       MirrorStatus
+	lastPush     time.Time
+	replicated   map[string]string // ref -> tip last replicated
+	replicatedOK bool
+}
+
+// replicator forwards the commits, vectors, and ref updates of each
+// repository to its mirrors: in the background after every push, and to every
+// mirror at a fixed interval to catch up after failures and restarts.
+type replicator struct {
+	repos    RepoOpener
+	manager  RepoManager
+	mirrors  MirrorStore
+	interval time.Duration
+	logger   *slog.Logger
+	now      func() time.Time
+	connect  func(m *Mirror, repo string) remote.RemoteClient
+
+	mu     sync.Mutex
+	states map[string]*mirrorState // keyed by repo and mirror name
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newReplicator(repos RepoOpener, manager RepoManager, mirrors MirrorStore, interval time.Duration, logger *slog.Logger) *replicator {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &replicator{
+		repos:    repos,
+		manager:  manager,
+		mirrors:  mirrors,
+		interval: interval,
+		logger:   logger,
+		now:      time.Now,
+		connect: func(m *Mirror, repo string) remote.RemoteClient {
+			return remote.NewHTTPClient(m.URL, m.targetRepo(repo), m.Token)
+		},
+		states: make(map[string]*mirrorState),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// targetRepo returns the repository name on the mirror server.
+func (m *Mirror) targetRepo(repo string) string {
+	if m.Repo != "" {
+		return m.Repo
+	}
+	return repo
+}
+
+// validate checks a mirror configuration before it is stored.
+func (m *Mirror) validate() error {
+	if !validRepoName(m.Name) {
+		return fmt.Errorf("invalid mirror name '%s'", m.Name)
+	}
+	u, err := url.Parse(m.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid mirror url '%s': want http(s)://host[:port]", m.URL)
+	}
+	if m.Repo != "" && !validRepoName(m.Repo) {
+		return fmt.Errorf("invalid mirror repo '%s'", m.Repo)
+	}
+	return nil
+}
+
+// start replicates every repository to its mirrors each interval until
+// stop. Pushes trigger replication whether or not an interval is set.
+func (rp *replicator) start() {
+	if rp.mirrors == nil || rp.interval <= 0 {
+		return
+	}
+	rp.wg.Add(1)
+	go func() {
+		defer rp.wg.Done()
+		ticker := time.NewTicker(rp.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-rp.ctx.Done():
+				return
+			case <-ticker.C:
+				rp.syncAll(rp.ctx)
+			}
+		}
+	}()
+	rp.logger.Info("scheduled mirroring enabled", "interval", rp.interval)
+}
+
+// stop cancels replication in progress and waits for it to end.
+func (rp *replicator) stop() {
+	rp.cancel()
+	rp.wg.Wait()
+}
+
+// state returns the replication state of a mirror, creating it on first use.
+// The caller holds rp.mu.
+func (rp *replicator) state(repo, name string) *mirrorState {
+	key := repo + "\x00" + name
+	s, ok := rp.states[key]
+	if !ok {
+		s = &mirrorState{}
+		rp.states[key] = s
+	}
+	return s
+}
+
+// notify schedules replication of a repository to its mirrors after a ref
+// changed. Runs in the background; a push arriving while a mirror is being
+// replicated queues one more run rather than one per push.
+func (rp *replicator) notify(repo string) {
+	if rp == nil || rp.mirrors == nil {
+		return
+	}
+	mirrors, err := rp.mirrors.ListMirrors(repo)
+	if err != nil {
+		rp.logger.Error("mirror: list mirrors", "repo", repo, "error", err)
+		return
+	}
+	now := rp.now()
+	for _, m := range mirrors {
+		rp.mu.Lock()
+		s := rp.state(repo, m.Name)
+		s.lastPush = now
+		if s.status.PendingSince == nil {
+			pending := now
+			s.status.PendingSince = &pending
+		}
+		queued := s.queued
+		s.queued = true
+		rp.mu.Unlock()
+		if queued {
+			continue
+		}
+
+		rp.wg.Add(1)
+		go func() {
+			defer rp.wg.Done()
+			if _, err := rp.sync(rp.ctx, repo, m); err != nil && rp.ctx.Err() == nil {
+				rp.logger.Warn("mirror: replication failed", "repo", repo, "mirror", m.Name, "error", err)
+			}
+		}()
+	}
+}
+
+// syncAll replicates every repository to each of its mirrors in turn.
+func (rp *replicator) syncAll(ctx context.Context) {
+	names, err := rp.manager.List()
+	if err != nil {
+		rp.logger.Error("scheduled mirroring: list repos", "error", err)
+		return
+	}
+	for _, repo := range names {
+		mirrors, err := rp.mirrors.ListMirrors(repo)
+		if err != nil {
+			rp.logger.Error("scheduled mirroring: list mirrors", "repo", repo, "error", err)
+			continue
+		}
+		for _, m := range mirrors {
+			if ctx.Err() != nil {
+				return
+			}
+			if _, err := rp.sync(ctx, repo, m); err != nil {
+				rp.logger.Warn("mirror: replication failed", "repo", repo, "mirror", m.Name, "error", err)
+			}
+		}
+	}
+}
+
+// sync replicates a repository to one mirror and records the outcome. Runs
+// for the same mirror are serialized.
+func (rp *replicator) sync(ctx context.Context, repo string, m *Mirror) (*MirrorStatus, error) {
+	rp.mu.Lock()
+	s := rp.state(repo, m.Name)
+	rp.mu.Unlock()
+
+	s.run.Lock()
+	defer s.run.Unlock()
+
+	rp.mu.Lock()
+	s.queued = false
+	rp.mu.Unlock()
+
+	started := rp.now()
+	refs, sent, err := rp.replicate(ctx, repo, m)
+
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	s.status.LastAttempt = started
+	s.status.CommitsSent = sent.commits
+	s.status.VectorsSent = sent.vectors
+	s.status.Error = ""
+	if err != nil {
+		s.status.Error = err.Error()
+	} else {
+		s.status.LastSuccess = started
+		s.replicated = refs
+		s.replicatedOK = true
+		// Pushes that arrived during the run may not have been replicated
+		if s.lastPush.After(started) {
+			pending := s.lastPush
+			s.status.PendingSince = &pending
+		} else {
+			s.status.PendingSince = nil
+		}
+	}
+	status := s.status
+	return &status, err
+}
+
+// status reports the replication status of a mirror, comparing the refs of
+// the repository with those last replicated.
+func (rp *replicator) status(ctx context.Context, repo, name string, meta metastore.MetaStore) (*MirrorStatus, error) {
+	refs, err := repoRefs(ctx, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	rp.mu.Lock()
+	s := rp.state(repo, name)
+	status := s.status
+	var outOfSync []string
+	for ref, tip := range refs {
+		if !s.replicatedOK || s.replicated[ref] != tip {
+			outOfSync = append(outOfSync, ref)
+		}
+	}
+	for ref := range s.replicated {
+		if _, ok := refs[ref]; !ok {
+			outOfSync = append(outOfSync, ref)
+		}
+	}
+	rp.mu.Unlock()
+
+	sort.Strings(outOfSync)
+	status.OutOfSync = outOfSync
+	if status.PendingSince != nil {
+		status.LagSeconds = rp.now().Sub(*status.PendingSince).Seconds()
+	}
+	return &status, nil
+}
+
+// forget drops the state of a removed mirror.
+func (rp *replicator) forget(repo, name string) {
+	rp.mu.Lock()
+	delete(rp.states, repo+"\x00"+name)
+	rp.mu.Unlock()
+}
+
+// mirrorTransfer counts what a replication run sent.
+type mirrorTransfer struct {
+	commits int
+	vectors int
+}
+
+// repoRefs returns the public branches and the tags of a repository, keyed
+// by name, tags prefixed with "tags/".
+func repoRefs(ctx context.Context, meta metastore.MetaStore) (map[string]string, error) {
+	branches, err := meta.ListBranches(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list branches: %w", err)
+	}
+	tags, err := meta.ListTags(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list tags: %w", err)
+	}
+	refs := make(map[string]string, len(branches)+len(tags))
+	for _, b := range publicBranches(branches) {
+		refs[b.Name] = b.CommitID
+	}
+	for _, t := range tags {
+		refs["tags/"+t.Name] = t.CommitID
+	}
+	return refs, nil
+}
+
+// replicate makes the mirror's branches and tags match the repository's:
+// missing commits are sent oldest first with the vectors they reference,
+// then refs are moved, created, and deleted. Branches on the mirror follow
+// the source even when they diverged, as a mirror accepts no pushes of its
+// own. Returns the refs replicated.
+func (rp *replicator) replicate(ctx context.Context, repo string, m *Mirror) (map[string]string, mirrorTransfer, error) {
+	var sent mirrorTransfer
+	meta, blobs, err := rp.repos.Open(repo)
+	if err != nil {
+		return nil, sent, fmt.Errorf("open repo: %w", err)
+	}
+	refs, err := repoRefs(ctx, meta)
+	if err != nil {
+		return nil, sent, err
+	}
+	client := rp.connect(m, repo)
+
+	branches, err := meta.ListBranches(ctx)
+	if err != nil {
+		return nil, sent, fmt.Errorf("list branches: %w", err)
+	}
+	tags, err := meta.ListTags(ctx)
+	if err != nil {
+		return nil, sent, fmt.Errorf("list tags: %w", err)
+	}
+	remoteBranches, err := client.ListBranches(ctx)
+	if err != nil {
+		return nil, sent, err
+	}
+	remoteTags, err := client.ListTags(ctx)
+	if err != nil {
+		return nil, sent, err
+	}
+	remoteTips := make(map[string]string, len(remoteBranches))
+	for _, b := range remoteBranches {
+		remoteTips[b.Name] = b.CommitID
+	}
+	remoteTagTips := make(map[string]string, len(remoteTags))
+	for _, t := range remoteTags {
+		remoteTagTips[t.Name] = t.CommitID
+	}
+
+	// Commits the mirror is known to have, to skip asking about them again
+	have := make(map[string]bool)
+	send := func(branch, tip string) error {
+		n, v, err := rp.sendHistory(ctx, meta, blobs, client, branch, tip, have)
+		sent.commits += n
+		sent.vectors += v
+		return err
+	}
+
+	for _, b := range publicBranches(branches) {
+		if remoteTips[b.Name] == b.CommitID {
+			continue
+		}
+		if err := send(b.Name, b.CommitID); err != nil {
+			return nil, sent, fmt.Errorf("branch %s: %w", b.Name, err)
+		}
+		if err := client.UpdateBranch(ctx, b.Name, b.CommitID, remoteTips[b.Name]); err != nil {
+			return nil, sent, err
+		}
+	}
+	for _, t := range tags {
+		if remoteTagTips[t.Name] == t.CommitID {
+			continue
+		}
+		if err := send("", t.CommitID); err != nil {
+			return nil, sent, fmt.Errorf("tag %s: %w", t.Name, err)
+		}
+		if err := client.PutTag(ctx, t, true); err != nil {
+			return nil, sent, err
+		}
+	}
+
+	for _, b := range remoteBranches {
+		if _, ok := refs[b.Name]; !ok && !isReservedRef(b.Name) {
+			if err := client.DeleteBranch(ctx, b.Name); err != nil {
+				return nil, sent, err
+			}
+		}
+	}
+	for _, t := range remoteTags {
+		if _, ok := refs["tags/"+t.Name]; !ok {
+			if err := client.DeleteTag(ctx, t.Name); err != nil {
+				return nil, sent, err
+			}
+		}
+	}
+	return refs, sent, nil
+}
+
+// sendHistory uploads the commits reachable from tip that the mirror lacks,
+// parents before children, with the vectors they reference. Returns the
+// number of commits and vectors sent.
+func (rp *replicator) sendHistory(ctx context.Context, meta metastore.MetaStore, blobs blobstore.BlobStore, client remote.RemoteClient, branch, tip string, have map[string]bool) (int, int, error) {
+	ancestors, err := meta.GetAncestors(ctx, tip)
+	if err != nil {
+		return 0, 0, fmt.Errorf("get ancestors: %w", err)
+	}
+	var unknown []string
+	for id := range ancestors {
+		if !have[id] {
+			unknown = append(unknown, id)
+		}
+	}
+	sort.Strings(unknown)
+
+	// Push negotiation needs a branch name; any name works for the
+	// commit check, and a tag's commits are not tied to a branch
+	negotiateBranch := branch
+	if negotiateBranch == "" {
+		negotiateBranch = "main"
+	}
+	missing := make(map[string]bool)
+	for start := 0; start < len(unknown); start += mirrorBatchSize {
+		batch := unknown[start:min(start+mirrorBatchSize, len(unknown))]
+		resp, err := client.NegotiatePush(ctx, negotiateBranch, batch)
+		if err != nil {
+			return 0, 0, err
+		}
+		for _, id := range resp.MissingCommits {
+			missing[id] = true
+		}
+		for _, id := range batch {
+			if !missing[id] {
+				have[id] = true
+			}
+		}
+	}
+	if len(missing) == 0 {
+		return 0, 0, nil
+	}
+
+	order, err := parentsFirst(ctx, meta, tip, missing)
+	if err != nil {
+		return 0, 0, err
+	}
+	vectors := 0
+	for _, id := range order {
+		bundle, err := meta.GetCommitBundle(ctx, id)
+		if err != nil {
+			return 0, vectors, fmt.Errorf("get commit bundle %s: %w", id, err)
+		}
+		if err := resolveBundlePayloads(ctx, blobs, bundle); err != nil {
+			return 0, vectors, err
+		}
+		n, err := sendVectors(ctx, blobs, client, bundle)
+		vectors += n
+		if err != nil {
+			return 0, vectors, err
+		}
+		if err := client.UploadCommitBundle(ctx, bundle); err != nil {
+			return 0, vectors, fmt.Errorf("upload commit %s: %w", id, err)
+		}
+		have[id] = true
+	}
+	return len(order), vectors, nil
+}
+
+// parentsFirst orders the missing commits reachable from tip so that every
+// commit follows its parents.
+func parentsFirst(ctx context.Context, meta metastore.MetaStore, tip string, missing map[string]bool) ([]string, error) {
+	var order []string
+	visited := make(map[string]bool)
+	type frame struct {
+		id       string
+		expanded bool
+	}
+	stack := []frame{{id: tip}}
+	for len(stack) > 0 {
+		f := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if f.expanded {
+			order = append(order, f.id)
+			continue
+		}
+		if visited[f.id] || !missing[f.id] {
+			continue
+		}
+		visited[f.id] = true
+		commit, err := meta.GetCommit(ctx, f.id)
+		if err != nil {
+			return nil, fmt.Errorf("get commit %s: %w", f.id, err)
+		}
+		stack = append(stack, frame{id: f.id, expanded: true})
+		for _, parent := range []string{commit.MergeParentID, commit.ParentID} {
+			if parent != "" {
+				stack = append(stack, frame{id: parent})
+			}
+		}
+	}
+	return order, nil
+}
+
+// sendVectors uploads the vector blobs referenced by a bundle that the mirror
+// lacks. Returns the number uploaded.
+func sendVectors(ctx context.Context, blobs blobstore.BlobStore, client remote.RemoteClient, bundle *remote.CommitBundle) (int, error) {
+	seen := make(map[string]bool)
+	var hashes []string
+	for _, op := range bundle.Operations {
+		for _, h := range op.VectorHashes() {
+			if !seen[h] {
+				seen[h] = true
+				hashes = append(hashes, h)
+			}
+		}
+	}
+
+	sent := 0
+	for start := 0; start < len(hashes); start += mirrorBatchSize {
+		check, err := client.CheckVectors(ctx, hashes[start:min(start+mirrorBatchSize, len(hashes))])
+		if err != nil {
+			return sent, err
+		}
+		for _, h := range check.Missing {
+			if err := sendVector(ctx, blobs, client, h); err != nil {
+				return sent, err
+			}
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+func sendVector(ctx context.Context, blobs blobstore.BlobStore, client remote.RemoteClient, hash string) error {
+	rc, dims, err := blobs.Get(ctx, hash)
+	if err != nil {
+		return fmt.Errorf("get vector %s: %w", hash, err)
+	}
+	defer rc.Close()
+	if err := client.UploadVector(ctx, hash, rc, dims); err != nil {
+		return fmt.Errorf("upload vector %s: %w", hash, err)
+	}
+	return nil
+}
+
+// --- Admin Handlers ---
+
+// registerMirrorAdmin adds the mirror admin endpoints through handle, which
+// applies the repository admin auth.
+func registerMirrorAdmin(handle func(pattern string, h http.HandlerFunc), repos RepoOpener, rp *replicator, logger *slog.Logger) {
+	if rp.mirrors == nil {
+		unavailable := func(w http.ResponseWriter, _ *http.Request) {
+			writeJSON(w, http.StatusNotImplemented, map[string]string{"error": "not_implemented", "message": "mirroring is not configured on this server"})
+		}
+		handle("/admin/repos/{repo}/mirrors", unavailable)
+		handle("/admin/repos/{repo}/mirrors/", unavailable)
+		return
+	}
+	handle("GET /admin/repos/{repo}/mirrors", makeAdminListMirrorsHandler(repos, rp))
+	handle("PUT /admin/repos/{repo}/mirrors/{name}", makeAdminPutMirrorHandler(repos, rp, logger))
+	handle("DELETE /admin/repos/{repo}/mirrors/{name}", makeAdminDeleteMirrorHandler(rp, logger))
+	handle("POST /admin/repos/{repo}/mirrors/{name}/sync", makeAdminSyncMirrorHandler(rp))
+}
+
+// findMirror returns the mirror of a repo with the given name, or nil.
+func findMirror(rp *replicator, repo, name string) (*Mirror, error) {
+	mirrors, err := rp.mirrors.ListMirrors(repo)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range mirrors {
+		if m.Name == name {
+			return m, nil
+		}
+	}
+	return nil, nil
+}
+
+// makeAdminListMirrorsHandler lists a repo's mirrors with their replication status.
+func makeAdminListMirrorsHandler(repos RepoOpener, rp *replicator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		repoName := r.PathValue("repo")
+		meta, _, err := repos.Open(repoName)
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not_found", "message": fmt.Sprintf("repository '%s' not found", repoName)})
+			return
+		}
+		mirrors, err := rp.mirrors.ListMirrors(repoName)
+		if err != nil {
+			internalError(w, "list mirrors", err)
+			return
+		}
+
+		infos := make([]*MirrorInfo, 0, len(mirrors))
+		for _, m := range mirrors {
+			status, err := rp.status(r.Context(), repoName, m.Name, meta)
+			if err != nil {
+				internalError(w, "mirror status", err)
+				return
+			}
+			info := &MirrorInfo{Mirror: *m, Status: status}
+			info.Token = ""
+			infos = append(infos, info)
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"mirrors": infos})
+	}
+}
+
+// makeAdminPutMirrorHandler creates or replaces a mirror and replicates to it.
+func makeAdminPutMirrorHandler(repos RepoOpener, rp *replicator, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		repoName := r.PathValue("repo")
+		if _, _, err := repos.Open(repoName); err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not_found", "message": fmt.Sprintf("repository '%s' not found", repoName)})
+			return
+		}
+
+		var m Mirror
+		if err := readJSON(w, r, 1<<20, &m); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request", "message": err.Error()})
+			return
+		}
+		m.Name = r.PathValue("name")
+		if err := m.validate(); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request", "message": err.Error()})
+			return
+		}
+		if err := rp.mirrors.PutMirror(repoName, &m); err != nil {
+			internalError(w, "put mirror", err)
+			return
+		}
+
+		logger.Info("mirror configured", "repo", repoName, "mirror", m.Name, "url", m.URL)
+		rp.forget(repoName, m.Name)
+		rp.notify(repoName)
+		resp := m
+		resp.Token = ""
+		writeJSON(w, http.StatusOK, &resp)
+	}
+}
+
+// makeAdminDeleteMirrorHandler removes a mirror. Data already replicated stays on it.
+func makeAdminDeleteMirrorHandler(rp *replicator, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		repoName, name := r.PathValue("repo"), r.PathValue("name")
+		err := rp.mirrors.DeleteMirror(repoName, name)
+		if errors.Is(err, ErrMirrorNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not_found", "message": fmt.Sprintf("mirror '%s' not found", name)})
+			return
+		}
+		if err != nil {
+			internalError(w, "delete mirror", err)
+			return
+		}
+		rp.forget(repoName, name)
+		logger.Info("mirror removed", "repo", repoName, "mirror", name)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// makeAdminSyncMirrorHandler replicates a repo to one mirror and reports the outcome.
+func makeAdminSyncMirrorHandler(rp *replicator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		repoName, name := r.PathValue("repo"), r.PathValue("name")
+		m, err := findMirror(rp, repoName, name)
+		if err != nil {
+			internalError(w, "list mirrors", err)
+			return
+		}
+		if m == nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not_found", "message": fmt.Sprintf("mirror '%s' not found", name)})
+			return
+		}
+
+		status, err := rp.sync(r.Context(), repoName, m)
+		if err != nil {
+			writeJSON(w, http.StatusBadGateway, map[string]any{"error": "mirror_failed", "message": err.Error(), "detail": status})
+			return
+		}
+		writeJSON(w, http.StatusOK, status)
+	}
+}
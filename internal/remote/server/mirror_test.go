@@ -0,0 +1,216 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/remote"
+	"github.com/kilupskalvis/wvc/internal/remote/blobstore"
+	"github.com/kilupskalvis/wvc/internal/remote/metastore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testMirrorStore implements MirrorStore for tests.
+type testMirrorStore struct {
+	mu      sync.Mutex
+	mirrors map[string][]*Mirror
+}
+
+func (s *testMirrorStore) ListMirrors(repo string) ([]*Mirror, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Mirror(nil), s.mirrors[repo]...), nil
+}
+
+func (s *testMirrorStore) PutMirror(repo string, m *Mirror) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, existing := range s.mirrors[repo] {
+		if existing.Name == m.Name {
+			s.mirrors[repo][i] = m
+			return nil
+		}
+	}
+	s.mirrors[repo] = append(s.mirrors[repo], m)
+	return nil
+}
+
+func (s *testMirrorStore) DeleteMirror(repo, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, m := range s.mirrors[repo] {
+		if m.Name == name {
+			s.mirrors[repo] = append(s.mirrors[repo][:i], s.mirrors[repo][i+1:]...)
+			return nil
+		}
+	}
+	return ErrMirrorNotFound
+}
+
+// insertTestCommit stores a commit with one insert, referencing vector, on top of parent.
+func insertTestCommit(t *testing.T, meta metastore.MetaStore, parent, message, vector string) string {
+	t.Helper()
+	ts := time.Now().Truncate(time.Second)
+	ops := []*models.Operation{{Type: models.OperationInsert, ClassName: "Article", ObjectID: message, VectorHash: vector}}
+	id := models.GenerateCommitID(message, ts, parent, ops)
+	require.NoError(t, meta.InsertCommitBundle(context.Background(), &remote.CommitBundle{
+		Commit:     &models.Commit{ID: id, ParentID: parent, Message: message, Timestamp: ts},
+		Operations: ops,
+	}))
+	return id
+}
+
+func TestMirror_ReplicatesRefsCommitsAndVectors(t *testing.T) {
+	ctx := context.Background()
+	target, targetMeta, targetBlobs, targetToken := newTestServer(t)
+
+	// The source server holds two commits on main, a tag, and a vector
+	tmpDir := t.TempDir()
+	meta, err := metastore.NewBboltStore(filepath.Join(tmpDir, "meta.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { meta.Close() })
+	blobs, err := blobstore.NewFSStore(filepath.Join(tmpDir, "blobs"))
+	require.NoError(t, err)
+
+	vector := []byte("vector data")
+	vectorHash := hashTestBytes(vector)
+	require.NoError(t, blobs.Put(ctx, vectorHash, bytes.NewReader(vector), 4))
+	first := insertTestCommit(t, meta, "", "first", vectorHash)
+	second := insertTestCommit(t, meta, first, "second", "")
+	require.NoError(t, meta.CreateBranch(ctx, "main", second))
+	require.NoError(t, meta.PutTag(ctx, &models.Tag{Name: "v1", CommitID: first}, false))
+
+	cfg := DefaultServerConfig()
+	cfg.AdminToken = "admin-token"
+	cfg.Mirrors = &testMirrorStore{mirrors: map[string][]*Mirror{}}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	h, cleanup := Handler(&testRepoOpener{meta: meta, blobs: blobs}, &testTokenStore{tokens: map[string]*TokenInfo{}},
+		cfg, logger, nil, &testRepoManager{repos: []string{"test"}})
+	t.Cleanup(cleanup)
+	source := httptest.NewServer(h)
+	t.Cleanup(source.Close)
+
+	admin := func(method, path string, body any) *http.Response {
+		var r *bytes.Reader
+		if body != nil {
+			data, err := json.Marshal(body)
+			require.NoError(t, err)
+			r = bytes.NewReader(data)
+		} else {
+			r = bytes.NewReader(nil)
+		}
+		resp, err := http.DefaultClient.Do(adminReq(method, source.URL+path, "admin-token", r))
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+	listMirrors := func() []*MirrorInfo {
+		resp := admin("GET", "/admin/repos/test/mirrors", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var body struct {
+			Mirrors []*MirrorInfo `json:"mirrors"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		return body.Mirrors
+	}
+
+	resp := admin("PUT", "/admin/repos/test/mirrors/backup", map[string]string{"url": "ftp://example.com"})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp = admin("PUT", "/admin/repos/test/mirrors/backup", &Mirror{URL: target.URL, Token: targetToken})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = admin("POST", "/admin/repos/test/mirrors/backup/sync", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	branch, err := targetMeta.GetBranch(ctx, "main")
+	require.NoError(t, err)
+	assert.Equal(t, second, branch.CommitID)
+	tag, err := targetMeta.GetTag(ctx, "v1")
+	require.NoError(t, err)
+	assert.Equal(t, first, tag.CommitID)
+	has, err := targetBlobs.Has(ctx, vectorHash)
+	require.NoError(t, err)
+	assert.True(t, has)
+
+	mirrors := listMirrors()
+	require.Len(t, mirrors, 1)
+	assert.Equal(t, "backup", mirrors[0].Name)
+	assert.Empty(t, mirrors[0].Token, "the token is never returned")
+	assert.Empty(t, mirrors[0].Status.Error)
+	assert.Empty(t, mirrors[0].Status.OutOfSync)
+	assert.Nil(t, mirrors[0].Status.PendingSince)
+	assert.False(t, mirrors[0].Status.LastSuccess.IsZero())
+
+	// Moving main and deleting the tag puts the mirror behind until the next sync
+	third := insertTestCommit(t, meta, second, "third", "")
+	require.NoError(t, meta.UpdateBranchCAS(ctx, "main", third, second))
+	require.NoError(t, meta.DeleteTag(ctx, "v1"))
+	assert.Equal(t, []string{"main", "tags/v1"}, listMirrors()[0].Status.OutOfSync)
+
+	resp = admin("POST", "/admin/repos/test/mirrors/backup/sync", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var status MirrorStatus
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&status))
+	assert.Equal(t, 1, status.CommitsSent)
+	branch, err = targetMeta.GetBranch(ctx, "main")
+	require.NoError(t, err)
+	assert.Equal(t, third, branch.CommitID)
+	_, err = targetMeta.GetTag(ctx, "v1")
+	assert.ErrorIs(t, err, metastore.ErrNotFound)
+
+	resp = admin("DELETE", "/admin/repos/test/mirrors/backup", nil)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	resp = admin("POST", "/admin/repos/test/mirrors/backup/sync", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestMirror_PushTriggersReplication(t *testing.T) {
+	ctx := context.Background()
+	target, targetMeta, _, targetToken := newTestServer(t)
+
+	tmpDir := t.TempDir()
+	meta, err := metastore.NewBboltStore(filepath.Join(tmpDir, "meta.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { meta.Close() })
+	blobs, err := blobstore.NewFSStore(filepath.Join(tmpDir, "blobs"))
+	require.NoError(t, err)
+
+	rawToken := "source-token"
+	tokens := &testTokenStore{tokens: map[string]*TokenInfo{
+		HashToken(rawToken): {ID: "tok-1", TokenHash: HashToken(rawToken), Repos: []string{"*"}, Permission: "rw"},
+	}}
+	cfg := DefaultServerConfig()
+	cfg.Mirrors = &testMirrorStore{mirrors: map[string][]*Mirror{
+		"test": {{Name: "backup", URL: target.URL, Token: targetToken}},
+	}}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	h, cleanup := Handler(&testRepoOpener{meta: meta, blobs: blobs}, tokens, cfg, logger, nil, nil)
+	t.Cleanup(cleanup)
+	source := httptest.NewServer(h)
+	t.Cleanup(source.Close)
+
+	commit := insertTestCommit(t, meta, "", "first", "")
+	body := strings.NewReader(fmt.Sprintf(`{"commit_id":%q}`, commit))
+	resp, err := http.DefaultClient.Do(authReq("PUT", source.URL+"/api/v1/repos/test/branches/main", rawToken, body))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assert.Eventually(t, func() bool {
+		branch, err := targetMeta.GetBranch(ctx, "main")
+		return err == nil && branch.CommitID == commit
+	}, 5*time.Second, 20*time.Millisecond)
+}
@@ -0,0 +1,219 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/remote"
+	"github.com/kilupskalvis/wvc/internal/remote/metastore"
+)
+
+// policyTimeout bounds one policy evaluation.
+const policyTimeout = 10 * time.Second
+
+// maxPolicyCommits bounds the pushed commits described to the policy.
+const maxPolicyCommits = 1000
+
+// PolicyInput describes a branch update to the push policy. Commits are the
+// commits reachable from After but not from Before, newest first, and
+// Diffstat counts their operations per class; Truncated is set when there
+// were more than maxPolicyCommits of them and only the newest are listed.
+// Forced is set when After does not descend from Before. ClassesAdded and
+// ClassesRemoved compare the schemas recorded at Before and After.
+type PolicyInput struct {
+	Repo           string           `json:"repo"`
+	Branch         string           `json:"branch"`
+	Before         string           `json:"before,omitempty"`
+	After          string           `json:"after"`
+	TokenID        string           `json:"token_id,omitempty"`
+	Forced         bool             `json:"forced"`
+	Commits        []*models.Commit `json:"commits"`
+	Truncated      bool             `json:"truncated,omitempty"`
+	Diffstat       *remote.Diffstat `json:"diffstat"`
+	ClassesAdded   []string         `json:"classes_added"`
+	ClassesRemoved []string         `json:"classes_removed"`
+}
+
+// PolicyDecision is the push policy's answer. A denial's Reason is shown to
+// the client.
+type PolicyDecision struct {
+	Allow  bool   `json:"allow"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// policyRequest and policyResponse follow the OPA data API, so a policy
+// package can be queried directly at /v1/data/<package>.
+type policyRequest struct {
+	Input *PolicyInput `json:"input"`
+}
+
+type policyResponse struct {
+	Result *PolicyDecision `json:"result"`
+}
+
+var policyClient = &http.Client{Timeout: policyTimeout}
+
+// checkPushPolicy asks cfg.PolicyURL whether the branch update req may be
+// applied, writing an error response and returning false when it may not
+// or the policy cannot be reached. Without a policy URL every update passes.
+func checkPushPolicy(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, cfg *ServerConfig, branch string, req *remote.BranchUpdateRequest) bool {
+	if cfg.PolicyURL == "" {
+		return true
+	}
+	tokenID, _ := r.Context().Value(contextKeyTokenID).(string)
+	input := &PolicyInput{
+		Repo:    r.PathValue("repo"),
+		Branch:  branch,
+		Before:  req.Expected,
+		After:   req.CommitID,
+		TokenID: tokenID,
+	}
+	if err := describePush(r.Context(), meta, input); err != nil {
+		if errors.Is(err, metastore.ErrNotFound) {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": "validation_failed", "message": "commit not found"})
+			return false
+		}
+		internalError(w, "describe push for policy", err)
+		return false
+	}
+
+	decision, err := evaluatePolicy(r.Context(), cfg.PolicyURL, cfg.PolicySecret, input)
+	if err != nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{
+			"error":   "policy_unavailable",
+			"message": fmt.Sprintf("push policy could not be evaluated: %v", err),
+		})
+		return false
+	}
+	if !decision.Allow {
+		message := fmt.Sprintf("push to '%s' rejected by policy", branch)
+		if decision.Reason != "" {
+			message += ": " + decision.Reason
+		}
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "policy_rejected", "message": message})
+		return false
+	}
+	return true
+}
+
+// describePush fills in the pushed commits, their diffstat, and the schema
+// changes of a policy input whose Before and After are set.
+func describePush(ctx context.Context, meta metastore.MetaStore, input *PolicyInput) error {
+	tip, err := meta.GetCommit(ctx, input.After)
+	if err != nil {
+		return err
+	}
+	known := map[string]bool{}
+	if input.Before != "" {
+		if known, err = meta.GetAncestors(ctx, input.Before); err != nil {
+			return fmt.Errorf("get ancestors of %s: %w", input.Before, err)
+		}
+		ancestors, err := meta.GetAncestors(ctx, input.After)
+		if err != nil {
+			return fmt.Errorf("get ancestors of %s: %w", input.After, err)
+		}
+		input.Forced = !ancestors[input.Before]
+	}
+
+	input.Commits = []*models.Commit{}
+	if !known[tip.ID] {
+		input.Commits, input.Truncated, err = collectCommits(ctx, meta, tip, known, maxPolicyCommits)
+		if err != nil {
+			return err
+		}
+	}
+	if input.Diffstat, err = diffstat(ctx, meta, input.Commits); err != nil {
+		return err
+	}
+
+	before, err := schemaClasses(ctx, meta, input.Before)
+	if err != nil {
+		return err
+	}
+	after, err := schemaClasses(ctx, meta, input.After)
+	if err != nil {
+		return err
+	}
+	input.ClassesAdded, input.ClassesRemoved = []string{}, []string{}
+	for class := range after {
+		if !before[class] {
+			input.ClassesAdded = append(input.ClassesAdded, class)
+		}
+	}
+	for class := range before {
+		if !after[class] {
+			input.ClassesRemoved = append(input.ClassesRemoved, class)
+		}
+	}
+	slices.Sort(input.ClassesAdded)
+	slices.Sort(input.ClassesRemoved)
+	return nil
+}
+
+// schemaClasses returns the names of the classes in the schema recorded at a
+// commit; none for an empty commitID or a commit without a schema.
+func schemaClasses(ctx context.Context, meta metastore.MetaStore, commitID string) (map[string]bool, error) {
+	classes := map[string]bool{}
+	if commitID == "" {
+		return classes, nil
+	}
+	bundle, err := meta.GetCommitBundle(ctx, commitID)
+	if err != nil {
+		return nil, fmt.Errorf("get commit %s: %w", commitID, err)
+	}
+	if bundle.Schema == nil || len(bundle.Schema.SchemaJSON) == 0 {
+		return classes, nil
+	}
+	var schema models.WeaviateSchema
+	if err := json.Unmarshal(bundle.Schema.SchemaJSON, &schema); err != nil {
+		return nil, fmt.Errorf("unmarshal schema of %s: %w", commitID, err)
+	}
+	for _, c := range schema.Classes {
+		classes[c.Class] = true
+	}
+	return classes, nil
+}
+
+// evaluatePolicy posts input to the policy at url, signed with secret when
+// it is set, and returns its decision. A response without a result, as OPA
+// gives for an undefined rule, is an error.
+func evaluatePolicy(ctx context.Context, url, secret string, input *PolicyInput) (*PolicyDecision, error) {
+	data, err := json.Marshal(&policyRequest{Input: input})
+	if err != nil {
+		return nil, fmt.Errorf("marshal policy input: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "wvc-server/1.0")
+	if secret != "" {
+		req.Header.Set("X-WVC-Signature-256", signWebhook(secret, data))
+	}
+
+	resp, err := policyClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	var out policyResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode policy response: %w", err)
+	}
+	if out.Result == nil {
+		return nil, errors.New("policy returned no decision")
+	}
+	return out.Result, nil
+}
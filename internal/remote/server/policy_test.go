@@ -0,0 +1,124 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/remote"
+	"github.com/kilupskalvis/wvc/internal/remote/blobstore"
+	"github.com/kilupskalvis/wvc/internal/remote/metastore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPushPolicy(t *testing.T) {
+	// The policy forbids removing classes from main
+	var inputs []*PolicyInput
+	policy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.True(t, VerifyWebhookSignature("policy-secret", body, r.Header.Get("X-WVC-Signature-256")))
+		var req policyRequest
+		require.NoError(t, json.Unmarshal(body, &req))
+		inputs = append(inputs, req.Input)
+		decision := &PolicyDecision{Allow: true}
+		if req.Input.Branch == "main" && len(req.Input.ClassesRemoved) > 0 {
+			decision = &PolicyDecision{Reason: "no schema deletions on main"}
+		}
+		if req.Input.Branch == "broken" {
+			w.Write([]byte(`{}`))
+			return
+		}
+		json.NewEncoder(w).Encode(&policyResponse{Result: decision})
+	}))
+	t.Cleanup(policy.Close)
+
+	tmpDir := t.TempDir()
+	meta, err := metastore.NewBboltStore(filepath.Join(tmpDir, "meta.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { meta.Close() })
+	blobs, err := blobstore.NewFSStore(filepath.Join(tmpDir, "blobs"))
+	require.NoError(t, err)
+	rawToken := "test-token-123"
+	tokens := &testTokenStore{tokens: map[string]*TokenInfo{
+		HashToken(rawToken): {ID: "tok-1", TokenHash: HashToken(rawToken), Repos: []string{"*"}, Permission: "rw"},
+	}}
+	cfg := DefaultServerConfig()
+	cfg.PolicyURL = policy.URL
+	cfg.PolicySecret = "policy-secret"
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	h, cleanup := Handler(&testRepoOpener{meta: meta, blobs: blobs}, tokens, cfg, logger, nil, nil)
+	t.Cleanup(cleanup)
+	ts := httptest.NewServer(h)
+	t.Cleanup(ts.Close)
+
+	// c1 has Article and Author; c2 adds objects; c3 drops Author
+	ctx := context.Background()
+	schema := func(hash string, classes ...string) *remote.SchemaSnapshot {
+		s := &models.WeaviateSchema{}
+		for _, c := range classes {
+			s.Classes = append(s.Classes, &models.WeaviateClass{Class: c})
+		}
+		data, _ := json.Marshal(s)
+		return &remote.SchemaSnapshot{SchemaJSON: data, SchemaHash: hash}
+	}
+	for _, b := range []*remote.CommitBundle{
+		{Commit: &models.Commit{ID: "c1", Message: "first", Timestamp: time.Now()}, Schema: schema("s1", "Article", "Author")},
+		{Commit: &models.Commit{ID: "c2", ParentID: "c1", Message: "second", Timestamp: time.Now()}, Schema: schema("s1", "Article", "Author"),
+			Operations: []*models.Operation{
+				{Type: models.OperationInsert, ClassName: "Article", ObjectID: "a1"},
+				{Type: models.OperationInsert, ClassName: "Article", ObjectID: "a2"},
+			}},
+		{Commit: &models.Commit{ID: "c3", ParentID: "c2", Message: "drop authors", Timestamp: time.Now()}, Schema: schema("s2", "Article")},
+	} {
+		require.NoError(t, meta.InsertCommitBundle(ctx, b))
+	}
+	require.NoError(t, meta.CreateBranch(ctx, "main", "c1"))
+	client := remote.NewHTTPClient(ts.URL, "test", rawToken)
+
+	require.NoError(t, client.UpdateBranch(ctx, "main", "c2", "c1"))
+	require.Len(t, inputs, 1)
+	in := inputs[0]
+	assert.Equal(t, "test", in.Repo)
+	assert.Equal(t, "main", in.Branch)
+	assert.Equal(t, "c1", in.Before)
+	assert.Equal(t, "tok-1", in.TokenID)
+	assert.False(t, in.Forced)
+	require.Len(t, in.Commits, 1)
+	assert.Equal(t, "c2", in.Commits[0].ID)
+	assert.Equal(t, 2, in.Diffstat.Classes["Article"].Inserted)
+	assert.Empty(t, in.ClassesRemoved)
+
+	err = client.UpdateBranch(ctx, "main", "c3", "c2")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no schema deletions on main")
+	assert.Equal(t, []string{"Author"}, inputs[1].ClassesRemoved)
+	branch, err := meta.GetBranch(ctx, "main")
+	require.NoError(t, err)
+	assert.Equal(t, "c2", branch.CommitID)
+
+	// Other branches may drop classes, and rewinding is reported as forced
+	require.NoError(t, client.UpdateBranch(ctx, "feature", "c3", ""))
+	assert.Equal(t, []string{"Article"}, inputs[2].ClassesAdded)
+	require.NoError(t, client.UpdateBranch(ctx, "feature", "c1", "c3"))
+	assert.True(t, inputs[3].Forced)
+	assert.Empty(t, inputs[3].Commits)
+	assert.Equal(t, []string{"Author"}, inputs[3].ClassesAdded)
+
+	// A policy without a decision fails closed
+	err = client.UpdateBranch(ctx, "broken", "c1", "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no decision")
+
+	// Personal refs skip the policy
+	require.NoError(t, client.UpdateUserRef(ctx, "wip", "c3", ""))
+	assert.Len(t, inputs, 5)
+}
@@ -0,0 +1,286 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/remote"
+	"github.com/kilupskalvis/wvc/internal/remote/blobstore"
+	"github.com/kilupskalvis/wvc/internal/remote/metastore"
+)
+
+// --- Proposal Handlers ---
+
+// Proposals are changed under the repository's write lock, so reading,
+// changing, and storing the whole list cannot race.
+
+func handleListProposals(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, _ blobstore.BlobStore, cfg *ServerConfig) {
+	proposals, err := meta.GetProposals(r.Context())
+	if err != nil {
+		internalError(w, "get proposals", err)
+		return
+	}
+	for _, p := range proposals {
+		p.RequiredApprovals = cfg.RequiredApprovals
+	}
+	writeJSON(w, http.StatusOK, proposals)
+}
+
+func handleGetProposal(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, _ blobstore.BlobStore, cfg *ServerConfig) {
+	_, p, ok := findProposal(w, r, meta)
+	if !ok {
+		return
+	}
+	p.RequiredApprovals = cfg.RequiredApprovals
+	writeJSON(w, http.StatusOK, p)
+}
+
+// handleCreateProposal opens a proposal to merge one existing branch into
+// another.
+func handleCreateProposal(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, _ blobstore.BlobStore, cfg *ServerConfig) {
+	var req remote.ProposalRequest
+	if err := readJSON(w, r, cfg.MaxRequestBody, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request", "message": err.Error()})
+		return
+	}
+	if req.Source == "" || req.Target == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request", "message": "source and target are required"})
+		return
+	}
+	if req.Source == req.Target {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request", "message": "source and target must differ"})
+		return
+	}
+	for _, name := range []string{req.Source, req.Target} {
+		if isReservedRef(name) {
+			writeReservedRef(w, name)
+			return
+		}
+		if _, err := meta.GetBranch(r.Context(), name); err != nil {
+			if errors.Is(err, metastore.ErrNotFound) {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": "not_found", "message": fmt.Sprintf("branch '%s' not found", name)})
+				return
+			}
+			internalError(w, "get branch", err)
+			return
+		}
+	}
+
+	proposals, err := meta.GetProposals(r.Context())
+	if err != nil {
+		internalError(w, "get proposals", err)
+		return
+	}
+	tokenID, _ := r.Context().Value(contextKeyTokenID).(string)
+	p := &remote.Proposal{
+		ID:        len(proposals) + 1,
+		Title:     req.Title,
+		Source:    req.Source,
+		Target:    req.Target,
+		Author:    tokenID,
+		State:     remote.ProposalOpen,
+		CreatedAt: time.Now(),
+	}
+	if err := meta.PutProposals(r.Context(), append(proposals, p)); err != nil {
+		internalError(w, "put proposals", err)
+		return
+	}
+	if !appendAudit(w, r, meta, remote.AuditProposalOpen, p.ID, "", p.Source+" -> "+p.Target) {
+		return
+	}
+
+	p.RequiredApprovals = cfg.RequiredApprovals
+	writeJSON(w, http.StatusCreated, p)
+}
+
+// handleApproveProposal records the calling reviewer's approval of the
+// proposal's current source tip, replacing an earlier approval of theirs.
+// Authors cannot approve their own proposals.
+func handleApproveProposal(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, _ blobstore.BlobStore, cfg *ServerConfig) {
+	proposals, p, ok := findProposal(w, r, meta)
+	if !ok || !requireOpen(w, p) {
+		return
+	}
+	tokenID, _ := r.Context().Value(contextKeyTokenID).(string)
+	if tokenID == p.Author {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "forbidden", "message": "the author of a proposal cannot approve it"})
+		return
+	}
+	sourceTip, ok := branchTip(w, r, meta, p.Source)
+	if !ok {
+		return
+	}
+
+	approvals := []*remote.Approval{}
+	for _, a := range p.Approvals {
+		if a.Reviewer != tokenID {
+			approvals = append(approvals, a)
+		}
+	}
+	p.Approvals = append(approvals, &remote.Approval{Reviewer: tokenID, CommitID: sourceTip, Timestamp: time.Now()})
+	if err := meta.PutProposals(r.Context(), proposals); err != nil {
+		internalError(w, "put proposals", err)
+		return
+	}
+	if !appendAudit(w, r, meta, remote.AuditProposalApprove, p.ID, sourceTip, "") {
+		return
+	}
+
+	p.RequiredApprovals = cfg.RequiredApprovals
+	writeJSON(w, http.StatusOK, p)
+}
+
+// handleMergeProposal fast-forwards the target branch to the source tip.
+// It is refused until cfg.RequiredApprovals reviewers have approved that
+// tip; refusals are recorded in the audit log too. Merges are subject to
+// the token's branch patterns and the push policy like any branch update.
+func handleMergeProposal(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, _ blobstore.BlobStore, cfg *ServerConfig) {
+	proposals, p, ok := findProposal(w, r, meta)
+	if !ok || !requireOpen(w, p) {
+		return
+	}
+	info, _ := r.Context().Value(contextKeyToken).(*TokenInfo)
+	if info == nil || !info.AllowsBranch(p.Target) {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "forbidden", "message": "token may not write branch '" + p.Target + "'"})
+		return
+	}
+	sourceTip, ok := branchTip(w, r, meta, p.Source)
+	if !ok {
+		return
+	}
+	targetTip, ok := branchTip(w, r, meta, p.Target)
+	if !ok {
+		return
+	}
+
+	if approved := p.ApprovalsAt(sourceTip); approved < cfg.RequiredApprovals {
+		message := fmt.Sprintf("proposal #%d has %d of %d required approvals of %s", p.ID, approved, cfg.RequiredApprovals, sourceTip)
+		if !appendAudit(w, r, meta, remote.AuditMergeRefused, p.ID, sourceTip, message) {
+			return
+		}
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "approval_required", "message": message})
+		return
+	}
+
+	ancestors, err := meta.GetAncestors(r.Context(), sourceTip)
+	if err != nil {
+		internalError(w, "get ancestors", err)
+		return
+	}
+	if !ancestors[targetTip] {
+		writeJSON(w, http.StatusConflict, map[string]string{
+			"error":   "not_fast_forward",
+			"message": fmt.Sprintf("'%s' has commits '%s' does not; merge '%s' into '%s' and push first", p.Target, p.Source, p.Target, p.Source),
+		})
+		return
+	}
+
+	update := &remote.BranchUpdateRequest{CommitID: sourceTip, Expected: targetTip}
+	if !checkPushPolicy(w, r, meta, cfg, p.Target, update) {
+		return
+	}
+	if err := meta.UpdateBranchCAS(r.Context(), p.Target, sourceTip, targetTip); err != nil {
+		if errors.Is(err, metastore.ErrConflict) {
+			writeJSON(w, http.StatusConflict, map[string]string{"error": "conflict", "message": fmt.Sprintf("branch '%s' changed concurrently", p.Target)})
+			return
+		}
+		internalError(w, "update branch", err)
+		return
+	}
+
+	tokenID, _ := r.Context().Value(contextKeyTokenID).(string)
+	now := time.Now()
+	p.State = remote.ProposalMerged
+	p.MergedBy = tokenID
+	p.MergedAt = &now
+	p.MergeCommit = sourceTip
+	if err := meta.PutProposals(r.Context(), proposals); err != nil {
+		internalError(w, "put proposals", err)
+		return
+	}
+	if !appendAudit(w, r, meta, remote.AuditProposalMerge, p.ID, sourceTip, "") {
+		return
+	}
+
+	publish(r, meta, cfg, &WebhookEvent{Event: remote.EventPush, Repo: r.PathValue("repo"), Branch: p.Target, CommitID: sourceTip, Before: targetTip})
+	p.RequiredApprovals = cfg.RequiredApprovals
+	writeJSON(w, http.StatusOK, p)
+}
+
+// handleGetAudit returns the repository's audit log, oldest first.
+func handleGetAudit(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, _ blobstore.BlobStore, _ *ServerConfig) {
+	entries, err := meta.ListAudit(r.Context())
+	if err != nil {
+		internalError(w, "get audit log", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+// findProposal returns every proposal and the one named by the path,
+// writing an error response when it cannot.
+func findProposal(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore) ([]*remote.Proposal, *remote.Proposal, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request", "message": "invalid proposal ID"})
+		return nil, nil, false
+	}
+	proposals, err := meta.GetProposals(r.Context())
+	if err != nil {
+		internalError(w, "get proposals", err)
+		return nil, nil, false
+	}
+	for _, p := range proposals {
+		if p.ID == id {
+			return proposals, p, true
+		}
+	}
+	writeJSON(w, http.StatusNotFound, map[string]string{"error": "not_found", "message": fmt.Sprintf("proposal #%d not found", id)})
+	return nil, nil, false
+}
+
+// requireOpen writes a conflict response for a proposal that was merged.
+func requireOpen(w http.ResponseWriter, p *remote.Proposal) bool {
+	if p.State != remote.ProposalOpen {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "conflict", "message": fmt.Sprintf("proposal #%d is %s", p.ID, p.State)})
+		return false
+	}
+	return true
+}
+
+// branchTip returns the commit a branch points at, writing an error
+// response when it cannot.
+func branchTip(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, name string) (string, bool) {
+	branch, err := meta.GetBranch(r.Context(), name)
+	if err != nil {
+		if errors.Is(err, metastore.ErrNotFound) {
+			writeJSON(w, http.StatusConflict, map[string]string{"error": "conflict", "message": fmt.Sprintf("branch '%s' no longer exists", name)})
+			return "", false
+		}
+		internalError(w, "get branch", err)
+		return "", false
+	}
+	return branch.CommitID, true
+}
+
+// appendAudit records an action of the calling token in the audit log,
+// writing an error response when it cannot.
+func appendAudit(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, action string, proposal int, commitID, detail string) bool {
+	tokenID, _ := r.Context().Value(contextKeyTokenID).(string)
+	entry := &remote.AuditEntry{
+		Timestamp: time.Now(),
+		TokenID:   tokenID,
+		Action:    action,
+		Proposal:  proposal,
+		CommitID:  commitID,
+		Detail:    detail,
+	}
+	if err := meta.AppendAudit(r.Context(), entry); err != nil {
+		internalError(w, "append audit log", err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,210 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/remote"
+	"github.com/kilupskalvis/wvc/internal/remote/blobstore"
+	"github.com/kilupskalvis/wvc/internal/remote/metastore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newProposalServer starts a server requiring two approvals, returning a
+// client for each of its tokens by ID: an author who may also review,
+// two reviewers, a pusher without the reviewer scope, and a repo admin.
+func newProposalServer(t *testing.T) (metastore.MetaStore, map[string]*remote.HTTPClient) {
+	t.Helper()
+
+	tmpDir := t.TempDir()
+	meta, err := metastore.NewBboltStore(filepath.Join(tmpDir, "meta.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { meta.Close() })
+	blobs, err := blobstore.NewFSStore(filepath.Join(tmpDir, "blobs"))
+	require.NoError(t, err)
+
+	scopes := map[string][]string{
+		"author":     {ScopePull, ScopePush, ScopeReviewer},
+		"reviewer-1": {ScopePull, ScopeReviewer},
+		"reviewer-2": {ScopePull, ScopeReviewer},
+		"pusher":     nil,
+		"admin":      {ScopeRepoAdmin},
+	}
+	tokens := &testTokenStore{tokens: map[string]*TokenInfo{}}
+	for id, s := range scopes {
+		raw := id + "-token"
+		tokens.tokens[HashToken(raw)] = &TokenInfo{ID: id, TokenHash: HashToken(raw), Repos: []string{"*"}, Permission: "rw",
+			TokenLimits: TokenLimits{Scopes: s}}
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	cfg := DefaultServerConfig()
+	cfg.RequiredApprovals = 2
+	h, cleanup := Handler(&testRepoOpener{meta: meta, blobs: blobs}, tokens, cfg, logger, nil, nil)
+	t.Cleanup(cleanup)
+	ts := httptest.NewServer(h)
+	t.Cleanup(ts.Close)
+
+	clients := make(map[string]*remote.HTTPClient)
+	for id := range scopes {
+		clients[id] = remote.NewHTTPClient(ts.URL, "test", id+"-token")
+	}
+	return meta, clients
+}
+
+func insertCommit(t *testing.T, meta metastore.MetaStore, id, parent string) {
+	t.Helper()
+	bundle := &remote.CommitBundle{Commit: &models.Commit{ID: id, ParentID: parent, Message: id, Timestamp: time.Now()}}
+	require.NoError(t, meta.InsertCommitBundle(context.Background(), bundle))
+}
+
+// assertRemoteError checks the status and code of a failed request.
+func assertRemoteError(t *testing.T, err error, status int, code string) {
+	t.Helper()
+	var re *remote.RemoteError
+	require.True(t, errors.As(err, &re), "expected a remote error, got %v", err)
+	assert.Equal(t, status, re.Status)
+	if code != "" {
+		assert.Equal(t, code, re.Code)
+	}
+}
+
+func TestProposal_MergeRequiresApprovals(t *testing.T) {
+	meta, clients := newProposalServer(t)
+	ctx := context.Background()
+
+	insertCommit(t, meta, "c1", "")
+	insertCommit(t, meta, "c2", "c1")
+	insertCommit(t, meta, "c3", "c2")
+	require.NoError(t, meta.CreateBranch(ctx, "main", "c1"))
+	require.NoError(t, meta.CreateBranch(ctx, "feature", "c2"))
+
+	p, err := clients["author"].CreateProposal(ctx, &remote.ProposalRequest{Title: "Add feature", Source: "feature", Target: "main"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, p.ID)
+	assert.Equal(t, remote.ProposalOpen, p.State)
+	assert.Equal(t, 2, p.RequiredApprovals)
+
+	// Approving needs the reviewer scope, and authors cannot approve their own
+	_, err = clients["pusher"].ApproveProposal(ctx, p.ID)
+	assertRemoteError(t, err, http.StatusForbidden, "forbidden")
+	_, err = clients["author"].ApproveProposal(ctx, p.ID)
+	assertRemoteError(t, err, http.StatusForbidden, "forbidden")
+
+	_, err = clients["author"].MergeProposal(ctx, p.ID)
+	assertRemoteError(t, err, http.StatusForbidden, "approval_required")
+
+	_, err = clients["reviewer-1"].ApproveProposal(ctx, p.ID)
+	require.NoError(t, err)
+	_, err = clients["author"].MergeProposal(ctx, p.ID)
+	assertRemoteError(t, err, http.StatusForbidden, "approval_required")
+
+	// Approvals of an earlier source tip do not count
+	require.NoError(t, meta.UpdateBranchCAS(ctx, "feature", "c3", "c2"))
+	p, err = clients["reviewer-2"].ApproveProposal(ctx, p.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, p.ApprovalsAt("c3"))
+	_, err = clients["author"].MergeProposal(ctx, p.ID)
+	assertRemoteError(t, err, http.StatusForbidden, "approval_required")
+
+	// Approving again replaces the reviewer's earlier approval
+	p, err = clients["reviewer-1"].ApproveProposal(ctx, p.ID)
+	require.NoError(t, err)
+	assert.Len(t, p.Approvals, 2)
+
+	p, err = clients["author"].MergeProposal(ctx, p.ID)
+	require.NoError(t, err)
+	assert.Equal(t, remote.ProposalMerged, p.State)
+	assert.Equal(t, "author", p.MergedBy)
+	assert.Equal(t, "c3", p.MergeCommit)
+	main, err := meta.GetBranch(ctx, "main")
+	require.NoError(t, err)
+	assert.Equal(t, "c3", main.CommitID)
+
+	_, err = clients["author"].MergeProposal(ctx, p.ID)
+	assertRemoteError(t, err, http.StatusConflict, "conflict")
+
+	// Every approval, refusal, and merge is in the audit log
+	_, err = clients["author"].GetAuditLog(ctx)
+	assertRemoteError(t, err, http.StatusForbidden, "forbidden")
+	entries, err := clients["admin"].GetAuditLog(ctx)
+	require.NoError(t, err)
+	var actions []string
+	for _, e := range entries {
+		actions = append(actions, e.Action)
+		assert.Equal(t, 1, e.Proposal)
+	}
+	assert.Equal(t, []string{
+		remote.AuditProposalOpen,
+		remote.AuditMergeRefused,
+		remote.AuditProposalApprove,
+		remote.AuditMergeRefused,
+		remote.AuditProposalApprove,
+		remote.AuditMergeRefused,
+		remote.AuditProposalApprove,
+		remote.AuditProposalMerge,
+	}, actions)
+	assert.Equal(t, "reviewer-1", entries[6].TokenID)
+	assert.Equal(t, "c3", entries[6].CommitID)
+	assert.Equal(t, "author", entries[7].TokenID)
+}
+
+func TestProposal_MergeOnlyFastForwards(t *testing.T) {
+	meta, clients := newProposalServer(t)
+	ctx := context.Background()
+
+	insertCommit(t, meta, "c1", "")
+	insertCommit(t, meta, "c2", "c1")
+	insertCommit(t, meta, "c3", "c1")
+	require.NoError(t, meta.CreateBranch(ctx, "main", "c2"))
+	require.NoError(t, meta.CreateBranch(ctx, "feature", "c3"))
+
+	p, err := clients["author"].CreateProposal(ctx, &remote.ProposalRequest{Source: "feature", Target: "main"})
+	require.NoError(t, err)
+	for _, reviewer := range []string{"reviewer-1", "reviewer-2"} {
+		_, err = clients[reviewer].ApproveProposal(ctx, p.ID)
+		require.NoError(t, err)
+	}
+
+	_, err = clients["author"].MergeProposal(ctx, p.ID)
+	assertRemoteError(t, err, http.StatusConflict, "not_fast_forward")
+	main, err := meta.GetBranch(ctx, "main")
+	require.NoError(t, err)
+	assert.Equal(t, "c2", main.CommitID)
+
+	got, err := clients["pusher"].GetProposal(ctx, p.ID)
+	require.NoError(t, err)
+	assert.Equal(t, remote.ProposalOpen, got.State)
+}
+
+func TestProposal_CreateValidatesBranches(t *testing.T) {
+	meta, clients := newProposalServer(t)
+	ctx := context.Background()
+
+	insertCommit(t, meta, "c1", "")
+	require.NoError(t, meta.CreateBranch(ctx, "main", "c1"))
+
+	_, err := clients["author"].CreateProposal(ctx, &remote.ProposalRequest{Source: "missing", Target: "main"})
+	assertRemoteError(t, err, http.StatusNotFound, "not_found")
+	_, err = clients["author"].CreateProposal(ctx, &remote.ProposalRequest{Source: "main", Target: "main"})
+	assertRemoteError(t, err, http.StatusBadRequest, "bad_request")
+
+	proposals, err := clients["pusher"].ListProposals(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, proposals)
+}
+
+func TestScopeReviewer(t *testing.T) {
+	assert.False(t, (&TokenInfo{Permission: "rw"}).HasScope(ScopeReviewer), "never granted by default")
+	assert.NoError(t, TokenLimits{Scopes: []string{ScopePull, ScopeReviewer}}.Validate("rw"))
+	assert.Error(t, TokenLimits{Scopes: []string{ScopeReviewer}}.Validate("ro"))
+}
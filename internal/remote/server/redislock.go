@@ -0,0 +1,186 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultLockTTL is how long a repo lock survives a replica that stops
+// renewing it, e.g. after a crash.
+const DefaultLockTTL = 30 * time.Second
+
+// Lua scripts that renew or release a lease only while it is still held by
+// the same token, so a replica never extends or deletes another's lock.
+var (
+	redisRenewScript   = redis.NewScript(`if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("pexpire", KEYS[1], ARGV[2]) else return 0 end`)
+	redisReleaseScript = redis.NewScript(`if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("del", KEYS[1]) else return 0 end`)
+)
+
+// RedisLocker implements RepoLocker with leases in Redis, so server replicas
+// sharing blob and metadata backends exclude each other's pushes and GC.
+// A lease expires after its TTL unless the holder renews it, which it does
+// in the background for as long as the lock is held.
+type RedisLocker struct {
+	client *redis.Client
+	prefix string
+	ttl    time.Duration
+	logger *slog.Logger
+
+	mu   sync.Mutex
+	held map[string]*redisLease
+}
+
+type redisLease struct {
+	key   string
+	token string
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// NewRedisLocker creates a locker from a URL of the form
+// redis://[user:password@]host:port[/db][?ttl=30s&prefix=wvc:]; rediss://
+// connects with TLS. Other query parameters are go-redis client options.
+// Connections are opened on first use.
+func NewRedisLocker(rawURL string, logger *slog.Logger) (*RedisLocker, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid lock URL: %w", err)
+	}
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return nil, fmt.Errorf("unsupported lock URL scheme %q: use redis:// or rediss://", u.Scheme)
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	l := &RedisLocker{
+		prefix: "wvc:",
+		ttl:    DefaultLockTTL,
+		logger: logger,
+		held:   make(map[string]*redisLease),
+	}
+	q := u.Query()
+	if v := q.Get("ttl"); v != "" {
+		if l.ttl, err = time.ParseDuration(v); err != nil || l.ttl < time.Second {
+			return nil, fmt.Errorf("invalid lock URL ttl %q: must be a duration of at least 1s", v)
+		}
+	}
+	if q.Has("prefix") {
+		l.prefix = q.Get("prefix")
+	}
+
+	q.Del("ttl")
+	q.Del("prefix")
+	u.RawQuery = q.Encode()
+	opts, err := redis.ParseURL(u.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid lock URL: %w", err)
+	}
+	l.client = redis.NewClient(opts)
+	return l, nil
+}
+
+// LockWrite acquires the repo's lease, polling until it is free or ctx is done.
+func (l *RedisLocker) LockWrite(ctx context.Context, repo string) error {
+	lease := &redisLease{
+		key:   l.prefix + "lock:repo:" + repo,
+		token: randomToken(),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+
+	wait := 25 * time.Millisecond
+	for {
+		ok, err := l.client.SetNX(ctx, lease.key, lease.token, l.ttl).Result()
+		if err != nil {
+			return fmt.Errorf("acquire lock for %s: %w", repo, err)
+		}
+		if ok {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("acquire lock for %s: %w", repo, ctx.Err())
+		case <-time.After(wait):
+		}
+		wait = min(wait*2, 500*time.Millisecond)
+	}
+
+	l.mu.Lock()
+	l.held[repo] = lease
+	l.mu.Unlock()
+
+	go l.renew(lease)
+	return nil
+}
+
+// UnlockWrite stops renewing the repo's lease and releases it.
+func (l *RedisLocker) UnlockWrite(repo string) {
+	l.mu.Lock()
+	lease, ok := l.held[repo]
+	delete(l.held, repo)
+	l.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	close(lease.stop)
+	<-lease.done
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := redisReleaseScript.Run(ctx, l.client, []string{lease.key}, lease.token).Err(); err != nil {
+		// The lease expires on its own after the TTL
+		l.logger.Warn("release repo lock", "repo", repo, "error", err)
+	}
+}
+
+// renew extends a lease every third of its TTL until it is released.
+func (l *RedisLocker) renew(lease *redisLease) {
+	defer close(lease.done)
+
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-lease.stop:
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), l.ttl/3)
+		renewed, err := redisRenewScript.Run(ctx, l.client, []string{lease.key}, lease.token, l.ttl.Milliseconds()).Int()
+		cancel()
+		if err != nil {
+			l.logger.Warn("renew repo lock", "key", lease.key, "error", err)
+			continue
+		}
+		if renewed == 0 {
+			l.logger.Error("repo lock lost: lease expired before renewal", "key", lease.key)
+			return
+		}
+	}
+}
+
+// Close closes the connections to Redis. Held leases expire after their TTL.
+func (l *RedisLocker) Close() error {
+	return l.client.Close()
+}
+
+func randomToken() string {
+	b := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,163 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRedisLocker_ParsesURL(t *testing.T) {
+	l, err := NewRedisLocker("rediss://locker:pw@redis.internal/2?ttl=10s&prefix=team:", nil)
+	require.NoError(t, err)
+	opts := l.client.Options()
+	assert.Equal(t, "redis.internal:6379", opts.Addr)
+	assert.NotNil(t, opts.TLSConfig)
+	assert.Equal(t, "locker", opts.Username)
+	assert.Equal(t, "pw", opts.Password)
+	assert.Equal(t, 2, opts.DB)
+	assert.Equal(t, 10*time.Second, l.ttl)
+	assert.Equal(t, "team:", l.prefix)
+
+	_, err = NewRedisLocker("postgres://db/locks", nil)
+	assert.ErrorContains(t, err, "unsupported lock URL scheme")
+	_, err = NewRedisLocker("redis://host?ttl=10ms", nil)
+	assert.ErrorContains(t, err, "invalid lock URL ttl")
+	_, err = NewRedisLocker("redis://host/db", nil)
+	assert.ErrorContains(t, err, "invalid lock URL")
+}
+
+func TestRedisLocker_ExcludesReplicas(t *testing.T) {
+	mr := miniredis.RunT(t)
+	mr.RequireAuth("pw")
+	addr := mr.Addr()
+	replicaA, err := NewRedisLocker("redis://:pw@"+addr+"/1", nil)
+	require.NoError(t, err)
+	defer replicaA.Close()
+	replicaB, err := NewRedisLocker("redis://:pw@"+addr+"/1", nil)
+	require.NoError(t, err)
+	defer replicaB.Close()
+
+	ctx := context.Background()
+	require.NoError(t, replicaA.LockWrite(ctx, "myrepo"))
+
+	// Other repos are not affected
+	require.NoError(t, replicaB.LockWrite(ctx, "other"))
+	replicaB.UnlockWrite("other")
+
+	short, cancel := context.WithTimeout(ctx, 150*time.Millisecond)
+	defer cancel()
+	err = replicaB.LockWrite(short, "myrepo")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	acquired := make(chan error, 1)
+	go func() { acquired <- replicaB.LockWrite(ctx, "myrepo") }()
+	time.Sleep(50 * time.Millisecond)
+	replicaA.UnlockWrite("myrepo")
+
+	select {
+	case err := <-acquired:
+		require.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("lock was not handed over after release")
+	}
+	replicaB.UnlockWrite("myrepo")
+}
+
+func TestRedisLocker_RenewsLease(t *testing.T) {
+	mr := miniredis.RunT(t)
+	replicaA, err := NewRedisLocker("redis://"+mr.Addr()+"?ttl=1s", nil)
+	require.NoError(t, err)
+	defer replicaA.Close()
+	replicaB, err := NewRedisLocker("redis://"+mr.Addr()+"?ttl=1s", nil)
+	require.NoError(t, err)
+	defer replicaB.Close()
+
+	ctx := context.Background()
+	require.NoError(t, replicaA.LockWrite(ctx, "myrepo"))
+
+	// Held past the TTL because replica A renews it every third of the TTL
+	mr.FastForward(900 * time.Millisecond)
+	time.Sleep(500 * time.Millisecond)
+	mr.FastForward(900 * time.Millisecond)
+	assert.True(t, mr.Exists("wvc:lock:repo:myrepo"))
+	short, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	assert.Error(t, replicaB.LockWrite(short, "myrepo"))
+
+	replicaA.UnlockWrite("myrepo")
+	require.NoError(t, replicaB.LockWrite(ctx, "myrepo"))
+	replicaB.UnlockWrite("myrepo")
+}
+
+func TestRedisLocker_ReleasesOnlyOwnLease(t *testing.T) {
+	mr := miniredis.RunT(t)
+	l, err := NewRedisLocker("redis://"+mr.Addr(), nil)
+	require.NoError(t, err)
+	defer l.Close()
+
+	require.NoError(t, l.LockWrite(context.Background(), "myrepo"))
+	// The lease expired and another replica took the lock
+	require.NoError(t, mr.Set("wvc:lock:repo:myrepo", "other"))
+	l.UnlockWrite("myrepo")
+
+	value, err := mr.Get("wvc:lock:repo:myrepo")
+	require.NoError(t, err)
+	assert.Equal(t, "other", value)
+}
+
+func TestRedisLocker_Unreachable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	ln.Close()
+
+	l, err := NewRedisLocker("redis://"+addr, nil)
+	require.NoError(t, err)
+	err = l.LockWrite(context.Background(), "myrepo")
+	assert.ErrorContains(t, err, "acquire lock for myrepo")
+}
+
+func TestRedisLocker_WrongPassword(t *testing.T) {
+	mr := miniredis.RunT(t)
+	mr.RequireAuth("pw")
+	l, err := NewRedisLocker("redis://:wrong@"+mr.Addr(), nil)
+	require.NoError(t, err)
+	err = l.LockWrite(context.Background(), "myrepo")
+	assert.ErrorContains(t, err, "WRONGPASS")
+}
+
+func TestHandler_WriteLockUnavailable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	ln.Close()
+	locker, err := NewRedisLocker("redis://"+addr, nil)
+	require.NoError(t, err)
+
+	rawToken := "test-token-123"
+	tokens := &testTokenStore{tokens: map[string]*TokenInfo{
+		HashToken(rawToken): {ID: "tok-1", TokenHash: HashToken(rawToken), Repos: []string{"*"}, Permission: "rw"},
+	}}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	h, cleanup := Handler(&testRepoOpener{}, tokens, DefaultServerConfig(), logger, locker, nil)
+	defer cleanup()
+	ts := httptest.NewServer(h)
+	defer ts.Close()
+
+	req := authReq("PUT", ts.URL+"/api/v1/repos/test/branches/main", rawToken, strings.NewReader(`{"commit_id":"c1"}`))
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, "1", resp.Header.Get("Retry-After"))
+}
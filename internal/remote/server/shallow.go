@@ -0,0 +1,222 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/remote"
+	"github.com/kilupskalvis/wvc/internal/remote/blobstore"
+	"github.com/kilupskalvis/wvc/internal/remote/metastore"
+)
+
+// handleGetCommitState returns the objects live after a commit, for clients
+// cutting their history at it. A class filter limits the objects sent.
+func handleGetCommitState(w http.ResponseWriter, r *http.Request, meta metastore.MetaStore, _ blobstore.BlobStore, cfg *ServerConfig) {
+	commitID := r.PathValue("id")
+	filter, err := remote.ParseFilter(r.URL.Query().Get("filter"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_request", "message": err.Error()})
+		return
+	}
+
+	ops, err := commitState(r.Context(), meta, commitID, filter, cfg.SnapshotInterval)
+	if err != nil {
+		if errors.Is(err, metastore.ErrNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not_found", "message": "commit not found"})
+			return
+		}
+		internalError(w, "compute commit state", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, &remote.CommitState{CommitID: commitID, Operations: ops})
+}
+
+// commitState replays the history of commitID, parents before children as
+// clients do, and returns one insert per object live at the end. The replay
+// starts from the nearest usable state snapshot, and commits passed on the
+// way whose history holds a multiple of interval commits get one. Commits
+// missing from a shallow server history end the walk.
+func commitState(ctx context.Context, meta metastore.MetaStore, commitID string, filter remote.Filter, interval int) ([]*models.Operation, error) {
+	if _, err := meta.GetCommit(ctx, commitID); err != nil {
+		return nil, err
+	}
+	graph, err := loadStateGraph(ctx, meta, commitID)
+	if err != nil {
+		return nil, err
+	}
+	start, snapshot, err := nearestStateSnapshot(ctx, meta, graph)
+	if err != nil {
+		return nil, err
+	}
+
+	live := make(map[string]*models.Operation)
+	apply := func(ops []*models.Operation) error {
+		for _, op := range ops {
+			key := models.ObjectKey(op.ClassName, op.ObjectID)
+			switch op.Type {
+			case models.OperationInsert, models.OperationUpdate:
+				// Stored deltas have their previous data inline
+				if err := op.DecodeDelta(); err != nil {
+					return fmt.Errorf("decode operation on %s: %w", key, err)
+				}
+				live[key] = op
+			case models.OperationDelete:
+				delete(live, key)
+			}
+		}
+		return nil
+	}
+	if err := apply(snapshot); err != nil {
+		return nil, err
+	}
+	for i := start; i < len(graph.path); i++ {
+		id := graph.path[i]
+		ops, err := meta.GetOperationsByCommit(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("get operations of %s: %w", id, err)
+		}
+		if err := apply(ops); err != nil {
+			return nil, err
+		}
+		if interval > 0 && (i+1)%interval == 0 && models.HistorySize(graph.parents, id) == i+1 {
+			if err := meta.PutStateSnapshot(ctx, id, stateInserts(live, remote.Filter{})); err != nil {
+				return nil, fmt.Errorf("save state snapshot of %s: %w", id, err)
+			}
+		}
+	}
+	return stateInserts(live, filter), nil
+}
+
+// stateInserts returns one insert per live object the filter keeps, sorted
+// by key.
+func stateInserts(live map[string]*models.Operation, filter remote.Filter) []*models.Operation {
+	keys := make([]string, 0, len(live))
+	for key, op := range live {
+		if filter.KeepsClass(op.ClassName) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	state := make([]*models.Operation, 0, len(keys))
+	for i, key := range keys {
+		op := live[key]
+		state = append(state, &models.Operation{
+			Seq:               i,
+			Timestamp:         op.Timestamp,
+			Type:              models.OperationInsert,
+			ClassName:         op.ClassName,
+			ObjectID:          op.ObjectID,
+			ObjectData:        op.ObjectData,
+			ObjectDataHash:    op.ObjectDataHash,
+			VectorHash:        op.VectorHash,
+			NamedVectorHashes: op.NamedVectorHashes,
+		})
+	}
+	return state
+}
+
+// nearestStateSnapshot returns the stored snapshot the replay of graph can
+// start from and the index in graph.path to replay on from: the latest
+// commit with a snapshot that every commit before it on the path leads to.
+func nearestStateSnapshot(ctx context.Context, meta metastore.MetaStore, graph *stateGraph) (int, []*models.Operation, error) {
+	ids, err := meta.ListStateSnapshots(ctx)
+	if err != nil {
+		return 0, nil, fmt.Errorf("list state snapshots: %w", err)
+	}
+	if len(ids) == 0 {
+		return 0, nil, nil
+	}
+	snapshots := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		snapshots[id] = true
+	}
+	for i := len(graph.path) - 1; i >= 0; i-- {
+		id := graph.path[i]
+		if !snapshots[id] || models.HistorySize(graph.parents, id) != i+1 {
+			continue
+		}
+		ops, err := meta.GetStateSnapshot(ctx, id)
+		if errors.Is(err, metastore.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return 0, nil, fmt.Errorf("get state snapshot of %s: %w", id, err)
+		}
+		return i + 1, ops, nil
+	}
+	return 0, nil, nil
+}
+
+// stateGraph is the history of a commit the server holds.
+type stateGraph struct {
+	path    []string            // topological order, ties broken by ID
+	parents map[string][]string // parents of each commit that are in the graph
+}
+
+// loadStateGraph returns commitID and its ancestors the server holds in
+// topological order, ties broken by ID, matching the order clients replay.
+func loadStateGraph(ctx context.Context, meta metastore.MetaStore, commitID string) (*stateGraph, error) {
+	parents := make(map[string][]string)
+	queue := []string{commitID}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if _, seen := parents[id]; seen {
+			continue
+		}
+		c, err := meta.GetCommit(ctx, id)
+		if errors.Is(err, metastore.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("get commit %s: %w", id, err)
+		}
+		parents[id] = []string{}
+		for _, p := range []string{c.ParentID, c.MergeParentID} {
+			if p != "" {
+				parents[id] = append(parents[id], p)
+				queue = append(queue, p)
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(parents))
+	for id := range parents {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	graph := &stateGraph{parents: make(map[string][]string, len(ids))}
+	inDegree := make(map[string]int, len(ids))
+	children := make(map[string][]string)
+	for _, id := range ids {
+		for _, p := range parents[id] {
+			if _, ok := parents[p]; ok {
+				inDegree[id]++
+				children[p] = append(children[p], id)
+				graph.parents[id] = append(graph.parents[id], p)
+			}
+		}
+	}
+	var roots []string
+	for _, id := range ids {
+		if inDegree[id] == 0 {
+			roots = append(roots, id)
+		}
+	}
+	graph.path = make([]string, 0, len(ids))
+	for len(roots) > 0 {
+		id := roots[0]
+		roots = roots[1:]
+		graph.path = append(graph.path, id)
+		for _, child := range children[id] {
+			if inDegree[child]--; inDegree[child] == 0 {
+				roots = append(roots, child)
+			}
+		}
+	}
+	return graph, nil
+}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShallowNegotiateAndState(t *testing.T) {
+	ts, meta, _, token := newTestServer(t)
+	ctx := context.Background()
+
+	insert := func(class, id, data string) *models.Operation {
+		return &models.Operation{Type: models.OperationInsert, ClassName: class, ObjectID: id, ObjectData: []byte(data)}
+	}
+	// c1 <- c2 <- c3 <- c4
+	for _, b := range []*remote.CommitBundle{
+		{Commit: &models.Commit{ID: "c1", Message: "first", Timestamp: time.Now()},
+			Operations: []*models.Operation{insert("Article", "a1", `{"v":1}`), insert("Author", "b1", `{}`)}},
+		{Commit: &models.Commit{ID: "c2", ParentID: "c1", Message: "second", Timestamp: time.Now()},
+			Operations: []*models.Operation{
+				{Type: models.OperationUpdate, ClassName: "Article", ObjectID: "a1", ObjectData: []byte(`{"v":2}`)},
+				insert("Article", "a2", `{}`),
+			}},
+		{Commit: &models.Commit{ID: "c3", ParentID: "c2", Message: "third", Timestamp: time.Now()},
+			Operations: []*models.Operation{{Type: models.OperationDelete, ClassName: "Article", ObjectID: "a2"}}},
+		{Commit: &models.Commit{ID: "c4", ParentID: "c3", Message: "fourth", Timestamp: time.Now()}},
+	} {
+		require.NoError(t, meta.InsertCommitBundle(ctx, b))
+	}
+	require.NoError(t, meta.CreateBranch(ctx, "main", "c4"))
+	client := remote.NewHTTPClient(ts.URL, "test", token)
+
+	// A depth cut reports the oldest commit sent as a boundary
+	result, err := client.NegotiatePull(ctx, "main", "", 2, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c3", "c4"}, result.MissingCommits)
+	assert.Equal(t, []string{"c3"}, result.Shallow)
+
+	// Deepening from the boundary sends what lies behind it
+	result, err = client.NegotiatePull(ctx, "main", "c4", 0, nil, []string{"c3"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c1", "c2"}, result.MissingCommits)
+	assert.Empty(t, result.Shallow)
+
+	// The state at a boundary holds every live object as an insert
+	state, err := client.DownloadCommitState(ctx, "c3", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "c3", state.CommitID)
+	require.Len(t, state.Operations, 2)
+	assert.Equal(t, "a1", state.Operations[0].ObjectID)
+	assert.Equal(t, models.OperationInsert, state.Operations[0].Type)
+	assert.JSONEq(t, `{"v":2}`, string(state.Operations[0].ObjectData))
+	assert.Equal(t, "b1", state.Operations[1].ObjectID)
+
+	state, err = client.DownloadCommitState(ctx, "c3", []string{"Author"})
+	require.NoError(t, err)
+	require.Len(t, state.Operations, 1)
+	assert.Equal(t, "Author", state.Operations[0].ClassName)
+
+	_, err = client.DownloadCommitState(ctx, "missing", nil)
+	assert.Error(t, err)
+	resp, err := http.DefaultClient.Do(authReq("GET", ts.URL+"/api/v1/repos/test/commits/missing/state", token, nil))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestCommitState_Snapshots(t *testing.T) {
+	_, meta, _, _ := newTestServer(t)
+	ctx := context.Background()
+
+	insert := func(id string) *models.Operation {
+		return &models.Operation{Type: models.OperationInsert, ClassName: "Article", ObjectID: id, ObjectData: []byte(`{}`)}
+	}
+	// c1 <- c2 <- c3 <- c4
+	parent := ""
+	for i, id := range []string{"c1", "c2", "c3", "c4"} {
+		require.NoError(t, meta.InsertCommitBundle(ctx, &remote.CommitBundle{
+			Commit:     &models.Commit{ID: id, ParentID: parent, Timestamp: time.Now()},
+			Operations: []*models.Operation{insert(fmt.Sprintf("obj-%d", i+1))},
+		}))
+		parent = id
+	}
+
+	// Computing c4 saves snapshots every second commit, unfiltered
+	state, err := commitState(ctx, meta, "c4", remote.Filter{Classes: []string{"Author"}}, 2)
+	require.NoError(t, err)
+	assert.Empty(t, state)
+	ids, err := meta.ListStateSnapshots(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"c2", "c4"}, ids)
+	snapshot, err := meta.GetStateSnapshot(ctx, "c2")
+	require.NoError(t, err)
+	assert.Len(t, snapshot, 2)
+
+	// Later computations start from the nearest snapshot
+	require.NoError(t, meta.PutStateSnapshot(ctx, "c2", []*models.Operation{insert("obj-9")}))
+	state, err = commitState(ctx, meta, "c3", remote.Filter{}, 0)
+	require.NoError(t, err)
+	require.Len(t, state, 2)
+	assert.Equal(t, "obj-3", state[0].ObjectID)
+	assert.Equal(t, "obj-9", state[1].ObjectID)
+}
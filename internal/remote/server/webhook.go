@@ -12,18 +12,44 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"slices"
+	"strings"
 	"time"
+
+	"github.com/kilupskalvis/wvc/internal/remote"
+	"github.com/kilupskalvis/wvc/internal/remote/metastore"
 )
 
-// WebhookEvent represents the payload sent to webhook URLs.
+// WebhookEvent represents the payload sent to webhook URLs. ID identifies the
+// delivery and stays the same across retries, so receivers can drop
+// duplicates. GC and Error describe a gc event.
+//
+// Push events also summarize the pushed commits, those reachable from
+// CommitID but not from Before: the new tip's message and author, the number
+// of commits, and the operations they recorded per class. Truncated is set
+// when the push had more than maxPushSummaryCommits commits and the summary
+// covers only the newest of them.
 type WebhookEvent struct {
-	Event     string `json:"event"`
-	Repo      string `json:"repo"`
-	Branch    string `json:"branch"`
-	CommitID  string `json:"commit_id"`
-	Timestamp string `json:"timestamp"`
+	ID             string                      `json:"id,omitempty"`
+	Event          string                      `json:"event"`
+	Repo           string                      `json:"repo"`
+	Branch         string                      `json:"branch"`
+	CommitID       string                      `json:"commit_id"`
+	Timestamp      string                      `json:"timestamp"`
+	GC             *GCResult                   `json:"gc,omitempty"`
+	Error          string                      `json:"error,omitempty"`
+	Before         string                      `json:"before,omitempty"`
+	Message        string                      `json:"message,omitempty"`
+	Author         string                      `json:"author,omitempty"`
+	Commits        int                         `json:"commits,omitempty"`
+	ChangedClasses []string                    `json:"changed_classes,omitempty"`
+	Operations     map[string]*WebhookClassOps `json:"operations,omitempty"`
+	Truncated      bool                        `json:"truncated,omitempty"`
 }
 
+// WebhookClassOps counts the operations pushed commits recorded on one class.
+type WebhookClassOps = remote.ClassDiffstat
+
 // WebhookConfig holds the list of configured webhook URLs.
 type WebhookConfig struct {
 	URLs         []string
@@ -32,6 +58,8 @@ type WebhookConfig struct {
 }
 
 // WebhookNotifier sends HTTP POST notifications to configured webhook URLs.
+// Set as ServerConfig.Webhooks, its URLs receive every event of every
+// repository through the server's delivery queue.
 type WebhookNotifier struct {
 	config *WebhookConfig
 	client *http.Client
@@ -77,36 +105,10 @@ func NewWebhookNotifier(cfg *WebhookConfig, logger *slog.Logger) *WebhookNotifie
 	} else {
 		var safeURLs []string
 		for _, rawURL := range cfg.URLs {
-			parsed, err := url.Parse(rawURL)
-			if err != nil {
-				logger.Warn("webhook: rejected invalid URL", "url", rawURL, "error", err)
-				continue
-			}
-
-			host := parsed.Hostname()
-			if host == "" {
-				logger.Warn("webhook: rejected URL with empty host", "url", rawURL)
-				continue
-			}
-
-			ips, err := net.LookupIP(host)
-			if err != nil {
-				logger.Warn("webhook: rejected URL — DNS lookup failed", "url", rawURL, "error", err)
+			if err := checkWebhookURL(rawURL); err != nil {
+				logger.Warn("webhook: rejected URL", "url", rawURL, "error", err)
 				continue
 			}
-
-			blocked := false
-			for _, ip := range ips {
-				if isPrivateIP(ip) {
-					logger.Warn("webhook: rejected URL — host resolves to private/loopback address", "url", rawURL, "ip", ip.String())
-					blocked = true
-					break
-				}
-			}
-			if blocked {
-				continue
-			}
-
 			safeURLs = append(safeURLs, rawURL)
 		}
 
@@ -116,39 +118,69 @@ func NewWebhookNotifier(cfg *WebhookConfig, logger *slog.Logger) *WebhookNotifie
 
 		cfg.URLs = safeURLs
 	}
-	var client *http.Client
-	if cfg.AllowPrivate {
-		client = &http.Client{Timeout: 10 * time.Second}
-	} else {
-		transport := &http.Transport{
-			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
-				host, port, err := net.SplitHostPort(addr)
-				if err != nil {
-					return nil, err
-				}
-				ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
-				if err != nil {
-					return nil, err
-				}
-				for _, ip := range ips {
-					if isPrivateIP(ip.IP) {
-						return nil, fmt.Errorf("webhook blocked: %s resolves to private IP %s", host, ip.IP)
-					}
-				}
-				dialer := &net.Dialer{Timeout: 10 * time.Second}
-				return dialer.DialContext(ctx, network, net.JoinHostPort(ips[0].IP.String(), port))
-			},
-		}
-		client = &http.Client{Timeout: 10 * time.Second, Transport: transport}
-	}
 	return &WebhookNotifier{
 		config: cfg,
-		client: client,
+		client: newWebhookClient(cfg.AllowPrivate),
 		logger: logger,
 		sem:    make(chan struct{}, 10),
 	}
 }
 
+// checkWebhookURL rejects webhook URLs that are not http or https, or whose
+// host resolves to a loopback, link-local, or private address.
+func checkWebhookURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("scheme must be http or https")
+	}
+	host := parsed.Hostname()
+	if host == "" {
+		return fmt.Errorf("empty host")
+	}
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return fmt.Errorf("DNS lookup failed: %w", err)
+	}
+	for _, ip := range ips {
+		if isPrivateIP(ip) {
+			return fmt.Errorf("host resolves to private/loopback address %s", ip)
+		}
+	}
+	return nil
+}
+
+// newWebhookClient returns the HTTP client for webhook deliveries. Unless
+// allowPrivate is set, it refuses to connect to hosts that resolve to private
+// addresses at dial time, so DNS changes after validation cannot reach them.
+func newWebhookClient(allowPrivate bool) *http.Client {
+	if allowPrivate {
+		return &http.Client{Timeout: 10 * time.Second}
+	}
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			host, port, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+			ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+			if err != nil {
+				return nil, err
+			}
+			for _, ip := range ips {
+				if isPrivateIP(ip.IP) {
+					return nil, fmt.Errorf("webhook blocked: %s resolves to private IP %s", host, ip.IP)
+				}
+			}
+			dialer := &net.Dialer{Timeout: 10 * time.Second}
+			return dialer.DialContext(ctx, network, net.JoinHostPort(ips[0].IP.String(), port))
+		},
+	}
+	return &http.Client{Timeout: 10 * time.Second, Transport: transport}
+}
+
 // NotifyPush sends a push event to all configured webhook URLs.
 // Runs asynchronously — does not block the caller.
 func (wn *WebhookNotifier) NotifyPush(repo, branch, commitID string) {
@@ -198,38 +230,191 @@ func (wn *WebhookNotifier) post(url string, data []byte) error {
 
 	var lastErr error
 	for attempt := 0; attempt <= maxRetries; attempt++ {
-		req, err := http.NewRequest("POST", url, bytes.NewReader(data))
-		if err != nil {
-			return fmt.Errorf("create request: %w", err)
+		retry, err := deliverWebhook(context.Background(), wn.client, url, wn.config.Secret, "", data)
+		if err == nil {
+			return nil
 		}
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("User-Agent", "wvc-server/1.0")
+		lastErr = err
+		if !retry {
+			return err
+		}
+		time.Sleep(time.Duration(attempt+1) * time.Second)
+	}
+
+	return lastErr
+}
 
-		if wn.config.Secret != "" {
-			mac := hmac.New(sha256.New, []byte(wn.config.Secret))
-			mac.Write(data)
-			sig := hex.EncodeToString(mac.Sum(nil))
-			req.Header.Set("X-WVC-Signature-256", "sha256="+sig)
+// deliverWebhook makes one delivery attempt, signed with secret when it is
+// set. retry reports whether a failure is worth retrying: network errors,
+// 5xx, 408, and 429 are; other 4xx responses are not.
+func deliverWebhook(ctx context.Context, client *http.Client, url, secret, deliveryID string, data []byte) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(data))
+	if err != nil {
+		return false, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "wvc-server/1.0")
+	if deliveryID != "" {
+		req.Header.Set("X-WVC-Delivery", deliveryID)
+	}
+	if secret != "" {
+		req.Header.Set("X-WVC-Signature-256", signWebhook(secret, data))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode >= 500, resp.StatusCode == http.StatusRequestTimeout, resp.StatusCode == http.StatusTooManyRequests:
+		return true, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return false, fmt.Errorf("HTTP %d", resp.StatusCode)
+}
+
+// signWebhook returns the X-WVC-Signature-256 header value for a payload.
+func signWebhook(secret string, data []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(data)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature reports whether signature, the X-WVC-Signature-256
+// header of a delivery, matches the HMAC-SHA256 of body under secret. The
+// comparison takes constant time.
+func VerifyWebhookSignature(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(signWebhook(secret, body)), []byte(signature))
+}
+
+// maxRepoWebhooks bounds the webhooks of one repository.
+const maxRepoWebhooks = 20
+
+// webhookDispatcher turns repository and server events into queued
+// deliveries: to the server-wide webhooks for every event, and to the
+// webhooks stored with a repository for the events they subscribed to.
+type webhookDispatcher struct {
+	global       *WebhookConfig // nil without server-wide webhooks
+	allowPrivate bool
+	queue        *webhookQueue
+	logger       *slog.Logger
+}
+
+func newWebhookDispatcher(global *WebhookNotifier, allowPrivate bool, queue *webhookQueue, logger *slog.Logger) *webhookDispatcher {
+	d := &webhookDispatcher{allowPrivate: allowPrivate, queue: queue, logger: logger}
+	if global != nil {
+		d.global = global.config
+	}
+	return d
+}
+
+// validate checks a repository's webhook list before it is stored.
+func (d *webhookDispatcher) validate(hooks []*remote.Webhook) error {
+	if len(hooks) > maxRepoWebhooks {
+		return fmt.Errorf("at most %d webhooks per repository", maxRepoWebhooks)
+	}
+	for _, h := range hooks {
+		if h == nil {
+			return fmt.Errorf("webhook must not be null")
+		}
+		if d.allowPrivate {
+			if u, err := url.Parse(h.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("webhook %q: invalid URL", h.URL)
+			}
+		} else if err := checkWebhookURL(h.URL); err != nil {
+			return fmt.Errorf("webhook %q: %w", h.URL, err)
 		}
+		for _, event := range h.Events {
+			if !slices.Contains(remote.RepoWebhookEvents, event) {
+				return fmt.Errorf("webhook %q: unknown event '%s' (want one of %s)", h.URL, event, strings.Join(remote.RepoWebhookEvents, ", "))
+			}
+		}
+	}
+	return nil
+}
 
-		resp, err := wn.client.Do(req)
+// notify queues an event for the server-wide webhooks and, when meta is set,
+// for the repository's webhooks that want it. Only reading the repository's
+// webhooks, and summarizing pushes that some webhook receives, blocks the
+// caller.
+func (d *webhookDispatcher) notify(ctx context.Context, meta metastore.MetaStore, event *WebhookEvent) {
+	event.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	type target struct{ url, secret string }
+	var targets []target
+	if d.global != nil {
+		for _, u := range d.global.URLs {
+			targets = append(targets, target{u, d.global.Secret})
+		}
+	}
+	if meta != nil {
+		hooks, err := meta.GetWebhooks(ctx)
 		if err != nil {
-			lastErr = err
-			time.Sleep(time.Duration(attempt+1) * time.Second)
-			continue
+			d.logger.Warn("webhook: read repository webhooks", "repo", event.Repo, "error", err)
 		}
-		resp.Body.Close()
+		for _, h := range hooks {
+			if h.Wants(event.Event) {
+				targets = append(targets, target{h.URL, h.Secret})
+			}
+		}
+	}
+	if len(targets) == 0 {
+		return
+	}
 
-		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-			return nil
+	if event.Event == remote.EventPush && meta != nil {
+		if err := summarizePush(ctx, meta, event); err != nil {
+			d.logger.Warn("webhook: summarize push", "repo", event.Repo, "branch", event.Branch, "error", err)
 		}
+	}
+	for _, t := range targets {
+		d.queue.enqueue(t.url, t.secret, event)
+	}
+}
 
-		lastErr = fmt.Errorf("HTTP %d", resp.StatusCode)
-		if resp.StatusCode < 500 {
-			return lastErr // don't retry 4xx
+// maxPushSummaryCommits bounds the commits read to summarize one push.
+const maxPushSummaryCommits = 1000
+
+// summarizePush fills in a push event's summary from the pushed commits,
+// walking back from the new tip until it reaches commits the branch already
+// had.
+func summarizePush(ctx context.Context, meta metastore.MetaStore, event *WebhookEvent) error {
+	tip, err := meta.GetCommit(ctx, event.CommitID)
+	if err != nil {
+		return fmt.Errorf("get commit %s: %w", event.CommitID, err)
+	}
+	event.Message = tip.Message
+	event.Author = tip.Author
+
+	known := map[string]bool{}
+	if event.Before != "" {
+		if known, err = meta.GetAncestors(ctx, event.Before); err != nil {
+			return fmt.Errorf("get ancestors of %s: %w", event.Before, err)
 		}
-		time.Sleep(time.Duration(attempt+1) * time.Second)
+	}
+	if known[tip.ID] {
+		return nil // the branch moved back or stayed put; nothing was pushed
 	}
 
-	return lastErr
+	order, truncated, err := collectCommits(ctx, meta, tip, known, maxPushSummaryCommits)
+	if err != nil {
+		return err
+	}
+	event.Truncated = truncated
+	event.Commits = len(order)
+	stat, err := diffstat(ctx, meta, order)
+	if err != nil {
+		return err
+	}
+	ops := stat.Classes
+	if len(ops) > 0 {
+		event.Operations = ops
+		for class := range ops {
+			event.ChangedClasses = append(event.ChangedClasses, class)
+		}
+		slices.Sort(event.ChangedClasses)
+	}
+	return nil
 }
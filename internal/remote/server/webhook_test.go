@@ -1,14 +1,27 @@
 package server
 
 import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/remote"
+	"github.com/kilupskalvis/wvc/internal/remote/blobstore"
+	"github.com/kilupskalvis/wvc/internal/remote/metastore"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -112,3 +125,472 @@ func TestWebhookNotifier_Post_4xxNoRetry(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, 1, callCount) // no retry for 4xx
 }
+
+func TestRepoWebhooks(t *testing.T) {
+	ctx := context.Background()
+	tmpDir := t.TempDir()
+	meta, err := metastore.NewBboltStore(filepath.Join(tmpDir, "meta.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { meta.Close() })
+	blobs, err := blobstore.NewFSStore(filepath.Join(tmpDir, "blobs"))
+	require.NoError(t, err)
+	require.NoError(t, meta.InsertCommitBundle(ctx, &remote.CommitBundle{
+		Commit: &models.Commit{ID: "c1", Message: "first", Timestamp: time.Now()},
+	}))
+
+	// The receiver records each delivery by hook, checking signatures
+	type delivery struct {
+		hook  string
+		event WebhookEvent
+	}
+	var mu sync.Mutex
+	var received []delivery
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		hook := r.URL.Query().Get("hook")
+		if hook == "signed" {
+			mac := hmac.New(sha256.New, []byte("s3cret"))
+			mac.Write(body)
+			if r.Header.Get("X-WVC-Signature-256") != "sha256="+hex.EncodeToString(mac.Sum(nil)) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+		}
+		var event WebhookEvent
+		require.NoError(t, json.Unmarshal(body, &event))
+		mu.Lock()
+		received = append(received, delivery{hook, event})
+		mu.Unlock()
+	}))
+	t.Cleanup(receiver.Close)
+
+	repoAdmin, pusher := "repo-admin-token", "push-token"
+	tokens := &testTokenStore{tokens: map[string]*TokenInfo{
+		HashToken(repoAdmin): {ID: "tok-1", TokenHash: HashToken(repoAdmin), Repos: []string{"test"}, Permission: "rw",
+			TokenLimits: TokenLimits{Scopes: []string{ScopeRepoAdmin}}},
+		HashToken(pusher): {ID: "tok-2", TokenHash: HashToken(pusher), Repos: []string{"*"}, Permission: "rw"},
+	}}
+	cfg := DefaultServerConfig()
+	cfg.WebhookAllowPrivate = true
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	h, cleanup := Handler(&testRepoOpener{meta: meta, blobs: blobs}, tokens, cfg, logger, nil, nil)
+	t.Cleanup(cleanup)
+	ts := httptest.NewServer(h)
+	t.Cleanup(ts.Close)
+
+	do := func(method, path, token string, body any) *http.Response {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(authReq(method, ts.URL+path, token, bytes.NewReader(data)))
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	hooks := &remote.WebhookList{Webhooks: []*remote.Webhook{
+		{URL: receiver.URL + "?hook=signed", Secret: "s3cret", Events: []string{remote.EventTag}},
+		{URL: receiver.URL + "?hook=all"},
+	}}
+	assert.Equal(t, http.StatusForbidden, do("PUT", "/api/v1/repos/test/webhooks", pusher, hooks).StatusCode)
+	bad := &remote.WebhookList{Webhooks: []*remote.Webhook{{URL: receiver.URL, Events: []string{"merge"}}}}
+	assert.Equal(t, http.StatusBadRequest, do("PUT", "/api/v1/repos/test/webhooks", repoAdmin, bad).StatusCode)
+	require.Equal(t, http.StatusOK, do("PUT", "/api/v1/repos/test/webhooks", repoAdmin, hooks).StatusCode)
+
+	resp := do("GET", "/api/v1/repos/test/webhooks", repoAdmin, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var listed remote.WebhookList
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&listed))
+	require.Len(t, listed.Webhooks, 2)
+	assert.Empty(t, listed.Webhooks[0].Secret, "secrets are never returned")
+	assert.Equal(t, []string{remote.EventTag}, listed.Webhooks[0].Events)
+
+	// A push only reaches the unfiltered hook; a tag reaches both
+	require.Equal(t, http.StatusOK, do("PUT", "/api/v1/repos/test/branches/main", pusher, &remote.BranchUpdateRequest{CommitID: "c1"}).StatusCode)
+	require.Equal(t, http.StatusOK, do("PUT", "/api/v1/repos/test/tags/v1", pusher, &remote.TagRequest{CommitID: "c1"}).StatusCode)
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == 3
+	}, 5*time.Second, 20*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	byHook := map[string][]string{}
+	for _, d := range received {
+		assert.Equal(t, "test", d.event.Repo)
+		byHook[d.hook] = append(byHook[d.hook], d.event.Event)
+	}
+	assert.Equal(t, []string{remote.EventTag}, byHook["signed"])
+	assert.ElementsMatch(t, []string{remote.EventPush, remote.EventTag}, byHook["all"])
+}
+
+// memoryQueueStore is a WebhookQueueStore that keeps the queue as JSON, like
+// a file would.
+type memoryQueueStore struct {
+	mu   sync.Mutex
+	data []byte
+}
+
+func (s *memoryQueueStore) LoadDeliveries() ([]*WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deliveries []*WebhookDelivery
+	if s.data == nil {
+		return nil, nil
+	}
+	err := json.Unmarshal(s.data, &deliveries)
+	return deliveries, err
+}
+
+func (s *memoryQueueStore) SaveDeliveries(deliveries []*WebhookDelivery) error {
+	data, err := json.Marshal(deliveries)
+	s.mu.Lock()
+	s.data = data
+	s.mu.Unlock()
+	return err
+}
+
+func newTestWebhookQueue(t *testing.T, store WebhookQueueStore) *webhookQueue {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	q := newWebhookQueue(newWebhookClient(true), store, logger)
+	q.backoff = 10 * time.Millisecond
+	q.maxBackoff = 40 * time.Millisecond
+	return q
+}
+
+func waitForDelivery(t *testing.T, q *webhookQueue, state string) *WebhookDelivery {
+	t.Helper()
+	var found *WebhookDelivery
+	require.Eventually(t, func() bool {
+		deliveries := q.deliveries(state)
+		if len(deliveries) == 0 {
+			return false
+		}
+		found = deliveries[0]
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+	return found
+}
+
+func TestWebhookQueue_RetriesWithBackoff(t *testing.T) {
+	var mu sync.Mutex
+	var ids []string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		ids = append(ids, r.Header.Get("X-WVC-Delivery"))
+		if len(ids) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	t.Cleanup(receiver.Close)
+
+	q := newTestWebhookQueue(t, nil)
+	q.start()
+	t.Cleanup(q.stop)
+	q.enqueue(receiver.URL, "", &WebhookEvent{Event: remote.EventPush, Repo: "test"})
+
+	d := waitForDelivery(t, q, DeliveryDelivered)
+	assert.Equal(t, 3, d.Attempts)
+	assert.Empty(t, d.LastError)
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, ids, 3)
+	assert.Equal(t, d.ID, ids[0])
+	assert.Equal(t, ids[0], ids[2], "retries keep the delivery ID")
+}
+
+func TestWebhookQueue_ClientErrorFails(t *testing.T) {
+	var calls int
+	var mu sync.Mutex
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	t.Cleanup(receiver.Close)
+
+	q := newTestWebhookQueue(t, nil)
+	q.start()
+	t.Cleanup(q.stop)
+	q.enqueue(receiver.URL, "", &WebhookEvent{Event: remote.EventPush, Repo: "test"})
+
+	d := waitForDelivery(t, q, DeliveryFailed)
+	assert.Equal(t, 1, d.Attempts)
+	assert.Equal(t, "HTTP 400", d.LastError)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 1, calls, "4xx responses are not retried")
+}
+
+func TestWebhookQueue_GivesUpAfterMaxAttempts(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	t.Cleanup(receiver.Close)
+
+	q := newTestWebhookQueue(t, nil)
+	q.maxAttempts = 3
+	q.start()
+	t.Cleanup(q.stop)
+	q.enqueue(receiver.URL, "", &WebhookEvent{Event: remote.EventPush, Repo: "test"})
+
+	d := waitForDelivery(t, q, DeliveryFailed)
+	assert.Equal(t, 3, d.Attempts)
+	assert.Equal(t, "HTTP 502", d.LastError)
+}
+
+func TestWebhookQueue_RetryDelay(t *testing.T) {
+	q := newWebhookQueue(nil, nil, slog.Default())
+	assert.Equal(t, 10*time.Second, q.retryDelay(1))
+	assert.Equal(t, 20*time.Second, q.retryDelay(2))
+	assert.Equal(t, 80*time.Second, q.retryDelay(4))
+	assert.Equal(t, time.Hour, q.retryDelay(20))
+}
+
+func TestWebhookQueue_SurvivesRestart(t *testing.T) {
+	var mu sync.Mutex
+	up := false
+	var received []WebhookEvent
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !up {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var event WebhookEvent
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		received = append(received, event)
+	}))
+	t.Cleanup(receiver.Close)
+
+	store := &memoryQueueStore{}
+	q := newTestWebhookQueue(t, store)
+	q.backoff = time.Hour // no second attempt before the restart
+	q.maxBackoff = time.Hour
+	q.start()
+	q.enqueue(receiver.URL, "s3cret", &WebhookEvent{Event: remote.EventTag, Repo: "test", Branch: "v1"})
+	require.Eventually(t, func() bool {
+		d := q.deliveries(DeliveryPending)
+		return len(d) == 1 && d[0].Attempts == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	q.stop()
+
+	loaded, err := store.LoadDeliveries()
+	require.NoError(t, err)
+	require.Len(t, loaded, 1)
+	assert.Equal(t, "s3cret", loaded[0].Secret, "secrets are persisted for signing retries")
+	id := loaded[0].ID
+
+	// Due now: the restarted queue delivers it straight away
+	loaded[0].NextAttempt = time.Now()
+	require.NoError(t, store.SaveDeliveries(loaded))
+	mu.Lock()
+	up = true
+	mu.Unlock()
+
+	q = newTestWebhookQueue(t, store)
+	q.start()
+	t.Cleanup(q.stop)
+	d := waitForDelivery(t, q, DeliveryDelivered)
+	assert.Equal(t, id, d.ID)
+	assert.Equal(t, 2, d.Attempts)
+	assert.Empty(t, d.Secret, "listed deliveries leave out secrets")
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, received, 1)
+	assert.Equal(t, id, received[0].ID)
+	assert.Equal(t, "v1", received[0].Branch)
+}
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	body := []byte(`{"event":"push"}`)
+	sig := signWebhook("s3cret", body)
+	assert.True(t, VerifyWebhookSignature("s3cret", body, sig))
+	assert.False(t, VerifyWebhookSignature("other", body, sig))
+	assert.False(t, VerifyWebhookSignature("s3cret", []byte(`{"event":"tag"}`), sig))
+	assert.False(t, VerifyWebhookSignature("s3cret", body, ""))
+}
+
+func TestWebhookEvents_AdminAndGC(t *testing.T) {
+	ctx := context.Background()
+	tmpDir := t.TempDir()
+	meta, err := metastore.NewBboltStore(filepath.Join(tmpDir, "meta.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { meta.Close() })
+	blobs, err := blobstore.NewFSStore(filepath.Join(tmpDir, "blobs"))
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	received := map[string][]WebhookEvent{}
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event WebhookEvent
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		mu.Lock()
+		hook := r.URL.Query().Get("hook")
+		received[hook] = append(received[hook], event)
+		mu.Unlock()
+	}))
+	t.Cleanup(receiver.Close)
+	require.NoError(t, meta.PutWebhooks(ctx, []*remote.Webhook{
+		{URL: receiver.URL + "?hook=repo", Events: []string{remote.EventGC}},
+	}))
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	adminToken := "admin-test-token-123"
+	cfg := DefaultServerConfig()
+	cfg.AdminToken = adminToken
+	cfg.WebhookAllowPrivate = true
+	cfg.Webhooks = NewWebhookNotifier(&WebhookConfig{URLs: []string{receiver.URL + "?hook=global"}, AllowPrivate: true}, logger)
+	h, cleanup := Handler(&testRepoOpener{meta: meta, blobs: blobs}, &testTokenStore{tokens: map[string]*TokenInfo{}}, cfg, logger, nil, &testRepoManager{})
+	t.Cleanup(cleanup)
+	ts := httptest.NewServer(h)
+	t.Cleanup(ts.Close)
+
+	do := func(method, path, body string) *http.Response {
+		var r io.Reader
+		if body != "" {
+			r = bytes.NewBufferString(body)
+		}
+		resp, err := http.DefaultClient.Do(adminReq(method, ts.URL+path, adminToken, r))
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+	require.Equal(t, http.StatusCreated, do("POST", "/admin/repos", `{"name":"test"}`).StatusCode)
+	require.Equal(t, http.StatusOK, do("POST", "/admin/repos/test/gc", "").StatusCode)
+	require.Equal(t, http.StatusNoContent, do("DELETE", "/admin/repos/test", "").StatusCode)
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received["global"]) == 3 && len(received["repo"]) == 1
+	}, 5*time.Second, 20*time.Millisecond)
+	mu.Lock()
+	var global []string
+	for _, e := range received["global"] {
+		assert.Equal(t, "test", e.Repo)
+		assert.NotEmpty(t, e.ID)
+		global = append(global, e.Event)
+	}
+	assert.ElementsMatch(t, []string{remote.EventRepoCreate, remote.EventGC, remote.EventRepoDelete}, global)
+	gc := received["repo"][0]
+	assert.Equal(t, remote.EventGC, gc.Event)
+	require.NotNil(t, gc.GC, "gc events carry the result")
+	mu.Unlock()
+
+	// The queue records a delivery just after the receiver answers
+	var listed struct {
+		Deliveries []*WebhookDelivery `json:"deliveries"`
+	}
+	require.Eventually(t, func() bool {
+		resp := do("GET", "/admin/webhooks/deliveries?state=delivered", "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&listed))
+		return len(listed.Deliveries) == 4
+	}, 5*time.Second, 20*time.Millisecond)
+	for _, d := range listed.Deliveries {
+		assert.Equal(t, DeliveryDelivered, d.State)
+		assert.Equal(t, 1, d.Attempts)
+	}
+
+	assert.Equal(t, http.StatusBadRequest, do("GET", "/admin/webhooks/deliveries?state=lost", "").StatusCode)
+	resp, err := http.Get(ts.URL + "/admin/webhooks/deliveries")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestPushWebhookSummary(t *testing.T) {
+	ctx := context.Background()
+	tmpDir := t.TempDir()
+	meta, err := metastore.NewBboltStore(filepath.Join(tmpDir, "meta.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { meta.Close() })
+	blobs, err := blobstore.NewFSStore(filepath.Join(tmpDir, "blobs"))
+	require.NoError(t, err)
+
+	op := func(typ models.OperationType, class string) *models.Operation {
+		return &models.Operation{Type: typ, ClassName: class, ObjectID: "obj"}
+	}
+	now := time.Now()
+	// c1 <- c2 <- c4 (merge of c3, which forks from c1)
+	for _, b := range []*remote.CommitBundle{
+		{Commit: &models.Commit{ID: "c1", Message: "first", Timestamp: now},
+			Operations: []*models.Operation{op(models.OperationInsert, "Article")}},
+		{Commit: &models.Commit{ID: "c2", ParentID: "c1", Message: "second", Timestamp: now},
+			Operations: []*models.Operation{op(models.OperationUpdate, "Article"), op(models.OperationInsert, "Author")}},
+		{Commit: &models.Commit{ID: "c3", ParentID: "c1", Message: "side", Timestamp: now},
+			Operations: []*models.Operation{op(models.OperationDelete, "Article")}},
+		{Commit: &models.Commit{ID: "c4", ParentID: "c2", MergeParentID: "c3", Message: "merge side", Author: "ana", Timestamp: now},
+			Operations: []*models.Operation{op(models.OperationDelete, "Article")}},
+	} {
+		require.NoError(t, meta.InsertCommitBundle(ctx, b))
+	}
+
+	var mu sync.Mutex
+	var received []WebhookEvent
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event WebhookEvent
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		mu.Lock()
+		received = append(received, event)
+		mu.Unlock()
+	}))
+	t.Cleanup(receiver.Close)
+	require.NoError(t, meta.PutWebhooks(ctx, []*remote.Webhook{{URL: receiver.URL, Events: []string{remote.EventPush}}}))
+
+	token := "push-token"
+	tokens := &testTokenStore{tokens: map[string]*TokenInfo{
+		HashToken(token): {ID: "tok-1", TokenHash: HashToken(token), Repos: []string{"*"}, Permission: "rw"},
+	}}
+	cfg := DefaultServerConfig()
+	cfg.WebhookAllowPrivate = true
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	h, cleanup := Handler(&testRepoOpener{meta: meta, blobs: blobs}, tokens, cfg, logger, nil, nil)
+	t.Cleanup(cleanup)
+	ts := httptest.NewServer(h)
+	t.Cleanup(ts.Close)
+	push := func(req *remote.BranchUpdateRequest) {
+		data, err := json.Marshal(req)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(authReq("PUT", ts.URL+"/api/v1/repos/test/branches/main", token, bytes.NewReader(data)))
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	push(&remote.BranchUpdateRequest{CommitID: "c1"})
+	push(&remote.BranchUpdateRequest{CommitID: "c4", Expected: "c1"})
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == 2
+	}, 5*time.Second, 20*time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	sort.Slice(received, func(i, j int) bool { return received[i].CommitID < received[j].CommitID })
+
+	first := received[0]
+	assert.Equal(t, "first", first.Message)
+	assert.Equal(t, 1, first.Commits)
+	assert.Equal(t, []string{"Article"}, first.ChangedClasses)
+
+	second := received[1]
+	assert.Equal(t, "c1", second.Before)
+	assert.Equal(t, "merge side", second.Message)
+	assert.Equal(t, "ana", second.Author)
+	assert.Equal(t, 3, second.Commits)
+	assert.False(t, second.Truncated)
+	assert.Equal(t, []string{"Article", "Author"}, second.ChangedClasses)
+	// The merge's own delete repeats c3's and is not counted
+	assert.Equal(t, map[string]*WebhookClassOps{
+		"Article": {Updated: 1, Deleted: 1},
+		"Author":  {Inserted: 1},
+	}, second.Operations)
+}
@@ -0,0 +1,307 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhook delivery states.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// Webhook delivery queue limits.
+const (
+	webhookMaxAttempts = 10
+	webhookBackoff     = 10 * time.Second // doubled after each failed attempt
+	webhookMaxBackoff  = time.Hour
+	webhookMaxPending  = 10000
+	webhookHistory     = 500 // finished deliveries kept for the status endpoint
+	webhookConcurrency = 10
+)
+
+// WebhookDelivery is one event on its way to one webhook URL. The secret is
+// persisted with the queue so retries after a restart are still signed; the
+// status endpoint never returns it.
+type WebhookDelivery struct {
+	ID          string        `json:"id"`
+	URL         string        `json:"url"`
+	Secret      string        `json:"secret,omitempty"`
+	Event       *WebhookEvent `json:"event"`
+	State       string        `json:"state"`
+	Attempts    int           `json:"attempts"`
+	NextAttempt time.Time     `json:"next_attempt,omitzero"`
+	LastError   string        `json:"last_error,omitempty"`
+	CreatedAt   time.Time     `json:"created_at"`
+	FinishedAt  time.Time     `json:"finished_at,omitzero"`
+}
+
+// WebhookQueueStore persists the webhook delivery queue, pending and recently
+// finished deliveries alike, so deliveries survive restarts.
+type WebhookQueueStore interface {
+	LoadDeliveries() ([]*WebhookDelivery, error)
+	SaveDeliveries(deliveries []*WebhookDelivery) error
+}
+
+// webhookQueue delivers webhook events in the background, retrying failed
+// deliveries with exponential backoff until they succeed, fail permanently,
+// or run out of attempts.
+type webhookQueue struct {
+	client      *http.Client
+	store       WebhookQueueStore // nil keeps the queue in memory
+	logger      *slog.Logger
+	now         func() time.Time
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	maxAttempts int
+
+	mu       sync.Mutex
+	pending  []*WebhookDelivery // oldest first
+	history  []*WebhookDelivery // finished, oldest first
+	inflight map[string]bool
+
+	wake   chan struct{}
+	sem    chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newWebhookQueue(client *http.Client, store WebhookQueueStore, logger *slog.Logger) *webhookQueue {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &webhookQueue{
+		client:      client,
+		store:       store,
+		logger:      logger,
+		now:         time.Now,
+		backoff:     webhookBackoff,
+		maxBackoff:  webhookMaxBackoff,
+		maxAttempts: webhookMaxAttempts,
+		inflight:    make(map[string]bool),
+		wake:        make(chan struct{}, 1),
+		sem:         make(chan struct{}, webhookConcurrency),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// start loads the persisted queue and delivers until stop.
+func (q *webhookQueue) start() {
+	if q.store != nil {
+		deliveries, err := q.store.LoadDeliveries()
+		if err != nil {
+			q.logger.Error("webhook: load delivery queue", "error", err)
+		}
+		q.mu.Lock()
+		for _, d := range deliveries {
+			if d.State == DeliveryPending {
+				q.pending = append(q.pending, d)
+			} else {
+				q.history = append(q.history, d)
+			}
+		}
+		q.mu.Unlock()
+		if len(q.pending) > 0 {
+			q.logger.Info("webhook: resuming queued deliveries", "count", len(q.pending))
+		}
+	}
+
+	q.wg.Add(1)
+	go func() {
+		defer q.wg.Done()
+		for {
+			wait := q.dispatch()
+			timer := time.NewTimer(wait)
+			select {
+			case <-q.ctx.Done():
+				timer.Stop()
+				return
+			case <-q.wake:
+			case <-timer.C:
+			}
+			timer.Stop()
+		}
+	}()
+}
+
+// stop ends delivery and waits for attempts in flight. Deliveries cut short
+// stay pending for the next start.
+func (q *webhookQueue) stop() {
+	q.cancel()
+	q.wg.Wait()
+}
+
+// enqueue queues a delivery of event to url.
+func (q *webhookQueue) enqueue(url, secret string, event *WebhookEvent) {
+	e := *event
+	e.ID = uuid.NewString()
+	d := &WebhookDelivery{
+		ID:        e.ID,
+		URL:       url,
+		Secret:    secret,
+		Event:     &e,
+		State:     DeliveryPending,
+		CreatedAt: q.now(),
+	}
+
+	q.mu.Lock()
+	if len(q.pending) >= webhookMaxPending {
+		dropped := q.pending[0]
+		q.pending = q.pending[1:]
+		q.finish(dropped, DeliveryFailed, "dropped: delivery queue full")
+		q.logger.Warn("webhook: delivery queue full, dropped oldest delivery", "url", dropped.URL, "event", dropped.Event.Event)
+	}
+	q.pending = append(q.pending, d)
+	q.save()
+	q.mu.Unlock()
+	q.signal()
+}
+
+// dispatch starts every due delivery there is capacity for and returns how
+// long to wait before the next one is due.
+func (q *webhookQueue) dispatch() time.Duration {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	wait := time.Hour
+	for _, d := range q.pending {
+		if q.inflight[d.ID] {
+			continue
+		}
+		if d.NextAttempt.After(now) {
+			wait = min(wait, d.NextAttempt.Sub(now))
+			continue
+		}
+		select {
+		case q.sem <- struct{}{}:
+		default:
+			return wait // a finishing delivery wakes the loop
+		}
+		q.inflight[d.ID] = true
+		q.wg.Add(1)
+		go func() {
+			defer q.wg.Done()
+			defer func() { <-q.sem }()
+			q.attempt(d)
+		}()
+	}
+	return wait
+}
+
+// attempt makes one delivery attempt and records the outcome.
+func (q *webhookQueue) attempt(d *WebhookDelivery) {
+	defer q.signal()
+
+	data, err := json.Marshal(d.Event)
+	retry := false
+	if err == nil {
+		retry, err = deliverWebhook(q.ctx, q.client, d.URL, d.Secret, d.ID, data)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.inflight, d.ID)
+	if q.ctx.Err() != nil {
+		return // shutting down; the delivery stays pending
+	}
+
+	d.Attempts++
+	switch {
+	case err == nil:
+		q.removePending(d)
+		q.finish(d, DeliveryDelivered, "")
+		q.logger.Debug("webhook: delivered", "url", d.URL, "event", d.Event.Event, "attempts", d.Attempts)
+	case !retry || d.Attempts >= q.maxAttempts:
+		q.removePending(d)
+		q.finish(d, DeliveryFailed, err.Error())
+		q.logger.Warn("webhook: delivery failed", "url", d.URL, "event", d.Event.Event, "attempts", d.Attempts, "error", err)
+	default:
+		d.LastError = err.Error()
+		d.NextAttempt = q.now().Add(q.retryDelay(d.Attempts))
+		q.logger.Info("webhook: delivery will be retried", "url", d.URL, "event", d.Event.Event,
+			"attempts", d.Attempts, "next_attempt", d.NextAttempt, "error", err)
+	}
+	q.save()
+}
+
+// retryDelay returns the backoff after the given number of failed attempts.
+func (q *webhookQueue) retryDelay(attempts int) time.Duration {
+	delay := q.backoff
+	for i := 1; i < attempts && delay < q.maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, q.maxBackoff)
+}
+
+// removePending drops a delivery from the pending list. Callers hold mu.
+func (q *webhookQueue) removePending(d *WebhookDelivery) {
+	for i, p := range q.pending {
+		if p == d {
+			q.pending = append(q.pending[:i:i], q.pending[i+1:]...)
+			return
+		}
+	}
+}
+
+// finish records a delivery as finished in the bounded history. Callers hold mu.
+func (q *webhookQueue) finish(d *WebhookDelivery, state, lastError string) {
+	d.State = state
+	d.LastError = lastError
+	d.NextAttempt = time.Time{}
+	d.FinishedAt = q.now()
+	q.history = append(q.history, d)
+	if len(q.history) > webhookHistory {
+		q.history = q.history[len(q.history)-webhookHistory:]
+	}
+}
+
+// save persists the queue. Callers hold mu.
+func (q *webhookQueue) save() {
+	if q.store == nil {
+		return
+	}
+	all := make([]*WebhookDelivery, 0, len(q.history)+len(q.pending))
+	all = append(all, q.history...)
+	all = append(all, q.pending...)
+	if err := q.store.SaveDeliveries(all); err != nil {
+		q.logger.Error("webhook: save delivery queue", "error", err)
+	}
+}
+
+func (q *webhookQueue) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// deliveries returns copies of the pending and finished deliveries in the
+// given state (all when empty), newest first, without their secrets.
+func (q *webhookQueue) deliveries(state string) []*WebhookDelivery {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	out := []*WebhookDelivery{}
+	for _, list := range [][]*WebhookDelivery{q.pending, q.history} {
+		for _, d := range list {
+			if state != "" && d.State != state {
+				continue
+			}
+			c := *d
+			c.Secret = ""
+			out = append(out, &c)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out
+}
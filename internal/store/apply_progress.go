@@ -0,0 +1,39 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+)
+
+// keyApplyProgress is the kv key holding the outcome of the last partial apply.
+const keyApplyProgress = "APPLY_PROGRESS"
+
+// GetApplyProgress returns the record of the last apply that left objects
+// unwritten, or nil if the last apply completed.
+func (s *Store) GetApplyProgress() (*models.ApplyProgress, error) {
+	v, err := s.getLocalValue(keyApplyProgress)
+	if err != nil || v == "" {
+		return nil, err
+	}
+	var progress models.ApplyProgress
+	if err := json.Unmarshal([]byte(v), &progress); err != nil {
+		return nil, fmt.Errorf("unmarshal apply progress: %w", err)
+	}
+	return &progress, nil
+}
+
+// SaveApplyProgress records the outcome of a partial apply, replacing any previous one.
+func (s *Store) SaveApplyProgress(progress *models.ApplyProgress) error {
+	data, err := json.Marshal(progress)
+	if err != nil {
+		return fmt.Errorf("marshal apply progress: %w", err)
+	}
+	return s.setLocalValue(keyApplyProgress, string(data))
+}
+
+// ClearApplyProgress removes the partial apply record.
+func (s *Store) ClearApplyProgress() error {
+	return s.deleteLocalValue(keyApplyProgress)
+}
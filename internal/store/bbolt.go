@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/kilupskalvis/wvc/internal/fsutil"
 	bolt "go.etcd.io/bbolt"
 )
 
@@ -18,8 +19,10 @@ var (
 	bucketOperations    = []byte("operations")
 	bucketBranches      = []byte("branches")
 	bucketSchemaVers    = []byte("schema_versions")
-	bucketSchemaIndex   = []byte("schema_index") // maps commit_id -> schema key for lookup
+	bucketSchemaIndex   = []byte("schema_index")  // maps commit_id -> schema key for lookup
+	bucketSchemaHashes  = []byte("schema_hashes") // maps schema hash -> schema key for deduplication
 	bucketVectorBlobs   = []byte("vector_blobs")
+	bucketVectorChunks  = []byte("vector_chunks") // raw chunks of large vector blobs, keyed "hash/seq"
 	bucketKV            = []byte("kv")
 	bucketKnownObjects  = []byte("known_objects")
 	bucketStagedChanges = []byte("staged_changes")
@@ -41,10 +44,23 @@ var (
 // Store represents the bbolt database store.
 type Store struct {
 	db *bolt.DB
+	// worktree names the linked worktree whose local state the store reads
+	// and writes; empty for the main worktree
+	worktree string
+	// fetchVector downloads vector blobs missing from a partial clone
+	fetchVector VectorFetcher
+	// fetchPayloads downloads operation payloads a partial clone omitted
+	fetchPayloads PayloadFetcher
+	// snapshotInterval is the number of commits between state snapshots
+	snapshotInterval int
 }
 
 // New opens or creates a bbolt database at the given path.
 func New(dbPath string) (*Store, error) {
+	dbPath, err := fsutil.LongPath(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("resolve database path: %w", err)
+	}
 	dir := filepath.Dir(dbPath)
 	if dir != "" && dir != "." {
 		if err := os.MkdirAll(dir, 0755); err != nil {
@@ -52,7 +68,14 @@ func New(dbPath string) (*Store, error) {
 		}
 	}
 
-	db, err := bolt.Open(dbPath, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	// Wait out a compaction replacing the file; once open, the bbolt lock
+	// keeps Compact away
+	unlock, err := fsutil.LockFile(compactLockPath(dbPath), openTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("open database %s (is another wvc process running?): %w", dbPath, err)
+	}
+	defer unlock()
+	db, err := bolt.Open(dbPath, 0600, &bolt.Options{Timeout: openTimeout})
 	if err != nil {
 		return nil, fmt.Errorf("open database %s (is another wvc process running?): %w", dbPath, err)
 	}
@@ -60,6 +83,18 @@ func New(dbPath string) (*Store, error) {
 	return &Store{db: db}, nil
 }
 
+// openTimeout bounds how long opening the database waits for another
+// process to release it.
+const openTimeout = 5 * time.Second
+
+// SetSyncPolicy sets how the database flushes commits to disk. bbolt syncs
+// the whole file on every write transaction, so fsutil.SyncFile and
+// fsutil.SyncFull behave alike; fsutil.SyncNone skips the sync, which is
+// faster on slow disks but can lose recent writes in a crash.
+func (s *Store) SetSyncPolicy(policy fsutil.SyncPolicy) {
+	s.db.NoSync = policy == fsutil.SyncNone
+}
+
 // Close closes the database.
 func (s *Store) Close() error {
 	if s.db == nil {
@@ -77,7 +112,9 @@ func (s *Store) Initialize() error {
 			bucketBranches,
 			bucketSchemaVers,
 			bucketSchemaIndex,
+			bucketSchemaHashes,
 			bucketVectorBlobs,
+			bucketVectorChunks,
 			bucketKV,
 			bucketKnownObjects,
 			bucketStagedChanges,
@@ -134,6 +171,17 @@ func (s *Store) SetValue(key, value string) error {
 	})
 }
 
+// DeleteValue removes a key from the key-value bucket.
+func (s *Store) DeleteValue(key string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketKV)
+		if b == nil {
+			return fmt.Errorf("kv bucket not found")
+		}
+		return b.Delete([]byte(key))
+	})
+}
+
 // RunMigrations checks the schema version and applies any needed migrations.
 func (s *Store) RunMigrations() error {
 	return s.db.Update(func(tx *bolt.Tx) error {
@@ -144,9 +192,32 @@ func (s *Store) RunMigrations() error {
 		versionBytes := kvBucket.Get([]byte("schema_version"))
 		if versionBytes == nil {
 			// Pre-migration database, set to version 1
-			return kvBucket.Put([]byte("schema_version"), []byte("1"))
+			if err := kvBucket.Put([]byte("schema_version"), []byte("1")); err != nil {
+				return err
+			}
+			versionBytes = []byte("1")
+		}
+
+		// Version 2: index schema versions by hash for deduplication
+		if string(versionBytes) == "1" {
+			if err := indexSchemaHashes(tx); err != nil {
+				return fmt.Errorf("migrate schema hash index: %w", err)
+			}
+			if err := kvBucket.Put([]byte("schema_version"), []byte("2")); err != nil {
+				return err
+			}
+			versionBytes = []byte("2")
+		}
+
+		// Version 3: index committed operations by object
+		if string(versionBytes) == "2" {
+			if err := indexObjectCommits(tx); err != nil {
+				return fmt.Errorf("migrate object commits index: %w", err)
+			}
+			if err := kvBucket.Put([]byte("schema_version"), []byte("3")); err != nil {
+				return err
+			}
 		}
-		// Current version is 1, no migrations needed yet
 		return nil
 	})
 }
@@ -0,0 +1,38 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+)
+
+// keyBisectState is the kv key holding the bisect session, if any.
+const keyBisectState = "BISECT_STATE"
+
+// GetBisectState returns the bisect session, or nil if none is in progress.
+func (s *Store) GetBisectState() (*models.BisectState, error) {
+	v, err := s.getLocalValue(keyBisectState)
+	if err != nil || v == "" {
+		return nil, err
+	}
+	var state models.BisectState
+	if err := json.Unmarshal([]byte(v), &state); err != nil {
+		return nil, fmt.Errorf("unmarshal bisect state: %w", err)
+	}
+	return &state, nil
+}
+
+// SaveBisectState records the bisect session, replacing any previous one.
+func (s *Store) SaveBisectState(state *models.BisectState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("marshal bisect state: %w", err)
+	}
+	return s.setLocalValue(keyBisectState, string(data))
+}
+
+// ClearBisectState removes the bisect session.
+func (s *Store) ClearBisectState() error {
+	return s.deleteLocalValue(keyBisectState)
+}
@@ -30,6 +30,9 @@ func (s *Store) CreateBranch(name, commitID string) error {
 		if err != nil {
 			return fmt.Errorf("marshal branch: %w", err)
 		}
+		if err := logBranch(tx, name, bucket.Get([]byte(name)), commitID); err != nil {
+			return err
+		}
 
 		return bucket.Put([]byte(name), data)
 	})
@@ -110,6 +113,9 @@ func (s *Store) UpdateBranch(name, commitID string) error {
 			return fmt.Errorf("unmarshal branch: %w", err)
 		}
 
+		if err := logRef(tx, name, branch.CommitID, commitID); err != nil {
+			return err
+		}
 		branch.CommitID = commitID
 
 		updatedData, err := json.Marshal(branch)
@@ -121,6 +127,36 @@ func (s *Store) UpdateBranch(name, commitID string) error {
 	})
 }
 
+// SetBranchClasses sets the classes a branch is scoped to. An empty list
+// removes the scope.
+func (s *Store) SetBranchClasses(name string, classes []string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketBranches)
+		if bucket == nil {
+			return fmt.Errorf("branches bucket not found")
+		}
+
+		data := bucket.Get([]byte(name))
+		if data == nil {
+			return fmt.Errorf("branch not found: %s", name)
+		}
+
+		var branch models.Branch
+		if err := json.Unmarshal(data, &branch); err != nil {
+			return fmt.Errorf("unmarshal branch: %w", err)
+		}
+
+		branch.Classes = classes
+
+		updatedData, err := json.Marshal(branch)
+		if err != nil {
+			return fmt.Errorf("marshal branch: %w", err)
+		}
+
+		return bucket.Put([]byte(name), updatedData)
+	})
+}
+
 // DeleteBranch removes a branch by name.
 func (s *Store) DeleteBranch(name string) error {
 	return s.db.Update(func(tx *bolt.Tx) error {
@@ -133,6 +169,9 @@ func (s *Store) DeleteBranch(name string) error {
 		if data == nil {
 			return fmt.Errorf("branch not found: %s", name)
 		}
+		if err := logBranch(tx, name, data, ""); err != nil {
+			return err
+		}
 
 		return bucket.Delete([]byte(name))
 	})
@@ -144,7 +183,7 @@ func (s *Store) GetCurrentBranch() (string, error) {
 	var branchName string
 
 	err := s.db.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket(bucketKV)
+		bucket := s.localKV(tx)
 		if bucket == nil {
 			return nil
 		}
@@ -166,7 +205,7 @@ func (s *Store) GetCurrentBranch() (string, error) {
 // SetCurrentBranch sets the current HEAD branch name in the kv bucket.
 func (s *Store) SetCurrentBranch(name string) error {
 	return s.db.Update(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket(bucketKV)
+		bucket := s.localKV(tx)
 		if bucket == nil {
 			return fmt.Errorf("kv bucket not found")
 		}
@@ -191,6 +230,9 @@ func (s *Store) UpdateBranchAndHEAD(branchName, commitID string) error {
 		if err := json.Unmarshal(data, &branch); err != nil {
 			return fmt.Errorf("unmarshal branch: %w", err)
 		}
+		if err := logRef(tx, branchName, branch.CommitID, commitID); err != nil {
+			return err
+		}
 		branch.CommitID = commitID
 		updatedData, err := json.Marshal(branch)
 		if err != nil {
@@ -201,10 +243,13 @@ func (s *Store) UpdateBranchAndHEAD(branchName, commitID string) error {
 		}
 
 		// Update HEAD
-		kvBucket := tx.Bucket(bucketKV)
+		kvBucket := s.localKV(tx)
 		if kvBucket == nil {
 			return fmt.Errorf("kv bucket not found (database not initialized?)")
 		}
+		if err := s.logHEAD(tx, commitID); err != nil {
+			return err
+		}
 		return kvBucket.Put([]byte("HEAD"), []byte(commitID))
 	})
 }
@@ -225,14 +270,20 @@ func (s *Store) CreateBranchAndHEAD(branchName, commitID string) error {
 		if err != nil {
 			return fmt.Errorf("marshal branch: %w", err)
 		}
+		if err := logBranch(tx, branchName, branchBucket.Get([]byte(branchName)), commitID); err != nil {
+			return err
+		}
 		if err := branchBucket.Put([]byte(branchName), branchData); err != nil {
 			return err
 		}
 
-		kvBucket := tx.Bucket(bucketKV)
+		kvBucket := s.localKV(tx)
 		if kvBucket == nil {
 			return fmt.Errorf("kv bucket not found (database not initialized?)")
 		}
+		if err := s.logHEAD(tx, commitID); err != nil {
+			return err
+		}
 		return kvBucket.Put([]byte("HEAD"), []byte(commitID))
 	})
 }
@@ -15,61 +15,89 @@ import (
 // to store downloaded data. The operation is idempotent — if the commit already exists,
 // no changes are made.
 func (s *Store) InsertCommitBundle(bundle *remote.CommitBundle) error {
-	if bundle == nil || bundle.Commit == nil {
-		return fmt.Errorf("invalid commit bundle: nil commit")
+	return s.InsertCommitBundles([]*remote.CommitBundle{bundle})
+}
+
+// InsertCommitBundles inserts several commit bundles in a single write transaction.
+// Either all bundles are stored or none are. Bundles whose commit already exists
+// are skipped, as with InsertCommitBundle.
+func (s *Store) InsertCommitBundles(bundles []*remote.CommitBundle) error {
+	for _, bundle := range bundles {
+		if bundle == nil || bundle.Commit == nil {
+			return fmt.Errorf("invalid commit bundle: nil commit")
+		}
 	}
 
 	return s.db.Update(func(tx *bolt.Tx) error {
-		commitBucket := tx.Bucket(bucketCommits)
-		if commitBucket == nil {
-			return fmt.Errorf("commits bucket not found (database not initialized?)")
-		}
-		opBucket := tx.Bucket(bucketOperations)
-		if opBucket == nil {
-			return fmt.Errorf("operations bucket not found (database not initialized?)")
+		for _, bundle := range bundles {
+			if err := insertCommitBundle(tx, bundle); err != nil {
+				return err
+			}
 		}
+		return nil
+	})
+}
 
-		// Idempotent: skip if commit already exists
-		if commitBucket.Get([]byte(bundle.Commit.ID)) != nil {
-			return nil
-		}
+// insertCommitBundle stores one bundle within an open write transaction.
+func insertCommitBundle(tx *bolt.Tx, bundle *remote.CommitBundle) error {
+	commitBucket := tx.Bucket(bucketCommits)
+	if commitBucket == nil {
+		return fmt.Errorf("commits bucket not found (database not initialized?)")
+	}
+	opBucket := tx.Bucket(bucketOperations)
+	if opBucket == nil {
+		return fmt.Errorf("operations bucket not found (database not initialized?)")
+	}
+
+	// Idempotent: skip if commit already exists
+	if commitBucket.Get([]byte(bundle.Commit.ID)) != nil {
+		return nil
+	}
+
+	// Store commit
+	commitData, err := json.Marshal(bundle.Commit)
+	if err != nil {
+		return fmt.Errorf("marshal commit: %w", err)
+	}
+	if err := commitBucket.Put([]byte(bundle.Commit.ID), commitData); err != nil {
+		return fmt.Errorf("store commit: %w", err)
+	}
 
-		// Store commit
-		commitData, err := json.Marshal(bundle.Commit)
+	// Store operations
+	for i, op := range bundle.Operations {
+		op.CommitID = bundle.Commit.ID
+		op.Seq = i
+
+		stored, err := prepareOperation(tx, op, false, nil)
 		if err != nil {
-			return fmt.Errorf("marshal commit: %w", err)
+			return fmt.Errorf("store operation %d: %w", i, err)
 		}
-		if err := commitBucket.Put([]byte(bundle.Commit.ID), commitData); err != nil {
-			return fmt.Errorf("store commit: %w", err)
+		opData, err := json.Marshal(stored)
+		if err != nil {
+			return fmt.Errorf("marshal operation %d: %w", i, err)
 		}
-
-		// Store operations
-		for i, op := range bundle.Operations {
-			op.CommitID = bundle.Commit.ID
-			op.Seq = i
-
-			opData, err := json.Marshal(op)
-			if err != nil {
-				return fmt.Errorf("marshal operation %d: %w", i, err)
-			}
-			key := operationKey(bundle.Commit.ID, i)
-			if err := opBucket.Put(key, opData); err != nil {
-				return fmt.Errorf("store operation %d: %w", i, err)
-			}
+		key := operationKey(bundle.Commit.ID, i)
+		if err := opBucket.Put(key, opData); err != nil {
+			return fmt.Errorf("store operation %d: %w", i, err)
+		}
+		if err := indexObjectCommit(tx, op); err != nil {
+			return err
 		}
+	}
 
-		// Store schema snapshot if present
-		if bundle.Schema != nil {
-			if err := insertBundleSchema(tx, bundle.Commit.ID, bundle.Schema); err != nil {
-				return fmt.Errorf("store schema: %w", err)
-			}
+	// Store schema snapshot if present
+	if bundle.Schema != nil {
+		if err := insertBundleSchema(tx, bundle.Commit.ID, bundle.Schema); err != nil {
+			return fmt.Errorf("store schema: %w", err)
 		}
+	}
 
-		return nil
-	})
+	return nil
 }
 
-// insertBundleSchema stores a schema snapshot from a remote bundle.
+// insertBundleSchema attaches a schema snapshot from a remote bundle to a commit.
+// Snapshots are deduplicated by hash; a snapshot without JSON is a reference to
+// a schema that must already be stored locally.
 func insertBundleSchema(tx *bolt.Tx, commitID string, schema *remote.SchemaSnapshot) error {
 	countersBucket := tx.Bucket(bucketCounters)
 	schemasBucket := tx.Bucket(bucketSchemaVers)
@@ -78,6 +106,10 @@ func insertBundleSchema(tx *bolt.Tx, commitID string, schema *remote.SchemaSnaps
 	if countersBucket == nil || schemasBucket == nil || indexBucket == nil {
 		return fmt.Errorf("required buckets not found")
 	}
+	hashBucket, err := tx.CreateBucketIfNotExists(bucketSchemaHashes)
+	if err != nil {
+		return fmt.Errorf("create bucket: %w", err)
+	}
 
 	// Check if schema already exists for this commit
 	indexKey := []byte(fmt.Sprintf("commit:%s", commitID))
@@ -85,6 +117,13 @@ func insertBundleSchema(tx *bolt.Tx, commitID string, schema *remote.SchemaSnaps
 		return nil // Already exists
 	}
 
+	if key := hashBucket.Get([]byte(schema.SchemaHash)); key != nil {
+		return attachSchemaToCommit(tx, commitID, append([]byte(nil), key...))
+	}
+	if len(schema.SchemaJSON) == 0 {
+		return fmt.Errorf("%w: %s", ErrSchemaNotFound, schema.SchemaHash)
+	}
+
 	// Get next schema ID
 	counterKey := []byte("next_schema_id")
 	var schemaID int64 = 1
@@ -112,9 +151,12 @@ func insertBundleSchema(tx *bolt.Tx, commitID string, schema *remote.SchemaSnaps
 	if err := schemasBucket.Put(schemaKey, svData); err != nil {
 		return fmt.Errorf("store schema version: %w", err)
 	}
+	if err := hashBucket.Put([]byte(schema.SchemaHash), schemaKey); err != nil {
+		return fmt.Errorf("store schema hash index: %w", err)
+	}
 
 	// Update index
-	if err := indexBucket.Put(indexKey, schemaKey); err != nil {
+	if err := attachSchemaToCommit(tx, commitID, schemaKey); err != nil {
 		return fmt.Errorf("store schema index: %w", err)
 	}
 
@@ -130,3 +130,72 @@ func TestInsertCommitBundle_Chain(t *testing.T) {
 	assert.True(t, ancestors["c2"])
 	assert.True(t, ancestors["c1"])
 }
+
+func TestInsertCommitBundles_Batch(t *testing.T) {
+	st := newTestStore(t)
+
+	existing := &remote.CommitBundle{Commit: &models.Commit{ID: "c1", Message: "first"}}
+	require.NoError(t, st.InsertCommitBundle(existing))
+
+	bundles := []*remote.CommitBundle{
+		{Commit: &models.Commit{ID: "c1", Message: "ignored"}},
+		{
+			Commit:     &models.Commit{ID: "c2", ParentID: "c1", Message: "second"},
+			Operations: []*models.Operation{{Type: models.OperationInsert, ClassName: "Article", ObjectID: "obj-001"}},
+		},
+		{Commit: &models.Commit{ID: "c3", ParentID: "c2", Message: "third"}},
+	}
+	require.NoError(t, st.InsertCommitBundles(bundles))
+
+	c1, err := st.GetCommit("c1")
+	require.NoError(t, err)
+	assert.Equal(t, "first", c1.Message)
+
+	ops, err := st.GetOperationsByCommit("c2")
+	require.NoError(t, err)
+	require.Len(t, ops, 1)
+	assert.Equal(t, "c2", ops[0].CommitID)
+
+	has, err := st.HasCommit("c3")
+	require.NoError(t, err)
+	assert.True(t, has)
+}
+
+func TestInsertCommitBundles_RejectsNilCommit(t *testing.T) {
+	st := newTestStore(t)
+
+	err := st.InsertCommitBundles([]*remote.CommitBundle{
+		{Commit: &models.Commit{ID: "c1"}},
+		{},
+	})
+	require.Error(t, err)
+
+	has, err := st.HasCommit("c1")
+	require.NoError(t, err)
+	assert.False(t, has)
+}
+
+func TestInsertCommitBundle_SchemaReference(t *testing.T) {
+	st := newTestStore(t)
+
+	full := &remote.CommitBundle{
+		Commit: &models.Commit{ID: "c1"},
+		Schema: &remote.SchemaSnapshot{SchemaJSON: []byte(`{"classes":[]}`), SchemaHash: "h1"},
+	}
+	ref := &remote.CommitBundle{
+		Commit: &models.Commit{ID: "c2", ParentID: "c1"},
+		Schema: &remote.SchemaSnapshot{SchemaHash: "h1"},
+	}
+	require.NoError(t, st.InsertCommitBundles([]*remote.CommitBundle{full, ref}))
+
+	sv, err := st.GetSchemaVersionByCommit("c2")
+	require.NoError(t, err)
+	require.NotNil(t, sv)
+	assert.Equal(t, []byte(`{"classes":[]}`), sv.SchemaJSON)
+
+	unknown := &remote.CommitBundle{
+		Commit: &models.Commit{ID: "c3", ParentID: "c2"},
+		Schema: &remote.SchemaSnapshot{SchemaHash: "missing"},
+	}
+	assert.ErrorIs(t, st.InsertCommitBundle(unknown), ErrSchemaNotFound)
+}
@@ -0,0 +1,154 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/fsutil"
+	"github.com/kilupskalvis/wvc/internal/models"
+	bolt "go.etcd.io/bbolt"
+	berrors "go.etcd.io/bbolt/errors"
+)
+
+// CloneDatabase copies the database at srcPath to a new file at dstPath for
+// a local clone. The source is held open read-only during the copy, so no
+// wvc process can write to it midway. Where the filesystem supports it the
+// copy is copy-on-write, sharing vector blobs and history with the source
+// until either repository rewrites them; cloned reports whether it was. The
+// file is never hard-linked, since both repositories go on writing to it.
+func CloneDatabase(srcPath, dstPath string, policy fsutil.SyncPolicy) (cloned bool, err error) {
+	srcPath, err = fsutil.LongPath(srcPath)
+	if err != nil {
+		return false, fmt.Errorf("resolve database path: %w", err)
+	}
+	dstPath, err = fsutil.LongPath(dstPath)
+	if err != nil {
+		return false, fmt.Errorf("resolve database path: %w", err)
+	}
+
+	src, err := bolt.Open(srcPath, 0600, &bolt.Options{ReadOnly: true, Timeout: 5 * time.Second})
+	if err != nil {
+		return false, fmt.Errorf("open database %s (is another wvc process running?): %w", srcPath, err)
+	}
+	defer src.Close()
+
+	cloned, err = fsutil.CopyFile(srcPath, dstPath, policy)
+	if err != nil {
+		return false, fmt.Errorf("copy database: %w", err)
+	}
+	return cloned, nil
+}
+
+// localStateKeys are the kv keys describing a repository's working copy
+// rather than its history
+var localStateKeys = []string{
+	"HEAD",
+	headBranchKey,
+	keyApplyProgress,
+	keyBisectState,
+	keyMergeState,
+	keyWriteLock,
+}
+
+// ResetLocalState drops the state a cloned database inherited from the
+// source's working copy: linked worktrees, HEAD and the current branch,
+// known objects, staging, uncommitted operations, stashes, HEAD reflogs, and
+// in-progress merge, bisect, apply, and lock records. Commits, branches, tags, remotes,
+// and blobs are kept, less the references the dropped operations and stashes
+// held on them.
+func (s *Store) ResetLocalState() error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := releaseLocalStateRefs(tx); err != nil {
+			return err
+		}
+		if err := tx.DeleteBucket(bucketWorktrees); err != nil && err != berrors.ErrBucketNotFound {
+			return fmt.Errorf("delete worktrees: %w", err)
+		}
+		// Where the source's HEADs pointed says nothing about the clone's
+		if err := dropRefLogs(tx, "HEAD\x00"); err != nil {
+			return err
+		}
+		if err := dropRefLogs(tx, "worktrees/"); err != nil {
+			return err
+		}
+		for _, name := range [][]byte{bucketKnownObjects, bucketStagedChanges, bucketScanMetadata, bucketStashes, bucketStashChanges} {
+			if err := tx.DeleteBucket(name); err != nil && err != berrors.ErrBucketNotFound {
+				return fmt.Errorf("delete bucket %s: %w", name, err)
+			}
+			if _, err := tx.CreateBucket(name); err != nil {
+				return fmt.Errorf("create bucket %s: %w", name, err)
+			}
+		}
+
+		counters := tx.Bucket(bucketCounters)
+		if counters == nil {
+			return fmt.Errorf("counters bucket not found")
+		}
+		if err := counters.Put(counterStagedCount, []byte("0")); err != nil {
+			return err
+		}
+		if err := counters.Put(counterStashCount, []byte("0")); err != nil {
+			return err
+		}
+
+		kv := tx.Bucket(bucketKV)
+		if kv == nil {
+			return fmt.Errorf("kv bucket not found")
+		}
+		for _, key := range localStateKeys {
+			if err := kv.Delete([]byte(key)); err != nil {
+				return err
+			}
+		}
+
+		// Uncommitted operations of the main worktree ("_uncommitted:") and
+		// every linked worktree ("_uncommitted@name:")
+		ops := tx.Bucket(bucketOperations)
+		if ops == nil {
+			return fmt.Errorf("operations bucket not found")
+		}
+		c := ops.Cursor()
+		for k, _ := c.Seek(uncommittedKeysPrefix); k != nil && bytes.HasPrefix(k, uncommittedKeysPrefix); k, _ = c.Seek(uncommittedKeysPrefix) {
+			if err := c.Delete(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// uncommittedKeysPrefix starts the keys of the uncommitted operations of every
+// worktree.
+var uncommittedKeysPrefix = []byte("_uncommitted")
+
+// releaseLocalStateRefs releases the blob references held by the uncommitted
+// operations and stash changes ResetLocalState drops.
+func releaseLocalStateRefs(tx *bolt.Tx) error {
+	var hashes []string
+	if ops := tx.Bucket(bucketOperations); ops != nil {
+		c := ops.Cursor()
+		for k, v := c.Seek(uncommittedKeysPrefix); k != nil && bytes.HasPrefix(k, uncommittedKeysPrefix); k, v = c.Next() {
+			var op models.Operation
+			if err := json.Unmarshal(v, &op); err != nil {
+				return fmt.Errorf("unmarshal operation %s: %w", k, err)
+			}
+			hashes = append(hashes, operationBlobHashes(&op)...)
+		}
+	}
+	if changes := tx.Bucket(bucketStashChanges); changes != nil {
+		err := changes.ForEach(func(k, v []byte) error {
+			var change models.StashChange
+			if err := json.Unmarshal(v, &change); err != nil {
+				return fmt.Errorf("unmarshal stash change %s: %w", k, err)
+			}
+			hashes = append(hashes, change.VectorHash, change.PreviousVectorHash)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return releaseVectorRefs(tx, hashes)
+}
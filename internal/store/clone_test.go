@@ -0,0 +1,114 @@
+package store
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/kilupskalvis/wvc/internal/fsutil"
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestCloneDatabase_KeepsHistoryAndResetsLocalState(t *testing.T) {
+	dir := t.TempDir()
+	srcPath := filepath.Join(dir, "src.db")
+	src, err := New(srcPath)
+	require.NoError(t, err)
+	require.NoError(t, src.Initialize())
+
+	blob, err := src.SaveVectorBlob([]byte{0, 0, 128, 63}, 1)
+	require.NoError(t, err)
+	require.NoError(t, src.CreateCommit(&models.Commit{ID: "c1", Message: "First"}))
+	require.NoError(t, src.CreateBranchAndHEAD("main", "c1"))
+	require.NoError(t, src.SaveKnownObject("Article", "obj-1", "h1", []byte(`{}`)))
+	require.NoError(t, src.AddStagedChange(&StagedChange{ClassName: "Article", ObjectID: "obj-2", ChangeType: "insert"}))
+	require.NoError(t, src.RecordOperation(&models.Operation{Type: models.OperationInsert, ClassName: "Article", ObjectID: "obj-2"}))
+	require.NoError(t, src.CreateWorktree(&models.Worktree{Name: "exp", Path: dir}))
+	_, err = src.CreateStash("wip", "main", "c1")
+	require.NoError(t, err)
+
+	require.NoError(t, src.Close())
+
+	dstPath := filepath.Join(dir, "dst.db")
+	_, err = CloneDatabase(srcPath, dstPath, fsutil.SyncNone)
+	require.NoError(t, err)
+
+	dst, err := New(dstPath)
+	require.NoError(t, err)
+	defer dst.Close()
+	require.NoError(t, dst.ResetLocalState())
+
+	commit, err := dst.GetCommit("c1")
+	require.NoError(t, err)
+	require.NotNil(t, commit)
+	branch, err := dst.GetBranch("main")
+	require.NoError(t, err)
+	assert.Equal(t, "c1", branch.CommitID)
+	has, err := dst.HasVectorBlob(blob)
+	require.NoError(t, err)
+	assert.True(t, has)
+
+	head, err := dst.GetHEAD()
+	require.NoError(t, err)
+	assert.Empty(t, head)
+	known, err := dst.GetAllKnownObjects()
+	require.NoError(t, err)
+	assert.Empty(t, known)
+	staged, err := dst.GetStagedChangesCount()
+	require.NoError(t, err)
+	assert.Zero(t, staged)
+	ops, err := dst.GetUncommittedOperations()
+	require.NoError(t, err)
+	assert.Empty(t, ops)
+	worktrees, err := dst.ListWorktrees()
+	require.NoError(t, err)
+	assert.Empty(t, worktrees)
+	stashes, err := dst.ListStashes()
+	require.NoError(t, err)
+	assert.Empty(t, stashes)
+
+	// The source is untouched
+	src, err = New(srcPath)
+	require.NoError(t, err)
+	defer src.Close()
+	known, err = src.GetAllKnownObjects()
+	require.NoError(t, err)
+	assert.Len(t, known, 1)
+}
+
+func TestResetLocalState_ReleasesBlobRefs(t *testing.T) {
+	st := newTestStore(t)
+
+	vector, err := st.SaveVectorBlob([]byte{0, 0, 128, 63}, 1)
+	require.NoError(t, err)
+	stashed, err := st.SaveVectorBlob([]byte{0, 0, 0, 64}, 1)
+	require.NoError(t, err)
+	payload := largePayload()
+	require.NoError(t, st.RecordOperation(&models.Operation{
+		Type: models.OperationInsert, ClassName: "Article", ObjectID: "obj-1", VectorHash: vector, ObjectData: payload,
+	}))
+	stashID, err := st.CreateStash("wip", "main", "")
+	require.NoError(t, err)
+	require.NoError(t, st.CreateStashChange(&models.StashChange{StashID: stashID, ClassName: "Article", ObjectID: "obj-2", ChangeType: "insert", VectorHash: stashed}))
+
+	require.NoError(t, st.ResetLocalState())
+
+	refCount := func(hash string) int {
+		var record vectorBlobRecord
+		require.NoError(t, st.db.View(func(tx *bolt.Tx) error {
+			return json.Unmarshal(tx.Bucket(bucketVectorBlobs).Get([]byte(hash)), &record)
+		}))
+		return record.RefCount
+	}
+	assert.Zero(t, refCount(vector))
+	assert.Zero(t, refCount(stashed))
+	assert.Zero(t, refCount(HashVector(payload)))
+
+	// The blobs themselves are left for prune
+	result, err := st.PruneVectorBlobs(true)
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Blobs)
+}
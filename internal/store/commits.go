@@ -79,12 +79,21 @@ func (s *Store) GetCommitByShortID(shortID string) (*models.Commit, error) {
 
 // GetHEAD returns the current HEAD commit ID.
 func (s *Store) GetHEAD() (string, error) {
-	return s.GetValue("HEAD")
+	return s.getLocalValue("HEAD")
 }
 
 // SetHEAD sets the current HEAD commit ID.
 func (s *Store) SetHEAD(commitID string) error {
-	return s.SetValue("HEAD", commitID)
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := s.localKV(tx)
+		if b == nil {
+			return fmt.Errorf("kv bucket not found")
+		}
+		if err := s.logHEAD(tx, commitID); err != nil {
+			return err
+		}
+		return b.Put([]byte("HEAD"), []byte(commitID))
+	})
 }
 
 // GetCommitLog returns commits in reverse chronological order.
@@ -190,7 +199,7 @@ func (s *Store) FinalizeCommit(commit *models.Commit, branchName string, branchE
 		if commitBucket == nil {
 			return fmt.Errorf("commits bucket not found (database not initialized?)")
 		}
-		kvBucket := tx.Bucket(bucketKV)
+		kvBucket := s.localKV(tx)
 		if kvBucket == nil {
 			return fmt.Errorf("kv bucket not found (database not initialized?)")
 		}
@@ -198,7 +207,7 @@ func (s *Store) FinalizeCommit(commit *models.Commit, branchName string, branchE
 		// 1. Mark uncommitted operations as committed under this commit ID
 		var keys [][]byte
 		c := opBucket.Cursor()
-		prefix := []byte(uncommittedPrefix)
+		prefix := []byte(s.uncommittedPrefix())
 		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
 			keyCopy := make([]byte, len(k))
 			copy(keyCopy, k)
@@ -223,6 +232,9 @@ func (s *Store) FinalizeCommit(commit *models.Commit, branchName string, branchE
 			if err := opBucket.Put(operationKey(commit.ID, seq), newData); err != nil {
 				return err
 			}
+			if err := indexObjectCommit(tx, &op); err != nil {
+				return err
+			}
 			if err := opBucket.Delete(oldKey); err != nil {
 				return err
 			}
@@ -235,6 +247,9 @@ func (s *Store) FinalizeCommit(commit *models.Commit, branchName string, branchE
 		}
 
 		// 3. Set HEAD
+		if err := s.logHEAD(tx, commit.ID); err != nil {
+			return err
+		}
 		if err := kvBucket.Put([]byte("HEAD"), []byte(commit.ID)); err != nil {
 			return fmt.Errorf("set HEAD: %w", err)
 		}
@@ -255,6 +270,9 @@ func (s *Store) FinalizeCommit(commit *models.Commit, branchName string, branchE
 				if err := json.Unmarshal(data, &branch); err != nil {
 					return fmt.Errorf("unmarshal branch: %w", err)
 				}
+				if err := logRef(tx, branchName, branch.CommitID, commit.ID); err != nil {
+					return err
+				}
 				branch.CommitID = commit.ID
 				updatedData, err := json.Marshal(branch)
 				if err != nil {
@@ -273,6 +291,9 @@ func (s *Store) FinalizeCommit(commit *models.Commit, branchName string, branchE
 				if err != nil {
 					return fmt.Errorf("marshal branch: %w", err)
 				}
+				if err := logRef(tx, branchName, "", commit.ID); err != nil {
+					return err
+				}
 				if err := branchBucket.Put([]byte(branchName), branchData); err != nil {
 					return err
 				}
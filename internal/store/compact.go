@@ -0,0 +1,185 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/kilupskalvis/wvc/internal/fsutil"
+	bolt "go.etcd.io/bbolt"
+)
+
+// compactTxMaxSize bounds the size of each write transaction during compaction.
+const compactTxMaxSize = 64 << 20
+
+// DBStats summarizes the on-disk footprint of the store.
+type DBStats struct {
+	FileSize     int64 // bytes on disk
+	PageSize     int
+	FreePages    int   // pages on the freelist, reusable by future writes
+	PendingPages int   // pages freed by transactions still in flight
+	FreeBytes    int64 // bytes held by free and pending pages
+	Commits      int
+	Operations   int
+	VectorBlobs  int
+}
+
+// Stats reports file size, free-page usage, and object counts for the store.
+func (s *Store) Stats() (*DBStats, error) {
+	info, err := os.Stat(s.db.Path())
+	if err != nil {
+		return nil, fmt.Errorf("stat database: %w", err)
+	}
+
+	dbStats := s.db.Stats()
+	stats := &DBStats{
+		FileSize:     info.Size(),
+		PageSize:     s.db.Info().PageSize,
+		FreePages:    dbStats.FreePageN,
+		PendingPages: dbStats.PendingPageN,
+	}
+	stats.FreeBytes = int64(stats.FreePages+stats.PendingPages) * int64(stats.PageSize)
+
+	err = s.db.View(func(tx *bolt.Tx) error {
+		stats.Commits = bucketKeyCount(tx, bucketCommits)
+		stats.Operations = bucketKeyCount(tx, bucketOperations)
+		stats.VectorBlobs = bucketKeyCount(tx, bucketVectorBlobs)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// bucketKeyCount returns the number of keys in a top-level bucket, or 0 if missing.
+func bucketKeyCount(tx *bolt.Tx, name []byte) int {
+	b := tx.Bucket(name)
+	if b == nil {
+		return 0
+	}
+	return b.Stats().KeyN
+}
+
+// CompactResult describes the outcome of Compact.
+type CompactResult struct {
+	SizeBefore int64
+	SizeAfter  int64
+}
+
+// Compact rewrites the database at dbPath into a fresh file, verifies it, and
+// atomically replaces the original. bbolt never returns freed pages to the
+// filesystem, so this is the only way to shrink the file after history has
+// been pruned. The database must not be open by any other Store; New waits
+// for the compaction lock, held from before the copy until after the
+// replacement, so none opens it midway.
+func Compact(dbPath string) (*CompactResult, error) {
+	dbPath, err := fsutil.LongPath(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("resolve database path: %w", err)
+	}
+	unlock, err := fsutil.LockFile(compactLockPath(dbPath), openTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("lock database %s (is another wvc process running?): %w", dbPath, err)
+	}
+	defer unlock()
+
+	info, err := os.Stat(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("stat database: %w", err)
+	}
+	result := &CompactResult{SizeBefore: info.Size()}
+
+	tmpPath := dbPath + ".compact"
+	_ = os.Remove(tmpPath) // leftover from an interrupted run
+
+	if err := compactInto(dbPath, tmpPath); err != nil {
+		_ = os.Remove(tmpPath)
+		return nil, err
+	}
+
+	if err := fsutil.Rename(tmpPath, dbPath); err != nil {
+		_ = os.Remove(tmpPath)
+		return nil, fmt.Errorf("replace database: %w", err)
+	}
+	fsutil.FsyncDir(filepath.Dir(dbPath), fsutil.SyncFull)
+
+	info, err = os.Stat(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("stat compacted database: %w", err)
+	}
+	result.SizeAfter = info.Size()
+	return result, nil
+}
+
+// compactLockPath returns the lock file that Compact holds while it replaces
+// the database at dbPath.
+func compactLockPath(dbPath string) string {
+	return dbPath + ".lock"
+}
+
+// compactInto copies src into a new database at dst and checks that the copy
+// is consistent and holds the same number of keys in every top-level bucket.
+func compactInto(srcPath, dstPath string) error {
+	src, err := bolt.Open(srcPath, 0600, &bolt.Options{Timeout: openTimeout})
+	if err != nil {
+		return fmt.Errorf("open database %s (is another wvc process running?): %w", srcPath, err)
+	}
+	defer src.Close()
+
+	dst, err := bolt.Open(dstPath, 0600, &bolt.Options{Timeout: openTimeout})
+	if err != nil {
+		return fmt.Errorf("create compacted database: %w", err)
+	}
+	defer dst.Close()
+
+	if err := bolt.Compact(dst, src, compactTxMaxSize); err != nil {
+		return fmt.Errorf("compact database: %w", err)
+	}
+
+	srcCounts, err := topLevelKeyCounts(src)
+	if err != nil {
+		return err
+	}
+	dstCounts, err := topLevelKeyCounts(dst)
+	if err != nil {
+		return err
+	}
+	if len(srcCounts) != len(dstCounts) {
+		return fmt.Errorf("integrity check failed: %d buckets copied, expected %d", len(dstCounts), len(srcCounts))
+	}
+	for name, n := range srcCounts {
+		if dstCounts[name] != n {
+			return fmt.Errorf("integrity check failed: bucket %s has %d keys, expected %d", name, dstCounts[name], n)
+		}
+	}
+
+	err = dst.View(func(tx *bolt.Tx) error {
+		var errs []error
+		for err := range tx.Check() {
+			errs = append(errs, err)
+		}
+		return errors.Join(errs...)
+	})
+	if err != nil {
+		return fmt.Errorf("integrity check failed: %w", err)
+	}
+
+	return dst.Sync()
+}
+
+// topLevelKeyCounts returns the key count of every top-level bucket.
+func topLevelKeyCounts(db *bolt.DB) (map[string]int, error) {
+	counts := make(map[string]int)
+	err := db.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+			counts[string(name)] = b.Stats().KeyN
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("count keys: %w", err)
+	}
+	return counts, nil
+}
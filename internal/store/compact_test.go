@@ -0,0 +1,96 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/fsutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompact_ShrinksFileAndKeepsData(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	st, err := New(dbPath)
+	require.NoError(t, err)
+	require.NoError(t, st.Initialize())
+
+	keep := []byte{0, 0, 128, 63}
+	keepHash, err := st.SaveVectorBlob(keep, 1)
+	require.NoError(t, err)
+
+	var hashes []string
+	for i := 0; i < 200; i++ {
+		data := make([]byte, 16*1024)
+		data[0], data[1] = byte(i), byte(i>>8)
+		h, err := st.SaveVectorBlob(data, len(data)/4)
+		require.NoError(t, err)
+		hashes = append(hashes, h)
+	}
+	for _, h := range hashes {
+		_, err := st.DecrementVectorRefCount(h)
+		require.NoError(t, err)
+	}
+
+	stats, err := st.Stats()
+	require.NoError(t, err)
+	assert.Greater(t, stats.FreePages, 0)
+	assert.Equal(t, 1, stats.VectorBlobs)
+	assert.Equal(t, int64(stats.FreePages+stats.PendingPages)*int64(stats.PageSize), stats.FreeBytes)
+	require.NoError(t, st.Close())
+
+	result, err := Compact(dbPath)
+	require.NoError(t, err)
+	assert.Less(t, result.SizeAfter, result.SizeBefore)
+
+	_, err = os.Stat(dbPath + ".compact")
+	assert.True(t, os.IsNotExist(err))
+
+	st, err = New(dbPath)
+	require.NoError(t, err)
+	defer st.Close()
+
+	data, dims, err := st.GetVectorBlob(keepHash)
+	require.NoError(t, err)
+	assert.Equal(t, keep, data)
+	assert.Equal(t, 1, dims)
+}
+
+func TestNew_WaitsForCompaction(t *testing.T) {
+	dbPath, err := fsutil.LongPath(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	unlock, err := fsutil.LockFile(compactLockPath(dbPath), time.Second)
+	require.NoError(t, err)
+
+	const hold = 100 * time.Millisecond
+	go func() {
+		time.Sleep(hold)
+		unlock()
+	}()
+	start := time.Now()
+	st, err := New(dbPath)
+	require.NoError(t, err)
+	defer st.Close()
+	assert.GreaterOrEqual(t, time.Since(start), hold)
+}
+
+func TestCompact_MissingDatabase(t *testing.T) {
+	_, err := Compact(filepath.Join(t.TempDir(), "missing.db"))
+	assert.Error(t, err)
+}
+
+func TestStore_SetSyncPolicy(t *testing.T) {
+	st := newTestStore(t)
+
+	st.SetSyncPolicy(fsutil.SyncNone)
+	assert.True(t, st.db.NoSync)
+	require.NoError(t, st.SetValue("key", "value"))
+
+	st.SetSyncPolicy(fsutil.SyncFile)
+	assert.False(t, st.db.NoSync)
+	value, err := st.GetValue("key")
+	require.NoError(t, err)
+	assert.Equal(t, "value", value)
+}
@@ -0,0 +1,79 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	bolt "go.etcd.io/bbolt"
+)
+
+// bucketDeployments maps environment name -> deployment JSON. Deployments are
+// shared by all worktrees.
+var bucketDeployments = []byte("deployments")
+
+// PutDeployment records the deployment of an environment, replacing any
+// previous one.
+func (s *Store) PutDeployment(d *models.Deployment) error {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("marshal deployment: %w", err)
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(bucketDeployments)
+		if err != nil {
+			return fmt.Errorf("create deployments bucket: %w", err)
+		}
+		return b.Put([]byte(d.Environment), data)
+	})
+}
+
+// GetDeployment returns the deployment of an environment. Returns (nil, nil)
+// if none is recorded.
+func (s *Store) GetDeployment(env string) (*models.Deployment, error) {
+	var d *models.Deployment
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketDeployments)
+		if b == nil {
+			return nil
+		}
+		data := b.Get([]byte(env))
+		if data == nil {
+			return nil
+		}
+		d = &models.Deployment{}
+		return json.Unmarshal(data, d)
+	})
+	return d, err
+}
+
+// ListDeployments returns all recorded deployments sorted by environment.
+func (s *Store) ListDeployments() ([]*models.Deployment, error) {
+	var deployments []*models.Deployment
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketDeployments)
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(_, v []byte) error {
+			var d models.Deployment
+			if err := json.Unmarshal(v, &d); err != nil {
+				return fmt.Errorf("unmarshal deployment: %w", err)
+			}
+			deployments = append(deployments, &d)
+			return nil
+		})
+	})
+	return deployments, err
+}
+
+// DeleteDeployment removes the deployment of an environment.
+func (s *Store) DeleteDeployment(env string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketDeployments)
+		if b == nil || b.Get([]byte(env)) == nil {
+			return fmt.Errorf("no deployment recorded for environment '%s'", env)
+		}
+		return b.Delete([]byte(env))
+	})
+}
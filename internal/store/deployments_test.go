@@ -0,0 +1,37 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeployments(t *testing.T) {
+	st := newTestStore(t)
+
+	d, err := st.GetDeployment("prod")
+	require.NoError(t, err)
+	assert.Nil(t, d)
+
+	require.NoError(t, st.PutDeployment(&models.Deployment{Environment: "staging", CommitID: "c1", Branch: "main", RecordedAt: time.Now()}))
+	require.NoError(t, st.PutDeployment(&models.Deployment{Environment: "prod", CommitID: "c1", RecordedAt: time.Now()}))
+	require.NoError(t, st.PutDeployment(&models.Deployment{Environment: "prod", CommitID: "c2", Branch: "release", RecordedAt: time.Now()}))
+
+	d, err = st.GetDeployment("prod")
+	require.NoError(t, err)
+	require.NotNil(t, d)
+	assert.Equal(t, "c2", d.CommitID)
+	assert.Equal(t, "release", d.Branch)
+
+	all, err := st.ListDeployments()
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	assert.Equal(t, "prod", all[0].Environment)
+	assert.Equal(t, "staging", all[1].Environment)
+
+	require.NoError(t, st.DeleteDeployment("prod"))
+	assert.Error(t, st.DeleteDeployment("prod"))
+}
@@ -0,0 +1,38 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+)
+
+// keyWriteLock is the kv key holding the write lock, if it is held.
+const keyWriteLock = "WRITE_LOCK"
+
+// GetWriteLock returns the held write lock, or nil if it is not held.
+func (s *Store) GetWriteLock() (*models.WriteLock, error) {
+	v, err := s.getLocalValue(keyWriteLock)
+	if err != nil || v == "" {
+		return nil, err
+	}
+	var lock models.WriteLock
+	if err := json.Unmarshal([]byte(v), &lock); err != nil {
+		return nil, fmt.Errorf("unmarshal write lock: %w", err)
+	}
+	return &lock, nil
+}
+
+// SaveWriteLock records that the write lock is held.
+func (s *Store) SaveWriteLock(lock *models.WriteLock) error {
+	data, err := json.Marshal(lock)
+	if err != nil {
+		return fmt.Errorf("marshal write lock: %w", err)
+	}
+	return s.setLocalValue(keyWriteLock, string(data))
+}
+
+// ClearWriteLock records that the write lock was released.
+func (s *Store) ClearWriteLock() error {
+	return s.deleteLocalValue(keyWriteLock)
+}
@@ -0,0 +1,38 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+)
+
+// keyMergeState is the kv key holding the in-progress merge, if any.
+const keyMergeState = "MERGE_STATE"
+
+// GetMergeState returns the in-progress merge, or nil if no merge is in progress.
+func (s *Store) GetMergeState() (*models.MergeState, error) {
+	v, err := s.getLocalValue(keyMergeState)
+	if err != nil || v == "" {
+		return nil, err
+	}
+	var state models.MergeState
+	if err := json.Unmarshal([]byte(v), &state); err != nil {
+		return nil, fmt.Errorf("unmarshal merge state: %w", err)
+	}
+	return &state, nil
+}
+
+// SaveMergeState records the in-progress merge, replacing any previous one.
+func (s *Store) SaveMergeState(state *models.MergeState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("marshal merge state: %w", err)
+	}
+	return s.setLocalValue(keyMergeState, string(data))
+}
+
+// ClearMergeState removes the in-progress merge record.
+func (s *Store) ClearMergeState() error {
+	return s.deleteLocalValue(keyMergeState)
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	bolt "go.etcd.io/bbolt"
+)
+
+// bucketNotes maps commit ID -> note JSON.
+var bucketNotes = []byte("notes")
+
+// PutNote stores a note, replacing any on the same commit.
+func (s *Store) PutNote(note *models.Note) error {
+	data, err := json.Marshal(note)
+	if err != nil {
+		return fmt.Errorf("marshal note: %w", err)
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(bucketNotes)
+		if err != nil {
+			return fmt.Errorf("create notes bucket: %w", err)
+		}
+		return b.Put([]byte(note.CommitID), data)
+	})
+}
+
+// GetNote retrieves the note on a commit. Returns (nil, nil) if there is none.
+func (s *Store) GetNote(commitID string) (*models.Note, error) {
+	var note *models.Note
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketNotes)
+		if b == nil {
+			return nil
+		}
+		data := b.Get([]byte(commitID))
+		if data == nil {
+			return nil
+		}
+		note = &models.Note{}
+		return json.Unmarshal(data, note)
+	})
+	return note, err
+}
+
+// ListNotes returns all notes sorted by commit ID.
+func (s *Store) ListNotes() ([]*models.Note, error) {
+	var notes []*models.Note
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketNotes)
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(_, v []byte) error {
+			var note models.Note
+			if err := json.Unmarshal(v, &note); err != nil {
+				return fmt.Errorf("unmarshal note: %w", err)
+			}
+			notes = append(notes, &note)
+			return nil
+		})
+	})
+	return notes, err
+}
+
+// DeleteNote removes the note on a commit.
+func (s *Store) DeleteNote(commitID string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketNotes)
+		if b == nil || b.Get([]byte(commitID)) == nil {
+			return fmt.Errorf("no note on commit %s", commitID)
+		}
+		return b.Delete([]byte(commitID))
+	})
+}
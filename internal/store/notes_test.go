@@ -0,0 +1,36 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotes(t *testing.T) {
+	st := newTestStore(t)
+
+	note, err := st.GetNote("c1")
+	require.NoError(t, err)
+	assert.Nil(t, note)
+
+	require.NoError(t, st.PutNote(&models.Note{CommitID: "c2", Message: "needs review", UpdatedAt: time.Now()}))
+	require.NoError(t, st.PutNote(&models.Note{CommitID: "c1", Message: "validated by QA", Author: "qa", UpdatedAt: time.Now()}))
+	require.NoError(t, st.PutNote(&models.Note{CommitID: "c2", Message: "reviewed", UpdatedAt: time.Now()}))
+
+	note, err = st.GetNote("c2")
+	require.NoError(t, err)
+	require.NotNil(t, note)
+	assert.Equal(t, "reviewed", note.Message)
+
+	notes, err := st.ListNotes()
+	require.NoError(t, err)
+	require.Len(t, notes, 2)
+	assert.Equal(t, "c1", notes[0].CommitID)
+	assert.Equal(t, "qa", notes[0].Author)
+
+	require.NoError(t, st.DeleteNote("c1"))
+	assert.Error(t, st.DeleteNote("c1"))
+}
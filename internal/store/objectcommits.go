@@ -0,0 +1,72 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	bolt "go.etcd.io/bbolt"
+)
+
+// bucketObjectCommits indexes committed operations by object, so the history
+// of one object is found without reading every commit's operations.
+var bucketObjectCommits = []byte("object_commits") // class/id \x00 commit_id -> empty
+
+// objectCommitKey builds the index key recording that commitID touched an object.
+func objectCommitKey(className, objectID, commitID string) []byte {
+	return []byte(models.ObjectKey(className, objectID) + "\x00" + commitID)
+}
+
+// indexObjectCommit records that op's commit touched op's object.
+func indexObjectCommit(tx *bolt.Tx, op *models.Operation) error {
+	b, err := tx.CreateBucketIfNotExists(bucketObjectCommits)
+	if err != nil {
+		return fmt.Errorf("create object commits bucket: %w", err)
+	}
+	return b.Put(objectCommitKey(op.ClassName, op.ObjectID, op.CommitID), []byte{})
+}
+
+// GetObjectCommits returns the IDs of the commits with an operation on an
+// object, sorted by ID.
+func (s *Store) GetObjectCommits(className, objectID string) ([]string, error) {
+	var ids []string
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketObjectCommits)
+		if b == nil {
+			return nil
+		}
+		prefix := []byte(models.ObjectKey(className, objectID) + "\x00")
+		c := b.Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			ids = append(ids, string(k[len(prefix):]))
+		}
+		return nil
+	})
+	return ids, err
+}
+
+// indexObjectCommits builds the object index from every committed
+// operation, for repositories created before it existed.
+func indexObjectCommits(tx *bolt.Tx) error {
+	ops := tx.Bucket(bucketOperations)
+	if ops == nil {
+		return nil
+	}
+	return ops.ForEach(func(k, v []byte) error {
+		if strings.HasPrefix(string(k), "_uncommitted") {
+			return nil
+		}
+		i := bytes.LastIndexByte(k, ':')
+		if i < 0 {
+			return nil
+		}
+		var op models.Operation
+		if err := json.Unmarshal(v, &op); err != nil {
+			return fmt.Errorf("unmarshal operation %s: %w", k, err)
+		}
+		op.CommitID = string(k[:i])
+		return indexObjectCommit(tx, &op)
+	})
+}
@@ -0,0 +1,75 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestObjectCommits(t *testing.T) {
+	st := newTestStore(t)
+
+	// Committing uncommitted operations indexes them
+	require.NoError(t, st.RecordOperations([]*models.Operation{
+		{Type: models.OperationInsert, ClassName: "Article", ObjectID: "obj-1"},
+		{Type: models.OperationInsert, ClassName: "Article", ObjectID: "obj-2"},
+	}))
+	_, err := st.FinalizeCommit(&models.Commit{ID: "c1", Timestamp: time.Now()}, "main", false)
+	require.NoError(t, err)
+
+	require.NoError(t, st.RecordOperation(&models.Operation{Type: models.OperationUpdate, ClassName: "Article", ObjectID: "obj-1"}))
+	_, err = st.MarkOperationsCommitted("c2")
+	require.NoError(t, err)
+
+	// So are operations recorded under a commit and received in bundles
+	require.NoError(t, st.RecordOperation(&models.Operation{CommitID: "c3", Type: models.OperationDelete, ClassName: "Article", ObjectID: "obj-2"}))
+	require.NoError(t, st.InsertCommitBundle(&remote.CommitBundle{
+		Commit:     &models.Commit{ID: "c4", ParentID: "c3", Timestamp: time.Now()},
+		Operations: []*models.Operation{{Type: models.OperationUpdate, ClassName: "Article", ObjectID: "obj-1"}},
+	}))
+
+	ids, err := st.GetObjectCommits("Article", "obj-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c1", "c2", "c4"}, ids)
+	ids, err = st.GetObjectCommits("Article", "obj-2")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c1", "c3"}, ids)
+	ids, err = st.GetObjectCommits("Article", "obj")
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+}
+
+func TestStore_MigrationIndexesObjectCommits(t *testing.T) {
+	st := newTestStore(t)
+
+	require.NoError(t, st.RecordOperations([]*models.Operation{
+		{CommitID: "c1", Type: models.OperationInsert, ClassName: "Article", ObjectID: "obj-1"},
+		{CommitID: "c2", Type: models.OperationUpdate, ClassName: "Article", ObjectID: "obj-1"},
+		{Type: models.OperationUpdate, ClassName: "Article", ObjectID: "obj-1"},
+	}))
+
+	// Simulate a version 2 database without the object index
+	require.NoError(t, st.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket(bucketObjectCommits); err != nil {
+			return err
+		}
+		return tx.Bucket(bucketKV).Put([]byte("schema_version"), []byte("2"))
+	}))
+	ids, err := st.GetObjectCommits("Article", "obj-1")
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+
+	require.NoError(t, st.RunMigrations())
+	ids, err = st.GetObjectCommits("Article", "obj-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c1", "c2"}, ids, "uncommitted operations are not indexed")
+
+	version, err := st.GetValue("schema_version")
+	require.NoError(t, err)
+	assert.Equal(t, "3", version)
+}
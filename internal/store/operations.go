@@ -18,49 +18,92 @@ func operationKey(commitID string, seq int) []byte {
 // uncommittedPrefix is the key prefix for operations not yet assigned to a commit.
 const uncommittedPrefix = "_uncommitted:"
 
+// uncommittedPrefix returns the uncommitted-operation key prefix of the
+// store's worktree, so commits in linked worktrees never pick up each
+// other's operations
+func (s *Store) uncommittedPrefix() string {
+	if s.worktree == "" {
+		return uncommittedPrefix
+	}
+	return "_uncommitted@" + s.worktree + ":"
+}
+
 // uncommittedKey builds a key for an uncommitted operation using a sequence counter.
-func uncommittedKey(seq int) []byte {
-	return []byte(fmt.Sprintf("%s%08d", uncommittedPrefix, seq))
+func (s *Store) uncommittedKey(seq int) []byte {
+	return []byte(fmt.Sprintf("%s%08d", s.uncommittedPrefix(), seq))
 }
 
 // RecordOperation records a new operation in the log.
 // If CommitID is empty, the operation is stored as uncommitted.
 func (s *Store) RecordOperation(op *models.Operation) error {
+	return s.RecordOperations([]*models.Operation{op})
+}
+
+// RecordOperations records a batch of operations in a single write transaction.
+// Uncommitted operations receive consecutive sequence numbers in slice order.
+func (s *Store) RecordOperations(ops []*models.Operation) error {
+	if len(ops) == 0 {
+		return nil
+	}
 	return s.db.Update(func(tx *bolt.Tx) error {
 		b := tx.Bucket(bucketOperations)
 		if b == nil {
 			return fmt.Errorf("operations bucket not found (database not initialized?)")
 		}
 
-		if op.CommitID == "" {
-			// Store as uncommitted — assign next sequence number
-			seq := nextUncommittedSeq(b)
-			op.Seq = seq
-			data, err := json.Marshal(op)
+		nextSeq := -1
+		for _, op := range ops {
+			if op.CommitID == "" {
+				// Store as uncommitted — assign next sequence number
+				if nextSeq < 0 {
+					nextSeq = nextUncommittedSeq(b, s.uncommittedPrefix())
+				}
+				op.Seq = nextSeq
+				nextSeq++
+				stored, err := prepareOperation(tx, op, true, nil)
+				if err != nil {
+					return err
+				}
+				data, err := json.Marshal(stored)
+				if err != nil {
+					return fmt.Errorf("marshal operation: %w", err)
+				}
+				if err := b.Put(s.uncommittedKey(op.Seq), data); err != nil {
+					return err
+				}
+				continue
+			}
+
+			// Committed operation — use commit_id:seq key
+			key := operationKey(op.CommitID, op.Seq)
+			stored, err := prepareOperation(tx, op, true, heldPayloads(b.Get(key)))
+			if err != nil {
+				return err
+			}
+			data, err := json.Marshal(stored)
 			if err != nil {
 				return fmt.Errorf("marshal operation: %w", err)
 			}
-			return b.Put(uncommittedKey(seq), data)
-		}
-
-		// Committed operation — use commit_id:seq key
-		data, err := json.Marshal(op)
-		if err != nil {
-			return fmt.Errorf("marshal operation: %w", err)
+			if err := b.Put(key, data); err != nil {
+				return err
+			}
+			if err := indexObjectCommit(tx, op); err != nil {
+				return err
+			}
 		}
-		return b.Put(operationKey(op.CommitID, op.Seq), data)
+		return nil
 	})
 }
 
 // nextUncommittedSeq scans for the highest uncommitted sequence and returns the next one.
-func nextUncommittedSeq(b *bolt.Bucket) int {
+func nextUncommittedSeq(b *bolt.Bucket, keyPrefix string) int {
 	c := b.Cursor()
-	prefix := []byte(uncommittedPrefix)
+	prefix := []byte(keyPrefix)
 	maxSeq := -1
 
-	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, []byte(uncommittedPrefix)); k, _ = c.Next() {
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
 		var seq int
-		if _, err := fmt.Sscanf(string(k[len(uncommittedPrefix):]), "%d", &seq); err == nil {
+		if _, err := fmt.Sscanf(string(k[len(prefix):]), "%d", &seq); err == nil {
 			if seq > maxSeq {
 				maxSeq = seq
 			}
@@ -69,6 +112,41 @@ func nextUncommittedSeq(b *bolt.Bucket) int {
 	return maxSeq + 1
 }
 
+// NextUncommittedSeq returns the sequence number the next recorded
+// uncommitted operation will receive.
+func (s *Store) NextUncommittedSeq() (int, error) {
+	var seq int
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketOperations)
+		if b == nil {
+			return fmt.Errorf("operations bucket not found (database not initialized?)")
+		}
+		seq = nextUncommittedSeq(b, s.uncommittedPrefix())
+		return nil
+	})
+	return seq, err
+}
+
+// DiscardUncommittedOperations deletes the uncommitted operations numbered
+// fromSeq and up, undoing what was recorded since NextUncommittedSeq
+// returned fromSeq. Blobs the operations stored are left for prune.
+func (s *Store) DiscardUncommittedOperations(fromSeq int) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketOperations)
+		if b == nil {
+			return fmt.Errorf("operations bucket not found (database not initialized?)")
+		}
+		c := b.Cursor()
+		prefix := []byte(s.uncommittedPrefix())
+		for k, _ := c.Seek(s.uncommittedKey(fromSeq)); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Seek(s.uncommittedKey(fromSeq)) {
+			if err := c.Delete(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 // GetUncommittedOperations returns all operations not yet committed.
 func (s *Store) GetUncommittedOperations() ([]*models.Operation, error) {
 	var ops []*models.Operation
@@ -78,13 +156,16 @@ func (s *Store) GetUncommittedOperations() ([]*models.Operation, error) {
 			return fmt.Errorf("operations bucket not found (database not initialized?)")
 		}
 		c := b.Cursor()
-		prefix := []byte(uncommittedPrefix)
+		prefix := []byte(s.uncommittedPrefix())
 
-		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, []byte(uncommittedPrefix)); k, v = c.Next() {
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
 			var op models.Operation
 			if err := json.Unmarshal(v, &op); err != nil {
 				return fmt.Errorf("unmarshal operation: %w", err)
 			}
+			if err := resolvePayloads(tx, &op); err != nil {
+				return err
+			}
 			ops = append(ops, &op)
 		}
 		return nil
@@ -108,11 +189,22 @@ func (s *Store) GetOperationsByCommit(commitID string) ([]*models.Operation, err
 			if err := json.Unmarshal(v, &op); err != nil {
 				return fmt.Errorf("unmarshal operation: %w", err)
 			}
+			if err := resolvePayloads(tx, &op); err != nil {
+				return err
+			}
 			ops = append(ops, &op)
 		}
 		return nil
 	})
-	return ops, err
+	if err != nil || s.fetchPayloads == nil {
+		return ops, err
+	}
+	for _, op := range ops {
+		if op.PayloadOmitted {
+			return ops, s.fillOmittedPayloads(commitID, ops)
+		}
+	}
+	return ops, nil
 }
 
 // MarkOperationsCommitted moves uncommitted operations to their commit, assigning
@@ -128,8 +220,8 @@ func (s *Store) MarkOperationsCommitted(commitID string) (int64, error) {
 		// Collect uncommitted operation keys
 		var keys [][]byte
 		c := b.Cursor()
-		prefix := []byte(uncommittedPrefix)
-		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, []byte(uncommittedPrefix)); k, _ = c.Next() {
+		prefix := []byte(s.uncommittedPrefix())
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
 			keyCopy := make([]byte, len(k))
 			copy(keyCopy, k)
 			keys = append(keys, keyCopy)
@@ -158,6 +250,9 @@ func (s *Store) MarkOperationsCommitted(commitID string) (int64, error) {
 			if err := b.Put(operationKey(commitID, seq), newData); err != nil {
 				return err
 			}
+			if err := indexObjectCommit(tx, &op); err != nil {
+				return err
+			}
 			if err := b.Delete(oldKey); err != nil {
 				return err
 			}
@@ -208,7 +303,7 @@ func (s *Store) GetKnownObject(className, objectID string) (string, []byte, erro
 	key := className + ":" + objectID
 	var info knownObjectRecord
 	err := s.db.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket(bucketKnownObjects)
+		b := s.local(tx).Bucket(bucketKnownObjects)
 		if b == nil {
 			return fmt.Errorf("known_objects bucket not found (database not initialized?)")
 		}
@@ -224,6 +319,34 @@ func (s *Store) GetKnownObject(className, objectID string) (string, []byte, erro
 	return info.ObjectHash, info.ObjectData, nil
 }
 
+// GetKnownObjectInfo retrieves a known object with its object and vector hashes.
+// Returns (nil, nil) if the object is not known.
+func (s *Store) GetKnownObjectInfo(className, objectID string) (*models.KnownObjectInfo, error) {
+	key := className + ":" + objectID
+	var info *models.KnownObjectInfo
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := s.local(tx).Bucket(bucketKnownObjects)
+		if b == nil {
+			return nil
+		}
+		v := b.Get([]byte(key))
+		if v == nil {
+			return nil
+		}
+		var rec knownObjectRecord
+		if err := json.Unmarshal(v, &rec); err != nil {
+			return fmt.Errorf("failed to unmarshal known object: %w", err)
+		}
+		var obj models.WeaviateObject
+		if err := json.Unmarshal(rec.ObjectData, &obj); err != nil {
+			return fmt.Errorf("failed to unmarshal known object data: %w", err)
+		}
+		info = &models.KnownObjectInfo{Object: &obj, ObjectHash: rec.ObjectHash, VectorHash: rec.VectorHash}
+		return nil
+	})
+	return info, err
+}
+
 // knownObjectRecord is the internal representation stored in bbolt.
 type knownObjectRecord struct {
 	ObjectHash string `json:"object_hash"`
@@ -235,7 +358,7 @@ type knownObjectRecord struct {
 func (s *Store) GetAllKnownObjects() (map[string]*models.WeaviateObject, error) {
 	objects := make(map[string]*models.WeaviateObject)
 	err := s.db.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket(bucketKnownObjects)
+		b := s.local(tx).Bucket(bucketKnownObjects)
 		if b == nil {
 			return fmt.Errorf("known_objects bucket not found (database not initialized?)")
 		}
@@ -263,7 +386,7 @@ func (s *Store) GetAllKnownObjects() (map[string]*models.WeaviateObject, error)
 func (s *Store) GetAllKnownObjectsWithHashes() (map[string]*models.KnownObjectInfo, error) {
 	objects := make(map[string]*models.KnownObjectInfo)
 	err := s.db.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket(bucketKnownObjects)
+		b := s.local(tx).Bucket(bucketKnownObjects)
 		if b == nil {
 			return fmt.Errorf("known_objects bucket not found (database not initialized?)")
 		}
@@ -290,26 +413,184 @@ func (s *Store) GetAllKnownObjectsWithHashes() (map[string]*models.KnownObjectIn
 	return objects, err
 }
 
+// GetKnownObjectInfos looks up the known state of several objects of one
+// class in a single read, keyed by object ID. Unknown objects are absent.
+func (s *Store) GetKnownObjectInfos(className string, objectIDs []string) (map[string]*models.KnownObjectInfo, error) {
+	infos := make(map[string]*models.KnownObjectInfo, len(objectIDs))
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := s.local(tx).Bucket(bucketKnownObjects)
+		if b == nil {
+			return fmt.Errorf("known_objects bucket not found (database not initialized?)")
+		}
+		for _, id := range objectIDs {
+			v := b.Get([]byte(className + ":" + id))
+			if v == nil {
+				continue
+			}
+			info, err := decodeKnownObject(v)
+			if err != nil {
+				return err
+			}
+			infos[id] = info
+		}
+		return nil
+	})
+	return infos, err
+}
+
+// ListKnownObjects returns up to limit known objects of a class in object ID
+// order, starting after afterID (from the first when empty). Paging through
+// a class this way keeps only one page in memory.
+func (s *Store) ListKnownObjects(className, afterID string, limit int) ([]*models.KnownObjectInfo, error) {
+	var infos []*models.KnownObjectInfo
+	prefix := []byte(className + ":")
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := s.local(tx).Bucket(bucketKnownObjects)
+		if b == nil {
+			return fmt.Errorf("known_objects bucket not found (database not initialized?)")
+		}
+		c := b.Cursor()
+		k, v := c.Seek(prefix)
+		if afterID != "" {
+			after := []byte(className + ":" + afterID)
+			k, v = c.Seek(after)
+			if bytes.Equal(k, after) {
+				k, v = c.Next()
+			}
+		}
+		for ; k != nil && bytes.HasPrefix(k, prefix) && len(infos) < limit; k, v = c.Next() {
+			info, err := decodeKnownObject(v)
+			if err != nil {
+				return err
+			}
+			infos = append(infos, info)
+		}
+		return nil
+	})
+	return infos, err
+}
+
+// GetKnownClasses returns the sorted names of the classes that have known
+// objects, reading only keys.
+func (s *Store) GetKnownClasses() ([]string, error) {
+	var classes []string
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := s.local(tx).Bucket(bucketKnownObjects)
+		if b == nil {
+			return fmt.Errorf("known_objects bucket not found (database not initialized?)")
+		}
+		c := b.Cursor()
+		for k, _ := c.First(); k != nil; {
+			className, _, ok := strings.Cut(string(k), ":")
+			if !ok {
+				k, _ = c.Next()
+				continue
+			}
+			classes = append(classes, className)
+			// ';' follows ':', so this skips the rest of the class
+			k, _ = c.Seek([]byte(className + ";"))
+		}
+		return nil
+	})
+	return classes, err
+}
+
+// decodeKnownObject decodes a known_objects record.
+func decodeKnownObject(v []byte) (*models.KnownObjectInfo, error) {
+	var rec knownObjectRecord
+	if err := json.Unmarshal(v, &rec); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal known object: %w", err)
+	}
+	var obj models.WeaviateObject
+	if err := json.Unmarshal(rec.ObjectData, &obj); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal known object data: %w", err)
+	}
+	return &models.KnownObjectInfo{Object: &obj, ObjectHash: rec.ObjectHash, VectorHash: rec.VectorHash}, nil
+}
+
 // DeleteKnownObject removes a known object.
 func (s *Store) DeleteKnownObject(className, objectID string) error {
 	key := className + ":" + objectID
 	return s.db.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket(bucketKnownObjects)
+		b := s.local(tx).Bucket(bucketKnownObjects)
 		if b == nil {
 			return fmt.Errorf("known_objects bucket not found (database not initialized?)")
 		}
-		return b.Delete([]byte(key))
+		if err := b.Delete([]byte(key)); err != nil {
+			return err
+		}
+		return s.forgetScan(tx, className)
 	})
 }
 
 // ClearKnownObjects removes all known objects.
 func (s *Store) ClearKnownObjects() error {
 	return s.db.Update(func(tx *bolt.Tx) error {
-		if err := tx.DeleteBucket(bucketKnownObjects); err != nil {
+		if err := s.local(tx).DeleteBucket(bucketKnownObjects); err != nil {
 			return err
 		}
-		_, err := tx.CreateBucket(bucketKnownObjects)
-		return err
+		if _, err := s.local(tx).CreateBucket(bucketKnownObjects); err != nil {
+			return err
+		}
+		return s.forgetScan(tx, "")
+	})
+}
+
+// ClearKnownObjectsForClass removes all known objects of one class.
+func (s *Store) ClearKnownObjectsForClass(className string) error {
+	prefix := []byte(className + ":")
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := s.local(tx).Bucket(bucketKnownObjects)
+		if b == nil {
+			return fmt.Errorf("known_objects bucket not found (database not initialized?)")
+		}
+		c := b.Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Seek(prefix) {
+			if err := c.Delete(); err != nil {
+				return err
+			}
+		}
+		return s.forgetScan(tx, className)
+	})
+}
+
+// KnownObjectEntry is one object's state for SaveKnownObjects.
+type KnownObjectEntry struct {
+	ClassName  string
+	ObjectID   string
+	ObjectHash string
+	VectorHash string
+	Data       []byte
+}
+
+// SaveKnownObjects saves the known state of several objects in a single
+// write transaction.
+func (s *Store) SaveKnownObjects(entries []KnownObjectEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := s.local(tx).Bucket(bucketKnownObjects)
+		if b == nil {
+			return fmt.Errorf("known_objects bucket not found (database not initialized?)")
+		}
+		for _, e := range entries {
+			encoded, err := json.Marshal(&knownObjectRecord{
+				ObjectHash: e.ObjectHash,
+				VectorHash: e.VectorHash,
+				ObjectData: e.Data,
+			})
+			if err != nil {
+				return fmt.Errorf("marshal known object: %w", err)
+			}
+			if err := b.Put([]byte(e.ClassName+":"+e.ObjectID), encoded); err != nil {
+				return err
+			}
+			if err := s.forgetScan(tx, e.ClassName); err != nil {
+				return err
+			}
+		}
+		return nil
 	})
 }
 
@@ -326,10 +607,13 @@ func (s *Store) SaveKnownObjectWithVector(className, objectID, objectHash, vecto
 		return fmt.Errorf("marshal known object: %w", err)
 	}
 	return s.db.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket(bucketKnownObjects)
+		b := s.local(tx).Bucket(bucketKnownObjects)
 		if b == nil {
 			return fmt.Errorf("known_objects bucket not found (database not initialized?)")
 		}
-		return b.Put([]byte(key), encoded)
+		if err := b.Put([]byte(key), encoded); err != nil {
+			return err
+		}
+		return s.forgetScan(tx, className)
 	})
 }
@@ -0,0 +1,116 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	bolt "go.etcd.io/bbolt"
+)
+
+// PayloadOffloadThreshold is the size above which an operation's object payload
+// is stored as a content-addressed blob and referenced by hash, keeping large
+// properties out of the operations bucket and out of commit bundles.
+const PayloadOffloadThreshold = 1 << 20
+
+// ErrPayloadNotFound is returned when an operation references a payload blob
+// that is not present in the local store.
+var ErrPayloadNotFound = errors.New("payload blob not found")
+
+// prepareOperation moves op's large payloads into blob storage and returns the
+// record to persist, with offloaded payload bytes stripped. op itself keeps its
+// data and gains the hash references. Payloads that already carry a hash are
+// always stored as blobs; unhashed payloads are offloaded only when offloadNew
+// is set, so commits received from a remote keep the form their ID was computed on.
+// The class of every blob op references is recorded for prune reports. A
+// delta-encoded op is expanded, and updates are stored as deltas where possible.
+// held names the payloads the record op replaces already references (see
+// heldPayloads); only the others take a new blob reference.
+func prepareOperation(tx *bolt.Tx, op *models.Operation, offloadNew bool, held map[string]bool) (*models.Operation, error) {
+	if err := op.DecodeDelta(); err != nil {
+		return nil, err
+	}
+	if err := offloadPayload(tx, op.ObjectData, &op.ObjectDataHash, offloadNew, held); err != nil {
+		return nil, err
+	}
+	if err := offloadPayload(tx, op.PreviousData, &op.PreviousDataHash, offloadNew, held); err != nil {
+		return nil, err
+	}
+	if err := recordBlobClasses(tx, op); err != nil {
+		return nil, err
+	}
+	stored := *op
+	stored.StripOffloadedPayloads()
+	stored.EncodeDelta()
+	return &stored, nil
+}
+
+// offloadPayload stores data as a blob under *hash, assigning the hash first if
+// the payload is new and above the threshold. The blob gains a reference
+// unless held already counts one.
+func offloadPayload(tx *bolt.Tx, data []byte, hash *string, offloadNew bool, held map[string]bool) error {
+	if len(data) == 0 {
+		return nil
+	}
+	if *hash == "" {
+		if !offloadNew || len(data) <= PayloadOffloadThreshold {
+			return nil
+		}
+		*hash = HashVector(data)
+	} else if h := HashVector(data); h != *hash {
+		return fmt.Errorf("payload hash mismatch: expected %s, got %s", *hash, h)
+	}
+	return putVectorBlob(tx, *hash, data, 0, !held[*hash])
+}
+
+// heldPayloads returns the payload hashes referenced by a stored operation
+// record, whose blob references were counted when it was written. A record
+// whose payloads a partial clone omitted holds none, and neither does a
+// missing one.
+func heldPayloads(record []byte) map[string]bool {
+	var op models.Operation
+	if record == nil || json.Unmarshal(record, &op) != nil || op.PayloadOmitted {
+		return nil
+	}
+	held := make(map[string]bool)
+	for _, h := range op.PayloadHashes() {
+		held[h] = true
+	}
+	return held
+}
+
+// resolvePayloads fills in offloaded payloads of op from blob storage and
+// expands a delta-encoded update. Payloads omitted by a partial clone are left
+// for fillOmittedPayloads.
+func resolvePayloads(tx *bolt.Tx, op *models.Operation) error {
+	if op.PayloadOmitted {
+		return nil
+	}
+	if op.ObjectDataHash != "" && len(op.ObjectData) == 0 {
+		data, err := readPayload(tx, op.ObjectDataHash)
+		if err != nil {
+			return err
+		}
+		op.ObjectData = data
+	}
+	if op.PreviousDataHash != "" && len(op.PreviousData) == 0 {
+		data, err := readPayload(tx, op.PreviousDataHash)
+		if err != nil {
+			return err
+		}
+		op.PreviousData = data
+	}
+	return op.DecodeDelta()
+}
+
+func readPayload(tx *bolt.Tx, hash string) ([]byte, error) {
+	data, _, err := readVectorBlob(tx, hash)
+	if errors.Is(err, ErrVectorNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrPayloadNotFound, hash)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read payload %s: %w", hash, err)
+	}
+	return data, nil
+}
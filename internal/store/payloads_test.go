@@ -0,0 +1,221 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func largePayload() []byte {
+	return append(append([]byte(`{"text":"`), bytes.Repeat([]byte("x"), PayloadOffloadThreshold)...), []byte(`"}`)...)
+}
+
+func TestRecordOperation_OffloadsLargePayload(t *testing.T) {
+	st := newTestStore(t)
+
+	data := largePayload()
+	op := &models.Operation{
+		Type:       models.OperationInsert,
+		ClassName:  "Article",
+		ObjectID:   "obj-1",
+		ObjectData: data,
+	}
+	require.NoError(t, st.RecordOperation(op))
+	assert.Equal(t, HashVector(data), op.ObjectDataHash)
+	assert.Equal(t, data, op.ObjectData, "caller keeps its payload")
+
+	// The stored record only carries the reference
+	require.NoError(t, st.db.View(func(tx *bolt.Tx) error {
+		var raw models.Operation
+		require.NoError(t, json.Unmarshal(tx.Bucket(bucketOperations).Get(st.uncommittedKey(0)), &raw))
+		assert.Empty(t, raw.ObjectData)
+		assert.Equal(t, op.ObjectDataHash, raw.ObjectDataHash)
+		return nil
+	}))
+
+	ops, err := st.GetUncommittedOperations()
+	require.NoError(t, err)
+	require.Len(t, ops, 1)
+	assert.Equal(t, data, ops[0].ObjectData)
+
+	// Commit IDs don't depend on whether the payload was resolved
+	stripped := *ops[0]
+	stripped.StripOffloadedPayloads()
+	assert.Equal(t, models.ComputeOperationsHash(ops), models.ComputeOperationsHash([]*models.Operation{&stripped}))
+}
+
+func TestRecordOperation_PayloadRefCount(t *testing.T) {
+	st := newTestStore(t)
+
+	data := largePayload()
+	refCount := func() int {
+		var record vectorBlobRecord
+		require.NoError(t, st.db.View(func(tx *bolt.Tx) error {
+			return json.Unmarshal(tx.Bucket(bucketVectorBlobs).Get([]byte(HashVector(data))), &record)
+		}))
+		return record.RefCount
+	}
+	op := func(id string) *models.Operation {
+		return &models.Operation{Type: models.OperationInsert, ClassName: "Article", ObjectID: id, ObjectData: data, CommitID: "c1"}
+	}
+
+	require.NoError(t, st.RecordOperation(op("obj-1")))
+	assert.Equal(t, 1, refCount())
+
+	// Rewriting the same record takes no new reference
+	require.NoError(t, st.RecordOperation(op("obj-1")))
+	assert.Equal(t, 1, refCount())
+
+	second := op("obj-2")
+	second.Seq = 1
+	require.NoError(t, st.RecordOperation(second))
+	assert.Equal(t, 2, refCount())
+}
+
+func TestRecordOperation_SmallPayloadInline(t *testing.T) {
+	st := newTestStore(t)
+
+	op := &models.Operation{Type: models.OperationInsert, ClassName: "Article", ObjectID: "obj-1", ObjectData: []byte(`{"a":1}`)}
+	require.NoError(t, st.RecordOperation(op))
+	assert.Empty(t, op.ObjectDataHash)
+
+	ops, err := st.GetUncommittedOperations()
+	require.NoError(t, err)
+	assert.Equal(t, []byte(`{"a":1}`), ops[0].ObjectData)
+}
+
+func TestInsertCommitBundle_PayloadReferences(t *testing.T) {
+	st := newTestStore(t)
+
+	data := largePayload()
+	hash := HashVector(data)
+	bundle := &remote.CommitBundle{
+		Commit: &models.Commit{ID: "c1"},
+		Operations: []*models.Operation{
+			{Type: models.OperationInsert, ClassName: "Article", ObjectID: "obj-1", ObjectDataHash: hash},
+		},
+	}
+	require.NoError(t, st.InsertCommitBundle(bundle))
+
+	// Payload blob not fetched yet
+	_, err := st.GetOperationsByCommit("c1")
+	assert.ErrorIs(t, err, ErrPayloadNotFound)
+
+	_, err = st.SaveVectorBlobFrom(bytes.NewReader(data), hash, 0)
+	require.NoError(t, err)
+
+	ops, err := st.GetOperationsByCommit("c1")
+	require.NoError(t, err)
+	require.Len(t, ops, 1)
+	assert.Equal(t, data, ops[0].ObjectData)
+}
+
+func TestInsertCommitBundle_RejectsPayloadHashMismatch(t *testing.T) {
+	st := newTestStore(t)
+
+	bundle := &remote.CommitBundle{
+		Commit: &models.Commit{ID: "c1"},
+		Operations: []*models.Operation{
+			{Type: models.OperationInsert, ClassName: "Article", ObjectID: "obj-1", ObjectData: []byte(`{}`), ObjectDataHash: HashVector([]byte("other"))},
+		},
+	}
+	assert.Error(t, st.InsertCommitBundle(bundle))
+}
+
+// updateWithVector returns the JSON of an object with a large vector, as
+// recorded for updates, with the given title.
+func updateWithVector(title string) []byte {
+	vector := make([]float32, 256)
+	for i := range vector {
+		vector[i] = float32(i) / 7
+	}
+	data, _ := json.Marshal(&models.WeaviateObject{
+		ID:         "obj-1",
+		Class:      "Article",
+		Properties: map[string]interface{}{"title": title, "body": "unchanged body text"},
+		Vector:     vector,
+	})
+	return data
+}
+
+func TestRecordOperation_StoresUpdateAsDelta(t *testing.T) {
+	st := newTestStore(t)
+
+	prev, next := updateWithVector("Old title"), updateWithVector("New title")
+	op := &models.Operation{
+		Type:         models.OperationUpdate,
+		ClassName:    "Article",
+		ObjectID:     "obj-1",
+		ObjectData:   next,
+		PreviousData: prev,
+	}
+	require.NoError(t, st.RecordOperation(op))
+	assert.Equal(t, next, op.ObjectData, "caller keeps its payload")
+
+	require.NoError(t, st.db.View(func(tx *bolt.Tx) error {
+		var raw models.Operation
+		require.NoError(t, json.Unmarshal(tx.Bucket(bucketOperations).Get(st.uncommittedKey(0)), &raw))
+		assert.Empty(t, raw.ObjectData)
+		assert.NotEmpty(t, raw.ObjectDelta)
+		assert.Less(t, len(raw.ObjectDelta), len(next)/4)
+		return nil
+	}))
+
+	ops, err := st.GetUncommittedOperations()
+	require.NoError(t, err)
+	require.Len(t, ops, 1)
+	assert.Equal(t, next, ops[0].ObjectData)
+	assert.Empty(t, ops[0].ObjectDelta)
+}
+
+func TestRecordOperation_UpdateFallsBackToSnapshot(t *testing.T) {
+	st := newTestStore(t)
+
+	// Nothing in common with the previous data, so a delta would not be smaller
+	next := []byte(`{"id":"obj-1","properties":{"title":"New"}}`)
+	op := &models.Operation{
+		Type:         models.OperationUpdate,
+		ClassName:    "Article",
+		ObjectID:     "obj-1",
+		ObjectData:   next,
+		PreviousData: []byte(`{"class":"Article"}`),
+	}
+	require.NoError(t, st.RecordOperation(op))
+
+	require.NoError(t, st.db.View(func(tx *bolt.Tx) error {
+		var raw models.Operation
+		require.NoError(t, json.Unmarshal(tx.Bucket(bucketOperations).Get(st.uncommittedKey(0)), &raw))
+		assert.Equal(t, next, raw.ObjectData)
+		assert.Empty(t, raw.ObjectDelta)
+		return nil
+	}))
+}
+
+func TestInsertCommitBundle_ExpandsDeltas(t *testing.T) {
+	st := newTestStore(t)
+
+	prev, next := updateWithVector("Old title"), updateWithVector("New title")
+	op := &models.Operation{Type: models.OperationUpdate, ClassName: "Article", ObjectID: "obj-1", ObjectData: next, PreviousData: prev}
+	commit := &models.Commit{Message: "m", HashVersion: models.CommitHashV2}
+	id, err := commit.ComputeID([]*models.Operation{op})
+	require.NoError(t, err)
+	commit.ID = id
+
+	op.EncodeDelta()
+	require.Empty(t, op.ObjectData)
+	require.NoError(t, st.InsertCommitBundle(&remote.CommitBundle{Commit: commit, Operations: []*models.Operation{op}}))
+
+	ops, err := st.GetOperationsByCommit(commit.ID)
+	require.NoError(t, err)
+	require.Len(t, ops, 1)
+	assert.Equal(t, next, ops[0].ObjectData)
+	got, err := commit.ComputeID(ops)
+	require.NoError(t, err)
+	assert.Equal(t, commit.ID, got)
+}
@@ -0,0 +1,166 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/remote"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Kv keys of a partial clone: the remote that missing data is fetched from,
+// and the filter specs saying what was left there.
+const (
+	keyPromisorRemote = "promisor_remote"
+	keyPromisorFilter = "promisor_filter"
+)
+
+// VectorFetcher downloads a vector blob that is missing from the local store,
+// returning its bytes and dimensions.
+type VectorFetcher func(hash string) (io.ReadCloser, int, error)
+
+// PayloadFetcher downloads the operation payloads of a commit whose bundle
+// was fetched without them.
+type PayloadFetcher func(commitID string) ([]*remote.OperationPayload, error)
+
+// GetPromisorRemote returns the remote that promises the data a partial
+// clone skipped, or "" when everything is fetched eagerly.
+func (s *Store) GetPromisorRemote() (string, error) {
+	return s.GetValue(keyPromisorRemote)
+}
+
+// GetPromisorFilter returns what a partial clone leaves on its promisor
+// remote. Partial clones made before filters were recorded skip vectors.
+func (s *Store) GetPromisorFilter() (remote.Filter, error) {
+	spec, err := s.GetValue(keyPromisorFilter)
+	if err != nil {
+		return remote.Filter{}, err
+	}
+	if spec == "" {
+		name, err := s.GetPromisorRemote()
+		return remote.Filter{NoVectors: name != ""}, err
+	}
+	return remote.ParseFilter(spec)
+}
+
+// SetPromisorRemote marks the repository as a partial clone of the remote
+// that leaves the filtered data there.
+func (s *Store) SetPromisorRemote(name string, filter remote.Filter) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketKV)
+		if b == nil {
+			return fmt.Errorf("kv bucket not found")
+		}
+		if err := b.Put([]byte(keyPromisorRemote), []byte(name)); err != nil {
+			return err
+		}
+		return b.Put([]byte(keyPromisorFilter), []byte(filter.String()))
+	})
+}
+
+// SetPayloadFetcher installs f to download the payloads of a commit the first
+// time its operations are read. Downloaded payloads are kept.
+func (s *Store) SetPayloadFetcher(f PayloadFetcher) {
+	s.fetchPayloads = f
+}
+
+// SetVectorFetcher installs f to download vector blobs on first read when
+// they are missing locally. Downloaded blobs are kept, so each one is fetched
+// at most once.
+func (s *Store) SetVectorFetcher(f VectorFetcher) {
+	s.fetchVector = f
+}
+
+// HasVectorBlob reports whether a blob is stored locally, without fetching it.
+func (s *Store) HasVectorBlob(hash string) (bool, error) {
+	var has bool
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if bucket := tx.Bucket(bucketVectorBlobs); bucket != nil {
+			has = bucket.Get([]byte(hash)) != nil
+		}
+		return nil
+	})
+	return has, err
+}
+
+// fetchVectorBlob downloads a missing blob through the installed fetcher
+// into the local store. It returns ErrVectorNotFound when no fetcher is set.
+func (s *Store) fetchVectorBlob(hash string) error {
+	if s.fetchVector == nil {
+		return ErrVectorNotFound
+	}
+	reader, dims, err := s.fetchVector(hash)
+	if err != nil {
+		return fmt.Errorf("fetch vector %s: %w", hash, err)
+	}
+	defer reader.Close()
+	if _, err := s.SaveVectorBlobFrom(reader, hash, dims); err != nil {
+		return fmt.Errorf("save fetched vector %s: %w", hash, err)
+	}
+	return nil
+}
+
+// fillOmittedPayloads downloads the payloads a payload filter left out of a
+// commit's operations, checks them against the commit ID, and stores them.
+// ops must be all operations of the commit; omitted ones are filled in place.
+func (s *Store) fillOmittedPayloads(commitID string, ops []*models.Operation) error {
+	payloads, err := s.fetchPayloads(commitID)
+	if err != nil {
+		return fmt.Errorf("fetch payloads of commit %s: %w", commitID, err)
+	}
+	bySeq := make(map[int]*remote.OperationPayload, len(payloads))
+	for _, p := range payloads {
+		bySeq[p.Seq] = p
+	}
+
+	var filled []*models.Operation
+	for _, op := range ops {
+		if !op.PayloadOmitted {
+			continue
+		}
+		p := bySeq[op.Seq]
+		if p == nil {
+			return fmt.Errorf("remote sent no payloads for operation %d of commit %s", op.Seq, commitID)
+		}
+		op.ObjectData = p.ObjectData
+		op.PreviousData = p.PreviousData
+		op.PayloadOmitted = false
+		filled = append(filled, op)
+	}
+
+	commit, err := s.GetCommit(commitID)
+	if err != nil {
+		return err
+	}
+	id, err := commit.ComputeID(ops)
+	if err != nil {
+		return err
+	}
+	if id != commitID {
+		return fmt.Errorf("payloads fetched for commit %s do not match its ID", commitID)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketOperations)
+		if b == nil {
+			return fmt.Errorf("operations bucket not found (database not initialized?)")
+		}
+		for _, op := range filled {
+			key := operationKey(commitID, op.Seq)
+			stored, err := prepareOperation(tx, op, false, heldPayloads(b.Get(key)))
+			if err != nil {
+				return fmt.Errorf("store payloads of operation %d: %w", op.Seq, err)
+			}
+			data, err := json.Marshal(stored)
+			if err != nil {
+				return fmt.Errorf("marshal operation %d: %w", op.Seq, err)
+			}
+			if err := b.Put(key, data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,113 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/kilupskalvis/wvc/internal/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromisorRemote(t *testing.T) {
+	st := newTestStore(t)
+
+	name, err := st.GetPromisorRemote()
+	require.NoError(t, err)
+	assert.Empty(t, name)
+
+	require.NoError(t, st.SetPromisorRemote("origin", remote.Filter{NoPayloads: true}))
+	name, err = st.GetPromisorRemote()
+	require.NoError(t, err)
+	assert.Equal(t, "origin", name)
+	filter, err := st.GetPromisorFilter()
+	require.NoError(t, err)
+	assert.Equal(t, remote.Filter{NoPayloads: true}, filter)
+}
+
+func TestPromisorFilter_LegacyCloneSkipsVectors(t *testing.T) {
+	st := newTestStore(t)
+	require.NoError(t, st.SetValue(keyPromisorRemote, "origin"))
+
+	filter, err := st.GetPromisorFilter()
+	require.NoError(t, err)
+	assert.Equal(t, remote.Filter{NoVectors: true}, filter)
+}
+
+func TestVectorFetcher_FetchesMissingBlobOnce(t *testing.T) {
+	st := newTestStore(t)
+	data := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	hash := HashVector(data)
+
+	// Without a fetcher a missing blob is simply not found
+	_, _, err := st.GetVectorBlob(hash)
+	assert.ErrorIs(t, err, ErrVectorNotFound)
+
+	calls := 0
+	st.SetVectorFetcher(func(h string) (io.ReadCloser, int, error) {
+		calls++
+		if h != hash {
+			return nil, 0, errors.New("unknown blob")
+		}
+		return io.NopCloser(bytes.NewReader(data)), 2, nil
+	})
+
+	has, err := st.HasVectorBlob(hash)
+	require.NoError(t, err)
+	assert.False(t, has, "checking for a blob does not fetch it")
+	assert.Equal(t, 0, calls)
+
+	got, dims, err := st.GetVectorBlob(hash)
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+	assert.Equal(t, 2, dims)
+
+	// The fetched blob is kept locally
+	reader, _, err := st.OpenVectorBlob(hash)
+	require.NoError(t, err)
+	reader.Close()
+	assert.Equal(t, 1, calls)
+
+	_, _, err = st.GetVectorBlob(HashVector([]byte("other")))
+	assert.ErrorContains(t, err, "unknown blob")
+}
+
+func TestPayloadFetcher_RejectsPayloadsNotMatchingCommit(t *testing.T) {
+	st := newTestStore(t)
+	data := []byte(`{"id":"obj-1","properties":{"title":"A"}}`)
+	ops := []*models.Operation{{Type: models.OperationInsert, ClassName: "Article", ObjectID: "obj-1", ObjectData: data}}
+	commit := &models.Commit{Message: "initial", Timestamp: time.Now()}
+	id, err := commit.ComputeID(ops)
+	require.NoError(t, err)
+	commit.ID = id
+
+	omitted := *ops[0]
+	omitted.OmitPayloads()
+	require.NoError(t, st.InsertCommitBundles([]*remote.CommitBundle{{Commit: commit, Operations: []*models.Operation{&omitted}}}))
+
+	// A remote serving different payloads is caught by the commit ID
+	st.SetPayloadFetcher(func(string) ([]*remote.OperationPayload, error) {
+		return []*remote.OperationPayload{{Seq: 0, ObjectData: []byte(`{"id":"obj-1","properties":{"title":"B"}}`)}}, nil
+	})
+	_, err = st.GetOperationsByCommit(id)
+	assert.ErrorContains(t, err, "do not match")
+
+	st.SetPayloadFetcher(func(string) ([]*remote.OperationPayload, error) {
+		return []*remote.OperationPayload{{Seq: 0, ObjectData: data}}, nil
+	})
+	got, err := st.GetOperationsByCommit(id)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, data, got[0].ObjectData)
+
+	// Once stored, payloads are read without the fetcher
+	st.SetPayloadFetcher(nil)
+	got, err = st.GetOperationsByCommit(id)
+	require.NoError(t, err)
+	assert.Equal(t, data, got[0].ObjectData)
+	assert.False(t, got[0].PayloadOmitted)
+}
@@ -0,0 +1,218 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+)
+
+// bucketBlobClasses maps a blob hash to the class of the first operation that
+// referenced it, so blobs can still be attributed once nothing references them.
+var bucketBlobClasses = []byte("blob_classes")
+
+// operationBlobHashes returns the hashes of every blob an operation
+// references: payloads and current and previous vectors. Some may be empty.
+func operationBlobHashes(op *models.Operation) []string {
+	hashes := append(op.PayloadHashes(), op.VectorHash, op.PreviousVectorHash)
+	hashes = append(hashes, models.SortedNamedVectorHashes(op.NamedVectorHashes)...)
+	return append(hashes, models.SortedNamedVectorHashes(op.PreviousNamedVectorHashes)...)
+}
+
+// recordBlobClasses remembers the class of the blobs an operation references.
+func recordBlobClasses(tx *bolt.Tx, op *models.Operation) error {
+	var b *bolt.Bucket
+	for _, h := range operationBlobHashes(op) {
+		if h == "" || op.ClassName == "" {
+			continue
+		}
+		if b == nil {
+			var err error
+			if b, err = tx.CreateBucketIfNotExists(bucketBlobClasses); err != nil {
+				return err
+			}
+		}
+		if b.Get([]byte(h)) == nil {
+			if err := b.Put([]byte(h), []byte(op.ClassName)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// PrunedClass is the share of unreferenced blobs attributed to one class.
+type PrunedClass struct {
+	ClassName string // empty when the class is unknown
+	Blobs     int
+	Bytes     int64
+}
+
+// PruneResult summarizes blobs that nothing in the store references any more.
+type PruneResult struct {
+	Blobs   int
+	Bytes   int64
+	Classes []PrunedClass // sorted by bytes, largest first
+}
+
+// PruneVectorBlobs finds vector and payload blobs that no operation, known
+// object, staged change, stash, or merge state references, and deletes them
+// unless dryRun is set. A blob counts as referenced when any stored record
+// names its hash under a hash field, so the sweep errs toward keeping blobs.
+func (s *Store) PruneVectorBlobs(dryRun bool) (*PruneResult, error) {
+	result := &PruneResult{}
+	prune := func(tx *bolt.Tx) error {
+		blobs := tx.Bucket(bucketVectorBlobs)
+		if blobs == nil {
+			return nil
+		}
+		referenced, err := referencedHashes(tx)
+		if err != nil {
+			return err
+		}
+
+		classes := tx.Bucket(bucketBlobClasses)
+		chunks := tx.Bucket(bucketVectorChunks)
+		byClass := make(map[string]*PrunedClass)
+		var unreferenced [][]byte
+		err = blobs.ForEach(func(k, v []byte) error {
+			if referenced[string(k)] {
+				return nil
+			}
+			var record vectorBlobRecord
+			if err := json.Unmarshal(v, &record); err != nil {
+				return fmt.Errorf("unmarshal blob %s: %w", k, err)
+			}
+			size := int64(len(record.Data))
+			for i := 0; i < record.Chunks && chunks != nil; i++ {
+				size += int64(len(chunks.Get(vectorChunkKey(string(k), i))))
+			}
+
+			class := ""
+			if classes != nil {
+				class = string(classes.Get(k))
+			}
+			pc := byClass[class]
+			if pc == nil {
+				pc = &PrunedClass{ClassName: class}
+				byClass[class] = pc
+			}
+			pc.Blobs++
+			pc.Bytes += size
+			result.Blobs++
+			result.Bytes += size
+			unreferenced = append(unreferenced, bytes.Clone(k))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, pc := range byClass {
+			result.Classes = append(result.Classes, *pc)
+		}
+		sortPrunedClasses(result.Classes)
+
+		if dryRun {
+			return nil
+		}
+		for _, k := range unreferenced {
+			var record vectorBlobRecord
+			if err := json.Unmarshal(blobs.Get(k), &record); err != nil {
+				return fmt.Errorf("unmarshal blob %s: %w", k, err)
+			}
+			for i := 0; i < record.Chunks && chunks != nil; i++ {
+				if err := chunks.Delete(vectorChunkKey(string(k), i)); err != nil {
+					return err
+				}
+			}
+			if err := blobs.Delete(k); err != nil {
+				return err
+			}
+			if classes != nil {
+				if err := classes.Delete(k); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	var err error
+	if dryRun {
+		err = s.db.View(prune)
+	} else {
+		err = s.db.Update(prune)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("prune vector blobs: %w", err)
+	}
+	return result, nil
+}
+
+// referencedHashes collects every hash named under a hash field of any
+// record outside the blob buckets themselves.
+func referencedHashes(tx *bolt.Tx) (map[string]bool, error) {
+	referenced := make(map[string]bool)
+	skip := map[string]bool{
+		string(bucketVectorBlobs):  true,
+		string(bucketVectorChunks): true,
+		string(bucketBlobClasses):  true,
+	}
+	var scan func(b *bolt.Bucket) error
+	scan = func(b *bolt.Bucket) error {
+		return b.ForEach(func(k, v []byte) error {
+			if v == nil {
+				if nested := b.Bucket(k); nested != nil {
+					return scan(nested)
+				}
+				return nil
+			}
+			var doc interface{}
+			if json.Unmarshal(v, &doc) == nil {
+				collectHashes(doc, false, referenced)
+			}
+			return nil
+		})
+	}
+	err := tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+		if skip[string(name)] {
+			return nil
+		}
+		return scan(b)
+	})
+	return referenced, err
+}
+
+// collectHashes marks the strings found under keys containing "hash",
+// including the values of maps under such keys, such as named vector hashes.
+func collectHashes(v interface{}, underHashKey bool, into map[string]bool) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			collectHashes(child, underHashKey || strings.Contains(strings.ToLower(k), "hash"), into)
+		}
+	case []interface{}:
+		for _, child := range val {
+			collectHashes(child, underHashKey, into)
+		}
+	case string:
+		if underHashKey && val != "" {
+			into[val] = true
+		}
+	}
+}
+
+// sortPrunedClasses orders classes by reclaimable bytes, largest first, then name.
+func sortPrunedClasses(classes []PrunedClass) {
+	sort.Slice(classes, func(i, j int) bool {
+		if classes[i].Bytes != classes[j].Bytes {
+			return classes[i].Bytes > classes[j].Bytes
+		}
+		return classes[i].ClassName < classes[j].ClassName
+	})
+}
@@ -0,0 +1,80 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestPruneVectorBlobs_DeletesOnlyUnreferenced(t *testing.T) {
+	st := newTestStore(t)
+
+	kept, err := st.SaveVectorBlob([]byte{1, 0, 0, 0}, 1)
+	require.NoError(t, err)
+	dropped, err := st.SaveVectorBlob([]byte{2, 0, 0, 0, 3, 0, 0, 0}, 2)
+	require.NoError(t, err)
+	orphan, err := st.SaveVectorBlob([]byte{4, 0, 0, 0}, 1)
+	require.NoError(t, err)
+
+	require.NoError(t, st.RecordOperation(&models.Operation{
+		Type: models.OperationInsert, ClassName: "Article", ObjectID: "a1", VectorHash: kept,
+	}))
+	// dropped was attributed to Author by an operation that no longer exists
+	require.NoError(t, st.db.Update(func(tx *bolt.Tx) error {
+		return recordBlobClasses(tx, &models.Operation{ClassName: "Author", VectorHash: dropped})
+	}))
+
+	result, err := st.PruneVectorBlobs(true)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Blobs)
+	assert.Equal(t, int64(12), result.Bytes)
+	require.Len(t, result.Classes, 2)
+	assert.Equal(t, PrunedClass{ClassName: "Author", Blobs: 1, Bytes: 8}, result.Classes[0])
+	assert.Equal(t, PrunedClass{ClassName: "", Blobs: 1, Bytes: 4}, result.Classes[1])
+
+	// A dry run deletes nothing
+	for _, h := range []string{kept, dropped, orphan} {
+		has, err := st.HasVectorBlob(h)
+		require.NoError(t, err)
+		assert.True(t, has)
+	}
+
+	result, err = st.PruneVectorBlobs(false)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Blobs)
+
+	has, err := st.HasVectorBlob(kept)
+	require.NoError(t, err)
+	assert.True(t, has)
+	for _, h := range []string{dropped, orphan} {
+		has, err := st.HasVectorBlob(h)
+		require.NoError(t, err)
+		assert.False(t, has)
+	}
+
+	result, err = st.PruneVectorBlobs(false)
+	require.NoError(t, err)
+	assert.Zero(t, result.Blobs)
+}
+
+func TestPruneVectorBlobs_KeepsNamedVectors(t *testing.T) {
+	st := newTestStore(t)
+
+	title, err := st.SaveVectorBlob([]byte{1, 0, 0, 0}, 1)
+	require.NoError(t, err)
+	previous, err := st.SaveVectorBlob([]byte{2, 0, 0, 0}, 1)
+	require.NoError(t, err)
+
+	require.NoError(t, st.RecordOperation(&models.Operation{
+		Type: models.OperationUpdate, ClassName: "Article", ObjectID: "a1",
+		NamedVectorHashes:         map[string]string{"title": title},
+		PreviousNamedVectorHashes: map[string]string{"title": previous},
+	}))
+
+	result, err := st.PruneVectorBlobs(false)
+	require.NoError(t, err)
+	assert.Zero(t, result.Blobs)
+}
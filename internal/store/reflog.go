@@ -0,0 +1,141 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	bolt "go.etcd.io/bbolt"
+)
+
+// bucketReflog records every move of a branch or HEAD, keyed
+// "<ref>\x00<unix nanoseconds>", so where a ref pointed at a past time can
+// be looked up.
+var bucketReflog = []byte("reflog")
+
+// headLogRef is the reflog name of this store's HEAD; each worktree logs
+// its own.
+func (s *Store) headLogRef() string {
+	if s.worktree == "" {
+		return "HEAD"
+	}
+	return "worktrees/" + s.worktree + "/HEAD"
+}
+
+// reflogKey builds the key of an entry of ref logged at t.
+func reflogKey(ref string, t time.Time) []byte {
+	return []byte(fmt.Sprintf("%s\x00%020d", ref, t.UnixNano()))
+}
+
+// logRef records that ref moved from old to new. Moves to where the ref
+// already pointed are not logged.
+func logRef(tx *bolt.Tx, ref, old, new string) error {
+	if old == new {
+		return nil
+	}
+	b, err := tx.CreateBucketIfNotExists(bucketReflog)
+	if err != nil {
+		return fmt.Errorf("create reflog bucket: %w", err)
+	}
+	entry := models.ReflogEntry{Old: old, New: new, Time: time.Now()}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("marshal reflog entry: %w", err)
+	}
+	// Moves within the same nanosecond keep their order
+	key := reflogKey(ref, entry.Time)
+	for b.Get(key) != nil {
+		entry.Time = entry.Time.Add(time.Nanosecond)
+		key = reflogKey(ref, entry.Time)
+	}
+	return b.Put(key, data)
+}
+
+// logBranch records a move of a branch whose stored record was data (nil
+// for a new branch).
+func logBranch(tx *bolt.Tx, name string, data []byte, new string) error {
+	var old string
+	if data != nil {
+		var branch models.Branch
+		if err := json.Unmarshal(data, &branch); err != nil {
+			return fmt.Errorf("unmarshal branch: %w", err)
+		}
+		old = branch.CommitID
+	}
+	return logRef(tx, name, old, new)
+}
+
+// logHEAD records a move of this store's HEAD.
+func (s *Store) logHEAD(tx *bolt.Tx, new string) error {
+	var old string
+	if kv := s.localKV(tx); kv != nil {
+		old = string(kv.Get([]byte("HEAD")))
+	}
+	return logRef(tx, s.headLogRef(), old, new)
+}
+
+// dropRefLogs deletes the reflog entries of every ref starting with prefix.
+func dropRefLogs(tx *bolt.Tx, prefix string) error {
+	b := tx.Bucket(bucketReflog)
+	if b == nil {
+		return nil
+	}
+	c := b.Cursor()
+	for k, _ := c.Seek([]byte(prefix)); k != nil && bytes.HasPrefix(k, []byte(prefix)); k, _ = c.Seek([]byte(prefix)) {
+		if err := c.Delete(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RefAt looks up where ref ("HEAD" or a branch name) pointed at t. at is the
+// last move at or before t; when there is none, next is the first move after
+// t. Both are nil when the ref was never logged, e.g. for branches last
+// moved before the reflog was introduced.
+func (s *Store) RefAt(ref string, t time.Time) (at, next *models.ReflogEntry, err error) {
+	if ref == "HEAD" {
+		ref = s.headLogRef()
+	}
+	prefix := []byte(ref + "\x00")
+	err = s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketReflog)
+		if b == nil {
+			return nil
+		}
+		c := b.Cursor()
+		decode := func(v []byte) (*models.ReflogEntry, error) {
+			var entry models.ReflogEntry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return nil, fmt.Errorf("unmarshal reflog entry: %w", err)
+			}
+			return &entry, nil
+		}
+
+		// The first entry after t, then step back to the last one at or before it
+		k, v := c.Seek(reflogKey(ref, t.Add(time.Nanosecond)))
+		if k != nil && bytes.HasPrefix(k, prefix) {
+			if next, err = decode(v); err != nil {
+				return err
+			}
+			k, v = c.Prev()
+		} else {
+			// Past the ref's entries: its last one, if any, is at or before t
+			if k == nil {
+				k, v = c.Last()
+			} else {
+				k, v = c.Prev()
+			}
+		}
+		if k != nil && bytes.HasPrefix(k, prefix) {
+			if at, err = decode(v); err != nil {
+				return err
+			}
+			next = nil
+		}
+		return nil
+	})
+	return at, next, err
+}
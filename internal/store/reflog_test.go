@@ -0,0 +1,55 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefAt(t *testing.T) {
+	st := newTestStore(t)
+
+	before := time.Now()
+	require.NoError(t, st.CreateBranchAndHEAD("main", "c1"))
+	afterFirst := time.Now()
+	require.NoError(t, st.UpdateBranchAndHEAD("main", "c2"))
+	require.NoError(t, st.CreateBranch("other", "c2"))
+	afterSecond := time.Now()
+	require.NoError(t, st.DeleteBranch("main"))
+
+	at, next, err := st.RefAt("main", before)
+	require.NoError(t, err)
+	assert.Nil(t, at)
+	require.NotNil(t, next)
+	assert.Equal(t, "", next.Old, "created after the time asked for")
+
+	at, _, err = st.RefAt("main", afterFirst)
+	require.NoError(t, err)
+	require.NotNil(t, at)
+	assert.Equal(t, "c1", at.New)
+
+	at, _, err = st.RefAt("main", afterSecond)
+	require.NoError(t, err)
+	assert.Equal(t, "c2", at.New)
+
+	at, _, err = st.RefAt("main", time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "", at.New, "deleted")
+
+	at, _, err = st.RefAt("HEAD", afterFirst)
+	require.NoError(t, err)
+	assert.Equal(t, "c1", at.New)
+
+	// Moves to the same commit are not logged
+	require.NoError(t, st.SetHEAD("c2"))
+	at, _, err = st.RefAt("HEAD", time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "c1", at.Old)
+
+	at, next, err = st.RefAt("unknown", time.Now())
+	require.NoError(t, err)
+	assert.Nil(t, at)
+	assert.Nil(t, next)
+}
@@ -144,6 +144,21 @@ func (s *Store) RemoveRemote(name string) error {
 
 // UpdateRemoteURL updates the URL of an existing remote.
 func (s *Store) UpdateRemoteURL(name, url string) error {
+	return s.updateRemote(name, func(remote *models.Remote) {
+		remote.URL = url
+	})
+}
+
+// SetRemotePIIApproved sets whether an existing remote is approved to hold
+// restricted personal data.
+func (s *Store) SetRemotePIIApproved(name string, approved bool) error {
+	return s.updateRemote(name, func(remote *models.Remote) {
+		remote.PIIApproved = approved
+	})
+}
+
+// updateRemote applies update to an existing remote.
+func (s *Store) updateRemote(name string, update func(*models.Remote)) error {
 	return s.db.Update(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket(bucketRemotes)
 		if bucket == nil {
@@ -160,7 +175,7 @@ func (s *Store) UpdateRemoteURL(name, url string) error {
 			return fmt.Errorf("unmarshal remote: %w", err)
 		}
 
-		remote.URL = url
+		update(&remote)
 
 		updatedData, err := json.Marshal(&remote)
 		if err != nil {
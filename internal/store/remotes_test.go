@@ -122,6 +122,25 @@ func TestStore_UpdateRemoteURL_NotFound(t *testing.T) {
 	assert.Contains(t, err.Error(), "does not exist")
 }
 
+func TestStore_SetRemotePIIApproved(t *testing.T) {
+	st := newTestStore(t)
+
+	require.NoError(t, st.AddRemote("origin", "https://example.com/repo"))
+	require.NoError(t, st.SetRemotePIIApproved("origin", true))
+
+	remote, err := st.GetRemote("origin")
+	require.NoError(t, err)
+	assert.True(t, remote.PIIApproved)
+	assert.Equal(t, "https://example.com/repo", remote.URL)
+
+	require.NoError(t, st.SetRemotePIIApproved("origin", false))
+	remote, err = st.GetRemote("origin")
+	require.NoError(t, err)
+	assert.False(t, remote.PIIApproved)
+
+	assert.Error(t, st.SetRemotePIIApproved("nonexistent", true))
+}
+
 func TestStore_RemoteToken(t *testing.T) {
 	st := newTestStore(t)
 
@@ -21,7 +21,7 @@ func (s *Store) GetScanMetadata(className string) (*ScanMetadata, error) {
 	var metadata *ScanMetadata
 
 	err := s.db.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket(bucketScanMetadata)
+		bucket := s.local(tx).Bucket(bucketScanMetadata)
 		if bucket == nil {
 			return nil
 		}
@@ -48,7 +48,7 @@ func (s *Store) GetKnownObjectCount(className string) (int, error) {
 	prefix := []byte(className + ":")
 
 	err := s.db.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket(bucketKnownObjects)
+		bucket := s.local(tx).Bucket(bucketKnownObjects)
 		if bucket == nil {
 			return nil
 		}
@@ -67,3 +67,36 @@ func (s *Store) GetKnownObjectCount(className string) (int, error) {
 
 	return count, nil
 }
+
+// SaveScanMetadata records the scan state of a class
+func (s *Store) SaveScanMetadata(metadata *ScanMetadata) error {
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := s.local(tx).CreateBucketIfNotExists(bucketScanMetadata)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(metadata.ClassName), data)
+	})
+}
+
+// forgetScan drops the scan state of a class whose known objects change,
+// or of every class when className is empty, since a scan only vouches for
+// the known state it compared against.
+func (s *Store) forgetScan(tx *bolt.Tx, className string) error {
+	bucket := s.local(tx).Bucket(bucketScanMetadata)
+	if bucket == nil {
+		return nil
+	}
+	if className != "" {
+		return bucket.Delete([]byte(className))
+	}
+	if err := s.local(tx).DeleteBucket(bucketScanMetadata); err != nil {
+		return err
+	}
+	_, err := s.local(tx).CreateBucket(bucketScanMetadata)
+	return err
+}
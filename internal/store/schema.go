@@ -2,6 +2,7 @@ package store
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
 	"time"
@@ -10,11 +11,34 @@ import (
 	bolt "go.etcd.io/bbolt"
 )
 
-// SaveSchemaVersion saves a new schema version with auto-incrementing ID
+// ErrSchemaNotFound is returned when a schema is referenced by hash but no
+// version with that hash is stored.
+var ErrSchemaNotFound = errors.New("schema version not found")
+
+// latestSchemaKey is the kv entry holding the schema key most recently
+// attached to a commit.
+const latestSchemaKey = "latest_schema_key"
+
+// SaveSchemaVersion saves a new schema version with auto-incrementing ID.
+// Schema versions are deduplicated by hash: if a version with the same hash
+// already exists, its ID is returned and nothing new is stored.
 func (s *Store) SaveSchemaVersion(schemaJSON []byte, schemaHash string) (int64, error) {
 	var schemaID int64
 
 	err := s.db.Update(func(tx *bolt.Tx) error {
+		hashBucket, err := tx.CreateBucketIfNotExists(bucketSchemaHashes)
+		if err != nil {
+			return fmt.Errorf("create bucket: %w", err)
+		}
+		if key := hashBucket.Get([]byte(schemaHash)); key != nil {
+			id, err := strconv.ParseInt(string(key), 10, 64)
+			if err != nil {
+				return fmt.Errorf("parse schema key: %w", err)
+			}
+			schemaID = id
+			return nil
+		}
+
 		countersBucket := tx.Bucket(bucketCounters)
 		if countersBucket == nil {
 			return fmt.Errorf("counters bucket not found")
@@ -57,6 +81,9 @@ func (s *Store) SaveSchemaVersion(schemaJSON []byte, schemaHash string) (int64,
 		if err := schemasBucket.Put(key, versionJSON); err != nil {
 			return fmt.Errorf("failed to store schema version: %w", err)
 		}
+		if err := hashBucket.Put([]byte(schemaHash), key); err != nil {
+			return fmt.Errorf("failed to index schema hash: %w", err)
+		}
 
 		// Update counter
 		nextID := schemaID + 1
@@ -74,7 +101,7 @@ func (s *Store) SaveSchemaVersion(schemaJSON []byte, schemaHash string) (int64,
 	return schemaID, nil
 }
 
-// GetLatestSchemaVersion returns the latest committed schema version
+// GetLatestSchemaVersion returns the schema version most recently attached to a commit
 func (s *Store) GetLatestSchemaVersion() (*models.SchemaVersion, error) {
 	var latestSchema *models.SchemaVersion
 
@@ -84,6 +111,21 @@ func (s *Store) GetLatestSchemaVersion() (*models.SchemaVersion, error) {
 			return fmt.Errorf("schema_versions bucket not found")
 		}
 
+		// Deduplicated versions can be re-attached to later commits, so the
+		// latest one is tracked explicitly rather than by ID order
+		if kvBucket := tx.Bucket(bucketKV); kvBucket != nil {
+			if key := kvBucket.Get([]byte(latestSchemaKey)); key != nil {
+				if v := schemasBucket.Get(key); v != nil {
+					var schemaVersion models.SchemaVersion
+					if err := json.Unmarshal(v, &schemaVersion); err != nil {
+						return fmt.Errorf("failed to unmarshal schema version: %w", err)
+					}
+					latestSchema = &schemaVersion
+					return nil
+				}
+			}
+		}
+
 		// Iterate in reverse to find the latest committed schema
 		cursor := schemasBucket.Cursor()
 		for k, v := cursor.Last(); k != nil; k, v = cursor.Prev() {
@@ -155,6 +197,18 @@ func (s *Store) GetSchemaVersionByCommit(commitID string) (*models.SchemaVersion
 	return schemaVersion, nil
 }
 
+// HasSchemaHash reports whether a schema version with the given hash is stored.
+func (s *Store) HasSchemaHash(schemaHash string) (bool, error) {
+	var found bool
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if b := tx.Bucket(bucketSchemaHashes); b != nil {
+			found = b.Get([]byte(schemaHash)) != nil
+		}
+		return nil
+	})
+	return found, err
+}
+
 // MarkSchemaVersionCommitted marks a schema version as committed and adds index entry
 func (s *Store) MarkSchemaVersionCommitted(schemaVersionID int64, commitID string) error {
 	return s.db.Update(func(tx *bolt.Tx) error {
@@ -163,11 +217,6 @@ func (s *Store) MarkSchemaVersionCommitted(schemaVersionID int64, commitID strin
 			return fmt.Errorf("schema_versions bucket not found")
 		}
 
-		indexBucket := tx.Bucket(bucketSchemaIndex)
-		if indexBucket == nil {
-			return fmt.Errorf("schema_index bucket not found")
-		}
-
 		// Get the schema version
 		key := []byte(fmt.Sprintf("%08d", schemaVersionID))
 		schemaJSON := schemasBucket.Get(key)
@@ -180,26 +229,64 @@ func (s *Store) MarkSchemaVersionCommitted(schemaVersionID int64, commitID strin
 			return fmt.Errorf("failed to unmarshal schema version: %w", err)
 		}
 
-		// Update commit ID
-		schemaVersion.CommitID = commitID
+		// Record the first commit that introduced this schema; later commits
+		// sharing it only get an index entry
+		if schemaVersion.CommitID == "" {
+			schemaVersion.CommitID = commitID
 
-		// Serialize and store
-		updatedJSON, err := json.Marshal(schemaVersion)
-		if err != nil {
-			return fmt.Errorf("failed to marshal updated schema version: %w", err)
-		}
+			updatedJSON, err := json.Marshal(schemaVersion)
+			if err != nil {
+				return fmt.Errorf("failed to marshal updated schema version: %w", err)
+			}
 
-		if err := schemasBucket.Put(key, updatedJSON); err != nil {
-			return fmt.Errorf("failed to update schema version: %w", err)
+			if err := schemasBucket.Put(key, updatedJSON); err != nil {
+				return fmt.Errorf("failed to update schema version: %w", err)
+			}
 		}
 
-		// Add index entry
-		indexKey := []byte(fmt.Sprintf("commit:%s", commitID))
-		if err := indexBucket.Put(indexKey, key); err != nil {
-			return fmt.Errorf("failed to create schema index entry: %w", err)
-		}
+		return attachSchemaToCommit(tx, commitID, key)
+	})
+}
 
+// attachSchemaToCommit adds the commit -> schema key index entry and marks the
+// schema as the latest committed one.
+func attachSchemaToCommit(tx *bolt.Tx, commitID string, schemaKey []byte) error {
+	indexBucket := tx.Bucket(bucketSchemaIndex)
+	if indexBucket == nil {
+		return fmt.Errorf("schema_index bucket not found")
+	}
+	indexKey := []byte(fmt.Sprintf("commit:%s", commitID))
+	if err := indexBucket.Put(indexKey, schemaKey); err != nil {
+		return fmt.Errorf("failed to create schema index entry: %w", err)
+	}
+
+	kvBucket := tx.Bucket(bucketKV)
+	if kvBucket == nil {
+		return fmt.Errorf("kv bucket not found")
+	}
+	return kvBucket.Put([]byte(latestSchemaKey), schemaKey)
+}
+
+// indexSchemaHashes builds the schema hash index from existing schema versions.
+// The first version stored for each hash wins.
+func indexSchemaHashes(tx *bolt.Tx) error {
+	hashBucket, err := tx.CreateBucketIfNotExists(bucketSchemaHashes)
+	if err != nil {
+		return err
+	}
+	schemasBucket := tx.Bucket(bucketSchemaVers)
+	if schemasBucket == nil {
 		return nil
+	}
+	return schemasBucket.ForEach(func(k, v []byte) error {
+		var sv models.SchemaVersion
+		if err := json.Unmarshal(v, &sv); err != nil {
+			return fmt.Errorf("unmarshal schema version %s: %w", k, err)
+		}
+		if sv.SchemaHash == "" || hashBucket.Get([]byte(sv.SchemaHash)) != nil {
+			return nil
+		}
+		return hashBucket.Put([]byte(sv.SchemaHash), append([]byte(nil), k...))
 	})
 }
 
@@ -1,11 +1,21 @@
 package store
 
 import (
+	"bytes"
 	"fmt"
 
+	"github.com/kilupskalvis/wvc/internal/models"
 	bolt "go.etcd.io/bbolt"
 )
 
+// bucketShallowStates holds the objects live at each shallow boundary, one
+// insert operation per object keyed like operations: commitID:seq.
+var bucketShallowStates = []byte("shallow_states")
+
+// shallowWithState is the shallow_commits value of a boundary whose state is
+// stored; boundaries marked by MarkShallowCommit have an empty value.
+var shallowWithState = []byte("state")
+
 // MarkShallowCommit marks a commit as a shallow boundary.
 // Shallow commits indicate where the local history was truncated during a shallow fetch.
 func (s *Store) MarkShallowCommit(commitID string) error {
@@ -48,13 +58,46 @@ func (s *Store) ListShallowCommits() ([]string, error) {
 	return ids, err
 }
 
-// RemoveShallowCommit removes a commit from the shallow set.
+// RemoveShallowCommit removes a commit from the shallow set, with its state.
 func (s *Store) RemoveShallowCommit(commitID string) error {
 	return s.db.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket(bucketShallowCommit)
-		if b == nil {
+		if b := tx.Bucket(bucketShallowCommit); b != nil {
+			if err := b.Delete([]byte(commitID)); err != nil {
+				return err
+			}
+		}
+		return deleteStateOperations(tx, bucketShallowStates, commitID)
+	})
+}
+
+// PutShallowState marks a commit as a shallow boundary and stores the
+// objects live after it, which history is replayed from in place of the
+// commit's missing ancestors. Any earlier state of the commit is replaced.
+func (s *Store) PutShallowState(commitID string, ops []*models.Operation) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		shallow := tx.Bucket(bucketShallowCommit)
+		if shallow == nil {
+			return fmt.Errorf("shallow_commits bucket not found")
+		}
+		if err := shallow.Put([]byte(commitID), shallowWithState); err != nil {
+			return err
+		}
+		return putStateOperations(tx, bucketShallowStates, commitID, ops)
+	})
+}
+
+// GetShallowState returns the objects live after a shallow boundary as
+// insert operations. ok is false when the commit has no stored state, as for
+// boundaries marked before states were fetched.
+func (s *Store) GetShallowState(commitID string) (ops []*models.Operation, ok bool, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		shallow := tx.Bucket(bucketShallowCommit)
+		if shallow == nil || !bytes.Equal(shallow.Get([]byte(commitID)), shallowWithState) {
 			return nil
 		}
-		return b.Delete([]byte(commitID))
+		ok = true
+		ops, err = getStateOperations(tx, bucketShallowStates, commitID)
+		return err
 	})
+	return ops, ok, err
 }
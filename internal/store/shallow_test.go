@@ -1,8 +1,10 @@
 package store
 
 import (
+	"bytes"
 	"testing"
 
+	"github.com/kilupskalvis/wvc/internal/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -74,3 +76,42 @@ func TestShallowCommit_RemoveNonexistent(t *testing.T) {
 	err := st.RemoveShallowCommit("nonexistent")
 	assert.NoError(t, err)
 }
+
+func TestShallowState(t *testing.T) {
+	st := newTestStore(t)
+
+	// A boundary marked without state has none
+	require.NoError(t, st.MarkShallowCommit("old"))
+	_, ok, err := st.GetShallowState("old")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	big := append([]byte(`{"id":"obj-2","properties":{"body":"`), bytes.Repeat([]byte("x"), PayloadOffloadThreshold)...)
+	big = append(big, `"}}`...)
+	require.NoError(t, st.PutShallowState("c5", []*models.Operation{
+		{Type: models.OperationInsert, ClassName: "Article", ObjectID: "obj-1", ObjectData: []byte(`{"id":"obj-1"}`)},
+		{Type: models.OperationInsert, ClassName: "Article", ObjectID: "obj-2", ObjectData: big},
+	}))
+	shallow, err := st.IsShallowCommit("c5")
+	require.NoError(t, err)
+	assert.True(t, shallow)
+
+	ops, ok, err := st.GetShallowState("c5")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Len(t, ops, 2)
+	assert.Equal(t, "obj-1", ops[0].ObjectID)
+	assert.Equal(t, big, ops[1].ObjectData, "offloaded payloads are resolved")
+
+	// An empty state is still a state
+	require.NoError(t, st.PutShallowState("c5", nil))
+	ops, ok, err = st.GetShallowState("c5")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Empty(t, ops)
+
+	require.NoError(t, st.RemoveShallowCommit("c5"))
+	_, ok, err = st.GetShallowState("c5")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
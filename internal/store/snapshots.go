@@ -0,0 +1,158 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"strconv"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Bucket names for state snapshots.
+var (
+	bucketSnapshots   = []byte("snapshots")           // commit_id -> number of objects in its snapshot
+	bucketSnapshotOps = []byte("snapshot_operations") // commit_id:seq -> insert operation
+)
+
+// SetSnapshotInterval sets how many commits apart state snapshots are saved:
+// a commit whose history, itself included, holds a multiple of n commits gets
+// one when its state is first rebuilt. n <= 0 disables new snapshots.
+func (s *Store) SetSnapshotInterval(n int) {
+	s.snapshotInterval = n
+}
+
+// SnapshotInterval returns the number of commits between state snapshots, or
+// 0 when snapshots are disabled.
+func (s *Store) SnapshotInterval() int {
+	return max(s.snapshotInterval, 0)
+}
+
+// PutStateSnapshot stores the objects live after a commit, one insert
+// operation per object, replacing any earlier snapshot of the commit.
+func (s *Store) PutStateSnapshot(commitID string, ops []*models.Operation) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(bucketSnapshots)
+		if err != nil {
+			return fmt.Errorf("create snapshots bucket: %w", err)
+		}
+		if err := putStateOperations(tx, bucketSnapshotOps, commitID, ops); err != nil {
+			return err
+		}
+		return b.Put([]byte(commitID), []byte(strconv.Itoa(len(ops))))
+	})
+}
+
+// GetStateSnapshot returns the snapshot of a commit's state. ok is false when
+// the commit has none.
+func (s *Store) GetStateSnapshot(commitID string) (ops []*models.Operation, ok bool, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketSnapshots)
+		if b == nil || b.Get([]byte(commitID)) == nil {
+			return nil
+		}
+		ok = true
+		ops, err = getStateOperations(tx, bucketSnapshotOps, commitID)
+		return err
+	})
+	return ops, ok, err
+}
+
+// ListStateSnapshots returns the IDs of commits with a state snapshot.
+func (s *Store) ListStateSnapshots() ([]string, error) {
+	var ids []string
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketSnapshots)
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, _ []byte) error {
+			ids = append(ids, string(k))
+			return nil
+		})
+	})
+	return ids, err
+}
+
+// putStateOperations replaces the operations stored under commitID in the
+// named bucket, keyed like commit operations. Large payloads are offloaded;
+// those the replaced operations referenced keep their blob references.
+func putStateOperations(tx *bolt.Tx, bucket []byte, commitID string, ops []*models.Operation) error {
+	held := make(map[string]bool)
+	if b := tx.Bucket(bucket); b != nil {
+		prefix := []byte(commitID + ":")
+		c := b.Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			maps.Copy(held, heldPayloads(v))
+		}
+	}
+	if err := deleteStateOperations(tx, bucket, commitID); err != nil {
+		return err
+	}
+	b, err := tx.CreateBucketIfNotExists(bucket)
+	if err != nil {
+		return fmt.Errorf("create bucket %s: %w", bucket, err)
+	}
+	for i, op := range ops {
+		op.CommitID = commitID
+		op.Seq = i
+		stored, err := prepareOperation(tx, op, true, held)
+		if err != nil {
+			return fmt.Errorf("store state operation %d: %w", i, err)
+		}
+		data, err := json.Marshal(stored)
+		if err != nil {
+			return fmt.Errorf("marshal state operation %d: %w", i, err)
+		}
+		if err := b.Put(operationKey(commitID, i), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getStateOperations returns the operations stored under commitID in the
+// named bucket, with their payloads resolved.
+func getStateOperations(tx *bolt.Tx, bucket []byte, commitID string) ([]*models.Operation, error) {
+	b := tx.Bucket(bucket)
+	if b == nil {
+		return nil, nil
+	}
+	var ops []*models.Operation
+	prefix := []byte(commitID + ":")
+	c := b.Cursor()
+	for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+		var op models.Operation
+		if err := json.Unmarshal(v, &op); err != nil {
+			return nil, fmt.Errorf("unmarshal state operation: %w", err)
+		}
+		if err := resolvePayloads(tx, &op); err != nil {
+			return nil, err
+		}
+		ops = append(ops, &op)
+	}
+	return ops, nil
+}
+
+// deleteStateOperations removes the operations stored under commitID in the
+// named bucket.
+func deleteStateOperations(tx *bolt.Tx, bucket []byte, commitID string) error {
+	b := tx.Bucket(bucket)
+	if b == nil {
+		return nil
+	}
+	prefix := []byte(commitID + ":")
+	var keys [][]byte
+	c := b.Cursor()
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		keys = append(keys, append([]byte(nil), k...))
+	}
+	for _, k := range keys {
+		if err := b.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
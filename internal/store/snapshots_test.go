@@ -0,0 +1,49 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateSnapshot(t *testing.T) {
+	st := newTestStore(t)
+
+	_, ok, err := st.GetStateSnapshot("c1")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, st.PutStateSnapshot("c1", []*models.Operation{
+		{Type: models.OperationInsert, ClassName: "Article", ObjectID: "obj-1", ObjectData: []byte(`{"id":"obj-1"}`)},
+		{Type: models.OperationInsert, ClassName: "Article", ObjectID: "obj-2", ObjectData: []byte(`{"id":"obj-2"}`), VectorHash: "v2"},
+	}))
+	ops, ok, err := st.GetStateSnapshot("c1")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Len(t, ops, 2)
+	assert.Equal(t, "obj-2", ops[1].ObjectID)
+	assert.Equal(t, "v2", ops[1].VectorHash)
+
+	// A snapshot is replaced as a whole, and an empty one is still a snapshot
+	require.NoError(t, st.PutStateSnapshot("c1", nil))
+	ops, ok, err = st.GetStateSnapshot("c1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Empty(t, ops)
+
+	require.NoError(t, st.PutStateSnapshot("c2", nil))
+	ids, err := st.ListStateSnapshots()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c1", "c2"}, ids)
+}
+
+func TestSnapshotInterval(t *testing.T) {
+	st := newTestStore(t)
+	assert.Equal(t, 0, st.SnapshotInterval())
+	st.SetSnapshotInterval(50)
+	assert.Equal(t, 50, st.SnapshotInterval())
+	st.SetSnapshotInterval(-1)
+	assert.Equal(t, 0, st.SnapshotInterval())
+}
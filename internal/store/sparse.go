@@ -0,0 +1,23 @@
+package store
+
+import "strings"
+
+// keySparseClasses is the kv key listing the classes a sparse checkout
+// tracks, comma-separated.
+const keySparseClasses = "sparse_classes"
+
+// GetSparseClasses returns the classes a sparse checkout tracks, or nil when
+// every class is tracked.
+func (s *Store) GetSparseClasses() ([]string, error) {
+	val, err := s.GetValue(keySparseClasses)
+	if err != nil || val == "" {
+		return nil, err
+	}
+	return strings.Split(val, ","), nil
+}
+
+// SetSparseClasses restricts status, commit, and checkout to the classes.
+// An empty list tracks every class again.
+func (s *Store) SetSparseClasses(classes []string) error {
+	return s.SetValue(keySparseClasses, strings.Join(classes, ","))
+}
@@ -23,6 +23,8 @@ type StagedChange struct {
 	StagedAt           time.Time
 	VectorHash         string
 	PreviousVectorHash string
+	MovedFrom          string // Source key when the insert half of a move
+	MovedTo            string // Destination key when the delete half of a move
 }
 
 // AddStagedChange adds or updates a staged change in the store.
@@ -30,7 +32,7 @@ type StagedChange struct {
 func (s *Store) AddStagedChange(change *StagedChange) error {
 	return s.db.Update(func(tx *bolt.Tx) error {
 		// Get or create the staged changes bucket
-		bucket, err := tx.CreateBucketIfNotExists(bucketStagedChanges)
+		bucket, err := s.local(tx).CreateBucketIfNotExists(bucketStagedChanges)
 		if err != nil {
 			return fmt.Errorf("failed to create staged changes bucket: %w", err)
 		}
@@ -66,7 +68,7 @@ func (s *Store) AddStagedChange(change *StagedChange) error {
 // RemoveStagedChange removes a staged change by class name and object ID.
 func (s *Store) RemoveStagedChange(className, objectID string) error {
 	return s.db.Update(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket(bucketStagedChanges)
+		bucket := s.local(tx).Bucket(bucketStagedChanges)
 		if bucket == nil {
 			return nil // No staged changes exist
 		}
@@ -95,7 +97,7 @@ func (s *Store) RemoveStagedChange(className, objectID string) error {
 // RemoveStagedChangesByClass removes all staged changes for a given class.
 func (s *Store) RemoveStagedChangesByClass(className string) error {
 	return s.db.Update(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket(bucketStagedChanges)
+		bucket := s.local(tx).Bucket(bucketStagedChanges)
 		if bucket == nil {
 			return nil // No staged changes exist
 		}
@@ -134,7 +136,7 @@ func (s *Store) RemoveStagedChangesByClass(className string) error {
 func (s *Store) ClearStagedChanges() error {
 	return s.db.Update(func(tx *bolt.Tx) error {
 		// Delete the staged changes bucket
-		if err := tx.DeleteBucket(bucketStagedChanges); err != nil && err != berrors.ErrBucketNotFound {
+		if err := s.local(tx).DeleteBucket(bucketStagedChanges); err != nil && err != berrors.ErrBucketNotFound {
 			return fmt.Errorf("failed to delete staged changes bucket: %w", err)
 		}
 
@@ -144,7 +146,7 @@ func (s *Store) ClearStagedChanges() error {
 		}
 
 		// Recreate the bucket
-		if _, err := tx.CreateBucketIfNotExists(bucketStagedChanges); err != nil {
+		if _, err := s.local(tx).CreateBucketIfNotExists(bucketStagedChanges); err != nil {
 			return fmt.Errorf("recreate staged changes bucket: %w", err)
 		}
 
@@ -158,7 +160,7 @@ func (s *Store) GetStagedChange(className, objectID string) (*StagedChange, erro
 	var change *StagedChange
 
 	err := s.db.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket(bucketStagedChanges)
+		bucket := s.local(tx).Bucket(bucketStagedChanges)
 		if bucket == nil {
 			return nil // No staged changes exist
 		}
@@ -180,12 +182,12 @@ func (s *Store) GetStagedChange(className, objectID string) (*StagedChange, erro
 	return change, err
 }
 
-// GetAllStagedChanges retrieves all staged changes, sorted by StagedAt.
+// GetAllStagedChanges retrieves all staged changes, sorted by StagedAt and then by key.
 func (s *Store) GetAllStagedChanges() ([]*StagedChange, error) {
 	var changes []*StagedChange
 
 	err := s.db.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket(bucketStagedChanges)
+		bucket := s.local(tx).Bucket(bucketStagedChanges)
 		if bucket == nil {
 			return nil // No staged changes exist
 		}
@@ -206,8 +208,9 @@ func (s *Store) GetAllStagedChanges() ([]*StagedChange, error) {
 		return nil, err
 	}
 
-	// Sort by StagedAt
-	sort.Slice(changes, func(i, j int) bool {
+	// Sort by StagedAt; the stable sort keeps changes staged at the same
+	// instant in key order
+	sort.SliceStable(changes, func(i, j int) bool {
 		return changes[i].StagedAt.Before(changes[j].StagedAt)
 	})
 
@@ -219,7 +222,7 @@ func (s *Store) GetStagedChangesByClass(className string) ([]*StagedChange, erro
 	var changes []*StagedChange
 
 	err := s.db.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket(bucketStagedChanges)
+		bucket := s.local(tx).Bucket(bucketStagedChanges)
 		if bucket == nil {
 			return nil // No staged changes exist
 		}
@@ -242,8 +245,9 @@ func (s *Store) GetStagedChangesByClass(className string) ([]*StagedChange, erro
 		return nil, err
 	}
 
-	// Sort by StagedAt
-	sort.Slice(changes, func(i, j int) bool {
+	// Sort by StagedAt; the stable sort keeps changes staged at the same
+	// instant in key order
+	sort.SliceStable(changes, func(i, j int) bool {
 		return changes[i].StagedAt.Before(changes[j].StagedAt)
 	})
 
@@ -255,7 +259,7 @@ func (s *Store) GetStagedChangesCount() (int, error) {
 	var count int
 
 	err := s.db.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket(bucketCounters)
+		bucket := s.local(tx).Bucket(bucketCounters)
 		if bucket == nil {
 			count = 0
 			return nil
@@ -291,7 +295,7 @@ func (s *Store) decrementStagedCount(tx *bolt.Tx) error {
 
 // adjustStagedCount adjusts the staged changes counter by the given delta.
 func (s *Store) adjustStagedCount(tx *bolt.Tx, delta int) error {
-	bucket, err := tx.CreateBucketIfNotExists(bucketCounters)
+	bucket, err := s.local(tx).CreateBucketIfNotExists(bucketCounters)
 	if err != nil {
 		return fmt.Errorf("failed to create counters bucket: %w", err)
 	}
@@ -322,7 +326,7 @@ func (s *Store) adjustStagedCount(tx *bolt.Tx, delta int) error {
 
 // resetStagedCount resets the staged changes counter to 0.
 func (s *Store) resetStagedCount(tx *bolt.Tx) error {
-	bucket, err := tx.CreateBucketIfNotExists(bucketCounters)
+	bucket, err := s.local(tx).CreateBucketIfNotExists(bucketCounters)
 	if err != nil {
 		return fmt.Errorf("failed to create counters bucket: %w", err)
 	}
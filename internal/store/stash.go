@@ -18,79 +18,146 @@ var (
 
 // CreateStash creates a new stash entry with an auto-assigned ID.
 func (s *Store) CreateStash(message, branchName, commitID string) (int64, error) {
-	var stashID int64
+	stash := &models.Stash{
+		Message:    message,
+		BranchName: branchName,
+		CommitID:   commitID,
+		CreatedAt:  time.Now(),
+	}
 
 	err := s.db.Update(func(tx *bolt.Tx) error {
-		stashBucket := tx.Bucket(bucketStashes)
-		if stashBucket == nil {
-			return fmt.Errorf("stashes bucket not found")
+		return createStash(tx, stash)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return stash.ID, nil
+}
+
+// ImportStash stores a stash received from a remote together with its changes
+// in a single transaction. The stash gets a new local ID and becomes the
+// newest entry; its message, branch, commit, creation time, and remote ID are
+// kept as given.
+func (s *Store) ImportStash(stash *models.Stash, changes []*models.StashChange) (int64, error) {
+	imported := *stash
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		if err := createStash(tx, &imported); err != nil {
+			return err
 		}
 
-		counterBucket := tx.Bucket(bucketCounters)
-		if counterBucket == nil {
-			return fmt.Errorf("counters bucket not found")
+		changeBucket := tx.Bucket(bucketStashChanges)
+		if changeBucket == nil {
+			return fmt.Errorf("stash_changes bucket not found")
 		}
+		for seq, change := range changes {
+			c := *change
+			c.ID = int64(seq)
+			c.StashID = imported.ID
 
-		// Get next stash ID
-		nextIDBytes := counterBucket.Get(counterNextStashID)
-		if nextIDBytes == nil {
-			stashID = 1
-		} else {
-			nextID, err := strconv.ParseInt(string(nextIDBytes), 10, 64)
+			changeData, err := json.Marshal(&c)
 			if err != nil {
-				return fmt.Errorf("failed to parse next stash ID: %w", err)
+				return fmt.Errorf("failed to marshal stash change: %w", err)
+			}
+			key := []byte(fmt.Sprintf("%08d:%08d", imported.ID, seq))
+			if err := changeBucket.Put(key, changeData); err != nil {
+				return fmt.Errorf("failed to store stash change: %w", err)
 			}
-			stashID = nextID
 		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
 
-		// Create stash
-		stash := &models.Stash{
-			ID:         stashID,
-			Message:    message,
-			BranchName: branchName,
-			CommitID:   commitID,
-			CreatedAt:  time.Now(),
-		}
+	return imported.ID, nil
+}
 
-		stashData, err := json.Marshal(stash)
-		if err != nil {
-			return fmt.Errorf("failed to marshal stash: %w", err)
+// SetStashRemoteID records the remote ID of a stash that was pushed to a remote.
+func (s *Store) SetStashRemoteID(stashID int64, remoteID string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		stashBucket := tx.Bucket(bucketStashes)
+		if stashBucket == nil {
+			return fmt.Errorf("stashes bucket not found")
 		}
 
-		// Store stash with zero-padded key
 		key := []byte(fmt.Sprintf("%08d", stashID))
-		if err := stashBucket.Put(key, stashData); err != nil {
-			return fmt.Errorf("failed to store stash: %w", err)
+		data := stashBucket.Get(key)
+		if data == nil {
+			return fmt.Errorf("stash %d not found", stashID)
 		}
 
-		// Increment next stash ID
-		nextStashID := stashID + 1
-		if err := counterBucket.Put(counterNextStashID, []byte(strconv.FormatInt(nextStashID, 10))); err != nil {
-			return fmt.Errorf("failed to update next stash ID: %w", err)
+		var stash models.Stash
+		if err := json.Unmarshal(data, &stash); err != nil {
+			return fmt.Errorf("failed to unmarshal stash: %w", err)
 		}
+		stash.RemoteID = remoteID
 
-		// Increment stash count
-		countBytes := counterBucket.Get(counterStashCount)
-		var count int64
-		if countBytes != nil {
-			count, err = strconv.ParseInt(string(countBytes), 10, 64)
-			if err != nil {
-				return fmt.Errorf("failed to parse stash count: %w", err)
-			}
-		}
-		count++
-		if err := counterBucket.Put(counterStashCount, []byte(strconv.FormatInt(count, 10))); err != nil {
-			return fmt.Errorf("failed to update stash count: %w", err)
+		stashData, err := json.Marshal(&stash)
+		if err != nil {
+			return fmt.Errorf("failed to marshal stash: %w", err)
 		}
-
-		return nil
+		return stashBucket.Put(key, stashData)
 	})
+}
+
+// createStash assigns the next stash ID to stash and stores it within an open
+// write transaction, updating the stash counters.
+func createStash(tx *bolt.Tx, stash *models.Stash) error {
+	stashBucket := tx.Bucket(bucketStashes)
+	if stashBucket == nil {
+		return fmt.Errorf("stashes bucket not found")
+	}
+
+	counterBucket := tx.Bucket(bucketCounters)
+	if counterBucket == nil {
+		return fmt.Errorf("counters bucket not found")
+	}
+
+	// Get next stash ID
+	stashID := int64(1)
+	if nextIDBytes := counterBucket.Get(counterNextStashID); nextIDBytes != nil {
+		nextID, err := strconv.ParseInt(string(nextIDBytes), 10, 64)
+		if err != nil {
+			return fmt.Errorf("failed to parse next stash ID: %w", err)
+		}
+		stashID = nextID
+	}
+	stash.ID = stashID
 
+	stashData, err := json.Marshal(stash)
 	if err != nil {
-		return 0, err
+		return fmt.Errorf("failed to marshal stash: %w", err)
+	}
+
+	// Store stash with zero-padded key
+	key := []byte(fmt.Sprintf("%08d", stashID))
+	if err := stashBucket.Put(key, stashData); err != nil {
+		return fmt.Errorf("failed to store stash: %w", err)
+	}
+
+	// Increment next stash ID
+	nextStashID := stashID + 1
+	if err := counterBucket.Put(counterNextStashID, []byte(strconv.FormatInt(nextStashID, 10))); err != nil {
+		return fmt.Errorf("failed to update next stash ID: %w", err)
+	}
+
+	// Increment stash count
+	countBytes := counterBucket.Get(counterStashCount)
+	var count int64
+	if countBytes != nil {
+		count, err = strconv.ParseInt(string(countBytes), 10, 64)
+		if err != nil {
+			return fmt.Errorf("failed to parse stash count: %w", err)
+		}
+	}
+	count++
+	if err := counterBucket.Put(counterStashCount, []byte(strconv.FormatInt(count, 10))); err != nil {
+		return fmt.Errorf("failed to update stash count: %w", err)
 	}
 
-	return stashID, nil
+	return nil
 }
 
 // CreateStashChange stores a stash change entry.
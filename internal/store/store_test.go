@@ -1,6 +1,9 @@
 package store
 
 import (
+	"bytes"
+	"fmt"
+	"io"
 	"path/filepath"
 	"testing"
 	"time"
@@ -8,6 +11,7 @@ import (
 	"github.com/kilupskalvis/wvc/internal/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
 )
 
 // newTestStore creates a new bbolt store in a temp directory for testing.
@@ -204,6 +208,109 @@ func TestStore_MarkOperationsCommitted(t *testing.T) {
 	assert.Len(t, ops, 3)
 }
 
+func TestStore_DiscardUncommittedOperations(t *testing.T) {
+	st := newTestStore(t)
+
+	require.NoError(t, st.RecordOperation(&models.Operation{
+		Type: models.OperationInsert, ClassName: "Article", ObjectID: "kept",
+	}))
+	fromSeq, err := st.NextUncommittedSeq()
+	require.NoError(t, err)
+	assert.Equal(t, 1, fromSeq)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, st.RecordOperation(&models.Operation{
+			Type: models.OperationInsert, ClassName: "Article", ObjectID: string(rune('a' + i)),
+		}))
+	}
+
+	require.NoError(t, st.DiscardUncommittedOperations(fromSeq))
+
+	ops, err := st.GetUncommittedOperations()
+	require.NoError(t, err)
+	require.Len(t, ops, 1)
+	assert.Equal(t, "kept", ops[0].ObjectID)
+}
+
+func TestStore_RecordOperations(t *testing.T) {
+	st := newTestStore(t)
+
+	require.NoError(t, st.RecordOperation(&models.Operation{
+		Type: models.OperationInsert, ClassName: "Article", ObjectID: "first",
+	}))
+
+	batch := make([]*models.Operation, 3)
+	for i := range batch {
+		batch[i] = &models.Operation{
+			Timestamp: time.Now(),
+			Type:      models.OperationInsert,
+			ClassName: "Article",
+			ObjectID:  string(rune('a' + i)),
+		}
+	}
+	require.NoError(t, st.RecordOperations(batch))
+	require.NoError(t, st.RecordOperations(nil))
+
+	ops, err := st.GetUncommittedOperations()
+	require.NoError(t, err)
+	require.Len(t, ops, 4)
+	for i, op := range ops {
+		assert.Equal(t, i, op.Seq)
+	}
+	assert.Equal(t, "first", ops[0].ObjectID)
+	assert.Equal(t, "a", ops[1].ObjectID)
+	assert.Equal(t, "c", ops[3].ObjectID)
+}
+
+func benchmarkOperations(n int) []*models.Operation {
+	ops := make([]*models.Operation, n)
+	for i := range ops {
+		ops[i] = &models.Operation{
+			Timestamp:  time.Now(),
+			Type:       models.OperationInsert,
+			ClassName:  "Article",
+			ObjectID:   fmt.Sprintf("obj-%06d", i),
+			ObjectData: []byte(`{"title": "Benchmark"}`),
+		}
+	}
+	return ops
+}
+
+func BenchmarkRecordOperation_PerOp(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		st, err := New(filepath.Join(b.TempDir(), "bench.db"))
+		require.NoError(b, err)
+		require.NoError(b, st.Initialize())
+		ops := benchmarkOperations(500)
+		b.StartTimer()
+
+		for _, op := range ops {
+			require.NoError(b, st.RecordOperation(op))
+		}
+
+		b.StopTimer()
+		st.Close()
+		b.StartTimer()
+	}
+}
+
+func BenchmarkRecordOperations_Batch(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		st, err := New(filepath.Join(b.TempDir(), "bench.db"))
+		require.NoError(b, err)
+		require.NoError(b, st.Initialize())
+		ops := benchmarkOperations(500)
+		b.StartTimer()
+
+		require.NoError(b, st.RecordOperations(ops))
+
+		b.StopTimer()
+		st.Close()
+		b.StartTimer()
+	}
+}
+
 // ==================== Known Objects Tests ====================
 
 func TestStore_SaveAndGetKnownObject(t *testing.T) {
@@ -251,6 +358,70 @@ func TestStore_ClearKnownObjects(t *testing.T) {
 	assert.Len(t, objects, 0)
 }
 
+func TestStore_ClearKnownObjectsForClass(t *testing.T) {
+	st := newTestStore(t)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, st.SaveKnownObject("Article", string(rune('a'+i)), "hash", []byte(`{}`)))
+		require.NoError(t, st.SaveKnownObject("ArticleDraft", string(rune('a'+i)), "hash", []byte(`{}`)))
+	}
+
+	require.NoError(t, st.ClearKnownObjectsForClass("Article"))
+
+	count, err := st.GetKnownObjectCount("Article")
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+	count, err = st.GetKnownObjectCount("ArticleDraft")
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+}
+
+func TestStore_KnownObjectPages(t *testing.T) {
+	st := newTestStore(t)
+
+	var entries []KnownObjectEntry
+	for _, class := range []string{"Article", "ArticleDraft", "Book"} {
+		for i := 0; i < 5; i++ {
+			id := string(rune('a' + i))
+			entries = append(entries, KnownObjectEntry{
+				ClassName:  class,
+				ObjectID:   id,
+				ObjectHash: "hash-" + id,
+				Data:       []byte(`{"class":"` + class + `","id":"` + id + `"}`),
+			})
+		}
+	}
+	require.NoError(t, st.SaveKnownObjects(entries))
+
+	classes, err := st.GetKnownClasses()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Article", "ArticleDraft", "Book"}, classes)
+
+	infos, err := st.GetKnownObjectInfos("Article", []string{"b", "d", "missing"})
+	require.NoError(t, err)
+	require.Len(t, infos, 2)
+	assert.Equal(t, "hash-d", infos["d"].ObjectHash)
+	assert.Equal(t, "Article", infos["d"].Object.Class)
+
+	// Pages stay within the class and resume after the last ID
+	var ids []string
+	after := ""
+	for {
+		page, err := st.ListKnownObjects("Article", after, 2)
+		require.NoError(t, err)
+		if len(page) == 0 {
+			break
+		}
+		assert.LessOrEqual(t, len(page), 2)
+		for _, info := range page {
+			assert.Equal(t, "Article", info.Object.Class)
+			ids = append(ids, info.Object.ID)
+		}
+		after = page[len(page)-1].Object.ID
+	}
+	assert.Equal(t, []string{"a", "b", "c", "d", "e"}, ids)
+}
+
 // ==================== Staging Tests ====================
 
 func TestStore_StagedChanges(t *testing.T) {
@@ -351,6 +522,104 @@ func TestStore_VectorBlobRefCount(t *testing.T) {
 	assert.Equal(t, ErrVectorNotFound, err)
 }
 
+func TestStore_VectorBlobStreamLarge(t *testing.T) {
+	st := newTestStore(t)
+
+	// 2.5 chunks worth of data forces the chunked layout
+	data := make([]byte, vectorChunkSize*5/2)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	hash := HashVector(data)
+	dims := len(data) / 4
+
+	saved, err := st.SaveVectorBlobFrom(bytes.NewReader(data), hash, dims)
+	require.NoError(t, err)
+	assert.Equal(t, hash, saved)
+
+	reader, gotDims, err := st.OpenVectorBlob(hash)
+	require.NoError(t, err)
+	streamed, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.NoError(t, reader.Close())
+	assert.Equal(t, dims, gotDims)
+	assert.Equal(t, data, streamed)
+
+	whole, _, err := st.GetVectorBlob(hash)
+	require.NoError(t, err)
+	assert.Equal(t, data, whole)
+
+	// Second save only bumps the ref count
+	_, err = st.SaveVectorBlobFrom(bytes.NewReader(data), hash, dims)
+	require.NoError(t, err)
+	deleted, err := st.DecrementVectorRefCount(hash)
+	require.NoError(t, err)
+	assert.False(t, deleted)
+	deleted, err = st.DecrementVectorRefCount(hash)
+	require.NoError(t, err)
+	assert.True(t, deleted)
+
+	_, _, err = st.OpenVectorBlob(hash)
+	assert.Equal(t, ErrVectorNotFound, err)
+	// Chunks are removed along with the record
+	require.NoError(t, st.db.View(func(tx *bolt.Tx) error {
+		assert.Nil(t, tx.Bucket(bucketVectorChunks).Get(vectorChunkKey(hash, 0)))
+		return nil
+	}))
+}
+
+func TestStore_VectorBlobStreamSeveralBatches(t *testing.T) {
+	st := newTestStore(t)
+
+	data := make([]byte, vectorChunkSize*(vectorChunkBatch+1)+7)
+	for i := range data {
+		data[i] = byte(i % 253)
+	}
+	hash := HashVector(data)
+
+	_, err := st.SaveVectorBlobFrom(bytes.NewReader(data), hash, len(data)/4)
+	require.NoError(t, err)
+	got, _, err := st.GetVectorBlob(hash)
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+
+	// A mismatch found after a batch was committed removes its chunks
+	wrong := HashVector([]byte("something else"))
+	_, err = st.SaveVectorBlobFrom(bytes.NewReader(data), wrong, len(data)/4)
+	require.ErrorContains(t, err, "hash mismatch")
+	require.NoError(t, st.db.View(func(tx *bolt.Tx) error {
+		assert.Nil(t, tx.Bucket(bucketVectorChunks).Get(vectorChunkKey(wrong, 0)))
+		return nil
+	}))
+}
+
+func TestStore_VectorBlobStreamSmall(t *testing.T) {
+	st := newTestStore(t)
+
+	data := []byte{0, 0, 128, 63}
+	hash, err := st.SaveVectorBlobFrom(bytes.NewReader(data), HashVector(data), 1)
+	require.NoError(t, err)
+
+	got, dims, err := st.GetVectorBlob(hash)
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+	assert.Equal(t, 1, dims)
+}
+
+func TestStore_VectorBlobStreamHashMismatch(t *testing.T) {
+	st := newTestStore(t)
+
+	data := make([]byte, vectorChunkSize+10)
+	wrong := HashVector([]byte("something else"))
+
+	_, err := st.SaveVectorBlobFrom(bytes.NewReader(data), wrong, len(data)/4)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "hash mismatch")
+
+	_, _, err = st.GetVectorBlob(wrong)
+	assert.Equal(t, ErrVectorNotFound, err)
+}
+
 // ==================== Schema Version Tests ====================
 
 func TestStore_SchemaVersion(t *testing.T) {
@@ -389,6 +658,57 @@ func TestStore_GetLatestSchemaVersion(t *testing.T) {
 	assert.Equal(t, []byte(`{"v": 2}`), latest.SchemaJSON)
 }
 
+func TestStore_SchemaVersionDeduplicatedByHash(t *testing.T) {
+	st := newTestStore(t)
+
+	idA, err := st.SaveSchemaVersion([]byte(`{"v": "a"}`), "hashA")
+	require.NoError(t, err)
+	require.NoError(t, st.MarkSchemaVersionCommitted(idA, "commit-001"))
+
+	idB, err := st.SaveSchemaVersion([]byte(`{"v": "b"}`), "hashB")
+	require.NoError(t, err)
+	require.NoError(t, st.MarkSchemaVersionCommitted(idB, "commit-002"))
+
+	// Back to schema A: no new version, the existing one is re-attached
+	idA2, err := st.SaveSchemaVersion([]byte(`{"v": "a"}`), "hashA")
+	require.NoError(t, err)
+	assert.Equal(t, idA, idA2)
+	require.NoError(t, st.MarkSchemaVersionCommitted(idA2, "commit-003"))
+
+	sv, err := st.GetSchemaVersionByCommit("commit-003")
+	require.NoError(t, err)
+	assert.Equal(t, []byte(`{"v": "a"}`), sv.SchemaJSON)
+	assert.Equal(t, "commit-001", sv.CommitID, "keeps the commit that introduced it")
+
+	latest, err := st.GetLatestSchemaVersion()
+	require.NoError(t, err)
+	assert.Equal(t, "hashA", latest.SchemaHash)
+}
+
+func TestStore_MigrationIndexesSchemaHashes(t *testing.T) {
+	st := newTestStore(t)
+
+	id, err := st.SaveSchemaVersion([]byte(`{"v": 1}`), "hash1")
+	require.NoError(t, err)
+
+	// Simulate a version 1 database without the hash index
+	require.NoError(t, st.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket(bucketSchemaHashes); err != nil {
+			return err
+		}
+		return tx.Bucket(bucketKV).Put([]byte("schema_version"), []byte("1"))
+	}))
+	require.NoError(t, st.RunMigrations())
+
+	again, err := st.SaveSchemaVersion([]byte(`{"v": 1}`), "hash1")
+	require.NoError(t, err)
+	assert.Equal(t, id, again)
+
+	version, err := st.GetValue("schema_version")
+	require.NoError(t, err)
+	assert.Equal(t, "3", version)
+}
+
 // ==================== Migration Tests ====================
 
 func TestStore_Migrations(t *testing.T) {
@@ -409,24 +729,39 @@ func TestVectorToBytes(t *testing.T) {
 	tests := []struct {
 		name     string
 		input    interface{}
+		wantRows int
 		wantDims int
 		wantErr  bool
 	}{
 		{
 			name:     "float32 slice",
 			input:    []float32{1.0, 2.0, 3.0},
+			wantRows: 1,
 			wantDims: 3,
 		},
 		{
 			name:     "float64 slice",
 			input:    []float64{1.0, 2.0},
+			wantRows: 1,
 			wantDims: 2,
 		},
 		{
 			name:     "interface slice with float64",
 			input:    []interface{}{1.0, 2.0, 3.0, 4.0},
+			wantRows: 1,
 			wantDims: 4,
 		},
+		{
+			name:     "multi-vector",
+			input:    [][]float32{{1.0, 2.0}, {3.0, 4.0}, {5.0, 6.0}},
+			wantRows: 3,
+			wantDims: 2,
+		},
+		{
+			name:    "ragged multi-vector",
+			input:   []interface{}{[]interface{}{1.0, 2.0}, []interface{}{3.0}},
+			wantErr: true,
+		},
 		{
 			name:    "nil input",
 			input:   nil,
@@ -449,7 +784,7 @@ func TestVectorToBytes(t *testing.T) {
 			require.NoError(t, err)
 			if tt.input != nil && tt.wantDims > 0 {
 				assert.Equal(t, tt.wantDims, dims)
-				assert.Len(t, bytes, tt.wantDims*4) // 4 bytes per float32
+				assert.Len(t, bytes, tt.wantRows*tt.wantDims*4) // 4 bytes per float32
 			}
 		})
 	}
@@ -467,6 +802,19 @@ func TestBytesToVector(t *testing.T) {
 	assert.Equal(t, original, result)
 }
 
+func TestBytesToMultiVector(t *testing.T) {
+	original := [][]float32{{1.0, 2.0}, {3.0, 4.0}}
+	bytes, dims, err := VectorToBytes(original)
+	require.NoError(t, err)
+
+	result, err := BytesToMultiVector(bytes, dims)
+	require.NoError(t, err)
+	assert.Equal(t, original, result)
+
+	_, err = BytesToMultiVector(bytes[:12], dims)
+	assert.ErrorIs(t, err, ErrInvalidVector)
+}
+
 func TestHashVector(t *testing.T) {
 	data := []byte{1, 2, 3, 4}
 	hash := HashVector(data)
@@ -481,3 +829,40 @@ func TestHashVector(t *testing.T) {
 	hash3 := HashVector([]byte{5, 6, 7, 8})
 	assert.NotEqual(t, hash, hash3)
 }
+
+func TestScanMetadata_ForgottenWhenKnownObjectsChange(t *testing.T) {
+	st := newTestStore(t)
+	save := func(className string) {
+		require.NoError(t, st.SaveScanMetadata(&ScanMetadata{ClassName: className, LastScanCount: 1, ScanHighWatermark: 1000}))
+	}
+	saved := func(className string) bool {
+		meta, err := st.GetScanMetadata(className)
+		require.NoError(t, err)
+		return meta != nil
+	}
+
+	save("Article")
+	save("Note")
+	meta, err := st.GetScanMetadata("Article")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000), meta.ScanHighWatermark)
+
+	require.NoError(t, st.SaveKnownObject("Article", "obj-001", "hash", []byte(`{}`)))
+	assert.False(t, saved("Article"))
+	assert.True(t, saved("Note"), "other classes keep theirs")
+
+	save("Article")
+	require.NoError(t, st.DeleteKnownObject("Article", "obj-001"))
+	assert.False(t, saved("Article"))
+
+	save("Article")
+	require.NoError(t, st.SaveKnownObjects([]KnownObjectEntry{{ClassName: "Article", ObjectID: "obj-002", Data: []byte(`{}`)}}))
+	assert.False(t, saved("Article"))
+
+	save("Article")
+	require.NoError(t, st.ClearKnownObjectsForClass("Article"))
+	assert.False(t, saved("Article"))
+
+	require.NoError(t, st.ClearKnownObjects())
+	assert.False(t, saved("Note"))
+}
@@ -0,0 +1,70 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+)
+
+const (
+	// keySubmodulePins is the kv key holding the submodule pins of the
+	// working state, which the next commit records.
+	keySubmodulePins = "SUBMODULE_PINS"
+	// keySubmodules is the kv key holding the materialized submodules.
+	keySubmodules = "SUBMODULES"
+)
+
+// GetSubmodulePins returns the submodule pins of the working state, by
+// submodule name, or nil when nothing is pinned.
+func (s *Store) GetSubmodulePins() (map[string]string, error) {
+	v, err := s.getLocalValue(keySubmodulePins)
+	if err != nil || v == "" {
+		return nil, err
+	}
+	var pins map[string]string
+	if err := json.Unmarshal([]byte(v), &pins); err != nil {
+		return nil, fmt.Errorf("unmarshal submodule pins: %w", err)
+	}
+	return pins, nil
+}
+
+// SetSubmodulePins replaces the submodule pins of the working state.
+func (s *Store) SetSubmodulePins(pins map[string]string) error {
+	if len(pins) == 0 {
+		return s.deleteLocalValue(keySubmodulePins)
+	}
+	data, err := json.Marshal(pins)
+	if err != nil {
+		return fmt.Errorf("marshal submodule pins: %w", err)
+	}
+	return s.setLocalValue(keySubmodulePins, string(data))
+}
+
+// GetMaterializedSubmodules returns the submodules materialized into
+// Weaviate, by name.
+func (s *Store) GetMaterializedSubmodules() (map[string]*models.SubmoduleState, error) {
+	v, err := s.getLocalValue(keySubmodules)
+	if err != nil || v == "" {
+		return map[string]*models.SubmoduleState{}, err
+	}
+	var states map[string]*models.SubmoduleState
+	if err := json.Unmarshal([]byte(v), &states); err != nil {
+		return nil, fmt.Errorf("unmarshal submodules: %w", err)
+	}
+	return states, nil
+}
+
+// SaveMaterializedSubmodule records that a submodule was materialized.
+func (s *Store) SaveMaterializedSubmodule(name string, state *models.SubmoduleState) error {
+	states, err := s.GetMaterializedSubmodules()
+	if err != nil {
+		return err
+	}
+	states[name] = state
+	data, err := json.Marshal(states)
+	if err != nil {
+		return fmt.Errorf("marshal submodules: %w", err)
+	}
+	return s.setLocalValue(keySubmodules, string(data))
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	bolt "go.etcd.io/bbolt"
+)
+
+// bucketTags maps tag name -> tag JSON.
+var bucketTags = []byte("tags")
+
+// PutTag stores a tag, replacing any with the same name.
+func (s *Store) PutTag(tag *models.Tag) error {
+	data, err := json.Marshal(tag)
+	if err != nil {
+		return fmt.Errorf("marshal tag: %w", err)
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(bucketTags)
+		if err != nil {
+			return fmt.Errorf("create tags bucket: %w", err)
+		}
+		return b.Put([]byte(tag.Name), data)
+	})
+}
+
+// GetTag retrieves a tag by name. Returns (nil, nil) if not found.
+func (s *Store) GetTag(name string) (*models.Tag, error) {
+	var tag *models.Tag
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketTags)
+		if b == nil {
+			return nil
+		}
+		data := b.Get([]byte(name))
+		if data == nil {
+			return nil
+		}
+		tag = &models.Tag{}
+		return json.Unmarshal(data, tag)
+	})
+	return tag, err
+}
+
+// ListTags returns all tags sorted by name.
+func (s *Store) ListTags() ([]*models.Tag, error) {
+	var tags []*models.Tag
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketTags)
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(_, v []byte) error {
+			var tag models.Tag
+			if err := json.Unmarshal(v, &tag); err != nil {
+				return fmt.Errorf("unmarshal tag: %w", err)
+			}
+			tags = append(tags, &tag)
+			return nil
+		})
+	})
+	return tags, err
+}
+
+// DeleteTag removes a tag.
+func (s *Store) DeleteTag(name string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketTags)
+		if b == nil || b.Get([]byte(name)) == nil {
+			return fmt.Errorf("tag not found: %s", name)
+		}
+		return b.Delete([]byte(name))
+	})
+}
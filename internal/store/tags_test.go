@@ -0,0 +1,35 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTags(t *testing.T) {
+	st := newTestStore(t)
+
+	tag, err := st.GetTag("v1")
+	require.NoError(t, err)
+	assert.Nil(t, tag)
+
+	require.NoError(t, st.PutTag(&models.Tag{Name: "v2", CommitID: "c2", CreatedAt: time.Now()}))
+	require.NoError(t, st.PutTag(&models.Tag{Name: "v1", CommitID: "c1", Message: "first release", CreatedAt: time.Now()}))
+
+	tag, err = st.GetTag("v1")
+	require.NoError(t, err)
+	require.NotNil(t, tag)
+	assert.Equal(t, "c1", tag.CommitID)
+	assert.Equal(t, "first release", tag.Message)
+
+	tags, err := st.ListTags()
+	require.NoError(t, err)
+	require.Len(t, tags, 2)
+	assert.Equal(t, "v1", tags[0].Name)
+
+	require.NoError(t, st.DeleteTag("v1"))
+	assert.Error(t, st.DeleteTag("v1"))
+}
@@ -2,12 +2,14 @@
 package store
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"math"
 
 	bolt "go.etcd.io/bbolt"
@@ -20,11 +22,23 @@ var (
 	ErrInvalidVector  = errors.New("invalid vector format")
 )
 
-// vectorBlobRecord stores vector data with reference counting
+// vectorBlobRecord stores vector data with reference counting.
+// Blobs larger than vectorChunkSize written through SaveVectorBlobFrom keep
+// their bytes in the vector_chunks bucket instead of Data, split into Chunks
+// raw chunks so they can be written and read without holding the whole blob.
 type vectorBlobRecord struct {
 	Data       []byte `json:"data"`
 	Dimensions int    `json:"dimensions"`
 	RefCount   int    `json:"ref_count"`
+	Chunks     int    `json:"chunks,omitempty"`
+}
+
+// vectorChunkSize is the size of one stored chunk of a large vector blob.
+const vectorChunkSize = 1 << 20
+
+// vectorChunkKey returns the vector_chunks key for chunk seq of a blob.
+func vectorChunkKey(hash string, seq int) []byte {
+	return []byte(fmt.Sprintf("%s/%08d", hash, seq))
 }
 
 // VectorToBytes converts a vector (interface{}) to raw binary float32 bytes (little-endian).
@@ -34,6 +48,10 @@ func VectorToBytes(v interface{}) ([]byte, int, error) {
 		return nil, 0, nil
 	}
 
+	if rows, ok := models.MultiVectorRows(v); ok {
+		return multiVectorToBytes(rows)
+	}
+
 	var floats []float32
 
 	switch vec := v.(type) {
@@ -77,6 +95,53 @@ func VectorToBytes(v interface{}) ([]byte, int, error) {
 	return buf, len(floats), nil
 }
 
+// multiVectorToBytes concatenates the rows of a multi-vector. The dimension
+// count is that of one row, which must all be the same length.
+func multiVectorToBytes(rows []interface{}) ([]byte, int, error) {
+	var buf []byte
+	dims := 0
+	for i, row := range rows {
+		if _, nested := models.MultiVectorRows(row); nested {
+			return nil, 0, fmt.Errorf("%w: row %d is not a vector", ErrInvalidVector, i)
+		}
+		data, n, err := VectorToBytes(row)
+		if err != nil {
+			return nil, 0, err
+		}
+		if i > 0 && n != dims {
+			return nil, 0, fmt.Errorf("%w: multi-vector row %d has %d dimensions, want %d", ErrInvalidVector, i, n, dims)
+		}
+		dims = n
+		buf = append(buf, data...)
+	}
+	if len(buf) == 0 {
+		return nil, 0, nil
+	}
+	return buf, dims, nil
+}
+
+// BytesToMultiVector converts raw binary bytes back to a multi-vector of
+// rows with the given dimensions each.
+func BytesToMultiVector(data []byte, dimensions int) ([][]float32, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	rowLen := dimensions * 4
+	if rowLen == 0 || len(data)%rowLen != 0 {
+		return nil, fmt.Errorf("%w: %d bytes is not a whole number of %d-dimensional rows",
+			ErrInvalidVector, len(data), dimensions)
+	}
+	rows := make([][]float32, 0, len(data)/rowLen)
+	for off := 0; off < len(data); off += rowLen {
+		row, err := BytesToVector(data[off:off+rowLen], dimensions)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
 // BytesToVector converts raw binary bytes back to []float32.
 func BytesToVector(data []byte, dimensions int) ([]float32, error) {
 	if len(data) == 0 {
@@ -116,6 +181,13 @@ func VectorFromObject(obj *models.WeaviateObject) ([]byte, int, error) {
 	return VectorToBytes(obj.Vector)
 }
 
+// IsMultiVector reports whether v is a multi-vector (ColBERT): a list of
+// vectors rather than one vector.
+func IsMultiVector(v interface{}) bool {
+	_, ok := models.MultiVectorRows(v)
+	return ok
+}
+
 // SaveVectorBlob stores a vector blob in the database.
 // If a blob with the same hash already exists, increments ref_count instead.
 // Returns the hash of the stored blob.
@@ -127,50 +199,61 @@ func (s *Store) SaveVectorBlob(data []byte, dimensions int) (string, error) {
 	hash := HashVector(data)
 
 	err := s.db.Update(func(tx *bolt.Tx) error {
-		bucket, err := tx.CreateBucketIfNotExists(bucketVectorBlobs)
-		if err != nil {
-			return fmt.Errorf("create bucket: %w", err)
-		}
+		return putVectorBlob(tx, hash, data, dimensions, true)
+	})
 
-		key := []byte(hash)
-		existing := bucket.Get(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to save vector blob: %w", err)
+	}
 
-		if existing != nil {
-			// Increment ref count on duplicate
-			var record vectorBlobRecord
-			if err := json.Unmarshal(existing, &record); err != nil {
-				return fmt.Errorf("unmarshal existing record: %w", err)
-			}
-			record.RefCount++
-			encoded, err := json.Marshal(record)
-			if err != nil {
-				return fmt.Errorf("marshal record: %w", err)
-			}
-			return bucket.Put(key, encoded)
-		}
+	return hash, nil
+}
+
+// putVectorBlob stores an inline blob within an open write transaction. If
+// the blob already exists, it increments the ref count when addRef is set and
+// otherwise leaves the blob alone.
+func putVectorBlob(tx *bolt.Tx, hash string, data []byte, dimensions int, addRef bool) error {
+	bucket, err := tx.CreateBucketIfNotExists(bucketVectorBlobs)
+	if err != nil {
+		return fmt.Errorf("create bucket: %w", err)
+	}
 
-		// Create new record
-		record := vectorBlobRecord{
-			Data:       data,
-			Dimensions: dimensions,
-			RefCount:   1,
+	key := []byte(hash)
+	existing := bucket.Get(key)
+
+	if existing != nil {
+		if !addRef {
+			return nil
+		}
+		// Increment ref count on duplicate
+		var record vectorBlobRecord
+		if err := json.Unmarshal(existing, &record); err != nil {
+			return fmt.Errorf("unmarshal existing record: %w", err)
 		}
+		record.RefCount++
 		encoded, err := json.Marshal(record)
 		if err != nil {
 			return fmt.Errorf("marshal record: %w", err)
 		}
 		return bucket.Put(key, encoded)
-	})
+	}
 
+	// Create new record
+	record := vectorBlobRecord{
+		Data:       data,
+		Dimensions: dimensions,
+		RefCount:   1,
+	}
+	encoded, err := json.Marshal(record)
 	if err != nil {
-		return "", fmt.Errorf("failed to save vector blob: %w", err)
+		return fmt.Errorf("marshal record: %w", err)
 	}
-
-	return hash, nil
+	return bucket.Put(key, encoded)
 }
 
 // GetVectorBlob retrieves vector bytes by hash.
-// Returns the binary data, dimensions, and any error.
+// Returns the binary data, dimensions, and any error. In a partial clone a
+// missing blob is downloaded through the vector fetcher first.
 func (s *Store) GetVectorBlob(hash string) ([]byte, int, error) {
 	if hash == "" {
 		return nil, 0, nil
@@ -179,26 +262,17 @@ func (s *Store) GetVectorBlob(hash string) ([]byte, int, error) {
 	var data []byte
 	var dimensions int
 
-	err := s.db.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket(bucketVectorBlobs)
-		if bucket == nil {
-			return ErrVectorNotFound
-		}
-
-		value := bucket.Get([]byte(hash))
-		if value == nil {
-			return ErrVectorNotFound
-		}
-
-		var record vectorBlobRecord
-		if err := json.Unmarshal(value, &record); err != nil {
-			return fmt.Errorf("unmarshal record: %w", err)
+	read := func(tx *bolt.Tx) error {
+		var err error
+		data, dimensions, err = readVectorBlob(tx, hash)
+		return err
+	}
+	err := s.db.View(read)
+	if errors.Is(err, ErrVectorNotFound) && s.fetchVector != nil {
+		if err = s.fetchVectorBlob(hash); err == nil {
+			err = s.db.View(read)
 		}
-
-		data = record.Data
-		dimensions = record.Dimensions
-		return nil
-	})
+	}
 
 	if err != nil {
 		if errors.Is(err, ErrVectorNotFound) {
@@ -210,6 +284,42 @@ func (s *Store) GetVectorBlob(hash string) ([]byte, int, error) {
 	return data, dimensions, nil
 }
 
+// readVectorBlob reads a whole blob, inline or chunked, within an open transaction.
+func readVectorBlob(tx *bolt.Tx, hash string) ([]byte, int, error) {
+	bucket := tx.Bucket(bucketVectorBlobs)
+	if bucket == nil {
+		return nil, 0, ErrVectorNotFound
+	}
+
+	value := bucket.Get([]byte(hash))
+	if value == nil {
+		return nil, 0, ErrVectorNotFound
+	}
+
+	var record vectorBlobRecord
+	if err := json.Unmarshal(value, &record); err != nil {
+		return nil, 0, fmt.Errorf("unmarshal record: %w", err)
+	}
+
+	if record.Chunks == 0 {
+		return record.Data, record.Dimensions, nil
+	}
+
+	chunks := tx.Bucket(bucketVectorChunks)
+	if chunks == nil {
+		return nil, 0, ErrVectorNotFound
+	}
+	var data []byte
+	for i := 0; i < record.Chunks; i++ {
+		chunk := chunks.Get(vectorChunkKey(hash, i))
+		if chunk == nil {
+			return nil, 0, fmt.Errorf("missing chunk %d of vector blob %s", i, hash)
+		}
+		data = append(data, chunk...)
+	}
+	return data, record.Dimensions, nil
+}
+
 // IncrementVectorRefCount increments the reference count for a vector blob.
 func (s *Store) IncrementVectorRefCount(hash string) error {
 	if hash == "" {
@@ -272,6 +382,15 @@ func (s *Store) DecrementVectorRefCount(hash string) (bool, error) {
 		record.RefCount--
 		if record.RefCount <= 0 {
 			deleted = true
+			if record.Chunks > 0 {
+				if chunks := tx.Bucket(bucketVectorChunks); chunks != nil {
+					for i := 0; i < record.Chunks; i++ {
+						if err := chunks.Delete(vectorChunkKey(hash, i)); err != nil {
+							return err
+						}
+					}
+				}
+			}
 			return bucket.Delete(key)
 		}
 
@@ -289,3 +408,234 @@ func (s *Store) DecrementVectorRefCount(hash string) (bool, error) {
 
 	return deleted, nil
 }
+
+// releaseVectorRefs drops one reference to each of the blobs within an open
+// write transaction, as when the records holding them are deleted. Blobs are
+// kept at zero references for PruneVectorBlobs, which deletes them once
+// nothing references them, since history may still reference a blob whose
+// count missed it.
+func releaseVectorRefs(tx *bolt.Tx, hashes []string) error {
+	bucket := tx.Bucket(bucketVectorBlobs)
+	if bucket == nil {
+		return nil
+	}
+	for _, hash := range hashes {
+		if hash == "" {
+			continue
+		}
+		value := bucket.Get([]byte(hash))
+		if value == nil {
+			continue
+		}
+		var record vectorBlobRecord
+		if err := json.Unmarshal(value, &record); err != nil {
+			return fmt.Errorf("unmarshal record: %w", err)
+		}
+		if record.RefCount <= 0 {
+			continue
+		}
+		record.RefCount--
+		encoded, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("marshal record: %w", err)
+		}
+		if err := bucket.Put([]byte(hash), encoded); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SaveVectorBlobFrom stores a vector blob read from r, verifying on the fly that
+// its SHA256 matches expectedHash. Small blobs are stored inline exactly like
+// SaveVectorBlob. Larger blobs are written vectorChunkBatch chunks per
+// transaction, and the blob record that makes them visible is written with
+// the last batch once the hash checks out, so at most one batch is held in
+// memory and a failed or mismatched stream never produces a readable blob.
+func (s *Store) SaveVectorBlobFrom(r io.Reader, expectedHash string, dimensions int) (string, error) {
+	first := make([]byte, vectorChunkSize)
+	n, err := io.ReadFull(r, first)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("read vector stream: %w", err)
+	}
+	if n < vectorChunkSize {
+		data := first[:n]
+		if h := HashVector(data); h != expectedHash {
+			return "", fmt.Errorf("vector hash mismatch: expected %s, got %s", expectedHash, h)
+		}
+		return s.SaveVectorBlob(data, dimensions)
+	}
+
+	// Already stored: just take another reference.
+	if err := s.IncrementVectorRefCount(expectedHash); err == nil {
+		return expectedHash, nil
+	} else if !errors.Is(err, ErrVectorNotFound) {
+		return "", err
+	}
+
+	hasher := sha256.New()
+	hasher.Write(first)
+	batch := [][]byte{first}
+	written := 0 // chunks already committed
+	for {
+		buf := make([]byte, vectorChunkSize)
+		n, err := io.ReadFull(r, buf)
+		if n == 0 && (errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)) {
+			break
+		}
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+			s.deleteVectorChunks(expectedHash, written)
+			return "", fmt.Errorf("read vector stream: %w", err)
+		}
+		hasher.Write(buf[:n])
+		batch = append(batch, buf[:n])
+
+		if len(batch) == vectorChunkBatch {
+			if err := s.putVectorChunks(expectedHash, written, batch, nil); err != nil {
+				s.deleteVectorChunks(expectedHash, written)
+				return "", err
+			}
+			written += len(batch)
+			batch = batch[:0]
+		}
+	}
+
+	if h := hex.EncodeToString(hasher.Sum(nil)); h != expectedHash {
+		s.deleteVectorChunks(expectedHash, written)
+		return "", fmt.Errorf("vector hash mismatch: expected %s, got %s", expectedHash, h)
+	}
+
+	record := &vectorBlobRecord{Dimensions: dimensions, RefCount: 1, Chunks: written + len(batch)}
+	if err := s.putVectorChunks(expectedHash, written, batch, record); err != nil {
+		s.deleteVectorChunks(expectedHash, written)
+		return "", fmt.Errorf("failed to save vector blob: %w", err)
+	}
+	return expectedHash, nil
+}
+
+// vectorChunkBatch is how many chunks of a large vector blob are written
+// per transaction.
+const vectorChunkBatch = 16
+
+// putVectorChunks writes consecutive raw chunks of a large vector blob,
+// starting at chunk seq, in one transaction. A non-nil record is written
+// with them, making the blob visible.
+func (s *Store) putVectorChunks(hash string, seq int, chunks [][]byte, record *vectorBlobRecord) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(bucketVectorChunks)
+		if err != nil {
+			return fmt.Errorf("create bucket: %w", err)
+		}
+		for i, data := range chunks {
+			if err := bucket.Put(vectorChunkKey(hash, seq+i), data); err != nil {
+				return err
+			}
+		}
+		if record == nil {
+			return nil
+		}
+		encoded, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("marshal record: %w", err)
+		}
+		blobs, err := tx.CreateBucketIfNotExists(bucketVectorBlobs)
+		if err != nil {
+			return fmt.Errorf("create bucket: %w", err)
+		}
+		return blobs.Put([]byte(hash), encoded)
+	})
+}
+
+// deleteVectorChunks removes the first count chunks of a blob. Best effort:
+// it only runs on failure paths, where leftover chunks are unreachable anyway.
+func (s *Store) deleteVectorChunks(hash string, count int) {
+	_ = s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketVectorChunks)
+		if bucket == nil {
+			return nil
+		}
+		for i := 0; i < count; i++ {
+			if err := bucket.Delete(vectorChunkKey(hash, i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// OpenVectorBlob returns a reader over a vector blob and its dimensions.
+// Chunked blobs are read one chunk at a time, each in its own read
+// transaction, so the whole blob is never held in memory.
+func (s *Store) OpenVectorBlob(hash string) (io.ReadCloser, int, error) {
+	var record vectorBlobRecord
+	read := func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketVectorBlobs)
+		if bucket == nil {
+			return ErrVectorNotFound
+		}
+		value := bucket.Get([]byte(hash))
+		if value == nil {
+			return ErrVectorNotFound
+		}
+		return json.Unmarshal(value, &record)
+	}
+	err := s.db.View(read)
+	if errors.Is(err, ErrVectorNotFound) && s.fetchVector != nil {
+		if err = s.fetchVectorBlob(hash); err == nil {
+			err = s.db.View(read)
+		}
+	}
+	if err != nil {
+		if errors.Is(err, ErrVectorNotFound) {
+			return nil, 0, ErrVectorNotFound
+		}
+		return nil, 0, fmt.Errorf("failed to open vector blob: %w", err)
+	}
+
+	if record.Chunks == 0 {
+		return io.NopCloser(bytes.NewReader(record.Data)), record.Dimensions, nil
+	}
+	return &vectorChunkReader{s: s, hash: hash, total: record.Chunks}, record.Dimensions, nil
+}
+
+// vectorChunkReader streams the chunks of a large vector blob in order.
+type vectorChunkReader struct {
+	s     *Store
+	hash  string
+	next  int
+	total int
+	buf   []byte
+}
+
+func (r *vectorChunkReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.next >= r.total {
+			return 0, io.EOF
+		}
+		err := r.s.db.View(func(tx *bolt.Tx) error {
+			bucket := tx.Bucket(bucketVectorChunks)
+			if bucket == nil {
+				return ErrVectorNotFound
+			}
+			chunk := bucket.Get(vectorChunkKey(r.hash, r.next))
+			if chunk == nil {
+				return fmt.Errorf("missing chunk %d of vector blob %s", r.next, r.hash)
+			}
+			// bbolt memory is only valid inside the transaction.
+			r.buf = append([]byte(nil), chunk...)
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+		r.next++
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func (r *vectorChunkReader) Close() error {
+	r.buf = nil
+	return nil
+}
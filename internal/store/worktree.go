@@ -0,0 +1,238 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	bolt "go.etcd.io/bbolt"
+)
+
+// bucketWorktrees holds one nested bucket per linked worktree. Each contains
+// the worktree's record under worktreeInfoKey and its own copy of the
+// worktree-local buckets.
+var bucketWorktrees = []byte("worktrees")
+
+var worktreeInfoKey = []byte("info")
+
+// worktreeLocalBuckets are the buckets each worktree keeps separately: its
+// HEAD and branch (kv), known state, staging area, scan metadata, and staged
+// count. Commits, operations, branches, stashes, and remotes are shared.
+var worktreeLocalBuckets = [][]byte{
+	bucketKV,
+	bucketKnownObjects,
+	bucketStagedChanges,
+	bucketScanMetadata,
+	bucketCounters,
+}
+
+// bucketContainer is the part of the bbolt API shared by *bolt.Tx and
+// *bolt.Bucket, so worktree-local buckets can live at the top level (main
+// worktree) or nested under the worktree's bucket (linked worktrees)
+type bucketContainer interface {
+	Bucket(name []byte) *bolt.Bucket
+	CreateBucket(name []byte) (*bolt.Bucket, error)
+	CreateBucketIfNotExists(name []byte) (*bolt.Bucket, error)
+	DeleteBucket(name []byte) error
+}
+
+// OpenWorktree opens the database at dbPath for the named linked worktree;
+// an empty name opens the main worktree, like New.
+func OpenWorktree(dbPath, name string) (*Store, error) {
+	s, err := New(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		return s, nil
+	}
+	wt, err := s.GetWorktree(name)
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+	if wt == nil {
+		s.Close()
+		return nil, fmt.Errorf("worktree '%s' is not registered in %s (was it removed?)", name, dbPath)
+	}
+	s.worktree = name
+	return s, nil
+}
+
+// Worktree returns the name of the linked worktree the store operates on,
+// or "" for the main worktree.
+func (s *Store) Worktree() string {
+	return s.worktree
+}
+
+// WithWorktree returns a store sharing this store's database but reading and
+// writing the worktree-local state of name ("" for the main worktree). Only
+// the original store should be closed.
+func (s *Store) WithWorktree(name string) *Store {
+	return &Store{db: s.db, worktree: name}
+}
+
+// local returns the container of this store's worktree-local buckets in tx.
+func (s *Store) local(tx *bolt.Tx) bucketContainer {
+	if s.worktree == "" {
+		return tx
+	}
+	if root := tx.Bucket(bucketWorktrees); root != nil {
+		if b := root.Bucket([]byte(s.worktree)); b != nil {
+			return b
+		}
+	}
+	return missingWorktree{}
+}
+
+// localKV returns the worktree-local key-value bucket.
+func (s *Store) localKV(tx *bolt.Tx) *bolt.Bucket {
+	return s.local(tx).Bucket(bucketKV)
+}
+
+// missingWorktree stands in for the buckets of a worktree removed while a
+// store still refers to it: reads find nothing and writes fail
+type missingWorktree struct{}
+
+func (missingWorktree) Bucket([]byte) *bolt.Bucket { return nil }
+
+func (missingWorktree) CreateBucket([]byte) (*bolt.Bucket, error) {
+	return nil, fmt.Errorf("worktree not found")
+}
+
+func (missingWorktree) CreateBucketIfNotExists([]byte) (*bolt.Bucket, error) {
+	return nil, fmt.Errorf("worktree not found")
+}
+
+func (missingWorktree) DeleteBucket([]byte) error {
+	return fmt.Errorf("worktree not found")
+}
+
+// getLocalValue gets a value from the worktree-local key-value bucket.
+func (s *Store) getLocalValue(key string) (string, error) {
+	var val string
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if b := s.localKV(tx); b != nil {
+			val = string(b.Get([]byte(key)))
+		}
+		return nil
+	})
+	return val, err
+}
+
+// setLocalValue sets a value in the worktree-local key-value bucket.
+func (s *Store) setLocalValue(key, value string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := s.localKV(tx)
+		if b == nil {
+			return fmt.Errorf("kv bucket not found")
+		}
+		return b.Put([]byte(key), []byte(value))
+	})
+}
+
+// deleteLocalValue removes a key from the worktree-local key-value bucket.
+func (s *Store) deleteLocalValue(key string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := s.localKV(tx)
+		if b == nil {
+			return fmt.Errorf("kv bucket not found")
+		}
+		return b.Delete([]byte(key))
+	})
+}
+
+// CreateWorktree registers a linked worktree with empty local state.
+func (s *Store) CreateWorktree(wt *models.Worktree) error {
+	data, err := json.Marshal(wt)
+	if err != nil {
+		return fmt.Errorf("marshal worktree: %w", err)
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		root, err := tx.CreateBucketIfNotExists(bucketWorktrees)
+		if err != nil {
+			return fmt.Errorf("create worktrees bucket: %w", err)
+		}
+		if root.Bucket([]byte(wt.Name)) != nil {
+			return fmt.Errorf("worktree '%s' already exists", wt.Name)
+		}
+		b, err := root.CreateBucket([]byte(wt.Name))
+		if err != nil {
+			return fmt.Errorf("create worktree bucket: %w", err)
+		}
+		for _, name := range worktreeLocalBuckets {
+			if _, err := b.CreateBucket(name); err != nil {
+				return fmt.Errorf("create bucket %s: %w", name, err)
+			}
+		}
+		return b.Put(worktreeInfoKey, data)
+	})
+}
+
+// GetWorktree returns a linked worktree with its HEAD and branch, or nil if
+// it does not exist.
+func (s *Store) GetWorktree(name string) (*models.Worktree, error) {
+	var wt *models.Worktree
+	err := s.db.View(func(tx *bolt.Tx) error {
+		root := tx.Bucket(bucketWorktrees)
+		if root == nil {
+			return nil
+		}
+		b := root.Bucket([]byte(name))
+		if b == nil {
+			return nil
+		}
+		var err error
+		wt, err = decodeWorktree(b)
+		return err
+	})
+	return wt, err
+}
+
+// ListWorktrees returns all linked worktrees, sorted by name.
+func (s *Store) ListWorktrees() ([]*models.Worktree, error) {
+	var worktrees []*models.Worktree
+	err := s.db.View(func(tx *bolt.Tx) error {
+		root := tx.Bucket(bucketWorktrees)
+		if root == nil {
+			return nil
+		}
+		return root.ForEachBucket(func(k []byte) error {
+			wt, err := decodeWorktree(root.Bucket(k))
+			if err != nil {
+				return err
+			}
+			worktrees = append(worktrees, wt)
+			return nil
+		})
+	})
+	sort.Slice(worktrees, func(i, j int) bool { return worktrees[i].Name < worktrees[j].Name })
+	return worktrees, err
+}
+
+// DeleteWorktree removes a linked worktree and all of its local state.
+func (s *Store) DeleteWorktree(name string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		root := tx.Bucket(bucketWorktrees)
+		if root == nil || root.Bucket([]byte(name)) == nil {
+			return fmt.Errorf("worktree '%s' not found", name)
+		}
+		if err := dropRefLogs(tx, "worktrees/"+name+"/HEAD\x00"); err != nil {
+			return err
+		}
+		return root.DeleteBucket([]byte(name))
+	})
+}
+
+func decodeWorktree(b *bolt.Bucket) (*models.Worktree, error) {
+	var wt models.Worktree
+	if err := json.Unmarshal(b.Get(worktreeInfoKey), &wt); err != nil {
+		return nil, fmt.Errorf("unmarshal worktree: %w", err)
+	}
+	if kv := b.Bucket(bucketKV); kv != nil {
+		wt.HEAD = string(kv.Get([]byte("HEAD")))
+		wt.Branch = string(kv.Get([]byte(headBranchKey)))
+	}
+	return &wt, nil
+}
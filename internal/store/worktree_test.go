@@ -0,0 +1,79 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorktree_LocalStateIsIsolated(t *testing.T) {
+	st := newTestStore(t)
+	require.NoError(t, st.SetHEAD("main-head"))
+	require.NoError(t, st.SetCurrentBranch("main"))
+	require.NoError(t, st.SaveKnownObject("Article", "obj-1", "hash", []byte(`{"class":"Article","id":"obj-1"}`)))
+	require.NoError(t, st.AddStagedChange(&StagedChange{ClassName: "Article", ObjectID: "obj-2", ChangeType: "insert", StagedAt: time.Now()}))
+
+	require.NoError(t, st.CreateWorktree(&models.Worktree{Name: "review", Path: "/tmp/review", WeaviateURL: "http://localhost:8081"}))
+	wt := st.WithWorktree("review")
+
+	// A new worktree starts with empty local state
+	head, err := wt.GetHEAD()
+	require.NoError(t, err)
+	assert.Empty(t, head)
+	count, err := wt.GetStagedChangesCount()
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+	known, err := wt.GetKnownObjectInfo("Article", "obj-1")
+	require.NoError(t, err)
+	assert.Nil(t, known)
+
+	require.NoError(t, wt.SetHEAD("review-head"))
+	require.NoError(t, wt.SetCurrentBranch("proposal"))
+	require.NoError(t, wt.RecordOperation(&models.Operation{Type: models.OperationInsert, ClassName: "Article", ObjectID: "obj-3"}))
+
+	// The main worktree is unaffected
+	head, err = st.GetHEAD()
+	require.NoError(t, err)
+	assert.Equal(t, "main-head", head)
+	branch, err := st.GetCurrentBranch()
+	require.NoError(t, err)
+	assert.Equal(t, "main", branch)
+	ops, err := st.GetUncommittedOperations()
+	require.NoError(t, err)
+	assert.Empty(t, ops)
+	ops, err = wt.GetUncommittedOperations()
+	require.NoError(t, err)
+	assert.Len(t, ops, 1)
+
+	info, err := st.GetWorktree("review")
+	require.NoError(t, err)
+	require.NotNil(t, info)
+	assert.Equal(t, "review-head", info.HEAD)
+	assert.Equal(t, "proposal", info.Branch)
+
+	require.NoError(t, st.DeleteWorktree("review"))
+	worktrees, err := st.ListWorktrees()
+	require.NoError(t, err)
+	assert.Empty(t, worktrees)
+}
+
+func TestOpenWorktree_RequiresRegisteredWorktree(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	st, err := New(dbPath)
+	require.NoError(t, err)
+	require.NoError(t, st.Initialize())
+	require.NoError(t, st.CreateWorktree(&models.Worktree{Name: "review"}))
+	require.NoError(t, st.Close())
+
+	_, err = OpenWorktree(dbPath, "missing")
+	assert.ErrorContains(t, err, "not registered")
+
+	opened, err := OpenWorktree(dbPath, "review")
+	require.NoError(t, err)
+	defer opened.Close()
+	assert.Equal(t, "review", opened.Worktree())
+}
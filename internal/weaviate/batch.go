@@ -0,0 +1,115 @@
+package weaviate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/weaviate/weaviate-go-client/v5/weaviate/filters"
+	weaviatemodels "github.com/weaviate/weaviate/entities/models"
+)
+
+// BatchPutObjects creates or replaces objects through the batch endpoint in a
+// single request. The returned slice holds one error per object, nil for
+// those written; the error is set when the request as a whole failed.
+func (c *Client) BatchPutObjects(ctx context.Context, objs []*models.WeaviateObject) ([]error, error) {
+	if len(objs) == 0 {
+		return nil, nil
+	}
+	batch := make([]*weaviatemodels.Object, len(objs))
+	for i, obj := range objs {
+		c.hashes.Invalidate(obj.TenantClass(), obj.ID)
+		batch[i] = &weaviatemodels.Object{
+			Class:      obj.Class,
+			Tenant:     obj.Tenant,
+			ID:         strfmt.UUID(obj.ID),
+			Properties: obj.Properties,
+			Vector:     vectorToFloat32(obj.Vector),
+			Vectors:    namedVectorsForAPI(obj.Vectors),
+		}
+	}
+
+	resp, err := c.client.Batch().ObjectsBatcher().WithObjects(batch...).Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("batch write of %d objects: %w", len(objs), err)
+	}
+
+	// Results come back in request order
+	errs := make([]error, len(objs))
+	for i := range objs {
+		if i >= len(resp) {
+			errs[i] = errors.New("missing from batch response")
+			continue
+		}
+		errs[i] = batchResultError(resp[i].Result)
+	}
+	return errs, nil
+}
+
+// BatchDeleteObjects deletes objects of one class by ID through the batch
+// delete endpoint in a single request. The returned slice holds one error per
+// ID, nil for those deleted or already absent; the error is set when the
+// request as a whole failed.
+func (c *Client) BatchDeleteObjects(ctx context.Context, name string, objectIDs []string) ([]error, error) {
+	if len(objectIDs) == 0 {
+		return nil, nil
+	}
+	for _, id := range objectIDs {
+		c.hashes.Invalidate(name, id)
+	}
+	className, tenant := models.SplitTenantClass(name)
+	where := filters.Where().
+		WithPath([]string{"id"}).
+		WithOperator(filters.ContainsAny).
+		WithValueText(objectIDs...)
+
+	resp, err := c.client.Batch().ObjectsBatchDeleter().
+		WithClassName(className).
+		WithTenant(tenant).
+		WithWhere(where).
+		WithOutput("verbose").
+		Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("batch delete of %d objects from %s: %w", len(objectIDs), name, err)
+	}
+
+	failed := make(map[string]error)
+	if resp != nil && resp.Results != nil {
+		for _, item := range resp.Results.Objects {
+			if item.Status != nil && *item.Status == "FAILED" {
+				failed[item.ID.String()] = errorResponseError(item.Errors)
+			}
+		}
+	}
+	errs := make([]error, len(objectIDs))
+	for i, id := range objectIDs {
+		errs[i] = failed[id]
+	}
+	return errs, nil
+}
+
+// batchResultError returns the error of one object in a batch response, or
+// nil when it was written.
+func batchResultError(result *weaviatemodels.ObjectsGetResponseAO2Result) error {
+	if result == nil || result.Errors == nil || len(result.Errors.Error) == 0 {
+		return nil
+	}
+	return errorResponseError(result.Errors)
+}
+
+// errorResponseError joins the messages of a Weaviate error response.
+func errorResponseError(resp *weaviatemodels.ErrorResponse) error {
+	if resp == nil || len(resp.Error) == 0 {
+		return errors.New("failed")
+	}
+	messages := make([]string, 0, len(resp.Error))
+	for _, item := range resp.Error {
+		if item != nil {
+			messages = append(messages, item.Message)
+		}
+	}
+	return errors.New(strings.Join(messages, "; "))
+}
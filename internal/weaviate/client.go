@@ -1,6 +1,10 @@
 // Package weaviate provides a client wrapper for interacting with Weaviate.
 // It handles object fetching, creation, updates, and deletion with support
 // for multiple Weaviate server versions.
+//
+// Methods taking a class name also accept a tenant-qualified one
+// ("Class@tenant", see models.TenantClass) to address the objects of one
+// tenant of a multi-tenant class.
 package weaviate
 
 import (
@@ -14,9 +18,11 @@ import (
 	"regexp"
 	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/kilupskalvis/wvc/internal/models"
 	"github.com/weaviate/weaviate-go-client/v5/weaviate"
+	wvauth "github.com/weaviate/weaviate-go-client/v5/weaviate/auth"
 	"github.com/weaviate/weaviate-go-client/v5/weaviate/graphql"
 	weaviatemodels "github.com/weaviate/weaviate/entities/models"
 )
@@ -65,10 +71,31 @@ func (v *ServerVersion) SupportsFeature(feature string) bool {
 type Client struct {
 	client *weaviate.Client
 	url    string
+	hashes *HashCache // hashes of this instance's objects
+}
+
+// Auth holds the credentials and extra headers sent to a secured Weaviate.
+// At most one of APIKey, BearerToken, ClientSecret, and Username with
+// Password is set; the last two are OIDC flows, whose token endpoint is
+// discovered from Weaviate when the client is created.
+type Auth struct {
+	APIKey       string
+	BearerToken  string
+	ClientSecret string
+	Username     string
+	Password     string
+	Scopes       []string          // OIDC scopes
+	Headers      map[string]string // sent with every request
 }
 
 // NewClient creates a new Weaviate client
 func NewClient(url string) (*Client, error) {
+	return NewClientWithAuth(url, nil)
+}
+
+// NewClientWithAuth creates a Weaviate client authenticating with auth; nil
+// connects without credentials.
+func NewClientWithAuth(url string, auth *Auth) (*Client, error) {
 	cfg := weaviate.Config{
 		Host:   url,
 		Scheme: "http",
@@ -83,6 +110,24 @@ func NewClient(url string) (*Client, error) {
 		cfg.Scheme = "https"
 	}
 
+	if auth != nil {
+		cfg.Headers = make(map[string]string, len(auth.Headers)+1)
+		for k, v := range auth.Headers {
+			cfg.Headers[k] = v
+		}
+		switch {
+		case auth.APIKey != "":
+			cfg.AuthConfig = wvauth.ApiKey{Value: auth.APIKey}
+		case auth.BearerToken != "":
+			// A static token needs no OIDC discovery
+			cfg.Headers["Authorization"] = "Bearer " + auth.BearerToken
+		case auth.ClientSecret != "":
+			cfg.AuthConfig = wvauth.ClientCredentials{ClientSecret: auth.ClientSecret, Scopes: auth.Scopes}
+		case auth.Username != "":
+			cfg.AuthConfig = wvauth.ResourceOwnerPasswordFlow{Username: auth.Username, Password: auth.Password, Scopes: auth.Scopes}
+		}
+	}
+
 	client, err := weaviate.NewClient(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Weaviate client: %w", err)
@@ -91,9 +136,15 @@ func NewClient(url string) (*Client, error) {
 	return &Client{
 		client: client,
 		url:    url,
+		hashes: NewHashCache(DefaultHashCacheSize),
 	}, nil
 }
 
+// HashCache returns the cache of object hashes for this Weaviate instance.
+func (c *Client) HashCache() *HashCache {
+	return c.hashes
+}
+
 // Ping checks if Weaviate is reachable
 func (c *Client) Ping(ctx context.Context) error {
 	live, err := c.client.Misc().LiveChecker().Do(ctx)
@@ -155,6 +206,11 @@ func (c *Client) GetSchemaTyped(ctx context.Context) (*models.WeaviateSchema, er
 			_ = json.Unmarshal(data, &wc.ModuleConfig)
 		}
 
+		if class.MultiTenancyConfig != nil && class.MultiTenancyConfig.Enabled {
+			data, _ := json.Marshal(class.MultiTenancyConfig)
+			_ = json.Unmarshal(data, &wc.MultiTenancy)
+		}
+
 		// Convert properties
 		for _, prop := range class.Properties {
 			wp := &models.WeaviateProperty{
@@ -185,6 +241,11 @@ func (c *Client) CreateClass(ctx context.Context, class *models.WeaviateClass) e
 		Description: class.Description,
 		Vectorizer:  class.Vectorizer,
 	}
+	if class.MultiTenant() {
+		data, _ := json.Marshal(class.MultiTenancy)
+		classObj.MultiTenancyConfig = &weaviatemodels.MultiTenancyConfig{}
+		_ = json.Unmarshal(data, classObj.MultiTenancyConfig)
+	}
 
 	// Add properties
 	for _, prop := range class.Properties {
@@ -204,6 +265,7 @@ func (c *Client) CreateClass(ctx context.Context, class *models.WeaviateClass) e
 
 // DeleteClass deletes a class from Weaviate
 func (c *Client) DeleteClass(ctx context.Context, className string) error {
+	c.hashes.InvalidateClass(className)
 	return c.client.Schema().ClassDeleter().WithClassName(className).Do(ctx)
 }
 
@@ -224,6 +286,35 @@ func (c *Client) AddProperty(ctx context.Context, className string, property *mo
 		Do(ctx)
 }
 
+// GetTenants returns the names of the tenants of a multi-tenant class
+func (c *Client) GetTenants(ctx context.Context, className string) ([]string, error) {
+	tenants, err := c.client.Schema().TenantsGetter().WithClassName(className).Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenants of %s: %w", className, err)
+	}
+	names := make([]string, 0, len(tenants))
+	for _, t := range tenants {
+		names = append(names, t.Name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// AddTenants creates tenants of a multi-tenant class
+func (c *Client) AddTenants(ctx context.Context, className string, tenants []string) error {
+	if len(tenants) == 0 {
+		return nil
+	}
+	add := make([]weaviatemodels.Tenant, 0, len(tenants))
+	for _, name := range tenants {
+		add = append(add, weaviatemodels.Tenant{Name: name})
+	}
+	if err := c.client.Schema().TenantsCreator().WithClassName(className).WithTenants(add...).Do(ctx); err != nil {
+		return fmt.Errorf("failed to add tenants to %s: %w", className, err)
+	}
+	return nil
+}
+
 // GetClasses returns all class names in the schema
 func (c *Client) GetClasses(ctx context.Context) ([]string, error) {
 	schema, err := c.client.Schema().Getter().Do(ctx)
@@ -239,7 +330,8 @@ func (c *Client) GetClasses(ctx context.Context) ([]string, error) {
 }
 
 // GetClassCount returns the number of objects in a class using aggregate query
-func (c *Client) GetClassCount(ctx context.Context, className string) (int, error) {
+func (c *Client) GetClassCount(ctx context.Context, name string) (int, error) {
+	className, tenant := models.SplitTenantClass(name)
 	metaField := graphql.Field{
 		Name: "meta",
 		Fields: []graphql.Field{
@@ -249,10 +341,11 @@ func (c *Client) GetClassCount(ctx context.Context, className string) (int, erro
 
 	result, err := c.client.GraphQL().Aggregate().
 		WithClassName(className).
+		WithTenant(tenant).
 		WithFields(metaField).
 		Do(ctx)
 	if err != nil {
-		return 0, fmt.Errorf("failed to get count for %s: %w", className, err)
+		return 0, fmt.Errorf("failed to get count for %s: %w", name, err)
 	}
 
 	// Parse the aggregate result
@@ -284,10 +377,73 @@ func (c *Client) GetClassCount(ctx context.Context, className string) (int, erro
 	return int(count), nil
 }
 
+// GetClassWatermark returns the object count of a class and the newest
+// lastUpdateTimeUnix among its objects. When the class cannot be sorted by
+// update time, only the count is reported.
+func (c *Client) GetClassWatermark(ctx context.Context, name string) (*models.ClassWatermark, error) {
+	count, err := c.GetClassCount(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	wm := &models.ClassWatermark{Count: count}
+	if count == 0 {
+		return wm, nil
+	}
+
+	className, tenant := models.SplitTenantClass(name)
+	result, err := c.client.GraphQL().Get().
+		WithClassName(className).
+		WithTenant(tenant).
+		WithSort(graphql.Sort{Path: []string{"_lastUpdateTimeUnix"}, Order: graphql.Desc}).
+		WithLimit(1).
+		WithFields(graphql.Field{Name: "_additional", Fields: []graphql.Field{{Name: "lastUpdateTimeUnix"}}}).
+		Do(ctx)
+	if err != nil || len(result.Errors) > 0 {
+		return wm, nil
+	}
+	get, _ := result.Data["Get"].(map[string]interface{})
+	objs, _ := get[className].([]interface{})
+	if len(objs) == 0 {
+		return wm, nil
+	}
+	first, _ := objs[0].(map[string]interface{})
+	additional, _ := first["_additional"].(map[string]interface{})
+	switch v := additional["lastUpdateTimeUnix"].(type) {
+	case string:
+		wm.LastUpdateTimeUnix, _ = strconv.ParseInt(v, 10, 64)
+	case float64:
+		wm.LastUpdateTimeUnix = int64(v)
+	}
+	return wm, nil
+}
+
+// Query runs a GraphQL query and returns the data of its response. Errors
+// in the response fail the query.
+func (c *Client) Query(ctx context.Context, query string) (map[string]interface{}, error) {
+	result, err := c.client.GraphQL().Raw().WithQuery(query).Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("graphql query: %w", err)
+	}
+	if len(result.Errors) > 0 {
+		messages := make([]string, len(result.Errors))
+		for i, e := range result.Errors {
+			messages[i] = e.Message
+		}
+		return nil, fmt.Errorf("graphql query: %s", strings.Join(messages, "; "))
+	}
+	data := make(map[string]interface{}, len(result.Data))
+	for k, v := range result.Data {
+		data[k] = v
+	}
+	return data, nil
+}
+
 // CheckObjectExists checks if an object exists in Weaviate
-func (c *Client) CheckObjectExists(ctx context.Context, className, objectID string) (bool, error) {
+func (c *Client) CheckObjectExists(ctx context.Context, name, objectID string) (bool, error) {
+	className, tenant := models.SplitTenantClass(name)
 	objs, err := c.client.Data().ObjectsGetter().
 		WithClassName(className).
+		WithTenant(tenant).
 		WithID(objectID).
 		Do(ctx)
 	if err != nil {
@@ -297,92 +453,77 @@ func (c *Client) CheckObjectExists(ctx context.Context, className, objectID stri
 	return len(objs) > 0, nil
 }
 
+// ObjectPageSize is the number of objects fetched per request when paging
+// through a class, and so the size of the batches IterateObjects yields.
+const ObjectPageSize = 100
+
 // GetAllObjects fetches all objects from a class with pagination method based on useCursor flag
 func (c *Client) GetAllObjects(ctx context.Context, className string, useCursor bool) ([]*models.WeaviateObject, error) {
-	if useCursor {
-		return c.getAllObjectsCursor(ctx, className)
+	var allObjects []*models.WeaviateObject
+	err := c.IterateObjects(ctx, className, useCursor, func(batch []*models.WeaviateObject) error {
+		allObjects = append(allObjects, batch...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	return c.getAllObjectsOffset(ctx, className)
+	return allObjects, nil
 }
 
-// getAllObjectsCursor uses WithAfter cursor pagination (Weaviate 1.18+)
-func (c *Client) getAllObjectsCursor(ctx context.Context, className string) ([]*models.WeaviateObject, error) {
-	var allObjects []*models.WeaviateObject
-	limit := 100
+// IterateObjects pages through the objects of a class, calling fn with each
+// page as it arrives, so only one page is held in memory at a time. Cursor
+// pagination (Weaviate 1.18+) is used when useCursor is set, offset/limit
+// pagination otherwise. An error from fn stops the iteration and is returned.
+func (c *Client) IterateObjects(ctx context.Context, name string, useCursor bool, fn ObjectBatchFunc) error {
+	className, tenant := models.SplitTenantClass(name)
 	afterCursor := ""
+	offset := 0
 
 	for {
 		getter := c.client.Data().ObjectsGetter().
 			WithClassName(className).
+			WithTenant(tenant).
 			WithVector().
-			WithLimit(limit)
+			WithLimit(ObjectPageSize)
 
-		// Use cursor-based pagination with WithAfter
-		if afterCursor != "" {
-			getter = getter.WithAfter(afterCursor)
+		if useCursor {
+			// Use cursor-based pagination with WithAfter
+			if afterCursor != "" {
+				getter = getter.WithAfter(afterCursor)
+			}
+		} else {
+			getter = getter.WithOffset(offset)
 		}
 
 		objs, err := getter.Do(ctx)
 		if err != nil {
-			return nil, fmt.Errorf("failed to fetch objects from %s: %w", className, err)
+			return fmt.Errorf("failed to fetch objects from %s: %w", name, err)
 		}
 
 		if len(objs) == 0 {
-			break
+			return nil
 		}
 
+		batch := make([]*models.WeaviateObject, 0, len(objs))
 		for _, obj := range objs {
 			wvcObj := convertToWVCObject(obj)
 			if wvcObj != nil {
-				allObjects = append(allObjects, wvcObj)
+				batch = append(batch, wvcObj)
 			}
 		}
-
-		// Set cursor for next page (use the last object's ID)
-		if len(objs) < limit {
-			break
-		}
-		afterCursor = objs[len(objs)-1].ID.String()
-	}
-
-	return allObjects, nil
-}
-
-// getAllObjectsOffset uses offset/limit pagination (older Weaviate versions)
-func (c *Client) getAllObjectsOffset(ctx context.Context, className string) ([]*models.WeaviateObject, error) {
-	var allObjects []*models.WeaviateObject
-	limit := 100
-	offset := 0
-
-	for {
-		objs, err := c.client.Data().ObjectsGetter().
-			WithClassName(className).
-			WithVector().
-			WithLimit(limit).
-			WithOffset(offset).
-			Do(ctx)
-		if err != nil {
-			return nil, fmt.Errorf("failed to fetch objects from %s: %w", className, err)
-		}
-
-		if len(objs) == 0 {
-			break
-		}
-
-		for _, obj := range objs {
-			wvcObj := convertToWVCObject(obj)
-			if wvcObj != nil {
-				allObjects = append(allObjects, wvcObj)
+		if len(batch) > 0 {
+			if err := fn(batch); err != nil {
+				return err
 			}
 		}
 
-		if len(objs) < limit {
-			break
+		if len(objs) < ObjectPageSize {
+			return nil
 		}
-		offset += limit
+		// Set cursor for next page (use the last object's ID)
+		afterCursor = objs[len(objs)-1].ID.String()
+		offset += ObjectPageSize
 	}
-
-	return allObjects, nil
 }
 
 // GetAllObjectsAllClasses fetches all objects from all classes
@@ -401,8 +542,7 @@ func (c *Client) GetAllObjectsAllClasses(ctx context.Context, useCursor bool) (m
 		}
 
 		for _, obj := range objects {
-			key := models.ObjectKey(className, obj.ID)
-			allObjects[key] = obj
+			allObjects[obj.Key()] = obj
 		}
 	}
 
@@ -410,9 +550,11 @@ func (c *Client) GetAllObjectsAllClasses(ctx context.Context, useCursor bool) (m
 }
 
 // GetObject fetches a single object by class and ID
-func (c *Client) GetObject(ctx context.Context, className, objectID string) (*models.WeaviateObject, error) {
+func (c *Client) GetObject(ctx context.Context, name, objectID string) (*models.WeaviateObject, error) {
+	className, tenant := models.SplitTenantClass(name)
 	objs, err := c.client.Data().ObjectsGetter().
 		WithClassName(className).
+		WithTenant(tenant).
 		WithID(objectID).
 		WithVector().
 		Do(ctx)
@@ -429,23 +571,31 @@ func (c *Client) GetObject(ctx context.Context, className, objectID string) (*mo
 }
 
 // DeleteObject deletes an object by class and ID
-func (c *Client) DeleteObject(ctx context.Context, className, objectID string) error {
+func (c *Client) DeleteObject(ctx context.Context, name, objectID string) error {
+	c.hashes.Invalidate(name, objectID)
+	className, tenant := models.SplitTenantClass(name)
 	return c.client.Data().Deleter().
 		WithClassName(className).
+		WithTenant(tenant).
 		WithID(objectID).
 		Do(ctx)
 }
 
 // CreateObject creates a new object
 func (c *Client) CreateObject(ctx context.Context, obj *models.WeaviateObject) error {
+	c.hashes.Invalidate(obj.TenantClass(), obj.ID)
 	creator := c.client.Data().Creator().
 		WithClassName(obj.Class).
+		WithTenant(obj.Tenant).
 		WithID(obj.ID).
 		WithProperties(obj.Properties)
 
 	if vec := vectorToFloat32(obj.Vector); vec != nil {
 		creator = creator.WithVector(vec)
 	}
+	if vectors := namedVectorsForAPI(obj.Vectors); vectors != nil {
+		creator = creator.WithVectors(vectors)
+	}
 
 	_, err := creator.Do(ctx)
 	return err
@@ -453,14 +603,19 @@ func (c *Client) CreateObject(ctx context.Context, obj *models.WeaviateObject) e
 
 // UpdateObject updates an existing object
 func (c *Client) UpdateObject(ctx context.Context, obj *models.WeaviateObject) error {
+	c.hashes.Invalidate(obj.TenantClass(), obj.ID)
 	updater := c.client.Data().Updater().
 		WithClassName(obj.Class).
+		WithTenant(obj.Tenant).
 		WithID(obj.ID).
 		WithProperties(obj.Properties)
 
 	if vec := vectorToFloat32(obj.Vector); vec != nil {
 		updater = updater.WithVector(vec)
 	}
+	if vectors := namedVectorsForAPI(obj.Vectors); vectors != nil {
+		updater = updater.WithVectors(vectors)
+	}
 
 	return updater.Do(ctx)
 }
@@ -501,6 +656,27 @@ func vectorToFloat32(v interface{}) []float32 {
 	}
 }
 
+// namedVectorsForAPI converts named vectors to the client's representation:
+// []float32 for a single vector, [][]float32 for a multi-vector.
+func namedVectorsForAPI(named map[string]interface{}) weaviatemodels.Vectors {
+	if len(named) == 0 {
+		return nil
+	}
+	vectors := make(weaviatemodels.Vectors, len(named))
+	for name, v := range named {
+		if rows, ok := models.MultiVectorRows(v); ok {
+			multi := make([][]float32, 0, len(rows))
+			for _, row := range rows {
+				multi = append(multi, vectorToFloat32(row))
+			}
+			vectors[name] = multi
+		} else if vec := vectorToFloat32(v); vec != nil {
+			vectors[name] = vec
+		}
+	}
+	return vectors
+}
+
 // convertToWVCObject converts a Weaviate API object to our internal model
 func convertToWVCObject(obj interface{}) *models.WeaviateObject {
 	// Use JSON marshaling/unmarshaling for safe conversion
@@ -513,8 +689,10 @@ func convertToWVCObject(obj interface{}) *models.WeaviateObject {
 	var raw struct {
 		ID         string                 `json:"id"`
 		Class      string                 `json:"class"`
+		Tenant     string                 `json:"tenant"`
 		Properties map[string]interface{} `json:"properties"`
 		Vector     interface{}            `json:"vector"`
+		Vectors    map[string]interface{} `json:"vectors"`
 		Additional struct {
 			CreationTimeUnix   int64 `json:"creationTimeUnix"`
 			LastUpdateTimeUnix int64 `json:"lastUpdateTimeUnix"`
@@ -540,14 +718,35 @@ func convertToWVCObject(obj interface{}) *models.WeaviateObject {
 	return &models.WeaviateObject{
 		ID:                 raw.ID,
 		Class:              raw.Class,
+		Tenant:             raw.Tenant,
 		Properties:         raw.Properties,
 		Vector:             raw.Vector,
+		Vectors:            nonEmptyVectors(raw.Vectors),
 		CreationTimeUnix:   creationTime,
 		LastUpdateTimeUnix: lastUpdateTime,
 	}
 }
 
-// HashObject creates a hash of an object's properties (excluding vector)
+// nonEmptyVectors returns named vectors without empty entries, or nil when
+// none remain, so objects of classes without named vectors compare equal
+// however Weaviate reports them.
+func nonEmptyVectors(named map[string]interface{}) map[string]interface{} {
+	var vectors map[string]interface{}
+	for name, v := range named {
+		if data, _ := vectorToBytes(v); len(data) == 0 {
+			continue
+		}
+		if vectors == nil {
+			vectors = make(map[string]interface{}, len(named))
+		}
+		vectors[name] = v
+	}
+	return vectors
+}
+
+// HashObject creates a hash of an object's properties (excluding vector).
+// Named vectors are included by the hash of each, so a change to any of them
+// changes the object hash; objects without named vectors hash as before.
 func HashObject(obj *models.WeaviateObject) string {
 	// Sort property keys for deterministic hashing
 	keys := make([]string, 0, len(obj.Properties))
@@ -582,6 +781,10 @@ func HashObject(obj *models.WeaviateObject) string {
 	buf = append(buf, idJSON...)
 	buf = append(buf, `,"properties":`...)
 	buf = append(buf, sortedProps...)
+	if len(obj.Vectors) > 0 {
+		buf = append(buf, `,"vectors":`...)
+		buf = append(buf, namedVectorsDigest(obj.Vectors)...)
+	}
 	buf = append(buf, '}')
 
 	hash := sha256.Sum256(buf)
@@ -607,6 +810,31 @@ func HashObjectFull(obj *models.WeaviateObject) (objectHash string, vectorHash s
 	return objectHash, vectorHash
 }
 
+// namedVectorsDigest encodes named vectors as a JSON object of name to the
+// SHA256 of the vector's bytes, in name order.
+func namedVectorsDigest(named map[string]interface{}) []byte {
+	names := make([]string, 0, len(named))
+	for name := range named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buf := []byte{'{'}
+	for i, name := range names {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		nameJSON, _ := json.Marshal(name)
+		vectorBytes, _ := vectorToBytes(named[name])
+		hash := sha256.Sum256(vectorBytes)
+		buf = append(buf, nameJSON...)
+		buf = append(buf, ':', '"')
+		buf = append(buf, hex.EncodeToString(hash[:])...)
+		buf = append(buf, '"')
+	}
+	return append(buf, '}')
+}
+
 // vectorToBytes converts a vector to raw binary float32 bytes (little-endian).
 // Returns (bytes, error). On error, returns (nil, error).
 func vectorToBytes(v interface{}) ([]byte, error) {
@@ -614,6 +842,19 @@ func vectorToBytes(v interface{}) ([]byte, error) {
 		return nil, nil
 	}
 
+	// A multi-vector is its rows' bytes concatenated
+	if rows, ok := models.MultiVectorRows(v); ok {
+		var buf []byte
+		for _, row := range rows {
+			data, err := vectorToBytes(row)
+			if err != nil {
+				return nil, err
+			}
+			buf = append(buf, data...)
+		}
+		return buf, nil
+	}
+
 	var floats []float32
 
 	switch vec := v.(type) {
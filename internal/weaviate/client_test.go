@@ -0,0 +1,45 @@
+package weaviate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewClientWithAuth_SendsCredentialsAndHeaders(t *testing.T) {
+	var got http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/.well-known/live" {
+			got = r.Header.Clone()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"version":"1.25.0"}`))
+	}))
+	defer ts.Close()
+
+	for _, tc := range []struct {
+		name string
+		auth *Auth
+		want string
+	}{
+		{"none", nil, ""},
+		{"api key", &Auth{APIKey: "key-1", Headers: map[string]string{"X-OpenAI-Api-Key": "sk-1"}}, "Bearer key-1"},
+		{"bearer token", &Auth{BearerToken: "token-1", Headers: map[string]string{"X-OpenAI-Api-Key": "sk-1"}}, "Bearer token-1"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got = nil
+			client, err := NewClientWithAuth(ts.URL, tc.auth)
+			require.NoError(t, err)
+			require.NoError(t, client.Ping(context.Background()))
+			require.NotNil(t, got)
+			assert.Equal(t, tc.want, got.Get("Authorization"))
+			if tc.auth != nil {
+				assert.Equal(t, "sk-1", got.Get("X-OpenAI-Api-Key"))
+			}
+		})
+	}
+}
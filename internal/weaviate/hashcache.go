@@ -0,0 +1,143 @@
+package weaviate
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+)
+
+// DefaultHashCacheSize bounds a hash cache. One CLI invocation typically
+// hashes each object a handful of times (scan, diff, known-state rebuild), so
+// a cache only needs to hold one working set.
+const DefaultHashCacheSize = 50000
+
+// hashCacheKey identifies an object: its class, qualified with the tenant,
+// and its ID. A cache holds one entry per object.
+type hashCacheKey struct {
+	class string
+	id    string
+}
+
+// hashCacheEntry holds the hashes of one payload of an object. Weaviate bumps
+// lastUpdateTimeUnix on every write, so the update time together with which
+// vectors were fetched pins down the content within one instance.
+type hashCacheEntry struct {
+	key        hashCacheKey
+	updateTime int64
+	hasVector  bool
+	vectors    int // named vectors fetched
+	objectHash string
+	vectorHash string
+}
+
+// HashCache is a bounded LRU cache of HashObjectFull results. Update times
+// only identify a payload within one Weaviate instance, so each Client owns
+// its own cache and drops the entries of the objects it writes. A nil
+// *HashCache hashes without caching.
+type HashCache struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[hashCacheKey]*list.Element
+}
+
+// NewHashCache creates a cache holding at most capacity entries.
+func NewHashCache(capacity int) *HashCache {
+	return &HashCache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[hashCacheKey]*list.Element),
+	}
+}
+
+// HashObjectFull returns the cached hashes for obj, computing and caching them
+// on a miss. Objects without an update timestamp are never cached since their
+// payload cannot be identified without hashing it.
+func (c *HashCache) HashObjectFull(obj *models.WeaviateObject) (string, string) {
+	if c == nil || obj.LastUpdateTimeUnix == 0 {
+		return HashObjectFull(obj)
+	}
+
+	key := hashCacheKey{class: obj.TenantClass(), id: obj.ID}
+	matches := func(e *hashCacheEntry) bool {
+		return e.updateTime == obj.LastUpdateTimeUnix && e.hasVector == (obj.Vector != nil) && e.vectors == len(obj.Vectors)
+	}
+
+	c.mu.Lock()
+	if el, ok := c.items[key]; ok {
+		if e := el.Value.(*hashCacheEntry); matches(e) {
+			c.ll.MoveToFront(el)
+			c.mu.Unlock()
+			return e.objectHash, e.vectorHash
+		}
+	}
+	c.mu.Unlock()
+
+	objectHash, vectorHash := HashObjectFull(obj)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := &hashCacheEntry{
+		key:        key,
+		updateTime: obj.LastUpdateTimeUnix,
+		hasVector:  obj.Vector != nil,
+		vectors:    len(obj.Vectors),
+		objectHash: objectHash,
+		vectorHash: vectorHash,
+	}
+	if el, ok := c.items[key]; ok {
+		el.Value = entry
+		c.ll.MoveToFront(el)
+		return objectHash, vectorHash
+	}
+	c.items[key] = c.ll.PushFront(entry)
+	if c.ll.Len() > c.capacity {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*hashCacheEntry).key)
+	}
+	return objectHash, vectorHash
+}
+
+// Invalidate drops the entry of an object, named by its tenant-qualified
+// class and ID. An object written within the millisecond of its cached
+// update time would otherwise keep its old hash.
+func (c *HashCache) Invalidate(class, id string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := hashCacheKey{class: class, id: id}
+	if el, ok := c.items[key]; ok {
+		c.ll.Remove(el)
+		delete(c.items, key)
+	}
+}
+
+// InvalidateClass drops the entries of every object of a class, in any
+// tenant.
+func (c *HashCache) InvalidateClass(className string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, el := range c.items {
+		if class, _ := models.SplitTenantClass(key.class); class == className {
+			c.ll.Remove(el)
+			delete(c.items, key)
+		}
+	}
+}
+
+// Len returns the number of cached entries.
+func (c *HashCache) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
@@ -0,0 +1,198 @@
+package weaviate
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/kilupskalvis/wvc/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func newHashTestObject(i int, updateTime int64) *models.WeaviateObject {
+	vec := make([]float32, 768)
+	for j := range vec {
+		vec[j] = float32(i*j) / 1000
+	}
+	return &models.WeaviateObject{
+		ID:    fmt.Sprintf("00000000-0000-0000-0000-%012d", i),
+		Class: "Article",
+		Properties: map[string]interface{}{
+			"title": fmt.Sprintf("Article %d", i),
+			"body":  "Lorem ipsum dolor sit amet, consectetur adipiscing elit.",
+			"tags":  []interface{}{"a", "b", "c"},
+			"views": float64(i),
+		},
+		Vector:             vec,
+		LastUpdateTimeUnix: updateTime,
+	}
+}
+
+func TestHashCache_MatchesUncached(t *testing.T) {
+	c := NewHashCache(10)
+	obj := newHashTestObject(1, 100)
+
+	wantObj, wantVec := HashObjectFull(obj)
+	gotObj, gotVec := c.HashObjectFull(obj)
+	assert.Equal(t, wantObj, gotObj)
+	assert.Equal(t, wantVec, gotVec)
+
+	// Second call is served from the cache with identical results.
+	gotObj, gotVec = c.HashObjectFull(obj)
+	assert.Equal(t, wantObj, gotObj)
+	assert.Equal(t, wantVec, gotVec)
+	assert.Equal(t, 1, c.Len())
+}
+
+func TestHashCache_NewUpdateTimeMisses(t *testing.T) {
+	c := NewHashCache(10)
+	obj := newHashTestObject(1, 100)
+	first, _ := c.HashObjectFull(obj)
+
+	obj.Properties["title"] = "changed"
+	obj.LastUpdateTimeUnix = 101
+	second, _ := c.HashObjectFull(obj)
+
+	assert.NotEqual(t, first, second)
+	assert.Equal(t, 1, c.Len(), "the new payload replaces the object's entry")
+}
+
+func TestHashCache_InvalidateDropsSameTimestampEntry(t *testing.T) {
+	c := NewHashCache(10)
+	obj := newHashTestObject(1, 100)
+	first, _ := c.HashObjectFull(obj)
+
+	// A write within the same millisecond keeps the update time.
+	obj.Properties["title"] = "changed"
+	stale, _ := c.HashObjectFull(obj)
+	assert.Equal(t, first, stale)
+
+	c.Invalidate(obj.TenantClass(), obj.ID)
+	fresh, _ := c.HashObjectFull(obj)
+	assert.NotEqual(t, first, fresh)
+}
+
+func TestHashCache_InvalidateClassCoversTenants(t *testing.T) {
+	c := NewHashCache(10)
+	a := newHashTestObject(1, 100)
+	b := newHashTestObject(2, 100)
+	b.Tenant = "tenant-a"
+	other := newHashTestObject(3, 100)
+	other.Class = "Other"
+	c.HashObjectFull(a)
+	c.HashObjectFull(b)
+	c.HashObjectFull(other)
+
+	c.InvalidateClass("Article")
+	assert.Equal(t, 1, c.Len())
+}
+
+func TestHashCache_ScopedToClient(t *testing.T) {
+	first := NewMockClient()
+	first.Hashes = NewHashCache(10)
+	second := NewMockClient()
+	second.Hashes = NewHashCache(10)
+
+	// Two instances can hold different payloads under the same update time.
+	obj := newHashTestObject(1, 100)
+	other := newHashTestObject(1, 100)
+	other.Properties["title"] = "different"
+	firstHash, _ := first.HashCache().HashObjectFull(obj)
+	secondHash, _ := second.HashCache().HashObjectFull(other)
+	assert.NotEqual(t, firstHash, secondHash)
+
+	// Writing through a client drops its entry.
+	assert.NoError(t, first.CreateObject(context.Background(), other))
+	assert.Equal(t, 0, first.HashCache().Len())
+	assert.Equal(t, 1, second.HashCache().Len())
+}
+
+func TestHashCache_NilHashesUncached(t *testing.T) {
+	var c *HashCache
+	obj := newHashTestObject(1, 100)
+	wantObj, wantVec := HashObjectFull(obj)
+	gotObj, gotVec := c.HashObjectFull(obj)
+	assert.Equal(t, wantObj, gotObj)
+	assert.Equal(t, wantVec, gotVec)
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestHashCache_SkipsObjectsWithoutTimestamp(t *testing.T) {
+	c := NewHashCache(10)
+	obj := newHashTestObject(1, 0)
+	c.HashObjectFull(obj)
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestHashCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewHashCache(2)
+	a := newHashTestObject(1, 1)
+	b := newHashTestObject(2, 1)
+	d := newHashTestObject(3, 1)
+
+	c.HashObjectFull(a)
+	c.HashObjectFull(b)
+	c.HashObjectFull(a) // a is now most recent
+	c.HashObjectFull(d) // evicts b
+
+	assert.Equal(t, 2, c.Len())
+	_, hasA := c.items[hashCacheKey{class: a.Class, id: a.ID}]
+	_, hasB := c.items[hashCacheKey{class: b.Class, id: b.ID}]
+	assert.True(t, hasA)
+	assert.False(t, hasB)
+}
+
+func BenchmarkHashObjectFull_Uncached(b *testing.B) {
+	objs := make([]*models.WeaviateObject, 1000)
+	for i := range objs {
+		objs[i] = newHashTestObject(i, int64(i+1))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		HashObjectFull(objs[i%len(objs)])
+	}
+}
+
+func BenchmarkHashObjectFull_Cached(b *testing.B) {
+	objs := make([]*models.WeaviateObject, 1000)
+	for i := range objs {
+		objs[i] = newHashTestObject(i, int64(i+1))
+	}
+	c := NewHashCache(len(objs))
+	for _, o := range objs {
+		c.HashObjectFull(o)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.HashObjectFull(objs[i%len(objs)])
+	}
+}
+
+func TestHashObjectFull_NamedVectors(t *testing.T) {
+	obj := newHashTestObject(1, 0)
+	plainHash, vectorHash := HashObjectFull(obj)
+
+	obj.Vectors = map[string]interface{}{
+		"title":   []float32{0.5, 0.25},
+		"colbert": [][]float32{{1, 2}, {3, 4}},
+	}
+	namedHash, namedVectorHash := HashObjectFull(obj)
+	assert.NotEqual(t, plainHash, namedHash, "named vectors are part of the object hash")
+	assert.Equal(t, vectorHash, namedVectorHash, "the vector hash covers the default vector")
+
+	// The same vectors decoded from JSON hash the same
+	decoded := *obj
+	decoded.Vectors = map[string]interface{}{
+		"colbert": []interface{}{[]interface{}{1.0, 2.0}, []interface{}{3.0, 4.0}},
+		"title":   []interface{}{0.5, 0.25},
+	}
+	decodedHash, _ := HashObjectFull(&decoded)
+	assert.Equal(t, namedHash, decodedHash)
+
+	decoded.Vectors = map[string]interface{}{
+		"colbert": [][]float32{{1, 2}, {3, 5}},
+		"title":   []float32{0.5, 0.25},
+	}
+	changedHash, _ := HashObjectFull(&decoded)
+	assert.NotEqual(t, namedHash, changedHash)
+}
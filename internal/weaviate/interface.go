@@ -6,6 +6,10 @@ import (
 	"github.com/kilupskalvis/wvc/internal/models"
 )
 
+// ObjectBatchFunc receives one page of objects from IterateObjects. The
+// page is not reused, so it may be kept after the call returns.
+type ObjectBatchFunc func(batch []*models.WeaviateObject) error
+
 // ClientInterface defines the contract for Weaviate client operations.
 // This interface enables mocking for testing the core package.
 type ClientInterface interface {
@@ -16,16 +20,30 @@ type ClientInterface interface {
 	AddProperty(ctx context.Context, className string, property *models.WeaviateProperty) error
 	GetClasses(ctx context.Context) ([]string, error)
 
+	// Tenant operations, on multi-tenant classes
+	GetTenants(ctx context.Context, className string) ([]string, error)
+	AddTenants(ctx context.Context, className string, tenants []string) error
+
 	// Object operations
 	GetAllObjectsAllClasses(ctx context.Context, useCursor bool) (map[string]*models.WeaviateObject, error)
 	GetAllObjects(ctx context.Context, className string, useCursor bool) ([]*models.WeaviateObject, error)
+	IterateObjects(ctx context.Context, className string, useCursor bool, fn ObjectBatchFunc) error
 	GetObject(ctx context.Context, className, objectID string) (*models.WeaviateObject, error)
 	CreateObject(ctx context.Context, obj *models.WeaviateObject) error
 	UpdateObject(ctx context.Context, obj *models.WeaviateObject) error
 	DeleteObject(ctx context.Context, className, objectID string) error
+	BatchPutObjects(ctx context.Context, objs []*models.WeaviateObject) ([]error, error)
+	BatchDeleteObjects(ctx context.Context, className string, objectIDs []string) ([]error, error)
 
 	// Query operations
 	GetClassCount(ctx context.Context, className string) (int, error)
+	GetClassWatermark(ctx context.Context, className string) (*models.ClassWatermark, error)
+	// Query runs a GraphQL query and returns the data of its response
+	Query(ctx context.Context, query string) (map[string]interface{}, error)
+
+	// HashCache returns the cache of object hashes for this instance, which
+	// the client keeps current with its own writes
+	HashCache() *HashCache
 }
 
 // Verify that *Client implements ClientInterface at compile time
@@ -3,20 +3,42 @@ package weaviate
 import (
 	"context"
 	"fmt"
+	"slices"
+	"sort"
+	"sync"
 
 	"github.com/kilupskalvis/wvc/internal/models"
 )
 
 // MockClient is a mock implementation of ClientInterface for testing.
 type MockClient struct {
-	// Objects stores objects by "ClassName/ObjectID" key
+	// Objects stores objects by "ClassName/ObjectID" key, the class name
+	// qualified with the tenant of objects of multi-tenant classes
 	Objects map[string]*models.WeaviateObject
+	// Tenants lists the tenants of multi-tenant classes by class name
+	Tenants map[string][]string
 	// Schema is the current mock schema
 	Schema *models.WeaviateSchema
 	// Err can be set to make methods return an error
 	Err error
+	// WriteErrs can be set to fail creates, updates, and deletes of specific
+	// objects, keyed by "ClassName/ObjectID"
+	WriteErrs map[string]error
 	// ClassCounts can be set to return specific counts (otherwise computed from Objects)
 	ClassCounts map[string]int
+	// PageSize is the batch size IterateObjects yields (ObjectPageSize when zero)
+	PageSize int
+	// BatchRequests counts calls to BatchPutObjects and BatchDeleteObjects
+	BatchRequests int
+	// Queries records the GraphQL queries run by Query
+	Queries []string
+	// QueryFunc can be set to answer queries (otherwise they return no data)
+	QueryFunc func(query string) (map[string]interface{}, error)
+	// Hashes can be set to cache object hashes (otherwise nothing is cached)
+	Hashes *HashCache
+
+	// mu serializes batch writes, which callers may issue concurrently
+	mu sync.Mutex
 }
 
 // NewMockClient creates a new MockClient for testing.
@@ -27,13 +49,13 @@ func NewMockClient() *MockClient {
 			Classes: []*models.WeaviateClass{},
 		},
 		ClassCounts: make(map[string]int),
+		Tenants:     make(map[string][]string),
 	}
 }
 
 // AddObject adds an object to the mock store.
 func (m *MockClient) AddObject(obj *models.WeaviateObject) {
-	key := models.ObjectKey(obj.Class, obj.ID)
-	m.Objects[key] = obj
+	m.Objects[obj.Key()] = obj
 }
 
 // AddClass adds a class to the mock schema.
@@ -70,6 +92,7 @@ func (m *MockClient) DeleteClass(ctx context.Context, className string) error {
 		}
 	}
 	// Also delete objects of this class
+	m.Hashes.InvalidateClass(className)
 	for key := range m.Objects {
 		if obj := m.Objects[key]; obj.Class == className {
 			delete(m.Objects, key)
@@ -92,6 +115,29 @@ func (m *MockClient) AddProperty(ctx context.Context, className string, property
 	return fmt.Errorf("class %s not found", className)
 }
 
+// GetTenants returns the tenants of a class.
+func (m *MockClient) GetTenants(ctx context.Context, className string) ([]string, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	tenants := slices.Clone(m.Tenants[className])
+	sort.Strings(tenants)
+	return tenants, nil
+}
+
+// AddTenants adds tenants to a class, ignoring those it already has.
+func (m *MockClient) AddTenants(ctx context.Context, className string, tenants []string) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	for _, t := range tenants {
+		if !slices.Contains(m.Tenants[className], t) {
+			m.Tenants[className] = append(m.Tenants[className], t)
+		}
+	}
+	return nil
+}
+
 // GetClasses returns all class names from the mock schema.
 func (m *MockClient) GetClasses(ctx context.Context) ([]string, error) {
 	if m.Err != nil {
@@ -123,13 +169,50 @@ func (m *MockClient) GetAllObjects(ctx context.Context, className string, useCur
 	}
 	var result []*models.WeaviateObject
 	for _, obj := range m.Objects {
-		if obj.Class == className {
+		if obj.TenantClass() == className {
 			result = append(result, obj)
 		}
 	}
 	return result, nil
 }
 
+// IterateObjects yields the objects of a class in pages, ordered by ID as
+// Weaviate's cursor pagination orders them. Objects deleted by fn before
+// their page is reached are skipped.
+func (m *MockClient) IterateObjects(ctx context.Context, className string, useCursor bool, fn ObjectBatchFunc) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	var ids []string
+	for _, obj := range m.Objects {
+		if obj.TenantClass() == className {
+			ids = append(ids, obj.ID)
+		}
+	}
+	sort.Strings(ids)
+
+	pageSize := m.PageSize
+	if pageSize <= 0 {
+		pageSize = ObjectPageSize
+	}
+	for start := 0; start < len(ids); start += pageSize {
+		end := min(start+pageSize, len(ids))
+		batch := make([]*models.WeaviateObject, 0, end-start)
+		for _, id := range ids[start:end] {
+			if obj, ok := m.Objects[models.ObjectKey(className, id)]; ok {
+				batch = append(batch, obj)
+			}
+		}
+		if len(batch) == 0 {
+			continue
+		}
+		if err := fn(batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // GetObject returns a specific object from the mock store.
 func (m *MockClient) GetObject(ctx context.Context, className, objectID string) (*models.WeaviateObject, error) {
 	if m.Err != nil {
@@ -148,7 +231,11 @@ func (m *MockClient) CreateObject(ctx context.Context, obj *models.WeaviateObjec
 	if m.Err != nil {
 		return m.Err
 	}
-	key := models.ObjectKey(obj.Class, obj.ID)
+	key := obj.Key()
+	if err := m.WriteErrs[key]; err != nil {
+		return err
+	}
+	m.Hashes.Invalidate(obj.TenantClass(), obj.ID)
 	m.Objects[key] = obj
 	return nil
 }
@@ -158,10 +245,14 @@ func (m *MockClient) UpdateObject(ctx context.Context, obj *models.WeaviateObjec
 	if m.Err != nil {
 		return m.Err
 	}
-	key := models.ObjectKey(obj.Class, obj.ID)
+	key := obj.Key()
+	if err := m.WriteErrs[key]; err != nil {
+		return err
+	}
 	if _, ok := m.Objects[key]; !ok {
-		return fmt.Errorf("object not found: %s/%s", obj.Class, obj.ID)
+		return fmt.Errorf("object not found: %s", key)
 	}
+	m.Hashes.Invalidate(obj.TenantClass(), obj.ID)
 	m.Objects[key] = obj
 	return nil
 }
@@ -172,10 +263,58 @@ func (m *MockClient) DeleteObject(ctx context.Context, className, objectID strin
 		return m.Err
 	}
 	key := models.ObjectKey(className, objectID)
+	if err := m.WriteErrs[key]; err != nil {
+		return err
+	}
+	m.Hashes.Invalidate(className, objectID)
 	delete(m.Objects, key)
 	return nil
 }
 
+// BatchPutObjects creates or replaces objects in the mock store. Objects
+// with an entry in WriteErrs fail individually.
+func (m *MockClient) BatchPutObjects(ctx context.Context, objs []*models.WeaviateObject) ([]error, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	m.BatchRequests++
+	errs := make([]error, len(objs))
+	for i, obj := range objs {
+		key := obj.Key()
+		if err := m.WriteErrs[key]; err != nil {
+			errs[i] = err
+			continue
+		}
+		m.Hashes.Invalidate(obj.TenantClass(), obj.ID)
+		m.Objects[key] = obj
+	}
+	return errs, nil
+}
+
+// BatchDeleteObjects removes objects of one class from the mock store.
+// Objects with an entry in WriteErrs fail individually.
+func (m *MockClient) BatchDeleteObjects(ctx context.Context, className string, objectIDs []string) ([]error, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	m.BatchRequests++
+	errs := make([]error, len(objectIDs))
+	for i, id := range objectIDs {
+		key := models.ObjectKey(className, id)
+		if err := m.WriteErrs[key]; err != nil {
+			errs[i] = err
+			continue
+		}
+		m.Hashes.Invalidate(className, id)
+		delete(m.Objects, key)
+	}
+	return errs, nil
+}
+
 // GetClassCount returns the count of objects in a class.
 func (m *MockClient) GetClassCount(ctx context.Context, className string) (int, error) {
 	if m.Err != nil {
@@ -188,12 +327,44 @@ func (m *MockClient) GetClassCount(ctx context.Context, className string) (int,
 	// Otherwise compute from objects
 	count := 0
 	for _, obj := range m.Objects {
-		if obj.Class == className {
+		if obj.TenantClass() == className {
 			count++
 		}
 	}
 	return count, nil
 }
 
+// GetClassWatermark returns the class's count and newest update time.
+func (m *MockClient) GetClassWatermark(ctx context.Context, className string) (*models.ClassWatermark, error) {
+	count, err := m.GetClassCount(ctx, className)
+	if err != nil {
+		return nil, err
+	}
+	wm := &models.ClassWatermark{Count: count}
+	for _, obj := range m.Objects {
+		if obj.TenantClass() == className && obj.LastUpdateTimeUnix > wm.LastUpdateTimeUnix {
+			wm.LastUpdateTimeUnix = obj.LastUpdateTimeUnix
+		}
+	}
+	return wm, nil
+}
+
+// Query records a GraphQL query and answers it with QueryFunc.
+func (m *MockClient) Query(ctx context.Context, query string) (map[string]interface{}, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	m.Queries = append(m.Queries, query)
+	if m.QueryFunc != nil {
+		return m.QueryFunc(query)
+	}
+	return map[string]interface{}{}, nil
+}
+
 // Verify MockClient implements ClientInterface
+// HashCache returns Hashes.
+func (m *MockClient) HashCache() *HashCache {
+	return m.Hashes
+}
+
 var _ ClientInterface = (*MockClient)(nil)